@@ -5,29 +5,41 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"maps"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/hyperjump/sagasu/internal/cli"
 	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/daemon"
 	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/events"
 	"github.com/hyperjump/sagasu/internal/extract"
 	"github.com/hyperjump/sagasu/internal/fileid"
 	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/instance"
+	"github.com/hyperjump/sagasu/internal/jobs"
 	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/llm"
 	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/schedule"
 	"github.com/hyperjump/sagasu/internal/search"
 	"github.com/hyperjump/sagasu/internal/server"
 	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/tray"
 	"github.com/hyperjump/sagasu/internal/vector"
 	"github.com/hyperjump/sagasu/internal/watcher"
 	"github.com/hyperjump/sagasu/pkg/utils"
@@ -67,6 +79,9 @@ func main() {
 		printUsage()
 		os.Exit(1)
 	}
+	if key := os.Getenv(apiKeyEnv); key != "" {
+		http.DefaultClient.Transport = &apiKeyTransport{key: key, base: http.DefaultTransport}
+	}
 	command := os.Args[1]
 	switch command {
 	case "server":
@@ -81,6 +96,41 @@ func main() {
 		runWatch()
 	case "status":
 		runStatus()
+	case "recent":
+		runRecent()
+	case "dupes":
+		runDupes()
+	case "graph":
+		runGraph()
+	case "count":
+		runCount()
+	case "diff-results":
+		runDiffResults()
+	case "audit":
+		runAudit()
+	case "analytics":
+		runAnalytics()
+	case "quality":
+		runQuality()
+	case "fsck":
+		runFsck()
+	case "profile":
+		runProfile()
+	case "exists":
+		runExists()
+	case "scan":
+		runScan()
+	case "snapshot":
+		runSnapshot()
+	case "reindex":
+		runReindex()
+	case "tray":
+		runTray()
+	case "daemon":
+		runDaemon()
+	case "extract-worker":
+		// Started by the server for each file when extract.sandbox is set; not for users
+		runExtractWorker()
 	case "version", "--version", "-v":
 		fmt.Printf("sagasu version %s\n", version)
 	case "help", "--help", "-h":
@@ -96,6 +146,7 @@ func runServer() {
 	fs := flag.NewFlagSet("server", flag.ExitOnError)
 	configPath := fs.String("config", defaultConfigPath, "config file path")
 	debug := fs.Bool("debug", false, "enable debug logging (directory changes, file indexing, etc.)")
+	pidFile := fs.String("pidfile", "", "write the server's process ID to this file while it runs")
 	_ = fs.Parse(os.Args[2:])
 
 	cfg, resolvedConfigPath, err := loadConfig(*configPath)
@@ -121,25 +172,88 @@ func runServer() {
 		logger.Fatal("Failed to initialize components", zap.Error(err))
 	}
 	defer components.Close()
+	if *pidFile != "" {
+		if err := instance.WritePIDFile(*pidFile); err != nil {
+			logger.Fatal("Failed to write pidfile", zap.Error(err))
+		}
+		defer os.Remove(*pidFile)
+	}
 
 	idx := components.Indexer
-	exts := cfg.Watch.Extensions
-	watchOpts := []watcher.WatcherOption{}
+	queue := newJobQueue(&cfg.Jobs, logger)
+	defer queue.Stop()
+	// Set below; the jobs index with the extensions it watches when they run.
+	var watchSvc *watcher.Watcher
+	indexFile := func(ctx context.Context, path string) error { return indexWatchedFile(ctx, idx, watchSvc, path) }
+	watchOpts := []watcher.WatcherOption{
+		// Changed files wait in the watcher while the embedding stage is full.
+		watcher.WithBackpressure(idx.Saturated),
+		// Files marked open are indexed ahead of the queued jobs, still after a running job
+		// for the same path.
+		watcher.WithPriorityIndex(func(path string) {
+			if _, err := queue.SubmitKeyedFirst(context.Background(), "index_file", path, path, func(ctx context.Context) error {
+				return indexFile(ctx, path)
+			}); err != nil {
+				logger.Warn("priority index file not queued", zap.String("path", path), zap.Error(err))
+			}
+		}),
+	}
+	// Directories holding the marker are skipped, and what was indexed from them removed.
+	watchOpts = append(watchOpts, watcher.WithNoIndexMarker(cfg.Watch.NoIndexMarker, func(dir string) {
+		if _, err := queue.Submit(context.Background(), "delete_directory", dir, func(ctx context.Context) error {
+			_, err := idx.DeleteUnder(ctx, dir)
+			return err
+		}); err != nil {
+			logger.Warn("watch delete opted-out directory not queued", zap.String("path", dir), zap.Error(err))
+		}
+	}))
+	watchOpts = append(watchOpts, watcher.WithEvents(components.Events))
+	if len(cfg.Watch.IndexWindows) > 0 {
+		// Changes and syncs outside the windows wait for the next one (or "sagasu watch flush").
+		windows, err := schedule.Parse(cfg.Watch.IndexWindows)
+		if err != nil {
+			logger.Fatal("Invalid watch.index_windows", zap.Error(err))
+		}
+		watchOpts = append(watchOpts, watcher.WithIndexWindows(windows))
+	}
+	if len(cfg.Watch.Rules) > 0 {
+		// Under a rule's directory, its extensions, globs, size limit and depth apply.
+		rules, err := cfg.Watch.PathRules()
+		if err != nil {
+			logger.Fatal("Invalid watch.rules", zap.Error(err))
+		}
+		watchOpts = append(watchOpts, watcher.WithRules(rules))
+	}
+	// Build output and caches listed in .gitignore and .sagasuignore files are skipped.
+	ignore, err := cfg.Watch.Ignore()
+	if err != nil {
+		logger.Fatal("Invalid watch.global_ignore", zap.Error(err))
+	}
+	if ignore != nil {
+		watchOpts = append(watchOpts, watcher.WithIgnore(ignore))
+	}
+	watchOpts = append(watchOpts, watcher.WithWalkOptions(cfg.Watch.WalkOptions()))
 	if debugMode {
 		watchOpts = append(watchOpts, watcher.WithLogger(logger))
 	}
-	watchSvc := watcher.NewWatcher(
+	watchSvc = watcher.NewWatcher(
 		cfg.Watch.Directories,
-		exts,
+		cfg.Watch.Extensions,
 		cfg.Watch.RecursiveOrDefault(),
 		func(path string) {
-			if err := idx.IndexFile(context.Background(), path, exts); err != nil {
-				logger.Warn("watch index file failed", zap.String("path", path), zap.Error(err))
+			// Keyed by path: the jobs of one file run in order, and newer ones replace those
+			// not started.
+			if _, err := queue.SubmitKeyed(context.Background(), "index_file", path, path, func(ctx context.Context) error {
+				return indexFile(ctx, path)
+			}); err != nil {
+				logger.Warn("watch index file not queued", zap.String("path", path), zap.Error(err))
 			}
 		},
 		func(path string) {
-			if err := idx.DeleteDocument(context.Background(), fileid.FileDocID(path)); err != nil {
-				logger.Warn("watch delete by path failed", zap.String("path", path), zap.Error(err))
+			if _, err := queue.SubmitKeyed(context.Background(), "delete_file", path, path, func(ctx context.Context) error {
+				return idx.DeleteDocument(ctx, fileid.FileDocID(path))
+			}); err != nil {
+				logger.Warn("watch delete by path not queued", zap.String("path", path), zap.Error(err))
 			}
 		},
 		watchOpts...,
@@ -150,6 +264,16 @@ func runServer() {
 		logger.Fatal("Failed to start watcher", zap.Error(err))
 	}
 	watchSvc.SyncExistingFiles()
+	// Syncing only adds files; documents of files deleted while the server was down go here.
+	if _, err := queue.Submit(context.Background(), "remove_missing", "", func(ctx context.Context) error {
+		_, err := idx.RemoveMissing(ctx, watchSvc.Directories())
+		return err
+	}); err != nil {
+		logger.Warn("removal of deleted files not queued", zap.Error(err))
+	}
+	if len(cfg.Retention.Policies) > 0 {
+		go runRetention(watchCtx, queue, idx, time.Duration(cfg.Retention.IntervalMinutes)*time.Minute, logger)
+	}
 
 	srv := server.NewServer(
 		components.Engine,
@@ -160,22 +284,48 @@ func runServer() {
 		watchSvc,
 		resolvedConfigPath,
 		cfg,
-	)
+	).WithJobs(queue).WithEvents(components.Events)
+	if cfg.Audit.Enabled {
+		srv.WithAuditLog()
+	}
+	if cfg.Analytics.Enabled {
+		srv.WithAnalytics()
+	}
+	if cfg.Server.Profiling {
+		srv.WithProfiling()
+	}
+	if len(cfg.Server.Auth.APIKeys) == 0 && !isLoopbackHost(cfg.Server.Host) {
+		logger.Warn("server is reachable from other machines without API keys; anyone on the network can search and change the index (set server.auth.api_keys)",
+			zap.String("host", cfg.Server.Host))
+	}
+	if cfg.LLM.Provider != "" {
+		client, err := newLLMClient(&cfg.LLM)
+		if err != nil {
+			logger.Fatal("Failed to create LLM client", zap.Error(err))
+		}
+		defer client.Close()
+		srv.WithLLM(client)
+	}
+	if components.Shadow != nil {
+		srv.WithShadowRebuild(components.Shadow)
+	}
 	go func() {
-		if err := srv.Start(); err != nil {
+		if err := srv.Start(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logger.Fatal("Server failed", zap.Error(err))
 		}
 	}()
+	if err := components.Instance.Publish(advertisedURL(&cfg.Server)); err != nil {
+		logger.Warn("server address not published; the CLI will assume the default", zap.Error(err))
+	}
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 	<-sigChan
 
 	logger.Info("Shutting down...")
-	if cfg.Storage.FAISSIndexPath != "" && components.VectorIndex != nil {
-		if err := components.VectorIndex.Save(cfg.Storage.FAISSIndexPath); err != nil && logger != nil {
-			logger.Warn("vector index save failed", zap.String("path", cfg.Storage.FAISSIndexPath), zap.Error(err))
-		}
+	queue.Stop()
+	if err := components.SaveVectorIndexes(cfg.Storage.FAISSIndexPath); err != nil && logger != nil {
+		logger.Warn("vector index save failed", zap.Error(err))
 	}
 	watchCancel()
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -183,6 +333,48 @@ func runServer() {
 	_ = srv.Stop(ctx)
 }
 
+// indexWatchedFile indexes the file at path if it has one of the extensions w watches
+// now, which PATCH /api/v1/config may have changed since the job was queued.
+func indexWatchedFile(ctx context.Context, idx *indexer.Indexer, w *watcher.Watcher, path string) error {
+	return idx.IndexFile(ctx, path, w.Extensions())
+}
+
+// runRetention queues a job that removes expired documents now and then every interval,
+// until ctx is done.
+func runRetention(ctx context.Context, queue *jobs.Queue, idx *indexer.Indexer, interval time.Duration, logger *zap.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := queue.Submit(ctx, "retention", "", func(ctx context.Context) error {
+			_, err := idx.ApplyRetention(ctx, time.Now())
+			return err
+		}); err != nil && ctx.Err() == nil {
+			logger.Warn("retention job not queued", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// newJobQueue creates the background indexing job queue from config.
+func newJobQueue(cfg *config.JobsConfig, logger *zap.Logger) *jobs.Queue {
+	maxRetries := cfg.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	return jobs.NewQueue(
+		jobs.WithWorkers(cfg.Workers),
+		jobs.WithQueueSize(cfg.QueueSize),
+		jobs.WithMaxRetries(maxRetries),
+		jobs.WithBackoff(time.Duration(cfg.RetryBackoffMs)*time.Millisecond),
+		jobs.WithHistory(cfg.History),
+		jobs.WithLogger(logger),
+	)
+}
+
 // printSearchUsage prints search subcommand usage and search efficiency hints.
 func printSearchUsage(fs *flag.FlagSet) {
 	fmt.Fprintf(fs.Output(), "Usage: sagasu search [flags] <query>\n\n")
@@ -194,12 +386,37 @@ Results are split into two lists: keyword matches and semantic-only matches.
   • Use --semantic=false for keyword-only search.
   • Use --fuzzy to enable typo tolerance (finds results despite spelling mistakes).
   • --min-keyword-score and --min-semantic-score filter low-relevance hits; --limit controls how many per list.
+  • Boolean queries: AND, OR, NOT (or -term), parentheses, and "quoted phrases".
+    Quote the whole query when it contains -term so it is not parsed as a flag.
+  • Field scopes: title:term, path:text, ext:pdf (prefix with - to exclude).
+  • --fields title (or path, or title,path) searches only file names or paths, word prefixes included,
+    skipping content and semantic search: fast when you roughly know what a file is called.
+  • --wildcard matches the query as a pattern (* any letters or digits, ? exactly one) and --regex
+    as a regular expression against whole indexed words; --literal finds the exact text anywhere in
+    titles and contents (serial numbers, code), however it is split into words. All are keyword only.
+  • --ext, --path, --after, and --before narrow results by file type, location, and modification date.
+  • --sort modified_time (or title, size) orders results by that field instead of relevance; --order asc|desc.
+  • --export-links DIR symlinks the matched files into DIR (named by rank); --export-list FILE writes their paths.
+  • --fusion rrf (or weighted, max) returns one list merging keyword and semantic matches.
+  • --diversity 0.3 moves results much like a better one down, so near-duplicates do not fill the page.
+  • --chunks N also shows each result's N best matching chunks with their character offsets.
+  • --explain-query prints how the query is parsed instead of searching, to see why it matched or didn't.
 
 Examples:
   sagasu search machine learning
   sagasu search "machine learning"                 # same as above
   sagasu search --keyword=false neural networks     # semantic-only
   sagasu search --fuzzy propodal                    # typo-tolerant search
+  sagasu search "(python OR golang) AND web -java"  # boolean query
+  sagasu search title:budget ext:pdf report         # field-scoped query
+  sagasu search --fields title budg q3              # file names only, e.g. budget-q3.xlsx
+  sagasu search --wildcard "INV-2024-???"           # invoice numbers such as INV-2024-017
+  sagasu search --regex "20[0-9]{2}q[1-4]"          # words such as 2024q3
+  sagasu search --literal "xs[i:j] = nil"           # an exact code snippet
+  sagasu search --ext docx --path ~/projects --after 2026-03-01 plan
+  sagasu search --sort modified_time report           # newest matches first
+  sagasu search --export-links /tmp/results invoice   # then zip, copy, or open /tmp/results
+  sagasu search --explain-query --fuzzy "budgt -draft" ext:pdf
   sagasu search --min-keyword-score 0.1 --min-semantic-score 0.2 --limit 20 your query
 `)
 }
@@ -268,8 +485,27 @@ func runSearch() {
 	semEnabled := fs.Bool("semantic", true, "enable semantic search")
 	fuzzyEnabled := fs.Bool("fuzzy", false, "enable fuzzy matching for typo tolerance")
 	outputFormat := fs.String("output", "text", "output format: text (human-readable), compact (one result per line), or json (parseable)")
+	extensions := fs.String("ext", "", "only documents with these file extensions (comma-separated, e.g. pdf,docx)")
+	pathPrefix := fs.String("path", "", "only documents under this path")
+	modifiedAfter := fs.String("after", "", "only documents modified on or after this date (YYYY-MM-DD or RFC 3339)")
+	modifiedBefore := fs.String("before", "", "only documents modified before this date (YYYY-MM-DD or RFC 3339)")
+	sortBy := fs.String("sort", "", "order results by relevance (default), modified_time, title, or size")
+	sortOrder := fs.String("order", "", "sort direction: asc or desc (default desc for modified_time and size, asc for title)")
+	exportLinks := fs.String("export-links", "", "create symlinks to the matched files in this directory")
+	exportList := fs.String("export-list", "", "write the matched file paths to this file, one per line")
+	fields := fs.String("fields", "", "match only these fields instead of title and content: title, path, or title,path (keyword only)")
+	wildcard := fs.Bool("wildcard", false, "match the query as a wildcard pattern: * is any letters or digits, ? exactly one (keyword only)")
+	regex := fs.Bool("regex", false, "match the query as a regular expression against whole indexed words (keyword only)")
+	literal := fs.Bool("literal", false, "match the query as an exact substring of titles and contents, ignoring case (keyword only)")
+	snapshot := fs.String("snapshot", "", "only documents of this snapshot, unchanged since it was taken")
+	asOf := fs.String("as-of", "", "search documents as they were at this time (YYYY-MM-DD or RFC 3339; needs storage.sqlite.version_history)")
+	chunks := fs.Int("chunks", 0, "also show up to this many matching chunks per result, with their character offsets")
+	fusion := fs.String("fusion", "", "return one list ranked by fusing keyword and semantic scores: rrf, weighted, or max")
+	diversity := fs.Float64("diversity", -1, "weight (0 to below 1) of moving results similar to better ones down; 0 = relevance order (default: search.diversity_lambda)")
+	explainQuery := fs.Bool("explain-query", false, "print how the query is parsed (terms, phrases, negations, filters, fuzzy expansion, spelling) instead of searching")
 	fs.Usage = func() { printSearchUsage(fs) }
 	_ = fs.Parse(searchArgs)
+	*serverURL = resolveServerURL(fs, *serverURL, *configPathFlag)
 
 	if fs.NArg() < 1 {
 		printSearchUsage(fs)
@@ -302,6 +538,47 @@ func runSearch() {
 		KeywordEnabled:   *kwEnabled,
 		SemanticEnabled:  *semEnabled,
 		FuzzyEnabled:     *fuzzyEnabled,
+		SortBy:           *sortBy,
+		SortOrder:        *sortOrder,
+		Snapshot:         *snapshot,
+		Chunks:           *chunks,
+		Fusion:           *fusion,
+	}
+	if *diversity >= 0 {
+		searchQuery.DiversityLambda = diversity
+	}
+	for _, f := range strings.Split(*fields, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			searchQuery.Fields = append(searchQuery.Fields, f)
+		}
+	}
+	switch {
+	case *wildcard && *regex, *literal && (*wildcard || *regex):
+		fmt.Fprintln(os.Stderr, "Invalid search: --wildcard, --regex, and --literal cannot be combined")
+		os.Exit(1)
+	case *wildcard:
+		searchQuery.Mode = models.QueryModeWildcard
+	case *regex:
+		searchQuery.Mode = models.QueryModeRegex
+	case *literal:
+		searchQuery.Mode = models.QueryModeLiteral
+	}
+	if err := applySearchFilterFlags(searchQuery, *extensions, *pathPrefix, *modifiedAfter, *modifiedBefore); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid filter: %v\n", err)
+		os.Exit(1)
+	}
+	var err error
+	if searchQuery.AsOf, err = parseDateFlag(*asOf); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid search: --as-of: %v\n", err)
+		os.Exit(1)
+	}
+	if err := searchQuery.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid search: %v\n", err)
+		os.Exit(1)
+	}
+	if *explainQuery {
+		explainSearchQuery(*serverURL, *configPathFlag, searchQuery, format)
+		return
 	}
 
 	if *serverURL != "" {
@@ -312,7 +589,7 @@ func runSearch() {
 			os.Exit(1)
 		}
 		// Auto-retry with fuzzy if no results and fuzzy not already enabled
-		if !searchQuery.FuzzyEnabled && response.TotalNonSemantic == 0 && response.TotalSemantic == 0 {
+		if !searchQuery.FuzzyEnabled && !searchQuery.IsPattern() && response.TotalNonSemantic == 0 && response.TotalSemantic == 0 {
 			searchQuery.FuzzyEnabled = true
 			fuzzyResponse, fuzzyErr := searchViaHTTP(*serverURL, searchQuery)
 			if fuzzyErr == nil && (fuzzyResponse.TotalNonSemantic > 0 || fuzzyResponse.TotalSemantic > 0) {
@@ -324,6 +601,7 @@ func runSearch() {
 			fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
 			os.Exit(1)
 		}
+		exportSearchResults(response, *exportLinks, *exportList)
 		return
 	}
 
@@ -353,7 +631,7 @@ func runSearch() {
 		os.Exit(1)
 	}
 	// Auto-retry with fuzzy if no results and fuzzy not already enabled
-	if !searchQuery.FuzzyEnabled && response.TotalNonSemantic == 0 && response.TotalSemantic == 0 {
+	if !searchQuery.FuzzyEnabled && !searchQuery.IsPattern() && response.TotalNonSemantic == 0 && response.TotalSemantic == 0 {
 		searchQuery.FuzzyEnabled = true
 		fuzzyResponse, fuzzyErr := components.Engine.Search(context.Background(), searchQuery)
 		if fuzzyErr == nil && (fuzzyResponse.TotalNonSemantic > 0 || fuzzyResponse.TotalSemantic > 0) {
@@ -365,6 +643,143 @@ func runSearch() {
 		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
 		os.Exit(1)
 	}
+	exportSearchResults(response, *exportLinks, *exportList)
+}
+
+// explainSearchQuery prints how query is parsed, asking the server when serverURL is set.
+func explainSearchQuery(serverURL, configPath string, query *models.SearchQuery, format cli.SearchOutputFormat) {
+	var exp *models.QueryExplanation
+	if serverURL != "" {
+		var err error
+		if exp, err = explainViaHTTP(serverURL, query); err != nil {
+			fmt.Fprintf(os.Stderr, "Explain failed: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		cfg, _, err := loadConfig(configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		logger, err := utils.NewLogger(cfg.Debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+			os.Exit(1)
+		}
+		defer logger.Sync()
+		components, err := initializeComponents(cfg, logger, cfg.Debug)
+		if err != nil {
+			logger.Fatal("Failed to initialize", zap.Error(err))
+		}
+		defer components.Close()
+		if exp, err = components.Engine.Explain(query); err != nil {
+			fmt.Fprintf(os.Stderr, "Explain failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := cli.WriteQueryExplanation(os.Stdout, exp, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func explainViaHTTP(serverURL string, query *models.SearchQuery) (*models.QueryExplanation, error) {
+	params := url.Values{}
+	params.Set("q", query.Query)
+	if query.FuzzyEnabled {
+		params.Set("fuzzy", "true")
+	}
+	if !query.KeywordEnabled {
+		params.Set("keyword", "false")
+	}
+	if !query.SemanticEnabled {
+		params.Set("semantic", "false")
+	}
+	if len(query.Extensions) > 0 {
+		params.Set("ext", strings.Join(query.Extensions, ","))
+	}
+	if query.PathPrefix != "" {
+		params.Set("path_prefix", query.PathPrefix)
+	}
+	if len(query.Fields) > 0 {
+		params.Set("fields", strings.Join(query.Fields, ","))
+	}
+	if query.Mode != "" {
+		params.Set("mode", query.Mode)
+	}
+	if query.Snapshot != "" {
+		params.Set("snapshot", query.Snapshot)
+	}
+	var exp models.QueryExplanation
+	if err := getJSON(serverURL+"/api/v1/explain?"+params.Encode(), &exp); err != nil {
+		return nil, err
+	}
+	return &exp, nil
+}
+
+// exportSearchResults writes the matched files to the --export-links directory and the
+// --export-list file when they are set. The summary goes to stderr so JSON output on
+// stdout stays parseable.
+func exportSearchResults(response *models.SearchResponse, linksDir, listFile string) {
+	if linksDir == "" && listFile == "" {
+		return
+	}
+	paths := cli.ResultFilePaths(response)
+	if linksDir != "" {
+		if err := cli.ExportLinks(linksDir, paths); err != nil {
+			fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Linked %d files in %s\n", len(paths), linksDir)
+	}
+	if listFile != "" {
+		if err := cli.ExportList(listFile, paths); err != nil {
+			fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d file paths to %s\n", len(paths), listFile)
+	}
+}
+
+// applySearchFilterFlags sets the metadata filters of query from the --ext, --path,
+// --after, and --before flag values. Empty values are ignored.
+func applySearchFilterFlags(query *models.SearchQuery, extensions, pathPrefix, after, before string) error {
+	for _, ext := range strings.Split(extensions, ",") {
+		if ext = strings.TrimSpace(ext); ext != "" {
+			query.Extensions = append(query.Extensions, ext)
+		}
+	}
+	if pathPrefix != "" {
+		abs, err := filepath.Abs(pathPrefix)
+		if err != nil {
+			return fmt.Errorf("--path: %w", err)
+		}
+		query.PathPrefix = abs
+	}
+	var err error
+	if query.ModifiedAfter, err = parseDateFlag(after); err != nil {
+		return fmt.Errorf("--after: %w", err)
+	}
+	if query.ModifiedBefore, err = parseDateFlag(before); err != nil {
+		return fmt.Errorf("--before: %w", err)
+	}
+	return nil
+}
+
+// parseDateFlag parses a YYYY-MM-DD date (local midnight) or an RFC 3339 time.
+// It returns nil for an empty value.
+func parseDateFlag(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q (use YYYY-MM-DD or RFC 3339)", v)
+	}
+	return &t, nil
 }
 
 func searchViaHTTP(serverURL string, query *models.SearchQuery) (*models.SearchResponse, error) {
@@ -394,10 +809,13 @@ type statusConfigResponse struct {
 	EmbeddingDimensions int    `json:"embedding_dimensions,omitempty"`
 	ChunkSize           int    `json:"chunk_size,omitempty"`
 	ChunkOverlap        int    `json:"chunk_overlap,omitempty"`
+	ChunkStrategy       string `json:"chunk_strategy,omitempty"`
 	RankingEnabled      bool   `json:"ranking_enabled,omitempty"`
 	DatabasePath        string `json:"database_path,omitempty"`
 	BleveIndexPath      string `json:"bleve_index_path,omitempty"`
 	FAISSIndexPath      string `json:"faiss_index_path,omitempty"`
+	KeywordBackend      string `json:"keyword_backend,omitempty"`
+	KeywordIndexPath    string `json:"keyword_index_path,omitempty"`
 }
 
 // statusResponse is the shape of GET /api/v1/status response.
@@ -415,6 +833,7 @@ func runStatus() {
 	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = use direct storage)")
 	outputFormat := fs.String("output", "text", "output format: text or json")
 	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
 
 	var status statusResponse
 	if *serverURL != "" {
@@ -463,13 +882,16 @@ func runStatus() {
 				EmbeddingDimensions: cfg.Embedding.Dimensions,
 				ChunkSize:           cfg.Search.ChunkSize,
 				ChunkOverlap:        cfg.Search.ChunkOverlap,
+				ChunkStrategy:       cfg.Search.ChunkStrategy,
 				RankingEnabled:      cfg.Search.RankingEnabled,
 				DatabasePath:        cfg.Storage.DatabasePath,
 				BleveIndexPath:      cfg.Storage.BleveIndexPath,
 				FAISSIndexPath:      cfg.Storage.FAISSIndexPath,
+				KeywordBackend:      cfg.Keyword.Backend,
+				KeywordIndexPath:    cfg.KeywordIndexPath(),
 			},
 		}
-		diskBytes, err := storage.DiskUsageBytes(cfg.Storage.DatabasePath, cfg.Storage.BleveIndexPath, cfg.Storage.FAISSIndexPath)
+		diskBytes, err := storage.DiskUsageBytes(cfg.Storage.DatabasePath, cfg.KeywordIndexPath(), cfg.Storage.FAISSIndexPath)
 		if err == nil {
 			status.DiskUsageBytes = &diskBytes
 		}
@@ -503,11 +925,19 @@ func runStatus() {
 			if status.Config.ChunkOverlap > 0 {
 				fmt.Printf("chunk_overlap:      %d\n", status.Config.ChunkOverlap)
 			}
+			if status.Config.ChunkStrategy != "" {
+				fmt.Printf("chunk_strategy:     %s\n", status.Config.ChunkStrategy)
+			}
 			fmt.Printf("ranking_enabled:    %t\n", status.Config.RankingEnabled)
 			if status.Config.DatabasePath != "" {
 				fmt.Printf("database_path:      %s\n", status.Config.DatabasePath)
 			}
-			if status.Config.BleveIndexPath != "" {
+			if status.Config.KeywordBackend != "" {
+				fmt.Printf("keyword_backend:    %s\n", status.Config.KeywordBackend)
+			}
+			if status.Config.KeywordIndexPath != "" && status.Config.KeywordIndexPath != status.Config.BleveIndexPath {
+				fmt.Printf("keyword_index_path: %s\n", status.Config.KeywordIndexPath)
+			} else if status.Config.BleveIndexPath != "" {
 				fmt.Printf("bleve_index_path:   %s\n", status.Config.BleveIndexPath)
 			}
 			if status.Config.FAISSIndexPath != "" {
@@ -537,114 +967,1604 @@ func statusViaHTTP(serverURL string) (*statusResponse, error) {
 	return &s, nil
 }
 
-func runIndex() {
-	fs := flag.NewFlagSet("index", flag.ExitOnError)
+func runRecent() {
+	fs := flag.NewFlagSet("recent", flag.ExitOnError)
 	configPath := fs.String("config", defaultConfigPath, "config file path")
-	_ = fs.String("title", "", "document title (unused; document title is derived from filename)")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = use direct storage)")
+	days := fs.Int("days", 7, "list documents modified in the last N days")
+	pathPrefix := fs.String("path-prefix", "", "only list documents under this path")
+	limit := fs.Int("limit", 50, "maximum number of documents")
+	outputFormat := fs.String("output", "text", "output format: text or json")
 	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
 
-	if fs.NArg() < 1 {
-		fmt.Println("Usage: sagasu index [flags] <file-or-directory>")
-		os.Exit(1)
-	}
-	path := fs.Arg(0)
-
-	cfg, _, err := loadConfig(*configPath)
-	if err != nil {
-		fmt.Printf("Failed to load config: %v\n", err)
+	format := cli.OutputText
+	switch *outputFormat {
+	case "json":
+		format = cli.OutputJSON
+	case "text":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown output format %q; use text or json\n", *outputFormat)
 		os.Exit(1)
 	}
-	debugMode := cfg.Debug
-	logger, err := utils.NewLogger(debugMode)
-	if err != nil {
-		fmt.Printf("Failed to create logger: %v\n", err)
+	if *days <= 0 || *limit <= 0 {
+		fmt.Fprintln(os.Stderr, "--days and --limit must be positive")
 		os.Exit(1)
 	}
-	defer logger.Sync()
-
-	components, err := initializeComponents(cfg, logger, debugMode)
-	if err != nil {
-		logger.Fatal("Failed to initialize", zap.Error(err))
+	prefix := *pathPrefix
+	if prefix != "" {
+		if abs, err := filepath.Abs(prefix); err == nil {
+			prefix = abs
+		}
 	}
-	defer components.Close()
 
-	ctx := context.Background()
-	info, err := os.Stat(path)
-	if err != nil {
-		fmt.Printf("Failed to stat path: %v\n", err)
-		os.Exit(1)
-	}
-	if info.IsDir() {
-		exts := cfg.Watch.Extensions
-		if exts == nil {
-			exts = []string{".txt", ".md", ".rst", ".pdf", ".docx", ".xlsx"}
+	var response *models.RecentResponse
+	if *serverURL != "" {
+		res, err := recentViaHTTP(*serverURL, *days, prefix, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Recent failed: %v\n", err)
+			os.Exit(1)
 		}
-		n, err := components.Indexer.IndexDirectory(ctx, path, exts)
+		response = res
+	} else {
+		cfg, _, err := loadConfig(*configPath)
 		if err != nil {
-			fmt.Printf("Indexing directory failed: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Indexed %d file(s) from %s\n", n, path)
-		return
+		store, err := storage.NewSQLiteStorage(cfg.Storage.DatabasePath, sqliteOptions(cfg)...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open storage: %v\n", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+		since := time.Now().AddDate(0, 0, -*days)
+		docs, total, err := store.ListRecentDocuments(context.Background(), since, prefix, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Recent failed: %v\n", err)
+			os.Exit(1)
+		}
+		response = &models.RecentResponse{Documents: docs, Total: total, Days: *days, PathPrefix: prefix}
 	}
-	// Single file: no extension filter
-	if err := components.Indexer.IndexFile(ctx, path, nil); err != nil {
-		fmt.Printf("Indexing failed: %v\n", err)
+	if err := cli.WriteRecentDocuments(os.Stdout, response, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
 		os.Exit(1)
 	}
-	absPath, _ := filepath.Abs(path)
-	docID := fileid.FileDocID(absPath)
-	fmt.Printf("Document indexed successfully: %s\n", docID)
 }
 
-func runWatch() {
-	if len(os.Args) < 3 {
-		fmt.Println("Usage: sagasu watch <add|remove|list> [path]")
-		fmt.Println("  sagasu watch add <path>     Add directory to watch")
-		fmt.Println("  sagasu watch remove <path>  Remove directory from watch")
-		fmt.Println("  sagasu watch list           List watched directories")
+func recentViaHTTP(serverURL string, days int, pathPrefix string, limit int) (*models.RecentResponse, error) {
+	params := url.Values{}
+	params.Set("days", fmt.Sprint(days))
+	params.Set("limit", fmt.Sprint(limit))
+	if pathPrefix != "" {
+		params.Set("path_prefix", pathPrefix)
+	}
+	resp, err := http.Get(serverURL + "/api/v1/recent?" + params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(b))
+	}
+	var response models.RecentResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &response, nil
+}
+
+func runDupes() {
+	fs := flag.NewFlagSet("dupes", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = use direct storage)")
+	maxDistance := fs.Int("max-distance", -1, "content fingerprint bits (0-64) near copies may differ in; 0 = identical only (default: search.dedupe_max_distance)")
+	pathPrefix := fs.String("path-prefix", "", "only compare documents under this path")
+	extensions := fs.String("ext", "", "only compare documents with these file extensions (comma-separated, e.g. pdf,docx)")
+	limit := fs.Int("limit", 100, "maximum number of groups")
+	outputFormat := fs.String("output", "text", "output format: text or json")
+	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+
+	format := cli.OutputText
+	switch *outputFormat {
+	case "json":
+		format = cli.OutputJSON
+	case "text":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown output format %q; use text or json\n", *outputFormat)
 		os.Exit(1)
 	}
-	sub := os.Args[2]
-	fs := flag.NewFlagSet("watch", flag.ExitOnError)
-	serverURL := fs.String("server", "http://localhost:8080", "server URL")
-	_ = fs.Parse(os.Args[3:])
-	switch sub {
-	case "add":
-		if fs.NArg() < 1 {
-			fmt.Println("Usage: sagasu watch add <path>")
-			os.Exit(1)
+	if *maxDistance < -1 || *maxDistance > 64 || *limit <= 0 {
+		fmt.Fprintln(os.Stderr, "--max-distance must be from 0 to 64 and --limit positive")
+		os.Exit(1)
+	}
+	filter := models.DocumentListFilter{PathPrefix: *pathPrefix}
+	if filter.PathPrefix != "" {
+		if abs, err := filepath.Abs(filter.PathPrefix); err == nil {
+			filter.PathPrefix = abs
 		}
-		path, _ := filepath.Abs(fs.Arg(0))
-		body, _ := json.Marshal(map[string]interface{}{"path": path, "sync": true})
-		resp, err := http.Post(*serverURL+"/api/v1/watch/directories", "application/json", bytes.NewReader(body))
-		if err != nil {
-			fmt.Printf("Request failed: %v\n", err)
-			os.Exit(1)
+	}
+	for _, ext := range strings.Split(*extensions, ",") {
+		if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+			filter.Extensions = append(filter.Extensions, ext)
 		}
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusCreated {
-			b, _ := io.ReadAll(resp.Body)
-			fmt.Printf("Add failed (%d): %s\n", resp.StatusCode, string(b))
+	}
+
+	var response *models.DuplicatesResponse
+	if *serverURL != "" {
+		res, err := dupesViaHTTP(*serverURL, filter, *maxDistance, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Dupes failed: %v\n", err)
 			os.Exit(1)
 		}
-		fmt.Printf("Added: %s\n", path)
-	case "remove":
-		if fs.NArg() < 1 {
-			fmt.Println("Usage: sagasu watch remove <path>")
+		response = res
+	} else {
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
 			os.Exit(1)
 		}
-		path, _ := filepath.Abs(fs.Arg(0))
-		req, _ := http.NewRequest(http.MethodDelete, *serverURL+"/api/v1/watch/directories?path="+url.QueryEscape(path), nil)
-		resp, err := http.DefaultClient.Do(req)
+		store, err := storage.NewSQLiteStorage(cfg.Storage.DatabasePath, sqliteOptions(cfg)...)
 		if err != nil {
-			fmt.Printf("Request failed: %v\n", err)
+			fmt.Fprintf(os.Stderr, "Failed to open storage: %v\n", err)
 			os.Exit(1)
 		}
-		defer resp.Body.Close()
-		if resp.StatusCode != http.StatusOK {
-			b, _ := io.ReadAll(resp.Body)
-			fmt.Printf("Remove failed (%d): %s\n", resp.StatusCode, string(b))
+		defer store.Close()
+		if *maxDistance < 0 {
+			*maxDistance = cfg.Search.DedupeMaxDistance
+		}
+		groups, err := indexer.FindDuplicates(context.Background(), store, filter, *maxDistance)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Dupes failed: %v\n", err)
+			os.Exit(1)
+		}
+		response = &models.DuplicatesResponse{Groups: groups[:min(*limit, len(groups))], Total: len(groups), Limit: *limit, MaxDistance: *maxDistance}
+	}
+	if err := cli.WriteDuplicates(os.Stdout, response, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func dupesViaHTTP(serverURL string, filter models.DocumentListFilter, maxDistance, limit int) (*models.DuplicatesResponse, error) {
+	params := url.Values{}
+	params.Set("limit", fmt.Sprint(limit))
+	if maxDistance >= 0 {
+		params.Set("max_distance", fmt.Sprint(maxDistance))
+	}
+	if filter.PathPrefix != "" {
+		params.Set("path_prefix", filter.PathPrefix)
+	}
+	if len(filter.Extensions) > 0 {
+		params.Set("ext", strings.Join(filter.Extensions, ","))
+	}
+	resp, err := http.Get(serverURL + "/api/v1/duplicates?" + params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(b))
+	}
+	var response models.DuplicatesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &response, nil
+}
+
+// runGraph exports the graph of documents whose embeddings are similar, for visualizing
+// the corpus in tools such as Gephi or Cytoscape.
+func runGraph() {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = open the indexes directly)")
+	threshold := fs.Float64("threshold", indexer.DefaultGraphThreshold, "minimum cosine similarity (above 0, at most 1) of linked documents")
+	neighbors := fs.Int("neighbors", indexer.DefaultGraphNeighbors, "most similar documents linked to each document")
+	maxDistance := fs.Int("max-distance", -1, "content fingerprint bits (0-64) duplicate links may differ in (default: search.dedupe_max_distance)")
+	pathPrefix := fs.String("path-prefix", "", "only compare documents under this path")
+	extensions := fs.String("ext", "", "only compare documents with these file extensions (comma-separated, e.g. pdf,docx)")
+	outputFormat := fs.String("output", "graphml", "output format: graphml or json")
+	outPath := fs.String("out", "", "write the graph to this file instead of stdout")
+	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+
+	if *outputFormat != "graphml" && *outputFormat != "json" {
+		fmt.Fprintf(os.Stderr, "Unknown output format %q; use graphml or json\n", *outputFormat)
+		os.Exit(1)
+	}
+	if *threshold <= 0 || *threshold > 1 || *neighbors <= 0 || *maxDistance < -1 || *maxDistance > 64 {
+		fmt.Fprintln(os.Stderr, "--threshold must be above 0 and at most 1, --neighbors positive, and --max-distance from 0 to 64")
+		os.Exit(1)
+	}
+	filter := models.DocumentListFilter{PathPrefix: *pathPrefix}
+	if filter.PathPrefix != "" {
+		if abs, err := filepath.Abs(filter.PathPrefix); err == nil {
+			filter.PathPrefix = abs
+		}
+	}
+	for _, ext := range strings.Split(*extensions, ",") {
+		if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+			filter.Extensions = append(filter.Extensions, ext)
+		}
+	}
+
+	var graph *models.SimilarityGraph
+	if *serverURL != "" {
+		params := url.Values{}
+		params.Set("threshold", fmt.Sprint(*threshold))
+		params.Set("neighbors", fmt.Sprint(*neighbors))
+		if *maxDistance >= 0 {
+			params.Set("max_distance", fmt.Sprint(*maxDistance))
+		}
+		if filter.PathPrefix != "" {
+			params.Set("path_prefix", filter.PathPrefix)
+		}
+		if len(filter.Extensions) > 0 {
+			params.Set("ext", strings.Join(filter.Extensions, ","))
+		}
+		graph = &models.SimilarityGraph{}
+		if err := getJSON(*serverURL+"/api/v1/graph?"+params.Encode(), graph); err != nil {
+			fmt.Fprintf(os.Stderr, "Graph failed: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		logger, err := utils.NewLogger(cfg.Debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+			os.Exit(1)
+		}
+		defer logger.Sync()
+		components, err := initializeComponents(cfg, logger, cfg.Debug)
+		if err != nil {
+			logger.Fatal("Failed to initialize", zap.Error(err))
+		}
+		defer components.Close()
+		if *maxDistance < 0 {
+			*maxDistance = cfg.Search.DedupeMaxDistance
+		}
+		if graph, err = components.Indexer.SimilarityGraph(context.Background(), filter, *threshold, *neighbors, *maxDistance); err != nil {
+			fmt.Fprintf(os.Stderr, "Graph failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outPath != "" {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", *outPath, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	var err error
+	if *outputFormat == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(graph)
+	} else {
+		err = indexer.WriteGraphML(out, graph)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
+		os.Exit(1)
+	}
+	if *outPath != "" {
+		fmt.Printf("Wrote %d documents and %d links in %d clusters to %s\n", len(graph.Nodes), len(graph.Edges), graph.Clusters, *outPath)
+	}
+}
+
+func runAudit() {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = use direct storage)")
+	sinceFlag := fs.String("since", "", "only entries at or after this date (YYYY-MM-DD or RFC 3339)")
+	untilFlag := fs.String("until", "", "only entries before this date (YYYY-MM-DD or RFC 3339)")
+	limit := fs.Int("limit", 0, "maximum number of entries (0 = all)")
+	outputFormat := fs.String("output", "text", "output format: text, json, or csv")
+	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+
+	format := cli.OutputText
+	switch *outputFormat {
+	case "json":
+		format = cli.OutputJSON
+	case "csv":
+		format = cli.OutputCSV
+	case "text":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown output format %q; use text, json, or csv\n", *outputFormat)
+		os.Exit(1)
+	}
+	if *limit < 0 {
+		fmt.Fprintln(os.Stderr, "--limit cannot be negative")
+		os.Exit(1)
+	}
+	var since, until time.Time
+	if t, err := parseDateFlag(*sinceFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "--since: %v\n", err)
+		os.Exit(1)
+	} else if t != nil {
+		since = *t
+	}
+	if t, err := parseDateFlag(*untilFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "--until: %v\n", err)
+		os.Exit(1)
+	} else if t != nil {
+		until = *t
+	}
+
+	var response *models.AuditResponse
+	if *serverURL != "" {
+		res, err := auditViaHTTP(*serverURL, since, until, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Audit failed: %v\n", err)
+			os.Exit(1)
+		}
+		response = res
+	} else {
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		store, err := storage.NewSQLiteStorage(cfg.Storage.DatabasePath, sqliteOptions(cfg)...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open storage: %v\n", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+		entries, err := store.ListAudit(context.Background(), since, until, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Audit failed: %v\n", err)
+			os.Exit(1)
+		}
+		response = &models.AuditResponse{Entries: entries, Total: len(entries)}
+	}
+	if err := cli.WriteAuditLog(os.Stdout, response, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func auditViaHTTP(serverURL string, since, until time.Time, limit int) (*models.AuditResponse, error) {
+	params := url.Values{}
+	if !since.IsZero() {
+		params.Set("since", since.Format(time.RFC3339))
+	}
+	if !until.IsZero() {
+		params.Set("until", until.Format(time.RFC3339))
+	}
+	if limit > 0 {
+		params.Set("limit", fmt.Sprint(limit))
+	}
+	resp, err := http.Get(serverURL + "/api/v1/audit?" + params.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(b))
+	}
+	var response models.AuditResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return &response, nil
+}
+
+// runAnalytics reports the most frequent queries and the queries that found nothing.
+func runAnalytics() {
+	fs := flag.NewFlagSet("analytics", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = use direct storage)")
+	sinceFlag := fs.String("since", "", "only searches at or after this date (YYYY-MM-DD or RFC 3339)")
+	untilFlag := fs.String("until", "", "only searches before this date (YYYY-MM-DD or RFC 3339)")
+	limit := fs.Int("limit", server.DefaultAnalyticsLimit, "number of top and zero-result queries to list")
+	outputFormat := fs.String("output", "text", "output format: text or json")
+	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+
+	format := cli.OutputText
+	switch *outputFormat {
+	case "json":
+		format = cli.OutputJSON
+	case "text":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown output format %q; use text or json\n", *outputFormat)
+		os.Exit(1)
+	}
+	if *limit <= 0 {
+		fmt.Fprintln(os.Stderr, "--limit must be positive")
+		os.Exit(1)
+	}
+	var since, until time.Time
+	if t, err := parseDateFlag(*sinceFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "--since: %v\n", err)
+		os.Exit(1)
+	} else if t != nil {
+		since = *t
+	}
+	if t, err := parseDateFlag(*untilFlag); err != nil {
+		fmt.Fprintf(os.Stderr, "--until: %v\n", err)
+		os.Exit(1)
+	} else if t != nil {
+		until = *t
+	}
+
+	var report *models.AnalyticsReport
+	if *serverURL != "" {
+		params := url.Values{}
+		if !since.IsZero() {
+			params.Set("since", since.Format(time.RFC3339))
+		}
+		if !until.IsZero() {
+			params.Set("until", until.Format(time.RFC3339))
+		}
+		params.Set("limit", fmt.Sprint(*limit))
+		report = &models.AnalyticsReport{}
+		if err := getJSON(*serverURL+"/api/v1/analytics?"+params.Encode(), report); err != nil {
+			fmt.Fprintf(os.Stderr, "Analytics failed: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		store, err := storage.NewSQLiteStorage(cfg.Storage.DatabasePath, sqliteOptions(cfg)...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open storage: %v\n", err)
+			os.Exit(1)
+		}
+		defer store.Close()
+		report, err = store.AnalyticsReport(context.Background(), since, until, *limit)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Analytics failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := cli.WriteAnalyticsReport(os.Stdout, report, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runQuality re-embeds a sample of chunks and reports drift and index mismatches. It exits 1
+// when the report has warnings, so it can run from cron or CI after a model upgrade.
+func runQuality() {
+	fs := flag.NewFlagSet("quality", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = open the indexes directly)")
+	sample := fs.Int("sample", indexer.DefaultQualitySample, "number of chunks to re-embed and compare")
+	outputFormat := fs.String("output", "text", "output format: text or json")
+	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+	if *sample <= 0 {
+		fmt.Fprintln(os.Stderr, "--sample must be positive")
+		os.Exit(2)
+	}
+	format := cli.OutputText
+	if *outputFormat == "json" {
+		format = cli.OutputJSON
+	}
+
+	var report *models.QualityReport
+	if *serverURL != "" {
+		report = &models.QualityReport{}
+		if err := getJSON(fmt.Sprintf("%s/api/v1/quality?sample=%d", *serverURL, *sample), report); err != nil {
+			fmt.Fprintf(os.Stderr, "Quality check failed: %v\n", err)
+			os.Exit(2)
+		}
+	} else {
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(2)
+		}
+		logger, err := utils.NewLogger(cfg.Debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+			os.Exit(2)
+		}
+		defer logger.Sync()
+		components, err := initializeComponents(cfg, logger, cfg.Debug)
+		if err != nil {
+			logger.Fatal("Failed to initialize", zap.Error(err))
+		}
+		defer components.Close()
+		if report, err = components.Indexer.CheckQuality(context.Background(), *sample); err != nil {
+			fmt.Fprintf(os.Stderr, "Quality check failed: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	if err := cli.WriteQualityReport(os.Stdout, report, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
+		os.Exit(2)
+	}
+	if len(report.Warnings) > 0 {
+		os.Exit(1)
+	}
+}
+
+// runFsck cross-checks storage, the keyword and vector indexes, and the filesystem, and with
+// --repair fixes what it finds. It exits 1 when problems were found and not repaired.
+func runFsck() {
+	fs := flag.NewFlagSet("fsck", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = open the indexes directly)")
+	repair := fs.Bool("repair", false, "delete orphans and documents of missing files, and re-index what is missing")
+	outputFormat := fs.String("output", "text", "output format: text or json")
+	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+	format := cli.OutputText
+	if *outputFormat == "json" {
+		format = cli.OutputJSON
+	}
+
+	report := &models.FsckReport{}
+	if *serverURL != "" {
+		var err error
+		if *repair {
+			err = postJSON(*serverURL+"/api/v1/fsck", nil, report)
+		} else {
+			err = getJSON(*serverURL+"/api/v1/fsck", report)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Consistency check failed: %v\n", err)
+			os.Exit(2)
+		}
+	} else {
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(2)
+		}
+		logger, err := utils.NewLogger(cfg.Debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+			os.Exit(2)
+		}
+		defer logger.Sync()
+		components, err := initializeComponents(cfg, logger, cfg.Debug)
+		if err != nil {
+			logger.Fatal("Failed to initialize", zap.Error(err))
+		}
+		defer components.Close()
+		if report, err = components.Indexer.Fsck(context.Background(), *repair); err != nil {
+			fmt.Fprintf(os.Stderr, "Consistency check failed: %v\n", err)
+			os.Exit(2)
+		}
+		if *repair {
+			if err := components.SaveVectorIndexes(cfg.Storage.FAISSIndexPath); err != nil {
+				fmt.Fprintf(os.Stderr, "Vector index save failed: %v\n", err)
+				os.Exit(2)
+			}
+		}
+	}
+	if err := cli.WriteFsckReport(os.Stdout, report, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
+		os.Exit(2)
+	}
+	if report.Problems() > 0 && !report.Repaired {
+		os.Exit(1)
+	}
+}
+
+// profileKinds are the profiles sagasu profile can capture.
+var profileKinds = []string{"cpu", "heap", "allocs", "goroutine", "block", "mutex", "trace"}
+
+// runProfile captures runtime profiles from a running server with server.profiling set
+// and writes them to files for "go tool pprof", e.g. to attach to a performance report.
+func runProfile() {
+	fs := flag.NewFlagSet("profile", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path (to find the running server)")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL")
+	duration := fs.Duration("duration", 30*time.Second, "how long to record the cpu profile and trace")
+	kinds := fs.String("profiles", "cpu,heap", "profiles to capture (comma-separated): "+strings.Join(profileKinds, ", "))
+	dir := fs.String("dir", ".", "directory to write the profiles to")
+	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+	if *serverURL == "" {
+		fmt.Fprintln(os.Stderr, "sagasu profile needs a running server (--server)")
+		os.Exit(1)
+	}
+	if *duration < time.Second {
+		fmt.Fprintln(os.Stderr, "--duration must be at least 1s")
+		os.Exit(1)
+	}
+	var selected []string
+	for _, kind := range strings.Split(*kinds, ",") {
+		if kind = strings.TrimSpace(kind); kind == "" {
+			continue
+		}
+		if !slices.Contains(profileKinds, kind) {
+			fmt.Fprintf(os.Stderr, "Unknown profile %q; use %s\n", kind, strings.Join(profileKinds, ", "))
+			os.Exit(1)
+		}
+		selected = append(selected, kind)
+	}
+	if len(selected) == 0 {
+		fmt.Fprintln(os.Stderr, "--profiles is empty")
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", *dir, err)
+		os.Exit(1)
+	}
+	stamp := time.Now().Format("20060102-150405")
+	for _, kind := range selected {
+		if kind == "cpu" || kind == "trace" {
+			fmt.Printf("Recording %s for %s...\n", kind, *duration)
+		}
+		path := filepath.Join(*dir, fmt.Sprintf("sagasu-%s-%s.pprof", kind, stamp))
+		if kind == "trace" {
+			path = strings.TrimSuffix(path, ".pprof") + ".trace"
+		}
+		if err := fetchProfile(*serverURL, kind, *duration, path); err != nil {
+			fmt.Fprintf(os.Stderr, "Profile %s failed: %v\n", kind, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote %s\n", path)
+	}
+	fmt.Println("Inspect with: go tool pprof -http=: <file> (traces: go tool trace <file>)")
+}
+
+// fetchProfile downloads a profile of kind from the server's pprof endpoints into the file
+// at path. The cpu profile and trace are recorded for duration; the heap profile is taken
+// after a garbage collection.
+func fetchProfile(serverURL, kind string, duration time.Duration, path string) error {
+	seconds := int(duration.Round(time.Second) / time.Second)
+	u := serverURL + "/api/v1/debug/pprof/"
+	switch kind {
+	case "cpu":
+		u += fmt.Sprintf("profile?seconds=%d", seconds)
+	case "trace":
+		u += fmt.Sprintf("trace?seconds=%d", seconds)
+	case "heap":
+		u += "heap?gc=1"
+	default:
+		u += kind
+	}
+	resp, err := http.Get(u)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return fmt.Errorf("download: %w", err)
+	}
+	return f.Close()
+}
+
+func runCount() {
+	fs := flag.NewFlagSet("count", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = use direct storage)")
+	fuzzyEnabled := fs.Bool("fuzzy", false, "enable fuzzy matching for typo tolerance")
+	extensions := fs.String("ext", "", "only documents with these file extensions (comma-separated, e.g. pdf,docx)")
+	pathPrefix := fs.String("path", "", "only documents under this path")
+	snapshot := fs.String("snapshot", "", "only documents of this snapshot, unchanged since it was taken")
+	_ = fs.Parse(searchArgsReorder(os.Args[2:]))
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+
+	queryStr := buildSearchQuery(fs.Args())
+	if queryStr == "" {
+		fmt.Fprintln(os.Stderr, "Usage: sagasu count [flags] <query>")
+		os.Exit(2)
+	}
+	query := &models.SearchQuery{Query: queryStr, KeywordEnabled: true, FuzzyEnabled: *fuzzyEnabled, Snapshot: *snapshot}
+	if err := applySearchFilterFlags(query, *extensions, *pathPrefix, "", ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid filter: %v\n", err)
+		os.Exit(2)
+	}
+
+	var n int
+	if *serverURL != "" {
+		res, err := countViaHTTP(*serverURL, query)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Count failed: %v\n", err)
+			os.Exit(2)
+		}
+		n = res.Count
+	} else {
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(2)
+		}
+		logger, err := utils.NewLogger(cfg.Debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+			os.Exit(2)
+		}
+		defer logger.Sync()
+		components, err := initializeComponents(cfg, logger, cfg.Debug)
+		if err != nil {
+			logger.Fatal("Failed to initialize", zap.Error(err))
+		}
+		defer components.Close()
+		if n, err = components.Engine.Count(context.Background(), query); err != nil {
+			fmt.Fprintf(os.Stderr, "Count failed: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	fmt.Println(n)
+}
+
+// runDiffResults compares the top results of a query with those recorded for it in the
+// past (--since) or with those of another search run now (--against and --against-*).
+func runDiffResults() {
+	args := searchArgsReorder(os.Args[2:])
+	defaultMinKw, defaultMinSem := searchMinScoreDefaultsFromConfig(searchConfigPathFromArgs(args, defaultConfigPath))
+	fs := flag.NewFlagSet("diff-results", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = use direct storage)")
+	sinceFlag := fs.String("since", "", "compare with the search recorded this long ago (e.g. 7d, 12h) or on this date (YYYY-MM-DD or RFC 3339)")
+	against := fs.String("against", "", "compare with this query, searched now")
+	againstKeyword := fs.Bool("against-keyword", true, "keyword search for the compared query (default: as for the query)")
+	againstSemantic := fs.Bool("against-semantic", true, "semantic search for the compared query (default: as for the query)")
+	againstFuzzy := fs.Bool("against-fuzzy", false, "fuzzy matching for the compared query (default: as for the query)")
+	limit := fs.Int("limit", 10, "number of results per list to compare")
+	kwEnabled := fs.Bool("keyword", true, "enable keyword search")
+	semEnabled := fs.Bool("semantic", true, "enable semantic search")
+	fuzzyEnabled := fs.Bool("fuzzy", false, "enable fuzzy matching for typo tolerance")
+	extensions := fs.String("ext", "", "only documents with these file extensions (comma-separated, e.g. pdf,docx)")
+	pathPrefix := fs.String("path", "", "only documents under this path")
+	snapshot := fs.String("snapshot", "", "search only documents of this snapshot, unchanged since it was taken")
+	outputFormat := fs.String("output", "text", "output format: text or json")
+	_ = fs.Parse(args)
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+
+	format := cli.OutputText
+	switch *outputFormat {
+	case "json":
+		format = cli.OutputJSON
+	case "text":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown output format %q; use text or json\n", *outputFormat)
+		os.Exit(1)
+	}
+	queryStr := buildSearchQuery(fs.Args())
+	if queryStr == "" {
+		fmt.Fprintln(os.Stderr, "Usage: sagasu diff-results [--since 7d | --against query] [flags] <query>")
+		os.Exit(1)
+	}
+	req := &models.ResultsDiffRequest{Query: &models.SearchQuery{
+		Query:            queryStr,
+		Limit:            *limit,
+		MinKeywordScore:  defaultMinKw,
+		MinSemanticScore: defaultMinSem,
+		KeywordEnabled:   *kwEnabled,
+		SemanticEnabled:  *semEnabled,
+		FuzzyEnabled:     *fuzzyEnabled,
+		Snapshot:         *snapshot,
+	}}
+	if err := applySearchFilterFlags(req.Query, *extensions, *pathPrefix, "", ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid filter: %v\n", err)
+		os.Exit(1)
+	}
+	compare := false
+	other := *req.Query
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "against":
+			other.Query = *against
+		case "against-keyword":
+			other.KeywordEnabled = *againstKeyword
+		case "against-semantic":
+			other.SemanticEnabled = *againstSemantic
+		case "against-fuzzy":
+			other.FuzzyEnabled = *againstFuzzy
+		default:
+			return
+		}
+		compare = true
+	})
+	since, err := parseAgoFlag(*sinceFlag)
+	switch {
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "--since: %v\n", err)
+		os.Exit(1)
+	case (since == nil) == !compare:
+		fmt.Fprintln(os.Stderr, "Give either --since or --against (or an --against-* flag)")
+		os.Exit(1)
+	case compare:
+		req.Against = &other
+	default:
+		req.Since = since
+	}
+
+	var diff *models.ResultsDiff
+	if *serverURL != "" {
+		diff = &models.ResultsDiff{}
+		if err := postJSON(*serverURL+"/api/v1/search/diff", req, diff); err != nil {
+			fmt.Fprintf(os.Stderr, "Diff failed: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		logger, err := utils.NewLogger(cfg.Debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+			os.Exit(1)
+		}
+		defer logger.Sync()
+		components, err := initializeComponents(cfg, logger, cfg.Debug)
+		if err != nil {
+			logger.Fatal("Failed to initialize", zap.Error(err))
+		}
+		defer components.Close()
+		if req.Against != nil {
+			diff, err = components.Engine.DiffSearches(context.Background(), req.Query, req.Against)
+		} else {
+			diff, err = components.Engine.DiffSince(context.Background(), req.Query, *req.Since)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Diff failed: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := cli.WriteResultsDiff(os.Stdout, diff, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// parseAgoFlag parses an age such as 7d, 2w, or 12h as that long before now, or else a
+// date as parseDateFlag does. It returns nil for an empty value.
+func parseAgoFlag(v string) (*time.Time, error) {
+	if v == "" {
+		return nil, nil
+	}
+	if n := len(v) - 1; n > 0 && (v[n] == 'd' || v[n] == 'w') {
+		if count, err := strconv.Atoi(v[:n]); err == nil && count >= 0 {
+			days := count
+			if v[n] == 'w' {
+				days *= 7
+			}
+			t := time.Now().AddDate(0, 0, -days)
+			return &t, nil
+		}
+	}
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		t := time.Now().Add(-d)
+		return &t, nil
+	}
+	t, err := parseDateFlag(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid age or date %q (use e.g. 7d, 2w, 12h, YYYY-MM-DD, or RFC 3339)", v)
+	}
+	return t, nil
+}
+
+func countViaHTTP(serverURL string, query *models.SearchQuery) (*models.CountResponse, error) {
+	params := url.Values{}
+	params.Set("q", query.Query)
+	if query.FuzzyEnabled {
+		params.Set("fuzzy", "true")
+	}
+	if len(query.Extensions) > 0 {
+		params.Set("ext", strings.Join(query.Extensions, ","))
+	}
+	if query.PathPrefix != "" {
+		params.Set("path_prefix", query.PathPrefix)
+	}
+	if query.Snapshot != "" {
+		params.Set("snapshot", query.Snapshot)
+	}
+	var response models.CountResponse
+	if err := getJSON(serverURL+"/api/v1/count?"+params.Encode(), &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// runExists exits 0 when the file is indexed (and, with --current, unchanged since), 1 when
+// it is not, and 2 on error.
+func runExists() {
+	fs := flag.NewFlagSet("exists", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = use direct storage)")
+	current := fs.Bool("current", false, "also require the indexed copy to match the file's current mtime and size")
+	quiet := fs.Bool("q", false, "print nothing; only set the exit code")
+	_ = fs.Parse(searchArgsReorder(os.Args[2:]))
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: sagasu exists [flags] <path>")
+		os.Exit(2)
+	}
+	path, err := filepath.Abs(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid path: %v\n", err)
+		os.Exit(2)
+	}
+
+	var status *models.FileStatus
+	if *serverURL != "" {
+		status = &models.FileStatus{}
+		if err := getJSON(*serverURL+"/api/v1/exists?path="+url.QueryEscape(path), status); err != nil {
+			fmt.Fprintf(os.Stderr, "Exists failed: %v\n", err)
+			os.Exit(2)
+		}
+	} else {
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(2)
+		}
+		store, err := storage.NewSQLiteStorage(cfg.Storage.DatabasePath, sqliteOptions(cfg)...)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open storage: %v\n", err)
+			os.Exit(2)
+		}
+		status, err = indexer.FileState(context.Background(), store, path)
+		store.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Exists failed: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	state := "not indexed"
+	switch {
+	case status.Indexed && status.Current:
+		state = "indexed"
+	case status.Indexed:
+		state = "indexed (changed since)"
+	}
+	if !*quiet {
+		fmt.Println(state)
+	}
+	if !status.Indexed || (*current && !status.Current) {
+		os.Exit(1)
+	}
+}
+
+// runScan reports the files under a directory by extension, and which of them indexing
+// with watch.extensions (or --ext) would skip, without indexing anything.
+func runScan() {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	extensions := fs.String("ext", "", "check these extensions instead of watch.extensions (comma-separated, e.g. pdf,docx)")
+	listSkipped := fs.Bool("skipped", false, "also list the files that would be skipped")
+	outputFormat := fs.String("output", "text", "output format: text or json")
+	_ = fs.Parse(searchArgsReorder(os.Args[2:]))
+
+	format := cli.OutputText
+	switch *outputFormat {
+	case "json":
+		format = cli.OutputJSON
+	case "text":
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown output format %q; use text or json\n", *outputFormat)
+		os.Exit(1)
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: sagasu scan [flags] <dir>")
+		os.Exit(1)
+	}
+	cfg, _, err := loadConfig(*configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		// Scanning helps write a first config, so none is needed yet
+		fmt.Fprintf(os.Stderr, "No config at %s; checking the default extensions\n", *configPath)
+		cfg = &config.Config{}
+		config.ApplyDefaults(cfg)
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	exts := cfg.Watch.Extensions
+	if *extensions != "" {
+		exts = nil
+		for _, ext := range strings.Split(*extensions, ",") {
+			if ext = strings.TrimSpace(ext); ext != "" {
+				exts = append(exts, "."+strings.TrimPrefix(ext, "."))
+			}
+		}
+	}
+	report, err := indexer.ScanDirectory(context.Background(), fs.Arg(0), exts, cfg.Watch.NoIndexMarker, *listSkipped)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Scan failed: %v\n", err)
+		os.Exit(1)
+	}
+	if err := cli.WriteScanReport(os.Stdout, report, format); err != nil {
+		fmt.Fprintf(os.Stderr, "Output failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// resolveServerURL returns serverURL when --server was given. Otherwise it returns the
+// address published by the server running on the configured storage, if any, and falls
+// back to serverURL (the flag's default).
+func resolveServerURL(fs *flag.FlagSet, serverURL, configPath string) string {
+	explicit := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "server" {
+			explicit = true
+		}
+	})
+	if explicit {
+		return serverURL
+	}
+	cfg, _, err := loadConfig(configPath)
+	if err != nil {
+		return serverURL
+	}
+	if info, err := instance.Discover(cfg.Storage.DataDir()); err == nil && info != nil {
+		return info.URL
+	}
+	return serverURL
+}
+
+// advertisedURL is the address clients on this machine use to reach a server configured
+// by cfg; a wildcard host is reached through the loopback address.
+func advertisedURL(cfg *config.ServerConfig) string {
+	host := cfg.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(cfg.Port))
+}
+
+// isLoopbackHost reports whether a server bound to host is reachable only from this machine.
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// apiKeyEnv holds the API key CLI commands send to a server that requires one.
+const apiKeyEnv = "SAGASU_API_KEY"
+
+// apiKeyTransport sends key as a bearer token with every request.
+type apiKeyTransport struct {
+	key  string
+	base http.RoundTripper
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.key)
+	return t.base.RoundTrip(req)
+}
+
+// getJSON fetches u and decodes the JSON response into out.
+func getJSON(u string, out interface{}) error {
+	resp, err := http.Get(u)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned %d: %s", resp.StatusCode, string(b))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// postJSON posts body as JSON to u and decodes the JSON response into out.
+func postJSON(u string, body, out interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(u, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned %d: %s", resp.StatusCode, string(b))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func runSnapshot() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: sagasu snapshot <create|list|delete> [flags] [name]")
+		fmt.Println("  sagasu snapshot create <name>  Record the documents indexed now for searches to target with --snapshot")
+		fmt.Println("  sagasu snapshot list           List snapshots")
+		fmt.Println("  sagasu snapshot delete <name>  Delete a snapshot")
+		os.Exit(1)
+	}
+	sub := os.Args[2]
+	fs := flag.NewFlagSet("snapshot", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = use direct storage)")
+	outputFormat := fs.String("output", "text", "output format for list: text or json")
+	_ = fs.Parse(searchArgsReorder(os.Args[3:]))
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+
+	name := fs.Arg(0)
+	switch sub {
+	case "create", "delete":
+		if err := models.ValidateSnapshotName(name); err != nil {
+			fmt.Fprintf(os.Stderr, "Usage: sagasu snapshot %s <name>: %v\n", sub, err)
+			os.Exit(1)
+		}
+	case "list":
+		if *outputFormat != "text" && *outputFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Unknown output format %q; use text or json\n", *outputFormat)
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("Unknown snapshot subcommand: %s\n", sub)
+		os.Exit(1)
+	}
+
+	var store storage.Storage
+	if *serverURL == "" {
+		cfg, _, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+			os.Exit(1)
+		}
+		logger, err := utils.NewLogger(cfg.Debug)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+			os.Exit(1)
+		}
+		defer logger.Sync()
+		components, err := initializeComponents(cfg, logger, cfg.Debug)
+		if err != nil {
+			logger.Fatal("Failed to initialize", zap.Error(err))
+		}
+		defer components.Close()
+		store = components.Storage
+	}
+
+	ctx := context.Background()
+	switch sub {
+	case "create":
+		snap := &models.Snapshot{}
+		var err error
+		if store != nil {
+			snap, err = store.CreateSnapshot(ctx, name)
+		} else {
+			err = sendJSON(http.MethodPost, *serverURL+"/api/v1/snapshots", map[string]string{"name": name}, snap)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Create failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Snapshot %s: %d documents\n", snap.Name, snap.Documents)
+	case "delete":
+		var err error
+		if store != nil {
+			err = store.DeleteSnapshot(ctx, name)
+		} else {
+			err = sendJSON(http.MethodDelete, *serverURL+"/api/v1/snapshots/"+url.PathEscape(name), nil, nil)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Delete failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Deleted: %s\n", name)
+	case "list":
+		var out struct {
+			Snapshots []*models.Snapshot `json:"snapshots"`
+		}
+		var err error
+		if store != nil {
+			out.Snapshots, err = store.ListSnapshots(ctx)
+		} else {
+			err = getJSON(*serverURL+"/api/v1/snapshots", &out)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "List failed: %v\n", err)
+			os.Exit(1)
+		}
+		if *outputFormat == "json" {
+			if out.Snapshots == nil {
+				out.Snapshots = []*models.Snapshot{}
+			}
+			_ = json.NewEncoder(os.Stdout).Encode(out.Snapshots)
+			return
+		}
+		for _, snap := range out.Snapshots {
+			fmt.Printf("%s\t%s\t%d documents\n", snap.Name, snap.CreatedAt.Local().Format("2006-01-02 15:04"), snap.Documents)
+		}
+	}
+}
+
+// sendJSON sends body, when non-nil, as JSON to u with method and decodes a successful
+// (2xx) JSON response into out, when non-nil.
+func sendJSON(method, u string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, u, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned %d: %s", resp.StatusCode, string(b))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+func runIndex() {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	_ = fs.String("title", "", "document title (unused; document title is derived from filename)")
+	_ = fs.Parse(os.Args[2:])
+
+	if fs.NArg() < 1 {
+		fmt.Println("Usage: sagasu index [flags] <file-or-directory>")
+		os.Exit(1)
+	}
+	path := fs.Arg(0)
+
+	cfg, _, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Printf("Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	debugMode := cfg.Debug
+	logger, err := utils.NewLogger(debugMode)
+	if err != nil {
+		fmt.Printf("Failed to create logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+
+	components, err := initializeComponents(cfg, logger, debugMode)
+	if err != nil {
+		logger.Fatal("Failed to initialize", zap.Error(err))
+	}
+	defer components.Close()
+
+	ctx := context.Background()
+	info, err := os.Stat(path)
+	if err != nil {
+		fmt.Printf("Failed to stat path: %v\n", err)
+		os.Exit(1)
+	}
+	if info.IsDir() {
+		exts := cfg.Watch.Extensions
+		if exts == nil {
+			exts = []string{".txt", ".md", ".rst", ".pdf", ".docx", ".xlsx"}
+		}
+		n, err := components.Indexer.IndexDirectory(ctx, path, exts)
+		if err != nil {
+			fmt.Printf("Indexing directory failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Indexed %d file(s) from %s\n", n, path)
+		return
+	}
+	// Single file: no extension filter
+	if err := components.Indexer.IndexFile(ctx, path, nil); err != nil {
+		fmt.Printf("Indexing failed: %v\n", err)
+		os.Exit(1)
+	}
+	absPath, _ := filepath.Abs(path)
+	docID := fileid.FileDocID(absPath)
+	fmt.Printf("Document indexed successfully: %s\n", docID)
+}
+
+// reindexStatusResponse is the shape of GET /api/v1/reindex response.
+type reindexStatusResponse struct {
+	State   string `json:"state"`
+	Done    int    `json:"done"`
+	Total   int    `json:"total"`
+	Indexed int    `json:"indexed"`
+	Failed  int    `json:"failed"`
+	Error   string `json:"error,omitempty"`
+}
+
+func runReindex() {
+	fs := flag.NewFlagSet("reindex", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL (empty = rebuild directly when server is not running)")
+	shadow := fs.Bool("shadow", false, "build new indexes alongside the current ones and swap them in when done")
+	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+
+	if *serverURL != "" {
+		status, err := reindexViaHTTP(*serverURL, *shadow)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\nReindex failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("\nReindexed %d of %d item(s), %d failed\n", status.Indexed, status.Total, status.Failed)
+		return
+	}
+
+	cfg, _, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	logger, err := utils.NewLogger(cfg.Debug)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer logger.Sync()
+	components, err := initializeComponents(cfg, logger, cfg.Debug)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize: %v\n", err)
+		os.Exit(1)
+	}
+	defer components.Close()
+
+	var result *indexer.ReindexResult
+	if *shadow && components.Shadow == nil {
+		fmt.Fprintln(os.Stderr, "Reindex failed: shadow rebuild is not supported when collections have their own indexes or an index is remote")
+		os.Exit(1)
+	}
+	if *shadow {
+		result, err = components.Indexer.RebuildShadow(context.Background(), cfg.Watch.Directories, cfg.Watch.Extensions, components.Shadow, printReindexProgress)
+	} else {
+		result, err = components.Indexer.ReindexAll(context.Background(), cfg.Watch.Directories, cfg.Watch.Extensions, printReindexProgress)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "\nReindex failed: %v\n", err)
+		os.Exit(1)
+	}
+	if err := components.SaveVectorIndexes(cfg.Storage.FAISSIndexPath); err != nil {
+		fmt.Fprintf(os.Stderr, "\nVector index save failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\nReindexed %d of %d item(s), %d failed\n", result.Indexed, result.Total, result.Failed)
+}
+
+func printReindexProgress(done, total int) {
+	fmt.Printf("\rReindexing: %d/%d", done, total)
+}
+
+// reindexViaHTTP starts a reindex on the server (a shadow rebuild when shadow is set) and
+// polls until it finishes.
+func reindexViaHTTP(serverURL string, shadow bool) (*reindexStatusResponse, error) {
+	u := serverURL + "/api/v1/reindex"
+	if shadow {
+		u += "?mode=shadow"
+	}
+	resp, err := http.Post(u, "application/json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, fmt.Errorf("server returned %d: %s", resp.StatusCode, string(b))
+	}
+	for {
+		time.Sleep(500 * time.Millisecond)
+		resp, err := http.Get(serverURL + "/api/v1/reindex")
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		var status reindexStatusResponse
+		err = json.NewDecoder(resp.Body).Decode(&status)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode response: %w", err)
+		}
+		printReindexProgress(status.Done, status.Total)
+		switch status.State {
+		case "completed":
+			return &status, nil
+		case "failed":
+			return nil, fmt.Errorf("%s", status.Error)
+		}
+	}
+}
+
+// runExtractWorker extracts the file named in the request on stdin and writes its text to
+// stdout, under the limits the request sets (see extract.WithSandbox).
+func runExtractWorker() {
+	if err := extract.RunWorker(os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runTray() {
+	fs := flag.NewFlagSet("tray", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path (used to find the running server)")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL")
+	interval := fs.Duration("interval", 3*time.Second, "how often to refresh indexing activity")
+	limit := fs.Int("limit", 10, "maximum number of search results in the menu")
+	_ = fs.Parse(os.Args[2:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+
+	client := tray.NewClient(*serverURL)
+	client.APIKey = os.Getenv(apiKeyEnv)
+	tray.Run(client, tray.Options{PollInterval: *interval, ResultLimit: *limit})
+}
+
+func runDaemon() {
+	sub := "start"
+	args := os.Args[2:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path the service runs the server with")
+	_ = fs.Parse(args)
+
+	cfg, resolvedConfigPath, err := loadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load config: %v\n", err)
+		os.Exit(1)
+	}
+	resolvedConfigPath, _ = filepath.Abs(resolvedConfigPath)
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to find the sagasu binary: %v\n", err)
+		os.Exit(1)
+	}
+	dataDir := cfg.Storage.DataDir()
+	svc, err := daemon.New(executable, resolvedConfigPath,
+		filepath.Join(dataDir, "sagasu.pid"), filepath.Join(dataDir, "sagasu.log"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+
+	switch sub {
+	case "start":
+		err = svc.Start()
+	case "stop":
+		err = svc.Stop()
+	case "restart":
+		err = svc.Restart()
+	case "uninstall":
+		err = svc.Uninstall()
+	case "status":
+		st, err := svc.Status()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Status failed: %v\n", err)
+			os.Exit(1)
+		}
+		if st.Installed {
+			fmt.Printf("Service:  installed (%s)\n", st.Path)
+		} else {
+			fmt.Println("Service:  not installed")
+		}
+		if st.PID == 0 {
+			fmt.Println("Server:   not running")
+			os.Exit(1)
+		}
+		if info, err := instance.Discover(dataDir); err == nil && info != nil && info.PID == st.PID {
+			fmt.Printf("Server:   running (pid %d) at %s\n", st.PID, info.URL)
+		} else {
+			fmt.Printf("Server:   running (pid %d)\n", st.PID)
+		}
+		return
+	default:
+		fmt.Printf("Unknown daemon subcommand: %s\n", sub)
+		fmt.Println("Usage: sagasu daemon [start|stop|restart|status|uninstall] [--config path]")
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Daemon %s failed: %v\n", sub, err)
+		os.Exit(1)
+	}
+	switch sub {
+	case "start", "restart":
+		fmt.Printf("Server started as a service (%s)\n", svc.Path())
+		fmt.Printf("Log: %s\n", svc.Log())
+	case "stop":
+		fmt.Println("Server stopped; it starts again at the next login or with \"sagasu daemon start\"")
+	case "uninstall":
+		fmt.Println("Service removed")
+	}
+}
+
+func runWatch() {
+	if len(os.Args) < 3 {
+		fmt.Println("Usage: sagasu watch <add|remove|list|open|close|flush> [path]")
+		fmt.Println("  sagasu watch add <path>     Add directory to watch")
+		fmt.Println("  sagasu watch remove <path>  Remove directory from watch")
+		fmt.Println("  sagasu watch list           List watched directories")
+		fmt.Println("  sagasu watch open <file>    Index changes to file immediately (e.g. open in an editor)")
+		fmt.Println("  sagasu watch close <file>   Return file to normal, debounced indexing")
+		fmt.Println("  sagasu watch open           List files marked open")
+		fmt.Println("  sagasu watch flush          Index changes held back outside watch.index_windows now")
+		os.Exit(1)
+	}
+	sub := os.Args[2]
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	configPath := fs.String("config", defaultConfigPath, "config file path (used to find the running server)")
+	serverURL := fs.String("server", "http://localhost:8080", "server URL")
+	_ = fs.Parse(os.Args[3:])
+	*serverURL = resolveServerURL(fs, *serverURL, *configPath)
+	switch sub {
+	case "add":
+		if fs.NArg() < 1 {
+			fmt.Println("Usage: sagasu watch add <path>")
+			os.Exit(1)
+		}
+		path, _ := filepath.Abs(fs.Arg(0))
+		body, _ := json.Marshal(map[string]interface{}{"path": path, "sync": true})
+		resp, err := http.Post(*serverURL+"/api/v1/watch/directories", "application/json", bytes.NewReader(body))
+		if err != nil {
+			fmt.Printf("Request failed: %v\n", err)
+			os.Exit(1)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			b, _ := io.ReadAll(resp.Body)
+			fmt.Printf("Add failed (%d): %s\n", resp.StatusCode, string(b))
+			os.Exit(1)
+		}
+		fmt.Printf("Added: %s\n", path)
+	case "remove":
+		if fs.NArg() < 1 {
+			fmt.Println("Usage: sagasu watch remove <path>")
+			os.Exit(1)
+		}
+		path, _ := filepath.Abs(fs.Arg(0))
+		req, _ := http.NewRequest(http.MethodDelete, *serverURL+"/api/v1/watch/directories?path="+url.QueryEscape(path), nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Printf("Request failed: %v\n", err)
+			os.Exit(1)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			fmt.Printf("Remove failed (%d): %s\n", resp.StatusCode, string(b))
 			os.Exit(1)
 		}
 		fmt.Printf("Removed: %s\n", path)
@@ -670,6 +2590,74 @@ func runWatch() {
 		for _, d := range out.Directories {
 			fmt.Println(d)
 		}
+	case "open":
+		if fs.NArg() < 1 {
+			var out struct {
+				Paths []string `json:"paths"`
+			}
+			if err := getJSON(*serverURL+"/api/v1/watch/priority", &out); err != nil {
+				fmt.Printf("List failed: %v\n", err)
+				os.Exit(1)
+			}
+			for _, p := range out.Paths {
+				fmt.Println(p)
+			}
+			return
+		}
+		path, _ := filepath.Abs(fs.Arg(0))
+		body, _ := json.Marshal(map[string]string{"path": path})
+		resp, err := http.Post(*serverURL+"/api/v1/watch/priority", "application/json", bytes.NewReader(body))
+		if err != nil {
+			fmt.Printf("Request failed: %v\n", err)
+			os.Exit(1)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			fmt.Printf("Open failed (%d): %s\n", resp.StatusCode, string(b))
+			os.Exit(1)
+		}
+		fmt.Printf("Marked open: %s\n", path)
+	case "close":
+		if fs.NArg() < 1 {
+			fmt.Println("Usage: sagasu watch close <file>")
+			os.Exit(1)
+		}
+		path, _ := filepath.Abs(fs.Arg(0))
+		req, _ := http.NewRequest(http.MethodDelete, *serverURL+"/api/v1/watch/priority?path="+url.QueryEscape(path), nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Printf("Request failed: %v\n", err)
+			os.Exit(1)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			fmt.Printf("Close failed (%d): %s\n", resp.StatusCode, string(b))
+			os.Exit(1)
+		}
+		fmt.Printf("Closed: %s\n", path)
+	case "flush":
+		resp, err := http.Post(*serverURL+"/api/v1/watch/flush", "application/json", nil)
+		if err != nil {
+			fmt.Printf("Request failed: %v\n", err)
+			os.Exit(1)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			b, _ := io.ReadAll(resp.Body)
+			fmt.Printf("Flush failed (%d): %s\n", resp.StatusCode, string(b))
+			os.Exit(1)
+		}
+		var out struct {
+			Files       int `json:"files"`
+			Directories int `json:"directories"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			fmt.Printf("Parse failed: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Indexing %d held files and %d held directories\n", out.Files, out.Directories)
 	default:
 		fmt.Printf("Unknown watch subcommand: %s\n", sub)
 		os.Exit(1)
@@ -718,9 +2706,57 @@ type Components struct {
 	Storage      storage.Storage
 	Embedder     embedding.Embedder
 	VectorIndex  vector.VectorIndex
+	VectorModel  vector.ModelStamp // the model whose vectors VectorIndex holds
 	KeywordIndex keyword.KeywordIndex
 	Engine       *search.Engine
 	Indexer      *indexer.Indexer
+	Reranker     search.Reranker
+	Shadow       *indexer.PathSwapTarget // builds and swaps in stores for a shadow rebuild; nil when collections have their own indexes or an index is remote
+	Collections  []collectionComponents
+	Events       *events.Bus // indexing activity, streamed by the server
+
+	EmbeddingCache *storage.EmbeddingCacheStore // nil when disabled or unavailable
+	Instance       *instance.Lock               // exclusive hold of the data directory
+}
+
+// collectionComponents are the indexes and model a collection, or an extra embedding
+// model, uses instead of the defaults. Any of them may be nil.
+type collectionComponents struct {
+	Config          config.CollectionConfig
+	Embedder        embedding.Embedder
+	VectorIndex     vector.VectorIndex
+	VectorIndexPath string
+	VectorModel     vector.ModelStamp
+	KeywordIndex    keyword.KeywordIndex
+}
+
+// SaveVectorIndexes saves the default vector index and every collection vector index that
+// has a path, each with a stamp of the model whose vectors it holds.
+func (c *Components) SaveVectorIndexes(path string) error {
+	if path != "" && c.VectorIndex != nil {
+		if err := saveVectorIndex(c.VectorIndex, path, c.VectorModel); err != nil {
+			return err
+		}
+	}
+	for _, col := range c.Collections {
+		if col.VectorIndex == nil || col.VectorIndexPath == "" {
+			continue
+		}
+		if err := saveVectorIndex(col.VectorIndex, col.VectorIndexPath, col.VectorModel); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func saveVectorIndex(vi vector.VectorIndex, path string, stamp vector.ModelStamp) error {
+	if err := vi.Save(path); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := vector.SaveModelStamp(path, stamp); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
 }
 
 func (c *Components) Close() {
@@ -736,96 +2772,574 @@ func (c *Components) Close() {
 	if c.KeywordIndex != nil {
 		_ = c.KeywordIndex.Close()
 	}
+	if c.Reranker != nil {
+		_ = c.Reranker.Close()
+	}
+	for _, col := range c.Collections {
+		if col.Embedder != nil {
+			_ = col.Embedder.Close()
+		}
+		if col.VectorIndex != nil {
+			_ = col.VectorIndex.Close()
+		}
+	}
+	if c.EmbeddingCache != nil {
+		_ = c.EmbeddingCache.Close()
+	}
+	if c.Instance != nil {
+		_ = c.Instance.Release()
+	}
 }
 
 func initializeComponents(cfg *config.Config, logger *zap.Logger, debug bool) (*Components, error) {
-	store, err := storage.NewSQLiteStorage(cfg.Storage.DatabasePath)
+	// Two processes writing the same indexes would corrupt them.
+	lock, err := instance.Acquire(cfg.Storage.DataDir())
 	if err != nil {
-		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+		return nil, err
 	}
-
-	var embedder embedding.Embedder
-	onnxEmbedder, err := embedding.NewONNXEmbedder(
-		cfg.Embedding.ModelPath,
-		cfg.Embedding.Dimensions,
-		cfg.Embedding.MaxTokens,
-		cfg.Embedding.CacheSize,
-	)
+	if err := indexer.RestoreInterruptedSwap(cfg.Storage.DatabasePath, cfg.KeywordIndexPath()); err != nil {
+		return nil, err
+	}
+	sqliteStore, err := storage.NewSQLiteStorage(cfg.Storage.DatabasePath, sqliteOptions(cfg)...)
 	if err != nil {
-		embedder = embedding.NewMockEmbedder(cfg.Embedding.Dimensions)
-	} else {
-		embedder = onnxEmbedder
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
 	}
+	store := storage.NewSwappableStorage(sqliteStore)
 
-	vectorIndex, err := vector.NewVectorIndex(cfg.Vector.IndexType, cfg.Embedding.Dimensions)
-	if err != nil {
-		// Fall back to memory index if configured type fails (e.g., FAISS not available)
-		if cfg.Vector.IndexType != "memory" && cfg.Vector.IndexType != "" {
+	// The embedding cache is an optimization: without it, every chunk is embedded again.
+	var embeddingCache *storage.EmbeddingCacheStore
+	var persistentCache embedding.PersistentCache
+	if cfg.Storage.EmbeddingCachePath != "" {
+		embeddingCache, err = storage.NewEmbeddingCacheStore(cfg.Storage.EmbeddingCachePath)
+		if err != nil {
 			if logger != nil {
-				logger.Warn("failed to create vector index, falling back to memory",
-					zap.String("requested_type", cfg.Vector.IndexType),
-					zap.Error(err))
-			}
-			vectorIndex, err = vector.NewVectorIndex("memory", cfg.Embedding.Dimensions)
-			if err != nil {
-				return nil, fmt.Errorf("failed to initialize vector index: %w", err)
+				logger.Warn("embedding cache unavailable", zap.Error(err))
 			}
 		} else {
-			return nil, fmt.Errorf("failed to initialize vector index: %w", err)
+			persistentCache = embeddingCache
 		}
 	}
-	if cfg.Storage.FAISSIndexPath != "" {
-		if loadErr := vectorIndex.Load(cfg.Storage.FAISSIndexPath); loadErr != nil && logger != nil {
-			logger.Warn("vector index load skipped (use full sync)", zap.String("path", cfg.Storage.FAISSIndexPath), zap.Error(loadErr))
+
+	embedder := newEmbedder(&cfg.Embedding, persistentCache)
+
+	// newVectorIndexDims creates a vector index for the embeddings of a model; name is the
+	// collection or embedding model it is for ("" for the default), which on a Qdrant
+	// server gets a collection of its own.
+	newVectorIndexDims := func(name string, dimensions int) (vector.VectorIndex, error) {
+		qdrant := cfg.Vector.Qdrant
+		apiKey := qdrant.APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("QDRANT_API_KEY")
+		}
+		collection := qdrant.Collection
+		if name != "" {
+			collection += "-" + name
+		}
+		vectorIndex, err := vector.NewVectorIndex(cfg.Vector.IndexType, dimensions,
+			vector.WithHNSWParams(cfg.Vector.HNSWM, cfg.Vector.HNSWEfConstruction, cfg.Vector.HNSWEfSearch),
+			vector.WithQuantization(cfg.Vector.Quantization, cfg.Vector.RefineFactor),
+			vector.WithQdrant(vector.QdrantConfig{
+				URL:        qdrant.URL,
+				Collection: collection,
+				APIKey:     apiKey,
+				Timeout:    time.Duration(qdrant.TimeoutSeconds) * time.Second,
+			}))
+		if err != nil {
+			// Fall back to memory index if configured type fails (e.g., FAISS not available);
+			// a remote index holds vectors that a memory index would silently go without.
+			if cfg.Vector.IndexType != "memory" && cfg.Vector.IndexType != "" && !cfg.Vector.RemoteVector() {
+				if logger != nil {
+					logger.Warn("failed to create vector index, falling back to memory",
+						zap.String("requested_type", cfg.Vector.IndexType),
+						zap.Error(err))
+				}
+				vectorIndex, err = vector.NewVectorIndex("memory", dimensions)
+				if err != nil {
+					return nil, fmt.Errorf("failed to initialize vector index: %w", err)
+				}
+			} else {
+				return nil, fmt.Errorf("failed to initialize vector index: %w", err)
+			}
+		}
+		// Log every change next to the saved index so a crash does not lose it
+		if cfg.Vector.WALCompactMB > 0 && !cfg.Vector.RemoteVector() {
+			vectorIndex = vector.NewWALIndex(vectorIndex, int64(cfg.Vector.WALCompactMB)<<20)
 		}
+		return vectorIndex, nil
+	}
+	newVectorIndex := func() (vector.VectorIndex, error) {
+		return newVectorIndexDims("", cfg.Embedding.Dimensions)
+	}
+	liveVectorIndex, err := newVectorIndex()
+	if err != nil {
+		return nil, err
 	}
+	vectorIndex := vector.NewSwappableIndex(liveVectorIndex)
+	vectorModel := modelStamp(&cfg.Embedding)
+	loadVectorIndex(vectorIndex, cfg.Storage.FAISSIndexPath, vectorModel, logger)
 	if logger != nil {
 		logger.Info("vector index initialized",
 			zap.String("type", cfg.Vector.IndexType),
 			zap.Bool("faiss_available", vector.IsFAISSAvailable()))
 	}
 
-	keywordIndex, err := keyword.NewBleveIndex(cfg.Storage.BleveIndexPath)
+	// Every keyword index ignores the stopwords, keeps the protected terms as written, and
+	// indexes stemmed copies of title and content when stemming is configured, and
+	// chunks with search.keyword_chunks.
+	unstemmedOpts := []keyword.BleveOption{
+		keyword.WithStopwords(cfg.Search.Stopwords),
+		keyword.WithProtectedTerms(cfg.Search.ProtectedTerms),
+	}
+	if cfg.Search.KeywordChunks {
+		unstemmedOpts = append(unstemmedOpts, keyword.WithChunks())
+	}
+	keywordOpts := append(slices.Clone(unstemmedOpts), keyword.WithStemming(cfg.Search.Stemming, cfg.Search.StemmedBoost))
+	// The default keyword index is a Bleve index or, with keyword.backend fts5, an FTS5
+	// database, or an index on an Elasticsearch or OpenSearch cluster; collections and
+	// languages with their own analyzer need Bleve.
+	newKeywordIndex := func(path string) (keyword.KeywordIndex, error) {
+		if cfg.Keyword.RemoteKeyword() {
+			es := cfg.Keyword.Elasticsearch
+			apiKey := es.APIKey
+			if apiKey == "" {
+				apiKey = os.Getenv("ELASTICSEARCH_API_KEY")
+			}
+			idx, err := keyword.NewElasticIndex(context.Background(), keyword.ElasticConfig{
+				URL:            es.URL,
+				Index:          es.Index,
+				APIKey:         apiKey,
+				Username:       es.Username,
+				Password:       es.Password,
+				Timeout:        time.Duration(es.TimeoutSeconds) * time.Second,
+				Stopwords:      cfg.Search.Stopwords,
+				ProtectedTerms: cfg.Search.ProtectedTerms,
+			})
+			if err != nil {
+				return nil, err
+			}
+			return idx, nil
+		}
+		if cfg.Keyword.Backend == "fts5" {
+			idx, err := keyword.NewFTS5Index(path, cfg.Search.Stopwords, cfg.Search.ProtectedTerms)
+			if err != nil {
+				return nil, err
+			}
+			return idx, nil
+		}
+		idx, err := keyword.NewBleveIndex(path, keywordOpts...)
+		if err != nil {
+			return nil, err
+		}
+		return idx, nil
+	}
+	defaultKeywordIndex, err := newKeywordIndex(cfg.KeywordIndexPath())
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize keyword index: %w", err)
 	}
 
+	// Collections with their own analyzer get their own Bleve index; with their own
+	// model, their own embedder and vector index.
+	var collections []collectionComponents
+	var indexerCollections []indexer.Collection
+	var collectionIndex *keyword.CollectionIndex
+	ownIndexes := false
+	for _, colCfg := range cfg.Collections {
+		col := collectionComponents{Config: colCfg}
+		ic := indexer.Collection{
+			Name:    colCfg.Name,
+			Root:    colCfg.Root,
+			Chunker: indexer.NewChunker(colCfg.ChunkSize, colCfg.ChunkOverlap).WithStrategy(colCfg.ChunkStrategy),
+		}
+		if colCfg.Analyzer != "" {
+			col.KeywordIndex, err = keyword.NewBleveIndexWithAnalyzer(cfg.Storage.BleveIndexPath+"-"+colCfg.Name, colCfg.Analyzer, keywordOpts...)
+			if err != nil {
+				return nil, fmt.Errorf("collection %s: failed to initialize keyword index: %w", colCfg.Name, err)
+			}
+			if collectionIndex == nil {
+				collectionIndex = keyword.NewCollectionIndex(defaultKeywordIndex)
+			}
+			collectionIndex.Add(colCfg.Root, col.KeywordIndex)
+		}
+		if colCfg.Embedding != nil {
+			col.Embedder = newEmbedder(colCfg.Embedding, persistentCache)
+			col.VectorIndex, err = newVectorIndexDims(colCfg.Name, colCfg.Embedding.Dimensions)
+			if err != nil {
+				return nil, fmt.Errorf("collection %s: %w", colCfg.Name, err)
+			}
+			if cfg.Storage.FAISSIndexPath != "" {
+				col.VectorIndexPath = cfg.Storage.FAISSIndexPath + "-" + colCfg.Name
+			}
+			col.VectorModel = modelStamp(colCfg.Embedding)
+			loadVectorIndex(col.VectorIndex, col.VectorIndexPath, col.VectorModel, logger)
+			ic.Embedder, ic.VectorIndex = col.Embedder, col.VectorIndex
+		}
+		ownIndexes = ownIndexes || colCfg.HasOwnIndexes()
+		collections = append(collections, col)
+		indexerCollections = append(indexerCollections, ic)
+	}
+	// Languages with their own analyzer get a Bleve index per analyzer for the documents
+	// outside those collections.
+	langIndexes := make(map[string]keyword.KeywordIndex)
+	for _, lang := range slices.Sorted(maps.Keys(cfg.Languages.Analyzers)) {
+		analyzer := cfg.Languages.Analyzers[lang]
+		langIndex, ok := langIndexes[analyzer]
+		if !ok {
+			langIndex, err = keyword.NewBleveIndexWithAnalyzer(cfg.Storage.BleveIndexPath+"-lang-"+analyzer, analyzer, keywordOpts...)
+			if err != nil {
+				return nil, fmt.Errorf("language %s: failed to initialize keyword index: %w", lang, err)
+			}
+			langIndexes[analyzer] = langIndex
+		}
+		if collectionIndex == nil {
+			collectionIndex = keyword.NewCollectionIndex(defaultKeywordIndex)
+		}
+		collectionIndex.AddLanguage(lang, langIndex)
+		ownIndexes = true
+	}
+	// With indexer.code and stemming, source files outside those collections get a Bleve
+	// index that does not stem, so identifiers are matched as written.
+	if cfg.Indexer.Code && cfg.Search.Stemming != "" {
+		codeIndex, err := keyword.NewBleveIndex(cfg.Storage.BleveIndexPath+"-code", unstemmedOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("code: failed to initialize keyword index: %w", err)
+		}
+		if collectionIndex == nil {
+			collectionIndex = keyword.NewCollectionIndex(defaultKeywordIndex)
+		}
+		collectionIndex.AddCode(codeIndex)
+		ownIndexes = true
+	}
+	// Extra embedding models embed the files with their extensions, wherever they are,
+	// into their own vector index. Collections are listed first so their settings win.
+	var models []collectionComponents
+	for _, m := range cfg.EmbeddingModels {
+		col := collectionComponents{Config: config.CollectionConfig{Name: m.Name}}
+		col.Embedder = newEmbedder(&m.Embedding, persistentCache)
+		col.VectorIndex, err = newVectorIndexDims(m.Name, m.Embedding.Dimensions)
+		if err != nil {
+			return nil, fmt.Errorf("embedding model %s: %w", m.Name, err)
+		}
+		if cfg.Storage.FAISSIndexPath != "" {
+			col.VectorIndexPath = cfg.Storage.FAISSIndexPath + "-" + m.Name
+		}
+		col.VectorModel = modelStamp(&m.Embedding)
+		loadVectorIndex(col.VectorIndex, col.VectorIndexPath, col.VectorModel, logger)
+		indexerCollections = append(indexerCollections, indexer.Collection{
+			Name:        m.Name,
+			Extensions:  m.Extensions,
+			Embedder:    col.Embedder,
+			VectorIndex: col.VectorIndex,
+		})
+		models = append(models, col)
+		ownIndexes = true
+	}
+	var keywordIndex *keyword.SwappableIndex
+	if collectionIndex != nil {
+		keywordIndex = keyword.NewSwappableIndex(collectionIndex)
+	} else {
+		keywordIndex = keyword.NewSwappableIndex(defaultKeywordIndex)
+	}
+
 	engine := search.NewEngine(store, embedder, vectorIndex, keywordIndex, &cfg.Search)
+	for _, col := range collections {
+		if col.VectorIndex != nil {
+			engine.WithSemanticIndex(col.Embedder, col.VectorIndex)
+		}
+	}
+	for i, col := range models {
+		engine.WithSemanticIndexFor(cfg.EmbeddingModels[i].Extensions, col.Embedder, col.VectorIndex)
+	}
 	// Initialize spell checker for typo tolerance
 	engine.WithSpellChecker()
-
-	idxOpts := []indexer.IndexerOption{}
+	reranker, err := search.LoadReranker(cfg.Search.RerankerModelPath, cfg.Embedding.MaxTokens)
+	if err != nil && logger != nil {
+		logger.Warn("reranker model not loaded, using fused order",
+			zap.String("path", cfg.Search.RerankerModelPath), zap.Error(err))
+	}
+	engine.WithReranker(reranker)
+	if cfg.Search.SynonymsPath != "" {
+		synonyms, err := search.LoadSynonyms(cfg.Search.SynonymsPath)
+		if err != nil && logger != nil {
+			logger.Warn("synonyms not loaded", zap.String("path", cfg.Search.SynonymsPath), zap.Error(err))
+		}
+		engine.WithSynonyms(synonyms)
+	}
+	engine.WithDocumentCache(cfg.Search.DocumentCacheSize)
+	engine.WithVectorCache(cfg.Search.VectorCacheSize, cfg.Search.VectorCacheMinSimilarity)
+
+	idxOpts := []indexer.IndexerOption{indexer.WithInvalidator(engine), indexer.WithCollections(indexerCollections...)}
+	for _, p := range cfg.Retention.Policies {
+		idxOpts = append(idxOpts, indexer.WithRetention(indexer.RetentionPolicy{
+			Root:   p.Root,
+			Tag:    p.Tag,
+			MaxAge: time.Duration(p.MaxAgeDays) * 24 * time.Hour,
+		}))
+	}
 	if debug && logger != nil {
 		idxOpts = append(idxOpts, indexer.WithLogger(logger))
 	}
-	idx := indexer.NewIndexer(store, embedder, vectorIndex, keywordIndex, &cfg.Search, extract.NewExtractor(), idxOpts...)
+	idxOpts = append(idxOpts, indexer.WithEmbedQueue(indexer.NewEmbedQueue(cfg.Jobs.EmbedQueueChunks)))
+	idxOpts = append(idxOpts, indexer.WithNoIndexMarker(cfg.Watch.NoIndexMarker))
+	if len(cfg.Watch.Rules) > 0 {
+		rules, err := cfg.Watch.PathRules()
+		if err != nil {
+			return nil, fmt.Errorf("watch.rules: %w", err)
+		}
+		idxOpts = append(idxOpts, indexer.WithRules(rules))
+	}
+	ignore, err := cfg.Watch.Ignore()
+	if err != nil {
+		return nil, fmt.Errorf("watch.global_ignore: %w", err)
+	}
+	if ignore != nil {
+		idxOpts = append(idxOpts, indexer.WithIgnore(ignore))
+	}
+	idxOpts = append(idxOpts, indexer.WithWalkOptions(cfg.Watch.WalkOptions()))
+	idxOpts = append(idxOpts, indexer.WithMaxFileSize(cfg.Indexer.MaxFileSize()))
+	if cfg.Indexer.SkipBinaryOrDefault() {
+		idxOpts = append(idxOpts, indexer.WithSkipBinary())
+	}
+	if cfg.Extract.MailAttachments {
+		idxOpts = append(idxOpts, indexer.WithMailAttachments())
+	}
+	if cfg.Indexer.Code {
+		idxOpts = append(idxOpts, indexer.WithCodeMode())
+	}
+	if ar := cfg.Extract.Archives; ar.Enabled {
+		idxOpts = append(idxOpts, indexer.WithArchives(extract.ArchiveOptions{
+			Extensions:   ar.Extensions,
+			MaxEntrySize: int64(ar.MaxEntrySizeMB) << 20,
+			MaxTotalSize: int64(ar.MaxTotalSizeMB) << 20,
+			MaxEntries:   ar.MaxEntries,
+		}))
+	}
+	bus := events.NewBus(events.DefaultHistory)
+	idxOpts = append(idxOpts, indexer.WithEvents(bus))
+	if cfg.Languages.DetectOrDefault() {
+		idxOpts = append(idxOpts, indexer.WithLanguageDetection())
+	}
+	passwords := make([]extract.PasswordRule, len(cfg.Passwords))
+	for i := range cfg.Passwords {
+		passwords[i] = extract.PasswordRule{Pattern: cfg.Passwords[i].Pattern, Password: cfg.Passwords[i].Secret()}
+	}
+	extractor := extract.NewExtractor().WithPasswords(passwords).WithMaxRows(cfg.Extract.MaxRows)
+	if tr := cfg.Extract.Transcriber; len(tr.Command) > 0 {
+		extractor.WithTranscriber(extract.TranscriberConfig{
+			Command:    tr.Command,
+			Extensions: tr.Extensions,
+			Timeout:    time.Duration(tr.TimeoutSeconds) * time.Second,
+			NoCache:    !tr.CacheOrDefault(),
+		})
+	}
+	if len(cfg.Extract.External) > 0 {
+		external := make([]extract.ExternalConfig, len(cfg.Extract.External))
+		for i, x := range cfg.Extract.External {
+			external[i] = extract.ExternalConfig{
+				Command:    x.Command,
+				Extensions: x.Extensions,
+				Timeout:    time.Duration(x.TimeoutSeconds) * time.Second,
+			}
+		}
+		extractor.WithExternal(external...)
+	}
+	if cv := cfg.Extract.Converter; cv.Enabled {
+		conv := extract.ConverterConfig{
+			Command:    cv.Command,
+			Extensions: cv.Extensions,
+			Timeout:    time.Duration(cv.TimeoutSeconds) * time.Second,
+		}
+		if cv.CacheOrDefault() {
+			conv.CacheDir = filepath.Join(cfg.Storage.DataDir(), "converted")
+		}
+		extractor.WithConverter(conv)
+	}
+	if cfg.Extract.Sandbox {
+		exe, err := os.Executable()
+		if err != nil {
+			return nil, fmt.Errorf("extraction sandbox: %w", err)
+		}
+		extractor.WithSandbox(extract.SandboxConfig{
+			Command:              []string{exe, "extract-worker"},
+			MemoryMB:             cfg.Extract.MemoryMB,
+			CPUSeconds:           cfg.Extract.CPUSeconds,
+			Timeout:              time.Duration(cfg.Extract.TimeoutSeconds) * time.Second,
+			AllowNetworkFallback: cfg.Extract.AllowNetworkFallback,
+			OnNetworkFallback: func(err error) {
+				logger.Warn("extraction workers run with the server's network: no network namespace", zap.Error(err))
+			},
+		})
+	}
+	idx := indexer.NewIndexer(store, embedder, vectorIndex, keywordIndex, &cfg.Search, extractor, idxOpts...)
 
-	return &Components{
+	components := &Components{
 		Storage:      store,
 		Embedder:     embedder,
 		VectorIndex:  vectorIndex,
+		VectorModel:  vectorModel,
 		KeywordIndex: keywordIndex,
 		Engine:       engine,
 		Indexer:      idx,
-	}, nil
+		Reranker:     reranker,
+		Collections:  append(collections, models...),
+		Events:       bus,
+
+		EmbeddingCache: embeddingCache,
+		Instance:       lock,
+	}
+	// A shadow rebuild only knows how to rebuild the default stores, on disk.
+	if !ownIndexes && !cfg.Keyword.RemoteKeyword() && !cfg.Vector.RemoteVector() {
+		components.Shadow = &indexer.PathSwapTarget{
+			Storage:         store,
+			KeywordIndex:    keywordIndex,
+			VectorIndex:     vectorIndex,
+			DatabasePath:    cfg.Storage.DatabasePath,
+			BleveIndexPath:  cfg.KeywordIndexPath(),
+			VectorIndexPath: cfg.Storage.FAISSIndexPath,
+			NewVectorIndex:  newVectorIndex,
+			KeywordOptions:  keywordOpts,
+			NewKeywordIndex: newKeywordIndex,
+			StorageOptions:  sqliteOptions(cfg),
+		}
+	}
+	return components, nil
+}
+
+// newEmbedder builds the embedder for cfg.Provider: a remote Ollama or OpenAI-compatible
+// service, or the ONNX model. It falls back to a mock embedder when that is unavailable.
+// A model embedder is wrapped with cache, when non-nil, so its embeddings persist.
+func newEmbedder(cfg *config.EmbeddingConfig, cache embedding.PersistentCache) embedding.Embedder {
+	persist := func(e embedding.Embedder, model string) embedding.Embedder {
+		if cache == nil {
+			return e
+		}
+		return embedding.NewPersistentCachedEmbedder(e, cache,
+			fmt.Sprintf("%s:%s:%d", cfg.Provider, model, cfg.Dimensions))
+	}
+	switch {
+	case cfg.Provider == "mock":
+		return embedding.NewMockEmbedder(cfg.Dimensions)
+	case cfg.IsRemote():
+		apiKey := cfg.APIKey
+		if apiKey == "" && cfg.Provider == embedding.ProviderOpenAI {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+		remote, err := embedding.NewRemoteEmbedder(embedding.RemoteConfig{
+			Provider:          cfg.Provider,
+			BaseURL:           cfg.BaseURL,
+			Model:             cfg.Model,
+			APIKey:            apiKey,
+			Dimensions:        cfg.Dimensions,
+			BatchSize:         cfg.BatchSize,
+			MaxRetries:        cfg.MaxRetries,
+			RequestsPerMinute: cfg.RequestsPerMinute,
+			Timeout:           time.Duration(cfg.TimeoutSeconds) * time.Second,
+			CacheSize:         cfg.CacheSize,
+		})
+		if err != nil {
+			return embedding.NewMockEmbedder(cfg.Dimensions)
+		}
+		return persist(remote, cfg.BaseURL+"/"+cfg.Model)
+	}
+	onnxEmbedder, err := embedding.NewONNXEmbedder(
+		cfg.ModelPath,
+		cfg.Dimensions,
+		cfg.MaxTokens,
+		cfg.CacheSize,
+	)
+	if err != nil {
+		return embedding.NewMockEmbedder(cfg.Dimensions)
+	}
+	return persist(onnxEmbedder, cfg.ModelPath)
+}
+
+// newLLMClient creates the chat client POST /api/v1/ask answers with.
+func newLLMClient(cfg *config.LLMConfig) (*llm.Client, error) {
+	apiKey := cfg.APIKey
+	if apiKey == "" && cfg.Provider == llm.ProviderOpenAI {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+	return llm.NewClient(llm.Config{
+		Provider:  cfg.Provider,
+		BaseURL:   cfg.BaseURL,
+		Model:     cfg.Model,
+		APIKey:    apiKey,
+		MaxTokens: cfg.MaxTokens,
+		Timeout:   time.Duration(cfg.TimeoutSeconds) * time.Second,
+	})
+}
+
+// sqliteOptions returns the options opening the document database with the configured
+// pragmas, trigram index, and version history.
+func sqliteOptions(cfg *config.Config) []storage.SQLiteOption {
+	c := cfg.Storage.SQLite
+	return []storage.SQLiteOption{
+		storage.WithPragmas(storage.Pragmas{
+			JournalMode: c.JournalMode,
+			Synchronous: c.Synchronous,
+			CacheSizeKB: c.CacheSizeMB * 1024,
+			BusyTimeout: time.Duration(c.BusyTimeoutMS) * time.Millisecond,
+		}),
+		storage.WithTrigramIndex(c.TrigramIndex),
+		storage.WithVersionHistory(c.VersionHistory),
+	}
+}
+
+// loadVectorIndex loads a saved vector index from path, if any.
+// modelStamp identifies the model configured by cfg in a saved vector index.
+func modelStamp(cfg *config.EmbeddingConfig) vector.ModelStamp {
+	return vector.ModelStamp{Model: cfg.ModelID(), Dimensions: cfg.Dimensions}
+}
+
+// loadVectorIndex loads the index saved at path unless it was built by a model other
+// than stamp's, in which case it starts empty until the next reindex.
+func loadVectorIndex(vi vector.VectorIndex, path string, stamp vector.ModelStamp, logger *zap.Logger) {
+	if path == "" {
+		return
+	}
+	if err := vector.CheckModelStamp(path, stamp); err != nil {
+		if logger != nil {
+			logger.Warn("vector index not loaded: embedding model changed", zap.String("path", path), zap.Error(err))
+		}
+		return
+	}
+	if loadErr := vi.Load(path); loadErr != nil && logger != nil {
+		logger.Warn("vector index load skipped (use full sync)", zap.String("path", path), zap.Error(loadErr))
+	}
 }
 
 func printUsage() {
 	fmt.Println(`sagasu - Fast local hybrid search engine
 
 Usage:
-  sagasu server [flags]           Start the HTTP server
+  sagasu server [flags]           Start the HTTP server (web UI at /)
   sagasu search [flags] <query>   Search documents
   sagasu index [flags] <file>     Index a document
   sagasu delete [flags] <id>       Delete a document
   sagasu status [flags]           Show engine/storage/index status
-  sagasu watch <add|remove|list>  Manage watched directories
+  sagasu recent [flags]           List recently modified documents
+  sagasu dupes [flags]            List groups of identical or near-identical indexed files
+  sagasu graph [flags]            Export a graph of related documents as GraphML or JSON
+  sagasu count [flags] <query>    Print the number of documents matching a query
+  sagasu diff-results [flags] <query>  Show documents that entered or left a query's top results
+  sagasu audit [flags]            Export the audit log of searches and document fetches
+  sagasu analytics [flags]        Report top queries and queries with no results
+  sagasu quality [flags]          Check embedding drift and index consistency; exit 1 on warnings
+  sagasu fsck [flags]             Cross-check storage, indexes, and files; --repair fixes problems
+  sagasu profile [flags]          Capture CPU, heap, and other profiles from a running server
+  sagasu exists [flags] <path>    Exit 0 if a file is indexed, 1 if not
+  sagasu scan [flags] <dir>       Report file types and sizes, and which files watch.extensions skips
+  sagasu snapshot <create|list|delete> [name]  Pin the indexed documents under a name for reproducible searches
+  sagasu reindex [flags]          Drop and rebuild all indexes from watched directories
+  sagasu watch <add|remove|list|open|close|flush>  Manage watched directories and open files
+  sagasu tray [flags]             Menu bar / tray icon with activity, quick search, and pause/resume
+  sagasu daemon [start|stop|restart|status|uninstall]  Run the server as a login service (launchd or systemd)
   sagasu version                  Show version
   sagasu help                     Show this help
 
 Server Flags:
   --config string    Config file path (default: /usr/local/etc/sagasu/config.yaml)
   --debug            Enable debug logging (directory changes, file indexing, etc.)
+  --pidfile string   Write the server's process ID to this file while it runs
 
 Search Flags:
   --config string             Config file path (for direct storage mode; also used for default min-score values)
@@ -836,6 +3350,17 @@ Search Flags:
   --keyword                   Enable keyword search (default: true)
   --semantic                  Enable semantic search (default: true)
   --fuzzy                     Enable fuzzy matching for typo tolerance (default: false)
+  --ext string                Only documents with these extensions (comma-separated, e.g. pdf,docx)
+  --path string               Only documents under this path
+  --after string              Only documents modified on or after this date (YYYY-MM-DD or RFC 3339)
+  --before string             Only documents modified before this date (YYYY-MM-DD or RFC 3339)
+  --sort string               Order results by relevance (default), modified_time, title, or size
+  --order string              Sort direction: asc or desc (default: desc for modified_time and size, asc for title)
+  --export-links string       Create symlinks to the matched files in this directory
+  --export-list string        Write the matched file paths to this file, one per line
+  --snapshot string           Only documents of this snapshot, unchanged since it was taken
+  --as-of string              Search documents as they were at this time (YYYY-MM-DD or RFC 3339; keyword only)
+  --explain-query             Print how the query is parsed instead of searching
 
 Index Flags:
   --config string    Config file path
@@ -846,9 +3371,111 @@ Status Flags:
   --server string    Server URL (default: http://localhost:8080). Use empty (--server "") for direct storage.
   --output string    Output format: text or json (default: text)
 
+Recent Flags:
+  --config string       Config file path (for direct storage mode)
+  --server string       Server URL (default: http://localhost:8080). Use empty (--server "") for direct storage.
+  --days int            List documents modified in the last N days (default: 7)
+  --path-prefix string  Only list documents under this path
+  --limit int           Maximum number of documents (default: 50)
+  --output string       Output format: text or json (default: text)
+
+Dupes Flags:
+  --config string       Config file path (for direct storage mode; also the default max distance)
+  --server string       Server URL (default: http://localhost:8080). Use empty (--server "") for direct storage.
+  --max-distance int    Fingerprint bits (0-64) near copies may differ in; 0 = identical only (default: search.dedupe_max_distance)
+  --path-prefix string  Only compare documents under this path
+  --ext string          Only compare documents with these extensions (comma-separated, e.g. pdf,docx)
+  --limit int           Maximum number of groups (default: 100)
+  --output string       Output format: text or json (default: text)
+
+Count Flags:
+  --config string    Config file path (for direct storage mode)
+  --server string    Server URL (default: http://localhost:8080). Use empty (--server "") for direct storage.
+  --fuzzy            Enable fuzzy matching for typo tolerance
+  --ext string       Only documents with these extensions (comma-separated)
+  --path string      Only documents under this path
+  --snapshot string  Only documents of this snapshot, unchanged since it was taken
+
+Diff-results Flags:
+  --config string    Config file path (for direct storage mode; also used for default min-score values)
+  --server string    Server URL (default: http://localhost:8080). Use empty (--server "") for direct storage.
+  --since string     Compare with the search recorded this long ago (e.g. 7d, 12h) or on this date (needs analytics.enabled)
+  --against string   Compare with this query instead, searched now
+  --against-keyword, --against-semantic, --against-fuzzy
+                     Compare with the query searched with keyword, semantic, or fuzzy search switched (default: as for the query)
+  --limit int        Number of results per list to compare (default: 10)
+  --keyword, --semantic, --fuzzy, --ext, --path, --snapshot
+                     As for search
+  --output string    Output format: text or json (default: text)
+
+Audit Flags:
+  --config string    Config file path (for direct storage mode)
+  --server string    Server URL (default: http://localhost:8080). Use empty (--server "") for direct storage.
+  --since string     Only entries at or after this date (YYYY-MM-DD or RFC 3339)
+  --until string     Only entries before this date (YYYY-MM-DD or RFC 3339)
+  --limit int        Maximum number of entries (default: 0, all)
+  --output string    Output format: text, json, or csv (default: text)
+
+Analytics Flags:
+  --config string    Config file path (for direct storage mode)
+  --server string    Server URL (default: http://localhost:8080). Use empty (--server "") for direct storage.
+  --since string     Only searches at or after this date (YYYY-MM-DD or RFC 3339)
+  --until string     Only searches before this date (YYYY-MM-DD or RFC 3339)
+  --limit int        Number of top and zero-result queries to list (default: 10)
+  --output string    Output format: text or json (default: text)
+
+Quality Flags:
+  --config string    Config file path (for direct mode)
+  --server string    Server URL (default: http://localhost:8080). Use empty (--server "") to open the indexes directly.
+  --sample int       Chunks to re-embed and compare (default: 100)
+  --output string    Output format: text or json (default: text)
+
+Fsck Flags:
+  --config string    Config file path (for direct mode)
+  --server string    Server URL (default: http://localhost:8080). Use empty (--server "") to open the indexes directly.
+  --repair           Delete orphans and documents of missing files, and re-index what is missing
+  --output string    Output format: text or json (default: text)
+
+Profile Flags (needs server.profiling):
+  --config string      Config file path (to find the running server)
+  --server string      Server URL (default: http://localhost:8080)
+  --duration duration  How long to record the cpu profile and trace (default: 30s)
+  --profiles string    Profiles to capture: cpu, heap, allocs, goroutine, block, mutex, trace (default: cpu,heap)
+  --dir string         Directory to write the profiles to (default: .)
+
+Exists Flags:
+  --config string    Config file path (for direct storage mode)
+  --server string    Server URL (default: http://localhost:8080). Use empty (--server "") for direct storage.
+  --current          Also require the indexed copy to be up to date with the file
+  -q                 Print nothing; only set the exit code (0 indexed, 1 not indexed, 2 error)
+
+Scan Flags:
+  --config string    Config file path (watch.extensions and watch.noindex_marker; defaults when missing)
+  --ext string       Check these extensions instead of watch.extensions (comma-separated, e.g. pdf,docx)
+  --skipped          Also list the files that would be skipped
+  --output string    Output format: text or json (default: text)
+
+Snapshot Flags:
+  --config string    Config file path (for direct storage mode)
+  --server string    Server URL (default: http://localhost:8080). Use empty (--server "") for direct storage.
+  --output string    Output format of list: text or json (default: text)
+
+Reindex Flags:
+  --config string    Config file path (for direct mode)
+  --server string    Server URL (default: http://localhost:8080). Use empty (--server "") to rebuild directly.
+  --shadow           Build new indexes alongside the current ones, which keep serving, then swap them in
+
 Watch Flags:
   --server string    Server URL (default: http://localhost:8080)
 
+Daemon Flags:
+  --config string    Config file path the service runs the server with (default: /usr/local/etc/sagasu/config.yaml)
+
+Tray Flags:
+  --server string      Server URL (default: http://localhost:8080)
+  --interval duration  How often to refresh indexing activity (default: 3s)
+  --limit int          Maximum number of search results in the menu (default: 10)
+
 Examples:
   sagasu server
   sagasu search "machine learning algorithms"
@@ -859,6 +3486,27 @@ Examples:
   sagasu delete doc-123
   sagasu status
   sagasu status --output json
+  sagasu recent --days 3 --path-prefix ~/notes
+  sagasu count --ext pdf invoice
+  sagasu diff-results --since 7d "quarterly budget"
+  sagasu diff-results --against-fuzzy "quarterly budget"
+  sagasu analytics --since 2026-01-01
+  sagasu exists -q --current ~/notes/todo.md && echo "up to date"
+  sagasu fsck --repair
+  sagasu profile --duration 30s --profiles cpu,heap,goroutine
+  sagasu scan ~/Documents
+  sagasu scan --ext txt,md,pdf,html ~/Documents
+  sagasu snapshot create eval-baseline
+  sagasu diff-results --snapshot eval-baseline --against-fuzzy "quarterly budget"
+  sagasu reindex
+  sagasu reindex --shadow
   sagasu watch add /path/to/docs
-  sagasu watch list`)
+  sagasu watch list
+  sagasu watch open ~/notes/todo.md
+  sagasu tray &
+  sagasu daemon               # install and start the server as a service
+  sagasu daemon status
+
+Environment:
+  SAGASU_API_KEY     API key sent to a server that requires one (server.auth.api_keys)`)
 }
@@ -1,10 +1,26 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/fileid"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/instance"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"github.com/hyperjump/sagasu/internal/watcher"
 )
 
 func TestSearchArgsReorder(t *testing.T) {
@@ -194,3 +210,192 @@ storage:
 		t.Errorf("unexpected server config: %+v", cfg.Server)
 	}
 }
+
+func TestApplySearchFilterFlags(t *testing.T) {
+	q := &models.SearchQuery{Query: "plan"}
+	if err := applySearchFilterFlags(q, "pdf, .docx,", "/projects", "2026-03-01", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Extensions) != 2 || q.Extensions[0] != "pdf" || q.Extensions[1] != ".docx" {
+		t.Errorf("extensions = %v", q.Extensions)
+	}
+	if q.PathPrefix != "/projects" {
+		t.Errorf("path prefix = %q", q.PathPrefix)
+	}
+	want := time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)
+	if q.ModifiedAfter == nil || !q.ModifiedAfter.Equal(want) {
+		t.Errorf("modified after = %v, want %v", q.ModifiedAfter, want)
+	}
+	if q.ModifiedBefore != nil {
+		t.Errorf("modified before = %v, want nil", q.ModifiedBefore)
+	}
+
+	if err := applySearchFilterFlags(&models.SearchQuery{}, "", "", "", "last week"); err == nil {
+		t.Error("expected an error for an invalid --before date")
+	}
+}
+
+func TestParseAgoFlag(t *testing.T) {
+	before := time.Now()
+	for v, age := range map[string]time.Duration{"7d": 7 * 24 * time.Hour, "2w": 14 * 24 * time.Hour, "12h": 12 * time.Hour} {
+		got, err := parseAgoFlag(v)
+		if err != nil || got == nil {
+			t.Fatalf("%s: %v", v, err)
+		}
+		// Days are calendar days, an hour off across a DST change
+		if d := before.Sub(*got) - age; d < -time.Hour || d > time.Hour {
+			t.Errorf("%s: got %v, want about %v ago", v, *got, age)
+		}
+	}
+	got, err := parseAgoFlag("2026-03-01")
+	if err != nil || got == nil || !got.Equal(time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("date: got %v, %v", got, err)
+	}
+	if got, err := parseAgoFlag(""); got != nil || err != nil {
+		t.Errorf("empty: got %v, %v", got, err)
+	}
+	for _, v := range []string{"-3d", "last week", "d"} {
+		if _, err := parseAgoFlag(v); err == nil {
+			t.Errorf("%q: expected an error", v)
+		}
+	}
+}
+
+func TestResolveServerURL(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("storage:\n  database_path: ./data/documents.db\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	resolve := func(args ...string) string {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		serverURL := fs.String("server", "http://localhost:8080", "")
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+		return resolveServerURL(fs, *serverURL, configPath)
+	}
+	if got := resolve(); got != "http://localhost:8080" {
+		t.Errorf("no server running: got %s", got)
+	}
+
+	lock, err := instance.Acquire(filepath.Join(dir, "data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Release()
+	if err := lock.Publish(advertisedURL(&config.ServerConfig{Host: "0.0.0.0", Port: 9123})); err != nil {
+		t.Fatal(err)
+	}
+	if got := resolve(); got != "http://127.0.0.1:9123" {
+		t.Errorf("discovered: got %s", got)
+	}
+	if got := resolve("--server", "http://other:1"); got != "http://other:1" {
+		t.Errorf("explicit --server: got %s", got)
+	}
+}
+
+func TestIsLoopbackHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"localhost": true, "127.0.0.1": true, "::1": true,
+		"": false, "0.0.0.0": false, "::": false, "192.168.1.20": false, "files.example.com": false,
+	} {
+		if got := isLoopbackHost(host); got != want {
+			t.Errorf("isLoopbackHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
+
+func TestAPIKeyTransport(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: &apiKeyTransport{key: "cli-key", base: http.DefaultTransport}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got != "Bearer cli-key" {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+func TestFetchProfile(t *testing.T) {
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = append(requested, r.URL.RequestURI())
+		if r.URL.Path == "/api/v1/debug/pprof/mutex" {
+			http.Error(w, "profiling not enabled", http.StatusNotImplemented)
+			return
+		}
+		_, _ = w.Write([]byte("profile data"))
+	}))
+	defer srv.Close()
+	dir := t.TempDir()
+
+	for _, kind := range []string{"cpu", "heap", "goroutine"} {
+		path := filepath.Join(dir, kind+".pprof")
+		if err := fetchProfile(srv.URL, kind, 5*time.Second, path); err != nil {
+			t.Fatal(err)
+		}
+		if b, err := os.ReadFile(path); err != nil || string(b) != "profile data" {
+			t.Errorf("%s: file %q, %v", kind, b, err)
+		}
+	}
+	want := []string{"/api/v1/debug/pprof/profile?seconds=5", "/api/v1/debug/pprof/heap?gc=1", "/api/v1/debug/pprof/goroutine"}
+	if !reflect.DeepEqual(requested, want) {
+		t.Errorf("requested %v, want %v", requested, want)
+	}
+
+	path := filepath.Join(dir, "mutex.pprof")
+	if err := fetchProfile(srv.URL, "mutex", time.Second, path); err == nil {
+		t.Error("expected an error when the server refuses")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("a refused profile should not leave a file")
+	}
+}
+
+func TestIndexWatchedFile_usesChangedExtensions(t *testing.T) {
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStorage(filepath.Join(dir, "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	keywordIndex, err := keyword.NewBleveIndex("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer keywordIndex.Close()
+	vectorIndex, err := vector.NewMemoryIndex(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	embedder := embedding.NewMockEmbedder(4)
+	defer embedder.Close()
+	cfg := &config.SearchConfig{ChunkSize: 100, ChunkOverlap: 10}
+	idx := indexer.NewIndexer(store, embedder, vectorIndex, keywordIndex, cfg, nil)
+
+	path := filepath.Join(dir, "server.log")
+	if err := os.WriteFile(path, []byte("connection refused"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	w := watcher.NewWatcher([]string{dir}, []string{"txt"}, true, func(string) {}, func(string) {})
+	ctx := context.Background()
+	if err := indexWatchedFile(ctx, idx, w, path); err == nil {
+		t.Fatal("a .log file should not be indexed while only .txt is watched")
+	}
+
+	// What PATCH /api/v1/config does with watch.extensions
+	w.SetExtensions([]string{"txt", "log"})
+	if err := indexWatchedFile(ctx, idx, w, path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetDocument(ctx, fileid.FileDocID(path)); err != nil {
+		t.Errorf("document of %s: %v", path, err)
+	}
+}
@@ -3,16 +3,23 @@ module github.com/hyperjump/sagasu
 go 1.24.1
 
 require (
+fyne.io/systray v1.12.2
 github.com/blevesearch/bleve/v2 v2.3.10
+github.com/blevesearch/bleve_index_api v1.0.6
 github.com/fsnotify/fsnotify v1.9.0
 github.com/go-chi/chi/v5 v5.0.11
 github.com/google/uuid v1.5.0
+github.com/klauspost/compress v1.18.0
 github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
 github.com/lu4p/cat v0.1.5
 github.com/mattn/go-sqlite3 v1.14.18
+github.com/richardlehane/mscfb v1.0.4
 github.com/xuri/excelize/v2 v2.8.1
 github.com/yalue/onnxruntime_go v1.8.0
 go.uber.org/zap v1.26.0
+golang.org/x/net v0.21.0
+golang.org/x/sys v0.37.0
+golang.org/x/text v0.14.0
 gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -20,7 +27,6 @@ require (
 github.com/EndFirstCorp/peekingReader v0.0.0-20171012052444-257fb6f1a1a6 // indirect
 github.com/RoaringBitmap/roaring v1.2.3 // indirect
 github.com/bits-and-blooms/bitset v1.2.0 // indirect
-github.com/blevesearch/bleve_index_api v1.0.6 // indirect
 github.com/blevesearch/geo v0.1.18 // indirect
 github.com/blevesearch/go-porterstemmer v1.0.3 // indirect
 github.com/blevesearch/gtreap v0.1.1 // indirect
@@ -36,13 +42,13 @@ github.com/blevesearch/zapx/v13 v13.3.10 // indirect
 github.com/blevesearch/zapx/v14 v14.3.10 // indirect
 github.com/blevesearch/zapx/v15 v15.3.13 // indirect
 github.com/gabriel-vasile/mimetype v1.1.1 // indirect
+github.com/godbus/dbus/v5 v5.1.0 // indirect
 github.com/golang/geo v0.0.0-20210211234256-740aa86cb551 // indirect
 github.com/golang/protobuf v1.3.2 // indirect
 github.com/golang/snappy v0.0.1 // indirect
 github.com/json-iterator/go v0.0.0-20171115153421-f7279a603ede // indirect
 github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
 github.com/mschoch/smat v0.2.0 // indirect
-github.com/richardlehane/mscfb v1.0.4 // indirect
 github.com/richardlehane/msoleps v1.0.3 // indirect
 github.com/stretchr/testify v1.11.1 // indirect
 github.com/xuri/efp v0.0.0-20231025114914-d1ff6096ae53 // indirect
@@ -50,7 +56,4 @@ github.com/xuri/nfp v0.0.0-20230919160717-d98342af3f05 // indirect
 go.etcd.io/bbolt v1.3.7 // indirect
 go.uber.org/multierr v1.10.0 // indirect
 golang.org/x/crypto v0.19.0 // indirect
-golang.org/x/net v0.21.0 // indirect
-golang.org/x/sys v0.37.0 // indirect
-golang.org/x/text v0.14.0 // indirect
 )
\ No newline at end of file
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// WriteAnalyticsReport writes a search analytics report to w, as JSON with OutputJSON and
+// as totals followed by the top and zero-result queries and the most opened documents
+// otherwise.
+func WriteAnalyticsReport(w io.Writer, report *models.AnalyticsReport, format SearchOutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	fmt.Fprintf(w, "Searches:         %d\n", report.Searches)
+	fmt.Fprintf(w, "Zero results:     %d%s\n", report.ZeroResultSearches, percentOf(report.ZeroResultSearches, report.Searches))
+	fmt.Fprintf(w, "Clicked:          %d%s\n", report.ClickedSearches, percentOf(report.ClickedSearches, report.Searches))
+	fmt.Fprintf(w, "Average latency:  %.0f ms\n", report.AvgLatencyMs)
+	fmt.Fprintf(w, "Opens:            %d\n", report.Opens)
+	writeQueryStats(w, "Top queries:", report.TopQueries)
+	writeQueryStats(w, "Zero-result queries:", report.ZeroResultQueries)
+	if len(report.TopOpened) > 0 {
+		fmt.Fprintln(w, "Most opened documents:")
+		for _, d := range report.TopOpened {
+			fmt.Fprintf(w, "  %5d  %s  (%d queries, last %s)\n",
+				d.Opens, SanitizeForLine(d.DocumentID), d.Queries, d.LastOpened.Local().Format("2006-01-02 15:04"))
+		}
+	}
+	return nil
+}
+
+// writeQueryStats writes a heading and one line per query, or nothing without queries.
+func writeQueryStats(w io.Writer, heading string, stats []*models.QueryStats) {
+	if len(stats) == 0 {
+		return
+	}
+	fmt.Fprintln(w, heading)
+	for _, q := range stats {
+		fmt.Fprintf(w, "  %5d  %q  (avg %.1f results, %d clicks, %.0f ms)\n",
+			q.Count, SanitizeForLine(q.Query), q.AvgResults, q.Clicks, q.AvgLatencyMs)
+	}
+}
+
+// percentOf formats n as a parenthesised percentage of total, or "" when total is 0.
+func percentOf(n, total int) string {
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%.1f%%)", float64(n)*100/float64(total))
+}
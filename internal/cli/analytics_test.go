@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestWriteAnalyticsReport(t *testing.T) {
+	report := &models.AnalyticsReport{
+		Searches:           4,
+		ZeroResultSearches: 1,
+		ClickedSearches:    2,
+		AvgLatencyMs:       12.5,
+		TopQueries: []*models.QueryStats{
+			{Query: "budget", Count: 3, AvgResults: 2, Clicks: 2, AvgLatencyMs: 10},
+			{Query: "forecats", Count: 1, AvgLatencyMs: 20},
+		},
+		ZeroResultQueries: []*models.QueryStats{{Query: "forecats", Count: 1, AvgLatencyMs: 20}},
+		Opens:             3,
+		TopOpened:         []*models.DocumentOpenStats{{DocumentID: "doc-budget", Opens: 2, Queries: 1, LastOpened: time.Now()}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAnalyticsReport(&buf, report, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"Searches:         4", "Zero results:     1 (25.0%)", "Clicked:          2 (50.0%)", "Top queries:", `"budget"`, "Zero-result queries:", "Opens:            3", "Most opened documents:", "doc-budget  (1 queries"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteAnalyticsReport(&buf, &models.AnalyticsReport{}, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "%") || strings.Contains(buf.String(), "queries:") || strings.Contains(buf.String(), "documents:") {
+		t.Errorf("an empty report should have no percentages or query lists:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteAnalyticsReport(&buf, report, OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"zero_result_queries"`) {
+		t.Errorf("JSON output: %s", buf.String())
+	}
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// auditColumns is the CSV header of an audit log export.
+var auditColumns = []string{"id", "time", "client", "api_key", "action", "query", "document_id", "results", "status"}
+
+// WriteAuditLog writes audit entries to w. OutputJSON writes the response as JSON,
+// OutputCSV one row per entry after a header row, and other formats one line per entry.
+func WriteAuditLog(w io.Writer, response *models.AuditResponse, format SearchOutputFormat) error {
+	switch format {
+	case OutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(response)
+	case OutputCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write(auditColumns)
+		for _, e := range response.Entries {
+			_ = cw.Write([]string{
+				strconv.FormatInt(e.ID, 10), e.Time.UTC().Format(time.RFC3339Nano), e.Client, e.APIKey,
+				e.Action, e.Query, e.DocumentID, strconv.Itoa(e.Results), strconv.Itoa(e.Status),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	fmt.Fprintf(w, "%d audit entries\n", response.Total)
+	for _, e := range response.Entries {
+		who := e.Client
+		if e.APIKey != "" {
+			who += " (" + e.APIKey + ")"
+		}
+		what := e.DocumentID
+		if e.Action == models.AuditSearch || e.Action == models.AuditAsk {
+			what = fmt.Sprintf("%q, %d results", SanitizeForLine(e.Query), e.Results)
+		}
+		fmt.Fprintf(w, "%s  %s  %s %s  [%d]\n", e.Time.Local().Format("2006-01-02 15:04:05"), who, e.Action, what, e.Status)
+	}
+	return nil
+}
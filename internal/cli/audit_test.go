@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestWriteAuditLog(t *testing.T) {
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+	response := &models.AuditResponse{
+		Entries: []*models.AuditEntry{
+			{ID: 1, Time: at, Client: "10.0.0.5", APIKey: "ci", Action: models.AuditSearch, Query: "salary, bands", Results: 3, Status: 200},
+			{ID: 2, Time: at.Add(time.Minute), Client: "10.0.0.5", Action: models.AuditDocumentGet, DocumentID: "doc-1", Status: 404},
+		},
+		Total: 2,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteAuditLog(&buf, response, OutputCSV); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 3 || strings.Join(rows[0], ",") != strings.Join(auditColumns, ",") {
+		t.Fatalf("rows = %q", rows)
+	}
+	want := []string{"1", "2026-03-01T09:30:00Z", "10.0.0.5", "ci", "search", "salary, bands", "", "3", "200"}
+	if strings.Join(rows[1], "|") != strings.Join(want, "|") {
+		t.Errorf("row 1 = %q, want %q", rows[1], want)
+	}
+
+	buf.Reset()
+	if err := WriteAuditLog(&buf, response, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	text := buf.String()
+	for _, s := range []string{"2 audit entries", `10.0.0.5 (ci)  search "salary, bands", 3 results  [200]`, "document.get doc-1  [404]"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("text output missing %q:\n%s", s, text)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// WriteResultsDiff writes a comparison of search results to w, as JSON with OutputJSON
+// and otherwise as a summary line followed by the documents that entered, left, and
+// moved, each with its list and rank.
+func WriteResultsDiff(w io.Writer, diff *models.ResultsDiff, format SearchOutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(diff)
+	}
+	baseline := fmt.Sprintf("compared with %q", SanitizeForLine(diff.Against))
+	if diff.BaselineTime != nil {
+		baseline = "since the search recorded " + diff.BaselineTime.Local().Format("2006-01-02 15:04")
+	}
+	fmt.Fprintf(w, "%q: %d entered, %d left, %d moved, %d unchanged in the top %d, %s\n",
+		SanitizeForLine(diff.Query), len(diff.Entered), len(diff.Left), len(diff.Moved), diff.Unchanged, diff.Limit, baseline)
+	writeResultChanges(w, "Entered:", "+", diff.Entered)
+	writeResultChanges(w, "Left:", "-", diff.Left)
+	writeResultChanges(w, "Moved:", "~", diff.Moved)
+	return nil
+}
+
+// writeResultChanges writes a heading and one line per change, or nothing without changes.
+func writeResultChanges(w io.Writer, heading, mark string, changes []*models.ResultChange) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintln(w, heading)
+	for _, c := range changes {
+		var place string
+		switch {
+		case c.After == nil:
+			place = formatResultRef(c.Before)
+		case c.Before == nil:
+			place = formatResultRef(c.After)
+		default:
+			place = formatResultRef(c.Before) + " -> " + formatResultRef(c.After)
+		}
+		name := c.Path
+		if name == "" {
+			name = SanitizeForLine(c.Title)
+		}
+		if name == "" {
+			name = c.DocumentID
+		}
+		if c.Deleted {
+			name += " (no longer indexed)"
+		}
+		fmt.Fprintf(w, "  %s %-14s  %s\n", mark, place, name)
+	}
+}
+
+// formatResultRef formats a result place as "keyword #3".
+func formatResultRef(ref *models.ResultRef) string {
+	return fmt.Sprintf("%s #%d", ref.List, ref.Rank)
+}
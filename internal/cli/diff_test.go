@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestWriteResultsDiff(t *testing.T) {
+	recorded := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	diff := &models.ResultsDiff{
+		Query: "budget", BaselineTime: &recorded, Limit: 10, Unchanged: 4,
+		Entered: []*models.ResultChange{{DocumentID: "d1", Path: "/docs/budget-2027.xlsx",
+			After: &models.ResultRef{DocumentID: "d1", List: models.ResultListKeyword, Rank: 1}}},
+		Left: []*models.ResultChange{{DocumentID: "d2", Deleted: true,
+			Before: &models.ResultRef{DocumentID: "d2", List: models.ResultListSemantic, Rank: 3}}},
+		Moved: []*models.ResultChange{{DocumentID: "d3", Title: "Plan",
+			Before: &models.ResultRef{DocumentID: "d3", List: models.ResultListKeyword, Rank: 1},
+			After:  &models.ResultRef{DocumentID: "d3", List: models.ResultListKeyword, Rank: 2}}},
+	}
+	var buf bytes.Buffer
+	if err := WriteResultsDiff(&buf, diff, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, sub := range []string{
+		`"budget": 1 entered, 1 left, 1 moved, 4 unchanged in the top 10, since the search recorded 2026-03-01 12:00`,
+		"+ keyword #1", "/docs/budget-2027.xlsx",
+		"- semantic #3", "d2 (no longer indexed)",
+		"~ keyword #1 -> keyword #2", "Plan",
+	} {
+		if !strings.Contains(out, sub) {
+			t.Errorf("text output missing %q:\n%s", sub, out)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteResultsDiff(&buf, diff, OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded models.ResultsDiff
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Moved) != 1 || decoded.Moved[0].Before.Rank != 1 {
+		t.Errorf("json output: %v %s", err, buf.String())
+	}
+}
@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// WriteDuplicates writes groups of duplicate documents to w. OutputJSON writes the response
+// as JSON; other formats write each group's heading followed by one line per file with its
+// size and path.
+func WriteDuplicates(w io.Writer, response *models.DuplicatesResponse, format SearchOutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(response)
+	}
+	var reclaimable int64
+	for _, g := range response.Groups {
+		reclaimable += g.ReclaimableBytes
+	}
+	fmt.Fprintf(w, "%d duplicate groups (fingerprints within %d bits)", response.Total, response.MaxDistance)
+	if len(response.Groups) > 0 {
+		fmt.Fprintf(w, ", %s reclaimable in those shown", FormatSize(reclaimable))
+	}
+	fmt.Fprintln(w)
+	for i, g := range response.Groups {
+		kind := "near-identical"
+		if g.Identical {
+			kind = "identical"
+		}
+		fmt.Fprintf(w, "\n#%d %s, %d files, %s reclaimable\n", response.Offset+i+1, kind, len(g.Files), FormatSize(g.ReclaimableBytes))
+		for _, f := range g.Files {
+			name := f.Path
+			if name == "" {
+				name = SanitizeForLine(f.Title)
+			}
+			if name == "" {
+				name = f.DocumentID
+			}
+			fmt.Fprintf(w, "  %9s  %s\n", FormatSize(f.Size), name)
+		}
+	}
+	return nil
+}
+
+// FormatSize formats a byte count with a binary unit, e.g. "1.5 MiB".
+func FormatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
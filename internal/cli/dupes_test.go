@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestWriteDuplicates(t *testing.T) {
+	response := &models.DuplicatesResponse{
+		Total: 1, Limit: 100, MaxDistance: 3,
+		Groups: []*models.DuplicateGroup{{
+			Identical:        true,
+			ReclaimableBytes: 2048,
+			Files: []*models.DuplicateFile{
+				{DocumentID: "a", Path: "/backup/report.pdf", Size: 2048},
+				{DocumentID: "b", Title: "Report", Size: 2048},
+			},
+		}},
+	}
+	var buf bytes.Buffer
+	if err := WriteDuplicates(&buf, response, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, sub := range []string{"1 duplicate groups", "within 3 bits", "#1 identical, 2 files, 2.0 KiB reclaimable", "2.0 KiB  /backup/report.pdf", "  Report"} {
+		if !strings.Contains(out, sub) {
+			t.Errorf("text output missing %q:\n%s", sub, out)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteDuplicates(&buf, response, OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded models.DuplicatesResponse
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.Groups) != 1 || len(decoded.Groups[0].Files) != 2 {
+		t.Errorf("json output: %v %s", err, buf.String())
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{0: "0 B", 1023: "1023 B", 1024: "1.0 KiB", 1536: "1.5 KiB", 5 << 20: "5.0 MiB", 3 << 30: "3.0 GiB"} {
+		if got := FormatSize(n); got != want {
+			t.Errorf("FormatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
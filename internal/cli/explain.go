@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// WriteQueryExplanation writes how a query is parsed to w. OutputJSON writes exp as JSON;
+// other formats write one labelled line per part, skipping parts the query does not have.
+func WriteQueryExplanation(w io.Writer, exp *models.QueryExplanation, format SearchOutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(exp)
+	}
+	line := func(label, value string) {
+		fmt.Fprintf(w, "%-15s%s\n", label+":", value)
+	}
+	list := func(label string, values []string) {
+		if len(values) > 0 {
+			line(label, strings.Join(values, ", "))
+		}
+	}
+
+	line("Query", exp.Query)
+	switch {
+	case exp.Mode == models.QueryModeLiteral:
+		line("Syntax", "literal string")
+	case exp.Mode != "":
+		line("Syntax", exp.Mode+" pattern")
+	case exp.Boolean && len(exp.Operators) > 0:
+		line("Syntax", "boolean ("+strings.Join(exp.Operators, ", ")+")")
+	case exp.Boolean:
+		line("Syntax", "boolean")
+	default:
+		line("Syntax", "plain (any term may match; documents with more terms rank higher)")
+	}
+	list("Terms", exp.Terms)
+	phrases := make([]string, len(exp.Phrases))
+	for i, p := range exp.Phrases {
+		phrases[i] = `"` + p + `"`
+	}
+	list("Phrases", phrases)
+	list("Near", exp.Near)
+	list("Excluded", exp.Negations)
+	filters := make([]string, len(exp.Filters))
+	for i, f := range exp.Filters {
+		filters[i] = f.Field + ":" + f.Value
+		if f.Exclude {
+			filters[i] = "-" + filters[i]
+		}
+	}
+	list("Filters", filters)
+	list("Fields", exp.Fields)
+
+	if exp.KeywordText != "" {
+		line("Keyword text", exp.KeywordText)
+	} else {
+		line("Keyword text", "(none: keyword search skipped)")
+	}
+	if exp.SemanticText != "" {
+		line("Semantic text", exp.SemanticText)
+	} else {
+		line("Semantic text", "(none: semantic search skipped)")
+	}
+
+	if !exp.Fuzzy {
+		line("Fuzzy", "off (use --fuzzy for typo tolerance)")
+	} else if len(exp.FuzzyExpansions) == 0 {
+		line("Fuzzy", "on (no similar indexed terms)")
+	} else {
+		terms := make([]string, 0, len(exp.FuzzyExpansions))
+		for term := range exp.FuzzyExpansions {
+			terms = append(terms, term)
+		}
+		sort.Strings(terms)
+		expansions := make([]string, len(terms))
+		for i, term := range terms {
+			expansions[i] = term + " → " + strings.Join(exp.FuzzyExpansions[term], ", ")
+		}
+		line("Fuzzy", "on; "+strings.Join(expansions, "; "))
+	}
+	list("Not indexed", exp.Misspelled)
+	if exp.CorrectedQuery != "" {
+		line("Did you mean", exp.CorrectedQuery)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestWriteQueryExplanation(t *testing.T) {
+	exp := &models.QueryExplanation{
+		Query:           `propodal -draft ext:pdf`,
+		Boolean:         true,
+		Operators:       []string{"NOT"},
+		Terms:           []string{"propodal"},
+		Negations:       []string{"draft"},
+		Filters:         []models.QueryFilter{{Field: "ext", Value: "pdf"}, {Field: "path", Value: "archive", Exclude: true}},
+		KeywordText:     "propodal -draft",
+		Fuzzy:           true,
+		FuzzyExpansions: map[string][]string{"propodal": {"proposal", "proposals"}},
+		Misspelled:      []string{"propodal"},
+		CorrectedQuery:  "proposal",
+	}
+	var buf bytes.Buffer
+	if err := WriteQueryExplanation(&buf, exp, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"Syntax:        boolean (NOT)\n",
+		"Terms:         propodal\n",
+		"Excluded:      draft\n",
+		"Filters:       ext:pdf, -path:archive\n",
+		"Semantic text: (none: semantic search skipped)\n",
+		"Fuzzy:         on; propodal → proposal, proposals\n",
+		"Did you mean:  proposal\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Phrases:") {
+		t.Errorf("empty parts should be skipped:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := WriteQueryExplanation(&buf, exp, OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded models.QueryExplanation
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.CorrectedQuery != "proposal" {
+		t.Errorf("json: %v, %+v", err, decoded)
+	}
+}
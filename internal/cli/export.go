@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// ResultFilePaths returns the source file paths of the results in response, fused results
+// or keyword results first, without duplicates. Results without a source file are skipped.
+func ResultFilePaths(response *models.SearchResponse) []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, result := range response.AllResults() {
+		path := DocumentFilePath(result.Document)
+		if path == "" || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// ExportLinks creates dir (if needed) and a symlink in it to each file in paths. Links are
+// named by rank and base name (e.g. "01-report.pdf") so they sort in result order and files
+// with the same name do not collide.
+func ExportLinks(dir string, paths []string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create export dir: %w", err)
+	}
+	width := len(fmt.Sprint(len(paths)))
+	if width < 2 {
+		width = 2
+	}
+	for i, path := range paths {
+		name := fmt.Sprintf("%0*d-%s", width, i+1, filepath.Base(path))
+		if err := os.Symlink(path, filepath.Join(dir, name)); err != nil {
+			return fmt.Errorf("link %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// ExportList writes paths to file, one per line, for tools such as xargs or zip -@.
+func ExportList(file string, paths []string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("create export list: %w", err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, path := range paths {
+		fmt.Fprintln(w, path)
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write export list: %w", err)
+	}
+	return nil
+}
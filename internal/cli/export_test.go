@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func resultAt(path string) *models.SearchResult {
+	doc := &models.Document{Metadata: map[string]interface{}{}}
+	if path != "" {
+		doc.Metadata["source_path"] = path
+	}
+	return &models.SearchResult{Document: doc}
+}
+
+func TestExportLinksAndList(t *testing.T) {
+	src := t.TempDir()
+	a := filepath.Join(src, "a", "report.txt")
+	b := filepath.Join(src, "b", "report.txt")
+	for _, p := range []string{a, b} {
+		_ = os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(p), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	response := &models.SearchResponse{
+		NonSemanticResults: []*models.SearchResult{resultAt(a), resultAt("")},
+		SemanticResults:    []*models.SearchResult{resultAt(b), resultAt(a)},
+	}
+	paths := ResultFilePaths(response)
+	if len(paths) != 2 || paths[0] != a || paths[1] != b {
+		t.Fatalf("ResultFilePaths = %v, want [%s %s]", paths, a, b)
+	}
+
+	dir := filepath.Join(t.TempDir(), "links")
+	if err := ExportLinks(dir, paths); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"01-report.txt": a, "02-report.txt": b} {
+		target, err := os.Readlink(filepath.Join(dir, name))
+		if err != nil || target != want {
+			t.Errorf("link %s -> %q (%v), want %q", name, target, err, want)
+		}
+	}
+
+	list := filepath.Join(t.TempDir(), "results.txt")
+	if err := ExportList(list, paths); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(list)
+	if string(data) != a+"\n"+b+"\n" {
+		t.Errorf("list = %q", data)
+	}
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// WriteFsckReport writes a consistency check report as text or JSON. The text lists each
+// kind of problem found with its first items, and what a repair fixed.
+func WriteFsckReport(w io.Writer, report *models.FsckReport, format SearchOutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	fmt.Fprintf(w, "Documents:        %d (keyword index: %d)\n", report.Documents, report.KeywordDocuments)
+	fmt.Fprintf(w, "Chunks:           %d (vectors: %d)\n", report.Chunks, report.Vectors)
+	for _, f := range []struct {
+		name    string
+		finding models.FsckFinding
+	}{
+		{"orphaned chunks (document gone)", report.OrphanChunks},
+		{"orphaned vectors (chunk gone)", report.OrphanVectors},
+		{"chunks without a vector", report.MissingVectors},
+		{"orphaned keyword entries (document gone)", report.OrphanKeywordDocuments},
+		{"documents missing from the keyword index", report.MissingKeywordDocuments},
+		{"documents whose source file is gone", report.MissingSources},
+	} {
+		if f.finding.Count == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%d %s", f.finding.Count, f.name)
+		if report.Repaired {
+			fmt.Fprintf(w, ", %d fixed", f.finding.Fixed)
+		}
+		fmt.Fprintln(w)
+		for _, item := range f.finding.Items {
+			fmt.Fprintf(w, "  %s\n", SanitizeForLine(item))
+		}
+		if more := f.finding.Count - len(f.finding.Items); more > 0 {
+			fmt.Fprintf(w, "  ... and %d more\n", more)
+		}
+	}
+	for _, s := range report.Skipped {
+		fmt.Fprintf(w, "Skipped: %s\n", s)
+	}
+	switch {
+	case report.Problems() == 0:
+		fmt.Fprintln(w, "OK: no problems found")
+	case !report.Repaired:
+		fmt.Fprintln(w, "Run with --repair to fix them.")
+	}
+	return nil
+}
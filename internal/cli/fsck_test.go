@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestWriteFsckReport(t *testing.T) {
+	report := &models.FsckReport{
+		Documents: 3, KeywordDocuments: 4, Chunks: 9, Vectors: 8,
+		MissingVectors:         models.FsckFinding{Count: 1, Items: []string{"d1_0"}},
+		OrphanKeywordDocuments: models.FsckFinding{Count: 25, Items: []string{"gone"}},
+		Skipped:                []string{"orphan chunks: the storage cannot look for them"},
+	}
+	var buf bytes.Buffer
+	if err := WriteFsckReport(&buf, report, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"1 chunks without a vector\n  d1_0", "25 orphaned keyword entries", "... and 24 more", "Skipped: orphan chunks", "--repair"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("text output missing %q:\n%s", want, out)
+		}
+	}
+
+	report.Repaired = true
+	report.MissingVectors.Fixed = 1
+	buf.Reset()
+	if err := WriteFsckReport(&buf, report, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "1 chunks without a vector, 1 fixed") || strings.Contains(out, "--repair") {
+		t.Errorf("repaired report:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := WriteFsckReport(&buf, &models.FsckReport{}, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "OK: no problems found") {
+		t.Errorf("healthy report: %s", buf.String())
+	}
+}
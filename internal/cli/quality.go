@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// WriteQualityReport writes an index-quality report to w, as JSON with OutputJSON and as a
+// summary followed by the warnings otherwise.
+func WriteQualityReport(w io.Writer, report *models.QualityReport, format SearchOutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	fmt.Fprintf(w, "Documents:        %d (keyword index: %d)\n", report.Documents, report.KeywordDocuments)
+	fmt.Fprintf(w, "Chunks:           %d (vectors: %d)\n", report.Chunks, report.Vectors)
+	fmt.Fprintf(w, "Sampled chunks:   %d (compared: %d, missing vectors: %d)\n", report.Sampled, report.Compared, report.MissingVectors)
+	fmt.Fprintf(w, "Embedding drift:  mean %.4f, max %.4f\n", report.MeanDrift, report.MaxDrift)
+	fmt.Fprintf(w, "Self recall:      %.1f%%\n", report.SelfRecall*100)
+	if len(report.Worst) > 0 && report.MaxDrift > 0 {
+		fmt.Fprintln(w, "Most drifted:")
+		for _, d := range report.Worst {
+			fmt.Fprintf(w, "  %.4f  %s (%s)\n", d.Drift, SanitizeForLine(d.Title), d.ChunkID)
+		}
+	}
+	if len(report.Warnings) == 0 {
+		fmt.Fprintln(w, "OK: no problems found")
+		return nil
+	}
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(w, "WARNING: %s\n", warning)
+	}
+	return nil
+}
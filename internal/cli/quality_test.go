@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestWriteQualityReport(t *testing.T) {
+	report := &models.QualityReport{
+		Documents: 3, KeywordDocuments: 3, Chunks: 9, Vectors: 9,
+		Sampled: 9, Compared: 9, MeanDrift: 0.2, MaxDrift: 0.4, SelfRecall: 0.5,
+		Worst:    []*models.ChunkDrift{{ChunkID: "d1_0", DocumentID: "d1", Title: "Notes", Drift: 0.4}},
+		Warnings: []string{"stored embeddings differ from the current model"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteQualityReport(&buf, report, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"mean 0.2000, max 0.4000", "Self recall:      50.0%", "0.4000  Notes (d1_0)", "WARNING: stored embeddings differ"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("text output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteQualityReport(&buf, &models.QualityReport{Warnings: []string{}}, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "OK: no problems found") {
+		t.Errorf("healthy report: %s", buf.String())
+	}
+
+	buf.Reset()
+	if err := WriteQualityReport(&buf, report, OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded models.QualityReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.MeanDrift != 0.2 {
+		t.Errorf("json output = %s (%v)", buf.String(), err)
+	}
+}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// WriteScanReport writes a directory scan to w, as JSON with OutputJSON and otherwise as
+// totals followed by one line per extension with its file count, size, and whether
+// indexing would take or skip it, then the opted-out directories and skipped files.
+func WriteScanReport(w io.Writer, report *models.ScanReport, format SearchOutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	allowed := "all extensions"
+	if len(report.Extensions) > 0 {
+		allowed = strings.Join(report.Extensions, " ")
+	}
+	fmt.Fprintf(w, "%s: %d files, %s\n", report.Root, report.Files, FormatSize(report.Bytes))
+	fmt.Fprintf(w, "Indexed:  %d files, %s (%s)\n", report.IncludedFiles, FormatSize(report.IncludedBytes), allowed)
+	fmt.Fprintf(w, "Skipped:  %d files, %s\n", report.SkippedFiles, FormatSize(report.SkippedBytes))
+	if len(report.ByExtension) > 0 {
+		fmt.Fprintf(w, "\n%-12s %8s %10s\n", "Extension", "Files", "Size")
+		for _, e := range report.ByExtension {
+			ext := e.Extension
+			if ext == "" {
+				ext = "(none)"
+			}
+			fmt.Fprintf(w, "%-12s %8d %10s  %s\n", ext, e.Files, FormatSize(e.Bytes), extensionState(e))
+		}
+	}
+	if len(report.OptedOut) > 0 {
+		fmt.Fprintln(w, "\nOpted out (not scanned):")
+		for _, dir := range report.OptedOut {
+			fmt.Fprintf(w, "  %s\n", dir)
+		}
+	}
+	if len(report.Skipped) > 0 {
+		fmt.Fprintln(w, "\nSkipped files:")
+		for _, path := range report.Skipped {
+			fmt.Fprintf(w, "  %s\n", path)
+		}
+	}
+	return nil
+}
+
+// extensionState says whether files of an extension would be indexed, and how.
+func extensionState(e *models.ExtensionStats) string {
+	switch {
+	case e.Included && e.KnownFormat:
+		return "indexed"
+	case e.Included:
+		return "indexed as plain text"
+	case e.KnownFormat:
+		return "skipped (supported format)"
+	default:
+		return "skipped"
+	}
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestWriteScanReport(t *testing.T) {
+	report := &models.ScanReport{
+		Root: "/docs", Extensions: []string{".txt", ".md"},
+		Files: 4, Bytes: 4096, IncludedFiles: 1, IncludedBytes: 1024, SkippedFiles: 3, SkippedBytes: 3072,
+		ByExtension: []*models.ExtensionStats{
+			{Extension: ".pdf", Files: 1, Bytes: 2048, KnownFormat: true},
+			{Extension: ".txt", Files: 1, Bytes: 1024, Included: true, KnownFormat: true},
+			{Extension: "", Files: 2, Bytes: 1024},
+		},
+		OptedOut: []string{"/docs/private"},
+		Skipped:  []string{"/docs/report.pdf"},
+	}
+	var buf bytes.Buffer
+	if err := WriteScanReport(&buf, report, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, sub := range []string{
+		"/docs: 4 files, 4.0 KiB", "Indexed:  1 files, 1.0 KiB (.txt .md)", "Skipped:  3 files, 3.0 KiB",
+		"skipped (supported format)", "(none)", "Opted out (not scanned):\n  /docs/private", "Skipped files:\n  /docs/report.pdf",
+	} {
+		if !strings.Contains(out, sub) {
+			t.Errorf("text output missing %q:\n%s", sub, out)
+		}
+	}
+
+	buf.Reset()
+	if err := WriteScanReport(&buf, report, OutputJSON); err != nil {
+		t.Fatal(err)
+	}
+	var decoded models.ScanReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || len(decoded.ByExtension) != 3 || !decoded.ByExtension[1].Included {
+		t.Errorf("json output: %v %s", err, buf.String())
+	}
+}
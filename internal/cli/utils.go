@@ -21,6 +21,8 @@ const (
 	OutputCompact SearchOutputFormat = "compact"
 	// OutputJSON is structured JSON for machine consumption.
 	OutputJSON SearchOutputFormat = "json"
+	// OutputCSV is comma-separated values with a header row (audit log export).
+	OutputCSV SearchOutputFormat = "csv"
 )
 
 // WriteSearchResults writes search results to w in the given format.
@@ -44,14 +46,25 @@ func writeSearchResultsText(w io.Writer, response *models.SearchResponse) {
 	total := response.TotalNonSemantic + response.TotalSemantic
 	fmt.Fprintf(w, "\nFound %d results in %dms (%d keyword-only, %d semantic-only)\n\n",
 		total, response.QueryTime, response.TotalNonSemantic, response.TotalSemantic)
-	// Show auto-fuzzy notice and suggestions
+	// Show auto-fuzzy or stemmed notice and suggestions
 	if response.AutoFuzzy && len(response.Suggestions) > 0 {
 		fmt.Fprintf(w, "No exact matches found. Showing results for %q instead.\n\n", response.Suggestions[0])
 	} else if response.AutoFuzzy {
 		fmt.Fprintln(w, "No exact matches found. Showing fuzzy results instead.")
+	} else if response.Stemmed {
+		fmt.Fprintln(w, "No exact matches found. Showing results for other forms of the words.")
 	} else if len(response.Suggestions) > 0 {
 		fmt.Fprintf(w, "Did you mean: %s?\n\n", strings.Join(response.Suggestions, ", "))
 	}
+	if len(response.TimedOut) > 0 {
+		fmt.Fprintf(w, "Partial results: %s search timed out.\n\n", strings.Join(response.TimedOut, " and "))
+	}
+	if len(response.Results) > 0 {
+		fmt.Fprintf(w, "--- Results (%s fusion) ---\n", response.Fusion)
+		for _, result := range response.Results {
+			writeOneResult(w, result, response.Fusion)
+		}
+	}
 	if len(response.NonSemanticResults) > 0 {
 		fmt.Fprintln(w, "--- Non-semantic (keyword) results ---")
 		for _, result := range response.NonSemanticResults {
@@ -68,12 +81,22 @@ func writeSearchResultsText(w io.Writer, response *models.SearchResponse) {
 
 func writeOneResult(w io.Writer, result *models.SearchResult, source string) {
 	fmt.Fprintf(w, "─────────────────────────────────────────────────────────\n")
-	fmt.Fprintf(w, "[%s] Rank: %d | Score: %.4f (Keyword: %.4f, Semantic: %.4f)\n",
-		source, result.Rank, result.Score, result.KeywordScore, result.SemanticScore)
+	fmt.Fprintf(w, "[%s] Rank: %d | Score: %.4f (Keyword: %.4f, Semantic: %.4f) | Confidence: %.2f\n",
+		source, result.Rank, result.Score, result.KeywordScore, result.SemanticScore, result.Confidence)
 	fmt.Fprintf(w, "ID: %s\n", result.Document.ID)
 	if result.Document.Title != "" {
 		fmt.Fprintf(w, "Title: %s\n", result.Document.Title)
 	}
+	for _, path := range result.AlsoFoundAt {
+		fmt.Fprintf(w, "Also found at: %s\n", path)
+	}
+	if result.KeywordChunk != nil {
+		fmt.Fprintf(w, "Keyword match: chunk %d\n", *result.KeywordChunk)
+	}
+	for _, c := range result.Chunks {
+		fmt.Fprintf(w, "Chunk %d (characters %d-%d, score %.2f): %s\n",
+			c.ChunkIndex, c.Start, c.End, c.Score, Truncate(SanitizeForLine(c.Content), 100))
+	}
 	fmt.Fprintf(w, "\n%s\n", Truncate(result.Document.Content, 200))
 	fmt.Fprintln(w)
 }
@@ -82,14 +105,22 @@ func writeOneResult(w io.Writer, result *models.SearchResult, source string) {
 func writeSearchResultsCompact(w io.Writer, response *models.SearchResponse) {
 	total := response.TotalNonSemantic + response.TotalSemantic
 	fmt.Fprintf(w, "Found %d results in %dms\n", total, response.QueryTime)
-	// Show auto-fuzzy notice and suggestions
+	// Show auto-fuzzy or stemmed notice and suggestions
 	if response.AutoFuzzy && len(response.Suggestions) > 0 {
 		fmt.Fprintf(w, "No exact matches found. Showing results for %q instead.\n", response.Suggestions[0])
 	} else if response.AutoFuzzy {
 		fmt.Fprintln(w, "No exact matches found. Showing fuzzy results instead.")
+	} else if response.Stemmed {
+		fmt.Fprintln(w, "No exact matches found. Showing results for other forms of the words.")
 	} else if len(response.Suggestions) > 0 {
 		fmt.Fprintf(w, "Did you mean: %s?\n", strings.Join(response.Suggestions, ", "))
 	}
+	if len(response.TimedOut) > 0 {
+		fmt.Fprintf(w, "Partial results: %s search timed out.\n", strings.Join(response.TimedOut, " and "))
+	}
+	for _, result := range response.Results {
+		writeOneResultCompact(w, result, response.Fusion)
+	}
 	for _, result := range response.NonSemanticResults {
 		writeOneResultCompact(w, result, "keyword")
 	}
@@ -109,6 +140,32 @@ func writeOneResultCompact(w io.Writer, result *models.SearchResult, source stri
 	fmt.Fprintf(w, "[%s] #%d %.4f | %s\n", source, result.Rank, result.Score, path)
 }
 
+// WriteRecentDocuments writes a recent-documents listing to w. OutputJSON writes the
+// response as JSON; other formats write one document per line, newest first.
+func WriteRecentDocuments(w io.Writer, response *models.RecentResponse, format SearchOutputFormat) error {
+	if format == OutputJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(response)
+	}
+	where := ""
+	if response.PathPrefix != "" {
+		where = fmt.Sprintf(" under %s", response.PathPrefix)
+	}
+	fmt.Fprintf(w, "%d documents modified in the last %d days%s\n", response.Total, response.Days, where)
+	for _, doc := range response.Documents {
+		name := doc.Path
+		if name == "" {
+			name = SanitizeForLine(doc.Title)
+		}
+		if name == "" {
+			name = doc.ID
+		}
+		fmt.Fprintf(w, "%s  %s\n", doc.ModifiedAt.Local().Format("2006-01-02 15:04"), name)
+	}
+	return nil
+}
+
 // DocumentFilePath returns the stored file path from document metadata (source_path), or empty if not set.
 func DocumentFilePath(doc *models.Document) string {
 	if doc == nil || doc.Metadata == nil {
@@ -99,6 +99,7 @@ func TestWriteSearchResults_text(t *testing.T) {
 					Title:   "Title One",
 					Content: "Short content",
 				},
+				AlsoFoundAt: []string{"/backup/one.txt"},
 			},
 		},
 		SemanticResults: nil,
@@ -109,7 +110,7 @@ func TestWriteSearchResults_text(t *testing.T) {
 		t.Fatalf("WriteSearchResults(text): %v", err)
 	}
 	out := buf.String()
-	for _, sub := range []string{"Found 1 results", "10ms", "keyword-only", "Non-semantic", "Rank: 1", "ID: id1", "Title One", "Short content"} {
+	for _, sub := range []string{"Found 1 results", "10ms", "keyword-only", "Non-semantic", "Rank: 1", "ID: id1", "Title One", "Also found at: /backup/one.txt", "Short content"} {
 		if !strings.Contains(out, sub) {
 			t.Errorf("text output missing %q:\n%s", sub, out)
 		}
@@ -315,6 +316,19 @@ func TestWriteSearchResults_compact_empty(t *testing.T) {
 	}
 }
 
+func TestWriteSearchResults_timedOutNotice(t *testing.T) {
+	response := &models.SearchResponse{Query: "q", TimedOut: []string{"semantic"}}
+	for _, format := range []SearchOutputFormat{OutputText, OutputCompact} {
+		var buf bytes.Buffer
+		if err := WriteSearchResults(&buf, response, format); err != nil {
+			t.Fatalf("WriteSearchResults(%s): %v", format, err)
+		}
+		if !strings.Contains(buf.String(), "Partial results: semantic search timed out.") {
+			t.Errorf("%s: expected partial results notice, got %q", format, buf.String())
+		}
+	}
+}
+
 func TestWriteSearchResults_unknownFormatTreatedAsText(t *testing.T) {
 	response := &models.SearchResponse{Query: "x", QueryTime: 0}
 	var buf bytes.Buffer
@@ -425,3 +439,26 @@ func TestPrintSearchResults(t *testing.T) {
 		t.Errorf("PrintSearchResults should write to stdout; got %q", out)
 	}
 }
+
+func TestWriteRecentDocuments_text(t *testing.T) {
+	modified := time.Date(2026, 3, 4, 9, 30, 0, 0, time.Local)
+	response := &models.RecentResponse{
+		Documents: []*models.RecentDocument{
+			{ID: "a", Title: "A", Path: "/docs/a.txt", ModifiedAt: modified},
+			{ID: "b", Title: "Note\nB", ModifiedAt: modified},
+		},
+		Total:      2,
+		Days:       7,
+		PathPrefix: "/docs",
+	}
+	var buf bytes.Buffer
+	if err := WriteRecentDocuments(&buf, response, OutputText); err != nil {
+		t.Fatal(err)
+	}
+	want := "2 documents modified in the last 7 days under /docs\n" +
+		"2026-03-04 09:30  /docs/a.txt\n" +
+		"2026-03-04 09:30  Note B\n"
+	if buf.String() != want {
+		t.Errorf("got %q, want %q", buf.String(), want)
+	}
+}
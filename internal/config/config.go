@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/hyperjump/sagasu/internal/fswalk"
+	"github.com/hyperjump/sagasu/internal/pathrules"
+	"github.com/hyperjump/sagasu/internal/schedule"
 	"gopkg.in/yaml.v3"
 )
 
@@ -20,6 +23,305 @@ type Config struct {
 	Watch     WatchConfig     `yaml:"watch"`
 	Ranking   RankingConfig   `yaml:"ranking"`
 	Vector    VectorConfig    `yaml:"vector"`
+	// Keyword selects the keyword index backend.
+	Keyword KeywordConfig `yaml:"keyword,omitempty"`
+	Jobs      JobsConfig      `yaml:"jobs"`
+	// Collections override chunking, keyword analysis, and the embedding model for the
+	// documents under their root.
+	Collections []CollectionConfig `yaml:"collections,omitempty"`
+	// Languages detects the language of documents and picks keyword analyzers by it.
+	Languages LanguagesConfig `yaml:"languages,omitempty"`
+	// EmbeddingModels are embedding models besides the default one, each used for the
+	// files with its extensions (e.g. a code model for go and py files).
+	EmbeddingModels []EmbeddingModelConfig `yaml:"embedding_models,omitempty"`
+	// Retention drops documents that have not been modified for a while.
+	Retention RetentionConfig `yaml:"retention,omitempty"`
+	// Audit records searches and document fetches made through the HTTP API.
+	Audit AuditConfig `yaml:"audit,omitempty"`
+	// Analytics records each search, its result counts, latency and clicked result.
+	Analytics AnalyticsConfig `yaml:"analytics,omitempty"`
+	// LLM answers POST /api/v1/ask questions from the assembled context; without a
+	// provider the endpoint returns the context only.
+	LLM LLMConfig `yaml:"llm,omitempty"`
+	// Passwords open encrypted PDF and Office files; those no password opens are indexed
+	// by file name only.
+	Passwords []PasswordConfig `yaml:"passwords,omitempty"`
+	// Extract controls how text is extracted from PDF, Office, and OpenDocument files.
+	Extract ExtractConfig `yaml:"extract,omitempty"`
+	// Indexer guards indexing against files too large or not text.
+	Indexer IndexerConfig `yaml:"indexer,omitempty"`
+}
+
+// IndexerConfig keeps stray large or binary files (logs, disk images, databases) from
+// stalling indexing or exhausting memory during extraction.
+type IndexerConfig struct {
+	// MaxFileSizeMB skips larger files. Default 100; a negative value means no limit.
+	MaxFileSizeMB int `yaml:"max_file_size_mb,omitempty"`
+	// SkipBinary skips the files read as plain text whose start looks binary (NUL bytes,
+	// or mostly control characters and invalid UTF-8). Default true.
+	SkipBinary *bool `yaml:"skip_binary,omitempty"`
+	// Code indexes source files (.go, .py, .js, .ts, ...) as code: chunked at function and
+	// class boundaries, with their language as "code_language" metadata, and without
+	// stemming.
+	Code bool `yaml:"code,omitempty"`
+}
+
+// MaxFileSize returns the file size limit in bytes, 0 when there is none.
+func (c *IndexerConfig) MaxFileSize() int64 {
+	if c.MaxFileSizeMB <= 0 {
+		return 0
+	}
+	return int64(c.MaxFileSizeMB) << 20
+}
+
+// SkipBinaryOrDefault returns whether binary files are skipped (default true).
+func (c *IndexerConfig) SkipBinaryOrDefault() bool {
+	if c.SkipBinary != nil {
+		return *c.SkipBinary
+	}
+	return true
+}
+
+// ExtractConfig runs extraction in a resource-limited worker process, so a malformed file
+// or a zip bomb cannot take down the server, and sets what is extracted from mail and data
+// files.
+type ExtractConfig struct {
+	// Sandbox extracts each PDF, Office, OpenDocument, and mail file in its own worker process.
+	Sandbox bool `yaml:"sandbox,omitempty"`
+	// AllowNetworkFallback runs workers with the server's network, with a warning, when
+	// the kernel refuses them a network namespace of their own. Without it, files the
+	// sandbox extracts then fail to index.
+	AllowNetworkFallback bool `yaml:"allow_network_fallback,omitempty"`
+	// MemoryMB caps a worker's memory.
+	MemoryMB int `yaml:"memory_mb,omitempty"`
+	// CPUSeconds caps a worker's CPU time.
+	CPUSeconds int `yaml:"cpu_seconds,omitempty"`
+	// TimeoutSeconds is the wall-clock time after which a worker is killed.
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+	// MailAttachments indexes the text of the attachments of .eml, .msg, and .mbox mail
+	// as documents of their own, next to their message.
+	MailAttachments bool `yaml:"mail_attachments,omitempty"`
+	// MaxRows is the number of rows of a CSV or TSV file, records of a JSON Lines file,
+	// and elements of each JSON array indexed. Default 10000; a negative value means no
+	// limit.
+	MaxRows int `yaml:"max_rows,omitempty"`
+	// Transcriber transcribes audio and video files with a speech-to-text program.
+	Transcriber TranscriberConfig `yaml:"transcriber,omitempty"`
+	// Archives indexes the files inside .zip, .tar, .tar.gz, and .tgz archives.
+	Archives ArchivesConfig `yaml:"archives,omitempty"`
+	// External extracts the files with the given extensions with programs of the user's,
+	// in place of the built-in extractors.
+	External []ExternalExtractorConfig `yaml:"external,omitempty"`
+	// Converter converts legacy Office files (.doc, .xls, .ppt) with LibreOffice, or
+	// another program, before extracting them.
+	Converter ConverterConfig `yaml:"converter,omitempty"`
+}
+
+// ConverterConfig converts files to DOCX, XLSX, or PPTX before extraction.
+type ConverterConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Command is the program and its arguments, with "{input}", "{outdir}", and "{format}"
+	// standing for the file, the directory to write to, and docx, xlsx, or pptx. Default
+	// LibreOffice: soffice --headless --convert-to {format} --outdir {outdir} {input}.
+	Command []string `yaml:"command,omitempty"`
+	// Extensions are those of the files converted. Default doc, xls, and ppt.
+	Extensions []string `yaml:"extensions,omitempty"`
+	// TimeoutSeconds is the wall-clock time after which the program is killed. Default 120.
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+	// Cache keeps the converted files in <data dir>/converted, so files are converted
+	// again only when their content changes. Default true.
+	Cache *bool `yaml:"cache,omitempty"`
+}
+
+// CacheOrDefault returns whether converted files are cached (default true).
+func (c *ConverterConfig) CacheOrDefault() bool {
+	if c.Cache != nil {
+		return *c.Cache
+	}
+	return true
+}
+
+// ExternalExtractorConfig runs Command on the files with Extensions. The program reads
+// the file's content from stdin (its name is in SAGASU_FILE_NAME) and writes
+// {"text": ..., "metadata": {...}} as JSON to stdout.
+type ExternalExtractorConfig struct {
+	Extensions []string `yaml:"extensions"`
+	Command    []string `yaml:"command"`
+	// TimeoutSeconds is the wall-clock time after which the program is killed. Default 120.
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+}
+
+// ArchivesConfig indexes each file inside an archive as a document of its own, with the
+// archive's path and the file's path in it as ID, so archives are searchable without
+// unpacking them. The archive extensions (zip, tar, gz, tgz) must be watched.
+type ArchivesConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Extensions are those of the files indexed from archives. Default: the watched
+	// extensions.
+	Extensions []string `yaml:"extensions,omitempty"`
+	// MaxEntrySizeMB skips files larger than this, uncompressed. Default 20.
+	MaxEntrySizeMB int `yaml:"max_entry_size_mb,omitempty"`
+	// MaxTotalSizeMB stops reading an archive once this much was uncompressed. Default 200.
+	MaxTotalSizeMB int `yaml:"max_total_size_mb,omitempty"`
+	// MaxEntries is the number of files indexed from an archive. Default 1000.
+	MaxEntries int `yaml:"max_entries,omitempty"`
+}
+
+// TranscriberConfig runs a speech-to-text program, such as whisper.cpp, on audio and video
+// files, so recordings are searchable by what is said in them.
+type TranscriberConfig struct {
+	// Command is the program and its arguments, with "{input}" standing for the media file
+	// (passed last without it). It writes WebVTT, SRT, whisper.cpp's timed lines, or plain
+	// text to stdout. Empty disables transcription.
+	Command []string `yaml:"command,omitempty"`
+	// Extensions are those of the files transcribed. Default mp3, mp4, and wav.
+	Extensions []string `yaml:"extensions,omitempty"`
+	// TimeoutSeconds is the wall-clock time after which the program is killed. Default 3600.
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+	// Cache keeps each transcript next to its file, as <file>.transcript.vtt, so it is
+	// only transcribed again when it changes. Default true.
+	Cache *bool `yaml:"cache,omitempty"`
+}
+
+// CacheOrDefault returns whether transcripts are cached next to their files (default true).
+func (c *TranscriberConfig) CacheOrDefault() bool {
+	if c.Cache != nil {
+		return *c.Cache
+	}
+	return true
+}
+
+// PasswordConfig is a password for the encrypted files matching Pattern. A pattern
+// without a path separator matches file names (e.g. "payroll-*.xlsx"); otherwise it
+// matches whole paths, resolved like watch directories, and a trailing "/**" matches
+// every file under a directory.
+type PasswordConfig struct {
+	Pattern  string `yaml:"pattern"`
+	Password string `yaml:"password,omitempty"`
+	// PasswordEnv names an environment variable holding the password, to keep it out of the file.
+	PasswordEnv string `yaml:"password_env,omitempty"`
+}
+
+// Secret returns the password, read from PasswordEnv when Password is empty.
+func (p *PasswordConfig) Secret() string {
+	if p.Password != "" {
+		return p.Password
+	}
+	if p.PasswordEnv != "" {
+		return os.Getenv(p.PasswordEnv)
+	}
+	return ""
+}
+
+// LLMConfig selects the chat model used to answer questions.
+type LLMConfig struct {
+	// Provider is "ollama" or "openai" (any OpenAI-compatible endpoint); empty disables answers.
+	Provider string `yaml:"provider,omitempty"`
+	// BaseURL is the ollama or openai endpoint; empty uses the provider's default.
+	BaseURL string `yaml:"base_url,omitempty"`
+	Model   string `yaml:"model,omitempty"`
+	// APIKey is sent as a bearer token. For openai it defaults to $OPENAI_API_KEY.
+	APIKey string `yaml:"api_key,omitempty"`
+	// MaxTokens bounds the length of an answer; 0 leaves it to the model.
+	MaxTokens int `yaml:"max_tokens,omitempty"`
+	// TimeoutSeconds bounds each request.
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+}
+
+// AuditConfig controls the audit log of searches and document content fetches.
+type AuditConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// AnalyticsConfig controls the search analytics log reported by sagasu analytics.
+type AnalyticsConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// RetentionConfig holds the document expiry policies and how often they are enforced.
+type RetentionConfig struct {
+	// IntervalMinutes is how often the server removes expired documents.
+	IntervalMinutes int `yaml:"interval_minutes,omitempty"`
+	// Policies are checked independently; a document is removed when any policy expires it.
+	Policies []RetentionPolicyConfig `yaml:"policies,omitempty"`
+}
+
+// RetentionPolicyConfig expires the documents under Root and/or tagged Tag that have not
+// been modified in MaxAgeDays days.
+type RetentionPolicyConfig struct {
+	Root       string `yaml:"root,omitempty"`
+	Tag        string `yaml:"tag,omitempty"`
+	MaxAgeDays int    `yaml:"max_age_days"`
+}
+
+// CollectionConfig holds indexing settings for the files under Root. Zero values inherit
+// the global settings.
+type CollectionConfig struct {
+	Name         string `yaml:"name"`
+	Root         string `yaml:"root"`
+	ChunkSize    int    `yaml:"chunk_size,omitempty"`
+	ChunkOverlap int    `yaml:"chunk_overlap,omitempty"`
+	// ChunkStrategy is the chunking strategy: fixed, sentence, paragraph, or token.
+	ChunkStrategy string `yaml:"chunk_strategy,omitempty"`
+	// Analyzer is the keyword analyzer: standard, english (stemming), simple (letters only),
+	// cjk (character bigrams), or another language's stemmer such as german.
+	// When set, the collection gets its own keyword index.
+	Analyzer string `yaml:"analyzer,omitempty"`
+	// Embedding selects another embedding model. When set, the collection gets its own
+	// vector index and queries are embedded with every configured model.
+	Embedding *EmbeddingConfig `yaml:"embedding,omitempty"`
+}
+
+// LanguagesConfig holds language detection and per-language keyword analysis settings.
+type LanguagesConfig struct {
+	// Detect records the language detected in each indexed document as its "language"
+	// metadata (an ISO 639-1 code such as "de"). Default true.
+	Detect *bool `yaml:"detect,omitempty"`
+	// Analyzers maps language codes to keyword analyzers (e.g. ja: cjk, de: german). The
+	// documents in those languages that are in no collection with its own analyzer get a
+	// keyword index per analyzer. Needs Detect, unless documents carry the metadata.
+	Analyzers map[string]string `yaml:"analyzers,omitempty"`
+}
+
+// DetectOrDefault returns whether language detection is on (default true).
+func (l *LanguagesConfig) DetectOrDefault() bool {
+	if l.Detect != nil {
+		return *l.Detect
+	}
+	return true
+}
+
+// EmbeddingModelConfig is an additional embedding model for the files with the given
+// extensions. It gets its own vector index, and queries are embedded with every model.
+type EmbeddingModelConfig struct {
+	Name string `yaml:"name"`
+	// Extensions are the file extensions routed to this model, without the dot.
+	Extensions []string        `yaml:"extensions"`
+	Embedding  EmbeddingConfig `yaml:"embedding"`
+}
+
+// HasOwnIndexes reports whether the collection needs a keyword or vector index of its own.
+func (c *CollectionConfig) HasOwnIndexes() bool {
+	return c.Analyzer != "" || c.Embedding != nil
+}
+
+// CollectionFor returns the collection whose root contains path (the deepest one when
+// roots are nested), or nil when path is in no collection.
+func (c *Config) CollectionFor(path string) *CollectionConfig {
+	var best *CollectionConfig
+	for i := range c.Collections {
+		col := &c.Collections[i]
+		if isUnder(path, col.Root) && (best == nil || len(col.Root) > len(best.Root)) {
+			best = col
+		}
+	}
+	return best
+}
+
+// isUnder reports whether path is root or inside it.
+func isUnder(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
 }
 
 // WatchConfig holds directory watch settings.
@@ -27,6 +329,70 @@ type WatchConfig struct {
 	Directories []string `yaml:"directories"`
 	Extensions  []string `yaml:"extensions"`
 	Recursive   *bool    `yaml:"recursive"`
+	// IndexWindows limits background indexing of changes and directory syncs to daily
+	// windows of local time ("HH:MM-HH:MM", e.g. "02:00-06:00"); empty means any time.
+	IndexWindows []string `yaml:"index_windows,omitempty"`
+	// NoIndexMarker is the file that opts its directory, and everything beneath it, out of
+	// indexing; documents already indexed from there are removed. Default ".noindex".
+	NoIndexMarker string `yaml:"noindex_marker,omitempty"`
+	// Rules override which files are indexed under a directory; the deepest matching
+	// root wins.
+	Rules []WatchRuleConfig `yaml:"rules,omitempty"`
+	// IgnoreFiles name the .gitignore-style files honored in the watched directories and
+	// beneath them. Default [".gitignore", ".sagasuignore"]; an empty list disables them.
+	IgnoreFiles []string `yaml:"ignore_files"`
+	// GlobalIgnore is an ignore file whose patterns apply in every watched directory.
+	// Default ~/.sagasuignore; "none" disables it.
+	GlobalIgnore string `yaml:"global_ignore,omitempty"`
+	// SkipHidden skips directories whose name starts with a dot (.git, .cache, ...).
+	SkipHidden bool `yaml:"skip_hidden,omitempty"`
+	// FollowSymlinks descends into symlinked directories, walking each directory once.
+	FollowSymlinks bool `yaml:"follow_symlinks,omitempty"`
+}
+
+// WalkOptions returns how the watcher and indexer walk the watched directories.
+func (w *WatchConfig) WalkOptions() fswalk.Options {
+	return fswalk.Options{SkipHidden: w.SkipHidden, FollowSymlinks: w.FollowSymlinks}
+}
+
+// Ignore returns the ignore files for the watcher and indexer, nil when there are none.
+func (w *WatchConfig) Ignore() (*pathrules.Ignore, error) {
+	if len(w.IgnoreFiles) == 0 && w.GlobalIgnore == "" {
+		return nil, nil
+	}
+	return pathrules.NewIgnore(w.IgnoreFiles, w.GlobalIgnore)
+}
+
+// WatchRuleConfig holds the indexing rules for the files under Root, a watched directory
+// or one inside it. Unset fields keep the watch settings. Globs use "/" and "**" (e.g.
+// "**/node_modules/**"); a glob without "/" matches a file or directory name.
+type WatchRuleConfig struct {
+	Root      string `yaml:"root"`
+	Recursive *bool  `yaml:"recursive,omitempty"`
+	// Extensions replace watch.extensions under Root.
+	Extensions []string `yaml:"extensions,omitempty"`
+	// Include, when set, indexes only the files matching one of its globs.
+	Include []string `yaml:"include,omitempty"`
+	// Exclude skips the files and directories matching one of its globs.
+	Exclude []string `yaml:"exclude,omitempty"`
+	// MaxFileSizeMB skips larger files; 0 means no limit.
+	MaxFileSizeMB int `yaml:"max_file_size_mb,omitempty"`
+}
+
+// PathRules returns the watch rules for the watcher and indexer.
+func (w *WatchConfig) PathRules() (pathrules.Rules, error) {
+	rules := make([]pathrules.Rule, len(w.Rules))
+	for i, r := range w.Rules {
+		rules[i] = pathrules.Rule{
+			Root:        r.Root,
+			Recursive:   r.Recursive,
+			Extensions:  r.Extensions,
+			Include:     r.Include,
+			Exclude:     r.Exclude,
+			MaxFileSize: int64(r.MaxFileSizeMB) << 20,
+		}
+	}
+	return pathrules.New(rules)
 }
 
 // Recursive returns whether to watch recursively; defaults to true when unset.
@@ -41,6 +407,51 @@ func (w *WatchConfig) RecursiveOrDefault() bool {
 type ServerConfig struct {
 	Host string `yaml:"host"`
 	Port int    `yaml:"port"`
+	// Auth requires an API key on /api/v1 requests once any key is configured.
+	Auth AuthConfig `yaml:"auth,omitempty"`
+	// OpenFiles lets POST /api/v1/documents/{id}/open launch a document's source file with
+	// the desktop's default application, for a web UI running on the same machine. Only
+	// same-origin requests over the loopback interface are accepted.
+	OpenFiles bool `yaml:"open_files,omitempty"`
+	// Profiling serves Go's net/http/pprof profiles under /api/v1/debug/pprof/ to write
+	// keys, for "sagasu profile".
+	Profiling bool `yaml:"profiling,omitempty"`
+}
+
+// API key scopes.
+const (
+	// ScopeRead allows searching and reading documents, status, and jobs.
+	ScopeRead = "read"
+	// ScopeWrite also allows indexing, deletion, watch, reindex, pins, pause/resume,
+	// reading the audit log, reading and changing the configuration, and profiling.
+	ScopeWrite = "write"
+)
+
+// AuthConfig holds the API keys accepted by the server.
+type AuthConfig struct {
+	APIKeys []APIKeyConfig `yaml:"api_keys,omitempty"`
+}
+
+// APIKeyConfig is one API key, sent as "Authorization: Bearer <key>" or "X-API-Key: <key>".
+type APIKeyConfig struct {
+	// Name identifies the key in logs and the audit log.
+	Name string `yaml:"name"`
+	Key  string `yaml:"key,omitempty"`
+	// KeyEnv names an environment variable holding the key, to keep it out of the file.
+	KeyEnv string `yaml:"key_env,omitempty"`
+	// Scope is ScopeRead (the default) or ScopeWrite.
+	Scope string `yaml:"scope,omitempty"`
+}
+
+// Secret returns the key, read from KeyEnv when Key is empty.
+func (k *APIKeyConfig) Secret() string {
+	if k.Key != "" {
+		return k.Key
+	}
+	if k.KeyEnv != "" {
+		return os.Getenv(k.KeyEnv)
+	}
+	return ""
 }
 
 // StorageConfig holds paths for database and indices.
@@ -48,15 +459,84 @@ type StorageConfig struct {
 	DatabasePath   string `yaml:"database_path"`
 	BleveIndexPath string `yaml:"bleve_index_path"`
 	FAISSIndexPath string `yaml:"faiss_index_path"`
+	// EmbeddingCachePath is the SQLite database that persists embeddings across index
+	// rebuilds. It defaults to embeddings.db next to DatabasePath; "none" disables it.
+	EmbeddingCachePath string `yaml:"embedding_cache_path"`
+	// SQLite tunes the connections to the document database.
+	SQLite SQLiteConfig `yaml:"sqlite"`
+}
+
+// SQLiteConfig holds the pragmas, optional indexes, and version history of the document
+// database.
+type SQLiteConfig struct {
+	// JournalMode is the SQLite journal mode; defaults to wal.
+	JournalMode string `yaml:"journal_mode"`
+	// Synchronous is how often SQLite syncs to disk: off, normal, full, or extra. It
+	// defaults to normal, which with WAL can lose the last commits on power loss but never
+	// corrupts the database.
+	Synchronous string `yaml:"synchronous"`
+	// CacheSizeMB is the page cache of each connection; 0 keeps SQLite's default (2 MB).
+	CacheSizeMB int `yaml:"cache_size_mb"`
+	// BusyTimeoutMS is how long a write waits for a locked database before failing.
+	BusyTimeoutMS int `yaml:"busy_timeout_ms"`
+	// TrigramIndex keeps an index of the three-character sequences of every document, so
+	// literal (exact substring) searches read only the documents that can match instead
+	// of all of them. It grows the database considerably; off by default.
+	TrigramIndex bool `yaml:"trigram_index"`
+	// VersionHistory keeps the previous content of documents replaced or deleted, so
+	// searches can ask for content as it was at a past time (as_of). Off by default.
+	VersionHistory bool `yaml:"version_history"`
+}
+
+// DataDir is the directory of the database, which also holds the instance lock and the
+// running server's address.
+func (c *StorageConfig) DataDir() string {
+	return filepath.Dir(c.DatabasePath)
 }
 
-// EmbeddingConfig holds ONNX embedder settings.
+// EmbeddingConfig holds embedder settings.
 type EmbeddingConfig struct {
+	// Provider selects the embedder: "onnx" (default, local model at ModelPath), "ollama",
+	// "openai" (any OpenAI-compatible endpoint), or "mock" (deterministic, for testing).
+	Provider        string `yaml:"provider"`
 	ModelPath       string `yaml:"model_path"`
 	Dimensions      int    `yaml:"dimensions"`
 	MaxTokens       int    `yaml:"max_tokens"`
 	UseQuantization bool   `yaml:"use_quantization"`
 	CacheSize       int    `yaml:"cache_size"`
+	// BaseURL is the ollama or openai endpoint; empty uses the provider's default.
+	BaseURL string `yaml:"base_url,omitempty"`
+	// Model is the ollama or openai model name.
+	Model string `yaml:"model,omitempty"`
+	// APIKey is sent as a bearer token. For openai it defaults to $OPENAI_API_KEY.
+	APIKey string `yaml:"api_key,omitempty"`
+	// BatchSize is the most texts sent to a remote provider in one request.
+	BatchSize int `yaml:"batch_size,omitempty"`
+	// MaxRetries is how many times a failed remote request is retried; -1 disables retry.
+	MaxRetries int `yaml:"max_retries,omitempty"`
+	// RequestsPerMinute limits remote requests; 0 means no limit.
+	RequestsPerMinute int `yaml:"requests_per_minute,omitempty"`
+	// TimeoutSeconds bounds each remote request.
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+}
+
+// ModelID identifies the model that produces the embeddings, e.g. "onnx:all-MiniLM-L6-v2.onnx"
+// or "ollama:nomic-embed-text". ONNX models are identified by file name so that moving
+// the model file does not invalidate the index.
+func (c *EmbeddingConfig) ModelID() string {
+	switch {
+	case c.IsRemote():
+		return c.Provider + ":" + c.Model
+	case c.Provider == "mock":
+		return "mock"
+	default:
+		return c.Provider + ":" + filepath.Base(c.ModelPath)
+	}
+}
+
+// IsRemote reports whether the embedder is an HTTP service.
+func (c *EmbeddingConfig) IsRemote() bool {
+	return c.Provider == "ollama" || c.Provider == "openai"
 }
 
 // SearchConfig holds search and chunking settings.
@@ -67,13 +547,168 @@ type SearchConfig struct {
 	DefaultSemanticEnabled     bool    `yaml:"default_semantic_enabled"`
 	DefaultMinKeywordScore     float64 `yaml:"default_min_keyword_score"`
 	DefaultMinSemanticScore    float64 `yaml:"default_min_semantic_score"`
+	// DefaultKeywordWeight and DefaultSemanticWeight weight the keyword and semantic
+	// scores of queries with weighted fusion that set no weights of their own.
+	DefaultKeywordWeight       float64 `yaml:"default_keyword_weight"`
+	DefaultSemanticWeight      float64 `yaml:"default_semantic_weight"`
+	// RRFK is the k of reciprocal rank fusion, 1/(k + rank): larger values flatten the
+	// advantage of the top ranks.
+	RRFK                       int     `yaml:"rrf_k"`
 	ChunkSize                  int     `yaml:"chunk_size"`
 	ChunkOverlap               int     `yaml:"chunk_overlap"`
+	// ChunkStrategy is how text is split into chunks: fixed (default) windows of
+	// chunk_size words, sentence or paragraph (whole sentences or paragraphs, starting at
+	// Markdown headings, of up to chunk_size words), or token (windows of chunk_size
+	// estimated model tokens). Source code is chunked by declaration whatever the strategy.
+	ChunkStrategy              string  `yaml:"chunk_strategy"`
 	TopKCandidates             int     `yaml:"top_k_candidates"`
 	KeywordTitleBoost          float64 `yaml:"keyword_title_boost"`
 	KeywordPhraseBoost         float64 `yaml:"keyword_phrase_boost"`
+	// KeywordPhraseSlop is how many other words may stand between the query terms for
+	// keyword_phrase_boost to apply: 0 (default) requires them adjacent.
+	KeywordPhraseSlop          int     `yaml:"keyword_phrase_slop"`
+	// KeywordFuzziness is the edit distance (1 or 2) of fuzzy keyword matching.
+	KeywordFuzziness           int     `yaml:"keyword_fuzziness"`
+	// KeywordCoverageExponent is the power of the share of query terms a document must
+	// match in multi-term keyword searches: 2 (default) ranks partial matches far below
+	// full ones, lower values soften that, and 0 turns the penalty off.
+	KeywordCoverageExponent    *float64 `yaml:"keyword_coverage_exponent"`
 	// RankingEnabled enables the new content-aware ranking system.
 	RankingEnabled             bool    `yaml:"ranking_enabled"`
+	// StopChunkFilterEnabled keeps low-information chunks (too short, mostly numbers or
+	// punctuation, or repeated boilerplate) out of the vector index. They remain in storage.
+	// The checks' thresholds default to 5 words, 0.4 letters, and 20 documents when unset;
+	// 0 turns a check off.
+	StopChunkFilterEnabled     bool     `yaml:"stop_chunk_filter_enabled"`
+	StopChunkMinWords          *int     `yaml:"stop_chunk_min_words"`
+	StopChunkMinAlphaRatio     *float64 `yaml:"stop_chunk_min_alpha_ratio"`
+	StopChunkMaxDocFrequency   *int     `yaml:"stop_chunk_max_doc_frequency"`
+	// HedgingEnabled returns without a search branch (keyword or semantic) once it runs
+	// HedgeLatencyMultiplier times slower than its recent average; the slow branch keeps
+	// running in the background to warm caches.
+	HedgingEnabled             bool    `yaml:"hedging_enabled"`
+	HedgeLatencyMultiplier     float64 `yaml:"hedge_latency_multiplier"`
+	HedgeMinDelayMs            int     `yaml:"hedge_min_delay_ms"`
+	// SearchBudgetMs caps how long a search waits for its branches; 0 means no limit.
+	SearchBudgetMs             int     `yaml:"search_budget_ms"`
+	// RerankerModelPath is an optional ONNX cross-encoder that re-orders the top
+	// RerankerTopK fused candidates of each result list. Ignored when the file is missing.
+	RerankerModelPath          string  `yaml:"reranker_model_path"`
+	RerankerTopK               int     `yaml:"reranker_top_k"`
+	// DocumentCacheSize is how many documents the engine keeps in memory for building
+	// responses; a negative value disables the cache.
+	DocumentCacheSize          int     `yaml:"document_cache_size"`
+	// VectorCacheSize is how many recent queries keep their vector search results, so
+	// repeated and near-identical queries skip the scan; a negative value disables it.
+	// Index writes empty the cache.
+	VectorCacheSize            int     `yaml:"vector_cache_size"`
+	// VectorCacheMinSimilarity is the cosine similarity at which a query embedding reuses
+	// the cached results of another query.
+	VectorCacheMinSimilarity   float64 `yaml:"vector_cache_min_similarity"`
+	// SuggestOnZeroResults adds "Did you mean?" suggestions and the corrected query to
+	// responses with no results even when fuzzy matching is off. Results are not changed.
+	// Defaults to true when unset.
+	SuggestOnZeroResults       *bool   `yaml:"suggest_on_zero_results"`
+	// DedupeEnabled collapses near-identical documents, such as copies of a report in
+	// different folders, into the best-ranked one, which lists the other copies' paths.
+	// Defaults to true when unset.
+	DedupeEnabled              *bool   `yaml:"dedupe_enabled"`
+	// DedupeMaxDistance is how many bits of two documents' 64-bit content fingerprints
+	// may differ for them to count as copies.
+	DedupeMaxDistance          int     `yaml:"dedupe_max_distance"`
+	// SemanticAggregation turns a document's chunk scores into its semantic score: "max"
+	// (default) takes the best chunk; "decay" also credits further matching chunks, each
+	// SemanticAggregationDecay times less than the one before, skipping chunks next to one
+	// already counted since they overlap it.
+	SemanticAggregation        string  `yaml:"semantic_aggregation"`
+	SemanticAggregationDecay   float64 `yaml:"semantic_aggregation_decay"`
+	// DiversityLambda reorders the top results by maximal marginal relevance, trading
+	// relevance for difference from the results above, so near-duplicates do not fill the
+	// first page: 0 (default) keeps relevance order, and higher values, below 1, weigh
+	// the difference more.
+	DiversityLambda            float64 `yaml:"diversity_lambda"`
+	// SynonymsPath is an optional YAML synonym dictionary (term: [synonyms]) expanding
+	// keyword queries, so "car" also finds "automobile". Ignored when the file is missing.
+	SynonymsPath               string  `yaml:"synonyms_path"`
+	// SynonymsInEmbeddings also appends the synonyms of a query's terms to the text
+	// embedded for semantic search.
+	SynonymsInEmbeddings       bool    `yaml:"synonyms_in_embeddings"`
+	// Stopwords are extra words (case-insensitive) that keyword search ignores, on top of
+	// the analyzer's own, and that never count as query terms in ranking.
+	Stopwords                  []string `yaml:"stopwords,omitempty"`
+	// ProtectedTerms, e.g. domain acronyms, are kept as written: never stemmed, dropped as
+	// stop words, matched fuzzily, or spell-corrected. Keyword indexes pick up changes
+	// to either list when rebuilt (sagasu reindex).
+	ProtectedTerms             []string `yaml:"protected_terms,omitempty"`
+	// Stemming names a stemming analyzer (e.g. english) that keyword indexes also index
+	// title and content with, in fields of their own: a query term matches them at
+	// StemmedBoost, so "running" finds "run" while exact matches rank first. Takes effect
+	// on rebuilt keyword indexes (sagasu reindex); empty disables it.
+	Stemming                   string  `yaml:"stemming,omitempty"`
+	StemmedBoost               float64 `yaml:"stemmed_boost"`
+	// StemmedFallback matches query terms only as written, and retries against the
+	// stemmed fields only when that finds no keyword result, before any fuzzy retry.
+	// Responses from the retry are marked stemmed. Needs Stemming.
+	StemmedFallback            bool    `yaml:"stemmed_fallback"`
+	// KeywordChunks also indexes each document's chunks in the Bleve keyword index, so a
+	// document's content matches score as its best chunk, long documents are not
+	// penalized for their length, and results report the chunk (keyword_chunk). Takes
+	// effect on rebuilt keyword indexes (sagasu reindex).
+	KeywordChunks              bool    `yaml:"keyword_chunks"`
+	// ConfidenceKeywordMidpoint is the keyword score given 0.5 keyword evidence in result
+	// confidences; ConfidenceSemanticMidpoint is the similarity given 0.5 semantic
+	// evidence, which rises more sharply around it the higher ConfidenceSemanticSteepness.
+	ConfidenceKeywordMidpoint   float64 `yaml:"confidence_keyword_midpoint"`
+	ConfidenceSemanticMidpoint  float64 `yaml:"confidence_semantic_midpoint"`
+	ConfidenceSemanticSteepness float64 `yaml:"confidence_semantic_steepness"`
+}
+
+// CoverageExponentOrDefault returns KeywordCoverageExponent, or 2 when unset.
+func (s *SearchConfig) CoverageExponentOrDefault() float64 {
+	if s.KeywordCoverageExponent != nil {
+		return *s.KeywordCoverageExponent
+	}
+	return 2
+}
+
+// SuggestOnZeroResultsOrDefault returns SuggestOnZeroResults, or true when unset.
+func (s *SearchConfig) SuggestOnZeroResultsOrDefault() bool {
+	if s.SuggestOnZeroResults != nil {
+		return *s.SuggestOnZeroResults
+	}
+	return true
+}
+
+// DedupeEnabledOrDefault returns DedupeEnabled, or true when unset.
+func (s *SearchConfig) DedupeEnabledOrDefault() bool {
+	if s.DedupeEnabled != nil {
+		return *s.DedupeEnabled
+	}
+	return true
+}
+
+// StopChunkMinWordsOrDefault returns StopChunkMinWords, or 5 when unset.
+func (s *SearchConfig) StopChunkMinWordsOrDefault() int {
+	if s.StopChunkMinWords != nil {
+		return *s.StopChunkMinWords
+	}
+	return 5
+}
+
+// StopChunkMinAlphaRatioOrDefault returns StopChunkMinAlphaRatio, or 0.4 when unset.
+func (s *SearchConfig) StopChunkMinAlphaRatioOrDefault() float64 {
+	if s.StopChunkMinAlphaRatio != nil {
+		return *s.StopChunkMinAlphaRatio
+	}
+	return 0.4
+}
+
+// StopChunkMaxDocFrequencyOrDefault returns StopChunkMaxDocFrequency, or 20 when unset.
+func (s *SearchConfig) StopChunkMaxDocFrequencyOrDefault() int {
+	if s.StopChunkMaxDocFrequency != nil {
+		return *s.StopChunkMaxDocFrequency
+	}
+	return 20
 }
 
 // RankingConfig holds content-aware ranking settings.
@@ -99,6 +734,7 @@ type RankingConfig struct {
 	AllWordsContentScore     float64 `yaml:"all_words_content_score"`
 	ScatteredWordsScore      float64 `yaml:"scattered_words_score"`
 	StemmingMatchScore       float64 `yaml:"stemming_match_score"`
+	ProximityMatchScore      float64 `yaml:"proximity_match_score"`
 
 	// Path scoring values
 	PathExactMatchScore      float64 `yaml:"path_exact_match_score"`
@@ -135,14 +771,111 @@ type RankingConfig struct {
 	FileSizeNormEnabled      bool    `yaml:"file_size_norm_enabled"`
 }
 
+// KeywordConfig selects the keyword index implementation.
+type KeywordConfig struct {
+	// Backend is "bleve" (default) or "fts5": an SQLite FTS5 table in one database file,
+	// smaller and simpler to back up, but without analyzers, stemming, or wildcard and
+	// regex search. FTS5 requires building with -tags=sqlite_fts5. "elasticsearch" or
+	// "opensearch" keeps the index on the cluster of Elasticsearch.
+	Backend string `yaml:"backend"`
+	// Path is the FTS5 database; it defaults to keyword.db next to storage.database_path.
+	Path string `yaml:"path,omitempty"`
+	// Elasticsearch is the cluster of the elasticsearch and opensearch backends.
+	Elasticsearch ElasticsearchConfig `yaml:"elasticsearch,omitempty"`
+}
+
+// ElasticsearchConfig connects to an Elasticsearch or OpenSearch cluster.
+type ElasticsearchConfig struct {
+	URL   string `yaml:"url"`
+	Index string `yaml:"index"` // default "sagasu"
+	// APIKey is sent as "Authorization: ApiKey"; it defaults to $ELASTICSEARCH_API_KEY.
+	// Otherwise Username and Password, when set, are sent as basic auth.
+	APIKey   string `yaml:"api_key,omitempty"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// TimeoutSeconds bounds each request.
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+}
+
+// RemoteKeyword reports whether the keyword index is on an external cluster rather than
+// on disk.
+func (k *KeywordConfig) RemoteKeyword() bool {
+	return k.Backend == "elasticsearch" || k.Backend == "opensearch"
+}
+
+// KeywordIndexPath returns where the default keyword index lives: the FTS5 database with
+// the fts5 backend, "" with a remote one, or the Bleve index directory.
+func (c *Config) KeywordIndexPath() string {
+	switch {
+	case c.Keyword.Backend == "fts5":
+		return c.Keyword.Path
+	case c.Keyword.RemoteKeyword():
+		return ""
+	}
+	return c.Storage.BleveIndexPath
+}
+
 // VectorConfig holds vector index settings.
 type VectorConfig struct {
-	// IndexType specifies the vector index implementation: "memory" (default) or "faiss".
-	// FAISS requires building with -tags=faiss and having FAISS library installed.
+	// IndexType specifies the vector index implementation: "memory" (default), "faiss",
+	// "hnsw", or "qdrant". FAISS requires building with -tags=faiss and having FAISS library
+	// installed; qdrant keeps the vectors on the server in Qdrant.
 	IndexType  string `yaml:"index_type"`
 	// MaxVectors is an optional limit on the number of vectors in the index.
 	// When set to 0 (default), there is no limit.
 	MaxVectors int    `yaml:"max_vectors"`
+	// WALCompactMB is the size of the vector write-ahead log at which it is folded into a
+	// new snapshot. A negative value disables the log; the index is then only saved on shutdown.
+	WALCompactMB int `yaml:"wal_compact_mb"`
+	// HNSWM is the number of neighbors per node in the hnsw graph; 0 uses the default (16).
+	HNSWM int `yaml:"hnsw_m"`
+	// HNSWEfConstruction is the candidate list size while inserting into the hnsw graph;
+	// 0 uses the default (200).
+	HNSWEfConstruction int `yaml:"hnsw_ef_construction"`
+	// HNSWEfSearch is the candidate list size while searching the hnsw graph; higher values
+	// improve recall at the cost of speed. 0 uses the default (64).
+	HNSWEfSearch int `yaml:"hnsw_ef_search"`
+	// Quantization makes the memory index keep compressed vectors in memory: "int8"
+	// (4x smaller) or "pq" (product quantization, about 32x smaller). Empty keeps full vectors.
+	Quantization string `yaml:"quantization"`
+	// RefineFactor is how many candidates per result are re-scored with full-precision
+	// vectors read from disk when Quantization is set. A negative value disables re-scoring.
+	RefineFactor int `yaml:"refine_factor"`
+	// Qdrant is the server of the qdrant index type.
+	Qdrant QdrantConfig `yaml:"qdrant,omitempty"`
+}
+
+// QdrantConfig connects to a Qdrant server.
+type QdrantConfig struct {
+	URL        string `yaml:"url"`        // default http://localhost:6333
+	Collection string `yaml:"collection"` // default "sagasu"
+	// APIKey is sent in the api-key header; it defaults to $QDRANT_API_KEY.
+	APIKey string `yaml:"api_key,omitempty"`
+	// TimeoutSeconds bounds each request.
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+}
+
+// RemoteVector reports whether the vector index is on an external server rather than
+// in memory and saved to disk.
+func (v *VectorConfig) RemoteVector() bool {
+	return v.IndexType == "qdrant"
+}
+
+// JobsConfig holds background indexing job queue settings.
+type JobsConfig struct {
+	// Workers is the number of jobs run concurrently.
+	Workers        int `yaml:"workers"`
+	// QueueSize is how many jobs may wait for a worker before submitters block.
+	QueueSize      int `yaml:"queue_size"`
+	// MaxRetries is how many times a failed job is retried; a negative value disables retry.
+	MaxRetries     int `yaml:"max_retries"`
+	// RetryBackoffMs is the delay before the first retry; it doubles on each retry.
+	RetryBackoffMs int `yaml:"retry_backoff_ms"`
+	// History is how many finished jobs are kept for GET /api/v1/jobs.
+	History        int `yaml:"history"`
+	// EmbedQueueChunks is the most chunks being embedded at once across jobs. While it is
+	// full, indexing waits and the watcher holds back changed files.
+	EmbedQueueChunks int `yaml:"embed_queue_chunks"`
 }
 
 // Load reads and parses the config file at path, expands paths, and applies defaults.
@@ -159,19 +892,436 @@ func Load(path string) (*Config, error) {
 	}
 
 	ApplyDefaults(&cfg)
+	if err := validateEmbedding("embedding", &cfg.Embedding); err != nil {
+		return nil, err
+	}
+	if err := validateCollections(cfg.Collections); err != nil {
+		return nil, err
+	}
+	if err := validateEmbeddingModels(cfg.EmbeddingModels, cfg.Collections); err != nil {
+		return nil, err
+	}
+	if err := validateExternalExtractors(cfg.Extract.External); err != nil {
+		return nil, err
+	}
+	if err := validateRetention(cfg.Retention.Policies); err != nil {
+		return nil, err
+	}
+	if err := validateLLM(&cfg.LLM); err != nil {
+		return nil, err
+	}
+	if err := validateAPIKeys(cfg.Server.Auth.APIKeys); err != nil {
+		return nil, err
+	}
+	if err := validateVector(&cfg.Vector); err != nil {
+		return nil, err
+	}
+	if err := validateSearch(&cfg.Search); err != nil {
+		return nil, err
+	}
+	if err := validateSQLite(&cfg.Storage.SQLite); err != nil {
+		return nil, err
+	}
+	if err := validateKeyword(&cfg); err != nil {
+		return nil, err
+	}
+	if err := validatePasswords(cfg.Passwords); err != nil {
+		return nil, err
+	}
+	for lang, analyzer := range cfg.Languages.Analyzers {
+		if lang == "" || analyzer == "" {
+			return nil, fmt.Errorf("languages.analyzers: language and analyzer are required, got %q: %q", lang, analyzer)
+		}
+	}
+	if _, err := schedule.Parse(cfg.Watch.IndexWindows); err != nil {
+		return nil, fmt.Errorf("watch.index_windows: %w", err)
+	}
+	if _, err := cfg.Watch.PathRules(); err != nil {
+		return nil, fmt.Errorf("watch.rules: %w", err)
+	}
+	if cfg.Jobs.EmbedQueueChunks < 0 {
+		return nil, fmt.Errorf("jobs.embed_queue_chunks must be positive, got %d", cfg.Jobs.EmbedQueueChunks)
+	}
 
 	configDir := filepath.Dir(path)
 	cfg.Storage.DatabasePath = expandPath(cfg.Storage.DatabasePath, configDir)
 	cfg.Storage.BleveIndexPath = expandPath(cfg.Storage.BleveIndexPath, configDir)
 	cfg.Storage.FAISSIndexPath = expandPath(cfg.Storage.FAISSIndexPath, configDir)
+	switch cfg.Storage.EmbeddingCachePath {
+	case "none":
+		cfg.Storage.EmbeddingCachePath = ""
+	case "":
+		// Derived from the expanded database path so both live in the same directory.
+		cfg.Storage.EmbeddingCachePath = filepath.Join(filepath.Dir(cfg.Storage.DatabasePath), "embeddings.db")
+	default:
+		cfg.Storage.EmbeddingCachePath = expandPath(cfg.Storage.EmbeddingCachePath, configDir)
+	}
+	if cfg.Keyword.Path == "" {
+		cfg.Keyword.Path = filepath.Join(filepath.Dir(cfg.Storage.DatabasePath), "keyword.db")
+	} else {
+		cfg.Keyword.Path = expandPath(cfg.Keyword.Path, configDir)
+	}
 	cfg.Embedding.ModelPath = expandPath(cfg.Embedding.ModelPath, configDir)
+	if cfg.Search.RerankerModelPath != "" {
+		cfg.Search.RerankerModelPath = expandPath(cfg.Search.RerankerModelPath, configDir)
+	}
+	if cfg.Search.SynonymsPath != "" {
+		cfg.Search.SynonymsPath = expandPath(cfg.Search.SynonymsPath, configDir)
+	}
 	for i := range cfg.Watch.Directories {
 		cfg.Watch.Directories[i] = expandPath(cfg.Watch.Directories[i], configDir)
 	}
+	for i := range cfg.Watch.Rules {
+		cfg.Watch.Rules[i].Root = expandPath(cfg.Watch.Rules[i].Root, configDir)
+	}
+	switch cfg.Watch.GlobalIgnore {
+	case "none":
+		cfg.Watch.GlobalIgnore = ""
+	case "":
+		cfg.Watch.GlobalIgnore = expandPath(".sagasuignore", configDir)
+	default:
+		cfg.Watch.GlobalIgnore = expandPath(cfg.Watch.GlobalIgnore, configDir)
+	}
+	for i := range cfg.Retention.Policies {
+		if p := &cfg.Retention.Policies[i]; p.Root != "" {
+			p.Root = expandPath(p.Root, configDir)
+		}
+	}
+	for i := range cfg.Collections {
+		col := &cfg.Collections[i]
+		col.Root = expandPath(col.Root, configDir)
+		if col.Embedding != nil {
+			if col.Embedding.ModelPath != "" {
+				col.Embedding.ModelPath = expandPath(col.Embedding.ModelPath, configDir)
+			}
+		}
+	}
+	for i := range cfg.EmbeddingModels {
+		if e := &cfg.EmbeddingModels[i].Embedding; e.ModelPath != "" {
+			e.ModelPath = expandPath(e.ModelPath, configDir)
+		}
+	}
+	for i := range cfg.Passwords {
+		if p := &cfg.Passwords[i]; strings.ContainsRune(p.Pattern, filepath.Separator) {
+			p.Pattern = expandPath(p.Pattern, configDir)
+		}
+	}
 
 	return &cfg, nil
 }
 
+// validateCollections checks that every collection has a unique name and a root.
+func validateCollections(cols []CollectionConfig) error {
+	seen := make(map[string]bool, len(cols))
+	for i, col := range cols {
+		if col.Name == "" {
+			return fmt.Errorf("collection %d: name is required", i)
+		}
+		if seen[col.Name] {
+			return fmt.Errorf("collection %q: duplicate name", col.Name)
+		}
+		seen[col.Name] = true
+		if col.Root == "" {
+			return fmt.Errorf("collection %q: root is required", col.Name)
+		}
+		if err := validateChunkStrategy(fmt.Sprintf("collection %q: chunk_strategy", col.Name), col.ChunkStrategy); err != nil {
+			return err
+		}
+		if col.Embedding != nil {
+			if err := validateEmbedding(fmt.Sprintf("collection %q: embedding", col.Name), col.Embedding); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validateEmbeddingModels checks that every model has a unique name, which also differs
+// from the collection names since both name vector index files, and extensions that no
+// other model claims. Extensions are normalized to lower case without the dot.
+func validateEmbeddingModels(models []EmbeddingModelConfig, cols []CollectionConfig) error {
+	names := make(map[string]bool, len(models)+len(cols))
+	for _, col := range cols {
+		names[col.Name] = true
+	}
+	owner := make(map[string]string)
+	for i := range models {
+		m := &models[i]
+		if m.Name == "" {
+			return fmt.Errorf("embedding model %d: name is required", i)
+		}
+		if names[m.Name] {
+			return fmt.Errorf("embedding model %q: name is already used by a collection or another model", m.Name)
+		}
+		names[m.Name] = true
+		if len(m.Extensions) == 0 {
+			return fmt.Errorf("embedding model %q: extensions are required", m.Name)
+		}
+		for j, ext := range m.Extensions {
+			ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+			if other, ok := owner[ext]; ok {
+				return fmt.Errorf("embedding model %q: extension %q is already routed to model %q", m.Name, ext, other)
+			}
+			owner[ext] = m.Name
+			m.Extensions[j] = ext
+		}
+		if err := validateEmbedding(fmt.Sprintf("embedding model %q: embedding", m.Name), &m.Embedding); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateExternalExtractors checks that every external extractor has a command and
+// extensions that no other one claims. Extensions are normalized to lower case without
+// the dot.
+func validateExternalExtractors(external []ExternalExtractorConfig) error {
+	owner := make(map[string]int)
+	for i := range external {
+		x := &external[i]
+		if len(x.Command) == 0 {
+			return fmt.Errorf("extract.external[%d]: command is required", i)
+		}
+		if len(x.Extensions) == 0 {
+			return fmt.Errorf("extract.external[%d]: extensions are required", i)
+		}
+		for j, ext := range x.Extensions {
+			ext = strings.ToLower(strings.TrimPrefix(ext, "."))
+			if other, ok := owner[ext]; ok {
+				return fmt.Errorf("extract.external[%d]: extension %q is already extracted by extract.external[%d]", i, ext, other)
+			}
+			owner[ext] = i
+			x.Extensions[j] = ext
+		}
+	}
+	return nil
+}
+
+// validateEmbedding checks that the provider is known and has what it needs: a model
+// path for onnx, a model name for ollama and openai. name prefixes errors.
+func validateEmbedding(name string, cfg *EmbeddingConfig) error {
+	switch cfg.Provider {
+	case "onnx":
+		if cfg.ModelPath == "" {
+			return fmt.Errorf("%s.model_path is required", name)
+		}
+	case "ollama", "openai":
+		if cfg.Model == "" {
+			return fmt.Errorf("%s.model is required for provider %s", name, cfg.Provider)
+		}
+	case "mock":
+	default:
+		return fmt.Errorf("%s.provider: unknown value %q (supported: onnx, ollama, openai, mock)", name, cfg.Provider)
+	}
+	return nil
+}
+
+// validateLLM checks that a configured provider is known and names a model.
+func validateLLM(cfg *LLMConfig) error {
+	switch cfg.Provider {
+	case "":
+		return nil
+	case "ollama", "openai":
+		if cfg.Model == "" {
+			return fmt.Errorf("llm.model is required for provider %s", cfg.Provider)
+		}
+		return nil
+	default:
+		return fmt.Errorf("llm.provider: unknown value %q (supported: ollama, openai)", cfg.Provider)
+	}
+}
+
+// validateAPIKeys checks that every key has a unique name, a distinct non-empty secret,
+// and a known scope.
+func validateAPIKeys(keys []APIKeyConfig) error {
+	names := make(map[string]bool)
+	secrets := make(map[string]bool)
+	for _, k := range keys {
+		if k.Name == "" {
+			return fmt.Errorf("server.auth.api_keys: name is required")
+		}
+		if names[k.Name] {
+			return fmt.Errorf("server.auth.api_keys: duplicate name %q", k.Name)
+		}
+		names[k.Name] = true
+		secret := k.Secret()
+		if secret == "" {
+			if k.KeyEnv != "" {
+				return fmt.Errorf("api key %q: environment variable %s is empty", k.Name, k.KeyEnv)
+			}
+			return fmt.Errorf("api key %q: key or key_env is required", k.Name)
+		}
+		if secrets[secret] {
+			return fmt.Errorf("api key %q: key is used by another entry", k.Name)
+		}
+		secrets[secret] = true
+		if k.Scope != ScopeRead && k.Scope != ScopeWrite {
+			return fmt.Errorf("api key %q: scope: unknown value %q (supported: read, write)", k.Name, k.Scope)
+		}
+	}
+	return nil
+}
+
+// validateRetention checks that every policy has a positive max age and a root or tag.
+func validateRetention(policies []RetentionPolicyConfig) error {
+	for i, p := range policies {
+		if p.Root == "" && p.Tag == "" {
+			return fmt.Errorf("retention policy %d: root or tag is required", i)
+		}
+		if p.MaxAgeDays <= 0 {
+			return fmt.Errorf("retention policy %d: max_age_days must be positive", i)
+		}
+	}
+	return nil
+}
+
+// validateVector checks that quantization is a known kind and used with the memory index.
+func validateVector(cfg *VectorConfig) error {
+	switch cfg.Quantization {
+	case "":
+		return nil
+	case "int8", "pq":
+	default:
+		return fmt.Errorf("vector.quantization: unknown value %q (supported: int8, pq)", cfg.Quantization)
+	}
+	if cfg.IndexType != "memory" {
+		return fmt.Errorf("vector.quantization requires index_type memory, got %q", cfg.IndexType)
+	}
+	return nil
+}
+
+// validateSearch checks the keyword search tuning options, the dedupe distance, the
+// semantic aggregation, and the confidence calibration.
+func validateSearch(cfg *SearchConfig) error {
+	if cfg.DefaultKeywordWeight < 0 || cfg.DefaultSemanticWeight < 0 {
+		return fmt.Errorf("search.default_keyword_weight and default_semantic_weight cannot be negative")
+	}
+	if cfg.RRFK < 1 {
+		return fmt.Errorf("search.rrf_k must be positive, got %d", cfg.RRFK)
+	}
+	if err := validateChunkStrategy("search.chunk_strategy", cfg.ChunkStrategy); err != nil {
+		return err
+	}
+	if cfg.KeywordFuzziness < 1 || cfg.KeywordFuzziness > 2 {
+		return fmt.Errorf("search.keyword_fuzziness must be 1 or 2, got %d", cfg.KeywordFuzziness)
+	}
+	if cfg.KeywordTitleBoost < 0 || cfg.KeywordPhraseBoost < 0 {
+		return fmt.Errorf("search.keyword_title_boost and keyword_phrase_boost cannot be negative")
+	}
+	if cfg.CoverageExponentOrDefault() < 0 {
+		return fmt.Errorf("search.keyword_coverage_exponent cannot be negative")
+	}
+	if cfg.KeywordPhraseSlop < 0 || cfg.KeywordPhraseSlop > 20 {
+		return fmt.Errorf("search.keyword_phrase_slop must be between 0 and 20, got %d", cfg.KeywordPhraseSlop)
+	}
+	if cfg.VectorCacheMinSimilarity <= 0 || cfg.VectorCacheMinSimilarity > 1 {
+		return fmt.Errorf("search.vector_cache_min_similarity must be in (0, 1], got %g", cfg.VectorCacheMinSimilarity)
+	}
+	if cfg.DedupeMaxDistance < 0 || cfg.DedupeMaxDistance > 64 {
+		return fmt.Errorf("search.dedupe_max_distance must be between 0 and 64, got %d", cfg.DedupeMaxDistance)
+	}
+	switch cfg.SemanticAggregation {
+	case "max", "decay":
+	default:
+		return fmt.Errorf("search.semantic_aggregation: unknown value %q (supported: max, decay)", cfg.SemanticAggregation)
+	}
+	if cfg.SemanticAggregationDecay <= 0 || cfg.SemanticAggregationDecay >= 1 {
+		return fmt.Errorf("search.semantic_aggregation_decay must be in (0, 1), got %g", cfg.SemanticAggregationDecay)
+	}
+	if cfg.DiversityLambda < 0 || cfg.DiversityLambda >= 1 {
+		return fmt.Errorf("search.diversity_lambda must be in [0, 1), got %g", cfg.DiversityLambda)
+	}
+	if cfg.StemmedBoost <= 0 || cfg.StemmedBoost > 1 {
+		return fmt.Errorf("search.stemmed_boost must be in (0, 1], got %g", cfg.StemmedBoost)
+	}
+	if cfg.ConfidenceKeywordMidpoint < 0 || cfg.ConfidenceSemanticSteepness < 0 {
+		return fmt.Errorf("search.confidence_keyword_midpoint and confidence_semantic_steepness cannot be negative")
+	}
+	if cfg.ConfidenceSemanticMidpoint < 0 || cfg.ConfidenceSemanticMidpoint > 1 {
+		return fmt.Errorf("search.confidence_semantic_midpoint must be in [0, 1], got %g", cfg.ConfidenceSemanticMidpoint)
+	}
+	return nil
+}
+
+// validateChunkStrategy checks that the chunking strategy set as name is known.
+func validateChunkStrategy(name, strategy string) error {
+	switch strategy {
+	case "fixed", "sentence", "paragraph", "token":
+		return nil
+	}
+	return fmt.Errorf("%s: unknown value %q (supported: fixed, sentence, paragraph, token)", name, strategy)
+}
+
+// validateKeyword checks that the keyword backend is known, that a remote one has a
+// URL, and, for backends other than bleve, that no analyzer, stemming, or chunk index
+// they lack is configured.
+func validateKeyword(cfg *Config) error {
+	switch cfg.Keyword.Backend {
+	case "bleve":
+		return nil
+	case "fts5":
+	case "elasticsearch", "opensearch":
+		if cfg.Keyword.Elasticsearch.URL == "" {
+			return fmt.Errorf("keyword.elasticsearch.url is required for keyword.backend %s", cfg.Keyword.Backend)
+		}
+	default:
+		return fmt.Errorf("keyword.backend: unknown value %q (supported: bleve, fts5, elasticsearch, opensearch)", cfg.Keyword.Backend)
+	}
+	if cfg.Search.Stemming != "" {
+		return fmt.Errorf("search.stemming requires keyword.backend bleve")
+	}
+	if cfg.Search.KeywordChunks {
+		return fmt.Errorf("search.keyword_chunks requires keyword.backend bleve")
+	}
+	if len(cfg.Languages.Analyzers) > 0 {
+		return fmt.Errorf("languages.analyzers requires keyword.backend bleve")
+	}
+	for _, col := range cfg.Collections {
+		if col.Analyzer != "" {
+			return fmt.Errorf("collection %q: analyzer requires keyword.backend bleve", col.Name)
+		}
+	}
+	return nil
+}
+
+// validateSQLite checks the pragmas of the document database.
+func validateSQLite(cfg *SQLiteConfig) error {
+	switch strings.ToLower(cfg.JournalMode) {
+	case "delete", "truncate", "persist", "memory", "wal", "off":
+	default:
+		return fmt.Errorf("storage.sqlite.journal_mode: unknown value %q (supported: delete, truncate, persist, memory, wal, off)", cfg.JournalMode)
+	}
+	switch strings.ToLower(cfg.Synchronous) {
+	case "off", "normal", "full", "extra":
+	default:
+		return fmt.Errorf("storage.sqlite.synchronous: unknown value %q (supported: off, normal, full, extra)", cfg.Synchronous)
+	}
+	if cfg.CacheSizeMB < 0 || cfg.BusyTimeoutMS < 0 {
+		return fmt.Errorf("storage.sqlite.cache_size_mb and busy_timeout_ms cannot be negative")
+	}
+	return nil
+}
+
+// validatePasswords checks that every password entry has a valid pattern and a non-empty
+// password.
+func validatePasswords(passwords []PasswordConfig) error {
+	for i, p := range passwords {
+		if p.Pattern == "" {
+			return fmt.Errorf("passwords %d: pattern is required", i)
+		}
+		if _, err := filepath.Match(p.Pattern, ""); err != nil {
+			return fmt.Errorf("passwords %d: pattern %q: %w", i, p.Pattern, err)
+		}
+		if p.Secret() == "" {
+			if p.PasswordEnv != "" {
+				return fmt.Errorf("passwords %d: environment variable %s is empty", i, p.PasswordEnv)
+			}
+			return fmt.Errorf("passwords %d: password or password_env is required", i)
+		}
+	}
+	return nil
+}
+
 // Save writes the config to path. Used for persisting watch directory add/remove.
 func Save(path string, cfg *Config) error {
 	data, err := yaml.Marshal(cfg)
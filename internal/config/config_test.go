@@ -117,6 +117,12 @@ func TestApplyDefaults(t *testing.T) {
 	if cfg.Search.DefaultMinSemanticScore != 0.05 {
 		t.Errorf("default min semantic score: got %f, want 0.05", cfg.Search.DefaultMinSemanticScore)
 	}
+	if cfg.Search.ChunkStrategy != "fixed" {
+		t.Errorf("default chunk_strategy: got %q, want fixed", cfg.Search.ChunkStrategy)
+	}
+	if cfg.Search.DefaultKeywordWeight != 0.5 || cfg.Search.DefaultSemanticWeight != 0.5 || cfg.Search.RRFK != 60 {
+		t.Errorf("default fusion settings: got weights %g/%g, rrf_k %d", cfg.Search.DefaultKeywordWeight, cfg.Search.DefaultSemanticWeight, cfg.Search.RRFK)
+	}
 	if cfg.Watch.Extensions == nil {
 		t.Error("watch extensions should be set by default")
 	}
@@ -177,3 +183,732 @@ func TestSave(t *testing.T) {
 		t.Errorf("loaded port: got %d", loaded.Server.Port)
 	}
 }
+
+func TestLoad_stopChunkFilter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("debug: false\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Search.StopChunkFilterEnabled {
+		t.Error("stop chunk filter should be disabled by default")
+	}
+	s := cfg.Search
+	if s.StopChunkMinWordsOrDefault() != 5 || s.StopChunkMinAlphaRatioOrDefault() != 0.4 || s.StopChunkMaxDocFrequencyOrDefault() != 20 {
+		t.Errorf("stop chunk defaults: got %d, %g, %d", s.StopChunkMinWordsOrDefault(), s.StopChunkMinAlphaRatioOrDefault(), s.StopChunkMaxDocFrequencyOrDefault())
+	}
+
+	content := "search:\n  stop_chunk_filter_enabled: true\n  stop_chunk_min_words: 0\n  stop_chunk_min_alpha_ratio: 0\n  stop_chunk_max_doc_frequency: 0\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	s = cfg.Search
+	if s.StopChunkMinWordsOrDefault() != 0 || s.StopChunkMinAlphaRatioOrDefault() != 0 || s.StopChunkMaxDocFrequencyOrDefault() != 0 {
+		t.Errorf("stop chunk checks set to 0: got %d, %g, %d; want all disabled", s.StopChunkMinWordsOrDefault(), s.StopChunkMinAlphaRatioOrDefault(), s.StopChunkMaxDocFrequencyOrDefault())
+	}
+}
+
+func TestApplyDefaults_Jobs(t *testing.T) {
+	cfg := &Config{}
+	ApplyDefaults(cfg)
+	if cfg.Jobs.Workers != 2 || cfg.Jobs.QueueSize != 1000 || cfg.Jobs.MaxRetries != 2 ||
+		cfg.Jobs.RetryBackoffMs != 500 || cfg.Jobs.History != 1000 || cfg.Jobs.EmbedQueueChunks != 2048 {
+		t.Errorf("jobs defaults: got %+v", cfg.Jobs)
+	}
+}
+
+func TestApplyDefaults_Hedging(t *testing.T) {
+	cfg := &Config{}
+	ApplyDefaults(cfg)
+	if cfg.Search.HedgingEnabled {
+		t.Error("hedging should be disabled by default")
+	}
+	if cfg.Search.HedgeLatencyMultiplier != 3 || cfg.Search.HedgeMinDelayMs != 50 || cfg.Search.SearchBudgetMs != 0 {
+		t.Errorf("hedging defaults: got %+v", cfg.Search)
+	}
+}
+
+func TestApplyDefaults_RerankerAndDocumentCache(t *testing.T) {
+	cfg := &Config{}
+	ApplyDefaults(cfg)
+	if cfg.Search.RerankerModelPath != "" || cfg.Search.RerankerTopK != 20 {
+		t.Errorf("reranker defaults: got path=%q top_k=%d", cfg.Search.RerankerModelPath, cfg.Search.RerankerTopK)
+	}
+	if cfg.Search.DocumentCacheSize != 1000 {
+		t.Errorf("document cache size: got %d, want 1000", cfg.Search.DocumentCacheSize)
+	}
+	if cfg.Search.VectorCacheSize != 256 || cfg.Search.VectorCacheMinSimilarity != 0.999 {
+		t.Errorf("vector cache: got size %d, min similarity %v", cfg.Search.VectorCacheSize, cfg.Search.VectorCacheMinSimilarity)
+	}
+	if cfg.Extract.Sandbox || cfg.Extract.AllowNetworkFallback || cfg.Extract.MemoryMB != 1024 || cfg.Extract.CPUSeconds != 60 || cfg.Extract.TimeoutSeconds != 120 || cfg.Extract.MaxRows != 10000 {
+		t.Errorf("extract defaults: got %+v", cfg.Extract)
+	}
+	if ar := cfg.Extract.Archives; ar.Enabled || ar.MaxEntrySizeMB != 20 || ar.MaxTotalSizeMB != 200 || ar.MaxEntries != 1000 {
+		t.Errorf("archives defaults: got %+v", ar)
+	}
+	if cv := cfg.Extract.Converter; cv.Enabled || cv.TimeoutSeconds != 120 || !cv.CacheOrDefault() {
+		t.Errorf("converter defaults: got %+v", cv)
+	}
+}
+
+func TestLoad_collections(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+search:
+  chunk_size: 400
+  chunk_strategy: paragraph
+collections:
+  - name: code
+    root: ./src
+    chunk_size: 200
+    chunk_strategy: token
+    analyzer: simple
+  - name: vendored
+    root: ./src/vendor
+    embedding:
+      model_path: ./models/code.onnx
+      dimensions: 768
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Collections) != 2 {
+		t.Fatalf("collections: got %d, want 2", len(cfg.Collections))
+	}
+	code, vendored := cfg.Collections[0], cfg.Collections[1]
+	if code.Root != filepath.Join(dir, "src") || code.ChunkSize != 200 || code.ChunkOverlap != 50 || code.ChunkStrategy != "token" {
+		t.Errorf("code collection: got %+v", code)
+	}
+	if vendored.ChunkSize != 400 || vendored.ChunkStrategy != "paragraph" || vendored.Embedding.MaxTokens != 256 || vendored.Embedding.Dimensions != 768 {
+		t.Errorf("vendored collection defaults: got %+v, embedding %+v", vendored, vendored.Embedding)
+	}
+	if vendored.Embedding.ModelPath != filepath.Join(dir, "models", "code.onnx") {
+		t.Errorf("model path: got %q", vendored.Embedding.ModelPath)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{filepath.Join(dir, "src", "main.go"), "code"},
+		{filepath.Join(dir, "src", "vendor", "lib", "x.go"), "vendored"},
+		{filepath.Join(dir, "srcx", "main.go"), ""},
+		{filepath.Join(dir, "notes.md"), ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if col := cfg.CollectionFor(tt.path); col != nil {
+			got = col.Name
+		}
+		if got != tt.want {
+			t.Errorf("CollectionFor(%s) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_collectionsInvalid(t *testing.T) {
+	for name, content := range map[string]string{
+		"missing name":   "collections:\n  - root: /src\n",
+		"missing root":   "collections:\n  - name: code\n",
+		"duplicate name": "collections:\n  - name: a\n    root: /x\n  - name: a\n    root: /y\n",
+		"bad strategy":   "collections:\n  - name: a\n    root: /x\n    chunk_strategy: semantic\n",
+		"bad default":    "search:\n  chunk_strategy: words\n",
+	} {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_languages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("debug: false\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Languages.DetectOrDefault() || len(cfg.Languages.Analyzers) != 0 {
+		t.Errorf("languages defaults: got %+v", cfg.Languages)
+	}
+
+	content := "languages:\n  detect: false\n  analyzers:\n    ja: cjk\n    de: german\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Languages.DetectOrDefault() || cfg.Languages.Analyzers["ja"] != "cjk" || cfg.Languages.Analyzers["de"] != "german" {
+		t.Errorf("languages: got %+v", cfg.Languages)
+	}
+
+	if err := os.WriteFile(path, []byte("languages:\n  analyzers:\n    ja: \"\"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for a language without analyzer")
+	}
+}
+
+func TestLoad_indexWindows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("watch:\n  index_windows: [\"02:00-06:00\", \"22:00-23:30\"]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Watch.IndexWindows) != 2 || cfg.Watch.IndexWindows[0] != "02:00-06:00" {
+		t.Errorf("index windows: got %v", cfg.Watch.IndexWindows)
+	}
+
+	if err := os.WriteFile(path, []byte("watch:\n  index_windows: [\"2am-6am\"]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); err == nil {
+		t.Error("expected error for an invalid window")
+	}
+}
+
+func TestLoad_watchRules(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `watch:
+  directories: [./docs]
+  rules:
+    - root: ./docs/code
+      extensions: [".go"]
+      exclude: ["**/node_modules/**"]
+      max_file_size_mb: 2
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := filepath.Join(dir, "docs", "code")
+	if cfg.Watch.Rules[0].Root != code {
+		t.Errorf("rule root = %q, want %q", cfg.Watch.Rules[0].Root, code)
+	}
+	rules, err := cfg.Watch.PathRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := rules.For(filepath.Join(code, "main.go")); r == nil || r.MaxFileSize != 2<<20 {
+		t.Errorf("rule for main.go = %+v, want a 2 MB limit", r)
+	}
+
+	for name, body := range map[string]string{
+		"no root":       "watch:\n  rules:\n    - exclude: [\"tmp\"]\n",
+		"bad glob":      "watch:\n  rules:\n    - root: ./docs\n      exclude: [\"[a\"]\n",
+		"negative size": "watch:\n  rules:\n    - root: ./docs\n      max_file_size_mb: -1\n",
+	} {
+		if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestLoad_watchIgnore(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("watch:\n  directories: [./docs]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Watch.IgnoreFiles; len(got) != 2 || got[0] != ".gitignore" || got[1] != ".sagasuignore" {
+		t.Errorf("ignore files = %v, want the defaults", got)
+	}
+	if home, err := os.UserHomeDir(); err == nil && cfg.Watch.GlobalIgnore != filepath.Join(home, ".sagasuignore") {
+		t.Errorf("global ignore = %q, want ~/.sagasuignore", cfg.Watch.GlobalIgnore)
+	}
+
+	content := "watch:\n  ignore_files: []\n  global_ignore: none\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Watch.IgnoreFiles) != 0 || cfg.Watch.GlobalIgnore != "" {
+		t.Errorf("ignore files %v, global %q; want both disabled", cfg.Watch.IgnoreFiles, cfg.Watch.GlobalIgnore)
+	}
+	if ig, err := cfg.Watch.Ignore(); err != nil || ig != nil {
+		t.Errorf("Ignore() = %v, %v; want nil", ig, err)
+	}
+}
+
+func TestLoad_indexerLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("debug: false\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Indexer.MaxFileSize() != 100<<20 || !cfg.Indexer.SkipBinaryOrDefault() {
+		t.Errorf("max size %d, skip binary %v; want 100 MB and true", cfg.Indexer.MaxFileSize(), cfg.Indexer.SkipBinaryOrDefault())
+	}
+
+	content := "indexer:\n  max_file_size_mb: -1\n  skip_binary: false\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Indexer.MaxFileSize() != 0 || cfg.Indexer.SkipBinaryOrDefault() {
+		t.Errorf("max size %d, skip binary %v; want both disabled", cfg.Indexer.MaxFileSize(), cfg.Indexer.SkipBinaryOrDefault())
+	}
+}
+
+func TestLoad_vectorQuantization(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("vector:\n  quantization: pq\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Vector.Quantization != "pq" || cfg.Vector.RefineFactor != 4 {
+		t.Errorf("vector: got %+v", cfg.Vector)
+	}
+
+	for name, content := range map[string]string{
+		"unknown kind": "vector:\n  quantization: int4\n",
+		"not memory":   "vector:\n  index_type: hnsw\n  quantization: int8\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_vectorQdrant(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("vector:\n  index_type: qdrant\n  qdrant:\n    collection: docs\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := cfg.Vector.Qdrant
+	if !cfg.Vector.RemoteVector() || q.URL != "http://localhost:6333" || q.Collection != "docs" || q.TimeoutSeconds != 30 {
+		t.Errorf("qdrant: got %+v", q)
+	}
+}
+
+func TestLoad_keywordBackend(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := "storage:\n  database_path: ./db/documents.db\nkeyword:\n  backend: fts5\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "db", "keyword.db"); cfg.KeywordIndexPath() != want {
+		t.Errorf("keyword index path = %s, want %s", cfg.KeywordIndexPath(), want)
+	}
+
+	content = "keyword:\n  backend: opensearch\n  elasticsearch:\n    url: http://search:9200\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Keyword.RemoteKeyword() || cfg.KeywordIndexPath() != "" || cfg.Keyword.Elasticsearch.Index != "sagasu" {
+		t.Errorf("opensearch backend: remote %v, path %q, index %q", cfg.Keyword.RemoteKeyword(), cfg.KeywordIndexPath(), cfg.Keyword.Elasticsearch.Index)
+	}
+
+	for name, content := range map[string]string{
+		"unknown backend":    "keyword:\n  backend: lucene\n",
+		"stemming":           "keyword:\n  backend: fts5\nsearch:\n  stemming: english\n",
+		"keyword chunks":     "keyword:\n  backend: fts5\nsearch:\n  keyword_chunks: true\n",
+		"language analyzers": "keyword:\n  backend: fts5\nlanguages:\n  analyzers:\n    ja: cjk\n",
+		"missing url":        "keyword:\n  backend: elasticsearch\n",
+		"remote stemming":    "keyword:\n  backend: elasticsearch\n  elasticsearch:\n    url: http://search:9200\nsearch:\n  stemming: english\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_embeddingProvider(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "embedding:\n  provider: ollama\n  model: nomic-embed-text\n  dimensions: 768\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Embedding.IsRemote() || cfg.Embedding.Model != "nomic-embed-text" {
+		t.Errorf("embedding: got %+v", cfg.Embedding)
+	}
+
+	for name, content := range map[string]string{
+		"unknown provider": "embedding:\n  provider: cohere\n  model: x\n",
+		"missing model":    "embedding:\n  provider: openai\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_embeddingCachePath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte("storage:\n  database_path: ./db/documents.db\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "db", "embeddings.db"); cfg.Storage.EmbeddingCachePath != want {
+		t.Errorf("embedding_cache_path = %s, want %s", cfg.Storage.EmbeddingCachePath, want)
+	}
+
+	if err := os.WriteFile(path, []byte("storage:\n  embedding_cache_path: none\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Storage.EmbeddingCachePath != "" {
+		t.Errorf("none should disable the cache, got %q", cfg.Storage.EmbeddingCachePath)
+	}
+}
+
+func TestLoad_embeddingModels(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `embedding:
+  model_path: ./models/text.onnx
+  dimensions: 384
+embedding_models:
+  - name: code
+    extensions: [".GO", py]
+    embedding:
+      model_path: ./models/code.onnx
+      dimensions: 768
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.EmbeddingModels) != 1 {
+		t.Fatalf("embedding_models: got %d, want 1", len(cfg.EmbeddingModels))
+	}
+	m := cfg.EmbeddingModels[0]
+	if len(m.Extensions) != 2 || m.Extensions[0] != "go" || m.Extensions[1] != "py" {
+		t.Errorf("extensions should be normalized, got %v", m.Extensions)
+	}
+	if m.Embedding.ModelPath != filepath.Join(dir, "models", "code.onnx") || m.Embedding.Dimensions != 768 {
+		t.Errorf("embedding: got %+v", m.Embedding)
+	}
+	if m.Embedding.MaxTokens != cfg.Embedding.MaxTokens {
+		t.Errorf("max_tokens should be inherited: got %d, want %d", m.Embedding.MaxTokens, cfg.Embedding.MaxTokens)
+	}
+	if got := m.Embedding.ModelID(); got != "onnx:code.onnx" {
+		t.Errorf("ModelID() = %q", got)
+	}
+
+	for name, content := range map[string]string{
+		"missing name":        "embedding_models:\n  - extensions: [go]\n",
+		"missing extensions":  "embedding_models:\n  - name: code\n",
+		"duplicate extension": "embedding_models:\n  - name: a\n    extensions: [go]\n  - name: b\n    extensions: [.go]\n",
+		"collection name":     "collections:\n  - name: code\n    root: /src\nembedding_models:\n  - name: code\n    extensions: [go]\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_externalExtractors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "extract:\n  external:\n    - extensions: [\".DWG\", dxf]\n      command: [dwg2json]\n    - extensions: [sketch]\n      command: [sketch-text, --json]\n      timeout_seconds: 10\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x := cfg.Extract.External; len(x) != 2 || x[0].Extensions[0] != "dwg" || x[0].TimeoutSeconds != 120 || x[1].TimeoutSeconds != 10 {
+		t.Errorf("external: got %+v", x)
+	}
+
+	for name, content := range map[string]string{
+		"missing command":     "extract:\n  external:\n    - extensions: [dwg]\n",
+		"missing extensions":  "extract:\n  external:\n    - command: [dwg2json]\n",
+		"duplicate extension": "extract:\n  external:\n    - extensions: [dwg]\n      command: [a]\n    - extensions: [.DWG]\n      command: [b]\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_retention(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "retention:\n  policies:\n    - root: ./downloads\n      max_age_days: 180\n    - tag: draft\n      max_age_days: 30\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Retention.IntervalMinutes != 60 || len(cfg.Retention.Policies) != 2 {
+		t.Fatalf("retention: got %+v", cfg.Retention)
+	}
+	if want := filepath.Join(filepath.Dir(path), "downloads"); cfg.Retention.Policies[0].Root != want {
+		t.Errorf("root: got %q, want %q", cfg.Retention.Policies[0].Root, want)
+	}
+
+	for name, content := range map[string]string{
+		"no root or tag": "retention:\n  policies:\n    - max_age_days: 10\n",
+		"no max age":     "retention:\n  policies:\n    - root: /x\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_passwords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	t.Setenv("SAGASU_TEST_PDF_PASSWORD", "s3cret")
+	content := "passwords:\n  - pattern: ./finance/**\n    password: hunter2\n  - pattern: \"*.pdf\"\n    password_env: SAGASU_TEST_PDF_PASSWORD\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Passwords) != 2 {
+		t.Fatalf("passwords: got %+v", cfg.Passwords)
+	}
+	if want := filepath.Join(filepath.Dir(path), "finance", "**"); cfg.Passwords[0].Pattern != want {
+		t.Errorf("pattern: got %q, want %q", cfg.Passwords[0].Pattern, want)
+	}
+	if cfg.Passwords[1].Pattern != "*.pdf" || cfg.Passwords[1].Secret() != "s3cret" {
+		t.Errorf("second entry: got %+v", cfg.Passwords[1])
+	}
+
+	for name, content := range map[string]string{
+		"no pattern":    "passwords:\n  - password: x\n",
+		"bad pattern":   "passwords:\n  - pattern: \"[\"\n    password: x\n",
+		"no password":   "passwords:\n  - pattern: \"*.pdf\"\n",
+		"empty env var": "passwords:\n  - pattern: \"*.pdf\"\n    password_env: SAGASU_TEST_UNSET_PASSWORD\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_llm(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "llm:\n  provider: ollama\n  model: llama3.2\n  max_tokens: 400\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.LLM.Provider != "ollama" || cfg.LLM.Model != "llama3.2" || cfg.LLM.MaxTokens != 400 {
+		t.Errorf("llm: got %+v", cfg.LLM)
+	}
+
+	for name, content := range map[string]string{
+		"unknown provider": "llm:\n  provider: gemini\n  model: x\n",
+		"missing model":    "llm:\n  provider: openai\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad_searchTuning(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("search:\n  keyword_coverage_exponent: 0\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Search.KeywordFuzziness != 2 || cfg.Search.CoverageExponentOrDefault() != 0 {
+		t.Errorf("fuzziness %d, coverage exponent %v; want 2 and 0", cfg.Search.KeywordFuzziness, cfg.Search.CoverageExponentOrDefault())
+	}
+	if !cfg.Search.DedupeEnabledOrDefault() || cfg.Search.DedupeMaxDistance != 3 {
+		t.Errorf("dedupe %v with distance %d; want on with 3", cfg.Search.DedupeEnabledOrDefault(), cfg.Search.DedupeMaxDistance)
+	}
+	if cfg.Search.SemanticAggregation != "max" || cfg.Search.SemanticAggregationDecay != 0.5 {
+		t.Errorf("semantic aggregation %q with decay %v; want max with 0.5", cfg.Search.SemanticAggregation, cfg.Search.SemanticAggregationDecay)
+	}
+	if sq := cfg.Storage.SQLite; sq.JournalMode != "wal" || sq.Synchronous != "normal" || sq.BusyTimeoutMS != 5000 {
+		t.Errorf("sqlite pragmas %+v; want wal, normal, and 5000 ms", sq)
+	}
+	if cfg.Search.Stemming != "" || cfg.Search.StemmedBoost != 0.5 {
+		t.Errorf("stemming %q with boost %v; want off with 0.5", cfg.Search.Stemming, cfg.Search.StemmedBoost)
+	}
+	if (&SearchConfig{}).CoverageExponentOrDefault() != 2 {
+		t.Error("unset coverage exponent should default to 2")
+	}
+
+	if err := os.WriteFile(path, []byte("search:\n  stopwords: [draft]\n  protected_terms: [IT, k8s]\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Search.Stopwords) != 1 || len(cfg.Search.ProtectedTerms) != 2 || cfg.Search.ProtectedTerms[1] != "k8s" {
+		t.Errorf("stopwords %v, protected terms %v", cfg.Search.Stopwords, cfg.Search.ProtectedTerms)
+	}
+
+	for name, content := range map[string]string{
+		"fuzziness":         "search:\n  keyword_fuzziness: 3\n",
+		"negative exponent": "search:\n  keyword_coverage_exponent: -1\n",
+		"negative boost":    "search:\n  keyword_title_boost: -2\n",
+		"phrase slop":       "search:\n  keyword_phrase_slop: 21\n",
+		"cache similarity":  "search:\n  vector_cache_min_similarity: 1.5\n",
+		"dedupe distance":   "search:\n  dedupe_max_distance: 65\n",
+		"aggregation":       "search:\n  semantic_aggregation: sum\n",
+		"decay":             "search:\n  semantic_aggregation_decay: 1\n",
+		"stemmed boost":     "search:\n  stemmed_boost: 2\n",
+		"diversity lambda":  "search:\n  diversity_lambda: 1\n",
+		"journal mode":      "storage:\n  sqlite:\n    journal_mode: fast\n",
+		"synchronous":       "storage:\n  sqlite:\n    synchronous: sometimes\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSearchConfig_SuggestOnZeroResultsOrDefault(t *testing.T) {
+	if !(&SearchConfig{}).SuggestOnZeroResultsOrDefault() {
+		t.Error("unset suggest_on_zero_results should default to true")
+	}
+	off := false
+	if (&SearchConfig{SuggestOnZeroResults: &off}).SuggestOnZeroResultsOrDefault() {
+		t.Error("suggest_on_zero_results: false should turn suggestions off")
+	}
+}
+
+func TestLoad_apiKeys(t *testing.T) {
+	t.Setenv("SAGASU_TEST_KEY", "from-env")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `server:
+  host: 0.0.0.0
+  auth:
+    api_keys:
+      - name: laptop
+        key: s3cret
+        scope: write
+      - name: dashboard
+        key_env: SAGASU_TEST_KEY
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := cfg.Server.Auth.APIKeys
+	if len(keys) != 2 || keys[0].Scope != ScopeWrite || keys[1].Scope != ScopeRead {
+		t.Fatalf("api_keys: got %+v", keys)
+	}
+	if keys[1].Secret() != "from-env" || keys[1].Key != "" {
+		t.Errorf("key_env should be read on use, not stored: %+v, secret %q", keys[1], keys[1].Secret())
+	}
+
+	for name, content := range map[string]string{
+		"missing name":  "server:\n  auth:\n    api_keys:\n      - key: a\n",
+		"missing key":   "server:\n  auth:\n    api_keys:\n      - name: a\n",
+		"empty env":     "server:\n  auth:\n    api_keys:\n      - name: a\n        key_env: SAGASU_TEST_UNSET\n",
+		"duplicate key": "server:\n  auth:\n    api_keys:\n      - name: a\n        key: k\n      - name: b\n        key: k\n",
+		"bad scope":     "server:\n  auth:\n    api_keys:\n      - name: a\n        key: k\n        scope: admin\n",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -8,6 +8,11 @@ func ApplyDefaults(cfg *Config) {
 	if cfg.Server.Port == 0 {
 		cfg.Server.Port = 8080
 	}
+	for i := range cfg.Server.Auth.APIKeys {
+		if k := &cfg.Server.Auth.APIKeys[i]; k.Scope == "" {
+			k.Scope = ScopeRead
+		}
+	}
 	if cfg.Storage.DatabasePath == "" {
 		cfg.Storage.DatabasePath = "/usr/local/var/sagasu/data/db/documents.db"
 	}
@@ -17,6 +22,18 @@ func ApplyDefaults(cfg *Config) {
 	if cfg.Storage.FAISSIndexPath == "" {
 		cfg.Storage.FAISSIndexPath = "/usr/local/var/sagasu/data/indices/faiss"
 	}
+	if cfg.Storage.SQLite.JournalMode == "" {
+		cfg.Storage.SQLite.JournalMode = "wal"
+	}
+	if cfg.Storage.SQLite.Synchronous == "" {
+		cfg.Storage.SQLite.Synchronous = "normal"
+	}
+	if cfg.Storage.SQLite.BusyTimeoutMS == 0 {
+		cfg.Storage.SQLite.BusyTimeoutMS = 5000
+	}
+	if cfg.Embedding.Provider == "" {
+		cfg.Embedding.Provider = "onnx"
+	}
 	if cfg.Embedding.ModelPath == "" {
 		cfg.Embedding.ModelPath = "/usr/local/var/sagasu/data/models/all-MiniLM-L6-v2.onnx"
 	}
@@ -45,6 +62,9 @@ func ApplyDefaults(cfg *Config) {
 	if cfg.Search.ChunkOverlap == 0 {
 		cfg.Search.ChunkOverlap = 50
 	}
+	if cfg.Search.ChunkStrategy == "" {
+		cfg.Search.ChunkStrategy = "fixed"
+	}
 	if cfg.Search.TopKCandidates == 0 {
 		cfg.Search.TopKCandidates = 100
 	}
@@ -54,12 +74,61 @@ func ApplyDefaults(cfg *Config) {
 	if cfg.Search.KeywordPhraseBoost == 0 {
 		cfg.Search.KeywordPhraseBoost = 1.5 // boost for adjacent query terms (phrase match)
 	}
+	if cfg.Search.KeywordFuzziness == 0 {
+		cfg.Search.KeywordFuzziness = 2
+	}
 	if cfg.Search.DefaultMinKeywordScore == 0 {
 		cfg.Search.DefaultMinKeywordScore = 0 // disabled - let ranking handle relevance
 	}
 	if cfg.Search.DefaultMinSemanticScore == 0 {
 		cfg.Search.DefaultMinSemanticScore = 0.05
 	}
+	if cfg.Search.DefaultKeywordWeight == 0 && cfg.Search.DefaultSemanticWeight == 0 {
+		cfg.Search.DefaultKeywordWeight = 0.5
+		cfg.Search.DefaultSemanticWeight = 0.5
+	}
+	if cfg.Search.RRFK == 0 {
+		cfg.Search.RRFK = 60
+	}
+	if cfg.Search.HedgeLatencyMultiplier == 0 {
+		cfg.Search.HedgeLatencyMultiplier = 3
+	}
+	if cfg.Search.HedgeMinDelayMs == 0 {
+		cfg.Search.HedgeMinDelayMs = 50
+	}
+	if cfg.Search.RerankerTopK == 0 {
+		cfg.Search.RerankerTopK = 20
+	}
+	if cfg.Search.DocumentCacheSize == 0 {
+		cfg.Search.DocumentCacheSize = 1000
+	}
+	if cfg.Search.VectorCacheSize == 0 {
+		cfg.Search.VectorCacheSize = 256
+	}
+	if cfg.Search.VectorCacheMinSimilarity == 0 {
+		cfg.Search.VectorCacheMinSimilarity = 0.999
+	}
+	if cfg.Search.DedupeMaxDistance == 0 {
+		cfg.Search.DedupeMaxDistance = 3
+	}
+	if cfg.Search.SemanticAggregation == "" {
+		cfg.Search.SemanticAggregation = "max"
+	}
+	if cfg.Search.SemanticAggregationDecay == 0 {
+		cfg.Search.SemanticAggregationDecay = 0.5
+	}
+	if cfg.Search.StemmedBoost == 0 {
+		cfg.Search.StemmedBoost = 0.5
+	}
+	if cfg.Search.ConfidenceKeywordMidpoint == 0 {
+		cfg.Search.ConfidenceKeywordMidpoint = 1
+	}
+	if cfg.Search.ConfidenceSemanticMidpoint == 0 {
+		cfg.Search.ConfidenceSemanticMidpoint = 0.5
+	}
+	if cfg.Search.ConfidenceSemanticSteepness == 0 {
+		cfg.Search.ConfidenceSemanticSteepness = 10
+	}
 	if cfg.Watch.Extensions == nil {
 		cfg.Watch.Extensions = []string{".txt", ".md", ".rst", ".pdf", ".docx", ".xlsx", ".pptx", ".odp", ".ods"}
 	}
@@ -68,12 +137,141 @@ func ApplyDefaults(cfg *Config) {
 		t := true
 		cfg.Watch.Recursive = &t
 	}
+	if cfg.Watch.NoIndexMarker == "" {
+		cfg.Watch.NoIndexMarker = ".noindex"
+	}
+	if cfg.Watch.IgnoreFiles == nil {
+		cfg.Watch.IgnoreFiles = []string{".gitignore", ".sagasuignore"}
+	}
+	if cfg.Indexer.MaxFileSizeMB == 0 {
+		cfg.Indexer.MaxFileSizeMB = 100
+	}
 
 	// Apply ranking defaults
 	applyRankingDefaults(&cfg.Ranking)
 
 	// Apply vector defaults
 	applyVectorDefaults(&cfg.Vector)
+
+	// Apply keyword defaults
+	if cfg.Keyword.Backend == "" {
+		cfg.Keyword.Backend = "bleve"
+	}
+	if cfg.Keyword.Elasticsearch.Index == "" {
+		cfg.Keyword.Elasticsearch.Index = "sagasu"
+	}
+	if cfg.Keyword.Elasticsearch.TimeoutSeconds == 0 {
+		cfg.Keyword.Elasticsearch.TimeoutSeconds = 30
+	}
+
+	// Apply job queue defaults
+	applyJobsDefaults(&cfg.Jobs)
+
+	if cfg.Retention.IntervalMinutes == 0 {
+		cfg.Retention.IntervalMinutes = 60
+	}
+
+	// Apply extraction sandbox defaults
+	if cfg.Extract.MemoryMB == 0 {
+		cfg.Extract.MemoryMB = 1024
+	}
+	if cfg.Extract.CPUSeconds == 0 {
+		cfg.Extract.CPUSeconds = 60
+	}
+	if cfg.Extract.TimeoutSeconds == 0 {
+		cfg.Extract.TimeoutSeconds = 120
+	}
+	if cfg.Extract.MaxRows == 0 {
+		cfg.Extract.MaxRows = 10000
+	}
+	if cfg.Extract.Archives.MaxEntrySizeMB == 0 {
+		cfg.Extract.Archives.MaxEntrySizeMB = 20
+	}
+	if cfg.Extract.Archives.MaxTotalSizeMB == 0 {
+		cfg.Extract.Archives.MaxTotalSizeMB = 200
+	}
+	if cfg.Extract.Archives.MaxEntries == 0 {
+		cfg.Extract.Archives.MaxEntries = 1000
+	}
+	if cfg.Extract.Converter.TimeoutSeconds == 0 {
+		cfg.Extract.Converter.TimeoutSeconds = 120
+	}
+	for i := range cfg.Extract.External {
+		if cfg.Extract.External[i].TimeoutSeconds == 0 {
+			cfg.Extract.External[i].TimeoutSeconds = 120
+		}
+	}
+
+	// Collections inherit unset chunking and embedding settings
+	for i := range cfg.Collections {
+		applyCollectionDefaults(&cfg.Collections[i], cfg)
+	}
+	// Additional embedding models inherit unset embedding settings
+	for i := range cfg.EmbeddingModels {
+		inheritEmbedding(&cfg.EmbeddingModels[i].Embedding, &cfg.Embedding)
+	}
+}
+
+// applyCollectionDefaults fills a collection's unset chunking and embedding settings from
+// the global ones.
+func applyCollectionDefaults(col *CollectionConfig, cfg *Config) {
+	if col.ChunkSize == 0 {
+		col.ChunkSize = cfg.Search.ChunkSize
+	}
+	if col.ChunkOverlap == 0 {
+		col.ChunkOverlap = cfg.Search.ChunkOverlap
+	}
+	if col.ChunkStrategy == "" {
+		col.ChunkStrategy = cfg.Search.ChunkStrategy
+	}
+	if col.Embedding != nil {
+		inheritEmbedding(col.Embedding, &cfg.Embedding)
+	}
+}
+
+// inheritEmbedding fills the unset settings of an additional embedding model from the
+// global one. The endpoint and key are only inherited along with the provider.
+func inheritEmbedding(e, global *EmbeddingConfig) {
+	if e.Provider == "" {
+		e.Provider = global.Provider
+		if e.BaseURL == "" {
+			e.BaseURL = global.BaseURL
+		}
+		if e.APIKey == "" {
+			e.APIKey = global.APIKey
+		}
+	}
+	if e.Dimensions == 0 {
+		e.Dimensions = global.Dimensions
+	}
+	if e.MaxTokens == 0 {
+		e.MaxTokens = global.MaxTokens
+	}
+	if e.CacheSize == 0 {
+		e.CacheSize = global.CacheSize
+	}
+}
+
+// applyJobsDefaults sets default values for the background job queue.
+func applyJobsDefaults(cfg *JobsConfig) {
+	if cfg.Workers == 0 {
+		cfg.Workers = 2
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 2
+	}
+	if cfg.RetryBackoffMs == 0 {
+		cfg.RetryBackoffMs = 500
+	}
+	if cfg.History == 0 {
+		cfg.History = 1000
+	}
+	if cfg.EmbedQueueChunks == 0 {
+		cfg.EmbedQueueChunks = 2048
+	}
 }
 
 // applyVectorDefaults sets default values for vector configuration.
@@ -82,6 +280,21 @@ func applyVectorDefaults(cfg *VectorConfig) {
 		cfg.IndexType = "memory" // Default to in-memory index
 	}
 	// MaxVectors defaults to 0 (unlimited)
+	if cfg.WALCompactMB == 0 {
+		cfg.WALCompactMB = 64
+	}
+	if cfg.RefineFactor == 0 {
+		cfg.RefineFactor = 4
+	}
+	if cfg.Qdrant.URL == "" {
+		cfg.Qdrant.URL = "http://localhost:6333"
+	}
+	if cfg.Qdrant.Collection == "" {
+		cfg.Qdrant.Collection = "sagasu"
+	}
+	if cfg.Qdrant.TimeoutSeconds == 0 {
+		cfg.Qdrant.TimeoutSeconds = 30
+	}
 }
 
 // applyRankingDefaults sets default values for ranking configuration.
@@ -139,6 +352,9 @@ func applyRankingDefaults(cfg *RankingConfig) {
 	if cfg.StemmingMatchScore == 0 {
 		cfg.StemmingMatchScore = 55
 	}
+	if cfg.ProximityMatchScore == 0 {
+		cfg.ProximityMatchScore = 100
+	}
 
 	// Path scoring
 	if cfg.PathExactMatchScore == 0 {
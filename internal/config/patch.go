@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Patch changes the settings that take effect while the server runs (PATCH
+// /api/v1/config). Nil fields are left as they are; its JSON names are the config keys.
+type Patch struct {
+	Search *SearchPatch `json:"search,omitempty"`
+	Watch  *WatchPatch  `json:"watch,omitempty"`
+}
+
+// SearchPatch holds the search defaults and ranking settings a Patch can change.
+type SearchPatch struct {
+	DefaultMinKeywordScore  *float64 `json:"default_min_keyword_score,omitempty"`
+	DefaultMinSemanticScore *float64 `json:"default_min_semantic_score,omitempty"`
+	TopKCandidates          *int     `json:"top_k_candidates,omitempty"`
+	KeywordTitleBoost       *float64 `json:"keyword_title_boost,omitempty"`
+	KeywordPhraseBoost      *float64 `json:"keyword_phrase_boost,omitempty"`
+	KeywordPhraseSlop       *int     `json:"keyword_phrase_slop,omitempty"`
+	KeywordFuzziness        *int     `json:"keyword_fuzziness,omitempty"`
+	KeywordCoverageExponent *float64 `json:"keyword_coverage_exponent,omitempty"`
+	RankingEnabled          *bool    `json:"ranking_enabled,omitempty"`
+	DedupeEnabled           *bool    `json:"dedupe_enabled,omitempty"`
+	DedupeMaxDistance       *int     `json:"dedupe_max_distance,omitempty"`
+	DiversityLambda         *float64 `json:"diversity_lambda,omitempty"`
+	SuggestOnZeroResults    *bool    `json:"suggest_on_zero_results,omitempty"`
+}
+
+// WatchPatch holds the watch settings a Patch can change. An empty Extensions list
+// watches all files.
+type WatchPatch struct {
+	Extensions []string `json:"extensions,omitempty"`
+}
+
+// Apply returns a copy of cfg with the patch applied, validated as Load validates the
+// search settings. cfg is not changed.
+func (p *Patch) Apply(cfg *Config) (*Config, error) {
+	next := *cfg
+	if s := p.Search; s != nil {
+		set(&next.Search.DefaultMinKeywordScore, s.DefaultMinKeywordScore)
+		set(&next.Search.DefaultMinSemanticScore, s.DefaultMinSemanticScore)
+		set(&next.Search.TopKCandidates, s.TopKCandidates)
+		set(&next.Search.KeywordTitleBoost, s.KeywordTitleBoost)
+		set(&next.Search.KeywordPhraseBoost, s.KeywordPhraseBoost)
+		set(&next.Search.KeywordPhraseSlop, s.KeywordPhraseSlop)
+		set(&next.Search.KeywordFuzziness, s.KeywordFuzziness)
+		set(&next.Search.RankingEnabled, s.RankingEnabled)
+		set(&next.Search.DedupeMaxDistance, s.DedupeMaxDistance)
+		set(&next.Search.DiversityLambda, s.DiversityLambda)
+		if s.KeywordCoverageExponent != nil {
+			next.Search.KeywordCoverageExponent = s.KeywordCoverageExponent
+		}
+		if s.DedupeEnabled != nil {
+			next.Search.DedupeEnabled = s.DedupeEnabled
+		}
+		if s.SuggestOnZeroResults != nil {
+			next.Search.SuggestOnZeroResults = s.SuggestOnZeroResults
+		}
+		if next.Search.DefaultMinKeywordScore < 0 || next.Search.DefaultMinSemanticScore < 0 {
+			return nil, fmt.Errorf("search.default_min_keyword_score and default_min_semantic_score cannot be negative")
+		}
+		if next.Search.TopKCandidates < 1 {
+			return nil, fmt.Errorf("search.top_k_candidates must be positive, got %d", next.Search.TopKCandidates)
+		}
+		if err := validateSearch(&next.Search); err != nil {
+			return nil, err
+		}
+	}
+	if w := p.Watch; w != nil && w.Extensions != nil {
+		exts := make([]string, len(w.Extensions))
+		for i, ext := range w.Extensions {
+			if exts[i] = strings.TrimSpace(ext); exts[i] == "" || exts[i] == "." {
+				return nil, fmt.Errorf("watch.extensions: empty extension")
+			}
+		}
+		next.Watch.Extensions = exts
+	}
+	return &next, nil
+}
+
+// set stores *v in dst when v is not nil.
+func set[T any](dst *T, v *T) {
+	if v != nil {
+		*dst = *v
+	}
+}
+
+// redacted replaces secrets in Redacted.
+const redacted = "[redacted]"
+
+// secretKeys are the config keys holding secrets: API keys, passwords, and the LLM,
+// embedding, and remote index credentials.
+var secretKeys = map[string]bool{"key": true, "api_key": true, "password": true}
+
+// Redacted returns the config as a tree of its YAML keys and values, e.g. for GET
+// /api/v1/config, with secrets replaced by "[redacted]".
+func (c *Config) Redacted() (map[string]interface{}, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	var tree map[string]interface{}
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	redact(tree)
+	return tree, nil
+}
+
+// redact replaces the non-empty values of secretKeys in v, recursively.
+func redact(v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			if s, ok := val.(string); ok && secretKeys[k] && s != "" {
+				v[k] = redacted
+				continue
+			}
+			redact(val)
+		}
+	case []interface{}:
+		for _, val := range v {
+			redact(val)
+		}
+	}
+}
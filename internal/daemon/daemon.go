@@ -0,0 +1,306 @@
+// Package daemon runs the server as a service of the current user: a launchd agent on
+// macOS and a systemd user unit on Linux. The service manager starts the server at login
+// and restarts it when it fails.
+package daemon
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/instance"
+)
+
+const (
+	// Label names the launchd agent.
+	Label = "com.hyperjump.sagasu"
+	// UnitName names the systemd user unit.
+	UnitName = "sagasu.service"
+)
+
+// ErrUnsupported is returned by New on systems without a supported service manager.
+var ErrUnsupported = errors.New("running as a service is supported on macOS (launchd) and Linux (systemd)")
+
+// Service is the server's service definition for the current user.
+type Service struct {
+	Executable string // absolute path of the sagasu binary
+	ConfigPath string // config file passed to "sagasu server"
+	PIDFile    string // pidfile the server writes
+	LogFile    string // where launchd writes the server's output; systemd uses the journal
+
+	goos string
+	home string
+	uid  int
+	// run runs a service manager command; replaced in tests.
+	run func(name string, args ...string) error
+}
+
+// Status describes the service and its server.
+type Status struct {
+	Installed bool   `json:"installed"`
+	Path      string `json:"path"` // service definition file
+	PID       int    `json:"pid"`  // 0 when the server is not running
+}
+
+// New returns the service that runs executable with configPath, writing pidFile and,
+// under launchd, logFile.
+func New(executable, configPath, pidFile, logFile string) (*Service, error) {
+	if runtime.GOOS != "darwin" && runtime.GOOS != "linux" {
+		return nil, ErrUnsupported
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		Executable: executable,
+		ConfigPath: configPath,
+		PIDFile:    pidFile,
+		LogFile:    logFile,
+		goos:       runtime.GOOS,
+		home:       home,
+		uid:        os.Getuid(),
+		run:        runCommand,
+	}, nil
+}
+
+// runCommand runs name, including its output in the error when it fails.
+func runCommand(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, msg)
+		}
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// Path returns the service definition file: the agent's plist or the unit file.
+func (s *Service) Path() string {
+	if s.goos == "darwin" {
+		return filepath.Join(s.home, "Library", "LaunchAgents", Label+".plist")
+	}
+	return filepath.Join(s.home, ".config", "systemd", "user", UnitName)
+}
+
+// Log returns where the server's output goes: the log file under launchd, or the command
+// that shows it from the journal under systemd.
+func (s *Service) Log() string {
+	if s.goos == "darwin" {
+		return s.LogFile
+	}
+	return "journalctl --user -u " + UnitName
+}
+
+// Definition returns the contents of the service definition file.
+func (s *Service) Definition() string {
+	args := []string{s.Executable, "server", "--config", s.ConfigPath, "--pidfile", s.PIDFile}
+	if s.goos == "darwin" {
+		return launchdPlist(args, s.LogFile)
+	}
+	return systemdUnit(args)
+}
+
+// launchdPlist returns an agent that starts args at login and again when it fails.
+func launchdPlist(args []string, logFile string) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>` + Label + `</string>
+	<key>ProgramArguments</key>
+	<array>
+`)
+	for _, arg := range args {
+		b.WriteString("\t\t<string>" + xmlEscape(arg) + "</string>\n")
+	}
+	b.WriteString(`	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+`)
+	if logFile != "" {
+		b.WriteString("\t<key>StandardOutPath</key>\n\t<string>" + xmlEscape(logFile) + "</string>\n")
+		b.WriteString("\t<key>StandardErrorPath</key>\n\t<string>" + xmlEscape(logFile) + "</string>\n")
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return b.String()
+}
+
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// systemdUnit returns a user unit that starts args at login and again when it fails.
+func systemdUnit(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = systemdQuote(arg)
+	}
+	return `[Unit]
+Description=Sagasu local search server
+
+[Service]
+Type=simple
+ExecStart=` + strings.Join(quoted, " ") + `
+Restart=on-failure
+RestartSec=5
+
+[Install]
+WantedBy=default.target
+`
+}
+
+// systemdQuote quotes arg for ExecStart=, where "%" starts a specifier.
+func systemdQuote(arg string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%")
+	return `"` + r.Replace(arg) + `"`
+}
+
+// Installed reports whether the service definition file exists.
+func (s *Service) Installed() bool {
+	_, err := os.Stat(s.Path())
+	return err == nil
+}
+
+// install writes the service definition and reports whether it changed.
+func (s *Service) install() (bool, error) {
+	def := []byte(s.Definition())
+	if old, err := os.ReadFile(s.Path()); err == nil && bytes.Equal(old, def) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path()), 0755); err != nil {
+		return false, fmt.Errorf("failed to create service directory: %w", err)
+	}
+	if s.LogFile != "" {
+		if err := os.MkdirAll(filepath.Dir(s.LogFile), 0755); err != nil {
+			return false, fmt.Errorf("failed to create log directory: %w", err)
+		}
+	}
+	if err := os.WriteFile(s.Path(), def, 0644); err != nil {
+		return false, fmt.Errorf("failed to write service definition: %w", err)
+	}
+	return true, nil
+}
+
+// Start installs the service, replacing an outdated definition, enables it at login, and
+// starts the server unless it is running with the current definition.
+func (s *Service) Start() error {
+	return s.start(false)
+}
+
+// Stop stops the server. It starts again at the next login or with Start.
+func (s *Service) Stop() error {
+	if !s.Installed() {
+		return nil
+	}
+	if s.goos == "darwin" {
+		if !s.loaded() {
+			return nil
+		}
+		return s.run("launchctl", "bootout", s.target())
+	}
+	return s.run("systemctl", "--user", "stop", UnitName)
+}
+
+// Restart installs the service like Start and starts the server again, stopping it first
+// when it is running.
+func (s *Service) Restart() error {
+	return s.start(true)
+}
+
+func (s *Service) start(restart bool) error {
+	changed, err := s.install()
+	if err != nil {
+		return err
+	}
+	if s.goos == "darwin" {
+		if s.loaded() {
+			if !changed && restart {
+				return s.run("launchctl", "kickstart", "-k", s.target())
+			}
+			if !changed {
+				return s.run("launchctl", "kickstart", s.target())
+			}
+			if err := s.run("launchctl", "bootout", s.target()); err != nil {
+				return err
+			}
+		}
+		return s.run("launchctl", "bootstrap", s.domain(), s.Path())
+	}
+	if changed {
+		if err := s.run("systemctl", "--user", "daemon-reload"); err != nil {
+			return err
+		}
+	}
+	if err := s.run("systemctl", "--user", "enable", UnitName); err != nil {
+		return err
+	}
+	if changed || restart {
+		// A server started from an old definition keeps running under "start".
+		return s.run("systemctl", "--user", "restart", UnitName)
+	}
+	return s.run("systemctl", "--user", "start", UnitName)
+}
+
+// Uninstall stops the server and removes the service definition.
+func (s *Service) Uninstall() error {
+	if !s.Installed() {
+		return nil
+	}
+	if err := s.Stop(); err != nil {
+		return err
+	}
+	if s.goos == "linux" {
+		if err := s.run("systemctl", "--user", "disable", UnitName); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(s.Path()); err != nil {
+		return fmt.Errorf("failed to remove service definition: %w", err)
+	}
+	if s.goos == "linux" {
+		return s.run("systemctl", "--user", "daemon-reload")
+	}
+	return nil
+}
+
+// Status reports whether the service is installed and the server in the pidfile running.
+func (s *Service) Status() (*Status, error) {
+	pid, err := instance.ReadPIDFile(s.PIDFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Status{Installed: s.Installed(), Path: s.Path(), PID: pid}, nil
+}
+
+// loaded reports whether launchd has loaded the agent.
+func (s *Service) loaded() bool {
+	return s.run("launchctl", "print", s.target()) == nil
+}
+
+// domain is the launchd domain of the user's GUI session.
+func (s *Service) domain() string {
+	return "gui/" + strconv.Itoa(s.uid)
+}
+
+// target names the agent in the user's launchd domain.
+func (s *Service) target() string {
+	return s.domain() + "/" + Label
+}
@@ -0,0 +1,192 @@
+package daemon
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeService returns a service for goos under a temporary home that records the service
+// manager commands it runs. Commands in failing fail.
+func fakeService(t *testing.T, goos string, failing ...string) (*Service, *[]string) {
+	t.Helper()
+	home := t.TempDir()
+	var ran []string
+	s := &Service{
+		Executable: "/usr/local/bin/sagasu",
+		ConfigPath: "/Users/me/My Config/config.yaml",
+		PIDFile:    filepath.Join(home, "data", "sagasu.pid"),
+		LogFile:    filepath.Join(home, "data", "sagasu.log"),
+		goos:       goos,
+		home:       home,
+		uid:        501,
+	}
+	s.run = func(name string, args ...string) error {
+		cmd := strings.Join(append([]string{name}, args...), " ")
+		ran = append(ran, cmd)
+		for _, f := range failing {
+			if cmd == f {
+				return errors.New("failed")
+			}
+		}
+		return nil
+	}
+	return s, &ran
+}
+
+func TestDefinition_launchd(t *testing.T) {
+	s, _ := fakeService(t, "darwin")
+	s.ConfigPath = "/Users/me/R&D/config.yaml"
+	if want := filepath.Join(s.home, "Library", "LaunchAgents", "com.hyperjump.sagasu.plist"); s.Path() != want {
+		t.Errorf("Path: got %s, want %s", s.Path(), want)
+	}
+	def := s.Definition()
+	for _, want := range []string{
+		"<string>com.hyperjump.sagasu</string>",
+		"<string>/usr/local/bin/sagasu</string>\n\t\t<string>server</string>",
+		"<string>/Users/me/R&amp;D/config.yaml</string>",
+		"<string>--pidfile</string>\n\t\t<string>" + s.PIDFile + "</string>",
+		"<key>StandardErrorPath</key>\n\t<string>" + s.LogFile + "</string>",
+	} {
+		if !strings.Contains(def, want) {
+			t.Errorf("plist missing %q:\n%s", want, def)
+		}
+	}
+}
+
+func TestDefinition_systemd(t *testing.T) {
+	s, _ := fakeService(t, "linux")
+	s.ConfigPath = `/home/me/100% "real"/config.yaml`
+	if want := filepath.Join(s.home, ".config", "systemd", "user", "sagasu.service"); s.Path() != want {
+		t.Errorf("Path: got %s, want %s", s.Path(), want)
+	}
+	def := s.Definition()
+	want := `ExecStart="/usr/local/bin/sagasu" "server" "--config" "/home/me/100%% \"real\"/config.yaml" "--pidfile" "` + s.PIDFile + `"`
+	if !strings.Contains(def, want+"\n") {
+		t.Errorf("unit missing %q:\n%s", want, def)
+	}
+	if !strings.Contains(def, "WantedBy=default.target") {
+		t.Errorf("unit not enabled at login:\n%s", def)
+	}
+}
+
+func TestStart_systemd(t *testing.T) {
+	s, ran := fakeService(t, "linux")
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if !s.Installed() {
+		t.Fatal("unit not written")
+	}
+	want := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable sagasu.service",
+		"systemctl --user restart sagasu.service",
+	}
+	if !reflect.DeepEqual(*ran, want) {
+		t.Errorf("first start: got %q, want %q", *ran, want)
+	}
+
+	// An unchanged unit is only started.
+	*ran = nil
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{"systemctl --user enable sagasu.service", "systemctl --user start sagasu.service"}
+	if !reflect.DeepEqual(*ran, want) {
+		t.Errorf("second start: got %q, want %q", *ran, want)
+	}
+
+	*ran = nil
+	if err := s.Uninstall(); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"systemctl --user stop sagasu.service",
+		"systemctl --user disable sagasu.service",
+		"systemctl --user daemon-reload",
+	}
+	if !reflect.DeepEqual(*ran, want) {
+		t.Errorf("uninstall: got %q, want %q", *ran, want)
+	}
+	if s.Installed() {
+		t.Error("unit left after uninstall")
+	}
+}
+
+func TestStart_launchd(t *testing.T) {
+	// Not loaded yet: "launchctl print" fails.
+	s, ran := fakeService(t, "darwin", "launchctl print gui/501/com.hyperjump.sagasu")
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"launchctl print gui/501/com.hyperjump.sagasu",
+		"launchctl bootstrap gui/501 " + s.Path(),
+	}
+	if !reflect.DeepEqual(*ran, want) {
+		t.Errorf("first start: got %q, want %q", *ran, want)
+	}
+	if _, err := os.Stat(filepath.Dir(s.LogFile)); err != nil {
+		t.Errorf("log directory not created: %v", err)
+	}
+
+	// Loaded with the current plist: restart in place.
+	loaded, ran := fakeService(t, "darwin")
+	loaded.home, loaded.PIDFile, loaded.LogFile = s.home, s.PIDFile, s.LogFile
+	if err := loaded.Restart(); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"launchctl print gui/501/com.hyperjump.sagasu",
+		"launchctl kickstart -k gui/501/com.hyperjump.sagasu",
+	}
+	if !reflect.DeepEqual(*ran, want) {
+		t.Errorf("restart: got %q, want %q", *ran, want)
+	}
+
+	// A changed plist is reloaded.
+	*ran = nil
+	loaded.ConfigPath = "/Users/me/other.yaml"
+	if err := loaded.Start(); err != nil {
+		t.Fatal(err)
+	}
+	want = []string{
+		"launchctl print gui/501/com.hyperjump.sagasu",
+		"launchctl bootout gui/501/com.hyperjump.sagasu",
+		"launchctl bootstrap gui/501 " + s.Path(),
+	}
+	if !reflect.DeepEqual(*ran, want) {
+		t.Errorf("start with changed plist: got %q, want %q", *ran, want)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	s, _ := fakeService(t, "linux")
+	st, err := s.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Installed || st.PID != 0 {
+		t.Errorf("before start: got %+v", st)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.PIDFile), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.PIDFile, []byte("2147483646\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	st, err = s.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Installed || st.Path != s.Path() || st.PID != 0 {
+		t.Errorf("installed with a stale pidfile: got %+v", st)
+	}
+}
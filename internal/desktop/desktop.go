@@ -0,0 +1,26 @@
+// Package desktop hands files to the desktop environment of the machine Sagasu runs on.
+package desktop
+
+import (
+	"os/exec"
+	"runtime"
+)
+
+// Open opens path with the desktop's default application. It returns once the opener
+// has started.
+func Open(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", path)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", path)
+	default:
+		cmd = exec.Command("xdg-open", path)
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
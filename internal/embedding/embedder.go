@@ -1,7 +1,10 @@
 // Package embedding provides text embedding via ONNX and caching.
 package embedding
 
-import "context"
+import (
+	"context"
+	"math"
+)
 
 // Embedder produces vector embeddings for text.
 type Embedder interface {
@@ -10,3 +13,18 @@ type Embedder interface {
 	Dimensions() int
 	Close() error
 }
+
+// NormalizeL2Slice normalizes the slice in place to unit L2 norm.
+func NormalizeL2Slice(x []float32) {
+	var sum float32
+	for _, v := range x {
+		sum += v * v
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(1.0 / math.Sqrt(float64(sum)))
+	for i := range x {
+		x[i] *= norm
+	}
+}
@@ -7,7 +7,6 @@ package embedding
 import (
 	"context"
 	"fmt"
-	"math"
 	"sync"
 
 	ort "github.com/yalue/onnxruntime_go"
@@ -172,18 +171,3 @@ func (e *ONNXEmbedder) Close() error {
 	}
 	return err
 }
-
-// NormalizeL2Slice normalizes the slice in place to unit L2 norm.
-func NormalizeL2Slice(x []float32) {
-	var sum float32
-	for _, v := range x {
-		sum += v * v
-	}
-	if sum == 0 {
-		return
-	}
-	norm := float32(1.0 / math.Sqrt(float64(sum)))
-	for i := range x {
-		x[i] *= norm
-	}
-}
@@ -0,0 +1,86 @@
+package embedding
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// PersistentCache stores embeddings by key across runs (e.g. storage.EmbeddingCacheStore).
+type PersistentCache interface {
+	GetEmbeddings(ctx context.Context, keys []string) (map[string][]float32, error)
+	PutEmbeddings(ctx context.Context, embeddings map[string][]float32) error
+}
+
+// PersistentCachedEmbedder wraps an Embedder with a PersistentCache, so re-indexing text
+// that was embedded before (such as unchanged chunks after an index rebuild) skips the
+// model. Entries are keyed by a hash of the model identity and the text; a cache that
+// fails to read or write is treated as empty, so it never fails embedding.
+type PersistentCachedEmbedder struct {
+	Embedder
+	cache PersistentCache
+	model string
+}
+
+// NewPersistentCachedEmbedder wraps e. model identifies the embedding model (e.g. its path
+// or name and dimensions) so that changing the model does not reuse stale embeddings.
+func NewPersistentCachedEmbedder(e Embedder, cache PersistentCache, model string) *PersistentCachedEmbedder {
+	return &PersistentCachedEmbedder{Embedder: e, cache: cache, model: model}
+}
+
+// Embed returns the embedding for text, using the persistent cache when available.
+func (e *PersistentCachedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch returns the embeddings for texts, embedding only those not in the cache.
+func (e *PersistentCachedEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	keys := make([]string, len(texts))
+	for i, text := range texts {
+		keys[i] = e.key(text)
+	}
+	cached, err := e.cache.GetEmbeddings(ctx, keys)
+	if err != nil {
+		cached = nil
+	}
+
+	embeddings := make([][]float32, len(texts))
+	var missing []int
+	var missingTexts []string
+	for i, key := range keys {
+		if v, ok := cached[key]; ok && len(v) == e.Dimensions() {
+			embeddings[i] = v
+		} else {
+			missing = append(missing, i)
+			missingTexts = append(missingTexts, texts[i])
+		}
+	}
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	computed, err := e.Embedder.EmbedBatch(ctx, missingTexts)
+	if err != nil {
+		return nil, err
+	}
+	fresh := make(map[string][]float32, len(missing))
+	for j, i := range missing {
+		embeddings[i] = computed[j]
+		fresh[keys[i]] = computed[j]
+	}
+	_ = e.cache.PutEmbeddings(ctx, fresh)
+	return embeddings, nil
+}
+
+// key is the cache key for text under the wrapped model.
+func (e *PersistentCachedEmbedder) key(text string) string {
+	h := sha256.New()
+	h.Write([]byte(e.model))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,67 @@
+package embedding
+
+import (
+	"context"
+	"testing"
+)
+
+type mapCache map[string][]float32
+
+func (m mapCache) GetEmbeddings(_ context.Context, keys []string) (map[string][]float32, error) {
+	found := make(map[string][]float32)
+	for _, k := range keys {
+		if v, ok := m[k]; ok {
+			found[k] = v
+		}
+	}
+	return found, nil
+}
+
+func (m mapCache) PutEmbeddings(_ context.Context, embeddings map[string][]float32) error {
+	for k, v := range embeddings {
+		m[k] = v
+	}
+	return nil
+}
+
+type countingEmbedder struct {
+	*MockEmbedder
+	texts int
+}
+
+func (e *countingEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	e.texts += len(texts)
+	return e.MockEmbedder.EmbedBatch(ctx, texts)
+}
+
+func TestPersistentCachedEmbedder(t *testing.T) {
+	ctx := context.Background()
+	cache := mapCache{}
+	first := &countingEmbedder{MockEmbedder: NewMockEmbedder(8)}
+	if _, err := NewPersistentCachedEmbedder(first, cache, "m1").EmbedBatch(ctx, []string{"a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new embedder over the same cache, as after a restart or index rebuild.
+	second := &countingEmbedder{MockEmbedder: NewMockEmbedder(8)}
+	e := NewPersistentCachedEmbedder(second, cache, "m1")
+	got, err := e.EmbedBatch(ctx, []string{"b", "c", "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.texts != 1 {
+		t.Errorf("embedded %d texts, want only the uncached one", second.texts)
+	}
+	want, _ := second.MockEmbedder.Embed(ctx, "a")
+	if len(got) != 3 || got[2][0] != want[0] {
+		t.Errorf("cached embedding for a does not match")
+	}
+
+	other := &countingEmbedder{MockEmbedder: NewMockEmbedder(8)}
+	if _, err := NewPersistentCachedEmbedder(other, cache, "m2").Embed(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if other.texts != 1 {
+		t.Error("a different model must not reuse cached embeddings")
+	}
+}
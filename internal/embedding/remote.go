@@ -0,0 +1,283 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Remote embedding providers.
+const (
+	// ProviderOllama is a local Ollama server (POST /api/embed).
+	ProviderOllama = "ollama"
+	// ProviderOpenAI is any OpenAI-compatible embeddings endpoint (POST /embeddings).
+	ProviderOpenAI = "openai"
+)
+
+// Default remote embedder settings.
+const (
+	DefaultOllamaURL        = "http://localhost:11434"
+	DefaultOpenAIURL        = "https://api.openai.com/v1"
+	DefaultRemoteBatchSize  = 32
+	DefaultRemoteMaxRetries = 3
+	DefaultRemoteTimeout    = 30 * time.Second
+)
+
+// RemoteConfig configures a RemoteEmbedder. Zero values use the defaults.
+type RemoteConfig struct {
+	Provider   string // ProviderOllama or ProviderOpenAI
+	BaseURL    string
+	Model      string
+	APIKey     string // sent as a bearer token when set
+	Dimensions int
+	// BatchSize is the most texts sent in one request.
+	BatchSize int
+	// MaxRetries is how many times a request failing with a network error, 429, or 5xx
+	// is retried; a negative value disables retry.
+	MaxRetries int
+	// RequestsPerMinute spaces requests out evenly; 0 means no limit.
+	RequestsPerMinute int
+	Timeout           time.Duration
+	CacheSize         int
+}
+
+// RemoteEmbedder gets embeddings from an HTTP embedding service, so semantic search works
+// without a local ONNX model. Texts are sent in batches, failed requests are retried with
+// exponential backoff (honoring Retry-After), and requests can be rate limited. Embeddings
+// are normalized to unit length and cached by text.
+type RemoteEmbedder struct {
+	cfg     RemoteConfig
+	client  *http.Client
+	cache   *EmbeddingCache
+	backoff time.Duration
+
+	limitMu sync.Mutex
+	next    time.Time // earliest start of the next request when rate limited
+}
+
+// NewRemoteEmbedder creates an embedder for cfg.Provider.
+func NewRemoteEmbedder(cfg RemoteConfig) (*RemoteEmbedder, error) {
+	switch cfg.Provider {
+	case ProviderOllama:
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = DefaultOllamaURL
+		}
+	case ProviderOpenAI:
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = DefaultOpenAIURL
+		}
+	default:
+		return nil, fmt.Errorf("unknown embedding provider: %s (supported: ollama, openai)", cfg.Provider)
+	}
+	if cfg.Model == "" {
+		return nil, errors.New("embedding model is required")
+	}
+	if cfg.Dimensions <= 0 {
+		return nil, errors.New("dimensions must be positive")
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultRemoteBatchSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultRemoteMaxRetries
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultRemoteTimeout
+	}
+	if cfg.CacheSize <= 0 {
+		cfg.CacheSize = 10000
+	}
+	return &RemoteEmbedder{
+		cfg:     cfg,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		cache:   NewEmbeddingCache(cfg.CacheSize),
+		backoff: 500 * time.Millisecond,
+	}, nil
+}
+
+// Embed returns the embedding for text, using cache when available.
+func (e *RemoteEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedBatch returns the embeddings for texts, requesting the uncached ones in batches.
+func (e *RemoteEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings := make([][]float32, len(texts))
+	var missing []int
+	for i, text := range texts {
+		if cached, ok := e.cache.Get(text); ok {
+			embeddings[i] = cached
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	for start := 0; start < len(missing); start += e.cfg.BatchSize {
+		batch := missing[start:min(start+e.cfg.BatchSize, len(missing))]
+		inputs := make([]string, len(batch))
+		for j, i := range batch {
+			inputs[j] = texts[i]
+		}
+		vectors, err := e.request(ctx, inputs)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range batch {
+			NormalizeL2Slice(vectors[j])
+			e.cache.Set(texts[i], vectors[j])
+			embeddings[i] = vectors[j]
+		}
+	}
+	return embeddings, nil
+}
+
+// Dimensions returns the embedding dimension.
+func (e *RemoteEmbedder) Dimensions() int {
+	return e.cfg.Dimensions
+}
+
+// Close releases idle connections.
+func (e *RemoteEmbedder) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// request embeds inputs with one API call, retrying transient failures.
+func (e *RemoteEmbedder) request(ctx context.Context, inputs []string) ([][]float32, error) {
+	url := e.cfg.BaseURL + "/embeddings"
+	if e.cfg.Provider == ProviderOllama {
+		url = e.cfg.BaseURL + "/api/embed"
+	}
+	body, err := json.Marshal(map[string]interface{}{"model": e.cfg.Model, "input": inputs})
+	if err != nil {
+		return nil, err
+	}
+	backoff := e.backoff
+	for attempt := 0; ; attempt++ {
+		if err := e.wait(ctx); err != nil {
+			return nil, err
+		}
+		vectors, retryAfter, err := e.post(ctx, url, body)
+		if err == nil {
+			return e.checkVectors(vectors, len(inputs))
+		}
+		if retryAfter < 0 || attempt >= e.cfg.MaxRetries || ctx.Err() != nil {
+			return nil, err
+		}
+		delay := backoff
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// post sends one request. retryAfter is negative when the error is not worth retrying,
+// and positive when the server said how long to wait.
+func (e *RemoteEmbedder) post(ctx context.Context, url string, body []byte) (vectors [][]float32, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, -1, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.cfg.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.cfg.APIKey)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err := fmt.Errorf("embedding service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return nil, -1, err
+		}
+		if secs, convErr := strconv.Atoi(resp.Header.Get("Retry-After")); convErr == nil && secs > 0 {
+			return nil, time.Duration(secs) * time.Second, err
+		}
+		return nil, 0, err
+	}
+
+	if e.cfg.Provider == ProviderOllama {
+		var out struct {
+			Embeddings [][]float32 `json:"embeddings"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			return nil, -1, fmt.Errorf("decode embedding response: %w", err)
+		}
+		return out.Embeddings, 0, nil
+	}
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, -1, fmt.Errorf("decode embedding response: %w", err)
+	}
+	vectors = make([][]float32, len(out.Data))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(vectors) {
+			return nil, -1, fmt.Errorf("embedding response index %d out of range", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, 0, nil
+}
+
+// checkVectors verifies the service returned one embedding of the configured size per input.
+func (e *RemoteEmbedder) checkVectors(vectors [][]float32, n int) ([][]float32, error) {
+	if len(vectors) != n {
+		return nil, fmt.Errorf("embedding service returned %d embeddings for %d inputs", len(vectors), n)
+	}
+	for _, v := range vectors {
+		if len(v) != e.cfg.Dimensions {
+			return nil, fmt.Errorf("embedding has %d dimensions, expected %d (set embedding.dimensions to match the model)", len(v), e.cfg.Dimensions)
+		}
+	}
+	return vectors, nil
+}
+
+// wait blocks until the rate limit allows another request.
+func (e *RemoteEmbedder) wait(ctx context.Context) error {
+	if e.cfg.RequestsPerMinute <= 0 {
+		return nil
+	}
+	interval := time.Minute / time.Duration(e.cfg.RequestsPerMinute)
+	e.limitMu.Lock()
+	now := time.Now()
+	start := e.next
+	if start.Before(now) {
+		start = now
+	}
+	e.next = start.Add(interval)
+	e.limitMu.Unlock()
+	if d := time.Until(start); d > 0 {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
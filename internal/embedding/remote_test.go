@@ -0,0 +1,110 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRemoteEmbedder_openAI(t *testing.T) {
+	var calls, failures atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if failures.Add(1) == 1 {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+			return
+		}
+		calls.Add(1)
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		type item struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		}
+		var data []item
+		for i := len(req.Input) - 1; i >= 0; i-- { // out of order on purpose
+			data = append(data, item{Index: i, Embedding: []float32{float32(len(req.Input[i])), 0, 0}})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	defer srv.Close()
+
+	e, err := NewRemoteEmbedder(RemoteConfig{
+		Provider: ProviderOpenAI, BaseURL: srv.URL + "/v1/", Model: "m", APIKey: "secret",
+		Dimensions: 3, BatchSize: 2,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e.backoff = time.Millisecond
+	got, err := e.EmbedBatch(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0][0] != 1 || got[2][0] != 1 {
+		t.Fatalf("embeddings should be normalized and in input order, got %v", got)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("3 texts in batches of 2: got %d successful requests, want 2", calls.Load())
+	}
+	if _, err := e.Embed(context.Background(), "bb"); err != nil || calls.Load() != 2 {
+		t.Errorf("cached text should not be requested again (err %v, %d requests)", err, calls.Load())
+	}
+}
+
+func TestRemoteEmbedder_ollama(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": [][]float32{{3, 4}}})
+	}))
+	defer srv.Close()
+
+	e, _ := NewRemoteEmbedder(RemoteConfig{Provider: ProviderOllama, BaseURL: srv.URL, Model: "nomic-embed-text", Dimensions: 2})
+	got, err := e.Embed(context.Background(), "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got[0] != 0.6 || got[1] != 0.8 {
+		t.Errorf("got %v, want [0.6 0.8]", got)
+	}
+
+	wrongDims, _ := NewRemoteEmbedder(RemoteConfig{Provider: ProviderOllama, BaseURL: srv.URL, Model: "m", Dimensions: 384})
+	if _, err := wrongDims.Embed(context.Background(), "hello"); err == nil || !strings.Contains(err.Error(), "dimensions") {
+		t.Errorf("expected dimension mismatch error, got %v", err)
+	}
+}
+
+func TestRemoteEmbedder_noRetryOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	e, _ := NewRemoteEmbedder(RemoteConfig{Provider: ProviderOpenAI, BaseURL: srv.URL, Model: "m", Dimensions: 3})
+	e.backoff = time.Millisecond
+	if _, err := e.Embed(context.Background(), "x"); err == nil {
+		t.Fatal("expected error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("401 should not be retried, got %d requests", calls.Load())
+	}
+	if _, err := NewRemoteEmbedder(RemoteConfig{Provider: "cohere", Model: "m", Dimensions: 3}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
@@ -0,0 +1,283 @@
+package embedding
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxWordPieceRunes is the longest word WordPiece splits; longer words become [UNK], as
+// in BERT.
+const maxWordPieceRunes = 100
+
+// WordPiece is the tokenizer of BERT-style models: text is split into words and
+// punctuation, and each word into the longest pieces of the model's vocabulary, pieces
+// after the first carrying the "##" prefix. Token IDs are the vocabulary's, so they match
+// what the model was trained on.
+type WordPiece struct {
+	vocab     map[string]int64
+	prefix    string // continuing subword prefix, "##"
+	lowercase bool   // lowercase and strip accents, for uncased models
+	cls, sep  int64
+	pad, unk  int64
+}
+
+// LoadWordPiece reads the vocabulary of a WordPiece tokenizer from a Hugging Face
+// tokenizer.json or a vocab.txt file (one token per line, IDs by line number). Text is
+// lowercased when tokenizer.json's normalizer says so or, for vocab.txt, when the
+// vocabulary has no upper-case tokens.
+func LoadWordPiece(path string) (*WordPiece, error) {
+	var (
+		wp  *WordPiece
+		err error
+	)
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		wp, err = loadTokenizerJSON(path)
+	} else {
+		wp, err = loadVocabTxt(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for token, id := range map[string]*int64{"[CLS]": &wp.cls, "[SEP]": &wp.sep, "[PAD]": &wp.pad, "[UNK]": &wp.unk} {
+		v, ok := wp.vocab[token]
+		if !ok {
+			return nil, fmt.Errorf("%s: vocabulary has no %s token", path, token)
+		}
+		*id = v
+	}
+	return wp, nil
+}
+
+// FindWordPiece loads the tokenizer.json or, failing that, the vocab.txt in dir, where
+// exported Hugging Face models keep them next to model.onnx.
+func FindWordPiece(dir string) (*WordPiece, error) {
+	for _, name := range []string{"tokenizer.json", "vocab.txt"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return LoadWordPiece(path)
+		}
+	}
+	return nil, fmt.Errorf("no tokenizer.json or vocab.txt in %s", dir)
+}
+
+func loadVocabTxt(path string) (*WordPiece, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	wp := &WordPiece{vocab: make(map[string]int64), prefix: "##", lowercase: true}
+	sc := bufio.NewScanner(f)
+	var id int64
+	for sc.Scan() {
+		token := strings.TrimRight(sc.Text(), "\r")
+		if _, ok := wp.vocab[token]; !ok {
+			wp.vocab[token] = id
+		}
+		id++
+		if !isSpecialToken(token) && strings.ToLower(token) != token {
+			wp.lowercase = false
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return wp, nil
+}
+
+// tokenizerJSON is the part of a Hugging Face tokenizer.json that WordPiece uses.
+type tokenizerJSON struct {
+	Normalizer *struct {
+		Type      string `json:"type"`
+		Lowercase *bool  `json:"lowercase"`
+	} `json:"normalizer"`
+	Model struct {
+		Type                    string           `json:"type"`
+		Vocab                   map[string]int64 `json:"vocab"`
+		ContinuingSubwordPrefix string           `json:"continuing_subword_prefix"`
+	} `json:"model"`
+}
+
+func loadTokenizerJSON(path string) (*WordPiece, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tj tokenizerJSON
+	if err := json.Unmarshal(data, &tj); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if tj.Model.Type != "WordPiece" {
+		return nil, fmt.Errorf("%s: model type %q is not WordPiece", path, tj.Model.Type)
+	}
+	if len(tj.Model.Vocab) == 0 {
+		return nil, errors.New(path + ": empty vocabulary")
+	}
+	wp := &WordPiece{vocab: tj.Model.Vocab, prefix: tj.Model.ContinuingSubwordPrefix}
+	if wp.prefix == "" {
+		wp.prefix = "##"
+	}
+	// BertNormalizer lowercases unless told not to.
+	if n := tj.Normalizer; n != nil && n.Type == "BertNormalizer" {
+		wp.lowercase = n.Lowercase == nil || *n.Lowercase
+	}
+	return wp, nil
+}
+
+// isSpecialToken reports whether token is a bracketed special token such as [CLS].
+func isSpecialToken(token string) bool {
+	return strings.HasPrefix(token, "[") && strings.HasSuffix(token, "]")
+}
+
+// Tokens returns the vocabulary IDs of text's pieces, without special tokens.
+func (wp *WordPiece) Tokens(text string) []int64 {
+	var ids []int64
+	for _, word := range wp.words(text) {
+		ids = append(ids, wp.pieces(word)...)
+	}
+	return ids
+}
+
+// Tokenize implements Tokenizer: "[CLS] text [SEP]" padded to maxTokens.
+func (wp *WordPiece) Tokenize(text string, maxTokens int) (inputIDs, attentionMask, tokenTypeIDs []int64) {
+	if maxTokens <= 0 {
+		maxTokens = 256
+	}
+	ids := wp.Tokens(text)
+	if len(ids) > maxTokens-2 {
+		ids = ids[:max(maxTokens-2, 0)]
+	}
+	return wp.encode(ids, nil, maxTokens)
+}
+
+// TokenizePair returns "[CLS] a [SEP] b [SEP]" padded to maxTokens, with token type 0
+// for a and 1 for b, as cross-encoders read a query and a passage. When the pair is too
+// long, tokens are dropped from the end of the longer of the two, one at a time.
+func (wp *WordPiece) TokenizePair(a, b string, maxTokens int) (inputIDs, attentionMask, tokenTypeIDs []int64) {
+	if maxTokens <= 0 {
+		maxTokens = 256
+	}
+	first, second := wp.Tokens(a), wp.Tokens(b)
+	for len(first)+len(second) > maxTokens-3 && len(first)+len(second) > 0 {
+		if len(first) > len(second) {
+			first = first[:len(first)-1]
+		} else {
+			second = second[:len(second)-1]
+		}
+	}
+	return wp.encode(first, second, maxTokens)
+}
+
+// encode lays out [CLS] first [SEP] (second [SEP]) in maxTokens slots padded with [PAD].
+func (wp *WordPiece) encode(first, second []int64, maxTokens int) (inputIDs, attentionMask, tokenTypeIDs []int64) {
+	inputIDs = make([]int64, maxTokens)
+	attentionMask = make([]int64, maxTokens)
+	tokenTypeIDs = make([]int64, maxTokens)
+	for i := range inputIDs {
+		inputIDs[i] = wp.pad
+	}
+	pos := 0
+	put := func(id, segment int64) {
+		if pos < maxTokens {
+			inputIDs[pos], attentionMask[pos], tokenTypeIDs[pos] = id, 1, segment
+			pos++
+		}
+	}
+	put(wp.cls, 0)
+	for _, id := range first {
+		put(id, 0)
+	}
+	put(wp.sep, 0)
+	if second != nil {
+		for _, id := range second {
+			put(id, 1)
+		}
+		put(wp.sep, 1)
+	}
+	return inputIDs, attentionMask, tokenTypeIDs
+}
+
+// words splits text as BERT's basic tokenizer does: control characters are dropped,
+// uncased models lowercase and strip accents, and punctuation and CJK characters become
+// words of their own.
+func (wp *WordPiece) words(text string) []string {
+	if wp.lowercase {
+		text = strings.ToLower(text)
+		var b strings.Builder
+		for _, r := range norm.NFD.String(text) {
+			if !unicode.Is(unicode.Mn, r) {
+				b.WriteRune(r)
+			}
+		}
+		text = b.String()
+	}
+	var words []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			words = append(words, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r == 0 || r == unicode.ReplacementChar || unicode.IsControl(r) && !unicode.IsSpace(r):
+		case unicode.IsSpace(r):
+			flush()
+		case isBertPunct(r) || unicode.Is(unicode.Han, r):
+			flush()
+			words = append(words, string(r))
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return words
+}
+
+// isBertPunct reports whether BERT treats r as punctuation: any ASCII symbol that is not
+// a letter, digit or space, and Unicode punctuation.
+func isBertPunct(r rune) bool {
+	if r >= 33 && r <= 47 || r >= 58 && r <= 64 || r >= 91 && r <= 96 || r >= 123 && r <= 126 {
+		return true
+	}
+	return unicode.IsPunct(r)
+}
+
+// pieces splits word into the longest vocabulary pieces from its start, or returns [UNK]
+// when some part of it is in no piece.
+func (wp *WordPiece) pieces(word string) []int64 {
+	runes := []rune(word)
+	if len(runes) > maxWordPieceRunes {
+		return []int64{wp.unk}
+	}
+	var ids []int64
+	for start := 0; start < len(runes); {
+		end := len(runes)
+		found := false
+		for ; end > start; end-- {
+			piece := string(runes[start:end])
+			if start > 0 {
+				piece = wp.prefix + piece
+			}
+			if id, ok := wp.vocab[piece]; ok {
+				ids = append(ids, id)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return []int64{wp.unk}
+		}
+		start = end
+	}
+	return ids
+}
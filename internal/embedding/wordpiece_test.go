@@ -0,0 +1,79 @@
+package embedding
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var testVocab = []string{"[PAD]", "[UNK]", "[CLS]", "[SEP]", "the", "cafe", "un", "##aff", "##able", "!", ",", "budget", "##s", "学"}
+
+func writeVocab(t *testing.T, tokens []string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vocab.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(tokens, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWordPiece_Tokens(t *testing.T) {
+	wp, err := LoadWordPiece(writeVocab(t, testVocab))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Lowercased and accent-stripped (the vocabulary is uncased), punctuation and CJK
+	// characters split off, unknown words [UNK].
+	got := wp.Tokens("The Café, unaffable Budgets! zeppelin 学")
+	want := []int64{4, 5, 10, 6, 7, 8, 11, 12, 9, 1, 13}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokens = %v, want %v", got, want)
+	}
+}
+
+func TestWordPiece_TokenizePair(t *testing.T) {
+	wp, err := LoadWordPiece(writeVocab(t, testVocab))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, mask, types := wp.TokenizePair("budget", "the cafe", 8)
+	if want := []int64{2, 11, 3, 4, 5, 3, 0, 0}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("input_ids = %v, want %v", ids, want)
+	}
+	if want := []int64{1, 1, 1, 1, 1, 1, 0, 0}; !reflect.DeepEqual(mask, want) {
+		t.Errorf("attention_mask = %v, want %v", mask, want)
+	}
+	if want := []int64{0, 0, 0, 1, 1, 1, 0, 0}; !reflect.DeepEqual(types, want) {
+		t.Errorf("token_type_ids = %v, want %v", types, want)
+	}
+	// Too long: the passage, the longer side, loses its last piece.
+	ids, _, _ = wp.TokenizePair("budget", "the unaffable cafe", 8)
+	if want := []int64{2, 11, 3, 4, 6, 7, 8, 3}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("truncated input_ids = %v, want %v", ids, want)
+	}
+}
+
+func TestLoadWordPiece_tokenizerJSON(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"normalizer": {"type": "BertNormalizer", "lowercase": false},
+		"model": {"type": "WordPiece", "continuing_subword_prefix": "##",
+			"vocab": {"[PAD]": 0, "[UNK]": 100, "[CLS]": 101, "[SEP]": 102, "Budget": 7, "##s": 8}}}`
+	if err := os.WriteFile(filepath.Join(dir, "tokenizer.json"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	wp, err := FindWordPiece(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := wp.Tokens("Budgets budget"); !reflect.DeepEqual(got, []int64{7, 8, 100}) {
+		t.Errorf("cased Tokens = %v", got)
+	}
+	if _, err := FindWordPiece(t.TempDir()); err == nil {
+		t.Error("FindWordPiece without a vocabulary should fail")
+	}
+	if _, err := LoadWordPiece(writeVocab(t, []string{"[PAD]", "hello"})); err == nil {
+		t.Error("vocabulary without special tokens should fail")
+	}
+}
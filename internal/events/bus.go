@@ -0,0 +1,115 @@
+// Package events broadcasts indexing activity (files indexed, deleted, or failed, and
+// directory syncs) to subscribers such as the server's event stream.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type is the kind of an Event.
+type Type string
+
+const (
+	// Indexed means a document was stored and indexed.
+	Indexed Type = "indexed"
+	// Deleted means a document was removed from the index.
+	Deleted Type = "deleted"
+	// Failed means indexing or deleting a document or file failed.
+	Failed Type = "failed"
+	// SyncStarted means the watcher started looking for files in a directory.
+	SyncStarted Type = "sync_started"
+	// SyncFinished means the watcher handed every file of a directory to indexing.
+	SyncFinished Type = "sync_finished"
+)
+
+const (
+	// DefaultHistory is how many recent events a Bus keeps for subscribers catching up.
+	DefaultHistory = 256
+	// subscriberBuffer is how many events may wait for a subscriber before it is dropped.
+	subscriberBuffer = 256
+)
+
+// Event is one piece of indexing activity.
+type Event struct {
+	// Seq numbers the events of a Bus from 1, so a subscriber can resume after the last
+	// one it saw.
+	Seq        int64     `json:"seq"`
+	Type       Type      `json:"type"`
+	Time       time.Time `json:"time"`
+	DocumentID string    `json:"document_id,omitempty"`
+	Path       string    `json:"path,omitempty"`  // source file or synced directory
+	Files      int       `json:"files,omitempty"` // files a finished sync handed to indexing
+	Error      string    `json:"error,omitempty"`
+}
+
+// Bus delivers published events to every subscriber. Publishing never blocks: a subscriber
+// that falls too far behind is dropped, its channel closed, and can subscribe again from
+// the last event it saw. A nil *Bus discards events.
+type Bus struct {
+	mu      sync.Mutex
+	seq     int64
+	history []Event // most recent last
+	size    int
+	subs    map[chan Event]struct{}
+}
+
+// NewBus creates a bus keeping the last history events (DefaultHistory when not positive).
+func NewBus(history int) *Bus {
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &Bus{size: history, subs: make(map[chan Event]struct{})}
+}
+
+// Publish numbers e, stamps it with the current time unless it has one, and delivers it.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.seq++
+	e.Seq = b.seq
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if len(b.history) == b.size {
+		b.history = append(b.history[:0], b.history[1:]...)
+	}
+	b.history = append(b.history, e)
+	for ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns a channel of the events published from now on, preceded by the kept
+// events numbered after since (none when since is 0), and the function that unsubscribes.
+// missed reports that events after since are no longer kept.
+func (b *Bus) Subscribe(since int64) (ch <-chan Event, cancel func(), missed bool) {
+	c := make(chan Event, subscriberBuffer+b.size)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if since > 0 {
+		for _, e := range b.history {
+			if e.Seq > since {
+				c <- e
+			}
+		}
+		missed = since < b.seq && (len(b.history) == 0 || b.history[0].Seq > since+1)
+	}
+	b.subs[c] = struct{}{}
+	return c, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[c]; ok {
+			delete(b.subs, c)
+			close(c)
+		}
+	}, missed
+}
@@ -0,0 +1,61 @@
+package events
+
+import "testing"
+
+func TestBus(t *testing.T) {
+	b := NewBus(3)
+	ch, cancel, missed := b.Subscribe(0)
+	if missed {
+		t.Error("a new subscriber without a cursor misses nothing")
+	}
+	for _, path := range []string{"a", "b", "c", "d"} {
+		b.Publish(Event{Type: Indexed, Path: path})
+	}
+	for i, want := range []string{"a", "b", "c", "d"} {
+		e := <-ch
+		if e.Path != want || e.Seq != int64(i+1) || e.Time.IsZero() {
+			t.Errorf("event %d = %+v, want %s", i, e, want)
+		}
+	}
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Error("channel should be closed after cancel")
+	}
+	cancel()
+
+	// Catching up replays the kept events after the cursor
+	ch, cancel, missed = b.Subscribe(2)
+	defer cancel()
+	if missed {
+		t.Error("events after 2 are all kept")
+	}
+	if e := <-ch; e.Path != "c" {
+		t.Errorf("replayed %+v, want c", e)
+	}
+	if e := <-ch; e.Path != "d" {
+		t.Errorf("replayed %+v, want d", e)
+	}
+	b.Publish(Event{Type: Deleted, Path: "e"})
+	if _, _, missed := b.Subscribe(1); !missed {
+		t.Error("event 2 is no longer kept, so catching up from 1 misses it")
+	}
+
+	var nilBus *Bus
+	nilBus.Publish(Event{Type: Deleted})
+}
+
+func TestBus_dropsSlowSubscriber(t *testing.T) {
+	b := NewBus(1)
+	ch, cancel, _ := b.Subscribe(0)
+	defer cancel()
+	for i := 0; i < subscriberBuffer+2; i++ {
+		b.Publish(Event{Type: Indexed})
+	}
+	n := 0
+	for range ch {
+		n++
+	}
+	if n != subscriberBuffer+1 {
+		t.Errorf("received %d events before being dropped, want %d", n, subscriberBuffer+1)
+	}
+}
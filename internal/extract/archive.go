@@ -0,0 +1,228 @@
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Default ArchiveOptions limits.
+const (
+	DefaultArchiveMaxEntrySize = 20 << 20
+	DefaultArchiveMaxTotalSize = 200 << 20
+	DefaultArchiveMaxEntries   = 1000
+)
+
+// maxArchiveDepth bounds how deep archives within archives are read.
+const maxArchiveDepth = 3
+
+// ArchiveOptions sets which entries of an archive are extracted. Zero limits use the
+// defaults.
+type ArchiveOptions struct {
+	// Extensions are those of the entries extracted, with or without the leading dot;
+	// empty means any. Archives within the archive are read whatever their extension.
+	Extensions []string `json:"extensions,omitempty"`
+	// MaxEntrySize skips entries larger than this many bytes, uncompressed.
+	MaxEntrySize int64 `json:"max_entry_size"`
+	// MaxTotalSize stops reading the archive once this many bytes were uncompressed.
+	MaxTotalSize int64 `json:"max_total_size"`
+	// MaxEntries stops reading the archive after this many entries were extracted.
+	MaxEntries int `json:"max_entries"`
+}
+
+// ArchiveEntry is a file within an archive.
+type ArchiveEntry struct {
+	// Path is the slash-separated path of the file in the archive; a file in an archive
+	// within it is under the inner archive's path: "build/site.zip/index.html".
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	Text string `json:"text"`
+	// Locked reports an encrypted file, which has no text.
+	Locked bool `json:"locked,omitempty"`
+}
+
+// IsArchive reports whether the file name is a ZIP or tar archive: .zip, .tar, .tar.gz,
+// or .tgz, in any case.
+func IsArchive(name string) bool {
+	name = strings.ToLower(name)
+	for _, suffix := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractArchive returns the files of the archive at path that opts selects, with their
+// text, in archive order. Directories, entries over the size limit, files that look
+// binary and are not in a known format, and files that fail to extract are left out.
+// With WithSandbox, the archive is read in a worker process.
+func (e *Extractor) ExtractArchive(path string, opts ArchiveOptions) ([]*ArchiveEntry, error) {
+	opts = opts.withDefaults()
+	if e.sandbox != nil {
+		return e.sandbox.extractArchive(path, opts)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return e.parseArchive(content, path, opts)
+}
+
+// withDefaults returns opts with the default limits for those unset and the extensions
+// in lower case with the leading dot.
+func (opts ArchiveOptions) withDefaults() ArchiveOptions {
+	if opts.MaxEntrySize <= 0 {
+		opts.MaxEntrySize = DefaultArchiveMaxEntrySize
+	}
+	if opts.MaxTotalSize <= 0 {
+		opts.MaxTotalSize = DefaultArchiveMaxTotalSize
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = DefaultArchiveMaxEntries
+	}
+	exts := make([]string, len(opts.Extensions))
+	for i, ext := range opts.Extensions {
+		exts[i] = "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+	}
+	opts.Extensions = exts
+	return opts
+}
+
+// archiveReader reads the entries of an archive and its inner archives within the limits
+// of opts.
+type archiveReader struct {
+	e       *Extractor
+	opts    ArchiveOptions
+	total   int64
+	entries []*ArchiveEntry
+}
+
+// errArchiveFull stops reading once the total size or entry limit is reached.
+var errArchiveFull = errors.New("archive limits reached")
+
+// parseArchive reads the archive content, named name.
+func (e *Extractor) parseArchive(content []byte, name string, opts ArchiveOptions) ([]*ArchiveEntry, error) {
+	r := &archiveReader{e: e, opts: opts}
+	if err := r.read(content, name, "", 0); err != nil && !errors.Is(err, errArchiveFull) {
+		return nil, err
+	}
+	return r.entries, nil
+}
+
+// read reads the archive content, named name, whose entries' paths start with prefix.
+func (r *archiveReader) read(content []byte, name, prefix string, depth int) error {
+	lower := strings.ToLower(name)
+	if strings.HasSuffix(lower, ".zip") {
+		zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return fmt.Errorf("open zip: %w", err)
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || int64(f.UncompressedSize64) > r.opts.MaxEntrySize {
+				continue
+			}
+			if err := r.entry(prefix, f.Name, depth, f.Open); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	var in io.Reader = bytes.NewReader(content)
+	if strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return fmt.Errorf("open gzip: %w", err)
+		}
+		defer gz.Close()
+		in = gz
+	}
+	tr := tar.NewReader(in)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read tar: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > r.opts.MaxEntrySize {
+			continue
+		}
+		open := func() (io.ReadCloser, error) { return io.NopCloser(tr), nil }
+		if err := r.entry(prefix, hdr.Name, depth, open); err != nil {
+			return err
+		}
+	}
+}
+
+// entry reads the file name of an archive, opened by open, and adds it to the entries, or
+// reads it as an archive within.
+func (r *archiveReader) entry(prefix, name string, depth int, open func() (io.ReadCloser, error)) error {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	base := path.Base(name)
+	if strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, "._") {
+		return nil
+	}
+	inner := IsArchive(name) && depth+1 < maxArchiveDepth
+	ext := strings.ToLower(path.Ext(name))
+	if !inner && !r.wanted(ext) {
+		return nil
+	}
+	if len(r.entries) >= r.opts.MaxEntries || r.total >= r.opts.MaxTotalSize {
+		return errArchiveFull
+	}
+	rc, err := open()
+	if err != nil {
+		return nil
+	}
+	content, err := io.ReadAll(io.LimitReader(rc, r.opts.MaxEntrySize+1))
+	rc.Close()
+	if err != nil || int64(len(content)) > r.opts.MaxEntrySize {
+		return nil
+	}
+	r.total += int64(len(content))
+	if r.total > r.opts.MaxTotalSize {
+		return errArchiveFull
+	}
+	if inner {
+		err := r.read(content, name, prefix+name+"/", depth+1)
+		if errors.Is(err, errArchiveFull) {
+			return err
+		}
+		return nil
+	}
+	if !KnownFormat(ext) && !r.e.ExternalFormat(ext) && IsBinary(content[:min(len(content), sniffLen)]) {
+		return nil
+	}
+	entry := &ArchiveEntry{Path: prefix + name, Size: int64(len(content))}
+	entry.Text, err = r.e.extractBytes(content, ext, nil)
+	switch {
+	case errors.Is(err, ErrLocked):
+		entry.Locked = true
+	case err != nil:
+		return nil
+	}
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// wanted reports whether entries with extension ext are extracted.
+func (r *archiveReader) wanted(ext string) bool {
+	if len(r.opts.Extensions) == 0 {
+		return true
+	}
+	for _, x := range r.opts.Extensions {
+		if x == ext {
+			return true
+		}
+	}
+	return false
+}
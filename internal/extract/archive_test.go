@@ -0,0 +1,144 @@
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func zipOf(t *testing.T, files map[string][]byte, order ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, name := range order {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write(files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func tarGzOf(t *testing.T, files map[string][]byte, order ...string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	w := tar.NewWriter(gz)
+	for _, name := range order {
+		if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name]))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(files[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestIsArchive(t *testing.T) {
+	for name, want := range map[string]bool{
+		"site.zip": true, "backup.TAR.GZ": true, "src.tgz": true, "logs.tar": true,
+		"notes.gz": false, "report.docx": false, "zip": false,
+	} {
+		if got := IsArchive(name); got != want {
+			t.Errorf("IsArchive(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestParseArchive(t *testing.T) {
+	inner := tarGzOf(t, map[string][]byte{
+		"docs/spec.md":   []byte("# Spec\n\nThe handoff format."),
+		"docs/logo.png":  {0x89, 'P', 'N', 'G', 0, 0, 0},
+		"../escape.txt":  []byte("cleaned"),
+		"docs/big.txt":   bytes.Repeat([]byte("x"), 100),
+		"docs/table.csv": []byte("name,qty\nbolts,12\n"),
+	}, "docs/spec.md", "docs/logo.png", "../escape.txt", "docs/big.txt", "docs/table.csv")
+	content := zipOf(t, map[string][]byte{
+		"deliverables/":            nil,
+		"deliverables/report.docx": minimalDocx("Final report"),
+		"deliverables/src.tar.gz":  inner,
+		"__MACOSX/._report.docx":   []byte("resource fork"),
+		"README":                   []byte("Unpack and enjoy"),
+	}, "deliverables/", "deliverables/report.docx", "deliverables/src.tar.gz", "__MACOSX/._report.docx", "README")
+
+	e := NewExtractor()
+	got, err := e.parseArchive(content, "handoff.zip", ArchiveOptions{}.withDefaults())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ArchiveEntry{
+		{Path: "deliverables/report.docx", Size: int64(len(minimalDocx("Final report"))), Text: "Final report"},
+		{Path: "deliverables/src.tar.gz/docs/spec.md", Size: 27, Text: "# Spec\n\nThe handoff format."},
+		{Path: "deliverables/src.tar.gz/escape.txt", Size: 7, Text: "cleaned"},
+		{Path: "deliverables/src.tar.gz/docs/big.txt", Size: 100, Text: string(bytes.Repeat([]byte("x"), 100))},
+		{Path: "deliverables/src.tar.gz/docs/table.csv", Size: 18, Text: "row 1: name: bolts | qty: 12"},
+		{Path: "README", Size: 16, Text: "Unpack and enjoy"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		for _, e := range got {
+			t.Errorf("got  %+v", *e)
+		}
+		for _, e := range want {
+			t.Errorf("want %+v", *e)
+		}
+	}
+
+	filtered, err := e.parseArchive(content, "handoff.zip", ArchiveOptions{Extensions: []string{"MD", ".csv"}}.withDefaults())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, entry := range filtered {
+		paths = append(paths, entry.Path)
+	}
+	if want := []string{"deliverables/src.tar.gz/docs/spec.md", "deliverables/src.tar.gz/docs/table.csv"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("filtered paths = %q, want %q", paths, want)
+	}
+
+	capped, err := e.parseArchive(inner, "src.tar.gz", ArchiveOptions{MaxEntrySize: 50, MaxEntries: 2}.withDefaults())
+	paths = nil
+	for _, entry := range capped {
+		paths = append(paths, entry.Path)
+	}
+	if want := []string{"docs/spec.md", "escape.txt"}; err != nil || !reflect.DeepEqual(paths, want) {
+		t.Errorf("MaxEntrySize 50, MaxEntries 2: got %q, %v; want %q", paths, err, want)
+	}
+
+	if _, err := e.parseArchive([]byte("not a zip"), "broken.zip", ArchiveOptions{}.withDefaults()); err == nil {
+		t.Error("broken zip: want an error")
+	}
+}
+
+func TestExtractArchive_sandbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.tgz")
+	content := tarGzOf(t, map[string][]byte{"a.txt": []byte("alpha"), "b.txt": []byte("beta")}, "a.txt", "b.txt")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := sandboxExtractor("extract-worker", 0).ExtractArchive(path, ArchiveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ArchiveEntry{{Path: "a.txt", Size: 5, Text: "alpha"}, {Path: "b.txt", Size: 4, Text: "beta"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractArchive() = %+v, want %+v", got, want)
+	}
+}
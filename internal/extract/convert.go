@@ -0,0 +1,223 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultConverterTimeout is the time a conversion may take when ConverterConfig sets
+// none.
+const DefaultConverterTimeout = 2 * time.Minute
+
+// DefaultConverterCommand converts with LibreOffice.
+var DefaultConverterCommand = []string{"soffice", "--headless", "--convert-to", "{format}", "--outdir", "{outdir}", "{input}"}
+
+// DefaultConverterExtensions are the legacy Office files converted when ConverterConfig
+// lists none.
+var DefaultConverterExtensions = []string{".doc", ".xls", ".ppt"}
+
+// ErrConverter is returned (wrapped) when the converter failed, was killed for taking too
+// long, or wrote no file.
+var ErrConverter = errors.New("converter failed")
+
+// ConverterConfig converts files sagasu cannot read, such as the binary Office formats of
+// .doc, .xls, and .ppt files, to a format it can, with a program such as LibreOffice.
+type ConverterConfig struct {
+	// Command is the program and its arguments: "{input}" stands for the path of the file,
+	// "{outdir}" for the directory the program writes the converted file to, and
+	// "{format}" for its format: xlsx for spreadsheets, pptx for presentations, else docx.
+	// Empty means DefaultConverterCommand.
+	Command []string
+	// Extensions are those of the files converted, with or without the leading dot; empty
+	// means DefaultConverterExtensions.
+	Extensions []string
+	// Timeout is the wall-clock time after which the program is killed; 0 means
+	// DefaultConverterTimeout.
+	Timeout time.Duration
+	// CacheDir keeps the converted files, named by the SHA-256 of the file converted, so
+	// a file is converted again only when its content changes; empty converts each time.
+	CacheDir string
+}
+
+// converter runs a ConverterConfig one conversion at a time, as LibreOffice instances
+// sharing a profile do not run side by side.
+type converter struct {
+	ConverterConfig
+	mu sync.Mutex
+}
+
+// WithConverter converts the files with cfg.Extensions with cfg.Command before extracting
+// the converted file. Without it, those files are read as plain text.
+func (e *Extractor) WithConverter(cfg ConverterConfig) *Extractor {
+	c := &converter{ConverterConfig: cfg}
+	if len(c.Command) == 0 {
+		c.Command = DefaultConverterCommand
+	}
+	exts := cfg.Extensions
+	if len(exts) == 0 {
+		exts = DefaultConverterExtensions
+	}
+	c.Extensions = make([]string, len(exts))
+	for i, ext := range exts {
+		c.Extensions[i] = "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultConverterTimeout
+	}
+	e.converter = c
+	return e
+}
+
+// Converts reports whether files with extension ext (with the leading dot, in any case)
+// are converted before extraction (see WithConverter).
+func (e *Extractor) Converts(ext string) bool {
+	if e.converter == nil {
+		return false
+	}
+	ext = strings.ToLower(ext)
+	for _, x := range e.converter.Extensions {
+		if x == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// convertFormat returns the format a file with extension ext is converted to.
+func convertFormat(ext string) string {
+	switch strings.ToLower(ext) {
+	case ".xls", ".xlt", ".xlw", ".wk1", ".wks", ".123", ".dbf", ".sdc":
+		return "xlsx"
+	case ".ppt", ".pps", ".pot", ".sdd":
+		return "pptx"
+	}
+	return "docx"
+}
+
+// ExtractConverted converts the file at path and returns the text of the converted file,
+// extracted in the sandbox when there is one.
+func (e *Extractor) ExtractConverted(path string) (string, error) {
+	if e.converter == nil {
+		return "", fmt.Errorf("%w: no converter for %s", ErrConverter, path)
+	}
+	converted, cleanup, err := e.converter.convert(path)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+	ext := filepath.Ext(converted)
+	if e.sandbox != nil {
+		return e.sandbox.extract(converted, ext, nil)
+	}
+	content, err := os.ReadFile(converted)
+	if err != nil {
+		return "", fmt.Errorf("read converted file: %w", err)
+	}
+	return e.extractBytes(content, ext, nil)
+}
+
+// convert returns the path of the converted file at path, from the cache when it has it,
+// and a function removing what is not kept in the cache.
+func (c *converter) convert(path string) (string, func(), error) {
+	format := convertFormat(filepath.Ext(path))
+	var cached string
+	if c.CacheDir != "" {
+		sum, err := fileSHA256(path)
+		if err != nil {
+			return "", nil, fmt.Errorf("read file: %w", err)
+		}
+		cached = filepath.Join(c.CacheDir, sum+"."+format)
+		if _, err := os.Stat(cached); err == nil {
+			return cached, func() {}, nil
+		}
+	}
+	outdir, err := os.MkdirTemp("", "sagasu-convert-")
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %v", ErrConverter, err)
+	}
+	cleanup := func() { os.RemoveAll(outdir) }
+	out, err := c.run(path, outdir, format)
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	if cached == "" {
+		return out, cleanup, nil
+	}
+	// A cache that cannot be written is skipped.
+	if content, err := os.ReadFile(out); err == nil && os.MkdirAll(c.CacheDir, 0o755) == nil &&
+		os.WriteFile(cached, content, 0o644) == nil {
+		cleanup()
+		return cached, func() {}, nil
+	}
+	return out, cleanup, nil
+}
+
+// run runs the converter on path, writing to outdir, and returns the path of the file it
+// wrote.
+func (c *converter) run(path, outdir, format string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+	args, input := make([]string, 0, len(c.Command)), false
+	for _, arg := range c.Command[1:] {
+		if strings.Contains(arg, "{input}") {
+			input = true
+		}
+		args = append(args, strings.NewReplacer("{input}", path, "{outdir}", outdir, "{format}", format).Replace(arg))
+	}
+	if !input {
+		args = append(args, path)
+	}
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.Command[0], args...)
+	cmd.Dir = outdir
+	cmd.Stdout, cmd.Stderr = io.Discard, &stderr
+	err := cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		return "", fmt.Errorf("%w: %s: killed after %s", ErrConverter, path, c.Timeout)
+	case err != nil:
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 200 {
+			msg = msg[len(msg)-200:]
+		}
+		return "", fmt.Errorf("%w: %s: %v %s", ErrConverter, path, err, msg)
+	}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "." + format
+	if _, err := os.Stat(filepath.Join(outdir, name)); err == nil {
+		return filepath.Join(outdir, name), nil
+	}
+	// Take the file the program wrote, whatever its name, when it wrote just one.
+	matches, _ := filepath.Glob(filepath.Join(outdir, "*."+format))
+	if len(matches) != 1 {
+		return "", fmt.Errorf("%w: %s: no %s file written", ErrConverter, path, format)
+	}
+	return matches[0], nil
+}
+
+// fileSHA256 returns the hex SHA-256 of the content of the file at path.
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,67 @@
+package extract
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractConverted(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	fixture := filepath.Join(dir, "converted.docx")
+	if err := os.WriteFile(fixture, minimalDocx("Minutes of 1998"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	doc := filepath.Join(dir, "minutes.DOC")
+	if err := os.WriteFile(doc, []byte("\xd0\xcf\x11\xe0 old binary"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	runs := filepath.Join(dir, "runs")
+	cache := filepath.Join(dir, "cache")
+	e := NewExtractor().WithConverter(ConverterConfig{
+		Command: []string{"sh", "-c", `echo "$3" >> ` + runs + `; cp ` + fixture + ` "$2/$(basename "$1" .DOC).$3"`,
+			"sh", "{input}", "{outdir}", "{format}"},
+		CacheDir: cache,
+	})
+	if !e.Converts(".doc") || !e.Converts(".PPT") || e.Converts(".docx") {
+		t.Fatal("Converts: want the default legacy Office extensions only")
+	}
+	for i := 0; i < 2; i++ {
+		if text, err := e.Extract(doc); err != nil || text != "Minutes of 1998" {
+			t.Errorf("Extract = %q, %v", text, err)
+		}
+	}
+	got, _ := os.ReadFile(runs)
+	if string(got) != "docx\n" {
+		t.Errorf("converter runs = %q, want one, to docx", got)
+	}
+
+	// Changed content is converted again.
+	if err := os.WriteFile(doc, []byte("\xd0\xcf\x11\xe0 edited"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.Extract(doc); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(runs); strings.Count(string(got), "\n") != 2 {
+		t.Errorf("converter runs = %q, want two", got)
+	}
+	if entries, _ := os.ReadDir(cache); len(entries) != 2 {
+		t.Errorf("cache holds %d files, want 2", len(entries))
+	}
+
+	silent := NewExtractor().WithConverter(ConverterConfig{Command: []string{"true"}})
+	if _, err := silent.ExtractConverted(doc); !errors.Is(err, ErrConverter) || !strings.Contains(err.Error(), "no docx file") {
+		t.Errorf("converter writing nothing: got %v", err)
+	}
+	failing := NewExtractor().WithConverter(ConverterConfig{Command: []string{"sh", "-c", "echo javaldx failed >&2; exit 1"}})
+	if _, err := failing.ExtractConverted(doc); !errors.Is(err, ErrConverter) || !strings.Contains(err.Error(), "javaldx failed") {
+		t.Errorf("failing converter: got %v", err)
+	}
+}
@@ -0,0 +1,314 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxRows is the number of rows read from a data file when WithMaxRows is not set.
+const DefaultMaxRows = 10000
+
+// Data is the text of a CSV, TSV, JSON, or JSON Lines file.
+type Data struct {
+	// Fields are the column headers of a table, or the key paths of the JSON values in
+	// the order met (the keys of an object sorted), with "[]" standing for array indexes:
+	// "users[].name".
+	Fields []string
+	// Rows is the number of rows of a table, or of records (lines of JSON Lines, elements
+	// of a top-level JSON array), in the file. A single JSON value is one record.
+	Rows int
+	// Truncated reports whether rows or array elements past the row limit were left out.
+	Truncated bool
+	// Text has a line per row or record, "row 3: name: Alice | city: Paris", or, for a
+	// single JSON value, a line per value, "users[0].name: Alice".
+	Text string
+}
+
+// IsData reports whether files with extension ext (with the leading dot, in any case) are
+// CSV, TSV, JSON, or JSON Lines data.
+func IsData(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".csv", ".tsv", ".json", ".jsonl", ".ndjson":
+		return true
+	}
+	return false
+}
+
+// WithMaxRows sets the number of rows of a table, and of elements of each JSON array, that
+// are read; later ones are left out of the text. 0 means DefaultMaxRows, a negative value
+// no limit.
+func (e *Extractor) WithMaxRows(n int) *Extractor {
+	e.maxRows = n
+	return e
+}
+
+// rowLimit returns the row limit of WithMaxRows, 0 when there is none.
+func (e *Extractor) rowLimit() int {
+	switch {
+	case e.maxRows == 0:
+		return DefaultMaxRows
+	case e.maxRows < 0:
+		return 0
+	}
+	return e.maxRows
+}
+
+// ExtractData reads the data file at path. Files that do not parse as their format are
+// read as plain text, without fields.
+func (e *Extractor) ExtractData(path string) (*Data, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return parseData(content, strings.ToLower(filepath.Ext(path)), e.rowLimit()), nil
+}
+
+// parseData reads content in the data format of ext, keeping up to limit rows (no limit
+// when 0).
+func parseData(content []byte, ext string, limit int) *Data {
+	text, _ := extractPlain(bytes.TrimPrefix(content, []byte("\ufeff")))
+	var d *Data
+	var err error
+	switch ext {
+	case ".csv":
+		d, err = parseTable(text, ',', limit)
+	case ".tsv":
+		d, err = parseTable(text, '\t', limit)
+	case ".json":
+		d, err = parseJSON(text, limit)
+	case ".jsonl", ".ndjson":
+		d, err = parseJSONLines(text, limit)
+	default:
+		err = fmt.Errorf("unknown data format %q", ext)
+	}
+	if err != nil {
+		return &Data{Text: text}
+	}
+	return d
+}
+
+// parseTable reads a table whose cells are separated by sep. The first row is the header
+// when its cells are all set and none is a number.
+func parseTable(text string, sep rune, limit int) (*Data, error) {
+	r := csv.NewReader(strings.NewReader(text))
+	r.Comma = sep
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	d := &Data{}
+	var header []string
+	var b strings.Builder
+	for first := true; ; first = false {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if first && isHeader(record) {
+			header = record
+			for _, h := range record {
+				d.Fields = append(d.Fields, strings.TrimSpace(h))
+			}
+			continue
+		}
+		d.Rows++
+		if limit > 0 && d.Rows > limit {
+			d.Truncated = true
+			continue
+		}
+		var cells []string
+		for i, cell := range record {
+			if cell = strings.TrimSpace(cell); cell == "" {
+				continue
+			}
+			if i < len(header) && d.Fields[i] != "" {
+				cell = d.Fields[i] + ": " + cell
+			}
+			cells = append(cells, cell)
+		}
+		if len(cells) > 0 {
+			fmt.Fprintf(&b, "row %d: %s\n", d.Rows, strings.Join(cells, " | "))
+		}
+	}
+	d.Text = strings.TrimSpace(b.String())
+	return d, nil
+}
+
+// isHeader reports whether record looks like the header of a table.
+func isHeader(record []string) bool {
+	for _, cell := range record {
+		cell = strings.TrimSpace(cell)
+		if cell == "" {
+			return false
+		}
+		if _, err := strconv.ParseFloat(cell, 64); err == nil {
+			return false
+		}
+	}
+	return len(record) > 0
+}
+
+// parseJSON reads a JSON value: each element of a top-level array is a record, another
+// value is flattened a line per value.
+func parseJSON(text string, limit int) (*Data, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("json: data after the top-level value")
+	}
+	f := &flattener{limit: limit, seen: make(map[string]bool)}
+	d := &Data{}
+	var b strings.Builder
+	if items, ok := v.([]interface{}); ok {
+		for i, item := range items {
+			d.Rows++
+			if limit > 0 && i >= limit {
+				f.truncated = true
+				continue
+			}
+			if line := f.record(item); line != "" {
+				fmt.Fprintf(&b, "record %d: %s\n", i+1, line)
+			}
+		}
+	} else {
+		d.Rows = 1
+		for _, kv := range f.flatten("", v, nil) {
+			if kv.path != "" {
+				b.WriteString(kv.path + ": ")
+			}
+			b.WriteString(kv.value + "\n")
+		}
+	}
+	d.Fields, d.Truncated = f.fields, f.truncated
+	d.Text = strings.TrimSpace(b.String())
+	return d, nil
+}
+
+// parseJSONLines reads a JSON value per line, each a record. Lines that are not JSON are
+// kept as they are.
+func parseJSONLines(text string, limit int) (*Data, error) {
+	f := &flattener{limit: limit, seen: make(map[string]bool)}
+	d := &Data{}
+	var b strings.Builder
+	valid := 0
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		d.Rows++
+		if limit > 0 && d.Rows > limit {
+			f.truncated = true
+			continue
+		}
+		dec := json.NewDecoder(strings.NewReader(line))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			fmt.Fprintf(&b, "record %d: %s\n", d.Rows, line)
+			continue
+		}
+		valid++
+		if s := f.record(v); s != "" {
+			fmt.Fprintf(&b, "record %d: %s\n", d.Rows, s)
+		}
+	}
+	if d.Rows > 0 && valid == 0 {
+		return nil, errors.New("jsonl: no JSON lines")
+	}
+	d.Fields, d.Truncated = f.fields, f.truncated
+	d.Text = strings.TrimSpace(b.String())
+	return d, nil
+}
+
+// flattener turns JSON values into key paths and values, noting the fields it meets.
+type flattener struct {
+	limit     int // elements kept of each array, 0 for all
+	truncated bool
+	fields    []string
+	seen      map[string]bool
+}
+
+// keyValue is a JSON value and its key path.
+type keyValue struct {
+	path, value string
+}
+
+// indexPattern matches the array indexes of key paths, which fields leave out.
+var indexPattern = regexp.MustCompile(`\[\d+\]`)
+
+// record renders v as one line, "user.name: Alice | user.age: 30".
+func (f *flattener) record(v interface{}) string {
+	kvs := f.flatten("", v, nil)
+	parts := make([]string, len(kvs))
+	for i, kv := range kvs {
+		parts[i] = kv.value
+		if kv.path != "" {
+			parts[i] = kv.path + ": " + kv.value
+		}
+	}
+	return strings.Join(parts, " | ")
+}
+
+// flatten appends the values within v, at key path path, to kvs, objects in key order.
+func (f *flattener) flatten(path string, v interface{}, kvs []keyValue) []keyValue {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			kvs = f.flatten(joinKey(path, k), x[k], kvs)
+		}
+		return kvs
+	case []interface{}:
+		for i, item := range x {
+			if f.limit > 0 && i >= f.limit {
+				f.truncated = true
+				break
+			}
+			kvs = f.flatten(fmt.Sprintf("%s[%d]", path, i), item, kvs)
+		}
+		return kvs
+	case nil:
+		return kvs
+	}
+	value := strings.TrimSpace(fmt.Sprint(v))
+	if value == "" {
+		return kvs
+	}
+	if path != "" {
+		if field := indexPattern.ReplaceAllString(path, "[]"); !f.seen[field] {
+			f.seen[field] = true
+			f.fields = append(f.fields, field)
+		}
+	}
+	return append(kvs, keyValue{path: path, value: strings.Join(strings.Fields(value), " ")})
+}
+
+// joinKey appends key k to path, quoting keys that would not read as one.
+func joinKey(path, k string) string {
+	if k == "" || strings.ContainsAny(k, ".[]: |") {
+		k = strconv.Quote(k)
+	}
+	if path == "" {
+		return k
+	}
+	return path + "." + k
+}
@@ -0,0 +1,91 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseData(t *testing.T) {
+	tests := []struct {
+		name, ext, content string
+		limit              int
+		want               Data
+	}{
+		{
+			name:    "csv with header",
+			ext:     ".csv",
+			content: "\ufeffname,city,amount\nAlice,\"Paris, France\",12\nBob,,7\n",
+			want: Data{
+				Fields: []string{"name", "city", "amount"},
+				Rows:   2,
+				Text:   "row 1: name: Alice | city: Paris, France | amount: 12\nrow 2: name: Bob | amount: 7",
+			},
+		},
+		{
+			name:    "tsv without header, over the limit",
+			ext:     ".tsv",
+			content: "1\tfirst\n2\tsecond\n3\tthird\n",
+			limit:   2,
+			want:    Data{Rows: 3, Truncated: true, Text: "row 1: 1 | first\nrow 2: 2 | second"},
+		},
+		{
+			name:    "json array of records",
+			ext:     ".json",
+			content: `[{"id": 1, "user": {"name": "Alice", "tags": ["admin", "ops"]}}, {"id": 2, "note": null}]`,
+			want: Data{
+				Fields: []string{"id", "user.name", "user.tags[]"},
+				Rows:   2,
+				Text:   "record 1: id: 1 | user.name: Alice | user.tags[0]: admin | user.tags[1]: ops\nrecord 2: id: 2",
+			},
+		},
+		{
+			name:    "json object with a long array",
+			ext:     ".json",
+			content: `{"title": "Ports", "ports": [22, 80, 443]}`,
+			limit:   2,
+			want: Data{
+				Fields:    []string{"ports[]", "title"},
+				Rows:      1,
+				Truncated: true,
+				Text:      "ports[0]: 22\nports[1]: 80\ntitle: Ports",
+			},
+		},
+		{
+			name:    "json lines",
+			ext:     ".jsonl",
+			content: "{\"level\": \"error\", \"msg\": \"disk full\"}\nnot json\n\n{\"level\": \"info\"}\n",
+			want: Data{
+				Fields: []string{"level", "msg"},
+				Rows:   3,
+				Text:   "record 1: level: error | msg: disk full\nrecord 2: not json\nrecord 3: level: info",
+			},
+		},
+		{
+			name:    "invalid json read as text",
+			ext:     ".json",
+			content: `{"broken": `,
+			want:    Data{Text: `{"broken": `},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseData([]byte(tt.content), tt.ext, tt.limit)
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("parseData() = %+v\nwant %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractor_WithMaxRows(t *testing.T) {
+	content := []byte("n\n1\n2\n3\n")
+	for _, tt := range []struct {
+		max  int
+		want string
+	}{{0, "row 1: n: 1\nrow 2: n: 2\nrow 3: n: 3"}, {1, "row 1: n: 1"}, {-1, "row 1: n: 1\nrow 2: n: 2\nrow 3: n: 3"}} {
+		got, err := NewExtractor().WithMaxRows(tt.max).ExtractBytes(content, ".csv")
+		if err != nil || got != tt.want {
+			t.Errorf("WithMaxRows(%d): got %q, %v; want %q", tt.max, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// EPUB container paths and the encryption algorithms that only obfuscate fonts.
+const (
+	epubContainerPath  = "META-INF/container.xml"
+	epubEncryptionPath = "META-INF/encryption.xml"
+	idpfFontAlgorithm  = "http://www.idpf.org/2008/embedding"
+	adobeFontAlgorithm = "http://ns.adobe.com/pdf/enc#RC"
+)
+
+// Book is the text and metadata of an EPUB book.
+type Book struct {
+	Title     string   `json:"title,omitempty"`
+	Authors   []string `json:"authors,omitempty"`
+	Publisher string   `json:"publisher,omitempty"`
+	Date      string   `json:"date,omitempty"`
+	Subjects  []string `json:"subjects,omitempty"`
+	// Text is the text of the chapters, in reading order.
+	Text string `json:"text"`
+}
+
+// IsEPUB reports whether files with extension ext (with the leading dot, in any case) are
+// EPUB books.
+func IsEPUB(ext string) bool {
+	return strings.ToLower(ext) == ".epub"
+}
+
+// ExtractEPUB reads the EPUB book at path. Books whose chapters are all encrypted (DRM)
+// yield ErrLocked. With WithSandbox, the book is read in a worker process.
+func (e *Extractor) ExtractEPUB(path string) (*Book, error) {
+	if e.sandbox != nil {
+		return e.sandbox.extractEPUB(path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return parseEPUB(content)
+}
+
+// BookText renders b as text: its title and authors, then its chapters.
+func BookText(b *Book) string {
+	var head []string
+	if b.Title != "" {
+		head = append(head, b.Title)
+	}
+	if len(b.Authors) > 0 {
+		head = append(head, strings.Join(b.Authors, ", "))
+	}
+	if len(head) == 0 {
+		return b.Text
+	}
+	return strings.Join(head, "\n") + "\n\n" + b.Text
+}
+
+// epubContainer is META-INF/container.xml, pointing at the package document.
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath  string `xml:"full-path,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage is the package document (.opf): the book's metadata, files, and reading order.
+type epubPackage struct {
+	Metadata struct {
+		Titles     []string `xml:"title"`
+		Creators   []string `xml:"creator"`
+		Publishers []string `xml:"publisher"`
+		Dates      []string `xml:"date"`
+		Subjects   []string `xml:"subject"`
+	} `xml:"metadata"`
+	Items []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// epubEncryption is META-INF/encryption.xml, listing the encrypted files.
+type epubEncryption struct {
+	Data []struct {
+		Method struct {
+			Algorithm string `xml:"Algorithm,attr"`
+		} `xml:"EncryptionMethod"`
+		URI struct {
+			URI string `xml:"URI,attr"`
+		} `xml:"CipherData>CipherReference"`
+	} `xml:"EncryptedData"`
+}
+
+// parseEPUB reads an EPUB book: a ZIP whose container.xml names the package document,
+// which lists the chapters in reading order.
+func parseEPUB(content []byte) (*Book, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("open epub: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	var container epubContainer
+	if err := readZipXML(files, epubContainerPath, &container); err != nil {
+		return nil, err
+	}
+	if len(container.Rootfiles) == 0 {
+		return nil, fmt.Errorf("epub: no package document in %s", epubContainerPath)
+	}
+	opfPath := container.Rootfiles[0].FullPath
+	var pkg epubPackage
+	if err := readZipXML(files, opfPath, &pkg); err != nil {
+		return nil, err
+	}
+	encrypted := make(map[string]bool)
+	var enc epubEncryption
+	if readZipXML(files, epubEncryptionPath, &enc) == nil {
+		for _, d := range enc.Data {
+			if a := d.Method.Algorithm; a != idpfFontAlgorithm && a != adobeFontAlgorithm {
+				encrypted[d.URI.URI] = true
+			}
+		}
+	}
+
+	md := pkg.Metadata
+	b := &Book{
+		Title:     firstNonEmpty(md.Titles),
+		Authors:   nonEmpty(md.Creators),
+		Publisher: firstNonEmpty(md.Publishers),
+		Date:      firstNonEmpty(md.Dates),
+		Subjects:  nonEmpty(md.Subjects),
+	}
+	hrefs := make(map[string]string, len(pkg.Items))
+	for _, item := range pkg.Items {
+		hrefs[item.ID] = item.Href
+	}
+	var chapters []string
+	locked := 0
+	for _, ref := range pkg.Spine {
+		href, err := url.PathUnescape(hrefs[ref.IDRef])
+		if err != nil || href == "" {
+			continue
+		}
+		name := path.Join(path.Dir(opfPath), href)
+		if encrypted[name] {
+			locked++
+			continue
+		}
+		f, ok := files[name]
+		if !ok {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("epub: read %s: %w", name, err)
+		}
+		if text := parseHTML(data).Text; text != "" {
+			chapters = append(chapters, text)
+		}
+	}
+	if locked > 0 && len(chapters) == 0 {
+		return nil, fmt.Errorf("%w: epub chapters are DRM-protected", ErrLocked)
+	}
+	b.Text = strings.Join(chapters, "\n\n")
+	return b, nil
+}
+
+// readZipXML decodes the XML file name of an archive into v.
+func readZipXML(files map[string]*zip.File, name string, v interface{}) error {
+	f, ok := files[name]
+	if !ok {
+		return fmt.Errorf("epub: %s not found", name)
+	}
+	data, err := readZipFile(f)
+	if err != nil {
+		return fmt.Errorf("epub: read %s: %w", name, err)
+	}
+	if err := xml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("epub: parse %s: %w", name, err)
+	}
+	return nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// nonEmpty returns the trimmed values of vs that are not empty.
+func nonEmpty(vs []string) []string {
+	var out []string
+	for _, v := range vs {
+		if v = strings.Join(strings.Fields(v), " "); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func firstNonEmpty(vs []string) string {
+	if vs := nonEmpty(vs); len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
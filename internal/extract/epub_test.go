@@ -0,0 +1,110 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// minimalEPUB returns an EPUB with the given files besides its container.xml, whose
+// package document is OEBPS/content.opf.
+func minimalEPUB(files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	fw, _ := w.Create("mimetype")
+	_, _ = fw.Write([]byte("application/epub+zip"))
+	fw, _ = w.Create(epubContainerPath)
+	_, _ = fw.Write([]byte(`<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`))
+	for name, content := range files {
+		fw, _ = w.Create(name)
+		_, _ = fw.Write([]byte(content))
+	}
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+const testOPF = `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>The Harbour</dc:title>
+<dc:creator>Ana Lima</dc:creator><dc:creator>Bo Chen</dc:creator>
+<dc:publisher>Tide Press</dc:publisher>
+<dc:date>2021-06-01</dc:date>
+<dc:subject>Sailing</dc:subject>
+</metadata>
+<manifest>
+<item id="c2" href="text/chapter%202.xhtml" media-type="application/xhtml+xml"/>
+<item id="c1" href="text/chapter1.xhtml" media-type="application/xhtml+xml"/>
+<item id="css" href="style.css" media-type="text/css"/>
+</manifest>
+<spine><itemref idref="c1"/><itemref idref="c2"/></spine>
+</package>`
+
+func TestParseEPUB(t *testing.T) {
+	book, err := parseEPUB(minimalEPUB(map[string]string{
+		"OEBPS/content.opf":          testOPF,
+		"OEBPS/text/chapter1.xhtml":  `<html xmlns="http://www.w3.org/1999/xhtml"><head><title>1</title></head><body><h1>Arrival</h1><p>The tide was high.</p></body></html>`,
+		"OEBPS/text/chapter 2.xhtml": `<html xmlns="http://www.w3.org/1999/xhtml"><body><h1>Departure</h1><p>The lamp went out.</p></body></html>`,
+		"OEBPS/style.css":            `p { margin: 0 }`,
+		"OEBPS/text/unlisted.xhtml":  `<p>Not in the spine</p>`,
+	}))
+	if err != nil {
+		t.Fatalf("parseEPUB: %v", err)
+	}
+	if book.Title != "The Harbour" || book.Publisher != "Tide Press" || book.Date != "2021-06-01" {
+		t.Errorf("metadata = %q, %q, %q", book.Title, book.Publisher, book.Date)
+	}
+	if !reflect.DeepEqual(book.Authors, []string{"Ana Lima", "Bo Chen"}) || !reflect.DeepEqual(book.Subjects, []string{"Sailing"}) {
+		t.Errorf("authors = %v, subjects = %v", book.Authors, book.Subjects)
+	}
+	want := "Arrival\n\nThe tide was high.\n\nDeparture\n\nThe lamp went out."
+	if book.Text != want {
+		t.Errorf("Text = %q, want %q", book.Text, want)
+	}
+	if got := BookText(book); got != "The Harbour\nAna Lima, Bo Chen\n\n"+want {
+		t.Errorf("BookText = %q", got)
+	}
+}
+
+func TestParseEPUB_drm(t *testing.T) {
+	_, err := parseEPUB(minimalEPUB(map[string]string{
+		"OEBPS/content.opf":          testOPF,
+		"OEBPS/text/chapter1.xhtml":  "\x8f\x01encrypted",
+		"OEBPS/text/chapter 2.xhtml": "\x8f\x02encrypted",
+		epubEncryptionPath: `<encryption xmlns="urn:oasis:names:tc:opendocument:xmlns:container" xmlns:enc="http://www.w3.org/2001/04/xmlenc#">
+<enc:EncryptedData><enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+<enc:CipherData><enc:CipherReference URI="OEBPS/text/chapter1.xhtml"/></enc:CipherData></enc:EncryptedData>
+<enc:EncryptedData><enc:EncryptionMethod Algorithm="http://www.w3.org/2001/04/xmlenc#aes128-cbc"/>
+<enc:CipherData><enc:CipherReference URI="OEBPS/text/chapter 2.xhtml"/></enc:CipherData></enc:EncryptedData>
+</encryption>`,
+	}))
+	if !errors.Is(err, ErrLocked) {
+		t.Errorf("err = %v, want ErrLocked", err)
+	}
+}
+
+func TestExtractEPUB_sandbox(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "harbour.epub")
+	if err := os.WriteFile(path, minimalEPUB(map[string]string{
+		"OEBPS/content.opf":          testOPF,
+		"OEBPS/text/chapter1.xhtml":  `<html><body><p>The tide was high.</p></body></html>`,
+		"OEBPS/text/chapter 2.xhtml": `<html><body><p>The lamp went out.</p></body></html>`,
+	}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	book, err := sandboxExtractor("extract-worker", 10*time.Second).ExtractEPUB(path)
+	if err != nil {
+		t.Fatalf("ExtractEPUB: %v", err)
+	}
+	if book.Title != "The Harbour" || book.Text != "The tide was high.\n\nThe lamp went out." {
+		t.Errorf("book = %+v", book)
+	}
+}
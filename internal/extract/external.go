@@ -0,0 +1,139 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultExternalTimeout is the time an external extractor may run when ExternalConfig
+// sets none.
+const DefaultExternalTimeout = 2 * time.Minute
+
+// ErrExternal is returned (wrapped) when an external extractor failed, was killed for
+// taking too long, or wrote something other than a response.
+var ErrExternal = errors.New("external extractor failed")
+
+// ExternalConfig extracts the files with Extensions with a program of the user's, for
+// formats sagasu does not read.
+//
+// The program gets the content of the file on stdin and its name in the SAGASU_FILE_NAME
+// environment variable ("file" and the extension for content not read from a file, such
+// as a file in an archive), and writes a JSON object to stdout:
+//
+//	{"text": "...", "metadata": {"author": "Alice"}, "error": "...", "locked": false}
+//
+// Metadata is optional. A non-empty error fails the file; with locked set, the file is
+// reported as encrypted (ErrLocked).
+type ExternalConfig struct {
+	// Command is the program and its arguments.
+	Command []string
+	// Extensions are those of the files extracted, with or without the leading dot.
+	Extensions []string
+	// Timeout is the wall-clock time after which the program is killed; 0 means
+	// DefaultExternalTimeout.
+	Timeout time.Duration
+}
+
+// External is what an external extractor found in a file.
+type External struct {
+	Text     string                 `json:"text"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Locked   bool                   `json:"locked,omitempty"`
+}
+
+// WithExternal extracts the files with the extensions of each of cfgs with its command,
+// in place of the built-in extractor of the format. An extension listed twice goes to the
+// first. Configurations without a command are ignored.
+func (e *Extractor) WithExternal(cfgs ...ExternalConfig) *Extractor {
+	e.external = nil
+	for _, cfg := range cfgs {
+		if len(cfg.Command) == 0 {
+			continue
+		}
+		exts := make([]string, len(cfg.Extensions))
+		for i, ext := range cfg.Extensions {
+			exts[i] = "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+		}
+		cfg.Extensions = exts
+		if cfg.Timeout <= 0 {
+			cfg.Timeout = DefaultExternalTimeout
+		}
+		e.external = append(e.external, cfg)
+	}
+	return e
+}
+
+// ExternalFormat reports whether files with extension ext (with the leading dot, in any
+// case) are extracted by an external extractor (see WithExternal).
+func (e *Extractor) ExternalFormat(ext string) bool {
+	return e.externalFor(ext) != nil
+}
+
+// externalFor returns the external extractor of extension ext, or nil.
+func (e *Extractor) externalFor(ext string) *ExternalConfig {
+	ext = strings.ToLower(ext)
+	for i := range e.external {
+		for _, x := range e.external[i].Extensions {
+			if x == ext {
+				return &e.external[i]
+			}
+		}
+	}
+	return nil
+}
+
+// ExtractExternal returns the text and metadata the external extractor of its extension
+// finds in the file at path.
+func (e *Extractor) ExtractExternal(path string) (*External, error) {
+	cfg := e.externalFor(filepath.Ext(path))
+	if cfg == nil {
+		return nil, fmt.Errorf("%w: no external extractor for %s", ErrExternal, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return cfg.run(content, filepath.Base(path))
+}
+
+// run runs the extractor on content, the file named name, and returns its response.
+func (cfg *ExternalConfig) run(content []byte, name string) (*External, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cfg.Command[0], cfg.Command[1:]...)
+	cmd.Env = append(os.Environ(), "SAGASU_FILE_NAME="+name)
+	cmd.Stdin = bytes.NewReader(content)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("%w: %s: killed after %s", ErrExternal, name, cfg.Timeout)
+	case err != nil:
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 200 {
+			msg = msg[len(msg)-200:]
+		}
+		return nil, fmt.Errorf("%w: %s: %v %s", ErrExternal, name, err, msg)
+	}
+	var resp External
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("%w: %s: invalid response: %v", ErrExternal, name, err)
+	}
+	switch {
+	case resp.Locked:
+		return nil, fmt.Errorf("%s: %w", name, ErrLocked)
+	case resp.Error != "":
+		return nil, fmt.Errorf("%w: %s: %s", ErrExternal, name, resp.Error)
+	}
+	return &resp, nil
+}
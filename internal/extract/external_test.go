@@ -0,0 +1,63 @@
+package extract
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractExternal(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "floor.DWG")
+	if err := os.WriteFile(path, []byte("LEVEL 2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	e := NewExtractor().WithExternal(
+		ExternalConfig{Extensions: []string{"dwg"}, Command: []string{"sh", "-c",
+			`printf '{"text": "%s from %s", "metadata": {"author": "Ana", "layers": ["walls", "doors"]}}' "$(cat)" "$SAGASU_FILE_NAME"`}},
+		ExternalConfig{Extensions: []string{".dwg", ".sketch"}, Command: []string{"false"}},
+		ExternalConfig{Extensions: []string{".txt"}},
+	)
+	if !e.ExternalFormat(".dwg") || !e.ExternalFormat(".SKETCH") || e.ExternalFormat(".txt") {
+		t.Fatal("ExternalFormat: want .dwg and .sketch only")
+	}
+	x, err := e.ExtractExternal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Text != "LEVEL 2 from floor.DWG" {
+		t.Errorf("text = %q", x.Text)
+	}
+	if want := map[string]interface{}{"author": "Ana", "layers": []interface{}{"walls", "doors"}}; !reflect.DeepEqual(x.Metadata, want) {
+		t.Errorf("metadata = %v, want %v", x.Metadata, want)
+	}
+	if text, err := e.Extract(path); err != nil || text != x.Text {
+		t.Errorf("Extract = %q, %v", text, err)
+	}
+	if text, err := e.ExtractBytes([]byte("LEVEL 3"), ".dwg"); err != nil || text != "LEVEL 3 from file.dwg" {
+		t.Errorf("ExtractBytes = %q, %v", text, err)
+	}
+
+	for name, tt := range map[string]struct {
+		script string
+		want   error
+		msg    string
+	}{
+		"exit status": {"echo no license >&2; exit 3", ErrExternal, "no license"},
+		"not json":    {"echo plain words", ErrExternal, "invalid response"},
+		"error":       {`echo '{"error": "unsupported version"}'`, ErrExternal, "unsupported version"},
+		"locked":      {`echo '{"locked": true}'`, ErrLocked, "floor.DWG"},
+	} {
+		failing := NewExtractor().WithExternal(ExternalConfig{Extensions: []string{"dwg"}, Command: []string{"sh", "-c", tt.script}})
+		if _, err := failing.ExtractExternal(path); !errors.Is(err, tt.want) || !strings.Contains(err.Error(), tt.msg) {
+			t.Errorf("%s: got %v, want %v with %q", name, err, tt.want, tt.msg)
+		}
+	}
+}
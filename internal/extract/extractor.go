@@ -2,6 +2,7 @@
 package extract
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,7 +10,15 @@ import (
 )
 
 // Extractor extracts plain text from document files.
-type Extractor struct{}
+type Extractor struct {
+	passwords []PasswordRule
+	sandbox   *sandbox
+	maxRows   int // see WithMaxRows
+
+	transcriber *TranscriberConfig // optional; see WithTranscriber
+	external    []ExternalConfig   // see WithExternal
+	converter   *converter         // optional; see WithConverter
+}
 
 // NewExtractor returns a new Extractor.
 func NewExtractor() *Extractor {
@@ -18,23 +27,87 @@ func NewExtractor() *Extractor {
 
 // Extract reads the file at path and returns its text content.
 // For plain text files (.txt, .md, .rst), content is returned as-is (UTF-8 validated).
-// For PDF, DOCX, Excel, PPTX, ODP, and ODS, text is extracted from the binary format.
-// Returns an error if the file cannot be read or the format is unsupported.
+// For PDF, DOCX, Excel, PPTX, ODP, and ODS, text is extracted from the binary format, and
+// for mail (.eml, .msg, .mbox) the headers and bodies of the messages (see MailText). HTML
+// pages (.html, .htm) yield their title and the text of their body (see PageText), EPUB
+// books their title, authors, and chapters (see BookText), and Markdown its text without
+// the YAML front matter (see ExtractMarkdown). CSV, TSV, JSON, and JSON Lines data yield
+// a line per row or record, up to the row limit (see ExtractData), and Apple Pages,
+// Numbers, and Keynote files the text of their paragraphs and tables. With WithTranscriber,
+// audio and video files yield their transcript (see TranscriptText), and with
+// WithExternal, the files of an external extractor the text it writes. With
+// WithConverter, legacy Office files (.doc, .xls, .ppt) yield the text of their conversion
+// (see ExtractConverted).
+// Returns an error if the file cannot be read or the format is unsupported, and one
+// wrapping ErrLocked if it is encrypted and no password set by WithPasswords opens it.
+// With WithSandbox, binary formats are extracted in a worker process.
 func (e *Extractor) Extract(path string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if e.Transcribes(ext) {
+		t, err := e.ExtractTranscript(path)
+		if err != nil {
+			return "", err
+		}
+		return TranscriptText(t), nil
+	}
+	if e.ExternalFormat(ext) {
+		x, err := e.ExtractExternal(path)
+		if err != nil {
+			return "", err
+		}
+		return x.Text, nil
+	}
+	if e.Converts(ext) {
+		return e.ExtractConverted(path)
+	}
+	if e.sandbox != nil && sandboxed(ext) {
+		return e.sandbox.extract(path, ext, e.passwordsFor(path))
+	}
 	content, err := os.ReadFile(path)
 	if err != nil {
 		return "", fmt.Errorf("read file: %w", err)
 	}
-	ext := strings.ToLower(filepath.Ext(path))
-	return e.ExtractBytes(content, ext)
+	return e.extractBytes(content, ext, e.passwordsFor(path))
+}
+
+// KnownFormat reports whether ext (with the leading dot, in any case) has an extractor of
+// its own. Files with other extensions are read as plain text.
+func KnownFormat(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".pdf", ".docx", ".odt", ".rtf", ".xlsx", ".pptx", ".odp", ".ods", ".txt", ".md", ".rst",
+		".eml", ".msg", ".mbox", ".html", ".htm", ".epub", ".markdown",
+		".csv", ".tsv", ".json", ".jsonl", ".ndjson", ".pages", ".numbers", ".key":
+		return true
+	}
+	return false
 }
 
 // ExtractBytes extracts text from content based on the given extension.
-// ext should include the leading dot (e.g. ".pdf").
+// ext should include the leading dot (e.g. ".pdf"). Encrypted content yields ErrLocked.
 func (e *Extractor) ExtractBytes(content []byte, ext string) (string, error) {
+	return e.extractBytes(content, ext, nil)
+}
+
+// extractBytes is ExtractBytes trying passwords on encrypted PDF and Office content.
+func (e *Extractor) extractBytes(content []byte, ext string, passwords []string) (string, error) {
+	if cfg := e.externalFor(ext); cfg != nil {
+		x, err := cfg.run(content, "file"+ext)
+		if err != nil {
+			return "", err
+		}
+		return x.Text, nil
+	}
+	switch ext {
+	case ".docx", ".xlsx", ".pptx":
+		decrypted, err := decryptOffice(content, passwords)
+		if err != nil {
+			return "", err
+		}
+		content = decrypted
+	}
 	switch ext {
 	case ".pdf":
-		return extractPDF(content)
+		return extractPDF(content, passwords)
 	case ".docx", ".odt", ".rtf":
 		return extractDOCX(content)
 	case ".xlsx":
@@ -45,7 +118,29 @@ func (e *Extractor) ExtractBytes(content []byte, ext string) (string, error) {
 		return extractODP(content)
 	case ".ods":
 		return extractODS(content)
-	case ".txt", ".md", ".rst", "":
+	case ".eml", ".msg", ".mbox":
+		return extractMail(content, ext)
+	case ".html", ".htm":
+		return PageText(parseHTML(content)), nil
+	case ".epub":
+		book, err := parseEPUB(content)
+		if err != nil {
+			return "", err
+		}
+		return BookText(book), nil
+	case ".md", ".markdown":
+		return parseMarkdown(content).Text, nil
+	case ".csv", ".tsv", ".json", ".jsonl", ".ndjson":
+		return parseData(content, ext, e.rowLimit()).Text, nil
+	case ".pages", ".numbers":
+		return extractIWork(content)
+	case ".key":
+		if !bytes.HasPrefix(content, []byte("PK")) {
+			// Not Keynote, e.g. a PEM private key
+			return extractPlain(content)
+		}
+		return extractIWork(content)
+	case ".txt", ".rst", "":
 		return extractPlain(content)
 	default:
 		// Unknown extension: treat as plain text
@@ -407,3 +407,26 @@ func TestExtract_odsContentNotFound(t *testing.T) {
 		t.Error("expected error when content.xml missing")
 	}
 }
+
+func TestIsBinary(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		sample []byte
+		want   bool
+	}{
+		{"text", []byte("Hello world\n\tindented\r\n"), false},
+		{"utf8", []byte("こんにちは世界"), false},
+		{"cut rune", []byte("日本")[:4], false},
+		{"latin1", []byte("caf\xe9 cr\xe8me br\xfbl\xe9e"), false},
+		{"empty", nil, false},
+		{"nul", []byte("PK\x03\x04\x00\x00"), true},
+		{"control", []byte("\x01\x02\x03\x04abc\x05\x06"), true},
+	} {
+		if got := IsBinary(tc.sample); got != tc.want {
+			t.Errorf("IsBinary(%s) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	if !PlainText(".LOG") || !PlainText("") || PlainText(".PDF") {
+		t.Error("PlainText should hold for all but the parsed formats")
+	}
+}
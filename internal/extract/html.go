@@ -0,0 +1,259 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+)
+
+// maxPageLinks bounds the links kept from a page.
+const maxPageLinks = 200
+
+// Page is the text and metadata of an HTML page.
+type Page struct {
+	Title       string
+	Description string
+	Author      string
+	// URL is where the page was saved from: its canonical or og:url link, or the URL a
+	// browser or SingleFile noted when saving it.
+	URL string
+	// Links are the distinct absolute http(s) links of the page, in order, at most 200.
+	Links []string
+	// Text is the text of the body, a line per block, with blank lines around headings and
+	// paragraphs.
+	Text string
+}
+
+// IsHTML reports whether files with extension ext (with the leading dot, in any case) are
+// HTML pages.
+func IsHTML(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".html", ".htm":
+		return true
+	}
+	return false
+}
+
+// ExtractHTML reads the HTML page at path. Its encoding is taken from a byte order mark or
+// a meta tag, else guessed from the content.
+func (e *Extractor) ExtractHTML(path string) (*Page, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return parseHTML(content), nil
+}
+
+// PageText renders p as text: its title, then the text of its body.
+func PageText(p *Page) string {
+	if p.Title == "" || strings.HasPrefix(p.Text, p.Title) {
+		return p.Text
+	}
+	return p.Title + "\n\n" + p.Text
+}
+
+// parseHTML reads the page in content, in the encoding it declares or seems to have.
+func parseHTML(content []byte) *Page {
+	enc, _, _ := charset.DetermineEncoding(content, "")
+	if decoded, err := enc.NewDecoder().Bytes(content); err == nil {
+		content = decoded
+	}
+	return readPage(bytes.NewReader(content))
+}
+
+// htmlText returns the text of an HTML body, such as that of a mail.
+func htmlText(s string) string {
+	return readPage(strings.NewReader(s)).Text
+}
+
+// readPage reads an HTML page from UTF-8 r. Scripts, styles, and other content that is not
+// shown are left out.
+func readPage(r io.Reader) *Page {
+	p := &Page{}
+	z := html.NewTokenizer(r)
+	var text pageText
+	var title strings.Builder
+	var base *url.URL
+	seen := make(map[string]bool)
+	skip, inTitle := 0, false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if p.Title == "" {
+				p.Title = strings.Join(strings.Fields(title.String()), " ")
+			}
+			p.Text = tidyLines(text.b.String())
+			return p
+		case html.CommentToken:
+			if p.URL == "" {
+				p.URL = savedFrom(string(z.Text()))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, hasAttr := z.TagName()
+			attrs := make(map[string]string)
+			for hasAttr {
+				var k, v []byte
+				k, v, hasAttr = z.TagAttr()
+				attrs[string(k)] = string(v)
+			}
+			start := tt != html.EndTagToken
+			switch tag := string(name); tag {
+			case "script", "style", "noscript", "template", "svg":
+				if tt == html.StartTagToken {
+					skip++
+				} else if tt == html.EndTagToken && skip > 0 {
+					skip--
+				}
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "base":
+				base, _ = url.Parse(attrs["href"])
+			case "meta":
+				p.meta(attrs)
+			case "link":
+				if strings.EqualFold(attrs["rel"], "canonical") && attrs["href"] != "" {
+					p.URL = attrs["href"]
+				}
+			case "a":
+				if link := absoluteLink(base, attrs["href"]); start && link != "" && !seen[link] && len(p.Links) < maxPageLinks {
+					seen[link] = true
+					p.Links = append(p.Links, link)
+				}
+			case "pre":
+				if tt == html.StartTagToken {
+					text.pre++
+				} else if tt == html.EndTagToken && text.pre > 0 {
+					text.pre--
+				}
+				text.brk(2)
+			case "h1", "h2", "h3", "h4", "h5", "h6", "p", "blockquote", "table", "ul", "ol", "dl", "hr", "figure":
+				text.brk(2)
+			case "br", "div", "li", "tr", "dt", "dd", "section", "article", "header", "footer", "nav", "aside", "figcaption":
+				text.brk(1)
+			case "td", "th":
+				text.write(" ")
+			}
+		case html.TextToken:
+			switch {
+			case inTitle:
+				title.Write(z.Text())
+			case skip == 0:
+				text.write(string(z.Text()))
+			}
+		}
+	}
+}
+
+// pageText is the text of a page body being read: whitespace collapsed as browsers do,
+// outside pre blocks, and line breaks or blank lines between blocks.
+type pageText struct {
+	b      strings.Builder
+	pre    int // depth of pre blocks
+	breaks int // newlines owed before the next text
+}
+
+// brk ends the current line, with n == 2 leaving a blank line after it.
+func (t *pageText) brk(n int) {
+	t.breaks = max(t.breaks, n)
+}
+
+func (t *pageText) write(s string) {
+	if t.pre == 0 {
+		s = collapseSpace(s)
+	}
+	if strings.TrimSpace(s) == "" && t.breaks > 0 {
+		return
+	}
+	if t.b.Len() > 0 {
+		t.b.WriteString(strings.Repeat("\n", t.breaks))
+	}
+	t.breaks = 0
+	t.b.WriteString(s)
+}
+
+// collapseSpace replaces each run of white space in s by a single space.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// meta records the page metadata a meta tag with attrs holds.
+func (p *Page) meta(attrs map[string]string) {
+	key := strings.ToLower(attrs["name"])
+	if key == "" {
+		key = strings.ToLower(attrs["property"])
+	}
+	content := strings.TrimSpace(attrs["content"])
+	if content == "" {
+		return
+	}
+	switch key {
+	case "description", "og:description":
+		if p.Description == "" {
+			p.Description = content
+		}
+	case "author":
+		p.Author = content
+	case "og:title":
+		p.Title = content
+	case "og:url":
+		if p.URL == "" {
+			p.URL = content
+		}
+	}
+}
+
+// savedFrom returns the URL a comment says the page was saved from: browsers write
+// "saved from url=(0023)https://example.com/", SingleFile a line "url: https://...".
+func savedFrom(comment string) string {
+	if _, rest, ok := strings.Cut(comment, "saved from url=("); ok {
+		if _, u, ok := strings.Cut(rest, ")"); ok {
+			return strings.TrimSpace(u)
+		}
+	}
+	for _, line := range strings.Split(comment, "\n") {
+		if u, ok := strings.CutPrefix(strings.TrimSpace(line), "url: "); ok {
+			return strings.TrimSpace(u)
+		}
+	}
+	return ""
+}
+
+// absoluteLink resolves href against base, returning it only when it is an http(s) URL.
+func absoluteLink(base *url.URL, href string) string {
+	u, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return ""
+	}
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return ""
+	}
+	u.Fragment = ""
+	return u.String()
+}
@@ -0,0 +1,77 @@
+package extract
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHTML(t *testing.T) {
+	page := parseHTML([]byte(`<!DOCTYPE html>
+<!-- saved from url=(0032)https://wiki.example.com/harbour -->
+<html><head>
+<meta charset="windows-1252">
+<title>Harbour | Wiki</title>
+<meta name="description" content="Tides and moorings">
+<meta name="author" content="Port Office">
+<base href="https://wiki.example.com/pages/">
+<style>body { color: red }</style>
+<script>var tides = "not text";</script>
+</head><body>
+<h1>Harbour</h1>
+<p>Moorings cost 40` + "\x80" + ` a night.</p>
+<h2>Tides</h2>
+<ul><li><a href="tides#spring">Spring tides</a></li><li><a href="https://example.org/">Almanac</a></li>
+<li><a href="mailto:port@example.com">Mail</a></li><li><a href="tides">Again</a></li></ul>
+<noscript>Enable JavaScript</noscript>
+</body></html>`))
+
+	if page.Title != "Harbour | Wiki" || page.Description != "Tides and moorings" || page.Author != "Port Office" {
+		t.Errorf("metadata = %q, %q, %q", page.Title, page.Description, page.Author)
+	}
+	if page.URL != "https://wiki.example.com/harbour" {
+		t.Errorf("URL = %q", page.URL)
+	}
+	if want := []string{"https://wiki.example.com/pages/tides", "https://example.org/"}; !reflect.DeepEqual(page.Links, want) {
+		t.Errorf("Links = %v, want %v", page.Links, want)
+	}
+	want := "Harbour\n\nMoorings cost 40€ a night.\n\nTides\n\nSpring tides\nAlmanac\nMail\nAgain"
+	if page.Text != want {
+		t.Errorf("Text = %q, want %q", page.Text, want)
+	}
+	if got := PageText(page); !strings.HasPrefix(got, "Harbour | Wiki\n\nHarbour\n") {
+		t.Errorf("PageText = %q", got)
+	}
+}
+
+func TestParseHTML_openGraph(t *testing.T) {
+	page := parseHTML([]byte(`<html><head><title>Post - Blog</title>
+<meta property="og:title" content="Post">
+<meta property="og:url" content="https://blog.example.com/post">
+<link rel="canonical" href="https://blog.example.com/post?ref=1">
+</head><body><article><h1>Post</h1><p>Body</p></article></body></html>`))
+	if page.Title != "Post" {
+		t.Errorf("Title = %q, want the og:title", page.Title)
+	}
+	if page.URL != "https://blog.example.com/post?ref=1" {
+		t.Errorf("URL = %q, want the canonical link", page.URL)
+	}
+	if got := PageText(page); got != "Post\n\nBody" {
+		t.Errorf("PageText = %q, want the title once", got)
+	}
+}
+
+func TestSavedFrom(t *testing.T) {
+	tests := []struct {
+		comment, want string
+	}{
+		{" saved from url=(0019)https://example.com/ ", "https://example.com/"},
+		{"\n Page saved with SingleFile \n url: https://example.com/a \n saved date: Mon Jan 01 2024\n", "https://example.com/a"},
+		{" generated by a wiki export ", ""},
+	}
+	for _, tt := range tests {
+		if got := savedFrom(tt.comment); got != tt.want {
+			t.Errorf("savedFrom(%q) = %q, want %q", tt.comment, got, tt.want)
+		}
+	}
+}
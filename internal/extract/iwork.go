@@ -0,0 +1,309 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/klauspost/compress/snappy"
+)
+
+// Object types of the iWork archives that hold text: the text storages of Pages
+// documents, shapes, and notes, and the string lists of tables.
+const (
+	iworkStorageArchive = 2001 // TSWP.StorageArchive: field 3 is the text
+	iworkTableDataList  = 6005 // TST.TableDataList: field 3 lists entries, whose field 3 is a string
+)
+
+// iworkLegacyPaths are the XML documents of iWork '09 files: Pages and Numbers, then
+// Keynote, each possibly gzipped.
+var iworkLegacyPaths = []string{"index.xml", "index.xml.gz", "index.apxl", "index.apxl.gz"}
+
+// iworkPreviewPath is the PDF preview some iWork files carry.
+const iworkPreviewPath = "QuickLook/Preview.pdf"
+
+// IsIWork reports whether files with extension ext (with the leading dot, in any case) are
+// Apple Pages, Numbers, or Keynote documents.
+func IsIWork(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".pages", ".numbers", ".key":
+		return true
+	}
+	return false
+}
+
+// extractIWork extracts the text of a Pages, Numbers, or Keynote file: a ZIP holding the
+// document's objects in IWA archives under Index/ (iWork 2013 and later), an XML
+// document (iWork '09), or only a PDF preview. Password-protected documents yield
+// ErrLocked. Documents saved as a package directory are not read.
+func extractIWork(content []byte) (string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return "", fmt.Errorf("extract iWork: not a zip: %w", err)
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	if files[".iwph"] != nil || files[".iwpv2"] != nil {
+		return "", fmt.Errorf("extract iWork: %w", ErrLocked)
+	}
+	if f := files["Index.zip"]; f != nil {
+		// A package saved by iWork 2013 keeps its archives in a ZIP of their own
+		data, err := readZipFile(f)
+		if err != nil {
+			return "", fmt.Errorf("extract iWork: read Index.zip: %w", err)
+		}
+		if zr, err = zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+			return "", fmt.Errorf("extract iWork: Index.zip: %w", err)
+		}
+	}
+	var archives []*zip.File
+	for _, f := range zr.File {
+		if strings.HasPrefix(f.Name, "Index/") && strings.HasSuffix(f.Name, ".iwa") {
+			archives = append(archives, f)
+		}
+	}
+	if len(archives) > 0 {
+		return iworkArchivesText(archives)
+	}
+	for _, name := range iworkLegacyPaths {
+		if f := files[name]; f != nil {
+			return iworkLegacyText(f)
+		}
+	}
+	if f := files[iworkPreviewPath]; f != nil {
+		data, err := readZipFile(f)
+		if err != nil {
+			return "", fmt.Errorf("extract iWork: read %s: %w", iworkPreviewPath, err)
+		}
+		return extractPDF(data, nil)
+	}
+	return "", errors.New("extract iWork: no document in the package")
+}
+
+// iworkArchivesText returns the text of the IWA archives files, Document.iwa first and the
+// others by name, a line per paragraph or table string.
+func iworkArchivesText(files []*zip.File) (string, error) {
+	sort.Slice(files, func(i, j int) bool {
+		di, dj := path.Base(files[i].Name) == "Document.iwa", path.Base(files[j].Name) == "Document.iwa"
+		if di != dj {
+			return di
+		}
+		return files[i].Name < files[j].Name
+	})
+	var lines []string
+	for _, f := range files {
+		data, err := readZipFile(f)
+		if err != nil {
+			return "", fmt.Errorf("extract iWork: read %s: %w", f.Name, err)
+		}
+		stream, err := iwaDecompress(data)
+		if err != nil {
+			return "", fmt.Errorf("extract iWork: %s: %w", f.Name, err)
+		}
+		lines = iwaText(stream, lines)
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// iwaDecompress undoes the framing of an IWA archive: chunks each led by a zero byte and
+// their 24-bit little-endian length, holding a Snappy block.
+func iwaDecompress(data []byte) ([]byte, error) {
+	var out []byte
+	for len(data) > 0 {
+		if len(data) < 4 || data[0] != 0 {
+			return nil, errors.New("invalid IWA chunk header")
+		}
+		n := int(data[1]) | int(data[2])<<8 | int(data[3])<<16
+		if len(data) < 4+n {
+			return nil, errors.New("truncated IWA chunk")
+		}
+		block, err := snappy.Decode(nil, data[4:4+n])
+		if err != nil {
+			return nil, fmt.Errorf("IWA chunk: %w", err)
+		}
+		out = append(out, block...)
+		data = data[4+n:]
+	}
+	return out, nil
+}
+
+// iwaText appends to lines the text of the objects of an IWA stream: each object is an
+// ArchiveInfo, prefixed by its varint length, listing the type and length of the
+// messages that follow it. Streams it cannot follow to the end keep the text read so far.
+func iwaText(stream []byte, lines []string) []string {
+	for len(stream) > 0 {
+		n, k := binary.Uvarint(stream)
+		if k <= 0 || uint64(len(stream)-k) < n {
+			return lines
+		}
+		info := stream[k : k+int(n)]
+		stream = stream[k+int(n):]
+		for _, msg := range protoBytes(info, 2) { // ArchiveInfo.message_infos
+			typ, length := protoVarint(msg, 1), protoVarint(msg, 3)
+			if uint64(len(stream)) < length {
+				return lines
+			}
+			payload := stream[:length]
+			stream = stream[length:]
+			switch typ {
+			case iworkStorageArchive:
+				for _, text := range protoBytes(payload, 3) {
+					lines = appendIWorkText(lines, string(text))
+				}
+			case iworkTableDataList:
+				for _, entry := range protoBytes(payload, 3) {
+					for _, s := range protoBytes(entry, 3) {
+						lines = appendIWorkText(lines, string(s))
+					}
+				}
+			}
+		}
+	}
+	return lines
+}
+
+// iworkBreaks turns the paragraph and line separators of iWork text into newlines and
+// drops the placeholders of attachments, such as images.
+var iworkBreaks = strings.NewReplacer("\u2028", "\n", "\u2029", "\n", "\ufffc", "", "\r", "\n")
+
+// appendIWorkText appends the non-empty lines of text to lines.
+func appendIWorkText(lines []string, text string) []string {
+	for _, line := range strings.Split(iworkBreaks.Replace(text), "\n") {
+		if line = strings.Join(strings.Fields(line), " "); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// protoBytes returns the length-delimited values of field num of the protobuf message msg.
+// A malformed message yields the values before the fault.
+func protoBytes(msg []byte, num uint64) [][]byte {
+	var out [][]byte
+	protoFields(msg, func(field, wire uint64, v uint64, b []byte) {
+		if field == num && wire == 2 {
+			out = append(out, b)
+		}
+	})
+	return out
+}
+
+// protoVarint returns the last varint value of field num of msg, or 0.
+func protoVarint(msg []byte, num uint64) uint64 {
+	var out uint64
+	protoFields(msg, func(field, wire uint64, v uint64, b []byte) {
+		if field == num && wire == 0 {
+			out = v
+		}
+	})
+	return out
+}
+
+// protoFields calls fn for each field of the protobuf message msg, with its varint value
+// or its bytes, until the end of msg or a malformed field.
+func protoFields(msg []byte, fn func(field, wire, v uint64, b []byte)) {
+	for len(msg) > 0 {
+		key, k := binary.Uvarint(msg)
+		if k <= 0 {
+			return
+		}
+		msg = msg[k:]
+		field, wire := key>>3, key&7
+		switch wire {
+		case 0:
+			v, k := binary.Uvarint(msg)
+			if k <= 0 {
+				return
+			}
+			fn(field, wire, v, nil)
+			msg = msg[k:]
+		case 1:
+			if len(msg) < 8 {
+				return
+			}
+			msg = msg[8:]
+		case 2:
+			n, k := binary.Uvarint(msg)
+			if k <= 0 || uint64(len(msg)-k) < n {
+				return
+			}
+			fn(field, wire, 0, msg[k:k+int(n)])
+			msg = msg[k+int(n):]
+		case 5:
+			if len(msg) < 4 {
+				return
+			}
+			msg = msg[4:]
+		default:
+			return
+		}
+	}
+}
+
+// iworkLegacyText returns the text of the XML document of an iWork '09 file: the
+// paragraphs (sf:p) and the strings of table cells (sf:ct's sfa:s), a line each.
+func iworkLegacyText(f *zip.File) (string, error) {
+	data, err := readZipFile(f)
+	if err != nil {
+		return "", fmt.Errorf("extract iWork: read %s: %w", f.Name, err)
+	}
+	if strings.HasSuffix(f.Name, ".gz") {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return "", fmt.Errorf("extract iWork: %s: %w", f.Name, err)
+		}
+		if data, err = io.ReadAll(gz); err != nil {
+			return "", fmt.Errorf("extract iWork: %s: %w", f.Name, err)
+		}
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+	var lines []string
+	var para *strings.Builder
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("extract iWork: parse %s: %w", f.Name, err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				para = &strings.Builder{}
+			case "br", "lnbr":
+				if para != nil {
+					para.WriteByte('\n')
+				}
+			case "ct":
+				for _, a := range t.Attr {
+					if a.Name.Local == "s" {
+						lines = appendIWorkText(lines, a.Value)
+					}
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "p" && para != nil {
+				lines = appendIWorkText(lines, para.String())
+				para = nil
+			}
+		case xml.CharData:
+			if para != nil {
+				para.Write(t)
+			}
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,105 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ErrLocked is returned (wrapped) when a file is encrypted and none of the passwords
+// supplied for it opens it.
+var ErrLocked = errors.New("file is encrypted")
+
+// cfbSignature starts a Compound File Binary, the container Office uses for encrypted
+// .docx, .xlsx and .pptx files instead of a ZIP.
+var cfbSignature = []byte{0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1}
+
+// PasswordRule supplies a password for the encrypted files whose path matches Pattern.
+// A pattern without a path separator matches the file name (e.g. "payroll-*.xlsx");
+// otherwise it matches the whole path, and a trailing "/**" matches every file under
+// the directory.
+type PasswordRule struct {
+	Pattern  string
+	Password string
+}
+
+// matches reports whether the rule applies to the file at path.
+func (r PasswordRule) matches(path string) bool {
+	if !strings.ContainsRune(r.Pattern, filepath.Separator) {
+		ok, _ := filepath.Match(r.Pattern, filepath.Base(path))
+		return ok
+	}
+	if dir, ok := strings.CutSuffix(r.Pattern, string(filepath.Separator)+"**"); ok {
+		for d := filepath.Dir(path); ; d = filepath.Dir(d) {
+			if ok, _ := filepath.Match(dir, d); ok {
+				return true
+			}
+			if parent := filepath.Dir(d); parent == d {
+				return false
+			}
+		}
+	}
+	ok, _ := filepath.Match(r.Pattern, path)
+	return ok
+}
+
+// WithPasswords sets the rules Extract uses to open encrypted files; every matching
+// rule's password is tried in order.
+func (e *Extractor) WithPasswords(rules []PasswordRule) *Extractor {
+	e.passwords = rules
+	return e
+}
+
+// HasPasswords reports whether a password rule matches path.
+func (e *Extractor) HasPasswords(path string) bool {
+	return len(e.passwordsFor(path)) > 0
+}
+
+// passwordsFor returns the passwords of the rules matching path.
+func (e *Extractor) passwordsFor(path string) []string {
+	var out []string
+	for _, r := range e.passwords {
+		if r.matches(path) {
+			out = append(out, r.Password)
+		}
+	}
+	return out
+}
+
+// decryptOffice returns the ZIP package of an encrypted Office file, or ErrLocked when
+// none of passwords opens it. Content that is not encrypted is returned as is.
+func decryptOffice(content []byte, passwords []string) ([]byte, error) {
+	if !bytes.HasPrefix(content, cfbSignature) {
+		return content, nil
+	}
+	for _, pw := range passwords {
+		if decrypted := decryptPackage(content, pw); decrypted != nil {
+			return decrypted, nil
+		}
+	}
+	return nil, fmt.Errorf("open Office document: %w", ErrLocked)
+}
+
+// decryptPackage decrypts an encrypted Office file with password, returning nil when it
+// fails. A wrong password is only noticed because the result is not a ZIP, and malformed
+// files can make the decryption panic.
+func decryptPackage(content []byte, password string) (decrypted []byte) {
+	defer func() {
+		if recover() != nil {
+			decrypted = nil
+		}
+	}()
+	decrypted, err := excelize.Decrypt(content, &excelize.Options{Password: password})
+	if err != nil {
+		return nil
+	}
+	if _, err := zip.NewReader(bytes.NewReader(decrypted), int64(len(decrypted))); err != nil {
+		return nil
+	}
+	return decrypted
+}
@@ -0,0 +1,119 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// encrypted encrypts an Office package with password. The package is padded with a
+// stored entry first, as excelize only decrypts packages larger than the 4 KiB mini
+// stream cutoff of their container.
+func encrypted(t *testing.T, content []byte, password string) []byte {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range zr.File {
+		if err := zw.Copy(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "docProps/padding.bin", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(make([]byte, 8192)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	out, err := excelize.Encrypt(buf.Bytes(), &excelize.Options{Password: password})
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	return out
+}
+
+func TestExtract_encryptedDocx(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "finance", "budget.docx")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, encrypted(t, minimalDocx("Quarterly budget"), "hunter2"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewExtractor().Extract(path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("without password: got %v, want ErrLocked", err)
+	}
+	wrong := NewExtractor().WithPasswords([]PasswordRule{{Pattern: "*.docx", Password: "nope"}})
+	if _, err := wrong.Extract(path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("wrong password: got %v, want ErrLocked", err)
+	}
+	e := NewExtractor().WithPasswords([]PasswordRule{
+		{Pattern: "*.docx", Password: "nope"},
+		{Pattern: filepath.Join(dir, "finance", "**"), Password: "hunter2"},
+	})
+	got, err := e.Extract(path)
+	if err != nil {
+		t.Fatalf("Extract: %v", err)
+	}
+	if !strings.Contains(got, "Quarterly budget") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestExtractBytes_encryptedExcel(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	f.SetCellValue("Sheet1", "A1", "Salary")
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := encrypted(t, buf.Bytes(), "s3cret")
+
+	if _, err := NewExtractor().ExtractBytes(content, ".xlsx"); !errors.Is(err, ErrLocked) {
+		t.Fatalf("got %v, want ErrLocked", err)
+	}
+	got, err := NewExtractor().extractBytes(content, ".xlsx", []string{"s3cret"})
+	if err != nil {
+		t.Fatalf("extractBytes: %v", err)
+	}
+	if got != "Salary" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestPasswordRule_matches(t *testing.T) {
+	path := filepath.Join("/home", "kim", "finance", "2024", "payroll-may.xlsx")
+	tests := []struct {
+		pattern string
+		want    bool
+	}{
+		{"payroll-*.xlsx", true},
+		{"*.pdf", false},
+		{filepath.Join("/home", "kim", "finance", "**"), true},
+		{filepath.Join("/home", "*", "finance", "**"), true},
+		{filepath.Join("/home", "kim", "photos", "**"), false},
+		{filepath.Join("/home", "kim", "finance", "*", "*.xlsx"), true},
+		{filepath.Join("/home", "kim", "finance", "*.xlsx"), false},
+	}
+	for _, tt := range tests {
+		if got := (PasswordRule{Pattern: tt.pattern}).matches(path); got != tt.want {
+			t.Errorf("%q: got %v, want %v", tt.pattern, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// maxMIMEDepth bounds the nesting of multipart bodies read from a message.
+const maxMIMEDepth = 10
+
+// Mail is a message read from an .eml or .msg file or from an mbox archive.
+type Mail struct {
+	Subject string    `json:"subject,omitempty"`
+	From    string    `json:"from,omitempty"`
+	To      string    `json:"to,omitempty"`
+	Date    time.Time `json:"date,omitempty"`
+	// Body is the plain-text body, or the text of the HTML body when there is none.
+	Body string `json:"body"`
+	// Attachments are the attached files with text, when asked for.
+	Attachments []*Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is a file attached to a Mail and the text extracted from it.
+type Attachment struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// IsMail reports whether files with extension ext (with the leading dot, in any case)
+// are mail: a message (.eml, .msg) or an mbox archive (.mbox).
+func IsMail(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".eml", ".msg", ".mbox":
+		return true
+	}
+	return false
+}
+
+// ExtractMail reads the messages of the mail file at path (see IsMail): one for a message
+// file, each one in an mbox archive. With attachments, the text of the attached files in
+// a known format (see KnownFormat) or of a text type is read as well; other attachments,
+// such as images, are left out. With WithSandbox, the file is parsed in a worker process.
+func (e *Extractor) ExtractMail(path string, attachments bool) ([]*Mail, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if !IsMail(ext) {
+		return nil, fmt.Errorf("not a mail file: %s", path)
+	}
+	if e.sandbox != nil {
+		return e.sandbox.extractMail(path, ext, attachments)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return parseMail(content, ext, attachments)
+}
+
+// MailText renders m as text: its subject, sender, recipients, and date, then its body
+// and the text of its attachments.
+func MailText(m *Mail) string {
+	var b strings.Builder
+	for _, h := range [][2]string{{"Subject", m.Subject}, {"From", m.From}, {"To", m.To}} {
+		if h[1] != "" {
+			fmt.Fprintf(&b, "%s: %s\n", h[0], h[1])
+		}
+	}
+	if !m.Date.IsZero() {
+		fmt.Fprintf(&b, "Date: %s\n", m.Date.Format(time.RFC1123Z))
+	}
+	if b.Len() > 0 {
+		b.WriteByte('\n')
+	}
+	b.WriteString(m.Body)
+	for _, a := range m.Attachments {
+		fmt.Fprintf(&b, "\n\nAttachment: %s\n%s", a.Name, a.Text)
+	}
+	return b.String()
+}
+
+// extractMail returns the text of the messages in content (see MailText).
+func extractMail(content []byte, ext string) (string, error) {
+	mails, err := parseMail(content, ext, false)
+	if err != nil {
+		return "", err
+	}
+	texts := make([]string, len(mails))
+	for i, m := range mails {
+		texts[i] = MailText(m)
+	}
+	return strings.Join(texts, "\n\n"), nil
+}
+
+// parseMail reads the messages of a mail file with extension ext.
+func parseMail(content []byte, ext string, attachments bool) ([]*Mail, error) {
+	var m *Mail
+	var err error
+	switch ext {
+	case ".mbox":
+		return parseMbox(content, attachments)
+	case ".msg":
+		m, err = parseMSG(content, attachments)
+	default:
+		m, err = parseEML(content, attachments)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return []*Mail{m}, nil
+}
+
+// parseMbox reads the messages of an mbox archive: each starts with a "From " line at the
+// start of the file or after an empty line, and ">From " lines in a body are unquoted.
+// Messages that cannot be parsed are left out.
+func parseMbox(content []byte, attachments bool) ([]*Mail, error) {
+	var mails []*Mail
+	var msg bytes.Buffer
+	flush := func() {
+		if len(bytes.TrimSpace(msg.Bytes())) > 0 {
+			if m, err := parseEML(msg.Bytes(), attachments); err == nil {
+				mails = append(mails, m)
+			}
+		}
+		msg.Reset()
+	}
+	blank := true
+	for len(content) > 0 {
+		line := content
+		if i := bytes.IndexByte(content, '\n'); i >= 0 {
+			line = content[:i+1]
+		}
+		content = content[len(line):]
+		if blank && bytes.HasPrefix(line, []byte("From ")) {
+			flush()
+			blank = false
+			continue
+		}
+		blank = len(bytes.TrimRight(line, "\r\n")) == 0
+		if quoted := bytes.TrimLeft(line, ">"); len(quoted) < len(line) && bytes.HasPrefix(quoted, []byte("From ")) {
+			line = line[1:]
+		}
+		msg.Write(line)
+	}
+	flush()
+	return mails, nil
+}
+
+// parseEML reads an RFC 822 message.
+func parseEML(content []byte, attachments bool) (*Mail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("parse message: %w", err)
+	}
+	m := &Mail{
+		Subject: decodeHeader(msg.Header.Get("Subject")),
+		From:    addressList(msg.Header.Get("From")),
+		To:      addressList(msg.Header.Get("To")),
+	}
+	if date, err := msg.Header.Date(); err == nil {
+		m.Date = date
+	}
+	p := &mailParts{attachments: attachments}
+	p.read(textproto.MIMEHeader(msg.Header), msg.Body, 0)
+	m.Body = p.body()
+	m.Attachments = p.attached
+	return m, nil
+}
+
+// mailParts collects the bodies and attachments of a MIME message.
+type mailParts struct {
+	attachments bool
+	plain, html []string
+	attached    []*Attachment
+}
+
+// read reads the part with header h and body r, and the parts inside it.
+func (p *mailParts) read(h textproto.MIMEHeader, r io.Reader, depth int) {
+	if depth > maxMIMEDepth {
+		return
+	}
+	mediaType, params, err := mime.ParseMediaType(h.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", nil
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				return
+			}
+			p.read(part.Header, part, depth+1)
+		}
+	}
+	disposition, dparams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	data, err := io.ReadAll(transferDecoder(h.Get("Content-Transfer-Encoding"), r))
+	if err != nil && len(data) == 0 {
+		return
+	}
+	switch {
+	case disposition != "attachment" && mediaType == "text/plain":
+		p.plain = append(p.plain, strings.TrimSpace(decodeCharset(data, params["charset"])))
+	case disposition != "attachment" && mediaType == "text/html":
+		p.html = append(p.html, htmlText(decodeCharset(data, params["charset"])))
+	case p.attachments:
+		name := dparams["filename"]
+		if name == "" {
+			name = params["name"]
+		}
+		name = decodeHeader(name)
+		if name == "" && mediaType == "message/rfc822" {
+			name = "message.eml"
+		}
+		if text, ok := attachmentText(name, mediaType, data, params["charset"]); ok {
+			p.attached = append(p.attached, &Attachment{Name: name, Text: text})
+		}
+	}
+}
+
+// body returns the plain-text bodies, or the text of the HTML ones when there are none.
+func (p *mailParts) body() string {
+	if len(p.plain) > 0 {
+		return strings.Join(p.plain, "\n\n")
+	}
+	return strings.Join(p.html, "\n\n")
+}
+
+// attachmentText extracts the text of an attachment named name, of the given media type:
+// by the extractor for its extension when it has one, as text for text types. It reports
+// false for attachments without text, such as images.
+func attachmentText(name, mediaType string, data []byte, charset string) (string, bool) {
+	ext := strings.ToLower(filepath.Ext(name))
+	switch {
+	case IsMail(ext):
+		// Attached messages are read without their own attachments.
+		mails, err := parseMail(data, ext, false)
+		if err != nil {
+			return "", false
+		}
+		texts := make([]string, len(mails))
+		for i, m := range mails {
+			texts[i] = MailText(m)
+		}
+		return strings.Join(texts, "\n\n"), true
+	case KnownFormat(ext):
+		text, err := NewExtractor().extractBytes(data, ext, nil)
+		return text, err == nil
+	case mediaType == "text/html":
+		return htmlText(decodeCharset(data, charset)), true
+	case strings.HasPrefix(mediaType, "text/"):
+		return decodeCharset(data, charset), true
+	}
+	return "", false
+}
+
+// transferDecoder decodes a part's Content-Transfer-Encoding. multipart.Reader already
+// decodes quoted-printable parts and drops the header.
+func transferDecoder(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	}
+	return r
+}
+
+// wordDecoder decodes RFC 2047 encoded words in any charset the htmlindex knows.
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, err
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// decodeHeader decodes the encoded words in a header value, keeping it as is on errors.
+func decodeHeader(s string) string {
+	decoded, err := wordDecoder.DecodeHeader(s)
+	if err != nil {
+		return strings.ToValidUTF8(s, "\ufffd")
+	}
+	return decoded
+}
+
+// addressList formats a list of addresses as "Name <address>, ...", or decodes it as
+// plain text when it does not parse.
+func addressList(s string) string {
+	if s == "" {
+		return ""
+	}
+	list, err := (&mail.AddressParser{WordDecoder: wordDecoder}).ParseList(s)
+	if err != nil {
+		return decodeHeader(s)
+	}
+	out := make([]string, len(list))
+	for i, a := range list {
+		out[i] = formatAddress(a.Name, a.Address)
+	}
+	return strings.Join(out, ", ")
+}
+
+func formatAddress(name, address string) string {
+	switch {
+	case name == "":
+		return address
+	case address == "":
+		return name
+	}
+	return name + " <" + address + ">"
+}
+
+// decodeCharset converts text in charset to UTF-8; unknown charsets and invalid bytes are
+// read as UTF-8 with replacement characters.
+func decodeCharset(data []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "", "utf-8", "utf8", "us-ascii":
+	default:
+		if enc, err := htmlindex.Get(charset); err == nil {
+			if decoded, err := enc.NewDecoder().Bytes(data); err == nil {
+				data = decoded
+			}
+		}
+	}
+	if !utf8.Valid(data) {
+		return strings.ToValidUTF8(string(data), "\ufffd")
+	}
+	return string(data)
+}
+
+// tidyLines trims each line and collapses runs of empty lines into one.
+func tidyLines(s string) string {
+	var out []string
+	blank := true
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" {
+			if !blank {
+				out = append(out, "")
+			}
+			blank = true
+			continue
+		}
+		out = append(out, line)
+		blank = false
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
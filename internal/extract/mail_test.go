@@ -0,0 +1,330 @@
+package extract
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+const testEML = "From: =?UTF-8?B?SsO8cmdlbg==?= <jurgen@example.com>\r\n" +
+	"To: Ana <ana@example.com>, bob@example.com\r\n" +
+	"Subject: =?UTF-8?Q?Budget_r=C3=A9view?=\r\n" +
+	"Date: Mon, 02 Jan 2006 15:04:05 +0000\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/mixed; boundary=outer\r\n" +
+	"\r\n" +
+	"--outer\r\n" +
+	"Content-Type: multipart/alternative; boundary=inner\r\n" +
+	"\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/plain; charset=iso-8859-1\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Numbers for the caf=E9 are attached.\r\n" +
+	"--inner\r\n" +
+	"Content-Type: text/html\r\n" +
+	"\r\n" +
+	"<p>Numbers for the caf&eacute; are attached.</p>\r\n" +
+	"--inner--\r\n" +
+	"--outer\r\n" +
+	"Content-Type: text/plain; name=notes.txt\r\n" +
+	"Content-Disposition: attachment; filename=notes.txt\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"Q29mZmVlIGJlYW5zOiA0MDAgRVVS\r\n" +
+	"--outer\r\n" +
+	"Content-Type: image/png\r\n" +
+	"Content-Disposition: attachment; filename=chart.png\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"iVBORw0KGgo=\r\n" +
+	"--outer--\r\n"
+
+func TestParseMail_eml(t *testing.T) {
+	mails, err := parseMail([]byte(testEML), ".eml", true)
+	if err != nil {
+		t.Fatalf("parseMail: %v", err)
+	}
+	if len(mails) != 1 {
+		t.Fatalf("got %d messages, want 1", len(mails))
+	}
+	m := mails[0]
+	if m.Subject != "Budget réview" {
+		t.Errorf("Subject = %q", m.Subject)
+	}
+	if m.From != "Jürgen <jurgen@example.com>" {
+		t.Errorf("From = %q", m.From)
+	}
+	if !strings.Contains(m.To, "ana@example.com") || !strings.Contains(m.To, "bob@example.com") {
+		t.Errorf("To = %q", m.To)
+	}
+	if want := time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC); !m.Date.Equal(want) {
+		t.Errorf("Date = %v, want %v", m.Date, want)
+	}
+	if m.Body != "Numbers for the café are attached." {
+		t.Errorf("Body = %q", m.Body)
+	}
+	if len(m.Attachments) != 1 || m.Attachments[0].Name != "notes.txt" || m.Attachments[0].Text != "Coffee beans: 400 EUR" {
+		t.Errorf("Attachments = %+v, want notes.txt only", m.Attachments)
+	}
+
+	withoutAttachments, err := parseMail([]byte(testEML), ".eml", false)
+	if err != nil {
+		t.Fatalf("parseMail: %v", err)
+	}
+	if n := len(withoutAttachments[0].Attachments); n != 0 {
+		t.Errorf("got %d attachments without asking for them", n)
+	}
+}
+
+func TestParseMail_htmlOnly(t *testing.T) {
+	msg := "Subject: Launch\r\nContent-Type: text/html; charset=utf-8\r\n\r\n" +
+		"<html><head><title>x</title><style>p{}</style></head><body><p>We ship</p><p>on Friday</p></body></html>"
+	mails, err := parseMail([]byte(msg), ".eml", false)
+	if err != nil {
+		t.Fatalf("parseMail: %v", err)
+	}
+	if got := mails[0].Body; got != "We ship\n\non Friday" {
+		t.Errorf("Body = %q", got)
+	}
+}
+
+func TestParseMail_mbox(t *testing.T) {
+	mbox := "From alice@example.com Mon Jan  2 15:04:05 2006\n" +
+		"From: alice@example.com\nSubject: First\n\n" +
+		"Hello\n>From the archive\n\n" +
+		"From bob@example.com Tue Jan  3 15:04:05 2006\n" +
+		"From: bob@example.com\nSubject: Second\n\n" +
+		"World\n"
+	mails, err := parseMail([]byte(mbox), ".mbox", false)
+	if err != nil {
+		t.Fatalf("parseMail: %v", err)
+	}
+	if len(mails) != 2 {
+		t.Fatalf("got %d messages, want 2", len(mails))
+	}
+	if mails[0].Subject != "First" || mails[0].Body != "Hello\nFrom the archive" {
+		t.Errorf("first message = %+v", mails[0])
+	}
+	if mails[1].Subject != "Second" || mails[1].From != "bob@example.com" || mails[1].Body != "World" {
+		t.Errorf("second message = %+v", mails[1])
+	}
+}
+
+func TestParseMail_msg(t *testing.T) {
+	sent := time.Date(2024, 3, 5, 9, 30, 0, 0, time.UTC)
+	fixed := make([]byte, 32+16)
+	binary.LittleEndian.PutUint16(fixed[32:], msgTypeTime)
+	binary.LittleEndian.PutUint16(fixed[34:], msgClientSubmitTime)
+	binary.LittleEndian.PutUint64(fixed[40:], uint64(sent.UnixNano()/100)+116444736000000000)
+	content := compoundFile([]cfbEntry{
+		{name: msgStreamPrefix + "0037001F", data: utf16le("Offsite agenda")},
+		{name: msgStreamPrefix + "0C1A001F", data: utf16le("Carol")},
+		{name: msgStreamPrefix + "5D01001F", data: utf16le("carol@example.com")},
+		{name: msgStreamPrefix + "0E04001F", data: utf16le("Dave")},
+		{name: msgStreamPrefix + "1000001F", data: utf16le("Bring the slides.\r\n")},
+		{name: msgPropertyStream, data: fixed},
+		{name: msgAttachPrefix + "#00000000", storage: true},
+		{name: msgStreamPrefix + "3707001F", data: utf16le("plan.txt"), parent: msgAttachPrefix + "#00000000"},
+		{name: msgStreamPrefix + "37010102", data: []byte("Day one: hiking"), parent: msgAttachPrefix + "#00000000"},
+	})
+
+	mails, err := parseMail(content, ".msg", true)
+	if err != nil {
+		t.Fatalf("parseMail: %v", err)
+	}
+	m := mails[0]
+	if m.Subject != "Offsite agenda" || m.From != "Carol <carol@example.com>" || m.To != "Dave" {
+		t.Errorf("headers = %q, %q, %q", m.Subject, m.From, m.To)
+	}
+	if m.Body != "Bring the slides." {
+		t.Errorf("Body = %q", m.Body)
+	}
+	if !m.Date.Equal(sent) {
+		t.Errorf("Date = %v, want %v", m.Date, sent)
+	}
+	if len(m.Attachments) != 1 || m.Attachments[0].Name != "plan.txt" || m.Attachments[0].Text != "Day one: hiking" {
+		t.Errorf("Attachments = %+v", m.Attachments)
+	}
+}
+
+func TestExtractMail(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "budget.eml")
+	if err := os.WriteFile(path, []byte(testEML), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for name, e := range map[string]*Extractor{
+		"direct":  NewExtractor(),
+		"sandbox": sandboxExtractor("extract-worker", 10*time.Second),
+	} {
+		t.Run(name, func(t *testing.T) {
+			mails, err := e.ExtractMail(path, true)
+			if err != nil {
+				t.Fatalf("ExtractMail: %v", err)
+			}
+			if len(mails) != 1 || mails[0].Subject != "Budget réview" || len(mails[0].Attachments) != 1 {
+				t.Errorf("got %+v", mails)
+			}
+			text, err := e.Extract(path)
+			if err != nil {
+				t.Fatalf("Extract: %v", err)
+			}
+			for _, want := range []string{"Subject: Budget réview", "From: Jürgen", "Numbers for the café"} {
+				if !strings.Contains(text, want) {
+					t.Errorf("Extract text missing %q:\n%s", want, text)
+				}
+			}
+		})
+	}
+}
+
+func utf16le(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+	return b
+}
+
+// cfbEntry is a stream, or with storage a storage, of a compound file; parent names the
+// storage holding it, empty for the root.
+type cfbEntry struct {
+	name    string
+	parent  string
+	storage bool
+	data    []byte
+}
+
+// compoundFile writes a version 3 compound file (as .msg files are) holding entries, each
+// stream in the mini stream, siblings chained as right siblings.
+func compoundFile(entries []cfbEntry) []byte {
+	const (
+		sector     = 512
+		endOfChain = 0xFFFFFFFE
+		noStream   = 0xFFFFFFFF
+	)
+	var mini []byte
+	var miniFAT []uint32
+	starts := make([]uint32, len(entries))
+	for i, e := range entries {
+		starts[i] = endOfChain
+		if e.storage || len(e.data) == 0 {
+			continue
+		}
+		starts[i] = uint32(len(miniFAT))
+		n := (len(e.data) + 63) / 64
+		for j := 0; j < n; j++ {
+			next := uint32(len(miniFAT) + 1)
+			if j == n-1 {
+				next = endOfChain
+			}
+			miniFAT = append(miniFAT, next)
+		}
+		mini = append(mini, e.data...)
+		mini = append(mini, make([]byte, n*64-len(e.data))...)
+	}
+	dirSectors := (len(entries) + 1 + 3) / 4
+	miniFATSectors := (len(miniFAT)*4 + sector - 1) / sector
+	miniSectors := (len(mini) + sector - 1) / sector
+	total := 1 + dirSectors + miniFATSectors + miniSectors
+
+	fat := make([]uint32, sector/4)
+	for i := range fat {
+		fat[i] = noStream
+	}
+	fat[0] = 0xFFFFFFFD // the FAT itself
+	chain := func(start, n int) {
+		for i := start; i < start+n; i++ {
+			fat[i] = uint32(i + 1)
+		}
+		if n > 0 {
+			fat[start+n-1] = endOfChain
+		}
+	}
+	chain(1, dirSectors)
+	chain(1+dirSectors, miniFATSectors)
+	chain(1+dirSectors+miniFATSectors, miniSectors)
+
+	out := make([]byte, sector*(1+total))
+	h := out[:sector]
+	binary.LittleEndian.PutUint64(h, 0xE11AB1A1E011CFD0)
+	binary.LittleEndian.PutUint16(h[24:], 0x3E)
+	binary.LittleEndian.PutUint16(h[26:], 3)
+	binary.LittleEndian.PutUint16(h[28:], 0xFFFE)
+	binary.LittleEndian.PutUint16(h[30:], 9)
+	binary.LittleEndian.PutUint16(h[32:], 6)
+	binary.LittleEndian.PutUint32(h[44:], 1)
+	binary.LittleEndian.PutUint32(h[48:], 1)
+	binary.LittleEndian.PutUint32(h[56:], 4096)
+	binary.LittleEndian.PutUint32(h[60:], uint32(1+dirSectors))
+	binary.LittleEndian.PutUint32(h[64:], uint32(miniFATSectors))
+	binary.LittleEndian.PutUint32(h[68:], endOfChain)
+	for i := 76; i < sector; i += 4 {
+		binary.LittleEndian.PutUint32(h[i:], noStream)
+	}
+	binary.LittleEndian.PutUint32(h[76:], 0)
+	body := out[sector:]
+	for i, v := range fat {
+		binary.LittleEndian.PutUint32(body[4*i:], v)
+	}
+	for i, v := range miniFAT {
+		binary.LittleEndian.PutUint32(body[(1+dirSectors)*sector+4*i:], v)
+	}
+	copy(body[(1+dirSectors+miniFATSectors)*sector:], mini)
+
+	// Directory entry 0 is the root; entry i+1 is entries[i].
+	dir := body[sector : (1+dirSectors)*sector]
+	firstChild := map[string]uint32{}
+	lastChild := map[string]int{}
+	right := make([]uint32, len(entries)+1)
+	for i := range right {
+		right[i] = noStream
+	}
+	for i, e := range entries {
+		if last, ok := lastChild[e.parent]; ok {
+			right[last] = uint32(i + 1)
+		} else {
+			firstChild[e.parent] = uint32(i + 1)
+		}
+		lastChild[e.parent] = i + 1
+	}
+	writeEntry := func(i int, name string, typ byte, start uint32, size int, child uint32) {
+		d := dir[i*128 : (i+1)*128]
+		u := utf16.Encode([]rune(name))
+		for j, c := range u {
+			binary.LittleEndian.PutUint16(d[2*j:], c)
+		}
+		binary.LittleEndian.PutUint16(d[64:], uint16(2*(len(u)+1)))
+		d[66], d[67] = typ, 1
+		binary.LittleEndian.PutUint32(d[68:], noStream)
+		binary.LittleEndian.PutUint32(d[72:], right[i])
+		binary.LittleEndian.PutUint32(d[76:], child)
+		binary.LittleEndian.PutUint32(d[116:], start)
+		binary.LittleEndian.PutUint32(d[120:], uint32(size))
+	}
+	childOf := func(name string) uint32 {
+		if c, ok := firstChild[name]; ok {
+			return c
+		}
+		return noStream
+	}
+	miniStart := uint32(endOfChain)
+	if len(mini) > 0 {
+		miniStart = uint32(1 + dirSectors + miniFATSectors)
+	}
+	writeEntry(0, "Root Entry", 5, miniStart, len(mini), childOf(""))
+	for i, e := range entries {
+		if e.storage {
+			writeEntry(i+1, e.name, 1, 0, 0, childOf(e.name))
+		} else {
+			writeEntry(i+1, e.name, 2, starts[i], len(e.data), noStream)
+		}
+	}
+	return out
+}
@@ -0,0 +1,146 @@
+package extract
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Markdown is the text of a Markdown file and the fields of its front matter.
+type Markdown struct {
+	// Fields are the top-level fields of the YAML front matter, by lower-case name, with
+	// strings, numbers, and booleans as they are, dates as "2006-01-02" or RFC 3339, and
+	// lists as []string; nested mappings are left out. Tags, keywords, and categories
+	// given as one string are split at commas, or else at spaces.
+	Fields map[string]interface{}
+	// Text is the body after the front matter, led by the front matter's title.
+	Text string
+}
+
+// IsMarkdown reports whether files with extension ext (with the leading dot, in any case)
+// are Markdown.
+func IsMarkdown(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// ExtractMarkdown reads the Markdown file at path and its YAML front matter, if any.
+func (e *Extractor) ExtractMarkdown(path string) (*Markdown, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	return parseMarkdown(content), nil
+}
+
+// parseMarkdown splits the front matter off content: a block opened by a "---" line at the
+// very start and closed by a "---" or "..." line. Content whose front matter is not a YAML
+// mapping is read whole, as plain text.
+func parseMarkdown(content []byte) *Markdown {
+	text, _ := extractPlain(content)
+	md := &Markdown{Text: text}
+	front, body, ok := splitFrontMatter(text)
+	if !ok {
+		return md
+	}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal([]byte(front), &raw); err != nil || raw == nil {
+		return md
+	}
+	md.Fields = make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		key := strings.ToLower(k)
+		if v = frontMatterValue(key, v); v != nil {
+			md.Fields[key] = v
+		}
+	}
+	md.Text = strings.TrimLeft(body, "\r\n")
+	if title, _ := md.Fields["title"].(string); title != "" && !strings.Contains(md.Text, title) {
+		md.Text = title + "\n\n" + md.Text
+	}
+	return md
+}
+
+// splitFrontMatter returns the front matter and the body of text.
+func splitFrontMatter(text string) (front, body string, ok bool) {
+	text = strings.TrimPrefix(text, "\ufeff")
+	rest, ok := strings.CutPrefix(text, "---")
+	if !ok {
+		return "", "", false
+	}
+	if rest, ok = cutLineBreak(rest); !ok {
+		return "", "", false
+	}
+	for i := 0; i <= len(rest); {
+		end := strings.IndexByte(rest[i:], '\n')
+		if end < 0 {
+			end = len(rest) - i
+		}
+		line := strings.TrimRight(rest[i:i+end], " \t\r")
+		if line == "---" || line == "..." {
+			return rest[:i], rest[min(i+end+1, len(rest)):], true
+		}
+		i += end + 1
+	}
+	return "", "", false
+}
+
+// cutLineBreak removes the line break s starts with, reporting whether it had one.
+func cutLineBreak(s string) (string, bool) {
+	s = strings.TrimLeft(s, " \t")
+	if rest, ok := strings.CutPrefix(s, "\r\n"); ok {
+		return rest, true
+	}
+	return strings.CutPrefix(s, "\n")
+}
+
+// frontMatterValue returns the metadata value of front matter field key (see
+// Markdown.Fields), or nil when it has none.
+func frontMatterValue(key string, v interface{}) interface{} {
+	switch x := v.(type) {
+	case string:
+		x = strings.TrimSpace(x)
+		if x == "" {
+			return nil
+		}
+		switch key {
+		case "tags", "keywords", "categories":
+			sep := strings.Fields
+			if strings.Contains(x, ",") {
+				sep = func(s string) []string { return strings.Split(s, ",") }
+			}
+			return nonEmpty(sep(x))
+		}
+		return x
+	case time.Time:
+		if x.Equal(x.Truncate(24*time.Hour)) && x.Location() == time.UTC {
+			return x.Format(time.DateOnly)
+		}
+		return x.Format(time.RFC3339)
+	case int, int64, uint64, float64, bool:
+		return x
+	case []interface{}:
+		var list []string
+		for _, item := range x {
+			switch item.(type) {
+			case string, int, int64, uint64, float64, bool, time.Time:
+				if s, ok := frontMatterValue("", item).(string); ok {
+					list = append(list, s)
+				} else {
+					list = append(list, fmt.Sprint(item))
+				}
+			}
+		}
+		if len(list) == 0 {
+			return nil
+		}
+		return list
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package extract
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		fields  map[string]interface{}
+		text    string
+	}{
+		{
+			name: "front matter",
+			content: "---\ntitle: Harbour notes\nDate: 2024-03-05\ntags: [sailing, Tides]\ndraft: false\n" +
+				"weight: 3\nparams:\n  nested: dropped\n---\n\n# Moorings\nVisitor berths.\n",
+			fields: map[string]interface{}{
+				"title": "Harbour notes", "date": "2024-03-05", "tags": []string{"sailing", "Tides"},
+				"draft": false, "weight": 3,
+			},
+			text: "Harbour notes\n\n# Moorings\nVisitor berths.\n",
+		},
+		{
+			name:    "tags as a string, title in the body",
+			content: "---\r\ntitle: Moorings\r\ntags: sailing, harbour tides\r\ncategories: notes log\r\n...\r\n# Moorings\r\n",
+			fields: map[string]interface{}{
+				"title": "Moorings", "tags": []string{"sailing", "harbour tides"}, "categories": []string{"notes", "log"},
+			},
+			text: "# Moorings\r\n",
+		},
+		{
+			name:    "time of day",
+			content: "---\ndate: 2024-03-05T09:30:00+01:00\n---\nBody",
+			fields:  map[string]interface{}{"date": "2024-03-05T09:30:00+01:00"},
+			text:    "Body",
+		},
+		{
+			name:    "no front matter",
+			content: "# Title\n\n---\n\nAfter a rule\n",
+			text:    "# Title\n\n---\n\nAfter a rule\n",
+		},
+		{
+			name:    "not a mapping",
+			content: "---\njust a line\n---\nBody\n",
+			text:    "---\njust a line\n---\nBody\n",
+		},
+		{
+			name:    "unclosed",
+			content: "---\ntitle: x\n",
+			text:    "---\ntitle: x\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := parseMarkdown([]byte(tt.content))
+			if !reflect.DeepEqual(md.Fields, tt.fields) {
+				t.Errorf("Fields = %#v, want %#v", md.Fields, tt.fields)
+			}
+			if md.Text != tt.text {
+				t.Errorf("Text = %q, want %q", md.Text, tt.text)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package extract
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/richardlehane/mscfb"
+)
+
+// Streams and property IDs of an Outlook .msg file ([MS-OXMSG]): each string or binary
+// property is a stream named after its ID and type, fixed-size ones such as dates are
+// entries of the properties stream, and each attachment is a storage of its own.
+const (
+	msgPropertyStream = "__properties_version1.0"
+	msgStreamPrefix   = "__substg1.0_"
+	msgAttachPrefix   = "__attach_version1.0_"
+
+	msgSubject          = "0037"
+	msgBody             = "1000"
+	msgHTML             = "1013"
+	msgSenderName       = "0C1A"
+	msgSenderEmail      = "0C1F"
+	msgSenderSMTP       = "5D01"
+	msgDisplayTo        = "0E04"
+	msgTransportHeaders = "007D"
+	msgAttachLongName   = "3707"
+	msgAttachName       = "3704"
+	msgAttachDisplay    = "3001"
+	msgAttachMIME       = "370E"
+	msgAttachData       = "37010102"
+
+	msgClientSubmitTime = 0x0039
+	msgDeliveryTime     = 0x0E06
+	msgTypeTime         = 0x0040
+)
+
+// msgProperties holds the variable-size properties of a message or an attachment, by the
+// stream name without its prefix (e.g. "0037001F").
+type msgProperties map[string][]byte
+
+// str returns the string property id, stored as UTF-16 or as 8-bit text.
+func (p msgProperties) str(id string) string {
+	if b, ok := p[id+"001F"]; ok {
+		u := make([]uint16, len(b)/2)
+		for i := range u {
+			u[i] = binary.LittleEndian.Uint16(b[2*i:])
+		}
+		return strings.TrimRight(string(utf16.Decode(u)), "\x00")
+	}
+	if b, ok := p[id+"001E"]; ok {
+		return strings.TrimRight(decodeCharset(b, ""), "\x00")
+	}
+	return ""
+}
+
+// parseMSG reads an Outlook .msg file. Embedded messages and recipient details are not read.
+func parseMSG(content []byte, attachments bool) (*Mail, error) {
+	doc, err := mscfb.New(bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("parse msg: %w", err)
+	}
+	props := make(msgProperties)
+	attached := make(map[string]msgProperties)
+	var fixed []byte
+	for f, err := doc.Next(); err == nil; f, err = doc.Next() {
+		if f.FileInfo().IsDir() || len(f.Path) > 1 {
+			continue
+		}
+		var into msgProperties
+		switch {
+		case len(f.Path) == 0:
+			into = props
+		case strings.HasPrefix(f.Path[0], msgAttachPrefix):
+			if !attachments {
+				continue
+			}
+			if into = attached[f.Path[0]]; into == nil {
+				into = make(msgProperties)
+				attached[f.Path[0]] = into
+			}
+		default:
+			continue
+		}
+		if len(f.Path) == 0 && f.Name == msgPropertyStream {
+			if fixed, err = io.ReadAll(f); err != nil {
+				return nil, fmt.Errorf("read msg properties: %w", err)
+			}
+			continue
+		}
+		id, ok := strings.CutPrefix(f.Name, msgStreamPrefix)
+		if !ok {
+			continue
+		}
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return nil, fmt.Errorf("read msg property %s: %w", id, err)
+		}
+		into[strings.ToUpper(id)] = data
+	}
+
+	m := &Mail{
+		Subject: props.str(msgSubject),
+		To:      props.str(msgDisplayTo),
+		Body:    strings.TrimSpace(props.str(msgBody)),
+	}
+	address := props.str(msgSenderSMTP)
+	if address == "" {
+		// Exchange senders have an X.500 address ("/O=..."), useless for search.
+		if a := props.str(msgSenderEmail); !strings.HasPrefix(a, "/") {
+			address = a
+		}
+	}
+	m.From = formatAddress(props.str(msgSenderName), address)
+	if m.Body == "" {
+		html := props.str(msgHTML)
+		if html == "" {
+			html = decodeCharset(props[msgHTML+"0102"], "")
+		}
+		m.Body = htmlText(html)
+	}
+	m.Date = msgDate(fixed)
+	if m.Date.IsZero() {
+		if h, err := mail.ReadMessage(strings.NewReader(props.str(msgTransportHeaders) + "\r\n\r\n")); err == nil {
+			m.Date, _ = h.Header.Date()
+		}
+	}
+
+	names := make([]string, 0, len(attached))
+	for name := range attached {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, storage := range names {
+		a := attached[storage]
+		data, ok := a[msgAttachData]
+		if !ok {
+			continue
+		}
+		name := a.str(msgAttachLongName)
+		if name == "" {
+			name = a.str(msgAttachName)
+		}
+		if name == "" {
+			name = a.str(msgAttachDisplay)
+		}
+		if text, ok := attachmentText(name, a.str(msgAttachMIME), data, ""); ok {
+			m.Attachments = append(m.Attachments, &Attachment{Name: name, Text: text})
+		}
+	}
+	return m, nil
+}
+
+// msgDate returns the time a message was sent, or else delivered, from the properties
+// stream of a message: a 32-byte header, then 16-byte entries of type, ID, flags, and value.
+func msgDate(fixed []byte) time.Time {
+	var delivered time.Time
+	for i := 32; i+16 <= len(fixed); i += 16 {
+		typ := binary.LittleEndian.Uint16(fixed[i:])
+		id := binary.LittleEndian.Uint16(fixed[i+2:])
+		if typ != msgTypeTime {
+			continue
+		}
+		t := filetime(binary.LittleEndian.Uint64(fixed[i+8:]))
+		switch id {
+		case msgClientSubmitTime:
+			return t
+		case msgDeliveryTime:
+			delivered = t
+		}
+	}
+	return delivered
+}
+
+// filetime converts a Windows FILETIME, 100-nanosecond intervals since 1601, to a time.
+func filetime(ft uint64) time.Time {
+	const unixEpoch = 116444736000000000 // 1970-01-01 as a FILETIME
+	if ft < unixEpoch || ft-unixEpoch > math.MaxInt64/100 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(ft-unixEpoch)*100).UTC()
+}
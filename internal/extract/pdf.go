@@ -2,14 +2,28 @@ package extract
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/ledongthuc/pdf"
 )
 
-func extractPDF(content []byte) (string, error) {
-	r, err := pdf.NewReader(bytes.NewReader(content), int64(len(content)))
+// extractPDF extracts the text of a PDF, trying passwords in turn when it is encrypted.
+// PDFs with only an owner password open without one.
+func extractPDF(content []byte, passwords []string) (string, error) {
+	next := 0
+	r, err := pdf.NewReaderEncrypted(bytes.NewReader(content), int64(len(content)), func() string {
+		if next == len(passwords) {
+			return ""
+		}
+		next++
+		return passwords[next-1]
+	})
 	if err != nil {
+		if errors.Is(err, pdf.ErrInvalidPassword) || strings.HasPrefix(err.Error(), "unsupported PDF: encryption") {
+			return "", fmt.Errorf("open PDF: %w", ErrLocked)
+		}
 		return "", fmt.Errorf("open PDF: %w", err)
 	}
 	var buf bytes.Buffer
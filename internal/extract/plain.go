@@ -1,10 +1,16 @@
 package extract
 
 import (
+	"errors"
+	"io"
+	"os"
 	"strings"
 	"unicode/utf8"
 )
 
+// sniffLen is how much of a file LooksBinary reads.
+const sniffLen = 8 << 10
+
 // extractPlain returns content as string, validating it is valid UTF-8.
 // Invalid UTF-8 sequences are replaced with the replacement character.
 func extractPlain(content []byte) (string, error) {
@@ -13,3 +19,49 @@ func extractPlain(content []byte) (string, error) {
 	}
 	return string(content), nil
 }
+
+// PlainText reports whether files with extension ext (with the leading dot, in any case)
+// are read as plain text rather than parsed as a document format.
+func PlainText(ext string) bool {
+	return !sandboxed(strings.ToLower(ext))
+}
+
+// LooksBinary reports whether the file at path seems to hold binary data rather than
+// text, judging by its first 8 KiB (see IsBinary).
+func LooksBinary(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	buf := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	return IsBinary(buf[:n]), nil
+}
+
+// IsBinary reports whether sample, the start of a file, looks like binary data: it holds
+// a NUL byte, or more than 30% of it is control characters and invalid UTF-8. A rune cut
+// off at the end of sample is not counted as invalid.
+func IsBinary(sample []byte) bool {
+	suspicious := 0
+	for i := 0; i < len(sample); {
+		r, size := utf8.DecodeRune(sample[i:])
+		switch {
+		case r == 0:
+			return true
+		case r == utf8.RuneError && size == 1:
+			if len(sample)-i < utf8.UTFMax && !utf8.FullRune(sample[i:]) {
+				i = len(sample)
+				continue
+			}
+			suspicious++
+		case r < 0x20 && r != '\t' && r != '\n' && r != '\r' && r != '\f' && r != '\v' && r != 0x1b:
+			suspicious++
+		}
+		i += size
+	}
+	return suspicious*10 > len(sample)*3
+}
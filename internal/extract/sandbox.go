@@ -0,0 +1,263 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Default SandboxConfig limits.
+const (
+	DefaultSandboxMemoryMB   = 1024
+	DefaultSandboxCPUSeconds = 60
+	DefaultSandboxTimeout    = 2 * time.Minute
+)
+
+// ErrSandbox is returned (wrapped) when the extraction worker crashed, ran out of memory
+// or CPU time, or was killed for taking too long.
+var ErrSandbox = errors.New("extraction worker failed")
+
+// SandboxConfig runs extraction in a child process. Zero limits use the defaults.
+type SandboxConfig struct {
+	// Command starts a worker that calls RunWorker, e.g. the sagasu executable and
+	// "extract-worker".
+	Command []string
+	// MemoryMB caps the worker's memory; CPUSeconds caps its CPU time.
+	MemoryMB   int
+	CPUSeconds int
+	// Timeout is the wall-clock time after which the worker is killed.
+	Timeout time.Duration
+	// AllowNetworkFallback runs workers with the server's network when they cannot be
+	// started in their own namespaces. Without it, such a worker fails to start.
+	AllowNetworkFallback bool
+	// OnNetworkFallback, if set, is called with the error that made workers fall back to
+	// the server's network, the first time they do.
+	OnNetworkFallback func(err error)
+}
+
+// ErrNoIsolation is returned (wrapped) when a worker could not be started without network
+// access and SandboxConfig.AllowNetworkFallback is off.
+var ErrNoIsolation = errors.New("extraction worker cannot be started without network access")
+
+// sandbox starts a worker per file.
+type sandbox struct {
+	cfg SandboxConfig
+	// isolate prepares a command to run in its own namespaces.
+	isolate func(cmd *exec.Cmd)
+	// shared is set once starting the worker in its own namespaces has failed and the
+	// fallback is allowed, so later workers share the server's network.
+	shared atomic.Bool
+}
+
+// workerRequest is what the parent writes to the worker's stdin.
+type workerRequest struct {
+	Path       string   `json:"path"`
+	Ext        string   `json:"ext"`
+	Passwords  []string `json:"passwords,omitempty"`
+	MemoryMB   int      `json:"memory_mb"`
+	CPUSeconds int      `json:"cpu_seconds"`
+	// Mail asks for the messages of a mail file (see ExtractMail) instead of its text,
+	// Book for the text and metadata of an EPUB book (see ExtractEPUB), and Archive for
+	// the files of an archive (see ExtractArchive).
+	Mail        bool            `json:"mail,omitempty"`
+	Attachments bool            `json:"attachments,omitempty"`
+	Book        bool            `json:"book,omitempty"`
+	Archive     *ArchiveOptions `json:"archive,omitempty"`
+}
+
+// workerResponse is what the worker writes to its stdout.
+type workerResponse struct {
+	Text    string          `json:"text"`
+	Mail    []*Mail         `json:"mail,omitempty"`
+	Book    *Book           `json:"book,omitempty"`
+	Archive []*ArchiveEntry `json:"archive,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	Locked  bool            `json:"locked,omitempty"`
+}
+
+// WithSandbox extracts PDF, Office, OpenDocument, iWork, EPUB, and mail files in a child
+// process started by cfg.Command, one per file, so a malformed file or a zip bomb can only
+// take down the worker. The worker's memory and CPU time are capped and it is killed after
+// cfg.Timeout. On Linux it also runs without network access; when the kernel does not allow
+// unprivileged user namespaces, workers fail to start unless cfg.AllowNetworkFallback is
+// set. Plain text is still read in-process.
+func (e *Extractor) WithSandbox(cfg SandboxConfig) *Extractor {
+	if cfg.MemoryMB <= 0 {
+		cfg.MemoryMB = DefaultSandboxMemoryMB
+	}
+	if cfg.CPUSeconds <= 0 {
+		cfg.CPUSeconds = DefaultSandboxCPUSeconds
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultSandboxTimeout
+	}
+	e.sandbox = &sandbox{cfg: cfg, isolate: isolate}
+	return e
+}
+
+// sandboxed reports whether files with extension ext are parsed, and so extracted in the
+// sandbox when there is one.
+func sandboxed(ext string) bool {
+	switch ext {
+	case ".pdf", ".docx", ".odt", ".rtf", ".xlsx", ".pptx", ".odp", ".ods", ".eml", ".msg", ".mbox", ".epub",
+		".pages", ".numbers", ".key":
+		return true
+	}
+	return false
+}
+
+// extract runs a worker for the text of the file at path.
+func (s *sandbox) extract(path, ext string, passwords []string) (string, error) {
+	resp, err := s.run(workerRequest{Path: path, Ext: ext, Passwords: passwords})
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// extractMail runs a worker for the messages of the mail file at path.
+func (s *sandbox) extractMail(path, ext string, attachments bool) ([]*Mail, error) {
+	resp, err := s.run(workerRequest{Path: path, Ext: ext, Mail: true, Attachments: attachments})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Mail, nil
+}
+
+// extractEPUB runs a worker for the EPUB book at path.
+func (s *sandbox) extractEPUB(path string) (*Book, error) {
+	resp, err := s.run(workerRequest{Path: path, Ext: ".epub", Book: true})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Book == nil {
+		return nil, fmt.Errorf("%w: %s: no book in response", ErrSandbox, path)
+	}
+	return resp.Book, nil
+}
+
+// extractArchive runs a worker for the files of the archive at path.
+func (s *sandbox) extractArchive(path string, opts ArchiveOptions) ([]*ArchiveEntry, error) {
+	resp, err := s.run(workerRequest{Path: path, Archive: &opts})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Archive, nil
+}
+
+// run runs a worker for r and returns its response, or the error it reported.
+func (s *sandbox) run(r workerRequest) (*workerResponse, error) {
+	path := r.Path
+	r.MemoryMB, r.CPUSeconds = s.cfg.MemoryMB, s.cfg.CPUSeconds
+	req, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.Timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := s.command(ctx, req, &stdout, &stderr, !s.shared.Load())
+	err = cmd.Start()
+	if err != nil && cmd.SysProcAttr != nil {
+		// The kernel refused the namespaces (e.g. unprivileged user namespaces are off)
+		if !s.cfg.AllowNetworkFallback {
+			return nil, fmt.Errorf("%w: %v", ErrNoIsolation, err)
+		}
+		if !s.shared.Swap(true) && s.cfg.OnNetworkFallback != nil {
+			s.cfg.OnNetworkFallback(err)
+		}
+		stdout.Reset()
+		stderr.Reset()
+		cmd = s.command(ctx, req, &stdout, &stderr, false)
+		err = cmd.Start()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("start extraction worker: %w", err)
+	}
+	err = cmd.Wait()
+	switch {
+	case ctx.Err() != nil:
+		return nil, fmt.Errorf("%w: %s: killed after %s", ErrSandbox, path, s.cfg.Timeout)
+	case err != nil:
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 200 {
+			msg = msg[:200]
+		}
+		return nil, fmt.Errorf("%w: %s: %v %s", ErrSandbox, path, err, msg)
+	}
+	var resp workerResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("%w: %s: invalid response: %v", ErrSandbox, path, err)
+	}
+	switch {
+	case resp.Locked:
+		return nil, &lockedError{msg: resp.Error}
+	case resp.Error != "":
+		return nil, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// lockedError is an ErrLocked reported by a worker, with its message.
+type lockedError struct {
+	msg string
+}
+
+func (e *lockedError) Error() string { return e.msg }
+func (e *lockedError) Unwrap() error { return ErrLocked }
+
+// command prepares a worker reading req, in its own namespaces when isolated. The worker
+// gets an empty environment, so it sees none of the server's secrets.
+func (s *sandbox) command(ctx context.Context, req []byte, stdout, stderr io.Writer, isolated bool) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, s.cfg.Command[0], s.cfg.Command[1:]...)
+	cmd.Env = []string{}
+	cmd.Stdin = bytes.NewReader(req)
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if isolated {
+		s.isolate(cmd)
+	}
+	return cmd
+}
+
+// RunWorker is the body of an extraction worker: it reads one request from r, caps its
+// own memory and CPU time, extracts the file, and writes the result to w.
+func RunWorker(r io.Reader, w io.Writer) error {
+	var req workerRequest
+	if err := json.NewDecoder(r).Decode(&req); err != nil {
+		return fmt.Errorf("read request: %w", err)
+	}
+	if req.MemoryMB > 0 {
+		// Collect hard before the memory limit is hit
+		debug.SetMemoryLimit(int64(req.MemoryMB) << 20 * 3 / 4)
+	}
+	if err := setLimits(req.MemoryMB, req.CPUSeconds); err != nil {
+		return fmt.Errorf("set limits: %w", err)
+	}
+	var resp workerResponse
+	content, err := os.ReadFile(req.Path)
+	switch {
+	case err == nil && req.Mail:
+		resp.Mail, err = parseMail(content, req.Ext, req.Attachments)
+	case err == nil && req.Book:
+		resp.Book, err = parseEPUB(content)
+	case err == nil && req.Archive != nil:
+		resp.Archive, err = NewExtractor().parseArchive(content, req.Path, *req.Archive)
+	case err == nil:
+		resp.Text, err = NewExtractor().extractBytes(content, req.Ext, req.Passwords)
+	default:
+		err = fmt.Errorf("read file: %w", err)
+	}
+	if err != nil {
+		resp.Error, resp.Locked = err.Error(), errors.Is(err, ErrLocked)
+	}
+	return json.NewEncoder(w).Encode(resp)
+}
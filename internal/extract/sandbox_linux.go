@@ -0,0 +1,20 @@
+//go:build linux
+
+package extract
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// isolate starts cmd in new user and network namespaces, so the worker has no network
+// interface but loopback, and kills it when the server dies.
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:  syscall.CLONE_NEWUSER | syscall.CLONE_NEWNET,
+		UidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings: []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+		Pdeathsig:   syscall.SIGKILL,
+	}
+}
@@ -0,0 +1,139 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary act as an extraction worker, as sagasu extract-worker
+// does, or as a worker that misbehaves.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "extract-worker":
+			if err := RunWorker(os.Stdin, os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			os.Exit(0)
+		case "extract-worker-hang":
+			time.Sleep(time.Minute)
+			os.Exit(0)
+		case "extract-worker-bomb":
+			if err := setLimits(256, 0); err != nil {
+				os.Exit(1)
+			}
+			var hold [][]byte
+			for i := 0; i < 64; i++ {
+				hold = append(hold, make([]byte, 64<<20))
+			}
+			fmt.Println(len(hold))
+			os.Exit(0)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+// sandboxExtractor falls back to the network of the tests where the kernel refuses
+// unprivileged user namespaces.
+func sandboxExtractor(worker string, timeout time.Duration) *Extractor {
+	return NewExtractor().WithSandbox(SandboxConfig{
+		Command:              []string{os.Args[0], worker},
+		Timeout:              timeout,
+		AllowNetworkFallback: true,
+	})
+}
+
+func TestExtract_sandbox(t *testing.T) {
+	dir := t.TempDir()
+	docx := filepath.Join(dir, "report.docx")
+	if err := os.WriteFile(docx, minimalDocx("Quarterly numbers"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(dir, "secret.docx")
+	if err := os.WriteFile(secret, encrypted(t, minimalDocx("Payroll"), "hunter2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	e := sandboxExtractor("extract-worker", 0)
+	if got, err := e.Extract(docx); err != nil || got != "Quarterly numbers" {
+		t.Errorf("Extract = %q, %v", got, err)
+	}
+	if _, err := e.Extract(secret); !errors.Is(err, ErrLocked) {
+		t.Errorf("encrypted file without password: got %v, want ErrLocked", err)
+	}
+	e.WithPasswords([]PasswordRule{{Pattern: "secret.docx", Password: "hunter2"}})
+	if got, err := e.Extract(secret); err != nil || got != "Payroll" {
+		t.Errorf("encrypted file with password: Extract = %q, %v", got, err)
+	}
+	if _, err := e.Extract(filepath.Join(dir, "missing.pdf")); err == nil || errors.Is(err, ErrSandbox) {
+		t.Errorf("missing file: got %v, want a read error from the worker", err)
+	}
+
+	// Plain text never starts a worker
+	txt := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(txt, []byte("plain"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := sandboxExtractor("extract-worker-hang", time.Millisecond).Extract(txt); err != nil || got != "plain" {
+		t.Errorf("plain text: Extract = %q, %v", got, err)
+	}
+}
+
+func TestExtract_sandboxFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bomb.pdf")
+	if err := os.WriteFile(path, []byte("%PDF-1.4"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sandboxExtractor("extract-worker-hang", 200*time.Millisecond).Extract(path); !errors.Is(err, ErrSandbox) {
+		t.Errorf("hanging worker: got %v, want ErrSandbox", err)
+	}
+	if runtime.GOOS == "windows" {
+		return
+	}
+	if _, err := sandboxExtractor("extract-worker-bomb", 0).Extract(path); !errors.Is(err, ErrSandbox) {
+		t.Errorf("worker over its memory limit: got %v, want ErrSandbox", err)
+	}
+}
+
+func TestExtract_sandboxNetworkFallback(t *testing.T) {
+	dir := t.TempDir()
+	docx := filepath.Join(dir, "report.docx")
+	if err := os.WriteFile(docx, minimalDocx("Quarterly numbers"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Namespaces the kernel refuses: the isolated worker fails to start
+	refuse := func(cmd *exec.Cmd) {
+		cmd.Path = filepath.Join(dir, "missing")
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+
+	e := NewExtractor().WithSandbox(SandboxConfig{Command: []string{os.Args[0], "extract-worker"}})
+	e.sandbox.isolate = refuse
+	if _, err := e.Extract(docx); !errors.Is(err, ErrNoIsolation) {
+		t.Errorf("without fallback: got %v, want ErrNoIsolation", err)
+	}
+
+	var warned int
+	e = NewExtractor().WithSandbox(SandboxConfig{
+		Command:              []string{os.Args[0], "extract-worker"},
+		AllowNetworkFallback: true,
+		OnNetworkFallback:    func(error) { warned++ },
+	})
+	e.sandbox.isolate = refuse
+	for range 2 {
+		if got, err := e.Extract(docx); err != nil || got != "Quarterly numbers" {
+			t.Errorf("with fallback: Extract = %q, %v", got, err)
+		}
+	}
+	if warned != 1 {
+		t.Errorf("OnNetworkFallback called %d times, want 1", warned)
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package extract
+
+import "syscall"
+
+// setLimits caps the data segment of the current process at memoryMB and its CPU time at
+// cpuSeconds; zero leaves a limit unset. Going over either kills the process. The address
+// space is not capped, as the Go runtime reserves far more of it than it uses.
+func setLimits(memoryMB, cpuSeconds int) error {
+	if memoryMB > 0 {
+		n := uint64(memoryMB) << 20
+		if err := syscall.Setrlimit(syscall.RLIMIT_DATA, &syscall.Rlimit{Cur: n, Max: n}); err != nil {
+			return err
+		}
+	}
+	if cpuSeconds > 0 {
+		n := uint64(cpuSeconds)
+		if err := syscall.Setrlimit(syscall.RLIMIT_CPU, &syscall.Rlimit{Cur: n, Max: n}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package extract
+
+// setLimits does nothing on Windows: the worker's memory is only bounded by its soft
+// memory limit, and its time by the parent's timeout.
+func setLimits(memoryMB, cpuSeconds int) error {
+	return nil
+}
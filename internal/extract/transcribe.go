@@ -0,0 +1,271 @@
+package extract
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transcriber defaults.
+const (
+	DefaultTranscriberTimeout = time.Hour
+	// TranscriptCacheSuffix is appended to the path of a media file to name its cached
+	// transcript, a WebVTT file: "standup.mp4" is transcribed once into
+	// "standup.mp4.transcript.vtt".
+	TranscriptCacheSuffix = ".transcript.vtt"
+)
+
+// DefaultTranscriberExtensions are the media files transcribed when TranscriberConfig
+// lists none.
+var DefaultTranscriberExtensions = []string{".mp3", ".mp4", ".wav"}
+
+// ErrTranscriber is returned (wrapped) when the transcriber failed or was killed for taking
+// too long.
+var ErrTranscriber = errors.New("transcriber failed")
+
+// TranscriberConfig runs a speech-to-text program, such as whisper.cpp, on audio and video
+// files.
+type TranscriberConfig struct {
+	// Command is the program and its arguments, with "{input}" standing for the path of
+	// the media file; without it, the path is passed last. The program writes the
+	// transcript to stdout as WebVTT, SRT, whisper.cpp's "[00:00:01.000 --> 00:00:04.000]
+	// text" lines, or plain text.
+	Command []string
+	// Extensions are those of the files transcribed, with the leading dot; empty means
+	// DefaultTranscriberExtensions.
+	Extensions []string
+	// Timeout is the wall-clock time after which the program is killed; 0 means
+	// DefaultTranscriberTimeout.
+	Timeout time.Duration
+	// NoCache transcribes files again each time instead of keeping their transcript in a
+	// file next to them (see TranscriptCacheSuffix).
+	NoCache bool
+}
+
+// Segment is a stretch of a transcript and when it is spoken.
+type Segment struct {
+	Start, End time.Duration
+	Text       string
+}
+
+// Transcript is the text of an audio or video file, in timed segments when the
+// transcriber gave times, else as one segment at time 0.
+type Transcript struct {
+	Segments []Segment
+	// Timed reports whether the segments have times.
+	Timed bool
+}
+
+// WithTranscriber extracts the files with cfg.Extensions with the transcriber cfg.Command.
+// Without it, or with an empty command, media files are not transcribed.
+func (e *Extractor) WithTranscriber(cfg TranscriberConfig) *Extractor {
+	if len(cfg.Command) == 0 {
+		e.transcriber = nil
+		return e
+	}
+	exts := cfg.Extensions
+	if len(exts) == 0 {
+		exts = DefaultTranscriberExtensions
+	}
+	cfg.Extensions = make([]string, len(exts))
+	for i, ext := range exts {
+		cfg.Extensions[i] = "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTranscriberTimeout
+	}
+	e.transcriber = &cfg
+	return e
+}
+
+// Transcribes reports whether files with extension ext (with the leading dot, in any case)
+// are transcribed (see WithTranscriber).
+func (e *Extractor) Transcribes(ext string) bool {
+	if e.transcriber == nil {
+		return false
+	}
+	ext = strings.ToLower(ext)
+	for _, x := range e.transcriber.Extensions {
+		if x == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractTranscript returns the transcript of the media file at path, from the cache file
+// next to it when that is not older than the file, else by running the transcriber and
+// caching what it wrote. A cache that cannot be written is skipped.
+func (e *Extractor) ExtractTranscript(path string) (*Transcript, error) {
+	cfg := e.transcriber
+	if cfg == nil {
+		return nil, fmt.Errorf("%w: no transcriber for %s", ErrTranscriber, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat file: %w", err)
+	}
+	cache := path + TranscriptCacheSuffix
+	if !cfg.NoCache {
+		if ci, err := os.Stat(cache); err == nil && !ci.ModTime().Before(info.ModTime()) {
+			if content, err := os.ReadFile(cache); err == nil {
+				return parseTranscript(string(content)), nil
+			}
+		}
+	}
+	out, err := cfg.run(path)
+	if err != nil {
+		return nil, err
+	}
+	t := parseTranscript(out)
+	if !cfg.NoCache {
+		_ = os.WriteFile(cache, []byte(t.VTT()), 0o644)
+	}
+	return t, nil
+}
+
+// run runs the transcriber on path and returns its output.
+func (cfg *TranscriberConfig) run(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	args, input := make([]string, 0, len(cfg.Command)), false
+	for _, arg := range cfg.Command[1:] {
+		if strings.Contains(arg, "{input}") {
+			arg, input = strings.ReplaceAll(arg, "{input}", path), true
+		}
+		args = append(args, arg)
+	}
+	if !input {
+		args = append(args, path)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, cfg.Command[0], args...)
+	cmd.Dir = filepath.Dir(path)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		return "", fmt.Errorf("%w: %s: killed after %s", ErrTranscriber, path, cfg.Timeout)
+	case err != nil:
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 200 {
+			msg = msg[len(msg)-200:]
+		}
+		return "", fmt.Errorf("%w: %s: %v %s", ErrTranscriber, path, err, msg)
+	}
+	return stdout.String(), nil
+}
+
+// cueTime matches the times of a cue, "00:01:02.500 --> 00:01:05.000" (hours optional, a
+// comma in SRT), bracketed in whisper.cpp's output, with the text that may follow.
+var cueTime = regexp.MustCompile(`^\[?((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})\s*-->\s*((?:\d+:)?\d{1,2}:\d{2}[.,]\d{1,3})\]?(?:\s+(.*))?$`)
+
+// cueTag matches the tags of WebVTT cue text, such as "<v Alice>".
+var cueTag = regexp.MustCompile(`</?[a-zA-Z][^>]*>|<\d[^>]*>`)
+
+// parseTranscript reads a transcript in WebVTT, SRT, or whisper.cpp's format, or else as
+// plain text.
+func parseTranscript(out string) *Transcript {
+	lines := strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n")
+	t := &Transcript{}
+	var cur *Segment
+	end := func() {
+		if cur != nil {
+			if cur.Text = strings.Join(strings.Fields(cur.Text), " "); cur.Text != "" {
+				t.Segments = append(t.Segments, *cur)
+			}
+			cur = nil
+		}
+	}
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if m := cueTime.FindStringSubmatch(line); m != nil {
+			end()
+			cur = &Segment{Start: parseCueTime(m[1]), End: parseCueTime(m[2]), Text: cueTag.ReplaceAllString(m[3], "")}
+			t.Timed = true
+			if m[3] != "" {
+				end()
+			}
+			continue
+		}
+		switch {
+		case line == "":
+			end()
+		case cur != nil:
+			cur.Text += " " + cueTag.ReplaceAllString(line, "")
+		}
+	}
+	end()
+	if !t.Timed {
+		if text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(out), "WEBVTT")); text != "" {
+			t.Segments = []Segment{{Text: text}}
+		}
+	}
+	return t
+}
+
+// parseCueTime parses "01:02.500" or "1:01:02,500".
+func parseCueTime(s string) time.Duration {
+	s = strings.ReplaceAll(s, ",", ".")
+	var d time.Duration
+	parts := strings.Split(s, ":")
+	for i, p := range parts {
+		unit := time.Second
+		for j := i; j < len(parts)-1; j++ {
+			unit *= 60
+		}
+		f, _ := strconv.ParseFloat(p, 64)
+		d += time.Duration(math.Round(f*1000)) * unit / 1000
+	}
+	return d
+}
+
+// VTT renders t as WebVTT, the format of the transcript cache. An untimed transcript
+// is written as text after the header, without cues.
+func (t *Transcript) VTT() string {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n")
+	if !t.Timed {
+		for _, s := range t.Segments {
+			b.WriteString("\n" + s.Text + "\n")
+		}
+		return b.String()
+	}
+	for _, s := range t.Segments {
+		fmt.Fprintf(&b, "\n%s --> %s\n%s\n", vttTime(s.Start), vttTime(s.End), s.Text)
+	}
+	return b.String()
+}
+
+func vttTime(d time.Duration) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+// TranscriptText renders t as text, a line per segment led by its start time,
+// "[00:01:02] Welcome, everyone.", or just its text when it is untimed.
+func TranscriptText(t *Transcript) string {
+	lines := make([]string, len(t.Segments))
+	for i, s := range t.Segments {
+		lines[i] = s.Text
+		if t.Timed {
+			lines[i] = "[" + Timestamp(s.Start) + "] " + s.Text
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Timestamp formats d as "hh:mm:ss".
+func Timestamp(d time.Duration) string {
+	s := int64(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
@@ -0,0 +1,91 @@
+package extract
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTranscript(t *testing.T) {
+	tests := []struct {
+		name, out string
+		want      string // TranscriptText
+		timed     bool
+	}{
+		{"webvtt", "WEBVTT\n\nNOTE made by hand\n\n00:01.000 --> 00:04.000\n<v Alice>Good morning\neveryone</v>\n\n01:02:03.500 --> 01:02:05.000\nThanks\n",
+			"[00:00:01] Good morning everyone\n[01:02:03] Thanks", true},
+		{"srt", "1\r\n00:00:07,250 --> 00:00:09,000\r\nBudget review\r\n\r\n2\r\n00:00:10,000 --> 00:00:12,000\r\nNext slide\r\n",
+			"[00:00:07] Budget review\n[00:00:10] Next slide", true},
+		{"whisper.cpp", "\n[00:00:00.000 --> 00:00:04.000]   The quarterly numbers are in.\n[00:00:04.000 --> 00:00:08.120]   Revenue is up.\n",
+			"[00:00:00] The quarterly numbers are in.\n[00:00:04] Revenue is up.", true},
+		{"plain", "  just the words  \n", "just the words", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := parseTranscript(tt.out)
+			if got := TranscriptText(tr); got != tt.want || tr.Timed != tt.timed {
+				t.Errorf("got %q (timed %v), want %q (timed %v)", got, tr.Timed, tt.want, tt.timed)
+			}
+			// The cache holds the transcript as WebVTT, which reads back the same.
+			if again := parseTranscript(tr.VTT()); !reflect.DeepEqual(again, tr) {
+				t.Errorf("VTT round trip = %+v, want %+v", again, tr)
+			}
+		})
+	}
+}
+
+func TestExtractTranscript(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	media := filepath.Join(dir, "standup.mp3")
+	if err := os.WriteFile(media, []byte("ID3"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	runs := filepath.Join(dir, "runs")
+	e := NewExtractor().WithTranscriber(TranscriberConfig{
+		Command: []string{"sh", "-c", `echo "$1" >> ` + runs + `; printf '[00:00:02.000 --> 00:00:05.000]  Ship it on Friday\n'`, "sh", "{input}"},
+	})
+	if !e.Transcribes(".MP3") || e.Transcribes(".txt") {
+		t.Fatal("Transcribes: want the default media extensions only")
+	}
+	for i := 0; i < 2; i++ {
+		text, err := e.Extract(media)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != "[00:00:02] Ship it on Friday" {
+			t.Errorf("text = %q", text)
+		}
+	}
+	got, _ := os.ReadFile(runs)
+	if strings.TrimSpace(string(got)) != media {
+		t.Errorf("transcriber runs = %q, want one, on %s", got, media)
+	}
+	if _, err := os.Stat(media + TranscriptCacheSuffix); err != nil {
+		t.Errorf("no cached transcript: %v", err)
+	}
+
+	// A newer file is transcribed again.
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(media, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := e.ExtractTranscript(media); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(runs); strings.Count(string(got), "\n") != 2 {
+		t.Errorf("transcriber runs = %q, want two", got)
+	}
+
+	failing := NewExtractor().WithTranscriber(TranscriberConfig{Command: []string{"sh", "-c", "echo no model >&2; exit 1"}, NoCache: true})
+	if _, err := failing.ExtractTranscript(media); !errors.Is(err, ErrTranscriber) || !strings.Contains(err.Error(), "no model") {
+		t.Errorf("failing transcriber: got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+// Package filemeta reads platform file metadata that is not part of os.FileInfo: the
+// owner, the creation (birth) time, and user tags such as macOS Finder tags.
+package filemeta
+
+import (
+	"encoding/binary"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Info is the platform metadata of a file. Fields the platform or file system does not
+// provide are left zero.
+type Info struct {
+	Owner   string
+	Created time.Time
+	Tags    []string
+}
+
+// Read returns the platform metadata of the file at path, whose os.Stat result is fi.
+// It never fails: metadata that cannot be read is left out.
+func Read(path string, fi os.FileInfo) Info {
+	return read(path, fi)
+}
+
+// parseXDGTags parses the comma-separated user.xdg.tags extended attribute used by Linux
+// file managers.
+func parseXDGTags(value string) []string {
+	var tags []string
+	for _, t := range strings.Split(value, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// parseFinderTags parses the com.apple.metadata:_kMDItemUserTags extended attribute: a
+// binary property list holding an array of strings, each a tag name optionally followed
+// by a newline and the tag's color number. It returns nil for anything else.
+func parseFinderTags(data []byte) []string {
+	p, ok := newBplist(data)
+	if !ok {
+		return nil
+	}
+	refs, ok := p.array(p.top)
+	if !ok {
+		return nil
+	}
+	var tags []string
+	for _, ref := range refs {
+		s, ok := p.string(ref)
+		if !ok {
+			return nil
+		}
+		if name, _, _ := strings.Cut(s, "\n"); name != "" {
+			tags = append(tags, name)
+		}
+	}
+	return tags
+}
+
+// bplist reads the objects of a binary property list ("bplist00").
+type bplist struct {
+	data       []byte
+	offsets    []uint64
+	objRefSize int
+	top        uint64
+}
+
+func newBplist(data []byte) (*bplist, bool) {
+	const trailerSize = 32
+	if len(data) < 8+trailerSize || string(data[:8]) != "bplist00" {
+		return nil, false
+	}
+	trailer := data[len(data)-trailerSize:]
+	offsetSize, objRefSize := int(trailer[6]), int(trailer[7])
+	numObjects := binary.BigEndian.Uint64(trailer[8:16])
+	top := binary.BigEndian.Uint64(trailer[16:24])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:32])
+	if offsetSize < 1 || offsetSize > 8 || objRefSize < 1 || objRefSize > 8 ||
+		numObjects == 0 || top >= numObjects ||
+		tableOffset+numObjects*uint64(offsetSize) > uint64(len(data)-trailerSize) {
+		return nil, false
+	}
+	p := &bplist{data: data, objRefSize: objRefSize, top: top}
+	for i := uint64(0); i < numObjects; i++ {
+		start := tableOffset + i*uint64(offsetSize)
+		off := readUint(data[start : start+uint64(offsetSize)])
+		if off >= uint64(len(data)-trailerSize) {
+			return nil, false
+		}
+		p.offsets = append(p.offsets, off)
+	}
+	return p, true
+}
+
+// object returns the marker of object ref, its length (the low nibble, or the integer
+// that follows when it is 0xF), and the offset of its contents.
+func (p *bplist) object(ref uint64) (marker byte, n uint64, start uint64, ok bool) {
+	if ref >= uint64(len(p.offsets)) {
+		return 0, 0, 0, false
+	}
+	off := p.offsets[ref]
+	marker = p.data[off] >> 4
+	n = uint64(p.data[off] & 0x0F)
+	start = off + 1
+	if n == 0x0F {
+		if start >= uint64(len(p.data)) || p.data[start]>>4 != 0x1 {
+			return 0, 0, 0, false
+		}
+		size := uint64(1) << (p.data[start] & 0x0F)
+		if start+1+size > uint64(len(p.data)) || size > 8 {
+			return 0, 0, 0, false
+		}
+		n = readUint(p.data[start+1 : start+1+size])
+		start += 1 + size
+	}
+	return marker, n, start, true
+}
+
+func (p *bplist) array(ref uint64) ([]uint64, bool) {
+	marker, n, start, ok := p.object(ref)
+	if !ok || marker != 0xA || start+n*uint64(p.objRefSize) > uint64(len(p.data)) {
+		return nil, false
+	}
+	refs := make([]uint64, n)
+	for i := range refs {
+		at := start + uint64(i*p.objRefSize)
+		refs[i] = readUint(p.data[at : at+uint64(p.objRefSize)])
+	}
+	return refs, true
+}
+
+func (p *bplist) string(ref uint64) (string, bool) {
+	marker, n, start, ok := p.object(ref)
+	if !ok {
+		return "", false
+	}
+	switch marker {
+	case 0x5: // ASCII
+		if start+n > uint64(len(p.data)) {
+			return "", false
+		}
+		return string(p.data[start : start+n]), true
+	case 0x6: // UTF-16 big-endian, n code units
+		if start+2*n > uint64(len(p.data)) {
+			return "", false
+		}
+		units := make([]uint16, n)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(p.data[start+uint64(2*i):])
+		}
+		return string(utf16.Decode(units)), true
+	}
+	return "", false
+}
+
+// readUint reads a big-endian unsigned integer of 1 to 8 bytes.
+func readUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
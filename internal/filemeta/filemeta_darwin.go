@@ -0,0 +1,36 @@
+package filemeta
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// finderTagsAttr is the extended attribute holding a file's Finder tags.
+const finderTagsAttr = "com.apple.metadata:_kMDItemUserTags"
+
+func read(path string, fi os.FileInfo) Info {
+	info := Info{Owner: ownerOf(fi)}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && st.Birthtimespec.Sec > 0 {
+		info.Created = time.Unix(st.Birthtimespec.Unix())
+	}
+	if data := getxattr(path, finderTagsAttr); data != nil {
+		info.Tags = parseFinderTags(data)
+	}
+	return info
+}
+
+// getxattr returns the value of the extended attribute name of path, or nil.
+func getxattr(path, name string) []byte {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil || size <= 0 {
+		return nil
+	}
+	buf := make([]byte, size)
+	if size, err = unix.Getxattr(path, name, buf); err != nil {
+		return nil
+	}
+	return buf[:size]
+}
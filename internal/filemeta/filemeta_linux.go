@@ -0,0 +1,38 @@
+package filemeta
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// xdgTagsAttr is the extended attribute file managers such as Dolphin store tags in.
+const xdgTagsAttr = "user.xdg.tags"
+
+func read(path string, fi os.FileInfo) Info {
+	info := Info{Owner: ownerOf(fi)}
+	// The birth time needs statx and a file system that records it.
+	var stx unix.Statx_t
+	if err := unix.Statx(unix.AT_FDCWD, path, 0, unix.STATX_BTIME, &stx); err == nil &&
+		stx.Mask&unix.STATX_BTIME != 0 && stx.Btime.Sec > 0 {
+		info.Created = time.Unix(stx.Btime.Sec, int64(stx.Btime.Nsec))
+	}
+	if data := getxattr(path, xdgTagsAttr); data != nil {
+		info.Tags = parseXDGTags(string(data))
+	}
+	return info
+}
+
+// getxattr returns the value of the extended attribute name of path, or nil.
+func getxattr(path, name string) []byte {
+	size, err := unix.Getxattr(path, name, nil)
+	if err != nil || size <= 0 {
+		return nil
+	}
+	buf := make([]byte, size)
+	if size, err = unix.Getxattr(path, name, buf); err != nil {
+		return nil
+	}
+	return buf[:size]
+}
@@ -0,0 +1,27 @@
+package filemeta
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func TestRead_xdgTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := unix.Setxattr(path, xdgTagsAttr, []byte("work,urgent"), 0); err != nil {
+		t.Skipf("file system without user extended attributes: %v", err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Read(path, fi).Tags; !reflect.DeepEqual(got, []string{"work", "urgent"}) {
+		t.Errorf("tags: got %q", got)
+	}
+}
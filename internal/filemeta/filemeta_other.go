@@ -0,0 +1,9 @@
+//go:build !darwin && !linux && !windows
+
+package filemeta
+
+import "os"
+
+func read(path string, fi os.FileInfo) Info {
+	return Info{}
+}
@@ -0,0 +1,92 @@
+package filemeta
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"unicode/utf16"
+)
+
+// finderTagsPlist encodes tags as macOS does: a binary property list of one array of
+// strings, with one-byte offsets and object references.
+func finderTagsPlist(tags ...string) []byte {
+	data := []byte("bplist00")
+	var offsets []byte
+	offsets = append(offsets, byte(len(data)))
+	data = append(data, 0xA0|byte(len(tags)))
+	for i := range tags {
+		data = append(data, byte(i+1))
+	}
+	for _, tag := range tags {
+		offsets = append(offsets, byte(len(data)))
+		ascii := true
+		for _, r := range tag {
+			ascii = ascii && r < 0x80
+		}
+		if ascii {
+			data = append(data, 0x50|byte(len(tag)))
+			data = append(data, tag...)
+			continue
+		}
+		units := utf16.Encode([]rune(tag))
+		data = append(data, 0x60|byte(len(units)))
+		for _, u := range units {
+			data = binary.BigEndian.AppendUint16(data, u)
+		}
+	}
+	tableOffset := len(data)
+	data = append(data, offsets...)
+	trailer := make([]byte, 32)
+	trailer[6], trailer[7] = 1, 1
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(offsets)))
+	binary.BigEndian.PutUint64(trailer[24:], uint64(tableOffset))
+	return append(data, trailer...)
+}
+
+func TestParseFinderTags(t *testing.T) {
+	got := parseFinderTags(finderTagsPlist("Red\n6", "Projects", "Café\n0"))
+	if want := []string{"Red", "Projects", "Café"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	for name, data := range map[string][]byte{
+		"empty":     nil,
+		"not plist": []byte("Red,Blue"),
+		"truncated": finderTagsPlist("Red")[:20],
+	} {
+		if got := parseFinderTags(data); got != nil {
+			t.Errorf("%s: got %q, want nil", name, got)
+		}
+	}
+}
+
+func TestParseXDGTags(t *testing.T) {
+	got := parseXDGTags("work, urgent,,Project X")
+	if want := []string{"work", "urgent", "Project X"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(path, []byte("x"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := Read(path, fi)
+	switch runtime.GOOS {
+	case "darwin", "linux", "windows":
+		if info.Owner == "" {
+			t.Error("owner is empty")
+		}
+	}
+	// File systems without birth times leave Created zero.
+	if !info.Created.IsZero() && info.Created.After(fi.ModTime().Add(1e9)) {
+		t.Errorf("created %v is after the modification time %v", info.Created, fi.ModTime())
+	}
+}
@@ -0,0 +1,38 @@
+package filemeta
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+func read(path string, fi os.FileInfo) Info {
+	var info Info
+	if attrs, ok := fi.Sys().(*syscall.Win32FileAttributeData); ok {
+		info.Created = time.Unix(0, attrs.CreationTime.Nanoseconds())
+	}
+	info.Owner = ownerOf(path)
+	return info
+}
+
+// ownerOf returns the owner of the file as DOMAIN\account, or "" when it cannot be read.
+func ownerOf(path string) string {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return ""
+	}
+	sid, _, err := sd.Owner()
+	if err != nil || sid == nil {
+		return ""
+	}
+	account, domain, _, err := sid.LookupAccount("")
+	if err != nil {
+		return sid.String()
+	}
+	if domain == "" {
+		return account
+	}
+	return domain + `\` + account
+}
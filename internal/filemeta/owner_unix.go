@@ -0,0 +1,33 @@
+//go:build darwin || linux
+
+package filemeta
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// owners caches user names by uid; looking one up may read /etc/passwd or ask a directory
+// service.
+var owners sync.Map
+
+// ownerOf returns the name of the user owning the file, or its uid when the user is unknown.
+func ownerOf(fi os.FileInfo) string {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+	uid := strconv.FormatUint(uint64(st.Uid), 10)
+	if name, ok := owners.Load(uid); ok {
+		return name.(string)
+	}
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+	owners.Store(uid, name)
+	return name
+}
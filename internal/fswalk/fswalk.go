@@ -0,0 +1,136 @@
+// Package fswalk walks directory trees like filepath.WalkDir, optionally skipping hidden
+// directories and following symlinked directories without looping.
+package fswalk
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Options change how Walk descends. The zero Options walk like filepath.WalkDir.
+type Options struct {
+	// SkipHidden skips the directories whose name starts with a dot (e.g. .git, .cache),
+	// with everything beneath them. The root is walked even when hidden.
+	SkipHidden bool
+	// FollowSymlinks descends into symlinked directories. Each directory is walked once,
+	// identified by device and inode, so a link back to an ancestor or a second link to
+	// the same directory is not followed.
+	FollowSymlinks bool
+}
+
+// Walk calls fn for root and each file and directory beneath it, in lexical order, as
+// filepath.WalkDir does; fn may return filepath.SkipDir or filepath.SkipAll. With
+// FollowSymlinks, a symlinked directory is reported by its path under the link, with a
+// DirEntry describing its target, and root itself may be a symlink.
+func Walk(root string, opts Options, fn fs.WalkDirFunc) error {
+	if !opts.SkipHidden && !opts.FollowSymlinks {
+		return filepath.WalkDir(root, fn)
+	}
+	w := &walker{opts: opts, fn: fn, visited: make(map[dirID]bool)}
+	stat := os.Lstat
+	if opts.FollowSymlinks {
+		stat = os.Stat
+	}
+	info, err := stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		if info.IsDir() {
+			w.visit(root, info)
+		}
+		err = w.walk(root, fs.FileInfoToDirEntry(info))
+	}
+	if errors.Is(err, filepath.SkipDir) || errors.Is(err, filepath.SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// Hidden reports whether path, beneath root, is in a hidden directory, or is one when
+// isDir; with SkipHidden, Walk does not report such paths.
+func Hidden(root, path string, isDir bool) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	parts := strings.Split(rel, string(filepath.Separator))
+	if !isDir {
+		parts = parts[:len(parts)-1]
+	}
+	for _, name := range parts {
+		if isHidden(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHidden(name string) bool {
+	return len(name) > 1 && name[0] == '.' && name != ".."
+}
+
+type walker struct {
+	opts    Options
+	fn      fs.WalkDirFunc
+	visited map[dirID]bool
+}
+
+// visit records the directory at path and reports whether it was not walked before.
+// Directories are only tracked when following symlinks, the only way to meet one twice.
+func (w *walker) visit(path string, info fs.FileInfo) bool {
+	if !w.opts.FollowSymlinks {
+		return true
+	}
+	id := identify(path, info)
+	if w.visited[id] {
+		return false
+	}
+	w.visited[id] = true
+	return true
+}
+
+// walk mirrors filepath.WalkDir's walkDir.
+func (w *walker) walk(path string, d fs.DirEntry) error {
+	if err := w.fn(path, d, nil); err != nil || !d.IsDir() {
+		if errors.Is(err, filepath.SkipDir) && d.IsDir() {
+			err = nil
+		}
+		return err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		// Second call, to report the ReadDir error.
+		if err = w.fn(path, d, err); err != nil {
+			if errors.Is(err, filepath.SkipDir) && d.IsDir() {
+				err = nil
+			}
+			return err
+		}
+	}
+	for _, e := range entries {
+		child := filepath.Join(path, e.Name())
+		if e.Type()&fs.ModeSymlink != 0 && w.opts.FollowSymlinks {
+			if info, err := os.Stat(child); err == nil && info.IsDir() {
+				e = fs.FileInfoToDirEntry(info)
+			}
+		}
+		if e.IsDir() {
+			if w.opts.SkipHidden && isHidden(e.Name()) {
+				continue
+			}
+			if info, err := e.Info(); err == nil && !w.visit(child, info) {
+				continue
+			}
+		}
+		if err := w.walk(child, e); err != nil {
+			if errors.Is(err, filepath.SkipDir) {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
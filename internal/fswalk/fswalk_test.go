@@ -0,0 +1,81 @@
+package fswalk
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "outside")
+	for _, d := range []string{filepath.Join(root, ".git"), filepath.Join(root, "sub"), outside} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{filepath.Join(root, ".git", "HEAD"), filepath.Join(root, ".env"), filepath.Join(root, "sub", "a.txt"), filepath.Join(outside, "b.txt")} {
+		if err := os.WriteFile(f, []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	links := map[string]string{
+		filepath.Join(root, "sub", "loop"): root,    // back to an ancestor
+		filepath.Join(root, "ext"):         outside, // walked once, under ext
+		filepath.Join(root, "sub", "ext2"): outside, // the same directory again
+	}
+	for link, target := range links {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symlinks not supported: %v", err)
+		}
+	}
+
+	walk := func(opts Options) []string {
+		var got []string
+		err := Walk(root, opts, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, _ := filepath.Rel(root, path)
+			if d.IsDir() {
+				rel += "/"
+			}
+			got = append(got, filepath.ToSlash(rel))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	if got, want := walk(Options{}), []string{"./", ".env", ".git/", ".git/HEAD", "ext", "sub/", "sub/a.txt", "sub/ext2", "sub/loop"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("zero options: %v, want %v", got, want)
+	}
+	if got, want := walk(Options{SkipHidden: true, FollowSymlinks: true}), []string{"./", ".env", "ext/", "ext/b.txt", "sub/", "sub/a.txt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("skip hidden, follow symlinks: %v, want %v", got, want)
+	}
+}
+
+func TestHidden(t *testing.T) {
+	root := filepath.FromSlash("/w")
+	for _, tc := range []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"/w", true, false},
+		{"/w/.env", false, false},
+		{"/w/.git", true, true},
+		{"/w/.git/HEAD", false, true},
+		{"/w/a/.cache/b/c.txt", false, true},
+		{"/w/a/b.txt", false, false},
+		{"/other/.git/x", false, false},
+	} {
+		if got := Hidden(root, filepath.FromSlash(tc.path), tc.isDir); got != tc.want {
+			t.Errorf("Hidden(%s, %v) = %v, want %v", tc.path, tc.isDir, got, tc.want)
+		}
+	}
+}
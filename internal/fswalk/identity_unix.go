@@ -0,0 +1,26 @@
+//go:build !windows
+
+package fswalk
+
+import (
+	"io/fs"
+	"path/filepath"
+	"syscall"
+)
+
+// dirID identifies a directory however it is reached.
+type dirID struct {
+	dev, ino uint64
+	path     string // resolved path, when the file system gives no inode
+}
+
+func identify(path string, info fs.FileInfo) dirID {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return dirID{dev: uint64(st.Dev), ino: uint64(st.Ino)}
+	}
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		real = path
+	}
+	return dirID{path: real}
+}
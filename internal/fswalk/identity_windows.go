@@ -0,0 +1,22 @@
+//go:build windows
+
+package fswalk
+
+import (
+	"io/fs"
+	"path/filepath"
+)
+
+// dirID identifies a directory however it is reached: by its resolved path, as
+// os.FileInfo on Windows carries no file index.
+type dirID struct {
+	path string
+}
+
+func identify(path string, info fs.FileInfo) dirID {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		real = path
+	}
+	return dirID{path: filepath.Clean(real)}
+}
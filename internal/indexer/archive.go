@@ -0,0 +1,75 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+// metaKeyArchiveEntry is the path within its archive of a document indexed from an archive
+// entry (see indexArchive).
+const metaKeyArchiveEntry = "archive_entry"
+
+// WithArchives indexes the files within ZIP and tar archives as child documents of the
+// archive (see extract.Extractor.ExtractArchive). Without opts.Extensions, the entries
+// kept are those with the extensions a file in the archive's directory would be indexed
+// with.
+func WithArchives(opts extract.ArchiveOptions) IndexerOption {
+	return func(idx *Indexer) { idx.archives = &opts }
+}
+
+// indexArchive indexes the archive at absPath as document docID, the list of the paths of
+// its entries, and each entry as a child document with ID docID + ":" + its path in the
+// archive, titled with its name. Children are deleted with the archive's document.
+func (idx *Indexer) indexArchive(ctx context.Context, absPath, docID string, info os.FileInfo, allowedExts []string) error {
+	opts := *idx.archives
+	if len(opts.Extensions) == 0 && len(allowedExts) > 0 {
+		opts.Extensions = idx.rules.Extensions(absPath, allowedExts)
+	}
+	entries, err := idx.extractor.ExtractArchive(absPath, opts)
+	if err != nil {
+		return fmt.Errorf("extract content: %w", err)
+	}
+	_ = idx.deleteDocument(ctx, docID)
+	paths := make([]string, len(entries))
+	for i, e := range entries {
+		paths[i] = e.Path
+	}
+	parent := idx.fileInput(absPath, docID, info, filepath.Base(absPath), strings.Join(paths, "\n"))
+	children := make([]*models.DocumentInput, len(entries))
+	for i, e := range entries {
+		child := childInput(parent, docID+":"+e.Path, path.Base(e.Path), e.Text)
+		child.Metadata[metaKeyArchiveEntry] = e.Path
+		if lang := codeLanguageOf(e.Path); idx.code && lang != "" {
+			child.Metadata[metaKeyCodeLanguage] = lang
+		}
+		if e.Locked {
+			child.Metadata[metaKeyLocked] = true
+		}
+		children[i] = child
+	}
+	if len(children) > 0 {
+		ids := make([]string, len(children))
+		for i, c := range children {
+			ids[i] = c.ID
+		}
+		parent.Metadata[metaKeyChildren] = ids
+	}
+	for _, input := range append([]*models.DocumentInput{parent}, children...) {
+		if err := idx.indexDocument(ctx, input); err != nil {
+			return err
+		}
+	}
+	if idx.logger != nil {
+		idx.logger.Debug("indexer archive indexed", zap.String("path", absPath), zap.String("doc_id", docID),
+			zap.Int("entries", len(entries)))
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package indexer
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+	"github.com/hyperjump/sagasu/internal/fileid"
+)
+
+func TestIndexFile_archive(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	idx.extractor = extract.NewExtractor()
+	WithArchives(extract.ArchiveOptions{})(idx)
+	WithSkipBinary()(idx)
+	ctx := context.Background()
+
+	path := filepath.Join(dir, "handoff.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := zip.NewWriter(f)
+	for name, content := range map[string]string{
+		"notes/kickoff.txt": "Launch on the first of June.",
+		"notes/photo.jpg":   "not indexed",
+		"README.md":         "# Handoff",
+	} {
+		entry, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := entry.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	// Entries are kept by the allowed extensions, which need not list the archive's own.
+	if err := idx.IndexFile(ctx, path, []string{"zip", "txt", "md"}); err != nil {
+		t.Fatal(err)
+	}
+	archiveID := fileid.FileDocID(mustAbs(path))
+	archive, err := store.GetDocument(ctx, archiveID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := metadataStrings(archive.Metadata, metaKeyChildren); len(got) != 2 {
+		t.Errorf("children = %v", got)
+	}
+	want := map[string]string{ // path in the archive -> title
+		"notes/kickoff.txt": "kickoff.txt",
+		"README.md":         "README.md",
+	}
+	for entry, title := range want {
+		doc, err := store.GetDocument(ctx, archiveID+":"+entry)
+		if err != nil {
+			t.Fatalf("entry %s: %v", entry, err)
+		}
+		if doc.Title != title || doc.Metadata[metaKeyArchiveEntry] != entry ||
+			doc.Metadata[metaKeyParentID] != archiveID || doc.Metadata[metaKeySourcePath] != mustAbs(path) {
+			t.Errorf("entry %s = %q %v", entry, doc.Title, doc.Metadata)
+		}
+	}
+	if kickoff, _ := store.GetDocument(ctx, archiveID+":notes/kickoff.txt"); kickoff.Content != "Launch on the first of June." {
+		t.Errorf("entry content = %q", kickoff.Content)
+	}
+
+	if err := idx.DeleteDocument(ctx, archiveID); err != nil {
+		t.Fatal(err)
+	}
+	for entry := range want {
+		if _, err := store.GetDocument(ctx, archiveID+":"+entry); err == nil {
+			t.Errorf("entry %s kept after its archive was deleted", entry)
+		}
+	}
+}
@@ -1,4 +1,207 @@
 package indexer
 
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
 // BatchSize is the default batch size for bulk indexing.
 const BatchSize = 32
+
+// batchDocument is a document of IndexDocuments on its way into the indexes.
+type batchDocument struct {
+	i              int // index into the inputs and errors
+	doc            *models.Document
+	chunks         []*models.DocumentChunk
+	semanticChunks []*models.DocumentChunk
+	embedder       embedding.Embedder
+	vectorIndex    vector.VectorIndex
+}
+
+// IndexDocuments indexes inputs together, which is much faster than indexing them one at
+// a time: chunks are embedded BatchSize at a time across documents, documents and chunks
+// are stored in one transaction, and each vector and keyword index is written once. It
+// returns an error per input, nil for those indexed; a failing input does not stop the
+// others. Like IndexDocument, inputs without an ID are given one.
+func (idx *Indexer) IndexDocuments(ctx context.Context, inputs []*models.DocumentInput) []error {
+	errs := make([]error, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	var batch []*batchDocument
+	for i, input := range inputs {
+		if input.ID == "" {
+			input.ID = uuid.New().String()
+		}
+		if seen[input.ID] {
+			errs[i] = fmt.Errorf("duplicate document ID in batch: %s", input.ID)
+			continue
+		}
+		seen[input.ID] = true
+		idx.recordIndex(input)
+		doc := &models.Document{
+			ID:       input.ID,
+			Title:    input.Title,
+			Metadata: input.Metadata,
+		}
+		chunker, embedder, vectorIndex := idx.settingsFor(doc)
+		doc.Content = preprocess(input, chunker)
+		setFingerprint(doc)
+		if idx.detectLang {
+			setLanguage(doc)
+		}
+		chunks, semanticChunks := idx.chunksFor(doc, chunker)
+		batch = append(batch, &batchDocument{
+			i: i, doc: doc, chunks: chunks, semanticChunks: semanticChunks,
+			embedder: embedder, vectorIndex: vectorIndex,
+		})
+	}
+
+	semantic := 0
+	for _, bd := range batch {
+		semantic += len(bd.semanticChunks)
+	}
+	if semantic > 0 {
+		release, err := idx.acquireEmbed(ctx, semantic)
+		if err != nil {
+			for _, bd := range batch {
+				errs[bd.i] = fmt.Errorf("failed to wait for embedding: %w", err)
+			}
+			return errs
+		}
+		defer release()
+	}
+	idx.embedBatch(ctx, batch, errs)
+	batch = pendingDocuments(batch, errs)
+	if len(batch) == 0 {
+		return errs
+	}
+
+	docs := make([]*models.Document, len(batch))
+	chunks := make([][]*models.DocumentChunk, len(batch))
+	for j, bd := range batch {
+		docs[j], chunks[j] = bd.doc, bd.chunks
+	}
+	storeErrs, err := idx.storage.BatchCreateDocuments(ctx, docs, chunks)
+	if err != nil {
+		for _, bd := range batch {
+			errs[bd.i] = fmt.Errorf("failed to store document: %w", err)
+		}
+		return errs
+	}
+	for j, bd := range batch {
+		if storeErrs[j] != nil {
+			errs[bd.i] = fmt.Errorf("failed to store document: %w", storeErrs[j])
+		}
+	}
+	batch = pendingDocuments(batch, errs)
+	for _, bd := range batch {
+		defer idx.invalidate(bd.doc.ID)
+	}
+
+	idx.addVectorsBatch(ctx, batch, errs)
+	keywordDocs := make([]*models.Document, len(batch))
+	for j, bd := range batch {
+		// Titles are normalized as in IndexDocument.
+		docForKeyword := *bd.doc
+		docForKeyword.Title = normalizeTitleForKeywordSearch(bd.doc.Title)
+		keywordDocs[j] = &docForKeyword
+	}
+	if err := keyword.IndexDocuments(ctx, idx.keywordIndex, keywordDocs); err != nil {
+		for _, bd := range batch {
+			if errs[bd.i] == nil {
+				errs[bd.i] = fmt.Errorf("failed to index keywords: %w", err)
+			}
+		}
+		return errs
+	}
+	for j, bd := range batch {
+		if err := keyword.IndexChunks(ctx, idx.keywordIndex, keywordDocs[j], bd.chunks); err != nil && errs[bd.i] == nil {
+			errs[bd.i] = fmt.Errorf("failed to index keyword chunks: %w", err)
+		}
+	}
+	return errs
+}
+
+// embedBatch embeds the semantic chunks of batch, BatchSize texts per request to each
+// embedder. The documents of a failed request get its error.
+func (idx *Indexer) embedBatch(ctx context.Context, batch []*batchDocument, errs []error) {
+	type text struct {
+		bd    *batchDocument
+		chunk *models.DocumentChunk
+	}
+	var order []embedding.Embedder
+	byEmbedder := make(map[embedding.Embedder][]text)
+	for _, bd := range batch {
+		if _, ok := byEmbedder[bd.embedder]; !ok {
+			order = append(order, bd.embedder)
+		}
+		for _, ch := range bd.semanticChunks {
+			byEmbedder[bd.embedder] = append(byEmbedder[bd.embedder], text{bd, ch})
+		}
+	}
+	for _, embedder := range order {
+		texts := byEmbedder[embedder]
+		for start := 0; start < len(texts); start += BatchSize {
+			end := min(start+BatchSize, len(texts))
+			contents := make([]string, end-start)
+			for j, t := range texts[start:end] {
+				contents[j] = t.chunk.Content
+			}
+			embeddings, err := embedder.EmbedBatch(ctx, contents)
+			for j, t := range texts[start:end] {
+				if err != nil {
+					errs[t.bd.i] = fmt.Errorf("failed to generate embeddings: %w", err)
+					continue
+				}
+				t.chunk.Embedding = embeddings[j]
+			}
+		}
+	}
+}
+
+// addVectorsBatch adds the embedded chunks of batch to their vector indexes, one call per
+// index. The documents of a failed call get its error.
+func (idx *Indexer) addVectorsBatch(ctx context.Context, batch []*batchDocument, errs []error) {
+	var order []vector.VectorIndex
+	byIndex := make(map[vector.VectorIndex][]*batchDocument)
+	for _, bd := range batch {
+		if len(bd.semanticChunks) == 0 {
+			continue
+		}
+		if _, ok := byIndex[bd.vectorIndex]; !ok {
+			order = append(order, bd.vectorIndex)
+		}
+		byIndex[bd.vectorIndex] = append(byIndex[bd.vectorIndex], bd)
+	}
+	for _, vectorIndex := range order {
+		var ids []string
+		var embeddings [][]float32
+		for _, bd := range byIndex[vectorIndex] {
+			for _, ch := range bd.semanticChunks {
+				ids = append(ids, ch.ID)
+				embeddings = append(embeddings, ch.Embedding)
+			}
+		}
+		if err := vectorIndex.Add(ctx, ids, embeddings); err != nil {
+			for _, bd := range byIndex[vectorIndex] {
+				errs[bd.i] = fmt.Errorf("failed to index vectors: %w", err)
+			}
+		}
+	}
+}
+
+// pendingDocuments returns the documents of batch that have not failed.
+func pendingDocuments(batch []*batchDocument, errs []error) []*batchDocument {
+	out := batch[:0]
+	for _, bd := range batch {
+		if errs[bd.i] == nil {
+			out = append(out, bd)
+		}
+	}
+	return out
+}
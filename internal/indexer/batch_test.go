@@ -0,0 +1,63 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestIndexDocuments(t *testing.T) {
+	ctx := context.Background()
+	idx, store := testIndexerWithStorage(t, t.TempDir())
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "existing", Content: "already here"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// More documents than BatchSize, so chunks are embedded in several requests.
+	var inputs []*models.DocumentInput
+	for i := 0; i < BatchSize+8; i++ {
+		inputs = append(inputs, &models.DocumentInput{
+			ID: fmt.Sprintf("note-%d", i), Title: "note_" + fmt.Sprint(i), Content: fmt.Sprintf("meeting notes number %d", i),
+		})
+	}
+	inputs = append(inputs,
+		&models.DocumentInput{ID: "existing", Content: "conflicts with a stored document"},
+		&models.DocumentInput{ID: "note-0", Content: "repeated in the batch"},
+		&models.DocumentInput{Content: "no ID given"},
+	)
+	errs := idx.IndexDocuments(ctx, inputs)
+
+	failed := map[int]bool{BatchSize + 8: true, BatchSize + 9: true}
+	for i, err := range errs {
+		if (err != nil) != failed[i] {
+			t.Errorf("input %d (%s): err = %v", i, inputs[i].ID, err)
+		}
+	}
+	if inputs[len(inputs)-1].ID == "" {
+		t.Error("an input without ID should be given one")
+	}
+	if n, _ := store.CountDocuments(ctx); n != int64(BatchSize+8+2) {
+		t.Errorf("documents: got %d, want %d", n, BatchSize+8+2)
+	}
+	if got := idx.vectorIndex.Size(); got != BatchSize+8+2 {
+		t.Errorf("vectors: got %d, want %d", got, BatchSize+8+2)
+	}
+	if n, _ := idx.keywordIndex.DocCount(); n != uint64(BatchSize+8+2) {
+		t.Errorf("keyword index: got %d documents, want %d", n, BatchSize+8+2)
+	}
+	results, err := idx.keywordIndex.Search(ctx, "note 7", 5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 0 || results[0].ID != "note-7" {
+		t.Errorf("title search: got %v, want note-7 first", results)
+	}
+	if doc, _ := store.GetDocument(ctx, "existing"); doc == nil || doc.Content != "already here" {
+		t.Errorf("stored document was overwritten: %+v", doc)
+	}
+	if doc, _ := store.GetDocument(ctx, "note-3"); doc == nil || doc.Metadata[metaKeyFingerprint] == nil {
+		t.Errorf("batch document has no content fingerprint: %+v", doc)
+	}
+}
@@ -9,10 +9,24 @@ import (
 	"github.com/hyperjump/sagasu/internal/models"
 )
 
-// Chunker splits text into overlapping word-based chunks.
+// Chunking strategies, the values of search.chunk_strategy.
+const (
+	// ChunkFixed splits text into windows of chunk size words (the default).
+	ChunkFixed = "fixed"
+	// ChunkSentence packs whole sentences into chunks of up to chunk size words.
+	ChunkSentence = "sentence"
+	// ChunkParagraph packs whole paragraphs into chunks of up to chunk size words,
+	// starting a chunk at each Markdown heading.
+	ChunkParagraph = "paragraph"
+	// ChunkToken splits text into windows of chunk size estimated model tokens.
+	ChunkToken = "token"
+)
+
+// Chunker splits text into overlapping chunks with a chunking strategy.
 type Chunker struct {
 	chunkSize    int
 	chunkOverlap int
+	strategy     string
 }
 
 // NewChunker creates a chunker with the given size and overlap (in words).
@@ -20,11 +34,49 @@ func NewChunker(chunkSize, chunkOverlap int) *Chunker {
 	return &Chunker{
 		chunkSize:    chunkSize,
 		chunkOverlap: chunkOverlap,
+		strategy:     ChunkFixed,
+	}
+}
+
+// WithStrategy sets the chunking strategy: ChunkFixed, ChunkSentence, ChunkParagraph, or
+// ChunkToken. Empty or unknown strategies mean ChunkFixed.
+func (c *Chunker) WithStrategy(strategy string) *Chunker {
+	switch strategy {
+	case ChunkSentence, ChunkParagraph, ChunkToken:
+		c.strategy = strategy
+	default:
+		c.strategy = ChunkFixed
 	}
+	return c
+}
+
+// Strategy returns the chunking strategy of c.
+func (c *Chunker) Strategy() string {
+	return c.strategy
+}
+
+// keepsLines reports whether the strategy of c needs the line and paragraph breaks of the
+// text, kept by PreprocessParagraphs.
+func (c *Chunker) keepsLines() bool {
+	return c.strategy == ChunkSentence || c.strategy == ChunkParagraph
 }
 
-// Chunk splits text into DocumentChunks with overlapping windows.
+// Chunk splits text into DocumentChunks with the strategy of c.
 func (c *Chunker) Chunk(docID, text string) []*models.DocumentChunk {
+	switch c.strategy {
+	case ChunkSentence:
+		return c.chunkSentences(docID, text)
+	case ChunkParagraph:
+		return c.chunkParagraphs(docID, text)
+	case ChunkToken:
+		return c.chunkTokens(docID, text)
+	}
+	return c.chunkWords(docID, text)
+}
+
+// chunkWords splits text into windows of chunk size words overlapping by chunk overlap
+// words.
+func (c *Chunker) chunkWords(docID, text string) []*models.DocumentChunk {
 	words := strings.Fields(text)
 	if len(words) == 0 {
 		return nil
@@ -42,13 +94,7 @@ func (c *Chunker) Chunk(docID, text string) []*models.DocumentChunk {
 		}
 		chunkWords := words[i:end]
 		chunkText := strings.Join(chunkWords, " ")
-		chunk := &models.DocumentChunk{
-			ID:         fmt.Sprintf("%s_%s", docID, uuid.New().String()[:8]),
-			DocumentID: docID,
-			Content:    chunkText,
-			ChunkIndex: chunkIndex,
-		}
-		chunks = append(chunks, chunk)
+		chunks = append(chunks, newChunk(docID, chunkIndex, chunkText))
 		chunkIndex++
 		if end >= len(words) {
 			break
@@ -56,3 +102,13 @@ func (c *Chunker) Chunk(docID, text string) []*models.DocumentChunk {
 	}
 	return chunks
 }
+
+// newChunk returns the chunk of docID at index with content.
+func newChunk(docID string, index int, content string) *models.DocumentChunk {
+	return &models.DocumentChunk{
+		ID:         fmt.Sprintf("%s_%s", docID, uuid.New().String()[:8]),
+		DocumentID: docID,
+		Content:    content,
+		ChunkIndex: index,
+	}
+}
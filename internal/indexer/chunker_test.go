@@ -1,7 +1,10 @@
 package indexer
 
 import (
+	"reflect"
 	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
 )
 
 func TestChunker_Chunk(t *testing.T) {
@@ -36,3 +39,76 @@ func TestPreprocess(t *testing.T) {
 		t.Error("expected trimmed and collapsed spaces")
 	}
 }
+
+func chunkContents(chunks []*models.DocumentChunk) []string {
+	var out []string
+	for i, ch := range chunks {
+		if ch.ChunkIndex != i || ch.DocumentID != "d" {
+			return []string{"bad chunk"}
+		}
+		out = append(out, ch.Content)
+	}
+	return out
+}
+
+func TestChunker_strategies(t *testing.T) {
+	text := PreprocessParagraphs(`# Setup
+
+Install the tool. Run it with   --help!   Dr. J. Smith wrote it, e.g. for teams.
+
+Configure the
+index.
+
+## Usage
+Search for words.`)
+	tests := []struct {
+		strategy      string
+		size, overlap int
+		text          string
+		want          []string
+	}{
+		{ChunkFixed, 4, 1, "one two three four five six", []string{"one two three four", "four five six"}},
+		{"", 4, 0, "one two three", []string{"one two three"}},
+		{ChunkSentence, 8, 3, text, []string{
+			"# Setup\n\nInstall the tool.",
+			"Install the tool. Run it with --help!",
+			"Dr. J. Smith wrote it, e.g. for teams.",
+			"Configure the\nindex.\n\n## Usage\nSearch for words.",
+		}},
+		{ChunkSentence, 3, 0, "A short one. This sentence runs far too long.", []string{
+			"A short one.", "This sentence runs", "far too long.",
+		}},
+		{ChunkSentence, 10, 0, "東京に行きます。大阪に行きます。", []string{"東京に行きます。大阪に行きます。"}},
+		{ChunkParagraph, 13, 5, text, []string{
+			"# Setup\n\nInstall the tool. Run it with --help!",
+			"# Setup\n\nDr. J. Smith wrote it, e.g. for teams.\n\nConfigure the\nindex.",
+			"## Usage\n\nSearch for words.",
+		}},
+		{ChunkParagraph, 4, 0, "first line\nsecond line\nthird line", []string{
+			"first line\n\nsecond line", "third line",
+		}},
+		{ChunkToken, 5, 2, "an internationalization test, ok", []string{"an internationalization", "test, ok"}},
+	}
+	for _, tt := range tests {
+		c := NewChunker(tt.size, tt.overlap).WithStrategy(tt.strategy)
+		got := chunkContents(c.Chunk("d", tt.text))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %d/%d: got %q, want %q", c.Strategy(), tt.size, tt.overlap, got, tt.want)
+		}
+	}
+}
+
+func TestPreprocessParagraphs(t *testing.T) {
+	got := PreprocessParagraphs("\n  Title \r\n\n\n  first   line\nsecond\t line \n\n")
+	if want := "Title\n\nfirst line\nsecond line"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	for word, want := range map[string]int{"the": 1, "internationalization": 4, "test,": 2, "東京": 2, "v1.2": 3} {
+		if got := estimateTokens(word); got != want {
+			t.Errorf("estimateTokens(%q) = %d, want %d", word, got, want)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package indexer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// piece is a unit that chunks are packed from, such as a paragraph, a sentence, or a word,
+// with sep joining it to the piece before it and its size in words or tokens.
+type piece struct {
+	text string
+	sep  string
+	size int
+}
+
+// chunkSentences packs whole sentences into chunks of up to chunk size words, each chunk
+// starting with the last sentences of the previous one that fit in the chunk overlap.
+// Sentences longer than a chunk are split into words.
+func (c *Chunker) chunkSentences(docID, text string) []*models.DocumentChunk {
+	return newChunks(docID, pack(c.fitSentences(sentences(text), c.chunkSize), c.chunkSize, c.chunkOverlap))
+}
+
+// chunkParagraphs packs whole paragraphs into chunks of up to chunk size words. A Markdown
+// heading starts a chunk, and the chunks its section continues into start with it too.
+// Paragraphs are separated by blank lines, or by line breaks in text without blank lines;
+// paragraphs longer than a chunk are split into sentences. Chunks do not overlap.
+func (c *Chunker) chunkParagraphs(docID, text string) []*models.DocumentChunk {
+	var texts []string
+	for _, s := range sections(text) {
+		limit, heading, paras := c.chunkSize, "", s.paragraphs
+		if size := len(strings.Fields(s.heading)); s.heading != "" && size*2 <= c.chunkSize {
+			limit, heading = c.chunkSize-size, s.heading
+		} else if s.heading != "" {
+			paras = append([]string{s.heading}, paras...)
+		}
+		var pieces []piece
+		for _, para := range paras {
+			if size := len(strings.Fields(para)); size <= limit {
+				pieces = append(pieces, piece{text: para, sep: "\n\n", size: size})
+				continue
+			}
+			split := c.fitSentences(sentences(para), limit)
+			split[0].sep = "\n\n"
+			pieces = append(pieces, split...)
+		}
+		if len(pieces) == 0 {
+			texts = append(texts, s.heading)
+			continue
+		}
+		for _, t := range pack(pieces, limit, 0) {
+			if heading != "" {
+				t = heading + "\n\n" + t
+			}
+			texts = append(texts, t)
+		}
+	}
+	return newChunks(docID, texts)
+}
+
+// chunkTokens splits text into windows of up to chunk size tokens overlapping by chunk
+// overlap tokens, as estimated by estimateTokens, so chunks fit the input of the
+// embedding model whatever the length of their words.
+func (c *Chunker) chunkTokens(docID, text string) []*models.DocumentChunk {
+	words := strings.Fields(text)
+	pieces := make([]piece, len(words))
+	for i, w := range words {
+		pieces[i] = piece{text: w, sep: " ", size: estimateTokens(w)}
+	}
+	return newChunks(docID, pack(pieces, c.chunkSize, c.chunkOverlap))
+}
+
+// fitSentences returns sentences with those over limit words split into words.
+func (c *Chunker) fitSentences(sentences []piece, limit int) []piece {
+	var out []piece
+	for _, s := range sentences {
+		if s.size <= limit {
+			out = append(out, s)
+			continue
+		}
+		for i, w := range strings.Fields(s.text) {
+			sep := " "
+			if i == 0 {
+				sep = s.sep
+			}
+			out = append(out, piece{text: w, sep: sep, size: 1})
+		}
+	}
+	return out
+}
+
+// pack joins pieces into texts of up to limit in size, each starting with the last pieces
+// of the previous one that fit in overlap. A piece over limit makes a text on its own.
+func pack(pieces []piece, limit, overlap int) []string {
+	var texts []string
+	var cur []piece
+	size := 0
+	for _, p := range pieces {
+		if len(cur) > 0 && size+p.size > limit {
+			texts = append(texts, joinPieces(cur))
+			keep, kept := len(cur), 0
+			for keep > 0 && kept+cur[keep-1].size <= overlap {
+				keep--
+				kept += cur[keep].size
+			}
+			cur, size = cur[keep:], kept
+			for len(cur) > 0 && size+p.size > limit {
+				size -= cur[0].size
+				cur = cur[1:]
+			}
+		}
+		cur = append(cur, p)
+		size += p.size
+	}
+	if len(cur) > 0 {
+		texts = append(texts, joinPieces(cur))
+	}
+	return texts
+}
+
+// joinPieces returns the text of pieces, each after its separator but the first.
+func joinPieces(pieces []piece) string {
+	var b strings.Builder
+	for i, p := range pieces {
+		if i > 0 {
+			b.WriteString(p.sep)
+		}
+		b.WriteString(p.text)
+	}
+	return b.String()
+}
+
+// newChunks returns the chunks of docID with texts as content.
+func newChunks(docID string, texts []string) []*models.DocumentChunk {
+	if len(texts) == 0 {
+		return nil
+	}
+	chunks := make([]*models.DocumentChunk, len(texts))
+	for i, t := range texts {
+		chunks[i] = newChunk(docID, i, t)
+	}
+	return chunks
+}
+
+// section is a Markdown heading and the paragraphs up to the next one. The section before
+// the first heading has none.
+type section struct {
+	heading    string
+	paragraphs []string
+}
+
+// sections splits text into sections at Markdown headings and their text into paragraphs:
+// blocks separated by blank lines, or lines when text has no blank line.
+func sections(text string) []section {
+	blocks := strings.Split(text, "\n\n")
+	if len(blocks) == 1 {
+		blocks = strings.Split(text, "\n")
+	}
+	var out []section
+	cur := section{}
+	for _, block := range blocks {
+		var para []string
+		flush := func() {
+			if len(para) > 0 {
+				cur.paragraphs = append(cur.paragraphs, strings.Join(para, "\n"))
+				para = nil
+			}
+		}
+		for _, line := range strings.Split(block, "\n") {
+			line = strings.TrimSpace(line)
+			switch {
+			case line == "":
+			case isHeading(line):
+				flush()
+				if cur.heading != "" || len(cur.paragraphs) > 0 {
+					out = append(out, cur)
+				}
+				cur = section{heading: line}
+			default:
+				para = append(para, line)
+			}
+		}
+		flush()
+	}
+	if cur.heading != "" || len(cur.paragraphs) > 0 {
+		out = append(out, cur)
+	}
+	return out
+}
+
+// isHeading reports whether line is a Markdown ATX heading, such as "## Usage".
+func isHeading(line string) bool {
+	n := 0
+	for n < len(line) && line[n] == '#' {
+		n++
+	}
+	return n >= 1 && n <= 6 && n < len(line) && line[n] == ' '
+}
+
+// sentenceClosers may follow the punctuation ending a sentence, such as closing quotes.
+const sentenceClosers = "\"')]}”’»"
+
+// sentences splits text into sentences, sized in words: a sentence ends at a line break,
+// at ".", "!", or "?" followed by white space and no lowercase letter (so "e.g. this"
+// stays whole), and after the full stops of CJK text. A period after a single letter,
+// such as an initial, or after a title such as "Dr" ends none.
+func sentences(text string) []piece {
+	var out []piece
+	runes := []rune(text)
+	start, sep := 0, ""
+	end := func(i int, next string) {
+		if s := strings.TrimSpace(string(runes[start:i])); s != "" {
+			out = append(out, piece{text: s, sep: sep, size: len(strings.Fields(s))})
+			sep = next
+		} else if next != " " && len(next) >= len(sep) {
+			// Blank text between breaks: keep the strongest break
+			sep = next
+		}
+		start = i
+	}
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '\n':
+			next := "\n"
+			if i+1 < len(runes) && runes[i+1] == '\n' {
+				next = "\n\n"
+			}
+			end(i, next)
+		case '。', '！', '？':
+			j := skipClosers(runes, i+1)
+			end(j, "")
+			i = j - 1
+		case '.', '!', '?':
+			j := skipClosers(runes, i+1)
+			if j < len(runes) && !unicode.IsSpace(runes[j]) {
+				continue
+			}
+			if r == '.' && (initialAt(runes, i) || abbreviationAt(runes, i)) {
+				continue
+			}
+			k := j
+			for k < len(runes) && unicode.IsSpace(runes[k]) && runes[k] != '\n' {
+				k++
+			}
+			if k < len(runes) && unicode.IsLower(runes[k]) {
+				continue
+			}
+			end(j, " ")
+			i = j - 1
+		}
+	}
+	end(len(runes), "")
+	return out
+}
+
+// skipClosers returns the index of the first rune of runes from i that is not a
+// sentence closer.
+func skipClosers(runes []rune, i int) int {
+	for i < len(runes) && strings.ContainsRune(sentenceClosers, runes[i]) {
+		i++
+	}
+	return i
+}
+
+// initialAt reports whether the period at runes[i] follows a single letter word, such as
+// the "J" of "J. Smith".
+func initialAt(runes []rune, i int) bool {
+	return i >= 1 && unicode.IsLetter(runes[i-1]) && (i == 1 || !unicode.IsLetter(runes[i-2]) && !unicode.IsDigit(runes[i-2]))
+}
+
+// sentenceAbbreviations are the abbreviations that end no sentence, being followed by a
+// name.
+var sentenceAbbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "st": true, "sr": true, "jr": true,
+}
+
+// abbreviationAt reports whether the period at runes[i] ends one of sentenceAbbreviations,
+// such as the "Dr" of "Dr. Smith".
+func abbreviationAt(runes []rune, i int) bool {
+	j := i
+	for j > 0 && unicode.IsLetter(runes[j-1]) {
+		j--
+	}
+	return sentenceAbbreviations[strings.ToLower(string(runes[j:i]))]
+}
+
+// estimateTokens estimates the tokens a subword tokenizer, such as WordPiece, splits word
+// into: one per punctuation mark or symbol, one per CJK character, and one per up to six
+// letters or digits of other runs. It errs on the high side for long rare words.
+func estimateTokens(word string) int {
+	tokens, run := 0, 0
+	flush := func() {
+		if run > 0 {
+			tokens += (run + 5) / 6
+			run = 0
+		}
+	}
+	for _, r := range word {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			flush()
+			tokens++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r):
+			run++
+		default:
+			flush()
+			tokens++
+		}
+	}
+	flush()
+	return tokens
+}
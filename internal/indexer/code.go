@@ -0,0 +1,294 @@
+package indexer
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// metaKeyCodeLanguage is the programming language of a source file indexed as code (see
+// WithCodeMode), such as "go" or "python".
+const metaKeyCodeLanguage = "code_language"
+
+// codeModifiers are the words that may precede a declaration keyword, as in
+// "export default async function" or "pub(crate) fn".
+const codeModifiers = `(?:(?:export|default|public|private|protected|internal|static|final|abstract|async|override|open|sealed|data|inline|virtual|unsafe|extern|partial|readonly|declare|pub(?:\([^)]*\))?)\s+)*`
+
+// codeSignature matches a C-style function or method signature, "int main(void) {" or
+// "public List<String> names() throws IOException {"; codeMethod one that must open its
+// body on the same line, such as JavaScript's "async load(url) {".
+const (
+	codeSignature = `^[\w$<>\[\],.*&:~ ]*[\w$~]\s*\([^;]*\)[^;]*$`
+	codeMethod    = `^` + codeModifiers + `(?:get\s+|set\s+|\*\s*)?[\w$]+\s*(?:<[^>]*>)?\s*\([^;]*\)[^;{]*\{\s*$`
+)
+
+// codeLanguage describes how declarations start in a programming language.
+type codeLanguage struct {
+	name string
+	decl *regexp.Regexp // a line starting a declaration, without its indentation
+}
+
+// declarations returns a regexp matching lines that start with one of the keywords, after
+// any modifiers, or any of the other patterns.
+func declarations(keywords string, patterns ...string) *regexp.Regexp {
+	alts := append([]string{`^` + codeModifiers + `(?:` + keywords + `)\b`}, patterns...)
+	return regexp.MustCompile(strings.Join(alts, "|"))
+}
+
+var (
+	goLang         = &codeLanguage{"go", declarations(`func|type`)}
+	pythonLang     = &codeLanguage{"python", declarations(`def|class`)}
+	javascriptLang = &codeLanguage{"javascript", declarations(`function|class`,
+		`^`+codeModifiers+`(?:const|let|var)\s+[\w$]+\s*=\s*(?:async\s+)?(?:function\b|\([^)]*\)\s*=>|[\w$]+\s*=>)`, codeMethod)}
+	typescriptLang = &codeLanguage{"typescript", declarations(`function|class|interface|type|enum|namespace`,
+		`^`+codeModifiers+`(?:const|let|var)\s+[\w$]+\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|\([^)]*\)[^=]*=>|[\w$]+\s*=>)`, codeMethod)}
+	javaLang   = &codeLanguage{"java", declarations(`class|interface|enum|record|@interface`, codeSignature)}
+	csharpLang = &codeLanguage{"csharp", declarations(`class|interface|enum|record|struct|namespace|delegate`, codeSignature)}
+	kotlinLang = &codeLanguage{"kotlin", declarations(`fun|class|interface|object|enum\s+class`)}
+	scalaLang  = &codeLanguage{"scala", declarations(`def|class|object|trait|case\s+class`)}
+	swiftLang  = &codeLanguage{"swift", declarations(`func|class|struct|enum|protocol|extension|init`)}
+	rustLang   = &codeLanguage{"rust", declarations(`fn|struct|enum|trait|impl|mod|union|const\s+fn`)}
+	rubyLang   = &codeLanguage{"ruby", declarations(`def|class|module`)}
+	phpLang    = &codeLanguage{"php", declarations(`function|class|interface|trait|enum`)}
+	cLang      = &codeLanguage{"c", declarations(`struct|union|enum|typedef`, codeSignature)}
+	cppLang    = &codeLanguage{"cpp", declarations(`class|struct|union|enum|namespace|template`, codeSignature)}
+	shellLang  = &codeLanguage{"shell", declarations(`function`, `^[\w-]+\s*\(\s*\)`)}
+)
+
+// codeExtensions maps the extensions of the source files indexed as code to their language.
+var codeExtensions = map[string]*codeLanguage{
+	".go":    goLang,
+	".py":    pythonLang,
+	".js":    javascriptLang,
+	".jsx":   javascriptLang,
+	".mjs":   javascriptLang,
+	".cjs":   javascriptLang,
+	".ts":    typescriptLang,
+	".tsx":   typescriptLang,
+	".java":  javaLang,
+	".cs":    csharpLang,
+	".kt":    kotlinLang,
+	".kts":   kotlinLang,
+	".scala": scalaLang,
+	".swift": swiftLang,
+	".rs":    rustLang,
+	".rb":    rubyLang,
+	".php":   phpLang,
+	".c":     cLang,
+	".h":     cLang,
+	".cc":    cppLang,
+	".cpp":   cppLang,
+	".cxx":   cppLang,
+	".hpp":   cppLang,
+	".hh":    cppLang,
+	".sh":    shellLang,
+	".bash":  shellLang,
+}
+
+// controlWords start statements that look like a signature, "if (ok) {", but declare nothing.
+var controlWords = map[string]bool{
+	"if": true, "else": true, "elif": true, "for": true, "foreach": true, "while": true,
+	"do": true, "switch": true, "case": true, "catch": true, "try": true, "return": true,
+	"throw": true, "new": true, "using": true, "lock": true, "synchronized": true,
+	"with": true, "when": true, "unless": true, "until": true, "defer": true, "go": true,
+}
+
+// WithCodeMode makes IndexFile index source files (.go, .py, .js, .ts, and other common
+// languages) as code: their language is recorded as "code_language" metadata, their line
+// breaks are kept, and they are chunked at function and class boundaries (see
+// Chunker.ChunkCode). A keyword index routing code elsewhere (see
+// keyword.CollectionIndex.AddCode) can then index it without stemming.
+func WithCodeMode() IndexerOption {
+	return func(idx *Indexer) { idx.code = true }
+}
+
+// codeLanguageOf returns the name of the programming language of the file at path, or ""
+// when it is not a source file.
+func codeLanguageOf(path string) string {
+	if l := codeExtensions[strings.ToLower(filepath.Ext(path))]; l != nil {
+		return l.name
+	}
+	return ""
+}
+
+// codeLanguageNamed returns the language called name, or nil.
+func codeLanguageNamed(name string) *codeLanguage {
+	for _, l := range codeExtensions {
+		if l.name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// preprocess normalizes the content of input for indexing: source code (documents with a
+// code language) with PreprocessCode, text chunked by sentence or paragraph with
+// PreprocessParagraphs, other text with Preprocess.
+func preprocess(input *models.DocumentInput, chunker *Chunker) string {
+	if lang, _ := input.Metadata[metaKeyCodeLanguage].(string); lang != "" {
+		return PreprocessCode(input.Content)
+	}
+	if chunker.keepsLines() {
+		return PreprocessParagraphs(input.Content)
+	}
+	return Preprocess(input.Content)
+}
+
+// PreprocessCode normalizes source code for indexing: line breaks become "\n", and the
+// trailing white space of lines and the blank lines around the code are removed. The
+// indentation and line structure are kept.
+func PreprocessCode(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
+
+// ChunkCode splits the source code text in language lang (a "code_language" value) into
+// chunks of whole declarations: functions, methods, classes, and types, each with the
+// comments, attributes, and decorators right above it. Consecutive declarations share a
+// chunk while it stays within the chunk size; a larger declaration is split at those it
+// contains, such as the methods of a class, or else into runs of lines overlapping by up
+// to the chunk overlap. Chunks keep their line breaks. Unknown languages are chunked like
+// text, with Chunk.
+func (c *Chunker) ChunkCode(docID, text, lang string) []*models.DocumentChunk {
+	l := codeLanguageNamed(lang)
+	if l == nil {
+		return c.Chunk(docID, text)
+	}
+	var chunks []*models.DocumentChunk
+	var lines []string
+	words := 0
+	flush := func() {
+		if words > 0 {
+			chunks = append(chunks, newChunk(docID, len(chunks), strings.Trim(strings.Join(lines, "\n"), "\n")))
+		}
+		lines, words = nil, 0
+	}
+	for _, section := range c.splitCode(l, strings.Split(text, "\n")) {
+		n := wordCount(section)
+		if words > 0 && words+n > c.chunkSize {
+			flush()
+		}
+		lines = append(lines, section...)
+		words += n
+	}
+	flush()
+	return chunks
+}
+
+// splitCode splits lines into sections within the chunk size, at the least indented
+// declarations after the first line, recursively, or else into runs of lines.
+func (c *Chunker) splitCode(l *codeLanguage, lines []string) [][]string {
+	if wordCount(lines) <= c.chunkSize {
+		return [][]string{lines}
+	}
+	starts := l.sectionStarts(lines)
+	if len(starts) == 0 {
+		return c.splitLines(lines)
+	}
+	var sections [][]string
+	prev := 0
+	for _, start := range starts {
+		sections = append(sections, c.splitCode(l, lines[prev:start])...)
+		prev = start
+	}
+	return append(sections, c.splitCode(l, lines[prev:])...)
+}
+
+// splitLines splits lines into runs within the chunk size (but for lines longer than it),
+// each repeating the last lines of the one before, up to the chunk overlap.
+func (c *Chunker) splitLines(lines []string) [][]string {
+	var runs [][]string
+	for start := 0; start < len(lines); {
+		end, words := start, 0
+		for end < len(lines) {
+			n := len(strings.Fields(lines[end]))
+			if end > start && words+n > c.chunkSize {
+				break
+			}
+			words += n
+			end++
+		}
+		runs = append(runs, lines[start:end])
+		if end == len(lines) {
+			break
+		}
+		next, overlap := end, 0
+		for next > start+1 {
+			n := len(strings.Fields(lines[next-1]))
+			if overlap+n > c.chunkOverlap {
+				break
+			}
+			overlap += n
+			next--
+		}
+		start = next
+	}
+	return runs
+}
+
+// sectionStarts returns the lines (in increasing order, all past the first) at which the
+// least indented declarations of lines start, including the comments, attributes, and
+// decorators right above them.
+func (l *codeLanguage) sectionStarts(lines []string) []int {
+	var starts, indents []int
+	minIndent := -1
+	for i, line := range lines {
+		indent := len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+		if !l.declares(line[indent:]) {
+			continue
+		}
+		start := i
+		for start > 0 && isCodeAnnotation(lines[start-1]) {
+			start--
+		}
+		if start == 0 || len(starts) > 0 && start <= starts[len(starts)-1] {
+			continue
+		}
+		starts, indents = append(starts, start), append(indents, indent)
+		if minIndent < 0 || indent < minIndent {
+			minIndent = indent
+		}
+	}
+	out := starts[:0]
+	for i, start := range starts {
+		if indents[i] == minIndent {
+			out = append(out, start)
+		}
+	}
+	return out
+}
+
+// declares reports whether line, without its indentation, starts a declaration.
+func (l *codeLanguage) declares(line string) bool {
+	fields := strings.FieldsFunc(line, func(r rune) bool { return !unicode.IsLetter(r) && r != '_' && r != '@' })
+	if len(fields) == 0 || controlWords[fields[0]] {
+		return false
+	}
+	return l.decl.MatchString(line)
+}
+
+// isCodeAnnotation reports whether line is a comment, attribute, or decorator, which
+// belongs to the declaration below it.
+func isCodeAnnotation(line string) bool {
+	line = strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "/*", "*", "#", "@", "--"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func wordCount(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		n += len(strings.Fields(line))
+	}
+	return n
+}
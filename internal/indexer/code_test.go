@@ -0,0 +1,142 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/fileid"
+)
+
+const goSource = `package shop
+
+import "fmt"
+
+// Cart holds the items of an order.
+type Cart struct {
+	Items []string
+}
+
+// Add puts item in the cart.
+func (c *Cart) Add(item string) {
+	c.Items = append(c.Items, item)
+}
+
+// Total prints the number of items and returns it.
+func (c *Cart) Total() int {
+	if len(c.Items) == 0 {
+		fmt.Println("empty cart")
+	}
+	return len(c.Items)
+}
+`
+
+const pythonSource = `class Parser:
+    """Parses configuration files into dictionaries of settings."""
+
+    def __init__(self, path):
+        self.path = path
+        self.settings = {}
+
+    @property
+    def name(self):
+        # The file name without its directory.
+        return os.path.basename(self.path)
+
+    def parse_config(self):
+        for line in open(self.path):
+            key, value = line.split("=", 1)
+            self.settings[key.strip()] = value.strip()
+`
+
+func TestChunker_ChunkCode(t *testing.T) {
+	tests := []struct {
+		name, lang, text string
+		size             int
+		want             []string // first line of each chunk
+	}{
+		{"go declarations", "go", goSource, 30,
+			[]string{"package shop", "// Add puts item in the cart.", "// Total prints the number of items and returns it."}},
+		{"everything fits", "go", goSource, 200, []string{"package shop"}},
+		{"class split at methods", "python", pythonSource, 20,
+			[]string{"class Parser:", "    @property", "    def parse_config(self):"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := NewChunker(tt.size, 5).ChunkCode("doc", PreprocessCode(tt.text), tt.lang)
+			var got []string
+			for i, ch := range chunks {
+				if ch.ChunkIndex != i || ch.DocumentID != "doc" {
+					t.Errorf("chunk %d = %+v", i, ch)
+				}
+				first, _, _ := strings.Cut(ch.Content, "\n")
+				got = append(got, first)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("chunks start with %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunker_ChunkCode_longFunction(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("func long() {\n")
+	for i := 0; i < 30; i++ {
+		b.WriteString("\tx := compute(a, b)\n")
+	}
+	b.WriteString("}\n")
+	chunks := NewChunker(12, 4).ChunkCode("doc", b.String(), "go")
+	if len(chunks) < 3 {
+		t.Fatalf("got %d chunks, want a long function split into several", len(chunks))
+	}
+	for _, ch := range chunks {
+		if n := len(strings.Fields(ch.Content)); n > 12 {
+			t.Errorf("chunk of %d words over the chunk size: %q", n, ch.Content)
+		}
+	}
+	if !strings.HasPrefix(chunks[1].Content, "\tx := compute(a, b)") {
+		t.Errorf("second chunk = %q, want whole lines", chunks[1].Content)
+	}
+}
+
+func TestIndexFile_code(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	WithCodeMode()(idx)
+	ctx := context.Background()
+
+	path := filepath.Join(dir, "cart.go")
+	if err := os.WriteFile(path, []byte(strings.ReplaceAll(goSource, "\n", "\r\n")), 0600); err != nil {
+		t.Fatal(err)
+	}
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("func   main"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []string{path, notes} {
+		if err := idx.IndexFile(ctx, p, []string{".go", ".txt"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc, err := store.GetDocument(ctx, fileid.FileDocID(mustAbs(path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Metadata[metaKeyCodeLanguage] != "go" {
+		t.Errorf("code_language = %v, want go", doc.Metadata[metaKeyCodeLanguage])
+	}
+	if doc.Content != strings.TrimSpace(goSource) {
+		t.Errorf("content = %q, want the source with its lines", doc.Content)
+	}
+	text, err := store.GetDocument(ctx, fileid.FileDocID(mustAbs(notes)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := text.Metadata[metaKeyCodeLanguage]; ok || text.Content != "func main" {
+		t.Errorf("text file = %q %v", text.Content, text.Metadata)
+	}
+}
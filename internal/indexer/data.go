@@ -0,0 +1,31 @@
+package indexer
+
+import "github.com/hyperjump/sagasu/internal/extract"
+
+// Metadata of the documents indexed from CSV, TSV, JSON, and JSON Lines files (see
+// dataMetadata).
+const (
+	metaKeyDataFields    = "data_fields"
+	metaKeyDataRows      = "data_rows"
+	metaKeyDataTruncated = "data_truncated"
+)
+
+// maxDataFields bounds the fields recorded for a data file.
+const maxDataFields = 200
+
+// dataMetadata returns the metadata recorded for data: its columns or key paths (at most
+// 200), its number of rows or records, and whether rows past the row limit were left out
+// of its text. Files read as plain text have none.
+func dataMetadata(data *extract.Data) map[string]interface{} {
+	if len(data.Fields) == 0 && data.Rows == 0 {
+		return nil
+	}
+	m := map[string]interface{}{metaKeyDataRows: data.Rows}
+	if len(data.Fields) > 0 {
+		m[metaKeyDataFields] = data.Fields[:min(len(data.Fields), maxDataFields)]
+	}
+	if data.Truncated {
+		m[metaKeyDataTruncated] = true
+	}
+	return m
+}
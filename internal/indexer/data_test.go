@@ -0,0 +1,40 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+	"github.com/hyperjump/sagasu/internal/fileid"
+)
+
+func TestIndexFile_data(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	idx.extractor = extract.NewExtractor().WithMaxRows(2)
+	ctx := context.Background()
+
+	path := filepath.Join(dir, "invoices.csv")
+	if err := os.WriteFile(path, []byte("customer,total\nAcme,120\nGlobex,75\nInitech,30\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexFile(ctx, path, []string{".csv"}); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := store.GetDocument(ctx, fileid.FileDocID(mustAbs(path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != "row 1: customer: Acme | total: 120 row 2: customer: Globex | total: 75" {
+		t.Errorf("content = %q", doc.Content)
+	}
+	if fields := metadataStrings(doc.Metadata, metaKeyDataFields); len(fields) != 2 || fields[0] != "customer" {
+		t.Errorf("fields = %v", fields)
+	}
+	if fmt.Sprint(doc.Metadata[metaKeyDataRows]) != "3" || doc.Metadata[metaKeyDataTruncated] != true {
+		t.Errorf("metadata = %v", doc.Metadata)
+	}
+}
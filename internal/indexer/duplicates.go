@@ -0,0 +1,124 @@
+package indexer
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/simhash"
+	"github.com/hyperjump/sagasu/internal/storage"
+)
+
+// FindDuplicates groups the documents in store matching filter whose content fingerprints
+// differ in at most maxDistance bits; 0 finds identical contents only. Near copies are
+// grouped transitively, so a group may hold two documents further apart linked by a
+// third. Documents without a fingerprint (empty, or indexed before fingerprints were
+// recorded) are left out. Groups come largest reclaimable size first.
+func FindDuplicates(ctx context.Context, store storage.Storage, filter models.DocumentListFilter, maxDistance int) ([]*models.DuplicateGroup, error) {
+	docs, _, err := store.ListDocumentSummaries(ctx, filter, 0, math.MaxInt)
+	if err != nil {
+		return nil, fmt.Errorf("list documents: %w", err)
+	}
+	// Documents sharing a fingerprint are identical; near copies are searched for among
+	// the distinct fingerprints only.
+	var fps []uint64
+	byFingerprint := make(map[uint64][]*models.DocumentSummary)
+	for _, doc := range docs {
+		s, _ := doc.Metadata[metaKeyFingerprint].(string)
+		fp, ok := simhash.Parse(s)
+		if !ok {
+			continue
+		}
+		if _, seen := byFingerprint[fp]; !seen {
+			fps = append(fps, fp)
+		}
+		byFingerprint[fp] = append(byFingerprint[fp], doc)
+	}
+
+	parent := make([]int, len(fps))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for _, pair := range nearPairs(fps, maxDistance) {
+		parent[find(pair[0])] = find(pair[1])
+	}
+
+	members := make(map[int][]uint64)
+	for i, fp := range fps {
+		root := find(i)
+		members[root] = append(members[root], fp)
+	}
+	var groups []*models.DuplicateGroup
+	for _, group := range members {
+		g := &models.DuplicateGroup{Identical: len(group) == 1}
+		var largest int64
+		for _, fp := range group {
+			for _, doc := range byFingerprint[fp] {
+				size := metadataInt64(doc.Metadata, metaKeySourceSize)
+				g.Files = append(g.Files, &models.DuplicateFile{DocumentID: doc.ID, Title: doc.Title, Path: doc.Path, Size: size})
+				g.ReclaimableBytes += size
+				largest = max(largest, size)
+			}
+		}
+		if len(g.Files) < 2 {
+			continue
+		}
+		g.ReclaimableBytes -= largest
+		slices.SortFunc(g.Files, func(a, b *models.DuplicateFile) int {
+			return cmp.Or(cmp.Compare(a.Path, b.Path), cmp.Compare(a.DocumentID, b.DocumentID))
+		})
+		groups = append(groups, g)
+	}
+	slices.SortFunc(groups, func(a, b *models.DuplicateGroup) int {
+		return cmp.Or(
+			cmp.Compare(b.ReclaimableBytes, a.ReclaimableBytes),
+			cmp.Compare(len(b.Files), len(a.Files)),
+			cmp.Compare(a.Files[0].Path, b.Files[0].Path),
+			cmp.Compare(a.Files[0].DocumentID, b.Files[0].DocumentID),
+		)
+	})
+	return groups, nil
+}
+
+// nearPairs returns the index pairs of fps within maxDistance bits of each other. The 64
+// bits are split into maxDistance+1 blocks: two fingerprints that close agree on at least
+// one whole block, so only fingerprints sharing a block value are compared.
+func nearPairs(fps []uint64, maxDistance int) [][2]int {
+	if maxDistance <= 0 {
+		return nil
+	}
+	type blockKey struct {
+		block int
+		value uint64
+	}
+	blocks := min(maxDistance+1, 64)
+	buckets := make(map[blockKey][]int)
+	var pairs [][2]int
+	for i, fp := range fps {
+		compared := make(map[int]bool)
+		for b := 0; b < blocks; b++ {
+			lo, hi := b*64/blocks, (b+1)*64/blocks
+			key := blockKey{b, fp >> lo & (1<<(hi-lo) - 1)}
+			for _, j := range buckets[key] {
+				if !compared[j] {
+					compared[j] = true
+					if simhash.Distance(fp, fps[j]) <= maxDistance {
+						pairs = append(pairs, [2]int{j, i})
+					}
+				}
+			}
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,81 @@
+package indexer
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/simhash"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	ctx := context.Background()
+	idx, store := testIndexerWithStorage(t, t.TempDir())
+	report := strings.Repeat("Revenue grew in every region while costs stayed flat this quarter. ", 10)
+	draft := strings.Replace(report, "flat", "low", 3)
+	a, _ := simhash.Compute(report)
+	if b, _ := simhash.Compute(draft); simhash.Distance(a, b) == 0 || simhash.Distance(a, b) > 3 {
+		t.Fatalf("draft fingerprint is %d bits from the report's, want 1 to 3", simhash.Distance(a, b))
+	}
+	for _, in := range []*models.DocumentInput{
+		{ID: "a", Content: report, Metadata: map[string]interface{}{"source_path": "/docs/report.pdf", "source_size": "4000"}},
+		{ID: "b", Content: report, Metadata: map[string]interface{}{"source_path": "/backup/report.pdf", "source_size": "4000"}},
+		{ID: "c", Content: draft, Metadata: map[string]interface{}{"source_path": "/drafts/report.docx", "source_size": "900"}},
+		{ID: "d", Content: "Meeting notes.", Metadata: map[string]interface{}{"source_path": "/notes/monday.txt", "source_size": "10"}},
+		{ID: "e", Content: "Meeting notes.", Metadata: map[string]interface{}{"source_path": "/notes/copy.txt", "source_size": "10"}},
+		{ID: "f", Content: "A lone memo about parking.", Metadata: map[string]interface{}{"source_path": "/notes/parking.txt"}},
+		{ID: "g", Content: ""},
+		{ID: "h", Content: ""},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	groups, err := FindDuplicates(ctx, store, models.DocumentListFilter{}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2: %+v", len(groups), groups)
+	}
+	g := groups[0]
+	if g.Identical || len(g.Files) != 3 || g.Files[0].Path != "/backup/report.pdf" || g.ReclaimableBytes != 4900 {
+		t.Errorf("report group: identical=%v reclaimable=%d files=%+v", g.Identical, g.ReclaimableBytes, g.Files)
+	}
+	if g := groups[1]; !g.Identical || len(g.Files) != 2 || g.Files[0].DocumentID != "e" || g.Files[0].Size != 10 {
+		t.Errorf("notes group: identical=%v files=%+v", g.Identical, g.Files)
+	}
+
+	groups, _ = FindDuplicates(ctx, store, models.DocumentListFilter{}, 0)
+	if len(groups) != 2 || len(groups[0].Files) != 2 || !groups[0].Identical {
+		t.Errorf("distance 0 should only group identical contents, got %+v", groups)
+	}
+	groups, _ = FindDuplicates(ctx, store, models.DocumentListFilter{Extensions: []string{"pdf"}}, 3)
+	if len(groups) != 1 || len(groups[0].Files) != 2 {
+		t.Errorf("filter should apply before grouping, got %+v", groups)
+	}
+}
+
+func TestNearPairs(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	fps := make([]uint64, 300)
+	for i := range fps {
+		fps[i] = rng.Uint64()
+	}
+	for _, maxDistance := range []int{1, 3, 8, 30} {
+		want := 0
+		for i := range fps {
+			for j := i + 1; j < len(fps); j++ {
+				if simhash.Distance(fps[i], fps[j]) <= maxDistance {
+					want++
+				}
+			}
+		}
+		if got := len(nearPairs(fps, maxDistance)); got != want {
+			t.Errorf("distance %d: got %d pairs, want %d", maxDistance, got, want)
+		}
+	}
+}
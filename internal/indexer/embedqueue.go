@@ -0,0 +1,123 @@
+package indexer
+
+import (
+	"context"
+	"sync"
+)
+
+// EmbedQueue bounds the chunks held by the embedding stage: from the moment a document's
+// chunks are sent to the embedder until their vectors are indexed. Documents wait in
+// arrival order while the stage is full, so a storm of file changes queues small jobs
+// instead of piling up pending chunk batches and their embeddings in memory.
+type EmbedQueue struct {
+	mu       sync.Mutex
+	capacity int
+	depth    int
+	peak     int
+	waits    int64
+	waiters  []*embedWaiter
+}
+
+// embedWaiter is a document waiting for room for n chunks; ready is closed once granted.
+type embedWaiter struct {
+	n     int
+	ready chan struct{}
+}
+
+// EmbedQueueStats describes the embedding stage for status reporting.
+type EmbedQueueStats struct {
+	Capacity int   `json:"capacity"` // most chunks the stage holds
+	Depth    int   `json:"depth"`    // chunks being embedded or indexed now
+	Waiting  int   `json:"waiting"`  // documents waiting for room
+	Peak     int   `json:"peak"`     // highest depth reached
+	Waits    int64 `json:"waits"`    // documents that had to wait, since start
+}
+
+// NewEmbedQueue returns a queue holding at most capacity chunks; capacity must be positive.
+func NewEmbedQueue(capacity int) *EmbedQueue {
+	return &EmbedQueue{capacity: max(capacity, 1)}
+}
+
+// acquire waits for room for n chunks and returns the function that gives it back. A
+// document with more chunks than the capacity waits for the stage to be empty. It fails
+// only when ctx is done first.
+func (q *EmbedQueue) acquire(ctx context.Context, n int) (release func(), err error) {
+	n = min(max(n, 1), q.capacity)
+	release = func() { q.release(n) }
+	q.mu.Lock()
+	if len(q.waiters) == 0 && q.depth+n <= q.capacity {
+		q.take(n)
+		q.mu.Unlock()
+		return release, nil
+	}
+	w := &embedWaiter{n: n, ready: make(chan struct{})}
+	q.waiters = append(q.waiters, w)
+	q.waits++
+	q.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return release, nil
+	case <-ctx.Done():
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case <-w.ready:
+		// Granted meanwhile: give the room back.
+		q.depth -= n
+	default:
+		for i, other := range q.waiters {
+			if other == w {
+				q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+				break
+			}
+		}
+	}
+	q.grant()
+	return nil, ctx.Err()
+}
+
+func (q *EmbedQueue) release(n int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.depth -= n
+	q.grant()
+}
+
+// take adds n chunks to the stage. Callers hold q.mu.
+func (q *EmbedQueue) take(n int) {
+	q.depth += n
+	q.peak = max(q.peak, q.depth)
+}
+
+// grant lets in the waiters at the head of the queue that fit. Callers hold q.mu.
+func (q *EmbedQueue) grant() {
+	for len(q.waiters) > 0 && q.depth+q.waiters[0].n <= q.capacity {
+		w := q.waiters[0]
+		q.waiters = q.waiters[1:]
+		q.take(w.n)
+		close(w.ready)
+	}
+}
+
+// Saturated reports whether the stage is full or documents are waiting for it, which
+// producers such as the watcher use as a signal to hold back work.
+func (q *EmbedQueue) Saturated() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.waiters) > 0 || q.depth >= q.capacity
+}
+
+// Stats returns the queue's current figures.
+func (q *EmbedQueue) Stats() EmbedQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return EmbedQueueStats{
+		Capacity: q.capacity,
+		Depth:    q.depth,
+		Waiting:  len(q.waiters),
+		Peak:     q.peak,
+		Waits:    q.waits,
+	}
+}
@@ -0,0 +1,112 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestEmbedQueue_waitsInOrder(t *testing.T) {
+	q := NewEmbedQueue(10)
+	ctx := context.Background()
+	releaseA, err := q.acquire(ctx, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Saturated() {
+		t.Error("queue with room should not be saturated")
+	}
+
+	done := make(chan error, 2)
+	for i, n := range []int{6, 1} {
+		go func() {
+			release, err := q.acquire(ctx, n)
+			if err == nil {
+				release()
+			}
+			done <- err
+		}()
+		// Let each waiter queue up before the next.
+		for deadline := time.Now().Add(time.Second); q.Stats().Waiting <= i && time.Now().Before(deadline); {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if !q.Saturated() {
+		t.Error("queue with waiters should be saturated")
+	}
+	// The second document fits beside the first but must not overtake the one before it.
+	if got := q.Stats(); got.Depth != 8 || got.Waiting != 2 || got.Waits != 2 {
+		t.Errorf("stats while waiting: %+v", got)
+	}
+
+	releaseA()
+	for range 2 {
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := q.Stats(); got.Depth != 0 || got.Waiting != 0 || got.Peak != 8 || got.Capacity != 10 {
+		t.Errorf("stats after release: %+v", got)
+	}
+}
+
+func TestEmbedQueue_oversizedAndCancelled(t *testing.T) {
+	q := NewEmbedQueue(4)
+	release, err := q.acquire(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("a document larger than the queue should get all of it: %v", err)
+	}
+	if !q.Saturated() {
+		t.Error("full queue should be saturated")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := q.acquire(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("acquire on a full queue = %v, want deadline exceeded", err)
+	}
+	release()
+	if got := q.Stats(); got.Depth != 0 || got.Waiting != 0 {
+		t.Errorf("stats after cancel and release: %+v", got)
+	}
+}
+
+func TestIndexer_embedQueue(t *testing.T) {
+	idx, _ := testIndexerWithStorage(t, t.TempDir())
+	if idx.Saturated() || idx.EmbedQueueStats() != nil {
+		t.Error("an indexer without an embed queue should report none")
+	}
+	q := NewEmbedQueue(3)
+	WithEmbedQueue(q)(idx)
+	ctx := context.Background()
+
+	content := "one two three four five six seven eight nine ten eleven twelve thirteen fourteen fifteen sixteen seventeen eighteen nineteen twenty"
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "a", Title: "a", Content: content}); err != nil {
+		t.Fatal(err)
+	}
+	errs := idx.IndexDocuments(ctx, []*models.DocumentInput{
+		{ID: "b", Title: "b", Content: content},
+		{ID: "c", Title: "c", Content: content},
+	})
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := idx.EmbedQueueStats()
+	if stats == nil || stats.Depth != 0 || stats.Peak != 3 {
+		t.Errorf("stats after indexing: %+v", stats)
+	}
+
+	// A full queue makes indexing wait, here until the context gives up.
+	release, _ := q.acquire(ctx, 3)
+	defer release()
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := idx.IndexDocument(waitCtx, &models.DocumentInput{ID: "d", Title: "d", Content: content}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("IndexDocument on a full queue = %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,34 @@
+package indexer
+
+import "github.com/hyperjump/sagasu/internal/extract"
+
+// Metadata of the documents indexed from EPUB books and Markdown front matter; the
+// ranker scores "author" matches as author matches, and "tags" as tag matches.
+const (
+	metaKeyTitle     = "title"
+	metaKeyAuthor    = "author"
+	metaKeyPublisher = "publisher"
+	metaKeyDate      = "date"
+)
+
+// bookMetadata returns the metadata recorded for book: its title, authors, publisher,
+// date, and subjects as tags, those it has.
+func bookMetadata(book *extract.Book) map[string]interface{} {
+	m := make(map[string]interface{})
+	for key, v := range map[string]string{
+		metaKeyTitle:     book.Title,
+		metaKeyPublisher: book.Publisher,
+		metaKeyDate:      book.Date,
+	} {
+		if v != "" {
+			m[key] = v
+		}
+	}
+	if len(book.Authors) > 0 {
+		m[metaKeyAuthor] = book.Authors
+	}
+	if len(book.Subjects) > 0 {
+		m[metaKeyTags] = book.Subjects
+	}
+	return m
+}
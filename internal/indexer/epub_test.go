@@ -0,0 +1,27 @@
+package indexer
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+)
+
+func TestBookMetadata(t *testing.T) {
+	got := bookMetadata(&extract.Book{
+		Title:    "The Harbour",
+		Authors:  []string{"Ana Lima", "Bo Chen"},
+		Date:     "2021-06-01",
+		Subjects: []string{"Sailing"},
+		Text:     "The tide was high.",
+	})
+	want := map[string]interface{}{
+		metaKeyTitle:  "The Harbour",
+		metaKeyAuthor: []string{"Ana Lima", "Bo Chen"},
+		metaKeyDate:   "2021-06-01",
+		metaKeyTags:   []string{"Sailing"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,28 @@
+package indexer
+
+import "github.com/hyperjump/sagasu/internal/events"
+
+// WithEvents publishes indexing activity to bus: documents and files indexed, documents
+// deleted, and failures, with the source path when known. Files skipped as unchanged are
+// not reported.
+func WithEvents(bus *events.Bus) IndexerOption {
+	return func(idx *Indexer) { idx.events = bus }
+}
+
+// publishIndexed publishes that the document id from path was indexed, or failed with err.
+func (idx *Indexer) publishIndexed(id, path string, err error) {
+	if idx.events == nil {
+		return
+	}
+	e := events.Event{Type: events.Indexed, DocumentID: id, Path: path}
+	if err != nil {
+		e.Type, e.Error = events.Failed, err.Error()
+	}
+	idx.events.Publish(e)
+}
+
+// sourcePath returns the source file path recorded in document metadata, if any.
+func sourcePath(metadata map[string]interface{}) string {
+	path, _ := metadata[metaKeySourcePath].(string)
+	return path
+}
@@ -0,0 +1,64 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/events"
+	"github.com/hyperjump/sagasu/internal/fileid"
+)
+
+func TestIndexer_events(t *testing.T) {
+	dir := t.TempDir()
+	idx, _ := testIndexerWithStorage(t, dir)
+	bus := events.NewBus(0)
+	WithEvents(bus)(idx)
+	ch, cancel, _ := bus.Subscribe(0)
+	defer cancel()
+	ctx := context.Background()
+
+	path := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(path, []byte("meeting notes"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexFile(ctx, path, nil); err != nil {
+		t.Fatal(err)
+	}
+	// Unchanged files are not reported
+	if err := idx.IndexFile(ctx, path, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexFile(ctx, filepath.Join(dir, "missing.txt"), nil); err == nil {
+		t.Fatal("expected an error for a missing file")
+	}
+	if err := idx.DeleteDocument(ctx, fileid.FileDocID(path)); err != nil {
+		t.Fatal(err)
+	}
+	// Deleting a document that is not indexed is not reported
+	if err := idx.DeleteDocument(ctx, "unknown"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []events.Event{
+		{Type: events.Indexed, DocumentID: fileid.FileDocID(path), Path: path},
+		{Type: events.Failed, Path: filepath.Join(dir, "missing.txt")},
+		{Type: events.Deleted, DocumentID: fileid.FileDocID(path), Path: path},
+	}
+	for i, w := range want {
+		select {
+		case e := <-ch:
+			if e.Type != w.Type || e.DocumentID != w.DocumentID || e.Path != w.Path || (e.Type == events.Failed) != (e.Error != "") {
+				t.Errorf("event %d = %+v, want %+v", i, e, w)
+			}
+		default:
+			t.Fatalf("event %d missing, want %+v", i, w)
+		}
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("unexpected event %+v", e)
+	default:
+	}
+}
@@ -0,0 +1,33 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperjump/sagasu/internal/fileid"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+)
+
+// FileState reports whether the file at path is indexed in store and whether the indexed
+// copy is current. A file that no longer exists on disk can still be indexed, but is
+// never current.
+func FileState(ctx context.Context, store storage.Storage, path string) (*models.FileStatus, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("absolute path: %w", err)
+	}
+	status := &models.FileStatus{Path: absPath, DocumentID: fileid.FileDocID(absPath)}
+	doc, err := store.GetDocument(ctx, status.DocumentID)
+	if err != nil {
+		return status, nil
+	}
+	status.Indexed = true
+	status.IndexedAt = &doc.UpdatedAt
+	if info, err := os.Stat(absPath); err == nil {
+		status.Current = indexedCopyCurrent(doc, absPath, info)
+	}
+	return status, nil
+}
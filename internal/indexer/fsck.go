@@ -0,0 +1,307 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+// fsckListed is the number of items of each finding listed in an FsckReport.
+const fsckListed = 20
+
+// fsckSource is a document whose source file no longer exists.
+type fsckSource struct {
+	id, path string
+}
+
+// Fsck cross-checks storage, the keyword and vector indexes, and the filesystem. It finds
+// stored chunks whose document is gone, vectors whose chunk is gone, chunks without a
+// vector, keyword entries without a stored document, stored documents missing from the
+// keyword index, and documents whose source file was deleted, e.g. while the server was
+// down. Checks that the storage or an index cannot run (see storage.OrphanChunker,
+// vector.Lister, and keyword.Lister) are listed in the report's Skipped.
+//
+// With repair, Fsck deletes the documents of missing files and the orphaned chunks,
+// vectors, and keyword entries, embeds the chunks without a vector, and indexes the
+// documents missing from the keyword index. Documents indexed or deleted while Fsck runs
+// may be reported; pause indexing first for an exact report. A repair looks up orphans
+// and missing vectors again before fixing them.
+func (idx *Indexer) Fsck(ctx context.Context, repair bool) (*models.FsckReport, error) {
+	report := &models.FsckReport{}
+	var err error
+	if report.Documents, err = idx.storage.CountDocuments(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+	if report.Chunks, err = idx.storage.CountChunks(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count chunks: %w", err)
+	}
+	for _, vi := range idx.vectorIndexes() {
+		report.Vectors += vi.Size()
+	}
+	if report.KeywordDocuments, err = idx.keywordIndex.DocCount(); err != nil {
+		return nil, fmt.Errorf("failed to count keyword documents: %w", err)
+	}
+
+	// List the indexes before walking storage: documents are stored before they reach the
+	// indexes, so those indexed meanwhile are not taken for orphans.
+	vectorIDs := make(map[vector.VectorIndex]map[string]bool)
+	for _, vi := range idx.vectorIndexes() {
+		if l, ok := vi.(vector.Lister); ok {
+			if ids, ok := l.IDs(); ok {
+				vectorIDs[vi] = idSet(ids)
+				continue
+			}
+		}
+		report.Skipped = append(report.Skipped, fmt.Sprintf("orphan and missing vectors: the %s vector index cannot list its vectors", vi.Type()))
+	}
+	var keywordIDs map[string]bool
+	if l, ok := idx.keywordIndex.(keyword.Lister); ok {
+		ids, err := l.DocIDs(ctx)
+		switch {
+		case errors.Is(err, keyword.ErrListUnsupported):
+		case err != nil:
+			return nil, fmt.Errorf("failed to list keyword documents: %w", err)
+		default:
+			keywordIDs = idSet(ids)
+		}
+	}
+	if keywordIDs == nil {
+		report.Skipped = append(report.Skipped, "keyword documents: the keyword index cannot list its documents")
+	}
+
+	storedDocs := make(map[string]bool)
+	storedChunks := make(map[string]bool)
+	var missingSources []fsckSource
+	var missingKeyword []string
+	missingVectors := make(map[string][]*models.DocumentChunk) // document ID -> its chunks without a vector
+	var missingVectorDocs []*models.Document
+	for offset := 0; ; offset += reindexPageSize {
+		docs, err := idx.storage.ListDocuments(ctx, offset, reindexPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		for _, doc := range docs {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			storedDocs[doc.ID] = true
+			if path, _ := doc.Metadata[metaKeySourcePath].(string); path != "" {
+				if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+					missingSources = append(missingSources, fsckSource{id: doc.ID, path: path})
+				}
+			}
+			if keywordIDs != nil && !keywordIDs[doc.ID] {
+				missingKeyword = append(missingKeyword, doc.ID)
+			}
+			chunks, err := idx.storage.GetChunksByDocumentID(ctx, doc.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get chunks of %s: %w", doc.ID, err)
+			}
+			_, _, vi := idx.settingsFor(doc)
+			listed := vectorIDs[vi]
+			for _, ch := range chunks {
+				storedChunks[ch.ID] = true
+				if listed == nil || listed[ch.ID] || (idx.stopChunks != nil && idx.stopChunks.Skipped(ch.Content)) {
+					continue
+				}
+				if missingVectors[doc.ID] == nil {
+					missingVectorDocs = append(missingVectorDocs, doc)
+				}
+				missingVectors[doc.ID] = append(missingVectors[doc.ID], ch)
+			}
+		}
+		if len(docs) < reindexPageSize {
+			break
+		}
+	}
+
+	orphaner, canOrphan := idx.storage.(storage.OrphanChunker)
+	var orphanChunks []string
+	if canOrphan {
+		orphanChunks, err = orphaner.OrphanChunks(ctx)
+		if errors.Is(err, storage.ErrOrphanChunksUnsupported) {
+			canOrphan = false
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to find orphaned chunks: %w", err)
+		}
+	}
+	if !canOrphan {
+		report.Skipped = append(report.Skipped, "orphan chunks: the storage cannot look for them")
+	}
+	orphanVectors := make(map[vector.VectorIndex][]string)
+	var allOrphanVectors []string
+	for vi, ids := range vectorIDs {
+		for id := range ids {
+			if !storedChunks[id] {
+				orphanVectors[vi] = append(orphanVectors[vi], id)
+				allOrphanVectors = append(allOrphanVectors, id)
+			}
+		}
+	}
+	var orphanKeyword []string
+	for id := range keywordIDs {
+		if !storedDocs[id] {
+			orphanKeyword = append(orphanKeyword, id)
+		}
+	}
+	var missingVectorIDs, sourcePaths []string
+	for _, doc := range missingVectorDocs {
+		for _, ch := range missingVectors[doc.ID] {
+			missingVectorIDs = append(missingVectorIDs, ch.ID)
+		}
+	}
+	for _, s := range missingSources {
+		sourcePaths = append(sourcePaths, s.path)
+	}
+	report.OrphanChunks = fsckFinding(orphanChunks)
+	report.OrphanVectors = fsckFinding(allOrphanVectors)
+	report.MissingVectors = fsckFinding(missingVectorIDs)
+	report.OrphanKeywordDocuments = fsckFinding(orphanKeyword)
+	report.MissingKeywordDocuments = fsckFinding(missingKeyword)
+	report.MissingSources = fsckFinding(sourcePaths)
+	if !repair {
+		return report, nil
+	}
+
+	report.Repaired = true
+	deleted := make(map[string]bool)
+	for _, s := range missingSources {
+		if err := idx.DeleteDocument(ctx, s.id); err != nil {
+			return nil, fmt.Errorf("failed to delete document of missing %s: %w", s.path, err)
+		}
+		deleted[s.id] = true
+		report.MissingSources.Fixed++
+	}
+	if len(orphanChunks) > 0 {
+		n, err := orphaner.DeleteOrphanChunks(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to delete orphaned chunks: %w", err)
+		}
+		report.OrphanChunks.Fixed = int(n)
+	}
+	// Orphans are looked up again before they are removed, in case their document was
+	// indexed while storage was walked.
+	for vi, ids := range orphanVectors {
+		var gone []string
+		for _, id := range ids {
+			if _, err := idx.storage.GetChunk(ctx, id); err != nil {
+				gone = append(gone, id)
+			}
+		}
+		if err := vi.Remove(ctx, gone); err != nil {
+			return nil, fmt.Errorf("failed to remove orphaned vectors: %w", err)
+		}
+		report.OrphanVectors.Fixed += len(gone)
+	}
+	for _, id := range orphanKeyword {
+		if _, err := idx.storage.GetDocument(ctx, id); err == nil {
+			continue
+		}
+		if err := idx.keywordIndex.Delete(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to delete orphaned keyword document %s: %w", id, err)
+		}
+		report.OrphanKeywordDocuments.Fixed++
+	}
+	current := make(map[vector.VectorIndex]map[string]bool)
+	for _, doc := range missingVectorDocs {
+		if deleted[doc.ID] {
+			continue
+		}
+		_, _, vi := idx.settingsFor(doc)
+		if current[vi] == nil {
+			ids, _ := vi.(vector.Lister).IDs()
+			current[vi] = idSet(ids)
+		}
+		var chunks []*models.DocumentChunk
+		for _, ch := range missingVectors[doc.ID] {
+			if !current[vi][ch.ID] {
+				chunks = append(chunks, ch)
+			}
+		}
+		if len(chunks) == 0 {
+			continue
+		}
+		if err := idx.addVectors(ctx, doc, chunks); err != nil {
+			return nil, fmt.Errorf("failed to embed the chunks of %s: %w", doc.ID, err)
+		}
+		report.MissingVectors.Fixed += len(chunks)
+	}
+	for _, id := range missingKeyword {
+		if deleted[id] {
+			continue
+		}
+		if err := idx.addKeywords(ctx, id); err != nil {
+			return nil, fmt.Errorf("failed to index keywords of %s: %w", id, err)
+		}
+		report.MissingKeywordDocuments.Fixed++
+	}
+	return report, nil
+}
+
+// addVectors embeds chunks of doc and adds them to its vector index.
+func (idx *Indexer) addVectors(ctx context.Context, doc *models.Document, chunks []*models.DocumentChunk) error {
+	_, embedder, vectorIndex := idx.settingsFor(doc)
+	release, err := idx.acquireEmbed(ctx, len(chunks))
+	if err != nil {
+		return err
+	}
+	defer release()
+	texts := make([]string, len(chunks))
+	ids := make([]string, len(chunks))
+	for i, ch := range chunks {
+		texts[i], ids[i] = ch.Content, ch.ID
+	}
+	embeddings, err := embedder.EmbedBatch(ctx, texts)
+	if err != nil {
+		return err
+	}
+	return vectorIndex.Add(ctx, ids, embeddings)
+}
+
+// addKeywords indexes the stored document id, and its chunks, in the keyword index.
+func (idx *Indexer) addKeywords(ctx context.Context, id string) error {
+	doc, err := idx.storage.GetDocument(ctx, id)
+	if err != nil {
+		return err
+	}
+	docForKeyword := *doc
+	docForKeyword.Title = normalizeTitleForKeywordSearch(doc.Title)
+	if err := idx.keywordIndex.Index(ctx, doc.ID, &docForKeyword); err != nil {
+		return err
+	}
+	if !keyword.IndexesChunks(idx.keywordIndex) {
+		return nil
+	}
+	chunks, err := idx.storage.GetChunksByDocumentID(ctx, doc.ID)
+	if err != nil {
+		return err
+	}
+	return keyword.IndexChunks(ctx, idx.keywordIndex, &docForKeyword, chunks)
+}
+
+// fsckFinding returns the finding of items, listing the first fsckListed in order.
+func fsckFinding(items []string) models.FsckFinding {
+	sorted := append([]string(nil), items...)
+	sort.Strings(sorted)
+	if len(sorted) > fsckListed {
+		sorted = sorted[:fsckListed]
+	}
+	return models.FsckFinding{Count: len(items), Items: sorted}
+}
+
+// idSet returns ids as a set.
+func idSet(ids []string) map[string]bool {
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
@@ -0,0 +1,105 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/fileid"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestFsck(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	ctx := context.Background()
+
+	var paths []string
+	for _, name := range []string{"a.txt", "b.txt", "c.txt", "gone.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("harbour tides and ferries at dawn"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.IndexFile(ctx, path, []string{".txt"}); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	report, err := idx.Fsck(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Problems() != 0 || report.Documents != 4 || len(report.Skipped) != 0 {
+		t.Fatalf("healthy index: %+v", report)
+	}
+
+	// b.txt lost its keyword entry, c.txt its vectors, gone.txt its file; a stray vector,
+	// keyword entry, and chunk have no document.
+	b, c := fileid.FileDocID(paths[1]), fileid.FileDocID(paths[2])
+	if err := idx.keywordIndex.Delete(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := store.GetChunksByDocumentID(ctx, c)
+	if err != nil || len(chunks) == 0 {
+		t.Fatalf("chunks of c.txt: %v, %v", chunks, err)
+	}
+	var cIDs []string
+	for _, ch := range chunks {
+		cIDs = append(cIDs, ch.ID)
+	}
+	if err := idx.vectorIndex.Remove(ctx, cIDs); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(paths[3]); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.vectorIndex.Add(ctx, []string{"stray_0"}, [][]float32{{1, 0, 0, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.keywordIndex.Index(ctx, "stray", &models.Document{ID: "stray", Content: "stray"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.BatchCreateChunks(ctx, []*models.DocumentChunk{{ID: "lost_0", DocumentID: "lost", Content: "lost"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = idx.Fsck(ctx, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, got := range map[string]models.FsckFinding{
+		"orphan chunks":            report.OrphanChunks,
+		"orphan vectors":           report.OrphanVectors,
+		"missing vectors":          report.MissingVectors,
+		"orphan keyword documents": report.OrphanKeywordDocuments,
+		"missing keyword":          report.MissingKeywordDocuments,
+		"missing sources":          report.MissingSources,
+	} {
+		want := 1
+		if name == "missing vectors" {
+			want = len(cIDs)
+		}
+		if got.Count != want || got.Fixed != 0 {
+			t.Errorf("%s = %+v, want %d", name, got, want)
+		}
+	}
+	if got := report.MissingSources.Items; len(got) != 1 || got[0] != paths[3] {
+		t.Errorf("missing sources = %v", got)
+	}
+
+	if report, err = idx.Fsck(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Repaired || report.MissingVectors.Fixed != len(cIDs) || report.OrphanVectors.Fixed != 1 ||
+		report.OrphanChunks.Fixed != 1 || report.MissingSources.Fixed != 1 ||
+		report.OrphanKeywordDocuments.Fixed != 1 || report.MissingKeywordDocuments.Fixed != 1 {
+		t.Errorf("repair: %+v", report)
+	}
+	if report, err = idx.Fsck(ctx, false); err != nil {
+		t.Fatal(err)
+	}
+	if report.Problems() != 0 || report.Documents != 3 {
+		t.Errorf("after repair: %+v", report)
+	}
+}
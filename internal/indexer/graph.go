@@ -0,0 +1,274 @@
+package indexer
+
+import (
+	"cmp"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/simhash"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+// Defaults of SimilarityGraph.
+const (
+	DefaultGraphThreshold = 0.8
+	DefaultGraphNeighbors = 10
+)
+
+// MaxGraphDocuments caps the documents SimilarityGraph compares, since every pair is.
+const MaxGraphDocuments = 5000
+
+// ErrGraphTooLarge is returned by SimilarityGraph when more than MaxGraphDocuments
+// documents match its filter.
+var ErrGraphTooLarge = errors.New("too many documents to compare")
+
+// graphDoc is a document of a similarity graph with its mean embedding.
+type graphDoc struct {
+	summary *models.DocumentSummary
+	space   vector.VectorIndex
+	vec     []float32
+	fp      uint64
+	hasFP   bool
+}
+
+// graphNeighbor is a document similar to another, by index in the compared documents.
+type graphNeighbor struct {
+	doc        int
+	similarity float64
+}
+
+// SimilarityGraph links the documents matching filter whose embeddings, the normalized
+// mean of their chunk vectors, have a cosine similarity of at least threshold. Each
+// document keeps its neighbors most similar ones, and an edge is kept when it is among
+// those of either end. Edges between documents whose content fingerprints differ in at
+// most maxDistance bits are marked as duplicates (none when maxDistance is negative).
+// Documents are only compared within one vector index, and those without stored vectors
+// are skipped. It fails when the vector index cannot return stored vectors, and with
+// ErrGraphTooLarge when more than MaxGraphDocuments documents match.
+func (idx *Indexer) SimilarityGraph(ctx context.Context, filter models.DocumentListFilter, threshold float64, neighbors, maxDistance int) (*models.SimilarityGraph, error) {
+	if threshold <= 0 {
+		threshold = DefaultGraphThreshold
+	}
+	if neighbors <= 0 {
+		neighbors = DefaultGraphNeighbors
+	}
+	summaries, total, err := idx.storage.ListDocumentSummaries(ctx, filter, 0, math.MaxInt)
+	if err != nil {
+		return nil, fmt.Errorf("list documents: %w", err)
+	}
+	if total > MaxGraphDocuments {
+		return nil, fmt.Errorf("%w: %d documents match and at most %d can be compared; narrow them by path or extension", ErrGraphTooLarge, total, MaxGraphDocuments)
+	}
+
+	var docs []*graphDoc
+	for _, s := range summaries {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		_, _, vectorIndex := idx.settingsFor(&models.Document{Metadata: s.Metadata})
+		getter, ok := vectorIndex.(vector.Getter)
+		if !ok {
+			return nil, fmt.Errorf("the %s vector index cannot return stored vectors", vectorIndex.Type())
+		}
+		chunks, err := idx.storage.GetChunksByDocumentID(ctx, s.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chunks of %s: %w", s.ID, err)
+		}
+		var mean []float32
+		for _, ch := range chunks {
+			v, ok := getter.Vector(ch.ID)
+			if !ok || mean != nil && len(v) != len(mean) {
+				continue
+			}
+			if mean == nil {
+				mean = make([]float32, len(v))
+			}
+			for i, x := range v {
+				mean[i] += x
+			}
+		}
+		norm := vector.L2Norm(mean)
+		if norm == 0 {
+			continue
+		}
+		for i := range mean {
+			mean[i] /= float32(norm)
+		}
+		d := &graphDoc{summary: s, space: vectorIndex, vec: mean}
+		fpText, _ := s.Metadata[metaKeyFingerprint].(string)
+		d.fp, d.hasFP = simhash.Parse(fpText)
+		docs = append(docs, d)
+	}
+
+	// The neighbors most similar to each document, most similar first.
+	nearest := make([][]graphNeighbor, len(docs))
+	keep := func(i int, n graphNeighbor) {
+		list := nearest[i]
+		if len(list) == neighbors && list[len(list)-1].similarity >= n.similarity {
+			return
+		}
+		pos, _ := slices.BinarySearchFunc(list, n.similarity, func(e graphNeighbor, s float64) int {
+			return cmp.Compare(s, e.similarity)
+		})
+		list = slices.Insert(list, pos, n)
+		nearest[i] = list[:min(len(list), neighbors)]
+	}
+	for i, a := range docs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for j := i + 1; j < len(docs); j++ {
+			b := docs[j]
+			if a.space != b.space || len(a.vec) != len(b.vec) {
+				continue
+			}
+			if sim := vector.InnerProduct(a.vec, b.vec); sim >= threshold {
+				keep(i, graphNeighbor{j, sim})
+				keep(j, graphNeighbor{i, sim})
+			}
+		}
+	}
+
+	graph := &models.SimilarityGraph{Threshold: threshold, Neighbors: neighbors, Compared: len(docs), Nodes: []*models.GraphNode{}, Edges: []*models.GraphEdge{}}
+	parent := make([]int, len(docs))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	linked := make(map[[2]int]bool)
+	for i, list := range nearest {
+		for _, n := range list {
+			pair := [2]int{min(i, n.doc), max(i, n.doc)}
+			if linked[pair] {
+				continue
+			}
+			linked[pair] = true
+			a, b := docs[pair[0]], docs[pair[1]]
+			if b.summary.ID < a.summary.ID {
+				a, b = b, a
+			}
+			graph.Edges = append(graph.Edges, &models.GraphEdge{
+				Source:     a.summary.ID,
+				Target:     b.summary.ID,
+				Similarity: n.similarity,
+				Duplicate:  maxDistance >= 0 && a.hasFP && b.hasFP && simhash.Distance(a.fp, b.fp) <= maxDistance,
+			})
+			parent[find(pair[0])] = find(pair[1])
+		}
+	}
+
+	members := make(map[int][]int)
+	for i, list := range nearest {
+		if len(list) > 0 {
+			members[find(i)] = append(members[find(i)], i)
+		}
+	}
+	clusters := make([][]int, 0, len(members))
+	for _, m := range members {
+		clusters = append(clusters, m)
+	}
+	firstKey := func(m []int) string { return docs[m[0]].summary.Path + "\x00" + docs[m[0]].summary.ID }
+	slices.SortFunc(clusters, func(a, b []int) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), cmp.Compare(firstKey(a), firstKey(b)))
+	})
+	for c, m := range clusters {
+		for _, i := range m {
+			s := docs[i].summary
+			graph.Nodes = append(graph.Nodes, &models.GraphNode{DocumentID: s.ID, Title: s.Title, Path: s.Path, Cluster: c + 1})
+		}
+	}
+	graph.Clusters = len(clusters)
+	slices.SortStableFunc(graph.Nodes, func(a, b *models.GraphNode) int {
+		return cmp.Or(cmp.Compare(a.Cluster, b.Cluster), cmp.Compare(a.Path, b.Path), cmp.Compare(a.DocumentID, b.DocumentID))
+	})
+	slices.SortFunc(graph.Edges, func(a, b *models.GraphEdge) int {
+		return cmp.Or(cmp.Compare(b.Similarity, a.Similarity), cmp.Compare(a.Source, b.Source), cmp.Compare(a.Target, b.Target))
+	})
+	return graph, nil
+}
+
+// graphML is the GraphML document written by WriteGraphML.
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Nodes       []graphMLItem `xml:"node"`
+	Edges       []graphMLItem `xml:"edge"`
+}
+
+type graphMLItem struct {
+	ID     string        `xml:"id,attr,omitempty"`
+	Source string        `xml:"source,attr,omitempty"`
+	Target string        `xml:"target,attr,omitempty"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteGraphML writes g to w as an undirected GraphML graph, which tools such as Gephi,
+// Cytoscape and yEd open. Nodes carry the title, path and cluster of their document,
+// edges the similarity and duplicate flag.
+func WriteGraphML(w io.Writer, g *models.SimilarityGraph) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "title", For: "node", Name: "title", Type: "string"},
+			{ID: "path", For: "node", Name: "path", Type: "string"},
+			{ID: "cluster", For: "node", Name: "cluster", Type: "int"},
+			{ID: "similarity", For: "edge", Name: "similarity", Type: "double"},
+			{ID: "duplicate", For: "edge", Name: "duplicate", Type: "boolean"},
+		},
+		Graph: graphMLGraph{EdgeDefault: "undirected"},
+	}
+	for _, n := range g.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLItem{ID: n.DocumentID, Data: []graphMLData{
+			{Key: "title", Value: n.Title},
+			{Key: "path", Value: n.Path},
+			{Key: "cluster", Value: strconv.Itoa(n.Cluster)},
+		}})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLItem{Source: e.Source, Target: e.Target, Data: []graphMLData{
+			{Key: "similarity", Value: strconv.FormatFloat(e.Similarity, 'f', 4, 64)},
+			{Key: "duplicate", Value: strconv.FormatBool(e.Duplicate)},
+		}})
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,86 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestSimilarityGraph(t *testing.T) {
+	ctx := context.Background()
+	idx, _ := testIndexerWithStorage(t, t.TempDir())
+	report := "Revenue grew in every region while costs stayed flat this quarter."
+	for _, in := range []*models.DocumentInput{
+		{ID: "a", Title: "Report", Content: report, Metadata: map[string]interface{}{"source_path": "/docs/report.pdf"}},
+		{ID: "b", Title: "Report copy", Content: report, Metadata: map[string]interface{}{"source_path": "/backup/report.pdf"}},
+		{ID: "c", Content: report, Metadata: map[string]interface{}{"source_path": "/drafts/report.txt"}},
+		{ID: "d", Content: "Meeting notes.", Metadata: map[string]interface{}{"source_path": "/notes/monday.txt"}},
+		{ID: "e", Content: "Meeting notes.", Metadata: map[string]interface{}{"source_path": "/notes/copy.txt"}},
+		{ID: "f", Content: "A lone memo about parking.", Metadata: map[string]interface{}{"source_path": "/notes/parking.txt"}},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g, err := idx.SimilarityGraph(ctx, models.DocumentListFilter{}, 0.9999, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Compared != 6 || g.Clusters != 2 || len(g.Nodes) != 5 || len(g.Edges) != 4 {
+		t.Fatalf("graph = %+v", g)
+	}
+	if n := g.Nodes[0]; n.Cluster != 1 || n.Path != "/backup/report.pdf" || n.Title != "Report copy" {
+		t.Errorf("first node = %+v", n)
+	}
+	if n := g.Nodes[4]; n.Cluster != 2 || n.DocumentID != "d" {
+		t.Errorf("last node = %+v", n)
+	}
+	for _, e := range g.Edges {
+		if e.Similarity < 0.9999 || !e.Duplicate || e.Source >= e.Target {
+			t.Errorf("edge = %+v", e)
+		}
+	}
+
+	g, _ = idx.SimilarityGraph(ctx, models.DocumentListFilter{}, 0.9999, 1, -1)
+	if len(g.Edges) != 3 || g.Edges[0].Duplicate {
+		t.Errorf("one neighbor each should keep 3 edges without duplicates, got %+v", g.Edges)
+	}
+	g, _ = idx.SimilarityGraph(ctx, models.DocumentListFilter{Extensions: []string{"pdf"}}, 0.9999, 0, 3)
+	if g.Compared != 2 || len(g.Edges) != 1 {
+		t.Errorf("filter should apply before comparing, got %+v", g)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGraphML(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	var doc graphML
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid GraphML: %v\n%s", err, buf.String())
+	}
+	if len(doc.Graph.Nodes) != 2 || len(doc.Graph.Edges) != 1 || !strings.Contains(buf.String(), `<data key="duplicate">true</data>`) {
+		t.Errorf("GraphML:\n%s", buf.String())
+	}
+}
+
+func TestSimilarityGraph_tooLarge(t *testing.T) {
+	ctx := context.Background()
+	idx, store := testIndexerWithStorage(t, t.TempDir())
+	docs := make([]*models.Document, MaxGraphDocuments+1)
+	for i := range docs {
+		docs[i] = &models.Document{ID: fmt.Sprintf("doc-%d", i)}
+	}
+	if _, err := store.BatchCreateDocuments(ctx, docs, make([][]*models.DocumentChunk, len(docs))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idx.SimilarityGraph(ctx, models.DocumentListFilter{}, 0, 0, 3); !errors.Is(err, ErrGraphTooLarge) {
+		t.Errorf("got %v, want ErrGraphTooLarge", err)
+	}
+}
@@ -0,0 +1,32 @@
+package indexer
+
+import "github.com/hyperjump/sagasu/internal/extract"
+
+// Metadata of the documents indexed from HTML pages (see pageMetadata).
+const (
+	metaKeyPageTitle       = "page_title"
+	metaKeyPageDescription = "page_description"
+	metaKeyPageAuthor      = "page_author"
+	metaKeyPageURL         = "page_url"
+	metaKeyPageLinks       = "page_links"
+)
+
+// pageMetadata returns the metadata recorded for page: its title, description, author,
+// the URL it was saved from, and its links, those it has.
+func pageMetadata(page *extract.Page) map[string]interface{} {
+	m := make(map[string]interface{})
+	for key, v := range map[string]string{
+		metaKeyPageTitle:       page.Title,
+		metaKeyPageDescription: page.Description,
+		metaKeyPageAuthor:      page.Author,
+		metaKeyPageURL:         page.URL,
+	} {
+		if v != "" {
+			m[key] = v
+		}
+	}
+	if len(page.Links) > 0 {
+		m[metaKeyPageLinks] = page.Links
+	}
+	return m
+}
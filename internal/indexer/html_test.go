@@ -0,0 +1,41 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+	"github.com/hyperjump/sagasu/internal/fileid"
+)
+
+func TestIndexFile_html(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	idx.extractor = extract.NewExtractor()
+	ctx := context.Background()
+
+	path := filepath.Join(dir, "moorings.html")
+	if err := os.WriteFile(path, []byte(`<html><head><title>Moorings</title>
+<link rel="canonical" href="https://wiki.example.com/moorings"></head>
+<body><h1>Visitor berths</h1><p>Call the <a href="https://port.example.com/">port office</a>.</p></body></html>`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexFile(ctx, path, []string{".html"}); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := store.GetDocument(ctx, fileid.FileDocID(mustAbs(path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "moorings.html" || doc.Content != "Moorings Visitor berths Call the port office." {
+		t.Errorf("doc = %q %q", doc.Title, doc.Content)
+	}
+	if doc.Metadata[metaKeyPageTitle] != "Moorings" || doc.Metadata[metaKeyPageURL] != "https://wiki.example.com/moorings" {
+		t.Errorf("metadata = %v", doc.Metadata)
+	}
+	if links := metadataStrings(doc.Metadata, metaKeyPageLinks); len(links) != 1 || links[0] != "https://port.example.com/" {
+		t.Errorf("links = %v", links)
+	}
+}
@@ -3,19 +3,29 @@ package indexer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hyperjump/sagasu/internal/config"
 	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/events"
 	"github.com/hyperjump/sagasu/internal/extract"
 	"github.com/hyperjump/sagasu/internal/fileid"
+	"github.com/hyperjump/sagasu/internal/filemeta"
+	"github.com/hyperjump/sagasu/internal/fswalk"
 	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/langdetect"
 	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/pathrules"
+	"github.com/hyperjump/sagasu/internal/simhash"
 	"github.com/hyperjump/sagasu/internal/storage"
 	"github.com/hyperjump/sagasu/internal/vector"
 	"go.uber.org/zap"
@@ -30,7 +40,61 @@ type Indexer struct {
 	chunker      *Chunker
 	config       *config.SearchConfig
 	extractor    *extract.Extractor
-	logger       *zap.Logger // optional; when set, logs debug events
+	stopChunks   *StopChunkFilter // optional; when set, low-information chunks are not embedded
+	logger       *zap.Logger      // optional; when set, logs debug events
+	invalidators []Invalidator    // notified when stored documents change
+	collections  []Collection     // deepest root first; see WithCollections
+	retention    []RetentionPolicy
+	embedQueue   *EmbedQueue             // optional; bounds the chunks being embedded
+	detectLang   bool                    // record each document's language; see WithLanguageDetection
+	noIndex      string                  // marker file of directories not to index; see WithNoIndexMarker
+	rules        pathrules.Rules         // per-directory indexing rules; see WithRules
+	ignore       *pathrules.Ignore       // optional; .gitignore-style files; see WithIgnore
+	maxFileSize  int64                   // larger files are skipped; 0 means no limit
+	skipBinary   bool                    // skip plain-text files that look binary; see WithSkipBinary
+	attachments  bool                    // index the attachments of mail; see WithMailAttachments
+	code         bool                    // index source files as code; see WithCodeMode
+	archives     *extract.ArchiveOptions // optional; index the files in archives; see WithArchives
+	walk         fswalk.Options          // hidden directories and symlinks; see WithWalkOptions
+	events       *events.Bus             // optional; indexing activity is published to it
+
+	rebuild *rebuildState // the journal of a running shadow rebuild; see RebuildShadow
+}
+
+// Invalidator is notified when stored documents change, so caches (e.g. the search
+// engine's document cache) can drop stale entries.
+type Invalidator interface {
+	InvalidateDocument(id string)
+	InvalidateAllDocuments()
+}
+
+// Collection holds the indexing settings for the files under Root, or, when Extensions is
+// set, for the files with those extensions (e.g. an embedding model for code). Nil fields
+// use the indexer's defaults; Embedder and VectorIndex are set together.
+type Collection struct {
+	Name        string
+	Root        string   // empty matches any path
+	Extensions  []string // lower case, without the dot; empty matches any extension
+	Chunker     *Chunker
+	Embedder    embedding.Embedder
+	VectorIndex vector.VectorIndex
+}
+
+// matches reports whether path belongs to the collection.
+func (c *Collection) matches(path string) bool {
+	if c.Root != "" && !pathUnder(path, c.Root) {
+		return false
+	}
+	if len(c.Extensions) == 0 {
+		return c.Root != ""
+	}
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	for _, e := range c.Extensions {
+		if e == ext {
+			return true
+		}
+	}
+	return false
 }
 
 // IndexerOption configures an Indexer.
@@ -41,6 +105,36 @@ func WithLogger(l *zap.Logger) IndexerOption {
 	return func(idx *Indexer) { idx.logger = l }
 }
 
+// WithCollections routes documents whose source path is under a collection's root (or has
+// one of its extensions) to that collection's chunker, embedder, and vector index. Each
+// setting comes from the first collection that has it, deepest root first; collections
+// routing only by extension come last.
+func WithCollections(cols ...Collection) IndexerOption {
+	return func(idx *Indexer) {
+		idx.collections = append(idx.collections, cols...)
+		sort.SliceStable(idx.collections, func(i, j int) bool {
+			return len(idx.collections[i].Root) > len(idx.collections[j].Root)
+		})
+	}
+}
+
+// WithInvalidator registers inv to be notified when documents are indexed, deleted, or rebuilt.
+func WithInvalidator(inv Invalidator) IndexerOption {
+	return func(idx *Indexer) { idx.invalidators = append(idx.invalidators, inv) }
+}
+
+// WithEmbedQueue makes documents wait for room in q before their chunks are embedded.
+// Indexers sharing q share its limit.
+func WithEmbedQueue(q *EmbedQueue) IndexerOption {
+	return func(idx *Indexer) { idx.embedQueue = q }
+}
+
+// WithLanguageDetection records the language detected in each document's content as its
+// "language" metadata, unless the document comes with one.
+func WithLanguageDetection() IndexerOption {
+	return func(idx *Indexer) { idx.detectLang = true }
+}
+
 // NewIndexer creates an indexer with the given dependencies.
 // extractor may be nil; when nil, IndexFile treats all files as plain text.
 // Options (e.g. WithLogger) can be passed for debug logging.
@@ -58,9 +152,13 @@ func NewIndexer(
 		embedder:     embedder,
 		vectorIndex:  vectorIndex,
 		keywordIndex: keywordIndex,
-		chunker:      NewChunker(cfg.ChunkSize, cfg.ChunkOverlap),
+		chunker:      NewChunker(cfg.ChunkSize, cfg.ChunkOverlap).WithStrategy(cfg.ChunkStrategy),
 		config:       cfg,
 		extractor:    extractor,
+		rebuild:      &rebuildState{},
+	}
+	if cfg.StopChunkFilterEnabled {
+		idx.stopChunks = NewStopChunkFilter(cfg.StopChunkMinWordsOrDefault(), cfg.StopChunkMinAlphaRatioOrDefault(), cfg.StopChunkMaxDocFrequencyOrDefault())
 	}
 	for _, opt := range opts {
 		opt(idx)
@@ -70,47 +168,63 @@ func NewIndexer(
 
 // IndexDocument indexes a document: store, chunk, embed, index in vector and keyword.
 func (idx *Indexer) IndexDocument(ctx context.Context, input *models.DocumentInput) error {
+	err := idx.indexDocument(ctx, input)
+	idx.publishIndexed(input.ID, sourcePath(input.Metadata), err)
+	return err
+}
+
+// indexDocument is IndexDocument without publishing the outcome.
+func (idx *Indexer) indexDocument(ctx context.Context, input *models.DocumentInput) error {
 	if input.ID == "" {
 		input.ID = uuid.New().String()
 	}
+	idx.recordIndex(input)
 	doc := &models.Document{
 		ID:       input.ID,
 		Title:    input.Title,
-		Content:  Preprocess(input.Content),
 		Metadata: input.Metadata,
 	}
+	chunker, embedder, vectorIndex := idx.settingsFor(doc)
+	doc.Content = preprocess(input, chunker)
+	setFingerprint(doc)
+	if idx.detectLang {
+		setLanguage(doc)
+	}
 	if err := idx.storage.CreateDocument(ctx, doc); err != nil {
 		return fmt.Errorf("failed to store document: %w", err)
 	}
-	chunks := idx.chunker.Chunk(doc.ID, doc.Content)
-	if len(chunks) == 0 {
-		chunks = []*models.DocumentChunk{{
-			ID:         doc.ID + "_0",
-			DocumentID: doc.ID,
-			Content:    doc.Content,
-			ChunkIndex: 0,
-		}}
-	}
-	texts := make([]string, len(chunks))
-	for i, ch := range chunks {
-		texts[i] = ch.Content
-	}
-	embeddings, err := idx.embedder.EmbedBatch(ctx, texts)
-	if err != nil {
-		return fmt.Errorf("failed to generate embeddings: %w", err)
-	}
-	for i := range chunks {
-		chunks[i].Embedding = embeddings[i]
+	defer idx.invalidate(doc.ID)
+	chunks, semanticChunks := idx.chunksFor(doc, chunker)
+	var embeddings [][]float32
+	if len(semanticChunks) > 0 {
+		release, err := idx.acquireEmbed(ctx, len(semanticChunks))
+		if err != nil {
+			return fmt.Errorf("failed to wait for embedding: %w", err)
+		}
+		defer release()
+		texts := make([]string, len(semanticChunks))
+		for i, ch := range semanticChunks {
+			texts[i] = ch.Content
+		}
+		embeddings, err = embedder.EmbedBatch(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to generate embeddings: %w", err)
+		}
+		for i := range semanticChunks {
+			semanticChunks[i].Embedding = embeddings[i]
+		}
 	}
 	if err := idx.storage.BatchCreateChunks(ctx, chunks); err != nil {
 		return fmt.Errorf("failed to store chunks: %w", err)
 	}
-	chunkIDs := make([]string, len(chunks))
-	for i, ch := range chunks {
-		chunkIDs[i] = ch.ID
-	}
-	if err := idx.vectorIndex.Add(ctx, chunkIDs, embeddings); err != nil {
-		return fmt.Errorf("failed to index vectors: %w", err)
+	if len(semanticChunks) > 0 {
+		chunkIDs := make([]string, len(semanticChunks))
+		for i, ch := range semanticChunks {
+			chunkIDs[i] = ch.ID
+		}
+		if err := vectorIndex.Add(ctx, chunkIDs, embeddings); err != nil {
+			return fmt.Errorf("failed to index vectors: %w", err)
+		}
 	}
 	// Normalize title for keyword search: underscores as spaces so "hyperjump_company_profile_2021.pptx"
 	// is searchable as "hyperjump company profile 2021" (standard analyzer does not split on underscore).
@@ -119,9 +233,148 @@ func (idx *Indexer) IndexDocument(ctx context.Context, input *models.DocumentInp
 	if err := idx.keywordIndex.Index(ctx, doc.ID, &docForKeyword); err != nil {
 		return fmt.Errorf("failed to index keywords: %w", err)
 	}
+	if err := keyword.IndexChunks(ctx, idx.keywordIndex, &docForKeyword, chunks); err != nil {
+		return fmt.Errorf("failed to index keyword chunks: %w", err)
+	}
 	return nil
 }
 
+// acquireEmbed waits for room for n chunks in the embed queue, if any, and returns the
+// function that gives it back.
+func (idx *Indexer) acquireEmbed(ctx context.Context, n int) (func(), error) {
+	if idx.embedQueue == nil {
+		return func() {}, nil
+	}
+	return idx.embedQueue.acquire(ctx, n)
+}
+
+// Saturated reports whether the embed queue is full, so producers of indexing work can
+// hold back. It is always false without an embed queue.
+func (idx *Indexer) Saturated() bool {
+	return idx.embedQueue != nil && idx.embedQueue.Saturated()
+}
+
+// EmbedQueueStats returns the embed queue's figures, or nil without an embed queue.
+func (idx *Indexer) EmbedQueueStats() *EmbedQueueStats {
+	if idx.embedQueue == nil {
+		return nil
+	}
+	stats := idx.embedQueue.Stats()
+	return &stats
+}
+
+// setFingerprint records the SimHash of doc's content in its metadata, which search uses
+// to collapse copies of the same document. Documents without words get none.
+func setFingerprint(doc *models.Document) {
+	fp, ok := simhash.Compute(doc.Content)
+	if !ok {
+		delete(doc.Metadata, metaKeyFingerprint)
+		return
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]interface{})
+	}
+	doc.Metadata[metaKeyFingerprint] = simhash.Format(fp)
+}
+
+// setLanguage records the language of doc's content in its metadata, which routes it to
+// the keyword index of that language's analyzer. Documents with a language keep it; those
+// whose language cannot be told, and source code, get none.
+func setLanguage(doc *models.Document) {
+	if lang, _ := doc.Metadata[metaKeyLanguage].(string); lang != "" {
+		return
+	}
+	if code, _ := doc.Metadata[metaKeyCodeLanguage].(string); code != "" {
+		return
+	}
+	lang := langdetect.Detect(doc.Content)
+	if lang == "" {
+		return
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = make(map[string]interface{})
+	}
+	doc.Metadata[metaKeyLanguage] = lang
+}
+
+// chunksFor splits doc into chunks (at least one) with chunker and returns them with the
+// chunks to embed: all chunks are stored, only informative ones are embedded for semantic
+// search.
+func (idx *Indexer) chunksFor(doc *models.Document, chunker *Chunker) (chunks, semanticChunks []*models.DocumentChunk) {
+	if lang, _ := doc.Metadata[metaKeyCodeLanguage].(string); lang != "" {
+		chunks = chunker.ChunkCode(doc.ID, doc.Content, lang)
+	} else {
+		chunks = chunker.Chunk(doc.ID, doc.Content)
+	}
+	if len(chunks) == 0 {
+		chunks = []*models.DocumentChunk{{
+			ID:         doc.ID + "_0",
+			DocumentID: doc.ID,
+			Content:    doc.Content,
+			ChunkIndex: 0,
+		}}
+	}
+	if strings.TrimSpace(doc.Content) == "" {
+		// Nothing to embed, e.g. an encrypted file indexed by name only.
+		if idx.stopChunks != nil {
+			idx.stopChunks.Forget(doc.ID)
+		}
+		return chunks, nil
+	}
+	semanticChunks = chunks
+	if idx.stopChunks != nil {
+		semanticChunks = idx.stopChunks.Filter(doc.ID, chunks)
+		if idx.logger != nil && len(semanticChunks) < len(chunks) {
+			idx.logger.Debug("indexer skipped stop chunks",
+				zap.String("doc_id", doc.ID),
+				zap.Int("skipped", len(chunks)-len(semanticChunks)),
+				zap.Int("total", len(chunks)))
+		}
+	}
+	return chunks, semanticChunks
+}
+
+// settingsFor returns the chunker, embedder, and vector index for doc: those of the
+// collections matching its source path, or the indexer's defaults.
+func (idx *Indexer) settingsFor(doc *models.Document) (*Chunker, embedding.Embedder, vector.VectorIndex) {
+	var chunker *Chunker
+	var embedder embedding.Embedder
+	var vectorIndex vector.VectorIndex
+	path, _ := doc.Metadata[metaKeySourcePath].(string)
+	if path != "" {
+		for i := range idx.collections {
+			col := &idx.collections[i]
+			if !col.matches(path) {
+				continue
+			}
+			if chunker == nil && col.Chunker != nil {
+				chunker = col.Chunker
+			}
+			if embedder == nil && col.Embedder != nil && col.VectorIndex != nil {
+				embedder, vectorIndex = col.Embedder, col.VectorIndex
+			}
+		}
+	}
+	if chunker == nil {
+		chunker = idx.chunker
+	}
+	if embedder == nil {
+		embedder, vectorIndex = idx.embedder, idx.vectorIndex
+	}
+	return chunker, embedder, vectorIndex
+}
+
+// vectorIndexes returns the default vector index and those of collections with their own.
+func (idx *Indexer) vectorIndexes() []vector.VectorIndex {
+	out := []vector.VectorIndex{idx.vectorIndex}
+	for _, col := range idx.collections {
+		if col.VectorIndex != nil {
+			out = append(out, col.VectorIndex)
+		}
+	}
+	return out
+}
+
 // normalizeTitleForKeywordSearch returns the title with underscores replaced by spaces
 // so that Bleve's standard analyzer can match multi-word queries (e.g. "hyperjump profile")
 // against filenames like "hyperjump_company_profile_2021.pptx".
@@ -133,23 +386,61 @@ const (
 	metaKeySourcePath  = "source_path"
 	metaKeySourceMtime = "source_mtime"
 	metaKeySourceSize  = "source_size"
+	// metaKeyLocked marks files indexed by name only because they are encrypted.
+	metaKeyLocked = "locked"
+	// Platform file metadata: the owner, the creation time (UnixNano, like the mtime) and
+	// user tags such as Finder tags, which retention and ranking read as "tags".
+	metaKeyOwner         = "owner"
+	metaKeySourceCreated = "source_created"
+	metaKeyTags          = "tags"
+	// metaKeyFingerprint is the SimHash of the content (see setFingerprint).
+	metaKeyFingerprint = "content_simhash"
+	// metaKeyLanguage is the ISO 639-1 code of the content's language (see setLanguage).
+	metaKeyLanguage = "language"
 )
 
+// reservedMetadataKeys are the metadata keys the indexer records about the file a document
+// was indexed from and the documents indexed with it. The server trusts them, e.g. to
+// serve source_path, so documents posted through the API may not set them.
+var reservedMetadataKeys = []string{
+	metaKeySourcePath, metaKeySourceMtime, metaKeySourceSize, metaKeySourceCreated,
+	metaKeyOwner, metaKeyLocked, metaKeyFingerprint,
+	metaKeyChildren, metaKeyParentID, metaKeyAttachment, metaKeyArchiveEntry,
+}
+
+// ReservedMetadataKey reports whether key is one only the indexer sets (see
+// reservedMetadataKeys).
+func ReservedMetadataKey(key string) bool {
+	return slices.Contains(reservedMetadataKeys, key)
+}
+
 // IndexFile reads a file from path and indexes it. The document ID is derived from the
 // absolute path so re-indexing updates the same document. If allowedExts is non-nil and
-// non-empty, the file's extension must be in the list (case-insensitive). Returns an error
-// if the path is not a regular file, cannot be read, or indexing fails.
+// non-empty, the file's extension must be in the list (case-insensitive), or in that of its
+// rule (see WithRules); the rule must not exclude the file either. Returns an error if the
+// path is not a regular file, cannot be read, or indexing fails.
 // Skips indexing if the file is already indexed with the same mtime and size (incremental sync).
-func (idx *Indexer) IndexFile(ctx context.Context, path string, allowedExts []string) error {
+// Files over the size limit (see WithMaxFileSize) are skipped, and documents indexed from
+// them earlier removed; so are changed files that look binary (see WithSkipBinary).
+// With an extractor, mail files are indexed with a document per message (see indexMail).
+// With WithCodeMode, source files are indexed as code.
+func (idx *Indexer) IndexFile(ctx context.Context, path string, allowedExts []string) (err error) {
 	if idx.logger != nil {
 		idx.logger.Debug("indexer indexing file", zap.String("path", path))
 	}
+	var docID string
+	indexed := false
+	defer func() {
+		if indexed || err != nil {
+			idx.publishIndexed(docID, path, err)
+		}
+	}()
 	absPath, err := filepath.Abs(path)
 	if err != nil {
 		return fmt.Errorf("absolute path: %w", err)
 	}
 	ext := strings.ToLower(filepath.Ext(absPath))
-	if len(allowedExts) > 0 && !extensionAllowed(ext, allowedExts) {
+	if len(allowedExts) > 0 && !extensionAllowed(ext, idx.rules.Extensions(absPath, allowedExts)) {
 		return fmt.Errorf("extension %q not in allowed list", ext)
 	}
 	info, err := os.Stat(absPath)
@@ -159,7 +450,32 @@ func (idx *Indexer) IndexFile(ctx context.Context, path string, allowedExts []st
 	if !info.Mode().IsRegular() {
 		return fmt.Errorf("not a regular file: %s", absPath)
 	}
-	docID := fileid.FileDocID(absPath)
+	if !idx.rules.AllowFile(absPath, info.Size()) {
+		return fmt.Errorf("excluded by the rules of %s: %s", idx.rules.For(absPath).Root, absPath)
+	}
+	path = absPath
+	docID = fileid.FileDocID(absPath)
+	if dir := optedOutDir(absPath, idx.noIndex); dir != "" {
+		_ = idx.DeleteDocument(ctx, docID)
+		if idx.logger != nil {
+			idx.logger.Debug("indexer skipping file in opted-out directory", zap.String("path", absPath), zap.String("dir", dir))
+		}
+		return nil
+	}
+	if idx.fileExpired(absPath, info.ModTime(), time.Now()) {
+		_ = idx.DeleteDocument(ctx, docID)
+		if idx.logger != nil {
+			idx.logger.Debug("indexer skipping expired file", zap.String("path", absPath))
+		}
+		return nil
+	}
+	if idx.tooLarge(info.Size()) {
+		_ = idx.DeleteDocument(ctx, docID)
+		if idx.logger != nil {
+			idx.logger.Info("indexer skipping large file", zap.String("path", absPath), zap.Int64("size", info.Size()), zap.Int64("max_size", idx.maxFileSize))
+		}
+		return nil
+	}
 	if skip, err := idx.shouldSkipFile(ctx, absPath, docID, info); err != nil {
 		return err
 	} else if skip {
@@ -168,34 +484,114 @@ func (idx *Indexer) IndexFile(ctx context.Context, path string, allowedExts []st
 			docForKeyword := *doc
 			docForKeyword.Title = normalizeTitleForKeywordSearch(doc.Title)
 			_ = idx.keywordIndex.Index(ctx, doc.ID, &docForKeyword)
+			if keyword.IndexesChunks(idx.keywordIndex) {
+				if chunks, err := idx.storage.GetChunksByDocumentID(ctx, doc.ID); err == nil {
+					_ = keyword.IndexChunks(ctx, idx.keywordIndex, &docForKeyword, chunks)
+				}
+			}
 		}
 		if idx.logger != nil {
 			idx.logger.Debug("indexer skipping unchanged file", zap.String("path", absPath))
 		}
 		return nil
 	}
-	text, err := idx.extractContent(absPath)
-	if err != nil {
+	if idx.looksBinary(absPath) {
+		_ = idx.DeleteDocument(ctx, docID)
+		if idx.logger != nil {
+			idx.logger.Debug("indexer skipping binary file", zap.String("path", absPath))
+		}
+		return nil
+	}
+	if extract.IsMail(ext) && idx.extractor != nil && !idx.extractor.ExternalFormat(ext) {
+		if err := idx.indexMail(ctx, absPath, docID, info); err != nil {
+			return err
+		}
+		indexed = true
+		return nil
+	}
+	if extract.IsArchive(absPath) && idx.archives != nil && idx.extractor != nil && !idx.extractor.ExternalFormat(ext) {
+		if err := idx.indexArchive(ctx, absPath, docID, info, allowedExts); err != nil {
+			return err
+		}
+		indexed = true
+		return nil
+	}
+	text, metadata, err := idx.extractContent(absPath)
+	locked := errors.Is(err, extract.ErrLocked)
+	if err != nil && !locked {
 		return fmt.Errorf("extract content: %w", err)
 	}
-	_ = idx.DeleteDocument(ctx, docID)
+	_ = idx.deleteDocument(ctx, docID)
+	input := idx.fileInput(absPath, docID, info, filepath.Base(absPath), text)
+	mergeMetadata(input.Metadata, metadata)
+	if lang := codeLanguageOf(absPath); idx.code && lang != "" {
+		input.Metadata[metaKeyCodeLanguage] = lang
+	}
+	if locked {
+		// Encrypted files without a working password are searchable by name only.
+		input.Metadata[metaKeyLocked] = true
+		if idx.logger != nil {
+			idx.logger.Info("indexer indexing encrypted file by name only", zap.String("path", absPath))
+		}
+	}
+	if err := idx.indexDocument(ctx, input); err != nil {
+		return err
+	}
+	indexed = true
+	if idx.logger != nil {
+		idx.logger.Debug("indexer file indexed", zap.String("path", absPath), zap.String("doc_id", docID))
+	}
+	return nil
+}
+
+// fileInput returns the input for document docID of the file at absPath, with the file's
+// source metadata.
+func (idx *Indexer) fileInput(absPath, docID string, info os.FileInfo, title, content string) *models.DocumentInput {
 	input := &models.DocumentInput{
-		ID:    docID,
-		Title: filepath.Base(absPath),
-		Content: text,
+		ID:      docID,
+		Title:   title,
+		Content: content,
 		Metadata: map[string]interface{}{
 			metaKeySourcePath:  absPath,
 			metaKeySourceMtime: strconv.FormatInt(info.ModTime().UnixNano(), 10),
 			metaKeySourceSize:  strconv.FormatInt(info.Size(), 10),
 		},
 	}
-	if err := idx.IndexDocument(ctx, input); err != nil {
-		return err
+	fm := filemeta.Read(absPath, info)
+	if fm.Owner != "" {
+		input.Metadata[metaKeyOwner] = fm.Owner
 	}
-	if idx.logger != nil {
-		idx.logger.Debug("indexer file indexed", zap.String("path", absPath), zap.String("doc_id", docID))
+	if !fm.Created.IsZero() {
+		input.Metadata[metaKeySourceCreated] = strconv.FormatInt(fm.Created.UnixNano(), 10)
+	}
+	if len(fm.Tags) > 0 {
+		input.Metadata[metaKeyTags] = fm.Tags
+	}
+	return input
+}
+
+// mergeMetadata adds the metadata extracted from a file to that of its document. Keys the
+// document has keep their value, except tags, which are merged with the file's.
+func mergeMetadata(dst, extracted map[string]interface{}) {
+	for k, v := range extracted {
+		switch _, ok := dst[k]; {
+		case !ok:
+			dst[k] = v
+		case k == metaKeyTags:
+			tags := metadataStrings(dst, k)
+			seen := make(map[string]bool, len(tags))
+			for _, t := range tags {
+				seen[t] = true
+			}
+			for _, t := range metadataStrings(extracted, k) {
+				if !seen[t] {
+					seen[t] = true
+					tags = append(tags, t)
+				}
+			}
+			dst[k] = tags
+		}
 	}
-	return nil
 }
 
 // shouldSkipFile returns true if the file is already indexed with the same mtime and size.
@@ -204,19 +600,26 @@ func (idx *Indexer) shouldSkipFile(ctx context.Context, absPath, docID string, i
 	if err != nil {
 		return false, nil
 	}
-	if doc.Metadata == nil {
+	if locked, _ := doc.Metadata[metaKeyLocked].(bool); locked && idx.extractor != nil && idx.extractor.HasPasswords(absPath) {
+		// A password may have been configured since the file was found locked.
 		return false, nil
 	}
+	return indexedCopyCurrent(doc, absPath, info), nil
+}
+
+// indexedCopyCurrent reports whether doc was indexed from absPath with the file's current
+// mtime and size.
+func indexedCopyCurrent(doc *models.Document, absPath string, info os.FileInfo) bool {
+	if doc.Metadata == nil {
+		return false
+	}
 	if doc.Metadata[metaKeySourcePath] != absPath {
-		return false, nil
+		return false
 	}
 	wantMtime := info.ModTime().UnixNano()
 	wantSize := info.Size()
 	// Values are stored as strings to avoid JSON float64 precision loss (UnixNano exceeds 53 bits).
-	if metadataInt64(doc.Metadata, metaKeySourceMtime) != wantMtime || metadataInt64(doc.Metadata, metaKeySourceSize) != wantSize {
-		return false, nil
-	}
-	return true, nil
+	return metadataInt64(doc.Metadata, metaKeySourceMtime) == wantMtime && metadataInt64(doc.Metadata, metaKeySourceSize) == wantSize
 }
 
 func metadataInt64(m map[string]interface{}, key string) int64 {
@@ -240,8 +643,12 @@ func metadataInt64(m map[string]interface{}, key string) int64 {
 }
 
 // IndexDirectory walks dir recursively and indexes each regular file whose extension
-// is in allowedExts (if non-nil and non-empty; otherwise all files). Returns the number
-// of files indexed and the first error encountered, if any.
+// is in allowedExts (if non-nil and non-empty; otherwise all files) and that the rules of
+// its directory allow (see WithRules) and the ignore files in dir and beneath it do not
+// ignore (see WithIgnore). Hidden and symlinked directories are handled as WithWalkOptions
+// sets. Directories opted out with the no-index marker are skipped, and
+// documents indexed from them earlier removed.
+// Returns the number of files indexed and the first error encountered, if any.
 func (idx *Indexer) IndexDirectory(ctx context.Context, dir string, allowedExts []string) (n int, err error) {
 	absDir, err := filepath.Abs(dir)
 	if err != nil {
@@ -254,15 +661,19 @@ func (idx *Indexer) IndexDirectory(ctx context.Context, dir string, allowedExts
 	if !info.IsDir() {
 		return 0, fmt.Errorf("not a directory: %s", absDir)
 	}
-	err = filepath.WalkDir(absDir, func(path string, d os.DirEntry, walkErr error) error {
+	var optedOut []string
+	err = fswalk.Walk(absDir, idx.walk, func(path string, d os.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
 		}
 		if d.IsDir() {
-			return nil
-		}
-		ext := strings.ToLower(filepath.Ext(path))
-		if len(allowedExts) > 0 && !extensionAllowed(ext, allowedExts) {
+			if hasMarker(path, idx.noIndex) {
+				optedOut = append(optedOut, path)
+				return filepath.SkipDir
+			}
+			if path != absDir && idx.skipDir(absDir, path) {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		// Resolve symlinks so we only index regular files
@@ -270,7 +681,7 @@ func (idx *Indexer) IndexDirectory(ctx context.Context, dir string, allowedExts
 		if statErr != nil {
 			return nil
 		}
-		if !finfo.Mode().IsRegular() {
+		if !finfo.Mode().IsRegular() || !idx.fileAllowed(absDir, path, finfo.Size(), allowedExts) {
 			return nil
 		}
 		if indexErr := idx.IndexFile(ctx, path, allowedExts); indexErr != nil {
@@ -279,18 +690,67 @@ func (idx *Indexer) IndexDirectory(ctx context.Context, dir string, allowedExts
 		n++
 		return nil
 	})
+	for _, dir := range optedOut {
+		if err != nil {
+			break
+		}
+		_, err = idx.DeleteUnder(ctx, dir)
+	}
 	return n, err
 }
 
-func (idx *Indexer) extractContent(path string) (string, error) {
-	if idx.extractor != nil {
-		return idx.extractor.Extract(path)
-	}
-	content, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
+// extractContent returns the text of the file at path, and the metadata extracted with it:
+// that of HTML pages (see pageMetadata), EPUB books (see bookMetadata), data files (see
+// dataMetadata), and transcribed audio and video (see transcriptMetadata), and the front
+// matter of Markdown files.
+func (idx *Indexer) extractContent(path string) (string, map[string]interface{}, error) {
+	ext := filepath.Ext(path)
+	switch {
+	case idx.extractor == nil:
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", nil, err
+		}
+		return string(content), nil, nil
+	case idx.extractor.Transcribes(ext):
+		t, err := idx.extractor.ExtractTranscript(path)
+		if err != nil {
+			return "", nil, err
+		}
+		return extract.TranscriptText(t), transcriptMetadata(t), nil
+	case idx.extractor.ExternalFormat(ext):
+		x, err := idx.extractor.ExtractExternal(path)
+		if err != nil {
+			return "", nil, err
+		}
+		return x.Text, x.Metadata, nil
+	case extract.IsHTML(ext):
+		page, err := idx.extractor.ExtractHTML(path)
+		if err != nil {
+			return "", nil, err
+		}
+		return extract.PageText(page), pageMetadata(page), nil
+	case extract.IsEPUB(ext):
+		book, err := idx.extractor.ExtractEPUB(path)
+		if err != nil {
+			return "", nil, err
+		}
+		return extract.BookText(book), bookMetadata(book), nil
+	case extract.IsData(ext):
+		data, err := idx.extractor.ExtractData(path)
+		if err != nil {
+			return "", nil, err
+		}
+		return data.Text, dataMetadata(data), nil
+	case extract.IsMarkdown(ext):
+		md, err := idx.extractor.ExtractMarkdown(path)
+		if err != nil {
+			return "", nil, err
+		}
+		return md.Text, md.Fields, nil
 	}
-	return string(content), nil
+	text, err := idx.extractor.Extract(path)
+	return text, nil, err
 }
 
 func extensionAllowed(ext string, allowed []string) bool {
@@ -303,11 +763,39 @@ func extensionAllowed(ext string, allowed []string) bool {
 	return false
 }
 
-// DeleteDocument removes a document from all indices and storage.
+// DeleteDocument removes a document from all indices and storage, with its child documents
+// (e.g. the attachments of a message; see WithMailAttachments).
 func (idx *Indexer) DeleteDocument(ctx context.Context, id string) error {
+	if idx.events == nil {
+		return idx.deleteDocument(ctx, id)
+	}
+	doc, getErr := idx.storage.GetDocument(ctx, id)
+	err := idx.deleteDocument(ctx, id)
+	switch {
+	case err != nil:
+		idx.events.Publish(events.Event{Type: events.Failed, DocumentID: id, Error: err.Error()})
+	case getErr == nil:
+		idx.events.Publish(events.Event{Type: events.Deleted, DocumentID: id, Path: sourcePath(doc.Metadata)})
+	}
+	return err
+}
+
+// deleteDocument is DeleteDocument without publishing the outcome.
+func (idx *Indexer) deleteDocument(ctx context.Context, id string) error {
 	if idx.logger != nil {
 		idx.logger.Debug("indexer deleting document", zap.String("id", id))
 	}
+	if doc, err := idx.storage.GetDocument(ctx, id); err == nil {
+		for _, child := range metadataStrings(doc.Metadata, metaKeyChildren) {
+			if err := idx.deleteDocument(ctx, child); err != nil {
+				return err
+			}
+		}
+	}
+	idx.recordDelete(id)
+	if idx.stopChunks != nil {
+		idx.stopChunks.Forget(id)
+	}
 	if err := idx.keywordIndex.Delete(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete from keyword index: %w", err)
 	}
@@ -319,8 +807,10 @@ func (idx *Indexer) DeleteDocument(ctx context.Context, id string) error {
 	for i, ch := range chunks {
 		chunkIDs[i] = ch.ID
 	}
-	if err := idx.vectorIndex.Remove(ctx, chunkIDs); err != nil {
-		return fmt.Errorf("failed to delete from vector index: %w", err)
+	for _, vi := range idx.vectorIndexes() {
+		if err := vi.Remove(ctx, chunkIDs); err != nil {
+			return fmt.Errorf("failed to delete from vector index: %w", err)
+		}
 	}
 	if err := idx.storage.DeleteChunksByDocumentID(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete chunks: %w", err)
@@ -328,8 +818,16 @@ func (idx *Indexer) DeleteDocument(ctx context.Context, id string) error {
 	if err := idx.storage.DeleteDocument(ctx, id); err != nil {
 		return fmt.Errorf("failed to delete document: %w", err)
 	}
+	idx.invalidate(id)
 	if idx.logger != nil {
 		idx.logger.Debug("indexer document deleted", zap.String("id", id))
 	}
 	return nil
 }
+
+// invalidate notifies the registered invalidators that document id changed.
+func (idx *Indexer) invalidate(id string) {
+	for _, inv := range idx.invalidators {
+		inv.InvalidateDocument(id)
+	}
+}
@@ -3,14 +3,19 @@ package indexer
 import (
 	"context"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/hyperjump/sagasu/internal/config"
 	"github.com/hyperjump/sagasu/internal/embedding"
 	"github.com/hyperjump/sagasu/internal/extract"
 	"github.com/hyperjump/sagasu/internal/fileid"
+	"github.com/hyperjump/sagasu/internal/filemeta"
 	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/simhash"
 	"github.com/hyperjump/sagasu/internal/storage"
 	"github.com/hyperjump/sagasu/internal/vector"
 	"github.com/xuri/excelize/v2"
@@ -290,6 +295,121 @@ func TestIndexFile_excelWithExtractor(t *testing.T) {
 	}
 }
 
+func TestIndexFile_encrypted(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	idx.extractor = extract.NewExtractor()
+
+	fPath := filepath.Join(dir, "payroll.xlsx")
+	f := excelize.NewFile()
+	f.SetCellValue("Sheet1", "A1", "Confidential salaries")
+	if err := f.SaveAs(fPath, excelize.Options{Password: "hunter2"}); err != nil {
+		t.Fatalf("SaveAs: %v", err)
+	}
+	f.Close()
+
+	ctx := context.Background()
+	if err := idx.IndexFile(ctx, fPath, nil); err != nil {
+		t.Fatalf("IndexFile: %v", err)
+	}
+	docID := fileid.FileDocID(mustAbs(fPath))
+	doc, err := store.GetDocument(ctx, docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "payroll.xlsx" || doc.Content != "" || doc.Metadata[metaKeyLocked] != true || doc.Metadata[metaKeyFingerprint] != nil {
+		t.Errorf("locked doc: title=%q content=%q metadata=%v", doc.Title, doc.Content, doc.Metadata)
+	}
+
+	// With a password configured, the unchanged file is indexed again, fully.
+	idx.extractor.WithPasswords([]extract.PasswordRule{{Pattern: "payroll*", Password: "hunter2"}})
+	if err := idx.IndexFile(ctx, fPath, nil); err != nil {
+		t.Fatalf("IndexFile: %v", err)
+	}
+	doc, err = store.GetDocument(ctx, docID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp, _ := simhash.Compute("Confidential salaries")
+	if doc.Content != "Confidential salaries" || doc.Metadata[metaKeyLocked] != nil || doc.Metadata[metaKeyFingerprint] != simhash.Format(fp) {
+		t.Errorf("unlocked doc: content=%q metadata=%v", doc.Content, doc.Metadata)
+	}
+}
+
+func TestIndexFile_platformMetadata(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	fPath := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(fPath, []byte("some notes"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(fPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filemeta.Read(fPath, info)
+
+	ctx := context.Background()
+	if err := idx.IndexFile(ctx, fPath, nil); err != nil {
+		t.Fatalf("IndexFile: %v", err)
+	}
+	doc, err := store.GetDocument(ctx, fileid.FileDocID(mustAbs(fPath)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner, _ := doc.Metadata[metaKeyOwner].(string); owner != want.Owner {
+		t.Errorf("owner: got %q, want %q", owner, want.Owner)
+	}
+	if created := metadataInt64(doc.Metadata, metaKeySourceCreated); want.Created.IsZero() != (created == 0) ||
+		(created != 0 && created != want.Created.UnixNano()) {
+		t.Errorf("created: got %d, want %v", created, want.Created)
+	}
+}
+
+func TestIndexFile_frontMatter(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	idx.extractor = extract.NewExtractor()
+	fPath := filepath.Join(dir, "harbour.md")
+	if err := os.WriteFile(fPath, []byte("---\ntitle: Harbour notes\ndate: 2024-03-05\ntags: [sailing, tides]\n"+
+		"source_path: /elsewhere\n---\nVisitor berths.\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := idx.IndexFile(ctx, fPath, []string{".md"}); err != nil {
+		t.Fatalf("IndexFile: %v", err)
+	}
+	doc, err := store.GetDocument(ctx, fileid.FileDocID(mustAbs(fPath)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != "Harbour notes Visitor berths." {
+		t.Errorf("content = %q, want the body led by the title", doc.Content)
+	}
+	if doc.Metadata[metaKeyTitle] != "Harbour notes" || doc.Metadata[metaKeyDate] != "2024-03-05" {
+		t.Errorf("metadata = %v", doc.Metadata)
+	}
+	if tags := metadataStrings(doc.Metadata, metaKeyTags); len(tags) != 2 || tags[0] != "sailing" || tags[1] != "tides" {
+		t.Errorf("tags = %v", tags)
+	}
+	if doc.Metadata[metaKeySourcePath] != mustAbs(fPath) {
+		t.Errorf("source_path = %v, want the file's", doc.Metadata[metaKeySourcePath])
+	}
+}
+
+func TestMergeMetadata(t *testing.T) {
+	dst := map[string]interface{}{metaKeyTags: []string{"work"}, metaKeyOwner: "ana"}
+	mergeMetadata(dst, map[string]interface{}{
+		metaKeyTags:  []interface{}{"sailing", "work"},
+		metaKeyOwner: "bo",
+		metaKeyTitle: "Harbour",
+	})
+	want := map[string]interface{}{metaKeyTags: []string{"work", "sailing"}, metaKeyOwner: "ana", metaKeyTitle: "Harbour"}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("got %v, want %v", dst, want)
+	}
+}
+
 func TestIndexDirectory(t *testing.T) {
 	dir := t.TempDir()
 	idx, _ := testIndexerWithStorage(t, dir)
@@ -320,3 +440,248 @@ func TestIndexDirectory(t *testing.T) {
 		t.Errorf("IndexDirectory: indexed %d files, want 3", n)
 	}
 }
+
+func TestIndexDocument_stopChunksStoredButNotEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	minWords, minAlpha, maxDocFreq := 2, 0.5, 0
+	cfg := &config.SearchConfig{
+		ChunkSize: 4, ChunkOverlap: 0, TopKCandidates: 20,
+		DefaultKeywordEnabled: true, DefaultSemanticEnabled: true,
+		StopChunkFilterEnabled: true, StopChunkMinWords: &minWords, StopChunkMinAlphaRatio: &minAlpha,
+		StopChunkMaxDocFrequency: &maxDocFreq,
+	}
+	store, err := storage.NewSQLiteStorage(filepath.Join(dir, "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	embedder := embedding.NewMockEmbedder(4)
+	vecIndex, err := vector.NewMemoryIndex(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kwIndex, err := keyword.NewBleveIndex(filepath.Join(dir, "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = kwIndex.Close() })
+	idx := NewIndexer(store, embedder, vecIndex, kwIndex, cfg, nil)
+
+	ctx := context.Background()
+	// Two chunks of 4 words: one prose, one numbers only.
+	input := &models.DocumentInput{ID: "d1", Content: "alpha beta gamma delta 1 2 3 4"}
+	if err := idx.IndexDocument(ctx, input); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := store.GetChunksByDocumentID(ctx, "d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("stored chunks: got %d, want 2", len(chunks))
+	}
+	if vecIndex.Size() != 1 {
+		t.Errorf("vector index size: got %d, want 1 (numeric chunk filtered)", vecIndex.Size())
+	}
+}
+
+func TestIndexDocument_collections(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	cfg := &config.SearchConfig{ChunkSize: 100, ChunkOverlap: 0}
+	store, err := storage.NewSQLiteStorage(filepath.Join(dir, "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	kwIndex, err := keyword.NewBleveIndex(filepath.Join(dir, "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	codeVecIndex, _ := vector.NewMemoryIndex(8)
+	idx := NewIndexer(store, embedding.NewMockEmbedder(4), vecIndex, kwIndex, cfg, nil,
+		WithCollections(
+			Collection{Name: "notes", Root: "/notes", Chunker: NewChunker(2, 0)},
+			Collection{Name: "code", Root: "/src", Embedder: embedding.NewMockEmbedder(8), VectorIndex: codeVecIndex},
+		))
+
+	content := "one two three four five six"
+	for id, path := range map[string]string{"note": "/notes/a.md", "code": "/src/main.go", "other": "/docs/a.txt"} {
+		input := &models.DocumentInput{ID: id, Content: content, Metadata: map[string]interface{}{"source_path": path}}
+		if err := idx.IndexDocument(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if chunks, _ := store.GetChunksByDocumentID(ctx, "note"); len(chunks) != 3 {
+		t.Errorf("notes chunk size: got %d chunks, want 3", len(chunks))
+	}
+	if chunks, _ := store.GetChunksByDocumentID(ctx, "other"); len(chunks) != 1 {
+		t.Errorf("default chunk size: got %d chunks, want 1", len(chunks))
+	}
+	if codeVecIndex.Size() != 1 || vecIndex.Size() != 4 {
+		t.Errorf("vector routing: code index %d, default index %d; want 1 and 4", codeVecIndex.Size(), vecIndex.Size())
+	}
+	if err := idx.DeleteDocument(ctx, "code"); err != nil {
+		t.Fatal(err)
+	}
+	if codeVecIndex.Size() != 0 {
+		t.Errorf("delete should remove vectors from the collection index, %d left", codeVecIndex.Size())
+	}
+}
+
+func TestIndexDocument_chunkStrategy(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	cfg := &config.SearchConfig{ChunkSize: 100, ChunkOverlap: 0, ChunkStrategy: "paragraph"}
+	store, err := storage.NewSQLiteStorage(filepath.Join(dir, "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	kwIndex, err := keyword.NewBleveIndex(filepath.Join(dir, "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	idx := NewIndexer(store, embedding.NewMockEmbedder(4), vecIndex, kwIndex, cfg, nil,
+		WithCollections(Collection{Name: "notes", Root: "/notes", Chunker: NewChunker(100, 0)}))
+
+	content := "# Install\n\nRun   make.\n\n# Usage\n\nRun sagasu."
+	for id, path := range map[string]string{"doc": "/docs/a.md", "note": "/notes/a.md"} {
+		input := &models.DocumentInput{ID: id, Content: content, Metadata: map[string]interface{}{"source_path": path}}
+		if err := idx.IndexDocument(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doc, err := store.GetDocument(ctx, "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Install\n\nRun make.\n\n# Usage\n\nRun sagasu."; doc.Content != want {
+		t.Errorf("paragraph content: got %q, want %q", doc.Content, want)
+	}
+	if chunks, _ := store.GetChunksByDocumentID(ctx, "doc"); len(chunks) != 2 {
+		t.Errorf("paragraph chunks: got %d, want one per heading", len(chunks))
+	}
+	note, err := store.GetDocument(ctx, "note")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "# Install Run make. # Usage Run sagasu."; note.Content != want {
+		t.Errorf("fixed content: got %q, want %q", note.Content, want)
+	}
+	if chunks, _ := store.GetChunksByDocumentID(ctx, "note"); len(chunks) != 1 {
+		t.Errorf("fixed chunks: got %d, want 1", len(chunks))
+	}
+}
+
+func TestIndexDocument_extensionModels(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	cfg := &config.SearchConfig{ChunkSize: 100, ChunkOverlap: 0}
+	store, err := storage.NewSQLiteStorage(filepath.Join(dir, "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	kwIndex, err := keyword.NewBleveIndex(filepath.Join(dir, "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	codeVecIndex, _ := vector.NewMemoryIndex(8)
+	idx := NewIndexer(store, embedding.NewMockEmbedder(4), vecIndex, kwIndex, cfg, nil,
+		WithCollections(
+			Collection{Name: "code", Extensions: []string{"go", "py"}, Embedder: embedding.NewMockEmbedder(8), VectorIndex: codeVecIndex},
+			Collection{Name: "notes", Root: "/notes", Chunker: NewChunker(2, 0)},
+		))
+
+	content := "one two three four five six"
+	for id, path := range map[string]string{"go": "/src/main.go", "py": "/notes/script.PY", "md": "/notes/a.md", "txt": "/docs/a.txt"} {
+		input := &models.DocumentInput{ID: id, Content: content, Metadata: map[string]interface{}{"source_path": path}}
+		if err := idx.IndexDocument(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// script.PY takes the notes chunker and the code model.
+	if chunks, _ := store.GetChunksByDocumentID(ctx, "py"); len(chunks) != 3 {
+		t.Errorf("notes chunk size for script.PY: got %d chunks, want 3", len(chunks))
+	}
+	if codeVecIndex.Size() != 4 || vecIndex.Size() != 4 {
+		t.Errorf("vector routing: code index %d, default index %d; want 4 and 4", codeVecIndex.Size(), vecIndex.Size())
+	}
+}
+
+func TestIndexer_languageDetection(t *testing.T) {
+	ctx := context.Background()
+	idx, store := testIndexerWithStorage(t, t.TempDir())
+	german := "Der Bericht für das dritte Quartal ist fertig und zeigt, dass die Kosten nicht mit dem Plan übereinstimmen."
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "off", Title: "off", Content: german}); err != nil {
+		t.Fatal(err)
+	}
+	WithLanguageDetection()(idx)
+	inputs := []*models.DocumentInput{
+		{ID: "de", Title: "de", Content: german},
+		{ID: "given", Title: "given", Content: german, Metadata: map[string]interface{}{metaKeyLanguage: "fr"}},
+		{ID: "short", Title: "short", Content: "Q3"},
+	}
+	if err := idx.IndexDocument(ctx, inputs[0]); err != nil {
+		t.Fatal(err)
+	}
+	for _, err := range idx.IndexDocuments(ctx, inputs[1:]) {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for id, want := range map[string]interface{}{"off": nil, "de": "de", "given": "fr", "short": nil} {
+		doc, err := store.GetDocument(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := doc.Metadata[metaKeyLanguage]; got != want {
+			t.Errorf("%s: language = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestIndexFile_external(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	WithSkipBinary()(idx)
+	idx.extractor = extract.NewExtractor().WithExternal(extract.ExternalConfig{
+		Extensions: []string{"dwg"},
+		Command: []string{"sh", "-c", `cat >/dev/null; echo '{"text": "Ground floor plan", ` +
+			`"metadata": {"author": ["Ana"], "source_path": "/elsewhere"}}'`},
+	})
+	ctx := context.Background()
+
+	// Binary content is left to the external extractor.
+	path := filepath.Join(dir, "plan.dwg")
+	if err := os.WriteFile(path, []byte("AC1032\x00\x00\x01"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexFile(ctx, path, []string{"dwg"}); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := store.GetDocument(ctx, fileid.FileDocID(mustAbs(path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != "Ground floor plan" {
+		t.Errorf("content = %q", doc.Content)
+	}
+	if authors := metadataStrings(doc.Metadata, metaKeyAuthor); len(authors) != 1 || authors[0] != "Ana" ||
+		doc.Metadata[metaKeySourcePath] != mustAbs(path) {
+		t.Errorf("metadata = %v", doc.Metadata)
+	}
+}
@@ -0,0 +1,42 @@
+package indexer
+
+import (
+	"path/filepath"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+)
+
+// WithMaxFileSize makes IndexFile skip files larger than max bytes, and remove what was
+// indexed from them earlier; 0 means no limit.
+func WithMaxFileSize(max int64) IndexerOption {
+	return func(idx *Indexer) { idx.maxFileSize = max }
+}
+
+// WithSkipBinary makes IndexFile skip the files read as plain text whose first bytes
+// look binary (see extract.IsBinary), and remove what was indexed from them earlier.
+// Files are checked only when they are new or changed, before extraction; files parsed
+// as a document format (PDF, Office), transcribed media, files of an external extractor or
+// converted, and archives whose files are indexed are not checked.
+func WithSkipBinary() IndexerOption {
+	return func(idx *Indexer) { idx.skipBinary = true }
+}
+
+// tooLarge reports whether a file of size bytes exceeds the limit set by WithMaxFileSize.
+func (idx *Indexer) tooLarge(size int64) bool {
+	return idx.maxFileSize > 0 && size > idx.maxFileSize
+}
+
+// looksBinary reports whether the file at path is skipped as binary (see WithSkipBinary).
+// A file that cannot be read is left to extraction to report.
+func (idx *Indexer) looksBinary(path string) bool {
+	ext := filepath.Ext(path)
+	if !idx.skipBinary || !extract.PlainText(ext) {
+		return false
+	}
+	if idx.extractor != nil && (idx.extractor.Transcribes(ext) || idx.extractor.ExternalFormat(ext) ||
+		idx.extractor.Converts(ext) || idx.archives != nil && extract.IsArchive(path)) {
+		return false
+	}
+	binary, err := extract.LooksBinary(path)
+	return err == nil && binary
+}
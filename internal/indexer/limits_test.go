@@ -0,0 +1,77 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+	"github.com/hyperjump/sagasu/internal/fileid"
+)
+
+func TestIndexFile_limits(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	ctx := context.Background()
+
+	files := map[string]string{
+		filepath.Join(dir, "notes.txt"): "some notes",
+		filepath.Join(dir, "app.log"):   strings.Repeat("GET /index.html 200\n", 10),
+		filepath.Join(dir, "blob.dat"):  "\x7fELF\x02\x01\x01\x00\x00\x00 not text",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Without limits every file is indexed
+	if n, err := idx.IndexDirectory(ctx, dir, []string{".txt", ".log", ".dat"}); err != nil || n != 3 {
+		t.Fatalf("IndexDirectory = %d, %v; want 3", n, err)
+	}
+
+	WithMaxFileSize(100)(idx)
+	WithSkipBinary()(idx)
+	// Binary files are checked when they change
+	blob := filepath.Join(dir, "blob.dat")
+	if err := os.WriteFile(blob, []byte(files[blob]+" again"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for path := range files {
+		if err := idx.IndexFile(ctx, path, nil); err != nil {
+			t.Fatalf("IndexFile(%s): %v", path, err)
+		}
+	}
+	for path, want := range map[string]bool{
+		filepath.Join(dir, "notes.txt"): true,
+		filepath.Join(dir, "app.log"):   false,
+		filepath.Join(dir, "blob.dat"):  false,
+	} {
+		if _, err := store.GetDocument(ctx, fileid.FileDocID(path)); (err == nil) != want {
+			t.Errorf("%s indexed = %v, want %v", path, err == nil, want)
+		}
+	}
+}
+
+func TestLooksBinary_extracted(t *testing.T) {
+	dir := t.TempDir()
+	idx, _ := testIndexerWithStorage(t, dir)
+	WithSkipBinary()(idx)
+	WithArchives(extract.ArchiveOptions{})(idx)
+	idx.extractor = extract.NewExtractor().
+		WithConverter(extract.ConverterConfig{}).
+		WithExternal(extract.ExternalConfig{Extensions: []string{"dwg"}, Command: []string{"dwg2json"}})
+	// Files a program or an archive reader extracts are not sniffed.
+	for name, want := range map[string]bool{
+		"memo.doc": false, "plan.dwg": false, "site.tar.gz": false, "blob.dat": true,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("\xd0\xcf\x11\xe0\x00\x00binary"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if got := idx.looksBinary(path); got != want {
+			t.Errorf("looksBinary(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
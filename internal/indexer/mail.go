@@ -0,0 +1,133 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+// Metadata of the documents indexed from mail files (see indexMail).
+const (
+	metaKeyMailFrom = "mail_from"
+	metaKeyMailTo   = "mail_to"
+	metaKeyMailDate = "mail_date" // RFC 3339
+	// metaKeyChildren lists the IDs of the documents deleted with a document.
+	metaKeyChildren = "children"
+	// metaKeyParentID is the ID of the document a child document was indexed with.
+	metaKeyParentID   = "parent_id"
+	metaKeyAttachment = "attachment"
+)
+
+// WithMailAttachments indexes the text of the attachments of mail files as child
+// documents of their message (see extract.Extractor.ExtractMail).
+func WithMailAttachments() IndexerOption {
+	return func(idx *Indexer) { idx.attachments = true }
+}
+
+// indexMail indexes the mail file at absPath as document docID. A message file is indexed
+// as its message, with its subject as the title; an mbox archive as the list of the
+// subjects of its messages, each message indexed as a child document. Attachments, when
+// indexed, are children of their message. Children are deleted with the file's document.
+func (idx *Indexer) indexMail(ctx context.Context, absPath, docID string, info os.FileInfo) error {
+	mails, err := idx.extractor.ExtractMail(absPath, idx.attachments)
+	if err != nil {
+		return fmt.Errorf("extract content: %w", err)
+	}
+	_ = idx.deleteDocument(ctx, docID)
+	name := filepath.Base(absPath)
+	var parent *models.DocumentInput
+	var children []*models.DocumentInput
+	if strings.ToLower(filepath.Ext(absPath)) != ".mbox" && len(mails) == 1 {
+		parent = idx.fileInput(absPath, docID, info, name, "")
+		children = mailInputs(parent, mails[0], docID+":a")
+	} else {
+		subjects := make([]string, len(mails))
+		for i, m := range mails {
+			subjects[i] = m.Subject
+		}
+		parent = idx.fileInput(absPath, docID, info, name, strings.Join(subjects, "\n"))
+		for i, m := range mails {
+			msg := childInput(parent, fmt.Sprintf("%s:m%d", docID, i), fmt.Sprintf("%s #%d", name, i+1), "")
+			children = append(children, msg)
+			children = append(children, mailInputs(msg, m, msg.ID+"a")...)
+		}
+	}
+	if len(children) > 0 {
+		ids := make([]string, len(children))
+		for i, c := range children {
+			ids[i] = c.ID
+		}
+		parent.Metadata[metaKeyChildren] = ids
+	}
+	for _, input := range append([]*models.DocumentInput{parent}, children...) {
+		if err := idx.indexDocument(ctx, input); err != nil {
+			return err
+		}
+	}
+	if idx.logger != nil {
+		idx.logger.Debug("indexer mail indexed", zap.String("path", absPath), zap.String("doc_id", docID),
+			zap.Int("messages", len(mails)), zap.Int("children", len(children)))
+	}
+	return nil
+}
+
+// mailInputs fills msg with message m and returns the inputs of its attachments, children
+// of msg with IDs starting with prefix.
+func mailInputs(msg *models.DocumentInput, m *extract.Mail, prefix string) []*models.DocumentInput {
+	if m.Subject != "" {
+		msg.Title = m.Subject
+	}
+	text := *m
+	text.Attachments = nil
+	msg.Content = extract.MailText(&text)
+	if m.From != "" {
+		msg.Metadata[metaKeyMailFrom] = m.From
+	}
+	if m.To != "" {
+		msg.Metadata[metaKeyMailTo] = m.To
+	}
+	if !m.Date.IsZero() {
+		msg.Metadata[metaKeyMailDate] = m.Date.Format(time.RFC3339)
+	}
+	inputs := make([]*models.DocumentInput, len(m.Attachments))
+	for i, a := range m.Attachments {
+		inputs[i] = childInput(msg, prefix+strconv.Itoa(i), a.Name, a.Text)
+		inputs[i].Metadata[metaKeyAttachment] = a.Name
+	}
+	return inputs
+}
+
+// childInput returns the input of child document id of parent, with the parent's metadata.
+func childInput(parent *models.DocumentInput, id, title, content string) *models.DocumentInput {
+	metadata := make(map[string]interface{}, len(parent.Metadata)+1)
+	for k, v := range parent.Metadata {
+		metadata[k] = v
+	}
+	metadata[metaKeyParentID] = parent.ID
+	return &models.DocumentInput{ID: id, Title: title, Content: content, Metadata: metadata}
+}
+
+// metadataStrings returns the list of strings under key, as stored or as read back.
+func metadataStrings(m map[string]interface{}, key string) []string {
+	switch v := m[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, x := range v {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+	"github.com/hyperjump/sagasu/internal/fileid"
+)
+
+func TestIndexFile_mail(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	idx.extractor = extract.NewExtractor()
+	WithMailAttachments()(idx)
+	ctx := context.Background()
+
+	eml := filepath.Join(dir, "offsite.eml")
+	if err := os.WriteFile(eml, []byte("From: Carol <carol@example.com>\r\nSubject: Offsite\r\n"+
+		"Date: Tue, 05 Mar 2024 09:30:00 +0000\r\n\r\nBring walking shoes.\r\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mbox := filepath.Join(dir, "archive.mbox")
+	if err := os.WriteFile(mbox, []byte("From alice@example.com Mon Jan  2 15:04:05 2006\n"+
+		"From: alice@example.com\nSubject: Harbour report\n"+
+		"Content-Type: multipart/mixed; boundary=b\n\n"+
+		"--b\nContent-Type: text/plain\n\nTides are high.\n"+
+		"--b\nContent-Type: text/plain\nContent-Disposition: attachment; filename=tides.txt\n\nSpring tide 4.2m\n"+
+		"--b--\n\n"+
+		"From bob@example.com Tue Jan  3 15:04:05 2006\n"+
+		"From: bob@example.com\nSubject: Lighthouse\n\nThe lamp is fixed.\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{eml, mbox} {
+		if err := idx.IndexFile(ctx, path, []string{".eml", ".mbox"}); err != nil {
+			t.Fatalf("IndexFile(%s): %v", path, err)
+		}
+	}
+
+	msg, err := store.GetDocument(ctx, fileid.FileDocID(mustAbs(eml)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Title != "Offsite" || msg.Metadata[metaKeyMailFrom] != "Carol <carol@example.com>" ||
+		msg.Metadata[metaKeyMailDate] != "2024-03-05T09:30:00Z" {
+		t.Errorf("message = %q %v", msg.Title, msg.Metadata)
+	}
+
+	archiveID := fileid.FileDocID(mustAbs(mbox))
+	archive, err := store.GetDocument(ctx, archiveID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archive.Content != "Harbour report Lighthouse" {
+		t.Errorf("archive content = %q", archive.Content)
+	}
+	want := map[string]string{ // id -> title
+		archiveID + ":m0":   "Harbour report",
+		archiveID + ":m0a0": "tides.txt",
+		archiveID + ":m1":   "Lighthouse",
+	}
+	if got := metadataStrings(archive.Metadata, metaKeyChildren); len(got) != len(want) {
+		t.Errorf("children = %v", got)
+	}
+	for id, title := range want {
+		doc, err := store.GetDocument(ctx, id)
+		if err != nil {
+			t.Fatalf("child %s: %v", id, err)
+		}
+		if doc.Title != title || doc.Metadata[metaKeySourcePath] != mustAbs(mbox) {
+			t.Errorf("child %s = %q %v", id, doc.Title, doc.Metadata)
+		}
+	}
+	attachment, _ := store.GetDocument(ctx, archiveID+":m0a0")
+	if attachment.Content != "Spring tide 4.2m" || attachment.Metadata[metaKeyParentID] != archiveID+":m0" {
+		t.Errorf("attachment = %q %v", attachment.Content, attachment.Metadata)
+	}
+
+	if err := idx.DeleteDocument(ctx, archiveID); err != nil {
+		t.Fatal(err)
+	}
+	for id := range want {
+		if _, err := store.GetDocument(ctx, id); err == nil {
+			t.Errorf("child %s kept after its archive was deleted", id)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+// WithNoIndexMarker skips every directory containing a file named marker (e.g. ".noindex"),
+// with everything beneath it: IndexDirectory and reindexing do not descend into it, and
+// IndexFile removes the files under it from the index instead of indexing them. An empty
+// marker disables opting out.
+func WithNoIndexMarker(marker string) IndexerOption {
+	return func(idx *Indexer) { idx.noIndex = marker }
+}
+
+// hasMarker reports whether dir holds a file named marker.
+func hasMarker(dir, marker string) bool {
+	if marker == "" {
+		return false
+	}
+	_, err := os.Lstat(filepath.Join(dir, marker))
+	return err == nil
+}
+
+// optedOutDir returns the nearest directory above path that holds marker, or "" when none does.
+func optedOutDir(path, marker string) string {
+	if marker == "" {
+		return ""
+	}
+	for dir := filepath.Dir(path); ; {
+		if hasMarker(dir, marker) {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// DeleteUnder deletes every document whose source file is in dir or beneath it, e.g. after
+// the directory was opted out of indexing, and returns how many were deleted.
+func (idx *Indexer) DeleteUnder(ctx context.Context, dir string) (int, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return 0, fmt.Errorf("absolute path: %w", err)
+	}
+	var ids []string
+	for offset := 0; ; offset += reindexPageSize {
+		docs, err := idx.storage.ListDocuments(ctx, offset, reindexPageSize)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list documents: %w", err)
+		}
+		for _, doc := range docs {
+			if path, _ := doc.Metadata[metaKeySourcePath].(string); pathUnder(path, absDir) {
+				ids = append(ids, doc.ID)
+			}
+		}
+		if len(docs) < reindexPageSize {
+			break
+		}
+	}
+	removed := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		if err := idx.DeleteDocument(ctx, id); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	if idx.logger != nil && removed > 0 {
+		idx.logger.Info("indexer removed documents under opted-out directory", zap.String("dir", absDir), zap.Int("count", removed))
+	}
+	return removed, nil
+}
@@ -0,0 +1,86 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/fileid"
+)
+
+func TestIndexDirectory_noIndexMarker(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	WithNoIndexMarker(".noindex")(idx)
+	ctx := context.Background()
+
+	docs := filepath.Join(dir, "docs")
+	private := filepath.Join(docs, "private")
+	nested := filepath.Join(private, "taxes")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(docs, "a.txt"):    "public notes",
+		filepath.Join(private, "b.txt"): "private notes",
+		filepath.Join(nested, "c.txt"):  "tax return",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := idx.IndexDirectory(ctx, docs, []string{".txt"}); err != nil || n != 3 {
+		t.Fatalf("IndexDirectory = %d, %v; want 3", n, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(private, ".noindex"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := idx.IndexDirectory(ctx, docs, []string{".txt"}); err != nil || n != 1 {
+		t.Fatalf("IndexDirectory after opting out = %d, %v; want 1", n, err)
+	}
+	for path := range files {
+		_, err := store.GetDocument(ctx, fileid.FileDocID(path))
+		if want := filepath.Dir(path) == docs; (err == nil) != want {
+			t.Errorf("%s indexed = %v, want %v", path, err == nil, want)
+		}
+	}
+
+	// A file beneath the marker is removed rather than indexed, wherever it comes from
+	if err := idx.IndexFile(ctx, filepath.Join(nested, "c.txt"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetDocument(ctx, fileid.FileDocID(filepath.Join(nested, "c.txt"))); err == nil {
+		t.Error("IndexFile should skip a file in an opted-out directory")
+	}
+	found, err := idx.collectSourceFiles([]string{docs}, []string{".txt"})
+	if err != nil || len(found) != 1 {
+		t.Errorf("collectSourceFiles = %v, %v; want only a.txt", found, err)
+	}
+}
+
+func TestDeleteUnder(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	ctx := context.Background()
+	for _, name := range []string{"keep/a.txt", "drop/b.txt", "drop/sub/c.txt", "dropped.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.IndexFile(ctx, path, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := idx.DeleteUnder(ctx, filepath.Join(dir, "drop")); err != nil || n != 2 {
+		t.Errorf("DeleteUnder = %d, %v; want 2", n, err)
+	}
+	if count, _ := store.CountDocuments(ctx); count != 2 {
+		t.Errorf("documents left = %d, want 2", count)
+	}
+}
@@ -23,3 +23,27 @@ func Preprocess(text string) string {
 	}
 	return b.String()
 }
+
+// PreprocessParagraphs normalizes text for indexing like Preprocess but keeps its
+// structure: the white space within lines is collapsed, line breaks become "\n", and runs
+// of blank lines a single blank line between paragraphs.
+func PreprocessParagraphs(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	var b strings.Builder
+	blank := false
+	for _, line := range lines {
+		line = Preprocess(line)
+		if line == "" {
+			blank = b.Len() > 0
+			continue
+		}
+		if blank {
+			b.WriteString("\n\n")
+		} else if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(line)
+		blank = false
+	}
+	return b.String()
+}
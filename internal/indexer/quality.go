@@ -0,0 +1,181 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+// DefaultQualitySample is the number of chunks CheckQuality re-embeds when none is given.
+const DefaultQualitySample = 100
+
+const (
+	qualityRecallK = 10 // a chunk is recalled when its own vector is among this many hits
+	qualityWorst   = 5  // most drifted chunks listed in the report
+	// Above these the report warns that semantic search has degraded.
+	qualityDriftWarn  = 0.05
+	qualityRecallWarn = 0.9
+)
+
+// CheckQuality samples up to sample stored chunks, recomputes their embeddings with the
+// current model (bypassing the persistent embedding cache), and compares them with the
+// vectors in the index. It also compares the document and chunk counts of storage with the
+// keyword and vector indexes. Drift shows up when the model, its settings, or text
+// preprocessing changed without a reindex.
+func (idx *Indexer) CheckQuality(ctx context.Context, sample int) (*models.QualityReport, error) {
+	if sample <= 0 {
+		sample = DefaultQualitySample
+	}
+	report := &models.QualityReport{Warnings: []string{}}
+	var err error
+	if report.Documents, err = idx.storage.CountDocuments(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+	if report.Chunks, err = idx.storage.CountChunks(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count chunks: %w", err)
+	}
+	for _, vi := range idx.vectorIndexes() {
+		report.Vectors += vi.Size()
+	}
+	if report.KeywordDocuments, err = idx.keywordIndex.DocCount(); err != nil {
+		return nil, fmt.Errorf("failed to count keyword documents: %w", err)
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	offsets := rng.Perm(int(report.Documents))
+	if len(offsets) > sample {
+		offsets = offsets[:sample]
+	}
+	perDoc := 1
+	if n := len(offsets); n > 0 && n < sample {
+		perDoc = (sample + n - 1) / n
+	}
+	var driftSum float64
+	var recalled int
+	var drifts []*models.ChunkDrift
+	for _, offset := range offsets {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		docs, err := idx.storage.ListDocuments(ctx, offset, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		if len(docs) == 0 {
+			continue
+		}
+		doc := docs[0]
+		chunks, err := idx.storage.GetChunksByDocumentID(ctx, doc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chunks of %s: %w", doc.ID, err)
+		}
+		var embedded []*models.DocumentChunk
+		for _, ch := range chunks {
+			if idx.stopChunks == nil || !idx.stopChunks.Skipped(ch.Content) {
+				embedded = append(embedded, ch)
+			}
+		}
+		rng.Shuffle(len(embedded), func(i, j int) { embedded[i], embedded[j] = embedded[j], embedded[i] })
+		if len(embedded) > perDoc {
+			embedded = embedded[:perDoc]
+		}
+		_, embedder, vectorIndex := idx.settingsFor(doc)
+		getter, canGet := vectorIndex.(vector.Getter)
+		for _, ch := range embedded {
+			if report.Sampled == sample {
+				break
+			}
+			fresh, err := uncached(embedder).Embed(ctx, ch.Content)
+			if err != nil {
+				return nil, fmt.Errorf("failed to embed chunk %s: %w", ch.ID, err)
+			}
+			report.Sampled++
+			hits, err := vectorIndex.Search(ctx, fresh, qualityRecallK)
+			if err != nil {
+				return nil, fmt.Errorf("failed to search vector index: %w", err)
+			}
+			selfScore, found := 0.0, false
+			for _, h := range hits {
+				if h.ID == ch.ID {
+					selfScore, found = h.Score, true
+					recalled++
+					break
+				}
+			}
+			// Read the stored vector back when the index can; otherwise its search score
+			// against the fresh embedding is the similarity.
+			var similarity float64
+			switch {
+			case canGet:
+				stored, ok := getter.Vector(ch.ID)
+				if !ok {
+					report.MissingVectors++
+					continue
+				}
+				similarity = vector.CosineSimilarity(stored, fresh)
+			case found:
+				similarity = selfScore
+			default:
+				continue
+			}
+			drift := 1 - similarity
+			report.Compared++
+			driftSum += drift
+			if drift > report.MaxDrift {
+				report.MaxDrift = drift
+			}
+			drifts = append(drifts, &models.ChunkDrift{ChunkID: ch.ID, DocumentID: doc.ID, Title: doc.Title, Drift: drift})
+		}
+	}
+	if report.Compared > 0 {
+		report.MeanDrift = driftSum / float64(report.Compared)
+	}
+	if report.Sampled > 0 {
+		report.SelfRecall = float64(recalled) / float64(report.Sampled)
+	}
+	sort.SliceStable(drifts, func(i, j int) bool { return drifts[i].Drift > drifts[j].Drift })
+	if len(drifts) > qualityWorst {
+		drifts = drifts[:qualityWorst]
+	}
+	report.Worst = drifts
+	report.Warnings = qualityWarnings(report, idx.stopChunks != nil)
+	return report, nil
+}
+
+// qualityWarnings describes the problems the numbers in r point to. stopChunks is set when
+// some chunks are deliberately left out of the vector index.
+func qualityWarnings(r *models.QualityReport, stopChunks bool) []string {
+	warnings := []string{}
+	if r.KeywordDocuments != uint64(r.Documents) {
+		warnings = append(warnings, fmt.Sprintf("keyword index holds %d documents but storage has %d; run sagasu reindex", r.KeywordDocuments, r.Documents))
+	}
+	if int64(r.Vectors) > r.Chunks {
+		warnings = append(warnings, fmt.Sprintf("vector index holds %d vectors for %d chunks; stale vectors can surface deleted content", r.Vectors, r.Chunks))
+	} else if !stopChunks && int64(r.Vectors) < r.Chunks {
+		warnings = append(warnings, fmt.Sprintf("vector index holds %d vectors for %d chunks; some chunks cannot be found by semantic search", r.Vectors, r.Chunks))
+	}
+	if r.MissingVectors > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d of %d sampled chunks have no vector", r.MissingVectors, r.Sampled))
+	}
+	if r.Compared > 0 && r.MeanDrift > qualityDriftWarn {
+		warnings = append(warnings, fmt.Sprintf("stored embeddings differ from the current model (mean drift %.3f); the model or preprocessing changed since indexing, run sagasu reindex", r.MeanDrift))
+	}
+	if r.Sampled > 0 && r.SelfRecall < qualityRecallWarn {
+		warnings = append(warnings, fmt.Sprintf("only %.0f%% of sampled chunks find their own vector in the top %d; semantic search quality is degraded", r.SelfRecall*100, qualityRecallK))
+	}
+	return warnings
+}
+
+// uncached returns e without its persistent cache, so embeddings come from the model.
+func uncached(e embedding.Embedder) embedding.Embedder {
+	if p, ok := e.(*embedding.PersistentCachedEmbedder); ok {
+		return p.Embedder
+	}
+	return e
+}
@@ -0,0 +1,84 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// retrainedEmbedder stands in for a changed model: it embeds different text than it is given.
+type retrainedEmbedder struct {
+	*embedding.MockEmbedder
+}
+
+func (e retrainedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	return e.MockEmbedder.Embed(ctx, "v2 "+text)
+}
+
+func TestCheckQuality(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	ctx := context.Background()
+	topics := []string{"apples", "harbour", "quantum", "violin", "glacier", "tax"}
+	for i, topic := range topics {
+		input := &models.DocumentInput{
+			ID:      fmt.Sprintf("doc-%d", i),
+			Title:   topic,
+			Content: strings.Repeat(topic+" notes ", 3),
+		}
+		if err := idx.IndexDocument(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := idx.CheckQuality(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Documents != 6 || report.KeywordDocuments != 6 || int64(report.Vectors) != report.Chunks {
+		t.Errorf("counts = %d docs, %d keyword docs, %d vectors, %d chunks", report.Documents, report.KeywordDocuments, report.Vectors, report.Chunks)
+	}
+	if report.Sampled == 0 || report.Compared != report.Sampled {
+		t.Errorf("sampled %d, compared %d", report.Sampled, report.Compared)
+	}
+	if report.MaxDrift > 1e-6 || report.SelfRecall != 1 || len(report.Warnings) != 0 {
+		t.Errorf("healthy index: max drift %v, self recall %v, warnings %v", report.MaxDrift, report.SelfRecall, report.Warnings)
+	}
+
+	idx.embedder = retrainedEmbedder{embedding.NewMockEmbedder(4)}
+	chunks, err := store.GetChunksByDocumentID(ctx, "doc-0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, ch := range chunks {
+		ids = append(ids, ch.ID)
+	}
+	if err := idx.vectorIndex.Remove(ctx, ids); err != nil {
+		t.Fatal(err)
+	}
+	report, err = idx.CheckQuality(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.MeanDrift <= qualityDriftWarn || len(report.Worst) == 0 {
+		t.Errorf("changed model: mean drift %v, worst %v", report.MeanDrift, report.Worst)
+	}
+	if report.MissingVectors == 0 {
+		t.Error("no missing vectors reported")
+	}
+	want := []string{"vector index holds", "have no vector", "differ from the current model"}
+	for _, w := range want {
+		found := false
+		for _, got := range report.Warnings {
+			found = found || strings.Contains(got, w)
+		}
+		if !found {
+			t.Errorf("no warning containing %q in %v", w, report.Warnings)
+		}
+	}
+}
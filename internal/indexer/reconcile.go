@@ -0,0 +1,80 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+)
+
+// RemoveMissing deletes the stored documents under roots whose source file no longer
+// exists, e.g. files deleted while the server was not running, which syncing the watched
+// directories does not notice. A root that is not a readable directory, such as an
+// unmounted drive, is skipped so its documents are kept. It returns how many documents
+// were deleted.
+func (idx *Indexer) RemoveMissing(ctx context.Context, roots []string) (int, error) {
+	var present []string
+	for _, root := range roots {
+		abs, err := filepath.Abs(root)
+		if err != nil {
+			continue
+		}
+		if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+			if idx.logger != nil {
+				idx.logger.Warn("keeping documents of a missing watch directory", zap.String("root", root))
+			}
+			continue
+		}
+		present = append(present, abs)
+	}
+	if len(present) == 0 {
+		return 0, nil
+	}
+	var missing []string
+	for offset := 0; ; offset += reindexPageSize {
+		docs, err := idx.storage.ListDocuments(ctx, offset, reindexPageSize)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list documents: %w", err)
+		}
+		for _, doc := range docs {
+			path, _ := doc.Metadata[metaKeySourcePath].(string)
+			if !underAny(path, present) {
+				continue
+			}
+			if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+				missing = append(missing, doc.ID)
+			}
+		}
+		if len(docs) < reindexPageSize {
+			break
+		}
+	}
+	removed := 0
+	for _, id := range missing {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		if err := idx.DeleteDocument(ctx, id); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	if idx.logger != nil && removed > 0 {
+		idx.logger.Info("removed documents of files deleted while not watching", zap.Int("count", removed))
+	}
+	return removed, nil
+}
+
+// underAny reports whether path is one of roots or inside one.
+func underAny(path string, roots []string) bool {
+	for _, root := range roots {
+		if pathUnder(path, root) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/fileid"
+)
+
+func TestRemoveMissing(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	ctx := context.Background()
+
+	watched := filepath.Join(dir, "watched")
+	other := filepath.Join(dir, "other")
+	unmounted := filepath.Join(dir, "drive")
+	files := map[string]bool{ // path -> kept
+		filepath.Join(watched, "kept.txt"):     true,
+		filepath.Join(watched, "sub", "x.txt"): false,
+		filepath.Join(other, "y.txt"):          true,
+		filepath.Join(unmounted, "z.txt"):      true,
+	}
+	for path := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("harbour tides"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.IndexFile(ctx, path, []string{".txt"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Deleted while not watching: x.txt, y.txt outside the roots, and the whole drive.
+	for _, path := range []string{filepath.Join(watched, "sub", "x.txt"), filepath.Join(other, "y.txt")} {
+		if err := os.Remove(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.RemoveAll(unmounted); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := idx.RemoveMissing(ctx, []string{watched, unmounted})
+	if err != nil || n != 1 {
+		t.Fatalf("RemoveMissing = %d, %v; want 1", n, err)
+	}
+	for path, kept := range files {
+		if _, err := store.GetDocument(ctx, fileid.FileDocID(path)); (err == nil) != kept {
+			t.Errorf("%s kept = %v, want %v", path, err == nil, kept)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperjump/sagasu/internal/fswalk"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+// reindexPageSize is the number of documents read per page when snapshotting storage.
+const reindexPageSize = 500
+
+// ReindexProgress is called after each item is processed during ReindexAll.
+// done counts processed items (indexed or failed) out of total.
+type ReindexProgress func(done, total int)
+
+// ReindexResult summarizes a ReindexAll run.
+type ReindexResult struct {
+	Total   int `json:"total"`
+	Indexed int `json:"indexed"`
+	Failed  int `json:"failed"`
+}
+
+// ReindexAll drops the storage, keyword index, and vector index, then rebuilds them from
+// the files under dirs whose extension is in allowedExts (all files when empty).
+// Documents that were not indexed from a file (e.g. added via the HTTP API) are re-indexed
+// from their stored content so they survive the rebuild. Use this after changing the
+// keyword mapping or chunking configuration. Per-item failures are counted and logged
+// but do not stop the rebuild; a cancelled ctx does.
+func (idx *Indexer) ReindexAll(ctx context.Context, dirs []string, allowedExts []string, progress ReindexProgress) (*ReindexResult, error) {
+	files, err := idx.collectSourceFiles(dirs, allowedExts)
+	if err != nil {
+		return nil, err
+	}
+	inputs, chunkIDs, err := idx.snapshotForReindex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := idx.dropAll(ctx, chunkIDs); err != nil {
+		return nil, err
+	}
+	return idx.indexAll(ctx, inputs, files, allowedExts, progress)
+}
+
+// indexAll indexes inputs and then files, counting per-item failures in the result.
+// It stops early only when ctx is cancelled.
+func (idx *Indexer) indexAll(ctx context.Context, inputs []*models.DocumentInput, files []string, allowedExts []string, progress ReindexProgress) (*ReindexResult, error) {
+	result := &ReindexResult{Total: len(inputs) + len(files)}
+	done := 0
+	report := func(err error, what string) {
+		done++
+		if err != nil {
+			result.Failed++
+			if idx.logger != nil {
+				idx.logger.Warn("reindex failed", zap.String("item", what), zap.Error(err))
+			}
+		} else {
+			result.Indexed++
+		}
+		if progress != nil {
+			progress(done, result.Total)
+		}
+	}
+	for _, input := range inputs {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		report(idx.IndexDocument(ctx, input), input.ID)
+	}
+	for _, path := range files {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		report(idx.IndexFile(ctx, path, allowedExts), path)
+	}
+	return result, nil
+}
+
+// snapshotForReindex returns inputs for every stored document without a source file,
+// plus the IDs of all stored chunks (used to clear indexes that cannot be reset).
+func (idx *Indexer) snapshotForReindex(ctx context.Context) ([]*models.DocumentInput, []string, error) {
+	var inputs []*models.DocumentInput
+	var chunkIDs []string
+	for offset := 0; ; offset += reindexPageSize {
+		docs, err := idx.storage.ListDocuments(ctx, offset, reindexPageSize)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		for _, doc := range docs {
+			chunks, err := idx.storage.GetChunksByDocumentID(ctx, doc.ID)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to get chunks: %w", err)
+			}
+			for _, ch := range chunks {
+				chunkIDs = append(chunkIDs, ch.ID)
+			}
+			if _, fromFile := doc.Metadata[metaKeySourcePath]; fromFile {
+				continue
+			}
+			inputs = append(inputs, &models.DocumentInput{
+				ID:       doc.ID,
+				Title:    doc.Title,
+				Content:  doc.Content,
+				Metadata: doc.Metadata,
+			})
+		}
+		if len(docs) < reindexPageSize {
+			break
+		}
+	}
+	return inputs, chunkIDs, nil
+}
+
+// dropAll clears the keyword index, vector index, and storage. Indexes implementing
+// Resetter are recreated; otherwise the known document and chunk IDs are deleted.
+func (idx *Indexer) dropAll(ctx context.Context, chunkIDs []string) error {
+	if r, ok := idx.keywordIndex.(keyword.Resetter); ok {
+		if err := r.Reset(); err != nil {
+			return fmt.Errorf("failed to reset keyword index: %w", err)
+		}
+	} else {
+		for offset := 0; ; offset += reindexPageSize {
+			docs, err := idx.storage.ListDocuments(ctx, offset, reindexPageSize)
+			if err != nil {
+				return fmt.Errorf("failed to list documents: %w", err)
+			}
+			for _, doc := range docs {
+				if err := idx.keywordIndex.Delete(ctx, doc.ID); err != nil {
+					return fmt.Errorf("failed to delete from keyword index: %w", err)
+				}
+			}
+			if len(docs) < reindexPageSize {
+				break
+			}
+		}
+	}
+	for _, vi := range idx.vectorIndexes() {
+		if r, ok := vi.(vector.Resetter); ok {
+			if err := r.Reset(); err != nil {
+				return fmt.Errorf("failed to reset vector index: %w", err)
+			}
+		} else if err := vi.Remove(ctx, chunkIDs); err != nil {
+			return fmt.Errorf("failed to delete from vector index: %w", err)
+		}
+	}
+	if err := idx.storage.Reset(ctx); err != nil {
+		return fmt.Errorf("failed to reset storage: %w", err)
+	}
+	for _, inv := range idx.invalidators {
+		inv.InvalidateAllDocuments()
+	}
+	return nil
+}
+
+// collectSourceFiles collects the files to index from every directory in dirs, skipping
+// directories that hold the no-index marker (see WithNoIndexMarker), what the rules
+// exclude (see WithRules), and what the ignore files ignore (see WithIgnore).
+func (idx *Indexer) collectSourceFiles(dirs []string, allowedExts []string) ([]string, error) {
+	var files []string
+	for _, dir := range dirs {
+		if optedOutDir(dir, idx.noIndex) != "" {
+			continue
+		}
+		found, err := idx.collectFiles(dir, allowedExts)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	return files, nil
+}
+
+// collectFiles walks dir recursively and returns every regular file whose extension is
+// in allowedExts (all files when empty) and that is neither excluded nor ignored. Missing
+// directories yield no files.
+func (idx *Indexer) collectFiles(dir string, allowedExts []string) ([]string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("absolute path: %w", err)
+	}
+	if _, err := os.Stat(absDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	var files []string
+	err = fswalk.Walk(absDir, idx.walk, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() {
+			if hasMarker(path, idx.noIndex) || (path != absDir && idx.skipDir(absDir, path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		finfo, statErr := os.Stat(path)
+		if statErr != nil || !finfo.Mode().IsRegular() || !idx.fileAllowed(absDir, path, finfo.Size(), allowedExts) {
+			return nil
+		}
+		files = append(files, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", absDir, err)
+	}
+	return files, nil
+}
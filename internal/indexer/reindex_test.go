@@ -0,0 +1,70 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/fileid"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestReindexAll(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	ctx := context.Background()
+
+	docs := filepath.Join(dir, "docs")
+	if err := os.Mkdir(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	kept := filepath.Join(docs, "kept.txt")
+	gone := filepath.Join(docs, "gone.txt")
+	for _, p := range []string{kept, gone} {
+		if err := os.WriteFile(p, []byte("file body"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.IndexFile(ctx, p, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "api-doc", Content: "added over http"}); err != nil {
+		t.Fatal(err)
+	}
+	// A file removed while the server was down should not survive the rebuild.
+	if err := os.Remove(gone); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls, lastTotal int
+	result, err := idx.ReindexAll(ctx, []string{docs}, []string{".txt"}, func(done, total int) {
+		calls++
+		lastTotal = total
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 2 || result.Indexed != 2 || result.Failed != 0 {
+		t.Errorf("result: got %+v, want total=2 indexed=2", result)
+	}
+	if calls != 2 || lastTotal != 2 {
+		t.Errorf("progress: %d calls, last total %d", calls, lastTotal)
+	}
+	if _, err := store.GetDocument(ctx, fileid.FileDocID(kept)); err != nil {
+		t.Errorf("kept file should be indexed: %v", err)
+	}
+	if _, err := store.GetDocument(ctx, fileid.FileDocID(gone)); err == nil {
+		t.Error("removed file should not be re-indexed")
+	}
+	if doc, err := store.GetDocument(ctx, "api-doc"); err != nil || doc.Content != "added over http" {
+		t.Errorf("API document should be re-indexed from stored content: %v", err)
+	}
+	n, err := store.CountDocuments(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("document count: got %d, want 2", n)
+	}
+}
@@ -0,0 +1,129 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+// RetentionPolicy expires documents that have not been modified within MaxAge. It applies
+// to documents under Root (when set) that carry Tag (when set). The modification time is
+// the source file's mtime, or the last index time for documents without a file.
+type RetentionPolicy struct {
+	Root   string
+	Tag    string
+	MaxAge time.Duration
+}
+
+// WithRetention sets the policies enforced by ApplyRetention. IndexFile also skips files
+// that a policy without a tag has already expired, so syncing does not bring them back.
+func WithRetention(policies ...RetentionPolicy) IndexerOption {
+	return func(idx *Indexer) { idx.retention = append(idx.retention, policies...) }
+}
+
+// ApplyRetention deletes every stored document expired by a retention policy at now and
+// returns how many were deleted.
+func (idx *Indexer) ApplyRetention(ctx context.Context, now time.Time) (int, error) {
+	if len(idx.retention) == 0 {
+		return 0, nil
+	}
+	var expired []string
+	for offset := 0; ; offset += reindexPageSize {
+		docs, err := idx.storage.ListDocuments(ctx, offset, reindexPageSize)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list documents: %w", err)
+		}
+		for _, doc := range docs {
+			if idx.documentExpired(doc, now) {
+				expired = append(expired, doc.ID)
+			}
+		}
+		if len(docs) < reindexPageSize {
+			break
+		}
+	}
+	removed := 0
+	for _, id := range expired {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		if err := idx.DeleteDocument(ctx, id); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	if idx.logger != nil && removed > 0 {
+		idx.logger.Info("retention removed expired documents", zap.Int("count", removed))
+	}
+	return removed, nil
+}
+
+// documentExpired reports whether any retention policy expires doc at now.
+func (idx *Indexer) documentExpired(doc *models.Document, now time.Time) bool {
+	path, _ := doc.Metadata[metaKeySourcePath].(string)
+	modified := doc.UpdatedAt
+	if ns := metadataInt64(doc.Metadata, metaKeySourceMtime); ns != 0 {
+		modified = time.Unix(0, ns)
+	}
+	for _, p := range idx.retention {
+		if p.Root != "" && !pathUnder(path, p.Root) {
+			continue
+		}
+		if p.Tag != "" && !hasTag(doc.Metadata, p.Tag) {
+			continue
+		}
+		if now.Sub(modified) > p.MaxAge {
+			return true
+		}
+	}
+	return false
+}
+
+// fileExpired reports whether a retention policy without a tag expires the file at path
+// with the given mtime.
+func (idx *Indexer) fileExpired(path string, mtime, now time.Time) bool {
+	for _, p := range idx.retention {
+		if p.Tag == "" && pathUnder(path, p.Root) && now.Sub(mtime) > p.MaxAge {
+			return true
+		}
+	}
+	return false
+}
+
+// pathUnder reports whether path is root or inside it.
+func pathUnder(path, root string) bool {
+	if path == "" {
+		return false
+	}
+	rel, err := filepath.Rel(root, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// hasTag reports whether the "tags" metadata of a document contains tag (case-insensitive).
+// Tags may be a list or a comma-separated string.
+func hasTag(metadata map[string]interface{}, tag string) bool {
+	var tags []string
+	switch v := metadata["tags"].(type) {
+	case string:
+		tags = strings.Split(v, ",")
+	case []interface{}:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+	case []string:
+		tags = v
+	}
+	for _, t := range tags {
+		if strings.EqualFold(strings.TrimSpace(t), tag) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/fileid"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestApplyRetention(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(filepath.Join(dir, "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	kwIndex, err := keyword.NewBleveIndex(filepath.Join(dir, "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	downloads := filepath.Join(dir, "downloads")
+	if err := os.MkdirAll(downloads, 0755); err != nil {
+		t.Fatal(err)
+	}
+	idx := NewIndexer(store, embedding.NewMockEmbedder(4), vecIndex, kwIndex, &config.SearchConfig{ChunkSize: 100}, nil,
+		WithRetention(
+			RetentionPolicy{Root: downloads, MaxAge: 180 * 24 * time.Hour},
+			RetentionPolicy{Tag: "draft", MaxAge: time.Hour},
+		))
+
+	oldFile, newFile := filepath.Join(downloads, "old.txt"), filepath.Join(downloads, "new.txt")
+	for _, f := range []string{oldFile, newFile} {
+		if err := os.WriteFile(f, []byte("content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.IndexFile(ctx, f, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, in := range []*models.DocumentInput{
+		{ID: "draft", Content: "draft notes", Metadata: map[string]interface{}{"tags": []interface{}{"Draft"}}},
+		{ID: "final", Content: "final notes", Metadata: map[string]interface{}{"tags": "final, reviewed"}},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-200 * 24 * time.Hour)
+	if err := os.Chtimes(oldFile, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexFile(ctx, oldFile, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.GetDocument(ctx, fileid.FileDocID(oldFile)); err == nil {
+		t.Error("IndexFile should drop a file a retention policy has expired")
+	}
+
+	removed, err := idx.ApplyRetention(ctx, time.Now().Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Errorf("removed %d documents, want 1 (the draft)", removed)
+	}
+	if n, _ := store.CountDocuments(ctx); n != 2 {
+		t.Errorf("%d documents left, want 2", n)
+	}
+	if _, err := store.GetDocument(ctx, "draft"); err == nil {
+		t.Error("expired draft should be removed")
+	}
+}
@@ -0,0 +1,46 @@
+package indexer
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/fswalk"
+	"github.com/hyperjump/sagasu/internal/pathrules"
+)
+
+// WithRules applies per-directory indexing rules (see pathrules.Rule): under a rule's
+// root, its extensions replace the allowed extensions passed to IndexFile and
+// IndexDirectory, and the files it excludes are not indexed. IndexDirectory and
+// reindexing do not descend into the directories it excludes.
+func WithRules(rules pathrules.Rules) IndexerOption {
+	return func(idx *Indexer) { idx.rules = rules }
+}
+
+// WithIgnore makes IndexDirectory and reindexing skip the files and directories that the
+// .gitignore-style files in the walked directory and beneath it ignore.
+func WithIgnore(ig *pathrules.Ignore) IndexerOption {
+	return func(idx *Indexer) { idx.ignore = ig }
+}
+
+// WithWalkOptions sets how IndexDirectory and reindexing walk directories: whether they
+// skip hidden directories and follow symlinked ones (see fswalk.Options).
+func WithWalkOptions(opts fswalk.Options) IndexerOption {
+	return func(idx *Indexer) { idx.walk = opts }
+}
+
+// skipDir reports whether walking root should not descend into the directory at path,
+// one of its subdirectories.
+func (idx *Indexer) skipDir(root, path string) bool {
+	return idx.rules.SkipDir(path) || idx.ignore.Match(root, path, true)
+}
+
+// fileAllowed reports whether the file at path, of size bytes, is picked up when
+// walking root: its extension is in allowedExts (all files when empty) or those of its
+// rule, its rule allows it, and it is not ignored.
+func (idx *Indexer) fileAllowed(root, path string, size int64, allowedExts []string) bool {
+	exts := idx.rules.Extensions(path, allowedExts)
+	if len(exts) > 0 && !extensionAllowed(strings.ToLower(filepath.Ext(path)), exts) {
+		return false
+	}
+	return idx.rules.AllowFile(path, size) && !idx.ignore.Match(root, path, false)
+}
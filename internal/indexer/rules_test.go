@@ -0,0 +1,156 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/fileid"
+	"github.com/hyperjump/sagasu/internal/fswalk"
+	"github.com/hyperjump/sagasu/internal/pathrules"
+)
+
+func TestIndexDirectory_rules(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	docs := filepath.Join(dir, "docs")
+	code := filepath.Join(docs, "code")
+	no := false
+	rules, err := pathrules.New([]pathrules.Rule{
+		{Root: docs, Exclude: []string{"**/node_modules/**"}, MaxFileSize: 100},
+		{Root: code, Recursive: &no, Extensions: []string{".go"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithRules(rules)(idx)
+	ctx := context.Background()
+
+	files := map[string]bool{
+		filepath.Join(docs, "a.txt"):                        true,
+		filepath.Join(docs, "big.txt"):                      false,
+		filepath.Join(docs, "web", "node_modules", "b.txt"): false,
+		filepath.Join(code, "main.go"):                      true,
+		filepath.Join(code, "notes.txt"):                    false,
+		filepath.Join(code, "sub", "util.go"):               false,
+	}
+	for path := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		content := "some notes"
+		if filepath.Base(path) == "big.txt" {
+			content = strings.Repeat("notes ", 100)
+		}
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := idx.IndexDirectory(ctx, docs, []string{".txt"}); err != nil || n != 2 {
+		t.Fatalf("IndexDirectory = %d, %v; want 2", n, err)
+	}
+	for path, want := range files {
+		if _, err := store.GetDocument(ctx, fileid.FileDocID(path)); (err == nil) != want {
+			t.Errorf("%s indexed = %v, want %v", path, err == nil, want)
+		}
+	}
+
+	if err := idx.IndexFile(ctx, filepath.Join(docs, "big.txt"), []string{".txt"}); err == nil {
+		t.Error("IndexFile should reject a file the rules exclude")
+	}
+	found, err := idx.collectSourceFiles([]string{docs}, []string{".txt"})
+	if err != nil || len(found) != 2 {
+		t.Errorf("collectSourceFiles = %v, %v; want a.txt and main.go", found, err)
+	}
+}
+
+func TestIndexDirectory_ignore(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	docs := filepath.Join(dir, "docs")
+	ig, err := pathrules.NewIgnore([]string{".gitignore"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	WithIgnore(ig)(idx)
+	ctx := context.Background()
+
+	files := map[string]bool{
+		filepath.Join(docs, "a.txt"):                 true,
+		filepath.Join(docs, "build", "out.txt"):      false,
+		filepath.Join(docs, "web", "cache", "c.txt"): false,
+		filepath.Join(docs, "web", "notes.txt"):      true,
+		filepath.Join(docs, "web", "debug.txt"):      false,
+		filepath.Join(docs, "web", "build", "b.txt"): false,
+	}
+	for path := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("some notes"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(docs, ".gitignore"), []byte("build/\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(docs, "web", ".gitignore"), []byte("cache\ndebug.*\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := idx.IndexDirectory(ctx, docs, []string{".txt"}); err != nil || n != 2 {
+		t.Fatalf("IndexDirectory = %d, %v; want 2", n, err)
+	}
+	for path, want := range files {
+		if _, err := store.GetDocument(ctx, fileid.FileDocID(path)); (err == nil) != want {
+			t.Errorf("%s indexed = %v, want %v", path, err == nil, want)
+		}
+	}
+	found, err := idx.collectSourceFiles([]string{docs}, []string{".txt"})
+	if err != nil || len(found) != 2 {
+		t.Errorf("collectSourceFiles = %v, %v; want a.txt and web/notes.txt", found, err)
+	}
+}
+
+func TestIndexDirectory_walkOptions(t *testing.T) {
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	docs := filepath.Join(dir, "docs")
+	shared := filepath.Join(dir, "shared")
+	ctx := context.Background()
+
+	files := map[string]bool{
+		filepath.Join(docs, "a.txt"):                 true,
+		filepath.Join(docs, ".cache", "b.txt"):       false,
+		filepath.Join(docs, "shared", "c.txt"):       true,  // through the symlink
+		filepath.Join(docs, "shared", "up", "a.txt"): false, // loop back to docs
+	}
+	for _, path := range []string{filepath.Join(docs, "a.txt"), filepath.Join(docs, ".cache", "b.txt"), filepath.Join(shared, "c.txt")} {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("some notes"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(shared, filepath.Join(docs, "shared")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(docs, filepath.Join(shared, "up")); err != nil {
+		t.Fatal(err)
+	}
+	WithWalkOptions(fswalk.Options{SkipHidden: true, FollowSymlinks: true})(idx)
+	if n, err := idx.IndexDirectory(ctx, docs, []string{".txt"}); err != nil || n != 2 {
+		t.Fatalf("IndexDirectory = %d, %v; want 2", n, err)
+	}
+	for path, want := range files {
+		if _, err := store.GetDocument(ctx, fileid.FileDocID(path)); (err == nil) != want {
+			t.Errorf("%s indexed = %v, want %v", path, err == nil, want)
+		}
+	}
+	found, err := idx.collectSourceFiles([]string{docs}, []string{".txt"})
+	if err != nil || len(found) != 2 {
+		t.Errorf("collectSourceFiles = %v, %v; want a.txt and shared/c.txt", found, err)
+	}
+}
@@ -0,0 +1,88 @@
+package indexer
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// ScanDirectory walks dir as IndexDirectory would, without indexing anything, and
+// reports the number and total size of its regular files by extension, and which of
+// them allowedExts (all files when empty) would let through. Directories holding marker
+// are skipped and listed. With listSkipped, the report also lists the skipped files.
+func ScanDirectory(ctx context.Context, dir string, allowedExts []string, marker string, listSkipped bool) (*models.ScanReport, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("absolute path: %w", err)
+	}
+	info, err := os.Stat(absDir)
+	if err != nil {
+		return nil, fmt.Errorf("stat directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("not a directory: %s", absDir)
+	}
+	report := &models.ScanReport{Root: absDir, Extensions: allowedExts, ByExtension: []*models.ExtensionStats{}}
+	byExt := make(map[string]*models.ExtensionStats)
+	err = filepath.WalkDir(absDir, func(path string, d os.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if hasMarker(path, marker) {
+				report.OptedOut = append(report.OptedOut, path)
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Symlinks count as the regular files they point to, as when indexing
+		finfo, statErr := os.Stat(path)
+		if statErr != nil || !finfo.Mode().IsRegular() {
+			return nil
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		stats := byExt[ext]
+		if stats == nil {
+			stats = &models.ExtensionStats{
+				Extension:   ext,
+				Included:    len(allowedExts) == 0 || extensionAllowed(ext, allowedExts),
+				KnownFormat: extract.KnownFormat(ext),
+			}
+			byExt[ext] = stats
+			report.ByExtension = append(report.ByExtension, stats)
+		}
+		size := finfo.Size()
+		stats.Files++
+		stats.Bytes += size
+		report.Files++
+		report.Bytes += size
+		if stats.Included {
+			report.IncludedFiles++
+			report.IncludedBytes += size
+			return nil
+		}
+		report.SkippedFiles++
+		report.SkippedBytes += size
+		if listSkipped {
+			report.Skipped = append(report.Skipped, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(report.ByExtension, func(a, b *models.ExtensionStats) int {
+		return cmp.Or(cmp.Compare(b.Bytes, a.Bytes), cmp.Compare(b.Files, a.Files), cmp.Compare(a.Extension, b.Extension))
+	})
+	return report, nil
+}
@@ -0,0 +1,66 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScanDirectory(t *testing.T) {
+	dir := t.TempDir()
+	private := filepath.Join(dir, "private")
+	if err := os.MkdirAll(private, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]int{
+		"a.txt": 10, "b.TXT": 5, "report.pdf": 100, "photo.jpg": 300, "notes.html": 20, "Makefile": 1,
+		"private/.noindex": 0, "private/secret.txt": 50,
+	}
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(strings.Repeat("x", size)), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := ScanDirectory(context.Background(), dir, []string{"txt", ".pdf"}, ".noindex", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 6 || report.Bytes != 436 || report.IncludedFiles != 3 || report.IncludedBytes != 115 ||
+		report.SkippedFiles != 3 || report.SkippedBytes != 321 {
+		t.Errorf("totals: got %+v", report)
+	}
+	if len(report.OptedOut) != 1 || report.OptedOut[0] != private {
+		t.Errorf("opted out: got %v, want [%s]", report.OptedOut, private)
+	}
+	if len(report.Skipped) != 3 {
+		t.Errorf("skipped: got %v", report.Skipped)
+	}
+	var exts []string
+	for _, e := range report.ByExtension {
+		exts = append(exts, e.Extension)
+	}
+	if got := strings.Join(exts, " "); got != ".jpg .pdf .html .txt " {
+		t.Errorf("extensions by size: got %q", got)
+	}
+	txt, jpg := report.ByExtension[3], report.ByExtension[0]
+	if txt.Files != 2 || txt.Bytes != 15 || !txt.Included || !txt.KnownFormat {
+		t.Errorf(".txt: got %+v", txt)
+	}
+	if jpg.Included || jpg.KnownFormat {
+		t.Errorf(".jpg: got %+v", jpg)
+	}
+
+	// Without configured extensions every file is included
+	if report, err = ScanDirectory(context.Background(), dir, nil, "", false); err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 8 || report.SkippedFiles != 0 || report.Skipped != nil {
+		t.Errorf("all extensions: got %+v", report)
+	}
+	if _, err := ScanDirectory(context.Background(), filepath.Join(dir, "a.txt"), nil, "", false); err == nil {
+		t.Error("expected an error scanning a file")
+	}
+}
@@ -0,0 +1,270 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+// ErrRebuildRunning is returned by RebuildShadow when another shadow rebuild is in progress.
+var ErrRebuildRunning = errors.New("shadow rebuild already running")
+
+// ErrShadowUnsupported is returned by RebuildShadow when collections have vector indexes of
+// their own, which a Generation cannot hold.
+var ErrShadowUnsupported = errors.New("shadow rebuild does not support collections with their own embedding model")
+
+// Generation is a complete set of stores: the one serving queries, or a new one being
+// built next to it by RebuildShadow.
+type Generation struct {
+	Storage      storage.Storage
+	VectorIndex  vector.VectorIndex
+	KeywordIndex keyword.KeywordIndex
+}
+
+// ShadowTarget creates the stores for a shadow rebuild and swaps them in once built.
+type ShadowTarget interface {
+	// Create returns an empty generation stored apart from the live one.
+	Create() (*Generation, error)
+	// Swap replaces the live stores with gen. On success gen's stores are owned by the
+	// live stores and must not be used directly.
+	Swap(gen *Generation) error
+	// Discard closes gen and removes its files after a failed or cancelled rebuild.
+	Discard(gen *Generation)
+}
+
+// journalEntry is a write made to the live stores during a shadow rebuild.
+type journalEntry struct {
+	input    *models.DocumentInput // document to index; nil for a delete
+	deleteID string
+}
+
+// rebuildJournal collects the writes made while a shadow rebuild runs.
+type rebuildJournal struct {
+	entries []journalEntry
+}
+
+// rebuildState guards an indexer's journal. It is held by pointer so that copies of the
+// indexer's configuration (see withGeneration) do not copy the lock.
+type rebuildState struct {
+	mu      sync.Mutex
+	journal *rebuildJournal // non-nil while a shadow rebuild runs
+}
+
+// RebuildShadow rebuilds every store from the files under dirs (and the stored documents
+// that have no source file, as ReindexAll does) into a new generation created by target,
+// while the current stores keep serving queries and accepting writes. Writes made during
+// the rebuild are recorded and replayed onto the new generation, which target then swaps
+// in. Use this instead of ReindexAll for relevance-affecting migrations (a new analyzer,
+// chunking or embedding configuration) so search stays online. On failure or cancellation
+// the new generation is discarded and the current stores are left untouched.
+func (idx *Indexer) RebuildShadow(ctx context.Context, dirs []string, allowedExts []string, target ShadowTarget, progress ReindexProgress) (*ReindexResult, error) {
+	if len(idx.vectorIndexes()) > 1 {
+		return nil, ErrShadowUnsupported
+	}
+	files, err := idx.collectSourceFiles(dirs, allowedExts)
+	if err != nil {
+		return nil, err
+	}
+	if !idx.startJournal() {
+		return nil, ErrRebuildRunning
+	}
+	defer idx.stopJournal()
+	inputs, _, err := idx.snapshotForReindex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gen, err := target.Create()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shadow stores: %w", err)
+	}
+	shadow := idx.withGeneration(gen)
+	result, err := shadow.indexAll(ctx, inputs, files, allowedExts, progress)
+	if err != nil {
+		target.Discard(gen)
+		return result, err
+	}
+	shadow.replay(ctx, idx.drainJournal())
+	if err := copyPins(ctx, idx.storage, gen.Storage); err != nil {
+		target.Discard(gen)
+		return result, err
+	}
+	if err := copyAuditLog(ctx, idx.storage, gen.Storage); err != nil {
+		target.Discard(gen)
+		return result, err
+	}
+	if err := copySearchAnalytics(ctx, idx.storage, gen.Storage); err != nil {
+		target.Discard(gen)
+		return result, err
+	}
+	if err := copySnapshots(ctx, idx.storage, gen.Storage); err != nil {
+		target.Discard(gen)
+		return result, err
+	}
+	if err := target.Swap(gen); err != nil {
+		return result, fmt.Errorf("failed to swap in rebuilt stores: %w", err)
+	}
+	// Writes that raced with the swap may have reached only the old stores; the live
+	// stores are now the new generation, so replay them there.
+	idx.replay(ctx, idx.stopJournal())
+	for _, inv := range idx.invalidators {
+		inv.InvalidateAllDocuments()
+	}
+	return result, nil
+}
+
+// copyPins copies the pins of from into to, which replaces it.
+func copyPins(ctx context.Context, from, to storage.Storage) error {
+	pins, err := from.ListPins(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read pins: %w", err)
+	}
+	for _, pin := range pins {
+		if err := to.CreatePin(ctx, pin); err != nil {
+			return fmt.Errorf("failed to copy pin %s: %w", pin.ID, err)
+		}
+	}
+	return nil
+}
+
+// copyAuditLog copies the audit log of from into to, which replaces it.
+func copyAuditLog(ctx context.Context, from, to storage.Storage) error {
+	entries, err := from.ListAudit(ctx, time.Time{}, time.Time{}, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	for _, entry := range entries {
+		if err := to.AppendAudit(ctx, entry); err != nil {
+			return fmt.Errorf("failed to copy audit log: %w", err)
+		}
+	}
+	return nil
+}
+
+// copySearchAnalytics copies the search analytics and result opens of from into to, which
+// replaces it. Searches keep their IDs so that feedback on results shown before the swap
+// still lands.
+func copySearchAnalytics(ctx context.Context, from, to storage.Storage) error {
+	events, err := from.ListSearchEvents(ctx, time.Time{}, time.Time{}, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read search analytics: %w", err)
+	}
+	for _, event := range events {
+		if err := to.AppendSearchEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to copy search analytics: %w", err)
+		}
+	}
+	opens, err := from.ListOpenEvents(ctx, time.Time{}, time.Time{}, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read result opens: %w", err)
+	}
+	for _, open := range opens {
+		if err := to.RecordOpen(ctx, open); err != nil {
+			return fmt.Errorf("failed to copy result opens: %w", err)
+		}
+	}
+	return nil
+}
+
+// copySnapshots copies the snapshots of from into to, which replaces it. Documents keep
+// their IDs in the rebuild, so the snapshots still pin those whose content is unchanged.
+func copySnapshots(ctx context.Context, from, to storage.Storage) error {
+	snaps, err := from.ListSnapshots(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshots: %w", err)
+	}
+	for _, snap := range snaps {
+		docs, err := from.SnapshotDocuments(ctx, snap.Name)
+		if err != nil {
+			return fmt.Errorf("failed to read snapshot %s: %w", snap.Name, err)
+		}
+		if err := to.ImportSnapshot(ctx, snap, docs); err != nil {
+			return fmt.Errorf("failed to copy snapshot %s: %w", snap.Name, err)
+		}
+	}
+	return nil
+}
+
+// withGeneration returns an indexer with idx's configuration that writes to gen, with a
+// journal of its own.
+func (idx *Indexer) withGeneration(gen *Generation) *Indexer {
+	c := *idx
+	c.storage, c.vectorIndex, c.keywordIndex = gen.Storage, gen.VectorIndex, gen.KeywordIndex
+	c.rebuild = &rebuildState{}
+	return &c
+}
+
+// startJournal starts recording writes. Returns false if a journal is already active.
+func (idx *Indexer) startJournal() bool {
+	idx.rebuild.mu.Lock()
+	defer idx.rebuild.mu.Unlock()
+	if idx.rebuild.journal != nil {
+		return false
+	}
+	idx.rebuild.journal = &rebuildJournal{}
+	return true
+}
+
+// drainJournal returns the recorded writes and clears them; recording continues.
+func (idx *Indexer) drainJournal() []journalEntry {
+	idx.rebuild.mu.Lock()
+	defer idx.rebuild.mu.Unlock()
+	if idx.rebuild.journal == nil {
+		return nil
+	}
+	entries := idx.rebuild.journal.entries
+	idx.rebuild.journal.entries = nil
+	return entries
+}
+
+// stopJournal stops recording and returns the writes recorded since the last drain.
+func (idx *Indexer) stopJournal() []journalEntry {
+	idx.rebuild.mu.Lock()
+	defer idx.rebuild.mu.Unlock()
+	if idx.rebuild.journal == nil {
+		return nil
+	}
+	entries := idx.rebuild.journal.entries
+	idx.rebuild.journal = nil
+	return entries
+}
+
+func (idx *Indexer) recordIndex(input *models.DocumentInput) {
+	idx.rebuild.mu.Lock()
+	defer idx.rebuild.mu.Unlock()
+	if idx.rebuild.journal != nil {
+		in := *input
+		idx.rebuild.journal.entries = append(idx.rebuild.journal.entries, journalEntry{input: &in})
+	}
+}
+
+func (idx *Indexer) recordDelete(id string) {
+	idx.rebuild.mu.Lock()
+	defer idx.rebuild.mu.Unlock()
+	if idx.rebuild.journal != nil {
+		idx.rebuild.journal.entries = append(idx.rebuild.journal.entries, journalEntry{deleteID: id})
+	}
+}
+
+// replay applies journal entries in order. Failures are logged and skipped: deletes of
+// documents the target never had are expected.
+func (idx *Indexer) replay(ctx context.Context, entries []journalEntry) {
+	for _, e := range entries {
+		var err error
+		if e.input != nil {
+			err = idx.IndexDocument(ctx, e.input)
+		} else {
+			err = idx.DeleteDocument(ctx, e.deleteID)
+		}
+		if err != nil && idx.logger != nil {
+			idx.logger.Debug("rebuild journal replay failed", zap.Error(err))
+		}
+	}
+}
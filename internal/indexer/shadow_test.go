@@ -0,0 +1,211 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/fileid"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+// testShadowIndexer returns an indexer over swappable stores in dir and the target that
+// rebuilds them.
+func testShadowIndexer(t *testing.T, dir string) (*Indexer, *PathSwapTarget) {
+	t.Helper()
+	cfg := &config.SearchConfig{
+		ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20,
+		DefaultKeywordEnabled: true, DefaultSemanticEnabled: true,
+	}
+	dbPath, blevePath := filepath.Join(dir, "db.sqlite"), filepath.Join(dir, "bleve")
+	sqlite, err := storage.NewSQLiteStorage(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bleveIdx, err := keyword.NewBleveIndex(blevePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newVectorIndex := func() (vector.VectorIndex, error) { return vector.NewMemoryIndex(4) }
+	vecIdx, _ := newVectorIndex()
+	target := &PathSwapTarget{
+		Storage:        storage.NewSwappableStorage(sqlite),
+		KeywordIndex:   keyword.NewSwappableIndex(bleveIdx),
+		VectorIndex:    vector.NewSwappableIndex(vecIdx),
+		DatabasePath:   dbPath,
+		BleveIndexPath: blevePath,
+		NewVectorIndex: newVectorIndex,
+	}
+	t.Cleanup(func() {
+		_ = target.Storage.Close()
+		_ = target.KeywordIndex.Close()
+		_ = target.VectorIndex.Close()
+	})
+	embedder := embedding.NewMockEmbedder(4)
+	t.Cleanup(func() { _ = embedder.Close() })
+	idx := NewIndexer(target.Storage, embedder, target.VectorIndex, target.KeywordIndex, cfg, nil)
+	return idx, target
+}
+
+func TestIndexer_RebuildShadow(t *testing.T) {
+	dir := t.TempDir()
+	idx, target := testShadowIndexer(t, dir)
+	ctx := context.Background()
+
+	docs := filepath.Join(dir, "docs")
+	if err := os.Mkdir(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	kept := filepath.Join(docs, "kept.txt")
+	if err := os.WriteFile(kept, []byte("quarterly budget"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexFile(ctx, kept, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "api-doc", Content: "added over http"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes made while the rebuild runs go to the live stores and must survive the swap.
+	var liveWriteErr error
+	written := false
+	result, err := idx.RebuildShadow(ctx, []string{docs}, []string{".txt"}, target, func(done, total int) {
+		if written {
+			return
+		}
+		written = true
+		if n, _ := target.Storage.CountDocuments(ctx); n != 2 {
+			t.Errorf("live stores during rebuild: got %d documents, want 2", n)
+		}
+		liveWriteErr = idx.IndexDocument(ctx, &models.DocumentInput{ID: "during-rebuild", Content: "late arrival"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if liveWriteErr != nil {
+		t.Fatal(liveWriteErr)
+	}
+	if result.Total != 2 || result.Indexed != 2 {
+		t.Errorf("result: got %+v, want total=2 indexed=2", result)
+	}
+
+	for _, id := range []string{fileid.FileDocID(kept), "api-doc", "during-rebuild"} {
+		if _, err := target.Storage.GetDocument(ctx, id); err != nil {
+			t.Errorf("document %s missing after swap: %v", id, err)
+		}
+	}
+	hits, err := target.KeywordIndex.Search(ctx, "arrival", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].ID != "during-rebuild" {
+		t.Errorf("keyword search after swap: got %v", hits)
+	}
+	if target.VectorIndex.Size() == 0 {
+		t.Error("vector index should be populated after swap")
+	}
+	for _, p := range []string{target.DatabasePath + rebuildSuffix, target.BleveIndexPath + rebuildSuffix,
+		target.DatabasePath + retiredSuffix, target.BleveIndexPath + retiredSuffix} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed after swap", p)
+		}
+	}
+}
+
+func TestIndexer_RebuildShadow_keepsOptions(t *testing.T) {
+	dir := t.TempDir()
+	idx, target := testShadowIndexer(t, dir)
+	WithNoIndexMarker(".noindex")(idx)
+	WithCodeMode()(idx)
+	ctx := context.Background()
+
+	docs := filepath.Join(dir, "docs")
+	private := filepath.Join(docs, "private")
+	if err := os.MkdirAll(private, 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		filepath.Join(docs, "notes.txt"):       "quarterly budget",
+		filepath.Join(docs, "main.go"):         "package main\n\nfunc main() {}\n",
+		filepath.Join(private, ".noindex"):     "",
+		filepath.Join(private, "salaries.txt"): "payroll",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := idx.RebuildShadow(ctx, []string{docs}, []string{".txt", ".go"}, target, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := target.Storage.GetDocument(ctx, fileid.FileDocID(filepath.Join(docs, "notes.txt"))); err != nil {
+		t.Errorf("notes.txt missing after rebuild: %v", err)
+	}
+	if _, err := target.Storage.GetDocument(ctx, fileid.FileDocID(filepath.Join(private, "salaries.txt"))); err == nil {
+		t.Error("file in a .noindex directory indexed by the rebuild")
+	}
+	doc, err := target.Storage.GetDocument(ctx, fileid.FileDocID(filepath.Join(docs, "main.go")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Metadata[metaKeyCodeLanguage] != "go" {
+		t.Errorf("main.go metadata after rebuild: got %v, want code_language go", doc.Metadata)
+	}
+
+	// The rebuild's indexer skips files in the directory however they reach it
+	shadow := idx.withGeneration(&Generation{Storage: target.Storage, VectorIndex: target.VectorIndex, KeywordIndex: target.KeywordIndex})
+	if err := shadow.IndexFile(ctx, filepath.Join(private, "salaries.txt"), nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := target.Storage.GetDocument(ctx, fileid.FileDocID(filepath.Join(private, "salaries.txt"))); err == nil {
+		t.Error("rebuild indexer indexed a file in a .noindex directory")
+	}
+}
+
+func TestIndexer_RebuildShadow_cancelled(t *testing.T) {
+	dir := t.TempDir()
+	idx, target := testShadowIndexer(t, dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for _, id := range []string{"api-doc", "other-doc"} {
+		if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: id, Content: "stays live"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := idx.RebuildShadow(ctx, nil, nil, target, func(done, total int) { cancel() })
+	if err == nil {
+		t.Fatal("expected error from cancelled rebuild")
+	}
+	if _, err := target.Storage.GetDocument(context.Background(), "api-doc"); err != nil {
+		t.Errorf("live stores should be untouched: %v", err)
+	}
+	if _, err := os.Stat(target.BleveIndexPath + rebuildSuffix); !os.IsNotExist(err) {
+		t.Error("rebuild directory should be discarded")
+	}
+}
+
+func TestRestoreInterruptedSwap(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bleve")
+	if err := os.Mkdir(path+retiredSuffix, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := RestoreInterruptedSwap(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("retired store should be restored: %v", err)
+	}
+	if err := RestoreInterruptedSwap(path); err != nil {
+		t.Errorf("restore with the live path present: %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package indexer
+
+import (
+	"hash/fnv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// StopChunkFilter decides which chunks carry too little information to be worth
+// embedding. Stop chunks are still stored (so document content is complete) but are
+// kept out of the vector index, which improves semantic precision and index size.
+type StopChunkFilter struct {
+	minWords      int
+	minAlphaRatio float64
+	maxDocFreq    int
+
+	mu       sync.Mutex
+	docs     map[uint64]map[string]struct{} // normalized chunk hash -> IDs of documents containing it
+	hashesOf map[string][]uint64            // document ID -> hashes recorded for it
+}
+
+// NewStopChunkFilter creates a filter. A chunk is a stop chunk when it has fewer than
+// minWords words, when the share of letters among its non-space runes is below
+// minAlphaRatio, or when the same normalized text has already been seen in more than
+// maxDocFreq documents (boilerplate such as headers, footers, and disclaimers).
+// Zero or negative values disable the corresponding check.
+func NewStopChunkFilter(minWords int, minAlphaRatio float64, maxDocFreq int) *StopChunkFilter {
+	return &StopChunkFilter{
+		minWords:      minWords,
+		minAlphaRatio: minAlphaRatio,
+		maxDocFreq:    maxDocFreq,
+		docs:          make(map[uint64]map[string]struct{}),
+		hashesOf:      make(map[string][]uint64),
+	}
+}
+
+// Filter returns the chunks of the document with docID that should be embedded and
+// indexed for semantic search. Document frequencies count the distinct documents that
+// contain a chunk text, replacing what was recorded for docID when it was indexed before,
+// so re-indexing a document never makes its own chunks boilerplate. Frequencies live in
+// memory only, so boilerplate detection restarts with the process.
+func (f *StopChunkFilter) Filter(docID string, chunks []*models.DocumentChunk) []*models.DocumentChunk {
+	kept := make([]*models.DocumentChunk, 0, len(chunks))
+	var freq map[uint64]int
+	if f.maxDocFreq > 0 {
+		freq = f.record(docID, chunks)
+	}
+	for _, ch := range chunks {
+		if f.isLowInformation(ch.Content) {
+			continue
+		}
+		if freq != nil && freq[chunkHash(ch.Content)] > f.maxDocFreq {
+			continue
+		}
+		kept = append(kept, ch)
+	}
+	return kept
+}
+
+// Forget removes the chunk texts recorded for the document with docID, e.g. when it is
+// deleted or indexed without content.
+func (f *StopChunkFilter) Forget(docID string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.forget(docID)
+}
+
+// Skipped reports whether Filter has been keeping text out of the vector index: it is low
+// information, or boilerplate already seen in too many documents by this process.
+func (f *StopChunkFilter) Skipped(text string) bool {
+	if f.isLowInformation(text) {
+		return true
+	}
+	if f.maxDocFreq <= 0 {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.docs[chunkHash(text)]) > f.maxDocFreq
+}
+
+// isLowInformation reports whether text is too short or mostly numbers/punctuation.
+func (f *StopChunkFilter) isLowInformation(text string) bool {
+	if f.minWords > 0 && len(strings.Fields(text)) < f.minWords {
+		return true
+	}
+	if f.minAlphaRatio > 0 && alphaRatio(text) < f.minAlphaRatio {
+		return true
+	}
+	return false
+}
+
+// record replaces the chunk texts recorded for docID with those of the informative chunks
+// and returns the number of documents containing each of them.
+func (f *StopChunkFilter) record(docID string, chunks []*models.DocumentChunk) map[uint64]int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.forget(docID)
+	freq := make(map[uint64]int)
+	var hashes []uint64
+	for _, ch := range chunks {
+		if f.isLowInformation(ch.Content) {
+			continue
+		}
+		h := chunkHash(ch.Content)
+		if _, ok := freq[h]; ok {
+			continue
+		}
+		ids := f.docs[h]
+		if ids == nil {
+			ids = make(map[string]struct{})
+			f.docs[h] = ids
+		}
+		ids[docID] = struct{}{}
+		hashes = append(hashes, h)
+		freq[h] = len(ids)
+	}
+	if len(hashes) > 0 {
+		f.hashesOf[docID] = hashes
+	}
+	return freq
+}
+
+// forget is Forget with f.mu held.
+func (f *StopChunkFilter) forget(docID string) {
+	for _, h := range f.hashesOf[docID] {
+		delete(f.docs[h], docID)
+		if len(f.docs[h]) == 0 {
+			delete(f.docs, h)
+		}
+	}
+	delete(f.hashesOf, docID)
+}
+
+// alphaRatio returns the fraction of non-space runes in text that are letters.
+// Empty or all-space text returns 0.
+func alphaRatio(text string) float64 {
+	var letters, total int
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		total++
+		if unicode.IsLetter(r) {
+			letters++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(letters) / float64(total)
+}
+
+// chunkHash hashes the lowercased, whitespace-collapsed chunk text so that
+// boilerplate differing only in case or spacing is counted together.
+func chunkHash(text string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strings.ToLower(strings.Join(strings.Fields(text), " "))))
+	return h.Sum64()
+}
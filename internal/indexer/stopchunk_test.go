@@ -0,0 +1,85 @@
+package indexer
+
+import (
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func chunksOf(texts ...string) []*models.DocumentChunk {
+	out := make([]*models.DocumentChunk, len(texts))
+	for i, t := range texts {
+		out[i] = &models.DocumentChunk{ID: t, Content: t, ChunkIndex: i}
+	}
+	return out
+}
+
+func TestStopChunkFilter_lowInformation(t *testing.T) {
+	f := NewStopChunkFilter(3, 0.5, 0)
+	tests := []struct {
+		text string
+		keep bool
+	}{
+		{"machine learning algorithms explained", true},
+		{"too short", false},
+		{"12 34 56 78 90 -- ##", false},
+		{"page 1 of 2 total 3.50", true},
+		{"   ", false},
+	}
+	for _, tt := range tests {
+		got := f.Filter("doc", chunksOf(tt.text))
+		if (len(got) == 1) != tt.keep {
+			t.Errorf("Filter(%q): kept=%v, want %v", tt.text, len(got) == 1, tt.keep)
+		}
+	}
+}
+
+func TestStopChunkFilter_boilerplateByDocFrequency(t *testing.T) {
+	f := NewStopChunkFilter(0, 0, 2)
+	footer := "Confidential - do not distribute"
+	for i := 0; i < 2; i++ {
+		// The same footer twice in one document counts once.
+		got := f.Filter(string(rune('a'+i)), chunksOf("unique body text "+string(rune('a'+i)), footer, footer))
+		if len(got) != 3 {
+			t.Fatalf("doc %d: kept %d chunks, want 3", i, len(got))
+		}
+	}
+	got := f.Filter("c", chunksOf("another body", "CONFIDENTIAL -  do not distribute"))
+	if len(got) != 1 || got[0].Content != "another body" {
+		t.Errorf("third document: expected footer dropped as boilerplate, got %v", got)
+	}
+}
+
+func TestStopChunkFilter_countsDistinctDocuments(t *testing.T) {
+	f := NewStopChunkFilter(0, 0, 2)
+	body := "quarterly notes on the garden project"
+	for i := 0; i < 5; i++ {
+		if got := f.Filter("notes", chunksOf(body)); len(got) != 1 {
+			t.Fatalf("re-index %d: own chunk dropped as boilerplate", i)
+		}
+	}
+	footer := "Confidential - do not distribute"
+	for _, id := range []string{"a", "b", "c"} {
+		f.Filter(id, chunksOf(footer))
+	}
+	if !f.Skipped(footer) {
+		t.Fatal("footer in three documents should be boilerplate")
+	}
+	f.Forget("c")
+	if f.Skipped(footer) {
+		t.Error("footer should not be boilerplate after a document is forgotten")
+	}
+	f.Filter("b", chunksOf(body))
+	if got := f.Filter("d", chunksOf(footer)); len(got) != 1 {
+		t.Error("re-indexed document without the footer should no longer count it")
+	}
+}
+
+func TestAlphaRatio(t *testing.T) {
+	if r := alphaRatio("abc123"); r != 0.5 {
+		t.Errorf("alphaRatio(abc123) = %f, want 0.5", r)
+	}
+	if r := alphaRatio(""); r != 0 {
+		t.Errorf("alphaRatio(empty) = %f, want 0", r)
+	}
+}
@@ -0,0 +1,223 @@
+package indexer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+const (
+	// rebuildSuffix is appended to a store's path for the generation being built.
+	rebuildSuffix = ".rebuild"
+	// retiredSuffix is appended to a store's path while it is being replaced.
+	retiredSuffix = ".old"
+)
+
+// sqliteSidecars are the files SQLite keeps next to a database in WAL mode.
+var sqliteSidecars = []string{"-wal", "-shm"}
+
+// PathSwapTarget is a ShadowTarget for the SQLite database and keyword index at fixed
+// paths. The new generation is built at "<path>.rebuild"; Swap closes both generations,
+// renames the new files over the live paths, and reopens them in the swappable wrappers,
+// so the configured paths always hold the serving stores. The vector index is built in
+// memory and saved to VectorIndexPath after the swap.
+type PathSwapTarget struct {
+	Storage      *storage.SwappableStorage
+	KeywordIndex *keyword.SwappableIndex
+	VectorIndex  *vector.SwappableIndex
+
+	DatabasePath    string
+	BleveIndexPath  string // the keyword index: a Bleve directory, or what NewKeywordIndex opens
+	VectorIndexPath string // optional; when empty the swapped-in vector index is not saved
+
+	// NewVectorIndex creates an empty vector index for the new generation.
+	NewVectorIndex func() (vector.VectorIndex, error)
+	// KeywordOptions configure the Bleve index of each generation, e.g. its stopwords.
+	KeywordOptions []keyword.BleveOption
+	// NewKeywordIndex opens the keyword index at a path, e.g. an FTS5 database; nil opens
+	// a Bleve index with KeywordOptions.
+	NewKeywordIndex func(path string) (keyword.KeywordIndex, error)
+	// StorageOptions configure the database of each generation, e.g. its pragmas.
+	StorageOptions []storage.SQLiteOption
+}
+
+// Create opens empty stores at the rebuild paths, removing any left by an earlier run.
+func (t *PathSwapTarget) Create() (*Generation, error) {
+	dbPath, blevePath := t.DatabasePath+rebuildSuffix, t.BleveIndexPath+rebuildSuffix
+	if err := removeSQLite(dbPath); err != nil {
+		return nil, err
+	}
+	if err := os.RemoveAll(blevePath); err != nil {
+		return nil, fmt.Errorf("failed to remove stale keyword index: %w", err)
+	}
+	if err := removeSidecars(blevePath); err != nil {
+		return nil, err
+	}
+	gen := &Generation{}
+	var err error
+	if gen.Storage, err = storage.NewSQLiteStorage(dbPath, t.StorageOptions...); err != nil {
+		return nil, err
+	}
+	if gen.KeywordIndex, err = t.openKeyword(blevePath); err != nil {
+		t.Discard(gen)
+		return nil, err
+	}
+	if gen.VectorIndex, err = t.NewVectorIndex(); err != nil {
+		t.Discard(gen)
+		return nil, err
+	}
+	return gen, nil
+}
+
+// Swap installs gen in the wrappers: the keyword index, then the vector index, then storage.
+// Each store is swapped under its wrapper's lock, so queries wait briefly instead of failing.
+// If moving files fails, the old store is reopened and the error returned.
+func (t *PathSwapTarget) Swap(gen *Generation) error {
+	err := t.KeywordIndex.Swap(func(old keyword.KeywordIndex) (keyword.KeywordIndex, error) {
+		_ = old.Close()
+		if err := gen.KeywordIndex.Close(); err != nil {
+			return t.reopenKeyword(fmt.Errorf("failed to close rebuilt keyword index: %w", err))
+		}
+		if err := removeSidecars(t.BleveIndexPath); err != nil {
+			return t.reopenKeyword(err)
+		}
+		if err := replacePath(t.BleveIndexPath, t.BleveIndexPath+rebuildSuffix); err != nil {
+			return t.reopenKeyword(err)
+		}
+		return t.reopenKeyword(nil)
+	})
+	if err != nil {
+		return err
+	}
+	_ = t.VectorIndex.Swap(func(old vector.VectorIndex) (vector.VectorIndex, error) {
+		_ = old.Close()
+		return gen.VectorIndex, nil
+	})
+	if t.VectorIndexPath != "" {
+		if err := t.VectorIndex.Save(t.VectorIndexPath); err != nil {
+			return fmt.Errorf("failed to save vector index: %w", err)
+		}
+	}
+	return t.Storage.Swap(func(old storage.Storage) (storage.Storage, error) {
+		_ = old.Close()
+		if err := gen.Storage.Close(); err != nil {
+			return reopenSQLite(t.DatabasePath, fmt.Errorf("failed to close rebuilt storage: %w", err), t.StorageOptions)
+		}
+		if err := removeSidecars(t.DatabasePath); err != nil {
+			return reopenSQLite(t.DatabasePath, err, t.StorageOptions)
+		}
+		if err := replacePath(t.DatabasePath, t.DatabasePath+rebuildSuffix); err != nil {
+			return reopenSQLite(t.DatabasePath, err, t.StorageOptions)
+		}
+		return reopenSQLite(t.DatabasePath, nil, t.StorageOptions)
+	})
+}
+
+// Discard closes gen's stores and removes the rebuild paths.
+func (t *PathSwapTarget) Discard(gen *Generation) {
+	if gen.Storage != nil {
+		_ = gen.Storage.Close()
+	}
+	if gen.KeywordIndex != nil {
+		_ = gen.KeywordIndex.Close()
+	}
+	if gen.VectorIndex != nil {
+		_ = gen.VectorIndex.Close()
+	}
+	_ = removeSQLite(t.DatabasePath + rebuildSuffix)
+	_ = os.RemoveAll(t.BleveIndexPath + rebuildSuffix)
+	_ = removeSidecars(t.BleveIndexPath + rebuildSuffix)
+}
+
+// RestoreInterruptedSwap moves "<path>.old" back to each path that is missing, undoing a
+// swap that was interrupted between retiring the live store and moving in the new one.
+// Call it before opening the stores.
+func RestoreInterruptedSwap(paths ...string) error {
+	for _, path := range paths {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			continue
+		}
+		if _, err := os.Stat(path + retiredSuffix); err != nil {
+			continue
+		}
+		if err := os.Rename(path+retiredSuffix, path); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// replacePath moves next over live: live is renamed aside, next takes its place, and the
+// retired copy is removed. If next cannot be moved, live is put back.
+func replacePath(live, next string) error {
+	retired := live + retiredSuffix
+	if err := os.RemoveAll(retired); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", retired, err)
+	}
+	if err := os.Rename(live, retired); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to retire %s: %w", live, err)
+	}
+	if err := os.Rename(next, live); err != nil {
+		_ = os.Rename(retired, live)
+		return fmt.Errorf("failed to move %s into place: %w", next, err)
+	}
+	if err := os.RemoveAll(retired); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", retired, err)
+	}
+	return nil
+}
+
+// removeSQLite removes a database file and its WAL sidecars.
+func removeSQLite(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	return removeSidecars(path)
+}
+
+// removeSidecars removes the WAL sidecars of a closed database, so they are not applied
+// to the database moved into its place.
+func removeSidecars(path string) error {
+	for _, suffix := range sqliteSidecars {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", path+suffix, err)
+		}
+	}
+	return nil
+}
+
+// openKeyword opens the keyword index at path with NewKeywordIndex, or as a Bleve index.
+func (t *PathSwapTarget) openKeyword(path string) (keyword.KeywordIndex, error) {
+	if t.NewKeywordIndex != nil {
+		return t.NewKeywordIndex(path)
+	}
+	idx, err := keyword.NewBleveIndex(path, t.KeywordOptions...)
+	if err != nil {
+		return nil, err
+	}
+	return idx, nil
+}
+
+// reopenKeyword opens the live keyword index and returns it with cause, the error (if
+// any) that made the swap fall back to it.
+func (t *PathSwapTarget) reopenKeyword(cause error) (keyword.KeywordIndex, error) {
+	idx, err := t.openKeyword(t.BleveIndexPath)
+	if err != nil {
+		return nil, errors.Join(cause, err)
+	}
+	return idx, cause
+}
+
+// reopenSQLite opens the database at path and returns it with cause, the error (if any)
+// that made the swap fall back to it.
+func reopenSQLite(path string, cause error, opts []storage.SQLiteOption) (storage.Storage, error) {
+	s, err := storage.NewSQLiteStorage(path, opts...)
+	if err != nil {
+		return nil, errors.Join(cause, err)
+	}
+	return s, cause
+}
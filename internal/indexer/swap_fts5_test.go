@@ -0,0 +1,52 @@
+//go:build sqlite_fts5
+
+package indexer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestIndexer_RebuildShadow_fts5(t *testing.T) {
+	dir := t.TempDir()
+	idx, target := testShadowIndexer(t, dir)
+	ctx := context.Background()
+	target.BleveIndexPath = filepath.Join(dir, "keyword.db")
+	target.NewKeywordIndex = func(path string) (keyword.KeywordIndex, error) {
+		f, err := keyword.NewFTS5Index(path, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+	if err := target.KeywordIndex.Swap(func(old keyword.KeywordIndex) (keyword.KeywordIndex, error) {
+		_ = old.Close()
+		return target.NewKeywordIndex(target.BleveIndexPath)
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "api-doc", Content: "quarterly budget"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := idx.RebuildShadow(ctx, nil, nil, target, nil); err != nil {
+		t.Fatal(err)
+	}
+	hits, err := target.KeywordIndex.Search(ctx, "budget", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].ID != "api-doc" {
+		t.Errorf("keyword search after swap: got %v", hits)
+	}
+	for _, p := range []string{target.BleveIndexPath + rebuildSuffix, target.BleveIndexPath + retiredSuffix} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s should be removed after swap", p)
+		}
+	}
+}
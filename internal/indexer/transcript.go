@@ -0,0 +1,27 @@
+package indexer
+
+import "github.com/hyperjump/sagasu/internal/extract"
+
+// Metadata of the documents indexed from transcribed audio and video (see
+// transcriptMetadata).
+const (
+	metaKeyTranscriptTimestamps = "transcript_timestamps"
+	metaKeyMediaDuration        = "media_duration"
+)
+
+// transcriptMetadata returns the metadata recorded for a timed transcript: the start time
+// of each segment, "hh:mm:ss", in the order of the lines of its text, and its length, the
+// end of the last segment. Untimed transcripts have none.
+func transcriptMetadata(t *extract.Transcript) map[string]interface{} {
+	if !t.Timed || len(t.Segments) == 0 {
+		return nil
+	}
+	stamps := make([]string, len(t.Segments))
+	for i, s := range t.Segments {
+		stamps[i] = extract.Timestamp(s.Start)
+	}
+	return map[string]interface{}{
+		metaKeyTranscriptTimestamps: stamps,
+		metaKeyMediaDuration:        extract.Timestamp(t.Segments[len(t.Segments)-1].End),
+	}
+}
@@ -0,0 +1,45 @@
+package indexer
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/extract"
+	"github.com/hyperjump/sagasu/internal/fileid"
+)
+
+func TestIndexFile_transcript(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	idx, store := testIndexerWithStorage(t, dir)
+	WithSkipBinary()(idx)
+	idx.extractor = extract.NewExtractor().WithTranscriber(extract.TranscriberConfig{
+		Command: []string{"sh", "-c", `printf '[00:00:01.000 --> 00:00:03.000] Welcome\n[00:01:10.000 --> 00:01:12.500] Any questions?\n'`},
+		NoCache: true,
+	})
+	ctx := context.Background()
+
+	path := filepath.Join(dir, "meeting.wav")
+	if err := os.WriteFile(path, []byte("RIFF\x00\x00\x00\x00WAVE"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexFile(ctx, path, []string{".wav"}); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := store.GetDocument(ctx, fileid.FileDocID(mustAbs(path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != "[00:00:01] Welcome [00:01:10] Any questions?" {
+		t.Errorf("content = %q", doc.Content)
+	}
+	stamps := metadataStrings(doc.Metadata, metaKeyTranscriptTimestamps)
+	if len(stamps) != 2 || stamps[1] != "00:01:10" || doc.Metadata[metaKeyMediaDuration] != "00:01:12" {
+		t.Errorf("metadata = %v", doc.Metadata)
+	}
+}
@@ -0,0 +1,135 @@
+// Package instance keeps one sagasu process per data directory and lets the CLI find the
+// running server.
+//
+// The process holding the storage takes an exclusive lock on "sagasu.lock" in the data
+// directory; the operating system releases it when the process exits, so a crash never
+// leaves a stale lock. A server also publishes its address in "server.json", which is
+// only trusted while the lock is held. A server run as a service also writes a pidfile
+// for the service manager and "sagasu daemon status".
+package instance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	lockFileName   = "sagasu.lock"
+	serverFileName = "server.json"
+)
+
+// ErrLocked is returned by Acquire when another process holds the data directory.
+var ErrLocked = errors.New("another sagasu instance is using the data directory")
+
+// Info describes a running server.
+type Info struct {
+	PID       int       `json:"pid"`
+	URL       string    `json:"url"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// Lock is the exclusive hold of a data directory by this process.
+type Lock struct {
+	dir       string
+	f         *os.File
+	published bool
+}
+
+// Acquire locks dir for this process, creating it if needed. When another process holds
+// it, the error wraps ErrLocked and names that process and, for a server, its address.
+func Acquire(dir string) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open instance lock: %w", err)
+	}
+	if err := tryLock(f); err != nil {
+		_ = f.Close()
+		return nil, lockedError(dir)
+	}
+	// The PID is only informational, for the error another process reports.
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return &Lock{dir: dir, f: f}, nil
+}
+
+// lockedError describes the process holding dir, as far as it can be read.
+func lockedError(dir string) error {
+	if info, err := readInfo(dir); err == nil {
+		return fmt.Errorf("%w: %s (server pid %d at %s)", ErrLocked, dir, info.PID, info.URL)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, lockFileName)); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			return fmt.Errorf("%w: %s (pid %d)", ErrLocked, dir, pid)
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrLocked, dir)
+}
+
+// Publish records that this process serves the HTTP API at url, for Discover.
+func (l *Lock) Publish(url string) error {
+	data, err := json.Marshal(Info{PID: os.Getpid(), URL: url, StartedAt: time.Now().UTC()})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(l.dir, serverFileName), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to publish server address: %w", err)
+	}
+	l.published = true
+	return nil
+}
+
+// Release removes the published address and unlocks the data directory.
+func (l *Lock) Release() error {
+	if l.published {
+		_ = os.Remove(filepath.Join(l.dir, serverFileName))
+		l.published = false
+	}
+	_ = unlock(l.f)
+	return l.f.Close()
+}
+
+// Discover returns the server published in dir, or nil when no server holds it (including
+// when one exited without removing its address).
+func Discover(dir string) (*Info, error) {
+	info, err := readInfo(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open instance lock: %w", err)
+	}
+	defer f.Close()
+	if tryLock(f) == nil {
+		_ = unlock(f)
+		return nil, nil
+	}
+	return info, nil
+}
+
+func readInfo(dir string) (*Info, error) {
+	data, err := os.ReadFile(filepath.Join(dir, serverFileName))
+	if err != nil {
+		return nil, err
+	}
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", serverFileName, err)
+	}
+	return &info, nil
+}
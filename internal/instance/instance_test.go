@@ -0,0 +1,88 @@
+package instance
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAcquire_exclusive(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "data")
+	lock, err := Acquire(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lock.Publish("http://127.0.0.1:8081"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Acquire(dir)
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("second Acquire: got %v, want ErrLocked", err)
+	}
+	if !strings.Contains(err.Error(), "http://127.0.0.1:8081") {
+		t.Errorf("error should name the running server: %v", err)
+	}
+	info, err := Discover(dir)
+	if err != nil || info == nil || info.URL != "http://127.0.0.1:8081" || info.PID != os.Getpid() {
+		t.Fatalf("Discover: got %+v, %v", info, err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := Discover(dir); err != nil || info != nil {
+		t.Errorf("Discover after release: got %+v, %v", info, err)
+	}
+	lock, err = Acquire(dir)
+	if err != nil {
+		t.Fatalf("Acquire after release: %v", err)
+	}
+	defer lock.Release()
+}
+
+func TestDiscover_staleAddress(t *testing.T) {
+	dir := t.TempDir()
+	if info, err := Discover(dir); err != nil || info != nil {
+		t.Fatalf("empty dir: got %+v, %v", info, err)
+	}
+	// A server that crashed leaves its address, but not its lock.
+	if err := os.WriteFile(filepath.Join(dir, serverFileName), []byte(`{"pid":1,"url":"http://127.0.0.1:9"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, lockFileName), []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := Discover(dir); err != nil || info != nil {
+		t.Errorf("stale address: got %+v, %v", info, err)
+	}
+}
+
+func TestPIDFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run", "sagasu.pid")
+	if pid, err := ReadPIDFile(path); err != nil || pid != 0 {
+		t.Fatalf("missing pidfile: got %d, %v", pid, err)
+	}
+	if err := WritePIDFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if pid, err := ReadPIDFile(path); err != nil || pid != os.Getpid() {
+		t.Fatalf("ReadPIDFile: got %d, %v, want %d", pid, err, os.Getpid())
+	}
+
+	// A pidfile left behind by an exited process is stale.
+	if err := os.WriteFile(path, []byte("2147483646\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if pid, err := ReadPIDFile(path); err != nil || pid != 0 {
+		t.Errorf("stale pidfile: got %d, %v", pid, err)
+	}
+	if err := os.WriteFile(path, []byte("sagasu\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPIDFile(path); err == nil {
+		t.Error("invalid pidfile: expected an error")
+	}
+}
@@ -0,0 +1,482 @@
+// Package jobs provides a bounded background worker pool for indexing work, with
+// per-job status tracking and retry with exponential backoff.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Status is the lifecycle state of a job.
+type Status string
+
+const (
+	// StatusQueued means the job is waiting for a worker (or for its next retry).
+	StatusQueued Status = "queued"
+	// StatusRunning means a worker is executing the job.
+	StatusRunning Status = "running"
+	// StatusCompleted means the job finished without error.
+	StatusCompleted Status = "completed"
+	// StatusFailed means the job failed on every attempt.
+	StatusFailed Status = "failed"
+	// StatusReplaced means a newer job with the same key took the job's place before it
+	// ran (see SubmitKeyed).
+	StatusReplaced Status = "replaced"
+)
+
+// ErrStopped is returned by Submit after the queue has been stopped.
+var ErrStopped = errors.New("job queue stopped")
+
+// Func is the work performed by a job.
+type Func func(ctx context.Context) error
+
+// Job is a snapshot of a job's state.
+type Job struct {
+	ID         string     `json:"id"`
+	Kind       string     `json:"kind"`
+	Target     string     `json:"target,omitempty"`
+	Status     Status     `json:"status"`
+	Attempts   int        `json:"attempts"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+type task struct {
+	job      *Job
+	fn       Func
+	key      string // jobs with the same non-empty key run one at a time, in order
+	replaced bool   // set when a newer job with the key replaced this one before it ran
+	first    bool   // taken by workers before the other jobs (see SubmitKeyedFirst)
+}
+
+// keyed holds the jobs of one key: the one given to the workers, and the newest job
+// submitted since, which waits for it to finish.
+type keyed struct {
+	active *task
+	next   *task
+}
+
+// Queue runs submitted jobs on a fixed number of workers.
+type Queue struct {
+	workers    int
+	queueSize  int
+	maxRetries int
+	backoff    time.Duration
+	history    int
+	logger     *zap.Logger // optional; when set, logs job failures
+
+	tasks  chan *task
+	first  chan *task // jobs from SubmitKeyedFirst, taken before tasks
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[string]*Job
+	done    []string      // IDs of finished jobs, oldest first, for history trimming
+	resumed chan struct{} // non-nil while paused; closed by Resume
+	keys    map[string]*keyed
+}
+
+// Option configures a Queue.
+type Option func(*Queue)
+
+// WithWorkers sets the number of concurrent workers (default 2).
+func WithWorkers(n int) Option {
+	return func(q *Queue) {
+		if n > 0 {
+			q.workers = n
+		}
+	}
+}
+
+// WithQueueSize sets how many jobs may wait for a worker before Submit blocks (default 1000).
+func WithQueueSize(n int) Option {
+	return func(q *Queue) {
+		if n > 0 {
+			q.queueSize = n
+		}
+	}
+}
+
+// WithMaxRetries sets how many times a failed job is retried (default 2; 0 disables retry).
+func WithMaxRetries(n int) Option {
+	return func(q *Queue) {
+		if n >= 0 {
+			q.maxRetries = n
+		}
+	}
+}
+
+// WithBackoff sets the delay before the first retry; it doubles on each further retry (default 500ms).
+func WithBackoff(d time.Duration) Option {
+	return func(q *Queue) {
+		if d > 0 {
+			q.backoff = d
+		}
+	}
+}
+
+// WithHistory sets how many finished jobs are kept for inspection (default 1000).
+func WithHistory(n int) Option {
+	return func(q *Queue) {
+		if n > 0 {
+			q.history = n
+		}
+	}
+}
+
+// WithLogger sets a logger for job failures.
+func WithLogger(l *zap.Logger) Option {
+	return func(q *Queue) { q.logger = l }
+}
+
+// NewQueue creates a queue and starts its workers. Call Stop to shut it down.
+func NewQueue(opts ...Option) *Queue {
+	q := &Queue{
+		workers:    2,
+		queueSize:  1000,
+		maxRetries: 2,
+		backoff:    500 * time.Millisecond,
+		history:    1000,
+		jobs:       make(map[string]*Job),
+		keys:       make(map[string]*keyed),
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	q.tasks = make(chan *task, q.queueSize)
+	q.first = make(chan *task, q.queueSize)
+	q.ctx, q.cancel = context.WithCancel(context.Background())
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	return q
+}
+
+// Submit enqueues fn as a job of the given kind. target describes what the job acts on
+// (e.g. a file path) and is reported in status. Submit blocks while the queue is full,
+// until ctx is done or the queue is stopped.
+func (q *Queue) Submit(ctx context.Context, kind, target string, fn Func) (Job, error) {
+	return q.SubmitKeyed(ctx, kind, target, "", fn)
+}
+
+// SubmitKeyed is Submit for a job that must not run alongside or before the earlier jobs
+// with the same key, such as the index and delete jobs of one file path. While a job with
+// the key is queued or running, the new one waits behind it without taking a queue slot,
+// and it replaces the jobs that have not started yet, so only the newest runs. An empty
+// key is Submit.
+func (q *Queue) SubmitKeyed(ctx context.Context, kind, target, key string, fn Func) (Job, error) {
+	return q.submit(ctx, kind, target, key, false, fn)
+}
+
+// SubmitKeyedFirst is SubmitKeyed for a job that goes ahead of the jobs already queued,
+// such as indexing a file the user has open. It still waits for a running job with the
+// key and replaces the key's jobs that have not started, so the key's jobs keep their
+// order; a paused queue holds it until Resume like the rest.
+func (q *Queue) SubmitKeyedFirst(ctx context.Context, kind, target, key string, fn Func) (Job, error) {
+	return q.submit(ctx, kind, target, key, true, fn)
+}
+
+func (q *Queue) submit(ctx context.Context, kind, target, key string, first bool, fn Func) (Job, error) {
+	if q.ctx.Err() != nil {
+		return Job{}, ErrStopped
+	}
+	job := &Job{
+		ID:        uuid.New().String(),
+		Kind:      kind,
+		Target:    target,
+		Status:    StatusQueued,
+		CreatedAt: time.Now(),
+	}
+	t := &task{job: job, fn: fn, key: key, first: first}
+	q.mu.Lock()
+	q.jobs[job.ID] = job
+	if key != "" {
+		if k := q.keys[key]; k != nil {
+			if k.next != nil {
+				q.replaceLocked(k.next)
+			}
+			if k.active.job.Attempts > 0 || !first {
+				if k.active.job.Attempts == 0 {
+					// Not started: the worker that takes it passes it over (see run).
+					k.active.replaced = true
+				}
+				k.next = t
+				q.mu.Unlock()
+				return q.snapshot(job), nil
+			}
+			// Not started, and t must not wait behind it in the queue: t takes its
+			// place, and the worker that takes it passes it over.
+			k.active.replaced = true
+			k.active, k.next = t, nil
+		} else {
+			q.keys[key] = &keyed{active: t}
+		}
+	}
+	q.mu.Unlock()
+
+	select {
+	case q.channel(t) <- t:
+		return q.snapshot(job), nil
+	case <-ctx.Done():
+		q.abandon(t)
+		return Job{}, ctx.Err()
+	case <-q.ctx.Done():
+		q.abandon(t)
+		return Job{}, ErrStopped
+	}
+}
+
+// Get returns a snapshot of the job with the given ID.
+func (q *Queue) Get(id string) (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	job, ok := q.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// List returns snapshots of all known jobs, newest first. When status is non-empty,
+// only jobs in that state are returned.
+func (q *Queue) List(status Status) []Job {
+	q.mu.Lock()
+	out := make([]Job, 0, len(q.jobs))
+	for _, job := range q.jobs {
+		if status == "" || job.Status == status {
+			out = append(out, *job)
+		}
+	}
+	q.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out
+}
+
+// Counts returns the number of known jobs per status.
+func (q *Queue) Counts() map[Status]int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	counts := make(map[Status]int)
+	for _, job := range q.jobs {
+		counts[job.Status]++
+	}
+	return counts
+}
+
+// Stop cancels running jobs, stops the workers, and waits for them to exit.
+// Jobs still waiting in the queue are dropped.
+func (q *Queue) Stop() {
+	q.cancel()
+	q.wg.Wait()
+}
+
+// Pause stops workers from starting jobs until Resume. Running jobs finish; submitted
+// jobs wait in the queue.
+func (q *Queue) Pause() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.resumed == nil {
+		q.resumed = make(chan struct{})
+	}
+}
+
+// Resume lets workers start jobs again after Pause.
+func (q *Queue) Resume() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.resumed != nil {
+		close(q.resumed)
+		q.resumed = nil
+	}
+}
+
+// Paused reports whether the queue is paused.
+func (q *Queue) Paused() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.resumed != nil
+}
+
+func (q *Queue) work() {
+	defer q.wg.Done()
+	for {
+		var t *task
+		select {
+		case t = <-q.first:
+		default:
+			select {
+			case <-q.ctx.Done():
+				return
+			case t = <-q.first:
+			case t = <-q.tasks:
+			}
+		}
+		// A task taken just as the queue was paused waits for Resume like the rest.
+		if !q.waitResumed() {
+			return
+		}
+		q.run(t)
+	}
+}
+
+// channel returns the channel workers take t from.
+func (q *Queue) channel(t *task) chan *task {
+	if t.first {
+		return q.first
+	}
+	return q.tasks
+}
+
+// waitResumed blocks while the queue is paused. It returns false if the queue is stopped.
+func (q *Queue) waitResumed() bool {
+	q.mu.Lock()
+	resumed := q.resumed
+	q.mu.Unlock()
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-resumed:
+		return true
+	case <-q.ctx.Done():
+		return false
+	}
+}
+
+func (q *Queue) run(t *task) {
+	now := time.Now()
+	q.mu.Lock()
+	if t.replaced {
+		// A newer job with the key replaced t before it started, which may have been
+		// while the worker waited for Resume.
+		q.replaceLocked(t)
+		q.nextLocked(t)
+		q.mu.Unlock()
+		return
+	}
+	t.job.Status = StatusRunning
+	t.job.Attempts++
+	if t.job.StartedAt == nil {
+		t.job.StartedAt = &now
+	}
+	attempt := t.job.Attempts
+	q.mu.Unlock()
+
+	err := t.fn(q.ctx)
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err == nil {
+		t.job.Status = StatusCompleted
+		t.job.Error = ""
+		q.finishLocked(t.job)
+		q.nextLocked(t)
+		return
+	}
+	t.job.Error = err.Error()
+	if attempt <= q.maxRetries && q.ctx.Err() == nil {
+		t.job.Status = StatusQueued
+		delay := q.backoff << (attempt - 1)
+		go q.retryAfter(t, delay)
+		return
+	}
+	t.job.Status = StatusFailed
+	q.finishLocked(t.job)
+	q.nextLocked(t)
+	if q.logger != nil {
+		q.logger.Warn("job failed",
+			zap.String("id", t.job.ID),
+			zap.String("kind", t.job.Kind),
+			zap.String("target", t.job.Target),
+			zap.Int("attempts", attempt),
+			zap.Error(err))
+	}
+}
+
+// retryAfter re-enqueues t after delay unless the queue is stopped first.
+func (q *Queue) retryAfter(t *task, delay time.Duration) {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-q.ctx.Done():
+		return
+	}
+	select {
+	case q.channel(t) <- t:
+	case <-q.ctx.Done():
+	}
+}
+
+// finishLocked records a finished job and trims the oldest finished jobs beyond the
+// history limit. q.mu must be held.
+func (q *Queue) finishLocked(job *Job) {
+	now := time.Now()
+	job.FinishedAt = &now
+	q.done = append(q.done, job.ID)
+	for len(q.done) > q.history {
+		delete(q.jobs, q.done[0])
+		q.done = q.done[1:]
+	}
+}
+
+// replaceLocked finishes t as replaced by a newer job. q.mu must be held.
+func (q *Queue) replaceLocked(t *task) {
+	t.job.Status = StatusReplaced
+	q.finishLocked(t.job)
+}
+
+// nextLocked hands the job waiting behind t, the finished active job of its key, to the
+// workers. q.mu must be held.
+func (q *Queue) nextLocked(t *task) {
+	if t.key == "" {
+		return
+	}
+	k := q.keys[t.key]
+	if k == nil || k.active != t {
+		return
+	}
+	if k.next == nil {
+		delete(q.keys, t.key)
+		return
+	}
+	k.active, k.next = k.next, nil
+	next := k.active
+	// Sent from a goroutine as workers, which drain the channel, call this.
+	go func() {
+		select {
+		case q.channel(next) <- next:
+		case <-q.ctx.Done():
+		}
+	}()
+}
+
+// abandon forgets t, which was never queued, and the jobs of its key waiting behind it.
+func (q *Queue) abandon(t *task) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.jobs, t.job.ID)
+	if k := q.keys[t.key]; t.key != "" && k != nil && k.active == t {
+		if k.next != nil {
+			delete(q.jobs, k.next.job.ID)
+		}
+		delete(q.keys, t.key)
+	}
+}
+
+func (q *Queue) snapshot(job *Job) Job {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return *job
+}
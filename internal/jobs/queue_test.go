@@ -0,0 +1,344 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitFor polls until the job reaches a finished state or the deadline passes.
+func waitFor(t *testing.T, q *Queue, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := q.Get(id)
+		if !ok {
+			t.Fatalf("job %s not found", id)
+		}
+		if job.Status == StatusCompleted || job.Status == StatusFailed || job.Status == StatusReplaced {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+func TestQueue_completes(t *testing.T) {
+	q := NewQueue(WithWorkers(1))
+	defer q.Stop()
+	job, err := q.Submit(context.Background(), "index_file", "/tmp/a.txt", func(ctx context.Context) error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != StatusQueued && job.Status != StatusRunning && job.Status != StatusCompleted {
+		t.Errorf("initial status: got %q", job.Status)
+	}
+	done := waitFor(t, q, job.ID)
+	if done.Status != StatusCompleted || done.Attempts != 1 || done.FinishedAt == nil {
+		t.Errorf("got %+v", done)
+	}
+	if done.Kind != "index_file" || done.Target != "/tmp/a.txt" {
+		t.Errorf("kind/target: got %q %q", done.Kind, done.Target)
+	}
+}
+
+func TestQueue_retriesThenSucceeds(t *testing.T) {
+	q := NewQueue(WithMaxRetries(2), WithBackoff(time.Millisecond))
+	defer q.Stop()
+	var calls int32
+	job, _ := q.Submit(context.Background(), "k", "", func(ctx context.Context) error {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	})
+	done := waitFor(t, q, job.ID)
+	if done.Status != StatusCompleted || done.Attempts != 3 || done.Error != "" {
+		t.Errorf("got %+v", done)
+	}
+}
+
+func TestQueue_failsAfterRetries(t *testing.T) {
+	q := NewQueue(WithMaxRetries(1), WithBackoff(time.Millisecond))
+	defer q.Stop()
+	job, _ := q.Submit(context.Background(), "k", "", func(ctx context.Context) error {
+		return errors.New("boom")
+	})
+	done := waitFor(t, q, job.ID)
+	if done.Status != StatusFailed || done.Attempts != 2 || done.Error != "boom" {
+		t.Errorf("got %+v", done)
+	}
+	if failed := q.List(StatusFailed); len(failed) != 1 || failed[0].ID != job.ID {
+		t.Errorf("List(failed): got %+v", failed)
+	}
+	if n := q.Counts()[StatusFailed]; n != 1 {
+		t.Errorf("Counts(failed): got %d", n)
+	}
+}
+
+func TestQueue_boundedWorkers(t *testing.T) {
+	q := NewQueue(WithWorkers(2))
+	defer q.Stop()
+	var running, peak int32
+	var ids []string
+	for i := 0; i < 6; i++ {
+		job, _ := q.Submit(context.Background(), "k", "", func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+		ids = append(ids, job.ID)
+	}
+	for _, id := range ids {
+		waitFor(t, q, id)
+	}
+	if peak > 2 {
+		t.Errorf("peak concurrency: got %d, want <= 2", peak)
+	}
+}
+
+func TestQueue_historyTrimmed(t *testing.T) {
+	q := NewQueue(WithWorkers(1), WithHistory(2))
+	defer q.Stop()
+	var last string
+	for i := 0; i < 4; i++ {
+		job, _ := q.Submit(context.Background(), "k", "", func(ctx context.Context) error { return nil })
+		last = job.ID
+		waitFor(t, q, last)
+	}
+	if n := len(q.List("")); n != 2 {
+		t.Errorf("kept %d jobs, want 2", n)
+	}
+	if _, ok := q.Get(last); !ok {
+		t.Error("newest job should be kept")
+	}
+}
+
+func TestQueue_submitAfterStop(t *testing.T) {
+	q := NewQueue()
+	q.Stop()
+	_, err := q.Submit(context.Background(), "k", "", func(ctx context.Context) error { return nil })
+	if !errors.Is(err, ErrStopped) {
+		t.Errorf("got %v, want ErrStopped", err)
+	}
+}
+
+func TestQueue_pauseResume(t *testing.T) {
+	q := NewQueue(WithWorkers(2))
+	defer q.Stop()
+	q.Pause()
+	if !q.Paused() {
+		t.Fatal("queue should be paused")
+	}
+	var ran int32
+	job, _ := q.Submit(context.Background(), "k", "", func(ctx context.Context) error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	})
+	time.Sleep(50 * time.Millisecond)
+	if atomic.LoadInt32(&ran) != 0 {
+		t.Fatal("job ran while paused")
+	}
+	if got, _ := q.Get(job.ID); got.Status != StatusQueued {
+		t.Errorf("status while paused: got %q, want queued", got.Status)
+	}
+	q.Resume()
+	if done := waitFor(t, q, job.ID); done.Status != StatusCompleted {
+		t.Errorf("got %+v", done)
+	}
+	if q.Paused() {
+		t.Error("queue should not be paused after Resume")
+	}
+}
+
+func TestQueue_keyedJobsRunInOrder(t *testing.T) {
+	q := NewQueue(WithWorkers(2))
+	defer q.Stop()
+	release := make(chan struct{})
+	var running, overlapped int32
+	var order []string
+	var mu sync.Mutex
+	job := func(name string, wait bool) Func {
+		return func(ctx context.Context) error {
+			if atomic.AddInt32(&running, 1) > 1 {
+				atomic.StoreInt32(&overlapped, 1)
+			}
+			if wait {
+				<-release
+			}
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			atomic.AddInt32(&running, -1)
+			return nil
+		}
+	}
+	first, _ := q.SubmitKeyed(context.Background(), "index_file", "/a", "/a", job("index", true))
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if got, _ := q.Get(first.ID); got.Status == StatusRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first job did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	second, _ := q.SubmitKeyed(context.Background(), "index_file", "/a", "/a", job("reindex", false))
+	third, _ := q.SubmitKeyed(context.Background(), "delete_file", "/a", "/a", job("delete", false))
+	if got, _ := q.Get(second.ID); got.Status != StatusReplaced {
+		t.Errorf("second job: got %q, want replaced", got.Status)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if got, _ := q.Get(third.ID); got.Status != StatusQueued {
+		t.Errorf("third job ran before the first finished: %q", got.Status)
+	}
+	close(release)
+	if done := waitFor(t, q, third.ID); done.Status != StatusCompleted {
+		t.Errorf("third job: got %+v", done)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if atomic.LoadInt32(&overlapped) != 0 || len(order) != 2 || order[0] != "index" || order[1] != "delete" {
+		t.Errorf("ran %v (overlapped %d), want [index delete] one at a time", order, atomic.LoadInt32(&overlapped))
+	}
+}
+
+func TestQueue_keyedReplacedWhilePaused(t *testing.T) {
+	q := NewQueue(WithWorkers(1))
+	defer q.Stop()
+	q.Pause()
+	var ran int32
+	stale, _ := q.SubmitKeyed(context.Background(), "index_file", "/c", "/c", func(ctx context.Context) error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	})
+	// Let the worker take the job; it then waits for Resume.
+	for deadline := time.Now().Add(5 * time.Second); len(q.tasks) > 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("worker did not take the job")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	fresh, _ := q.SubmitKeyed(context.Background(), "delete_file", "/c", "/c", func(ctx context.Context) error { return nil })
+	q.Resume()
+	if done := waitFor(t, q, stale.ID); done.Status != StatusReplaced || atomic.LoadInt32(&ran) != 0 {
+		t.Errorf("job replaced while paused: got %+v, ran %d times", done, ran)
+	}
+	if done := waitFor(t, q, fresh.ID); done.Status != StatusCompleted {
+		t.Errorf("newer job: got %+v", done)
+	}
+}
+
+func TestQueue_keyedReplacesQueuedJob(t *testing.T) {
+	q := NewQueue(WithWorkers(1))
+	defer q.Stop()
+	release := make(chan struct{})
+	busy, _ := q.Submit(context.Background(), "k", "", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	var ran int32
+	stale, _ := q.SubmitKeyed(context.Background(), "index_file", "/b", "/b", func(ctx context.Context) error {
+		atomic.AddInt32(&ran, 1)
+		return nil
+	})
+	fresh, _ := q.SubmitKeyed(context.Background(), "delete_file", "/b", "/b", func(ctx context.Context) error { return nil })
+	close(release)
+	waitFor(t, q, busy.ID)
+	if done := waitFor(t, q, stale.ID); done.Status != StatusReplaced || atomic.LoadInt32(&ran) != 0 {
+		t.Errorf("queued job: got %+v, ran %d times", done, ran)
+	}
+	if done := waitFor(t, q, fresh.ID); done.Status != StatusCompleted {
+		t.Errorf("newer job: got %+v", done)
+	}
+	// The key is free again: a later job runs on its own.
+	later, _ := q.SubmitKeyed(context.Background(), "index_file", "/b", "/b", func(ctx context.Context) error { return nil })
+	if done := waitFor(t, q, later.ID); done.Status != StatusCompleted {
+		t.Errorf("later job: got %+v", done)
+	}
+}
+
+func TestQueue_keyedFirstRunsBeforeQueuedJobs(t *testing.T) {
+	q := NewQueue(WithWorkers(1))
+	defer q.Stop()
+	release := make(chan struct{})
+	var order []string
+	var mu sync.Mutex
+	job := func(name string) Func {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+	busy, _ := q.Submit(context.Background(), "k", "", func(ctx context.Context) error {
+		<-release
+		return nil
+	})
+	queued, _ := q.Submit(context.Background(), "k", "", job("queued"))
+	stale, _ := q.SubmitKeyed(context.Background(), "index_file", "/d", "/d", job("stale"))
+	first, _ := q.SubmitKeyedFirst(context.Background(), "index_file", "/d", "/d", job("first"))
+	if got, _ := q.Get(stale.ID); got.Status != StatusQueued {
+		t.Errorf("replaced job before it was taken: got %q, want queued", got.Status)
+	}
+	close(release)
+	waitFor(t, q, busy.ID)
+	waitFor(t, q, queued.ID)
+	if done := waitFor(t, q, stale.ID); done.Status != StatusReplaced {
+		t.Errorf("older job with the key: got %+v", done)
+	}
+	if done := waitFor(t, q, first.ID); done.Status != StatusCompleted {
+		t.Errorf("first job: got %+v", done)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 2 || order[0] != "first" || order[1] != "queued" {
+		t.Errorf("ran %v, want [first queued]", order)
+	}
+}
+
+func TestQueue_keyedFirstWaitsForRunningJob(t *testing.T) {
+	q := NewQueue(WithWorkers(2))
+	defer q.Stop()
+	release := make(chan struct{})
+	var finished int32
+	running, _ := q.SubmitKeyed(context.Background(), "index_file", "/e", "/e", func(ctx context.Context) error {
+		<-release
+		atomic.StoreInt32(&finished, 1)
+		return nil
+	})
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if got, _ := q.Get(running.ID); got.Status == StatusRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first job did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	var afterRunning int32
+	first, _ := q.SubmitKeyedFirst(context.Background(), "index_file", "/e", "/e", func(ctx context.Context) error {
+		afterRunning = atomic.LoadInt32(&finished)
+		return nil
+	})
+	time.Sleep(20 * time.Millisecond)
+	if got, _ := q.Get(first.ID); got.Status != StatusQueued {
+		t.Errorf("job started alongside the running one with its key: %q", got.Status)
+	}
+	close(release)
+	if done := waitFor(t, q, first.ID); done.Status != StatusCompleted || afterRunning != 1 {
+		t.Errorf("got %+v, ran after the running job: %v", done, afterRunning == 1)
+	}
+}
@@ -4,59 +4,220 @@ package keyword
 import (
 	"context"
 	"fmt"
+	"math"
 	"os"
 	"sort"
 	"strings"
+	"sync"
+	"unicode"
 
 	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/simple"
 	"github.com/blevesearch/bleve/v2/analysis/analyzer/standard"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ar"
+	"github.com/blevesearch/bleve/v2/analysis/lang/cjk"
+	"github.com/blevesearch/bleve/v2/analysis/lang/de"
+	"github.com/blevesearch/bleve/v2/analysis/lang/en"
+	"github.com/blevesearch/bleve/v2/analysis/lang/es"
+	"github.com/blevesearch/bleve/v2/analysis/lang/fr"
+	"github.com/blevesearch/bleve/v2/analysis/lang/it"
+	"github.com/blevesearch/bleve/v2/analysis/lang/nl"
+	"github.com/blevesearch/bleve/v2/analysis/lang/pt"
+	"github.com/blevesearch/bleve/v2/analysis/lang/ru"
+	"github.com/blevesearch/bleve/v2/analysis/lang/sv"
+	"github.com/blevesearch/bleve/v2/mapping"
 	blevequery "github.com/blevesearch/bleve/v2/search/query"
 	"github.com/hyperjump/sagasu/internal/models"
 )
 
 // BleveIndex implements KeywordIndex using Bleve.
 type BleveIndex struct {
-	index bleve.Index
+	index    bleve.Index
+	chunks   bleve.Index // chunk index of WithChunks, nil without one
+	path     string
+	analyzer string       // Bleve analyzer name used when the index is (re)created
+	mu       sync.RWMutex // guards index and chunks during Reset
+
+	stopwords map[string]bool // see WithStopwords
+	protected map[string]bool // see WithProtectedTerms
+	stemming  string          // see WithStemming
+	stemBoost float64
+	chunked   bool // see WithChunks
 }
 
-// NewBleveIndex creates or opens a Bleve index at path.
-// If the path already exists, the existing index is opened and reused so that
-// keyword search works with incremental sync (unchanged files are not re-indexed).
-// If you change the index mapping in code, remove the index directory to force a full re-index.
-func NewBleveIndex(path string) (*BleveIndex, error) {
+// analyzers maps the analyzer names accepted by NewBleveIndexWithAnalyzer to Bleve analyzers.
+// Besides standard and simple, each stems (and drops the stop words of) one language, but
+// cjk, which indexes Chinese, Japanese, and Korean text as overlapping character bigrams.
+var analyzers = map[string]string{
+	"standard":   standard.Name,
+	"english":    en.AnalyzerName,
+	"simple":     simple.Name,
+	"arabic":     ar.AnalyzerName,
+	"cjk":        cjk.AnalyzerName,
+	"german":     de.AnalyzerName,
+	"spanish":    es.AnalyzerName,
+	"french":     fr.AnalyzerName,
+	"italian":    it.AnalyzerName,
+	"dutch":      nl.AnalyzerName,
+	"portuguese": pt.AnalyzerName,
+	"russian":    ru.AnalyzerName,
+	"swedish":    sv.AnalyzerName,
+}
+
+// AnalyzerNames returns the analyzer names NewBleveIndexWithAnalyzer accepts, sorted.
+func AnalyzerNames() []string {
+	names := make([]string, 0, len(analyzers))
+	for name := range analyzers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newIndexMapping returns the document mapping used for new Bleve indexes, analyzing
+// title and content with the named Bleve analyzer, wrapped in a vocabularyAnalyzer when
+// the index has stopwords or protected terms, plus stemmed copies with WithStemming.
+func (b *BleveIndex) newIndexMapping() (*mapping.IndexMappingImpl, error) {
 	im := bleve.NewIndexMapping()
+	analyzer := b.analyzer
+	if len(b.stopwords) > 0 || len(b.protected) > 0 {
+		err := im.AddCustomAnalyzer(vocabularyAnalyzerName, map[string]interface{}{
+			"type":      vocabularyAnalyzerType,
+			"base":      analyzer,
+			"stopwords": sortedWords(b.stopwords),
+			"protected": sortedWords(b.protected),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to add vocabulary analyzer: %w", err)
+		}
+		analyzer = vocabularyAnalyzerName
+	}
 
 	docMapping := bleve.NewDocumentMapping()
 	textFieldMapping := bleve.NewTextFieldMapping()
 	// Use standard analyzer (lowercase + tokenize, no stemming) so queries like "bayes" match
 	// the exact word; English analyzer stems e.g. "Bayesian" -> "bayesi" and "bayes" -> "bay", so they don't match.
-	textFieldMapping.Analyzer = standard.Name
-	docMapping.AddFieldMappingsAt("content", textFieldMapping)
-	docMapping.AddFieldMappingsAt("title", textFieldMapping)
+	textFieldMapping.Analyzer = analyzer
+	if err := b.addTextFields(im, docMapping, textFieldMapping); err != nil {
+		return nil, err
+	}
 	keywordFieldMapping := bleve.NewKeywordFieldMapping()
 	docMapping.AddFieldMappingsAt("id", keywordFieldMapping)
 	im.AddDocumentMapping("document", docMapping)
 	im.DefaultType = "document"
+	im.DefaultAnalyzer = analyzer  // queries without a field are analyzed like the fields
 	im.DefaultMapping = docMapping // so _default type also indexes content/title
+	return im, nil
+}
 
+// NewBleveIndex creates or opens a Bleve index at path.
+// If the path already exists, the existing index is opened and reused so that
+// keyword search works with incremental sync (unchanged files are not re-indexed).
+// If you change the index mapping in code, call Reset (or remove the index directory) to force a full re-index.
+func NewBleveIndex(path string, opts ...BleveOption) (*BleveIndex, error) {
+	return NewBleveIndexWithAnalyzer(path, "", opts...)
+}
+
+// NewBleveIndexWithAnalyzer is like NewBleveIndex but analyzes title and content of a new
+// index with analyzer: "standard" (the default when empty), "english" (stemming), "simple"
+// (letters only, e.g. for code), "cjk" (character bigrams), or another language's stemmer
+// (see AnalyzerNames). An existing index keeps the analyzer it was created with until Reset,
+// including the stopwords, protected terms, and stemmed fields of its options.
+func NewBleveIndexWithAnalyzer(path, analyzer string, opts ...BleveOption) (*BleveIndex, error) {
+	if analyzer == "" {
+		analyzer = "standard"
+	}
+	bleveAnalyzer, ok := analyzers[analyzer]
+	if !ok {
+		return nil, fmt.Errorf("unknown analyzer %q (use one of %s)", analyzer, strings.Join(AnalyzerNames(), ", "))
+	}
+	b := &BleveIndex{path: path, analyzer: bleveAnalyzer}
+	for _, opt := range opts {
+		opt(b)
+	}
+	if _, err := b.stemAnalyzer(); err != nil {
+		return nil, err
+	}
 	if _, err := os.Stat(path); err == nil {
 		index, openErr := bleve.Open(path)
 		if openErr != nil {
 			return nil, fmt.Errorf("failed to open Bleve index: %w", openErr)
 		}
-		return &BleveIndex{index: index}, nil
+		b.index = index
+		if err := b.openChunks(false); err != nil {
+			_ = index.Close()
+			return nil, err
+		}
+		return b, nil
 	}
 
+	im, err := b.newIndexMapping()
+	if err != nil {
+		return nil, err
+	}
 	index, err := bleve.New(path, im)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Bleve index: %w", err)
 	}
-	return &BleveIndex{index: index}, nil
+	b.index = index
+	if err := b.openChunks(b.chunked); err != nil {
+		_ = index.Close()
+		return nil, err
+	}
+	return b, nil
+}
+
+// current returns the active Bleve index.
+func (b *BleveIndex) current() bleve.Index {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.index
+}
+
+// Reset drops every document by deleting the index directory and recreating it with the
+// current mapping. Searches running concurrently with Reset may fail while it is in progress.
+func (b *BleveIndex) Reset() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.index.Close(); err != nil {
+		return fmt.Errorf("failed to close Bleve index: %w", err)
+	}
+	if b.chunks != nil {
+		if err := b.chunks.Close(); err != nil {
+			return fmt.Errorf("failed to close Bleve chunk index: %w", err)
+		}
+		b.chunks = nil
+	}
+	if err := os.RemoveAll(b.path); err != nil {
+		return fmt.Errorf("failed to remove Bleve index: %w", err)
+	}
+	im, err := b.newIndexMapping()
+	if err != nil {
+		return err
+	}
+	index, err := bleve.New(b.path, im)
+	if err != nil {
+		return fmt.Errorf("failed to recreate Bleve index: %w", err)
+	}
+	b.index = index
+	return b.openChunks(b.chunked)
 }
 
 // Index indexes a document by id.
 func (b *BleveIndex) Index(ctx context.Context, id string, doc *models.Document) error {
-	return b.index.Index(id, doc)
+	return b.current().Index(id, doc)
+}
+
+// IndexBatch indexes docs in one Bleve batch.
+func (b *BleveIndex) IndexBatch(ctx context.Context, docs []*models.Document) error {
+	index := b.current()
+	batch := index.NewBatch()
+	for _, doc := range docs {
+		if err := batch.Index(doc.ID, doc); err != nil {
+			return err
+		}
+	}
+	return index.Batch(batch)
 }
 
 // Search runs a match query and returns up to limit results.
@@ -64,43 +225,74 @@ func (b *BleveIndex) Index(ctx context.Context, id string, doc *models.Document)
 // When opts.TitleBoost > 1, we run separate title and content queries and merge with additive scoring,
 // term coverage bonus, and phrase proximity boost for smarter multi-term ranking.
 // When opts.FuzzyEnabled is true, fuzzy matching is used for typo tolerance.
+// Queries using boolean syntax (AND/OR/NOT, -term, parentheses) are translated into a
+// Bleve boolean query instead; see IsBooleanQuery. With opts.Pattern the query is a
+// wildcard pattern or regex (see searchPattern).
 func (b *BleveIndex) Search(ctx context.Context, query string, limit int, opts *SearchOptions) ([]*KeywordResult, error) {
 	titleBoost := 1.0
 	phraseBoost := 1.0
+	phraseSlop := 0
 	fuzzyEnabled := false
-	fuzziness := 2 // default fuzziness level
+	fuzziness := DefaultFuzziness
+	coverageExponent := DefaultCoverageExponent
+	stems := true
+	var synonyms map[string][]string
+	var fields []string
 	if opts != nil {
+		stems = !opts.NoStems
 		if opts.TitleBoost > 0 {
 			titleBoost = opts.TitleBoost
 		}
 		if opts.PhraseBoost > 0 {
 			phraseBoost = opts.PhraseBoost
 		}
+		phraseSlop = minTwo(max(opts.PhraseSlop, 0), models.MaxPhraseSlop)
 		fuzzyEnabled = opts.FuzzyEnabled
 		if opts.Fuzziness > 0 {
 			fuzziness = opts.Fuzziness
 		}
+		if opts.CoverageExponent != nil {
+			coverageExponent = *opts.CoverageExponent
+		}
+		synonyms = opts.Synonyms
+		fields = bleveFields(opts.Fields)
 	}
 
+	if opts != nil && opts.Pattern != "" {
+		return b.searchPattern(ctx, query, opts.Pattern, limit, titleBoost, fields)
+	}
+	if IsBooleanQuery(query) {
+		return b.searchBoolean(ctx, query, limit, titleBoost, fuzzyEnabled, fuzziness, fields, stems)
+	}
+	if len(fields) > 0 {
+		return b.searchFields(ctx, query, limit, fields, fuzzyEnabled, fuzziness)
+	}
 	if titleBoost <= 1.0 && phraseBoost <= 1.0 {
-		return b.searchSingle(ctx, query, limit, fuzzyEnabled, fuzziness)
+		if b.chunkIndex() != nil {
+			// Content is scored by chunk, apart from the title, but without the term
+			// coverage penalty of boosted searches.
+			return b.searchWithBoosts(ctx, query, limit, titleBoost, phraseBoost, 0, phraseSlop, fuzzyEnabled, fuzziness, synonyms, stems)
+		}
+		return b.searchSingle(ctx, query, limit, fuzzyEnabled, fuzziness, synonyms, stems)
 	}
-	return b.searchWithBoosts(ctx, query, limit, titleBoost, phraseBoost, fuzzyEnabled, fuzziness)
+	return b.searchWithBoosts(ctx, query, limit, titleBoost, phraseBoost, coverageExponent, phraseSlop, fuzzyEnabled, fuzziness, synonyms, stems)
 }
 
 // searchSingle runs one MatchQuery over all fields (original behavior).
 // When fuzzyEnabled is true, uses FuzzyQuery for each term with the specified fuzziness.
-func (b *BleveIndex) searchSingle(ctx context.Context, query string, limit int, fuzzyEnabled bool, fuzziness int) ([]*KeywordResult, error) {
+// With stems, the stemmed fields match too (see withStems).
+func (b *BleveIndex) searchSingle(ctx context.Context, query string, limit int, fuzzyEnabled bool, fuzziness int, synonyms map[string][]string, stems bool) ([]*KeywordResult, error) {
 	var q blevequery.Query
 	if fuzzyEnabled {
 		q = b.buildFuzzyQuery(query, fuzziness, "")
 	} else {
 		q = bleve.NewMatchQuery(query)
 	}
+	q = withSynonyms(b.maybeStems(q, query, "", stems), b.terms(query), synonyms, "")
 	search := bleve.NewSearchRequest(q)
 	search.Size = limit
 	search.Fields = []string{"*"}
-	results, err := b.index.Search(search)
+	results, err := b.current().Search(search)
 	if err != nil {
 		return nil, fmt.Errorf("Bleve search failed: %w", err)
 	}
@@ -111,12 +303,145 @@ func (b *BleveIndex) searchSingle(ctx context.Context, query string, limit int,
 	return out, nil
 }
 
+// searchBoolean runs a boolean query (see boolquery.go). Each term or phrase matches the
+// title (weighted by titleBoost) or the content, or one of fields when given; negated
+// clauses exclude documents.
+func (b *BleveIndex) searchBoolean(ctx context.Context, query string, limit int, titleBoost float64, fuzzyEnabled bool, fuzziness int, fields []string, stems bool) ([]*KeywordResult, error) {
+	root := parseBoolQuery(query)
+	if root == nil {
+		return nil, nil
+	}
+	req := bleve.NewSearchRequest(root.toBleve(b.boolLeaf(titleBoost, fuzzyEnabled, fuzziness, fields, stems)))
+	req.Size = limit
+	results, err := b.current().SearchInContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Bleve boolean search failed: %w", err)
+	}
+	out := make([]*KeywordResult, len(results.Hits))
+	for i, hit := range results.Hits {
+		out[i] = &KeywordResult{ID: hit.ID, Score: hit.Score}
+	}
+	return out, nil
+}
+
+// Count returns the number of documents matching query: every document a boolean query
+// matches, or any document containing a query term (fuzzily when opts enables it).
+func (b *BleveIndex) Count(ctx context.Context, query string, opts *SearchOptions) (uint64, error) {
+	fuzzyEnabled, fuzziness := false, DefaultFuzziness
+	stems := true
+	var synonyms map[string][]string
+	var fields []string
+	if opts != nil {
+		stems = !opts.NoStems
+		fuzzyEnabled = opts.FuzzyEnabled
+		if opts.Fuzziness > 0 {
+			fuzziness = opts.Fuzziness
+		}
+		synonyms = opts.Synonyms
+		fields = bleveFields(opts.Fields)
+		if opts.Pattern != "" {
+			return b.countPattern(ctx, query, opts.Pattern, fields)
+		}
+	}
+	var q blevequery.Query
+	switch {
+	case IsBooleanQuery(query):
+		root := parseBoolQuery(query)
+		if root == nil {
+			return 0, nil
+		}
+		q = root.toBleve(b.boolLeaf(1, fuzzyEnabled, fuzziness, fields, stems))
+	case len(fields) > 0:
+		if q = b.fieldsQuery(query, fields, fuzzyEnabled, fuzziness); q == nil {
+			return 0, nil
+		}
+	case fuzzyEnabled:
+		q = withSynonyms(b.maybeStems(b.buildFuzzyQuery(query, fuzziness, ""), query, "", stems), b.terms(query), synonyms, "")
+	default:
+		q = withSynonyms(b.maybeStems(bleve.NewMatchQuery(query), query, "", stems), b.terms(query), synonyms, "")
+	}
+	req := bleve.NewSearchRequest(q)
+	req.Size = 0
+	results, err := b.current().SearchInContext(ctx, req)
+	if err != nil {
+		return 0, fmt.Errorf("Bleve count failed: %w", err)
+	}
+	return results.Total, nil
+}
+
+// MatchNegated returns the subset of ids matching any NOT clause of the boolean query.
+// It returns nil when the query has no negation.
+func (b *BleveIndex) MatchNegated(ctx context.Context, query string, ids []string) (map[string]bool, error) {
+	if len(ids) == 0 || !IsBooleanQuery(query) {
+		return nil, nil
+	}
+	root := parseBoolQuery(query)
+	if root == nil {
+		return nil, nil
+	}
+	negated := negatedNodes(root)
+	if len(negated) == 0 {
+		return nil, nil
+	}
+	leaf := b.boolLeaf(1, false, 0, nil, false)
+	disj := make([]blevequery.Query, len(negated))
+	for i, n := range negated {
+		disj[i] = n.toBleve(leaf)
+	}
+	q := bleve.NewConjunctionQuery(bleve.NewDocIDQuery(ids), bleve.NewDisjunctionQuery(disj...))
+	req := bleve.NewSearchRequest(q)
+	req.Size = len(ids)
+	results, err := b.current().SearchInContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Bleve negation search failed: %w", err)
+	}
+	matched := make(map[string]bool, len(results.Hits))
+	for _, hit := range results.Hits {
+		matched[hit.ID] = true
+	}
+	return matched, nil
+}
+
+// MatchScoped returns the subset of ids satisfying every required field-scoped term or
+// phrase of the boolean query (e.g. title:budget). It returns nil when the query has none.
+func (b *BleveIndex) MatchScoped(ctx context.Context, query string, ids []string) (map[string]bool, error) {
+	if len(ids) == 0 || !IsBooleanQuery(query) {
+		return nil, nil
+	}
+	root := parseBoolQuery(query)
+	if root == nil {
+		return nil, nil
+	}
+	scoped := scopedNodes(root)
+	if len(scoped) == 0 {
+		return nil, nil
+	}
+	leaf := b.boolLeaf(1, false, 0, nil, true)
+	conj := []blevequery.Query{bleve.NewDocIDQuery(ids)}
+	for _, n := range scoped {
+		conj = append(conj, n.toBleve(leaf))
+	}
+	req := bleve.NewSearchRequest(bleve.NewConjunctionQuery(conj...))
+	req.Size = len(ids)
+	results, err := b.current().SearchInContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Bleve field scope search failed: %w", err)
+	}
+	matched := make(map[string]bool, len(results.Hits))
+	for _, hit := range results.Hits {
+		matched[hit.ID] = true
+	}
+	return matched, nil
+}
+
 // searchWithBoosts runs smart multi-term search with:
 // 1. Additive scoring: score = (titleScore * titleBoost) + contentScore
-// 2. Term coverage bonus: documents matching more query terms get higher scores
-// 3. Phrase proximity boost: documents with adjacent query terms get boosted
-// When fuzzyEnabled is true, uses FuzzyQuery for typo tolerance.
-func (b *BleveIndex) searchWithBoosts(ctx context.Context, query string, limit int, titleBoost, phraseBoost float64, fuzzyEnabled bool, fuzziness int) ([]*KeywordResult, error) {
+// 2. Term coverage penalty: scores are multiplied by (matched terms / query terms)^coverageExponent
+// 3. Phrase proximity boost: documents with the query terms in order, adjacent or with at
+// most phraseSlop other words between them, get boosted
+// When fuzzyEnabled is true, uses FuzzyQuery for typo tolerance. With stems, the stemmed
+// fields match too. With a chunk index, contentScore is that of the best chunk.
+func (b *BleveIndex) searchWithBoosts(ctx context.Context, query string, limit int, titleBoost, phraseBoost, coverageExponent float64, phraseSlop int, fuzzyEnabled bool, fuzziness int, synonyms map[string][]string, stems bool) ([]*KeywordResult, error) {
 	// Request enough from each so merged top "limit" is correct (same doc can appear in both).
 	reqSize := limit * 2
 	if reqSize < 50 {
@@ -124,7 +449,7 @@ func (b *BleveIndex) searchWithBoosts(ctx context.Context, query string, limit i
 	}
 
 	// Tokenize query into terms for term coverage calculation
-	terms := tokenizeQuery(query)
+	terms := b.terms(query)
 	numTerms := len(terms)
 
 	// Run title and content queries
@@ -140,44 +465,37 @@ func (b *BleveIndex) searchWithBoosts(ctx context.Context, query string, limit i
 		cq.SetField("content")
 		contentQuery = cq
 	}
+	titleQuery = withSynonyms(b.maybeStems(titleQuery, query, "title", stems), terms, synonyms, "title")
+	contentQuery = withSynonyms(b.maybeStems(contentQuery, query, "content", stems), terms, synonyms, "content")
 	titleReq := bleve.NewSearchRequest(titleQuery)
 	titleReq.Size = reqSize
 	titleReq.Fields = []string{"*"}
 
-	contentReq := bleve.NewSearchRequest(contentQuery)
-	contentReq.Size = reqSize
-	contentReq.Fields = []string{"*"}
-
-	titleResults, err := b.index.Search(titleReq)
+	titleResults, err := b.current().Search(titleReq)
 	if err != nil {
 		return nil, fmt.Errorf("Bleve title search failed: %w", err)
 	}
-	contentResults, err := b.index.Search(contentReq)
+	contentScores, bestChunks, err := b.contentScores(ctx, contentQuery, reqSize)
 	if err != nil {
-		return nil, fmt.Errorf("Bleve content search failed: %w", err)
+		return nil, err
 	}
 
-	// Collect title and content scores separately for additive merge
+	// Collect title scores separately for additive merge
 	titleScores := make(map[string]float64)
-	contentScores := make(map[string]float64)
-
 	for _, hit := range titleResults.Hits {
 		titleScores[hit.ID] = hit.Score * titleBoost
 	}
-	for _, hit := range contentResults.Hits {
-		contentScores[hit.ID] = hit.Score
-	}
 
 	// Calculate term coverage: for multi-term queries, count how many terms each doc matches
 	termCoverage := make(map[string]int) // docID -> number of matched terms
-	if numTerms > 1 {
-		termCoverage = b.calculateTermCoverage(terms, reqSize, fuzzyEnabled, fuzziness)
+	if numTerms > 1 && coverageExponent != 0 {
+		termCoverage = b.calculateTermCoverage(terms, reqSize, fuzzyEnabled, fuzziness, synonyms, stems)
 	}
 
 	// Check for phrase matches if phraseBoost > 1 and query has multiple terms
 	phraseMatches := make(map[string]bool)
 	if phraseBoost > 1.0 && numTerms > 1 {
-		phraseMatches = b.findPhraseMatches(query, reqSize)
+		phraseMatches = b.findPhraseMatches(query, reqSize, phraseSlop)
 	}
 
 	// Merge scores: ADDITIVE (title + content) * termCoverageMultiplier * phraseMultiplier
@@ -195,11 +513,13 @@ func (b *BleveIndex) searchWithBoosts(ctx context.Context, query string, limit i
 		baseScore := titleScores[id] + contentScores[id]
 
 		// Term coverage multiplier: PENALIZE documents that don't match all terms
-		// Formula: (matched/total)^2 - this heavily penalizes partial matches
+		// Formula: (matched/total)^coverageExponent - with the default exponent 2 this
+		// heavily penalizes partial matches
 		// - 2/2 terms: (1.0)^2 = 1.0 (no penalty)
 		// - 1/2 terms: (0.5)^2 = 0.25 (75% penalty!)
 		// - 1/3 terms: (0.33)^2 = 0.11 (89% penalty!)
-		// This ensures documents matching ALL query terms rank higher than partial matches
+		// This ensures documents matching ALL query terms rank higher than partial matches.
+		// Lower exponents suit corpora where partial matches matter; 0 disables the penalty.
 		termCoverageMultiplier := 1.0
 		if numTerms > 1 {
 			matched := termCoverage[id]
@@ -207,7 +527,7 @@ func (b *BleveIndex) searchWithBoosts(ctx context.Context, query string, limit i
 				matched = 1 // at least matched once to be in results
 			}
 			coverage := float64(matched) / float64(numTerms)
-			termCoverageMultiplier = coverage * coverage // squared penalty
+			termCoverageMultiplier = math.Pow(coverage, coverageExponent)
 		}
 
 		// Phrase boost multiplier
@@ -236,6 +556,9 @@ func (b *BleveIndex) searchWithBoosts(ctx context.Context, query string, limit i
 	out := make([]*KeywordResult, len(merged))
 	for i, s := range merged {
 		out[i] = &KeywordResult{ID: s.id, Score: s.score}
+		if n, ok := bestChunks[s.id]; ok {
+			out[i].Chunk = &n
+		}
 	}
 	return out, nil
 }
@@ -253,10 +576,191 @@ func tokenizeQuery(query string) []string {
 	return terms
 }
 
+// queryTerms is tokenizeQuery for matching: runs of CJK characters, which are written
+// without spaces, are split into overlapping bigrams like the cjk analyzer's tokens, so
+// "東京の天気" counts as four terms rather than one that no document contains.
+func queryTerms(query string) []string {
+	words := tokenizeQuery(query)
+	terms := make([]string, 0, len(words))
+	for _, w := range words {
+		if !hasCJK(w) {
+			terms = append(terms, w)
+			continue
+		}
+		var run, other []rune
+		flush := func() {
+			if len(other) > 0 {
+				terms = append(terms, string(other))
+				other = other[:0]
+			}
+			if len(run) == 1 {
+				terms = append(terms, string(run))
+			}
+			for i := 0; i+1 < len(run); i++ {
+				terms = append(terms, string(run[i:i+2]))
+			}
+			run = run[:0]
+		}
+		for _, r := range w {
+			if isCJK(r) {
+				if len(other) > 0 {
+					flush()
+				}
+				run = append(run, r)
+				continue
+			}
+			if len(run) > 0 {
+				flush()
+			}
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				other = append(other, r)
+			} else {
+				flush()
+			}
+		}
+		flush()
+	}
+	return terms
+}
+
+// isCJK reports whether r is a Han, kana, or Hangul character, counting the prolonged
+// sound mark "ー" that katakana words use but Unicode files under no script.
+func isCJK(r rune) bool {
+	return r == 'ー' || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+func hasCJK(s string) bool {
+	return strings.IndexFunc(s, isCJK) >= 0
+}
+
+// terms is queryTerms without the index's stopwords.
+func (b *BleveIndex) terms(query string) []string {
+	return b.withoutStopwords(queryTerms(query))
+}
+
+// fuzzyTermQuery returns a FuzzyQuery for term, or a MatchQuery when term is CJK: edit
+// distance means nothing on one- and two-character tokens, and the analyzer must turn
+// the term into the index's tokens. Protected terms are matched exactly too.
+func (b *BleveIndex) fuzzyTermQuery(term string, fuzziness int, field string) blevequery.Query {
+	if hasCJK(term) || b.protected[term] {
+		mq := bleve.NewMatchQuery(term)
+		if field != "" {
+			mq.SetField(field)
+		}
+		return mq
+	}
+	fq := bleve.NewFuzzyQuery(term)
+	fq.SetFuzziness(fuzziness)
+	if field != "" {
+		fq.SetField(field)
+	}
+	return fq
+}
+
+// searchFields runs a plain query against fields only (see SearchOptions.Fields).
+func (b *BleveIndex) searchFields(ctx context.Context, query string, limit int, fields []string, fuzzyEnabled bool, fuzziness int) ([]*KeywordResult, error) {
+	q := b.fieldsQuery(query, fields, fuzzyEnabled, fuzziness)
+	if q == nil {
+		return nil, nil
+	}
+	req := bleve.NewSearchRequest(q)
+	req.Size = limit
+	results, err := b.current().SearchInContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Bleve field search failed: %w", err)
+	}
+	out := make([]*KeywordResult, len(results.Hits))
+	for i, hit := range results.Hits {
+		out[i] = &KeywordResult{ID: hit.ID, Score: hit.Score}
+	}
+	return out, nil
+}
+
+// bleveFields maps SearchOptions.Fields to Bleve field names, skipping unknown ones.
+func bleveFields(fields []string) []string {
+	var out []string
+	for _, f := range fields {
+		switch f {
+		case FieldTitle:
+			out = append(out, "title")
+		case FieldPath:
+			out = append(out, "metadata.source_path")
+		}
+	}
+	return out
+}
+
+// fieldsQuery returns a query requiring every term of query in one of fields, matched as
+// a word (fuzzily when enabled) or, at half the weight, as the start of a word, so
+// "budg" finds budget.xlsx. It returns nil when query has no terms.
+func (b *BleveIndex) fieldsQuery(query string, fields []string, fuzzyEnabled bool, fuzziness int) blevequery.Query {
+	terms := b.terms(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	conjuncts := make([]blevequery.Query, 0, len(terms))
+	for _, term := range terms {
+		var alts []blevequery.Query
+		for _, field := range fields {
+			if fuzzyEnabled {
+				alts = append(alts, b.fuzzyTermQuery(term, fuzziness, field))
+			} else {
+				mq := bleve.NewMatchQuery(term)
+				mq.SetField(field)
+				alts = append(alts, mq)
+			}
+			if !hasCJK(term) {
+				pq := bleve.NewPrefixQuery(term)
+				pq.SetField(field)
+				pq.SetBoost(0.5)
+				alts = append(alts, pq)
+			}
+		}
+		conjuncts = append(conjuncts, bleve.NewDisjunctionQuery(alts...))
+	}
+	return bleve.NewConjunctionQuery(conjuncts...)
+}
+
+// SynonymBoost weighs a match of a synonym against a match of the query term itself.
+const SynonymBoost = 0.8
+
+// withSynonyms returns q, or, when synonyms has alternatives for any of terms, q or'ed
+// with a query per alternative: a MatchQuery for a word, a MatchPhraseQuery for a phrase.
+// If field is empty, the alternatives search all fields.
+func withSynonyms(q blevequery.Query, terms []string, synonyms map[string][]string, field string) blevequery.Query {
+	if len(synonyms) == 0 {
+		return q
+	}
+	queries := []blevequery.Query{q}
+	for _, term := range terms {
+		for _, alt := range synonyms[term] {
+			if strings.Contains(alt, " ") {
+				pq := bleve.NewMatchPhraseQuery(alt)
+				pq.SetBoost(SynonymBoost)
+				if field != "" {
+					pq.SetField(field)
+				}
+				queries = append(queries, pq)
+				continue
+			}
+			mq := bleve.NewMatchQuery(alt)
+			mq.SetBoost(SynonymBoost)
+			if field != "" {
+				mq.SetField(field)
+			}
+			queries = append(queries, mq)
+		}
+	}
+	if len(queries) == 1 {
+		return q
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
 // buildFuzzyQuery creates a disjunction of FuzzyQueries for each term in the query.
 // If field is empty, searches all fields; otherwise restricts to the specified field.
 func (b *BleveIndex) buildFuzzyQuery(queryStr string, fuzziness int, field string) blevequery.Query {
-	terms := tokenizeQuery(queryStr)
+	terms := b.terms(queryStr)
 	if len(terms) == 0 {
 		// Fallback to match query for empty terms
 		mq := bleve.NewMatchQuery(queryStr)
@@ -268,24 +772,14 @@ func (b *BleveIndex) buildFuzzyQuery(queryStr string, fuzziness int, field strin
 
 	if len(terms) == 1 {
 		// Single term: use simple FuzzyQuery
-		fq := bleve.NewFuzzyQuery(terms[0])
-		fq.SetFuzziness(fuzziness)
-		if field != "" {
-			fq.SetField(field)
-		}
-		return fq
+		return b.fuzzyTermQuery(terms[0], fuzziness, field)
 	}
 
 	// Multiple terms: combine with BooleanQuery (should match)
 	// This mimics MatchQuery behavior where any term can match
 	queries := make([]blevequery.Query, 0, len(terms))
 	for _, term := range terms {
-		fq := bleve.NewFuzzyQuery(term)
-		fq.SetFuzziness(fuzziness)
-		if field != "" {
-			fq.SetField(field)
-		}
-		queries = append(queries, fq)
+		queries = append(queries, b.fuzzyTermQuery(term, fuzziness, field))
 	}
 
 	// Use DisjunctionQuery - matches if any term matches (OR semantics)
@@ -294,22 +788,22 @@ func (b *BleveIndex) buildFuzzyQuery(queryStr string, fuzziness int, field strin
 }
 
 // calculateTermCoverage counts how many unique query terms each document matches.
-// When fuzzyEnabled is true, uses FuzzyQuery for each term.
-func (b *BleveIndex) calculateTermCoverage(terms []string, reqSize int, fuzzyEnabled bool, fuzziness int) map[string]int {
+// When fuzzyEnabled is true, uses FuzzyQuery for each term. A synonym of a term counts
+// as the term.
+func (b *BleveIndex) calculateTermCoverage(terms []string, reqSize int, fuzzyEnabled bool, fuzziness int, synonyms map[string][]string, stems bool) map[string]int {
 	coverage := make(map[string]int)
 	for _, term := range terms {
 		// Run a match/fuzzy query for each individual term
 		var q blevequery.Query
 		if fuzzyEnabled {
-			fq := bleve.NewFuzzyQuery(term)
-			fq.SetFuzziness(fuzziness)
-			q = fq
+			q = b.fuzzyTermQuery(term, fuzziness, "")
 		} else {
 			q = bleve.NewMatchQuery(term)
 		}
+		q = withSynonyms(b.maybeStems(q, term, "", stems), []string{term}, synonyms, "")
 		req := bleve.NewSearchRequest(q)
 		req.Size = reqSize
-		results, err := b.index.Search(req)
+		results, err := b.current().Search(req)
 		if err != nil {
 			continue
 		}
@@ -320,53 +814,155 @@ func (b *BleveIndex) calculateTermCoverage(terms []string, reqSize int, fuzzyEna
 	return coverage
 }
 
-// findPhraseMatches finds documents where the query appears as a phrase (adjacent terms).
-func (b *BleveIndex) findPhraseMatches(query string, reqSize int) map[string]bool {
+// findPhraseMatches finds documents where the query appears as a phrase in the content or
+// title: its terms in order, with at most slop other words between them in total (0 =
+// adjacent).
+func (b *BleveIndex) findPhraseMatches(query string, reqSize, slop int) map[string]bool {
 	matches := make(map[string]bool)
+	for _, field := range []string{"content", "title"} {
+		if slop == 0 {
+			// MatchPhraseQuery analyzes query like the field and requires adjacent terms.
+			phraseQuery := bleve.NewMatchPhraseQuery(query)
+			phraseQuery.SetField(field)
+			req := bleve.NewSearchRequest(phraseQuery)
+			req.Size = reqSize
+			results, err := b.current().Search(req)
+			if err != nil {
+				return matches
+			}
+			for _, hit := range results.Hits {
+				matches[hit.ID] = true
+			}
+			continue
+		}
+		if err := b.findSloppyPhrases(query, field, reqSize, slop, matches); err != nil {
+			return matches
+		}
+	}
+	return matches
+}
 
-	// Use MatchPhraseQuery which is more flexible than PhraseQuery
-	// It allows some slop (terms don't need to be immediately adjacent)
-	phraseQuery := bleve.NewMatchPhraseQuery(query)
-	phraseQuery.SetField("content")
-	req := bleve.NewSearchRequest(phraseQuery)
+// findSloppyPhrases adds to matches the documents with the terms of query in field, in
+// order, with at most slop other words between them. Bleve phrases have no slop, so the
+// documents with every term are fetched with their term locations and checked here.
+func (b *BleveIndex) findSloppyPhrases(query, field string, reqSize, slop int, matches map[string]bool) error {
+	m := b.current().Mapping()
+	terms := analyzeTerms(m.AnalyzerNamed(m.AnalyzerNameForPath(field)), query)
+	if len(terms) == 0 {
+		return nil
+	}
+	var must []blevequery.Query
+	for _, term := range terms {
+		tq := bleve.NewTermQuery(term)
+		tq.SetField(field)
+		must = append(must, tq)
+	}
+	req := bleve.NewSearchRequest(bleve.NewConjunctionQuery(must...))
 	req.Size = reqSize
-	results, err := b.index.Search(req)
+	req.IncludeLocations = true
+	results, err := b.current().Search(req)
 	if err != nil {
-		return matches
+		return err
 	}
 	for _, hit := range results.Hits {
-		matches[hit.ID] = true
+		positions := make([][]uint64, len(terms))
+		for i, term := range terms {
+			for _, loc := range hit.Locations[field][term] {
+				positions[i] = append(positions[i], loc.Pos)
+			}
+		}
+		if withinSlop(positions, slop) {
+			matches[hit.ID] = true
+		}
 	}
+	return nil
+}
 
-	// Also check title field for phrase matches
-	titlePhraseQuery := bleve.NewMatchPhraseQuery(query)
-	titlePhraseQuery.SetField("title")
-	titleReq := bleve.NewSearchRequest(titlePhraseQuery)
-	titleReq.Size = reqSize
-	titleResults, err := b.index.Search(titleReq)
-	if err != nil {
-		return matches
+// withinSlop reports whether one position can be picked from each list, in increasing
+// order, with at most slop positions between the first and the last beyond those picked.
+// Taking the earliest position after the previous one keeps the span of each start
+// position smallest.
+func withinSlop(positions [][]uint64, slop int) bool {
+	if len(positions) == 0 {
+		return false
 	}
-	for _, hit := range titleResults.Hits {
-		matches[hit.ID] = true
+	for _, start := range positions[0] {
+		last, ok := start, true
+		for _, next := range positions[1:] {
+			earliest, found := uint64(0), false
+			for _, pos := range next {
+				if pos > last && (!found || pos < earliest) {
+					earliest, found = pos, true
+				}
+			}
+			if !found {
+				ok = false
+				break
+			}
+			last = earliest
+		}
+		if ok && int(last-start)-(len(positions)-1) <= slop {
+			return true
+		}
 	}
-
-	return matches
+	return false
 }
 
-// Delete removes a document from the index.
+// Delete removes a document, and its chunks, from the index.
 func (b *BleveIndex) Delete(ctx context.Context, id string) error {
-	return b.index.Delete(id)
+	if err := b.current().Delete(id); err != nil {
+		return err
+	}
+	chunks := b.chunkIndex()
+	if chunks == nil {
+		return nil
+	}
+	batch := chunks.NewBatch()
+	if err := deleteChunks(chunks, batch, id); err != nil {
+		return err
+	}
+	return chunks.Batch(batch)
 }
 
-// Close closes the Bleve index.
+// Close closes the Bleve index and its chunk index.
 func (b *BleveIndex) Close() error {
-	return b.index.Close()
+	if chunks := b.chunkIndex(); chunks != nil {
+		if err := chunks.Close(); err != nil {
+			return err
+		}
+	}
+	return b.current().Close()
 }
 
 // DocCount returns the total number of documents in the index.
 func (b *BleveIndex) DocCount() (uint64, error) {
-	return b.index.DocCount()
+	return b.current().DocCount()
+}
+
+// docIDsPage is how many document IDs DocIDs reads per search.
+const docIDsPage = 1000
+
+// DocIDs returns the IDs of the documents in the index, paging through them in ID order.
+func (b *BleveIndex) DocIDs(ctx context.Context) ([]string, error) {
+	index := b.current()
+	var ids []string
+	for {
+		req := bleve.NewSearchRequestOptions(bleve.NewMatchAllQuery(), docIDsPage, 0, false)
+		req.SortBy([]string{"_id"})
+		if len(ids) > 0 {
+			req.SetSearchAfter([]string{ids[len(ids)-1]})
+		}
+		res, err := index.SearchInContext(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list documents: %w", err)
+		}
+		for _, hit := range res.Hits {
+			ids = append(ids, hit.ID)
+		}
+		if len(res.Hits) < docIDsPage {
+			return ids, nil
+		}
+	}
 }
 
 // GetTermDocFrequency returns the number of documents containing the given term.
@@ -375,8 +971,8 @@ func (b *BleveIndex) GetTermDocFrequency(term string) (int, error) {
 	// Search for the term and count unique documents
 	q := bleve.NewMatchQuery(term)
 	req := bleve.NewSearchRequest(q)
-	req.Size = 10000 // Get all matching docs for accurate count
-	results, err := b.index.Search(req)
+	req.Size = 0 // Total counts every match without loading hits
+	results, err := b.current().Search(req)
 	if err != nil {
 		return 0, fmt.Errorf("failed to search for term frequency: %w", err)
 	}
@@ -415,7 +1011,7 @@ func (b *BleveIndex) GetAllTerms() ([]string, error) {
 	seen := make(map[string]struct{})
 
 	// Get terms from content field
-	contentDict, err := b.index.FieldDict("content")
+	contentDict, err := b.current().FieldDict("content")
 	if err == nil {
 		defer contentDict.Close()
 		for {
@@ -431,7 +1027,7 @@ func (b *BleveIndex) GetAllTerms() ([]string, error) {
 	}
 
 	// Get terms from title field
-	titleDict, err := b.index.FieldDict("title")
+	titleDict, err := b.current().FieldDict("title")
 	if err == nil {
 		defer titleDict.Close()
 		for {
@@ -2,8 +2,10 @@ package keyword
 
 import (
 	"context"
+	"math"
 	"os"
 	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/hyperjump/sagasu/internal/models"
@@ -281,6 +283,49 @@ func TestBleveIndex_Search_termCoverageBoostsAllTermMatches(t *testing.T) {
 	}
 }
 
+// TestBleveIndex_Search_coverageExponent tests that CoverageExponent sets the partial-match
+// penalty: a document matching one of two terms scores (1/2)^exponent of its unpenalized score.
+func TestBleveIndex_Search_coverageExponent(t *testing.T) {
+	idx, err := NewBleveIndex(filepath.Join(t.TempDir(), "bleve"))
+	if err != nil {
+		t.Fatalf("NewBleveIndex: %v", err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+	ctx := context.Background()
+	docs := []*models.Document{
+		{ID: "both", Title: "notes.txt", Content: "symon highlights for the quarter"},
+		{ID: "one", Title: "deck.pptx", Content: "symon roadmap and hiring"},
+	}
+	for _, doc := range docs {
+		if err := idx.Index(ctx, doc.ID, doc); err != nil {
+			t.Fatalf("Index %s: %v", doc.ID, err)
+		}
+	}
+	scoreOf := func(opts *SearchOptions) float64 {
+		t.Helper()
+		results, err := idx.Search(ctx, "symon highlights", 10, opts)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		for _, r := range results {
+			if r.ID == "one" {
+				return r.Score
+			}
+		}
+		t.Fatalf("partial match missing from %v", results)
+		return 0
+	}
+	zero, one := 0.0, 1.0
+	unpenalized := scoreOf(&SearchOptions{TitleBoost: 3, CoverageExponent: &zero})
+	squared := scoreOf(&SearchOptions{TitleBoost: 3})
+	linear := scoreOf(&SearchOptions{TitleBoost: 3, CoverageExponent: &one})
+	if math.Abs(squared-unpenalized/4) > 1e-9 || math.Abs(linear-unpenalized/2) > 1e-9 {
+		t.Errorf("scores: exponent 0 = %v, default = %v, exponent 1 = %v", unpenalized, squared, linear)
+	}
+}
+
 // TestBleveIndex_Search_phraseBoostBoostedAdjacentTerms tests that documents with
 // query terms appearing close together rank higher than those with scattered terms.
 func TestBleveIndex_Search_phraseBoostBoostedAdjacentTerms(t *testing.T) {
@@ -332,6 +377,54 @@ func TestBleveIndex_Search_phraseBoostBoostedAdjacentTerms(t *testing.T) {
 	}
 }
 
+// TestBleveIndex_Search_phraseSlop tests that PhraseSlop lets the phrase boost apply to
+// query terms with other words between them, in query order only.
+func TestBleveIndex_Search_phraseSlop(t *testing.T) {
+	idx, err := NewBleveIndex(filepath.Join(t.TempDir(), "bleve"))
+	if err != nil {
+		t.Fatalf("NewBleveIndex: %v", err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	ctx := context.Background()
+	for _, doc := range []*models.Document{
+		{ID: "close", Title: "a.txt", Content: "Notes on machine and deep learning."},
+		{ID: "reversed", Title: "b.txt", Content: "Notes on learning about the machine."},
+		{ID: "far", Title: "c.txt", Content: "The machine was broken, so we spent the week learning."},
+	} {
+		if err := idx.Index(ctx, doc.ID, doc); err != nil {
+			t.Fatalf("Index %s: %v", doc.ID, err)
+		}
+	}
+
+	scores := func(slop int) map[string]float64 {
+		t.Helper()
+		results, err := idx.Search(ctx, "machine learning", 10, &SearchOptions{TitleBoost: 3, PhraseBoost: 2, PhraseSlop: slop})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		out := make(map[string]float64)
+		for _, r := range results {
+			out[r.ID] = r.Score
+		}
+		return out
+	}
+	strict, sloppy := scores(0), scores(2)
+	if len(strict) != 3 || len(sloppy) != 3 {
+		t.Fatalf("expected 3 results, got %v and %v", strict, sloppy)
+	}
+	if ratio := sloppy["close"] / strict["close"]; math.Abs(ratio-2) > 1e-9 {
+		t.Errorf("close: slop 2 scores %g times slop 0, want 2", ratio)
+	}
+	for _, id := range []string{"reversed", "far"} {
+		if sloppy[id] != strict[id] {
+			t.Errorf("%s: boosted with slop 2 (%g, was %g)", id, sloppy[id], strict[id])
+		}
+	}
+}
+
 // TestBleveIndex_Search_additiveScoring tests that title and content scores are added
 // (not max'd), so a document with matches in both ranks higher.
 func TestBleveIndex_Search_additiveScoring(t *testing.T) {
@@ -697,6 +790,64 @@ func TestBleveIndex_Search_fuzzyDefaultFuzziness(t *testing.T) {
 	}
 }
 
+func TestQueryTerms_cjk(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"Budget Report", []string{"budget", "report"}},
+		{"東京の天気", []string{"東京", "京の", "の天", "天気"}},
+		{"東京 天気", []string{"東京", "天気"}},
+		{"会議 Q3レポート", []string{"会議", "q3", "レポ", "ポー", "ート"}},
+		{"東", []string{"東"}},
+		{"서울날씨", []string{"서울", "울날", "날씨"}},
+	}
+	for _, tt := range tests {
+		if got := queryTerms(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("queryTerms(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+// TestBleveIndex_Search_cjk tests that CJK queries match with the cjk analyzer, with and
+// without fuzzy search, and that term coverage counts the query's bigrams.
+func TestBleveIndex_Search_cjk(t *testing.T) {
+	idx, err := NewBleveIndexWithAnalyzer(filepath.Join(t.TempDir(), "bleve"), "cjk")
+	if err != nil {
+		t.Fatalf("NewBleveIndexWithAnalyzer: %v", err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	ctx := context.Background()
+	docs := []*models.Document{
+		{ID: "weather", Title: "天気.txt", Content: "東京の天気は明日から雨になる予報です。"},
+		{ID: "station", Title: "駅.txt", Content: "東京駅の近くで会議があります。"},
+	}
+	for _, doc := range docs {
+		if err := idx.Index(ctx, doc.ID, doc); err != nil {
+			t.Fatalf("Index: %v", err)
+		}
+	}
+
+	for _, opts := range []*SearchOptions{nil, {FuzzyEnabled: true, Fuzziness: 2}} {
+		results, err := idx.Search(ctx, "東京の天気", 10, opts)
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("opts %+v: expected both documents, got %d", opts, len(results))
+		}
+		if results[0].ID != "weather" {
+			t.Errorf("opts %+v: expected the document with every bigram first, got %s", opts, results[0].ID)
+		}
+		if results[0].Score < results[1].Score*4 {
+			t.Errorf("opts %+v: partial match should be penalized: %v vs %v", opts, results[0].Score, results[1].Score)
+		}
+	}
+}
+
 // TestBleveIndex_DocCount tests the DocCount method.
 func TestBleveIndex_DocCount(t *testing.T) {
 	dir := t.TempDir()
@@ -945,3 +1096,102 @@ func TestBleveIndex_GetTermFrequency(t *testing.T) {
 		t.Errorf("GetTermFrequency('machine') = %d, want 1", freq)
 	}
 }
+
+func TestBleveIndex_Reset(t *testing.T) {
+	dir := t.TempDir()
+	idx, err := NewBleveIndex(filepath.Join(dir, "bleve"))
+	if err != nil {
+		t.Fatalf("NewBleveIndex: %v", err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	ctx := context.Background()
+	if err := idx.Index(ctx, "d1", &models.Document{ID: "d1", Content: "reset me"}); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if err := idx.Reset(); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	count, err := idx.DocCount()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("DocCount after Reset: got %d, want 0", count)
+	}
+	if err := idx.Index(ctx, "d2", &models.Document{ID: "d2", Content: "fresh"}); err != nil {
+		t.Fatalf("Index after Reset: %v", err)
+	}
+	results, err := idx.Search(ctx, "fresh", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected 1 result after Reset, got %d", len(results))
+	}
+}
+
+func TestBleveIndex_Search_fields(t *testing.T) {
+	idx, err := NewBleveIndex(filepath.Join(t.TempDir(), "bleve"))
+	if err != nil {
+		t.Fatalf("NewBleveIndex: %v", err)
+	}
+	defer func() {
+		_ = idx.Close()
+	}()
+
+	ctx := context.Background()
+	docs := []*models.Document{
+		{ID: "sheet", Title: "budget-q3.xlsx", Content: "Totals per team.", Metadata: map[string]interface{}{"source_path": "/work/finance/budget-q3.xlsx"}},
+		{ID: "notes", Title: "notes.md", Content: "The budget for q3 is tight.", Metadata: map[string]interface{}{"source_path": "/work/finance/notes.md"}},
+		{ID: "plan", Title: "plan.md", Content: "Holiday plan.", Metadata: map[string]interface{}{"source_path": "/home/trips/plan.md"}},
+	}
+	for _, doc := range docs {
+		if err := idx.Index(ctx, doc.ID, doc); err != nil {
+			t.Fatalf("Index: %v", err)
+		}
+	}
+
+	ids := func(query string, opts *SearchOptions) []string {
+		t.Helper()
+		results, err := idx.Search(ctx, query, 10, opts)
+		if err != nil {
+			t.Fatalf("Search %q: %v", query, err)
+		}
+		var out []string
+		for _, r := range results {
+			out = append(out, r.ID)
+		}
+		slices.Sort(out)
+		return out
+	}
+	title := &SearchOptions{TitleBoost: 3, PhraseBoost: 1.5, Fields: []string{FieldTitle}}
+	path := &SearchOptions{Fields: []string{FieldPath}}
+
+	if got := ids("budget q3", title); !slices.Equal(got, []string{"sheet"}) {
+		t.Errorf("title budget q3 = %v, want the file name match only", got)
+	}
+	if got := ids("budg", title); !slices.Equal(got, []string{"sheet"}) {
+		t.Errorf("title prefix budg = %v, want sheet", got)
+	}
+	if got := ids("budget holiday", title); len(got) != 0 {
+		t.Errorf("title budget holiday = %v, want none: every term must match", got)
+	}
+	if got := ids("finance", path); !slices.Equal(got, []string{"notes", "sheet"}) {
+		t.Errorf("path finance = %v, want both files under it", got)
+	}
+	if got := ids("work -finance OR trips", path); !slices.Equal(got, []string{"plan"}) {
+		t.Errorf("path boolean = %v, want plan", got)
+	}
+	if got := ids("notes", title); !slices.Equal(got, []string{"notes"}) {
+		t.Errorf("title notes = %v, want notes.md by prefix", got)
+	}
+	if got := ids("finanse", &SearchOptions{Fields: []string{FieldPath}, FuzzyEnabled: true, Fuzziness: 1}); !slices.Equal(got, []string{"notes", "sheet"}) {
+		t.Errorf("fuzzy path = %v, want both", got)
+	}
+	if n, err := idx.Count(ctx, "work", path); err != nil || n != 2 {
+		t.Errorf("Count path work = %d, %v; want 2", n, err)
+	}
+}
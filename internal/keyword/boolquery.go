@@ -0,0 +1,652 @@
+package keyword
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis"
+	blevequery "github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Boolean query syntax:
+//
+//	machine learning           any term may match (same as a plain query)
+//	python AND pandas          both must match
+//	python OR golang           either may match
+//	NOT java, -java            exclude documents matching java
+//	(python OR golang) AND web grouping with parentheses
+//	"neural network"           exact phrase
+//	deep NEAR/3 learning       both, at most 3 words apart, in either order
+//	title:budget report        title must contain budget; report may match anywhere
+//
+// Operators must be upper case. NEAR binds tightest (NEAR without a distance allows
+// DefaultNearDistance words), then adjacent terms, then AND, then OR. Phrases, NEAR
+// groups, and field-scoped terms and phrases (title:term, title:"a phrase") are required
+// rather than optional next to other terms; scoped ones only match that field.
+// Parsing is lenient: missing closing parentheses are implied and stray operators
+// or parentheses are ignored, so every query yields a result.
+
+type boolOp int
+
+const (
+	opTerm   boolOp = iota
+	opPhrase        // quoted phrase
+	opAny           // adjacent units: positives should match, negations must not
+	opAnd
+	opOr
+	opNot
+	opNear // two terms or phrases within distance words of each other
+)
+
+// DefaultNearDistance is the distance of NEAR without one; MaxNearDistance caps the
+// distance of NEAR/n, since each allowed gap is a phrase query of its own.
+const (
+	DefaultNearDistance = 5
+	MaxNearDistance     = 20
+)
+
+// scopedFields are the field prefixes a term or phrase can be scoped to.
+var scopedFields = map[string]bool{"title": true}
+
+// boolNode is a node of a parsed boolean query.
+type boolNode struct {
+	op       boolOp
+	text     string
+	field    string // for terms and phrases: the scoped field, or "" for title and content
+	distance int    // for NEAR: the most words allowed between the two children
+	children []*boolNode
+}
+
+// IsBooleanQuery reports whether query uses boolean syntax: AND/OR/NOT/NEAR operators,
+// a leading "-" on a term, parentheses, quoted phrases, or field-scoped terms. Other
+// queries keep the plain search path.
+func IsBooleanQuery(query string) bool {
+	if strings.ContainsAny(query, "()") {
+		return true
+	}
+	for _, tok := range tokenizeBoolQuery(query) {
+		switch {
+		case tok == "AND" || tok == "OR" || tok == "NOT":
+			return true
+		case isNegatedTerm(tok) || strings.HasPrefix(tok, "-\""):
+			return true
+		case strings.HasPrefix(tok, "\"") && phraseNode(tok) != nil:
+			return true
+		case scopedField(tok) != "":
+			return true
+		}
+		if _, ok := NearDistance(tok); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// PositiveQueryText returns the terms and phrases of query that are neither negated nor
+// field-scoped, joined by spaces, for use as semantic search text. Non-boolean queries
+// are returned unchanged.
+func PositiveQueryText(query string) string {
+	if !IsBooleanQuery(query) {
+		return query
+	}
+	var parts []string
+	var walk func(n *boolNode)
+	walk = func(n *boolNode) {
+		switch n.op {
+		case opTerm, opPhrase:
+			if n.field == "" {
+				parts = append(parts, n.text)
+			}
+		case opNot:
+			return
+		default: // NEAR walks its two children like the rest
+			for _, c := range n.children {
+				walk(c)
+			}
+		}
+	}
+	if root := parseBoolQuery(query); root != nil {
+		walk(root)
+	}
+	return strings.Join(parts, " ")
+}
+
+// HasNegation reports whether query is a boolean query with a NOT clause.
+func HasNegation(query string) bool {
+	if !IsBooleanQuery(query) {
+		return false
+	}
+	root := parseBoolQuery(query)
+	return root != nil && len(negatedNodes(root)) > 0
+}
+
+// HasFieldScope reports whether query has a required field-scoped term or phrase, exact
+// phrase, or NEAR group (see scopedNodes).
+func HasFieldScope(query string) bool {
+	if !IsBooleanQuery(query) {
+		return false
+	}
+	root := parseBoolQuery(query)
+	return root != nil && len(scopedNodes(root)) > 0
+}
+
+// scopedNodes returns the field-scoped terms and phrases, exact phrases, and NEAR groups
+// every match must satisfy: those reached from the root through adjacency and AND only,
+// not under OR or NOT.
+func scopedNodes(n *boolNode) []*boolNode {
+	switch n.op {
+	case opTerm, opPhrase:
+		if n.field != "" || n.op == opPhrase {
+			return []*boolNode{n}
+		}
+	case opNear:
+		return []*boolNode{n}
+	case opAny, opAnd:
+		var out []*boolNode
+		for _, c := range n.children {
+			out = append(out, scopedNodes(c)...)
+		}
+		return out
+	}
+	return nil
+}
+
+// scopedField returns the field of a "field:value" token (optionally negated), or "" when
+// tok is not field-scoped.
+func scopedField(tok string) string {
+	tok = strings.TrimPrefix(tok, "-")
+	i := strings.IndexByte(tok, ':')
+	if i <= 0 || i == len(tok)-1 || !scopedFields[tok[:i]] {
+		return ""
+	}
+	return tok[:i]
+}
+
+// negatedNodes returns the operands of the outermost NOT nodes.
+func negatedNodes(n *boolNode) []*boolNode {
+	if n.op == opNot {
+		return []*boolNode{n.children[0]}
+	}
+	var out []*boolNode
+	for _, c := range n.children {
+		out = append(out, negatedNodes(c)...)
+	}
+	return out
+}
+
+// isNegatedTerm reports whether tok is "-term". Negative numbers ("-5") and dashes ("--")
+// are treated as plain terms.
+func isNegatedTerm(tok string) bool {
+	if len(tok) < 2 || tok[0] != '-' {
+		return false
+	}
+	next := rune(tok[1])
+	return next != '-' && next != '"' && !unicode.IsDigit(next)
+}
+
+// tokenizeBoolQuery splits query into words, quoted phrases (kept with their quotes, and
+// an optional leading "-"), and parentheses.
+func tokenizeBoolQuery(query string) []string {
+	var tokens []string
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, string(r))
+			i++
+		case r == '"' || (r == '-' && i+1 < len(runes) && runes[i+1] == '"'):
+			start := i
+			if r == '-' {
+				i++
+			}
+			i++ // opening quote
+			for i < len(runes) && runes[i] != '"' {
+				i++
+			}
+			if i < len(runes) {
+				i++ // closing quote
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+			}
+			if i < len(runes) && runes[i] == '"' && i > start && runes[i-1] == ':' {
+				// field:"a phrase"
+				i++
+				for i < len(runes) && runes[i] != '"' {
+					i++
+				}
+				if i < len(runes) {
+					i++
+				}
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		}
+	}
+	return tokens
+}
+
+// boolParser is a recursive-descent parser over tokenizeBoolQuery tokens.
+type boolParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseBoolQuery parses query into a tree. Returns nil when the query has no terms.
+func parseBoolQuery(query string) *boolNode {
+	p := &boolParser{tokens: tokenizeBoolQuery(query)}
+	var parts []*boolNode
+	for p.pos < len(p.tokens) {
+		if n := p.parseOr(); n != nil {
+			parts = append(parts, n)
+		} else {
+			p.pos++ // skip a stray ")" or operator
+		}
+	}
+	return combine(opAny, parts)
+}
+
+func (p *boolParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *boolParser) parseOr() *boolNode {
+	var parts []*boolNode
+	for {
+		if n := p.parseAnd(); n != nil {
+			parts = append(parts, n)
+		}
+		if p.peek() != "OR" {
+			break
+		}
+		p.pos++
+	}
+	return combine(opOr, parts)
+}
+
+func (p *boolParser) parseAnd() *boolNode {
+	var parts []*boolNode
+	for {
+		if n := p.parseSeq(); n != nil {
+			parts = append(parts, n)
+		}
+		if p.peek() != "AND" {
+			break
+		}
+		p.pos++
+	}
+	return combine(opAnd, parts)
+}
+
+func (p *boolParser) parseSeq() *boolNode {
+	var parts []*boolNode
+	for {
+		switch p.peek() {
+		case "", ")", "AND", "OR":
+			return combine(opAny, parts)
+		}
+		if n := p.parseNear(); n != nil {
+			parts = append(parts, n)
+		}
+	}
+}
+
+// parseNear parses a unit, joined by NEAR with the next one when both are unscoped terms
+// or phrases; otherwise the units stay side by side. Only the first NEAR of a chain
+// groups ("a NEAR b NEAR c" is a NEAR b, then c).
+func (p *boolParser) parseNear() *boolNode {
+	left := p.parseUnary()
+	distance, ok := NearDistance(p.peek())
+	if !ok || left == nil {
+		return left
+	}
+	p.pos++
+	switch p.peek() {
+	case "", ")", "AND", "OR":
+		return left
+	}
+	right := p.parseUnary()
+	if !nearOperand(left) || !nearOperand(right) {
+		return combine(opAny, nonNil(left, right))
+	}
+	return &boolNode{op: opNear, distance: distance, children: []*boolNode{left, right}}
+}
+
+// nearText formats a NEAR node as written, e.g. deep NEAR/3 "machine learning".
+func (n *boolNode) nearText() string {
+	side := func(c *boolNode) string {
+		if c.op == opPhrase {
+			return `"` + c.text + `"`
+		}
+		return c.text
+	}
+	return side(n.children[0]) + " NEAR/" + strconv.Itoa(n.distance) + " " + side(n.children[1])
+}
+
+// nearOperand reports whether n can be a side of NEAR.
+func nearOperand(n *boolNode) bool {
+	return n != nil && (n.op == opTerm || n.op == opPhrase) && n.field == ""
+}
+
+func nonNil(nodes ...*boolNode) []*boolNode {
+	var out []*boolNode
+	for _, n := range nodes {
+		if n != nil {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+// NearDistance parses a NEAR operator: "NEAR" allows DefaultNearDistance words between
+// its sides and "NEAR/n" n, capped at MaxNearDistance.
+func NearDistance(tok string) (int, bool) {
+	if tok == "NEAR" {
+		return DefaultNearDistance, true
+	}
+	n, ok := strings.CutPrefix(tok, "NEAR/")
+	if !ok {
+		return 0, false
+	}
+	d, err := strconv.Atoi(n)
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return minTwo(d, MaxNearDistance), true
+}
+
+func (p *boolParser) parseUnary() *boolNode {
+	tok := p.peek()
+	switch {
+	case tok == "" || tok == ")" || tok == "AND" || tok == "OR":
+		return nil // NOT without an operand
+	case tok == "NOT":
+		p.pos++
+		return negate(p.parseUnary())
+	case tok == "(":
+		p.pos++
+		n := p.parseOr()
+		if p.peek() == ")" {
+			p.pos++
+		}
+		return n
+	case strings.HasPrefix(tok, "-\""):
+		p.pos++
+		return negate(phraseNode(tok[1:]))
+	case strings.HasPrefix(tok, "\""):
+		p.pos++
+		return phraseNode(tok)
+	case scopedField(tok) != "":
+		p.pos++
+		if strings.HasPrefix(tok, "-") {
+			return negate(scopedNode(tok[1:]))
+		}
+		return scopedNode(tok)
+	case isNegatedTerm(tok):
+		p.pos++
+		return negate(&boolNode{op: opTerm, text: tok[1:]})
+	case tok == "-":
+		p.pos++
+		if p.peek() == "(" {
+			return negate(p.parseUnary())
+		}
+		return nil
+	default:
+		p.pos++
+		if _, ok := NearDistance(tok); ok {
+			return nil // NEAR without a left side
+		}
+		return &boolNode{op: opTerm, text: tok}
+	}
+}
+
+func phraseNode(quoted string) *boolNode {
+	text := strings.TrimSpace(strings.Trim(quoted, "\""))
+	if text == "" {
+		return nil
+	}
+	return &boolNode{op: opPhrase, text: text}
+}
+
+// scopedNode parses a "field:term" or field:"phrase" token.
+func scopedNode(tok string) *boolNode {
+	field, value, _ := strings.Cut(tok, ":")
+	var n *boolNode
+	if strings.HasPrefix(value, "\"") {
+		n = phraseNode(value)
+	} else {
+		n = &boolNode{op: opTerm, text: value}
+	}
+	if n != nil {
+		n.field = field
+	}
+	return n
+}
+
+func negate(n *boolNode) *boolNode {
+	if n == nil {
+		return nil
+	}
+	return &boolNode{op: opNot, children: []*boolNode{n}}
+}
+
+// combine returns a node of op over parts, collapsing a single part to itself.
+func combine(op boolOp, parts []*boolNode) *boolNode {
+	switch len(parts) {
+	case 0:
+		return nil
+	case 1:
+		return parts[0]
+	}
+	return &boolNode{op: op, children: parts}
+}
+
+// leafQuery builds the query for one term, phrase, or NEAR node.
+type leafQuery func(n *boolNode) blevequery.Query
+
+// toBleve translates n into a Bleve query using leaf for terms and phrases.
+func (n *boolNode) toBleve(leaf leafQuery) blevequery.Query {
+	switch n.op {
+	case opTerm, opPhrase, opNear:
+		return leaf(n)
+	case opNot:
+		q := bleve.NewBooleanQuery()
+		q.AddMustNot(n.children[0].toBleve(leaf))
+		return q
+	case opAnd:
+		conj := make([]blevequery.Query, len(n.children))
+		for i, c := range n.children {
+			conj[i] = c.toBleve(leaf)
+		}
+		return bleve.NewConjunctionQuery(conj...)
+	case opOr:
+		disj := make([]blevequery.Query, len(n.children))
+		for i, c := range n.children {
+			disj[i] = c.toBleve(leaf)
+		}
+		return bleve.NewDisjunctionQuery(disj...)
+	default: // opAny
+		q := bleve.NewBooleanQuery()
+		for _, c := range n.children {
+			if c.op == opNot {
+				q.AddMustNot(c.children[0].toBleve(leaf))
+			} else if c.field != "" || c.op == opPhrase || c.op == opNear {
+				q.AddMust(c.toBleve(leaf))
+			} else {
+				q.AddShould(c.toBleve(leaf))
+			}
+		}
+		return q
+	}
+}
+
+// boolLeaf returns a leafQuery that matches title (boosted) or content, or any of fields
+// when given, or only the scoped field of a field-scoped node. Protected terms are not
+// matched fuzzily. With stems, a term also matches its stemmed forms (see withStems);
+// phrases and NEAR groups are matched as written.
+func (b *BleveIndex) boolLeaf(titleBoost float64, fuzzyEnabled bool, fuzziness int, fields []string, stems bool) leafQuery {
+	return func(n *boolNode) blevequery.Query {
+		fieldQuery := func(field string, boost float64) blevequery.Query {
+			switch n.op {
+			case opPhrase:
+				q := bleve.NewMatchPhraseQuery(n.text)
+				q.SetField(field)
+				q.SetBoost(boost)
+				return q
+			case opNear:
+				q := b.nearQuery(n, field)
+				q.SetBoost(boost)
+				return q
+			}
+			q := bleve.NewMatchQuery(n.text)
+			q.SetField(field)
+			if fuzzyEnabled && !b.protected[strings.ToLower(n.text)] {
+				q.SetFuzziness(fuzziness)
+			}
+			if !stems {
+				q.SetBoost(boost)
+				return q
+			}
+			sq := b.withStems(q, n.text, field)
+			if bq, ok := sq.(blevequery.BoostableQuery); ok {
+				bq.SetBoost(boost)
+			}
+			return sq
+		}
+		if n.field == "title" {
+			return fieldQuery("title", titleBoost)
+		}
+		if len(fields) > 0 {
+			queries := make([]blevequery.Query, len(fields))
+			for i, f := range fields {
+				queries[i] = fieldQuery(f, 1)
+			}
+			return bleve.NewDisjunctionQuery(queries...)
+		}
+		return bleve.NewDisjunctionQuery(fieldQuery("title", titleBoost), fieldQuery("content", 1))
+	}
+}
+
+// nearQuery matches the two sides of a NEAR node in field with at most n.distance words
+// between them, in either order. Bleve phrases have no slop, so each allowed gap is a
+// phrase query of its own, with empty terms standing for the words in between.
+func (b *BleveIndex) nearQuery(n *boolNode, field string) blevequery.BoostableQuery {
+	m := b.current().Mapping()
+	analyzer := m.AnalyzerNamed(m.AnalyzerNameForPath(field))
+	left, right := analyzeTerms(analyzer, n.children[0].text), analyzeTerms(analyzer, n.children[1].text)
+	if len(left) == 0 || len(right) == 0 {
+		return bleve.NewMatchNoneQuery()
+	}
+	var alts []blevequery.Query
+	for gap := 0; gap <= n.distance; gap++ {
+		for _, sides := range [][2][]string{{left, right}, {right, left}} {
+			terms := append(append(append([]string{}, sides[0]...), make([]string, gap)...), sides[1]...)
+			alts = append(alts, bleve.NewPhraseQuery(terms, field))
+		}
+	}
+	return bleve.NewDisjunctionQuery(alts...)
+}
+
+// analyzeTerms returns the terms analyzer makes of text, or its lower-cased words when
+// there is no analyzer.
+func analyzeTerms(analyzer analysis.Analyzer, text string) []string {
+	if analyzer == nil {
+		return tokenizeQuery(text)
+	}
+	var terms []string
+	for _, tok := range analyzer.Analyze([]byte(text)) {
+		terms = append(terms, string(tok.Term))
+	}
+	return terms
+}
+
+// QueryParts are the pieces of a query as the keyword index reads them.
+type QueryParts struct {
+	Boolean   bool     // see IsBooleanQuery; otherwise any term may match
+	Operators []string // AND, OR, NOT, and NEAR, each listed once, in order of first use
+	Terms     []string // words matched in title or content
+	Phrases   []string // quoted phrases matched in title or content
+	Near      []string // NEAR groups, as "a NEAR/3 b"
+	Negated   []string // excluded terms, and excluded phrases in quotes
+	Scoped    []ScopedPart
+}
+
+// ScopedPart is a field-scoped term or phrase, such as title:budget.
+type ScopedPart struct {
+	Field   string
+	Value   string
+	Negated bool
+}
+
+// ParseQueryParts returns the parts of query. A plain query is split into its words.
+func ParseQueryParts(query string) *QueryParts {
+	parts := &QueryParts{Boolean: IsBooleanQuery(query)}
+	if !parts.Boolean {
+		parts.Terms = tokenizeQuery(strings.ReplaceAll(query, `"`, " "))
+		return parts
+	}
+	root := parseBoolQuery(query)
+	if root == nil {
+		return parts
+	}
+	addOp := func(op string) {
+		for _, o := range parts.Operators {
+			if o == op {
+				return
+			}
+		}
+		parts.Operators = append(parts.Operators, op)
+	}
+	var walk func(n *boolNode, negated bool)
+	walk = func(n *boolNode, negated bool) {
+		switch n.op {
+		case opTerm, opPhrase:
+			text := n.text
+			if n.op == opPhrase {
+				text = `"` + text + `"`
+			}
+			switch {
+			case n.field != "":
+				parts.Scoped = append(parts.Scoped, ScopedPart{Field: n.field, Value: text, Negated: negated})
+			case negated:
+				parts.Negated = append(parts.Negated, text)
+			case n.op == opPhrase:
+				parts.Phrases = append(parts.Phrases, n.text)
+			default:
+				parts.Terms = append(parts.Terms, n.text)
+			}
+			return
+		case opNear:
+			addOp("NEAR")
+			if text := n.nearText(); negated {
+				parts.Negated = append(parts.Negated, text)
+			} else {
+				parts.Near = append(parts.Near, text)
+			}
+			return
+		case opNot:
+			addOp("NOT")
+			walk(n.children[0], !negated)
+			return
+		case opAnd:
+			addOp("AND")
+		case opOr:
+			addOp("OR")
+		}
+		for _, c := range n.children {
+			walk(c, negated)
+		}
+	}
+	walk(root, false)
+	return parts
+}
@@ -0,0 +1,278 @@
+package keyword
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestIsBooleanQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"machine learning", false},
+		{"state-of-the-art", false},
+		{"temperature -5", false},
+		{"python AND pandas", true},
+		{"python or golang", false}, // operators are upper case only
+		{"python OR golang", true},
+		{"NOT java", true},
+		{"python -java", true},
+		{`python -"java script"`, true},
+		{"(python)", true},
+		{"title:budget report", true},
+		{`title:"annual budget"`, true},
+		{"meeting at 10:30", false}, // not a scoped field
+		{"title:", false},
+		{`machine "learning models"`, true},
+		{`5" screen`, true},
+		{`""`, false},
+		{"deep NEAR/3 learning", true},
+		{"deep NEAR learning", true},
+		{"near field", false},
+	}
+	for _, tt := range tests {
+		if got := IsBooleanQuery(tt.query); got != tt.want {
+			t.Errorf("IsBooleanQuery(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+// render prints a parsed tree in a compact prefix form for assertions.
+func render(n *boolNode) string {
+	if n == nil {
+		return "<nil>"
+	}
+	field := ""
+	if n.field != "" {
+		field = n.field + ":"
+	}
+	switch n.op {
+	case opTerm:
+		return field + n.text
+	case opPhrase:
+		return field + `"` + n.text + `"`
+	case opNot:
+		return "NOT(" + render(n.children[0]) + ")"
+	case opNear:
+		return n.nearText()
+	}
+	name := map[boolOp]string{opAny: "ANY", opAnd: "AND", opOr: "OR"}[n.op]
+	parts := make([]string, len(n.children))
+	for i, c := range n.children {
+		parts[i] = render(c)
+	}
+	return name + "(" + strings.Join(parts, " ") + ")"
+}
+
+func TestParseBoolQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"a AND b OR c", "OR(AND(a b) c)"},
+		{"a OR b AND c", "OR(a AND(b c))"},
+		{"a b AND c", "AND(ANY(a b) c)"},
+		{"(a OR b) AND NOT c", "AND(OR(a b) NOT(c))"},
+		{`"neural network" -java`, `ANY("neural network" NOT(java))`},
+		{"-(a OR b) c", "ANY(NOT(OR(a b)) c)"},
+		{"(a OR b", "OR(a b)"}, // missing ")" implied
+		{"a ) AND", "a"},       // stray tokens ignored
+		{"NOT", "<nil>"},       // nothing to negate
+		{"AND OR", "<nil>"},
+		{`title:budget title:"q3 plan" report`, `ANY(title:budget title:"q3 plan" report)`},
+		{"-title:draft notes", "ANY(NOT(title:draft) notes)"},
+		{`deep NEAR/3 "neural nets" today`, `ANY(deep NEAR/3 "neural nets" today)`},
+		{"a NEAR b NEAR c", "ANY(a NEAR/5 b c)"},
+		{"a NEAR/99 b OR c", "OR(a NEAR/20 b c)"},
+		{"NEAR/2 a", "a"},              // no left side
+		{"a NEAR/2", "a"},              // no right side
+		{"-a NEAR b", "ANY(NOT(a) b)"}, // negations are not grouped
+		{"a NEAR/x b", "ANY(a NEAR/x b)"},
+	}
+	for _, tt := range tests {
+		if got := render(parseBoolQuery(tt.query)); got != tt.want {
+			t.Errorf("parseBoolQuery(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestPositiveQueryText(t *testing.T) {
+	if got := PositiveQueryText("machine learning"); got != "machine learning" {
+		t.Errorf("plain query: got %q", got)
+	}
+	if got := PositiveQueryText(`(python OR "data science") AND NOT java -scala`); got != "python data science" {
+		t.Errorf("boolean query: got %q", got)
+	}
+	if !HasNegation("python -java") || HasNegation("python OR java") || HasNegation("python java") {
+		t.Error("HasNegation mismatch")
+	}
+	if got := PositiveQueryText("title:budget quarterly report"); got != "quarterly report" {
+		t.Errorf("scoped query: got %q", got)
+	}
+	if !HasFieldScope("title:budget report") || HasFieldScope("title:a OR report") || HasFieldScope("-title:a report") {
+		t.Error("HasFieldScope mismatch")
+	}
+	if !HasFieldScope(`"annual report" budget`) || !HasFieldScope("deep NEAR learning") || HasFieldScope(`"annual report" OR budget`) {
+		t.Error("HasFieldScope mismatch for phrases and NEAR")
+	}
+	if got := PositiveQueryText(`deep NEAR/2 learning -java`); got != "deep learning" {
+		t.Errorf("NEAR query: got %q", got)
+	}
+}
+
+func TestBleveIndex_SearchBoolean(t *testing.T) {
+	idx, err := NewBleveIndex(filepath.Join(t.TempDir(), "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	ctx := context.Background()
+	docs := map[string]string{
+		"py":     "python web framework",
+		"pyjava": "python and java interop",
+		"go":     "golang web server",
+		"java":   "java enterprise beans",
+	}
+	for id, content := range docs {
+		if err := idx.Index(ctx, id, &models.Document{ID: id, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(q string) string {
+		results, err := idx.Search(ctx, q, 10, &SearchOptions{TitleBoost: 2, PhraseBoost: 1.5})
+		if err != nil {
+			t.Fatalf("Search(%q): %v", q, err)
+		}
+		ids := make([]string, len(results))
+		for i, r := range results {
+			ids[i] = r.ID
+		}
+		sort.Strings(ids)
+		return strings.Join(ids, ",")
+	}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"python -java", "py"},
+		{"python AND NOT java", "py"},
+		{"web AND (python OR golang)", "go,py"},
+		{"java OR golang", "go,java,pyjava"},
+		{"NOT python", "go,java"},
+		{`"web server" OR beans`, "go,java"},
+		{`"web server" golang`, "go"},
+		{`"server web" golang`, ""},
+		{"python NEAR/1 java", "pyjava"},
+		{"java NEAR/1 python", "pyjava"},
+		{"python NEAR/0 java", ""},
+		{"golang NEAR server", "go"},
+	}
+	for _, tt := range tests {
+		if got := search(tt.query); got != tt.want {
+			t.Errorf("Search(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	matched, err := idx.MatchNegated(ctx, "web -java", []string{"py", "pyjava", "java"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matched) != 2 || !matched["pyjava"] || !matched["java"] {
+		t.Errorf("MatchNegated: got %v", matched)
+	}
+}
+
+func TestBleveIndex_SearchFieldScoped(t *testing.T) {
+	idx, err := NewBleveIndex(filepath.Join(t.TempDir(), "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	ctx := context.Background()
+	docs := []*models.Document{
+		{ID: "b1", Title: "budget 2026", Content: "quarterly report on spending"},
+		{ID: "b2", Title: "notes", Content: "the budget report is late"},
+		{ID: "b3", Title: "budget draft", Content: "numbers only"},
+	}
+	for _, d := range docs {
+		if err := idx.Index(ctx, d.ID, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(q string) string {
+		results, err := idx.Search(ctx, q, 10, nil)
+		if err != nil {
+			t.Fatalf("Search(%q): %v", q, err)
+		}
+		ids := make([]string, len(results))
+		for i, r := range results {
+			ids[i] = r.ID
+		}
+		sort.Strings(ids)
+		return strings.Join(ids, ",")
+	}
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"title:budget", "b1,b3"},
+		{"title:budget report", "b1,b3"}, // title scope required, report optional
+		{`title:"budget draft"`, "b3"},
+		{"report -title:notes", "b1"},
+	}
+	for _, tt := range tests {
+		if got := search(tt.query); got != tt.want {
+			t.Errorf("Search(%q) = %s, want %s", tt.query, got, tt.want)
+		}
+	}
+
+	matched, err := idx.MatchScoped(ctx, "title:budget report", []string{"b1", "b2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matched) != 1 || !matched["b1"] {
+		t.Errorf("MatchScoped: got %v", matched)
+	}
+}
+
+func TestParseQueryParts(t *testing.T) {
+	got := ParseQueryParts(`(python OR "data science") AND NOT java -"java script" title:budget -title:draft`)
+	want := &QueryParts{
+		Boolean:   true,
+		Operators: []string{"AND", "OR", "NOT"},
+		Terms:     []string{"python"},
+		Phrases:   []string{"data science"},
+		Negated:   []string{"java", `"java script"`},
+		Scoped: []ScopedPart{
+			{Field: "title", Value: "budget"},
+			{Field: "title", Value: "draft", Negated: true},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("boolean query:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	got = ParseQueryParts(`Machine learning models`)
+	if got.Boolean || !reflect.DeepEqual(got.Terms, []string{"machine", "learning", "models"}) || got.Phrases != nil {
+		t.Errorf("plain query: got %+v", got)
+	}
+
+	got = ParseQueryParts(`"learning models" deep NEAR/3 networks -"data NEAR java"`)
+	want = &QueryParts{
+		Boolean:   true,
+		Operators: []string{"NEAR", "NOT"},
+		Phrases:   []string{"learning models"},
+		Near:      []string{"deep NEAR/3 networks"},
+		Negated:   []string{`"data NEAR java"`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("phrase and NEAR query:\ngot  %+v\nwant %+v", got, want)
+	}
+}
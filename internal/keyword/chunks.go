@@ -0,0 +1,202 @@
+package keyword
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	blevequery "github.com/blevesearch/bleve/v2/search/query"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// chunkIndexDir names the chunk index of WithChunks inside the index directory, so it is
+// removed by Reset and moved along with the index by a shadow rebuild.
+const chunkIndexDir = "chunks"
+
+// chunkDocField holds the ID of the document a chunk entry belongs to.
+const chunkDocField = "doc_id"
+
+// maxChunkHits bounds the chunk hits one content search reads while collecting the
+// documents it asks for.
+const maxChunkHits = 10000
+
+// WithChunks also indexes the content of new indexes chunk by chunk, in a second Bleve
+// index inside the index directory (see IndexChunks). Plain queries then score content
+// matches by a document's best chunk, so long documents are not penalized for their
+// length, and report that chunk in KeywordResult.Chunk. As with the other options, an
+// existing index keeps or lacks its chunk index until Reset.
+func WithChunks() BleveOption {
+	return func(b *BleveIndex) {
+		b.chunked = true
+	}
+}
+
+// chunkIndexPath returns the path of the chunk index of the index at path.
+func chunkIndexPath(path string) string {
+	return filepath.Join(path, chunkIndexDir)
+}
+
+// newChunkIndexMapping returns the mapping of a new chunk index: the document mapping,
+// whose content field the chunks are indexed in, plus the document ID as a keyword.
+func (b *BleveIndex) newChunkIndexMapping() (*mapping.IndexMappingImpl, error) {
+	im, err := b.newIndexMapping()
+	if err != nil {
+		return nil, err
+	}
+	docField := bleve.NewKeywordFieldMapping()
+	docField.IncludeInAll = false
+	im.DefaultMapping.AddFieldMappingsAt(chunkDocField, docField)
+	return im, nil
+}
+
+// openChunks opens the chunk index of the index at b.path when it has one, and creates
+// one when create is set. It leaves b.chunks nil otherwise.
+func (b *BleveIndex) openChunks(create bool) error {
+	path := chunkIndexPath(b.path)
+	if _, err := os.Stat(path); err == nil {
+		index, err := bleve.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open Bleve chunk index: %w", err)
+		}
+		b.chunks = index
+		return nil
+	}
+	if !create {
+		return nil
+	}
+	im, err := b.newChunkIndexMapping()
+	if err != nil {
+		return err
+	}
+	index, err := bleve.New(path, im)
+	if err != nil {
+		return fmt.Errorf("failed to create Bleve chunk index: %w", err)
+	}
+	b.chunks = index
+	return nil
+}
+
+// chunkIndex returns the active chunk index, nil without one.
+func (b *BleveIndex) chunkIndex() bleve.Index {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.chunks
+}
+
+// IndexesChunks reports whether the index has a chunk index (see WithChunks).
+func (b *BleveIndex) IndexesChunks() bool {
+	return b.chunkIndex() != nil
+}
+
+// IndexChunks replaces the chunks of doc in the chunk index with chunks. Without a chunk
+// index it does nothing. Chunks without words are left out.
+func (b *BleveIndex) IndexChunks(ctx context.Context, doc *models.Document, chunks []*models.DocumentChunk) error {
+	index := b.chunkIndex()
+	if index == nil {
+		return nil
+	}
+	batch := index.NewBatch()
+	if err := deleteChunks(index, batch, doc.ID); err != nil {
+		return err
+	}
+	for _, ch := range chunks {
+		if strings.TrimSpace(ch.Content) == "" {
+			continue
+		}
+		entry := map[string]interface{}{chunkDocField: doc.ID, "content": ch.Content}
+		if err := batch.Index(chunkEntryID(doc.ID, ch.ChunkIndex), entry); err != nil {
+			return err
+		}
+	}
+	return index.Batch(batch)
+}
+
+// deleteChunks adds the deletion of every chunk entry of document id to batch.
+func deleteChunks(index bleve.Index, batch *bleve.Batch, id string) error {
+	q := bleve.NewTermQuery(id)
+	q.SetField(chunkDocField)
+	const page = 1000
+	for from := 0; ; from += page {
+		req := bleve.NewSearchRequestOptions(q, page, from, false)
+		results, err := index.Search(req)
+		if err != nil {
+			return fmt.Errorf("Bleve chunk lookup failed: %w", err)
+		}
+		for _, hit := range results.Hits {
+			batch.Delete(hit.ID)
+		}
+		if len(results.Hits) < page {
+			return nil
+		}
+	}
+}
+
+// chunkEntryID returns the ID of the chunk entry for chunk n of document id.
+func chunkEntryID(id string, n int) string {
+	return id + "#" + strconv.Itoa(n)
+}
+
+// parseChunkEntryID splits a chunk entry ID into the document ID and chunk number.
+// Document IDs may contain "#", chunk numbers cannot.
+func parseChunkEntryID(entry string) (id string, n int, ok bool) {
+	i := strings.LastIndexByte(entry, '#')
+	if i < 0 {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(entry[i+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	return entry[:i], n, true
+}
+
+// contentScores runs q, a query of the content field, and returns the score of up to
+// size documents. With a chunk index it is run against the chunks: a document scores
+// as its best chunk, whose number is returned in best.
+func (b *BleveIndex) contentScores(ctx context.Context, q blevequery.Query, size int) (scores map[string]float64, best map[string]int, err error) {
+	scores = make(map[string]float64)
+	chunks := b.chunkIndex()
+	if chunks == nil {
+		req := bleve.NewSearchRequest(q)
+		req.Size = size
+		results, err := b.current().SearchInContext(ctx, req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Bleve content search failed: %w", err)
+		}
+		for _, hit := range results.Hits {
+			scores[hit.ID] = hit.Score
+		}
+		return scores, nil, nil
+	}
+	// Hits come best first, so the first chunk hit of a document is its best chunk. Read
+	// pages until size documents are found.
+	best = make(map[string]int)
+	page := max(size*4, 100)
+	for from := 0; len(scores) < size && from < maxChunkHits; from += page {
+		req := bleve.NewSearchRequestOptions(q, page, from, false)
+		results, err := chunks.SearchInContext(ctx, req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Bleve chunk search failed: %w", err)
+		}
+		for _, hit := range results.Hits {
+			id, n, ok := parseChunkEntryID(hit.ID)
+			if !ok {
+				continue
+			}
+			if _, seen := scores[id]; seen || len(scores) == size {
+				continue
+			}
+			scores[id] = hit.Score
+			best[id] = n
+		}
+		if len(results.Hits) < page {
+			break
+		}
+	}
+	return scores, best, nil
+}
@@ -0,0 +1,159 @@
+package keyword
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// chunkedDocument returns a document whose content is texts, with one chunk per text.
+func chunkedDocument(id, title string, texts ...string) (*models.Document, []*models.DocumentChunk) {
+	doc := &models.Document{ID: id, Title: title, Content: strings.Join(texts, " ")}
+	chunks := make([]*models.DocumentChunk, len(texts))
+	for i, text := range texts {
+		chunks[i] = &models.DocumentChunk{ID: id + "_" + string(rune('0'+i)), DocumentID: id, Content: text, ChunkIndex: i}
+	}
+	return doc, chunks
+}
+
+func TestBleveIndex_Chunks(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "bleve")
+	idx, err := NewBleveIndex(path, WithChunks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = idx.Close() }()
+	if !idx.IndexesChunks() {
+		t.Fatal("a new index with WithChunks should index chunks")
+	}
+
+	filler := strings.Repeat("lorem ipsum dolor sit amet ", 100)
+	long, longChunks := chunkedDocument("long", "annual report.pdf", filler, "the budget review for next year", filler)
+	short, shortChunks := chunkedDocument("short", "notes.txt", "a note on the budget among "+strings.Repeat("other words ", 30))
+	title, titleChunks := chunkedDocument("title", "budget plan.xlsx", "nothing else here")
+	for _, d := range []struct {
+		doc    *models.Document
+		chunks []*models.DocumentChunk
+	}{{long, longChunks}, {short, shortChunks}, {title, titleChunks}} {
+		if err := idx.Index(ctx, d.doc.ID, d.doc); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.IndexChunks(ctx, d.doc, d.chunks); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	results, err := idx.Search(ctx, "budget", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chunks := make(map[string]*int)
+	var order []string
+	for _, r := range results {
+		chunks[r.ID] = r.Chunk
+		order = append(order, r.ID)
+	}
+	if len(results) != 3 {
+		t.Fatalf("results = %v, want long, short, and title", order)
+	}
+	if c := chunks["long"]; c == nil || *c != 1 {
+		t.Errorf("long document chunk = %v, want 1", c)
+	}
+	if c := chunks["short"]; c == nil || *c != 0 {
+		t.Errorf("short document chunk = %v, want 0", c)
+	}
+	if chunks["title"] != nil {
+		t.Errorf("a title-only match should report no chunk, got %d", *chunks["title"])
+	}
+	// The long document's matching chunk is shorter than the short document.
+	if ids := strings.Join(order, ","); strings.Index(ids, "long") > strings.Index(ids, "short") {
+		t.Errorf("order = %v, want the long document's short chunk above the short document", order)
+	}
+
+	// Boosted searches report chunks too.
+	boosted, err := idx.Search(ctx, "budget review", 10, &SearchOptions{TitleBoost: 2, PhraseBoost: 1.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boosted) == 0 || boosted[0].ID != "long" || boosted[0].Chunk == nil || *boosted[0].Chunk != 1 {
+		t.Errorf("boosted search: first result should be the long document's chunk 1")
+	}
+
+	// Reindexing replaces the chunks; deleting removes them.
+	long, longChunks = chunkedDocument("long", "annual report.pdf", "the budget review", filler)
+	if err := idx.Index(ctx, long.ID, long); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexChunks(ctx, long, longChunks); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := idx.chunkIndex().DocCount(); n != 4 {
+		t.Errorf("chunk entries after reindexing = %d, want 4", n)
+	}
+	if err := idx.Delete(ctx, "long"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := idx.chunkIndex().DocCount(); n != 2 {
+		t.Errorf("chunk entries after delete = %d, want 2", n)
+	}
+	if n, _ := idx.DocCount(); n != 2 {
+		t.Errorf("DocCount = %d, want 2 (chunks are not documents)", n)
+	}
+
+	// The chunk index is reopened with the index, with or without the option.
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	idx, err = NewBleveIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !idx.IndexesChunks() {
+		t.Error("an existing index should keep its chunk index")
+	}
+	results, err = idx.Search(ctx, "budget", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, r := range results {
+		found = found || (r.ID == "short" && r.Chunk != nil)
+	}
+	if len(results) != 2 || !found {
+		t.Errorf("reopened index: %d results, want 2 with the short document's chunk", len(results))
+	}
+
+	// Reset drops the chunk index of an index without the option.
+	if err := idx.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if idx.IndexesChunks() {
+		t.Error("Reset without WithChunks should drop the chunk index")
+	}
+}
+
+func TestParseChunkEntryID(t *testing.T) {
+	for _, tc := range []struct {
+		entry string
+		id    string
+		n     int
+		ok    bool
+	}{
+		{"doc#3", "doc", 3, true},
+		{"a#b#12", "a#b", 12, true},
+		{"doc", "", 0, false},
+		{"doc#x", "", 0, false},
+	} {
+		id, n, ok := parseChunkEntryID(tc.entry)
+		if id != tc.id || n != tc.n || ok != tc.ok {
+			t.Errorf("parseChunkEntryID(%q) = %q, %d, %v", tc.entry, id, n, ok)
+		}
+	}
+	if id, n, _ := parseChunkEntryID(chunkEntryID("x#1", 7)); id != "x#1" || n != 7 {
+		t.Errorf("round trip = %q, %d", id, n)
+	}
+}
@@ -0,0 +1,376 @@
+package keyword
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// CollectionIndex routes documents to per-collection keyword indexes by their source path
+// and searches all of them, so collections can use different analyzers. Documents outside
+// every collection root (including those without a source file) go to the code index when
+// they are source code (the "code_language" metadata) and one was added, else to the index
+// of their language (the "language" metadata), if one was added, and otherwise to the
+// default index.
+type CollectionIndex struct {
+	defaultIndex KeywordIndex
+	collections  []rootIndex             // deepest root first
+	code         KeywordIndex            // optional; see AddCode
+	languages    map[string]KeywordIndex // language code -> index
+	langIndexes  []KeywordIndex          // distinct language indexes, in the order added
+}
+
+type rootIndex struct {
+	root string
+	idx  KeywordIndex
+}
+
+// NewCollectionIndex creates a CollectionIndex with defaultIndex for unrouted documents.
+func NewCollectionIndex(defaultIndex KeywordIndex) *CollectionIndex {
+	return &CollectionIndex{defaultIndex: defaultIndex}
+}
+
+// Add routes documents under root to idx. Call before indexing or searching.
+func (c *CollectionIndex) Add(root string, idx KeywordIndex) {
+	c.collections = append(c.collections, rootIndex{root: root, idx: idx})
+	sort.SliceStable(c.collections, func(i, j int) bool {
+		return len(c.collections[i].root) > len(c.collections[j].root)
+	})
+}
+
+// AddLanguage routes documents outside every collection whose language is lang (an ISO
+// 639-1 code such as "ja") to idx. Languages may share an index. Call before indexing or
+// searching.
+func (c *CollectionIndex) AddLanguage(lang string, idx KeywordIndex) {
+	if c.languages == nil {
+		c.languages = make(map[string]KeywordIndex)
+	}
+	c.languages[lang] = idx
+	if !slices.Contains(c.langIndexes, idx) {
+		c.langIndexes = append(c.langIndexes, idx)
+	}
+}
+
+// AddCode routes source code outside every collection (documents with "code_language"
+// metadata) to idx, such as an index that does not stem, so identifiers are matched as
+// written. Call before indexing or searching.
+func (c *CollectionIndex) AddCode(idx KeywordIndex) {
+	c.code = idx
+}
+
+// all returns every index, the default one first.
+func (c *CollectionIndex) all() []KeywordIndex {
+	out := make([]KeywordIndex, 0, len(c.collections)+len(c.langIndexes)+2)
+	out = append(out, c.defaultIndex)
+	for _, col := range c.collections {
+		out = append(out, col.idx)
+	}
+	if c.code != nil {
+		out = append(out, c.code)
+	}
+	return append(out, c.langIndexes...)
+}
+
+// route returns the index for doc.
+func (c *CollectionIndex) route(doc *models.Document) KeywordIndex {
+	if path, _ := doc.Metadata["source_path"].(string); path != "" {
+		for _, col := range c.collections {
+			rel, err := filepath.Rel(col.root, path)
+			if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+				return col.idx
+			}
+		}
+	}
+	if lang, _ := doc.Metadata["code_language"].(string); lang != "" && c.code != nil {
+		return c.code
+	}
+	if lang, _ := doc.Metadata["language"].(string); lang != "" {
+		if idx, ok := c.languages[lang]; ok {
+			return idx
+		}
+	}
+	return c.defaultIndex
+}
+
+// Index indexes doc in its collection's index and removes it from the others, so a
+// document whose path moved between collections is not found twice.
+func (c *CollectionIndex) Index(ctx context.Context, id string, doc *models.Document) error {
+	target := c.route(doc)
+	for _, idx := range c.all() {
+		if idx == target {
+			continue
+		}
+		if err := idx.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return target.Index(ctx, id, doc)
+}
+
+// IndexBatch indexes each document like Index, batching the documents of each collection.
+func (c *CollectionIndex) IndexBatch(ctx context.Context, docs []*models.Document) error {
+	groups := make(map[KeywordIndex][]*models.Document)
+	for _, doc := range docs {
+		target := c.route(doc)
+		for _, idx := range c.all() {
+			if idx == target {
+				continue
+			}
+			if err := idx.Delete(ctx, doc.ID); err != nil {
+				return err
+			}
+		}
+		groups[target] = append(groups[target], doc)
+	}
+	for _, idx := range c.all() {
+		if group := groups[idx]; len(group) > 0 {
+			if err := IndexDocuments(ctx, idx, group); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// IndexesChunks reports whether any index indexes chunks.
+func (c *CollectionIndex) IndexesChunks() bool {
+	for _, idx := range c.all() {
+		if IndexesChunks(idx) {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexChunks indexes the chunks of doc in its collection's index.
+func (c *CollectionIndex) IndexChunks(ctx context.Context, doc *models.Document, chunks []*models.DocumentChunk) error {
+	return IndexChunks(ctx, c.route(doc), doc, chunks)
+}
+
+// Search searches every index and merges the hits by score.
+func (c *CollectionIndex) Search(ctx context.Context, query string, limit int, opts *SearchOptions) ([]*KeywordResult, error) {
+	var merged []*KeywordResult
+	for _, idx := range c.all() {
+		results, err := idx.Search(ctx, query, limit, opts)
+		if err != nil {
+			return nil, err
+		}
+		merged = append(merged, results...)
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Score > merged[j].Score })
+	if limit > 0 && len(merged) > limit {
+		merged = merged[:limit]
+	}
+	return merged, nil
+}
+
+// Delete removes id from every index.
+func (c *CollectionIndex) Delete(ctx context.Context, id string) error {
+	for _, idx := range c.all() {
+		if err := idx.Delete(ctx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes every index and returns the first error.
+func (c *CollectionIndex) Close() error {
+	var first error
+	for _, idx := range c.all() {
+		if err := idx.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// DocCount returns the number of documents across all indexes.
+func (c *CollectionIndex) DocCount() (uint64, error) {
+	var total uint64
+	for _, idx := range c.all() {
+		n, err := idx.DocCount()
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// GetTermDocFrequency returns the number of documents containing term across all indexes.
+func (c *CollectionIndex) GetTermDocFrequency(term string) (int, error) {
+	total := 0
+	for _, idx := range c.all() {
+		n, err := idx.GetTermDocFrequency(term)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// GetCorpusStats sums the corpus statistics of all indexes.
+func (c *CollectionIndex) GetCorpusStats(terms []string) (int, map[string]int, error) {
+	totalDocs := 0
+	docFreqs := make(map[string]int, len(terms))
+	for _, idx := range c.all() {
+		n, freqs, err := idx.GetCorpusStats(terms)
+		if err != nil {
+			return 0, nil, err
+		}
+		totalDocs += n
+		for term, f := range freqs {
+			docFreqs[term] += f
+		}
+	}
+	return totalDocs, docFreqs, nil
+}
+
+// GetAllTerms returns the unique terms of all indexes that have a term dictionary.
+func (c *CollectionIndex) GetAllTerms() ([]string, error) {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, idx := range c.all() {
+		dict, ok := idx.(TermDictionary)
+		if !ok {
+			continue
+		}
+		all, err := dict.GetAllTerms()
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range all {
+			if !seen[t] {
+				seen[t] = true
+				terms = append(terms, t)
+			}
+		}
+	}
+	return terms, nil
+}
+
+// GetTermFrequency returns the document frequency of term summed over all indexes.
+func (c *CollectionIndex) GetTermFrequency(term string) (int, error) {
+	total := 0
+	for _, idx := range c.all() {
+		dict, ok := idx.(TermDictionary)
+		if !ok {
+			continue
+		}
+		n, err := dict.GetTermFrequency(term)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// ContainsTerm reports whether any index contains term.
+func (c *CollectionIndex) ContainsTerm(term string) (bool, error) {
+	for _, idx := range c.all() {
+		dict, ok := idx.(TermDictionary)
+		if !ok {
+			continue
+		}
+		found, err := dict.ContainsTerm(term)
+		if err != nil {
+			return false, err
+		}
+		if found {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// DocIDs returns the IDs of the documents across all indexes.
+func (c *CollectionIndex) DocIDs(ctx context.Context) ([]string, error) {
+	var ids []string
+	for _, idx := range c.all() {
+		l, ok := idx.(Lister)
+		if !ok {
+			return nil, ErrListUnsupported
+		}
+		more, err := l.DocIDs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, more...)
+	}
+	return ids, nil
+}
+
+// Reset resets every index that implements Resetter.
+func (c *CollectionIndex) Reset() error {
+	for _, idx := range c.all() {
+		if r, ok := idx.(Resetter); ok {
+			if err := r.Reset(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Count returns the number of documents matching query across all indexes.
+func (c *CollectionIndex) Count(ctx context.Context, query string, opts *SearchOptions) (uint64, error) {
+	var total uint64
+	for _, idx := range c.all() {
+		counter, ok := idx.(Counter)
+		if !ok {
+			return 0, errNoCounter
+		}
+		n, err := counter.Count(ctx, query, opts)
+		if err != nil {
+			return 0, err
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// MatchNegated reports the ids matching a NOT clause of query in any index.
+func (c *CollectionIndex) MatchNegated(ctx context.Context, query string, ids []string) (map[string]bool, error) {
+	matched := make(map[string]bool)
+	for _, idx := range c.all() {
+		m, ok := idx.(NegationMatcher)
+		if !ok {
+			continue
+		}
+		found, err := m.MatchNegated(ctx, query, ids)
+		if err != nil {
+			return nil, err
+		}
+		for id := range found {
+			matched[id] = true
+		}
+	}
+	return matched, nil
+}
+
+// MatchScoped reports the ids satisfying the field-scoped terms of query in the index
+// that holds them.
+func (c *CollectionIndex) MatchScoped(ctx context.Context, query string, ids []string) (map[string]bool, error) {
+	matched := make(map[string]bool)
+	for _, idx := range c.all() {
+		m, ok := idx.(ScopeMatcher)
+		if !ok {
+			continue
+		}
+		found, err := m.MatchScoped(ctx, query, ids)
+		if err != nil {
+			return nil, err
+		}
+		for id := range found {
+			matched[id] = true
+		}
+	}
+	return matched, nil
+}
@@ -0,0 +1,246 @@
+package keyword
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestCollectionIndex(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	defaultIdx, err := NewBleveIndex(filepath.Join(dir, "default"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	englishIdx, err := NewBleveIndexWithAnalyzer(filepath.Join(dir, "notes"), "english")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := NewCollectionIndex(defaultIdx)
+	idx.Add("/home/me/notes", englishIdx)
+	defer idx.Close()
+
+	docs := map[string]string{
+		"note":  "/home/me/notes/run.md",
+		"other": "/home/me/docs/run.md",
+	}
+	for id, path := range docs {
+		doc := &models.Document{ID: id, Title: "log", Content: "running every morning",
+			Metadata: map[string]interface{}{"source_path": path}}
+		if err := idx.Index(ctx, id, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := idx.Index(ctx, "api", &models.Document{ID: "api", Content: "running late"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, _ := englishIdx.DocCount(); n != 1 {
+		t.Errorf("english index: got %d documents, want 1", n)
+	}
+	if n, _ := idx.DocCount(); n != 3 {
+		t.Errorf("total: got %d documents, want 3", n)
+	}
+	// Only the english analyzer stems "running" to "run".
+	results, err := idx.Search(ctx, "run", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != "note" {
+		t.Errorf("stemmed search: got %v, want [note]", results)
+	}
+	results, err = idx.Search(ctx, "running", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Errorf("search across collections: got %d results, want 3", len(results))
+	}
+
+	// Moving a document into another collection removes it from the old index.
+	moved := &models.Document{ID: "other", Content: "running", Metadata: map[string]interface{}{
+		"source_path": "/home/me/notes/moved.md"}}
+	if err := idx.Index(ctx, "other", moved); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := defaultIdx.DocCount(); n != 1 {
+		t.Errorf("default index after move: got %d documents, want 1", n)
+	}
+	if err := idx.Delete(ctx, "note"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := idx.DocCount(); n != 2 {
+		t.Errorf("after delete: got %d documents, want 2", n)
+	}
+}
+
+func TestCollectionIndex_IndexBatch(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	defaultIdx, err := NewBleveIndex(filepath.Join(dir, "default"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	notesIdx, err := NewBleveIndex(filepath.Join(dir, "notes"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	col := NewCollectionIndex(defaultIdx)
+	col.Add("/home/me/notes", notesIdx)
+	idx := NewSwappableIndex(col)
+	defer idx.Close()
+
+	// "moved" starts in the default index and is batched into the notes collection.
+	if err := idx.Index(ctx, "moved", &models.Document{ID: "moved", Content: "draft"}); err != nil {
+		t.Fatal(err)
+	}
+	docs := []*models.Document{
+		{ID: "a", Content: "draft one", Metadata: map[string]interface{}{"source_path": "/home/me/notes/a.md"}},
+		{ID: "b", Content: "draft two"},
+		{ID: "moved", Content: "draft three", Metadata: map[string]interface{}{"source_path": "/home/me/notes/m.md"}},
+	}
+	if err := IndexDocuments(ctx, idx, docs); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := notesIdx.DocCount(); n != 2 {
+		t.Errorf("notes index: got %d documents, want 2", n)
+	}
+	if n, _ := defaultIdx.DocCount(); n != 1 {
+		t.Errorf("default index: got %d documents, want 1", n)
+	}
+	results, err := idx.Search(ctx, "draft", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Errorf("search: got %d results, want 3", len(results))
+	}
+}
+
+func TestNewBleveIndexWithAnalyzer_unknown(t *testing.T) {
+	if _, err := NewBleveIndexWithAnalyzer(filepath.Join(t.TempDir(), "idx"), "klingon"); err == nil {
+		t.Error("expected error for unknown analyzer")
+	}
+}
+
+func TestCollectionIndex_languages(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	newIndex := func(name, analyzer string) *BleveIndex {
+		idx, err := NewBleveIndexWithAnalyzer(filepath.Join(dir, name), analyzer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return idx
+	}
+	defaultIdx, notesIdx := newIndex("default", ""), newIndex("notes", "")
+	cjkIdx, germanIdx := newIndex("cjk", "cjk"), newIndex("german", "german")
+	idx := NewCollectionIndex(defaultIdx)
+	idx.Add("/home/me/notes", notesIdx)
+	idx.AddLanguage("ja", cjkIdx)
+	idx.AddLanguage("zh", cjkIdx)
+	idx.AddLanguage("de", germanIdx)
+	defer idx.Close()
+
+	docs := []*models.Document{
+		{ID: "ja", Content: "気分は天国", Metadata: map[string]interface{}{"language": "ja"}},
+		{ID: "unknown", Content: "気分は天国"},
+		{ID: "de", Content: "Die alten Häuser", Metadata: map[string]interface{}{"language": "de"}},
+		{ID: "note", Content: "気分は天国", Metadata: map[string]interface{}{"language": "ja", "source_path": "/home/me/notes/a.txt"}},
+		{ID: "fr", Content: "les maisons", Metadata: map[string]interface{}{"language": "fr"}},
+	}
+	if err := IndexDocuments(ctx, idx, docs); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		idx  KeywordIndex
+		want uint64
+	}{{defaultIdx, 2}, {notesIdx, 1}, {cjkIdx, 1}, {germanIdx, 1}} {
+		if n, _ := tt.idx.DocCount(); n != tt.want {
+			t.Errorf("%T %p: got %d documents, want %d", tt.idx, tt.idx, n, tt.want)
+		}
+	}
+	if n, _ := idx.DocCount(); n != 5 {
+		t.Errorf("shared language index counted twice: got %d documents, want 5", n)
+	}
+
+	// The standard analyzer splits ideographs apart, so 天 and 気 anywhere match 天気;
+	// the cjk analyzer indexes bigrams and does not.
+	results, err := idx.Search(ctx, "天気", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if containsID(results, "ja") || !containsID(results, "unknown") {
+		t.Errorf("search for a bigram only the standard analyzer matches: got %v", results)
+	}
+	results, err = idx.Search(ctx, "天国", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !containsID(results, "ja") {
+		t.Errorf("cjk search: got %v, want ja among them", results)
+	}
+	// German stemming matches the singular.
+	results, err = idx.Search(ctx, "haus", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != "de" {
+		t.Errorf("german search: got %v, want [de]", results)
+	}
+}
+
+func TestCollectionIndex_code(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	defaultIdx, err := NewBleveIndex(filepath.Join(dir, "default"), WithStemming("english", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	codeIdx, err := NewBleveIndex(filepath.Join(dir, "code"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := NewCollectionIndex(defaultIdx)
+	idx.AddCode(codeIdx)
+	defer idx.Close()
+
+	docs := []*models.Document{
+		{ID: "prose", Content: "the running jobs"},
+		{ID: "code", Content: "func main() { running_jobs := runningJobs() }",
+			Metadata: map[string]interface{}{"code_language": "go", "language": "en"}},
+	}
+	if err := IndexDocuments(ctx, idx, docs); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := codeIdx.DocCount(); n != 1 {
+		t.Errorf("code index: got %d documents, want 1", n)
+	}
+	// Prose is stemmed, code is not: "run" finds only the prose, identifiers match whole.
+	results, err := idx.Search(ctx, "run", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != "prose" {
+		t.Errorf("stemmed search: got %v, want [prose]", results)
+	}
+	results, err = idx.Search(ctx, "running_jobs", 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].ID != "code" {
+		t.Errorf("identifier search: got %v, want [code]", results)
+	}
+}
+
+func containsID(results []*KeywordResult, id string) bool {
+	for _, r := range results {
+		if r.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,737 @@
+package keyword
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// Default ElasticIndex settings.
+const (
+	DefaultElasticIndex      = "sagasu"
+	DefaultElasticTimeout    = 30 * time.Second
+	DefaultElasticMaxRetries = 3
+)
+
+// elasticAnalyzer is the analyzer of the index's text fields: the standard tokenizer,
+// lower-cased and folded to ASCII, without English stop words or the configured ones.
+const elasticAnalyzer = "sagasu"
+
+// ElasticConfig configures an ElasticIndex. Zero values use the defaults.
+type ElasticConfig struct {
+	URL   string // e.g. http://localhost:9200
+	Index string
+	// APIKey is sent as "Authorization: ApiKey <key>"; otherwise Username and Password,
+	// when set, as basic auth.
+	APIKey   string
+	Username string
+	Password string
+	Timeout  time.Duration
+	// MaxRetries is how many times a request failing with a network error, 429, or 502-504
+	// is retried; a negative value disables retry.
+	MaxRetries int
+	// Stopwords are left out of the index and queries; ProtectedTerms are never matched
+	// fuzzily, as with WithStopwords and WithProtectedTerms.
+	Stopwords      []string
+	ProtectedTerms []string
+}
+
+// ElasticIndex implements KeywordIndex on an index of an Elasticsearch or OpenSearch
+// cluster, through its REST API, so teams can search on shared infrastructure. It
+// supports the query syntax of BleveIndex, ranking by the cluster's BM25, with the
+// coverage penalty and phrase boost applied to the hits. It has no stemming or term
+// dictionary. Writes become searchable after the cluster's refresh interval (1s by
+// default).
+type ElasticIndex struct {
+	cfg     ElasticConfig
+	client  *http.Client
+	backoff time.Duration
+
+	stopwords map[string]bool
+	protected map[string]bool
+}
+
+// elasticError is an error response of the cluster.
+type elasticError struct {
+	status int
+	body   string
+}
+
+func (e *elasticError) Error() string {
+	return fmt.Sprintf("elasticsearch returned %d: %s", e.status, e.body)
+}
+
+// NewElasticIndex connects to the cluster at cfg.URL and creates cfg.Index there, with
+// title, content, and path text fields, unless it exists.
+func NewElasticIndex(ctx context.Context, cfg ElasticConfig) (*ElasticIndex, error) {
+	if cfg.URL == "" {
+		return nil, errors.New("elasticsearch url is required")
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	if cfg.Index == "" {
+		cfg.Index = DefaultElasticIndex
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultElasticTimeout
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultElasticMaxRetries
+	}
+	e := &ElasticIndex{
+		cfg:       cfg,
+		client:    &http.Client{Timeout: cfg.Timeout},
+		backoff:   500 * time.Millisecond,
+		stopwords: wordSet(cfg.Stopwords),
+		protected: wordSet(cfg.ProtectedTerms),
+	}
+	if err := e.create(ctx); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// create creates the index unless it exists.
+func (e *ElasticIndex) create(ctx context.Context) error {
+	err := e.do(ctx, http.MethodHead, "", nil, nil)
+	var ee *elasticError
+	if err == nil || !errors.As(err, &ee) || ee.status != http.StatusNotFound {
+		return err
+	}
+	filters := []string{"lowercase", "asciifolding", "stop"}
+	analysis := map[string]interface{}{}
+	if len(e.cfg.Stopwords) > 0 {
+		stop := make([]string, len(e.cfg.Stopwords))
+		for i, w := range e.cfg.Stopwords {
+			stop[i] = strings.ToLower(w)
+		}
+		analysis["filter"] = map[string]interface{}{"sagasu_stop": map[string]interface{}{"type": "stop", "stopwords": stop}}
+		filters = append(filters, "sagasu_stop")
+	}
+	analysis["analyzer"] = map[string]interface{}{
+		elasticAnalyzer: map[string]interface{}{"type": "custom", "tokenizer": "standard", "filter": filters},
+	}
+	text := map[string]interface{}{"type": "text", "analyzer": elasticAnalyzer}
+	body := map[string]interface{}{
+		"settings": map[string]interface{}{"analysis": analysis},
+		"mappings": map[string]interface{}{"properties": map[string]interface{}{
+			"title": text, "content": text, "path": text,
+		}},
+	}
+	if err := e.do(ctx, http.MethodPut, "", body, nil); err != nil {
+		return fmt.Errorf("failed to create elasticsearch index %s: %w", e.cfg.Index, err)
+	}
+	return nil
+}
+
+// do sends a request for path under the index, encoding body as JSON (or sending it as
+// is when it is []byte, as NDJSON) and decoding the response into out when non-nil.
+// Transient failures are retried with exponential backoff.
+func (e *ElasticIndex) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		payload, contentType = b, "application/x-ndjson"
+	default:
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	u := e.cfg.URL + "/" + url.PathEscape(e.cfg.Index) + path
+	backoff := e.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := e.send(ctx, method, u, contentType, payload, out)
+		if err == nil || !retry || attempt >= e.cfg.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// send sends one request, reporting whether a failure is worth retrying.
+func (e *ElasticIndex) send(ctx context.Context, method, u, contentType string, payload []byte, out interface{}) (retry bool, err error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return false, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	switch {
+	case e.cfg.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+e.cfg.APIKey)
+	case e.cfg.Username != "":
+		req.SetBasicAuth(e.cfg.Username, e.cfg.Password)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("elasticsearch request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			retry = true
+		}
+		return retry, &elasticError{status: resp.StatusCode, body: strings.TrimSpace(string(b))}
+	}
+	if out == nil {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("decode elasticsearch response: %w", err)
+	}
+	return false, nil
+}
+
+// elasticDoc is the indexed form of a document.
+func elasticDoc(doc *models.Document) map[string]interface{} {
+	path, _ := doc.Metadata["source_path"].(string)
+	return map[string]interface{}{"title": doc.Title, "content": doc.Content, "path": path}
+}
+
+// Index indexes a document by id.
+func (e *ElasticIndex) Index(ctx context.Context, id string, doc *models.Document) error {
+	if err := e.do(ctx, http.MethodPut, "/_doc/"+url.PathEscape(id), elasticDoc(doc), nil); err != nil {
+		return fmt.Errorf("failed to index %s: %w", id, err)
+	}
+	return nil
+}
+
+// IndexBatch indexes docs with one bulk request, replacing earlier versions.
+func (e *ElasticIndex) IndexBatch(ctx context.Context, docs []*models.Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, doc := range docs {
+		if err := enc.Encode(map[string]interface{}{"index": map[string]string{"_id": doc.ID}}); err != nil {
+			return err
+		}
+		if err := enc.Encode(elasticDoc(doc)); err != nil {
+			return err
+		}
+	}
+	var out struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/_bulk", buf.Bytes(), &out); err != nil {
+		return fmt.Errorf("elasticsearch bulk index failed: %w", err)
+	}
+	if !out.Errors {
+		return nil
+	}
+	for _, item := range out.Items {
+		for _, r := range item {
+			if len(r.Error) > 0 {
+				return fmt.Errorf("failed to index %s: %s", r.ID, r.Error)
+			}
+		}
+	}
+	return errors.New("elasticsearch bulk index failed")
+}
+
+// Delete removes a document from the index.
+func (e *ElasticIndex) Delete(ctx context.Context, id string) error {
+	err := e.do(ctx, http.MethodDelete, "/_doc/"+url.PathEscape(id), nil, nil)
+	var ee *elasticError
+	if errors.As(err, &ee) && ee.status == http.StatusNotFound {
+		return nil
+	}
+	return err
+}
+
+// Reset deletes the index and creates it again, empty.
+func (e *ElasticIndex) Reset() error {
+	ctx := context.Background()
+	err := e.do(ctx, http.MethodDelete, "", nil, nil)
+	var ee *elasticError
+	if err != nil && !(errors.As(err, &ee) && ee.status == http.StatusNotFound) {
+		return fmt.Errorf("failed to delete elasticsearch index %s: %w", e.cfg.Index, err)
+	}
+	return e.create(ctx)
+}
+
+// Close releases idle connections. The remote index is left as it is.
+func (e *ElasticIndex) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// count returns the number of documents matching query, or all of them when it is nil.
+func (e *ElasticIndex) count(ctx context.Context, query map[string]interface{}) (uint64, error) {
+	var body interface{}
+	if query != nil {
+		body = map[string]interface{}{"query": query}
+	}
+	var out struct {
+		Count uint64 `json:"count"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/_count", body, &out); err != nil {
+		return 0, fmt.Errorf("elasticsearch count failed: %w", err)
+	}
+	return out.Count, nil
+}
+
+// DocCount returns the total number of documents in the index.
+func (e *ElasticIndex) DocCount() (uint64, error) {
+	return e.count(context.Background(), nil)
+}
+
+// GetTermDocFrequency returns the number of documents whose title or content contains term.
+func (e *ElasticIndex) GetTermDocFrequency(term string) (int, error) {
+	n, err := e.count(context.Background(), esMultiMatch(term, []string{"title", "content"}, ""))
+	if err != nil {
+		return 0, fmt.Errorf("failed to search for term frequency: %w", err)
+	}
+	return int(n), nil
+}
+
+// GetCorpusStats returns the total document count and the document frequency of each term.
+func (e *ElasticIndex) GetCorpusStats(terms []string) (totalDocs int, docFreqs map[string]int, err error) {
+	count, err := e.DocCount()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get doc count: %w", err)
+	}
+	docFreqs = make(map[string]int, len(terms))
+	for _, term := range terms {
+		freq, err := e.GetTermDocFrequency(term)
+		if err != nil {
+			freq = 0
+		}
+		docFreqs[term] = freq
+	}
+	return int(count), docFreqs, nil
+}
+
+// esMultiMatch returns a multi_match query of text over fields, of type typ ("" for the
+// default).
+func esMultiMatch(text string, fields []string, typ string) map[string]interface{} {
+	mm := map[string]interface{}{"query": text, "fields": fields}
+	if typ != "" {
+		mm["type"] = typ
+	}
+	return map[string]interface{}{"multi_match": mm}
+}
+
+// esBool returns a bool query with the given clauses, leaving out empty ones.
+func esBool(clauses map[string][]interface{}) map[string]interface{} {
+	b := map[string]interface{}{}
+	for kind, qs := range clauses {
+		if len(qs) > 0 {
+			b[kind] = qs
+		}
+	}
+	return map[string]interface{}{"bool": b}
+}
+
+// esFields returns the index fields of SearchOptions.Fields, or nil when it selects none.
+func esFields(fields []string) []string {
+	var out []string
+	for _, f := range fields {
+		switch f {
+		case FieldTitle:
+			out = append(out, "title")
+		case FieldPath:
+			out = append(out, "path")
+		}
+	}
+	return out
+}
+
+// titleContent returns the fields title (weighted by titleBoost) and content.
+func titleContent(titleBoost float64) []string {
+	if titleBoost == 1 {
+		return []string{"title", "content"}
+	}
+	return []string{"title^" + strconv.FormatFloat(titleBoost, 'g', -1, 64), "content"}
+}
+
+// termQuery returns the query matching term in fields, within fuzziness edits when fuzzy
+// and term is not protected, or as one of its synonyms at SynonymBoost.
+func (e *ElasticIndex) termQuery(term string, fields []string, fuzzy bool, fuzziness int, synonyms []string) map[string]interface{} {
+	q := esMultiMatch(term, fields, "")
+	if fuzzy && !e.protected[strings.ToLower(term)] && !hasCJK(term) {
+		q["multi_match"].(map[string]interface{})["fuzziness"] = fuzziness
+	}
+	if len(synonyms) == 0 {
+		return q
+	}
+	alts := []interface{}{q}
+	for _, s := range synonyms {
+		sq := esMultiMatch(s, fields, "phrase")
+		sq["multi_match"].(map[string]interface{})["boost"] = SynonymBoost
+		alts = append(alts, sq)
+	}
+	return esBool(map[string][]interface{}{"should": alts})
+}
+
+// esLeaf returns the query of a term, phrase, or NEAR node: over its scoped field, or
+// fields.
+func (e *ElasticIndex) esLeaf(n *boolNode, fields []string, titleBoost float64, fuzzy bool, fuzziness int) map[string]interface{} {
+	if n.field == "title" {
+		fields = titleContent(titleBoost)[:1]
+	}
+	switch n.op {
+	case opPhrase:
+		return esMultiMatch(n.text, fields, "phrase")
+	case opNear:
+		var alts []interface{}
+		for _, field := range fields {
+			name, _, _ := strings.Cut(field, "^")
+			alts = append(alts, map[string]interface{}{"intervals": map[string]interface{}{name: map[string]interface{}{
+				"all_of": map[string]interface{}{
+					"ordered":  false,
+					"max_gaps": n.distance,
+					"intervals": []interface{}{
+						map[string]interface{}{"match": map[string]interface{}{"query": n.children[0].text, "max_gaps": 0, "ordered": true}},
+						map[string]interface{}{"match": map[string]interface{}{"query": n.children[1].text, "max_gaps": 0, "ordered": true}},
+					},
+				},
+			}}})
+		}
+		return esBool(map[string][]interface{}{"should": alts})
+	}
+	return e.termQuery(n.text, fields, fuzzy, fuzziness, nil)
+}
+
+// esQuery translates a parsed boolean query, as boolNode.toBleve does for Bleve. A bool
+// query with only must_not clauses matches every other document.
+func (e *ElasticIndex) esQuery(n *boolNode, fields []string, titleBoost float64, fuzzy bool, fuzziness int) map[string]interface{} {
+	sub := func(c *boolNode) interface{} { return e.esQuery(c, fields, titleBoost, fuzzy, fuzziness) }
+	switch n.op {
+	case opTerm, opPhrase, opNear:
+		return e.esLeaf(n, fields, titleBoost, fuzzy, fuzziness)
+	case opNot:
+		return esBool(map[string][]interface{}{"must_not": {sub(n.children[0])}})
+	case opAnd:
+		var must []interface{}
+		for _, c := range n.children {
+			must = append(must, sub(c))
+		}
+		return esBool(map[string][]interface{}{"must": must})
+	case opOr:
+		var should []interface{}
+		for _, c := range n.children {
+			should = append(should, sub(c))
+		}
+		return esBool(map[string][]interface{}{"should": should})
+	}
+	// opAny
+	var must, should, mustNot []interface{}
+	for _, c := range n.children {
+		switch {
+		case c.op == opNot:
+			mustNot = append(mustNot, sub(c.children[0]))
+		case c.field != "" || c.op == opPhrase || c.op == opNear:
+			must = append(must, sub(c))
+		default:
+			should = append(should, sub(c))
+		}
+	}
+	return esBool(map[string][]interface{}{"must": must, "should": should, "must_not": mustNot})
+}
+
+// patternQuery returns the query of a wildcard or regex pattern over fields. A regex, and
+// each word of a wildcard, is matched against whole indexed words; the words of a
+// wildcard spanning several must follow each other.
+func patternQuery(pattern, mode string, fields []string) map[string]interface{} {
+	var alts []interface{}
+	words := strings.Fields(strings.ToLower(pattern))
+	for _, field := range fields {
+		name, _, _ := strings.Cut(field, "^")
+		switch {
+		case mode == PatternRegex:
+			alts = append(alts, map[string]interface{}{"regexp": map[string]interface{}{name: map[string]interface{}{"value": pattern, "case_insensitive": true}}})
+		case len(words) == 1:
+			alts = append(alts, map[string]interface{}{"wildcard": map[string]interface{}{name: map[string]interface{}{"value": words[0], "case_insensitive": true}}})
+		default:
+			var parts []interface{}
+			for _, w := range words {
+				if strings.ContainsAny(w, "*?") {
+					parts = append(parts, map[string]interface{}{"wildcard": map[string]interface{}{"pattern": w}})
+				} else {
+					parts = append(parts, map[string]interface{}{"match": map[string]interface{}{"query": w}})
+				}
+			}
+			alts = append(alts, map[string]interface{}{"intervals": map[string]interface{}{name: map[string]interface{}{
+				"all_of": map[string]interface{}{"ordered": true, "max_gaps": 0, "intervals": parts},
+			}}})
+		}
+	}
+	return esBool(map[string][]interface{}{"should": alts})
+}
+
+// esSearch holds the SearchOptions of a search, defaults filled in.
+type esSearch struct {
+	titleBoost, phraseBoost, coverageExponent float64
+	fuzzy                                     bool
+	fuzziness, phraseSlop                     int
+	synonyms                                  map[string][]string
+	fields                                    []string
+	pattern                                   string
+}
+
+func newESSearch(opts *SearchOptions) *esSearch {
+	s := &esSearch{titleBoost: 1, phraseBoost: 1, coverageExponent: DefaultCoverageExponent, fuzziness: DefaultFuzziness}
+	if opts == nil {
+		return s
+	}
+	if opts.TitleBoost > 0 {
+		s.titleBoost = opts.TitleBoost
+	}
+	if opts.PhraseBoost > 0 {
+		s.phraseBoost = opts.PhraseBoost
+	}
+	s.phraseSlop = minTwo(max(opts.PhraseSlop, 0), models.MaxPhraseSlop)
+	s.fuzzy = opts.FuzzyEnabled
+	if opts.Fuzziness > 0 {
+		s.fuzziness = opts.Fuzziness
+	}
+	if opts.CoverageExponent != nil {
+		s.coverageExponent = *opts.CoverageExponent
+	}
+	s.synonyms = opts.Synonyms
+	s.fields = esFields(opts.Fields)
+	s.pattern = opts.Pattern
+	return s
+}
+
+// query returns the query of a search for text, and for a plain query of several
+// words, its terms: each term's clause is named "term:<i>", and the clause matching
+// them as a phrase "phrase", so hits report which they matched. It returns nil when
+// nothing can match.
+func (e *ElasticIndex) query(text string, s *esSearch) (map[string]interface{}, []string) {
+	fields := s.fields
+	if len(fields) == 0 {
+		fields = titleContent(s.titleBoost)
+	}
+	switch {
+	case s.pattern != "":
+		return patternQuery(text, s.pattern, fields), nil
+	case IsBooleanQuery(text):
+		root := parseBoolQuery(text)
+		if root == nil {
+			return nil, nil
+		}
+		return e.esQuery(root, fields, s.titleBoost, s.fuzzy, s.fuzziness), nil
+	case len(s.fields) > 0:
+		q := esMultiMatch(text, fields, "bool_prefix")
+		q["multi_match"].(map[string]interface{})["operator"] = "and"
+		return q, nil
+	}
+	terms := e.terms(text)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	var should []interface{}
+	for i, term := range terms {
+		should = append(should, esNamed(e.termQuery(term, fields, s.fuzzy, s.fuzziness, s.synonyms[term]), "term:"+strconv.Itoa(i)))
+	}
+	if len(terms) == 1 {
+		return should[0].(map[string]interface{}), nil
+	}
+	if s.phraseBoost > 1 {
+		phrase := esMultiMatch(strings.Join(terms, " "), fields, "phrase")
+		if s.phraseSlop > 0 {
+			phrase["multi_match"].(map[string]interface{})["slop"] = s.phraseSlop
+		}
+		should = append(should, esNamed(phrase, "phrase"))
+	}
+	return esBool(map[string][]interface{}{"should": should}), terms
+}
+
+// terms is queryTerms without the configured stopwords.
+func (e *ElasticIndex) terms(query string) []string {
+	var out []string
+	for _, t := range queryTerms(query) {
+		if !e.stopwords[t] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// esNamed wraps q in a bool query named name, which hits matching it list in
+// matched_queries.
+func esNamed(q map[string]interface{}, name string) map[string]interface{} {
+	return map[string]interface{}{"bool": map[string]interface{}{"should": []interface{}{q}, "_name": name}}
+}
+
+// Search returns up to limit documents matching query. Plain queries of several words
+// fetch more candidates, whose scores are multiplied by the share of terms they match to
+// the power CoverageExponent and, with PhraseBoost, by PhraseBoost when the terms appear
+// as a phrase, as in BleveIndex. PhraseSlop is the phrase's slop, which also counts a
+// transposition of terms as two words apart.
+func (e *ElasticIndex) Search(ctx context.Context, query string, limit int, opts *SearchOptions) ([]*KeywordResult, error) {
+	s := newESSearch(opts)
+	q, terms := e.query(query, s)
+	if q == nil {
+		return nil, nil
+	}
+	size := limit
+	if len(terms) > 1 {
+		size = max(limit*2, 50)
+	}
+	hits, err := e.search(ctx, q, size)
+	if err != nil {
+		return nil, err
+	}
+	if len(terms) > 1 {
+		for _, h := range hits {
+			h.rescore(len(terms), s)
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	out := make([]*KeywordResult, 0, minTwo(len(hits), limit))
+	for _, h := range hits[:minTwo(len(hits), limit)] {
+		out = append(out, h.KeywordResult)
+	}
+	return out, nil
+}
+
+// esHit is a search hit with the names of the query clauses it matched.
+type esHit struct {
+	*KeywordResult
+	matched []string
+}
+
+// rescore multiplies the hit's score by the share of the terms it matched to the power
+// of the coverage exponent, and by the phrase boost when it matched them as a phrase.
+func (h *esHit) rescore(terms int, s *esSearch) {
+	matched, phrase := 0, false
+	for _, name := range h.matched {
+		if strings.HasPrefix(name, "term:") {
+			matched++
+		}
+		phrase = phrase || name == "phrase"
+	}
+	h.Score *= math.Pow(float64(max(matched, 1))/float64(terms), s.coverageExponent)
+	if phrase {
+		h.Score *= s.phraseBoost
+	}
+}
+
+// search returns up to size hits of q, best first. Hits of queries that do not score,
+// such as pure negations, get score 1.
+func (e *ElasticIndex) search(ctx context.Context, q map[string]interface{}, size int) ([]*esHit, error) {
+	body := map[string]interface{}{"query": q, "size": size, "_source": false}
+	var out struct {
+		Hits struct {
+			Hits []struct {
+				ID      string   `json:"_id"`
+				Score   *float64 `json:"_score"`
+				Matched []string `json:"matched_queries"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := e.do(ctx, http.MethodPost, "/_search", body, &out); err != nil {
+		return nil, fmt.Errorf("elasticsearch search failed: %w", err)
+	}
+	hits := make([]*esHit, len(out.Hits.Hits))
+	for i, h := range out.Hits.Hits {
+		score := 1.0
+		if h.Score != nil && *h.Score > 0 {
+			score = *h.Score
+		}
+		hits[i] = &esHit{KeywordResult: &KeywordResult{ID: h.ID, Score: score}, matched: h.Matched}
+	}
+	return hits, nil
+}
+
+// Count returns the number of documents matching query, as Search matches them.
+func (e *ElasticIndex) Count(ctx context.Context, query string, opts *SearchOptions) (uint64, error) {
+	q, _ := e.query(query, newESSearch(opts))
+	if q == nil {
+		return 0, nil
+	}
+	return e.count(ctx, q)
+}
+
+// matchIDs returns the subset of ids matching every query of must.
+func (e *ElasticIndex) matchIDs(ctx context.Context, ids []string, must ...interface{}) (map[string]bool, error) {
+	filter := append([]interface{}{map[string]interface{}{"ids": map[string]interface{}{"values": ids}}}, must...)
+	hits, err := e.search(ctx, esBool(map[string][]interface{}{"filter": filter}), len(ids))
+	if err != nil {
+		return nil, err
+	}
+	matched := make(map[string]bool, len(hits))
+	for _, h := range hits {
+		matched[h.ID] = true
+	}
+	return matched, nil
+}
+
+// MatchNegated returns the subset of ids matching any NOT clause of the boolean query.
+// It returns nil when the query has no negation.
+func (e *ElasticIndex) MatchNegated(ctx context.Context, query string, ids []string) (map[string]bool, error) {
+	if len(ids) == 0 || !IsBooleanQuery(query) {
+		return nil, nil
+	}
+	root := parseBoolQuery(query)
+	if root == nil {
+		return nil, nil
+	}
+	negated := negatedNodes(root)
+	if len(negated) == 0 {
+		return nil, nil
+	}
+	fields := titleContent(1)
+	var should []interface{}
+	for _, n := range negated {
+		should = append(should, e.esQuery(n, fields, 1, false, 0))
+	}
+	return e.matchIDs(ctx, ids, esBool(map[string][]interface{}{"should": should}))
+}
+
+// MatchScoped returns the subset of ids satisfying every required field-scoped term or
+// phrase, exact phrase, and NEAR group of the boolean query. It returns nil when the
+// query has none.
+func (e *ElasticIndex) MatchScoped(ctx context.Context, query string, ids []string) (map[string]bool, error) {
+	if len(ids) == 0 || !IsBooleanQuery(query) {
+		return nil, nil
+	}
+	root := parseBoolQuery(query)
+	if root == nil {
+		return nil, nil
+	}
+	scoped := scopedNodes(root)
+	if len(scoped) == 0 {
+		return nil, nil
+	}
+	fields := titleContent(1)
+	must := make([]interface{}, len(scoped))
+	for i, n := range scoped {
+		must[i] = e.esLeaf(n, fields, 1, false, 0)
+	}
+	return e.matchIDs(ctx, ids, must...)
+}
@@ -0,0 +1,213 @@
+package keyword
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// fakeElastic is an Elasticsearch stand-in that records requests and answers searches
+// with canned hits.
+type fakeElastic struct {
+	mu       sync.Mutex
+	created  bool
+	requests []string // "METHOD path"
+	bodies   map[string][]byte
+	bulkIDs  []string
+	hits     []map[string]interface{}
+	failures int // 503s before answering
+}
+
+func (f *fakeElastic) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "ApiKey secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if f.failures > 0 {
+		f.failures--
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+		return
+	}
+	key := r.Method + " " + r.URL.Path
+	f.requests = append(f.requests, key)
+	var body []byte
+	if r.Body != nil {
+		sc := bufio.NewScanner(r.Body)
+		for sc.Scan() {
+			line := sc.Bytes()
+			if strings.HasSuffix(r.URL.Path, "/_bulk") {
+				var action map[string]map[string]string
+				if json.Unmarshal(line, &action) == nil && action["index"] != nil {
+					f.bulkIDs = append(f.bulkIDs, action["index"]["_id"])
+				}
+			}
+			body = append(append(body, line...), '\n')
+		}
+	}
+	f.bodies[key] = body
+	switch {
+	case r.Method == http.MethodHead:
+		if !f.created {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case r.Method == http.MethodPut && r.URL.Path == "/docs":
+		f.created = true
+	case r.Method == http.MethodDelete && strings.Contains(r.URL.Path, "/_doc/"):
+		http.Error(w, `{"result":"not_found"}`, http.StatusNotFound)
+	case strings.HasSuffix(r.URL.Path, "/_bulk"):
+		_, _ = w.Write([]byte(`{"errors":false,"items":[]}`))
+	case strings.HasSuffix(r.URL.Path, "/_count"):
+		_, _ = w.Write([]byte(`{"count":7}`))
+	case strings.HasSuffix(r.URL.Path, "/_search"):
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"hits": map[string]interface{}{"hits": f.hits}})
+	}
+}
+
+func testElasticIndex(t *testing.T) (*ElasticIndex, *fakeElastic) {
+	t.Helper()
+	fake := &fakeElastic{bodies: make(map[string][]byte)}
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	idx, err := NewElasticIndex(context.Background(), ElasticConfig{
+		URL: srv.URL + "/", Index: "docs", APIKey: "secret", Stopwords: []string{"Acme"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.backoff = time.Millisecond
+	t.Cleanup(func() { _ = idx.Close() })
+	return idx, fake
+}
+
+func TestElasticIndex_createAndWrite(t *testing.T) {
+	ctx := context.Background()
+	idx, fake := testElasticIndex(t)
+	if !fake.created || !strings.Contains(string(fake.bodies["PUT /docs"]), `"stopwords":["acme"]`) {
+		t.Fatalf("index should be created with the stopwords, got %s", fake.bodies["PUT /docs"])
+	}
+	if _, err := NewElasticIndex(ctx, ElasticConfig{URL: idx.cfg.URL, Index: "docs", APIKey: "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"HEAD /docs", "PUT /docs", "HEAD /docs"}; !reflect.DeepEqual(fake.requests, want) {
+		t.Errorf("an existing index should not be created again: %v", fake.requests)
+	}
+
+	docs := []*models.Document{
+		{ID: "a/1", Title: "Budget", Content: "quarterly budget", Metadata: map[string]interface{}{"source_path": "/docs/budget.md"}},
+		{ID: "b", Title: "Trip", Content: "trip report"},
+	}
+	if err := IndexDocuments(ctx, idx, docs); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fake.bulkIDs, []string{"a/1", "b"}) {
+		t.Errorf("bulk ids = %v", fake.bulkIDs)
+	}
+	if !strings.Contains(string(fake.bodies["POST /docs/_bulk"]), `"path":"/docs/budget.md"`) {
+		t.Errorf("bulk body should carry the source path: %s", fake.bodies["POST /docs/_bulk"])
+	}
+
+	fake.failures = 2
+	if err := idx.Delete(ctx, "a/1"); err != nil {
+		t.Errorf("deleting a missing document after retries: %v", err)
+	}
+	if fake.requests[len(fake.requests)-1] != "DELETE /docs/_doc/a/1" {
+		t.Errorf("last request = %s", fake.requests[len(fake.requests)-1])
+	}
+	if n, err := idx.DocCount(); err != nil || n != 7 {
+		t.Errorf("DocCount = %d, %v; want 7", n, err)
+	}
+}
+
+func TestElasticIndex_Search(t *testing.T) {
+	ctx := context.Background()
+	idx, fake := testElasticIndex(t)
+	fake.hits = []map[string]interface{}{
+		{"_id": "partial", "_score": 2.0, "matched_queries": []string{"term:0"}},
+		{"_id": "phrase", "_score": 1.5, "matched_queries": []string{"term:0", "term:1", "phrase"}},
+		{"_id": "both", "_score": 1.0, "matched_queries": []string{"term:0", "term:1"}},
+	}
+	results, err := idx.Search(ctx, "quarterly acme budget", 2, &SearchOptions{TitleBoost: 3, PhraseBoost: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	// partial: 2 * (1/2)^2 = 0.5; phrase: 1.5 * 2 = 3; both: 1
+	if want := []string{"phrase", "both"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("results = %v, want %v", ids, want)
+	}
+	body := string(fake.bodies["POST /docs/_search"])
+	for _, want := range []string{`"title^3"`, `"_name":"phrase"`, `"query":"quarterly budget"`, `"size":50`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("search body should contain %s: %s", want, body)
+		}
+	}
+	if strings.Contains(body, "acme") {
+		t.Errorf("stopwords should be left out of the query: %s", body)
+	}
+	if strings.Contains(body, `"slop"`) {
+		t.Errorf("the phrase should have no slop by default: %s", body)
+	}
+	if _, err := idx.Search(ctx, "quarterly budget", 2, &SearchOptions{PhraseBoost: 2, PhraseSlop: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if body := string(fake.bodies["POST /docs/_search"]); !strings.Contains(body, `"slop":3`) {
+		t.Errorf("the phrase should have slop 3: %s", body)
+	}
+
+	fake.hits = []map[string]interface{}{{"_id": "other", "_score": nil}}
+	results, err = idx.Search(ctx, "NOT draft", 10, nil)
+	if err != nil || len(results) != 1 || results[0].Score != 1 {
+		t.Fatalf("negation-only search = %v, %v; want one hit scoring 1", results, err)
+	}
+	if body := string(fake.bodies["POST /docs/_search"]); !strings.Contains(body, `"must_not"`) {
+		t.Errorf("NOT should translate to must_not: %s", body)
+	}
+}
+
+func TestElasticIndex_query(t *testing.T) {
+	idx := &ElasticIndex{protected: wordSet([]string{"k8s"})}
+	tests := []struct {
+		name  string
+		query string
+		opts  *SearchOptions
+		want  []string
+	}{
+		{"near", "budget NEAR/3 review", nil, []string{`"intervals"`, `"max_gaps":3`, `"ordered":false`}},
+		{"scoped phrase", `title:"annual report" -draft`, nil, []string{`"type":"phrase"`, `"fields":["title"]`, `"must_not"`}},
+		{"fuzzy", "budgt", &SearchOptions{FuzzyEnabled: true, Fuzziness: 1}, []string{`"fuzziness":1`}},
+		{"protected", "k8s", &SearchOptions{FuzzyEnabled: true}, nil},
+		{"fields", "budg", &SearchOptions{Fields: []string{FieldPath}}, []string{`"type":"bool_prefix"`, `"fields":["path"]`, `"operator":"and"`}},
+		{"wildcard", "bud*", &SearchOptions{Pattern: PatternWildcard}, []string{`"wildcard"`, `"value":"bud*"`}},
+		{"regex", "bud[a-z]+", &SearchOptions{Pattern: PatternRegex}, []string{`"regexp"`}},
+		{"synonyms", "car", &SearchOptions{Synonyms: map[string][]string{"car": {"automobile"}}}, []string{`"query":"automobile"`}},
+	}
+	for _, tt := range tests {
+		q, _ := idx.query(tt.query, newESSearch(tt.opts))
+		b, err := json.Marshal(q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(string(b), want) {
+				t.Errorf("%s: query should contain %s: %s", tt.name, want, b)
+			}
+		}
+		if tt.name == "protected" && strings.Contains(string(b), "fuzziness") {
+			t.Errorf("protected terms should not be fuzzy: %s", b)
+		}
+	}
+}
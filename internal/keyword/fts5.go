@@ -0,0 +1,724 @@
+//go:build sqlite_fts5
+
+package keyword
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ftsSchema maps document IDs to the rowids of the FTS5 table, which indexes the title,
+// content, and source path of each document with the unicode61 tokenizer (lower-cased,
+// diacritics removed, no stemming), and exposes its term dictionary through fts5vocab.
+const ftsSchema = `
+CREATE TABLE IF NOT EXISTS keyword_docs (
+	doc INTEGER PRIMARY KEY,
+	id TEXT NOT NULL UNIQUE
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS keyword_fts USING fts5(title, content, path, tokenize = 'unicode61 remove_diacritics 2');
+CREATE VIRTUAL TABLE IF NOT EXISTS keyword_vocab USING fts5vocab(keyword_fts, col);
+`
+
+// maxFuzzyExpansions caps the indexed terms a fuzzy query term is expanded to, nearest first.
+const maxFuzzyExpansions = 50
+
+// errFTS5Pattern is returned for wildcard and regex searches, which FTS5 cannot run.
+var errFTS5Pattern = errors.New("wildcard and regex search need the bleve keyword backend")
+
+// FTS5Index implements KeywordIndex with an SQLite FTS5 table in a database file of its
+// own, ranking matches by BM25. It supports the query syntax of BleveIndex but wildcard
+// and regex patterns, and matches words as written: it has no analyzers or stemming.
+// Stopwords are dropped from queries only.
+type FTS5Index struct {
+	db   *sql.DB
+	path string
+
+	stopwords map[string]bool
+	protected map[string]bool
+}
+
+// FTS5Available reports whether this build can open FTS5 indexes (the sqlite_fts5 tag).
+func FTS5Available() bool { return true }
+
+// NewFTS5Index creates or opens the FTS5 index database at path. Stopwords are left out
+// of queries and protected terms are never matched fuzzily, as with WithStopwords and
+// WithProtectedTerms.
+func NewFTS5Index(path string, stopwords, protected []string) (*FTS5Index, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create keyword index directory: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open FTS5 index: %w", err)
+	}
+	if _, err := db.Exec(ftsSchema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create FTS5 index: %w", err)
+	}
+	return &FTS5Index{db: db, path: path, stopwords: wordSet(stopwords), protected: wordSet(protected)}, nil
+}
+
+// Index indexes a document by id.
+func (f *FTS5Index) Index(ctx context.Context, id string, doc *models.Document) error {
+	d := *doc
+	d.ID = id
+	return f.IndexBatch(ctx, []*models.Document{&d})
+}
+
+// IndexBatch indexes docs in one transaction, replacing earlier versions.
+func (f *FTS5Index) IndexBatch(ctx context.Context, docs []*models.Document) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, doc := range docs {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO keyword_docs (id) VALUES (?)`, doc.ID); err != nil {
+			return fmt.Errorf("failed to index %s: %w", doc.ID, err)
+		}
+		var rowid int64
+		if err := tx.QueryRowContext(ctx, `SELECT doc FROM keyword_docs WHERE id = ?`, doc.ID).Scan(&rowid); err != nil {
+			return fmt.Errorf("failed to index %s: %w", doc.ID, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM keyword_fts WHERE rowid = ?`, rowid); err != nil {
+			return fmt.Errorf("failed to index %s: %w", doc.ID, err)
+		}
+		path, _ := doc.Metadata["source_path"].(string)
+		if _, err := tx.ExecContext(ctx, `INSERT INTO keyword_fts (rowid, title, content, path) VALUES (?, ?, ?, ?)`,
+			rowid, doc.Title, doc.Content, path); err != nil {
+			return fmt.Errorf("failed to index %s: %w", doc.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Delete removes a document from the index.
+func (f *FTS5Index) Delete(ctx context.Context, id string) error {
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM keyword_fts WHERE rowid = (SELECT doc FROM keyword_docs WHERE id = ?)`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM keyword_docs WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reset drops every document.
+func (f *FTS5Index) Reset() error {
+	_, err := f.db.Exec(`DELETE FROM keyword_fts; DELETE FROM keyword_docs;`)
+	return err
+}
+
+// Close closes the index database.
+func (f *FTS5Index) Close() error {
+	return f.db.Close()
+}
+
+// DocCount returns the total number of documents in the index.
+func (f *FTS5Index) DocCount() (uint64, error) {
+	var n uint64
+	err := f.db.QueryRow(`SELECT count(*) FROM keyword_docs`).Scan(&n)
+	return n, err
+}
+
+// DocIDs returns the IDs of the documents in the index.
+func (f *FTS5Index) DocIDs(ctx context.Context) ([]string, error) {
+	rows, err := f.db.QueryContext(ctx, `SELECT id FROM keyword_docs ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetTermDocFrequency returns the number of documents whose title or content contains term.
+func (f *FTS5Index) GetTermDocFrequency(term string) (int, error) {
+	var n int
+	err := f.db.QueryRow(`SELECT count(*) FROM keyword_fts WHERE keyword_fts MATCH ?`,
+		"{title content} : "+ftsQuote(term)).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("failed to search for term frequency: %w", err)
+	}
+	return n, nil
+}
+
+// GetCorpusStats returns the total document count and the document frequency of each term.
+func (f *FTS5Index) GetCorpusStats(terms []string) (totalDocs int, docFreqs map[string]int, err error) {
+	count, err := f.DocCount()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get doc count: %w", err)
+	}
+	docFreqs = make(map[string]int, len(terms))
+	for _, term := range terms {
+		freq, err := f.GetTermDocFrequency(term)
+		if err != nil {
+			freq = 0
+		}
+		docFreqs[term] = freq
+	}
+	return int(count), docFreqs, nil
+}
+
+// GetAllTerms returns the distinct terms of the titles and contents, for spell checking.
+func (f *FTS5Index) GetAllTerms() ([]string, error) {
+	rows, err := f.db.Query(`SELECT DISTINCT term FROM keyword_vocab WHERE col IN ('title', 'content')`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var terms []string
+	for rows.Next() {
+		var term string
+		if err := rows.Scan(&term); err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return terms, rows.Err()
+}
+
+// ContainsTerm checks if a term exists in the index.
+func (f *FTS5Index) ContainsTerm(term string) (bool, error) {
+	freq, err := f.GetTermDocFrequency(term)
+	if err != nil {
+		return false, err
+	}
+	return freq > 0, nil
+}
+
+// GetTermFrequency returns the document frequency for a term.
+func (f *FTS5Index) GetTermFrequency(term string) (int, error) {
+	return f.GetTermDocFrequency(term)
+}
+
+// ftsQuote quotes s as an FTS5 string, which matches the phrase of its tokens.
+func ftsQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// ftsColumns returns the FTS5 column filter of SearchOptions.Fields, or of title and
+// content when fields selects none.
+func ftsColumns(fields []string) string {
+	var cols []string
+	for _, field := range fields {
+		switch field {
+		case FieldTitle:
+			cols = append(cols, "title")
+		case FieldPath:
+			cols = append(cols, "path")
+		}
+	}
+	if len(cols) == 0 {
+		return "{title content}"
+	}
+	return "{" + strings.Join(cols, " ") + "}"
+}
+
+// terms is queryTerms without the index's stopwords.
+func (f *FTS5Index) terms(query string) []string {
+	var out []string
+	for _, t := range queryTerms(query) {
+		if !f.stopwords[t] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// termExpr returns the FTS5 expression matching term: the term itself, with fuzzy, the
+// indexed terms within fuzziness edits of it, and its synonyms.
+func (f *FTS5Index) termExpr(ctx context.Context, term string, fuzzy bool, fuzziness int, synonyms map[string][]string) (string, error) {
+	alts := []string{ftsQuote(term)}
+	if fuzzy && !f.protected[term] && !hasCJK(term) {
+		near, err := f.fuzzyTerms(ctx, term, fuzziness)
+		if err != nil {
+			return "", err
+		}
+		for _, t := range near {
+			alts = append(alts, ftsQuote(t))
+		}
+	}
+	for _, alt := range synonyms[term] {
+		alts = append(alts, ftsQuote(alt))
+	}
+	if len(alts) == 1 {
+		return alts[0], nil
+	}
+	return "(" + strings.Join(alts, " OR ") + ")", nil
+}
+
+// fuzzyTerms returns the indexed terms other than term within fuzziness edits of it,
+// nearest first.
+func (f *FTS5Index) fuzzyTerms(ctx context.Context, term string, fuzziness int) ([]string, error) {
+	n := len([]rune(term))
+	rows, err := f.db.QueryContext(ctx,
+		`SELECT DISTINCT term FROM keyword_vocab WHERE col IN ('title', 'content') AND length(term) BETWEEN ? AND ?`,
+		n-fuzziness, n+fuzziness)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	type candidate struct {
+		term     string
+		distance int
+	}
+	var near []candidate
+	for rows.Next() {
+		var t string
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		if d := LevenshteinDistance(term, t); d > 0 && d <= fuzziness {
+			near = append(near, candidate{t, d})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(near, func(i, j int) bool {
+		if near[i].distance != near[j].distance {
+			return near[i].distance < near[j].distance
+		}
+		return near[i].term < near[j].term
+	})
+	out := make([]string, 0, minTwo(len(near), maxFuzzyExpansions))
+	for _, c := range near[:minTwo(len(near), maxFuzzyExpansions)] {
+		out = append(out, c.term)
+	}
+	return out, nil
+}
+
+// ftsBool translates a parsed boolean query for FTS5. FTS5's NOT only excludes from a
+// positive match, so each node yields its positive expression and the expression of
+// what it excludes; negations under OR with nothing to exclude from are dropped.
+type ftsBool struct {
+	f         *FTS5Index
+	ctx       context.Context
+	columns   string // column filter of unscoped leaves
+	fuzzy     bool
+	fuzziness int
+}
+
+// leaf returns the expression of a term, phrase, or NEAR node, filtered to its columns.
+func (b *ftsBool) leaf(n *boolNode) (string, error) {
+	columns := b.columns
+	if n.field == "title" {
+		columns = "title"
+	}
+	switch n.op {
+	case opPhrase:
+		return columns + " : " + ftsQuote(n.text), nil
+	case opNear:
+		return fmt.Sprintf("%s : NEAR(%s %s, %d)", columns, ftsQuote(n.children[0].text), ftsQuote(n.children[1].text), n.distance), nil
+	}
+	expr, err := b.f.termExpr(b.ctx, strings.ToLower(n.text), b.fuzzy, b.fuzziness, nil)
+	if err != nil {
+		return "", err
+	}
+	return columns + " : " + expr, nil
+}
+
+// expr returns the positive and excluded expressions of n, either of which may be empty.
+func (b *ftsBool) expr(n *boolNode) (pos, neg string, err error) {
+	switch n.op {
+	case opTerm, opPhrase, opNear:
+		pos, err = b.leaf(n)
+		return pos, "", err
+	case opNot:
+		p, ng, err := b.expr(n.children[0])
+		if err != nil {
+			return "", "", err
+		}
+		if p == "" {
+			return ng, "", nil // NOT NOT x
+		}
+		return "", ftsExclude(p, ng), nil
+	case opOr:
+		var parts []string
+		for _, c := range n.children {
+			p, ng, err := b.expr(c)
+			if err != nil {
+				return "", "", err
+			}
+			if p != "" {
+				parts = append(parts, ftsExclude(p, ng))
+			}
+		}
+		return ftsJoin(parts, "OR"), "", nil
+	}
+	// opAnd, opAny: every child is required under AND; in adjacent units only phrases,
+	// NEAR groups, and scoped terms are, and otherwise any term may match.
+	var must, should, nots []string
+	for _, c := range n.children {
+		p, ng, err := b.expr(c)
+		if err != nil {
+			return "", "", err
+		}
+		if ng != "" {
+			nots = append(nots, ng)
+		}
+		if p == "" {
+			continue
+		}
+		if n.op == opAnd || c.field != "" || c.op != opTerm {
+			must = append(must, p)
+		} else {
+			should = append(should, p)
+		}
+	}
+	if len(must) > 0 {
+		pos = ftsJoin(must, "AND")
+	} else {
+		pos = ftsJoin(should, "OR")
+	}
+	return pos, ftsJoin(nots, "OR"), nil
+}
+
+// ftsJoin joins expressions with op, parenthesized when there are several.
+func ftsJoin(exprs []string, op string) string {
+	switch len(exprs) {
+	case 0:
+		return ""
+	case 1:
+		return exprs[0]
+	}
+	return "(" + strings.Join(exprs, " "+op+" ") + ")"
+}
+
+// ftsExclude returns pos without the matches of neg.
+func ftsExclude(pos, neg string) string {
+	if neg == "" {
+		return pos
+	}
+	return "(" + pos + " NOT " + neg + ")"
+}
+
+// Search returns up to limit documents matching query, ranked by BM25 with title matches
+// weighted by opts.TitleBoost. Plain queries match any term, and with several terms the
+// score is multiplied by the share of terms matched to the power CoverageExponent and,
+// with PhraseBoost, by PhraseBoost when the terms appear as a phrase, as in BleveIndex.
+// With PhraseSlop, the phrase is a NEAR group, which also accepts the terms out of order.
+// A boolean query consisting only of negations matches every other document.
+func (f *FTS5Index) Search(ctx context.Context, query string, limit int, opts *SearchOptions) ([]*KeywordResult, error) {
+	titleBoost, phraseBoost, phraseSlop := 1.0, 1.0, 0
+	fuzzy, fuzziness := false, DefaultFuzziness
+	coverageExponent := DefaultCoverageExponent
+	var synonyms map[string][]string
+	var fields []string
+	if opts != nil {
+		if opts.Pattern != "" {
+			return nil, errFTS5Pattern
+		}
+		if opts.TitleBoost > 0 {
+			titleBoost = opts.TitleBoost
+		}
+		if opts.PhraseBoost > 0 {
+			phraseBoost = opts.PhraseBoost
+		}
+		phraseSlop = minTwo(max(opts.PhraseSlop, 0), models.MaxPhraseSlop)
+		fuzzy = opts.FuzzyEnabled
+		if opts.Fuzziness > 0 {
+			fuzziness = opts.Fuzziness
+		}
+		if opts.CoverageExponent != nil {
+			coverageExponent = *opts.CoverageExponent
+		}
+		synonyms = opts.Synonyms
+		fields = opts.Fields
+	}
+
+	if IsBooleanQuery(query) {
+		pos, neg, err := f.boolExpr(ctx, query, fields, fuzzy, fuzziness)
+		if err != nil {
+			return nil, err
+		}
+		return f.match(ctx, pos, neg, titleBoost, limit)
+	}
+	if len(fields) > 0 {
+		return f.match(ctx, f.fieldsExpr(query, fields), "", 1, limit)
+	}
+	terms := f.terms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	termExprs := make([]string, len(terms))
+	for i, term := range terms {
+		expr, err := f.termExpr(ctx, term, fuzzy, fuzziness, synonyms)
+		if err != nil {
+			return nil, err
+		}
+		termExprs[i] = "{title content} : " + expr
+	}
+	if len(terms) == 1 {
+		return f.match(ctx, termExprs[0], "", titleBoost, limit)
+	}
+
+	candidates, err := f.match(ctx, ftsJoin(termExprs, "OR"), "", titleBoost, max(limit*2, 50))
+	if err != nil || len(candidates) == 0 {
+		return candidates, err
+	}
+	ids := make([]string, len(candidates))
+	for i, c := range candidates {
+		ids[i] = c.ID
+	}
+	coverage := make(map[string]int)
+	for _, expr := range termExprs {
+		matched, err := f.matchIDs(ctx, expr, ids)
+		if err != nil {
+			return nil, err
+		}
+		for id := range matched {
+			coverage[id]++
+		}
+	}
+	var phrase map[string]bool
+	if phraseBoost > 1 {
+		if phrase, err = f.matchIDs(ctx, "{title content} : "+ftsPhrase(terms, phraseSlop), ids); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range candidates {
+		c.Score *= math.Pow(float64(max(coverage[c.ID], 1))/float64(len(terms)), coverageExponent)
+		if phrase[c.ID] {
+			c.Score *= phraseBoost
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+	return candidates[:minTwo(len(candidates), limit)], nil
+}
+
+// ftsPhrase returns the expression of terms as a phrase or, with slop, as a NEAR group
+// allowing slop other tokens between them. NEAR counts the tokens between the first term
+// and the last, so those in the middle are added.
+func ftsPhrase(terms []string, slop int) string {
+	if slop == 0 {
+		return ftsQuote(strings.Join(terms, " "))
+	}
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = ftsQuote(term)
+	}
+	return fmt.Sprintf("NEAR(%s, %d)", strings.Join(quoted, " "), slop+len(terms)-2)
+}
+
+// boolExpr translates a boolean query into the expressions of its positive matches and
+// of what it excludes.
+func (f *FTS5Index) boolExpr(ctx context.Context, query string, fields []string, fuzzy bool, fuzziness int) (pos, neg string, err error) {
+	root := parseBoolQuery(query)
+	if root == nil {
+		return "", "", nil
+	}
+	b := &ftsBool{f: f, ctx: ctx, columns: ftsColumns(fields), fuzzy: fuzzy, fuzziness: fuzziness}
+	return b.expr(root)
+}
+
+// fieldsExpr returns the expression requiring every term of query in one of fields, as
+// a word or the start of one (see SearchOptions.Fields).
+func (f *FTS5Index) fieldsExpr(query string, fields []string) string {
+	terms := f.terms(query)
+	exprs := make([]string, len(terms))
+	for i, term := range terms {
+		exprs[i] = ftsQuote(term) + "*"
+	}
+	if len(exprs) == 0 {
+		return ""
+	}
+	return ftsColumns(fields) + " : " + ftsJoin(exprs, "AND")
+}
+
+// match returns up to limit documents matching pos but not neg, best first. With pos
+// empty, every document not matching neg scores 1; with both empty, none match.
+func (f *FTS5Index) match(ctx context.Context, pos, neg string, titleBoost float64, limit int) ([]*KeywordResult, error) {
+	var rows *sql.Rows
+	var err error
+	switch {
+	case pos != "":
+		rows, err = f.db.QueryContext(ctx,
+			`SELECT d.id, -bm25(keyword_fts, ?, 1, 1) AS score FROM keyword_fts
+			 JOIN keyword_docs d ON d.doc = keyword_fts.rowid
+			 WHERE keyword_fts MATCH ? ORDER BY score DESC LIMIT ?`,
+			titleBoost, ftsExclude(pos, neg), limit)
+	case neg != "":
+		rows, err = f.db.QueryContext(ctx,
+			`SELECT id, 1 FROM keyword_docs WHERE doc NOT IN (
+			   SELECT rowid FROM keyword_fts WHERE keyword_fts MATCH ?)
+			 ORDER BY id LIMIT ?`, neg, limit)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("FTS5 search failed: %w", err)
+	}
+	defer rows.Close()
+	var out []*KeywordResult
+	for rows.Next() {
+		r := &KeywordResult{}
+		if err := rows.Scan(&r.ID, &r.Score); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// matchIDs returns the subset of ids matching expr.
+func (f *FTS5Index) matchIDs(ctx context.Context, expr string, ids []string) (map[string]bool, error) {
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, expr)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := f.db.QueryContext(ctx,
+		`SELECT d.id FROM keyword_fts JOIN keyword_docs d ON d.doc = keyword_fts.rowid
+		 WHERE keyword_fts MATCH ? AND d.id IN (`+strings.TrimSuffix(strings.Repeat("?,", len(ids)), ",")+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("FTS5 search failed: %w", err)
+	}
+	defer rows.Close()
+	matched := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		matched[id] = true
+	}
+	return matched, rows.Err()
+}
+
+// Count returns the number of documents matching query, as Search matches them.
+func (f *FTS5Index) Count(ctx context.Context, query string, opts *SearchOptions) (uint64, error) {
+	fuzzy, fuzziness := false, DefaultFuzziness
+	var synonyms map[string][]string
+	var fields []string
+	if opts != nil {
+		if opts.Pattern != "" {
+			return 0, errFTS5Pattern
+		}
+		fuzzy = opts.FuzzyEnabled
+		if opts.Fuzziness > 0 {
+			fuzziness = opts.Fuzziness
+		}
+		synonyms = opts.Synonyms
+		fields = opts.Fields
+	}
+	var pos, neg string
+	switch {
+	case IsBooleanQuery(query):
+		var err error
+		if pos, neg, err = f.boolExpr(ctx, query, fields, fuzzy, fuzziness); err != nil {
+			return 0, err
+		}
+	case len(fields) > 0:
+		pos = f.fieldsExpr(query, fields)
+	default:
+		var exprs []string
+		for _, term := range f.terms(query) {
+			expr, err := f.termExpr(ctx, term, fuzzy, fuzziness, synonyms)
+			if err != nil {
+				return 0, err
+			}
+			exprs = append(exprs, expr)
+		}
+		if len(exprs) > 0 {
+			pos = "{title content} : " + ftsJoin(exprs, "OR")
+		}
+	}
+	var n uint64
+	var err error
+	switch {
+	case pos != "":
+		err = f.db.QueryRowContext(ctx, `SELECT count(*) FROM keyword_fts WHERE keyword_fts MATCH ?`, ftsExclude(pos, neg)).Scan(&n)
+	case neg != "":
+		err = f.db.QueryRowContext(ctx,
+			`SELECT count(*) FROM keyword_docs WHERE doc NOT IN (
+			   SELECT rowid FROM keyword_fts WHERE keyword_fts MATCH ?)`, neg).Scan(&n)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("FTS5 count failed: %w", err)
+	}
+	return n, nil
+}
+
+// MatchNegated returns the subset of ids matching any NOT clause of the boolean query.
+// It returns nil when the query has no negation.
+func (f *FTS5Index) MatchNegated(ctx context.Context, query string, ids []string) (map[string]bool, error) {
+	if len(ids) == 0 || !IsBooleanQuery(query) {
+		return nil, nil
+	}
+	root := parseBoolQuery(query)
+	if root == nil {
+		return nil, nil
+	}
+	negated := negatedNodes(root)
+	if len(negated) == 0 {
+		return nil, nil
+	}
+	b := &ftsBool{f: f, ctx: ctx, columns: ftsColumns(nil)}
+	var exprs []string
+	for _, n := range negated {
+		pos, neg, err := b.expr(n)
+		if err != nil {
+			return nil, err
+		}
+		if pos != "" {
+			exprs = append(exprs, ftsExclude(pos, neg))
+		}
+	}
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+	return f.matchIDs(ctx, ftsJoin(exprs, "OR"), ids)
+}
+
+// MatchScoped returns the subset of ids satisfying every required field-scoped term or
+// phrase, exact phrase, and NEAR group of the boolean query. It returns nil when the
+// query has none.
+func (f *FTS5Index) MatchScoped(ctx context.Context, query string, ids []string) (map[string]bool, error) {
+	if len(ids) == 0 || !IsBooleanQuery(query) {
+		return nil, nil
+	}
+	root := parseBoolQuery(query)
+	if root == nil {
+		return nil, nil
+	}
+	scoped := scopedNodes(root)
+	if len(scoped) == 0 {
+		return nil, nil
+	}
+	b := &ftsBool{f: f, ctx: ctx, columns: ftsColumns(nil)}
+	exprs := make([]string, len(scoped))
+	for i, n := range scoped {
+		expr, err := b.leaf(n)
+		if err != nil {
+			return nil, err
+		}
+		exprs[i] = expr
+	}
+	return f.matchIDs(ctx, ftsJoin(exprs, "AND"), ids)
+}
@@ -0,0 +1,60 @@
+//go:build !sqlite_fts5
+
+package keyword
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// errFTS5Unavailable is returned by the FTS5Index stub.
+var errFTS5Unavailable = errors.New("FTS5 keyword index not available: build with -tags=sqlite_fts5")
+
+// FTS5Index is a stub that returns an error when SQLite is built without FTS5.
+// Build with -tags=sqlite_fts5 to enable the FTS5 keyword backend.
+type FTS5Index struct{}
+
+// FTS5Available reports whether this build can open FTS5 indexes (the sqlite_fts5 tag).
+func FTS5Available() bool { return false }
+
+// NewFTS5Index returns an error because FTS5 is not available.
+func NewFTS5Index(path string, stopwords, protected []string) (*FTS5Index, error) {
+	return nil, errFTS5Unavailable
+}
+
+// Index is not implemented without FTS5.
+func (f *FTS5Index) Index(ctx context.Context, id string, doc *models.Document) error {
+	return errFTS5Unavailable
+}
+
+// Search is not implemented without FTS5.
+func (f *FTS5Index) Search(ctx context.Context, query string, limit int, opts *SearchOptions) ([]*KeywordResult, error) {
+	return nil, errFTS5Unavailable
+}
+
+// Delete is not implemented without FTS5.
+func (f *FTS5Index) Delete(ctx context.Context, id string) error {
+	return errFTS5Unavailable
+}
+
+// Close is a no-op without FTS5.
+func (f *FTS5Index) Close() error {
+	return nil
+}
+
+// DocCount is not implemented without FTS5.
+func (f *FTS5Index) DocCount() (uint64, error) {
+	return 0, errFTS5Unavailable
+}
+
+// GetTermDocFrequency is not implemented without FTS5.
+func (f *FTS5Index) GetTermDocFrequency(term string) (int, error) {
+	return 0, errFTS5Unavailable
+}
+
+// GetCorpusStats is not implemented without FTS5.
+func (f *FTS5Index) GetCorpusStats(terms []string) (int, map[string]int, error) {
+	return 0, nil, errFTS5Unavailable
+}
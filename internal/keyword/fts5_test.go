@@ -0,0 +1,169 @@
+//go:build sqlite_fts5
+
+package keyword
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func testFTS5Index(t *testing.T) (*FTS5Index, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keyword.db")
+	idx, err := NewFTS5Index(path, []string{"the"}, []string{"kubernetes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = idx.Close() })
+	ctx := context.Background()
+	for _, doc := range []*models.Document{
+		{ID: "budget", Title: "budget.xlsx", Content: "The quarterly budget for marketing and sales.", Metadata: map[string]interface{}{"source_path": "/finance/budget.xlsx"}},
+		{ID: "report", Title: "report.pdf", Content: "Sales grew while the marketing budget stayed flat.", Metadata: map[string]interface{}{"source_path": "/finance/reports/report.pdf"}},
+		{ID: "notes", Title: "notes.txt", Content: "Deploying kubernetes clusters with helm charts.", Metadata: map[string]interface{}{"source_path": "/ops/notes.txt"}},
+	} {
+		if err := idx.Index(ctx, doc.ID, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return idx, path
+}
+
+func resultIDs(results []*KeywordResult) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.ID
+	}
+	return ids
+}
+
+func TestFTS5Index_Search(t *testing.T) {
+	idx, _ := testFTS5Index(t)
+	ctx := context.Background()
+	for _, tc := range []struct {
+		query string
+		opts  *SearchOptions
+		want  []string
+	}{
+		{"budget", &SearchOptions{TitleBoost: 3}, []string{"budget", "report"}},
+		{"quarterly budget", &SearchOptions{TitleBoost: 2}, []string{"budget", "report"}},
+		{"the", nil, nil},
+		{"budget AND grew", nil, []string{"report"}},
+		{"budget -grew", nil, []string{"budget"}},
+		{"NOT budget", nil, []string{"notes"}},
+		{`"marketing budget"`, nil, []string{"report"}},
+		{"marketing NEAR/2 sales", nil, []string{"budget"}},
+		{"title:budget", nil, []string{"budget"}},
+		{"helm OR (sales AND quarterly)", nil, []string{"budget", "notes"}},
+		{"budgte", &SearchOptions{FuzzyEnabled: true, TitleBoost: 3}, []string{"budget", "report"}},
+		{"budgte", &SearchOptions{FuzzyEnabled: true, Fuzziness: 1}, nil},
+		{"reports", &SearchOptions{Fields: []string{FieldPath}}, []string{"report"}},
+		{"budg", &SearchOptions{Fields: []string{FieldTitle}}, []string{"budget"}},
+		{"k8s", &SearchOptions{Synonyms: map[string][]string{"k8s": {"kubernetes clusters"}}}, []string{"notes"}},
+	} {
+		results, err := idx.Search(ctx, tc.query, 10, tc.opts)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.query, err)
+		}
+		got := resultIDs(results)
+		if tc.opts == nil || tc.opts.TitleBoost == 0 {
+			slices.Sort(got) // only boosted queries are checked for order
+		}
+		if !slices.Equal(got, tc.want) && len(got)+len(tc.want) > 0 {
+			t.Errorf("%q: got %v, want %v", tc.query, got, tc.want)
+		}
+		n, err := idx.Count(ctx, tc.query, tc.opts)
+		if err != nil || n != uint64(len(tc.want)) {
+			t.Errorf("%q: count = %d, %v; want %d", tc.query, n, err, len(tc.want))
+		}
+	}
+
+	if _, err := idx.Search(ctx, "bud*", 10, &SearchOptions{Pattern: PatternWildcard}); err == nil {
+		t.Error("pattern search should fail")
+	}
+}
+
+func TestFTS5Index_Matchers(t *testing.T) {
+	idx, _ := testFTS5Index(t)
+	ctx := context.Background()
+	ids := []string{"budget", "report", "notes"}
+	negated, err := idx.MatchNegated(ctx, "sales -grew", ids)
+	if err != nil || len(negated) != 1 || !negated["report"] {
+		t.Errorf("MatchNegated = %v, %v", negated, err)
+	}
+	scoped, err := idx.MatchScoped(ctx, "title:notes helm", ids)
+	if err != nil || len(scoped) != 1 || !scoped["notes"] {
+		t.Errorf("MatchScoped = %v, %v", scoped, err)
+	}
+	if m, _ := idx.MatchNegated(ctx, "sales", ids); m != nil {
+		t.Errorf("plain query should have no negations, got %v", m)
+	}
+}
+
+func TestFTS5Index_DeleteAndReopen(t *testing.T) {
+	idx, path := testFTS5Index(t)
+	ctx := context.Background()
+	if err := idx.Index(ctx, "budget", &models.Document{Title: "budget.xlsx", Content: "Travel expenses."}); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Delete(ctx, "report"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := idx.DocCount(); n != 2 {
+		t.Errorf("DocCount = %d, want 2", n)
+	}
+	if freq, _ := idx.GetTermDocFrequency("budget"); freq != 1 {
+		t.Errorf("budget frequency = %d, want 1 (title only)", freq)
+	}
+	terms, err := idx.GetAllTerms()
+	if err != nil || !slices.Contains(terms, "travel") || slices.Contains(terms, "sales") || slices.Contains(terms, "finance") {
+		t.Errorf("GetAllTerms = %v, %v", terms, err)
+	}
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFTS5Index(path, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	total, freqs, err := reopened.GetCorpusStats([]string{"travel", "helm"})
+	if err != nil || total != 2 || freqs["travel"] != 1 || freqs["helm"] != 1 {
+		t.Errorf("GetCorpusStats = %d, %v, %v", total, freqs, err)
+	}
+	if err := reopened.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := reopened.DocCount(); n != 0 {
+		t.Errorf("DocCount after Reset = %d", n)
+	}
+}
+
+func TestFTS5Index_Search_phraseSlop(t *testing.T) {
+	idx, _ := testFTS5Index(t)
+	ctx := context.Background()
+	scores := func(slop int) map[string]float64 {
+		t.Helper()
+		results, err := idx.Search(ctx, "marketing sales", 10, &SearchOptions{PhraseBoost: 2, PhraseSlop: slop})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := make(map[string]float64)
+		for _, r := range results {
+			out[r.ID] = r.Score
+		}
+		return out
+	}
+	// "marketing and sales" is one word from a phrase.
+	strict, sloppy := scores(0), scores(1)
+	if strict["budget"] == 0 || sloppy["budget"] != 2*strict["budget"] {
+		t.Errorf("budget: slop 1 scores %g, slop 0 %g; want twice", sloppy["budget"], strict["budget"])
+	}
+	if sloppy["report"] != strict["report"] {
+		t.Errorf("report boosted with slop 1: %g, was %g", sloppy["report"], strict["report"])
+	}
+}
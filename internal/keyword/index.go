@@ -7,6 +7,12 @@ import (
 	"github.com/hyperjump/sagasu/internal/models"
 )
 
+// Defaults used when SearchOptions leaves a field unset.
+const (
+	DefaultFuzziness        = 2
+	DefaultCoverageExponent = 2.0
+)
+
 // SearchOptions optional parameters for keyword search. Nil means use defaults.
 type SearchOptions struct {
 	// TitleBoost multiplies the score contribution from matches in the title (filename) field.
@@ -15,14 +21,42 @@ type SearchOptions struct {
 	// PhraseBoost multiplies the score when query terms appear close together (phrase match).
 	// Values > 1 boost documents with adjacent query terms (e.g. 1.5). Use 1.0 for no boost.
 	PhraseBoost float64
+	// PhraseSlop is how many other words, in total, may stand between the query terms of
+	// a phrase match for PhraseBoost: 0 requires them adjacent, and 2 lets "machine
+	// learning" match "machine and deep learning". At most models.MaxPhraseSlop.
+	PhraseSlop int
 	// FuzzyEnabled enables fuzzy matching for typo tolerance.
 	// When true, searches will match terms within the specified edit distance.
 	FuzzyEnabled bool
 	// Fuzziness is the maximum Levenshtein edit distance for fuzzy matching (1 or 2).
 	// Default is 2 when FuzzyEnabled is true. Higher values are more lenient.
 	Fuzziness int
+	// CoverageExponent is the power of the share of query terms a document matches, which
+	// multiplies its score in multi-term searches with boosts. Nil uses
+	// DefaultCoverageExponent (a squared penalty); 0 disables the penalty.
+	CoverageExponent *float64
+	// Synonyms maps lower-case query terms to terms and phrases that also match them,
+	// at SynonymBoost of a literal match. Boolean queries are not expanded.
+	Synonyms map[string][]string
+	// Fields restricts matching to these fields (FieldTitle, FieldPath) instead of title
+	// and content: every term of a plain query must match one of them, by word or word
+	// prefix. Synonyms are not used.
+	Fields []string
+	// Pattern runs the query as a wildcard pattern or regular expression (PatternWildcard,
+	// PatternRegex) over title and content, or Fields, instead of as words. Fuzziness,
+	// stemming and synonyms are not used.
+	Pattern string
+	// NoStems matches query terms only as written, leaving out the stemmed fields of an
+	// index with stemming (see WithStemming).
+	NoStems bool
 }
 
+// Fields for SearchOptions.Fields.
+const (
+	FieldTitle = "title" // the document title, usually the file name
+	FieldPath  = "path"  // the source_path metadata: directory and file names
+)
+
 // KeywordIndex defines keyword search operations.
 type KeywordIndex interface {
 	Index(ctx context.Context, id string, doc *models.Document) error
@@ -41,6 +75,10 @@ type KeywordIndex interface {
 type KeywordResult struct {
 	ID    string
 	Score float64
+	// Chunk is the number (models.DocumentChunk.ChunkIndex) of the chunk whose content
+	// matched best, with a chunk index (see ChunkIndexer); nil otherwise, or when only
+	// the title matched.
+	Chunk *int
 }
 
 // TermDictionary provides access to the term dictionary for spell checking.
@@ -53,3 +91,86 @@ type TermDictionary interface {
 	// ContainsTerm checks if a term exists in the index.
 	ContainsTerm(term string) (bool, error)
 }
+
+// Resetter is implemented by keyword indexes that can drop all documents and recreate
+// their underlying structures (e.g. after an index mapping change).
+type Resetter interface {
+	Reset() error
+}
+
+// StemmedIndex is implemented by keyword indexes that can match the stemmed forms of
+// query terms. HasStems reports whether this index can (see WithStemming), so callers
+// know whether a search with SearchOptions.NoStems has a stemmed pass to fall back to.
+type StemmedIndex interface {
+	HasStems() bool
+}
+
+// Counter is implemented by keyword indexes that can count the documents matching a
+// query without fetching them.
+type Counter interface {
+	Count(ctx context.Context, query string, opts *SearchOptions) (uint64, error)
+}
+
+// Lister is implemented by keyword indexes that can list the IDs of their documents, e.g.
+// to find entries left behind by documents deleted from storage.
+type Lister interface {
+	DocIDs(ctx context.Context) ([]string, error)
+}
+
+// BatchIndexer is implemented by keyword indexes that can index many documents in one
+// write, which is much faster than indexing them one at a time. Documents are indexed
+// under their IDs.
+type BatchIndexer interface {
+	IndexBatch(ctx context.Context, docs []*models.Document) error
+}
+
+// IndexDocuments indexes docs in idx, in one batch when idx is a BatchIndexer.
+func IndexDocuments(ctx context.Context, idx KeywordIndex, docs []*models.Document) error {
+	if b, ok := idx.(BatchIndexer); ok {
+		return b.IndexBatch(ctx, docs)
+	}
+	for _, doc := range docs {
+		if err := idx.Index(ctx, doc.ID, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChunkIndexer is implemented by keyword indexes that can also index documents chunk by
+// chunk, to score content matches by a document's best chunk and report it in
+// KeywordResult.Chunk. IndexesChunks reports whether they do, so callers can skip
+// loading chunks. IndexChunks replaces the chunks of doc, which is indexed with Index
+// first; Delete removes them.
+type ChunkIndexer interface {
+	IndexesChunks() bool
+	IndexChunks(ctx context.Context, doc *models.Document, chunks []*models.DocumentChunk) error
+}
+
+// IndexesChunks reports whether idx indexes the chunks of documents.
+func IndexesChunks(idx KeywordIndex) bool {
+	c, ok := idx.(ChunkIndexer)
+	return ok && c.IndexesChunks()
+}
+
+// IndexChunks indexes the chunks of doc in idx when it indexes chunks.
+func IndexChunks(ctx context.Context, idx KeywordIndex, doc *models.Document, chunks []*models.DocumentChunk) error {
+	if c, ok := idx.(ChunkIndexer); ok {
+		return c.IndexChunks(ctx, doc, chunks)
+	}
+	return nil
+}
+
+// NegationMatcher is implemented by keyword indexes that support boolean queries. It
+// reports which of ids match a NOT clause of query, so other result sources (e.g.
+// semantic search) can exclude them too.
+type NegationMatcher interface {
+	MatchNegated(ctx context.Context, query string, ids []string) (map[string]bool, error)
+}
+
+// ScopeMatcher is implemented by keyword indexes that support field-scoped query terms
+// (title:term). It reports which of ids satisfy every required field-scoped term, so
+// other result sources (e.g. semantic search) can be restricted the same way.
+type ScopeMatcher interface {
+	MatchScoped(ctx context.Context, query string, ids []string) (map[string]bool, error)
+}
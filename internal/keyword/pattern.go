@@ -0,0 +1,272 @@
+package keyword
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/blevesearch/bleve/v2"
+	blevequery "github.com/blevesearch/bleve/v2/search/query"
+	index "github.com/blevesearch/bleve_index_api"
+)
+
+// Patterns for SearchOptions.Pattern.
+const (
+	PatternWildcard = "wildcard" // * matches any letters or digits, ? exactly one
+	PatternRegex    = "regex"    // a regular expression matched against whole indexed words
+)
+
+// MaxPatternTerms caps how many indexed words one part of a wildcard or regex query may
+// match in a field; broader patterns fail with ErrPatternTooBroad instead of searching
+// for all of them.
+const MaxPatternTerms = 1000
+
+// patternCandidateFactor is how many times limit hits a wildcard query spanning several
+// words fetches, since hits whose words are not next to each other are dropped.
+const patternCandidateFactor = 4
+
+// ErrPatternTooBroad is returned when a wildcard or regex query matches more than
+// MaxPatternTerms indexed words.
+var ErrPatternTooBroad = errors.New("pattern matches too many indexed words")
+
+// keywordPattern is a wildcard or regex query translated for Bleve.
+type keywordPattern struct {
+	query  blevequery.Query
+	fields []string       // stored fields verify checks
+	verify *regexp.Regexp // for wildcards spanning several words: the whole pattern; else nil
+}
+
+// searchPattern runs pattern as a wildcard or regex query (see SearchOptions.Pattern)
+// over title (weighted by titleBoost) and content, or fields when given.
+func (b *BleveIndex) searchPattern(ctx context.Context, pattern, mode string, limit int, titleBoost float64, fields []string) ([]*KeywordResult, error) {
+	p, err := b.patternQuery(pattern, mode, titleBoost, fields)
+	if err != nil || p == nil {
+		return nil, err
+	}
+	req := bleve.NewSearchRequest(p.query)
+	req.Size = limit
+	if p.verify != nil {
+		req.Size = limit * patternCandidateFactor
+		req.Fields = p.fields
+	}
+	results, err := b.current().SearchInContext(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("Bleve pattern search failed: %w", err)
+	}
+	out := make([]*KeywordResult, 0, len(results.Hits))
+	for _, hit := range results.Hits {
+		if p.matches(hit.Fields) {
+			out = append(out, &KeywordResult{ID: hit.ID, Score: hit.Score})
+		}
+		if len(out) == limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+// countPattern returns the number of documents pattern matches.
+func (b *BleveIndex) countPattern(ctx context.Context, pattern, mode string, fields []string) (uint64, error) {
+	p, err := b.patternQuery(pattern, mode, 1, fields)
+	if err != nil || p == nil {
+		return 0, err
+	}
+	req := bleve.NewSearchRequest(p.query)
+	req.Size = 0
+	results, err := b.current().SearchInContext(ctx, req)
+	if err != nil {
+		return 0, fmt.Errorf("Bleve pattern count failed: %w", err)
+	}
+	if p.verify == nil || results.Total == 0 {
+		return results.Total, nil
+	}
+	req.Size = int(results.Total)
+	req.Fields = p.fields
+	if results, err = b.current().SearchInContext(ctx, req); err != nil {
+		return 0, fmt.Errorf("Bleve pattern count failed: %w", err)
+	}
+	var n uint64
+	for _, hit := range results.Hits {
+		if p.matches(hit.Fields) {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// matches reports whether the stored fields of a hit contain the whole pattern. A hit
+// without the stored fields is kept.
+func (p *keywordPattern) matches(stored map[string]interface{}) bool {
+	if p.verify == nil {
+		return true
+	}
+	found := false
+	for _, f := range p.fields {
+		if s, ok := stored[f].(string); ok {
+			found = true
+			if p.verify.MatchString(s) {
+				return true
+			}
+		}
+	}
+	return !found
+}
+
+// patternQuery translates pattern. A regex is matched against whole words of each field.
+// A wildcard pattern is split into words like the indexed text; every word with a letter
+// or digit must match in the same field, and when there are several, the stored text is
+// checked for the pattern as a whole. It returns nil when nothing can match.
+func (b *BleveIndex) patternQuery(pattern, mode string, titleBoost float64, fields []string) (*keywordPattern, error) {
+	boosts := map[string]float64{"title": titleBoost, "content": 1}
+	if len(fields) == 0 {
+		fields = []string{"title", "content"}
+	} else {
+		boosts = nil
+	}
+	p := &keywordPattern{fields: fields}
+	var words []string
+	if mode == PatternRegex {
+		words = []string{"(?i)" + pattern}
+	} else {
+		words = wildcardWords(strings.ToLower(pattern))
+		if len(words) > 1 || len(words) == 1 && words[0] != strings.ToLower(strings.TrimSpace(pattern)) {
+			p.verify = wildcardRegexp(pattern)
+		}
+	}
+	if len(words) == 0 {
+		return nil, nil
+	}
+	var alts []blevequery.Query
+	for _, field := range fields {
+		var conj []blevequery.Query
+		for _, w := range words {
+			q, err := b.patternWordQuery(w, mode, field)
+			if err != nil {
+				return nil, err
+			}
+			if q != nil {
+				conj = append(conj, q)
+			}
+		}
+		if len(conj) == 0 {
+			continue
+		}
+		q := bleve.NewConjunctionQuery(conj...)
+		if boost, ok := boosts[field]; ok {
+			q.SetBoost(boost)
+		}
+		alts = append(alts, q)
+	}
+	if len(alts) == 0 {
+		return nil, nil
+	}
+	p.query = bleve.NewDisjunctionQuery(alts...)
+	return p, nil
+}
+
+// patternWordQuery returns the query for one word of a pattern in field: a regexp or
+// wildcard query after checking how many indexed words it matches, or a match query
+// for a word without wildcards (nil when the analyzer drops it, e.g. a stopword).
+func (b *BleveIndex) patternWordQuery(word, mode, field string) (blevequery.Query, error) {
+	if mode == PatternRegex {
+		if err := b.checkPatternTerms(field, word); err != nil {
+			return nil, err
+		}
+		q := bleve.NewRegexpQuery(word)
+		q.SetField(field)
+		return q, nil
+	}
+	if !strings.ContainsAny(word, "*?") {
+		m := b.current().Mapping()
+		if len(analyzeTerms(m.AnalyzerNamed(m.AnalyzerNameForPath(field)), word)) == 0 {
+			return nil, nil
+		}
+		q := bleve.NewMatchQuery(word)
+		q.SetField(field)
+		return q, nil
+	}
+	re := strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(regexp.QuoteMeta(word))
+	if err := b.checkPatternTerms(field, re); err != nil {
+		return nil, err
+	}
+	q := bleve.NewWildcardQuery(word)
+	q.SetField(field)
+	return q, nil
+}
+
+// checkPatternTerms returns ErrPatternTooBroad when re matches more than MaxPatternTerms
+// indexed words of field.
+func (b *BleveIndex) checkPatternTerms(field, re string) error {
+	advanced, err := b.current().Advanced()
+	if err != nil {
+		return fmt.Errorf("failed to open index: %w", err)
+	}
+	reader, err := advanced.Reader()
+	if err != nil {
+		return fmt.Errorf("failed to open index reader: %w", err)
+	}
+	defer reader.Close()
+	dictReader, ok := reader.(index.IndexReaderRegexp)
+	if !ok {
+		return nil
+	}
+	dict, err := dictReader.FieldDictRegexp(field, re)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	defer dict.Close()
+	n := 0
+	entry, err := dict.Next()
+	for ; err == nil && entry != nil; entry, err = dict.Next() {
+		if n++; n > MaxPatternTerms {
+			return fmt.Errorf("%w: more than %d in %s", ErrPatternTooBroad, MaxPatternTerms, field)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read terms: %w", err)
+	}
+	return nil
+}
+
+// isPatternWordRune reports whether r belongs to a word of a wildcard pattern: a letter,
+// a digit, or a wildcard.
+func isPatternWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || r == '*' || r == '?'
+}
+
+// wildcardWords splits a wildcard pattern into words where the analyzer splits text,
+// dropping words without a letter or digit, which match any word.
+func wildcardWords(pattern string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(pattern, func(r rune) bool { return !isPatternWordRune(r) }) {
+		if strings.IndexFunc(w, func(r rune) bool { return r != '*' && r != '?' }) >= 0 {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
+// wildcardRegexp matches pattern as a whole in text, case-insensitively and at word
+// boundaries: * is any letters or digits, ? one, and whitespace any whitespace.
+func wildcardRegexp(pattern string) *regexp.Regexp {
+	const edge = `[^\p{L}\p{N}]`
+	var words []string
+	for _, f := range strings.Fields(pattern) {
+		var sb strings.Builder
+		for _, r := range f {
+			switch r {
+			case '*':
+				sb.WriteString(`[\p{L}\p{N}]*`)
+			case '?':
+				sb.WriteString(`[\p{L}\p{N}]`)
+			default:
+				sb.WriteString(regexp.QuoteMeta(string(r)))
+			}
+		}
+		words = append(words, sb.String())
+	}
+	return regexp.MustCompile(`(?i)(?:^|` + edge + `)` + strings.Join(words, `\s+`) + `(?:$|` + edge + `)`)
+}
@@ -0,0 +1,92 @@
+package keyword
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestBleveIndex_SearchPattern(t *testing.T) {
+	idx, err := NewBleveIndex(filepath.Join(t.TempDir(), "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	ctx := context.Background()
+	docs := []*models.Document{
+		{ID: "inv1", Title: "march invoices", Content: "Invoice INV-2024-017 is due."},
+		{ID: "inv2", Title: "INV-2024-1234", Content: "an older invoice"},
+		{ID: "inv3", Title: "notes", Content: "inv 2024 was a busy year, 001 items"},
+		{ID: "report", Title: "report 2024q3", Content: "quarterly reporting"},
+	}
+	for _, d := range docs {
+		if err := idx.Index(ctx, d.ID, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(pattern, mode string) string {
+		opts := &SearchOptions{TitleBoost: 2, Pattern: mode}
+		results, err := idx.Search(ctx, pattern, 10, opts)
+		if err != nil {
+			t.Fatalf("Search(%q, %s): %v", pattern, mode, err)
+		}
+		ids := make([]string, len(results))
+		for i, r := range results {
+			ids[i] = r.ID
+		}
+		sort.Strings(ids)
+		n, err := idx.Count(ctx, pattern, opts)
+		if err != nil {
+			t.Fatalf("Count(%q, %s): %v", pattern, mode, err)
+		}
+		if int(n) != len(ids) {
+			t.Errorf("Count(%q, %s) = %d, want %d", pattern, mode, n, len(ids))
+		}
+		return strings.Join(ids, ",")
+	}
+	tests := []struct {
+		pattern, mode, want string
+	}{
+		{"INV-2024-???", PatternWildcard, "inv1"}, // not inv2 (4 digits) or inv3 (not adjacent)
+		{"INV-2024-*", PatternWildcard, "inv1,inv2"},
+		{"report*", PatternWildcard, "report"},
+		{"invoice?", PatternWildcard, "inv1"},
+		{"20[0-9]{2}q[1-4]", PatternRegex, "report"},
+		{"INVOICES?", PatternRegex, "inv1,inv2"},
+		{"inv-2024", PatternRegex, ""}, // one regex never spans words
+	}
+	for _, tt := range tests {
+		if got := search(tt.pattern, tt.mode); got != tt.want {
+			t.Errorf("Search(%q, %s) = %s, want %s", tt.pattern, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestBleveIndex_SearchPatternTooBroad(t *testing.T) {
+	idx, err := NewBleveIndex(filepath.Join(t.TempDir(), "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer idx.Close()
+	ctx := context.Background()
+	words := make([]string, MaxPatternTerms+1)
+	for i := range words {
+		words[i] = fmt.Sprintf("code%d", i)
+	}
+	if err := idx.Index(ctx, "d", &models.Document{ID: "d", Content: strings.Join(words, " ")}); err != nil {
+		t.Fatal(err)
+	}
+	_, err = idx.Search(ctx, "code*", 10, &SearchOptions{Pattern: PatternWildcard})
+	if !errors.Is(err, ErrPatternTooBroad) {
+		t.Errorf("Search(code*) error = %v, want ErrPatternTooBroad", err)
+	}
+	if _, err := idx.Search(ctx, "code1?", 10, &SearchOptions{Pattern: PatternWildcard}); err != nil {
+		t.Errorf("Search(code1?): %v", err)
+	}
+}
@@ -30,6 +30,7 @@ type SpellChecker struct {
 	maxDistance int
 	minFreq     int
 	maxSuggestions int
+	known       map[string]bool // never corrected, e.g. protected terms and stopwords
 
 	// Cached terms for faster lookup
 	termsCache []string
@@ -69,6 +70,14 @@ func WithMaxSuggestions(n int) SpellCheckerOption {
 	}
 }
 
+// WithKnownTerms treats terms (case-insensitive) as correctly spelled even when the index
+// does not contain them, e.g. domain acronyms and stopwords, so they are never corrected.
+func WithKnownTerms(terms []string) SpellCheckerOption {
+	return func(s *SpellChecker) {
+		s.known = wordSet(terms)
+	}
+}
+
 // NewSpellChecker creates a new SpellChecker with the given dictionary.
 func NewSpellChecker(dict TermDictionary, opts ...SpellCheckerOption) *SpellChecker {
 	s := &SpellChecker{
@@ -133,7 +142,7 @@ func (s *SpellChecker) Check(query string) (*SpellCheckResult, error) {
 		_, exists := s.termSet[termLower]
 		s.cacheMu.RUnlock()
 
-		if exists {
+		if exists || s.known[termLower] {
 			// Term is valid, keep it
 			correctedTerms = append(correctedTerms, term)
 			continue
@@ -168,6 +177,9 @@ func (s *SpellChecker) Suggest(term string) []Suggestion {
 
 	termLower := strings.ToLower(term)
 	suggestions := make([]Suggestion, 0)
+	if s.known[termLower] {
+		return suggestions
+	}
 
 	s.cacheMu.RLock()
 	terms := s.termsCache
@@ -238,7 +250,7 @@ func (s *SpellChecker) IsMisspelled(term string) bool {
 	defer s.cacheMu.RUnlock()
 
 	_, exists := s.termSet[strings.ToLower(term)]
-	return !exists
+	return !exists && !s.known[strings.ToLower(term)]
 }
 
 // GetSuggestedQuery returns the best suggested query string for a misspelled query.
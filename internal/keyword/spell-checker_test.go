@@ -576,3 +576,22 @@ func TestSpellChecker_Check_SingleShortTerm(t *testing.T) {
 		t.Log("short term 'ax' might not have corrections depending on implementation")
 	}
 }
+
+func TestSpellChecker_WithKnownTerms(t *testing.T) {
+	dict := newMockTermDictionary(map[string]int{"kubernetes": 5, "deployment": 3})
+	sc := NewSpellChecker(dict, WithKnownTerms([]string{"K8S", "deploymnt"}))
+
+	result, err := sc.Check("k8s deploymnt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.HasCorrections || result.CorrectedQuery != "k8s deploymnt" {
+		t.Errorf("known terms were corrected: %+v", result)
+	}
+	if sc.IsMisspelled("k8s") {
+		t.Error("known term reported as misspelled")
+	}
+	if got := sc.Suggest("deploymnt"); len(got) != 0 {
+		t.Errorf("Suggest(known term) = %v, want none", got)
+	}
+}
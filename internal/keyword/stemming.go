@@ -0,0 +1,152 @@
+package keyword
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/mapping"
+	blevequery "github.com/blevesearch/bleve/v2/search/query"
+)
+
+// Stemmed copies of title and content, indexed next to them with the stemming analyzer
+// of WithStemming.
+const (
+	titleStemField   = "title_stem"
+	contentStemField = "content_stem"
+)
+
+// stemAnalyzerName names the stemming analyzer in the mapping when it is wrapped in a
+// vocabularyAnalyzer.
+const stemAnalyzerName = "sagasu_stem"
+
+// DefaultStemmedBoost weighs a match of the stemmed fields against an exact match.
+const DefaultStemmedBoost = 0.5
+
+// WithStemming also indexes title and content of new indexes with analyzer (a stemming
+// one such as "english", see AnalyzerNames), and matches query terms against both: exact
+// matches at full weight, stemmed ones at boost, so "running" also finds "run". The
+// fields keep their own analyzer, so exact matches stay precise. A boost of 0 means
+// DefaultStemmedBoost.
+func WithStemming(analyzer string, boost float64) BleveOption {
+	return func(b *BleveIndex) {
+		b.stemming = analyzer
+		b.stemBoost = boost
+	}
+}
+
+// stemAnalyzer returns the Bleve analyzer of WithStemming, or "" without stemming.
+func (b *BleveIndex) stemAnalyzer() (string, error) {
+	if b.stemming == "" {
+		return "", nil
+	}
+	analyzer, ok := analyzers[b.stemming]
+	if !ok {
+		return "", fmt.Errorf("unknown stemming analyzer %q (use one of %s)", b.stemming, strings.Join(AnalyzerNames(), ", "))
+	}
+	return analyzer, nil
+}
+
+// addTextFields maps title and content with textMapping and, with stemming, a second
+// time into the stemmed fields. Those are left out of the composite field searched by
+// queries without a field, and not stored.
+func (b *BleveIndex) addTextFields(im *mapping.IndexMappingImpl, docMapping *mapping.DocumentMapping, textMapping *mapping.FieldMapping) error {
+	analyzer, err := b.stemAnalyzer()
+	if err != nil {
+		return err
+	}
+	if analyzer == "" {
+		docMapping.AddFieldMappingsAt("content", textMapping)
+		docMapping.AddFieldMappingsAt("title", textMapping)
+		return nil
+	}
+	if len(b.stopwords) > 0 || len(b.protected) > 0 {
+		err := im.AddCustomAnalyzer(stemAnalyzerName, map[string]interface{}{
+			"type":      vocabularyAnalyzerType,
+			"base":      analyzer,
+			"stopwords": sortedWords(b.stopwords),
+			"protected": sortedWords(b.protected),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add stemming analyzer: %w", err)
+		}
+		analyzer = stemAnalyzerName
+	}
+	stemMapping := func(name string) *mapping.FieldMapping {
+		fm := bleve.NewTextFieldMapping()
+		fm.Name = name
+		fm.Analyzer = analyzer
+		fm.Store = false
+		fm.IncludeInAll = false
+		fm.IncludeTermVectors = false
+		return fm
+	}
+	docMapping.AddFieldMappingsAt("content", textMapping, stemMapping(contentStemField))
+	docMapping.AddFieldMappingsAt("title", textMapping, stemMapping(titleStemField))
+	return nil
+}
+
+// hasStemFields reports whether the mapping of an index has the stemmed fields, which an
+// index opened from disk has only when it was created with stemming.
+func hasStemFields(m mapping.IndexMapping) bool {
+	im, ok := m.(*mapping.IndexMappingImpl)
+	if !ok || im.DefaultMapping == nil {
+		return false
+	}
+	content := im.DefaultMapping.Properties["content"]
+	if content == nil {
+		return false
+	}
+	for _, f := range content.Fields {
+		if f.Name == contentStemField {
+			return true
+		}
+	}
+	return false
+}
+
+// HasStems reports whether the index has the stemmed fields of WithStemming. An index
+// opened from disk has them only when it was built with stemming.
+func (b *BleveIndex) HasStems() bool {
+	return hasStemFields(b.current().Mapping())
+}
+
+// withStems returns q, or, when the index has stemmed fields, q or'ed with text matched
+// against the stemmed copy of field (of title and content when field is empty) at the
+// stemmed boost. Other fields have no stemmed copy.
+func (b *BleveIndex) withStems(q blevequery.Query, text, field string) blevequery.Query {
+	if !hasStemFields(b.current().Mapping()) {
+		return q
+	}
+	boost := b.stemBoost
+	if boost <= 0 {
+		boost = DefaultStemmedBoost
+	}
+	var fields []string
+	switch field {
+	case "":
+		fields = []string{titleStemField, contentStemField}
+	case "title":
+		fields = []string{titleStemField}
+	case "content":
+		fields = []string{contentStemField}
+	default:
+		return q
+	}
+	queries := []blevequery.Query{q}
+	for _, f := range fields {
+		mq := bleve.NewMatchQuery(text)
+		mq.SetField(f)
+		mq.SetBoost(boost)
+		queries = append(queries, mq)
+	}
+	return bleve.NewDisjunctionQuery(queries...)
+}
+
+// maybeStems returns withStems(q, text, field) when stems is set, and q otherwise.
+func (b *BleveIndex) maybeStems(q blevequery.Query, text, field string, stems bool) blevequery.Query {
+	if !stems {
+		return q
+	}
+	return b.withStems(q, text, field)
+}
@@ -0,0 +1,110 @@
+package keyword
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestBleveIndex_stemming(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bleve")
+	idx, err := NewBleveIndex(path, WithStemming("english", 0))
+	if err != nil {
+		t.Fatalf("NewBleveIndex: %v", err)
+	}
+	ctx := context.Background()
+	for _, doc := range []*models.Document{
+		{ID: "running", Title: "notes.txt", Content: "Running every morning."},
+		{ID: "run", Title: "log.txt", Content: "A short run today."},
+		{ID: "bayes", Title: "stats.txt", Content: "Bayes theorem."},
+	} {
+		if err := idx.Index(ctx, doc.ID, doc); err != nil {
+			t.Fatalf("Index: %v", err)
+		}
+	}
+
+	search := func(idx *BleveIndex, query string, opts *SearchOptions) []string {
+		t.Helper()
+		results, err := idx.Search(ctx, query, 10, opts)
+		if err != nil {
+			t.Fatalf("Search %q: %v", query, err)
+		}
+		var out []string
+		for _, r := range results {
+			out = append(out, r.ID)
+		}
+		return out
+	}
+	// The exact match ranks above the stemmed one, with and without boosts.
+	for _, opts := range []*SearchOptions{nil, {TitleBoost: 2, PhraseBoost: 1.5}, {FuzzyEnabled: true}} {
+		if got := search(idx, "running", opts); !slices.Equal(got, []string{"running", "run"}) {
+			t.Errorf("running (%+v): got %v, want [running run]", opts, got)
+		}
+	}
+	if got := search(idx, "running AND today", nil); !slices.Equal(got, []string{"run"}) {
+		t.Errorf("boolean: got %v, want [run]", got)
+	}
+	// The exact field is still analyzed without stemming.
+	if got := search(idx, "bayes", nil); !slices.Equal(got, []string{"bayes"}) {
+		t.Errorf("bayes: got %v, want [bayes]", got)
+	}
+	if n, err := idx.Count(ctx, "runs", nil); err != nil || n != 2 {
+		t.Errorf("Count(runs) = %d, %v; want 2", n, err)
+	}
+	// NoStems leaves the stemmed fields out.
+	for _, opts := range []*SearchOptions{{NoStems: true}, {NoStems: true, TitleBoost: 2, PhraseBoost: 1.5}} {
+		if got := search(idx, "running", opts); !slices.Equal(got, []string{"running"}) {
+			t.Errorf("running (%+v): got %v, want [running]", opts, got)
+		}
+	}
+	if got := search(idx, "runs AND today", &SearchOptions{NoStems: true}); len(got) != 0 {
+		t.Errorf("boolean without stems: got %v, want no matches", got)
+	}
+	if n, err := idx.Count(ctx, "runs", &SearchOptions{NoStems: true}); err != nil || n != 0 {
+		t.Errorf("Count(runs) without stems = %d, %v; want 0", n, err)
+	}
+	if !idx.HasStems() {
+		t.Error("HasStems() = false for an index built with stemming")
+	}
+
+	// The index keeps its stemmed fields when reopened without options.
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewBleveIndex(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() {
+		_ = reopened.Close()
+	}()
+	if got := search(reopened, "running", nil); !slices.Equal(got, []string{"running", "run"}) {
+		t.Errorf("reopened: got %v, want [running run]", got)
+	}
+
+	plain, err := NewBleveIndex(filepath.Join(t.TempDir(), "plain"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = plain.Close()
+	}()
+	for _, doc := range []*models.Document{{ID: "run", Content: "A short run today."}} {
+		if err := plain.Index(ctx, doc.ID, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := search(plain, "running", nil); len(got) != 0 {
+		t.Errorf("without stemming: got %v, want no matches", got)
+	}
+	if plain.HasStems() {
+		t.Error("HasStems() = true for an index built without stemming")
+	}
+
+	if _, err := NewBleveIndex(filepath.Join(t.TempDir(), "bad"), WithStemming("klingon", 0)); err == nil {
+		t.Error("expected an error for an unknown stemming analyzer")
+	}
+}
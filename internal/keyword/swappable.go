@@ -0,0 +1,205 @@
+package keyword
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// errNoTermDictionary is returned by SwappableIndex's TermDictionary methods when the
+// wrapped index has no term dictionary.
+var errNoTermDictionary = errors.New("keyword index has no term dictionary")
+
+// errNoCounter is returned by Count when a wrapped index does not implement Counter.
+var errNoCounter = errors.New("keyword index cannot count matches")
+
+// ErrListUnsupported is returned by DocIDs when a wrapped index does not implement Lister.
+var ErrListUnsupported = errors.New("keyword index cannot list its documents")
+
+// SwappableIndex wraps a KeywordIndex so it can be replaced while in use (e.g. by an index
+// rebuilt with a new mapping). Each call holds a read lock for its duration, so Swap waits
+// for in-flight searches to finish and callers never see a closed index. The optional
+// interfaces (TermDictionary, Resetter, BatchIndexer, ChunkIndexer, NegationMatcher,
+// ScopeMatcher) are forwarded when the wrapped index implements them.
+type SwappableIndex struct {
+	mu  sync.RWMutex
+	idx KeywordIndex
+}
+
+// NewSwappableIndex wraps idx.
+func NewSwappableIndex(idx KeywordIndex) *SwappableIndex {
+	return &SwappableIndex{idx: idx}
+}
+
+// Swap calls fn with the current index while no other calls are running and installs the
+// index it returns. A non-nil index is installed even when fn also returns an error, so fn
+// can restore a usable index after a failed replacement.
+func (w *SwappableIndex) Swap(fn func(old KeywordIndex) (KeywordIndex, error)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	next, err := fn(w.idx)
+	if next != nil {
+		w.idx = next
+	}
+	return err
+}
+
+func (w *SwappableIndex) Index(ctx context.Context, id string, doc *models.Document) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Index(ctx, id, doc)
+}
+
+// IndexBatch forwards to the wrapped index's BatchIndexer, or indexes docs one at a time.
+func (w *SwappableIndex) IndexBatch(ctx context.Context, docs []*models.Document) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return IndexDocuments(ctx, w.idx, docs)
+}
+
+// IndexesChunks forwards to the wrapped index's ChunkIndexer.
+func (w *SwappableIndex) IndexesChunks() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return IndexesChunks(w.idx)
+}
+
+// IndexChunks forwards to the wrapped index's ChunkIndexer. Without one, chunks are not
+// indexed.
+func (w *SwappableIndex) IndexChunks(ctx context.Context, doc *models.Document, chunks []*models.DocumentChunk) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return IndexChunks(ctx, w.idx, doc, chunks)
+}
+
+func (w *SwappableIndex) Search(ctx context.Context, query string, limit int, opts *SearchOptions) ([]*KeywordResult, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Search(ctx, query, limit, opts)
+}
+
+func (w *SwappableIndex) Delete(ctx context.Context, id string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Delete(ctx, id)
+}
+
+func (w *SwappableIndex) Close() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Close()
+}
+
+func (w *SwappableIndex) DocCount() (uint64, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.DocCount()
+}
+
+func (w *SwappableIndex) GetTermDocFrequency(term string) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.GetTermDocFrequency(term)
+}
+
+func (w *SwappableIndex) GetCorpusStats(terms []string) (int, map[string]int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.GetCorpusStats(terms)
+}
+
+// GetAllTerms forwards to the wrapped index's TermDictionary.
+func (w *SwappableIndex) GetAllTerms() ([]string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	dict, ok := w.idx.(TermDictionary)
+	if !ok {
+		return nil, errNoTermDictionary
+	}
+	return dict.GetAllTerms()
+}
+
+// GetTermFrequency forwards to the wrapped index's TermDictionary.
+func (w *SwappableIndex) GetTermFrequency(term string) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	dict, ok := w.idx.(TermDictionary)
+	if !ok {
+		return 0, errNoTermDictionary
+	}
+	return dict.GetTermFrequency(term)
+}
+
+// ContainsTerm forwards to the wrapped index's TermDictionary.
+func (w *SwappableIndex) ContainsTerm(term string) (bool, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	dict, ok := w.idx.(TermDictionary)
+	if !ok {
+		return false, errNoTermDictionary
+	}
+	return dict.ContainsTerm(term)
+}
+
+// Reset forwards to the wrapped index's Resetter.
+func (w *SwappableIndex) Reset() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	r, ok := w.idx.(Resetter)
+	if !ok {
+		return errors.New("keyword index cannot be reset")
+	}
+	return r.Reset()
+}
+
+// DocIDs forwards to the wrapped index's Lister.
+func (w *SwappableIndex) DocIDs(ctx context.Context) ([]string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	l, ok := w.idx.(Lister)
+	if !ok {
+		return nil, ErrListUnsupported
+	}
+	return l.DocIDs(ctx)
+}
+
+// Count forwards to the wrapped index's Counter.
+func (w *SwappableIndex) Count(ctx context.Context, query string, opts *SearchOptions) (uint64, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	c, ok := w.idx.(Counter)
+	if !ok {
+		return 0, errNoCounter
+	}
+	return c.Count(ctx, query, opts)
+}
+
+// MatchNegated forwards to the wrapped index's NegationMatcher. Without one, no IDs
+// are reported as negated.
+func (w *SwappableIndex) MatchNegated(ctx context.Context, query string, ids []string) (map[string]bool, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	m, ok := w.idx.(NegationMatcher)
+	if !ok {
+		return map[string]bool{}, nil
+	}
+	return m.MatchNegated(ctx, query, ids)
+}
+
+// MatchScoped forwards to the wrapped index's ScopeMatcher. Without one, every ID is
+// reported as matching.
+func (w *SwappableIndex) MatchScoped(ctx context.Context, query string, ids []string) (map[string]bool, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	m, ok := w.idx.(ScopeMatcher)
+	if !ok {
+		matched := make(map[string]bool, len(ids))
+		for _, id := range ids {
+			matched[id] = true
+		}
+		return matched, nil
+	}
+	return m.MatchScoped(ctx, query, ids)
+}
@@ -0,0 +1,165 @@
+package keyword
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2/analysis"
+	"github.com/blevesearch/bleve/v2/analysis/tokenizer/unicode"
+	"github.com/blevesearch/bleve/v2/registry"
+)
+
+// vocabularyAnalyzerType is the Bleve analyzer type of indexes created with stopwords or
+// protected terms. The analyzer is stored in the index mapping with its word lists, so an
+// index keeps them until Reset.
+const vocabularyAnalyzerType = "sagasu_vocabulary"
+
+// vocabularyAnalyzerName names the analyzer in the mapping of such indexes.
+const vocabularyAnalyzerName = "sagasu"
+
+func init() {
+	registry.RegisterAnalyzer(vocabularyAnalyzerType, newVocabularyAnalyzer)
+}
+
+// vocabularyAnalyzer wraps a base analyzer: it drops the tokens of stopwords and keeps
+// protected terms as written (lower-cased), even where the base analyzer would stem them
+// or drop them as its own stop words. Words are compared by the input text of a token,
+// so a stopword does not remove the words that stem to the same term.
+type vocabularyAnalyzer struct {
+	base      analysis.Analyzer
+	words     analysis.Tokenizer
+	stopwords map[string]bool
+	protected map[string]bool
+}
+
+func newVocabularyAnalyzer(config map[string]interface{}, cache *registry.Cache) (analysis.Analyzer, error) {
+	baseName, _ := config["base"].(string)
+	base, err := cache.AnalyzerNamed(baseName)
+	if err != nil {
+		return nil, fmt.Errorf("vocabulary analyzer: base %q: %w", baseName, err)
+	}
+	words, err := cache.TokenizerNamed(unicode.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &vocabularyAnalyzer{
+		base:      base,
+		words:     words,
+		stopwords: wordSet(configStrings(config["stopwords"])),
+		protected: wordSet(configStrings(config["protected"])),
+	}, nil
+}
+
+// configStrings reads a string list from an analyzer config, which holds []interface{}
+// once the mapping has been stored and loaded as JSON.
+func configStrings(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		out := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// wordSet returns words lower-cased as a set, or nil when there are none.
+func wordSet(words []string) map[string]bool {
+	var set map[string]bool
+	for _, w := range words {
+		if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+			if set == nil {
+				set = make(map[string]bool, len(words))
+			}
+			set[w] = true
+		}
+	}
+	return set
+}
+
+func (a *vocabularyAnalyzer) Analyze(input []byte) analysis.TokenStream {
+	tokens := a.base.Analyze(input)
+	out := make(analysis.TokenStream, 0, len(tokens))
+	starts := make(map[int]bool, len(tokens))
+	for _, tok := range tokens {
+		starts[tok.Start] = true
+		word := tokenWord(input, tok)
+		if a.stopwords[word] {
+			continue
+		}
+		if a.protected[word] {
+			tok.Term = []byte(word)
+		}
+		out = append(out, tok)
+	}
+	if len(a.protected) == 0 {
+		return out
+	}
+	added := false
+	for _, tok := range a.words.Tokenize(input) {
+		if word := strings.ToLower(string(tok.Term)); a.protected[word] && !starts[tok.Start] {
+			tok.Term = []byte(word)
+			out = append(out, tok)
+			added = true
+		}
+	}
+	if added {
+		sort.SliceStable(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+	}
+	return out
+}
+
+// tokenWord returns the lower-cased input text of tok, or its term when the offsets do
+// not fit input.
+func tokenWord(input []byte, tok *analysis.Token) string {
+	if tok.Start < 0 || tok.Start > tok.End || tok.End > len(input) {
+		return string(tok.Term)
+	}
+	return strings.ToLower(string(input[tok.Start:tok.End]))
+}
+
+// BleveOption configures a BleveIndex.
+type BleveOption func(*BleveIndex)
+
+// WithStopwords drops words (case-insensitive) from new indexes and from queries, on top
+// of the analyzer's own stop words; they do not count as query terms either.
+func WithStopwords(words []string) BleveOption {
+	return func(b *BleveIndex) { b.stopwords = wordSet(words) }
+}
+
+// WithProtectedTerms keeps terms (case-insensitive), e.g. domain acronyms, as written in
+// new indexes: the analyzer neither stems nor drops them, and fuzzy search matches them
+// exactly.
+func WithProtectedTerms(terms []string) BleveOption {
+	return func(b *BleveIndex) { b.protected = wordSet(terms) }
+}
+
+// withoutStopwords returns terms without the index's stopwords.
+func (b *BleveIndex) withoutStopwords(terms []string) []string {
+	if len(b.stopwords) == 0 {
+		return terms
+	}
+	out := terms[:0:0]
+	for _, t := range terms {
+		if !b.stopwords[t] {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// sortedWords returns the words of set, sorted, for storing in the index mapping.
+func sortedWords(set map[string]bool) []string {
+	out := make([]string, 0, len(set))
+	for w := range set {
+		out = append(out, w)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,79 @@
+package keyword
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestBleveIndex_vocabulary(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bleve")
+	idx, err := NewBleveIndexWithAnalyzer(path, "english",
+		WithStopwords([]string{"Confidential"}),
+		WithProtectedTerms([]string{"IT", "SAS"}))
+	if err != nil {
+		t.Fatalf("NewBleveIndexWithAnalyzer: %v", err)
+	}
+	ctx := context.Background()
+	for _, doc := range []*models.Document{
+		{ID: "it", Title: "licences.txt", Content: "The IT team renewed the SAS licence. Confidential."},
+		{ID: "cat", Title: "pets.txt", Content: "The cat sat on the mat."},
+	} {
+		if err := idx.Index(ctx, doc.ID, doc); err != nil {
+			t.Fatalf("Index: %v", err)
+		}
+	}
+
+	ids := func(idx *BleveIndex, query string, opts *SearchOptions) []string {
+		t.Helper()
+		results, err := idx.Search(ctx, query, 10, opts)
+		if err != nil {
+			t.Fatalf("Search %q: %v", query, err)
+		}
+		var out []string
+		for _, r := range results {
+			out = append(out, r.ID)
+		}
+		slices.Sort(out)
+		return out
+	}
+	// "it" is an English stop word, but protected.
+	if got := ids(idx, "IT", nil); !slices.Equal(got, []string{"it"}) {
+		t.Errorf("protected stop word: got %v, want [it]", got)
+	}
+	if got := ids(idx, "confidential", nil); len(got) != 0 {
+		t.Errorf("stopword: got %v, want no matches", got)
+	}
+	// Fuzzy "sas" would match "sat"; a protected term matches exactly.
+	fuzzy := &SearchOptions{FuzzyEnabled: true, Fuzziness: 1}
+	if got := ids(idx, "sas", fuzzy); !slices.Equal(got, []string{"it"}) {
+		t.Errorf("fuzzy protected term: got %v, want [it]", got)
+	}
+	if got := ids(idx, "sas", &SearchOptions{FuzzyEnabled: true, Fuzziness: 1, TitleBoost: 2}); !slices.Equal(got, []string{"it"}) {
+		t.Errorf("fuzzy protected term with boosts: got %v, want [it]", got)
+	}
+	if got := ids(idx, "sas OR mat", fuzzy); !slices.Equal(got, []string{"cat", "it"}) {
+		t.Errorf("boolean: got %v, want [cat it]", got)
+	}
+	if got := idx.terms("the confidential licence"); !slices.Equal(got, []string{"the", "licence"}) {
+		t.Errorf("terms = %v, want the stopword left out", got)
+	}
+
+	// The index keeps its analyzer when reopened without options.
+	if err := idx.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewBleveIndex(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer func() {
+		_ = reopened.Close()
+	}()
+	if got := ids(reopened, "it", nil); !slices.Equal(got, []string{"it"}) {
+		t.Errorf("reopened: got %v, want [it]", got)
+	}
+}
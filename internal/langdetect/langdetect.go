@@ -0,0 +1,124 @@
+// Package langdetect guesses the language of a text from its script and, for text in
+// Latin script, from its most frequent function words. It is meant for routing documents
+// to a keyword analyzer, so it only tells apart languages those analyzers handle.
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// sampleRunes is how much of a text is looked at; the start of a document is usually
+// enough to tell its language.
+const sampleRunes = 4000
+
+// minLetters is the fewest letters Detect decides on; shorter texts get "".
+const minLetters = 20
+
+// stopwords are frequent function words by ISO 639-1 code. Words shared by several
+// languages count for each of them; the distinctive ones decide.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "is", "in", "that", "it", "for", "with", "as", "was", "on", "are", "this", "be", "by", "not", "you", "have"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "den", "mit", "sich", "des", "auf", "für", "im", "dem", "von", "auch", "werden"},
+	"fr": {"le", "la", "les", "et", "est", "des", "une", "du", "que", "pas", "pour", "dans", "qui", "sur", "avec", "ce", "il", "au", "sont", "par"},
+	"es": {"el", "la", "los", "las", "y", "es", "que", "del", "en", "una", "por", "con", "para", "se", "no", "su", "al", "lo", "como", "más"},
+	"it": {"il", "la", "che", "di", "e", "è", "per", "una", "del", "non", "sono", "della", "con", "gli", "le", "nel", "anche", "questo", "alla", "si"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "te", "zijn", "met", "voor", "ook", "er", "die", "aan", "wordt", "bij", "maar"},
+	"pt": {"o", "a", "os", "as", "e", "é", "que", "de", "do", "da", "não", "em", "um", "uma", "para", "com", "por", "dos", "das", "mais"},
+	"sv": {"och", "att", "det", "som", "en", "är", "på", "för", "med", "av", "inte", "den", "till", "har", "de", "jag", "ett", "om", "var", "kan"},
+}
+
+// stopwordLanguages maps each stop word to the languages listing it.
+var stopwordLanguages = func() map[string][]string {
+	m := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+// Detect returns the ISO 639-1 code of the language text is most likely written in: one
+// of en, de, fr, es, it, nl, pt, sv, ru, el, ar, ja, zh, or ko. It returns "" when the
+// text is too short or the evidence is too weak to decide.
+func Detect(text string) string {
+	var letters, kana, han, hangul, cyrillic, greek, arabic int
+	n := 0
+	for _, r := range text {
+		if n++; n > sampleRunes {
+			break
+		}
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		case unicode.Is(unicode.Hangul, r):
+			hangul++
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Greek, r):
+			greek++
+		case unicode.Is(unicode.Arabic, r):
+			arabic++
+		}
+	}
+	if letters < minLetters {
+		return ""
+	}
+	// CJK text has no spaces, so a few characters outweigh many Latin words: a third of
+	// the letters is enough. Japanese mixes kana into Han; Chinese has none.
+	third := letters / 3
+	switch {
+	case kana+han > third && kana*10 > kana+han:
+		return "ja"
+	case hangul > third:
+		return "ko"
+	case han > third:
+		return "zh"
+	case cyrillic*2 > letters:
+		return "ru"
+	case greek*2 > letters:
+		return "el"
+	case arabic*2 > letters:
+		return "ar"
+	}
+	return detectByStopwords(text)
+}
+
+// detectByStopwords scores the Latin-script languages by how many of the sample's words
+// are their stop words, and returns the best one if it is clearly ahead.
+func detectByStopwords(text string) string {
+	if len(text) > sampleRunes*2 {
+		text = text[:sampleRunes*2]
+	}
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+	scores := make(map[string]int)
+	for _, w := range words {
+		for _, lang := range stopwordLanguages[w] {
+			scores[lang]++
+		}
+	}
+	best, bestScore, second := "", 0, 0
+	for lang, s := range scores {
+		switch {
+		case s > bestScore || (s == bestScore && lang < best):
+			best, bestScore, second = lang, s, max(second, bestScore)
+		case s > second:
+			second = s
+		}
+	}
+	// Function words are a good share of any running text; require a few of them and a
+	// lead over the next language.
+	if bestScore < 3 || bestScore*10 < len(words) || bestScore*4 < second*5 {
+		return ""
+	}
+	return best
+}
@@ -0,0 +1,33 @@
+package langdetect
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name, text, want string
+	}{
+		{"english", "The quarterly report is ready for review and it shows that the budget was on track for the year.", "en"},
+		{"german", "Der Bericht für das dritte Quartal ist fertig und zeigt, dass die Kosten nicht mit dem Plan übereinstimmen.", "de"},
+		{"french", "Le rapport du troisième trimestre est prêt et il montre que les coûts sont dans le budget pour la région.", "fr"},
+		{"spanish", "El informe del tercer trimestre está listo y muestra que los costes de la empresa son más altos que el plan.", "es"},
+		{"italian", "Il rapporto del terzo trimestre è pronto e mostra che i costi della sede sono per ora nel budget.", "it"},
+		{"dutch", "Het rapport van het derde kwartaal is klaar en laat zien dat de kosten niet binnen het budget zijn.", "nl"},
+		{"portuguese", "O relatório do terceiro trimestre está pronto e mostra que os custos da empresa não estão no orçamento.", "pt"},
+		{"swedish", "Rapporten för tredje kvartalet är klar och den visar att kostnaderna inte har ökat som det var planerat.", "sv"},
+		{"russian", "Отчёт за третий квартал готов и показывает, что расходы остаются в рамках бюджета компании.", "ru"},
+		{"greek", "Η έκθεση του τρίτου τριμήνου είναι έτοιμη και δείχνει ότι το κόστος είναι εντός προϋπολογισμού.", "el"},
+		{"arabic", "تقرير الربع الثالث جاهز ويظهر أن التكاليف ضمن الميزانية المخططة للشركة هذا العام.", "ar"},
+		{"japanese", "第三四半期の報告書が完成しました。費用は予算の範囲内に収まっていることが分かります。", "ja"},
+		{"chinese", "第三季度的报告已经完成，显示公司的费用仍在预算范围之内，没有超出计划。", "zh"},
+		{"korean", "3분기 보고서가 완성되었으며 비용이 예산 범위 안에 있다는 것을 보여줍니다.", "ko"},
+		{"japanese in an english file", "Meeting notes: 来週の会議の議題について確認してください。資料は共有フォルダにあります。", "ja"},
+		{"too short", "Budget 2024", ""},
+		{"numbers only", "12 34 56 78 90 12 34 56 78 90 12 34 56 78 90 12 34 56 78 90", ""},
+		{"no function words", "Invoice total amount currency payment terms customer number reference", ""},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.text); got != tt.want {
+			t.Errorf("%s: Detect() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
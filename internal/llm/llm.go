@@ -0,0 +1,141 @@
+// Package llm is a minimal client for chat models served by Ollama or an OpenAI-compatible
+// endpoint, used to answer questions from retrieved context.
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Chat providers.
+const (
+	// ProviderOllama is a local Ollama server (POST /api/chat).
+	ProviderOllama = "ollama"
+	// ProviderOpenAI is any OpenAI-compatible chat endpoint (POST /chat/completions).
+	ProviderOpenAI = "openai"
+)
+
+// Default client settings.
+const (
+	DefaultOllamaURL = "http://localhost:11434"
+	DefaultOpenAIURL = "https://api.openai.com/v1"
+	DefaultTimeout   = 60 * time.Second
+)
+
+// Config configures a Client. Zero values use the defaults.
+type Config struct {
+	Provider string // ProviderOllama or ProviderOpenAI
+	BaseURL  string
+	Model    string
+	APIKey   string // sent as a bearer token when set
+	// MaxTokens bounds the length of an answer; 0 leaves it to the server.
+	MaxTokens int
+	Timeout   time.Duration
+}
+
+// Message is one chat message.
+type Message struct {
+	Role    string `json:"role"` // "system", "user", or "assistant"
+	Content string `json:"content"`
+}
+
+// Client sends chat requests to a model.
+type Client struct {
+	cfg    Config
+	client *http.Client
+}
+
+// NewClient creates a client for cfg.Provider.
+func NewClient(cfg Config) (*Client, error) {
+	switch cfg.Provider {
+	case ProviderOllama:
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = DefaultOllamaURL
+		}
+	case ProviderOpenAI:
+		if cfg.BaseURL == "" {
+			cfg.BaseURL = DefaultOpenAIURL
+		}
+	default:
+		return nil, fmt.Errorf("unknown llm provider: %s (supported: ollama, openai)", cfg.Provider)
+	}
+	if cfg.Model == "" {
+		return nil, errors.New("llm model is required")
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	return &Client{cfg: cfg, client: &http.Client{Timeout: cfg.Timeout}}, nil
+}
+
+// Model identifies the model answers come from, e.g. "ollama:llama3.2".
+func (c *Client) Model() string {
+	return c.cfg.Provider + ":" + c.cfg.Model
+}
+
+// Chat sends messages and returns the model's reply.
+func (c *Client) Chat(ctx context.Context, messages []Message) (string, error) {
+	url := c.cfg.BaseURL + "/chat/completions"
+	req := map[string]interface{}{"model": c.cfg.Model, "messages": messages}
+	if c.cfg.Provider == ProviderOllama {
+		url = c.cfg.BaseURL + "/api/chat"
+		req["stream"] = false
+		if c.cfg.MaxTokens > 0 {
+			req["options"] = map[string]interface{}{"num_predict": c.cfg.MaxTokens}
+		}
+	} else if c.cfg.MaxTokens > 0 {
+		req["max_tokens"] = c.cfg.MaxTokens
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.cfg.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.cfg.APIKey)
+	}
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("llm request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("llm service returned %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+
+	var out struct {
+		Message Message `json:"message"` // ollama
+		Choices []struct {
+			Message Message `json:"message"`
+		} `json:"choices"` // openai
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decode llm response: %w", err)
+	}
+	if c.cfg.Provider == ProviderOllama {
+		return strings.TrimSpace(out.Message.Content), nil
+	}
+	if len(out.Choices) == 0 {
+		return "", errors.New("llm response has no choices")
+	}
+	return strings.TrimSpace(out.Choices[0].Message.Content), nil
+}
+
+// Close releases idle connections.
+func (c *Client) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
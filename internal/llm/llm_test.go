@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestClient_Chat(t *testing.T) {
+	var got map[string]interface{}
+	var gotPath, gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotAuth = r.URL.Path, r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		if r.URL.Path == "/api/chat" {
+			_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":" From Ollama [1]. "}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"From OpenAI [2]."}}]}`))
+	}))
+	defer srv.Close()
+	messages := AnswerMessages("what is the budget?", "[1] Budget\nThe budget is 10.")
+
+	ollama, err := NewClient(Config{Provider: ProviderOllama, BaseURL: srv.URL + "/", Model: "llama3.2", MaxTokens: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, err := ollama.Chat(context.Background(), messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer != "From Ollama [1]." || gotPath != "/api/chat" {
+		t.Errorf("ollama: answer %q from %s", answer, gotPath)
+	}
+	opts, _ := got["options"].(map[string]interface{})
+	if got["model"] != "llama3.2" || got["stream"] != false || opts["num_predict"] != float64(200) {
+		t.Errorf("ollama request = %v", got)
+	}
+	msgs, _ := got["messages"].([]interface{})
+	if len(msgs) != 2 || !strings.Contains(msgs[0].(map[string]interface{})["content"].(string), "The budget is 10.") {
+		t.Errorf("messages = %v", msgs)
+	}
+
+	openai, err := NewClient(Config{Provider: ProviderOpenAI, BaseURL: srv.URL, Model: "gpt-4o-mini", APIKey: "sk-test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if answer, err = openai.Chat(context.Background(), messages); err != nil {
+		t.Fatal(err)
+	}
+	if answer != "From OpenAI [2]." || gotPath != "/chat/completions" || gotAuth != "Bearer sk-test" {
+		t.Errorf("openai: answer %q from %s, auth %q", answer, gotPath, gotAuth)
+	}
+	if _, ok := got["max_tokens"]; ok {
+		t.Errorf("max_tokens should be omitted when unset: %v", got)
+	}
+	if openai.Model() != "openai:gpt-4o-mini" {
+		t.Errorf("Model() = %q", openai.Model())
+	}
+}
+
+func TestClient_ChatError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not found", http.StatusNotFound)
+	}))
+	defer srv.Close()
+	c, _ := NewClient(Config{Provider: ProviderOllama, BaseURL: srv.URL, Model: "missing"})
+	if _, err := c.Chat(context.Background(), AnswerMessages("q", "")); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("err = %v, want a 404 error", err)
+	}
+}
+
+func TestNewClient_invalid(t *testing.T) {
+	if _, err := NewClient(Config{Provider: "anthropic", Model: "x"}); err == nil {
+		t.Error("unknown provider should fail")
+	}
+	if _, err := NewClient(Config{Provider: ProviderOllama}); err == nil {
+		t.Error("missing model should fail")
+	}
+}
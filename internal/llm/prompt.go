@@ -0,0 +1,15 @@
+package llm
+
+// answerInstructions tell the model to answer from the numbered sources of the context only.
+const answerInstructions = `You answer questions using only the numbered sources below, which are excerpts from the user's documents.
+Cite the sources you use with their numbers in square brackets, e.g. [1] or [2][3], right after the statements they support.
+If the sources do not contain the answer, say that you could not find it in the documents. Do not use outside knowledge.`
+
+// AnswerMessages returns the chat messages asking the model to answer question from
+// context, an assembled set of sources headed [1], [2], ...
+func AnswerMessages(question, context string) []Message {
+	return []Message{
+		{Role: "system", Content: answerInstructions + "\n\nSources:\n\n" + context},
+		{Role: "user", Content: question},
+	}
+}
@@ -0,0 +1,86 @@
+package models
+
+import "time"
+
+// SearchEvent is one search recorded by the analytics log, with the result the user
+// opened, if any.
+type SearchEvent struct {
+	ID    int64     `json:"id"`
+	Time  time.Time `json:"time"`
+	Query string    `json:"query"`
+	// KeywordResults and SemanticResults are the total matches of each kind.
+	KeywordResults  int   `json:"keyword_results"`
+	SemanticResults int   `json:"semantic_results"`
+	LatencyMs       int64 `json:"latency_ms"`
+	// ClickedDocumentID is the last result opened from this search; ClickedRank is its
+	// 1-based position in the results.
+	ClickedDocumentID string `json:"clicked_document_id,omitempty"`
+	ClickedRank       int    `json:"clicked_rank,omitempty"`
+	// Results are the places of the documents returned, kept to compare later searches
+	// for the query with; see ResultsDiff.
+	Results []ResultRef `json:"results,omitempty"`
+}
+
+// FeedbackRequest is the request body for POST /api/v1/feedback.
+type FeedbackRequest struct {
+	// QueryID is the query_id of the search response the result came from.
+	QueryID    int64  `json:"query_id"`
+	DocumentID string `json:"document_id"`
+	// Rank is the 1-based position of the result; 0 when unknown.
+	Rank int `json:"rank,omitempty"`
+}
+
+// OpenEvent records that a search result was opened. Unlike the click of a SearchEvent,
+// every open is kept, so repeated opens of a document for a query add up.
+type OpenEvent struct {
+	ID         int64     `json:"id"`
+	Time       time.Time `json:"time"`
+	Query      string    `json:"query"`
+	DocumentID string    `json:"document_id"`
+	// Rank is the 1-based position of the result; 0 when unknown.
+	Rank int `json:"rank,omitempty"`
+	// QueryID is the analytics ID of the search the result came from, when known.
+	QueryID int64 `json:"query_id,omitempty"`
+}
+
+// OpenFeedbackRequest is the request body for POST /api/v1/feedback/open.
+type OpenFeedbackRequest struct {
+	Query      string `json:"query"`
+	DocumentID string `json:"document_id"`
+	Rank       int    `json:"rank,omitempty"`
+	// QueryID, when set, also records the open as the click of that search.
+	QueryID int64 `json:"query_id,omitempty"`
+}
+
+// DocumentOpenStats counts the recorded opens of one document.
+type DocumentOpenStats struct {
+	DocumentID string    `json:"document_id"`
+	Opens      int       `json:"opens"`
+	Queries    int       `json:"queries"` // distinct queries it was opened from
+	LastOpened time.Time `json:"last_opened"`
+}
+
+// QueryStats aggregates the searches for one query, compared case-insensitively.
+type QueryStats struct {
+	Query        string  `json:"query"`
+	Count        int     `json:"count"`
+	AvgResults   float64 `json:"avg_results"`
+	Clicks       int     `json:"clicks"`
+	AvgLatencyMs float64 `json:"avg_latency_ms"`
+}
+
+// AnalyticsReport is the response for GET /api/v1/analytics.
+type AnalyticsReport struct {
+	Searches           int     `json:"searches"`
+	ZeroResultSearches int     `json:"zero_result_searches"`
+	ClickedSearches    int     `json:"clicked_searches"`
+	AvgLatencyMs       float64 `json:"avg_latency_ms"`
+	// TopQueries are the most frequent queries, most frequent first.
+	TopQueries []*QueryStats `json:"top_queries"`
+	// ZeroResultQueries are the most frequent queries that returned nothing.
+	ZeroResultQueries []*QueryStats `json:"zero_result_queries"`
+	// Opens counts the results opened, as reported by POST /api/v1/feedback/open.
+	Opens int `json:"opens"`
+	// TopOpened are the most opened documents, most opened first.
+	TopOpened []*DocumentOpenStats `json:"top_opened"`
+}
@@ -0,0 +1,43 @@
+package models
+
+// AskRequest is the request for POST /api/v1/ask. The embedded query selects the documents
+// (its filters apply); when it enables neither keyword nor semantic search, both are used.
+type AskRequest struct {
+	SearchQuery
+	// MaxChunks is the most chunks put in the context (default 8).
+	MaxChunks int `json:"max_chunks,omitempty"`
+	// MaxContextChars bounds the length of the context (default 6000).
+	MaxContextChars int `json:"max_context_chars,omitempty"`
+	// ContextOnly returns the context without asking the configured LLM.
+	ContextOnly bool `json:"context_only,omitempty"`
+}
+
+// AskSource is a document quoted in the context of an answer.
+type AskSource struct {
+	// Citation is the number the document is quoted under, as [n], in the context and answer.
+	Citation   int     `json:"citation"`
+	DocumentID string  `json:"document_id"`
+	Title      string  `json:"title"`
+	Path       string  `json:"path,omitempty"`
+	Score      float64 `json:"score"`
+	// Confidence is the document's search result confidence (see SearchResult).
+	Confidence float64 `json:"confidence"`
+	// Chunks are the indexes of the document's chunks quoted, in document order.
+	Chunks []int `json:"chunks"`
+}
+
+// AskResponse is the response for POST /api/v1/ask.
+type AskResponse struct {
+	Query string `json:"query"`
+	// Answer is the LLM's answer citing Sources; empty when no LLM was asked.
+	Answer string `json:"answer,omitempty"`
+	// Model identifies the LLM that wrote Answer.
+	Model string `json:"model,omitempty"`
+	// Context holds the quoted chunks under a "[n] title (path)" heading per source.
+	Context   string       `json:"context"`
+	Sources   []*AskSource `json:"sources"`
+	QueryTime int64        `json:"query_time_ms"`
+	// Confidence is the search's confidence (see SearchResponse); callers may decline to
+	// answer when it is low.
+	Confidence float64 `json:"confidence"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Audit actions.
+const (
+	AuditSearch       = "search"        // POST /api/v1/search
+	AuditAsk          = "ask"           // POST /api/v1/ask
+	AuditDocumentGet  = "document.get"  // GET /api/v1/documents/{id}
+	AuditDocumentFile = "document.file" // GET /api/v1/documents/{id}/file
+	AuditDocumentOpen = "document.open" // POST /api/v1/documents/{id}/open
+)
+
+// AuditEntry records one search or document content fetch: who made it, what was asked
+// for, and when.
+type AuditEntry struct {
+	ID   int64     `json:"id"`
+	Time time.Time `json:"time"`
+	// Client is the address the request came from.
+	Client string `json:"client"`
+	// APIKey is the name of the API key the request authenticated with, if any.
+	APIKey string `json:"api_key,omitempty"`
+	Action string `json:"action"`
+	// Query is the search text for searches.
+	Query string `json:"query,omitempty"`
+	// DocumentID is the fetched document for document actions.
+	DocumentID string `json:"document_id,omitempty"`
+	// Results is the number of documents a search returned.
+	Results int `json:"results,omitempty"`
+	// Status is the HTTP status of the response.
+	Status int `json:"status"`
+}
+
+// AuditResponse is the response for GET /api/v1/audit.
+type AuditResponse struct {
+	Entries []*AuditEntry `json:"entries"`
+	Total   int           `json:"total"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Change log operations, recorded by the database for every document write.
+const (
+	ChangeOpInsert = "insert"
+	ChangeOpUpdate = "update"
+	ChangeOpDelete = "delete"
+)
+
+// ChangeLogEntry is one document write in the change log.
+type ChangeLogEntry struct {
+	Seq        int64
+	DocumentID string
+	Op         string
+	Time       time.Time
+}
+
+// Document changes reported by GET /api/v1/status/changes.
+const (
+	ChangeAdded   = "added"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// DocumentChange is the net change of a document since a cursor.
+type DocumentChange struct {
+	ID     string    `json:"id"`
+	Change string    `json:"change"`
+	Time   time.Time `json:"time"` // of the document's last write
+}
+
+// ChangesResponse is the response for GET /api/v1/status/changes.
+type ChangesResponse struct {
+	Changes []*DocumentChange `json:"changes"`
+	// Cursor is passed as ?since= to get the changes after these.
+	Cursor string `json:"cursor"`
+	// HasMore reports that more changes follow Cursor.
+	HasMore bool `json:"has_more,omitempty"`
+	// Reset reports that ?since= came from another database (the index was rebuilt or
+	// replaced), so the client must list all documents again.
+	Reset bool `json:"reset,omitempty"`
+}
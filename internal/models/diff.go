@@ -0,0 +1,71 @@
+package models
+
+import "time"
+
+// Result lists of a SearchResponse, as named in ResultRef.
+const (
+	ResultListKeyword  = "keyword"  // NonSemanticResults
+	ResultListSemantic = "semantic" // SemanticResults
+	ResultListFused    = "fused"    // Results
+)
+
+// ResultRef is a document's place in a search response: its list and 1-based rank there.
+type ResultRef struct {
+	DocumentID string `json:"document_id"`
+	List       string `json:"list"`
+	Rank       int    `json:"rank"`
+}
+
+// ResultRefs returns the places of the results of response: fused results, or keyword
+// results first.
+func ResultRefs(response *SearchResponse) []ResultRef {
+	var refs []ResultRef
+	add := func(list string, results []*SearchResult) {
+		for i, r := range results {
+			if r.Document != nil {
+				refs = append(refs, ResultRef{DocumentID: r.Document.ID, List: list, Rank: i + 1})
+			}
+		}
+	}
+	add(ResultListFused, response.Results)
+	add(ResultListKeyword, response.NonSemanticResults)
+	add(ResultListSemantic, response.SemanticResults)
+	return refs
+}
+
+// ResultsDiffRequest is the request body for POST /api/v1/search/diff. Query is compared
+// with either Against, another search run now, or the search for the same query text
+// recorded in the analytics log at Since.
+type ResultsDiffRequest struct {
+	Query   *SearchQuery `json:"query"`
+	Against *SearchQuery `json:"against,omitempty"`
+	Since   *time.Time   `json:"since,omitempty"`
+}
+
+// ResultChange is a document that entered, left, or moved within the compared results.
+// Before and After are its places in the baseline and in the current results; nil when
+// it was not there.
+type ResultChange struct {
+	DocumentID string     `json:"document_id"`
+	Title      string     `json:"title,omitempty"`
+	Path       string     `json:"path,omitempty"`
+	Before     *ResultRef `json:"before,omitempty"`
+	After      *ResultRef `json:"after,omitempty"`
+	// Deleted reports that a document that left the results is no longer indexed.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// ResultsDiff compares the results of a query with a baseline: those of another search
+// (Against) or those recorded for the query at BaselineTime.
+type ResultsDiff struct {
+	Query   string `json:"query"`
+	Against string `json:"against,omitempty"`
+	// BaselineTime is when the baseline search was recorded; nil for a search run now.
+	BaselineTime *time.Time `json:"baseline_time,omitempty"`
+	// Limit is the number of results per list compared.
+	Limit     int             `json:"limit"`
+	Entered   []*ResultChange `json:"entered"`
+	Left      []*ResultChange `json:"left"`
+	Moved     []*ResultChange `json:"moved"`
+	Unchanged int             `json:"unchanged"`
+}
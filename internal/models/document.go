@@ -30,3 +30,65 @@ type DocumentInput struct {
 	Content  string                 `json:"content"`
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
+
+// Batch indexing statuses.
+const (
+	BatchIndexed = "indexed"
+	BatchFailed  = "failed"
+)
+
+// BatchIndexResult is the outcome of one document of POST /api/v1/documents:batch.
+type BatchIndexResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchIndexResponse is the response for POST /api/v1/documents:batch, with one result
+// per input document in request order.
+type BatchIndexResponse struct {
+	Results []*BatchIndexResult `json:"results"`
+	Indexed int                 `json:"indexed"`
+	Failed  int                 `json:"failed"`
+}
+
+// DocumentDetail is the response for GET /api/v1/documents/{id}: the document with its
+// chunks in order.
+type DocumentDetail struct {
+	*Document
+	Chunks []*DocumentChunk `json:"chunks"`
+}
+
+// DocumentSummary is a document listed by GET /api/v1/documents, without its content.
+type DocumentSummary struct {
+	ID        string                 `json:"id"`
+	Title     string                 `json:"title"`
+	Path      string                 `json:"path,omitempty"` // source file path, empty for documents indexed via the API
+	Metadata  map[string]interface{} `json:"metadata"`
+	CreatedAt time.Time              `json:"created_at"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+// DocumentListFilter narrows a document listing; zero values match every document.
+type DocumentListFilter struct {
+	PathPrefix string
+	// Extensions are lower case, without the dot. Documents without a source file have none.
+	Extensions []string
+}
+
+// DocumentListResponse is the response for GET /api/v1/documents.
+type DocumentListResponse struct {
+	Documents []*DocumentSummary `json:"documents"`
+	Total     int                `json:"total"` // documents matching the filter, across pages
+	Offset    int                `json:"offset"`
+	Limit     int                `json:"limit"`
+}
+
+// RecentDocument is a recently modified document, listed without its content.
+type RecentDocument struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title"`
+	Path       string    `json:"path,omitempty"` // source file path, empty for documents indexed via the API
+	ModifiedAt time.Time `json:"modified_at"`    // file mtime, or the last index time when there is no file
+	IndexedAt  time.Time `json:"indexed_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+// DuplicateFile is one document of a DuplicateGroup.
+type DuplicateFile struct {
+	DocumentID string `json:"document_id"`
+	Title      string `json:"title,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Size       int64  `json:"size"` // source file size in bytes, 0 when unknown
+}
+
+// DuplicateGroup is a set of indexed documents with identical or near-identical content,
+// ordered by path.
+type DuplicateGroup struct {
+	// Identical is true when all documents have the same content fingerprint.
+	Identical bool             `json:"identical"`
+	Files     []*DuplicateFile `json:"files"`
+	// ReclaimableBytes is the size of all files but the largest: the space freed by
+	// keeping one copy.
+	ReclaimableBytes int64 `json:"reclaimable_bytes"`
+}
+
+// DuplicatesResponse lists groups of duplicate documents, most reclaimable space first.
+type DuplicatesResponse struct {
+	Groups      []*DuplicateGroup `json:"groups"`
+	Total       int               `json:"total"` // number of groups
+	Offset      int               `json:"offset"`
+	Limit       int               `json:"limit"`
+	MaxDistance int               `json:"max_distance"`
+}
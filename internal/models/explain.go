@@ -0,0 +1,39 @@
+package models
+
+// QueryExplanation describes how a search query is interpreted: what the keyword index
+// matches, the text embedded for semantic search, the filters applied to the results,
+// and the fuzzy expansions and spelling corrections suggested by the indexed terms.
+type QueryExplanation struct {
+	Query string `json:"query"`
+	// Boolean is true when the query uses AND, OR, NOT, -term, parentheses, or field
+	// scopes. Otherwise any of its terms may match.
+	Boolean   bool          `json:"boolean"`
+	Operators []string      `json:"operators,omitempty"`
+	Terms     []string      `json:"terms,omitempty"`     // words matched in title or content
+	Phrases   []string      `json:"phrases,omitempty"`   // quoted phrases matched in title or content
+	Near      []string      `json:"near,omitempty"`      // NEAR groups, e.g. deep NEAR/3 learning
+	Negations []string      `json:"negations,omitempty"` // terms and "phrases" that exclude documents
+	Filters   []QueryFilter `json:"filters,omitempty"`
+	// KeywordText is the text sent to the keyword index, without path: and ext: filters.
+	KeywordText string `json:"keyword_text,omitempty"`
+	// SemanticText is the text embedded for semantic search; when empty it is skipped.
+	SemanticText string `json:"semantic_text,omitempty"`
+	Fuzzy        bool   `json:"fuzzy"`
+	// FuzzyExpansions lists, for each term, the closest indexed terms it also matches.
+	FuzzyExpansions map[string][]string `json:"fuzzy_expansions,omitempty"`
+	Misspelled      []string            `json:"misspelled,omitempty"`      // terms not in the index
+	CorrectedQuery  string              `json:"corrected_query,omitempty"` // the query with misspelled terms corrected
+	// Synonyms lists, for each term, the synonyms keyword search also matches.
+	Synonyms map[string][]string `json:"synonyms,omitempty"`
+	// Fields are the fields searched instead of title and content, when restricted.
+	Fields []string `json:"fields,omitempty"`
+	// Mode is wildcard or regex when the query is matched as a pattern instead of parsed.
+	Mode string `json:"mode,omitempty"`
+}
+
+// QueryFilter is one restriction on the documents a query returns.
+type QueryFilter struct {
+	Field   string `json:"field"` // title, path, ext, path_prefix, modified_after, modified_before, created_after, created_before, min_size, max_size, or a metadata key
+	Value   string `json:"value"`
+	Exclude bool   `json:"exclude,omitempty"` // documents matching the value are removed
+}
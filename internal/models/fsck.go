@@ -0,0 +1,43 @@
+package models
+
+// FsckReport is the response for GET and POST /api/v1/fsck: where storage, the keyword
+// and vector indexes, and the filesystem disagree, and what a repair fixed.
+type FsckReport struct {
+	Documents        int64  `json:"documents"`
+	Chunks           int64  `json:"chunks"`
+	Vectors          int    `json:"vectors"`
+	KeywordDocuments uint64 `json:"keyword_documents"`
+	// OrphanChunks are stored chunks whose document is gone.
+	OrphanChunks FsckFinding `json:"orphan_chunks"`
+	// OrphanVectors are vectors whose chunk is not stored.
+	OrphanVectors FsckFinding `json:"orphan_vectors"`
+	// MissingVectors are stored chunks without a vector. Stop chunks, which are never
+	// embedded, are not counted.
+	MissingVectors FsckFinding `json:"missing_vectors"`
+	// OrphanKeywordDocuments are keyword index entries whose document is not stored.
+	OrphanKeywordDocuments FsckFinding `json:"orphan_keyword_documents"`
+	// MissingKeywordDocuments are stored documents missing from the keyword index.
+	MissingKeywordDocuments FsckFinding `json:"missing_keyword_documents"`
+	// MissingSources are documents indexed from files that no longer exist; their items
+	// are the file paths.
+	MissingSources FsckFinding `json:"missing_sources"`
+	// Skipped describes the checks the storage or an index could not run.
+	Skipped []string `json:"skipped,omitempty"`
+	// Repaired reports that the check repaired what it found.
+	Repaired bool `json:"repaired"`
+}
+
+// FsckFinding is one kind of inconsistency found by a consistency check.
+type FsckFinding struct {
+	Count int `json:"count"`
+	// Items lists the first of them: chunk or document IDs, or file paths.
+	Items []string `json:"items,omitempty"`
+	// Fixed is how many of them a repair fixed.
+	Fixed int `json:"fixed,omitempty"`
+}
+
+// Problems returns the number of inconsistencies found.
+func (r *FsckReport) Problems() int {
+	return r.OrphanChunks.Count + r.OrphanVectors.Count + r.MissingVectors.Count +
+		r.OrphanKeywordDocuments.Count + r.MissingKeywordDocuments.Count + r.MissingSources.Count
+}
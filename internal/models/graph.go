@@ -0,0 +1,31 @@
+package models
+
+// GraphNode is a document of a SimilarityGraph.
+type GraphNode struct {
+	DocumentID string `json:"document_id"`
+	Title      string `json:"title,omitempty"`
+	Path       string `json:"path,omitempty"`
+	// Cluster numbers the connected groups of related documents from 1, largest first.
+	Cluster int `json:"cluster"`
+}
+
+// GraphEdge links two related documents of a SimilarityGraph.
+type GraphEdge struct {
+	Source     string  `json:"source"`
+	Target     string  `json:"target"`
+	Similarity float64 `json:"similarity"` // cosine similarity of the documents' mean embeddings
+	// Duplicate is true when the content fingerprints are near enough for the documents
+	// to be near-identical copies (see DuplicateGroup).
+	Duplicate bool `json:"duplicate,omitempty"`
+}
+
+// SimilarityGraph links the documents whose embeddings are at least Threshold similar.
+// Only documents with at least one edge are nodes.
+type SimilarityGraph struct {
+	Threshold float64      `json:"threshold"`
+	Neighbors int          `json:"neighbors"` // most edges kept per document
+	Compared  int          `json:"compared"`  // documents with vectors that were compared
+	Clusters  int          `json:"clusters"`
+	Nodes     []*GraphNode `json:"nodes"`
+	Edges     []*GraphEdge `json:"edges"`
+}
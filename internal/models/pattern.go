@@ -0,0 +1,89 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// Query modes for SearchQuery.Mode.
+const (
+	QueryModeWildcard = "wildcard" // * matches any letters or digits, ? exactly one
+	QueryModeRegex    = "regex"    // a regular expression matched against whole indexed words
+	QueryModeLiteral  = "literal"  // an exact, case-insensitive substring of the title or content
+)
+
+// LiteralMatch is a document containing the text of a literal query, with the number of
+// times it occurs in the title and in the content.
+type LiteralMatch struct {
+	DocumentID   string
+	TitleCount   int
+	ContentCount int
+}
+
+// MaxPatternLength caps the length of wildcard, regex, and literal queries.
+const MaxPatternLength = 256
+
+// minWildcardLiterals is how many letters or digits a wildcard pattern needs, so that
+// "*" or "???" cannot match every word in the index.
+const minWildcardLiterals = 2
+
+// validatePattern checks query as a pattern of mode: its length, and for wildcards the
+// letters and digits it needs, for regexes syntax the keyword index can run.
+func validatePattern(mode, query string) error {
+	if len(query) > MaxPatternLength {
+		return fmt.Errorf("%s pattern is longer than %d characters", mode, MaxPatternLength)
+	}
+	if mode == QueryModeLiteral {
+		if strings.TrimSpace(query) == "" {
+			return fmt.Errorf("literal query cannot be blank")
+		}
+		return nil
+	}
+	if mode == QueryModeWildcard {
+		literals := 0
+		for _, r := range query {
+			if unicode.IsLetter(r) || unicode.IsNumber(r) {
+				literals++
+			}
+		}
+		if literals < minWildcardLiterals {
+			return fmt.Errorf("wildcard pattern needs at least %d letters or digits", minWildcardLiterals)
+		}
+		return nil
+	}
+	re, err := regexp.Compile(query)
+	if err != nil {
+		return fmt.Errorf("invalid regex: %w", err)
+	}
+	if re.MatchString("") {
+		return fmt.Errorf("regex must not match an empty word")
+	}
+	parsed, err := syntax.Parse(query, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("invalid regex: %w", err)
+	}
+	return checkRegexSyntax(parsed)
+}
+
+// checkRegexSyntax rejects what the index's regex automaton does not support: anchors
+// and word boundaries (a regex always matches a whole word) and lazy quantifiers.
+func checkRegexSyntax(re *syntax.Regexp) error {
+	switch re.Op {
+	case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return fmt.Errorf("regex cannot use anchors or word boundaries: it always matches whole words")
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		if re.Flags&syntax.NonGreedy != 0 {
+			return fmt.Errorf("regex cannot use lazy quantifiers")
+		}
+	}
+	for _, sub := range re.Sub {
+		if err := checkRegexSyntax(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
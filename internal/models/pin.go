@@ -0,0 +1,45 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Pin puts a document, or the documents under a path, first in the results of queries
+// containing its terms (a "best bet").
+type Pin struct {
+	ID string `json:"id"`
+	// Query holds the terms a search must contain, in any order and case, for the pin to apply.
+	Query string `json:"query"`
+	// DocumentID pins one document; it is added to the results when the search missed it.
+	DocumentID string `json:"document_id,omitempty"`
+	// Path pins the matching documents whose source path starts with it.
+	Path      string    `json:"path,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks that the pin has query terms and exactly one of DocumentID and Path.
+func (p *Pin) Validate() error {
+	if len(p.Terms()) == 0 {
+		return errors.New("query is required")
+	}
+	if (p.DocumentID == "") == (p.Path == "") {
+		return errors.New("exactly one of document_id and path is required")
+	}
+	return nil
+}
+
+// Terms returns the lowercased words of the pin's query.
+func (p *Pin) Terms() []string {
+	return QueryWords(p.Query)
+}
+
+// QueryWords splits text into lowercased words of letters and digits, dropping operators,
+// quotes and other punctuation.
+func QueryWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
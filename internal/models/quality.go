@@ -0,0 +1,37 @@
+package models
+
+// QualityReport is the response for GET /api/v1/quality: how far the stored chunk
+// embeddings of a random sample have drifted from what the current model produces, and
+// whether the storage, vector, and keyword indexes agree on what they hold.
+type QualityReport struct {
+	Documents        int64  `json:"documents"`
+	Chunks           int64  `json:"chunks"`
+	Vectors          int    `json:"vectors"`
+	KeywordDocuments uint64 `json:"keyword_documents"`
+	// Sampled is the number of chunks whose embeddings were recomputed. Stop chunks,
+	// which are never embedded, are not sampled.
+	Sampled int `json:"sampled"`
+	// Compared is the number of sampled chunks whose stored vector could be read back.
+	Compared int `json:"compared"`
+	// MissingVectors is the number of sampled chunks with no stored vector.
+	MissingVectors int `json:"missing_vectors"`
+	// MeanDrift and MaxDrift are 1 - cosine similarity between stored and recomputed
+	// embeddings, over the compared chunks.
+	MeanDrift float64 `json:"mean_drift"`
+	MaxDrift  float64 `json:"max_drift"`
+	// SelfRecall is the share of sampled chunks whose recomputed embedding finds the
+	// chunk's own vector among the top results of the vector index.
+	SelfRecall float64 `json:"self_recall"`
+	// Worst lists the most drifted chunks, most drifted first.
+	Worst []*ChunkDrift `json:"worst,omitempty"`
+	// Warnings describe likely problems; empty when the index looks healthy.
+	Warnings []string `json:"warnings"`
+}
+
+// ChunkDrift is the embedding drift of one sampled chunk.
+type ChunkDrift struct {
+	ChunkID    string  `json:"chunk_id"`
+	DocumentID string  `json:"document_id"`
+	Title      string  `json:"title"`
+	Drift      float64 `json:"drift"`
+}
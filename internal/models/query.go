@@ -1,6 +1,45 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Sort fields for SearchQuery.SortBy.
+const (
+	SortByRelevance    = "relevance"
+	SortByModifiedTime = "modified_time"
+	SortByTitle        = "title"
+	SortBySize         = "size"
+)
+
+// Sort directions for SearchQuery.SortOrder.
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// MaxPhraseSlop caps SearchQuery.PhraseSlop and search.keyword_phrase_slop.
+const MaxPhraseSlop = 20
+
+// MaxResultChunks caps SearchQuery.Chunks.
+const MaxResultChunks = 20
+
+// Fusion strategies for SearchQuery.Fusion, merging keyword and semantic matches into one
+// ranked list (SearchResponse.Results).
+const (
+	FusionRRF      = "rrf"      // reciprocal rank fusion: sum of 1/(k + rank) over both lists
+	FusionWeighted = "weighted" // weighted sum of the keyword and semantic scores
+	FusionMax      = "max"      // the higher of the keyword and semantic scores
+)
+
+// Search fields for SearchQuery.Fields.
+const (
+	SearchFieldTitle = "title" // the document title, usually the file name
+	SearchFieldPath  = "path"  // the source path: directory and file names
+)
 
 // SearchQuery represents a search request with optional filters.
 type SearchQuery struct {
@@ -10,10 +49,89 @@ type SearchQuery struct {
 	KeywordEnabled     bool                   `json:"keyword_enabled,omitempty"`
 	SemanticEnabled    bool                   `json:"semantic_enabled,omitempty"`
 	FuzzyEnabled       bool                   `json:"fuzzy_enabled,omitempty"`         // enable fuzzy matching for typo tolerance
+	// Keyword tuning overrides for this query; unset fields use the search config.
+	Fuzziness          int                    `json:"fuzziness,omitempty"`             // fuzzy edit distance, 1 or 2
+	TitleBoost         float64                `json:"title_boost,omitempty"`           // multiplier for title matches
+	PhraseBoost        float64                `json:"phrase_boost,omitempty"`          // multiplier for adjacent query terms
+	PhraseSlop         *int                   `json:"phrase_slop,omitempty"`           // words allowed between the terms of a phrase match; 0 = adjacent
+	CoverageExponent   *float64               `json:"coverage_exponent,omitempty"`     // power of the matched-term share; 0 disables the penalty
 	MinScore           float64                `json:"min_score,omitempty"`             // legacy: used for both when MinKeywordScore/MinSemanticScore are unset
 	MinKeywordScore    float64                `json:"min_keyword_score,omitempty"`     // minimum score for keyword (non-semantic) results
 	MinSemanticScore   float64                `json:"min_semantic_score,omitempty"`    // minimum score for semantic-only results
+	// Filters keeps only documents whose metadata has each key with the given value, or
+	// a list (such as tags) containing it.
 	Filters            map[string]interface{} `json:"filters,omitempty"`
+	Extensions         []string               `json:"extensions,omitempty"`      // file extensions to keep, e.g. ["pdf", ".docx"]
+	PathPrefix         string                 `json:"path_prefix,omitempty"`     // keep documents whose source path starts with this
+	ModifiedAfter      *time.Time             `json:"modified_after,omitempty"`  // keep documents modified at or after this time
+	ModifiedBefore     *time.Time             `json:"modified_before,omitempty"` // keep documents modified before this time
+	CreatedAfter       *time.Time             `json:"created_after,omitempty"`   // keep files created at or after this time
+	CreatedBefore      *time.Time             `json:"created_before,omitempty"`  // keep files created before this time
+	MinSize            int64                  `json:"min_size,omitempty"`        // minimum source file size in bytes
+	MaxSize            int64                  `json:"max_size,omitempty"`        // maximum source file size in bytes (0 = no limit)
+	// SortBy orders each result list by relevance (default), modified_time, title, or size.
+	SortBy             string                 `json:"sort_by,omitempty"`
+	// SortOrder is asc or desc. Defaults to desc for modified_time and size and asc for
+	// title; relevance is always best first.
+	SortOrder          string                 `json:"sort_order,omitempty"`
+	// Dedupe collapses near-identical documents into one result; false returns every
+	// copy. Unset uses search.dedupe_enabled.
+	Dedupe             *bool                  `json:"dedupe,omitempty"`
+	// Fields restricts matching to the title or path (or both) instead of title and
+	// content. Every query term must match one of them, and semantic search is skipped.
+	Fields             []string               `json:"fields,omitempty"`
+	// Mode runs Query as a wildcard pattern or regular expression (QueryModeWildcard,
+	// QueryModeRegex) over title and content, or Fields, instead of as words, or as an
+	// exact substring (QueryModeLiteral) of the title and content. Semantic search and
+	// fuzzy matching are skipped.
+	Mode               string                 `json:"mode,omitempty"`
+	// Snapshot keeps only the documents of the named snapshot whose content is unchanged
+	// since it was taken (see Snapshot).
+	Snapshot           string                 `json:"snapshot,omitempty"`
+	// AsOf searches the documents as they were at this time instead of as they are, from
+	// the versions kept with storage.sqlite.version_history. Only keyword search runs.
+	AsOf               *time.Time             `json:"as_of,omitempty"`
+	// Chunks returns up to this many of each result's matching chunks, best first, with
+	// their character offsets in the document (SearchResult.Chunks); 0 returns none.
+	Chunks             int                    `json:"chunks,omitempty"`
+	// Fusion returns keyword and semantic matches as one list, SearchResponse.Results,
+	// ranked by a fusion strategy (FusionRRF, FusionWeighted, FusionMax) instead of as
+	// the two disjoint lists; empty keeps the split.
+	Fusion             string                 `json:"fusion,omitempty"`
+	// KeywordWeight and SemanticWeight weight the scores with FusionWeighted; when both
+	// are 0, search.default_keyword_weight and default_semantic_weight apply.
+	KeywordWeight      float64                `json:"keyword_weight,omitempty"`
+	SemanticWeight     float64                `json:"semantic_weight,omitempty"`
+	// DiversityLambda overrides search.diversity_lambda, the weight of maximal marginal
+	// relevance diversification: 0 keeps relevance order.
+	DiversityLambda    *float64               `json:"diversity_lambda,omitempty"`
+}
+
+// IsPattern reports whether Query is a wildcard pattern, regular expression, or literal
+// string rather than words.
+func (q *SearchQuery) IsPattern() bool {
+	return q.Mode == QueryModeWildcard || q.Mode == QueryModeRegex || q.Mode == QueryModeLiteral
+}
+
+// SortsByField reports whether results are ordered by a document field instead of relevance.
+func (q *SearchQuery) SortsByField() bool {
+	return q.SortBy != "" && q.SortBy != SortByRelevance
+}
+
+// SortDescending reports whether a field sort runs from largest (newest, last) to smallest.
+func (q *SearchQuery) SortDescending() bool {
+	if q.SortOrder != "" {
+		return q.SortOrder == SortDesc
+	}
+	return q.SortBy != SortByTitle
+}
+
+// HasDocumentFilters reports whether any metadata filter (extension, path, modification
+// or creation time, size, or custom metadata) or a snapshot is set.
+func (q *SearchQuery) HasDocumentFilters() bool {
+	return len(q.Filters) > 0 || len(q.Extensions) > 0 || q.PathPrefix != "" ||
+		q.ModifiedAfter != nil || q.ModifiedBefore != nil || q.CreatedAfter != nil || q.CreatedBefore != nil ||
+		q.MinSize > 0 || q.MaxSize > 0 || q.Snapshot != ""
 }
 
 // Validate ensures the search query has valid fields and sets defaults.
@@ -22,6 +140,98 @@ func (q *SearchQuery) Validate() error {
 	if q.Query == "" {
 		return fmt.Errorf("query cannot be empty")
 	}
+	if q.MinSize < 0 || q.MaxSize < 0 {
+		return fmt.Errorf("size filters cannot be negative")
+	}
+	if q.MaxSize > 0 && q.MinSize > q.MaxSize {
+		return fmt.Errorf("min_size cannot exceed max_size")
+	}
+	if q.ModifiedAfter != nil && q.ModifiedBefore != nil && !q.ModifiedAfter.Before(*q.ModifiedBefore) {
+		return fmt.Errorf("modified_after must be before modified_before")
+	}
+	if q.CreatedAfter != nil && q.CreatedBefore != nil && !q.CreatedAfter.Before(*q.CreatedBefore) {
+		return fmt.Errorf("created_after must be before created_before")
+	}
+	if q.Fuzziness < 0 || q.Fuzziness > 2 {
+		return fmt.Errorf("fuzziness must be 1 or 2")
+	}
+	if q.TitleBoost < 0 || q.PhraseBoost < 0 {
+		return fmt.Errorf("title_boost and phrase_boost cannot be negative")
+	}
+	if q.CoverageExponent != nil && *q.CoverageExponent < 0 {
+		return fmt.Errorf("coverage_exponent cannot be negative")
+	}
+	if q.PhraseSlop != nil && (*q.PhraseSlop < 0 || *q.PhraseSlop > MaxPhraseSlop) {
+		return fmt.Errorf("phrase_slop must be between 0 and %d", MaxPhraseSlop)
+	}
+	if q.Chunks < 0 || q.Chunks > MaxResultChunks {
+		return fmt.Errorf("chunks must be between 0 and %d", MaxResultChunks)
+	}
+	q.Fusion = strings.ToLower(strings.TrimSpace(q.Fusion))
+	switch q.Fusion {
+	case "", FusionRRF, FusionWeighted, FusionMax:
+	default:
+		return fmt.Errorf("fusion must be rrf, weighted, or max")
+	}
+	if q.KeywordWeight < 0 || q.SemanticWeight < 0 {
+		return fmt.Errorf("keyword_weight and semantic_weight cannot be negative")
+	}
+	if q.DiversityLambda != nil && (*q.DiversityLambda < 0 || *q.DiversityLambda >= 1) {
+		return fmt.Errorf("diversity_lambda must be at least 0 and below 1")
+	}
+	q.SortBy = strings.ToLower(strings.TrimSpace(q.SortBy))
+	switch q.SortBy {
+	case "", SortByRelevance, SortByModifiedTime, SortByTitle, SortBySize:
+	default:
+		return fmt.Errorf("sort_by must be relevance, modified_time, title, or size")
+	}
+	q.SortOrder = strings.ToLower(strings.TrimSpace(q.SortOrder))
+	if q.SortOrder != "" && q.SortOrder != SortAsc && q.SortOrder != SortDesc {
+		return fmt.Errorf("sort_order must be asc or desc")
+	}
+	for i, f := range q.Fields {
+		q.Fields[i] = strings.ToLower(strings.TrimSpace(f))
+		if q.Fields[i] != SearchFieldTitle && q.Fields[i] != SearchFieldPath {
+			return fmt.Errorf("fields must be title or path, got %q", f)
+		}
+	}
+	if len(q.Fields) > 0 {
+		q.KeywordEnabled = true
+		q.SemanticEnabled = false
+	}
+	q.Mode = strings.ToLower(strings.TrimSpace(q.Mode))
+	switch q.Mode {
+	case "":
+	case QueryModeWildcard, QueryModeRegex, QueryModeLiteral:
+		if err := validatePattern(q.Mode, q.Query); err != nil {
+			return err
+		}
+		if q.Mode == QueryModeLiteral && slices.Contains(q.Fields, SearchFieldPath) {
+			return fmt.Errorf("literal mode searches title and content; fields can only be title")
+		}
+		q.KeywordEnabled = true
+		q.SemanticEnabled = false
+		q.FuzzyEnabled = false
+	default:
+		return fmt.Errorf("mode must be wildcard, regex, or literal")
+	}
+	if q.Snapshot != "" {
+		if err := ValidateSnapshotName(q.Snapshot); err != nil {
+			return err
+		}
+	}
+	if q.AsOf != nil {
+		switch {
+		case q.Snapshot != "":
+			return fmt.Errorf("as_of cannot be combined with snapshot")
+		case q.Mode != "":
+			return fmt.Errorf("as_of searches words; mode is not supported")
+		case q.SortsByField():
+			return fmt.Errorf("as_of results are ordered by relevance; sort_by is not supported")
+		}
+		q.KeywordEnabled = true
+		q.SemanticEnabled = false
+	}
 	if q.Limit <= 0 {
 		q.Limit = 10
 	}
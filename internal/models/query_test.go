@@ -1,10 +1,14 @@
 package models
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestSearchQuery_Validate(t *testing.T) {
+	earlier := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.AddDate(0, 1, 0)
 	tests := []struct {
 		name    string
 		query   *SearchQuery
@@ -15,6 +19,15 @@ func TestSearchQuery_Validate(t *testing.T) {
 		{"sets default limit", &SearchQuery{Query: "x", Limit: 0}, false},
 		{"caps limit at 100", &SearchQuery{Query: "x", Limit: 200}, false},
 		{"enables both when both false", &SearchQuery{Query: "x", KeywordEnabled: false, SemanticEnabled: false}, false},
+		{"negative size", &SearchQuery{Query: "x", MinSize: -1}, true},
+		{"min size above max size", &SearchQuery{Query: "x", MinSize: 10, MaxSize: 5}, true},
+		{"modified range reversed", &SearchQuery{Query: "x", ModifiedAfter: &later, ModifiedBefore: &earlier}, true},
+		{"modified range", &SearchQuery{Query: "x", ModifiedAfter: &earlier, ModifiedBefore: &later}, false},
+		{"created range reversed", &SearchQuery{Query: "x", CreatedAfter: &later, CreatedBefore: &earlier}, true},
+		{"created range", &SearchQuery{Query: "x", CreatedAfter: &earlier, CreatedBefore: &later}, false},
+		{"sort by modified time", &SearchQuery{Query: "x", SortBy: "Modified_Time", SortOrder: "ASC"}, false},
+		{"unknown sort field", &SearchQuery{Query: "x", SortBy: "author"}, true},
+		{"unknown sort order", &SearchQuery{Query: "x", SortBy: "size", SortOrder: "up"}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -71,3 +84,154 @@ func TestSearchQuery_FuzzyEnabled(t *testing.T) {
 		})
 	}
 }
+
+func TestSearchQuery_SortDescending(t *testing.T) {
+	tests := []struct {
+		sortBy, sortOrder string
+		want              bool
+	}{
+		{SortByModifiedTime, "", true},
+		{SortBySize, "", true},
+		{SortByTitle, "", false},
+		{SortByTitle, SortDesc, true},
+		{SortByModifiedTime, SortAsc, false},
+	}
+	for _, tt := range tests {
+		q := &SearchQuery{SortBy: tt.sortBy, SortOrder: tt.sortOrder}
+		if got := q.SortDescending(); got != tt.want {
+			t.Errorf("SortDescending(%q, %q) = %v, want %v", tt.sortBy, tt.sortOrder, got, tt.want)
+		}
+	}
+}
+
+func TestSearchQuery_Validate_keywordTuning(t *testing.T) {
+	neg, tooSloppy := -1.0, MaxPhraseSlop+1
+	for name, q := range map[string]SearchQuery{
+		"fuzziness":         {Query: "q", Fuzziness: 3},
+		"title boost":       {Query: "q", TitleBoost: -1},
+		"coverage exponent": {Query: "q", CoverageExponent: &neg},
+		"phrase slop":       {Query: "q", PhraseSlop: &tooSloppy},
+	} {
+		if err := q.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	zero, slop := 0.0, 2
+	q := SearchQuery{Query: "q", Fuzziness: 1, PhraseBoost: 2, CoverageExponent: &zero, PhraseSlop: &slop}
+	if err := q.Validate(); err != nil {
+		t.Errorf("valid overrides: %v", err)
+	}
+}
+
+func TestSearchQuery_Validate_chunks(t *testing.T) {
+	for _, n := range []int{-1, MaxResultChunks + 1} {
+		q := SearchQuery{Query: "q", Chunks: n}
+		if err := q.Validate(); err == nil {
+			t.Errorf("chunks %d: expected error", n)
+		}
+	}
+	q := SearchQuery{Query: "q", Chunks: 3}
+	if err := q.Validate(); err != nil {
+		t.Errorf("chunks 3: %v", err)
+	}
+}
+
+func TestSearchQuery_Validate_fields(t *testing.T) {
+	q := SearchQuery{Query: "budget", Fields: []string{" Title", "path"}, SemanticEnabled: true}
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if q.Fields[0] != SearchFieldTitle || !q.KeywordEnabled || q.SemanticEnabled {
+		t.Errorf("fields %v, keyword %v, semantic %v; want title, keyword only", q.Fields, q.KeywordEnabled, q.SemanticEnabled)
+	}
+	bad := SearchQuery{Query: "budget", Fields: []string{"content"}}
+	if err := bad.Validate(); err == nil {
+		t.Error("expected error for an unknown field")
+	}
+}
+
+func TestSearchQuery_Validate_mode(t *testing.T) {
+	q := SearchQuery{Query: "INV-2024-???", Mode: " Wildcard", SemanticEnabled: true, FuzzyEnabled: true}
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !q.IsPattern() || !q.KeywordEnabled || q.SemanticEnabled || q.FuzzyEnabled {
+		t.Errorf("mode %q, keyword %v, semantic %v, fuzzy %v; want wildcard, keyword only", q.Mode, q.KeywordEnabled, q.SemanticEnabled, q.FuzzyEnabled)
+	}
+	for _, bad := range []SearchQuery{
+		{Query: "budget", Mode: "glob"},
+		{Query: "*?*", Mode: QueryModeWildcard},
+		{Query: "a" + strings.Repeat("?", MaxPatternLength), Mode: QueryModeWildcard},
+		{Query: "inv[", Mode: QueryModeRegex},
+		{Query: "x*", Mode: QueryModeRegex},
+		{Query: "^inv", Mode: QueryModeRegex},
+		{Query: `inv\b`, Mode: QueryModeRegex},
+		{Query: "in+?v", Mode: QueryModeRegex},
+		{Query: "   ", Mode: QueryModeLiteral},
+		{Query: "SN-0042", Mode: QueryModeLiteral, Fields: []string{"path"}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("expected error for %s %q", bad.Mode, bad.Query)
+		}
+	}
+	for _, ok := range []SearchQuery{
+		{Query: `inv\d{3}`, Mode: QueryModeRegex},
+		{Query: "x := y[0]", Mode: QueryModeLiteral},
+	} {
+		if err := ok.Validate(); err != nil {
+			t.Errorf("%s %q: %v", ok.Mode, ok.Query, err)
+		}
+	}
+}
+
+func TestSearchQuery_Validate_asOf(t *testing.T) {
+	asOf := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	for name, q := range map[string]SearchQuery{
+		"snapshot": {Query: "q", AsOf: &asOf, Snapshot: "eval"},
+		"mode":     {Query: "q*", AsOf: &asOf, Mode: QueryModeWildcard},
+		"sort":     {Query: "q", AsOf: &asOf, SortBy: SortByTitle},
+	} {
+		if err := q.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	q := SearchQuery{Query: "q", AsOf: &asOf, SemanticEnabled: true}
+	if err := q.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if !q.KeywordEnabled || q.SemanticEnabled {
+		t.Errorf("keyword %v, semantic %v; want keyword only", q.KeywordEnabled, q.SemanticEnabled)
+	}
+}
+
+func TestSearchQuery_Validate_fusion(t *testing.T) {
+	for name, q := range map[string]SearchQuery{
+		"unknown fusion":  {Query: "q", Fusion: "sum"},
+		"negative weight": {Query: "q", Fusion: FusionWeighted, KeywordWeight: -0.5},
+	} {
+		if err := q.Validate(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	q := SearchQuery{Query: "q", Fusion: " RRF ", SemanticWeight: 0.7}
+	if err := q.Validate(); err != nil {
+		t.Fatalf("valid fusion: %v", err)
+	}
+	if q.Fusion != FusionRRF {
+		t.Errorf("Fusion = %q, want %q", q.Fusion, FusionRRF)
+	}
+}
+
+func TestSearchQuery_Validate_diversityLambda(t *testing.T) {
+	for _, lambda := range []float64{-0.1, 1} {
+		q := SearchQuery{Query: "q", DiversityLambda: &lambda}
+		if err := q.Validate(); err == nil {
+			t.Errorf("diversity_lambda %g: expected error", lambda)
+		}
+	}
+	lambda := 0.3
+	q := SearchQuery{Query: "q", DiversityLambda: &lambda}
+	if err := q.Validate(); err != nil {
+		t.Errorf("diversity_lambda 0.3: %v", err)
+	}
+}
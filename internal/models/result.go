@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 // SearchResult represents a single search hit with document and scores.
 type SearchResult struct {
 	Document      *Document         `json:"document"`
@@ -8,15 +10,56 @@ type SearchResult struct {
 	SemanticScore float64           `json:"semantic_score"`
 	Highlights    map[string]string `json:"highlights,omitempty"`
 	Rank          int               `json:"rank"`
+	Pinned        bool              `json:"pinned,omitempty"` // placed first by a pin
+	// AlsoFoundAt lists the source paths of near-identical documents collapsed into this one.
+	AlsoFoundAt []string `json:"also_found_at,omitempty"`
+	// KeywordChunk is the index of the chunk whose content matched the keywords best
+	// (DocumentChunk.ChunkIndex), with search.keyword_chunks; nil otherwise, or when only
+	// the title matched.
+	KeywordChunk *int `json:"keyword_chunk,omitempty"`
+	// Confidence estimates from 0 to 1 how likely the document is to answer the query,
+	// from its keyword and semantic scores (see Calibration). Unlike Score it is comparable
+	// across queries.
+	Confidence float64 `json:"confidence"`
+	// Chunks are the document's chunks that matched the query best, when the query asks
+	// for them (SearchQuery.Chunks).
+	Chunks []*ChunkMatch `json:"chunks,omitempty"`
+}
+
+// ChunkMatch is a chunk of a result document that matched the query, locating the
+// section of the document it came from.
+type ChunkMatch struct {
+	ChunkID    string `json:"chunk_id"`
+	DocumentID string `json:"document_id"`
+	ChunkIndex int    `json:"chunk_index"`
+	// Start and End are the character offsets of the chunk in the document's content,
+	// End exclusive; both are -1 when the chunk's text is no longer found in it.
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	Content string `json:"content"`
+	// Score is SemanticScore plus KeywordScore.
+	Score float64 `json:"score"`
+	// SemanticScore is the chunk's normalized similarity to the query, when the semantic
+	// search found it.
+	SemanticScore float64 `json:"semantic_score"`
+	// KeywordScore is the share of the query's words (of three or more letters or digits)
+	// the chunk contains.
+	KeywordScore float64 `json:"keyword_score"`
 }
 
 // SearchResponse is the response for a search request.
 // NonSemanticResults and SemanticResults are disjoint (no document appears in both).
+// With fusion, the totals count the keyword hits and semantic-only hits of Results.
 type SearchResponse struct {
 	// NonSemanticResults are keyword-only hits (not in semantic set).
 	NonSemanticResults []*SearchResult `json:"non_semantic_results"`
 	// SemanticResults are semantic-only hits (not in keyword set).
 	SemanticResults []*SearchResult `json:"semantic_results"`
+	// Results are the keyword and semantic hits in one list, ranked by the query's
+	// Fusion strategy, when it has one; the two lists above are then empty.
+	Results []*SearchResult `json:"results,omitempty"`
+	// Fusion is the query's fusion strategy, when it has one.
+	Fusion string `json:"fusion,omitempty"`
 	TotalNonSemantic int             `json:"total_non_semantic"`
 	TotalSemantic    int             `json:"total_semantic"`
 	QueryTime        int64           `json:"query_time_ms"`
@@ -24,8 +67,76 @@ type SearchResponse struct {
 	// Suggestions contains "Did you mean?" spelling suggestions when typos are detected.
 	// Only populated when FuzzyEnabled is true and misspelled terms are found.
 	Suggestions []string `json:"suggestions,omitempty"`
+	// CorrectedQuery is the query with each misspelled term replaced by its best
+	// correction, set along with Suggestions.
+	CorrectedQuery string `json:"corrected_query,omitempty"`
 	// AutoFuzzy indicates that fuzzy search was automatically enabled because the
 	// initial exact search returned no results. This helps the user understand
 	// why results may include fuzzy matches.
 	AutoFuzzy bool `json:"auto_fuzzy,omitempty"`
+	// Stemmed indicates that no keyword result matched the query as written, and the
+	// keyword results match stemmed forms of its words instead ("running" for "run").
+	// Only set with search.stemmed_fallback.
+	Stemmed bool `json:"stemmed,omitempty"`
+	// TimedOut lists the sources ("keyword", "semantic") left out because they exceeded
+	// their hedge deadline or the search budget. Results are partial when non-empty.
+	TimedOut []string `json:"timed_out,omitempty"`
+	// QueryID identifies the search in the analytics log, for POST /api/v1/feedback.
+	// Set only when analytics is enabled.
+	QueryID int64 `json:"query_id,omitempty"`
+	// Confidence is that of the most confident match, on any page; 0 when nothing matched.
+	// A low value means the corpus likely has no answer.
+	Confidence float64 `json:"confidence"`
+	// Calibration holds the parameters and corpus statistics the confidences came from.
+	Calibration *Calibration `json:"calibration,omitempty"`
+}
+
+// AllResults returns the results of r in order: the fused results, or the keyword results
+// then the semantic ones.
+func (r *SearchResponse) AllResults() []*SearchResult {
+	all := make([]*SearchResult, 0, len(r.Results)+len(r.NonSemanticResults)+len(r.SemanticResults))
+	all = append(all, r.Results...)
+	all = append(all, r.NonSemanticResults...)
+	return append(all, r.SemanticResults...)
+}
+
+// Calibration describes how result confidences were computed. Keyword evidence is
+// score / (score + KeywordMidpoint), weighted by (1 + TermSpecificity) / 2; semantic
+// evidence is a logistic curve of the similarity, 0.5 at SemanticMidpoint. A result's
+// confidence is 1 - (1 - keyword)(1 - semantic).
+type Calibration struct {
+	KeywordMidpoint   float64 `json:"keyword_midpoint"`
+	SemanticMidpoint  float64 `json:"semantic_midpoint"`
+	SemanticSteepness float64 `json:"semantic_steepness"`
+	// TermSpecificity is the mean inverse document frequency of the query terms found in
+	// the corpus, from 0 (every document has them) to 1 (one document does).
+	TermSpecificity float64 `json:"term_specificity"`
+	// QueryTerms and KnownTerms count the query's terms and those found in the corpus.
+	QueryTerms      int `json:"query_terms"`
+	KnownTerms      int `json:"known_terms"`
+	CorpusDocuments int `json:"corpus_documents"`
+}
+
+// CountResponse is the number of documents matching a query by keyword.
+type CountResponse struct {
+	Query string `json:"query"`
+	Count int    `json:"count"`
+}
+
+// FileStatus reports whether a file is indexed. Current is true when the indexed copy
+// has the file's current modification time and size.
+type FileStatus struct {
+	Path       string     `json:"path"`
+	DocumentID string     `json:"document_id"`
+	Indexed    bool       `json:"indexed"`
+	Current    bool       `json:"current"`
+	IndexedAt  *time.Time `json:"indexed_at,omitempty"`
+}
+
+// RecentResponse lists documents modified within the last Days days, newest first.
+type RecentResponse struct {
+	Documents  []*RecentDocument `json:"documents"`
+	Total      int               `json:"total"`
+	Days       int               `json:"days"`
+	PathPrefix string            `json:"path_prefix,omitempty"`
 }
@@ -0,0 +1,35 @@
+package models
+
+// ExtensionStats counts the files of one extension found by a directory scan.
+type ExtensionStats struct {
+	// Extension is lower case with the leading dot; "" for files without one.
+	Extension string `json:"extension"`
+	Files     int    `json:"files"`
+	Bytes     int64  `json:"bytes"`
+	// Included reports that the configured extensions let these files be indexed.
+	Included bool `json:"included"`
+	// KnownFormat reports that the extension has an extractor of its own; other files
+	// are read as plain text.
+	KnownFormat bool `json:"known_format"`
+}
+
+// ScanReport describes the files under a directory and which of them indexing with the
+// configured extensions would take or skip.
+type ScanReport struct {
+	Root string `json:"root"`
+	// Extensions are the allowed extensions the scan was checked against; empty allows all.
+	Extensions    []string `json:"extensions"`
+	Files         int      `json:"files"`
+	Bytes         int64    `json:"bytes"`
+	IncludedFiles int      `json:"included_files"`
+	IncludedBytes int64    `json:"included_bytes"`
+	SkippedFiles  int      `json:"skipped_files"`
+	SkippedBytes  int64    `json:"skipped_bytes"`
+	// ByExtension has one entry per extension found, largest total size first.
+	ByExtension []*ExtensionStats `json:"by_extension"`
+	// OptedOut are the directories skipped for holding the no-index marker; their files
+	// are not counted.
+	OptedOut []string `json:"opted_out,omitempty"`
+	// Skipped lists the paths of the skipped files, when asked for.
+	Skipped []string `json:"skipped,omitempty"`
+}
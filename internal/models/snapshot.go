@@ -0,0 +1,35 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// maxSnapshotName is the longest snapshot name accepted.
+const maxSnapshotName = 64
+
+// Snapshot is a named, read-only record of the documents indexed when it was taken and
+// their content. Searches naming it (SearchQuery.Snapshot) only return those documents,
+// and only while their content is unchanged, so that evaluation runs and A/B comparisons
+// see the same corpus however indexing goes on.
+type Snapshot struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	Documents int       `json:"documents"`
+}
+
+// ValidateSnapshotName checks that name is 1-64 letters, digits, '.', '_' or '-'.
+func ValidateSnapshotName(name string) error {
+	if name == "" {
+		return errors.New("snapshot name is required")
+	}
+	if len(name) > maxSnapshotName {
+		return errors.New("snapshot name is longer than 64 characters")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return errors.New("snapshot name may only contain letters, digits, '.', '_' and '-'")
+		}
+	}
+	return nil
+}
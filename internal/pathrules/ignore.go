@@ -0,0 +1,208 @@
+package pathrules
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Ignore skips the files and directories listed in .gitignore-style files: those named
+// one of its names in a walked root and the directories beneath it, plus a global file
+// whose patterns apply in every root. Patterns follow gitignore: "#" comments, "!" to
+// re-include, a trailing "/" for directories only, a leading or inner "/" to anchor the
+// pattern to the ignore file's directory, and "**" for any number of directories. The
+// patterns of deeper files come after those above them, and the last matching pattern
+// wins. Ignore files are read again when they change. A nil *Ignore ignores nothing.
+type Ignore struct {
+	names  []string
+	global []ignorePattern
+
+	mu    sync.Mutex
+	files map[string]*ignoreFile // ignore file path -> its patterns when last read
+}
+
+// ignoreFile holds the patterns of an ignore file, and when it was read.
+type ignoreFile struct {
+	modTime  time.Time
+	size     int64
+	patterns []ignorePattern
+}
+
+// ignorePattern is one line of an ignore file.
+type ignorePattern struct {
+	pattern  string
+	negate   bool // "!pattern" re-includes what an earlier pattern ignored
+	dirOnly  bool // "pattern/" matches directories only
+	anchored bool // matched against the path relative to the ignore file's directory
+}
+
+// NewIgnore returns an Ignore reading the files named names (e.g. ".gitignore") in the
+// walked directories, and the patterns of the file at global unless it is empty. A
+// missing global file ignores nothing.
+func NewIgnore(names []string, global string) (*Ignore, error) {
+	ig := &Ignore{names: names, files: make(map[string]*ignoreFile)}
+	if global != "" {
+		f, err := os.Open(global)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err == nil {
+			ig.global = parseIgnore(f)
+			_ = f.Close()
+		}
+	}
+	return ig, nil
+}
+
+// IsIgnoreFile reports whether name is the name of an ignore file.
+func (ig *Ignore) IsIgnoreFile(name string) bool {
+	if ig == nil {
+		return false
+	}
+	for _, n := range ig.names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether the ignore files of root and of the directories between root and
+// p ignore p itself; its parent directories are not checked. Use it while walking root
+// and skipping the directories it ignores.
+func (ig *Ignore) Match(root, p string, isDir bool) bool {
+	if ig == nil {
+		return false
+	}
+	root, p = filepath.Clean(root), filepath.Clean(p)
+	if p == root || !under(p, root) {
+		return false
+	}
+	ignored := false
+	rel := filepath.ToSlash(mustRel(root, p))
+	for _, pat := range ig.global {
+		if pat.matches(rel, isDir) {
+			ignored = !pat.negate
+		}
+	}
+	for dir := root; ; {
+		dirRel := filepath.ToSlash(mustRel(dir, p))
+		for _, pat := range ig.patterns(dir) {
+			if pat.matches(dirRel, isDir) {
+				ignored = !pat.negate
+			}
+		}
+		next, _, _ := strings.Cut(dirRel, "/")
+		if next == dirRel {
+			return ignored
+		}
+		dir = filepath.Join(dir, next)
+	}
+}
+
+// Ignored reports whether p, or a directory between root and p, is ignored.
+func (ig *Ignore) Ignored(root, p string, isDir bool) bool {
+	if ig == nil {
+		return false
+	}
+	root, p = filepath.Clean(root), filepath.Clean(p)
+	if p == root || !under(p, root) {
+		return false
+	}
+	for dir := filepath.Dir(p); dir != root && under(dir, root); dir = filepath.Dir(dir) {
+		if ig.Match(root, dir, true) {
+			return true
+		}
+	}
+	return ig.Match(root, p, isDir)
+}
+
+// patterns returns the patterns of the ignore files in dir, in the order of ig.names.
+func (ig *Ignore) patterns(dir string) []ignorePattern {
+	var out []ignorePattern
+	for _, name := range ig.names {
+		out = append(out, ig.read(filepath.Join(dir, name))...)
+	}
+	return out
+}
+
+// read returns the patterns of the ignore file at p, reading it again when it changed.
+func (ig *Ignore) read(p string) []ignorePattern {
+	info, err := os.Stat(p)
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	if err != nil || !info.Mode().IsRegular() {
+		delete(ig.files, p)
+		return nil
+	}
+	if f := ig.files[p]; f != nil && f.modTime.Equal(info.ModTime()) && f.size == info.Size() {
+		return f.patterns
+	}
+	file, err := os.Open(p)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	f := &ignoreFile{modTime: info.ModTime(), size: info.Size(), patterns: parseIgnore(file)}
+	ig.files[p] = f
+	return f.patterns
+}
+
+// parseIgnore reads the patterns of an ignore file.
+func parseIgnore(f *os.File) []ignorePattern {
+	var out []ignorePattern
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var pat ignorePattern
+		if strings.HasPrefix(line, "!") {
+			pat.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			pat.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			pat.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" || ValidatePattern(line) != nil {
+			continue
+		}
+		pat.pattern = line
+		out = append(out, pat)
+	}
+	return out
+}
+
+// matches reports whether the pattern matches rel, a "/"-separated path relative to the
+// ignore file's directory.
+func (p ignorePattern) matches(rel string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	if !p.anchored {
+		ok, _ := path.Match(p.pattern, path.Base(rel))
+		return ok
+	}
+	return Match(p.pattern, rel)
+}
+
+// mustRel returns p relative to base, which contains it.
+func mustRel(base, p string) string {
+	rel, err := filepath.Rel(base, p)
+	if err != nil {
+		return p
+	}
+	return rel
+}
@@ -0,0 +1,78 @@
+package pathrules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIgnore(t *testing.T) {
+	root := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+		p := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	global := filepath.Join(t.TempDir(), ".sagasuignore")
+	if err := os.WriteFile(global, []byte("*.log\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	write(".gitignore", "# build output\nbuild/\n/dist\n*.o\n!keep.o\ndocs/**/draft-*\n")
+	write("web/.gitignore", "node_modules\n")
+	write("web/.sagasuignore", "!build/\n")
+	ig, err := NewIgnore([]string{".gitignore", ".sagasuignore"}, global)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		rel   string
+		isDir bool
+		want  bool
+	}{
+		{"main.go", false, false},
+		{"build", true, true},
+		{"build", false, false}, // "build/" matches directories only
+		{"build/out.txt", false, true},
+		{"sub/build/out.txt", false, true},
+		{"dist", true, true},
+		{"sub/dist", true, false}, // "/dist" is anchored to the root
+		{"a.o", false, true},
+		{"keep.o", false, false},
+		{"docs/2024/draft-1.md", false, true},
+		{"docs/final.md", false, false},
+		{"web/node_modules/x.js", false, true},
+		{"node_modules/x.js", false, false}, // web/.gitignore applies under web only
+		{"web/build/app.js", false, false},  // re-included by a deeper file
+		{"server.log", false, true},         // the global file
+	} {
+		p := filepath.Join(root, filepath.FromSlash(tc.rel))
+		if got := ig.Ignored(root, p, tc.isDir); got != tc.want {
+			t.Errorf("Ignored(%s) = %v, want %v", tc.rel, got, tc.want)
+		}
+	}
+	if ig.Match(root, filepath.Join(root, "build", "out.txt"), false) {
+		t.Error("Match should not check parent directories")
+	}
+
+	// Changed ignore files are read again.
+	time.Sleep(10 * time.Millisecond)
+	write("web/.gitignore", "*.js\n")
+	if !ig.Ignored(root, filepath.Join(root, "web", "app.js"), false) {
+		t.Error("a changed ignore file should be read again")
+	}
+
+	var none *Ignore
+	if none.Ignored(root, filepath.Join(root, "a.o"), false) || none.IsIgnoreFile(".gitignore") {
+		t.Error("a nil Ignore should ignore nothing")
+	}
+	if !ig.IsIgnoreFile(".sagasuignore") || ig.IsIgnoreFile("notes.txt") {
+		t.Error("IsIgnoreFile should match the ignore file names")
+	}
+}
@@ -0,0 +1,229 @@
+// Package pathrules describes which files under a watched directory are indexed: by
+// extension, include and exclude globs, file size, whether subdirectories count, and
+// .gitignore-style ignore files.
+package pathrules
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Rule holds the indexing rules for the files under Root. Zero values keep the defaults.
+//
+// Glob patterns use "/" as separator and "**" for any number of directories. A pattern
+// without a "/" matches a name: the file's or, for Exclude, that of any directory between
+// Root and the file (e.g. "node_modules" or "*.tmp"). Otherwise it matches the path
+// relative to Root (e.g. "**/node_modules/**" or "drafts/*.md").
+type Rule struct {
+	Root string
+	// Recursive set to false indexes only the files directly in Root.
+	Recursive *bool
+	// Extensions replace the default extensions under Root; empty keeps them.
+	Extensions []string
+	// Include, when set, indexes only the files matching one of its patterns.
+	Include []string
+	// Exclude skips the files and directories matching one of its patterns.
+	Exclude []string
+	// MaxFileSize skips files larger than this many bytes; 0 means no limit.
+	MaxFileSize int64
+}
+
+// Rules is a set of rules, deepest root first. The rule of a path is the deepest one
+// whose root contains it; a nil or empty set has no rules.
+type Rules []Rule
+
+// New checks the patterns of rules and returns them deepest root first. Roots are
+// cleaned but should already be absolute.
+func New(rules []Rule) (Rules, error) {
+	rs := make(Rules, len(rules))
+	for i, r := range rules {
+		if r.Root == "" {
+			return nil, fmt.Errorf("rule %d: root is required", i)
+		}
+		if r.MaxFileSize < 0 {
+			return nil, fmt.Errorf("rule %q: max file size cannot be negative", r.Root)
+		}
+		for _, p := range append(append([]string(nil), r.Include...), r.Exclude...) {
+			if err := ValidatePattern(p); err != nil {
+				return nil, fmt.Errorf("rule %q: %w", r.Root, err)
+			}
+		}
+		r.Root = filepath.Clean(r.Root)
+		rs[i] = r
+	}
+	sort.SliceStable(rs, func(i, j int) bool { return len(rs[i].Root) > len(rs[j].Root) })
+	return rs, nil
+}
+
+// ValidatePattern checks that pattern is a valid glob.
+func ValidatePattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("empty pattern")
+	}
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// For returns the rule of path, nil when no rule's root contains it.
+func (rs Rules) For(p string) *Rule {
+	p = filepath.Clean(p)
+	for i := range rs {
+		if under(p, rs[i].Root) {
+			return &rs[i]
+		}
+	}
+	return nil
+}
+
+// Extensions returns the extensions allowed for the file at p: those of its rule, or
+// exts when the rule has none.
+func (rs Rules) Extensions(p string, exts []string) []string {
+	if r := rs.For(p); r != nil && len(r.Extensions) > 0 {
+		return r.Extensions
+	}
+	return exts
+}
+
+// AllowFile reports whether the file at p, of size bytes, is indexed by its rule. Its
+// extension is checked separately (see Extensions). A negative size is not checked.
+func (rs Rules) AllowFile(p string, size int64) bool {
+	r := rs.For(p)
+	if r == nil {
+		return true
+	}
+	p = filepath.Clean(p)
+	if !r.recursive() && filepath.Dir(p) != r.Root {
+		return false
+	}
+	rel := r.rel(p)
+	if r.excludes(rel, false) {
+		return false
+	}
+	if len(r.Include) > 0 && !matchAny(r.Include, rel) {
+		return false
+	}
+	return r.MaxFileSize == 0 || size < 0 || size <= r.MaxFileSize
+}
+
+// SkipDir reports whether walking should not descend into the directory at p: it is
+// excluded by its rule, or below the root of a rule that is not recursive. Directories
+// on the way to a deeper rule's root are never skipped.
+func (rs Rules) SkipDir(p string) bool {
+	r := rs.For(p)
+	if r == nil {
+		return false
+	}
+	p = filepath.Clean(p)
+	if p == r.Root {
+		return false
+	}
+	for i := range rs {
+		if under(rs[i].Root, p) && rs[i].Root != p {
+			return false
+		}
+	}
+	return !r.recursive() || r.excludes(r.rel(p), true)
+}
+
+// Recursive reports whether subdirectories of root are indexed: the Recursive setting
+// of root's rule, or def without one.
+func (rs Rules) Recursive(root string, def bool) bool {
+	if r := rs.For(root); r != nil && r.Recursive != nil {
+		return *r.Recursive
+	}
+	return def
+}
+
+func (r *Rule) recursive() bool {
+	return r.Recursive == nil || *r.Recursive
+}
+
+// rel returns p relative to the rule's root, with "/" separators.
+func (r *Rule) rel(p string) string {
+	rel, err := filepath.Rel(r.Root, p)
+	if err != nil {
+		return filepath.ToSlash(p)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// excludes reports whether rel, or a directory on its way, matches an Exclude pattern.
+// A directory also matches a pattern ending in "/**" without that ending.
+func (r *Rule) excludes(rel string, dir bool) bool {
+	if rel == "." {
+		return false
+	}
+	for _, pattern := range r.Exclude {
+		if dir {
+			if prefix, ok := strings.CutSuffix(pattern, "/**"); ok && match(prefix, rel) {
+				return true
+			}
+		}
+		for p := rel; p != "."; p = path.Dir(p) {
+			if match(pattern, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchAny reports whether one of patterns matches rel.
+func matchAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if match(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether pattern matches rel, a "/"-separated relative path. A pattern
+// without "/" matches the last element of rel.
+func match(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return Match(pattern, rel)
+}
+
+// Match reports whether name matches pattern, element by element, where a "**" element
+// matches any number of elements, none included.
+func Match(pattern, name string) bool {
+	return matchElems(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchElems(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchElems(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], name[0]); !ok {
+		return false
+	}
+	return matchElems(pattern[1:], name[1:])
+}
+
+// under reports whether p is root or inside it.
+func under(p, root string) bool {
+	rel, err := filepath.Rel(root, p)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
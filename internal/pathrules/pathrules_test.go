@@ -0,0 +1,94 @@
+package pathrules
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/node_modules/**", "node_modules/a.js", true},
+		{"**/node_modules/**", "web/node_modules/lib/a.js", true},
+		{"**/node_modules/**", "web/src/a.js", false},
+		{"drafts/*.md", "drafts/a.md", true},
+		{"drafts/*.md", "drafts/old/a.md", false},
+		{"drafts/**/*.md", "drafts/old/a.md", true},
+		{"**", "a/b/c", true},
+	} {
+		if got := Match(tc.pattern, tc.name); got != tc.want {
+			t.Errorf("Match(%q, %q) = %v, want %v", tc.pattern, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRules(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	flat := filepath.Join(root, "inbox")
+	no := false
+	rs, err := New([]Rule{
+		{Root: root, Extensions: []string{".md"}, Exclude: []string{"**/node_modules/**", "*.tmp"}, MaxFileSize: 100},
+		{Root: flat, Recursive: &no, Include: []string{"report-*"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := rs.For(filepath.Join(flat, "a.txt")); r == nil || r.Root != flat {
+		t.Errorf("For: want the deeper inbox rule, got %+v", r)
+	}
+	if rs.For(filepath.Join(t.TempDir(), "a.txt")) != nil {
+		t.Error("For: a path outside every root should have no rule")
+	}
+	if got := rs.Extensions(filepath.Join(root, "a.txt"), []string{".txt"}); len(got) != 1 || got[0] != ".md" {
+		t.Errorf("Extensions = %v, want the rule's", got)
+	}
+	if got := rs.Extensions(filepath.Join(flat, "a.txt"), []string{".txt"}); len(got) != 1 || got[0] != ".txt" {
+		t.Errorf("Extensions = %v, want the defaults", got)
+	}
+
+	for _, tc := range []struct {
+		path string
+		size int64
+		want bool
+	}{
+		{filepath.Join(root, "a.md"), 10, true},
+		{filepath.Join(root, "a.md"), 101, false},
+		{filepath.Join(root, "a.md"), -1, true},
+		{filepath.Join(root, "web", "node_modules", "x", "a.md"), 10, false},
+		{filepath.Join(root, "notes.tmp"), 10, false},
+		{filepath.Join(root, "cache.tmp", "a.md"), 10, false},
+		{filepath.Join(flat, "report-1.md"), 1000, true},
+		{filepath.Join(flat, "notes.md"), 10, false},
+		{filepath.Join(flat, "sub", "report-1.md"), 10, false},
+	} {
+		if got := rs.AllowFile(tc.path, tc.size); got != tc.want {
+			t.Errorf("AllowFile(%s, %d) = %v, want %v", tc.path, tc.size, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct {
+		path string
+		want bool
+	}{
+		{root, false},
+		{filepath.Join(root, "node_modules"), true},
+		{filepath.Join(root, "web", "node_modules"), true},
+		{filepath.Join(root, "web"), false},
+		{flat, false},
+		{filepath.Join(flat, "sub"), true},
+	} {
+		if got := rs.SkipDir(tc.path); got != tc.want {
+			t.Errorf("SkipDir(%s) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+
+	if rs.Recursive(flat, true) || rs.Recursive(root, false) || !rs.Recursive(root, true) {
+		t.Error("Recursive should follow the rule, or the default without a setting")
+	}
+
+	if _, err := New([]Rule{{Root: root, Exclude: []string{"[a"}}}); err == nil {
+		t.Error("New should reject an invalid pattern")
+	}
+}
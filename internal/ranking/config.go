@@ -23,6 +23,7 @@ type RankingConfig struct {
 	AllWordsContentScore     float64 `yaml:"all_words_content_score"`     // default: 90
 	ScatteredWordsScore      float64 `yaml:"scattered_words_score"`       // default: 70
 	StemmingMatchScore       float64 `yaml:"stemming_match_score"`        // default: 55
+	ProximityMatchScore      float64 `yaml:"proximity_match_score"`       // default: 100
 
 	// Path scoring values
 	PathExactMatchScore      float64 `yaml:"path_exact_match_score"`      // default: 40
@@ -83,6 +84,7 @@ func DefaultRankingConfig() *RankingConfig {
 		AllWordsContentScore: 90,
 		ScatteredWordsScore:  70,
 		StemmingMatchScore:   55,
+		ProximityMatchScore:  100,
 
 		// Path scoring
 		PathExactMatchScore:   40,
@@ -176,6 +178,9 @@ func (c *RankingConfig) ApplyDefaults() {
 	if c.StemmingMatchScore == 0 {
 		c.StemmingMatchScore = defaults.StemmingMatchScore
 	}
+	if c.ProximityMatchScore == 0 {
+		c.ProximityMatchScore = defaults.ProximityMatchScore
+	}
 
 	// Path scoring
 	if c.PathExactMatchScore == 0 {
@@ -46,6 +46,13 @@ func (s *ContentScorer) Score(ctx *ScoringContext) float64 {
 		score = max(score, phraseScore)
 	}
 
+	// Score NEAR groups whose sides are close enough
+	for _, g := range ctx.Query.Near {
+		if WithinDistance(g.Left, g.Right, g.Distance, content) {
+			score = max(score, s.config.ProximityMatchScore)
+		}
+	}
+
 	// Score header matches
 	headerScore := s.scoreHeaderMatch(tokens, content)
 	score = max(score, headerScore)
@@ -103,6 +103,19 @@ func TestContentScorer_Score(t *testing.T) {
 	}
 }
 
+func TestContentScorer_Proximity(t *testing.T) {
+	scorer := NewContentScorer(DefaultRankingConfig())
+	query := NewQueryAnalyzer().Analyze("deep NEAR/2 learning")
+	score := func(content string) float64 {
+		return scorer.Score(&ScoringContext{Query: query, Document: &models.Document{Content: content}, Content: content})
+	}
+	near := score("deep reinforcement learning for robots")
+	far := score("deep sea exploration needs much more machine learning")
+	if near <= far {
+		t.Errorf("Score within distance = %v, want above %v", near, far)
+	}
+}
+
 func TestContentScorer_NilInputs(t *testing.T) {
 	config := DefaultRankingConfig()
 	scorer := NewContentScorer(config)
@@ -86,8 +86,8 @@ func (s *MetadataScorer) scoreMetadataField(key, value string, terms []string) f
 func (s *MetadataScorer) getFieldBaseScore(key string) float64 {
 	keyLower := strings.ToLower(key)
 
-	// Author-related fields
-	if keyLower == "author" || keyLower == "creator" || keyLower == "by" || keyLower == "created_by" {
+	// Author-related fields, including the file owner recorded when indexing
+	if keyLower == "author" || keyLower == "creator" || keyLower == "by" || keyLower == "created_by" || keyLower == "owner" {
 		return s.config.AuthorMatchScore
 	}
 
@@ -103,9 +103,22 @@ func (s *MetadataScorer) getFieldBaseScore(key string) float64 {
 // isInternalMetadataKey checks if a metadata key is internal (not for user matching).
 func isInternalMetadataKey(key string) bool {
 	internalKeys := map[string]bool{
-		"source_path":  true,
-		"source_mtime": true,
-		"source_size":  true,
+		"source_path":     true,
+		"source_mtime":    true,
+		"source_size":     true,
+		"source_created":  true,
+		"content_simhash": true,
+		"language":        true,
+		// Indexed mail, web pages, and data files: document IDs, dates, link lists, and counts
+		"children":       true,
+		"parent_id":      true,
+		"mail_date":      true,
+		"page_links":     true,
+		"data_rows":      true,
+		"data_truncated": true,
+		// Transcribed audio and video: times
+		"transcript_timestamps": true,
+		"media_duration":        true,
 	}
 	return internalKeys[key]
 }
@@ -27,6 +27,16 @@ func TestMetadataScorer_Score(t *testing.T) {
 			wantMin: config.AuthorMatchScore * 0.9,
 			wantMax: config.AuthorMatchScore * 1.1,
 		},
+		{
+			name:  "owner match",
+			query: "kim",
+			metadata: map[string]interface{}{
+				"owner":          "kim",
+				"source_created": "1700000000000000000",
+			},
+			wantMin: config.AuthorMatchScore * 0.9,
+			wantMax: config.AuthorMatchScore * 1.1,
+		},
 		{
 			name:  "tag match",
 			query: "finance",
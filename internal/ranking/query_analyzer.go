@@ -4,16 +4,33 @@ import (
 	"regexp"
 	"strings"
 	"unicode"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
 )
 
+// nearRegex matches a NEAR group whose sides are single words or quoted phrases.
+var nearRegex = regexp.MustCompile(`("[^"]+"|[^\s"()]+)\s+(NEAR(?:/\d+)?)\s+("[^"]+"|[^\s"()]+)`)
+
 // QueryAnalyzer analyzes search queries to extract terms, phrases, and metadata.
-type QueryAnalyzer struct{}
+type QueryAnalyzer struct {
+	stopwords map[string]bool
+}
 
 // NewQueryAnalyzer creates a new QueryAnalyzer.
 func NewQueryAnalyzer() *QueryAnalyzer {
 	return &QueryAnalyzer{}
 }
 
+// WithStopwords leaves words (case-insensitive) out of the terms of analyzed queries,
+// unless the query has no other terms.
+func (qa *QueryAnalyzer) WithStopwords(words []string) *QueryAnalyzer {
+	qa.stopwords = make(map[string]bool, len(words))
+	for _, w := range words {
+		qa.stopwords[strings.ToLower(strings.TrimSpace(w))] = true
+	}
+	return qa
+}
+
 // Analyze parses a query string and returns an AnalyzedQuery.
 func (qa *QueryAnalyzer) Analyze(query string) *AnalyzedQuery {
 	result := &AnalyzedQuery{
@@ -26,11 +43,15 @@ func (qa *QueryAnalyzer) Analyze(query string) *AnalyzedQuery {
 	// Check for wildcards
 	result.HasWildcard = strings.ContainsAny(query, "*?")
 
+	// Extract NEAR groups; their sides stay in the query as terms and phrases
+	remaining := qa.extractNear(query, result)
+
 	// Extract quoted phrases
-	remaining := qa.extractPhrases(query, result)
+	remaining = qa.extractPhrases(remaining, result)
 
 	// Extract negated terms and regular terms
 	qa.extractTerms(remaining, result)
+	qa.dropStopwords(result)
 
 	// Classify query type
 	result.QueryType = qa.classifyQuery(result)
@@ -58,6 +79,21 @@ func (qa *QueryAnalyzer) extractPhrases(query string, result *AnalyzedQuery) str
 	return phraseRegex.ReplaceAllString(query, " ")
 }
 
+// extractNear extracts NEAR groups from the query.
+// Returns the query with the NEAR operators removed.
+func (qa *QueryAnalyzer) extractNear(query string, result *AnalyzedQuery) string {
+	return nearRegex.ReplaceAllStringFunc(query, func(m string) string {
+		match := nearRegex.FindStringSubmatch(m)
+		distance, _ := keyword.NearDistance(match[2])
+		left := strings.ToLower(strings.Trim(match[1], `"`))
+		right := strings.ToLower(strings.Trim(match[3], `"`))
+		if !strings.HasPrefix(match[1], "-") && !strings.HasPrefix(match[3], "-") {
+			result.Near = append(result.Near, NearGroup{Left: left, Right: right, Distance: distance})
+		}
+		return match[1] + " " + match[3]
+	})
+}
+
 // extractTerms extracts individual terms from the remaining query.
 func (qa *QueryAnalyzer) extractTerms(query string, result *AnalyzedQuery) {
 	// Split by whitespace and filter
@@ -94,6 +130,22 @@ func (qa *QueryAnalyzer) extractTerms(query string, result *AnalyzedQuery) {
 	}
 }
 
+// dropStopwords removes stopwords from the terms, keeping them when nothing else is left.
+func (qa *QueryAnalyzer) dropStopwords(result *AnalyzedQuery) {
+	if len(qa.stopwords) == 0 {
+		return
+	}
+	terms := make([]string, 0, len(result.Terms))
+	for _, t := range result.Terms {
+		if !qa.stopwords[t] {
+			terms = append(terms, t)
+		}
+	}
+	if len(terms) > 0 {
+		result.Terms = terms
+	}
+}
+
 // normalizeToken normalizes a single token (lowercase, remove punctuation from edges).
 func (qa *QueryAnalyzer) normalizeToken(token string) string {
 	// Convert to lowercase
@@ -221,6 +273,53 @@ func TermsInOrder(terms []string, text string) bool {
 	return true
 }
 
+// WithinDistance checks if left and right (words or phrases) appear in text, in either
+// order, with at most distance words between them.
+func WithinDistance(left, right string, distance int, text string) bool {
+	words := matchWords(text)
+	l, r := matchWords(left), matchWords(right)
+	if len(l) == 0 || len(r) == 0 {
+		return false
+	}
+	lPos, rPos := wordPositions(l, words), wordPositions(r, words)
+	for _, i := range lPos {
+		for _, j := range rPos {
+			if j >= i+len(l) && j-(i+len(l)) <= distance {
+				return true
+			}
+			if i >= j+len(r) && i-(j+len(r)) <= distance {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchWords splits text into lowercase words, ignoring punctuation.
+func matchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// wordPositions returns the word offsets at which seq occurs in words.
+func wordPositions(seq, words []string) []int {
+	var positions []int
+	for i := 0; i+len(seq) <= len(words); i++ {
+		match := true
+		for k, w := range seq {
+			if words[i+k] != w {
+				match = false
+				break
+			}
+		}
+		if match {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
 // FindPhrasePosition finds the position of a phrase in text.
 // Returns -1 if not found.
 func FindPhrasePosition(phrase, text string) int {
@@ -1,6 +1,7 @@
 package ranking
 
 import (
+	"slices"
 	"testing"
 )
 
@@ -179,6 +180,16 @@ func TestQueryAnalyzer_Analyze(t *testing.T) {
 	}
 }
 
+func TestQueryAnalyzer_WithStopwords(t *testing.T) {
+	qa := NewQueryAnalyzer().WithStopwords([]string{"Report", "the"})
+	if got := qa.Analyze("the quarterly report").Terms; !slices.Equal(got, []string{"quarterly"}) {
+		t.Errorf("Terms = %v, want [quarterly]", got)
+	}
+	if got := qa.Analyze("the report").Terms; !slices.Equal(got, []string{"the", "report"}) {
+		t.Errorf("Terms of a stopword-only query = %v, want them kept", got)
+	}
+}
+
 func TestAllTermsMatch(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -462,6 +473,46 @@ func TestCountOccurrences(t *testing.T) {
 	}
 }
 
+func TestQueryAnalyzer_Near(t *testing.T) {
+	qa := NewQueryAnalyzer()
+	got := qa.Analyze(`deep NEAR/3 "Neural Nets" java NEAR/99 go -a NEAR b`)
+	want := []NearGroup{
+		{Left: "deep", Right: "neural nets", Distance: 3},
+		{Left: "java", Right: "go", Distance: 20},
+	}
+	if !slices.Equal(got.Near, want) {
+		t.Errorf("Near = %v, want %v", got.Near, want)
+	}
+	if !slices.Equal(got.Terms, []string{"deep", "java", "go", "b"}) {
+		t.Errorf("Terms = %v, want NEAR operators dropped", got.Terms)
+	}
+	if !slices.Equal(got.Phrases, []string{"neural nets"}) {
+		t.Errorf("Phrases = %v, want [neural nets]", got.Phrases)
+	}
+}
+
+func TestWithinDistance(t *testing.T) {
+	text := "Deep, convolutional neural nets learn features."
+	tests := []struct {
+		left, right string
+		distance    int
+		want        bool
+	}{
+		{"deep", "neural nets", 1, true},
+		{"deep", "neural nets", 0, false},
+		{"learn", "deep", 3, true},
+		{"learn", "deep", 2, false},
+		{"neural", "nets", 0, true},
+		{"deep", "missing", 20, false},
+		{"", "deep", 5, false},
+	}
+	for _, tt := range tests {
+		if got := WithinDistance(tt.left, tt.right, tt.distance, text); got != tt.want {
+			t.Errorf("WithinDistance(%q, %q, %d) = %v, want %v", tt.left, tt.right, tt.distance, got, tt.want)
+		}
+	}
+}
+
 func TestFindPhrasePosition(t *testing.T) {
 	tests := []struct {
 		name    string
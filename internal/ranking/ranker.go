@@ -43,6 +43,12 @@ func (r *Ranker) WithCorpusStats(stats *CorpusStats) *Ranker {
 	return r
 }
 
+// WithStopwords leaves words out of the query terms that documents are scored on.
+func (r *Ranker) WithStopwords(words []string) *Ranker {
+	r.analyzer = NewQueryAnalyzer().WithStopwords(words)
+	return r
+}
+
 // WithMultipliers sets custom multipliers.
 func (r *Ranker) WithMultipliers(multipliers []Multiplier) *Ranker {
 	r.multipliers = multipliers
@@ -89,6 +89,15 @@ type AnalyzedQuery struct {
 	HasWildcard bool
 	// NegatedTerms are terms that should be excluded (NOT operator).
 	NegatedTerms []string
+	// Near are the proximity groups of the query (deep NEAR/3 learning).
+	Near []NearGroup
+}
+
+// NearGroup is a NEAR operator: Left and Right within Distance words of each other.
+type NearGroup struct {
+	Left     string
+	Right    string
+	Distance int
 }
 
 // CorpusStats holds corpus-level statistics for IDF calculation.
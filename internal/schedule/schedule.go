@@ -0,0 +1,107 @@
+// Package schedule describes daily windows of local time, such as "02:00-06:00", during
+// which background work is allowed.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Window is a daily span of local time from Start up to End, in minutes since midnight.
+// A window whose End is before its Start crosses midnight.
+type Window struct {
+	Start int
+	End   int
+}
+
+// String formats the window as "HH:MM-HH:MM".
+func (w Window) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", w.Start/60, w.Start%60, w.End/60, w.End%60)
+}
+
+func (w Window) contains(minute int) bool {
+	if w.Start < w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	return minute >= w.Start || minute < w.End
+}
+
+// Windows is a set of daily windows. An empty set allows any time.
+type Windows []Window
+
+// Parse parses windows written as "HH:MM-HH:MM", e.g. "22:00-06:00" for nights.
+func Parse(specs []string) (Windows, error) {
+	var ws Windows
+	for _, spec := range specs {
+		start, end, ok := strings.Cut(strings.TrimSpace(spec), "-")
+		if !ok {
+			return nil, fmt.Errorf("window %q: want HH:MM-HH:MM", spec)
+		}
+		s, err := parseClock(start)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", spec, err)
+		}
+		e, err := parseClock(end)
+		if err != nil {
+			return nil, fmt.Errorf("window %q: %w", spec, err)
+		}
+		if s == e {
+			return nil, fmt.Errorf("window %q: start and end are equal", spec)
+		}
+		ws = append(ws, Window{Start: s, End: e})
+	}
+	return ws, nil
+}
+
+// parseClock parses "HH:MM" into minutes since midnight; "24:00" is accepted as an end.
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		if strings.TrimSpace(s) == "24:00" {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Allows reports whether t falls in one of the windows.
+func (ws Windows) Allows(t time.Time) bool {
+	if len(ws) == 0 {
+		return true
+	}
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range ws {
+		if w.contains(minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns t when a window allows it, otherwise the start of the next window.
+func (ws Windows) NextOpen(t time.Time) time.Time {
+	if ws.Allows(t) {
+		return t
+	}
+	var next time.Time
+	for day := 0; day <= 1; day++ {
+		for _, w := range ws {
+			start := time.Date(t.Year(), t.Month(), t.Day()+day, w.Start/60, w.Start%60, 0, 0, t.Location())
+			if start.After(t) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+	return next
+}
+
+// Strings formats the windows as Parse accepts them.
+func (ws Windows) Strings() []string {
+	out := make([]string, len(ws))
+	for i, w := range ws {
+		out[i] = w.String()
+	}
+	return out
+}
@@ -0,0 +1,63 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	ws, err := Parse([]string{"02:00-06:00", " 22:30-01:15 ", "18:00-24:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Windows{{Start: 120, End: 360}, {Start: 1350, End: 75}, {Start: 1080, End: 0}}
+	if !reflect.DeepEqual(ws, want) {
+		t.Errorf("Parse = %v, want %v", ws, want)
+	}
+	if got := ws.Strings(); !reflect.DeepEqual(got, []string{"02:00-06:00", "22:30-01:15", "18:00-00:00"}) {
+		t.Errorf("Strings = %v", got)
+	}
+	for _, bad := range []string{"02:00", "2am-6am", "25:00-06:00", "06:00-06:00"} {
+		if _, err := Parse([]string{bad}); err == nil {
+			t.Errorf("Parse(%q): expected error", bad)
+		}
+	}
+}
+
+func TestWindows_Allows(t *testing.T) {
+	ws, err := Parse([]string{"22:00-06:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(h, m int) time.Time { return time.Date(2026, 3, 10, h, m, 0, 0, time.UTC) }
+	for _, tc := range []struct {
+		t    time.Time
+		want bool
+	}{
+		{at(23, 0), true},
+		{at(0, 30), true},
+		{at(5, 59), true},
+		{at(6, 0), false},
+		{at(12, 0), false},
+		{at(22, 0), true},
+	} {
+		if got := ws.Allows(tc.t); got != tc.want {
+			t.Errorf("Allows(%s) = %v, want %v", tc.t.Format("15:04"), got, tc.want)
+		}
+	}
+	if !Windows(nil).Allows(at(12, 0)) {
+		t.Error("no windows should allow any time")
+	}
+
+	if got := ws.NextOpen(at(12, 0)); !got.Equal(at(22, 0)) {
+		t.Errorf("NextOpen(12:00) = %v, want 22:00 the same day", got)
+	}
+	if got := ws.NextOpen(at(23, 0)); !got.Equal(at(23, 0)) {
+		t.Errorf("NextOpen inside a window = %v, want the time itself", got)
+	}
+	early, _ := Parse([]string{"02:00-04:00"})
+	if got := early.NextOpen(at(12, 0)); !got.Equal(time.Date(2026, 3, 11, 2, 0, 0, 0, time.UTC)) {
+		t.Errorf("NextOpen(12:00) = %v, want 02:00 the next day", got)
+	}
+}
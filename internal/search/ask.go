@@ -0,0 +1,223 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// Context assembly defaults for AskContext.
+const (
+	DefaultAskMaxChunks       = 8
+	DefaultAskMaxContextChars = 6000
+)
+
+// askChunk is a chunk of a found document with its relevance to the question.
+type askChunk struct {
+	chunk *models.DocumentChunk
+	score float64
+}
+
+// AskContext runs req's search and assembles the best chunks of the documents found into
+// a context for answering it, quoting each document under a [n] heading. Documents are
+// taken alternately from the keyword and semantic results. A document's chunks are ranked
+// by their similarity to the question plus the share of its words they contain, and every
+// document's best chunk is taken before any second one, until req.MaxChunks chunks or
+// req.MaxContextChars characters. A document whose best chunk does not fit is left out.
+func (e *Engine) AskContext(ctx context.Context, req *models.AskRequest) (*models.AskResponse, error) {
+	start := time.Now()
+	maxChunks, maxChars := req.MaxChunks, req.MaxContextChars
+	if maxChunks <= 0 {
+		maxChunks = DefaultAskMaxChunks
+	}
+	if maxChars <= 0 {
+		maxChars = DefaultAskMaxContextChars
+	}
+	query := req.SearchQuery
+	if !query.KeywordEnabled && !query.SemanticEnabled {
+		query.KeywordEnabled, query.SemanticEnabled = true, true
+	}
+	query.Offset = 0
+	query.Limit = maxChunks
+	found, err := e.Search(ctx, &query)
+	if err != nil {
+		return nil, err
+	}
+	results := interleaveResults(found.NonSemanticResults, found.SemanticResults)
+	if query.Fusion != "" {
+		// Fused results are already ranked across both searches
+		results = interleaveResults(found.Results, nil)
+	}
+
+	queryText, scope := parseScopeFilters(query.Query)
+	positive := keyword.PositiveQueryText(queryText)
+	similarity := make(map[string]float64)
+	if query.SemanticEnabled && strings.TrimSpace(positive) != "" {
+		cfg := e.config()
+		hits, err := e.searchChunks(ctx, e.semanticQueryText(cfg, queryText), cfg.TopKCandidates, newDocFilter(&query, scope))
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range hits {
+			similarity[h.ID] = h.Score
+		}
+	}
+	terms := askTerms(positive)
+
+	ranked := make([][]askChunk, len(results))
+	for i, r := range results {
+		chunks, err := e.storage.GetChunksByDocumentID(ctx, r.Document.ID)
+		if err != nil {
+			continue
+		}
+		for _, c := range chunks {
+			ranked[i] = append(ranked[i], askChunk{chunk: c, score: similarity[c.ID] + termShare(c.Content, terms)})
+		}
+		sort.SliceStable(ranked[i], func(a, b int) bool { return ranked[i][a].score > ranked[i][b].score })
+	}
+
+	// Take chunks round by round: each document's best, then each one's second best, ...
+	// Only a document's best chunk may share nothing with the question.
+	selected := make([][]*models.DocumentChunk, len(results))
+	citation := make([]int, len(results))
+	skipped := make([]bool, len(results))
+	var sources []*models.AskSource
+	used, taken := 0, 0
+	for round := 0; taken < maxChunks; round++ {
+		more := false
+		for i, r := range results {
+			if taken >= maxChunks {
+				break
+			}
+			if skipped[i] || round >= len(ranked[i]) {
+				continue
+			}
+			more = true
+			c := ranked[i][round]
+			if round > 0 && c.score == 0 {
+				continue
+			}
+			cost := len(c.chunk.Content) + 2
+			if round == 0 {
+				cost += len(sourceHeading(len(sources)+1, r.Document)) + 2
+			}
+			if used+cost > maxChars {
+				skipped[i] = round == 0
+				continue
+			}
+			if round == 0 {
+				citation[i] = len(sources) + 1
+				sources = append(sources, &models.AskSource{
+					Citation:   citation[i],
+					DocumentID: r.Document.ID,
+					Title:      r.Document.Title,
+					Path:       documentPath(r.Document),
+					Score:      r.Score,
+					Confidence: r.Confidence,
+				})
+			}
+			selected[i] = append(selected[i], c.chunk)
+			used += cost
+			taken++
+		}
+		if !more {
+			break
+		}
+	}
+
+	var blocks []string
+	for i, r := range results {
+		chunks := selected[i]
+		if len(chunks) == 0 {
+			continue
+		}
+		sort.Slice(chunks, func(x, y int) bool { return chunks[x].ChunkIndex < chunks[y].ChunkIndex })
+		src := sources[citation[i]-1]
+		texts := make([]string, len(chunks))
+		for j, c := range chunks {
+			texts[j] = strings.TrimSpace(c.Content)
+			src.Chunks = append(src.Chunks, c.ChunkIndex)
+		}
+		blocks = append(blocks, sourceHeading(citation[i], r.Document)+"\n"+strings.Join(texts, "\n\n"))
+	}
+	if sources == nil {
+		sources = []*models.AskSource{}
+	}
+	return &models.AskResponse{
+		Query:      req.Query,
+		Context:    strings.Join(blocks, "\n\n"),
+		Sources:    sources,
+		QueryTime:  time.Since(start).Milliseconds(),
+		Confidence: found.Confidence,
+	}, nil
+}
+
+// interleaveResults alternates the keyword and semantic results, best first, so the
+// context draws on both.
+func interleaveResults(keywordResults, semanticResults []*models.SearchResult) []*models.SearchResult {
+	var out []*models.SearchResult
+	for i := 0; i < len(keywordResults) || i < len(semanticResults); i++ {
+		if i < len(keywordResults) && keywordResults[i].Document != nil {
+			out = append(out, keywordResults[i])
+		}
+		if i < len(semanticResults) && semanticResults[i].Document != nil {
+			out = append(out, semanticResults[i])
+		}
+	}
+	return out
+}
+
+// sourceHeading is the line a document is quoted under: "[n] title (path)".
+func sourceHeading(n int, doc *models.Document) string {
+	title := doc.Title
+	if title == "" {
+		title = doc.ID
+	}
+	if path := documentPath(doc); path != "" {
+		return fmt.Sprintf("[%d] %s (%s)", n, title, path)
+	}
+	return fmt.Sprintf("[%d] %s", n, title)
+}
+
+// documentPath returns doc's source path, or "" for documents added through the API.
+func documentPath(doc *models.Document) string {
+	path, _ := doc.Metadata["source_path"].(string)
+	return path
+}
+
+// askTerms returns the distinct lowercase words of text with at least three letters or
+// digits; shorter words are mostly stopwords that would match any chunk.
+func askTerms(text string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(w)) >= 3 && !seen[w] {
+			seen[w] = true
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// termShare returns the fraction of terms that occur in content.
+func termShare(content string, terms []string) float64 {
+	if len(terms) == 0 {
+		return 0
+	}
+	lower := strings.ToLower(content)
+	n := 0
+	for _, t := range terms {
+		if strings.Contains(lower, t) {
+			n++
+		}
+	}
+	return float64(n) / float64(len(terms))
+}
@@ -0,0 +1,110 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_AskContext(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	kwIndex, err := keyword.NewBleveIndex(filepath.Join(t.TempDir(), "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 8, ChunkOverlap: 0}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	docs := []*models.DocumentInput{
+		{ID: "policy", Title: "Travel Policy", Metadata: map[string]interface{}{"source_path": "/docs/travel.md"},
+			Content: "Employees book flights through the portal in advance. " +
+				"Hotel stays are limited to three nights per trip abroad. " +
+				"Travel reimbursement requires receipts within thirty days."},
+		{ID: "memo", Title: "Budget Memo", Content: "The travel budget for next year grows by five percent overall."},
+	}
+	for _, d := range docs {
+		if err := idx.IndexDocument(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ask := func(req *models.AskRequest) *models.AskResponse {
+		t.Helper()
+		resp, err := engine.AskContext(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	query := models.SearchQuery{Query: "travel reimbursement receipts", KeywordEnabled: true}
+
+	resp := ask(&models.AskRequest{SearchQuery: query})
+	if len(resp.Sources) != 2 || resp.Sources[0].DocumentID != "policy" || resp.Sources[1].DocumentID != "memo" {
+		t.Fatalf("sources = %+v", resp.Sources)
+	}
+	if src := resp.Sources[0]; src.Citation != 1 || src.Path != "/docs/travel.md" || len(src.Chunks) == 0 {
+		t.Errorf("first source = %+v", src)
+	}
+	if !strings.HasPrefix(resp.Context, "[1] Travel Policy (/docs/travel.md)\n") || !strings.Contains(resp.Context, "\n\n[2] Budget Memo\n") {
+		t.Errorf("context headings:\n%s", resp.Context)
+	}
+	if !strings.Contains(resp.Context, "reimbursement requires receipts") {
+		t.Errorf("context misses the matching chunk:\n%s", resp.Context)
+	}
+	if strings.Contains(resp.Context, "Hotel stays") {
+		t.Errorf("chunks sharing no words with the question should be left out:\n%s", resp.Context)
+	}
+
+	// One chunk: the policy's best, the one with the most question words.
+	resp = ask(&models.AskRequest{SearchQuery: query, MaxChunks: 1})
+	if len(resp.Sources) != 1 || !strings.Contains(resp.Context, "receipts") {
+		t.Errorf("max_chunks=1: sources %+v, context:\n%s", resp.Sources, resp.Context)
+	}
+	// A budget too small for any chunk leaves an empty context.
+	resp = ask(&models.AskRequest{SearchQuery: query, MaxContextChars: 20})
+	if resp.Context != "" || len(resp.Sources) != 0 {
+		t.Errorf("tiny budget: sources %+v, context %q", resp.Sources, resp.Context)
+	}
+	// Filters of the query apply.
+	filtered := query
+	filtered.PathPrefix = "/docs"
+	resp = ask(&models.AskRequest{SearchQuery: filtered})
+	if len(resp.Sources) != 1 || resp.Sources[0].DocumentID != "policy" {
+		t.Errorf("path_prefix: sources %+v", resp.Sources)
+	}
+	// Without either search enabled, both are used.
+	resp = ask(&models.AskRequest{SearchQuery: models.SearchQuery{Query: "receipts"}})
+	if len(resp.Sources) == 0 {
+		t.Error("hybrid default found nothing")
+	}
+}
+
+func TestInterleaveResults(t *testing.T) {
+	r := func(id string) *models.SearchResult { return &models.SearchResult{Document: &models.Document{ID: id}} }
+	got := interleaveResults([]*models.SearchResult{r("k1"), r("k2"), r("k3")}, []*models.SearchResult{r("s1")})
+	var ids []string
+	for _, x := range got {
+		ids = append(ids, x.Document.ID)
+	}
+	if want := []string{"k1", "s1", "k2", "k3"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("order = %v, want %v", ids, want)
+	}
+}
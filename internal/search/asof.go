@@ -0,0 +1,85 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+)
+
+// searchAsOf answers query from the documents as they were at query.AsOf. The indexes
+// hold only current content, so the versions storage kept (see storage.VersionReader) are
+// indexed in a temporary in-memory keyword index and searched as keyword search would,
+// then filtered and paged like other results. Past versions have no embeddings, so
+// semantic search does not run, and the reranker, pins, and deduplication, which read
+// current documents, are skipped.
+func (e *Engine) searchAsOf(ctx context.Context, cfg *config.SearchConfig, query *models.SearchQuery, startTime time.Time) (*models.SearchResponse, error) {
+	reader, ok := e.storage.(storage.VersionReader)
+	if !ok {
+		return nil, storage.ErrVersionHistoryDisabled
+	}
+	docs, err := reader.DocumentsAsOf(ctx, *query.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	queryText, scope := parseScopeFilters(query.Query)
+	filter := newDocFilter(query, scope)
+	byID := make(map[string]*models.Document, len(docs))
+	var results []*keyword.KeywordResult
+	if strings.TrimSpace(queryText) != "" {
+		index, err := keyword.NewBleveIndex("")
+		if err != nil {
+			return nil, err
+		}
+		defer index.Close()
+		for _, doc := range docs {
+			if filter != nil && !filter.matches(doc) {
+				continue
+			}
+			byID[doc.ID] = doc
+			// Titles are indexed with underscores as spaces, as the indexer does.
+			copied := *doc
+			copied.Title = strings.ReplaceAll(doc.Title, "_", " ")
+			if err := index.Index(ctx, doc.ID, &copied); err != nil {
+				return nil, fmt.Errorf("failed to index past versions: %w", err)
+			}
+		}
+		if len(byID) > 0 {
+			if results, err = index.Search(ctx, queryText, len(byID), e.keywordOptions(cfg, query)); err != nil {
+				return nil, fmt.Errorf("keyword search failed: %w", err)
+			}
+		}
+	}
+
+	fused, _ := SplitBySource(NormalizeKeywordScores(results), nil)
+	if minScore := resolveMinKeywordScore(query, cfg); minScore > 0 {
+		fused = filterByMinScore(fused, minScore)
+	}
+	paged := pageResults(fused, query.Offset, query.Limit)
+	found := make([]*models.SearchResult, 0, len(paged))
+	for _, r := range paged {
+		found = append(found, &models.SearchResult{
+			Document:     byID[r.DocumentID],
+			Score:        r.Score,
+			KeywordScore: r.KeywordScore,
+			Rank:         len(found) + 1,
+		})
+	}
+	response := &models.SearchResponse{
+		NonSemanticResults: found,
+		SemanticResults:    []*models.SearchResult{},
+		TotalNonSemantic:   len(fused),
+		QueryTime:          time.Since(startTime).Milliseconds(),
+		Query:              query.Query,
+	}
+	if query.Fusion != "" {
+		response.Results, response.Fusion = found, query.Fusion
+		response.NonSemanticResults = []*models.SearchResult{}
+	}
+	return response, nil
+}
@@ -0,0 +1,114 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_Search_asOf(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:", storage.WithVersionHistory(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	index := func(id, content string) {
+		t.Helper()
+		if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: id, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tick := func() time.Time {
+		time.Sleep(5 * time.Millisecond)
+		now := time.Now()
+		time.Sleep(5 * time.Millisecond)
+		return now
+	}
+
+	index("policy", "remote work requires manager approval")
+	index("retired", "travel policy: economy class only")
+	lastQuarter := tick()
+	// Re-indexing replaces the document; the old text stays as a version.
+	if err := idx.DeleteDocument(ctx, "policy"); err != nil {
+		t.Fatal(err)
+	}
+	index("policy", "remote work is allowed without approval")
+	if err := idx.DeleteDocument(ctx, "retired"); err != nil {
+		t.Fatal(err)
+	}
+	index("added", "approval workflow for expenses")
+
+	search := func(text string, asOf *time.Time) []string {
+		t.Helper()
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: text, Limit: 10, AsOf: asOf})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if asOf != nil && len(resp.SemanticResults) != 0 {
+			t.Errorf("as_of %q: %d semantic results, want none", text, len(resp.SemanticResults))
+		}
+		ids := resultIDs(resp.NonSemanticResults)
+		for _, r := range resp.NonSemanticResults {
+			if r.Document == nil {
+				t.Fatalf("as_of %q: result without document", text)
+			}
+		}
+		return ids
+	}
+
+	if ids := search("manager", &lastQuarter); len(ids) != 1 || ids[0] != "policy" {
+		t.Errorf("as of last quarter, manager: %v, want [policy]", ids)
+	}
+	if ids := search("economy", &lastQuarter); len(ids) != 1 || ids[0] != "retired" {
+		t.Errorf("as of last quarter, deleted document: %v, want [retired]", ids)
+	}
+	if ids := search("expenses", &lastQuarter); len(ids) != 0 {
+		t.Errorf("as of last quarter, document added since: %v, want none", ids)
+	}
+	if ids := search("manager", nil); len(ids) != 0 {
+		t.Errorf("current search finds the replaced text: %v", ids)
+	}
+	now := time.Now()
+	if ids := search("without", &now); len(ids) != 1 || ids[0] != "policy" {
+		t.Errorf("as of now: %v, want [policy]", ids)
+	}
+
+	resp, err := engine.Search(ctx, &models.SearchQuery{Query: "manager", Limit: 10, AsOf: &lastQuarter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resp.NonSemanticResults[0].Document.Content; got != "remote work requires manager approval" {
+		t.Errorf("result content = %q, want the version of last quarter", got)
+	}
+
+	plain, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	engine = NewEngine(plain, emb, vecIndex, kwIndex, cfg)
+	if _, err := engine.Search(ctx, &models.SearchQuery{Query: "manager", AsOf: &lastQuarter}); !errors.Is(err, storage.ErrVersionHistoryDisabled) {
+		t.Errorf("without version history: err = %v, want ErrVersionHistoryDisabled", err)
+	}
+}
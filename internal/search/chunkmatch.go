@@ -0,0 +1,101 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// attachChunks sets the Chunks of results to up to n of their document's chunks that
+// match the query best: those the semantic search found, scored by semanticByChunk, and
+// those containing words of queryText, scored by the share they contain. The chunk a
+// keyword chunk index matched best is kept even without such words, e.g. when it matched
+// a stem.
+func (e *Engine) attachChunks(ctx context.Context, results []*models.SearchResult, semanticByChunk map[string]float64, queryText string, n int) {
+	terms := askTerms(queryText)
+	for _, r := range results {
+		chunks, err := e.storage.GetChunksByDocumentID(ctx, r.Document.ID)
+		if err != nil || len(chunks) == 0 {
+			continue
+		}
+		sort.Slice(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+		offsets := chunkOffsets(r.Document.Content, chunks)
+		var matches []*models.ChunkMatch
+		for i, c := range chunks {
+			m := &models.ChunkMatch{
+				ChunkID:       c.ID,
+				DocumentID:    c.DocumentID,
+				ChunkIndex:    c.ChunkIndex,
+				Start:         offsets[i][0],
+				End:           offsets[i][1],
+				Content:       c.Content,
+				SemanticScore: semanticByChunk[c.ID],
+				KeywordScore:  termShare(c.Content, terms),
+			}
+			m.Score = m.SemanticScore + m.KeywordScore
+			if m.Score > 0 || (r.KeywordChunk != nil && *r.KeywordChunk == c.ChunkIndex) {
+				matches = append(matches, m)
+			}
+		}
+		sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+		if len(matches) > n {
+			matches = matches[:n]
+		}
+		r.Chunks = matches
+	}
+}
+
+// chunkOffsets returns the character offsets, start and end, of chunks (in chunk order) in
+// content, or -1 and -1 for a chunk not found. A chunk is looked for after where the one
+// before it starts, as chunks may overlap. Chunks whose text differs from content in
+// their line breaks, such as paragraph chunks repeating their section's heading, are
+// located by their first line found there and their last line.
+func chunkOffsets(content string, chunks []*models.DocumentChunk) [][2]int {
+	offsets := make([][2]int, len(chunks))
+	from := 0
+	for i, c := range chunks {
+		start, end := locateChunk(content, c.Content, from)
+		if start < 0 {
+			offsets[i] = [2]int{-1, -1}
+			continue
+		}
+		from = start + 1
+		offsets[i] = [2]int{utf8.RuneCountInString(content[:start]), utf8.RuneCountInString(content[:end])}
+	}
+	return offsets
+}
+
+// locateChunk returns the byte offsets of text in content from from, or -1 and -1.
+func locateChunk(content, text string, from int) (int, int) {
+	if text == "" {
+		return -1, -1
+	}
+	if i := strings.Index(content[from:], text); i >= 0 {
+		return from + i, from + i + len(text)
+	}
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	for k, line := range lines {
+		i := strings.Index(content[from:], line)
+		if i < 0 {
+			continue
+		}
+		start := from + i
+		last := lines[len(lines)-1]
+		if k == len(lines)-1 {
+			return start, start + len(last)
+		}
+		if j := strings.Index(content[start+len(line):], last); j >= 0 {
+			return start, start + len(line) + j + len(last)
+		}
+		return -1, -1
+	}
+	return -1, -1
+}
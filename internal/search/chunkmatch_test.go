@@ -0,0 +1,81 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_Search_chunks(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 20, ChunkOverlap: 5}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	filler := strings.Repeat("café ipsum dolor sit amet ", 8)
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "doc", Title: "report", Content: filler + "the zeppelin budget " + filler}); err != nil {
+		t.Fatal(err)
+	}
+
+	query := &models.SearchQuery{Query: "zeppelin budget", Limit: 10, KeywordEnabled: true}
+	resp, err := engine.Search(ctx, query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.NonSemanticResults) != 1 || resp.NonSemanticResults[0].Chunks != nil {
+		t.Fatalf("without chunks: got %+v", resp.NonSemanticResults)
+	}
+
+	query.Chunks = 1
+	if resp, err = engine.Search(ctx, query); err != nil {
+		t.Fatal(err)
+	}
+	r := resp.NonSemanticResults[0]
+	if len(r.Chunks) != 1 {
+		t.Fatalf("chunks = %d, want 1", len(r.Chunks))
+	}
+	m := r.Chunks[0]
+	if m.DocumentID != "doc" || m.KeywordScore != 1 || !strings.Contains(m.Content, "zeppelin budget") {
+		t.Errorf("chunk = %+v", m)
+	}
+	if got := string([]rune(r.Document.Content)[m.Start:m.End]); got != m.Content {
+		t.Errorf("offsets %d-%d select %q, want %q", m.Start, m.End, got, m.Content)
+	}
+}
+
+func TestChunkOffsets(t *testing.T) {
+	content := "# Über\n\nFirst part.\n\nSecond part.\nThird line."
+	chunks := []*models.DocumentChunk{
+		{Content: "# Über\n\nFirst part."},
+		{Content: "# Über\n\nSecond part.\n\nThird line."},
+		{Content: "gone"},
+		{Content: "Third line."},
+	}
+	want := [][2]int{{0, 19}, {21, 45}, {-1, -1}, {34, 45}}
+	got := chunkOffsets(content, chunks)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("chunk %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
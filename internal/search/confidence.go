@@ -0,0 +1,123 @@
+package search
+
+import (
+	"math"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// Confidence calibration used when the search config leaves it unset.
+const (
+	defaultConfidenceKeywordMidpoint   = 1.0
+	defaultConfidenceSemanticMidpoint  = 0.5
+	defaultConfidenceSemanticSteepness = 10.0
+)
+
+// calibrator turns a result's keyword and semantic scores into a 0-1 confidence (see
+// models.Calibration).
+type calibrator struct {
+	models.Calibration
+}
+
+// calibration returns the calibrator for a search of queryText with cfg. The specificity of its
+// terms is read from the keyword index when keyword search runs on words; pattern queries
+// and searches without keyword results count as fully specific.
+func (e *Engine) calibration(cfg *config.SearchConfig, query *models.SearchQuery, queryText string, keywordResults int) *calibrator {
+	c := &calibrator{models.Calibration{
+		KeywordMidpoint:   cfg.ConfidenceKeywordMidpoint,
+		SemanticMidpoint:  cfg.ConfidenceSemanticMidpoint,
+		SemanticSteepness: cfg.ConfidenceSemanticSteepness,
+		TermSpecificity:   1,
+	}}
+	if c.KeywordMidpoint <= 0 {
+		c.KeywordMidpoint = defaultConfidenceKeywordMidpoint
+	}
+	if c.SemanticMidpoint <= 0 {
+		c.SemanticMidpoint = defaultConfidenceSemanticMidpoint
+	}
+	if c.SemanticSteepness <= 0 {
+		c.SemanticSteepness = defaultConfidenceSemanticSteepness
+	}
+	if keywordResults == 0 || query.IsPattern() {
+		return c
+	}
+	terms := calibrationTerms(cfg, queryText)
+	c.QueryTerms = len(terms)
+	if len(terms) == 0 {
+		return c
+	}
+	total, freqs, err := e.keywordIndex.GetCorpusStats(terms)
+	if err != nil {
+		return c
+	}
+	c.CorpusDocuments = total
+	c.TermSpecificity = 0
+	sum := 0.0
+	for _, t := range terms {
+		df := freqs[t]
+		if df <= 0 {
+			continue
+		}
+		c.KnownTerms++
+		sum += termSpecificity(df, total)
+	}
+	if c.KnownTerms > 0 {
+		c.TermSpecificity = sum / float64(c.KnownTerms)
+	}
+	return c
+}
+
+// calibrationTerms returns the distinct positive, unscoped words of queryText, leaving out
+// the stopwords of cfg.
+func calibrationTerms(cfg *config.SearchConfig, queryText string) []string {
+	stop := make(map[string]bool, len(cfg.Stopwords))
+	for _, w := range cfg.Stopwords {
+		stop[strings.ToLower(w)] = true
+	}
+	seen := make(map[string]bool)
+	var terms []string
+	for _, t := range keyword.ParseQueryParts(keyword.PositiveQueryText(queryText)).Terms {
+		if !stop[t] && !seen[t] {
+			seen[t] = true
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// termSpecificity maps a term's document frequency df among total documents to [0, 1]:
+// log(total/df) / log(total), 1 for a term in one document and 0 for one in every document.
+func termSpecificity(df, total int) float64 {
+	if total <= 1 {
+		return 1
+	}
+	return min(max(math.Log(float64(total)/float64(df))/math.Log(float64(total)), 0), 1)
+}
+
+// confidence returns the confidence of a result with the given keyword and semantic
+// scores: the chance that either kind of evidence is right, taken as independent.
+func (c *calibrator) confidence(keywordScore, semanticScore float64) float64 {
+	kw := 0.0
+	if keywordScore > 0 {
+		kw = keywordScore / (keywordScore + c.KeywordMidpoint) * (1 + c.TermSpecificity) / 2
+	}
+	sem := 0.0
+	if semanticScore > 0 {
+		sem = 1 / (1 + math.Exp(-c.SemanticSteepness*(semanticScore-c.SemanticMidpoint)))
+	}
+	return 1 - (1-kw)*(1-sem)
+}
+
+// best returns the highest confidence among results, or 0 when there are none.
+func (c *calibrator) best(results ...[]*FusedResult) float64 {
+	best := 0.0
+	for _, list := range results {
+		for _, r := range list {
+			best = max(best, c.confidence(r.KeywordScore, r.SemanticScore))
+		}
+	}
+	return best
+}
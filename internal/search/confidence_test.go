@@ -0,0 +1,115 @@
+package search
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestCalibrator_confidence(t *testing.T) {
+	c := &calibrator{models.Calibration{KeywordMidpoint: 1, SemanticMidpoint: 0.5, SemanticSteepness: 10, TermSpecificity: 1}}
+	tests := []struct {
+		name         string
+		keyword, sem float64
+		want         float64
+	}{
+		{"no evidence", 0, 0, 0},
+		{"keyword at midpoint", 1, 0, 0.5},
+		{"semantic at midpoint", 0, 0.5, 0.5},
+		{"both at midpoint", 1, 0.5, 0.75},
+		{"strong keyword", 9, 0, 0.9},
+	}
+	for _, tt := range tests {
+		if got := c.confidence(tt.keyword, tt.sem); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: confidence(%g, %g) = %g, want %g", tt.name, tt.keyword, tt.sem, got, tt.want)
+		}
+	}
+	if lo, hi := c.confidence(0, 0.2), c.confidence(0, 0.8); lo > 0.05 || hi < 0.95 {
+		t.Errorf("semantic confidence at 0.2 and 0.8 = %g, %g; want near 0 and 1", lo, hi)
+	}
+	c.TermSpecificity = 0
+	if got := c.confidence(1, 0); got != 0.25 {
+		t.Errorf("keyword at midpoint with common terms = %g, want 0.25", got)
+	}
+}
+
+func TestTermSpecificity(t *testing.T) {
+	if got := termSpecificity(1, 100); got != 1 {
+		t.Errorf("term in one of 100 documents = %g, want 1", got)
+	}
+	if got := termSpecificity(100, 100); got != 0 {
+		t.Errorf("term in every document = %g, want 0", got)
+	}
+	if got := termSpecificity(10, 100); math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("term in 10 of 100 documents = %g, want 0.5", got)
+	}
+	if got := termSpecificity(1, 1); got != 1 {
+		t.Errorf("single-document corpus = %g, want 1", got)
+	}
+}
+
+func TestEngine_Search_confidence(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 500, ChunkOverlap: 10}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for _, in := range []*models.DocumentInput{
+		{ID: "budget", Title: "plan", Content: "the quarterly budget report"},
+		{ID: "trip", Title: "notes", Content: "the trip report"},
+		{ID: "menu", Title: "lunch", Content: "the report on the menu"},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(q string) *models.SearchResponse {
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: q, Limit: 10, KeywordEnabled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	rare, common := search("budget"), search("report")
+	if len(rare.NonSemanticResults) != 1 || len(common.NonSemanticResults) != 3 {
+		t.Fatalf("got %d and %d results, want 1 and 3", len(rare.NonSemanticResults), len(common.NonSemanticResults))
+	}
+	if rare.Calibration == nil || rare.Calibration.KnownTerms != 1 || rare.Calibration.CorpusDocuments != 3 || rare.Calibration.TermSpecificity != 1 {
+		t.Errorf("calibration of a rare term = %+v", rare.Calibration)
+	}
+	if common.Calibration.TermSpecificity != 0 {
+		t.Errorf("specificity of a term in every document = %g, want 0", common.Calibration.TermSpecificity)
+	}
+	if rare.Confidence <= common.Confidence {
+		t.Errorf("confidence of a rare term %g should exceed that of a term in every document %g", rare.Confidence, common.Confidence)
+	}
+	if got := rare.NonSemanticResults[0].Confidence; got != rare.Confidence || got <= 0 || got >= 1 {
+		t.Errorf("result confidence = %g, want the response confidence %g in (0, 1)", got, rare.Confidence)
+	}
+
+	none := search("zebra")
+	if none.Confidence != 0 || none.Calibration == nil {
+		t.Errorf("no results: confidence %g, calibration %+v; want 0 and set", none.Confidence, none.Calibration)
+	}
+}
@@ -0,0 +1,78 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// Count returns the number of documents matching query by keyword, without the candidate
+// limit or score thresholds of Search. Semantic matches are not counted: every document
+// is similar to a query to some degree. Without path, extension, date, or metadata filters
+// the keyword index counts the matches itself; with them, matching documents are loaded
+// to apply the filters. As in Search, search.stemmed_fallback counts the stemmed matches
+// of a query that matches nothing as written.
+func (e *Engine) Count(ctx context.Context, query *models.SearchQuery) (int, error) {
+	if err := ProcessQuery(query); err != nil {
+		return 0, err
+	}
+	queryText, scope := e.queryScope(query)
+	if strings.TrimSpace(queryText) == "" {
+		return 0, nil
+	}
+	opts := e.keywordOptions(e.config(), query)
+	filter := newDocFilter(query, scope)
+	if err := e.loadSnapshot(ctx, filter); err != nil {
+		return 0, err
+	}
+	if query.Mode == models.QueryModeLiteral {
+		results, err := e.literalResults(ctx, query, opts.TitleBoost)
+		if err != nil {
+			return 0, fmt.Errorf("literal search failed: %w", err)
+		}
+		return e.countFiltered(ctx, results, filter), nil
+	}
+	n, err := e.countKeyword(ctx, queryText, opts, filter)
+	if err != nil || n > 0 || !e.stemmedFallback(query, opts) {
+		return n, err
+	}
+	opts.NoStems = false
+	return e.countKeyword(ctx, queryText, opts, filter)
+}
+
+// countKeyword returns the number of documents the keyword index matches with opts that
+// pass filter.
+func (e *Engine) countKeyword(ctx context.Context, queryText string, opts *keyword.SearchOptions, filter *docFilter) (int, error) {
+	if counter, ok := e.keywordIndex.(keyword.Counter); ok && filter == nil {
+		n, err := counter.Count(ctx, queryText, opts)
+		if err != nil {
+			return 0, fmt.Errorf("keyword count failed: %w", err)
+		}
+		return int(n), nil
+	}
+
+	total, err := e.keywordIndex.DocCount()
+	if err != nil || total == 0 {
+		return 0, err
+	}
+	results, err := e.keywordIndex.Search(ctx, queryText, int(total), opts)
+	if err != nil {
+		return 0, fmt.Errorf("keyword search failed: %w", err)
+	}
+	return e.countFiltered(ctx, results, filter), nil
+}
+
+// countFiltered returns the number of results that pass filter.
+func (e *Engine) countFiltered(ctx context.Context, results []*keyword.KeywordResult, filter *docFilter) int {
+	if filter == nil {
+		return len(results)
+	}
+	fused := make([]*FusedResult, len(results))
+	for i, r := range results {
+		fused[i] = &FusedResult{DocumentID: r.ID}
+	}
+	return len(e.filterDocuments(ctx, fused, filter))
+}
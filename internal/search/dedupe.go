@@ -0,0 +1,65 @@
+package search
+
+import (
+	"context"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/simhash"
+)
+
+// fingerprintKey is the metadata key of the content fingerprint written by the indexer.
+const fingerprintKey = "content_simhash"
+
+// dedupeEnabled reports whether query collapses near-identical documents.
+func (e *Engine) dedupeEnabled(cfg *config.SearchConfig, query *models.SearchQuery) bool {
+	if query.Dedupe != nil {
+		return *query.Dedupe
+	}
+	return cfg.DedupeEnabledOrDefault()
+}
+
+// dedupeResults collapses near-identical documents in both result lists: a document whose
+// content fingerprint is within search.dedupe_max_distance bits of one ranked before it
+// (non-semantic results rank before semantic ones) is dropped. It returns the kept lists
+// and the source paths of the dropped copies by the ID of the document that kept them.
+// Documents without a fingerprint are always kept.
+func (e *Engine) dedupeResults(ctx context.Context, cfg *config.SearchConfig, nonSemantic, semantic []*FusedResult) ([]*FusedResult, []*FusedResult, map[string][]string) {
+	type kept struct {
+		id string
+		fp uint64
+	}
+	var seen []kept
+	copies := make(map[string][]string)
+	dedupe := func(results []*FusedResult) []*FusedResult {
+		out := results[:0]
+	next:
+		for _, r := range results {
+			doc, err := e.getDocument(ctx, r.DocumentID)
+			if err != nil {
+				out = append(out, r)
+				continue
+			}
+			s, _ := doc.Metadata[fingerprintKey].(string)
+			fp, ok := simhash.Parse(s)
+			if !ok {
+				out = append(out, r)
+				continue
+			}
+			for _, k := range seen {
+				if simhash.Distance(fp, k.fp) <= cfg.DedupeMaxDistance {
+					if path, _ := doc.Metadata["source_path"].(string); path != "" {
+						copies[k.id] = append(copies[k.id], path)
+					}
+					continue next
+				}
+			}
+			seen = append(seen, kept{r.DocumentID, fp})
+			out = append(out, r)
+		}
+		return out
+	}
+	nonSemantic = dedupe(nonSemantic)
+	semantic = dedupe(semantic)
+	return nonSemantic, semantic, copies
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_Search_dedupe(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 500, ChunkOverlap: 10, DedupeMaxDistance: 3}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	report := strings.Repeat("The annual report shows revenue growth in every region and flat costs. ", 10)
+	for _, in := range []*models.DocumentInput{
+		{ID: "original", Title: "annual report", Content: report, Metadata: map[string]interface{}{"source_path": "/reports/annual.pdf"}},
+		{ID: "copy", Content: report, Metadata: map[string]interface{}{"source_path": "/backup/annual.pdf"}},
+		{ID: "draft", Content: report + " Draft.", Metadata: map[string]interface{}{"source_path": "/drafts/annual.pdf"}},
+		{ID: "other", Content: "The annual report template lists the sections every team fills in before the review.", Metadata: map[string]interface{}{"source_path": "/templates/report.md"}},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(dedupe *bool) *models.SearchResponse {
+		t.Helper()
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: "annual report", Limit: 10, KeywordEnabled: true, Dedupe: dedupe})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := search(nil)
+	results := resp.NonSemanticResults
+	if len(results) != 2 || resp.TotalNonSemantic != 2 {
+		t.Fatalf("copies should collapse into one result, got %v", resultIDs(results))
+	}
+	if results[0].Document.ID != "original" {
+		t.Fatalf("the best-ranked copy should be kept, got %v", resultIDs(results))
+	}
+	want := []string{"/backup/annual.pdf", "/drafts/annual.pdf"}
+	if got := results[0].AlsoFoundAt; !reflect.DeepEqual(got, want) {
+		t.Errorf("also found at %q, want %q", got, want)
+	}
+	if results[1].Document.ID != "other" || results[1].AlsoFoundAt != nil {
+		t.Errorf("unrelated document should be kept alone, got %v", results[1])
+	}
+
+	off := false
+	if results := search(&off).NonSemanticResults; len(results) != 4 {
+		t.Errorf("dedupe: false should return every copy, got %v", resultIDs(results))
+	}
+}
@@ -0,0 +1,139 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// ErrNoRecordedSearch is returned by DiffSince when no search for the query was recorded.
+var ErrNoRecordedSearch = errors.New("no recorded search for the query")
+
+// DiffSearches runs query and against and compares their first query.Limit results per
+// list: documents only query found entered, those only against found left, and those
+// both found at another place moved. Offsets are ignored.
+func (e *Engine) DiffSearches(ctx context.Context, query, against *models.SearchQuery) (*models.ResultsDiff, error) {
+	if err := ProcessQuery(query); err != nil {
+		return nil, err
+	}
+	after, err := e.firstPage(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	before, err := e.firstPage(ctx, against)
+	if err != nil {
+		return nil, fmt.Errorf("against: %w", err)
+	}
+	diff := e.diffResults(ctx, models.ResultRefs(before), after, query.Limit, documentsOf(before))
+	diff.Against = against.Query
+	return diff, nil
+}
+
+// DiffSince runs query and compares its first query.Limit results per list with those
+// of the search for the same text recorded at since, or the first one after it when
+// none was recorded before (see storage.Storage.RecordedSearch). Only the query text is
+// recorded, so the recorded search is taken to have had the other options of query.
+// It returns ErrNoRecordedSearch when the query was never recorded.
+func (e *Engine) DiffSince(ctx context.Context, query *models.SearchQuery, since time.Time) (*models.ResultsDiff, error) {
+	if err := ProcessQuery(query); err != nil {
+		return nil, err
+	}
+	recorded, err := e.storage.RecordedSearch(ctx, query.Query, since)
+	if err != nil {
+		return nil, fmt.Errorf("recorded search: %w", err)
+	}
+	if recorded == nil {
+		return nil, fmt.Errorf("%w %q", ErrNoRecordedSearch, query.Query)
+	}
+	after, err := e.firstPage(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	diff := e.diffResults(ctx, recorded.Results, after, query.Limit, nil)
+	diff.BaselineTime = &recorded.Time
+	return diff, nil
+}
+
+// firstPage runs query from its first result.
+func (e *Engine) firstPage(ctx context.Context, query *models.SearchQuery) (*models.SearchResponse, error) {
+	q := *query
+	q.Offset = 0
+	return e.Search(ctx, &q)
+}
+
+// diffResults compares the places in before with the results of after, up to limit per
+// list. Documents that left are described from known, or else loaded from storage.
+func (e *Engine) diffResults(ctx context.Context, before []models.ResultRef, after *models.SearchResponse, limit int, known map[string]*models.Document) *models.ResultsDiff {
+	diff := &models.ResultsDiff{
+		Query:   after.Query,
+		Limit:   limit,
+		Entered: []*models.ResultChange{},
+		Left:    []*models.ResultChange{},
+		Moved:   []*models.ResultChange{},
+	}
+	docs := documentsOf(after)
+	was := make(map[string]models.ResultRef, len(before))
+	for _, ref := range before {
+		if ref.Rank <= limit {
+			was[ref.DocumentID] = ref
+		}
+	}
+	now := make(map[string]bool)
+	for _, ref := range models.ResultRefs(after) {
+		if ref.Rank > limit {
+			continue
+		}
+		now[ref.DocumentID] = true
+		ref := ref
+		old, ok := was[ref.DocumentID]
+		switch {
+		case !ok:
+			diff.Entered = append(diff.Entered, describeChange(docs[ref.DocumentID], ref.DocumentID, nil, &ref))
+		case old != ref:
+			diff.Moved = append(diff.Moved, describeChange(docs[ref.DocumentID], ref.DocumentID, &old, &ref))
+		default:
+			diff.Unchanged++
+		}
+	}
+	for _, ref := range before {
+		if ref.Rank > limit || now[ref.DocumentID] {
+			continue
+		}
+		ref := ref
+		doc, deleted := known[ref.DocumentID], false
+		if doc == nil {
+			var err error
+			if doc, err = e.getDocument(ctx, ref.DocumentID); err != nil {
+				doc, deleted = nil, true
+			}
+		}
+		change := describeChange(doc, ref.DocumentID, &ref, nil)
+		change.Deleted = deleted
+		diff.Left = append(diff.Left, change)
+	}
+	return diff
+}
+
+// describeChange returns the change of document id, titled from doc when known.
+func describeChange(doc *models.Document, id string, before, after *models.ResultRef) *models.ResultChange {
+	change := &models.ResultChange{DocumentID: id, Before: before, After: after}
+	if doc != nil {
+		change.Title = doc.Title
+		change.Path, _ = doc.Metadata["source_path"].(string)
+	}
+	return change
+}
+
+// documentsOf returns the documents of the results of response by ID.
+func documentsOf(response *models.SearchResponse) map[string]*models.Document {
+	docs := make(map[string]*models.Document)
+	for _, r := range response.AllResults() {
+		if r.Document != nil {
+			docs[r.Document.ID] = r.Document
+		}
+	}
+	return docs
+}
@@ -0,0 +1,79 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_Diff(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for _, d := range []*models.DocumentInput{
+		{ID: "budget", Title: "budget.xlsx", Content: "budget plan for the quarter"},
+		{ID: "roadmap", Title: "roadmap.md", Content: "product roadmap plan"},
+	} {
+		if err := idx.IndexDocument(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keywordOnly := func(q string) *models.SearchQuery {
+		return &models.SearchQuery{Query: q, KeywordEnabled: true}
+	}
+
+	diff, err := engine.DiffSearches(ctx, keywordOnly("plan"), keywordOnly("budget"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Against != "budget" || diff.Limit != 10 || len(diff.Entered) != 1 || diff.Entered[0].DocumentID != "roadmap" ||
+		diff.Entered[0].Title != "roadmap.md" || len(diff.Left) != 0 || diff.Unchanged+len(diff.Moved) != 1 {
+		t.Errorf("plan against budget: got %+v", diff)
+	}
+
+	if _, err := engine.DiffSince(ctx, keywordOnly("plan"), time.Now()); !errors.Is(err, ErrNoRecordedSearch) {
+		t.Errorf("unrecorded query: got %v, want ErrNoRecordedSearch", err)
+	}
+	recorded := &models.SearchEvent{Time: time.Now().Add(-48 * time.Hour), Query: "plan", KeywordResults: 2, Results: []models.ResultRef{
+		{DocumentID: "gone", List: models.ResultListKeyword, Rank: 1},
+		{DocumentID: "budget", List: models.ResultListKeyword, Rank: 2},
+	}}
+	if err := store.AppendSearchEvent(ctx, recorded); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = engine.DiffSince(ctx, keywordOnly("plan"), time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.BaselineTime == nil || !diff.BaselineTime.Equal(recorded.Time) {
+		t.Errorf("baseline time: got %v, want %v", diff.BaselineTime, recorded.Time)
+	}
+	if len(diff.Left) != 1 || diff.Left[0].DocumentID != "gone" || !diff.Left[0].Deleted || diff.Left[0].Before.Rank != 1 {
+		t.Errorf("left: got %+v", diff.Left)
+	}
+	if len(diff.Entered) != 1 || diff.Entered[0].DocumentID != "roadmap" || diff.Entered[0].After == nil {
+		t.Errorf("entered: got %+v", diff.Entered)
+	}
+}
@@ -0,0 +1,127 @@
+package search
+
+import (
+	"context"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+// diversityLambda returns the weight query gives diversity over relevance: its own, or
+// search.diversity_lambda.
+func (e *Engine) diversityLambda(cfg *config.SearchConfig, query *models.SearchQuery) float64 {
+	if query.DiversityLambda != nil {
+		return *query.DiversityLambda
+	}
+	return cfg.DiversityLambda
+}
+
+// diversify reorders the first top-K results by maximal marginal relevance: each next
+// result is the one with the best (1 - lambda) × relevance - lambda × its highest
+// similarity to a result placed before it, so near-duplicates of a better result move
+// down. Relevance is the fused score scaled to 0-1 among the candidates; similarity is the
+// cosine of the documents' mean chunk embeddings. Documents whose vectors the vector
+// indexes cannot return are compared by relevance alone. Scores are left as they are.
+func (e *Engine) diversify(ctx context.Context, cfg *config.SearchConfig, results []*FusedResult, lambda float64) []*FusedResult {
+	if lambda <= 0 || len(results) < 2 {
+		return results
+	}
+	n := min(len(results), cfg.TopKCandidates)
+	if n < 2 {
+		return results
+	}
+	candidates := results[:n]
+	best, worst := candidates[0].Score, candidates[0].Score
+	for _, r := range candidates {
+		best, worst = max(best, r.Score), min(worst, r.Score)
+	}
+	relevance := make([]float64, n)
+	vectors := make([]docVector, n)
+	for i, r := range candidates {
+		relevance[i] = 1
+		if best > worst {
+			relevance[i] = (r.Score - worst) / (best - worst)
+		}
+		vectors[i] = e.documentVector(ctx, r.DocumentID)
+	}
+
+	// maxSim[i] is the highest similarity of candidate i to one placed so far.
+	maxSim := make([]float64, n)
+	placed := make([]bool, n)
+	out := make([]*FusedResult, 0, len(results))
+	for len(out) < n {
+		pick, pickValue := -1, 0.0
+		for i := range candidates {
+			if placed[i] {
+				continue
+			}
+			value := (1-lambda)*relevance[i] - lambda*maxSim[i]
+			if pick < 0 || value > pickValue {
+				pick, pickValue = i, value
+			}
+		}
+		placed[pick] = true
+		out = append(out, candidates[pick])
+		for i := range candidates {
+			if !placed[i] {
+				maxSim[i] = max(maxSim[i], vectors[pick].similarity(vectors[i]))
+			}
+		}
+	}
+	return append(out, results[n:]...)
+}
+
+// docVector is the normalized mean of a document's chunk embeddings, with the semantic
+// space they come from, since vectors of different models cannot be compared.
+type docVector struct {
+	space int
+	vec   []float32
+}
+
+// similarity returns the cosine similarity of v and w, or 0 when either is missing or
+// they come from different semantic spaces.
+func (v docVector) similarity(w docVector) float64 {
+	if v.vec == nil || w.vec == nil || v.space != w.space {
+		return 0
+	}
+	return vector.InnerProduct(v.vec, w.vec)
+}
+
+// documentVector returns the vector of the document with docID from the first semantic
+// space whose index can return its chunks' embeddings, or a zero docVector.
+func (e *Engine) documentVector(ctx context.Context, docID string) docVector {
+	chunks, err := e.storage.GetChunksByDocumentID(ctx, docID)
+	if err != nil || len(chunks) == 0 {
+		return docVector{}
+	}
+	spaces := append([]semanticSpace{{vectorIndex: e.vectorIndex}}, e.extraSpaces...)
+	for i, sp := range spaces {
+		getter, ok := sp.vectorIndex.(vector.Getter)
+		if !ok {
+			continue
+		}
+		var mean []float32
+		for _, c := range chunks {
+			v, ok := getter.Vector(c.ID)
+			if !ok || mean != nil && len(v) != len(mean) {
+				continue
+			}
+			if mean == nil {
+				mean = make([]float32, len(v))
+			}
+			for j, x := range v {
+				mean[j] += x
+			}
+		}
+		norm := vector.L2Norm(mean)
+		if norm == 0 {
+			continue
+		}
+		for j := range mean {
+			mean[j] /= float32(norm)
+		}
+		return docVector{space: i, vec: mean}
+	}
+	return docVector{}
+}
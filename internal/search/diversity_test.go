@@ -0,0 +1,69 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_diversify(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	vecIndex, _ := vector.NewMemoryIndex(2)
+	vectors := map[string][][]float32{
+		"report":  {{1, 0}, {0.8, 0.6}},
+		"copy":    {{1, 0}, {0.8, 0.6}},
+		"summary": {{0.6, 0.8}},
+		"memo":    {{0, 1}},
+	}
+	for id, vecs := range vectors {
+		if err := store.CreateDocument(ctx, &models.Document{ID: id, Title: id, Content: id}); err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range vecs {
+			chunk := &models.DocumentChunk{ID: id + "_" + string(rune('0'+i)), DocumentID: id, ChunkIndex: i, Content: id}
+			if err := store.CreateChunk(ctx, chunk); err != nil {
+				t.Fatal(err)
+			}
+			if err := vecIndex.Add(ctx, []string{chunk.ID}, [][]float32{v}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	engine := NewEngine(store, nil, vecIndex, nil, &config.SearchConfig{TopKCandidates: 4})
+	results := func() []*FusedResult {
+		return []*FusedResult{
+			{DocumentID: "report", Score: 1},
+			{DocumentID: "copy", Score: 0.95},
+			{DocumentID: "summary", Score: 0.9},
+			{DocumentID: "memo", Score: 0.5},
+			{DocumentID: "unindexed", Score: 0.4},
+		}
+	}
+
+	order := func(rs []*FusedResult) string {
+		var ids string
+		for _, r := range rs {
+			ids += r.DocumentID + " "
+		}
+		return ids
+	}
+	if got := order(engine.diversify(ctx, engine.config(), results(), 0)); got != "report copy summary memo unindexed " {
+		t.Errorf("lambda 0 should keep the order, got %s", got)
+	}
+	// Only the top 4 candidates are reordered.
+	if got := order(engine.diversify(ctx, engine.config(), results(), 0.4)); got != "report summary copy memo unindexed " {
+		t.Errorf("lambda 0.4: got %s", got)
+	}
+	if got := order(engine.diversify(ctx, engine.config(), results(), 0.8)); got != "report memo summary copy unindexed " {
+		t.Errorf("lambda 0.8: got %s", got)
+	}
+}
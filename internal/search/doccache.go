@@ -0,0 +1,145 @@
+package search
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// DocumentCache is an LRU cache of stored documents keyed by ID, so response assembly,
+// reranking, and ranking do not re-read the same documents (title, source path, mtime,
+// size, and content) from storage on every query. The indexer invalidates entries when
+// documents change.
+type DocumentCache struct {
+	capacity int
+	entries  map[string]*list.Element
+	lru      *list.List
+	// gen is bumped on every invalidation; a load that started before an invalidation
+	// is not cached, so a concurrent re-index cannot leave a stale entry behind.
+	gen uint64
+	mu  sync.Mutex
+}
+
+// NewDocumentCache creates a cache holding up to capacity documents.
+func NewDocumentCache(capacity int) *DocumentCache {
+	return &DocumentCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// Get returns the cached document for id if present.
+func (c *DocumentCache) Get(id string) (*models.Document, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[id]; ok {
+		c.lru.MoveToFront(elem)
+		return elem.Value.(*models.Document), true
+	}
+	return nil, false
+}
+
+// generation returns the current invalidation generation.
+func (c *DocumentCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// add stores doc unless the cache was invalidated since gen was read.
+func (c *DocumentCache) add(doc *models.Document, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	if elem, ok := c.entries[doc.ID]; ok {
+		c.lru.MoveToFront(elem)
+		elem.Value = doc
+		return
+	}
+	c.entries[doc.ID] = c.lru.PushFront(doc)
+	if c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*models.Document).ID)
+	}
+}
+
+// Invalidate drops the document with the given ID.
+func (c *DocumentCache) Invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	if elem, ok := c.entries[id]; ok {
+		c.lru.Remove(elem)
+		delete(c.entries, id)
+	}
+}
+
+// InvalidateAll drops every cached document.
+func (c *DocumentCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// Len returns the number of cached documents.
+func (c *DocumentCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// WithDocumentCache enables caching of up to capacity documents. A capacity <= 0 disables it.
+func (e *Engine) WithDocumentCache(capacity int) *Engine {
+	e.docCache = nil
+	if capacity > 0 {
+		e.docCache = NewDocumentCache(capacity)
+	}
+	return e
+}
+
+// InvalidateDocument drops id from the document cache and empties the vector cache.
+// Called by the indexer after a document is indexed or deleted.
+func (e *Engine) InvalidateDocument(id string) {
+	if e.docCache != nil {
+		e.docCache.Invalidate(id)
+	}
+	if e.vectorCache != nil {
+		e.vectorCache.InvalidateAll()
+	}
+}
+
+// InvalidateAllDocuments empties the document and vector caches. Called by the indexer
+// when all indexes are rebuilt.
+func (e *Engine) InvalidateAllDocuments() {
+	if e.docCache != nil {
+		e.docCache.InvalidateAll()
+	}
+	if e.vectorCache != nil {
+		e.vectorCache.InvalidateAll()
+	}
+}
+
+// getDocument returns the document from the cache, loading it from storage on a miss.
+func (e *Engine) getDocument(ctx context.Context, id string) (*models.Document, error) {
+	if e.docCache == nil {
+		return e.storage.GetDocument(ctx, id)
+	}
+	if doc, ok := e.docCache.Get(id); ok {
+		return doc, nil
+	}
+	gen := e.docCache.generation()
+	doc, err := e.storage.GetDocument(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	e.docCache.add(doc, gen)
+	return doc, nil
+}
@@ -0,0 +1,93 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestDocumentCache_LRU(t *testing.T) {
+	c := NewDocumentCache(2)
+	gen := c.generation()
+	c.add(&models.Document{ID: "a"}, gen)
+	c.add(&models.Document{ID: "b"}, gen)
+	c.Get("a")
+	c.add(&models.Document{ID: "c"}, gen) // evicts b
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to remain")
+	}
+	c.Invalidate("a")
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to be invalidated")
+	}
+	c.InvalidateAll()
+	if c.Len() != 0 {
+		t.Errorf("Len after InvalidateAll: got %d", c.Len())
+	}
+}
+
+func TestDocumentCache_staleLoadNotCached(t *testing.T) {
+	c := NewDocumentCache(10)
+	gen := c.generation()
+	c.Invalidate("a") // document changed while it was being loaded
+	c.add(&models.Document{ID: "a", Title: "old"}, gen)
+	if _, ok := c.Get("a"); ok {
+		t.Error("load started before invalidation should not be cached")
+	}
+}
+
+func TestEngine_DocumentCache_invalidatedByIndexer(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg).WithDocumentCache(10)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil, indexer.WithInvalidator(engine))
+
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "d1", Title: "first", Content: "alpha"}); err != nil {
+		t.Fatal(err)
+	}
+	search := func() *models.SearchResponse {
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: "alpha", Limit: 5, KeywordEnabled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	if resp := search(); len(resp.NonSemanticResults) != 1 || resp.NonSemanticResults[0].Document.Title != "first" {
+		t.Fatalf("first search: got %+v", resp.NonSemanticResults)
+	}
+	if engine.docCache.Len() != 1 {
+		t.Fatalf("expected document to be cached, Len=%d", engine.docCache.Len())
+	}
+
+	if err := idx.DeleteDocument(ctx, "d1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "d1", Title: "second", Content: "alpha"}); err != nil {
+		t.Fatal(err)
+	}
+	if resp := search(); len(resp.NonSemanticResults) != 1 || resp.NonSemanticResults[0].Document.Title != "second" {
+		t.Errorf("search after re-index should see new title, got %+v", resp.NonSemanticResults[0].Document)
+	}
+}
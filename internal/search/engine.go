@@ -5,7 +5,8 @@ import (
 	"context"
 	"fmt"
 	"sort"
-	"sync"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/hyperjump/sagasu/internal/config"
@@ -23,10 +24,37 @@ type Engine struct {
 	embedder      embedding.Embedder
 	vectorIndex   vector.VectorIndex
 	keywordIndex  keyword.KeywordIndex
-	config        *config.SearchConfig
+	cfg           atomic.Pointer[config.SearchConfig] // replaced by SetConfig
 	ranker        *ranking.Ranker
 	rankingConfig *config.RankingConfig
 	spellChecker  *keyword.SpellChecker
+	latency       *latencyTracker
+	reranker      Reranker
+	docCache      *DocumentCache  // optional; when set, documents are served from memory
+	vectorCache   *VectorCache    // optional; when set, vector results are reused across queries
+	extraSpaces   []semanticSpace // collection embedding models searched alongside the default
+	synonyms      Synonyms        // optional; expands keyword queries and, if configured, embedded ones
+	snapshots     snapshotCache
+}
+
+// semanticSpace is an embedding model and the vector index of the chunks it embedded.
+type semanticSpace struct {
+	embedder    embedding.Embedder
+	vectorIndex vector.VectorIndex
+	extensions  []string // files the model embeds; empty means any
+}
+
+// accepts reports whether the space can hold documents with one of exts (any when empty).
+func (sp *semanticSpace) accepts(exts []string) bool {
+	if len(sp.extensions) == 0 || len(exts) == 0 {
+		return true
+	}
+	for _, ext := range exts {
+		if equalsAny(ext, sp.extensions) {
+			return true
+		}
+	}
+	return false
 }
 
 // NewEngine creates a search engine with the given dependencies.
@@ -37,33 +65,84 @@ func NewEngine(
 	keywordIndex keyword.KeywordIndex,
 	cfg *config.SearchConfig,
 ) *Engine {
-	return &Engine{
+	e := &Engine{
 		storage:      storage,
 		embedder:     embedder,
 		vectorIndex:  vectorIndex,
 		keywordIndex: keywordIndex,
-		config:       cfg,
+		latency:      newLatencyTracker(),
 	}
+	e.cfg.Store(cfg)
+	return e
+}
+
+// config returns the search config in effect.
+func (e *Engine) config() *config.SearchConfig {
+	return e.cfg.Load()
+}
+
+// SetConfig replaces the search config, e.g. after PATCH /api/v1/config. Searches already
+// running finish with the old one. cfg must not be changed afterwards; settings fixed
+// when the engine was set up, such as the reranker, synonyms, and caches, are not affected.
+func (e *Engine) SetConfig(cfg *config.SearchConfig) {
+	e.cfg.Store(cfg)
 }
 
 // WithRanking enables content-aware ranking with the given configuration.
 func (e *Engine) WithRanking(cfg *config.RankingConfig) *Engine {
 	e.rankingConfig = cfg
 	if cfg != nil {
-		e.ranker = ranking.NewRanker(configToRankingConfig(cfg))
+		e.ranker = ranking.NewRanker(configToRankingConfig(cfg)).WithStopwords(e.config().Stopwords)
 	}
 	return e
 }
 
+// WithSemanticIndex adds a vector index whose chunks were embedded by embedder (e.g. a
+// collection with its own model). Semantic search embeds the query with each model and
+// merges the hits of every index.
+func (e *Engine) WithSemanticIndex(embedder embedding.Embedder, vectorIndex vector.VectorIndex) *Engine {
+	e.extraSpaces = append(e.extraSpaces, semanticSpace{embedder: embedder, vectorIndex: vectorIndex})
+	return e
+}
+
+// WithSemanticIndexFor is WithSemanticIndex for a model that only embeds files with the
+// given extensions (lower case, without the dot). Queries filtered to other extensions
+// skip it.
+func (e *Engine) WithSemanticIndexFor(extensions []string, embedder embedding.Embedder, vectorIndex vector.VectorIndex) *Engine {
+	e.extraSpaces = append(e.extraSpaces, semanticSpace{embedder: embedder, vectorIndex: vectorIndex, extensions: extensions})
+	return e
+}
+
+// WithSynonyms expands queries with the synonyms of their terms: keyword search also
+// matches the synonyms, and with search.synonyms_in_embeddings the embedded text includes
+// them.
+func (e *Engine) WithSynonyms(synonyms Synonyms) *Engine {
+	e.synonyms = synonyms
+	return e
+}
+
+// semanticQueryText returns the text semantic search embeds for queryText: its
+// non-negated, unscoped terms, plus their synonyms when search.synonyms_in_embeddings is set.
+func (e *Engine) semanticQueryText(cfg *config.SearchConfig, queryText string) string {
+	text := keyword.PositiveQueryText(queryText)
+	if cfg.SynonymsInEmbeddings && len(e.synonyms) > 0 && strings.TrimSpace(text) != "" {
+		text = e.synonyms.Blend(text)
+	}
+	return text
+}
+
 // WithSpellChecker enables spell checking for "Did you mean?" suggestions.
-// The keywordIndex must implement the TermDictionary interface.
+// The keywordIndex must implement the TermDictionary interface. Protected terms and
+// stopwords from the search config are never corrected.
 func (e *Engine) WithSpellChecker() *Engine {
 	// Check if keywordIndex implements TermDictionary
 	if dict, ok := e.keywordIndex.(keyword.TermDictionary); ok {
+		known := append(append([]string(nil), e.config().ProtectedTerms...), e.config().Stopwords...)
 		e.spellChecker = keyword.NewSpellChecker(dict,
 			keyword.WithMaxDistance(2),
 			keyword.WithMinFrequency(1),
 			keyword.WithMaxSuggestions(3),
+			keyword.WithKnownTerms(known),
 		)
 	}
 	return e
@@ -100,6 +179,7 @@ func configToRankingConfig(cfg *config.RankingConfig) *ranking.RankingConfig {
 		AllWordsContentScore:    cfg.AllWordsContentScore,
 		ScatteredWordsScore:     cfg.ScatteredWordsScore,
 		StemmingMatchScore:      cfg.StemmingMatchScore,
+		ProximityMatchScore:     cfg.ProximityMatchScore,
 		PathExactMatchScore:     cfg.PathExactMatchScore,
 		PathPartialMatchScore:   cfg.PathPartialMatchScore,
 		PathComponentBonus:      cfg.PathComponentBonus,
@@ -139,89 +219,254 @@ func (e *Engine) UpdateCorpusStats(ctx context.Context) error {
 	return nil
 }
 
+// keywordOptions returns the keyword search options for query: its overrides, falling
+// back to cfg.
+func (e *Engine) keywordOptions(cfg *config.SearchConfig, query *models.SearchQuery) *keyword.SearchOptions {
+	opts := &keyword.SearchOptions{
+		TitleBoost:   cfg.KeywordTitleBoost,
+		PhraseBoost:  cfg.KeywordPhraseBoost,
+		PhraseSlop:   cfg.KeywordPhraseSlop,
+		FuzzyEnabled: query.FuzzyEnabled,
+		Fuzziness:    cfg.KeywordFuzziness,
+	}
+	if query.TitleBoost > 0 {
+		opts.TitleBoost = query.TitleBoost
+	}
+	if query.PhraseBoost > 0 {
+		opts.PhraseBoost = query.PhraseBoost
+	}
+	if query.PhraseSlop != nil {
+		opts.PhraseSlop = *query.PhraseSlop
+	}
+	if query.Fuzziness > 0 {
+		opts.Fuzziness = query.Fuzziness
+	}
+	exponent := cfg.CoverageExponentOrDefault()
+	if query.CoverageExponent != nil {
+		exponent = *query.CoverageExponent
+	}
+	opts.CoverageExponent = &exponent
+	opts.Synonyms = e.synonyms
+	opts.Fields = query.Fields
+	opts.NoStems = cfg.StemmedFallback
+	if query.Mode != models.QueryModeLiteral {
+		opts.Pattern = query.Mode
+	}
+	return opts
+}
+
 // Search runs hybrid search and returns document-level results.
 func (e *Engine) Search(ctx context.Context, query *models.SearchQuery) (*models.SearchResponse, error) {
+	return e.search(ctx, query, nil)
+}
+
+// SearchStream is Search that calls partial with a response holding only the keyword
+// results as soon as keyword search is done, when semantic search is still running. The
+// returned response is complete and supersedes it: pins and duplicate removal can move
+// results between the lists once both are known. partial is not called when keyword search
+// finishes last or does not run.
+func (e *Engine) SearchStream(ctx context.Context, query *models.SearchQuery, partial func(*models.SearchResponse)) (*models.SearchResponse, error) {
+	return e.search(ctx, query, partial)
+}
+
+// search runs Search, reporting the keyword results to partial early when it is non-nil.
+// The whole search runs with the config in effect when it starts (see SetConfig).
+func (e *Engine) search(ctx context.Context, query *models.SearchQuery, partial func(*models.SearchResponse)) (*models.SearchResponse, error) {
 	startTime := time.Now()
+	cfg := e.config()
 	if err := ProcessQuery(query); err != nil {
 		return nil, err
 	}
+	if query.AsOf != nil {
+		return e.searchAsOf(ctx, cfg, query, startTime)
+	}
 
-	var (
-		keywordResults  []*keyword.KeywordResult
-		semanticResults []*vector.VectorResult
-		errChan         = make(chan error, 2)
-		wg              sync.WaitGroup
-	)
+	// path: and ext: filters are applied to candidates below with the query's metadata
+	// filters; title: terms stay in the text for the keyword index.
+	queryText, scope := e.queryScope(query)
+	filter := newDocFilter(query, scope)
+	if err := e.loadSnapshot(ctx, filter); err != nil {
+		return nil, err
+	}
+	candidates := cfg.TopKCandidates
+	if filter != nil {
+		candidates *= filterCandidateFactor
+	}
 
-	if query.KeywordEnabled {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			kwOpts := &keyword.SearchOptions{
-				TitleBoost:   e.config.KeywordTitleBoost,
-				PhraseBoost:  e.config.KeywordPhraseBoost,
-				FuzzyEnabled: query.FuzzyEnabled,
-				Fuzziness:    2, // default fuzziness level
+	var branches []branchRun
+	if query.KeywordEnabled && strings.TrimSpace(queryText) != "" {
+		branches = append(branches, branchRun{name: branchKeyword, run: func(ctx context.Context) branchResult {
+			if query.Mode == models.QueryModeLiteral {
+				results, err := e.literalResults(ctx, query, e.keywordOptions(cfg, query).TitleBoost)
+				if err != nil {
+					return branchResult{err: fmt.Errorf("literal search failed: %w", err)}
+				}
+				return branchResult{keyword: results[:min(len(results), candidates)]}
 			}
-			results, err := e.keywordIndex.Search(ctx, query.Query, e.config.TopKCandidates, kwOpts)
+			opts := e.keywordOptions(cfg, query)
+			results, err := e.keywordIndex.Search(ctx, queryText, candidates, opts)
 			if err != nil {
-				errChan <- fmt.Errorf("keyword search failed: %w", err)
-				return
+				return branchResult{err: fmt.Errorf("keyword search failed: %w", err)}
+			}
+			if len(results) > 0 || !e.stemmedFallback(query, opts) {
+				return branchResult{keyword: results}
 			}
-			keywordResults = results
-		}()
+			opts.NoStems = false
+			results, err = e.keywordIndex.Search(ctx, queryText, candidates, opts)
+			if err != nil {
+				return branchResult{err: fmt.Errorf("stemmed keyword search failed: %w", err)}
+			}
+			return branchResult{keyword: results, stemmed: len(results) > 0}
+		}})
 	}
 
-	if query.SemanticEnabled {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			queryEmbedding, err := e.embedder.Embed(ctx, query.Query)
+	// Boolean queries embed only their non-negated, unscoped terms; a query without such
+	// terms has nothing to embed and skips semantic search.
+	semanticText := e.semanticQueryText(cfg, queryText)
+	if query.SemanticEnabled && strings.TrimSpace(semanticText) != "" {
+		branches = append(branches, branchRun{name: branchSemantic, run: func(ctx context.Context) branchResult {
+			results, err := e.searchChunks(ctx, semanticText, candidates, filter)
 			if err != nil {
-				errChan <- fmt.Errorf("embedding failed: %w", err)
-				return
+				return branchResult{err: err}
 			}
-			results, err := e.vectorIndex.Search(ctx, queryEmbedding, e.config.TopKCandidates)
-			if err != nil {
-				errChan <- fmt.Errorf("vector search failed: %w", err)
+			return branchResult{semantic: results}
+		}})
+	}
+
+	var ready func(branchResult)
+	if partial != nil {
+		ready = func(r branchResult) {
+			if r.name != branchKeyword {
 				return
 			}
-			semanticResults = results
-		}()
+			if resp, err := e.respond(ctx, cfg, query, queryText, filter, startTime, []branchResult{r}, nil); err == nil {
+				partial(resp)
+			}
+		}
 	}
+	branchResults, timedOut, err := e.runBranches(ctx, cfg, branches, ready)
+	if err != nil {
+		return nil, err
+	}
+	return e.respond(ctx, cfg, query, queryText, filter, startTime, branchResults, timedOut)
+}
 
-	wg.Wait()
-	close(errChan)
-	for err := range errChan {
-		if err != nil {
-			return nil, err
+// respond fuses the results of the finished branches into the response to query: it
+// aggregates, filters, reranks, pins, dedupes, pages, and loads the result documents.
+func (e *Engine) respond(ctx context.Context, cfg *config.SearchConfig, query *models.SearchQuery, queryText string, filter *docFilter, startTime time.Time, branchResults []branchResult, timedOut []string) (*models.SearchResponse, error) {
+	var (
+		keywordResults  []*keyword.KeywordResult
+		semanticResults []*vector.VectorResult
+		stemmed         bool
+	)
+	for _, r := range branchResults {
+		switch r.name {
+		case branchKeyword:
+			keywordResults = r.keyword
+			stemmed = r.stemmed
+		case branchSemantic:
+			semanticResults = r.semantic
 		}
 	}
 
 	keywordScores := NormalizeKeywordScores(keywordResults)
+	keywordChunks := make(map[string]*int)
+	for _, r := range keywordResults {
+		if r.Chunk != nil {
+			keywordChunks[r.ID] = r.Chunk
+		}
+	}
 	semanticByChunk := NormalizeSemanticScores(semanticResults)
 	chunkToDoc := make(map[string]string)
+	chunks := make(map[string]SemanticChunk)
 	for _, r := range semanticResults {
 		chunk, err := e.storage.GetChunk(ctx, r.ID)
 		if err != nil {
 			continue
 		}
 		chunkToDoc[r.ID] = chunk.DocumentID
+		chunks[r.ID] = SemanticChunk{DocumentID: chunk.DocumentID, Index: chunk.ChunkIndex}
+	}
+	var semanticByDoc map[string]float64
+	if cfg.SemanticAggregation == "decay" {
+		semanticByDoc = AggregateSemanticWithDecay(chunks, semanticByChunk, cfg.SemanticAggregationDecay)
+	} else {
+		semanticByDoc = AggregateSemanticByDocument(chunkToDoc, semanticByChunk)
+	}
+	if err := e.excludeNegated(ctx, queryText, semanticByDoc); err != nil {
+		return nil, err
 	}
-	semanticByDoc := AggregateSemanticByDocument(chunkToDoc, semanticByChunk)
+	if err := e.restrictToScoped(ctx, queryText, semanticByDoc); err != nil {
+		return nil, err
+	}
+	// With fusion, the fused list takes the place of the keyword list and the semantic
+	// list stays empty.
 	nonSemanticFused, semanticFused := SplitBySource(keywordScores, semanticByDoc)
+	if query.Fusion != "" {
+		nonSemanticFused, semanticFused = e.fuse(cfg, query, keywordScores, semanticByDoc), nil
+	}
+	if filter != nil {
+		nonSemanticFused = e.filterDocuments(ctx, nonSemanticFused, filter)
+		semanticFused = e.filterDocuments(ctx, semanticFused, filter)
+	}
 
-	minKeywordScore := resolveMinKeywordScore(query, e.config)
-	minSemanticScore := resolveMinSemanticScore(query, e.config)
-	if minKeywordScore > 0 {
-		nonSemanticFused = filterByMinScore(nonSemanticFused, minKeywordScore)
+	minKeywordScore := resolveMinKeywordScore(query, cfg)
+	minSemanticScore := resolveMinSemanticScore(query, cfg)
+	if query.Fusion != "" {
+		nonSemanticFused = filterFusedByMinScores(nonSemanticFused, minKeywordScore, minSemanticScore)
+	} else {
+		if minKeywordScore > 0 {
+			nonSemanticFused = filterByMinScore(nonSemanticFused, minKeywordScore)
+		}
+		if minSemanticScore > 0 {
+			semanticFused = filterByMinScore(semanticFused, minSemanticScore)
+		}
+	}
+
+	// A field sort replaces relevance order, so the reranker, diversification, pins and
+	// content ranker are skipped. So are the reranker and content ranker for patterns, which are not text.
+	// The content ranker is also skipped when the reranker or diversification ordered the
+	// results, since re-sorting by its score would undo them.
+	var pinned map[string]int
+	reordered := false
+	if query.SortsByField() {
+		nonSemanticFused = e.sortByField(ctx, nonSemanticFused, query)
+		semanticFused = e.sortByField(ctx, semanticFused, query)
+	} else {
+		if !query.IsPattern() && e.reranker != nil {
+			nonSemanticFused = e.rerankCandidates(ctx, cfg, queryText, nonSemanticFused)
+			semanticFused = e.rerankCandidates(ctx, cfg, queryText, semanticFused)
+			reordered = true
+		}
+		if lambda := e.diversityLambda(cfg, query); lambda > 0 {
+			nonSemanticFused = e.diversify(ctx, cfg, nonSemanticFused, lambda)
+			semanticFused = e.diversify(ctx, cfg, semanticFused, lambda)
+			reordered = true
+		}
+		pins, err := e.matchingPins(ctx, queryText)
+		if err != nil {
+			return nil, err
+		}
+		nonSemanticFused, semanticFused, pinned = e.applyPins(ctx, pins, nonSemanticFused, semanticFused, filter)
 	}
-	if minSemanticScore > 0 {
-		semanticFused = filterByMinScore(semanticFused, minSemanticScore)
+	var copies map[string][]string
+	if e.dedupeEnabled(cfg, query) {
+		nonSemanticFused, semanticFused, copies = e.dedupeResults(ctx, cfg, nonSemanticFused, semanticFused)
 	}
 
+	cal := e.calibration(cfg, query, queryText, len(keywordResults))
 	totalNonSemantic := len(nonSemanticFused)
 	totalSemantic := len(semanticFused)
+	if query.Fusion != "" {
+		totalNonSemantic, totalSemantic = 0, 0
+		for _, r := range nonSemanticFused {
+			if r.KeywordScore > 0 {
+				totalNonSemantic++
+			} else {
+				totalSemantic++
+			}
+		}
+	}
 	nonSemanticPaged := pageResults(nonSemanticFused, query.Offset, query.Limit)
 	semanticPaged := pageResults(semanticFused, query.Offset, query.Limit)
 
@@ -232,12 +477,16 @@ func (e *Engine) Search(ctx context.Context, query *models.SearchQuery) (*models
 		TotalSemantic:      totalSemantic,
 		QueryTime:          time.Since(startTime).Milliseconds(),
 		Query:              query.Query,
+		TimedOut:           timedOut,
+		Stemmed:            stemmed,
+		Confidence:         cal.best(nonSemanticFused, semanticFused),
+		Calibration:        &cal.Calibration,
 	}
 
 	// Collect documents for potential re-ranking
 	var nonSemanticDocs []*models.SearchResult
 	for _, r := range nonSemanticPaged {
-		doc, err := e.storage.GetDocument(ctx, r.DocumentID)
+		doc, err := e.getDocument(ctx, r.DocumentID)
 		if err != nil {
 			continue
 		}
@@ -246,12 +495,15 @@ func (e *Engine) Search(ctx context.Context, query *models.SearchQuery) (*models
 			Score:         r.Score,
 			KeywordScore:  r.KeywordScore,
 			SemanticScore: r.SemanticScore,
+			AlsoFoundAt:   copies[r.DocumentID],
+			KeywordChunk:  keywordChunks[r.DocumentID],
+			Confidence:    cal.confidence(r.KeywordScore, r.SemanticScore),
 		})
 	}
 
 	var semanticDocs []*models.SearchResult
 	for _, r := range semanticPaged {
-		doc, err := e.storage.GetDocument(ctx, r.DocumentID)
+		doc, err := e.getDocument(ctx, r.DocumentID)
 		if err != nil {
 			continue
 		}
@@ -260,13 +512,22 @@ func (e *Engine) Search(ctx context.Context, query *models.SearchQuery) (*models
 			Score:         r.Score,
 			KeywordScore:  r.KeywordScore,
 			SemanticScore: r.SemanticScore,
+			AlsoFoundAt:   copies[r.DocumentID],
+			KeywordChunk:  keywordChunks[r.DocumentID],
+			Confidence:    cal.confidence(r.KeywordScore, r.SemanticScore),
 		})
 	}
 
-	// Apply content-aware re-ranking if enabled
-	if e.ranker != nil && e.config.RankingEnabled {
-		nonSemanticDocs = e.reRankResults(query.Query, nonSemanticDocs)
-		semanticDocs = e.reRankResults(query.Query, semanticDocs)
+	// Apply content-aware re-ranking if enabled, unless the reranker or diversification ordered the results
+	if e.ranker != nil && cfg.RankingEnabled && !reordered && !query.SortsByField() && !query.IsPattern() {
+		nonSemanticDocs = e.reRankResults(queryText, nonSemanticDocs)
+		semanticDocs = e.reRankResults(queryText, semanticDocs)
+	}
+	nonSemanticDocs = pinResults(nonSemanticDocs, pinned)
+	semanticDocs = pinResults(semanticDocs, pinned)
+	if query.Chunks > 0 {
+		e.attachChunks(ctx, nonSemanticDocs, semanticByChunk, queryText, query.Chunks)
+		e.attachChunks(ctx, semanticDocs, semanticByChunk, queryText, query.Chunks)
 	}
 
 	// Assign final ranks
@@ -277,20 +538,146 @@ func (e *Engine) Search(ctx context.Context, query *models.SearchQuery) (*models
 		semanticDocs[i].Rank = i + 1
 	}
 
-	response.NonSemanticResults = nonSemanticDocs
-	response.SemanticResults = semanticDocs
+	if query.Fusion != "" {
+		response.Results, response.Fusion = nonSemanticDocs, query.Fusion
+	} else {
+		response.NonSemanticResults = nonSemanticDocs
+		response.SemanticResults = semanticDocs
+	}
 
-	// Add spell check suggestions if fuzzy is enabled and spell checker is available
-	if query.FuzzyEnabled && e.spellChecker != nil {
+	// Add spell check suggestions if fuzzy is enabled (or nothing matched and suggestions
+	// on zero results are not turned off) and spell checker is available
+	noResults := response.TotalNonSemantic == 0 && response.TotalSemantic == 0
+	if (query.FuzzyEnabled || (noResults && cfg.SuggestOnZeroResultsOrDefault())) && e.spellChecker != nil && !query.IsPattern() {
 		suggestions := e.spellChecker.GetTopSuggestions(query.Query, 3)
 		if len(suggestions) > 0 {
 			response.Suggestions = suggestions
+			response.CorrectedQuery = suggestions[0]
 		}
 	}
 
 	return response, nil
 }
 
+// stemmedFallback reports whether a keyword search with opts that found nothing is
+// retried with the stemmed fields: with search.stemmed_fallback, for a query of words,
+// when the keyword index has them.
+func (e *Engine) stemmedFallback(query *models.SearchQuery, opts *keyword.SearchOptions) bool {
+	if !opts.NoStems || query.IsPattern() {
+		return false
+	}
+	s, ok := e.keywordIndex.(keyword.StemmedIndex)
+	return ok && s.HasStems()
+}
+
+// queryScope splits the path: and ext: scopes off the query text (see parseScopeFilters).
+// A wildcard, regex, or literal query is used as it is.
+func (e *Engine) queryScope(query *models.SearchQuery) (string, *scopeFilter) {
+	if query.IsPattern() {
+		return query.Query, nil
+	}
+	return parseScopeFilters(query.Query)
+}
+
+// searchChunks returns the k nearest chunks to text in each semantic space whose files
+// the filter's extensions can include.
+func (e *Engine) searchChunks(ctx context.Context, text string, k int, filter *docFilter) ([]*vector.VectorResult, error) {
+	spaces := append([]semanticSpace{{embedder: e.embedder, vectorIndex: e.vectorIndex}}, e.extraSpaces...)
+	var results []*vector.VectorResult
+	for i, sp := range spaces {
+		if filter != nil && !sp.accepts(filter.exts) {
+			continue
+		}
+		hits, err := e.searchSpace(ctx, i, &sp, text, k)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, hits...)
+	}
+	return results, nil
+}
+
+// searchSpace returns the k nearest chunks to text in the semantic space at index space,
+// from the vector cache when it holds them.
+func (e *Engine) searchSpace(ctx context.Context, space int, sp *semanticSpace, text string, k int) ([]*vector.VectorResult, error) {
+	var gen uint64
+	if e.vectorCache != nil {
+		if hits, ok := e.vectorCache.getText(space, text, k); ok {
+			return hits, nil
+		}
+		gen = e.vectorCache.generation()
+	}
+	queryEmbedding, err := sp.embedder.Embed(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	if e.vectorCache != nil {
+		if hits, ok := e.vectorCache.getSimilar(space, queryEmbedding, k); ok {
+			return hits, nil
+		}
+	}
+	hits, err := sp.vectorIndex.Search(ctx, queryEmbedding, k)
+	if err != nil {
+		return nil, fmt.Errorf("vector search failed: %w", err)
+	}
+	if e.vectorCache != nil {
+		e.vectorCache.add(space, text, queryEmbedding, k, hits, gen)
+	}
+	return hits, nil
+}
+
+// excludeNegated removes documents matching a NOT clause of a boolean query from the
+// semantic scores, so negation applies to both result lists. Keyword results already
+// exclude them.
+func (e *Engine) excludeNegated(ctx context.Context, queryStr string, semanticByDoc map[string]float64) error {
+	if len(semanticByDoc) == 0 || !keyword.HasNegation(queryStr) {
+		return nil
+	}
+	matcher, ok := e.keywordIndex.(keyword.NegationMatcher)
+	if !ok {
+		return nil
+	}
+	ids := make([]string, 0, len(semanticByDoc))
+	for id := range semanticByDoc {
+		ids = append(ids, id)
+	}
+	negated, err := matcher.MatchNegated(ctx, queryStr, ids)
+	if err != nil {
+		return fmt.Errorf("negation filter failed: %w", err)
+	}
+	for id := range negated {
+		delete(semanticByDoc, id)
+	}
+	return nil
+}
+
+// restrictToScoped removes documents that do not satisfy the field-scoped terms of the
+// query (e.g. title:budget) from the semantic scores, so the scope applies to both result
+// lists. Keyword results already satisfy it.
+func (e *Engine) restrictToScoped(ctx context.Context, queryStr string, semanticByDoc map[string]float64) error {
+	if len(semanticByDoc) == 0 || !keyword.HasFieldScope(queryStr) {
+		return nil
+	}
+	matcher, ok := e.keywordIndex.(keyword.ScopeMatcher)
+	if !ok {
+		return nil
+	}
+	ids := make([]string, 0, len(semanticByDoc))
+	for id := range semanticByDoc {
+		ids = append(ids, id)
+	}
+	matched, err := matcher.MatchScoped(ctx, queryStr, ids)
+	if err != nil {
+		return fmt.Errorf("field scope filter failed: %w", err)
+	}
+	for id := range semanticByDoc {
+		if !matched[id] {
+			delete(semanticByDoc, id)
+		}
+	}
+	return nil
+}
+
 // reRankResults re-ranks search results using the content-aware ranker.
 func (e *Engine) reRankResults(queryStr string, results []*models.SearchResult) []*models.SearchResult {
 	if e.ranker == nil || len(results) == 0 {
@@ -349,6 +736,29 @@ func filterByMinScore(results []*FusedResult, minScore float64) []*FusedResult {
 	return filtered
 }
 
+// filterFusedByMinScores keeps the fused results whose keyword score is at least
+// minKeywordScore or whose semantic score is at least minSemanticScore, counting only
+// the searches that found them.
+func filterFusedByMinScores(results []*FusedResult, minKeywordScore, minSemanticScore float64) []*FusedResult {
+	filtered := results[:0]
+	for _, r := range results {
+		if (r.KeywordScore > 0 && r.KeywordScore >= minKeywordScore) || (r.SemanticScore > 0 && r.SemanticScore >= minSemanticScore) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// fuse returns the keyword and semantic document scores fused by query's strategy, with
+// its weights or else those of cfg.
+func (e *Engine) fuse(cfg *config.SearchConfig, query *models.SearchQuery, keywordScores, semanticScores map[string]float64) []*FusedResult {
+	kw, sem := query.KeywordWeight, query.SemanticWeight
+	if kw == 0 && sem == 0 {
+		kw, sem = cfg.DefaultKeywordWeight, cfg.DefaultSemanticWeight
+	}
+	return FuseResults(query.Fusion, keywordScores, semanticScores, kw, sem, cfg.RRFK)
+}
+
 func pageResults(results []*FusedResult, offset, limit int) []*FusedResult {
 	start := offset
 	end := offset + limit
@@ -2,6 +2,7 @@ package search
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/hyperjump/sagasu/internal/config"
@@ -384,3 +385,382 @@ func TestEngine_RefreshSpellChecker_NilChecker(t *testing.T) {
 		t.Errorf("RefreshSpellChecker with nil checker should return nil, got %v", err)
 	}
 }
+
+func TestEngine_Search_NegationExcludesFromBothLists(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for id, content := range map[string]string{
+		"py":   "python tutorial",
+		"java": "java tutorial",
+		"misc": "cooking recipes",
+	} {
+		if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: id, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := engine.Search(ctx, &models.SearchQuery{
+		Query: "tutorial -java", Limit: 10, KeywordEnabled: true, SemanticEnabled: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range append(resp.NonSemanticResults, resp.SemanticResults...) {
+		if r.Document.ID == "java" {
+			t.Errorf("negated document returned: %+v", r.Document)
+		}
+	}
+	if len(resp.NonSemanticResults) != 1 || resp.NonSemanticResults[0].Document.ID != "py" {
+		t.Errorf("keyword results: got %d, want [py]", len(resp.NonSemanticResults))
+	}
+}
+
+func TestEngine_Search_SuggestOnZeroResults(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	emb := embedding.NewMockEmbedder(4)
+	defer emb.Close()
+
+	vecIndex, err := vector.NewMemoryIndex(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vecIndex.Close()
+
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	suggest := false
+	cfg := &config.SearchConfig{
+		TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10,
+		DefaultKeywordEnabled: true, DefaultSemanticEnabled: true,
+		SuggestOnZeroResults: &suggest,
+	}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg).WithSpellChecker()
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{
+		ID: "d1", Title: "Budget Proposal", Content: "The proposal for the budget was approved.",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.RefreshSpellChecker(); err != nil {
+		t.Fatal(err)
+	}
+
+	search := func(q string) *models.SearchResponse {
+		t.Helper()
+		resp, err := engine.Search(ctx, &models.SearchQuery{
+			Query: q, Limit: 5, KeywordEnabled: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	if resp := search("propodal"); len(resp.Suggestions) != 0 {
+		t.Errorf("suggestions without fuzzy or suggest_on_zero_results: %v", resp.Suggestions)
+	}
+
+	cfg.SuggestOnZeroResults = nil // on by default
+	resp := search("propodal")
+	if resp.TotalNonSemantic != 0 || resp.TotalSemantic != 0 {
+		t.Fatalf("results changed: %d keyword, %d semantic", resp.TotalNonSemantic, resp.TotalSemantic)
+	}
+	if len(resp.Suggestions) == 0 || resp.Suggestions[0] != "proposal" {
+		t.Errorf("suggestions = %v, want [proposal ...]", resp.Suggestions)
+	}
+	if resp.CorrectedQuery != "proposal" {
+		t.Errorf("corrected query = %q, want proposal", resp.CorrectedQuery)
+	}
+
+	if resp := search("proposal"); len(resp.Suggestions) != 0 {
+		t.Errorf("suggestions for a query with results: %v", resp.Suggestions)
+	}
+}
+
+func TestEngine_Search_collectionSemanticIndex(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	emb, codeEmb := embedding.NewMockEmbedder(4), embedding.NewMockEmbedder(8)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	codeVecIndex, _ := vector.NewMemoryIndex(8)
+
+	// Both documents have the same content, which would otherwise be deduplicated.
+	noDedupe := false
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10, DedupeEnabled: &noDedupe}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg).WithSemanticIndex(codeEmb, codeVecIndex)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil,
+		indexer.WithCollections(indexer.Collection{Name: "code", Root: "/src", Embedder: codeEmb, VectorIndex: codeVecIndex}))
+	for id, path := range map[string]string{"code": "/src/main.go", "doc": "/docs/a.txt"} {
+		if err := idx.IndexDocument(ctx, &models.DocumentInput{
+			ID: id, Content: "binary search tree", Metadata: map[string]interface{}{"source_path": path},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := engine.Search(ctx, &models.SearchQuery{Query: "binary search tree", Limit: 10, SemanticEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := map[string]bool{}
+	for _, r := range resp.SemanticResults {
+		found[r.Document.ID] = true
+	}
+	if !found["code"] || !found["doc"] {
+		t.Errorf("semantic results should include both indexes, got %v", found)
+	}
+}
+
+func TestEngine_keywordOptions(t *testing.T) {
+	half := 0.5
+	e := NewEngine(nil, nil, nil, nil, &config.SearchConfig{
+		KeywordTitleBoost: 3, KeywordPhraseBoost: 1.5, KeywordFuzziness: 2, KeywordCoverageExponent: &half,
+		KeywordPhraseSlop: 3,
+	})
+
+	opts := e.keywordOptions(e.config(), &models.SearchQuery{Query: "q", FuzzyEnabled: true})
+	if opts.TitleBoost != 3 || opts.PhraseBoost != 1.5 || opts.PhraseSlop != 3 || opts.Fuzziness != 2 || !opts.FuzzyEnabled || *opts.CoverageExponent != 0.5 {
+		t.Errorf("config defaults: %+v (coverage %v)", opts, *opts.CoverageExponent)
+	}
+
+	zero, strict := 0.0, 0
+	opts = e.keywordOptions(e.config(), &models.SearchQuery{Query: "q", TitleBoost: 5, PhraseBoost: 2, PhraseSlop: &strict, Fuzziness: 1, CoverageExponent: &zero})
+	if opts.TitleBoost != 5 || opts.PhraseBoost != 2 || opts.PhraseSlop != 0 || opts.Fuzziness != 1 || *opts.CoverageExponent != 0 {
+		t.Errorf("query overrides: %+v (coverage %v)", opts, *opts.CoverageExponent)
+	}
+}
+
+func TestEngine_Search_stemmedFallback(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir()+"/bleve", keyword.WithStemming("english", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 500, ChunkOverlap: 10, StemmedFallback: true}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for _, in := range []*models.DocumentInput{
+		{ID: "running", Title: "notes", Content: "Running every morning."},
+		{ID: "run", Title: "log", Content: "A short run today."},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(q string) *models.SearchResponse {
+		t.Helper()
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: q, Limit: 10, KeywordEnabled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	// Exact matches leave the stemmed fields out.
+	resp := search("running")
+	if got := resultIDs(resp.NonSemanticResults); len(got) != 1 || got[0] != "running" || resp.Stemmed {
+		t.Errorf("running: got %v (stemmed %v), want [running] as written", got, resp.Stemmed)
+	}
+	// A query matching nothing as written falls back to its stems and says so.
+	resp = search("runs")
+	if got := resultIDs(resp.NonSemanticResults); len(got) != 2 || !resp.Stemmed {
+		t.Errorf("runs: got %v (stemmed %v), want both documents from the stemmed pass", got, resp.Stemmed)
+	}
+	if n, err := engine.Count(ctx, &models.SearchQuery{Query: "runs", KeywordEnabled: true}); err != nil || n != 2 {
+		t.Errorf("Count(runs) = %d, %v; want 2", n, err)
+	}
+	if resp = search("walking"); resp.TotalNonSemantic != 0 || resp.Stemmed {
+		t.Errorf("walking: got %d results (stemmed %v), want none", resp.TotalNonSemantic, resp.Stemmed)
+	}
+}
+
+func TestEngine_Search_keywordChunk(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir()+"/bleve", keyword.WithChunks())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 20, ChunkOverlap: 0}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	filler := strings.Repeat("lorem ipsum dolor sit amet ", 8)
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "doc", Title: "report", Content: filler + "the zeppelin budget " + filler}); err != nil {
+		t.Fatal(err)
+	}
+	chunks, err := store.GetChunksByDocumentID(ctx, "doc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := -1
+	for _, ch := range chunks {
+		if strings.Contains(ch.Content, "zeppelin") {
+			want = ch.ChunkIndex
+		}
+	}
+	if want < 1 {
+		t.Fatalf("test document should have zeppelin in a later chunk, got chunk %d", want)
+	}
+
+	resp, err := engine.Search(ctx, &models.SearchQuery{Query: "zeppelin", Limit: 10, KeywordEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.NonSemanticResults) != 1 {
+		t.Fatalf("results = %d, want 1", len(resp.NonSemanticResults))
+	}
+	if got := resp.NonSemanticResults[0].KeywordChunk; got == nil || *got != want {
+		t.Errorf("keyword chunk = %v, want %d", got, want)
+	}
+}
+
+func TestEngine_Search_fusion(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 5, RRFK: 60}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for id, content := range map[string]string{"zep": "the zeppelin budget", "other": "quarterly garden notes"} {
+		if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: id, Title: id, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, fusion := range []string{models.FusionRRF, models.FusionWeighted, models.FusionMax} {
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: "zeppelin", Limit: 10, KeywordEnabled: true, SemanticEnabled: true, Fusion: fusion})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Fusion != fusion || len(resp.NonSemanticResults) != 0 || len(resp.SemanticResults) != 0 {
+			t.Fatalf("%s: fusion = %q, lists = %d/%d", fusion, resp.Fusion, len(resp.NonSemanticResults), len(resp.SemanticResults))
+		}
+		if len(resp.Results) == 0 || resp.Results[0].Document.ID != "zep" {
+			t.Fatalf("%s: results = %+v", fusion, resp.Results)
+		}
+		if got := resp.TotalNonSemantic + resp.TotalSemantic; got != len(resp.Results) {
+			t.Errorf("%s: totals = %d, want %d", fusion, got, len(resp.Results))
+		}
+		seen := map[string]bool{}
+		for _, r := range resp.Results {
+			if seen[r.Document.ID] {
+				t.Errorf("%s: %s listed twice", fusion, r.Document.ID)
+			}
+			seen[r.Document.ID] = true
+		}
+	}
+}
+
+// swappingIndex is a keyword index that calls swap before each search.
+type swappingIndex struct {
+	keyword.KeywordIndex
+	swap func()
+}
+
+func (s *swappingIndex) Search(ctx context.Context, query string, limit int, opts *keyword.SearchOptions) ([]*keyword.KeywordResult, error) {
+	s.swap()
+	return s.KeywordIndex.Search(ctx, query, limit, opts)
+}
+
+func TestEngine_Search_keepsConfigOfRunningSearch(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 5}
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "zep", Title: "zep", Content: "the zeppelin budget"}); err != nil {
+		t.Fatal(err)
+	}
+	// The config is replaced while the keyword search runs by one whose minimum
+	// score drops every result.
+	strict := &config.SearchConfig{TopKCandidates: 20, DefaultMinKeywordScore: 2}
+	var engine *Engine
+	engine = NewEngine(store, emb, vecIndex, &swappingIndex{kwIndex, func() { engine.SetConfig(strict) }}, cfg)
+
+	query := func() int {
+		t.Helper()
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: "zeppelin", Limit: 10, KeywordEnabled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(resp.NonSemanticResults)
+	}
+	if got := query(); got != 1 {
+		t.Errorf("search running when the config changed: results = %d, want 1", got)
+	}
+	if got := query(); got != 0 {
+		t.Errorf("search started after the change: results = %d, want 0", got)
+	}
+}
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// Explain returns how Search interprets query, without running it: the terms, phrases,
+// negations and operators the keyword index matches, the path:, ext: and title: scopes
+// and the query's own filters, the text embedded for semantic search, and, from the
+// indexed terms, the fuzzy expansions (when query.FuzzyEnabled) and spelling corrections.
+// Synonyms of the terms are listed when the engine has a synonym dictionary. A wildcard,
+// regex, or literal query is not parsed; only its mode, fields and filters are listed.
+func (e *Engine) Explain(query *models.SearchQuery) (*models.QueryExplanation, error) {
+	if err := ProcessQuery(query); err != nil {
+		return nil, err
+	}
+	queryText, scope := e.queryScope(query)
+	if query.IsPattern() {
+		exp := &models.QueryExplanation{Query: query.Query, Mode: query.Mode, Fields: query.Fields, KeywordText: queryText}
+		exp.Filters = explainFilters(query, nil)
+		return exp, nil
+	}
+	parts := keyword.ParseQueryParts(queryText)
+	exp := &models.QueryExplanation{
+		Query:     query.Query,
+		Boolean:   parts.Boolean,
+		Operators: parts.Operators,
+		Terms:     parts.Terms,
+		Phrases:   parts.Phrases,
+		Near:      parts.Near,
+		Negations: parts.Negated,
+		Fuzzy:     query.FuzzyEnabled,
+		Fields:    query.Fields,
+	}
+	for _, sp := range parts.Scoped {
+		exp.Filters = append(exp.Filters, models.QueryFilter{Field: sp.Field, Value: sp.Value, Exclude: sp.Negated})
+	}
+	exp.Filters = append(exp.Filters, explainFilters(query, scope)...)
+	if query.KeywordEnabled {
+		exp.KeywordText = strings.TrimSpace(queryText)
+	}
+	if query.SemanticEnabled {
+		exp.SemanticText = strings.TrimSpace(e.semanticQueryText(e.config(), queryText))
+	}
+	if query.KeywordEnabled && !parts.Boolean && len(query.Fields) == 0 {
+		exp.Synonyms = e.synonyms.Expand(parts.Terms)
+	}
+
+	if e.spellChecker == nil {
+		return exp, nil
+	}
+	if query.FuzzyEnabled {
+		for _, term := range parts.Terms {
+			var expansions []string
+			for _, s := range e.spellChecker.Suggest(term) {
+				expansions = append(expansions, s.Term)
+			}
+			if len(expansions) > 0 {
+				if exp.FuzzyExpansions == nil {
+					exp.FuzzyExpansions = make(map[string][]string)
+				}
+				exp.FuzzyExpansions[term] = expansions
+			}
+		}
+	}
+	if positive := keyword.PositiveQueryText(queryText); strings.TrimSpace(positive) != "" {
+		if check, err := e.spellChecker.Check(positive); err == nil && check.HasCorrections {
+			exp.Misspelled = check.MisspelledTerms
+			exp.CorrectedQuery = check.CorrectedQuery
+		}
+	}
+	return exp, nil
+}
+
+// explainFilters lists the path: and ext: scopes of the query text and the filters of
+// query, in the order docFilter applies them.
+func explainFilters(query *models.SearchQuery, scope *scopeFilter) []models.QueryFilter {
+	var out []models.QueryFilter
+	add := func(field, value string, exclude bool) {
+		out = append(out, models.QueryFilter{Field: field, Value: value, Exclude: exclude})
+	}
+	if scope != nil {
+		for _, v := range scope.paths {
+			add("path", v, false)
+		}
+		for _, v := range scope.exts {
+			add("ext", v, false)
+		}
+		for _, v := range scope.notPaths {
+			add("path", v, true)
+		}
+		for _, v := range scope.notExts {
+			add("ext", v, true)
+		}
+	}
+	if f := newDocFilter(query, nil); f != nil {
+		for _, ext := range f.exts {
+			add("ext", ext, false)
+		}
+		if f.pathPrefix != "" {
+			add("path_prefix", f.pathPrefix, false)
+		}
+		if f.modifiedAfter != nil {
+			add("modified_after", f.modifiedAfter.Format(time.RFC3339), false)
+		}
+		if f.modifiedBefore != nil {
+			add("modified_before", f.modifiedBefore.Format(time.RFC3339), false)
+		}
+		if f.createdAfter != nil {
+			add("created_after", f.createdAfter.Format(time.RFC3339), false)
+		}
+		if f.createdBefore != nil {
+			add("created_before", f.createdBefore.Format(time.RFC3339), false)
+		}
+		if f.minSize > 0 {
+			add("min_size", strconv.FormatInt(f.minSize, 10), false)
+		}
+		if f.maxSize > 0 {
+			add("max_size", strconv.FormatInt(f.maxSize, 10), false)
+		}
+		keys := make([]string, 0, len(f.metadata))
+		for k := range f.metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			add(k, fmt.Sprint(f.metadata[k]), false)
+		}
+		if f.snapshot != "" {
+			add("snapshot", f.snapshot, false)
+		}
+	}
+	return out
+}
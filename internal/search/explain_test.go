@@ -0,0 +1,80 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_Explain(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	kwIndex, err := keyword.NewBleveIndex(filepath.Join(t.TempDir(), "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg).WithSpellChecker()
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{
+		ID: "d1", Title: "Budget Proposal", Content: "The proposal for the budget was approved.",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.RefreshSpellChecker(); err != nil {
+		t.Fatal(err)
+	}
+
+	exp, err := engine.Explain(&models.SearchQuery{
+		Query:        `propodal -draft ext:pdf`,
+		FuzzyEnabled: true,
+		Extensions:   []string{".DOCX"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exp.Boolean || !reflect.DeepEqual(exp.Terms, []string{"propodal"}) || !reflect.DeepEqual(exp.Negations, []string{"draft"}) {
+		t.Errorf("parts: got %+v", exp)
+	}
+	wantFilters := []models.QueryFilter{{Field: "ext", Value: "pdf"}, {Field: "ext", Value: "docx"}}
+	if !reflect.DeepEqual(exp.Filters, wantFilters) {
+		t.Errorf("filters = %+v, want %+v", exp.Filters, wantFilters)
+	}
+	if exp.KeywordText != "propodal -draft" || exp.SemanticText != "propodal" {
+		t.Errorf("keyword text %q, semantic text %q", exp.KeywordText, exp.SemanticText)
+	}
+	if got := exp.FuzzyExpansions["propodal"]; len(got) == 0 || got[0] != "proposal" {
+		t.Errorf("fuzzy expansions = %v", exp.FuzzyExpansions)
+	}
+	if !reflect.DeepEqual(exp.Misspelled, []string{"propodal"}) || exp.CorrectedQuery != "proposal" {
+		t.Errorf("spelling: misspelled %v, corrected %q", exp.Misspelled, exp.CorrectedQuery)
+	}
+
+	exp, err = engine.Explain(&models.SearchQuery{Query: "budget", KeywordEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp.SemanticText != "" || exp.FuzzyExpansions != nil || exp.CorrectedQuery != "" {
+		t.Errorf("keyword-only query without typos: got %+v", exp)
+	}
+
+	if _, err := engine.Explain(&models.SearchQuery{}); err == nil {
+		t.Error("empty query should fail")
+	}
+}
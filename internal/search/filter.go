@@ -0,0 +1,172 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+)
+
+// filterCandidateFactor widens the candidate pool of each search branch when results are
+// filtered by document metadata, so narrow filters still fill a page.
+const filterCandidateFactor = 4
+
+// docFilter narrows candidates by document metadata. It combines the structured filters of
+// a SearchQuery with the path: and ext: scopes of its text.
+type docFilter struct {
+	scope          *scopeFilter
+	exts           []string
+	pathPrefix     string
+	modifiedAfter  *time.Time
+	modifiedBefore *time.Time
+	createdAfter   *time.Time
+	createdBefore  *time.Time
+	minSize        int64
+	maxSize        int64
+	metadata       map[string]interface{}
+	snapshot       string
+	// snapshotDocs holds the content hashes of the snapshot's documents, loaded by
+	// loadSnapshot; without them no document matches a snapshot.
+	snapshotDocs map[string]string
+}
+
+// newDocFilter returns the filter for query and scope, or nil when neither filters anything.
+func newDocFilter(query *models.SearchQuery, scope *scopeFilter) *docFilter {
+	if scope == nil && !query.HasDocumentFilters() {
+		return nil
+	}
+	f := &docFilter{
+		scope:          scope,
+		pathPrefix:     query.PathPrefix,
+		modifiedAfter:  query.ModifiedAfter,
+		modifiedBefore: query.ModifiedBefore,
+		createdAfter:   query.CreatedAfter,
+		createdBefore:  query.CreatedBefore,
+		minSize:        query.MinSize,
+		maxSize:        query.MaxSize,
+		metadata:       query.Filters,
+		snapshot:       query.Snapshot,
+	}
+	for _, ext := range query.Extensions {
+		if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+			f.exts = append(f.exts, ext)
+		}
+	}
+	return f
+}
+
+// matches reports whether doc passes every filter. Extension, path, size, and creation time
+// filters need a source file (and, for the creation time, a file system recording it); the
+// modification time is the file's mtime, or the last index time for documents without one.
+// A snapshot keeps the documents it recorded with the same content.
+func (f *docFilter) matches(doc *models.Document) bool {
+	if f.scope != nil && !f.scope.matches(doc) {
+		return false
+	}
+	path, _ := doc.Metadata["source_path"].(string)
+	if len(f.exts) > 0 && !equalsAny(strings.ToLower(strings.TrimPrefix(filepath.Ext(path), ".")), f.exts) {
+		return false
+	}
+	if f.pathPrefix != "" && !strings.HasPrefix(path, f.pathPrefix) {
+		return false
+	}
+	if f.modifiedAfter != nil || f.modifiedBefore != nil {
+		mtime := documentModTime(doc)
+		if f.modifiedAfter != nil && mtime.Before(*f.modifiedAfter) {
+			return false
+		}
+		if f.modifiedBefore != nil && !mtime.Before(*f.modifiedBefore) {
+			return false
+		}
+	}
+	if f.createdAfter != nil || f.createdBefore != nil {
+		ns, ok := metadataInt64(doc.Metadata, "source_created")
+		if !ok {
+			return false
+		}
+		created := time.Unix(0, ns)
+		if f.createdAfter != nil && created.Before(*f.createdAfter) {
+			return false
+		}
+		if f.createdBefore != nil && !created.Before(*f.createdBefore) {
+			return false
+		}
+	}
+	if f.minSize > 0 || f.maxSize > 0 {
+		size, ok := metadataInt64(doc.Metadata, "source_size")
+		if !ok || size < f.minSize || (f.maxSize > 0 && size > f.maxSize) {
+			return false
+		}
+	}
+	for key, want := range f.metadata {
+		got, ok := doc.Metadata[key]
+		if !ok || !metadataValueMatches(got, want) {
+			return false
+		}
+	}
+	if f.snapshot != "" {
+		hash, ok := f.snapshotDocs[doc.ID]
+		if !ok || hash != storage.ContentHash(doc.Content) {
+			return false
+		}
+	}
+	return true
+}
+
+// metadataValueMatches reports whether a metadata value equals want or, for a list such
+// as tags, contains it.
+func metadataValueMatches(got, want interface{}) bool {
+	if list, ok := got.([]interface{}); ok {
+		if _, wantList := want.([]interface{}); !wantList {
+			for _, v := range list {
+				if fmt.Sprint(v) == fmt.Sprint(want) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return fmt.Sprint(got) == fmt.Sprint(want)
+}
+
+// documentModTime returns the source file mtime from metadata, or doc.UpdatedAt.
+func documentModTime(doc *models.Document) time.Time {
+	if ns, ok := metadataInt64(doc.Metadata, "source_mtime"); ok {
+		return time.Unix(0, ns)
+	}
+	return doc.UpdatedAt
+}
+
+// metadataInt64 reads an integer stored in metadata as a decimal string or a JSON number.
+func metadataInt64(m map[string]interface{}, key string) (int64, bool) {
+	switch v := m[key].(type) {
+	case string:
+		n, err := strconv.ParseInt(v, 10, 64)
+		return n, err == nil
+	case float64:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	}
+	return 0, false
+}
+
+// filterDocuments keeps the candidates whose documents satisfy f.
+func (e *Engine) filterDocuments(ctx context.Context, results []*FusedResult, f *docFilter) []*FusedResult {
+	out := results[:0]
+	for _, r := range results {
+		doc, err := e.getDocument(ctx, r.DocumentID)
+		if err != nil || !f.matches(doc) {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
@@ -0,0 +1,120 @@
+package search
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestDocFilter_matches(t *testing.T) {
+	now := time.Now()
+	weekAgo := now.Add(-7 * 24 * time.Hour)
+	doc := &models.Document{Metadata: map[string]interface{}{
+		"source_path":    "/projects/plan.DOCX",
+		"source_mtime":   strconv.FormatInt(now.Add(-time.Hour).UnixNano(), 10),
+		"source_size":    "2048",
+		"author":         "kim",
+		"source_created": strconv.FormatInt(now.Add(-30*24*time.Hour).UnixNano(), 10),
+		"tags":           []interface{}{"Red", "Projects"},
+	}}
+	tests := []struct {
+		name  string
+		query models.SearchQuery
+		want  bool
+	}{
+		{"extension", models.SearchQuery{Extensions: []string{".docx"}}, true},
+		{"other extension", models.SearchQuery{Extensions: []string{"pdf"}}, false},
+		{"path prefix", models.SearchQuery{PathPrefix: "/projects/"}, true},
+		{"other path prefix", models.SearchQuery{PathPrefix: "/archive/"}, false},
+		{"modified after", models.SearchQuery{ModifiedAfter: &weekAgo}, true},
+		{"modified before", models.SearchQuery{ModifiedBefore: &weekAgo}, false},
+		{"size range", models.SearchQuery{MinSize: 1024, MaxSize: 4096}, true},
+		{"too small", models.SearchQuery{MinSize: 4096}, false},
+		{"metadata", models.SearchQuery{Filters: map[string]interface{}{"author": "kim"}}, true},
+		{"other metadata", models.SearchQuery{Filters: map[string]interface{}{"author": "lee"}}, false},
+		{"tag", models.SearchQuery{Filters: map[string]interface{}{"tags": "Projects"}}, true},
+		{"other tag", models.SearchQuery{Filters: map[string]interface{}{"tags": "Blue"}}, false},
+		{"created before", models.SearchQuery{CreatedBefore: &weekAgo}, true},
+		{"created after", models.SearchQuery{CreatedAfter: &weekAgo}, false},
+	}
+	for _, tt := range tests {
+		f := newDocFilter(&tt.query, nil)
+		if f == nil {
+			t.Fatalf("%s: newDocFilter returned nil", tt.name)
+		}
+		if got := f.matches(doc); got != tt.want {
+			t.Errorf("%s: matches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if newDocFilter(&models.SearchQuery{Query: "x"}, nil) != nil {
+		t.Error("expected nil filter for a query without filters")
+	}
+}
+
+func TestEngine_Search_DocumentFilters(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	defer emb.Close()
+	vecIndex, err := vector.NewMemoryIndex(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vecIndex.Close()
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{
+		TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10,
+		DefaultKeywordEnabled: true, DefaultSemanticEnabled: true,
+	}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	mtime := func(age time.Duration) string { return strconv.FormatInt(time.Now().Add(-age).UnixNano(), 10) }
+	for _, in := range []*models.DocumentInput{
+		{ID: "new-docx", Content: "project plan", Metadata: map[string]interface{}{
+			"source_path": "/projects/plan.docx", "source_mtime": mtime(time.Hour)}},
+		{ID: "old-docx", Content: "project plan", Metadata: map[string]interface{}{
+			"source_path": "/projects/old.docx", "source_mtime": mtime(90 * 24 * time.Hour)}},
+		{ID: "new-pdf", Content: "project plan", Metadata: map[string]interface{}{
+			"source_path": "/projects/plan.pdf", "source_mtime": mtime(time.Hour)}},
+		{ID: "elsewhere", Content: "project plan", Metadata: map[string]interface{}{
+			"source_path": "/home/plan.docx", "source_mtime": mtime(time.Hour)}},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	after := time.Now().Add(-30 * 24 * time.Hour)
+	resp, err := engine.Search(ctx, &models.SearchQuery{
+		Query: "project plan", Limit: 10, KeywordEnabled: true, SemanticEnabled: true,
+		Extensions: []string{"docx"}, PathPrefix: "/projects/", ModifiedAfter: &after,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range append(resp.NonSemanticResults, resp.SemanticResults...) {
+		ids = append(ids, r.Document.ID)
+	}
+	if len(ids) != 1 || ids[0] != "new-docx" {
+		t.Errorf("results = %v, want [new-docx]", ids)
+	}
+}
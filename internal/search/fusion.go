@@ -5,9 +5,14 @@ import (
 	"sort"
 
 	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
 	"github.com/hyperjump/sagasu/internal/vector"
 )
 
+// DefaultRRFK is the k of reciprocal rank fusion when search.rrf_k is unset: larger values
+// flatten the advantage of the top ranks.
+const DefaultRRFK = 60
+
 // FusedResult holds a document ID and keyword/semantic scores for split result lists.
 type FusedResult struct {
 	DocumentID    string
@@ -53,6 +58,56 @@ func AggregateSemanticByDocument(chunkToDoc map[string]string, semanticScores ma
 	return byDoc
 }
 
+// SemanticChunk locates a chunk in its document for aggregation.
+type SemanticChunk struct {
+	DocumentID string
+	Index      int
+}
+
+// AggregateSemanticWithDecay converts chunk ID -> score to document ID -> score, crediting
+// every distinct matching chunk rather than only the best one. A document's chunks are
+// taken best first; one next to a chunk already counted is skipped, since the chunk
+// overlap makes it repeat that chunk's text. The k-th counted chunk (from 0) closes
+// decay^k of the remaining gap to 1 by its score: 1 - prod(1 - score*decay^k). With one
+// chunk this is the max; further chunks add less and less, and the result stays in
+// [0, 1], so long documents do not win by chunk count alone and score thresholds keep
+// their meaning.
+func AggregateSemanticWithDecay(chunks map[string]SemanticChunk, semanticScores map[string]float64, decay float64) map[string]float64 {
+	type scored struct {
+		index int
+		score float64
+	}
+	perDoc := make(map[string][]scored)
+	for chunkID, score := range semanticScores {
+		c, ok := chunks[chunkID]
+		if !ok || c.DocumentID == "" {
+			continue
+		}
+		perDoc[c.DocumentID] = append(perDoc[c.DocumentID], scored{c.Index, min(max(score, 0), 1)})
+	}
+	byDoc := make(map[string]float64, len(perDoc))
+	for docID, list := range perDoc {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].score != list[j].score {
+				return list[i].score > list[j].score
+			}
+			return list[i].index < list[j].index
+		})
+		counted := make(map[int]bool, len(list))
+		miss, weight := 1.0, 1.0
+		for _, c := range list {
+			if counted[c.index-1] || counted[c.index+1] {
+				continue
+			}
+			counted[c.index] = true
+			miss *= 1 - c.score*weight
+			weight *= decay
+		}
+		byDoc[docID] = 1 - miss
+	}
+	return byDoc
+}
+
 // SplitBySource splits keyword and semantic score maps into two disjoint result lists:
 // nonSemantic = all documents from keyword (sorted by keyword score desc),
 // semantic = documents only in semantic, not in keyword (sorted by semantic score desc).
@@ -83,3 +138,83 @@ func SplitBySource(keywordScores, semanticScores map[string]float64) (nonSemanti
 	sort.Slice(semantic, func(i, j int) bool { return semantic[i].SemanticScore > semantic[j].SemanticScore })
 	return nonSemantic, semantic
 }
+
+// FuseResults merges keyword and semantic scores into one list of every document in
+// either, ranked by strategy (models.FusionRRF, FusionWeighted, or FusionMax) best first:
+// rrf sums 1/(rrfK + rank) over the keyword and semantic rankings, weighted sums the
+// keyword score divided by the best one times keywordWeight and the semantic score times
+// semanticWeight, and max takes the higher of those two unweighted. Scaling the keyword
+// scores makes them comparable with the semantic scores, which are already in [0, 1].
+func FuseResults(strategy string, keywordScores, semanticScores map[string]float64, keywordWeight, semanticWeight float64, rrfK int) []*FusedResult {
+	byDoc := make(map[string]*FusedResult, len(keywordScores)+len(semanticScores))
+	result := func(docID string) *FusedResult {
+		r := byDoc[docID]
+		if r == nil {
+			r = &FusedResult{DocumentID: docID}
+			byDoc[docID] = r
+		}
+		return r
+	}
+	bestKeyword := 0.0
+	for docID, score := range keywordScores {
+		result(docID).KeywordScore = score
+		bestKeyword = max(bestKeyword, score)
+	}
+	for docID, score := range semanticScores {
+		result(docID).SemanticScore = score
+	}
+	keywordShare := func(r *FusedResult) float64 {
+		if bestKeyword <= 0 {
+			return 0
+		}
+		return r.KeywordScore / bestKeyword
+	}
+	switch strategy {
+	case models.FusionRRF:
+		if rrfK <= 0 {
+			rrfK = DefaultRRFK
+		}
+		for _, ids := range [][]string{rankedIDs(keywordScores), rankedIDs(semanticScores)} {
+			for rank, docID := range ids {
+				byDoc[docID].Score += 1 / float64(rrfK+rank+1)
+			}
+		}
+	case models.FusionWeighted:
+		for _, r := range byDoc {
+			r.Score = keywordWeight*keywordShare(r) + semanticWeight*r.SemanticScore
+		}
+	default:
+		for _, r := range byDoc {
+			r.Score = max(keywordShare(r), r.SemanticScore)
+		}
+	}
+	fused := make([]*FusedResult, 0, len(byDoc))
+	for _, r := range byDoc {
+		fused = append(fused, r)
+	}
+	sort.Slice(fused, func(i, j int) bool {
+		if fused[i].Score != fused[j].Score {
+			return fused[i].Score > fused[j].Score
+		}
+		if fused[i].KeywordScore != fused[j].KeywordScore {
+			return fused[i].KeywordScore > fused[j].KeywordScore
+		}
+		return fused[i].DocumentID < fused[j].DocumentID
+	})
+	return fused
+}
+
+// rankedIDs returns the document IDs of scores by score, best first, ties by ID.
+func rankedIDs(scores map[string]float64) []string {
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
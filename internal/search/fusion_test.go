@@ -1,9 +1,11 @@
 package search
 
 import (
+	"math"
 	"testing"
 
 	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
 	"github.com/hyperjump/sagasu/internal/vector"
 )
 
@@ -52,6 +54,38 @@ func TestAggregateSemanticByDocument(t *testing.T) {
 	}
 }
 
+func TestAggregateSemanticWithDecay(t *testing.T) {
+	chunks := map[string]SemanticChunk{
+		"a0": {"long", 0}, "a1": {"long", 1}, "a2": {"long", 2}, "a5": {"long", 5},
+		"b0": {"short", 0},
+		"c0": {"two", 0}, "c4": {"two", 4},
+	}
+	semantic := map[string]float64{
+		"a0": 0.6, "a1": 0.6, "a2": 0.6, "a5": 0.2,
+		"b0": 0.7,
+		"c0": 0.6, "c4": 0.6,
+	}
+	byDoc := AggregateSemanticWithDecay(chunks, semantic, 0.5)
+	// short: a single chunk scores as with max.
+	if math.Abs(byDoc["short"]-0.7) > 1e-9 {
+		t.Errorf("short = %v, want 0.7", byDoc["short"])
+	}
+	// two: 1 - (1-0.6)(1-0.3) = 0.72.
+	if math.Abs(byDoc["two"]-0.72) > 1e-9 {
+		t.Errorf("two = %v, want 0.72", byDoc["two"])
+	}
+	// long: a0 and a2 count, a1 overlaps both; a5 adds 0.2*0.25.
+	want := 1 - (1-0.6)*(1-0.3)*(1-0.05)
+	if math.Abs(byDoc["long"]-want) > 1e-9 {
+		t.Errorf("long = %v, want %v", byDoc["long"], want)
+	}
+	for doc, score := range byDoc {
+		if score < 0 || score > 1 {
+			t.Errorf("%s = %v, want within [0, 1]", doc, score)
+		}
+	}
+}
+
 func TestSplitBySource(t *testing.T) {
 	kw := map[string]float64{"d1": 1.0, "d2": 0.5, "d3": 0.3}
 	semScores := map[string]float64{"d1": 0.8, "d4": 0.9, "d5": 0.2}
@@ -117,3 +151,35 @@ func TestSplitBySource_EmptySemantic(t *testing.T) {
 		t.Errorf("expected 0 semantic, got %d", len(semRes))
 	}
 }
+
+func TestFuseResults(t *testing.T) {
+	keywordScores := map[string]float64{"a": 4, "b": 2}
+	semanticScores := map[string]float64{"b": 0.9, "c": 0.6}
+	tests := []struct {
+		strategy string
+		kw, sem  float64
+		order    string
+		scores   []float64
+	}{
+		{models.FusionRRF, 0, 0, "bac", []float64{1.0/61 + 1.0/62, 1.0 / 61, 1.0 / 62}},
+		{models.FusionWeighted, 0.5, 0.5, "bac", []float64{0.7, 0.5, 0.3}},
+		{models.FusionWeighted, 1, 0, "abc", []float64{1, 0.5, 0}},
+		{models.FusionMax, 0, 0, "abc", []float64{1, 0.9, 0.6}},
+	}
+	for _, tt := range tests {
+		fused := FuseResults(tt.strategy, keywordScores, semanticScores, tt.kw, tt.sem, 0)
+		order := ""
+		for i, r := range fused {
+			order += r.DocumentID
+			if math.Abs(r.Score-tt.scores[i]) > 1e-9 {
+				t.Errorf("%s %g/%g: %s score = %g, want %g", tt.strategy, tt.kw, tt.sem, r.DocumentID, r.Score, tt.scores[i])
+			}
+		}
+		if order != tt.order {
+			t.Errorf("%s %g/%g: order = %s, want %s", tt.strategy, tt.kw, tt.sem, order, tt.order)
+		}
+		if fused[0].DocumentID == "b" && (fused[0].KeywordScore != 2 || fused[0].SemanticScore != 0.9) {
+			t.Errorf("%s: b keeps its scores, got %+v", tt.strategy, fused[0])
+		}
+	}
+}
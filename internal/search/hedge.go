@@ -0,0 +1,206 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+// Search branch names, as reported in SearchResponse.TimedOut.
+const (
+	branchKeyword  = "keyword"
+	branchSemantic = "semantic"
+)
+
+const (
+	// latencyAlpha is the weight of the newest sample in the latency moving average.
+	latencyAlpha = 0.2
+	// minLatencySamples is how many completed runs a branch needs before it can be hedged.
+	minLatencySamples = 5
+	// detachedBranchTimeout bounds how long an abandoned branch keeps running in the background.
+	detachedBranchTimeout = 30 * time.Second
+)
+
+// latencyTracker keeps an exponentially weighted moving average of each branch's latency.
+type latencyTracker struct {
+	mu      sync.Mutex
+	avg     map[string]time.Duration
+	samples map[string]int
+}
+
+func newLatencyTracker() *latencyTracker {
+	return &latencyTracker{
+		avg:     make(map[string]time.Duration),
+		samples: make(map[string]int),
+	}
+}
+
+func (t *latencyTracker) observe(branch string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.samples[branch] == 0 {
+		t.avg[branch] = d
+	} else {
+		t.avg[branch] = time.Duration(latencyAlpha*float64(d) + (1-latencyAlpha)*float64(t.avg[branch]))
+	}
+	t.samples[branch]++
+}
+
+// hedgeAfter returns how long to wait for branch before giving up on it: multiplier times
+// its average latency, but at least minDelay. ok is false until the branch has enough history.
+func (t *latencyTracker) hedgeAfter(branch string, multiplier float64, minDelay time.Duration) (d time.Duration, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.samples[branch] < minLatencySamples {
+		return 0, false
+	}
+	d = time.Duration(multiplier * float64(t.avg[branch]))
+	if d < minDelay {
+		d = minDelay
+	}
+	return d, true
+}
+
+// branchResult is the outcome of one search branch.
+type branchResult struct {
+	name     string
+	keyword  []*keyword.KeywordResult
+	semantic []*vector.VectorResult
+	stemmed  bool // keyword results are from the stemmed fallback pass
+	err      error
+}
+
+// branchRun is a search branch to start.
+type branchRun struct {
+	name string
+	run  func(ctx context.Context) branchResult
+}
+
+// runBranches runs the branches in parallel and collects their results. Without hedging
+// or a search budget it waits for every branch. Otherwise branches run on a context
+// detached from ctx's cancellation, and any branch still running at its hedge deadline
+// (once another branch has finished) or when the budget expires is left out and named in
+// timedOut; it keeps running in the background so its caches are warm for the next query.
+// ready, when non-nil, is called with each result that arrives while other branches are
+// still pending.
+func (e *Engine) runBranches(ctx context.Context, cfg *config.SearchConfig, branches []branchRun, ready func(branchResult)) (results []branchResult, timedOut []string, err error) {
+	start := time.Now()
+	hedging := cfg.HedgingEnabled && len(branches) > 1
+	detached := hedging || cfg.SearchBudgetMs > 0
+
+	branchCtx := ctx
+	var wg sync.WaitGroup
+	if detached {
+		var cancel context.CancelFunc
+		branchCtx, cancel = context.WithTimeout(context.WithoutCancel(ctx), detachedBranchTimeout)
+		defer func() { go func() { wg.Wait(); cancel() }() }()
+	}
+
+	done := make(chan branchResult, len(branches))
+	pending := make(map[string]bool, len(branches))
+	for _, b := range branches {
+		pending[b.name] = true
+		wg.Add(1)
+		go func(b branchRun) {
+			defer wg.Done()
+			began := time.Now()
+			r := b.run(branchCtx)
+			r.name = b.name
+			if r.err == nil {
+				e.latency.observe(b.name, time.Since(began))
+			}
+			done <- r
+		}(b)
+	}
+
+	var budget <-chan time.Time
+	if cfg.SearchBudgetMs > 0 {
+		timer := time.NewTimer(time.Duration(cfg.SearchBudgetMs) * time.Millisecond)
+		defer timer.Stop()
+		budget = timer.C
+	}
+	var hedge <-chan time.Time
+	var hedgeTimer *time.Timer
+	defer func() {
+		if hedgeTimer != nil {
+			hedgeTimer.Stop()
+		}
+	}()
+
+	for len(pending) > 0 {
+		select {
+		case r := <-done:
+			delete(pending, r.name)
+			if r.err != nil {
+				return nil, nil, r.err
+			}
+			results = append(results, r)
+			if ready != nil && len(pending) > 0 {
+				ready(r)
+			}
+			if hedging && len(pending) > 0 && hedgeTimer == nil {
+				if wait, ok := e.nextHedgeDeadline(cfg, pending, start); ok {
+					hedgeTimer = time.NewTimer(wait)
+					hedge = hedgeTimer.C
+				}
+			}
+		case <-hedge:
+			hedge = nil
+			for name := range pending {
+				if wait, ok := e.hedgeDeadline(cfg, name, start); ok && wait <= 0 {
+					delete(pending, name)
+					timedOut = append(timedOut, name)
+				}
+			}
+			if len(pending) > 0 {
+				if wait, ok := e.nextHedgeDeadline(cfg, pending, start); ok {
+					hedgeTimer.Reset(wait)
+					hedge = hedgeTimer.C
+				}
+			}
+		case <-budget:
+			for name := range pending {
+				delete(pending, name)
+				timedOut = append(timedOut, name)
+			}
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	sort.Strings(timedOut)
+	return results, timedOut, nil
+}
+
+// hedgeDeadline returns the time left before branch name should be abandoned.
+func (e *Engine) hedgeDeadline(cfg *config.SearchConfig, name string, start time.Time) (time.Duration, bool) {
+	after, ok := e.latency.hedgeAfter(name, cfg.HedgeLatencyMultiplier,
+		time.Duration(cfg.HedgeMinDelayMs)*time.Millisecond)
+	if !ok {
+		return 0, false
+	}
+	return after - time.Since(start), true
+}
+
+// nextHedgeDeadline returns the earliest hedge deadline among the pending branches.
+func (e *Engine) nextHedgeDeadline(cfg *config.SearchConfig, pending map[string]bool, start time.Time) (time.Duration, bool) {
+	var next time.Duration
+	found := false
+	for name := range pending {
+		wait, ok := e.hedgeDeadline(cfg, name, start)
+		if !ok {
+			continue
+		}
+		if !found || wait < next {
+			next, found = wait, true
+		}
+	}
+	if next < 0 {
+		next = 0
+	}
+	return next, found
+}
@@ -0,0 +1,198 @@
+package search
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+// slowEmbedder delays Embed by the current delay (in nanoseconds).
+type slowEmbedder struct {
+	embedding.Embedder
+	delay    atomic.Int64
+	finished atomic.Int32
+}
+
+func (s *slowEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	time.Sleep(time.Duration(s.delay.Load()))
+	defer s.finished.Add(1)
+	return s.Embedder.Embed(ctx, text)
+}
+
+func TestLatencyTracker_hedgeAfter(t *testing.T) {
+	tr := newLatencyTracker()
+	if _, ok := tr.hedgeAfter("keyword", 3, time.Millisecond); ok {
+		t.Error("hedgeAfter without history should not be ok")
+	}
+	for i := 0; i < minLatencySamples; i++ {
+		tr.observe("keyword", 10*time.Millisecond)
+	}
+	d, ok := tr.hedgeAfter("keyword", 3, time.Millisecond)
+	if !ok || d != 30*time.Millisecond {
+		t.Errorf("hedgeAfter: got %v, %v; want 30ms, true", d, ok)
+	}
+	if d, _ := tr.hedgeAfter("keyword", 3, time.Second); d != time.Second {
+		t.Errorf("hedgeAfter min delay: got %v, want 1s", d)
+	}
+}
+
+func TestEngine_Search_hedgesSlowSemanticBranch(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := &slowEmbedder{Embedder: embedding.NewMockEmbedder(4)}
+	defer emb.Close()
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	defer vecIndex.Close()
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{
+		TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10,
+		HedgingEnabled: true, HedgeLatencyMultiplier: 3, HedgeMinDelayMs: 20,
+	}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{
+		ID: "d1", Title: "T1", Content: "machine learning algorithms",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	query := func() *models.SearchResponse {
+		resp, err := engine.Search(ctx, &models.SearchQuery{
+			Query: "machine learning", Limit: 5, KeywordEnabled: true, SemanticEnabled: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	for i := 0; i < minLatencySamples; i++ {
+		if resp := query(); len(resp.TimedOut) != 0 {
+			t.Fatalf("warm-up query %d timed out: %v", i, resp.TimedOut)
+		}
+	}
+
+	emb.delay.Store(int64(500 * time.Millisecond))
+	before := emb.finished.Load()
+	start := time.Now()
+	resp := query()
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("hedged search took %v, expected to return before the slow branch", elapsed)
+	}
+	if len(resp.TimedOut) != 1 || resp.TimedOut[0] != "semantic" {
+		t.Errorf("TimedOut: got %v, want [semantic]", resp.TimedOut)
+	}
+	if resp.TotalNonSemantic != 1 {
+		t.Errorf("keyword results should still be returned, got %d", resp.TotalNonSemantic)
+	}
+
+	// The abandoned branch keeps running in the background.
+	deadline := time.Now().Add(2 * time.Second)
+	for emb.finished.Load() == before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if emb.finished.Load() == before {
+		t.Error("slow semantic branch did not complete in the background")
+	}
+}
+
+func TestEngine_Search_budget(t *testing.T) {
+	ctx := context.Background()
+	store, _ := storage.NewSQLiteStorage(":memory:")
+	defer store.Close()
+	emb := &slowEmbedder{Embedder: embedding.NewMockEmbedder(4)}
+	emb.delay.Store(int64(300 * time.Millisecond))
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	defer vecIndex.Close()
+	kwIndex, _ := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, SearchBudgetMs: 50}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	resp, err := engine.Search(ctx, &models.SearchQuery{Query: "anything", SemanticEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.TimedOut) != 1 || resp.TimedOut[0] != "semantic" {
+		t.Errorf("TimedOut: got %v, want [semantic]", resp.TimedOut)
+	}
+}
+
+func TestEngine_SearchStream(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := &slowEmbedder{Embedder: embedding.NewMockEmbedder(4)}
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	defer vecIndex.Close()
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for _, doc := range []*models.DocumentInput{
+		{ID: "d1", Title: "T1", Content: "machine learning algorithms"},
+		{ID: "d2", Title: "T2", Content: "neural networks"},
+	} {
+		if err := idx.IndexDocument(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	emb.delay.Store(int64(200 * time.Millisecond))
+	start := time.Now()
+	var partial *models.SearchResponse
+	var partialAfter time.Duration
+	resp, err := engine.SearchStream(ctx, &models.SearchQuery{
+		Query: "machine learning", Limit: 5, KeywordEnabled: true, SemanticEnabled: true,
+	}, func(r *models.SearchResponse) {
+		partial, partialAfter = r, time.Since(start)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if partial == nil {
+		t.Fatal("expected keyword results before semantic search finished")
+	}
+	if partialAfter >= 200*time.Millisecond {
+		t.Errorf("keyword results arrived after %v, expected before the slow embedding", partialAfter)
+	}
+	if len(partial.NonSemanticResults) != 1 || partial.NonSemanticResults[0].Document.ID != "d1" || len(partial.SemanticResults) != 0 {
+		t.Errorf("partial response: %d keyword, %d semantic results", len(partial.NonSemanticResults), len(partial.SemanticResults))
+	}
+	if len(resp.NonSemanticResults) != 1 || len(resp.SemanticResults) != 1 || resp.SemanticResults[0].Document.ID != "d2" {
+		t.Errorf("final response: %d keyword, %d semantic results", len(resp.NonSemanticResults), len(resp.SemanticResults))
+	}
+
+	// Keyword-only searches have nothing to stream early
+	partial = nil
+	if _, err := engine.SearchStream(ctx, &models.SearchQuery{Query: "machine", KeywordEnabled: true}, func(r *models.SearchResponse) {
+		partial = r
+	}); err != nil || partial != nil {
+		t.Errorf("keyword-only search: partial %v, err %v", partial, err)
+	}
+}
@@ -0,0 +1,41 @@
+package search
+
+import (
+	"context"
+	"slices"
+	"sort"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+)
+
+// literalResults returns the documents whose title or content (only the title when
+// query.Fields says so) contains query's text exactly, ignoring case, as keyword results
+// scored by occurrences: those in the title weighted by the title boost (1 when unset).
+func (e *Engine) literalResults(ctx context.Context, query *models.SearchQuery, titleBoost float64) ([]*keyword.KeywordResult, error) {
+	searcher, ok := e.storage.(storage.LiteralSearcher)
+	if !ok {
+		return nil, storage.ErrLiteralSearchUnsupported
+	}
+	matches, err := searcher.SearchLiteral(ctx, query.Query)
+	if err != nil {
+		return nil, err
+	}
+	if titleBoost <= 0 {
+		titleBoost = 1
+	}
+	titleOnly := slices.Contains(query.Fields, models.SearchFieldTitle)
+	results := make([]*keyword.KeywordResult, 0, len(matches))
+	for _, m := range matches {
+		score := float64(m.TitleCount) * titleBoost
+		if !titleOnly {
+			score += float64(m.ContentCount)
+		}
+		if score > 0 {
+			results = append(results, &keyword.KeywordResult{ID: m.DocumentID, Score: score})
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return results, nil
+}
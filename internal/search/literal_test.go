@@ -0,0 +1,69 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_Search_literal(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:", storage.WithTrigramIndex(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 500, ChunkOverlap: 10, KeywordTitleBoost: 3}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for _, in := range []*models.DocumentInput{
+		{ID: "label", Title: "SN-0042-X label", Content: "Device label."},
+		{ID: "log", Title: "service log", Content: "Replaced sn-0042-x twice; sn-0042-x is back."},
+		{ID: "split", Title: "notes", Content: "SN 0042 X was a different unit."},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	query := func(fields ...string) *models.SearchQuery {
+		return &models.SearchQuery{Query: "SN-0042-X", Mode: models.QueryModeLiteral, Limit: 10, SemanticEnabled: true, Fields: fields}
+	}
+	resp, err := engine.Search(ctx, query())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resultIDs(resp.NonSemanticResults), []string{"label", "log"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("literal results = %v, want %v (a title occurrence outweighs two in the content)", got, want)
+	}
+	if len(resp.SemanticResults) != 0 {
+		t.Errorf("literal search should skip semantic search, got %v", resultIDs(resp.SemanticResults))
+	}
+	n, err := engine.Count(ctx, query())
+	if err != nil || n != 2 {
+		t.Errorf("Count = %d, %v; want 2", n, err)
+	}
+	resp, err = engine.Search(ctx, query(models.SearchFieldTitle))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := resultIDs(resp.NonSemanticResults), []string{"label"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("title-only literal results = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,123 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// matchingPins returns the pins whose terms all occur in queryText, oldest first.
+func (e *Engine) matchingPins(ctx context.Context, queryText string) ([]*models.Pin, error) {
+	pins, err := e.storage.ListPins(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pins: %w", err)
+	}
+	if len(pins) == 0 {
+		return nil, nil
+	}
+	words := make(map[string]bool)
+	for _, w := range models.QueryWords(queryText) {
+		words[w] = true
+	}
+	var matched []*models.Pin
+	for _, pin := range pins {
+		terms := pin.Terms()
+		ok := len(terms) > 0
+		for _, t := range terms {
+			ok = ok && words[t]
+		}
+		if ok {
+			matched = append(matched, pin)
+		}
+	}
+	return matched, nil
+}
+
+// applyPins moves the documents selected by pins to the front of both result lists, in
+// pin order, and returns their positions by document ID. A pinned document found by
+// neither search is added to the front of nonSemantic when it exists and passes filter.
+func (e *Engine) applyPins(ctx context.Context, pins []*models.Pin, nonSemantic, semantic []*FusedResult, filter *docFilter) ([]*FusedResult, []*FusedResult, map[string]int) {
+	if len(pins) == 0 {
+		return nonSemantic, semantic, nil
+	}
+	pinned := make(map[string]int)
+	pin := func(id string, pos int) {
+		if _, ok := pinned[id]; !ok {
+			pinned[id] = pos
+		}
+	}
+	found := make(map[string]bool, len(nonSemantic)+len(semantic))
+	for _, r := range nonSemantic {
+		found[r.DocumentID] = true
+	}
+	for _, r := range semantic {
+		found[r.DocumentID] = true
+	}
+	for i, p := range pins {
+		if p.DocumentID != "" {
+			if !found[p.DocumentID] {
+				doc, err := e.getDocument(ctx, p.DocumentID)
+				if err != nil || (filter != nil && !filter.matches(doc)) {
+					continue
+				}
+				score := 1.0
+				if len(nonSemantic) > 0 {
+					score = nonSemantic[0].Score
+				}
+				nonSemantic = append(nonSemantic, &FusedResult{DocumentID: p.DocumentID, Score: score})
+				found[p.DocumentID] = true
+			}
+			pin(p.DocumentID, i)
+			continue
+		}
+		for _, list := range [][]*FusedResult{nonSemantic, semantic} {
+			for _, r := range list {
+				doc, err := e.getDocument(ctx, r.DocumentID)
+				if err != nil {
+					continue
+				}
+				if path, _ := doc.Metadata["source_path"].(string); strings.HasPrefix(path, p.Path) {
+					pin(r.DocumentID, i)
+				}
+			}
+		}
+	}
+	return pinFused(nonSemantic, pinned), pinFused(semantic, pinned), pinned
+}
+
+// pinFused stably moves pinned results to the front, ordered by pin position.
+func pinFused(results []*FusedResult, pinned map[string]int) []*FusedResult {
+	sort.SliceStable(results, func(i, j int) bool {
+		return pinnedBefore(pinned, results[i].DocumentID, results[j].DocumentID)
+	})
+	return results
+}
+
+// pinResults stably moves pinned results to the front, ordered by pin position, and marks
+// them. It restores pin order after the content ranker re-sorts a page.
+func pinResults(results []*models.SearchResult, pinned map[string]int) []*models.SearchResult {
+	if len(pinned) == 0 {
+		return results
+	}
+	for _, r := range results {
+		_, r.Pinned = pinned[r.Document.ID]
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return pinnedBefore(pinned, results[i].Document.ID, results[j].Document.ID)
+	})
+	return results
+}
+
+// pinnedBefore reports whether document a sorts before b: pinned before unpinned, and
+// pinned documents by pin position.
+func pinnedBefore(pinned map[string]int, a, b string) bool {
+	pa, aok := pinned[a]
+	pb, bok := pinned[b]
+	if aok != bok {
+		return aok
+	}
+	return aok && pa < pb
+}
@@ -0,0 +1,75 @@
+package search
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_Search_pins(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for _, in := range []*models.DocumentInput{
+		{ID: "notes", Content: "travel expense expense expense notes", Metadata: map[string]interface{}{"source_path": "/notes/travel.md"}},
+		{ID: "policy", Content: "travel expense policy", Metadata: map[string]interface{}{"source_path": "/hr/policy.md"}},
+		{ID: "canonical", Content: "reimbursement rules", Metadata: map[string]interface{}{"source_path": "/hr/rules.md"}},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(q string) []*models.SearchResult {
+		t.Helper()
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: q, Limit: 10, KeywordEnabled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.NonSemanticResults
+	}
+
+	_ = store.CreatePin(ctx, &models.Pin{Query: "Expense", DocumentID: "canonical"})
+	_ = store.CreatePin(ctx, &models.Pin{Query: "travel expense", Path: "/hr/"})
+
+	results := search("travel expense")
+	if len(results) != 3 || results[0].Document.ID != "canonical" || results[1].Document.ID != "policy" {
+		t.Fatalf("pinned documents should come first in pin order, got %v", resultIDs(results))
+	}
+	if !results[0].Pinned || !results[1].Pinned || results[2].Pinned {
+		t.Errorf("only pinned results should be marked")
+	}
+
+	results = search("travel")
+	if len(results) != 2 || results[0].Pinned {
+		t.Errorf("pins should not apply without all their terms, got %v", resultIDs(results))
+	}
+}
+
+func resultIDs(results []*models.SearchResult) []string {
+	ids := make([]string, len(results))
+	for i, r := range results {
+		ids[i] = r.Document.ID
+	}
+	return ids
+}
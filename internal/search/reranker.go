@@ -0,0 +1,102 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// defaultRerankTopK is how many fused candidates per result list are re-scored when
+// SearchConfig.RerankerTopK is unset.
+const defaultRerankTopK = 20
+
+// Reranker scores (query, passage) pairs with a second-stage model such as a cross-encoder.
+// Higher scores mean more relevant; scores are only compared within one call.
+type Reranker interface {
+	Score(ctx context.Context, query string, passages []string) ([]float32, error)
+	Close() error
+}
+
+// LoadReranker opens the ONNX cross-encoder at modelPath with the WordPiece vocabulary
+// next to it (tokenizer.json or vocab.txt, see embedding.FindWordPiece). It returns
+// (nil, nil) when modelPath is empty or the file does not exist, so search falls back to
+// the fused order, and an error when the vocabulary is missing.
+func LoadReranker(modelPath string, maxTokens int) (Reranker, error) {
+	if modelPath == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(modelPath); err != nil {
+		return nil, nil
+	}
+	tokenizer, err := embedding.FindWordPiece(filepath.Dir(modelPath))
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewONNXReranker(modelPath, tokenizer, maxTokens)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// WithReranker enables second-stage reranking of the top fused candidates. A nil reranker
+// disables it.
+func (e *Engine) WithReranker(r Reranker) *Engine {
+	e.reranker = r
+	return e
+}
+
+// rerankCandidates re-orders the first top-K fused results by reranker score. Scores are
+// left as fused scores. On any error the input order is kept.
+func (e *Engine) rerankCandidates(ctx context.Context, cfg *config.SearchConfig, queryStr string, results []*FusedResult) []*FusedResult {
+	if e.reranker == nil || len(results) < 2 {
+		return results
+	}
+	topK := cfg.RerankerTopK
+	if topK <= 0 {
+		topK = defaultRerankTopK
+	}
+	if topK > len(results) {
+		topK = len(results)
+	}
+
+	candidates := make([]*FusedResult, 0, topK)
+	passages := make([]string, 0, topK)
+	for _, r := range results[:topK] {
+		doc, err := e.getDocument(ctx, r.DocumentID)
+		if err != nil {
+			return results
+		}
+		candidates = append(candidates, r)
+		passages = append(passages, rerankPassage(doc))
+	}
+	scores, err := e.reranker.Score(ctx, queryStr, passages)
+	if err != nil || len(scores) != len(candidates) {
+		return results
+	}
+
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+	out := make([]*FusedResult, 0, len(results))
+	for _, i := range order {
+		out = append(out, candidates[i])
+	}
+	return append(out, results[topK:]...)
+}
+
+// rerankPassage is the text scored against the query: the title followed by the content.
+// The reranker's tokenizer truncates it to its token limit.
+func rerankPassage(doc *models.Document) string {
+	if doc.Title == "" {
+		return doc.Content
+	}
+	return doc.Title + "\n" + doc.Content
+}
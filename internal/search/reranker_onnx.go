@@ -0,0 +1,125 @@
+//go:build cgo
+// +build cgo
+
+package search
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/hyperjump/sagasu/internal/embedding"
+	ort "github.com/yalue/onnxruntime_go"
+)
+
+// ONNXReranker runs a BERT-style cross-encoder that reads "[CLS] query [SEP] passage [SEP]"
+// and outputs one relevance logit. It requires CGO and the onnxruntime shared library.
+type ONNXReranker struct {
+	session             *ort.AdvancedSession
+	tokenizer           *embedding.WordPiece
+	maxTokens           int
+	inputIDsTensor      *ort.Tensor[int64]
+	attentionMaskTensor *ort.Tensor[int64]
+	tokenTypeIDsTensor  *ort.Tensor[int64]
+	outputTensor        *ort.Tensor[float32]
+	mu                  sync.Mutex
+}
+
+// NewONNXReranker loads the cross-encoder at modelPath, which reads the token IDs of
+// tokenizer, the model's own vocabulary. The model must take input_ids, attention_mask,
+// and token_type_ids of shape (1, maxTokens) and produce "logits" of shape (1, 1).
+func NewONNXReranker(modelPath string, tokenizer *embedding.WordPiece, maxTokens int) (*ONNXReranker, error) {
+	if maxTokens <= 0 {
+		maxTokens = 256
+	}
+	// The embedder may already have initialized the shared runtime environment.
+	if !ort.IsInitialized() {
+		if err := ort.InitializeEnvironment(); err != nil {
+			return nil, fmt.Errorf("failed to initialize ONNX runtime: %w", err)
+		}
+	}
+	shape := ort.NewShape(1, int64(maxTokens))
+	inputIDsTensor, err := ort.NewEmptyTensor[int64](shape)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input_ids tensor: %w", err)
+	}
+	attentionMaskTensor, err := ort.NewEmptyTensor[int64](shape)
+	if err != nil {
+		inputIDsTensor.Destroy()
+		return nil, fmt.Errorf("failed to create attention_mask tensor: %w", err)
+	}
+	tokenTypeIDsTensor, err := ort.NewEmptyTensor[int64](shape)
+	if err != nil {
+		inputIDsTensor.Destroy()
+		attentionMaskTensor.Destroy()
+		return nil, fmt.Errorf("failed to create token_type_ids tensor: %w", err)
+	}
+	outputTensor, err := ort.NewEmptyTensor[float32](ort.NewShape(1, 1))
+	if err != nil {
+		inputIDsTensor.Destroy()
+		attentionMaskTensor.Destroy()
+		tokenTypeIDsTensor.Destroy()
+		return nil, fmt.Errorf("failed to create output tensor: %w", err)
+	}
+	session, err := ort.NewAdvancedSession(
+		modelPath,
+		[]string{"input_ids", "attention_mask", "token_type_ids"},
+		[]string{"logits"},
+		[]ort.ArbitraryTensor{inputIDsTensor, attentionMaskTensor, tokenTypeIDsTensor},
+		[]ort.ArbitraryTensor{outputTensor},
+		nil,
+	)
+	if err != nil {
+		inputIDsTensor.Destroy()
+		attentionMaskTensor.Destroy()
+		tokenTypeIDsTensor.Destroy()
+		outputTensor.Destroy()
+		return nil, fmt.Errorf("failed to create ONNX session: %w", err)
+	}
+	return &ONNXReranker{
+		session:             session,
+		tokenizer:           tokenizer,
+		maxTokens:           maxTokens,
+		inputIDsTensor:      inputIDsTensor,
+		attentionMaskTensor: attentionMaskTensor,
+		tokenTypeIDsTensor:  tokenTypeIDsTensor,
+		outputTensor:        outputTensor,
+	}, nil
+}
+
+// Score runs the cross-encoder once per passage and returns the logits.
+func (r *ONNXReranker) Score(ctx context.Context, query string, passages []string) ([]float32, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	scores := make([]float32, len(passages))
+	for i, passage := range passages {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		inputIDs, attentionMask, tokenTypeIDs := r.tokenizer.TokenizePair(query, passage, r.maxTokens)
+		copy(r.inputIDsTensor.GetData(), inputIDs)
+		copy(r.attentionMaskTensor.GetData(), attentionMask)
+		copy(r.tokenTypeIDsTensor.GetData(), tokenTypeIDs)
+		if err := r.session.Run(); err != nil {
+			return nil, fmt.Errorf("inference failed: %w", err)
+		}
+		scores[i] = r.outputTensor.GetData()[0]
+	}
+	return scores, nil
+}
+
+// Close destroys the session and tensors.
+func (r *ONNXReranker) Close() error {
+	var err error
+	if r.session != nil {
+		err = r.session.Destroy()
+		r.session = nil
+	}
+	for _, t := range []interface{ Destroy() error }{r.inputIDsTensor, r.attentionMaskTensor, r.tokenTypeIDsTensor, r.outputTensor} {
+		if t != nil {
+			_ = t.Destroy()
+		}
+	}
+	r.inputIDsTensor, r.attentionMaskTensor, r.tokenTypeIDsTensor, r.outputTensor = nil, nil, nil, nil
+	return err
+}
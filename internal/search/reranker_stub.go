@@ -0,0 +1,27 @@
+//go:build !cgo
+// +build !cgo
+
+package search
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hyperjump/sagasu/internal/embedding"
+)
+
+// ONNXReranker stub type when built without CGO (see reranker_onnx.go for real implementation).
+type ONNXReranker struct{}
+
+// NewONNXReranker returns an error when built without CGO (ONNX not available).
+func NewONNXReranker(_ string, _ *embedding.WordPiece, _ int) (*ONNXReranker, error) {
+	return nil, errors.New("ONNX reranker requires CGO; build with CGO_ENABLED=1 and onnxruntime")
+}
+
+// Score is never reached because NewONNXReranker always fails without CGO.
+func (r *ONNXReranker) Score(_ context.Context, _ string, _ []string) ([]float32, error) {
+	return nil, errors.New("ONNX reranker requires CGO")
+}
+
+// Close is a no-op.
+func (r *ONNXReranker) Close() error { return nil }
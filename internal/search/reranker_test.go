@@ -0,0 +1,171 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+// wordReranker scores passages by whether they contain a marker word.
+type wordReranker struct {
+	marker string
+	err    error
+}
+
+func (r *wordReranker) Score(_ context.Context, _ string, passages []string) ([]float32, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	scores := make([]float32, len(passages))
+	for i, p := range passages {
+		if strings.Contains(p, r.marker) {
+			scores[i] = 1
+		}
+	}
+	return scores, nil
+}
+
+func (r *wordReranker) Close() error { return nil }
+
+func TestLoadReranker_missingModel(t *testing.T) {
+	r, err := LoadReranker("", 128)
+	if r != nil || err != nil {
+		t.Errorf("empty path: got %v, %v; want nil, nil", r, err)
+	}
+	r, err = LoadReranker(filepath.Join(t.TempDir(), "missing.onnx"), 128)
+	if r != nil || err != nil {
+		t.Errorf("missing file: got %v, %v; want nil, nil", r, err)
+	}
+	model := filepath.Join(t.TempDir(), "model.onnx")
+	if err := os.WriteFile(model, []byte("onnx"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if r, err = LoadReranker(model, 128); r != nil || err == nil {
+		t.Errorf("model without vocabulary: got %v, %v; want an error", r, err)
+	}
+}
+
+func TestEngine_rerankCandidates(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10, RerankerTopK: 2}
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for _, d := range []struct{ id, content string }{
+		{"a", "first"}, {"b", "second relevant"}, {"c", "third relevant"},
+	} {
+		if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: d.id, Content: d.content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fused := []*FusedResult{{DocumentID: "a", Score: 0.9}, {DocumentID: "b", Score: 0.8}, {DocumentID: "c", Score: 0.7}}
+	ids := func(rs []*FusedResult) string {
+		var out []string
+		for _, r := range rs {
+			out = append(out, r.DocumentID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	if got := ids(engine.rerankCandidates(ctx, cfg, "q", fused)); got != "a,b,c" {
+		t.Errorf("without reranker: got %s", got)
+	}
+
+	engine.WithReranker(&wordReranker{marker: "relevant"})
+	// Only the top 2 are re-scored; "c" stays in place even though it matches.
+	if got := ids(engine.rerankCandidates(ctx, cfg, "q", append([]*FusedResult(nil), fused...))); got != "b,a,c" {
+		t.Errorf("reranked: got %s, want b,a,c", got)
+	}
+
+	engine.WithReranker(&wordReranker{err: errors.New("model failed")})
+	if got := ids(engine.rerankCandidates(ctx, cfg, "q", append([]*FusedResult(nil), fused...))); got != "a,b,c" {
+		t.Errorf("reranker error should keep fused order, got %s", got)
+	}
+}
+
+func TestEngine_Search_rankingKeepsRerankedOrder(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	cfg := &config.Config{}
+	config.ApplyDefaults(cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, &cfg.Search, nil)
+	for _, d := range []struct{ id, title, content string }{
+		{"budget", "budget", "the budget " + strings.Repeat("lorem ipsum ", 20)}, {"notes", "budget notes", "budget budget budget, relevant"}, {"memo", "memo", "budget budget memo"},
+	} {
+		if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: d.id, Title: d.title, Content: d.content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, &cfg.Search).WithRanking(&cfg.Ranking)
+	order := func(ranking bool, lambda float64) string {
+		t.Helper()
+		search := cfg.Search
+		search.RankingEnabled = ranking
+		engine.SetConfig(&search)
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: "budget", Limit: 10, KeywordEnabled: true, DiversityLambda: &lambda})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, r := range resp.NonSemanticResults {
+			ids = append(ids, r.Document.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	// The content ranker alone prefers the title matches.
+	if got := order(true, 0); got != "budget,notes,memo" {
+		t.Fatalf("ranking only: got %s", got)
+	}
+	for _, tc := range []struct {
+		name     string
+		reranker Reranker
+		lambda   float64
+	}{
+		{"reranker", &wordReranker{marker: "relevant"}, 0},
+		{"diversification", nil, 0.8},
+		{"reranker and diversification", &wordReranker{marker: "relevant"}, 0.8},
+	} {
+		engine.WithReranker(tc.reranker)
+		want := order(false, tc.lambda)
+		if got := order(true, tc.lambda); got != want {
+			t.Errorf("%s: ranking enabled gives %s, want %s", tc.name, got, want)
+		}
+		if want == "budget,notes,memo" {
+			t.Errorf("%s: order %s was not changed by the reranker or diversification", tc.name, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package search
+
+import (
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// scopeFilter holds the path: and ext: filters of a query. They are matched against the
+// document's source path. Values of the same field are alternatives and different fields
+// must all match; excluded values (-path:, -ext:) must not match.
+type scopeFilter struct {
+	paths, exts       []string
+	notPaths, notExts []string
+}
+
+// parseScopeFilters removes path:value and ext:value tokens (optionally negated with "-",
+// values optionally quoted) from query and returns the remaining text with the filters.
+// The filter is nil when the query has none; the query is then returned unchanged.
+func parseScopeFilters(query string) (string, *scopeFilter) {
+	var f scopeFilter
+	var rest []string
+	found := false
+	for _, tok := range splitQueryTokens(query) {
+		negated := strings.HasPrefix(tok, "-")
+		field, value, ok := strings.Cut(strings.TrimPrefix(tok, "-"), ":")
+		value = strings.ToLower(strings.Trim(value, `"`))
+		if !ok || value == "" || (field != "path" && field != "ext") {
+			rest = append(rest, tok)
+			continue
+		}
+		found = true
+		switch {
+		case field == "path" && negated:
+			f.notPaths = append(f.notPaths, value)
+		case field == "path":
+			f.paths = append(f.paths, value)
+		case negated:
+			f.notExts = append(f.notExts, strings.TrimPrefix(value, "."))
+		default:
+			f.exts = append(f.exts, strings.TrimPrefix(value, "."))
+		}
+	}
+	if !found {
+		return query, nil
+	}
+	return strings.Join(rest, " "), &f
+}
+
+// splitQueryTokens splits query on whitespace outside double quotes.
+func splitQueryTokens(query string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inQuote := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			cur.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuote:
+			if cur.Len() > 0 {
+				tokens = append(tokens, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if cur.Len() > 0 {
+		tokens = append(tokens, cur.String())
+	}
+	return tokens
+}
+
+// matches reports whether doc's source path satisfies the filter. Paths match when they
+// contain the value (case-insensitive); extensions match without the leading dot.
+// Documents without a source path only pass a filter that has no path: or ext: values.
+func (f *scopeFilter) matches(doc *models.Document) bool {
+	path := ""
+	if doc.Metadata != nil {
+		path, _ = doc.Metadata["source_path"].(string)
+	}
+	path = strings.ToLower(path)
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+
+	if len(f.paths) > 0 && !containsAny(path, f.paths) {
+		return false
+	}
+	if len(f.exts) > 0 && !equalsAny(ext, f.exts) {
+		return false
+	}
+	if path != "" && containsAny(path, f.notPaths) {
+		return false
+	}
+	return ext == "" || !equalsAny(ext, f.notExts)
+}
+
+func containsAny(s string, subs []string) bool {
+	for _, sub := range subs {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalsAny(s string, values []string) bool {
+	for _, v := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
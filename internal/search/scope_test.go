@@ -0,0 +1,112 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestParseScopeFilters(t *testing.T) {
+	text, f := parseScopeFilters(`title:budget ext:PDF path:"My Docs" -ext:.tmp report`)
+	if text != "title:budget report" {
+		t.Errorf("text = %q", text)
+	}
+	want := &scopeFilter{paths: []string{"my docs"}, exts: []string{"pdf"}, notExts: []string{"tmp"}}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("filter = %+v, want %+v", f, want)
+	}
+
+	if text, f := parseScopeFilters("plain  query"); text != "plain  query" || f != nil {
+		t.Errorf("unscoped query: got %q, %+v", text, f)
+	}
+}
+
+func TestScopeFilter_matches(t *testing.T) {
+	doc := func(path string) *models.Document {
+		if path == "" {
+			return &models.Document{}
+		}
+		return &models.Document{Metadata: map[string]interface{}{"source_path": path}}
+	}
+	tests := []struct {
+		filter scopeFilter
+		path   string
+		want   bool
+	}{
+		{scopeFilter{exts: []string{"pdf", "docx"}}, "/p/Report.DOCX", true},
+		{scopeFilter{exts: []string{"pdf"}}, "/p/report.txt", false},
+		{scopeFilter{paths: []string{"projects"}}, "/home/me/Projects/a.txt", true},
+		{scopeFilter{paths: []string{"projects"}, exts: []string{"pdf"}}, "/home/me/projects/a.txt", false},
+		{scopeFilter{notPaths: []string{"archive"}}, "/docs/archive/a.txt", false},
+		{scopeFilter{notExts: []string{"tmp"}}, "/docs/a.txt", true},
+		{scopeFilter{exts: []string{"pdf"}}, "", false},
+		{scopeFilter{notExts: []string{"pdf"}}, "", true},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.matches(doc(tt.path)); got != tt.want {
+			t.Errorf("%+v matches(%q) = %v, want %v", tt.filter, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestEngine_Search_FieldScopedQuery(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	defer emb.Close()
+	vecIndex, err := vector.NewMemoryIndex(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vecIndex.Close()
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{
+		TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10,
+		DefaultKeywordEnabled: true, DefaultSemanticEnabled: true,
+	}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for _, in := range []*models.DocumentInput{
+		{ID: "pdf", Title: "budget 2026", Content: "quarterly report", Metadata: map[string]interface{}{"source_path": "/docs/budget.pdf"}},
+		{ID: "txt", Title: "budget notes", Content: "quarterly report", Metadata: map[string]interface{}{"source_path": "/docs/budget.txt"}},
+		{ID: "other", Title: "minutes", Content: "budget report", Metadata: map[string]interface{}{"source_path": "/docs/minutes.pdf"}},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := engine.Search(ctx, &models.SearchQuery{
+		Query: "title:budget ext:pdf report", Limit: 10, KeywordEnabled: true, SemanticEnabled: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range append(resp.NonSemanticResults, resp.SemanticResults...) {
+		ids = append(ids, r.Document.ID)
+	}
+	if len(ids) != 1 || ids[0] != "pdf" {
+		t.Errorf("results = %v, want [pdf]", ids)
+	}
+	if resp.Query != "title:budget ext:pdf report" {
+		t.Errorf("response query = %q, want the original query", resp.Query)
+	}
+}
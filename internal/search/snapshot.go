@@ -0,0 +1,50 @@
+package search
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// snapshotCache keeps the documents of snapshots searched recently. Snapshots cannot
+// change, so an entry stays valid while a snapshot of its name has the same creation time.
+type snapshotCache struct {
+	mu      sync.Mutex
+	entries map[string]snapshotEntry
+}
+
+type snapshotEntry struct {
+	createdAt time.Time
+	docs      map[string]string
+}
+
+// loadSnapshot loads the documents of the snapshot f targets, if any. It returns
+// storage.ErrSnapshotNotFound for an unknown snapshot.
+func (e *Engine) loadSnapshot(ctx context.Context, f *docFilter) error {
+	if f == nil || f.snapshot == "" {
+		return nil
+	}
+	snap, err := e.storage.GetSnapshot(ctx, f.snapshot)
+	if err != nil {
+		return err
+	}
+	e.snapshots.mu.Lock()
+	entry, ok := e.snapshots.entries[snap.Name]
+	e.snapshots.mu.Unlock()
+	if ok && entry.createdAt.Equal(snap.CreatedAt) {
+		f.snapshotDocs = entry.docs
+		return nil
+	}
+	docs, err := e.storage.SnapshotDocuments(ctx, snap.Name)
+	if err != nil {
+		return err
+	}
+	e.snapshots.mu.Lock()
+	if e.snapshots.entries == nil {
+		e.snapshots.entries = make(map[string]snapshotEntry)
+	}
+	e.snapshots.entries[snap.Name] = snapshotEntry{createdAt: snap.CreatedAt, docs: docs}
+	e.snapshots.mu.Unlock()
+	f.snapshotDocs = docs
+	return nil
+}
@@ -0,0 +1,88 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_Search_snapshot(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	index := func(id, content string) {
+		t.Helper()
+		if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: id, Content: content}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	index("kept", "budget review notes")
+	index("edited", "budget forecast draft")
+	if _, err := store.CreateSnapshot(ctx, "eval"); err != nil {
+		t.Fatal(err)
+	}
+	// Indexed mid-run: a new document and a changed one.
+	index("added", "budget summary")
+	if err := idx.DeleteDocument(ctx, "edited"); err != nil {
+		t.Fatal(err)
+	}
+	index("edited", "budget forecast final")
+
+	search := func(snapshot string) ([]string, error) {
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: "budget", Limit: 10, KeywordEnabled: true, Snapshot: snapshot})
+		if err != nil {
+			return nil, err
+		}
+		return resultIDs(resp.NonSemanticResults), nil
+	}
+	if ids, err := search(""); err != nil || len(ids) != 3 {
+		t.Fatalf("without a snapshot: got %v, %v", ids, err)
+	}
+	ids, err := search("eval")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || ids[0] != "kept" {
+		t.Errorf("snapshot should keep only unchanged documents it recorded, got %v", ids)
+	}
+	n, err := engine.Count(ctx, &models.SearchQuery{Query: "budget", Snapshot: "eval"})
+	if err != nil || n != 1 {
+		t.Errorf("Count in snapshot: got %d, %v", n, err)
+	}
+	if _, err := search("missing"); !errors.Is(err, storage.ErrSnapshotNotFound) {
+		t.Errorf("unknown snapshot: got %v, want ErrSnapshotNotFound", err)
+	}
+
+	// A snapshot taken again under the same name replaces the cached one.
+	if err := store.DeleteSnapshot(ctx, "eval"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.CreateSnapshot(ctx, "eval"); err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := search("eval"); err != nil || len(ids) != 3 {
+		t.Errorf("retaken snapshot: got %v, %v", ids, err)
+	}
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// sortByField orders results by query.SortBy in query.SortDescending order, breaking ties
+// by score. Results whose document cannot be loaded are dropped, and documents without a
+// source size sort after those with one in either direction.
+func (e *Engine) sortByField(ctx context.Context, results []*FusedResult, query *models.SearchQuery) []*FusedResult {
+	type sortItem struct {
+		result  *FusedResult
+		doc     *models.Document
+		size    int64
+		hasSize bool
+	}
+	items := make([]sortItem, 0, len(results))
+	for _, r := range results {
+		doc, err := e.getDocument(ctx, r.DocumentID)
+		if err != nil {
+			continue
+		}
+		size, ok := metadataInt64(doc.Metadata, "source_size")
+		items = append(items, sortItem{result: r, doc: doc, size: size, hasSize: ok})
+	}
+	desc := query.SortDescending()
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		var c int
+		switch query.SortBy {
+		case models.SortByModifiedTime:
+			c = documentModTime(a.doc).Compare(documentModTime(b.doc))
+		case models.SortByTitle:
+			c = strings.Compare(strings.ToLower(a.doc.Title), strings.ToLower(b.doc.Title))
+		case models.SortBySize:
+			if a.hasSize != b.hasSize {
+				return a.hasSize
+			}
+			c = compareInt64(a.size, b.size)
+		}
+		if c == 0 {
+			return a.result.Score > b.result.Score
+		}
+		if desc {
+			return c > 0
+		}
+		return c < 0
+	})
+	out := make([]*FusedResult, len(items))
+	for i, it := range items {
+		out[i] = it.result
+	}
+	return out
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
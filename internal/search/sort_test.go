@@ -0,0 +1,93 @@
+package search
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func TestEngine_Search_SortBy(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	defer emb.Close()
+	vecIndex, err := vector.NewMemoryIndex(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer vecIndex.Close()
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+
+	noDedupe := false
+	cfg := &config.SearchConfig{
+		TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10,
+		DefaultKeywordEnabled: true, DefaultSemanticEnabled: true,
+		DedupeEnabled: &noDedupe, // the documents share their content so only the sort orders them
+	}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	mtime := func(age time.Duration) string { return strconv.FormatInt(time.Now().Add(-age).UnixNano(), 10) }
+	for _, in := range []*models.DocumentInput{
+		{ID: "week", Title: "beta", Content: "status report", Metadata: map[string]interface{}{
+			"source_mtime": mtime(7 * 24 * time.Hour), "source_size": "300"}},
+		{ID: "hour", Title: "Alpha", Content: "status report", Metadata: map[string]interface{}{
+			"source_mtime": mtime(time.Hour), "source_size": "100"}},
+		{ID: "day", Title: "gamma", Content: "status report", Metadata: map[string]interface{}{
+			"source_mtime": mtime(24 * time.Hour)}},
+	} {
+		if err := idx.IndexDocument(ctx, in); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		sortBy, sortOrder string
+		want              []string
+	}{
+		{models.SortByModifiedTime, "", []string{"hour", "day", "week"}},
+		{models.SortByModifiedTime, models.SortAsc, []string{"week", "day", "hour"}},
+		{models.SortByTitle, "", []string{"hour", "week", "day"}},
+		{models.SortBySize, "", []string{"week", "hour", "day"}},
+		{models.SortBySize, models.SortAsc, []string{"hour", "week", "day"}},
+	}
+	for _, tt := range tests {
+		resp, err := engine.Search(ctx, &models.SearchQuery{
+			Query: "status report", Limit: 10, KeywordEnabled: true,
+			SortBy: tt.sortBy, SortOrder: tt.sortOrder,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, r := range resp.NonSemanticResults {
+			ids = append(ids, r.Document.ID)
+		}
+		if len(ids) != len(tt.want) {
+			t.Errorf("%s %s: got %v, want %v", tt.sortBy, tt.sortOrder, ids, tt.want)
+			continue
+		}
+		for i := range ids {
+			if ids[i] != tt.want[i] {
+				t.Errorf("%s %s: got %v, want %v", tt.sortBy, tt.sortOrder, ids, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package search
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Synonyms maps a lower-case term to the terms and phrases that also match it.
+type Synonyms map[string][]string
+
+// LoadSynonyms reads a YAML synonym dictionary: a map of a term to its synonyms, e.g.
+// "car: [automobile, auto, motor vehicle]". Each entry is a group whose members all match
+// one another, so "automobile" also finds "car". Multi-word synonyms match as phrases;
+// they are looked up only as synonyms of single-word terms.
+func LoadSynonyms(path string) (Synonyms, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read synonyms: %w", err)
+	}
+	var groups map[string][]string
+	if err := yaml.Unmarshal(data, &groups); err != nil {
+		return nil, fmt.Errorf("failed to parse synonyms %s: %w", path, err)
+	}
+	syn := make(Synonyms)
+	for term, synonyms := range groups {
+		group := []string{normalizeSynonym(term)}
+		for _, s := range synonyms {
+			group = append(group, normalizeSynonym(s))
+		}
+		for _, member := range group {
+			if member == "" || strings.Contains(member, " ") {
+				continue
+			}
+			for _, other := range group {
+				if other != "" && other != member && !slices.Contains(syn[member], other) {
+					syn[member] = append(syn[member], other)
+				}
+			}
+		}
+	}
+	for term := range syn {
+		slices.Sort(syn[term])
+	}
+	return syn, nil
+}
+
+// normalizeSynonym lower-cases s and collapses its whitespace.
+func normalizeSynonym(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+// Expand returns the synonyms of the given terms, keyed by term; nil when none has any.
+func (s Synonyms) Expand(terms []string) map[string][]string {
+	var out map[string][]string
+	for _, term := range terms {
+		if alts := s[strings.ToLower(term)]; len(alts) > 0 {
+			if out == nil {
+				out = make(map[string][]string)
+			}
+			out[term] = alts
+		}
+	}
+	return out
+}
+
+// Blend appends to text the synonyms of its words that it does not already contain, so
+// the embedded query leans towards every wording of it.
+func (s Synonyms) Blend(text string) string {
+	words := strings.Fields(strings.ToLower(text))
+	lower := " " + strings.Join(words, " ") + " "
+	var extra []string
+	for _, w := range words {
+		for _, alt := range s[strings.Trim(w, `"'.,;:!?()`)] {
+			if !strings.Contains(lower, " "+alt+" ") && !slices.Contains(extra, alt) {
+				extra = append(extra, alt)
+			}
+		}
+	}
+	if len(extra) == 0 {
+		return text
+	}
+	return text + " " + strings.Join(extra, " ")
+}
@@ -0,0 +1,123 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+func writeSynonyms(t *testing.T, content string) Synonyms {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "synonyms.yaml")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	syn, err := LoadSynonyms(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return syn
+}
+
+func TestLoadSynonyms(t *testing.T) {
+	syn := writeSynonyms(t, "Car: [automobile, Motor  Vehicle]\ninvoice: [bill]\n")
+	want := Synonyms{
+		"car":        {"automobile", "motor vehicle"},
+		"automobile": {"car", "motor vehicle"},
+		"invoice":    {"bill"},
+		"bill":       {"invoice"},
+	}
+	if !reflect.DeepEqual(syn, want) {
+		t.Errorf("LoadSynonyms = %v, want %v", syn, want)
+	}
+
+	if got := syn.Expand([]string{"car", "report"}); !reflect.DeepEqual(got, map[string][]string{"car": {"automobile", "motor vehicle"}}) {
+		t.Errorf("Expand = %v", got)
+	}
+	if got := syn.Expand([]string{"report"}); got != nil {
+		t.Errorf("Expand without synonyms = %v, want nil", got)
+	}
+	if got := syn.Blend("car automobile costs"); got != "car automobile costs motor vehicle" {
+		t.Errorf("Blend = %q", got)
+	}
+
+	if _, err := LoadSynonyms(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+	path := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := os.WriteFile(path, []byte("car: automobile: auto\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSynonyms(path); err == nil {
+		t.Error("expected error for invalid YAML")
+	}
+}
+
+func TestEngine_Search_synonyms(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	kwIndex, err := keyword.NewBleveIndex(filepath.Join(t.TempDir(), "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10, KeywordTitleBoost: 3, KeywordPhraseBoost: 1.5, SynonymsInEmbeddings: true}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	for _, doc := range []*models.DocumentInput{
+		{ID: "auto", Title: "fleet.txt", Content: "Every automobile in the fleet needs new tyres."},
+		{ID: "car", Title: "parking.txt", Content: "The car park closes at night."},
+	} {
+		if err := idx.IndexDocument(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func() []string {
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: "car", Limit: 10, KeywordEnabled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []string
+		for _, r := range resp.NonSemanticResults {
+			ids = append(ids, r.Document.ID)
+		}
+		return ids
+	}
+	if got := search(); !reflect.DeepEqual(got, []string{"car"}) {
+		t.Errorf("without synonyms: %v", got)
+	}
+
+	engine.WithSynonyms(writeSynonyms(t, "car: [automobile]\n"))
+	if got := search(); !reflect.DeepEqual(got, []string{"car", "auto"}) {
+		t.Errorf("with synonyms: %v, want the literal match first", got)
+	}
+	n, err := engine.Count(ctx, &models.SearchQuery{Query: "car", KeywordEnabled: true})
+	if err != nil || n != 2 {
+		t.Errorf("Count = %d, %v; want 2", n, err)
+	}
+
+	exp, err := engine.Explain(&models.SearchQuery{Query: "car", KeywordEnabled: true, SemanticEnabled: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(exp.Synonyms, map[string][]string{"car": {"automobile"}}) || exp.SemanticText != "car automobile" {
+		t.Errorf("explain: synonyms %v, semantic text %q", exp.Synonyms, exp.SemanticText)
+	}
+}
@@ -0,0 +1,162 @@
+package search
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+// DefaultVectorCacheMinSimilarity is the cosine similarity above which a query embedding
+// reuses the cached results of another query.
+const DefaultVectorCacheMinSimilarity = 0.999
+
+// VectorCache is an LRU cache of vector search results keyed by query, so repeated or
+// near-identical queries (e.g. from a live-preview UI searching on every keystroke) do
+// not scan the vector index again. A query with the same text skips embedding as well;
+// one whose embedding is within the similarity threshold of a cached query reuses its
+// results. Any index write invalidates every entry, since new chunks may belong in any
+// result list.
+type VectorCache struct {
+	capacity      int
+	minSimilarity float64
+	entries       map[vectorCacheKey]*list.Element
+	lru           *list.List
+	// gen is bumped on every invalidation; results computed before an invalidation are
+	// not cached.
+	gen uint64
+	mu  sync.Mutex
+}
+
+// vectorCacheKey identifies a query in one semantic space (index into the engine's spaces).
+type vectorCacheKey struct {
+	space int
+	text  string
+}
+
+type vectorCacheEntry struct {
+	key       vectorCacheKey
+	embedding []float32
+	k         int
+	hits      []*vector.VectorResult
+}
+
+// NewVectorCache creates a cache holding the results of up to capacity queries. A
+// minSimilarity outside (0, 1] uses DefaultVectorCacheMinSimilarity.
+func NewVectorCache(capacity int, minSimilarity float64) *VectorCache {
+	if minSimilarity <= 0 || minSimilarity > 1 {
+		minSimilarity = DefaultVectorCacheMinSimilarity
+	}
+	return &VectorCache{
+		capacity:      capacity,
+		minSimilarity: minSimilarity,
+		entries:       make(map[vectorCacheKey]*list.Element),
+		lru:           list.New(),
+	}
+}
+
+// getText returns the k nearest chunks cached for text in space.
+func (c *VectorCache) getText(space int, text string, k int) ([]*vector.VectorResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[vectorCacheKey{space, text}]
+	if !ok || !elem.Value.(*vectorCacheEntry).covers(k) {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*vectorCacheEntry).top(k), true
+}
+
+// getSimilar returns the k nearest chunks cached for the query in space whose embedding
+// is most similar to embedding, if that similarity reaches the threshold.
+func (c *VectorCache) getSimilar(space int, embedding []float32, k int) ([]*vector.VectorResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var best *list.Element
+	bestSim := c.minSimilarity
+	for elem := c.lru.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*vectorCacheEntry)
+		if entry.key.space != space || !entry.covers(k) {
+			continue
+		}
+		if sim := vector.CosineSimilarity(embedding, entry.embedding); sim >= bestSim {
+			best, bestSim = elem, sim
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	c.lru.MoveToFront(best)
+	return best.Value.(*vectorCacheEntry).top(k), true
+}
+
+// generation returns the current invalidation generation.
+func (c *VectorCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// add caches the k nearest chunks to text in space unless the cache was invalidated
+// since gen was read.
+func (c *VectorCache) add(space int, text string, embedding []float32, k int, hits []*vector.VectorResult, gen uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return
+	}
+	key := vectorCacheKey{space, text}
+	entry := &vectorCacheEntry{key: key, embedding: embedding, k: k, hits: hits}
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+		elem.Value = entry
+		return
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	if c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*vectorCacheEntry).key)
+	}
+}
+
+// InvalidateAll drops every cached result.
+func (c *VectorCache) InvalidateAll() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.entries = make(map[vectorCacheKey]*list.Element)
+	c.lru.Init()
+}
+
+// Len returns the number of cached queries.
+func (c *VectorCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// covers reports whether the entry holds at least the k nearest chunks. An entry with
+// fewer hits than it asked for holds every chunk there was, which covers any k.
+func (e *vectorCacheEntry) covers(k int) bool {
+	return e.k >= k || len(e.hits) < e.k
+}
+
+// top returns a copy of the first k hits.
+func (e *vectorCacheEntry) top(k int) []*vector.VectorResult {
+	if k > len(e.hits) {
+		k = len(e.hits)
+	}
+	return append([]*vector.VectorResult(nil), e.hits[:k]...)
+}
+
+// WithVectorCache enables caching the vector results of up to capacity queries, reusing
+// them for queries whose embeddings have at least minSimilarity cosine similarity. A
+// capacity <= 0 disables it.
+func (e *Engine) WithVectorCache(capacity int, minSimilarity float64) *Engine {
+	e.vectorCache = nil
+	if capacity > 0 {
+		e.vectorCache = NewVectorCache(capacity, minSimilarity)
+	}
+	return e
+}
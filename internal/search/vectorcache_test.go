@@ -0,0 +1,114 @@
+package search
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+)
+
+// countingIndex counts the searches that reach the wrapped index.
+type countingIndex struct {
+	vector.VectorIndex
+	searches atomic.Int32
+}
+
+func (c *countingIndex) Search(ctx context.Context, query []float32, k int) ([]*vector.VectorResult, error) {
+	c.searches.Add(1)
+	return c.VectorIndex.Search(ctx, query, k)
+}
+
+func TestVectorCache(t *testing.T) {
+	c := NewVectorCache(2, 0.99)
+	hits := []*vector.VectorResult{{ID: "a", Score: 0.9}, {ID: "b", Score: 0.8}, {ID: "c", Score: 0.7}}
+	gen := c.generation()
+	c.add(0, "alpha", []float32{1, 0}, 3, hits, gen)
+
+	if got, ok := c.getText(0, "alpha", 2); !ok || len(got) != 2 || got[1].ID != "b" {
+		t.Errorf("fewer hits than cached: got %v, %v", got, ok)
+	}
+	if _, ok := c.getText(0, "alpha", 5); ok {
+		t.Error("more hits than cached should miss")
+	}
+	if _, ok := c.getText(1, "alpha", 2); ok {
+		t.Error("another semantic space should miss")
+	}
+	if got, ok := c.getSimilar(0, []float32{0.9999, 0.0141}, 3); !ok || len(got) != 3 {
+		t.Errorf("near-identical embedding should hit, got %v, %v", got, ok)
+	}
+	if _, ok := c.getSimilar(0, []float32{0.6, 0.8}, 3); ok {
+		t.Error("dissimilar embedding should miss")
+	}
+
+	// A query that found fewer chunks than it asked for found all there were.
+	c.add(0, "rare", []float32{0, 1}, 10, hits[:1], gen)
+	if got, ok := c.getText(0, "rare", 20); !ok || len(got) != 1 {
+		t.Errorf("exhaustive entry should cover any k, got %v, %v", got, ok)
+	}
+
+	c.add(0, "third", []float32{0.6, 0.8}, 3, hits, gen) // evicts alpha
+	if _, ok := c.getText(0, "alpha", 1); ok {
+		t.Error("expected alpha to be evicted")
+	}
+
+	c.InvalidateAll()
+	c.add(0, "stale", []float32{1, 0}, 3, hits, gen) // computed before the invalidation
+	if c.Len() != 0 {
+		t.Errorf("Len after InvalidateAll and stale add: got %d", c.Len())
+	}
+}
+
+func TestEngine_VectorCache_invalidatedByIndexer(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewSQLiteStorage(":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	emb := embedding.NewMockEmbedder(4)
+	mem, _ := vector.NewMemoryIndex(4)
+	vecIndex := &countingIndex{VectorIndex: mem}
+	kwIndex, err := keyword.NewBleveIndex(t.TempDir() + "/bleve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	// Both documents have the same content, which would otherwise be deduplicated.
+	noDedupe := false
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10, DedupeEnabled: &noDedupe}
+	engine := NewEngine(store, emb, vecIndex, kwIndex, cfg).WithVectorCache(10, 0)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil, indexer.WithInvalidator(engine))
+
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "d1", Title: "first", Content: "alpha"}); err != nil {
+		t.Fatal(err)
+	}
+	search := func() *models.SearchResponse {
+		resp, err := engine.Search(ctx, &models.SearchQuery{Query: "alpha", Limit: 5, SemanticEnabled: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	search()
+	search()
+	if n := vecIndex.searches.Load(); n != 1 {
+		t.Fatalf("repeated query should be served from the cache, got %d index searches", n)
+	}
+
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "d2", Title: "second", Content: "alpha"}); err != nil {
+		t.Fatal(err)
+	}
+	if resp := search(); resp.TotalSemantic != 2 {
+		t.Errorf("search after indexing should see the new document, got %d semantic results", resp.TotalSemantic)
+	}
+	if n := vecIndex.searches.Load(); n != 2 {
+		t.Errorf("indexing should invalidate the cache, got %d index searches", n)
+	}
+}
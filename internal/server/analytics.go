@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+// DefaultAnalyticsLimit is the number of top and zero-result queries GET /api/v1/analytics
+// lists without ?limit=.
+const DefaultAnalyticsLimit = 10
+
+// WithAnalytics records each search in the storage analytics log and accepts result
+// click feedback.
+func (s *Server) WithAnalytics() *Server {
+	s.analytics = true
+	return s
+}
+
+// recordSearch logs a search with the places of its results and sets response.QueryID
+// so that the client can report clicks. Later pages (a non-zero offset) of a search are
+// not counted again, nor are searches of a snapshot, which evaluation runs make.
+func (s *Server) recordSearch(ctx context.Context, query *models.SearchQuery, response *models.SearchResponse) {
+	if !s.analytics || query.Offset > 0 || query.Snapshot != "" {
+		return
+	}
+	event := &models.SearchEvent{
+		Query:           query.Query,
+		KeywordResults:  response.TotalNonSemantic,
+		SemanticResults: response.TotalSemantic,
+		LatencyMs:       response.QueryTime,
+		Results:         models.ResultRefs(response),
+	}
+	if err := s.storage.AppendSearchEvent(context.WithoutCancel(ctx), event); err != nil {
+		s.logger.Error("analytics write failed", zap.Error(err))
+		return
+	}
+	response.QueryID = event.ID
+}
+
+// handleFeedback records which result of a search the user opened.
+func (s *Server) handleFeedback(w http.ResponseWriter, r *http.Request) {
+	if !s.analytics {
+		s.respondError(w, http.StatusNotImplemented, "analytics is not enabled")
+		return
+	}
+	var req models.FeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.QueryID <= 0 || req.DocumentID == "" {
+		s.respondError(w, http.StatusBadRequest, "query_id and document_id are required")
+		return
+	}
+	if req.Rank < 0 {
+		s.respondError(w, http.StatusBadRequest, "rank cannot be negative")
+		return
+	}
+	if err := s.storage.RecordClick(r.Context(), req.QueryID, req.DocumentID, req.Rank); err != nil {
+		if errors.Is(err, storage.ErrSearchEventNotFound) {
+			s.respondError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		s.logger.Error("record click failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]string{"status": "recorded"})
+}
+
+// handleFeedbackOpen records that the user opened a result for a query. Every open is
+// kept; with a query_id, the open is also recorded as that search's click, as by
+// handleFeedback, unless the search is unknown.
+func (s *Server) handleFeedbackOpen(w http.ResponseWriter, r *http.Request) {
+	if !s.analytics {
+		s.respondError(w, http.StatusNotImplemented, "analytics is not enabled")
+		return
+	}
+	var req models.OpenFeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if strings.TrimSpace(req.Query) == "" || req.DocumentID == "" {
+		s.respondError(w, http.StatusBadRequest, "query and document_id are required")
+		return
+	}
+	if req.Rank < 0 || req.QueryID < 0 {
+		s.respondError(w, http.StatusBadRequest, "rank and query_id cannot be negative")
+		return
+	}
+	if req.QueryID > 0 {
+		err := s.storage.RecordClick(r.Context(), req.QueryID, req.DocumentID, req.Rank)
+		if err != nil && !errors.Is(err, storage.ErrSearchEventNotFound) {
+			s.logger.Error("record click failed", zap.Error(err))
+			s.respondError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	event := &models.OpenEvent{Query: req.Query, DocumentID: req.DocumentID, Rank: req.Rank, QueryID: req.QueryID}
+	if err := s.storage.RecordOpen(r.Context(), event); err != nil {
+		s.logger.Error("record open failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]string{"status": "recorded"})
+}
+
+// handleAnalytics reports the searches recorded in [?since=, ?until=) (RFC 3339, both
+// optional) with up to ?limit= top and zero-result queries (default 10).
+func (s *Server) handleAnalytics(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	since, until, err := timeRangeParams(q)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit, ok := positiveIntParam(q.Get("limit"), DefaultAnalyticsLimit)
+	if !ok {
+		s.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+		return
+	}
+	report, err := s.storage.AnalyticsReport(r.Context(), since, until, limit)
+	if err != nil {
+		s.logger.Error("analytics report failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, report)
+}
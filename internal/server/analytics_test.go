@@ -0,0 +1,136 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestAnalytics(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	ctx := context.Background()
+	_ = idx.IndexDocument(ctx, &models.DocumentInput{ID: "d1", Title: "Budget", Content: "quarterly budget review"})
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	searchFor := func(q string, offset int) *models.SearchResponse {
+		body, _ := json.Marshal(map[string]interface{}{"query": q, "keyword_enabled": true, "offset": offset})
+		w := httptest.NewRecorder()
+		srv.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search", bytes.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("search: status %d, body: %s", w.Code, w.Body.String())
+		}
+		var resp models.SearchResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		return &resp
+	}
+	feedback := func(req models.FeedbackRequest) int {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		srv.handleFeedback(w, httptest.NewRequest(http.MethodPost, "/api/v1/feedback", bytes.NewReader(body)))
+		return w.Code
+	}
+	openFeedback := func(req models.OpenFeedbackRequest) int {
+		body, _ := json.Marshal(req)
+		w := httptest.NewRecorder()
+		srv.handleFeedbackOpen(w, httptest.NewRequest(http.MethodPost, "/api/v1/feedback/open", bytes.NewReader(body)))
+		return w.Code
+	}
+	report := func(params string) (int, *models.AnalyticsReport) {
+		w := httptest.NewRecorder()
+		srv.handleAnalytics(w, httptest.NewRequest(http.MethodGet, "/api/v1/analytics?"+params, nil))
+		var resp models.AnalyticsReport
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, &resp
+	}
+
+	if resp := searchFor("budget", 0); resp.QueryID != 0 {
+		t.Errorf("query_id should be unset without WithAnalytics, got %d", resp.QueryID)
+	}
+	if code := feedback(models.FeedbackRequest{QueryID: 1, DocumentID: "d1"}); code != http.StatusNotImplemented {
+		t.Errorf("feedback without analytics: status %d, want 501", code)
+	}
+	if code := openFeedback(models.OpenFeedbackRequest{Query: "budget", DocumentID: "d1"}); code != http.StatusNotImplemented {
+		t.Errorf("open feedback without analytics: status %d, want 501", code)
+	}
+
+	srv.WithAnalytics()
+	first := searchFor("budget", 0)
+	if first.QueryID == 0 {
+		t.Fatal("search response should carry a query_id")
+	}
+	if resp := searchFor("budget", 10); resp.QueryID != 0 {
+		t.Errorf("later pages should not be recorded, got query_id %d", resp.QueryID)
+	}
+	searchFor("Budget", 0)
+	searchFor("zzzunknown", 0)
+
+	if code := feedback(models.FeedbackRequest{QueryID: first.QueryID, DocumentID: "d1", Rank: 1}); code != http.StatusOK {
+		t.Errorf("feedback: status %d", code)
+	}
+	if code := feedback(models.FeedbackRequest{QueryID: 9999, DocumentID: "d1"}); code != http.StatusNotFound {
+		t.Errorf("feedback for an unknown query: status %d, want 404", code)
+	}
+	if code := feedback(models.FeedbackRequest{QueryID: first.QueryID}); code != http.StatusBadRequest {
+		t.Errorf("feedback without document_id: status %d, want 400", code)
+	}
+	for _, req := range []models.OpenFeedbackRequest{
+		{Query: "budget", DocumentID: "d1", Rank: 1},
+		{Query: "Budget", DocumentID: "d1", Rank: 2},
+		{Query: "budget", DocumentID: "d1", QueryID: 9999}, // unknown searches still count as opens
+	} {
+		if code := openFeedback(req); code != http.StatusOK {
+			t.Errorf("open feedback %+v: status %d", req, code)
+		}
+	}
+	if code := openFeedback(models.OpenFeedbackRequest{Query: "  ", DocumentID: "d1"}); code != http.StatusBadRequest {
+		t.Errorf("open feedback without query: status %d, want 400", code)
+	}
+	if code := openFeedback(models.OpenFeedbackRequest{Query: "budget", DocumentID: "d1", Rank: -1}); code != http.StatusBadRequest {
+		t.Errorf("open feedback with a negative rank: status %d, want 400", code)
+	}
+
+	code, got := report("")
+	if code != http.StatusOK {
+		t.Fatalf("analytics: status %d", code)
+	}
+	if got.Searches != 3 || got.ZeroResultSearches != 1 || got.ClickedSearches != 1 {
+		t.Errorf("totals: got %+v", got)
+	}
+	if len(got.TopQueries) != 2 || got.TopQueries[0].Query != "budget" || got.TopQueries[0].Count != 2 || got.TopQueries[0].Clicks != 1 {
+		t.Errorf("top queries: got %+v", got.TopQueries)
+	}
+	if got.Opens != 3 || len(got.TopOpened) != 1 || got.TopOpened[0].DocumentID != "d1" || got.TopOpened[0].Opens != 3 {
+		t.Errorf("opens: got %d, %+v", got.Opens, got.TopOpened)
+	}
+	if len(got.ZeroResultQueries) != 1 || got.ZeroResultQueries[0].Query != "zzzunknown" {
+		t.Errorf("zero-result queries: got %+v", got.ZeroResultQueries)
+	}
+	if code, _ := report("limit=0"); code != http.StatusBadRequest {
+		t.Errorf("limit=0: status %d, want 400", code)
+	}
+	if code, _ := report("since=yesterday"); code != http.StatusBadRequest {
+		t.Errorf("bad since: status %d, want 400", code)
+	}
+}
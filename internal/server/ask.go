@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/hyperjump/sagasu/internal/llm"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+// WithLLM lets POST /api/v1/ask answer questions with c; without it the endpoint returns
+// the assembled context only.
+func (s *Server) WithLLM(c *llm.Client) *Server {
+	s.llm = c
+	return s
+}
+
+// handleAsk searches for the question in the request body, assembles the best chunks into
+// a context (see search.Engine.AskContext), and asks the LLM to answer from it with
+// citations. The context is returned alone when no LLM is configured, context_only is
+// set, or nothing was found.
+func (s *Server) handleAsk(w http.ResponseWriter, r *http.Request) {
+	var req models.AskRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := req.Validate(); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.AsOf != nil {
+		// Answers quote chunks, which are kept for current content only.
+		s.respondError(w, http.StatusBadRequest, "as_of is not supported by ask")
+		return
+	}
+	audit := &models.AuditEntry{Action: models.AuditAsk, Query: req.Query}
+	w, done := s.audited(w, r, audit)
+	defer done()
+
+	resp, err := s.engine.AskContext(r.Context(), &req)
+	if errors.Is(err, storage.ErrSnapshotNotFound) {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.Error("ask: context assembly failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	audit.Results = len(resp.Sources)
+	if s.llm != nil && !req.ContextOnly && len(resp.Sources) > 0 {
+		answer, err := s.llm.Chat(r.Context(), llm.AnswerMessages(req.Query, resp.Context))
+		if err != nil {
+			s.logger.Error("ask: llm request failed", zap.Error(err))
+			s.respondError(w, http.StatusBadGateway, err.Error())
+			return
+		}
+		resp.Answer = answer
+		resp.Model = s.llm.Model()
+	}
+	s.respondJSON(w, http.StatusOK, resp)
+}
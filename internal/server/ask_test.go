@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/llm"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestHandleAsk(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	_ = idx.IndexDocument(context.Background(), &models.DocumentInput{ID: "d1", Title: "Leave", Content: "Parental leave lasts sixteen weeks."})
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	ask := func(body string) (int, *models.AskResponse) {
+		w := httptest.NewRecorder()
+		srv.handleAsk(w, httptest.NewRequest(http.MethodPost, "/api/v1/ask", strings.NewReader(body)))
+		var resp models.AskResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, &resp
+	}
+
+	// Without an LLM the context is returned alone.
+	code, resp := ask(`{"query": "how long is parental leave", "keyword_enabled": true}`)
+	if code != http.StatusOK || resp.Answer != "" || len(resp.Sources) != 1 || !strings.Contains(resp.Context, "sixteen weeks") {
+		t.Fatalf("status %d, response %+v", code, resp)
+	}
+
+	var prompt []llm.Message
+	chat := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []llm.Message `json:"messages"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		prompt = req.Messages
+		_, _ = w.Write([]byte(`{"message": {"role": "assistant", "content": "Sixteen weeks [1]."}}`))
+	}))
+	defer chat.Close()
+	client, _ := llm.NewClient(llm.Config{Provider: llm.ProviderOllama, BaseURL: chat.URL, Model: "llama3.2"})
+	srv.WithLLM(client)
+
+	code, resp = ask(`{"query": "how long is parental leave", "keyword_enabled": true}`)
+	if code != http.StatusOK || resp.Answer != "Sixteen weeks [1]." || resp.Model != "ollama:llama3.2" {
+		t.Fatalf("status %d, response %+v", code, resp)
+	}
+	if len(prompt) != 2 || !strings.Contains(prompt[0].Content, "[1] Leave\nParental leave lasts sixteen weeks.") || prompt[1].Content != "how long is parental leave" {
+		t.Errorf("prompt = %+v", prompt)
+	}
+
+	prompt = nil
+	if _, resp = ask(`{"query": "parental leave", "keyword_enabled": true, "context_only": true}`); resp.Answer != "" || prompt != nil {
+		t.Errorf("context_only should not ask the LLM: %+v", resp)
+	}
+	if _, resp = ask(`{"query": "pension", "keyword_enabled": true}`); resp.Answer != "" || prompt != nil || len(resp.Sources) != 0 {
+		t.Errorf("nothing found should not ask the LLM: %+v", resp)
+	}
+
+	chat.Close()
+	if code, _ = ask(`{"query": "parental leave", "keyword_enabled": true}`); code != http.StatusBadGateway {
+		t.Errorf("unreachable LLM: status %d, want 502", code)
+	}
+	if code, _ = ask(`{"query": ""}`); code != http.StatusBadRequest {
+		t.Errorf("empty query: status %d, want 400", code)
+	}
+}
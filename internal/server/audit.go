@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+// WithAuditLog records searches and document content fetches in the storage audit log.
+func (s *Server) WithAuditLog() *Server {
+	s.auditLog = true
+	return s
+}
+
+// audited starts an audit record of the request. The handler writes its response to the
+// returned writer, fills in entry as it learns the query or result count, and defers done,
+// which stores entry with the response status. Without an audit log it returns w and a no-op.
+func (s *Server) audited(w http.ResponseWriter, r *http.Request, entry *models.AuditEntry) (http.ResponseWriter, func()) {
+	if !s.auditLog {
+		return w, func() {}
+	}
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	entry.Time = time.Now()
+	entry.Client = clientAddr(r)
+	entry.APIKey = apiKeyName(r)
+	return ww, func() {
+		entry.Status = ww.Status()
+		if entry.Status == 0 {
+			entry.Status = http.StatusOK
+		}
+		// Record requests the client gave up on too.
+		if err := s.storage.AppendAudit(context.WithoutCancel(r.Context()), entry); err != nil {
+			s.logger.Error("audit log write failed", zap.String("action", entry.Action), zap.Error(err))
+		}
+	}
+}
+
+// clientAddr returns the host part of the request's remote address.
+func clientAddr(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// timeRangeParams parses the optional ?since= and ?until= RFC 3339 times of q.
+func timeRangeParams(q url.Values) (since, until time.Time, err error) {
+	for _, p := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &since}, {"until", &until}} {
+		v := q.Get(p.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New(p.name + " must be an RFC 3339 time")
+		}
+		*p.t = t
+	}
+	return since, until, nil
+}
+
+// handleAuditList returns audit entries recorded in [?since=, ?until=) (RFC 3339, both
+// optional), oldest first, up to ?limit= entries (default all).
+func (s *Server) handleAuditList(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	since, until, err := timeRangeParams(q)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	entries, err := s.storage.ListAudit(r.Context(), since, until, limit)
+	if err != nil {
+		s.logger.Error("list audit log failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if entries == nil {
+		entries = []*models.AuditEntry{}
+	}
+	s.respondJSON(w, http.StatusOK, &models.AuditResponse{Entries: entries, Total: len(entries)})
+}
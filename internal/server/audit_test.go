@@ -0,0 +1,102 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestAuditLog(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	ctx := context.Background()
+	_ = idx.IndexDocument(ctx, &models.DocumentInput{ID: "d1", Title: "Budget", Content: "quarterly budget review"})
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	searchFor := func(q string) {
+		body, _ := json.Marshal(map[string]interface{}{"query": q, "keyword_enabled": true})
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/search", bytes.NewReader(body))
+		r.RemoteAddr = "10.0.0.7:51234"
+		w := httptest.NewRecorder()
+		srv.handleSearch(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("search: status %d, body: %s", w.Code, w.Body.String())
+		}
+	}
+	getDocument := func(id string) {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/documents/"+id, nil)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		srv.handleGetDocument(httptest.NewRecorder(), r)
+	}
+	list := func(params string) (int, *models.AuditResponse) {
+		w := httptest.NewRecorder()
+		srv.handleAuditList(w, httptest.NewRequest(http.MethodGet, "/api/v1/audit?"+params, nil))
+		var resp models.AuditResponse
+		_ = json.NewDecoder(w.Body).Decode(&resp)
+		return w.Code, &resp
+	}
+
+	searchFor("budget")
+	if _, resp := list(""); resp.Total != 0 {
+		t.Fatalf("nothing should be recorded without WithAuditLog, got %+v", resp.Entries)
+	}
+
+	srv.WithAuditLog()
+	start := time.Now().Add(-time.Second)
+	searchFor("budget")
+	getDocument("d1")
+	getDocument("missing")
+
+	code, resp := list("since=" + url.QueryEscape(start.Format(time.RFC3339)))
+	if code != http.StatusOK || resp.Total != 3 {
+		t.Fatalf("status %d, entries %+v", code, resp.Entries)
+	}
+	s, got, missing := resp.Entries[0], resp.Entries[1], resp.Entries[2]
+	if s.Action != models.AuditSearch || s.Query != "budget" || s.Results != 1 || s.Client != "10.0.0.7" || s.Status != http.StatusOK {
+		t.Errorf("search entry = %+v", s)
+	}
+	if got.Action != models.AuditDocumentGet || got.DocumentID != "d1" || got.Status != http.StatusOK {
+		t.Errorf("document entry = %+v", got)
+	}
+	if missing.DocumentID != "missing" || missing.Status != http.StatusNotFound {
+		t.Errorf("missing document entry = %+v", missing)
+	}
+
+	if _, resp = list("limit=1"); resp.Total != 1 {
+		t.Errorf("limit=1: got %d entries", resp.Total)
+	}
+	if _, resp = list("until=" + url.QueryEscape(start.Format(time.RFC3339))); resp.Total != 0 {
+		t.Errorf("until before the requests: got %+v", resp.Entries)
+	}
+	for _, bad := range []string{"since=yesterday", "limit=0"} {
+		if code, _ = list(bad); code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", bad, code)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"go.uber.org/zap"
+)
+
+// apiKeyNameKey is the request context key for the name of the API key a request used.
+type apiKeyNameKey struct{}
+
+// requireScope returns middleware that lets a request through when no API keys are
+// configured, or when it carries a key with scope (a write key also has read scope).
+// Requests without a valid key get 401; valid keys without the scope get 403.
+func (s *Server) requireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			keys := s.config.Auth.APIKeys
+			if len(keys) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			key := s.lookupAPIKey(requestAPIKey(r))
+			if key == nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="sagasu"`)
+				s.respondError(w, http.StatusUnauthorized, "missing or invalid API key")
+				return
+			}
+			if scope == config.ScopeWrite && key.Scope != config.ScopeWrite {
+				s.logger.Debug("api key lacks write scope", zap.String("key", key.Name), zap.String("path", r.URL.Path))
+				s.respondError(w, http.StatusForbidden, "API key "+key.Name+" is read-only")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyNameKey{}, key.Name)))
+		})
+	}
+}
+
+// lookupAPIKey returns the configured key whose secret is presented, or nil. Every key is
+// compared in constant time so response timing does not reveal partial matches.
+func (s *Server) lookupAPIKey(presented string) *config.APIKeyConfig {
+	if presented == "" {
+		return nil
+	}
+	var found *config.APIKeyConfig
+	for i := range s.config.Auth.APIKeys {
+		k := &s.config.Auth.APIKeys[i]
+		if subtle.ConstantTimeCompare([]byte(k.Secret()), []byte(presented)) == 1 {
+			found = k
+		}
+	}
+	return found
+}
+
+// requestAPIKey returns the key of an "Authorization: Bearer" or "X-API-Key" header.
+func requestAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Key"))
+}
+
+// apiKeyName returns the name of the API key r was authorized with, or "".
+func apiKeyName(r *http.Request) string {
+	name, _ := r.Context().Value(apiKeyNameKey{}).(string)
+	return name
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestAuth(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	serverCfg := &config.ServerConfig{Port: 8080}
+	srv := NewServer(engine, idx, store, serverCfg, zap.NewNop(), nil, "", nil).WithAuditLog()
+	handler := srv.routes()
+
+	do := func(method, path, body string, headers ...string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		for i := 0; i+1 < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	// Without keys everything is open.
+	if w := do(http.MethodGet, "/api/v1/status", ""); w.Code != http.StatusOK {
+		t.Fatalf("no keys: status %d", w.Code)
+	}
+
+	serverCfg.Auth.APIKeys = []config.APIKeyConfig{
+		{Name: "dashboard", Key: "read-key", Scope: config.ScopeRead},
+		{Name: "admin", Key: "write-key", Scope: config.ScopeWrite},
+	}
+	readKey := []string{"Authorization", "Bearer read-key"}
+	writeKey := []string{"X-API-Key", "write-key"}
+
+	w := do(http.MethodGet, "/api/v1/status", "")
+	if w.Code != http.StatusUnauthorized || w.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("no key: status %d, WWW-Authenticate %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+	if w = do(http.MethodGet, "/api/v1/status", "", "Authorization", "Bearer wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong key: status %d", w.Code)
+	}
+	for _, open := range []string{"/health", "/", "/assets/app.js"} {
+		if w = do(http.MethodGet, open, ""); w.Code != http.StatusOK {
+			t.Errorf("%s should not need a key: status %d", open, w.Code)
+		}
+	}
+
+	if w = do(http.MethodGet, "/api/v1/status", "", readKey...); w.Code != http.StatusOK {
+		t.Errorf("read key, status: %d", w.Code)
+	}
+	if w = do(http.MethodPost, "/api/v1/search", `{"query": "x", "keyword_enabled": true}`, "authorization", "bearer read-key"); w.Code != http.StatusOK {
+		t.Errorf("read key, search (lowercase scheme): %d %s", w.Code, w.Body.String())
+	}
+	for _, req := range [][2]string{
+		{http.MethodPost, "/api/v1/pins"},
+		{http.MethodDelete, "/api/v1/documents/d1"},
+		{http.MethodPost, "/api/v1/pause"},
+		{http.MethodGet, "/api/v1/audit"},
+	} {
+		if w = do(req[0], req[1], `{}`, readKey...); w.Code != http.StatusForbidden {
+			t.Errorf("read key, %s %s: status %d, want 403", req[0], req[1], w.Code)
+		}
+	}
+	if w = do(http.MethodPost, "/api/v1/pins", `{"query": "handbook", "path": "/docs/hr"}`, writeKey...); w.Code != http.StatusCreated {
+		t.Errorf("write key, create pin: %d %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodGet, "/api/v1/audit", "", writeKey...)
+	var audit models.AuditResponse
+	if err := json.NewDecoder(w.Body).Decode(&audit); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("audit: status %d, err %v", w.Code, err)
+	}
+	var searches []*models.AuditEntry
+	for _, e := range audit.Entries {
+		if e.Action == models.AuditSearch {
+			searches = append(searches, e)
+		}
+	}
+	if len(searches) != 1 || searches[0].APIKey != "dashboard" {
+		t.Errorf("audit should record the key name of the search: %+v", audit.Entries)
+	}
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+// MaxBatchDocuments is the most documents POST /api/v1/documents:batch accepts.
+const MaxBatchDocuments = 1000
+
+// handleIndexDocumentsBatch indexes an array of documents (JSON, or gob; see
+// ContentTypeGob) together and reports the outcome of each. The response is 200 even when
+// some documents failed.
+func (s *Server) handleIndexDocumentsBatch(w http.ResponseWriter, r *http.Request) {
+	var inputs []*models.DocumentInput
+	if err := decodeBody(r, &inputs); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid request body: expected an array of documents")
+		return
+	}
+	if len(inputs) == 0 {
+		s.respondError(w, http.StatusBadRequest, "no documents")
+		return
+	}
+	if len(inputs) > MaxBatchDocuments {
+		s.respondError(w, http.StatusBadRequest, fmt.Sprintf("at most %d documents per batch", MaxBatchDocuments))
+		return
+	}
+	for i, input := range inputs {
+		if input == nil {
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("document %d is null", i))
+			return
+		}
+		if err := checkMetadata(input); err != nil {
+			s.respondError(w, http.StatusBadRequest, fmt.Sprintf("document %d: %v", i, err))
+			return
+		}
+	}
+	s.logger.Debug("batch index request", zap.Int("documents", len(inputs)))
+	errs := s.indexer.IndexDocuments(r.Context(), inputs)
+	response := &models.BatchIndexResponse{Results: make([]*models.BatchIndexResult, len(inputs))}
+	for i, input := range inputs {
+		result := &models.BatchIndexResult{ID: input.ID, Status: models.BatchIndexed}
+		if errs[i] != nil {
+			result.Status, result.Error = models.BatchFailed, errs[i].Error()
+			response.Failed++
+			s.logger.Warn("batch index: document failed", zap.String("id", input.ID), zap.Error(errs[i]))
+		} else {
+			response.Indexed++
+		}
+		response.Results[i] = result
+	}
+	s.respond(w, r, http.StatusOK, response)
+}
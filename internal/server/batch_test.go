@@ -0,0 +1,66 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestIndexDocumentsBatch(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	handler := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil).routes()
+
+	post := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/documents:batch", strings.NewReader(body)))
+		return w
+	}
+
+	w := post(`[{"id":"a","title":"Alpha","content":"first note"},{"id":"a","content":"again"},{"content":"untitled"}]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body: %s", w.Code, w.Body.String())
+	}
+	var resp models.BatchIndexResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Indexed != 2 || resp.Failed != 1 || len(resp.Results) != 3 {
+		t.Fatalf("response: %+v", resp)
+	}
+	if r := resp.Results[1]; r.ID != "a" || r.Status != models.BatchFailed || r.Error == "" {
+		t.Errorf("duplicate: got %+v", r)
+	}
+	if r := resp.Results[2]; r.ID == "" || r.Status != models.BatchIndexed {
+		t.Errorf("generated ID: got %+v", r)
+	}
+	if doc, err := store.GetDocument(t.Context(), "a"); err != nil || doc.Title != "Alpha" {
+		t.Errorf("stored document: %+v, %v", doc, err)
+	}
+
+	for _, body := range []string{`{"id":"x"}`, `[]`, `[null]`, `[{"content":"x","metadata":{"source_path":"/etc/passwd"}}]`} {
+		if w := post(body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, w.Code)
+		}
+	}
+}
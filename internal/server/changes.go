@@ -0,0 +1,115 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultChangesLimit = 1000
+	maxChangesLimit     = 10000
+)
+
+// handleChanges returns the documents added, updated, or deleted after the cursor ?since=,
+// each once with its net change, and the cursor to pass next. Without ?since= it returns
+// only the current cursor, to take before listing all documents. ?limit= bounds the
+// change log entries read (default 1000); has_more reports that more follow.
+func (s *Server) handleChanges(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, ok := positiveIntParam(q.Get("limit"), defaultChangesLimit)
+	if !ok {
+		s.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+		return
+	}
+	limit = min(limit, maxChangesLimit)
+	logID, head, err := s.storage.ChangeLogHead(r.Context())
+	if err != nil {
+		s.logger.Error("read change log failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp := &models.ChangesResponse{Changes: []*models.DocumentChange{}, Cursor: formatCursor(logID, head)}
+	since := q.Get("since")
+	if since == "" {
+		s.respondJSON(w, http.StatusOK, resp)
+		return
+	}
+	sinceID, after, err := parseCursor(since)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if sinceID != logID || after > head {
+		resp.Reset = true
+		s.respondJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	entries, err := s.storage.ListChanges(r.Context(), after, limit)
+	if err != nil {
+		s.logger.Error("list changes failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	resp.Changes = netChanges(entries)
+	resp.Cursor = formatCursor(logID, after)
+	if len(entries) > 0 {
+		resp.Cursor = formatCursor(logID, entries[len(entries)-1].Seq)
+	}
+	resp.HasMore = len(entries) == limit && entries[len(entries)-1].Seq < head
+	s.respondJSON(w, http.StatusOK, resp)
+}
+
+// netChanges reduces change log entries to one change per document, in the order of their
+// last write: deleted when the last write deleted it, added when the first one inserted it
+// (documents both added and deleted are left out), and updated otherwise. Reindexing a
+// file deletes and inserts its document again, which is an update.
+func netChanges(entries []*models.ChangeLogEntry) []*models.DocumentChange {
+	first := make(map[string]string)
+	last := make(map[string]*models.ChangeLogEntry)
+	for _, e := range entries {
+		if _, ok := first[e.DocumentID]; !ok {
+			first[e.DocumentID] = e.Op
+		}
+		last[e.DocumentID] = e
+	}
+	changes := []*models.DocumentChange{}
+	for _, e := range entries {
+		if last[e.DocumentID] != e {
+			continue
+		}
+		change := models.ChangeUpdated
+		switch {
+		case e.Op == models.ChangeOpDelete && first[e.DocumentID] == models.ChangeOpInsert:
+			continue
+		case e.Op == models.ChangeOpDelete:
+			change = models.ChangeDeleted
+		case first[e.DocumentID] == models.ChangeOpInsert:
+			change = models.ChangeAdded
+		}
+		changes = append(changes, &models.DocumentChange{ID: e.DocumentID, Change: change, Time: e.Time})
+	}
+	return changes
+}
+
+// formatCursor returns the cursor of the change log entry seq of the log logID.
+func formatCursor(logID string, seq int64) string {
+	return logID + ":" + strconv.FormatInt(seq, 10)
+}
+
+// parseCursor splits a cursor of formatCursor.
+func parseCursor(cursor string) (logID string, seq int64, err error) {
+	i := strings.LastIndexByte(cursor, ':')
+	if i > 0 {
+		seq, err = strconv.ParseInt(cursor[i+1:], 10, 64)
+	}
+	if i <= 0 || err != nil || seq < 0 {
+		return "", 0, fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return cursor[:i], seq, nil
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+func TestHandleChanges(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(t.TempDir() + "/db.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	srv := NewServer(nil, nil, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	changes := func(query string) (int, models.ChangesResponse) {
+		w := httptest.NewRecorder()
+		srv.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/status/changes"+query, nil))
+		var out models.ChangesResponse
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, out
+	}
+	create := func(id string) {
+		if err := store.CreateDocument(ctx, &models.Document{ID: id, Content: "c"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	create("kept")
+	create("reindexed")
+	create("removed")
+	_, start := changes("")
+	if start.Cursor == "" || len(start.Changes) != 0 {
+		t.Fatalf("no cursor: got %+v", start)
+	}
+
+	create("new")
+	create("temporary")
+	for _, id := range []string{"reindexed", "removed", "temporary"} {
+		if err := store.DeleteDocument(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	create("reindexed")
+
+	_, out := changes("?since=" + url.QueryEscape(start.Cursor))
+	got := map[string]string{}
+	for _, c := range out.Changes {
+		got[c.ID] = c.Change
+	}
+	want := map[string]string{"new": models.ChangeAdded, "removed": models.ChangeDeleted, "reindexed": models.ChangeUpdated}
+	if len(got) != len(want) || out.HasMore || out.Reset {
+		t.Fatalf("changes: got %+v", out)
+	}
+	for id, change := range want {
+		if got[id] != change {
+			t.Errorf("%s: got %q, want %q", id, got[id], change)
+		}
+	}
+
+	if _, next := changes("?since=" + url.QueryEscape(out.Cursor)); len(next.Changes) != 0 || next.Cursor != out.Cursor {
+		t.Errorf("caught up: got %+v", next)
+	}
+	if _, page := changes("?limit=2&since=" + url.QueryEscape(start.Cursor)); len(page.Changes) != 2 || !page.HasMore {
+		t.Errorf("first page: got %+v", page)
+	}
+	if _, reset := changes("?since=" + url.QueryEscape("other-log:3")); !reset.Reset || reset.Cursor != out.Cursor {
+		t.Errorf("other log: got %+v", reset)
+	}
+	for _, query := range []string{"?since=nonsense", "?since=id:-1", "?limit=0"} {
+		if code, _ := changes(query); code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", query, code)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	_ = idx.IndexDocument(context.Background(), &models.DocumentInput{ID: "d1", Title: "T", Content: "hello world"})
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	getDocument := func(header, value string) *httptest.ResponseRecorder {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "d1")
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/documents/d1", nil)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		srv.handleGetDocument(w, r)
+		return w
+	}
+	w := getDocument("", "")
+	etag, lastModified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if w.Code != http.StatusOK || etag == "" || lastModified == "" {
+		t.Fatalf("document: status %d, ETag %q, Last-Modified %q", w.Code, etag, lastModified)
+	}
+	if w = getDocument("If-None-Match", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("matching ETag: status %d, %d body bytes", w.Code, w.Body.Len())
+	}
+	if w = getDocument("If-None-Match", `W/"other"`); w.Code != http.StatusOK {
+		t.Errorf("other ETag: status %d, want 200", w.Code)
+	}
+	if w = getDocument("If-Modified-Since", lastModified); w.Code != http.StatusNotModified {
+		t.Errorf("If-Modified-Since: status %d, want 304", w.Code)
+	}
+	if w = getDocument("If-Modified-Since", "Mon, 01 Jan 2001 00:00:00 GMT"); w.Code != http.StatusOK {
+		t.Errorf("older If-Modified-Since: status %d, want 200", w.Code)
+	}
+
+	getStatus := func(etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/status", nil)
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		srv.handleStatus(w, r)
+		return w
+	}
+	etag = getStatus("").Header().Get("ETag")
+	if w = getStatus(etag); w.Code != http.StatusNotModified {
+		t.Errorf("unchanged status: got %d, want 304", w.Code)
+	}
+	_ = idx.IndexDocument(context.Background(), &models.DocumentInput{ID: "d2", Title: "T2", Content: "more text"})
+	if w = getStatus(etag); w.Code != http.StatusOK {
+		t.Errorf("changed status: got %d, want 200", w.Code)
+	}
+}
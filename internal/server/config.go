@@ -0,0 +1,113 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"go.uber.org/zap"
+)
+
+// extensionSetter is a WatchDirectoryService whose file extensions can change while it
+// runs, such as *watcher.Watcher.
+type extensionSetter interface {
+	SetExtensions(extensions []string)
+}
+
+// fullConfig returns the server's full config, nil without one. Changes replace it
+// rather than modify it, so callers may keep reading what they got.
+func (s *Server) fullConfig() *config.Config {
+	s.watchConfigMu.Lock()
+	defer s.watchConfigMu.Unlock()
+	return s.watchConfig
+}
+
+// updateConfig replaces the full config with the copy change makes of it and saves that
+// to the config file, when there is one. On error the config is kept.
+func (s *Server) updateConfig(change func(cfg *config.Config) (*config.Config, error)) (*config.Config, error) {
+	s.watchConfigMu.Lock()
+	defer s.watchConfigMu.Unlock()
+	next, err := change(s.watchConfig)
+	if err != nil {
+		return nil, err
+	}
+	if s.configPath != "" {
+		if err := config.Save(s.configPath, next); err != nil {
+			return nil, err
+		}
+	}
+	s.watchConfig = next
+	return next, nil
+}
+
+// saveWatchDirectories records the watched directories in the full config.
+func (s *Server) saveWatchDirectories() error {
+	_, err := s.updateConfig(func(cfg *config.Config) (*config.Config, error) {
+		next := *cfg
+		next.Watch.Directories = s.watch.Directories()
+		return &next, nil
+	})
+	return err
+}
+
+// handleConfigGet returns the configuration in effect, with secrets redacted.
+func (s *Server) handleConfigGet(w http.ResponseWriter, r *http.Request) {
+	cfg := s.fullConfig()
+	if cfg == nil {
+		s.respondError(w, http.StatusNotImplemented, "config is not available")
+		return
+	}
+	s.respondConfig(w, cfg)
+}
+
+// handleConfigPatch applies a config.Patch: the search settings to the engine and the
+// watch extensions to the watcher at once, and both to the config file. Fields outside
+// the patch are rejected.
+func (s *Server) handleConfigPatch(w http.ResponseWriter, r *http.Request) {
+	if s.fullConfig() == nil {
+		s.respondError(w, http.StatusNotImplemented, "config is not available")
+		return
+	}
+	var patch config.Patch
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&patch); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid request body (only search defaults, ranking settings, and watch.extensions can be changed): "+err.Error())
+		return
+	}
+	var invalid error
+	next, err := s.updateConfig(func(cfg *config.Config) (*config.Config, error) {
+		next, err := patch.Apply(cfg)
+		invalid = err
+		return next, err
+	})
+	if invalid != nil {
+		s.respondError(w, http.StatusBadRequest, invalid.Error())
+		return
+	}
+	if err != nil {
+		s.logger.Error("config save failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if patch.Search != nil && s.engine != nil {
+		s.engine.SetConfig(&next.Search)
+	}
+	if patch.Watch != nil && patch.Watch.Extensions != nil {
+		if ws, ok := s.watch.(extensionSetter); ok {
+			ws.SetExtensions(next.Watch.Extensions)
+		}
+	}
+	s.logger.Info("config changed", zap.String("path", s.configPath))
+	s.respondConfig(w, next)
+}
+
+// respondConfig writes cfg with secrets redacted.
+func (s *Server) respondConfig(w http.ResponseWriter, cfg *config.Config) {
+	tree, err := cfg.Redacted()
+	if err != nil {
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, tree)
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"go.uber.org/zap"
+)
+
+// extensionWatchService is a mockWatchService whose extensions can be changed.
+type extensionWatchService struct {
+	mockWatchService
+	extensions []string
+}
+
+func (m *extensionWatchService) SetExtensions(extensions []string) {
+	m.extensions = extensions
+}
+
+func TestHandleConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := `server:
+  auth:
+    api_keys:
+      - name: admin
+        key: s3cret
+        scope: write
+storage:
+  database_path: ./db.sqlite
+llm:
+  provider: openai
+  model: gpt-4o-mini
+  api_key: sk-test
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watch := &extensionWatchService{}
+	srv := NewServer(nil, nil, nil, &cfg.Server, zap.NewNop(), watch, path, cfg)
+	do := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, "/api/v1/config", strings.NewReader(body))
+		if method == http.MethodGet {
+			srv.handleConfigGet(w, r)
+		} else {
+			srv.handleConfigPatch(w, r)
+		}
+		return w
+	}
+
+	w := do(http.MethodGet, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("get: status %d, body: %s", w.Code, w.Body.String())
+	}
+	if body := w.Body.String(); strings.Contains(body, "s3cret") || strings.Contains(body, "sk-test") || !strings.Contains(body, `"api_key":"[redacted]"`) {
+		t.Errorf("get should redact secrets: %s", body)
+	}
+	var got struct {
+		Search map[string]interface{} `json:"search"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Search["keyword_phrase_boost"] != 1.5 {
+		t.Errorf("get: search.keyword_phrase_boost = %v, want 1.5", got.Search["keyword_phrase_boost"])
+	}
+
+	for _, body := range []string{
+		`{"search": {"chunk_size": 100}}`,          // not changeable at runtime
+		`{"search": {"keyword_fuzziness": 3}}`,     // invalid value
+		`{"search": {"top_k_candidates": 0}}`,      // invalid value
+		`{"watch": {"extensions": ["pdf", " "]}}`,  // empty extension
+		`{"search": {"keyword_phrase_slop": "2"}}`, // wrong type
+	} {
+		if w := do(http.MethodPatch, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, w.Code)
+		}
+	}
+	if srv.fullConfig() != cfg {
+		t.Error("rejected patches should keep the config")
+	}
+
+	w = do(http.MethodPatch, `{"search": {"keyword_phrase_slop": 2, "dedupe_enabled": false}, "watch": {"extensions": ["pdf", ".md"]}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("patch: status %d, body: %s", w.Code, w.Body.String())
+	}
+	if !reflect.DeepEqual(watch.extensions, []string{"pdf", ".md"}) {
+		t.Errorf("watcher extensions = %v", watch.extensions)
+	}
+	next := srv.fullConfig()
+	if next.Search.KeywordPhraseSlop != 2 || next.Search.DedupeEnabledOrDefault() || next.Search.KeywordPhraseBoost != 1.5 {
+		t.Errorf("patched search config: %+v", next.Search)
+	}
+	if cfg.Search.KeywordPhraseSlop != 0 {
+		t.Error("the previous config should not change")
+	}
+
+	saved, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Search.KeywordPhraseSlop != 2 || !reflect.DeepEqual(saved.Watch.Extensions, []string{"pdf", ".md"}) {
+		t.Errorf("saved config: slop %d, extensions %v", saved.Search.KeywordPhraseSlop, saved.Watch.Extensions)
+	}
+	if saved.Server.Auth.APIKeys[0].Key != "s3cret" {
+		t.Error("saving should keep secrets in the file")
+	}
+
+	noConfig := NewServer(nil, nil, nil, &config.ServerConfig{}, zap.NewNop(), nil, "", nil)
+	w = httptest.NewRecorder()
+	noConfig.handleConfigPatch(w, httptest.NewRequest(http.MethodPatch, "/api/v1/config", strings.NewReader(`{}`)))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("without a config: status %d, want 501", w.Code)
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+// handleCount returns the number of documents matching ?q= by keyword. ?fuzzy=true
+// enables typo tolerance; ?ext= (comma-separated) and ?path_prefix= narrow the count,
+// ?fields=title,path matches only those fields, and ?mode=wildcard or ?mode=regex matches
+// q as a pattern.
+func (s *Server) handleCount(w http.ResponseWriter, r *http.Request) {
+	query := queryFromURL(r.URL.Query())
+	query.KeywordEnabled = true
+	if err := query.Validate(); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	n, err := s.engine.Count(r.Context(), query)
+	if errors.Is(err, keyword.ErrPatternTooBroad) {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, storage.ErrSnapshotNotFound) {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.Error("count failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, &models.CountResponse{Query: query.Query, Count: n})
+}
+
+// handleExists reports whether the file at ?path= is indexed and whether the indexed
+// copy is current.
+func (s *Server) handleExists(w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		s.respondError(w, http.StatusBadRequest, "path is required")
+		return
+	}
+	status, err := indexer.FileState(r.Context(), s.storage, path)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, status)
+}
+
+// queryFromURL reads the q, fuzzy, ext and fields (comma-separated), path_prefix, mode,
+// and snapshot parameters.
+func queryFromURL(q url.Values) *models.SearchQuery {
+	query := &models.SearchQuery{
+		Query:        strings.TrimSpace(q.Get("q")),
+		FuzzyEnabled: q.Get("fuzzy") == "true",
+		PathPrefix:   q.Get("path_prefix"),
+		Mode:         q.Get("mode"),
+		Snapshot:     q.Get("snapshot"),
+	}
+	for _, ext := range strings.Split(q.Get("ext"), ",") {
+		if ext = strings.TrimSpace(ext); ext != "" {
+			query.Extensions = append(query.Extensions, ext)
+		}
+	}
+	for _, f := range strings.Split(q.Get("fields"), ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			query.Fields = append(query.Fields, f)
+		}
+	}
+	return query
+}
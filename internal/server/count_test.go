@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestHandleCountAndExists(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStorage(filepath.Join(dir, "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	kwIndex, err := keyword.NewBleveIndex(filepath.Join(dir, "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	cfg := &config.SearchConfig{TopKCandidates: 1, ChunkSize: 50, ChunkOverlap: 10}
+	engine := search.NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+
+	files := map[string]string{"a.txt": "quarterly budget", "b.md": "budget review", "c.txt": "holiday plans"}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.IndexFile(ctx, path, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	count := func(params string) int {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.handleCount(w, httptest.NewRequest(http.MethodGet, "/api/v1/count?"+params, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("count %s: status %d, body: %s", params, w.Code, w.Body.String())
+		}
+		var out models.CountResponse
+		if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		return out.Count
+	}
+	// The count is not capped by top_k_candidates.
+	if n := count("q=budget"); n != 2 {
+		t.Errorf("budget: got %d, want 2", n)
+	}
+	if n := count("q=budget&ext=md"); n != 1 {
+		t.Errorf("budget in .md: got %d, want 1", n)
+	}
+	if n := count("q=budget&fields=title"); n != 0 {
+		t.Errorf("budget in titles: got %d, want 0", n)
+	}
+	if n := count("q=b&fields=title,path"); n != 1 {
+		t.Errorf("b in titles and paths: got %d, want 1", n)
+	}
+	w := httptest.NewRecorder()
+	srv.handleCount(w, httptest.NewRequest(http.MethodGet, "/api/v1/count", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("missing q: got %d, want 400", w.Code)
+	}
+
+	exists := func(path string) *models.FileStatus {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.handleExists(w, httptest.NewRequest(http.MethodGet, "/api/v1/exists?path="+url.QueryEscape(path), nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("exists %s: status %d, body: %s", path, w.Code, w.Body.String())
+		}
+		var out models.FileStatus
+		if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		return &out
+	}
+	a := filepath.Join(dir, "a.txt")
+	if st := exists(a); !st.Indexed || !st.Current {
+		t.Errorf("indexed file: got %+v", st)
+	}
+	if err := os.WriteFile(a, []byte("quarterly budget, revised"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if st := exists(a); !st.Indexed || st.Current {
+		t.Errorf("changed file should not be current: got %+v", st)
+	}
+	if st := exists(filepath.Join(dir, "missing.txt")); st.Indexed {
+		t.Errorf("missing file: got %+v", st)
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+// handleSearchDiff compares the results of a search with those of another search
+// (against) or with the results recorded for its query at since in the analytics log.
+func (s *Server) handleSearchDiff(w http.ResponseWriter, r *http.Request) {
+	var req models.ResultsDiffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Query == nil || (req.Against == nil) == (req.Since == nil) {
+		s.respondError(w, http.StatusBadRequest, "query and one of against or since are required")
+		return
+	}
+	if err := req.Query.Validate(); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var diff *models.ResultsDiff
+	var err error
+	if req.Against != nil {
+		if err := req.Against.Validate(); err != nil {
+			s.respondError(w, http.StatusBadRequest, "against: "+err.Error())
+			return
+		}
+		diff, err = s.engine.DiffSearches(r.Context(), req.Query, req.Against)
+	} else {
+		diff, err = s.engine.DiffSince(r.Context(), req.Query, *req.Since)
+	}
+	switch {
+	case errors.Is(err, search.ErrNoRecordedSearch), errors.Is(err, storage.ErrSnapshotNotFound):
+		s.respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, keyword.ErrPatternTooBroad):
+		s.respondError(w, http.StatusBadRequest, err.Error())
+	case err != nil:
+		s.logger.Error("search diff failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+	default:
+		s.respondJSON(w, http.StatusOK, diff)
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestHandleSearchDiff(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := storage.NewSQLiteStorage(filepath.Join(dir, "db.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	kwIndex, err := keyword.NewBleveIndex(filepath.Join(dir, "bleve"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer kwIndex.Close()
+	emb := embedding.NewMockEmbedder(4)
+	vecIndex, _ := vector.NewMemoryIndex(4)
+	cfg := &config.SearchConfig{TopKCandidates: 20, ChunkSize: 50, ChunkOverlap: 10}
+	engine := search.NewEngine(store, emb, vecIndex, kwIndex, cfg)
+	idx := indexer.NewIndexer(store, emb, vecIndex, kwIndex, cfg, nil)
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "d1", Title: "budget.xlsx", Content: "quarterly budget"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil).WithAnalytics()
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+		return w
+	}
+	// A search records its results, the baseline of later diffs
+	if w := post("/api/v1/search", `{"query": "budget", "keyword_enabled": true}`); w.Code != http.StatusOK {
+		t.Fatalf("search: status %d, body: %s", w.Code, w.Body.String())
+	}
+	if err := idx.IndexDocument(ctx, &models.DocumentInput{ID: "d2", Title: "budget-2027.xlsx", Content: "budget draft"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w := post("/api/v1/search/diff", `{"query": {"query": "Budget", "keyword_enabled": true}, "since": "2000-01-01T00:00:00Z"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("diff since: status %d, body: %s", w.Code, w.Body.String())
+	}
+	var diff models.ResultsDiff
+	if err := json.NewDecoder(w.Body).Decode(&diff); err != nil {
+		t.Fatal(err)
+	}
+	if diff.BaselineTime == nil || len(diff.Entered) != 1 || diff.Entered[0].DocumentID != "d2" || len(diff.Left) != 0 {
+		t.Errorf("diff since: got %+v", diff)
+	}
+
+	for body, want := range map[string]int{
+		`{"query": {"query": "budget"}}`: http.StatusBadRequest,
+		`{"query": {"query": "budget"}, "against": {"query": "plan"}, "since": "2000-01-01T00:00:00Z"}`: http.StatusBadRequest,
+		`{"query": {"query": "roadmap"}, "since": "2000-01-01T00:00:00Z"}`:                              http.StatusNotFound,
+		`{"query": {"query": "budget"}, "against": {"query": "draft"}}`:                                 http.StatusOK,
+	} {
+		if w := post("/api/v1/search/diff", body); w.Code != want {
+			t.Errorf("%s: status %d, want %d (%s)", body, w.Code, want, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDocumentsLimit = 50
+	maxDocumentsLimit     = 1000
+)
+
+// handleListDocuments lists indexed documents without their content, newest first, a page
+// of ?limit= (default 50) at a time from ?offset=. ?path_prefix= keeps documents whose
+// source path starts with it and ?ext= (comma-separated or repeated) those with one of
+// the extensions.
+func (s *Server) handleListDocuments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, ok := positiveIntParam(q.Get("limit"), defaultDocumentsLimit)
+	if !ok {
+		s.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+		return
+	}
+	limit = min(limit, maxDocumentsLimit)
+	offset := 0
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		offset = n
+	}
+	filter := documentListFilter(q)
+
+	docs, total, err := s.storage.ListDocumentSummaries(r.Context(), filter, offset, limit)
+	if err != nil {
+		s.logger.Error("list documents failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if docs == nil {
+		docs = []*models.DocumentSummary{}
+	}
+	s.respondJSON(w, http.StatusOK, &models.DocumentListResponse{
+		Documents: docs,
+		Total:     total,
+		Offset:    offset,
+		Limit:     limit,
+	})
+}
+
+// documentListFilter reads the path_prefix and ext (comma-separated or repeated)
+// parameters.
+func documentListFilter(q url.Values) models.DocumentListFilter {
+	filter := models.DocumentListFilter{PathPrefix: q.Get("path_prefix")}
+	for _, v := range q["ext"] {
+		for _, ext := range strings.Split(v, ",") {
+			if ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), ".")); ext != "" {
+				filter.Extensions = append(filter.Extensions, ext)
+			}
+		}
+	}
+	return filter
+}
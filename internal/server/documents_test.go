@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+func TestHandleListDocuments(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(t.TempDir() + "/db.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	for _, id := range []string{"a", "b", "c"} {
+		if err := store.CreateDocument(ctx, &models.Document{ID: id, Title: id, Content: "secret", Metadata: map[string]interface{}{
+			"source_path": "/docs/" + id + ".md",
+		}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := NewServer(nil, nil, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	list := func(target string) (int, models.DocumentListResponse) {
+		w := httptest.NewRecorder()
+		srv.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		var out models.DocumentListResponse
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, out
+	}
+
+	code, out := list("/api/v1/documents?limit=2&offset=1&ext=.MD,pdf")
+	if code != http.StatusOK {
+		t.Fatalf("status: got %d", code)
+	}
+	if out.Total != 3 || out.Offset != 1 || out.Limit != 2 || len(out.Documents) != 2 || out.Documents[0].ID != "b" {
+		t.Errorf("page: got %+v", out)
+	}
+
+	if _, out = list("/api/v1/documents?ext=pdf"); out.Total != 0 || out.Documents == nil {
+		t.Errorf("no match: got %+v, want an empty list", out)
+	}
+	if _, out = list("/api/v1/documents?limit=5000"); out.Limit != maxDocumentsLimit {
+		t.Errorf("limit: got %d, want %d", out.Limit, maxDocumentsLimit)
+	}
+	for _, target := range []string{"/api/v1/documents?limit=0", "/api/v1/documents?offset=-1"} {
+		if code, _ := list(target); code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", target, code)
+		}
+	}
+}
+
+func TestHandleGetDocument_chunks(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(t.TempDir() + "/db.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	if err := store.CreateDocument(ctx, &models.Document{ID: "doc", Title: "Doc", Content: "one two"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.BatchCreateChunks(ctx, []*models.DocumentChunk{
+		{ID: "doc_1", DocumentID: "doc", Content: "two", ChunkIndex: 1},
+		{ID: "doc_0", DocumentID: "doc", Content: "one", ChunkIndex: 0},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(nil, nil, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	w := httptest.NewRecorder()
+	srv.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/documents/doc", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, body: %s", w.Code, w.Body.String())
+	}
+	var out models.DocumentDetail
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Document == nil || out.Title != "Doc" || out.Content != "one two" {
+		t.Errorf("document: got %+v", out.Document)
+	}
+	if len(out.Chunks) != 2 || out.Chunks[0].Content != "one" || out.Chunks[1].Content != "two" {
+		t.Errorf("chunks: got %+v", out.Chunks)
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultDuplicatesLimit = 100
+	maxDuplicatesLimit     = 1000
+	// defaultDuplicatesDistance is used without a loaded config; it matches the default
+	// of search.dedupe_max_distance.
+	defaultDuplicatesDistance = 3
+)
+
+// handleDuplicates lists groups of indexed documents with identical or near-identical
+// content, most reclaimable space first, a page of ?limit= (default 100) groups at a time
+// from ?offset=. ?max_distance= (0 to 64 fingerprint bits; default
+// search.dedupe_max_distance) sets how near copies must be, and ?path_prefix= and ?ext=
+// restrict the documents compared.
+func (s *Server) handleDuplicates(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit, ok := positiveIntParam(q.Get("limit"), defaultDuplicatesLimit)
+	if !ok {
+		s.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+		return
+	}
+	limit = min(limit, maxDuplicatesLimit)
+	offset := 0
+	if v := q.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return
+		}
+		offset = n
+	}
+	maxDistance := defaultDuplicatesDistance
+	if cfg := s.fullConfig(); cfg != nil {
+		maxDistance = cfg.Search.DedupeMaxDistance
+	}
+	if v := q.Get("max_distance"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 64 {
+			s.respondError(w, http.StatusBadRequest, "max_distance must be an integer from 0 to 64")
+			return
+		}
+		maxDistance = n
+	}
+
+	groups, err := indexer.FindDuplicates(r.Context(), s.storage, documentListFilter(q), maxDistance)
+	if err != nil {
+		s.logger.Error("find duplicates failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	total := len(groups)
+	groups = groups[min(offset, total):min(offset+limit, total)]
+	if len(groups) == 0 {
+		groups = []*models.DuplicateGroup{}
+	}
+	s.respondJSON(w, http.StatusOK, &models.DuplicatesResponse{
+		Groups:      groups,
+		Total:       total,
+		Offset:      offset,
+		Limit:       limit,
+		MaxDistance: maxDistance,
+	})
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+func TestHandleDuplicates(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(t.TempDir() + "/db.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	for id, fp := range map[string]string{"a": "00000000000000ff", "b": "00000000000000ff", "c": "00000000000000fe", "d": "ffff000000000000", "e": "ffff000000000000"} {
+		if err := store.CreateDocument(ctx, &models.Document{ID: id, Title: id, Content: "text", Metadata: map[string]interface{}{
+			"source_path": "/docs/" + id + ".md", "source_size": "100", "content_simhash": fp,
+		}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := NewServer(nil, nil, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	get := func(target string) (int, models.DuplicatesResponse) {
+		w := httptest.NewRecorder()
+		srv.routes().ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		var out models.DuplicatesResponse
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return w.Code, out
+	}
+
+	code, out := get("/api/v1/duplicates")
+	if code != http.StatusOK {
+		t.Fatalf("status: got %d", code)
+	}
+	if out.Total != 2 || out.MaxDistance != defaultDuplicatesDistance || len(out.Groups) != 2 {
+		t.Fatalf("got %+v", out)
+	}
+	if g := out.Groups[0]; len(g.Files) != 3 || g.Identical || g.ReclaimableBytes != 200 || g.Files[0].Path != "/docs/a.md" {
+		t.Errorf("near group: %+v", g)
+	}
+
+	if _, out = get("/api/v1/duplicates?max_distance=0&limit=1&offset=1"); out.Total != 2 || len(out.Groups) != 1 || out.Groups[0].Files[0].DocumentID != "d" {
+		t.Errorf("identical page: got %+v", out)
+	}
+	if _, out = get("/api/v1/duplicates?path_prefix=/other/"); out.Total != 0 || out.Groups == nil {
+		t.Errorf("no match: got %+v, want an empty list", out)
+	}
+	for _, target := range []string{"/api/v1/duplicates?max_distance=65", "/api/v1/duplicates?limit=0", "/api/v1/duplicates?offset=x"} {
+		if code, _ := get(target); code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", target, code)
+		}
+	}
+}
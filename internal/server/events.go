@@ -0,0 +1,92 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/hyperjump/sagasu/internal/events"
+)
+
+// eventsPath is the indexing activity stream, which stays open past the request timeout.
+const eventsPath = "/api/v1/events"
+
+// eventsKeepAlive is how often an idle event stream gets a comment, so proxies keep it
+// open and a client that left is noticed.
+const eventsKeepAlive = 30 * time.Second
+
+// WithEvents enables GET /api/v1/events, streaming the indexing activity published to bus.
+func (s *Server) WithEvents(bus *events.Bus) *Server {
+	s.events = bus
+	return s
+}
+
+// handleEvents streams indexing activity as server-sent events named after their type
+// (indexed, deleted, failed, sync_started, sync_finished), each with its sequence number
+// as the event ID. A client resuming with the Last-Event-ID header or ?since= first gets
+// the kept events it missed; when some are no longer kept, a "reset" event tells it to
+// reload its state. The stream ends when the client leaves, or with a "reset" when the
+// client falls too far behind.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if s.events == nil {
+		s.respondError(w, http.StatusNotImplemented, "events not enabled")
+		return
+	}
+	cursor := r.Header.Get("Last-Event-ID")
+	if cursor == "" {
+		cursor = r.URL.Query().Get("since")
+	}
+	var since int64
+	if cursor != "" {
+		var err error
+		if since, err = strconv.ParseInt(cursor, 10, 64); err != nil || since < 0 {
+			s.respondError(w, http.StatusBadRequest, "since must be a non-negative event ID")
+			return
+		}
+	}
+	ch, cancel, missed := s.events.Subscribe(since)
+	defer cancel()
+
+	ew := newEventWriter(w)
+	if missed {
+		_ = ew.send("reset", "", map[string]int64{"since": since})
+	}
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				_ = ew.send("reset", "", map[string]string{"reason": "client fell behind"})
+				return
+			}
+			if err := ew.send(string(e.Type), strconv.FormatInt(e.Seq, 10), e); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if err := ew.comment("keep-alive"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// requestTimeout is middleware.Timeout(d) for every request but the event stream, the
+// consistency check, and the runtime profiles.
+func requestTimeout(d time.Duration) func(http.Handler) http.Handler {
+	timeout := middleware.Timeout(d)
+	return func(next http.Handler) http.Handler {
+		limited := timeout(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == eventsPath || r.URL.Path == fsckPath || strings.HasPrefix(r.URL.Path, pprofPath) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limited.ServeHTTP(w, r)
+		})
+	}
+}
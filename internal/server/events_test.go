@@ -0,0 +1,77 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/events"
+	"go.uber.org/zap"
+)
+
+func TestHandleEvents(t *testing.T) {
+	srv := NewServer(nil, nil, nil, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	w := httptest.NewRecorder()
+	srv.handleEvents(w, httptest.NewRequest(http.MethodGet, "/api/v1/events", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("without a bus: status %d, want 501", w.Code)
+	}
+
+	bus := events.NewBus(0)
+	srv.WithEvents(bus)
+	bus.Publish(events.Event{Type: events.Indexed, Path: "/docs/a.txt"})
+	bus.Publish(events.Event{Type: events.Deleted, Path: "/docs/b.txt"})
+	ts := httptest.NewServer(srv.routes())
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/api/v1/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("content type %q", resp.Header.Get("Content-Type"))
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	next := func() (id, name string, e events.Event) {
+		for sc.Scan() {
+			line := sc.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				_ = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e)
+				return id, name, e
+			}
+		}
+		t.Fatalf("stream ended: %v", sc.Err())
+		return
+	}
+	// The missed event is replayed, then live ones follow
+	if id, name, e := next(); id != "2" || name != "deleted" || e.Path != "/docs/b.txt" {
+		t.Errorf("replayed event %s %s %+v", id, name, e)
+	}
+	bus.Publish(events.Event{Type: events.Failed, Path: "/docs/c.pdf", Error: "extract content: bad pdf"})
+	if id, name, e := next(); id != "3" || name != "failed" || e.Error == "" {
+		t.Errorf("live event %s %s %+v", id, name, e)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleEvents(w, httptest.NewRequest(http.MethodGet, "/api/v1/events?since=x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid since: status %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,23 @@
+package server
+
+import "net/http"
+
+// handleExplain returns how ?q= is parsed and searched (see search.Engine.Explain).
+// It takes the parameters of handleCount; ?keyword=false or ?semantic=false explain a
+// search with that branch disabled.
+func (s *Server) handleExplain(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := queryFromURL(q)
+	query.KeywordEnabled = q.Get("keyword") != "false"
+	query.SemanticEnabled = q.Get("semantic") != "false"
+	if !query.KeywordEnabled && !query.SemanticEnabled {
+		s.respondError(w, http.StatusBadRequest, "keyword and semantic search cannot both be disabled")
+		return
+	}
+	exp, err := s.engine.Explain(query)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, exp)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestHandleExplain(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	explain := func(params string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		srv.handleExplain(w, httptest.NewRequest(http.MethodGet, "/api/v1/explain?"+params, nil))
+		return w
+	}
+
+	w := explain("q=" + url.QueryEscape(`"annual report" OR budget -path:archive`) + "&ext=pdf&semantic=false")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body: %s", w.Code, w.Body.String())
+	}
+	var exp models.QueryExplanation
+	if err := json.NewDecoder(w.Body).Decode(&exp); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(exp.Operators, []string{"OR"}) || !reflect.DeepEqual(exp.Phrases, []string{"annual report"}) ||
+		!reflect.DeepEqual(exp.Terms, []string{"budget"}) {
+		t.Errorf("parts: got %+v", exp)
+	}
+	wantFilters := []models.QueryFilter{{Field: "path", Value: "archive", Exclude: true}, {Field: "ext", Value: "pdf"}}
+	if !reflect.DeepEqual(exp.Filters, wantFilters) {
+		t.Errorf("filters = %+v, want %+v", exp.Filters, wantFilters)
+	}
+	if exp.SemanticText != "" {
+		t.Errorf("semantic=false should leave no semantic text, got %q", exp.SemanticText)
+	}
+
+	if w := explain(""); w.Code != http.StatusBadRequest {
+		t.Errorf("missing q: got %d, want 400", w.Code)
+	}
+	if w := explain("q=a&keyword=false&semantic=false"); w.Code != http.StatusBadRequest {
+		t.Errorf("both branches disabled: got %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,28 @@
+package server
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// fsckPath is the consistency check endpoint, which walks every document and so may take
+// longer than the request timeout.
+const fsckPath = "/api/v1/fsck"
+
+// handleFsck cross-checks storage, the keyword and vector indexes, and the filesystem,
+// and reports orphaned chunks, vectors and keyword entries, missing vectors and keyword
+// entries, and documents whose source file is gone. POST also repairs what it finds.
+func (s *Server) handleFsck(w http.ResponseWriter, r *http.Request) {
+	repair := r.Method == http.MethodPost
+	report, err := s.indexer.Fsck(r.Context(), repair)
+	if err != nil {
+		s.logger.Error("consistency check failed", zap.Bool("repair", repair), zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if repair && report.Problems() > 0 {
+		s.logger.Info("consistency check repaired the index", zap.Int("problems", report.Problems()))
+	}
+	s.respondJSON(w, http.StatusOK, report)
+}
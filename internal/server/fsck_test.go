@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestHandleFsck(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	if err := idx.IndexDocument(context.Background(), &models.DocumentInput{ID: "d1", Title: "Notes", Content: "harbour tides and ferries"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := vecIdx.Add(context.Background(), []string{"stray_0"}, [][]float32{{1, 0, 0, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	for _, tc := range []struct {
+		method         string
+		orphans, fixed int
+	}{
+		{http.MethodGet, 1, 0},
+		{http.MethodPost, 1, 1},
+		{http.MethodGet, 0, 0},
+	} {
+		w := httptest.NewRecorder()
+		srv.handleFsck(w, httptest.NewRequest(tc.method, fsckPath, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: got %d: %s", tc.method, w.Code, w.Body)
+		}
+		var report models.FsckReport
+		if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+			t.Fatal(err)
+		}
+		if report.Documents != 1 || report.OrphanVectors.Count != tc.orphans || report.OrphanVectors.Fixed != tc.fixed ||
+			report.Repaired != (tc.method == http.MethodPost) {
+			t.Errorf("%s: report = %+v", tc.method, report)
+		}
+	}
+}
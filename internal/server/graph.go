@@ -0,0 +1,71 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"go.uber.org/zap"
+)
+
+// handleGraph links the indexed documents whose embeddings are at least ?threshold=
+// (above 0 up to 1; default 0.8) similar, keeping each document's ?neighbors= (default 10)
+// most similar ones, with ?path_prefix= and ?ext= restricting the documents compared and
+// ?max_distance= (default search.dedupe_max_distance) setting how near the fingerprints
+// of duplicate edges must be. It responds with JSON, or GraphML with ?format=graphml.
+// Every pair of documents is compared, so it needs write scope.
+func (s *Server) handleGraph(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	threshold := indexer.DefaultGraphThreshold
+	if v := q.Get("threshold"); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t <= 0 || t > 1 {
+			s.respondError(w, http.StatusBadRequest, "threshold must be a number above 0 and at most 1")
+			return
+		}
+		threshold = t
+	}
+	neighbors, ok := positiveIntParam(q.Get("neighbors"), indexer.DefaultGraphNeighbors)
+	if !ok {
+		s.respondError(w, http.StatusBadRequest, "neighbors must be a positive integer")
+		return
+	}
+	maxDistance := defaultDuplicatesDistance
+	if cfg := s.fullConfig(); cfg != nil {
+		maxDistance = cfg.Search.DedupeMaxDistance
+	}
+	if v := q.Get("max_distance"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 64 {
+			s.respondError(w, http.StatusBadRequest, "max_distance must be an integer from 0 to 64")
+			return
+		}
+		maxDistance = n
+	}
+	format := q.Get("format")
+	if format != "" && format != "json" && format != "graphml" {
+		s.respondError(w, http.StatusBadRequest, "format must be json or graphml")
+		return
+	}
+
+	graph, err := s.indexer.SimilarityGraph(r.Context(), documentListFilter(q), threshold, neighbors, maxDistance)
+	if errors.Is(err, indexer.ErrGraphTooLarge) {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.Error("similarity graph failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if format == "graphml" {
+		w.Header().Set("Content-Type", "application/graphml+xml")
+		w.WriteHeader(http.StatusOK)
+		if err := indexer.WriteGraphML(w, graph); err != nil {
+			s.logger.Error("write graphml failed", zap.Error(err))
+		}
+		return
+	}
+	s.respondJSON(w, http.StatusOK, graph)
+}
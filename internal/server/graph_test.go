@@ -0,0 +1,66 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestHandleGraph(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	for _, id := range []string{"d1", "d2"} {
+		if err := idx.IndexDocument(context.Background(), &models.DocumentInput{ID: id, Title: "Notes", Content: "harbour tides and ferries"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	w := httptest.NewRecorder()
+	srv.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/v1/graph?threshold=0.99", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", w.Code, w.Body)
+	}
+	var graph models.SimilarityGraph
+	if err := json.NewDecoder(w.Body).Decode(&graph); err != nil {
+		t.Fatal(err)
+	}
+	if graph.Threshold != 0.99 || len(graph.Nodes) != 2 || len(graph.Edges) != 1 || !graph.Edges[0].Duplicate {
+		t.Errorf("graph = %+v", graph)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/v1/graph?format=graphml", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/graphml+xml" || !strings.Contains(w.Body.String(), `<edge source="d1" target="d2">`) {
+		t.Errorf("graphml: got %d %q:\n%s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+
+	for _, query := range []string{"threshold=0", "threshold=1.5", "neighbors=0", "max_distance=65", "format=csv"} {
+		w = httptest.NewRecorder()
+		srv.handleGraph(w, httptest.NewRequest(http.MethodGet, "/api/v1/graph?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", query, w.Code)
+		}
+	}
+}
@@ -2,12 +2,16 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
 	"github.com/hyperjump/sagasu/internal/models"
 	"github.com/hyperjump/sagasu/internal/storage"
 	"go.uber.org/zap"
@@ -15,18 +19,35 @@ import (
 
 func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
 	var query models.SearchQuery
-	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+	if err := decodeBody(r, &query); err != nil {
 		s.respondError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	if err := query.Validate(); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	audit := &models.AuditEntry{Action: models.AuditSearch, Query: query.Query}
+	w, done := s.audited(w, r, audit)
+	defer done()
 	s.logger.Debug("search request", zap.String("query", query.Query), zap.Int("limit", query.Limit))
 	response, err := s.engine.Search(r.Context(), &query)
+	if errors.Is(err, keyword.ErrPatternTooBroad) || errors.Is(err, storage.ErrVersionHistoryDisabled) {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, storage.ErrSnapshotNotFound) {
+		s.respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
 	if err != nil {
 		s.logger.Error("search failed", zap.Error(err))
 		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	s.respondJSON(w, http.StatusOK, response)
+	audit.Results = len(response.AllResults())
+	s.recordSearch(r.Context(), &query, response)
+	s.respond(w, r, http.StatusOK, response)
 }
 
 func (s *Server) handleIndexDocument(w http.ResponseWriter, r *http.Request) {
@@ -35,7 +56,15 @@ func (s *Server) handleIndexDocument(w http.ResponseWriter, r *http.Request) {
 		s.respondError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	if err := checkMetadata(&input); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	s.logger.Debug("index document request", zap.String("id", input.ID), zap.String("title", input.Title))
+	if r.URL.Query().Get("async") == "true" {
+		s.submitIndexDocument(w, r, &input)
+		return
+	}
 	if err := s.indexer.IndexDocument(r.Context(), &input); err != nil {
 		s.logger.Error("indexing failed", zap.Error(err))
 		s.respondError(w, http.StatusInternalServerError, err.Error())
@@ -44,14 +73,36 @@ func (s *Server) handleIndexDocument(w http.ResponseWriter, r *http.Request) {
 	s.respondJSON(w, http.StatusCreated, map[string]string{"id": input.ID, "status": "indexed"})
 }
 
+// checkMetadata rejects documents setting metadata only the indexer records, such as
+// source_path, which names the file GET /api/v1/documents/{id}/file serves.
+func checkMetadata(input *models.DocumentInput) error {
+	for key := range input.Metadata {
+		if indexer.ReservedMetadataKey(key) {
+			return fmt.Errorf("metadata key %q is reserved for indexed files", key)
+		}
+	}
+	return nil
+}
+
 func (s *Server) handleGetDocument(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	w, done := s.audited(w, r, &models.AuditEntry{Action: models.AuditDocumentGet, DocumentID: id})
+	defer done()
 	doc, err := s.storage.GetDocument(r.Context(), id)
 	if err != nil {
 		s.respondError(w, http.StatusNotFound, "document not found")
 		return
 	}
-	s.respondJSON(w, http.StatusOK, doc)
+	chunks, err := s.storage.GetChunksByDocumentID(r.Context(), id)
+	if err != nil {
+		s.logger.Error("get document chunks failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if chunks == nil {
+		chunks = []*models.DocumentChunk{}
+	}
+	s.respondCacheable(w, r, &models.DocumentDetail{Document: doc, Chunks: chunks}, doc.UpdatedAt)
 }
 
 func (s *Server) handleDeleteDocument(w http.ResponseWriter, r *http.Request) {
@@ -90,31 +141,48 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		"chunks":            chunkCount,
 		"vector_index_size": vectorSize,
 	}
+	if s.jobs != nil {
+		resp["paused"] = s.jobs.Paused()
+		resp["jobs"] = s.jobs.Counts()
+	}
+	if s.indexer != nil {
+		if stats := s.indexer.EmbedQueueStats(); stats != nil {
+			resp["embed_queue"] = stats
+		}
+	}
+	if iw := s.indexWindowService(); iw != nil {
+		if st := iw.IndexWindowStatus(); st != nil {
+			resp["index_windows"] = st
+		}
+	}
 
 	// Add configuration info
 	configInfo := map[string]interface{}{
 		"vector_index_type": vectorIndexType,
+		"open_files":        s.config.OpenFiles,
 	}
-	if s.watchConfig != nil {
-		configInfo["embedding_dimensions"] = s.watchConfig.Embedding.Dimensions
-		configInfo["chunk_size"] = s.watchConfig.Search.ChunkSize
-		configInfo["chunk_overlap"] = s.watchConfig.Search.ChunkOverlap
-		configInfo["ranking_enabled"] = s.watchConfig.Search.RankingEnabled
-		configInfo["database_path"] = s.watchConfig.Storage.DatabasePath
-		configInfo["bleve_index_path"] = s.watchConfig.Storage.BleveIndexPath
-		configInfo["faiss_index_path"] = s.watchConfig.Storage.FAISSIndexPath
+	if cfg := s.fullConfig(); cfg != nil {
+		configInfo["embedding_dimensions"] = cfg.Embedding.Dimensions
+		configInfo["chunk_size"] = cfg.Search.ChunkSize
+		configInfo["chunk_overlap"] = cfg.Search.ChunkOverlap
+		configInfo["ranking_enabled"] = cfg.Search.RankingEnabled
+		configInfo["database_path"] = cfg.Storage.DatabasePath
+		configInfo["bleve_index_path"] = cfg.Storage.BleveIndexPath
+		configInfo["faiss_index_path"] = cfg.Storage.FAISSIndexPath
+		configInfo["keyword_backend"] = cfg.Keyword.Backend
+		configInfo["keyword_index_path"] = cfg.KeywordIndexPath()
 
 		diskBytes, err := storage.DiskUsageBytes(
-			s.watchConfig.Storage.DatabasePath,
-			s.watchConfig.Storage.BleveIndexPath,
-			s.watchConfig.Storage.FAISSIndexPath,
+			cfg.Storage.DatabasePath,
+			cfg.KeywordIndexPath(),
+			cfg.Storage.FAISSIndexPath,
 		)
 		if err == nil {
 			resp["disk_usage_bytes"] = diskBytes
 		}
 	}
 	resp["config"] = configInfo
-	s.respondJSON(w, http.StatusOK, resp)
+	s.respondCacheable(w, r, resp, time.Time{})
 }
 
 func (s *Server) handleWatchDirectoriesList(w http.ResponseWriter, r *http.Request) {
@@ -173,12 +241,8 @@ func (s *Server) handleWatchDirectoriesAdd(w http.ResponseWriter, r *http.Reques
 		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	if s.configPath != "" && s.watchConfig != nil {
-		s.watchConfigMu.Lock()
-		s.watchConfig.Watch.Directories = s.watch.Directories()
-		err := config.Save(s.configPath, s.watchConfig)
-		s.watchConfigMu.Unlock()
-		if err != nil {
+	if s.fullConfig() != nil {
+		if err := s.saveWatchDirectories(); err != nil {
 			s.logger.Warn("failed to persist watch config", zap.Error(err))
 		}
 	}
@@ -214,12 +278,8 @@ func (s *Server) handleWatchDirectoriesRemove(w http.ResponseWriter, r *http.Req
 		s.respondError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	if s.configPath != "" && s.watchConfig != nil {
-		s.watchConfigMu.Lock()
-		s.watchConfig.Watch.Directories = s.watch.Directories()
-		err := config.Save(s.configPath, s.watchConfig)
-		s.watchConfigMu.Unlock()
-		if err != nil {
+	if s.fullConfig() != nil {
+		if err := s.saveWatchDirectories(); err != nil {
 			s.logger.Warn("failed to persist watch config", zap.Error(err))
 		}
 	}
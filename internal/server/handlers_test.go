@@ -225,6 +225,27 @@ func TestHandleSearch(t *testing.T) {
 	if w.Code != http.StatusOK {
 		t.Errorf("status: got %d", w.Code)
 	}
+
+	body, _ = json.Marshal(map[string]interface{}{"query": "hello", "min_size": 10, "max_size": 5})
+	w = httptest.NewRecorder()
+	srv.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search", bytes.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid size range: got %d, want 400", w.Code)
+	}
+
+	body, _ = json.Marshal(map[string]interface{}{"query": "hello", "sort_by": "author"})
+	w = httptest.NewRecorder()
+	srv.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search", bytes.NewReader(body)))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid sort_by: got %d, want 400", w.Code)
+	}
+
+	body, _ = json.Marshal(map[string]interface{}{"query": "hello", "snapshot": "missing"})
+	w = httptest.NewRecorder()
+	srv.handleSearch(w, httptest.NewRequest(http.MethodPost, "/api/v1/search", bytes.NewReader(body)))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown snapshot: got %d, want 404", w.Code)
+	}
 }
 
 func TestHandleStatus(t *testing.T) {
@@ -240,7 +261,7 @@ func TestHandleStatus(t *testing.T) {
 	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20,
 		DefaultKeywordEnabled: true, DefaultSemanticEnabled: true}
 	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
-	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil, indexer.WithEmbedQueue(indexer.NewEmbedQueue(64)))
 	_ = idx.IndexDocument(context.Background(), &models.DocumentInput{ID: "d1", Title: "T", Content: "hello world"})
 	logger := zap.NewNop()
 
@@ -252,9 +273,10 @@ func TestHandleStatus(t *testing.T) {
 		t.Errorf("status: got %d, body: %s", w.Code, w.Body.String())
 	}
 	var out struct {
-		Documents       int64 `json:"documents"`
-		Chunks          int64 `json:"chunks"`
-		VectorIndexSize int   `json:"vector_index_size"`
+		Documents       int64                    `json:"documents"`
+		Chunks          int64                    `json:"chunks"`
+		VectorIndexSize int                      `json:"vector_index_size"`
+		EmbedQueue      *indexer.EmbedQueueStats `json:"embed_queue"`
 	}
 	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
 		t.Fatal(err)
@@ -268,6 +290,9 @@ func TestHandleStatus(t *testing.T) {
 	if out.VectorIndexSize < 1 {
 		t.Errorf("vector_index_size: got %d, want >= 1", out.VectorIndexSize)
 	}
+	if out.EmbedQueue == nil || out.EmbedQueue.Capacity != 64 || out.EmbedQueue.Depth != 0 || out.EmbedQueue.Peak < 1 {
+		t.Errorf("embed_queue: got %+v", out.EmbedQueue)
+	}
 }
 
 func TestHandleStatus_WithDiskUsage(t *testing.T) {
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hyperjump/sagasu/internal/jobs"
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+// submitIndexDocument queues input for indexing and responds 202 with the job.
+func (s *Server) submitIndexDocument(w http.ResponseWriter, r *http.Request, input *models.DocumentInput) {
+	if s.jobs == nil {
+		s.respondError(w, http.StatusNotImplemented, "jobs not enabled")
+		return
+	}
+	job, err := s.jobs.Submit(r.Context(), "index_document", input.ID, func(ctx context.Context) error {
+		return s.indexer.IndexDocument(ctx, input)
+	})
+	if err != nil {
+		s.logger.Error("queue index document failed", zap.Error(err))
+		s.respondError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusAccepted, job)
+}
+
+func (s *Server) handleJobsList(w http.ResponseWriter, r *http.Request) {
+	if s.jobs == nil {
+		s.respondError(w, http.StatusNotImplemented, "jobs not enabled")
+		return
+	}
+	status := jobs.Status(r.URL.Query().Get("status"))
+	switch status {
+	case "", jobs.StatusQueued, jobs.StatusRunning, jobs.StatusCompleted, jobs.StatusFailed, jobs.StatusReplaced:
+	default:
+		s.respondError(w, http.StatusBadRequest, "invalid status")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"jobs":   s.jobs.List(status),
+		"counts": s.jobs.Counts(),
+	})
+}
+
+func (s *Server) handleJobGet(w http.ResponseWriter, r *http.Request) {
+	if s.jobs == nil {
+		s.respondError(w, http.StatusNotImplemented, "jobs not enabled")
+		return
+	}
+	job, ok := s.jobs.Get(chi.URLParam(r, "id"))
+	if !ok {
+		s.respondError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, job)
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/jobs"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestHandleIndexDocument_async(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	defer embedder.Close()
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	defer vecIdx.Close()
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20,
+		DefaultKeywordEnabled: true, DefaultSemanticEnabled: true}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	queue := jobs.NewQueue(jobs.WithWorkers(1))
+	defer queue.Stop()
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil).WithJobs(queue)
+
+	body, _ := json.Marshal(map[string]string{"id": "doc1", "title": "T", "content": "queued content"})
+	w := httptest.NewRecorder()
+	srv.handleIndexDocument(w, httptest.NewRequest(http.MethodPost, "/api/v1/documents?async=true", bytes.NewReader(body)))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status: got %d, body: %s", w.Code, w.Body.String())
+	}
+	var job jobs.Job
+	if err := json.NewDecoder(w.Body).Decode(&job); err != nil {
+		t.Fatal(err)
+	}
+	if job.ID == "" || job.Kind != "index_document" || job.Target != "doc1" {
+		t.Fatalf("job: got %+v", job)
+	}
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", job.ID)
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/jobs/"+job.ID, nil)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		w = httptest.NewRecorder()
+		srv.handleJobGet(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("get job: got %d", w.Code)
+		}
+		_ = json.NewDecoder(w.Body).Decode(&job)
+		if job.Status == jobs.StatusCompleted || job.Status == jobs.StatusFailed {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if job.Status != jobs.StatusCompleted {
+		t.Fatalf("job status: got %q (%s)", job.Status, job.Error)
+	}
+	if _, err := store.GetDocument(context.Background(), "doc1"); err != nil {
+		t.Errorf("document not indexed: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleJobsList(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs?status=completed", nil))
+	var list struct {
+		Jobs []jobs.Job `json:"jobs"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Jobs) != 1 {
+		t.Errorf("completed jobs: got %d, want 1", len(list.Jobs))
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleJobsList(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs?status=bogus", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid status filter: got %d", w.Code)
+	}
+}
+
+func TestHandleJobsList_NotEnabled(t *testing.T) {
+	srv := NewServer(nil, nil, nil, &config.ServerConfig{}, zap.NewNop(), nil, "", nil)
+	w := httptest.NewRecorder()
+	srv.handleJobsList(w, httptest.NewRequest(http.MethodGet, "/api/v1/jobs", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("status: got %d, want 501", w.Code)
+	}
+}
@@ -0,0 +1,24 @@
+package server
+
+import "net/http"
+
+// handlePause stops the job queue from starting indexing jobs until resumed. Changes seen
+// by the watcher keep being queued and are indexed on resume.
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	if s.jobs == nil {
+		s.respondError(w, http.StatusNotImplemented, "jobs not enabled")
+		return
+	}
+	s.jobs.Pause()
+	s.respondJSON(w, http.StatusOK, map[string]bool{"paused": true})
+}
+
+// handleResume lets the job queue start indexing jobs again.
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	if s.jobs == nil {
+		s.respondError(w, http.StatusNotImplemented, "jobs not enabled")
+		return
+	}
+	s.jobs.Resume()
+	s.respondJSON(w, http.StatusOK, map[string]bool{"paused": false})
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/jobs"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestHandlePauseResume(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	w := httptest.NewRecorder()
+	srv.handlePause(w, httptest.NewRequest(http.MethodPost, "/api/v1/pause", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("without jobs: got %d, want 501", w.Code)
+	}
+
+	queue := jobs.NewQueue(jobs.WithWorkers(1))
+	defer queue.Stop()
+	srv.WithJobs(queue)
+	paused := func() bool {
+		t.Helper()
+		w := httptest.NewRecorder()
+		srv.handleStatus(w, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+		var out struct {
+			Paused bool `json:"paused"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		return out.Paused
+	}
+	srv.handlePause(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/pause", nil))
+	if !queue.Paused() || !paused() {
+		t.Error("pause should pause the queue and be reported by status")
+	}
+	srv.handleResume(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/resume", nil))
+	if queue.Paused() || paused() {
+		t.Error("resume should resume the queue")
+	}
+}
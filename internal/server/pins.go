@@ -0,0 +1,63 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+// handlePinsList returns all pins, oldest first.
+func (s *Server) handlePinsList(w http.ResponseWriter, r *http.Request) {
+	pins, err := s.storage.ListPins(r.Context())
+	if err != nil {
+		s.logger.Error("list pins failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if pins == nil {
+		pins = []*models.Pin{}
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"pins": pins})
+}
+
+// handlePinCreate stores a pin from the request body ({"query", and "document_id" or "path"}).
+func (s *Server) handlePinCreate(w http.ResponseWriter, r *http.Request) {
+	var pin models.Pin
+	if err := json.NewDecoder(r.Body).Decode(&pin); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := pin.Validate(); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	pin.ID = ""
+	pin.CreatedAt = time.Time{}
+	if err := s.storage.CreatePin(r.Context(), &pin); err != nil {
+		s.logger.Error("create pin failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusCreated, &pin)
+}
+
+// handlePinDelete removes the pin with the given ID.
+func (s *Server) handlePinDelete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := s.storage.DeletePin(r.Context(), id); err != nil {
+		if errors.Is(err, storage.ErrPinNotFound) {
+			s.respondError(w, http.StatusNotFound, "pin not found")
+			return
+		}
+		s.logger.Error("delete pin failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+func TestHandlePins(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(t.TempDir() + "/db.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	srv := NewServer(nil, nil, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	r := chi.NewRouter()
+	r.Get("/api/v1/pins", srv.handlePinsList)
+	r.Post("/api/v1/pins", srv.handlePinCreate)
+	r.Delete("/api/v1/pins/{id}", srv.handlePinDelete)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	for _, body := range []string{`{"document_id":"d1"}`, `{"query":"q"}`, `{"query":"q","document_id":"d1","path":"/p"}`} {
+		if w := do(http.MethodPost, "/api/v1/pins", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, w.Code)
+		}
+	}
+
+	w := do(http.MethodPost, "/api/v1/pins", `{"query":"expense policy","document_id":"d1"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body: %s", w.Code, w.Body.String())
+	}
+	var pin models.Pin
+	if err := json.NewDecoder(w.Body).Decode(&pin); err != nil {
+		t.Fatal(err)
+	}
+
+	var list struct {
+		Pins []*models.Pin `json:"pins"`
+	}
+	if err := json.NewDecoder(do(http.MethodGet, "/api/v1/pins", "").Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Pins) != 1 || list.Pins[0].ID != pin.ID {
+		t.Errorf("list: got %+v", list.Pins)
+	}
+
+	if w := do(http.MethodDelete, "/api/v1/pins/"+pin.ID, ""); w.Code != http.StatusOK {
+		t.Errorf("delete: status %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/v1/pins/"+pin.ID, ""); w.Code != http.StatusNotFound {
+		t.Errorf("delete missing: status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+
+	"github.com/hyperjump/sagasu/internal/watcher"
+	"go.uber.org/zap"
+)
+
+// PriorityService marks files whose changes are indexed immediately (optional; implemented by
+// *watcher.Watcher).
+type PriorityService interface {
+	AddPriority(path string) error
+	RemovePriority(path string)
+	PriorityPaths() []string
+}
+
+func (s *Server) priorityService() PriorityService {
+	p, _ := s.watch.(PriorityService)
+	return p
+}
+
+// handlePriorityList returns the high-priority files.
+func (s *Server) handlePriorityList(w http.ResponseWriter, r *http.Request) {
+	p := s.priorityService()
+	if p == nil {
+		s.respondError(w, http.StatusNotImplemented, "watch not enabled")
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"paths": p.PriorityPaths()})
+}
+
+// handlePriorityAdd marks the file in the request body ({"path"}) as high priority, e.g. when an
+// editor opens it, so its changes skip the debounce and the job queue.
+func (s *Server) handlePriorityAdd(w http.ResponseWriter, r *http.Request) {
+	p := s.priorityService()
+	if p == nil {
+		s.respondError(w, http.StatusNotImplemented, "watch not enabled")
+		return
+	}
+	var req struct {
+		Path string `json:"path"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Path == "" {
+		s.respondError(w, http.StatusBadRequest, "path is required")
+		return
+	}
+	abs, err := filepath.Abs(req.Path)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid path")
+		return
+	}
+	if err := p.AddPriority(abs); err != nil {
+		if errors.Is(err, watcher.ErrNotWatched) {
+			s.respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.logger.Error("add priority file failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]string{"path": abs, "status": "priority"})
+}
+
+// handlePriorityRemove returns a file (query or body "path") to normal, debounced indexing,
+// e.g. when an editor closes it.
+func (s *Server) handlePriorityRemove(w http.ResponseWriter, r *http.Request) {
+	p := s.priorityService()
+	if p == nil {
+		s.respondError(w, http.StatusNotImplemented, "watch not enabled")
+		return
+	}
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		var body struct {
+			Path string `json:"path"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+			path = body.Path
+		}
+	}
+	if path == "" {
+		s.respondError(w, http.StatusBadRequest, "path is required (query or body)")
+		return
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid path")
+		return
+	}
+	p.RemovePriority(abs)
+	s.respondJSON(w, http.StatusOK, map[string]string{"path": abs, "status": "normal"})
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"github.com/hyperjump/sagasu/internal/watcher"
+	"go.uber.org/zap"
+)
+
+func TestHandlePriority(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+
+	noWatch := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	w := httptest.NewRecorder()
+	noWatch.handlePriorityList(w, httptest.NewRequest(http.MethodGet, "/api/v1/watch/priority", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("without watcher: got %d, want 501", w.Code)
+	}
+
+	docs := filepath.Join(dir, "docs")
+	watch := watcher.NewWatcher([]string{docs}, []string{".md"}, true, nil, nil)
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), watch, "", nil)
+	add := func(path string) int {
+		body, _ := json.Marshal(map[string]string{"path": path})
+		w := httptest.NewRecorder()
+		srv.handlePriorityAdd(w, httptest.NewRequest(http.MethodPost, "/api/v1/watch/priority", bytes.NewReader(body)))
+		return w.Code
+	}
+	list := func() []string {
+		w := httptest.NewRecorder()
+		srv.handlePriorityList(w, httptest.NewRequest(http.MethodGet, "/api/v1/watch/priority", nil))
+		var out struct {
+			Paths []string `json:"paths"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		return out.Paths
+	}
+
+	open := filepath.Join(docs, "todo.md")
+	if code := add(open); code != http.StatusOK {
+		t.Fatalf("add: got %d", code)
+	}
+	if code := add(filepath.Join(dir, "elsewhere.md")); code != http.StatusBadRequest {
+		t.Errorf("add outside watched roots: got %d, want 400", code)
+	}
+	if code := add(""); code != http.StatusBadRequest {
+		t.Errorf("add without path: got %d, want 400", code)
+	}
+	if got := list(); len(got) != 1 || got[0] != open {
+		t.Errorf("list = %v, want [%s]", got, open)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handlePriorityRemove(w, httptest.NewRequest(http.MethodDelete, "/api/v1/watch/priority?path="+open, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("remove: got %d", w.Code)
+	}
+	if got := list(); len(got) != 0 {
+		t.Errorf("list after remove = %v", got)
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// pprofPath is the prefix of the runtime profiles, which may take longer than the request
+// timeout to collect.
+const pprofPath = "/api/v1/debug/pprof/"
+
+// WithProfiling enables the net/http/pprof profiles under /api/v1/debug/pprof/ (write
+// scope), e.g. for "sagasu profile".
+func (s *Server) WithProfiling() *Server {
+	s.profiling = true
+	return s
+}
+
+// handlePprof serves the pprof index and profiles: profile (CPU, ?seconds=), trace,
+// cmdline, symbol, and the runtime profiles such as heap, allocs, goroutine, block, and
+// mutex.
+func (s *Server) handlePprof(w http.ResponseWriter, r *http.Request) {
+	if !s.profiling {
+		s.respondError(w, http.StatusNotImplemented, "profiling not enabled (set server.profiling)")
+		return
+	}
+	switch name := chi.URLParam(r, "*"); name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Handler(strings.TrimSuffix(name, "/")).ServeHTTP(w, r)
+	}
+}
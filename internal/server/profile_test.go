@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"go.uber.org/zap"
+)
+
+func TestHandlePprof(t *testing.T) {
+	serverCfg := &config.ServerConfig{Auth: config.AuthConfig{APIKeys: []config.APIKeyConfig{
+		{Name: "dashboard", Key: "read-key", Scope: config.ScopeRead},
+		{Name: "admin", Key: "write-key", Scope: config.ScopeWrite},
+	}}}
+	srv := NewServer(nil, nil, nil, serverCfg, zap.NewNop(), nil, "", nil)
+	do := func(path, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		srv.routes().ServeHTTP(w, r)
+		return w
+	}
+
+	if w := do("/api/v1/debug/pprof/heap", "write-key"); w.Code != http.StatusNotImplemented {
+		t.Errorf("without profiling: status %d, want 501", w.Code)
+	}
+	srv.WithProfiling()
+	if w := do("/api/v1/debug/pprof/heap", "read-key"); w.Code != http.StatusForbidden {
+		t.Errorf("read key: status %d, want 403", w.Code)
+	}
+	w := do("/api/v1/debug/pprof/", "write-key")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "goroutine") {
+		t.Errorf("index: status %d, body: %.200s", w.Code, w.Body.String())
+	}
+	if w = do("/api/v1/debug/pprof/heap?gc=1", "write-key"); w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Errorf("heap: status %d, %d bytes", w.Code, w.Body.Len())
+	}
+	if w = do("/api/v1/debug/pprof/profile?seconds=1", "write-key"); w.Code != http.StatusOK || w.Body.Len() == 0 {
+		t.Errorf("cpu profile: status %d, body: %.200s", w.Code, w.Body.String())
+	}
+	if w = do("/api/v1/debug/pprof/nosuchprofile", "write-key"); w.Code != http.StatusNotFound {
+		t.Errorf("unknown profile: status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,32 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"go.uber.org/zap"
+)
+
+// maxQualitySample bounds ?sample= on /api/v1/quality; each sampled chunk runs the model.
+const maxQualitySample = 1000
+
+// handleQuality re-embeds a sample of ?sample= stored chunks (default 100) with the current
+// model and reports how far the indexed vectors have drifted, plus count mismatches between
+// storage and the keyword and vector indexes.
+func (s *Server) handleQuality(w http.ResponseWriter, r *http.Request) {
+	sample, ok := positiveIntParam(r.URL.Query().Get("sample"), indexer.DefaultQualitySample)
+	if !ok {
+		s.respondError(w, http.StatusBadRequest, "sample must be a positive integer")
+		return
+	}
+	if sample > maxQualitySample {
+		sample = maxQualitySample
+	}
+	report, err := s.indexer.CheckQuality(r.Context(), sample)
+	if err != nil {
+		s.logger.Error("quality check failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestHandleQuality(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	if err := idx.IndexDocument(context.Background(), &models.DocumentInput{ID: "d1", Title: "Notes", Content: "harbour tides and ferries"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	w := httptest.NewRecorder()
+	srv.handleQuality(w, httptest.NewRequest(http.MethodGet, "/api/v1/quality?sample=5", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", w.Code, w.Body)
+	}
+	var report models.QualityReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Documents != 1 || report.Sampled == 0 || report.Sampled > 5 || len(report.Warnings) != 0 {
+		t.Errorf("report = %+v", report)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleQuality(w, httptest.NewRequest(http.MethodGet, "/api/v1/quality?sample=-1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("negative sample: got %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRecentDays  = 7
+	defaultRecentLimit = 50
+	maxRecentLimit     = 1000
+)
+
+// handleRecent lists documents modified in the last ?days= days (default 7), optionally
+// restricted to source paths under ?path_prefix=, newest first.
+func (s *Server) handleRecent(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	days, ok := positiveIntParam(q.Get("days"), defaultRecentDays)
+	if !ok {
+		s.respondError(w, http.StatusBadRequest, "days must be a positive integer")
+		return
+	}
+	limit, ok := positiveIntParam(q.Get("limit"), defaultRecentLimit)
+	if !ok {
+		s.respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+		return
+	}
+	if limit > maxRecentLimit {
+		limit = maxRecentLimit
+	}
+	pathPrefix := q.Get("path_prefix")
+
+	since := time.Now().AddDate(0, 0, -days)
+	docs, total, err := s.storage.ListRecentDocuments(r.Context(), since, pathPrefix, limit)
+	if err != nil {
+		s.logger.Error("list recent documents failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if docs == nil {
+		docs = []*models.RecentDocument{}
+	}
+	s.respondJSON(w, http.StatusOK, &models.RecentResponse{
+		Documents:  docs,
+		Total:      total,
+		Days:       days,
+		PathPrefix: pathPrefix,
+	})
+}
+
+// positiveIntParam parses v as a positive integer, returning def when v is empty.
+func positiveIntParam(v string, def int) (int, bool) {
+	if v == "" {
+		return def, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+func TestHandleRecent(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(t.TempDir() + "/db.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	for id, age := range map[string]time.Duration{"fresh": time.Hour, "stale": 10 * 24 * time.Hour} {
+		if err := store.CreateDocument(ctx, &models.Document{ID: id, Content: "c", Metadata: map[string]interface{}{
+			"source_path":  "/docs/" + id + ".txt",
+			"source_mtime": strconv.FormatInt(time.Now().Add(-age).UnixNano(), 10),
+		}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := NewServer(nil, nil, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+
+	w := httptest.NewRecorder()
+	srv.handleRecent(w, httptest.NewRequest(http.MethodGet, "/api/v1/recent", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: got %d, body: %s", w.Code, w.Body.String())
+	}
+	var out models.RecentResponse
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Days != 7 || out.Total != 1 || out.Documents[0].ID != "fresh" {
+		t.Errorf("default 7 days: got %+v", out)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleRecent(w, httptest.NewRequest(http.MethodGet, "/api/v1/recent?days=30&path_prefix=/docs/s", nil))
+	out = models.RecentResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Total != 1 || out.Documents[0].ID != "stale" {
+		t.Errorf("30 days with path prefix: got %+v", out)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleRecent(w, httptest.NewRequest(http.MethodGet, "/api/v1/recent?days=0", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("days=0: got %d, want 400", w.Code)
+	}
+}
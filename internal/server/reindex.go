@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"go.uber.org/zap"
+)
+
+// Reindex job states reported by GET /api/v1/reindex.
+const (
+	reindexStateIdle      = "idle"
+	reindexStateRunning   = "running"
+	reindexStateCompleted = "completed"
+	reindexStateFailed    = "failed"
+)
+
+// Reindex modes accepted by POST /api/v1/reindex?mode=.
+const (
+	reindexModeInPlace = "in_place"
+	reindexModeShadow  = "shadow"
+)
+
+// reindexStatus is the progress of the most recent reindex run.
+type reindexStatus struct {
+	State      string     `json:"state"`
+	Mode       string     `json:"mode,omitempty"`
+	Done       int        `json:"done"`
+	Total      int        `json:"total"`
+	Indexed    int        `json:"indexed"`
+	Failed     int        `json:"failed"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// reindexTracker allows one reindex at a time and records its progress.
+type reindexTracker struct {
+	mu     sync.Mutex
+	status reindexStatus
+}
+
+// start marks a run in mode as started. Returns false if one is already running.
+func (t *reindexTracker) start(mode string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.status.State == reindexStateRunning {
+		return false
+	}
+	now := time.Now()
+	t.status = reindexStatus{State: reindexStateRunning, Mode: mode, StartedAt: &now}
+	return true
+}
+
+func (t *reindexTracker) progress(done, total int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status.Done = done
+	t.status.Total = total
+}
+
+func (t *reindexTracker) finish(result *indexer.ReindexResult, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.status.FinishedAt = &now
+	if result != nil {
+		t.status.Total = result.Total
+		t.status.Indexed = result.Indexed
+		t.status.Failed = result.Failed
+	}
+	if err != nil {
+		t.status.State = reindexStateFailed
+		t.status.Error = err.Error()
+		return
+	}
+	t.status.State = reindexStateCompleted
+}
+
+func (t *reindexTracker) snapshot() reindexStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := t.status
+	if s.State == "" {
+		s.State = reindexStateIdle
+	}
+	return s
+}
+
+// reindexSources returns the directories and extensions to rebuild from.
+func (s *Server) reindexSources() (dirs, exts []string) {
+	cfg := s.fullConfig()
+	if s.watch != nil {
+		dirs = s.watch.Directories()
+	} else if cfg != nil {
+		dirs = append([]string(nil), cfg.Watch.Directories...)
+	}
+	if cfg != nil {
+		exts = cfg.Watch.Extensions
+	}
+	return dirs, exts
+}
+
+func (s *Server) handleReindexStart(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "":
+		mode = reindexModeInPlace
+	case reindexModeInPlace:
+	case reindexModeShadow:
+		if s.shadow == nil {
+			s.respondError(w, http.StatusBadRequest, "shadow rebuild is not available")
+			return
+		}
+	default:
+		s.respondError(w, http.StatusBadRequest, "mode must be in_place or shadow")
+		return
+	}
+	if !s.reindex.start(mode) {
+		s.respondError(w, http.StatusConflict, "reindex already running")
+		return
+	}
+	dirs, exts := s.reindexSources()
+	s.logger.Info("reindex started", zap.String("mode", mode), zap.Strings("directories", dirs))
+	go func() {
+		var result *indexer.ReindexResult
+		var err error
+		if mode == reindexModeShadow {
+			result, err = s.indexer.RebuildShadow(context.Background(), dirs, exts, s.shadow, s.reindex.progress)
+		} else {
+			result, err = s.indexer.ReindexAll(context.Background(), dirs, exts, s.reindex.progress)
+		}
+		if err != nil {
+			s.logger.Error("reindex failed", zap.Error(err))
+		} else {
+			s.logger.Info("reindex completed",
+				zap.Int("indexed", result.Indexed), zap.Int("failed", result.Failed))
+			if err := s.engine.RefreshSpellChecker(); err != nil {
+				s.logger.Warn("spell checker refresh failed", zap.Error(err))
+			}
+		}
+		s.reindex.finish(result, err)
+	}()
+	s.respondJSON(w, http.StatusAccepted, s.reindex.snapshot())
+}
+
+func (s *Server) handleReindexStatus(w http.ResponseWriter, r *http.Request) {
+	s.respondJSON(w, http.StatusOK, s.reindex.snapshot())
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestHandleReindex(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	defer embedder.Close()
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	defer vecIdx.Close()
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20,
+		DefaultKeywordEnabled: true, DefaultSemanticEnabled: true}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	logger := zap.NewNop()
+
+	docs := filepath.Join(dir, "docs")
+	if err := os.Mkdir(docs, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte("content of "+name), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mock := &mockWatchService{dirs: []string{docs}}
+	fullCfg := &config.Config{Watch: config.WatchConfig{Extensions: []string{".txt"}}}
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, logger, mock, "", fullCfg)
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/reindex", nil)
+	w := httptest.NewRecorder()
+	srv.handleReindexStart(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status: got %d, body: %s", w.Code, w.Body.String())
+	}
+
+	var status reindexStatus
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		w = httptest.NewRecorder()
+		srv.handleReindexStatus(w, httptest.NewRequest(http.MethodGet, "/api/v1/reindex", nil))
+		if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+			t.Fatal(err)
+		}
+		if status.State != reindexStateRunning {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if status.State != reindexStateCompleted {
+		t.Fatalf("state: got %q (error %q), want completed", status.State, status.Error)
+	}
+	if status.Total != 2 || status.Indexed != 2 || status.Done != 2 {
+		t.Errorf("progress: got %+v", status)
+	}
+}
+
+func TestReindexTracker_singleRun(t *testing.T) {
+	var tr reindexTracker
+	if got := tr.snapshot().State; got != reindexStateIdle {
+		t.Errorf("initial state: got %q, want idle", got)
+	}
+	if !tr.start(reindexModeInPlace) {
+		t.Fatal("first start should succeed")
+	}
+	if tr.start(reindexModeInPlace) {
+		t.Error("second start while running should be rejected")
+	}
+	tr.finish(&indexer.ReindexResult{Total: 1, Indexed: 1}, nil)
+	if !tr.start(reindexModeInPlace) {
+		t.Error("start after finish should succeed")
+	}
+}
+
+func TestHandleReindex_mode(t *testing.T) {
+	srv := NewServer(nil, nil, nil, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"?mode=shadow", "shadow rebuild is not available"},
+		{"?mode=sideways", "mode must be in_place or shadow"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		srv.handleReindexStart(w, httptest.NewRequest(http.MethodPost, "/api/v1/reindex"+tt.query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status got %d, want 400", tt.query, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), tt.want) {
+			t.Errorf("%s: body %q should contain %q", tt.query, w.Body.String(), tt.want)
+		}
+	}
+	if got := srv.reindex.snapshot().State; got != reindexStateIdle {
+		t.Errorf("rejected requests should not start a run, state %q", got)
+	}
+}
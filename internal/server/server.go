@@ -11,7 +11,11 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/desktop"
+	"github.com/hyperjump/sagasu/internal/events"
 	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/jobs"
+	"github.com/hyperjump/sagasu/internal/llm"
 	"github.com/hyperjump/sagasu/internal/search"
 	"github.com/hyperjump/sagasu/internal/storage"
 	"github.com/hyperjump/sagasu/internal/watcher"
@@ -37,6 +41,15 @@ type Server struct {
 	configPath   string
 	watchConfig  *config.Config
 	watchConfigMu sync.Mutex
+	reindex      reindexTracker
+	jobs         *jobs.Queue
+	shadow       indexer.ShadowTarget
+	auditLog     bool
+	analytics    bool
+	llm          *llm.Client
+	openFile     func(path string) error
+	events       *events.Bus
+	profiling    bool
 }
 
 // NewServer creates a server with the given dependencies.
@@ -61,36 +74,103 @@ func NewServer(
 		watch:       watchSvc,
 		configPath:  configPath,
 		watchConfig: fullCfg,
+		openFile:    desktop.Open,
 	}
 }
 
-// Start starts the HTTP server and blocks until it stops.
-func (s *Server) Start() error {
-	r := chi.NewRouter()
-	r.Use(middleware.Logger)
-	r.Use(middleware.Recoverer)
-	r.Use(middleware.Timeout(60 * time.Second))
-	r.Use(middleware.Compress(5))
+// WithJobs routes asynchronous indexing through q and enables the jobs endpoints.
+func (s *Server) WithJobs(q *jobs.Queue) *Server {
+	s.jobs = q
+	return s
+}
 
-	r.Post("/api/v1/search", s.handleSearch)
-	r.Post("/api/v1/documents", s.handleIndexDocument)
-	r.Get("/api/v1/documents/{id}", s.handleGetDocument)
-	r.Delete("/api/v1/documents/{id}", s.handleDeleteDocument)
-	r.Get("/api/v1/watch/directories", s.handleWatchDirectoriesList)
-	r.Post("/api/v1/watch/directories", s.handleWatchDirectoriesAdd)
-	r.Delete("/api/v1/watch/directories", s.handleWatchDirectoriesRemove)
-	r.Get("/api/v1/status", s.handleStatus)
-	r.Get("/health", s.handleHealth)
+// WithShadowRebuild enables POST /api/v1/reindex?mode=shadow, which rebuilds into stores
+// created by t while the current ones keep serving, then swaps them in.
+func (s *Server) WithShadowRebuild(t indexer.ShadowTarget) *Server {
+	s.shadow = t
+	return s
+}
 
+// Start starts the HTTP server and blocks until it stops.
+func (s *Server) Start() error {
 	addr := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
 	s.server = &http.Server{
 		Addr:    addr,
-		Handler: r,
+		Handler: s.routes(),
 	}
 	s.logger.Info("Starting server", zap.String("addr", addr))
 	return s.server.ListenAndServe()
 }
 
+// routes returns the router. With API keys configured, /api/v1 endpoints need a key:
+// read scope for searches and lookups, write scope for changes, the audit log, analytics,
+// the configuration, and profiles.
+// /health and the web UI are open; the UI asks for a key when the API refuses it.
+func (s *Server) routes() http.Handler {
+	r := chi.NewRouter()
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(requestTimeout(60 * time.Second))
+	r.Use(middleware.Compress(5))
+
+	read := r.With(s.requireScope(config.ScopeRead))
+	write := r.With(s.requireScope(config.ScopeWrite))
+
+	read.Post("/api/v1/search", s.handleSearch)
+	read.Get("/api/v1/search/stream", s.handleSearchStream)
+	read.Post("/api/v1/search/diff", s.handleSearchDiff)
+	read.Post("/api/v1/ask", s.handleAsk)
+	write.Post("/api/v1/documents", s.handleIndexDocument)
+	write.Post("/api/v1/documents:batch", s.handleIndexDocumentsBatch)
+	read.Get("/api/v1/documents", s.handleListDocuments)
+	read.Get("/api/v1/documents/{id}", s.handleGetDocument)
+	read.Get("/api/v1/documents/{id}/file", s.handleDocumentFile)
+	read.Post("/api/v1/documents/{id}/open", s.handleOpenDocument)
+	write.Delete("/api/v1/documents/{id}", s.handleDeleteDocument)
+	read.Get("/api/v1/watch/directories", s.handleWatchDirectoriesList)
+	write.Post("/api/v1/watch/directories", s.handleWatchDirectoriesAdd)
+	write.Delete("/api/v1/watch/directories", s.handleWatchDirectoriesRemove)
+	read.Get("/api/v1/watch/priority", s.handlePriorityList)
+	write.Post("/api/v1/watch/priority", s.handlePriorityAdd)
+	write.Delete("/api/v1/watch/priority", s.handlePriorityRemove)
+	write.Post("/api/v1/watch/flush", s.handleIndexHeld)
+	write.Post("/api/v1/reindex", s.handleReindexStart)
+	read.Get("/api/v1/reindex", s.handleReindexStatus)
+	read.Get("/api/v1/recent", s.handleRecent)
+	read.Get("/api/v1/duplicates", s.handleDuplicates)
+	write.Get("/api/v1/graph", s.handleGraph)
+	read.Get("/api/v1/count", s.handleCount)
+	read.Get("/api/v1/explain", s.handleExplain)
+	read.Get("/api/v1/exists", s.handleExists)
+	read.Get("/api/v1/pins", s.handlePinsList)
+	write.Post("/api/v1/pins", s.handlePinCreate)
+	write.Delete("/api/v1/pins/{id}", s.handlePinDelete)
+	read.Get("/api/v1/snapshots", s.handleSnapshotsList)
+	write.Post("/api/v1/snapshots", s.handleSnapshotCreate)
+	write.Delete("/api/v1/snapshots/{name}", s.handleSnapshotDelete)
+	write.Get("/api/v1/audit", s.handleAuditList)
+	read.Post("/api/v1/feedback", s.handleFeedback)
+	read.Post("/api/v1/feedback/open", s.handleFeedbackOpen)
+	write.Get("/api/v1/analytics", s.handleAnalytics)
+	read.Get(eventsPath, s.handleEvents)
+	read.Get("/api/v1/jobs", s.handleJobsList)
+	read.Get("/api/v1/jobs/{id}", s.handleJobGet)
+	write.Post("/api/v1/pause", s.handlePause)
+	write.Post("/api/v1/resume", s.handleResume)
+	read.Get("/api/v1/status", s.handleStatus)
+	write.Get("/api/v1/config", s.handleConfigGet)
+	write.Patch("/api/v1/config", s.handleConfigPatch)
+	read.Get("/api/v1/status/changes", s.handleChanges)
+	read.Get("/api/v1/quality", s.handleQuality)
+	read.Get(fsckPath, s.handleFsck)
+	write.Post(fsckPath, s.handleFsck)
+	write.Get(pprofPath+"*", s.handlePprof)
+	r.Get("/health", s.handleHealth)
+	r.Get("/", s.handleWebUI)
+	r.Get("/assets/*", s.handleWebAsset)
+	return r
+}
+
 // Stop gracefully shuts down the server. If the server was created with a watcher
 // that implements the Stop method (e.g. *watcher.Watcher), it is stopped first.
 func (s *Server) Stop(ctx context.Context) error {
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+// handleSnapshotsList returns all snapshots, oldest first.
+func (s *Server) handleSnapshotsList(w http.ResponseWriter, r *http.Request) {
+	snaps, err := s.storage.ListSnapshots(r.Context())
+	if err != nil {
+		s.logger.Error("list snapshots failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if snaps == nil {
+		snaps = []*models.Snapshot{}
+	}
+	s.respondJSON(w, http.StatusOK, map[string]interface{}{"snapshots": snaps})
+}
+
+// handleSnapshotCreate records the documents indexed now as the snapshot named in the
+// request body ({"name"}).
+func (s *Server) handleSnapshotCreate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := models.ValidateSnapshotName(req.Name); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	snap, err := s.storage.CreateSnapshot(r.Context(), req.Name)
+	if errors.Is(err, storage.ErrSnapshotExists) {
+		s.respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if err != nil {
+		s.logger.Error("create snapshot failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusCreated, snap)
+}
+
+// handleSnapshotDelete removes the snapshot with the given name.
+func (s *Server) handleSnapshotDelete(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := s.storage.DeleteSnapshot(r.Context(), name); err != nil {
+		if errors.Is(err, storage.ErrSnapshotNotFound) {
+			s.respondError(w, http.StatusNotFound, "snapshot not found")
+			return
+		}
+		s.logger.Error("delete snapshot failed", zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+func TestHandleSnapshots(t *testing.T) {
+	store, err := storage.NewSQLiteStorage(t.TempDir() + "/db.sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if err := store.CreateDocument(t.Context(), &models.Document{ID: "d1", Content: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(nil, nil, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	r := chi.NewRouter()
+	r.Get("/api/v1/snapshots", srv.handleSnapshotsList)
+	r.Post("/api/v1/snapshots", srv.handleSnapshotCreate)
+	r.Delete("/api/v1/snapshots/{name}", srv.handleSnapshotDelete)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	for _, body := range []string{`{}`, `{"name":"a b"}`, `{"name":"../x"}`} {
+		if w := do(http.MethodPost, "/api/v1/snapshots", body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, w.Code)
+		}
+	}
+
+	w := do(http.MethodPost, "/api/v1/snapshots", `{"name":"eval-2026.10"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d, body: %s", w.Code, w.Body.String())
+	}
+	var snap models.Snapshot
+	if err := json.NewDecoder(w.Body).Decode(&snap); err != nil {
+		t.Fatal(err)
+	}
+	if snap.Name != "eval-2026.10" || snap.Documents != 1 {
+		t.Errorf("create: got %+v", snap)
+	}
+	if w := do(http.MethodPost, "/api/v1/snapshots", `{"name":"eval-2026.10"}`); w.Code != http.StatusConflict {
+		t.Errorf("create taken name: status %d, want 409", w.Code)
+	}
+
+	var list struct {
+		Snapshots []*models.Snapshot `json:"snapshots"`
+	}
+	if err := json.NewDecoder(do(http.MethodGet, "/api/v1/snapshots", "").Body).Decode(&list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Snapshots) != 1 || list.Snapshots[0].Name != snap.Name {
+		t.Errorf("list: got %+v", list.Snapshots)
+	}
+
+	if w := do(http.MethodDelete, "/api/v1/snapshots/"+snap.Name, ""); w.Code != http.StatusOK {
+		t.Errorf("delete: status %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/api/v1/snapshots/"+snap.Name, ""); w.Code != http.StatusNotFound {
+		t.Errorf("delete missing: status %d, want 404", w.Code)
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"go.uber.org/zap"
+)
+
+// Server-sent events of GET /api/v1/search/stream.
+const (
+	streamEventKeyword = "keyword" // the keyword results, before semantic search finishes
+	streamEventDone    = "done"    // the complete response, as POST /api/v1/search returns it
+	streamEventError   = "error"   // the search failed: {"error": message}
+)
+
+// handleSearchStream runs the search for ?q= and streams it as server-sent events, so a
+// UI can show the keyword results while the query is still being embedded: a "keyword"
+// event with a response holding only the keyword results, when semantic search is still
+// running, then a "done" event with the complete response, which replaces it. It takes
+// the parameters of handleCount plus ?limit=, ?offset=, ?fusion=, and ?keyword=false or
+// ?semantic=false to disable a branch.
+func (s *Server) handleSearchStream(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := queryFromURL(q)
+	query.KeywordEnabled = q.Get("keyword") != "false"
+	query.SemanticEnabled = q.Get("semantic") != "false"
+	query.Fusion = q.Get("fusion")
+	if !query.KeywordEnabled && !query.SemanticEnabled {
+		s.respondError(w, http.StatusBadRequest, "keyword and semantic search cannot both be disabled")
+		return
+	}
+	for name, dst := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				s.respondError(w, http.StatusBadRequest, name+" must be a non-negative integer")
+				return
+			}
+			*dst = n
+		}
+	}
+	if err := query.Validate(); err != nil {
+		s.respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	audit := &models.AuditEntry{Action: models.AuditSearch, Query: query.Query}
+	w, done := s.audited(w, r, audit)
+	defer done()
+
+	ew := newEventWriter(w)
+	response, err := s.engine.SearchStream(r.Context(), query, func(partial *models.SearchResponse) {
+		_ = ew.send(streamEventKeyword, "", partial)
+	})
+	if err != nil {
+		if !errors.Is(err, keyword.ErrPatternTooBroad) && !errors.Is(err, storage.ErrSnapshotNotFound) {
+			s.logger.Error("search failed", zap.Error(err))
+		}
+		_ = ew.send(streamEventError, "", map[string]string{"error": err.Error()})
+		return
+	}
+	audit.Results = len(response.AllResults())
+	s.recordSearch(r.Context(), query, response)
+	_ = ew.send(streamEventDone, "", response)
+}
+
+// eventWriter writes server-sent events, flushing each so it reaches the client at once.
+type eventWriter struct {
+	w  http.ResponseWriter
+	rc *http.ResponseController
+}
+
+// newEventWriter sends the headers of an event stream.
+func newEventWriter(w http.ResponseWriter) *eventWriter {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	return &eventWriter{w: w, rc: http.NewResponseController(w)}
+}
+
+// send writes an event named event with data as JSON, and with id when non-empty.
+func (ew *eventWriter) send(event, id string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(ew.w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(ew.w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+		return err
+	}
+	return ew.rc.Flush()
+}
+
+// comment writes a comment line, which clients ignore, e.g. to keep an idle stream open.
+func (ew *eventWriter) comment(text string) error {
+	if _, err := fmt.Fprintf(ew.w, ": %s\n\n", text); err != nil {
+		return err
+	}
+	return ew.rc.Flush()
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+// delayedEmbedder delays Embed by delay.
+type delayedEmbedder struct {
+	embedding.Embedder
+	delay time.Duration
+}
+
+func (d *delayedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	time.Sleep(d.delay)
+	return d.Embedder.Embed(ctx, text)
+}
+
+func TestHandleSearchStream(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := &delayedEmbedder{Embedder: embedding.NewMockEmbedder(4)}
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	ctx := context.Background()
+	for _, doc := range []*models.DocumentInput{
+		{ID: "d1", Title: "Budget", Content: "quarterly budget review"},
+		{ID: "d2", Title: "Notes", Content: "meeting notes"},
+	} {
+		if err := idx.IndexDocument(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	embedder.delay = 100 * time.Millisecond
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	ts := httptest.NewServer(srv.routes())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/v1/search/stream?q=budget&limit=5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	var events []string
+	var final models.SearchResponse
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			events = append(events, name)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok && events[len(events)-1] == streamEventDone {
+			if err := json.Unmarshal([]byte(data), &final); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if strings.Join(events, ",") != "keyword,done" {
+		t.Errorf("events = %v, want keyword then done", events)
+	}
+	if len(final.NonSemanticResults) != 1 || final.NonSemanticResults[0].Document.ID != "d1" {
+		t.Errorf("final keyword results = %+v", final.NonSemanticResults)
+	}
+
+	w := httptest.NewRecorder()
+	srv.handleSearchStream(w, httptest.NewRequest(http.MethodGet, "/api/v1/search/stream?q=budget&limit=x", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("invalid limit: status %d, want 400", w.Code)
+	}
+	w = httptest.NewRecorder()
+	srv.handleSearchStream(w, httptest.NewRequest(http.MethodGet, "/api/v1/search/stream?q=", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("empty query: status %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hyperjump/sagasu/internal/models"
+	"go.uber.org/zap"
+)
+
+// webAssets holds the browser UI served at GET /: a search page and a status page
+// that talk to the JSON API.
+//
+//go:embed web
+var webAssets embed.FS
+
+// webRoot is webAssets with the web/ prefix stripped.
+var webRoot, _ = fs.Sub(webAssets, "web")
+
+// handleWebUI serves the UI's index.html.
+func (s *Server) handleWebUI(w http.ResponseWriter, r *http.Request) {
+	http.ServeFileFS(w, r, webRoot, "index.html")
+}
+
+// handleWebAsset serves the UI's scripts and stylesheets under /assets/.
+func (s *Server) handleWebAsset(w http.ResponseWriter, r *http.Request) {
+	http.StripPrefix("/assets/", http.FileServerFS(webRoot)).ServeHTTP(w, r)
+}
+
+// handleDocumentFile streams the file a document was indexed from, so the UI can link
+// to results. Only the source_path the indexer recorded for a document is ever served,
+// and only while it lies in a watched directory (see sourceFile).
+func (s *Server) handleDocumentFile(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	w, done := s.audited(w, r, &models.AuditEntry{Action: models.AuditDocumentFile, DocumentID: id})
+	defer done()
+	doc, err := s.storage.GetDocument(r.Context(), id)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, "document not found")
+		return
+	}
+	path, status, msg := s.sourceFile(doc)
+	if status != http.StatusOK {
+		s.respondError(w, status, msg)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		s.logger.Debug("open source file failed", zap.String("path", path), zap.Error(err))
+		s.respondError(w, http.StatusNotFound, "source file not found")
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		s.respondError(w, http.StatusNotFound, "source file not found")
+		return
+	}
+	http.ServeContent(w, r, filepath.Base(path), info.ModTime(), f)
+}
+
+// handleOpenDocument opens the file a document was indexed from with the default
+// application of the server's desktop, for a UI running on the same machine. It needs
+// server.open_files and, as it acts on the local desktop, accepts only requests over the
+// loopback interface that are not cross-origin.
+func (s *Server) handleOpenDocument(w http.ResponseWriter, r *http.Request) {
+	if !s.config.OpenFiles {
+		s.respondError(w, http.StatusForbidden, "opening files is disabled (server.open_files)")
+		return
+	}
+	if !localRequest(r) {
+		s.respondError(w, http.StatusForbidden, "files can only be opened from the server's machine")
+		return
+	}
+	id := chi.URLParam(r, "id")
+	w, done := s.audited(w, r, &models.AuditEntry{Action: models.AuditDocumentOpen, DocumentID: id})
+	defer done()
+	doc, err := s.storage.GetDocument(r.Context(), id)
+	if err != nil {
+		s.respondError(w, http.StatusNotFound, "document not found")
+		return
+	}
+	path, status, msg := s.sourceFile(doc)
+	if status != http.StatusOK {
+		s.respondError(w, status, msg)
+		return
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		s.respondError(w, http.StatusNotFound, "source file not found")
+		return
+	}
+	if err := s.openFile(path); err != nil {
+		s.logger.Error("open source file failed", zap.String("path", path), zap.Error(err))
+		s.respondError(w, http.StatusInternalServerError, "failed to open file: "+err.Error())
+		return
+	}
+	s.respondJSON(w, http.StatusOK, map[string]string{"status": "opened", "path": path})
+}
+
+// sourceFile returns the source_path of doc when it lies in a watched directory, or the
+// status and message to respond with. API clients cannot set source_path (see
+// checkMetadata), and the watched directories keep documents indexed before that, or
+// from directories no longer watched, from naming arbitrary files.
+func (s *Server) sourceFile(doc *models.Document) (path string, status int, msg string) {
+	path, _ = doc.Metadata["source_path"].(string)
+	if path == "" {
+		return "", http.StatusNotFound, "document has no source file"
+	}
+	for _, dir := range s.watchedDirectories() {
+		if pathUnder(filepath.Clean(path), dir) {
+			return path, http.StatusOK, ""
+		}
+	}
+	return "", http.StatusForbidden, "source file is not in a watched directory"
+}
+
+// watchedDirectories returns the absolute paths of the watched directories: the
+// watcher's, or those of the config without one.
+func (s *Server) watchedDirectories() []string {
+	var dirs []string
+	if s.watch != nil {
+		dirs = s.watch.Directories()
+	} else if cfg := s.fullConfig(); cfg != nil {
+		dirs = cfg.Watch.Directories
+	}
+	abs := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if d, err := filepath.Abs(dir); err == nil {
+			abs = append(abs, d)
+		}
+	}
+	return abs
+}
+
+// pathUnder reports whether path is root or lies below it.
+func pathUnder(path, root string) bool {
+	rel, err := filepath.Rel(root, path)
+	return err == nil && filepath.IsAbs(path) && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// localRequest reports whether r comes over the loopback interface and, when the browser
+// sent an Origin, from a page served by the same host, so other sites cannot trigger it.
+func localRequest(r *http.Request) bool {
+	ip := net.ParseIP(clientAddr(r))
+	if ip == nil || !ip.IsLoopback() {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
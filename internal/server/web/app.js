@@ -0,0 +1,276 @@
+// Sagasu web UI: search and status views over the HTTP API.
+(function () {
+  "use strict";
+
+  var $ = function (id) { return document.getElementById(id); };
+
+  function showError(msg) {
+    var el = $("error");
+    el.textContent = msg || "";
+    el.hidden = !msg;
+  }
+
+  // The API key, when the server requires one, is kept in local storage.
+  var keyStorage = "sagasu.apiKey";
+
+  function authHeaders() {
+    var key = localStorage.getItem(keyStorage);
+    return key ? { "Authorization": "Bearer " + key } : {};
+  }
+
+  // authFetch fetches path with the stored API key. When the server refuses the key (or
+  // its absence), it asks for one and retries once.
+  function authFetch(path, opts, retried) {
+    opts = opts || {};
+    opts.headers = Object.assign({}, opts.headers, authHeaders());
+    return fetch(path, opts).then(function (res) {
+      if (res.status !== 401 || retried) return res;
+      var key = window.prompt("This server requires an API key:");
+      if (!key) return res;
+      localStorage.setItem(keyStorage, key.trim());
+      return authFetch(path, opts, true);
+    });
+  }
+
+  function api(method, path, body) {
+    var opts = { method: method, headers: {} };
+    if (body !== undefined) {
+      opts.headers["Content-Type"] = "application/json";
+      opts.body = JSON.stringify(body);
+    }
+    return authFetch(path, opts).then(function (res) {
+      return res.json().catch(function () { return {}; }).then(function (data) {
+        if (!res.ok) throw new Error(data.error || res.status + " " + res.statusText);
+        return data;
+      });
+    });
+  }
+
+  // openWithKey opens a link that needs the API key: links cannot send headers, so the
+  // file is fetched and opened from memory.
+  function openWithKey(ev) {
+    if (!localStorage.getItem(keyStorage)) return;
+    ev.preventDefault();
+    var win = window.open("", "_blank");
+    authFetch(ev.currentTarget.href).then(function (res) {
+      if (!res.ok) throw new Error(res.status + " " + res.statusText);
+      return res.blob();
+    }).then(function (blob) {
+      win.location = URL.createObjectURL(blob);
+    }).catch(function (err) {
+      win.close();
+      showError(err.message);
+    });
+  }
+
+  // openLocally is set when the server opens files on this machine (server.open_files) and
+  // the UI is served from loopback, so result links open in the desktop application.
+  var openLocally = false;
+
+  function detectOpenLocally() {
+    if (["localhost", "127.0.0.1", "[::1]"].indexOf(location.hostname) < 0) return;
+    api("GET", "/api/v1/status").then(function (st) {
+      openLocally = !!(st.config && st.config.open_files);
+    }).catch(function () {});
+  }
+
+  // openOnServer asks the server to open the document's file in its default application.
+  function openOnServer(ev) {
+    ev.preventDefault();
+    api("POST", "/api/v1/documents/" + encodeURIComponent(ev.currentTarget.dataset.id) + "/open")
+      .catch(function (err) { showError(err.message); });
+  }
+
+  // queryTerms returns the lowercase words of q worth highlighting, dropping operators
+  // and field scopes such as ext:pdf.
+  function queryTerms(q) {
+    return q.toLowerCase().split(/[\s"()]+/).filter(function (t) {
+      return t && t.indexOf(":") < 0 && t[0] !== "-" && ["and", "or", "not"].indexOf(t) < 0;
+    });
+  }
+
+  // snippet returns about 240 characters of content around the first query term,
+  // or the start of content when no term appears.
+  function snippet(content, terms) {
+    content = (content || "").replace(/\s+/g, " ");
+    var lower = content.toLowerCase(), at = -1;
+    for (var i = 0; i < terms.length && at < 0; i++) at = lower.indexOf(terms[i]);
+    var start = Math.max(0, at - 80), end = Math.min(content.length, start + 240);
+    return (start > 0 ? "… " : "") + content.slice(start, end) + (end < content.length ? " …" : "");
+  }
+
+  // highlight appends text to el with every query term wrapped in <mark>.
+  function highlight(el, text, terms) {
+    if (!terms.length) { el.textContent = text; return; }
+    var escaped = terms.map(function (t) { return t.replace(/[.*+?^${}()|[\]\\]/g, "\\$&"); });
+    var parts = text.split(new RegExp("(" + escaped.join("|") + ")", "gi"));
+    parts.forEach(function (part, i) {
+      if (i % 2) {
+        var m = document.createElement("mark");
+        m.textContent = part;
+        el.appendChild(m);
+      } else if (part) {
+        el.appendChild(document.createTextNode(part));
+      }
+    });
+  }
+
+  // sendOpen reports that the result at rank (1-based) of search queryID for query was
+  // opened. Analytics are best effort, so failures are ignored.
+  function sendOpen(query, queryID, documentID, rank) {
+    authFetch("/api/v1/feedback/open", {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({ query: query, query_id: queryID, document_id: documentID, rank: rank })
+    }).catch(function () {});
+  }
+
+  // renderResult renders one result; onOpen, when set, is called when its link is followed.
+  function renderResult(result, source, terms, onOpen) {
+    var doc = result.document || {}, meta = doc.metadata || {};
+    var li = document.createElement("li");
+
+    var title = document.createElement("a");
+    title.className = "title";
+    title.textContent = doc.title || doc.id;
+    title.href = meta.source_path
+      ? "/api/v1/documents/" + encodeURIComponent(doc.id) + "/file"
+      : "/api/v1/documents/" + encodeURIComponent(doc.id);
+    title.target = "_blank";
+    title.rel = "noopener";
+    title.dataset.id = doc.id;
+    title.addEventListener("click", openLocally && meta.source_path ? openOnServer : openWithKey);
+    if (onOpen) title.addEventListener("click", onOpen);
+    li.appendChild(title);
+
+    var tag = document.createElement("span");
+    tag.className = "source";
+    tag.textContent = source + " · " + result.score.toFixed(3) + (result.pinned ? " · pinned" : "") +
+      (meta.locked ? " · encrypted, name only" : "");
+    li.appendChild(tag);
+
+    if (meta.source_path) {
+      var path = document.createElement("div");
+      path.className = "path";
+      path.textContent = meta.source_path;
+      li.appendChild(path);
+    }
+    (result.also_found_at || []).forEach(function (copy) {
+      var also = document.createElement("div");
+      also.className = "path also";
+      also.textContent = "also at " + copy;
+      li.appendChild(also);
+    });
+
+    var p = document.createElement("p");
+    p.className = "snippet";
+    highlight(p, snippet(doc.content, terms), terms);
+    li.appendChild(p);
+    return li;
+  }
+
+  // showSuggestions offers the corrected query as a link that searches for it.
+  function showSuggestions(resp) {
+    var el = $("suggestions");
+    el.textContent = "";
+    if (!resp.corrected_query) return;
+    var link = document.createElement("a");
+    link.href = "#";
+    link.textContent = resp.corrected_query;
+    link.addEventListener("click", function (ev) {
+      ev.preventDefault();
+      $("q").value = resp.corrected_query;
+      search();
+    });
+    el.appendChild(document.createTextNode("Did you mean "));
+    el.appendChild(link);
+    el.appendChild(document.createTextNode("?"));
+  }
+
+  function search(ev) {
+    if (ev) ev.preventDefault();
+    var q = $("q").value.trim();
+    if (!q) return;
+    if (!$("keyword").checked && !$("semantic").checked) {
+      showError("Enable keyword or semantic search.");
+      return;
+    }
+    showError("");
+    var body = {
+      query: q,
+      limit: 20,
+      keyword_enabled: $("keyword").checked,
+      semantic_enabled: $("semantic").checked,
+      fuzzy_enabled: $("fuzzy").checked
+    };
+    api("POST", "/api/v1/search", body).then(function (resp) {
+      var list = $("results"), terms = queryTerms(q);
+      list.textContent = "";
+      var add = function (source) {
+        return function (r) {
+          var rank = list.children.length + 1, id = (r.document || {}).id;
+          var onOpen = resp.query_id ? function () { sendOpen(q, resp.query_id, id, rank); } : null;
+          list.appendChild(renderResult(r, source, terms, onOpen));
+        };
+      };
+      (resp.non_semantic_results || []).forEach(add("keyword"));
+      (resp.semantic_results || []).forEach(add("semantic"));
+      var n = list.children.length;
+      $("summary").textContent = n + (n === 1 ? " result" : " results") + " in " + resp.query_time_ms + " ms" +
+        (resp.auto_fuzzy ? " (fuzzy matching was enabled automatically)" : "") +
+        (resp.timed_out && resp.timed_out.length ? " (partial: " + resp.timed_out.join(", ") + " timed out)" : "");
+      showSuggestions(resp);
+    }).catch(function (err) { showError(err.message); });
+  }
+
+  function formatBytes(n) {
+    var units = ["B", "KB", "MB", "GB", "TB"], i = 0;
+    while (n >= 1024 && i < units.length - 1) { n /= 1024; i++; }
+    return (i ? n.toFixed(1) : n) + " " + units[i];
+  }
+
+  function loadStatus() {
+    showError("");
+    api("GET", "/api/v1/status").then(function (st) {
+      var rows = [
+        ["Documents", st.documents],
+        ["Chunks", st.chunks],
+        ["Vectors", st.vector_index_size]
+      ];
+      if (st.disk_usage_bytes !== undefined) rows.push(["Disk usage", formatBytes(st.disk_usage_bytes)]);
+      if (st.paused !== undefined) rows.push(["Indexing", st.paused ? "paused" : "running"]);
+      if (st.jobs) {
+        Object.keys(st.jobs).forEach(function (k) { rows.push(["Jobs " + k, st.jobs[k]]); });
+      }
+      if (st.embed_queue) {
+        var eq = st.embed_queue;
+        rows.push(["Embedding", eq.depth + " / " + eq.capacity + " chunks" + (eq.waiting ? ", " + eq.waiting + " waiting" : "")]);
+      }
+      var cfg = st.config || {};
+      Object.keys(cfg).forEach(function (k) { rows.push([k.replace(/_/g, " "), String(cfg[k])]); });
+
+      var dl = $("status");
+      dl.textContent = "";
+      rows.forEach(function (row) {
+        var dt = document.createElement("dt"), dd = document.createElement("dd");
+        dt.textContent = row[0];
+        dd.textContent = row[1];
+        dl.appendChild(dt);
+        dl.appendChild(dd);
+      });
+    }).catch(function (err) { showError(err.message); });
+  }
+
+  function route() {
+    var view = location.hash === "#status" ? "status" : "search";
+    document.querySelectorAll(".view").forEach(function (el) { el.hidden = el.id !== "view-" + view; });
+    document.querySelectorAll("nav a").forEach(function (a) { a.classList.toggle("active", a.dataset.view === view); });
+    if (view === "status") loadStatus();
+  }
+
+  $("search-form").addEventListener("submit", search);
+  $("refresh").addEventListener("click", loadStatus);
+  window.addEventListener("hashchange", route);
+  detectOpenLocally();
+  route();
+})();
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestWebUI(t *testing.T) {
+	srv := &Server{logger: zap.NewNop()}
+
+	w := httptest.NewRecorder()
+	srv.handleWebUI(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("index: status %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(w.Body.String(), `src="/assets/app.js"`) {
+		t.Error("index does not load app.js")
+	}
+
+	for _, name := range []string{"app.js", "style.css"} {
+		w = httptest.NewRecorder()
+		srv.handleWebAsset(w, httptest.NewRequest(http.MethodGet, "/assets/"+name, nil))
+		if w.Code != http.StatusOK || w.Body.Len() == 0 {
+			t.Errorf("%s: status %d, %d bytes", name, w.Code, w.Body.Len())
+		}
+	}
+	w = httptest.NewRecorder()
+	srv.handleWebAsset(w, httptest.NewRequest(http.MethodGet, "/assets/missing.js", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("missing asset: status %d, want 404", w.Code)
+	}
+}
+
+func TestHandleDocumentFile(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	fullCfg := &config.Config{Watch: config.WatchConfig{Directories: []string{dir}}}
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", fullCfg)
+
+	ctx := context.Background()
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("meeting notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	if err := os.WriteFile(outside, []byte("not indexed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	docs := []*models.Document{
+		{ID: "file", Title: "notes", Content: "meeting notes", Metadata: map[string]interface{}{"source_path": notes}},
+		{ID: "api", Title: "api", Content: "posted via the API", Metadata: map[string]interface{}{}},
+		{ID: "gone", Title: "gone", Content: "deleted", Metadata: map[string]interface{}{"source_path": filepath.Join(dir, "gone.txt")}},
+		{ID: "outside", Title: "secret", Content: "x", Metadata: map[string]interface{}{"source_path": outside}},
+		{ID: "escape", Title: "escape", Content: "x", Metadata: map[string]interface{}{"source_path": dir + "/../" + filepath.Base(filepath.Dir(outside)) + "/secret.txt"}},
+	}
+	for _, d := range docs {
+		if err := store.CreateDocument(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	getFile := func(id string) *httptest.ResponseRecorder {
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", id)
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/documents/"+id+"/file", nil)
+		r = r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+		srv.handleDocumentFile(w, r)
+		return w
+	}
+	w := getFile("file")
+	if w.Code != http.StatusOK || w.Body.String() != "meeting notes" {
+		t.Fatalf("file: status %d, body %q", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("Content-Type = %q, want text/plain", ct)
+	}
+	for _, id := range []string{"api", "gone", "missing"} {
+		if w = getFile(id); w.Code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", id, w.Code)
+		}
+	}
+	for _, id := range []string{"outside", "escape"} {
+		if w = getFile(id); w.Code != http.StatusForbidden {
+			t.Errorf("%s: status %d, want 403", id, w.Code)
+		}
+	}
+
+	// Documents posted through the API cannot name a file to serve.
+	for _, body := range []string{
+		`{"id":"posted","content":"x","metadata":{"source_path":"` + notes + `"}}`,
+		`{"id":"posted","content":"x","metadata":{"parent_id":"file"}}`,
+	} {
+		w := httptest.NewRecorder()
+		srv.routes().ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/documents", strings.NewReader(body)))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, w.Code)
+		}
+	}
+	if _, err := store.GetDocument(ctx, "posted"); err == nil {
+		t.Error("document with reserved metadata was indexed")
+	}
+}
+
+func TestHandleOpenDocument(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	serverCfg := &config.ServerConfig{Port: 8080}
+	srv := NewServer(nil, nil, store, serverCfg, zap.NewNop(), &mockWatchService{dirs: []string{dir}}, "", nil)
+	var opened []string
+	srv.openFile = func(path string) error {
+		opened = append(opened, path)
+		return nil
+	}
+
+	ctx := context.Background()
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("meeting notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	docs := []*models.Document{
+		{ID: "file", Title: "notes", Content: "meeting notes", Metadata: map[string]interface{}{"source_path": notes}},
+		{ID: "api", Title: "api", Content: "posted via the API", Metadata: map[string]interface{}{}},
+		{ID: "dir", Title: "dir", Content: "a directory", Metadata: map[string]interface{}{"source_path": dir}},
+	}
+	for _, d := range docs {
+		if err := store.CreateDocument(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	open := func(id, remote, origin string) int {
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:8080/api/v1/documents/"+id+"/open", nil)
+		r.RemoteAddr = remote
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		w := httptest.NewRecorder()
+		srv.routes().ServeHTTP(w, r)
+		return w.Code
+	}
+	if code := open("file", "127.0.0.1:5000", ""); code != http.StatusForbidden {
+		t.Errorf("without open_files: status %d, want 403", code)
+	}
+
+	serverCfg.OpenFiles = true
+	if code := open("file", "192.168.1.20:5000", ""); code != http.StatusForbidden {
+		t.Errorf("remote client: status %d, want 403", code)
+	}
+	if code := open("file", "127.0.0.1:5000", "http://evil.example"); code != http.StatusForbidden {
+		t.Errorf("cross-origin request: status %d, want 403", code)
+	}
+	if len(opened) != 0 {
+		t.Fatalf("refused requests opened %v", opened)
+	}
+	if code := open("file", "127.0.0.1:5000", "http://localhost:8080"); code != http.StatusOK {
+		t.Errorf("same-origin request: status %d, want 200", code)
+	}
+	if code := open("file", "[::1]:5000", ""); code != http.StatusOK {
+		t.Errorf("IPv6 loopback: status %d, want 200", code)
+	}
+	if len(opened) != 2 || opened[0] != notes {
+		t.Errorf("opened %v, want %s twice", opened, notes)
+	}
+	for _, id := range []string{"api", "dir", "missing"} {
+		if code := open(id, "127.0.0.1:5000", ""); code != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", id, code)
+		}
+	}
+
+	srv.openFile = func(string) error { return errors.New("no desktop") }
+	if code := open("file", "127.0.0.1:5000", ""); code != http.StatusInternalServerError {
+		t.Errorf("failed open: status %d, want 500", code)
+	}
+}
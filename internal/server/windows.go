@@ -0,0 +1,31 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/hyperjump/sagasu/internal/watcher"
+)
+
+// IndexWindowService holds back background indexing outside the configured index windows
+// (optional; implemented by *watcher.Watcher).
+type IndexWindowService interface {
+	IndexWindowStatus() *watcher.IndexWindowStatus
+	IndexHeld() (files, directories int)
+}
+
+func (s *Server) indexWindowService() IndexWindowService {
+	iw, _ := s.watch.(IndexWindowService)
+	return iw
+}
+
+// handleIndexHeld indexes the changes the watcher held back outside the index windows now,
+// without waiting for the next window.
+func (s *Server) handleIndexHeld(w http.ResponseWriter, r *http.Request) {
+	iw := s.indexWindowService()
+	if iw == nil {
+		s.respondError(w, http.StatusNotImplemented, "watch not enabled")
+		return
+	}
+	files, dirs := iw.IndexHeld()
+	s.respondJSON(w, http.StatusOK, map[string]int{"files": files, "directories": dirs})
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/schedule"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"github.com/hyperjump/sagasu/internal/watcher"
+	"go.uber.org/zap"
+)
+
+func TestHandleIndexHeld(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+
+	noWatch := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil)
+	w := httptest.NewRecorder()
+	noWatch.handleIndexHeld(w, httptest.NewRequest(http.MethodPost, "/api/v1/watch/flush", nil))
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("without watcher: got %d, want 501", w.Code)
+	}
+
+	// A window that opens in two hours, so the sync below is held.
+	start := time.Now().Add(2 * time.Hour)
+	windows, err := schedule.Parse([]string{start.Format("15:04") + "-" + start.Add(time.Hour).Format("15:04")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := filepath.Join(dir, "docs")
+	watch := watcher.NewWatcher([]string{docs}, []string{".md"}, true, func(string) {}, nil, watcher.WithIndexWindows(windows))
+	defer watch.Stop()
+	watch.SyncExistingFiles()
+	srv := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), watch, "", nil)
+
+	w = httptest.NewRecorder()
+	srv.handleStatus(w, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	var status struct {
+		IndexWindows *watcher.IndexWindowStatus `json:"index_windows"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+	if st := status.IndexWindows; st == nil || st.Open || st.HeldDirectories != 1 {
+		t.Fatalf("status index_windows = %+v, want closed with the sync held", st)
+	}
+
+	w = httptest.NewRecorder()
+	srv.handleIndexHeld(w, httptest.NewRequest(http.MethodPost, "/api/v1/watch/flush", nil))
+	var out map[string]int
+	if err := json.NewDecoder(w.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || out["files"] != 0 || out["directories"] != 1 {
+		t.Errorf("flush: got %d %v, want 200 with one directory", w.Code, out)
+	}
+	if st := watch.IndexWindowStatus(); st.HeldDirectories != 0 {
+		t.Errorf("held directories after flush = %d, want 0", st.HeldDirectories)
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ContentTypeGob is the media type of gob-encoded bodies. Search and batch indexing accept
+// request bodies in it (Content-Type) and answer in it when asked (Accept), which saves
+// high-throughput clients written in Go the cost of JSON. Errors are always JSON.
+const ContentTypeGob = "application/x-gob"
+
+func init() {
+	// Values of Metadata and Filters maps, besides the basic types gob knows.
+	gob.Register([]interface{}{})
+	gob.Register(map[string]interface{}{})
+	gob.Register(time.Time{})
+}
+
+// isGob reports whether a Content-Type or Accept header value names ContentTypeGob.
+func isGob(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part)); err == nil && mediaType == ContentTypeGob {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeBody decodes the body of r into v: gob when its Content-Type is ContentTypeGob,
+// otherwise JSON.
+func decodeBody(r *http.Request, v interface{}) error {
+	if isGob(r.Header.Get("Content-Type")) {
+		return gob.NewDecoder(r.Body).Decode(v)
+	}
+	return json.NewDecoder(r.Body).Decode(v)
+}
+
+// respond writes data as gob when the Accept header of r asks for ContentTypeGob, and as
+// JSON otherwise (see respondJSON).
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, status int, data interface{}) {
+	if !isGob(r.Header.Get("Accept")) {
+		s.respondJSON(w, status, data)
+		return
+	}
+	w.Header().Set("Content-Type", ContentTypeGob)
+	w.WriteHeader(status)
+	_ = gob.NewEncoder(w).Encode(data)
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/config"
+	"github.com/hyperjump/sagasu/internal/embedding"
+	"github.com/hyperjump/sagasu/internal/indexer"
+	"github.com/hyperjump/sagasu/internal/keyword"
+	"github.com/hyperjump/sagasu/internal/models"
+	"github.com/hyperjump/sagasu/internal/search"
+	"github.com/hyperjump/sagasu/internal/storage"
+	"github.com/hyperjump/sagasu/internal/vector"
+	"go.uber.org/zap"
+)
+
+func TestGobWireFormat(t *testing.T) {
+	dir := t.TempDir()
+	store, _ := storage.NewSQLiteStorage(dir + "/db.sqlite")
+	defer store.Close()
+	embedder := embedding.NewMockEmbedder(4)
+	vecIdx, _ := vector.NewMemoryIndex(4)
+	kwIdx, _ := keyword.NewBleveIndex(dir + "/bleve")
+	defer kwIdx.Close()
+	cfg := &config.SearchConfig{ChunkSize: 10, ChunkOverlap: 2, TopKCandidates: 20}
+	engine := search.NewEngine(store, embedder, vecIdx, kwIdx, cfg)
+	idx := indexer.NewIndexer(store, embedder, vecIdx, kwIdx, cfg, nil)
+	handler := NewServer(engine, idx, store, &config.ServerConfig{Port: 8080}, zap.NewNop(), nil, "", nil).routes()
+
+	post := func(path string, v interface{}, accept string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if err := gob.NewEncoder(&body).Encode(v); err != nil {
+			t.Fatal(err)
+		}
+		r := httptest.NewRequest(http.MethodPost, path, &body)
+		r.Header.Set("Content-Type", ContentTypeGob)
+		r.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: status %d, body: %s", path, w.Code, w.Body.String())
+		}
+		return w
+	}
+
+	inputs := []*models.DocumentInput{
+		{ID: "g1", Title: "Gob", Content: "binary wire format", Metadata: map[string]interface{}{
+			"tags": []interface{}{"wire", "fast"}, "author": map[string]interface{}{"name": "A"},
+		}},
+	}
+	w := post("/api/v1/documents:batch", inputs, "application/json")
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("batch response Content-Type = %q, want JSON when gob is not accepted", ct)
+	}
+
+	w = post("/api/v1/search", &models.SearchQuery{Query: "wire", KeywordEnabled: true}, ContentTypeGob+", application/json;q=0.5")
+	if ct := w.Header().Get("Content-Type"); ct != ContentTypeGob {
+		t.Fatalf("search response Content-Type = %q, want %s", ct, ContentTypeGob)
+	}
+	var resp models.SearchResponse
+	if err := gob.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.NonSemanticResults) != 1 || resp.NonSemanticResults[0].Document.ID != "g1" {
+		t.Fatalf("results: %+v", resp.NonSemanticResults)
+	}
+	if tags, ok := resp.NonSemanticResults[0].Document.Metadata["tags"].([]interface{}); !ok || len(tags) != 2 {
+		t.Errorf("metadata tags: %#v", resp.NonSemanticResults[0].Document.Metadata["tags"])
+	}
+}
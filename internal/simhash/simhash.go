@@ -0,0 +1,68 @@
+// Package simhash computes 64-bit SimHash fingerprints of text. Near-identical texts get
+// fingerprints that differ in few bits, so copies of a document can be found by comparing
+// fingerprints instead of contents.
+package simhash
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// shingleSize is the number of consecutive words hashed together. Hashing word runs
+// rather than single words makes reordered text count as different.
+const shingleSize = 3
+
+// Compute returns the fingerprint of text, built from its lower-cased word shingles. It
+// returns false when text has no words.
+func Compute(text string) (uint64, bool) {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	if len(words) == 0 {
+		return 0, false
+	}
+	n := min(shingleSize, len(words))
+	var weights [64]int
+	for i := 0; i+n <= len(words); i++ {
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(words[i:i+n], " ")))
+		sum := h.Sum64()
+		for b := range weights {
+			if sum&(1<<b) != 0 {
+				weights[b]++
+			} else {
+				weights[b]--
+			}
+		}
+	}
+	var fp uint64
+	for b, w := range weights {
+		if w > 0 {
+			fp |= 1 << b
+		}
+	}
+	return fp, true
+}
+
+// Distance returns the number of bits in which fingerprints a and b differ.
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Format returns fp as 16 hexadecimal digits, the form stored in document metadata.
+func Format(fp uint64) string {
+	return fmt.Sprintf("%016x", fp)
+}
+
+// Parse parses a fingerprint written by Format.
+func Parse(s string) (uint64, bool) {
+	if len(s) != 16 {
+		return 0, false
+	}
+	fp, err := strconv.ParseUint(s, 16, 64)
+	return fp, err == nil
+}
@@ -0,0 +1,46 @@
+package simhash
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompute(t *testing.T) {
+	report := strings.Repeat("Quarterly revenue grew in every region while costs stayed flat. ", 20) +
+		"Headcount rose to 120 and the board approved the new office lease."
+	a, ok := Compute(report)
+	if !ok {
+		t.Fatal("no fingerprint")
+	}
+	if b, _ := Compute(strings.ToUpper(report)); b != a {
+		t.Errorf("case changed the fingerprint: %s vs %s", Format(a), Format(b))
+	}
+	edited, _ := Compute(strings.Replace(report, "120", "121", 1))
+	if d := Distance(a, edited); d > 3 {
+		t.Errorf("one-word edit: distance %d, want at most 3", d)
+	}
+	other, _ := Compute("Minutes of the design review: the team chose SQLite for storage and Bleve for keyword search.")
+	if d := Distance(a, other); d < 10 {
+		t.Errorf("unrelated text: distance %d, want at least 10", d)
+	}
+	if _, ok := Compute(" -- \n"); ok {
+		t.Error("text without words has a fingerprint")
+	}
+}
+
+func TestFormatParse(t *testing.T) {
+	for _, fp := range []uint64{0, 1, 0xdeadbeef, 1<<64 - 1} {
+		s := Format(fp)
+		if len(s) != 16 {
+			t.Errorf("Format(%x) = %q", fp, s)
+		}
+		if got, ok := Parse(s); !ok || got != fp {
+			t.Errorf("Parse(%q) = %x, %v", s, got, ok)
+		}
+	}
+	for _, s := range []string{"", "abc", "zzzzzzzzzzzzzzzz"} {
+		if _, ok := Parse(s); ok {
+			t.Errorf("Parse(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// minCompressSize is the content length below which compressing does not pay off.
+const minCompressSize = 128
+
+// zstdMagic starts every zstd frame. Text content cannot start with it (0xB5 is not a
+// valid first byte of a UTF-8 character), so compressed and plain rows tell themselves
+// apart and databases written before compression still read as they are.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+)
+
+// compressContent returns the value to store for document or chunk content: a zstd
+// BLOB, or s itself when it is short or does not shrink.
+func compressContent(s string) any {
+	if len(s) < minCompressSize {
+		return s
+	}
+	b := zstdEncoder.EncodeAll([]byte(s), make([]byte, 0, len(s)/2))
+	if len(b) >= len(s) {
+		return s
+	}
+	return b
+}
+
+// decompressContent returns the text of stored content, which is either plain text or a
+// BLOB written by compressContent.
+func decompressContent(s string) (string, error) {
+	if !bytes.HasPrefix([]byte(s), zstdMagic) {
+		return s, nil
+	}
+	b, err := zstdDecoder.DecodeAll([]byte(s), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decompress content: %w", err)
+	}
+	return string(b), nil
+}
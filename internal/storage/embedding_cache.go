@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// embeddingLookupBatch bounds the keys in one SELECT, below SQLite's variable limit.
+const embeddingLookupBatch = 500
+
+// EmbeddingCacheStore persists embeddings by key in their own SQLite database. It is kept
+// apart from the document database so that an index rebuild, which replaces that database,
+// can reuse the embeddings of unchanged chunks instead of running the model again.
+type EmbeddingCacheStore struct {
+	db *sql.DB
+}
+
+// NewEmbeddingCacheStore opens or creates the embedding cache database at dbPath.
+// Parent directories are created if they do not exist.
+func NewEmbeddingCacheStore(dbPath string) (*EmbeddingCacheStore, error) {
+	if dir := filepath.Dir(dbPath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create embedding cache directory: %w", err)
+		}
+	}
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open embedding cache: %w", err)
+	}
+	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to enable WAL: %w", err)
+	}
+	schema := `
+	CREATE TABLE IF NOT EXISTS embeddings (
+		key TEXT PRIMARY KEY,
+		embedding BLOB NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+	if _, err := db.Exec(schema); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize embedding cache schema: %w", err)
+	}
+	return &EmbeddingCacheStore{db: db}, nil
+}
+
+// GetEmbeddings returns the stored embeddings for keys. Keys without one are left out.
+func (s *EmbeddingCacheStore) GetEmbeddings(ctx context.Context, keys []string) (map[string][]float32, error) {
+	found := make(map[string][]float32, len(keys))
+	for start := 0; start < len(keys); start += embeddingLookupBatch {
+		batch := keys[start:min(start+embeddingLookupBatch, len(keys))]
+		args := make([]interface{}, len(batch))
+		for i, k := range batch {
+			args[i] = k
+		}
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		rows, err := s.db.QueryContext(ctx,
+			`SELECT key, embedding FROM embeddings WHERE key IN (`+placeholders+`)`, args...)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var key string
+			var blob []byte
+			if err := rows.Scan(&key, &blob); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			found[key] = decodeEmbedding(blob)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// PutEmbeddings stores embeddings by key in a transaction, replacing existing entries.
+func (s *EmbeddingCacheStore) PutEmbeddings(ctx context.Context, embeddings map[string][]float32) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR REPLACE INTO embeddings (key, embedding) VALUES (?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for key, v := range embeddings {
+		if _, err := stmt.ExecContext(ctx, key, encodeEmbedding(v)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// CountEmbeddings returns the number of stored embeddings.
+func (s *EmbeddingCacheStore) CountEmbeddings(ctx context.Context) (int64, error) {
+	var count int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM embeddings`).Scan(&count)
+	return count, err
+}
+
+// Close closes the database connection.
+func (s *EmbeddingCacheStore) Close() error {
+	return s.db.Close()
+}
+
+// encodeEmbedding packs v as little-endian float32 values.
+func encodeEmbedding(v []float32) []byte {
+	b := make([]byte, 4*len(v))
+	for i, f := range v {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+// decodeEmbedding unpacks a blob written by encodeEmbedding.
+func decodeEmbedding(b []byte) []float32 {
+	v := make([]float32, len(b)/4)
+	for i := range v {
+		v[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return v
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbeddingCacheStore_persists(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "cache", "embeddings.db")
+	store, err := NewEmbeddingCacheStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PutEmbeddings(ctx, map[string][]float32{"a": {0.5, -1.25}, "b": {1, 0}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.PutEmbeddings(ctx, map[string][]float32{"b": {0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	store, err = NewEmbeddingCacheStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	got, err := store.GetEmbeddings(ctx, []string{"a", "b", "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d embeddings, want 2", len(got))
+	}
+	if v := got["a"]; len(v) != 2 || v[0] != 0.5 || v[1] != -1.25 {
+		t.Errorf("a = %v", v)
+	}
+	if v := got["b"]; len(v) != 2 || v[1] != 1 {
+		t.Errorf("b should be replaced, got %v", v)
+	}
+	if n, _ := store.CountEmbeddings(ctx); n != 2 {
+		t.Errorf("count = %d, want 2", n)
+	}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrOrphanChunksUnsupported is returned by OrphanChunks and DeleteOrphanChunks when the
+// wrapped storage cannot look for orphaned chunks.
+var ErrOrphanChunksUnsupported = errors.New("orphaned chunk check is not supported by this storage")
+
+// OrphanChunker is implemented by storages that can find the chunks whose document no
+// longer exists, e.g. left behind by a crash or an older version deleting documents.
+type OrphanChunker interface {
+	// OrphanChunks returns the IDs of the chunks whose document is gone.
+	OrphanChunks(ctx context.Context) ([]string, error)
+	// DeleteOrphanChunks deletes them and returns how many it deleted.
+	DeleteOrphanChunks(ctx context.Context) (int64, error)
+}
+
+// OrphanChunks returns the IDs of the chunks whose document is gone.
+func (s *SQLiteStorage) OrphanChunks(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+	SELECT c.id FROM document_chunks c
+	WHERE NOT EXISTS (SELECT 1 FROM documents d WHERE d.id = c.document_id)
+	ORDER BY c.id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// DeleteOrphanChunks deletes the chunks whose document is gone.
+func (s *SQLiteStorage) DeleteOrphanChunks(ctx context.Context) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+	DELETE FROM document_chunks
+	WHERE NOT EXISTS (SELECT 1 FROM documents d WHERE d.id = document_chunks.document_id)`)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// OrphanChunks forwards to the current store, returning ErrOrphanChunksUnsupported when
+// it is not an OrphanChunker.
+func (w *SwappableStorage) OrphanChunks(ctx context.Context) ([]string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	o, ok := w.s.(OrphanChunker)
+	if !ok {
+		return nil, ErrOrphanChunksUnsupported
+	}
+	return o.OrphanChunks(ctx)
+}
+
+// DeleteOrphanChunks forwards to the current store, returning ErrOrphanChunksUnsupported
+// when it is not an OrphanChunker.
+func (w *SwappableStorage) DeleteOrphanChunks(ctx context.Context) (int64, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	o, ok := w.s.(OrphanChunker)
+	if !ok {
+		return 0, ErrOrphanChunksUnsupported
+	}
+	return o.DeleteOrphanChunks(ctx)
+}
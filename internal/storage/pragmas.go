@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Pragmas tunes the SQLite connections of a SQLiteStorage. They are passed in the
+// connection string, so every connection of the pool gets them. Zero values keep
+// SQLite's defaults, except JournalMode, which defaults to WAL.
+type Pragmas struct {
+	JournalMode string        // DELETE, TRUNCATE, PERSIST, MEMORY, WAL, or OFF
+	Synchronous string        // OFF, NORMAL, FULL, or EXTRA
+	CacheSizeKB int           // page cache of each connection
+	BusyTimeout time.Duration // how long a locked database is retried before failing
+}
+
+// SQLiteOption configures a SQLiteStorage.
+type SQLiteOption func(*sqliteOptions)
+
+type sqliteOptions struct {
+	pragmas  Pragmas
+	trigrams bool
+	versions bool
+}
+
+// WithPragmas sets the pragmas of every connection.
+func WithPragmas(p Pragmas) SQLiteOption {
+	return func(o *sqliteOptions) { o.pragmas = p }
+}
+
+var journalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+
+var synchronousLevels = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+
+// journalMode returns the journal mode to set, upper-cased.
+func (p Pragmas) journalMode() (string, error) {
+	mode := strings.ToUpper(p.JournalMode)
+	if mode == "" {
+		return "WAL", nil
+	}
+	if slices.Contains(journalModes, mode) {
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown journal mode %q (use one of %s)", p.JournalMode, strings.Join(journalModes, ", "))
+}
+
+// dsn returns the connection string opening dbPath with the pragmas that apply per
+// connection. The journal mode is set once after opening, since it is stored in the
+// database.
+func (p Pragmas) dsn(dbPath string) (string, error) {
+	params := url.Values{}
+	if p.Synchronous != "" {
+		level := strings.ToUpper(p.Synchronous)
+		if !slices.Contains(synchronousLevels, level) {
+			return "", fmt.Errorf("unknown synchronous level %q (use one of %s)", p.Synchronous, strings.Join(synchronousLevels, ", "))
+		}
+		params.Set("_synchronous", level)
+	}
+	if p.CacheSizeKB > 0 {
+		// A negative cache_size is in KiB rather than pages.
+		params.Set("_cache_size", strconv.Itoa(-p.CacheSizeKB))
+	}
+	if p.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(p.BusyTimeout.Milliseconds(), 10))
+	}
+	if len(params) == 0 {
+		return dbPath, nil
+	}
+	return dbPath + "?" + params.Encode(), nil
+}
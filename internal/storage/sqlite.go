@@ -8,34 +8,58 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 
+	"github.com/google/uuid"
 	"github.com/hyperjump/sagasu/internal/models"
 )
 
 // SQLiteStorage implements Storage using SQLite.
 type SQLiteStorage struct {
 	db *sql.DB
+
+	// Inserts prepared once and reused by single and batched writes.
+	insertDocument *sql.Stmt
+	insertChunk    *sql.Stmt
+
+	trigrams bool // maintain the trigram index (see WithTrigramIndex)
+	versions bool // keep replaced and deleted documents (see WithVersionHistory)
 }
 
 // NewSQLiteStorage opens or creates a SQLite database at dbPath and initializes the schema.
-// Parent directories are created if they do not exist.
-func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
+// Parent directories are created if they do not exist. The database uses WAL mode unless
+// WithPragmas sets another journal mode.
+func NewSQLiteStorage(dbPath string, opts ...SQLiteOption) (*SQLiteStorage, error) {
+	var o sqliteOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	journalMode, err := o.pragmas.journalMode()
+	if err != nil {
+		return nil, err
+	}
+	dsn, err := o.pragmas.dsn(dbPath)
+	if err != nil {
+		return nil, err
+	}
 	if dir := filepath.Dir(dbPath); dir != "." {
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return nil, fmt.Errorf("failed to create database directory: %w", err)
 		}
 	}
-	db, err := sql.Open("sqlite3", dbPath)
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	if _, err := db.Exec("PRAGMA journal_mode=WAL"); err != nil {
+	if _, err := db.Exec("PRAGMA journal_mode=" + journalMode); err != nil {
 		_ = db.Close()
-		return nil, fmt.Errorf("failed to enable WAL: %w", err)
+		return nil, fmt.Errorf("failed to set journal mode %s: %w", journalMode, err)
 	}
 
 	if err := initSchema(db); err != nil {
@@ -43,7 +67,34 @@ func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
 		return nil, fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
-	return &SQLiteStorage{db: db}, nil
+	if err := initTrigrams(context.Background(), db, o.trigrams); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to initialize trigram index: %w", err)
+	}
+
+	s := &SQLiteStorage{db: db, trigrams: o.trigrams, versions: o.versions}
+	if err := s.prepare(); err != nil {
+		_ = s.Close()
+		return nil, fmt.Errorf("failed to prepare statements: %w", err)
+	}
+	return s, nil
+}
+
+// prepare prepares the statements kept for the lifetime of the storage.
+func (s *SQLiteStorage) prepare() error {
+	var err error
+	s.insertDocument, err = s.db.Prepare(
+		`INSERT INTO documents (id, title, content, metadata, created_at, updated_at, modified_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?)`,
+	)
+	if err != nil {
+		return err
+	}
+	s.insertChunk, err = s.db.Prepare(
+		`INSERT INTO document_chunks (id, document_id, content, chunk_index, created_at)
+		 VALUES (?, ?, ?, ?, ?)`,
+	)
+	return err
 }
 
 func initSchema(db *sql.DB) error {
@@ -54,7 +105,8 @@ func initSchema(db *sql.DB) error {
 		content TEXT NOT NULL,
 		metadata TEXT,
 		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		modified_at INTEGER NOT NULL DEFAULT 0
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_documents_created_at ON documents(created_at);
@@ -70,11 +122,183 @@ func initSchema(db *sql.DB) error {
 
 	CREATE INDEX IF NOT EXISTS idx_chunks_document_id ON document_chunks(document_id);
 	CREATE INDEX IF NOT EXISTS idx_chunks_document_chunk ON document_chunks(document_id, chunk_index);
+
+	CREATE TABLE IF NOT EXISTS pins (
+		id TEXT PRIMARY KEY,
+		query TEXT NOT NULL,
+		document_id TEXT NOT NULL DEFAULT '',
+		path TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS snapshots (
+		name TEXT PRIMARY KEY,
+		created_at TIMESTAMP NOT NULL,
+		documents INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE TABLE IF NOT EXISTS snapshot_documents (
+		snapshot TEXT NOT NULL,
+		document_id TEXT NOT NULL,
+		content_hash TEXT NOT NULL,
+		PRIMARY KEY (snapshot, document_id)
+	);
+
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time TIMESTAMP NOT NULL,
+		client TEXT NOT NULL DEFAULT '',
+		api_key TEXT NOT NULL DEFAULT '',
+		action TEXT NOT NULL,
+		query TEXT NOT NULL DEFAULT '',
+		document_id TEXT NOT NULL DEFAULT '',
+		results INTEGER NOT NULL DEFAULT 0,
+		status INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_log_time ON audit_log(time);
+
+	CREATE TABLE IF NOT EXISTS search_analytics (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time TIMESTAMP NOT NULL,
+		query TEXT NOT NULL,
+		keyword_results INTEGER NOT NULL DEFAULT 0,
+		semantic_results INTEGER NOT NULL DEFAULT 0,
+		latency_ms INTEGER NOT NULL DEFAULT 0,
+		clicked_document_id TEXT NOT NULL DEFAULT '',
+		clicked_rank INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_search_analytics_time ON search_analytics(time);
+
+	CREATE TABLE IF NOT EXISTS search_results (
+		search_id INTEGER NOT NULL,
+		list TEXT NOT NULL,
+		rank INTEGER NOT NULL,
+		document_id TEXT NOT NULL,
+		PRIMARY KEY (search_id, list, rank)
+	);
+
+	CREATE TABLE IF NOT EXISTS result_opens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		time TIMESTAMP NOT NULL,
+		query TEXT NOT NULL,
+		document_id TEXT NOT NULL,
+		rank INTEGER NOT NULL DEFAULT 0,
+		query_id INTEGER NOT NULL DEFAULT 0
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_result_opens_time ON result_opens(time);
+	CREATE INDEX IF NOT EXISTS idx_result_opens_document ON result_opens(document_id);
+
+	CREATE TABLE IF NOT EXISTS document_changes (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		document_id TEXT NOT NULL,
+		op TEXT NOT NULL,
+		time TIMESTAMP NOT NULL DEFAULT (strftime('%Y-%m-%d %H:%M:%f', 'now'))
+	);
+
+	CREATE TRIGGER IF NOT EXISTS documents_insert_change AFTER INSERT ON documents BEGIN
+		INSERT INTO document_changes (document_id, op) VALUES (NEW.id, 'insert');
+	END;
+	CREATE TRIGGER IF NOT EXISTS documents_update_change AFTER UPDATE ON documents BEGIN
+		INSERT INTO document_changes (document_id, op) VALUES (NEW.id, 'update');
+	END;
+	CREATE TRIGGER IF NOT EXISTS documents_delete_change AFTER DELETE ON documents BEGIN
+		INSERT INTO document_changes (document_id, op) VALUES (OLD.id, 'delete');
+	END;
+
+	CREATE TABLE IF NOT EXISTS document_versions (
+		document_id TEXT NOT NULL,
+		title TEXT,
+		content TEXT NOT NULL,
+		metadata TEXT,
+		created_at TIMESTAMP,
+		updated_at TIMESTAMP,
+		replaced_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_document_versions_replaced_at ON document_versions(replaced_at);
+
+	CREATE TABLE IF NOT EXISTS meta (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
 	`
-	_, err := db.Exec(schema)
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+	if err := initModifiedAt(db); err != nil {
+		return err
+	}
+	// The change log ID tells cursors of this database from those of a rebuilt one.
+	_, err := db.Exec(`INSERT OR IGNORE INTO meta (key, value) VALUES ('change_log_id', ?)`, uuid.New().String())
+	return err
+}
+
+// initModifiedAt adds the modified_at column to databases created before it, filling it
+// from each document's metadata, and indexes it for ListRecentDocuments.
+func initModifiedAt(db *sql.DB) error {
+	var exists int
+	if err := db.QueryRow(
+		`SELECT count(*) FROM pragma_table_info('documents') WHERE name = 'modified_at'`,
+	).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if _, err := tx.Exec(`ALTER TABLE documents ADD COLUMN modified_at INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return err
+		}
+		rows, err := tx.Query(`SELECT id, metadata, updated_at FROM documents`)
+		if err != nil {
+			return err
+		}
+		modified := make(map[string]int64)
+		for rows.Next() {
+			var id string
+			var metadataJSON sql.NullString
+			var updatedAt time.Time
+			if err := rows.Scan(&id, &metadataJSON, &updatedAt); err != nil {
+				rows.Close()
+				return err
+			}
+			var metadata map[string]interface{}
+			_ = json.Unmarshal([]byte(metadataJSON.String), &metadata)
+			modified[id] = modifiedAt(metadata, updatedAt).UnixNano()
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for id, ns := range modified {
+			if _, err := tx.Exec(`UPDATE documents SET modified_at = ? WHERE id = ?`, ns, id); err != nil {
+				return err
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_documents_modified_at ON documents(modified_at)`)
 	return err
 }
 
+// modifiedAt returns a document's modification time: the source file mtime from
+// metadata, or updatedAt for documents without a source file.
+func modifiedAt(metadata map[string]interface{}, updatedAt time.Time) time.Time {
+	if mtime, ok := metadata["source_mtime"].(string); ok {
+		if ns, err := strconv.ParseInt(mtime, 10, 64); err == nil {
+			return time.Unix(0, ns)
+		}
+	}
+	return updatedAt
+}
+
 // CreateDocument inserts a document.
 func (s *SQLiteStorage) CreateDocument(ctx context.Context, doc *models.Document) error {
 	metadataJSON, err := json.Marshal(doc.Metadata)
@@ -86,12 +310,28 @@ func (s *SQLiteStorage) CreateDocument(ctx context.Context, doc *models.Document
 	doc.CreatedAt = now
 	doc.UpdatedAt = now
 
-	_, err = s.db.ExecContext(ctx,
-		`INSERT INTO documents (id, title, content, metadata, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?)`,
-		doc.ID, doc.Title, doc.Content, string(metadataJSON), doc.CreatedAt, doc.UpdatedAt,
-	)
-	return err
+	if !s.trigrams {
+		_, err = s.insertDocument.ExecContext(ctx,
+			doc.ID, doc.Title, compressContent(doc.Content), string(metadataJSON), doc.CreatedAt, doc.UpdatedAt,
+			modifiedAt(doc.Metadata, doc.UpdatedAt).UnixNano(),
+		)
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.StmtContext(ctx, s.insertDocument).ExecContext(ctx,
+		doc.ID, doc.Title, compressContent(doc.Content), string(metadataJSON), doc.CreatedAt, doc.UpdatedAt,
+		modifiedAt(doc.Metadata, doc.UpdatedAt).UnixNano(),
+	); err != nil {
+		return err
+	}
+	if err := insertTrigrams(ctx, tx, doc.ID, doc.Title+"\n"+doc.Content); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // GetDocument returns a document by ID.
@@ -111,6 +351,9 @@ func (s *SQLiteStorage) GetDocument(ctx context.Context, id string) (*models.Doc
 		return nil, err
 	}
 
+	if doc.Content, err = decompressContent(doc.Content); err != nil {
+		return nil, err
+	}
 	if metadataJSON != "" {
 		if err := json.Unmarshal([]byte(metadataJSON), &doc.Metadata); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal metadata: %w", err)
@@ -129,10 +372,22 @@ func (s *SQLiteStorage) UpdateDocument(ctx context.Context, doc *models.Document
 
 	doc.UpdatedAt = time.Now()
 
-	result, err := s.db.ExecContext(ctx,
-		`UPDATE documents SET title = ?, content = ?, metadata = ?, updated_at = ?
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if s.versions {
+		// The replaced version ends when this one starts, on the same clock.
+		if err := archiveVersions(ctx, tx, doc.UpdatedAt, `WHERE id = ?`, doc.ID); err != nil {
+			return err
+		}
+	}
+	result, err := tx.ExecContext(ctx,
+		`UPDATE documents SET title = ?, content = ?, metadata = ?, updated_at = ?, modified_at = ?
 		 WHERE id = ?`,
-		doc.Title, doc.Content, string(metadataJSON), doc.UpdatedAt, doc.ID,
+		doc.Title, compressContent(doc.Content), string(metadataJSON), doc.UpdatedAt,
+		modifiedAt(doc.Metadata, doc.UpdatedAt).UnixNano(), doc.ID,
 	)
 	if err != nil {
 		return err
@@ -141,13 +396,32 @@ func (s *SQLiteStorage) UpdateDocument(ctx context.Context, doc *models.Document
 	if n == 0 {
 		return fmt.Errorf("document not found: %s", doc.ID)
 	}
-	return nil
+	if s.trigrams {
+		if err := reindexTrigrams(ctx, tx, doc.ID, doc.Title, doc.Content); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // DeleteDocument removes a document by ID.
 func (s *SQLiteStorage) DeleteDocument(ctx context.Context, id string) error {
-	_, err := s.db.ExecContext(ctx, `DELETE FROM documents WHERE id = ?`, id)
-	return err
+	if !s.versions {
+		_, err := s.db.ExecContext(ctx, `DELETE FROM documents WHERE id = ?`, id)
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := archiveVersions(ctx, tx, time.Now(), `WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM documents WHERE id = ?`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 // ListDocuments returns documents with offset and limit.
@@ -169,6 +443,9 @@ func (s *SQLiteStorage) ListDocuments(ctx context.Context, offset, limit int) ([
 		if err := rows.Scan(&doc.ID, &doc.Title, &doc.Content, &metadataJSON, &doc.CreatedAt, &doc.UpdatedAt); err != nil {
 			return nil, err
 		}
+		if doc.Content, err = decompressContent(doc.Content); err != nil {
+			return nil, err
+		}
 		if metadataJSON != "" {
 			_ = json.Unmarshal([]byte(metadataJSON), &doc.Metadata)
 		}
@@ -177,13 +454,101 @@ func (s *SQLiteStorage) ListDocuments(ctx context.Context, offset, limit int) ([
 	return docs, rows.Err()
 }
 
+// ListRecentDocuments returns documents modified at or after since, newest first, up to
+// limit (0 means no limit), and the number of such documents. The modification time is
+// the source file mtime from metadata, or updated_at for documents without a source file,
+// kept in the indexed modified_at column.
+func (s *SQLiteStorage) ListRecentDocuments(ctx context.Context, since time.Time, pathPrefix string, limit int) ([]*models.RecentDocument, int, error) {
+	const where = `WHERE modified_at >= ?
+		 AND (? = '' OR substr(coalesce(json_extract(metadata, '$.source_path'), ''), 1, length(?)) = ?)`
+	args := []interface{}{since.UnixNano(), pathPrefix, pathPrefix, pathPrefix}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT count(*) FROM documents `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, title, coalesce(json_extract(metadata, '$.source_path'), ''), updated_at, modified_at
+		 FROM documents `+where+` ORDER BY modified_at DESC, id LIMIT ?`,
+		append(args, limit)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var docs []*models.RecentDocument
+	for rows.Next() {
+		var doc models.RecentDocument
+		var modified int64
+		if err := rows.Scan(&doc.ID, &doc.Title, &doc.Path, &doc.IndexedAt, &modified); err != nil {
+			return nil, 0, err
+		}
+		doc.ModifiedAt = time.Unix(0, modified)
+		docs = append(docs, &doc)
+	}
+	return docs, total, rows.Err()
+}
+
+// ListDocumentSummaries returns the page [offset, offset+limit) of the documents matching
+// filter, newest first, and the number of matching documents. Filters apply to the source
+// path in metadata, so documents are matched in Go like ListRecentDocuments does.
+func (s *SQLiteStorage) ListDocumentSummaries(ctx context.Context, filter models.DocumentListFilter, offset, limit int) ([]*models.DocumentSummary, int, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, title, metadata, created_at, updated_at
+		 FROM documents ORDER BY created_at DESC, id`,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var docs []*models.DocumentSummary
+	total := 0
+	for rows.Next() {
+		var doc models.DocumentSummary
+		var metadataJSON string
+		if err := rows.Scan(&doc.ID, &doc.Title, &metadataJSON, &doc.CreatedAt, &doc.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		if metadataJSON != "" {
+			_ = json.Unmarshal([]byte(metadataJSON), &doc.Metadata)
+		}
+		doc.Path, _ = doc.Metadata["source_path"].(string)
+		if !summaryMatches(&doc, filter) {
+			continue
+		}
+		if total >= offset && len(docs) < limit {
+			docs = append(docs, &doc)
+		}
+		total++
+	}
+	return docs, total, rows.Err()
+}
+
+// summaryMatches reports whether doc passes the path prefix and extension filters.
+func summaryMatches(doc *models.DocumentSummary, filter models.DocumentListFilter) bool {
+	if filter.PathPrefix != "" && !strings.HasPrefix(doc.Path, filter.PathPrefix) {
+		return false
+	}
+	if len(filter.Extensions) == 0 {
+		return true
+	}
+	if doc.Path == "" {
+		return false
+	}
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(doc.Path), "."))
+	return slices.Contains(filter.Extensions, ext)
+}
+
 // CreateChunk inserts a single chunk.
 func (s *SQLiteStorage) CreateChunk(ctx context.Context, chunk *models.DocumentChunk) error {
 	chunk.CreatedAt = time.Now()
-	_, err := s.db.ExecContext(ctx,
-		`INSERT INTO document_chunks (id, document_id, content, chunk_index, created_at)
-		 VALUES (?, ?, ?, ?, ?)`,
-		chunk.ID, chunk.DocumentID, chunk.Content, chunk.ChunkIndex, chunk.CreatedAt,
+	_, err := s.insertChunk.ExecContext(ctx,
+		chunk.ID, chunk.DocumentID, compressContent(chunk.Content), chunk.ChunkIndex, chunk.CreatedAt,
 	)
 	return err
 }
@@ -202,6 +567,9 @@ func (s *SQLiteStorage) GetChunk(ctx context.Context, id string) (*models.Docume
 	if err != nil {
 		return nil, err
 	}
+	if chunk.Content, err = decompressContent(chunk.Content); err != nil {
+		return nil, err
+	}
 	return &chunk, nil
 }
 
@@ -223,6 +591,9 @@ func (s *SQLiteStorage) GetChunksByDocumentID(ctx context.Context, docID string)
 		if err := rows.Scan(&chunk.ID, &chunk.DocumentID, &chunk.Content, &chunk.ChunkIndex, &chunk.CreatedAt); err != nil {
 			return nil, err
 		}
+		if chunk.Content, err = decompressContent(chunk.Content); err != nil {
+			return nil, err
+		}
 		chunks = append(chunks, &chunk)
 	}
 	return chunks, rows.Err()
@@ -234,33 +605,93 @@ func (s *SQLiteStorage) DeleteChunksByDocumentID(ctx context.Context, docID stri
 	return err
 }
 
-// BatchCreateChunks inserts multiple chunks in a transaction.
+// BatchCreateChunks inserts multiple chunks in one transaction, reusing the prepared
+// insert.
 func (s *SQLiteStorage) BatchCreateChunks(ctx context.Context, chunks []*models.DocumentChunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx,
-		`INSERT INTO document_chunks (id, document_id, content, chunk_index, created_at)
-		 VALUES (?, ?, ?, ?, ?)`,
-	)
-	if err != nil {
-		return err
-	}
+	stmt := tx.StmtContext(ctx, s.insertChunk)
 	defer stmt.Close()
 
 	now := time.Now()
 	for _, chunk := range chunks {
 		chunk.CreatedAt = now
-		if _, err := stmt.ExecContext(ctx, chunk.ID, chunk.DocumentID, chunk.Content, chunk.ChunkIndex, chunk.CreatedAt); err != nil {
+		if _, err := stmt.ExecContext(ctx, chunk.ID, chunk.DocumentID, compressContent(chunk.Content), chunk.ChunkIndex, chunk.CreatedAt); err != nil {
 			return err
 		}
 	}
 	return tx.Commit()
 }
 
+// BatchCreateDocuments stores each document with its chunks under a savepoint of one
+// transaction, so a failing document is rolled back on its own.
+func (s *SQLiteStorage) BatchCreateDocuments(ctx context.Context, docs []*models.Document, chunks [][]*models.DocumentChunk) ([]error, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	docStmt := tx.StmtContext(ctx, s.insertDocument)
+	defer docStmt.Close()
+	chunkStmt := tx.StmtContext(ctx, s.insertChunk)
+	defer chunkStmt.Close()
+
+	now := time.Now()
+	errs := make([]error, len(docs))
+	for i, doc := range docs {
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT batch_document`); err != nil {
+			return nil, err
+		}
+		errs[i] = createDocumentTx(ctx, docStmt, chunkStmt, doc, chunks[i], now)
+		if errs[i] == nil && s.trigrams {
+			errs[i] = insertTrigrams(ctx, tx, doc.ID, doc.Title+"\n"+doc.Content)
+		}
+		if errs[i] != nil {
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO batch_document`); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := tx.ExecContext(ctx, `RELEASE batch_document`); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return errs, nil
+}
+
+// createDocumentTx inserts doc and its chunks with the prepared statements of a transaction.
+func createDocumentTx(ctx context.Context, docStmt, chunkStmt *sql.Stmt, doc *models.Document, chunks []*models.DocumentChunk, now time.Time) error {
+	metadataJSON, err := json.Marshal(doc.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	doc.CreatedAt = now
+	doc.UpdatedAt = now
+	if _, err := docStmt.ExecContext(ctx,
+		doc.ID, doc.Title, compressContent(doc.Content), string(metadataJSON), doc.CreatedAt, doc.UpdatedAt,
+		modifiedAt(doc.Metadata, doc.UpdatedAt).UnixNano(),
+	); err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		chunk.CreatedAt = now
+		if _, err := chunkStmt.ExecContext(ctx, chunk.ID, chunk.DocumentID, compressContent(chunk.Content), chunk.ChunkIndex, chunk.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CountDocuments returns the total number of documents.
 func (s *SQLiteStorage) CountDocuments(ctx context.Context) (int64, error) {
 	var count int64
@@ -268,14 +699,614 @@ func (s *SQLiteStorage) CountDocuments(ctx context.Context) (int64, error) {
 	return count, err
 }
 
-// CountChunks returns the total number of chunks.
-func (s *SQLiteStorage) CountChunks(ctx context.Context) (int64, error) {
-	var count int64
-	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM document_chunks`).Scan(&count)
-	return count, err
+// CreatePin inserts a pin, assigning an ID and creation time when it has none.
+func (s *SQLiteStorage) CreatePin(ctx context.Context, pin *models.Pin) error {
+	if pin.ID == "" {
+		pin.ID = uuid.New().String()
+	}
+	if pin.CreatedAt.IsZero() {
+		pin.CreatedAt = time.Now()
+	}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO pins (id, query, document_id, path, created_at) VALUES (?, ?, ?, ?, ?)`,
+		pin.ID, pin.Query, pin.DocumentID, pin.Path, pin.CreatedAt,
+	)
+	return err
 }
 
-// Close closes the database connection.
-func (s *SQLiteStorage) Close() error {
+// ListPins returns all pins, oldest first.
+func (s *SQLiteStorage) ListPins(ctx context.Context) ([]*models.Pin, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, query, document_id, path, created_at FROM pins ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pins []*models.Pin
+	for rows.Next() {
+		var pin models.Pin
+		if err := rows.Scan(&pin.ID, &pin.Query, &pin.DocumentID, &pin.Path, &pin.CreatedAt); err != nil {
+			return nil, err
+		}
+		pins = append(pins, &pin)
+	}
+	return pins, rows.Err()
+}
+
+// DeletePin removes a pin by ID.
+func (s *SQLiteStorage) DeletePin(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM pins WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrPinNotFound, id)
+	}
+	return nil
+}
+
+// CreateSnapshot records the documents stored now and the hashes of their content as a
+// snapshot named name. It returns ErrSnapshotExists when the name is taken.
+func (s *SQLiteStorage) CreateSnapshot(ctx context.Context, name string) (*models.Snapshot, error) {
+	// One transaction reads a consistent set of documents while indexing goes on.
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	snap := &models.Snapshot{Name: name, CreatedAt: time.Now().UTC()}
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO snapshots (name, created_at) VALUES (?, ?) ON CONFLICT (name) DO NOTHING`,
+		snap.Name, snap.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotExists, name)
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT id, content FROM documents`)
+	if err != nil {
+		return nil, err
+	}
+	docs := make(map[string]string)
+	for rows.Next() {
+		var id, content string
+		if err := rows.Scan(&id, &content); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if content, err = decompressContent(content); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		docs[id] = ContentHash(content)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := insertSnapshotDocuments(ctx, tx, name, docs); err != nil {
+		return nil, err
+	}
+	snap.Documents = len(docs)
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
+
+// ImportSnapshot stores snap with the document content hashes docs, as returned by
+// SnapshotDocuments, replacing a snapshot of the same name.
+func (s *SQLiteStorage) ImportSnapshot(ctx context.Context, snap *models.Snapshot, docs map[string]string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `DELETE FROM snapshot_documents WHERE snapshot = ?`, snap.Name); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT OR REPLACE INTO snapshots (name, created_at) VALUES (?, ?)`, snap.Name, snap.CreatedAt.UTC(),
+	); err != nil {
+		return err
+	}
+	if err := insertSnapshotDocuments(ctx, tx, snap.Name, docs); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertSnapshotDocuments adds docs to the snapshot name and sets its document count.
+func insertSnapshotDocuments(ctx context.Context, tx *sql.Tx, name string, docs map[string]string) error {
+	stmt, err := tx.PrepareContext(ctx,
+		`INSERT INTO snapshot_documents (snapshot, document_id, content_hash) VALUES (?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for id, hash := range docs {
+		if _, err := stmt.ExecContext(ctx, name, id, hash); err != nil {
+			return fmt.Errorf("failed to record snapshot document: %w", err)
+		}
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE snapshots SET documents = ? WHERE name = ?`, len(docs), name)
+	return err
+}
+
+// GetSnapshot returns the snapshot named name, or ErrSnapshotNotFound.
+func (s *SQLiteStorage) GetSnapshot(ctx context.Context, name string) (*models.Snapshot, error) {
+	var snap models.Snapshot
+	err := s.db.QueryRowContext(ctx,
+		`SELECT name, created_at, documents FROM snapshots WHERE name = ?`, name,
+	).Scan(&snap.Name, &snap.CreatedAt, &snap.Documents)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// ListSnapshots returns all snapshots, oldest first.
+func (s *SQLiteStorage) ListSnapshots(ctx context.Context) ([]*models.Snapshot, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT name, created_at, documents FROM snapshots ORDER BY created_at, name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var snaps []*models.Snapshot
+	for rows.Next() {
+		var snap models.Snapshot
+		if err := rows.Scan(&snap.Name, &snap.CreatedAt, &snap.Documents); err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, &snap)
+	}
+	return snaps, rows.Err()
+}
+
+// SnapshotDocuments returns the content hashes (see ContentHash) of the documents of the
+// snapshot named name by document ID, or ErrSnapshotNotFound.
+func (s *SQLiteStorage) SnapshotDocuments(ctx context.Context, name string) (map[string]string, error) {
+	if _, err := s.GetSnapshot(ctx, name); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT document_id, content_hash FROM snapshot_documents WHERE snapshot = ?`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	docs := make(map[string]string)
+	for rows.Next() {
+		var id, hash string
+		if err := rows.Scan(&id, &hash); err != nil {
+			return nil, err
+		}
+		docs[id] = hash
+	}
+	return docs, rows.Err()
+}
+
+// DeleteSnapshot removes the snapshot named name, or returns ErrSnapshotNotFound.
+func (s *SQLiteStorage) DeleteSnapshot(ctx context.Context, name string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx, `DELETE FROM snapshots WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM snapshot_documents WHERE snapshot = ?`, name); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AppendAudit records entry, assigning its ID and, when unset, its time. Times are
+// stored in UTC so that ListAudit's range comparisons on the stored text are ordered.
+func (s *SQLiteStorage) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Time = entry.Time.UTC()
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO audit_log (time, client, api_key, action, query, document_id, results, status)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		entry.Time, entry.Client, entry.APIKey, entry.Action, entry.Query, entry.DocumentID, entry.Results, entry.Status,
+	)
+	if err != nil {
+		return err
+	}
+	entry.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListAudit returns audit entries in [since, until), oldest first.
+func (s *SQLiteStorage) ListAudit(ctx context.Context, since, until time.Time, limit int) ([]*models.AuditEntry, error) {
+	query := `SELECT id, time, client, api_key, action, query, document_id, results, status FROM audit_log WHERE 1=1`
+	var args []interface{}
+	if !since.IsZero() {
+		query += ` AND time >= ?`
+		args = append(args, since.UTC())
+	}
+	if !until.IsZero() {
+		query += ` AND time < ?`
+		args = append(args, until.UTC())
+	}
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	query += ` ORDER BY time, id LIMIT ?`
+	args = append(args, limit)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		if err := rows.Scan(&e.ID, &e.Time, &e.Client, &e.APIKey, &e.Action, &e.Query, &e.DocumentID, &e.Results, &e.Status); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
+
+// AppendSearchEvent records event, assigning its ID and time when unset. Like audit
+// entries, times are stored in UTC.
+func (s *SQLiteStorage) AppendSearchEvent(ctx context.Context, event *models.SearchEvent) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	event.Time = event.Time.UTC()
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO search_analytics (id, time, query, keyword_results, semantic_results, latency_ms, clicked_document_id, clicked_rank)
+		 VALUES (NULLIF(?, 0), ?, ?, ?, ?, ?, ?, ?)`,
+		event.ID, event.Time, event.Query, event.KeywordResults, event.SemanticResults, event.LatencyMs, event.ClickedDocumentID, event.ClickedRank,
+	)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	for _, r := range event.Results {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO search_results (search_id, list, rank, document_id) VALUES (?, ?, ?, ?)`,
+			id, r.List, r.Rank, r.DocumentID,
+		); err != nil {
+			return fmt.Errorf("failed to record results: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	event.ID = id
+	return nil
+}
+
+// RecordedSearch returns the last search for query (compared ignoring case and
+// surrounding space) recorded at or before at, or else the first one after it, with its
+// Results. Searches recorded with results found but not their places are skipped. It
+// returns nil when there is none.
+func (s *SQLiteStorage) RecordedSearch(ctx context.Context, query string, at time.Time) (*models.SearchEvent, error) {
+	const sel = `SELECT id, time, query, keyword_results, semantic_results, latency_ms, clicked_document_id, clicked_rank
+		 FROM search_analytics
+		 WHERE lower(trim(query)) = lower(trim(?))
+		   AND (keyword_results + semantic_results = 0 OR id IN (SELECT search_id FROM search_results))`
+	var e models.SearchEvent
+	scan := func(row *sql.Row) error {
+		return row.Scan(&e.ID, &e.Time, &e.Query, &e.KeywordResults, &e.SemanticResults, &e.LatencyMs, &e.ClickedDocumentID, &e.ClickedRank)
+	}
+	err := scan(s.db.QueryRowContext(ctx, sel+` AND time <= ? ORDER BY time DESC, id DESC LIMIT 1`, query, at.UTC()))
+	if err == sql.ErrNoRows {
+		err = scan(s.db.QueryRowContext(ctx, sel+` AND time > ? ORDER BY time, id LIMIT 1`, query, at.UTC()))
+	}
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT list, rank, document_id FROM search_results WHERE search_id = ? ORDER BY list, rank`, e.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r models.ResultRef
+		if err := rows.Scan(&r.List, &r.Rank, &r.DocumentID); err != nil {
+			return nil, err
+		}
+		e.Results = append(e.Results, r)
+	}
+	return &e, rows.Err()
+}
+
+// RecordClick sets the clicked result of search queryID, replacing an earlier click.
+func (s *SQLiteStorage) RecordClick(ctx context.Context, queryID int64, documentID string, rank int) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE search_analytics SET clicked_document_id = ?, clicked_rank = ? WHERE id = ?`,
+		documentID, rank, queryID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %d", ErrSearchEventNotFound, queryID)
+	}
+	return nil
+}
+
+// RecordOpen records event, assigning its ID and time when unset. Times are stored in UTC.
+func (s *SQLiteStorage) RecordOpen(ctx context.Context, event *models.OpenEvent) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	event.Time = event.Time.UTC()
+	result, err := s.db.ExecContext(ctx,
+		`INSERT INTO result_opens (id, time, query, document_id, rank, query_id)
+		 VALUES (NULLIF(?, 0), ?, ?, ?, ?, ?)`,
+		event.ID, event.Time, event.Query, event.DocumentID, event.Rank, event.QueryID,
+	)
+	if err != nil {
+		return err
+	}
+	event.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListOpenEvents returns the result opens recorded in [since, until), oldest first.
+func (s *SQLiteStorage) ListOpenEvents(ctx context.Context, since, until time.Time, limit int) ([]*models.OpenEvent, error) {
+	where, args := analyticsRange(since, until)
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, time, query, document_id, rank, query_id
+		 FROM result_opens`+where+` ORDER BY time, id LIMIT ?`,
+		append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.OpenEvent
+	for rows.Next() {
+		var e models.OpenEvent
+		if err := rows.Scan(&e.ID, &e.Time, &e.Query, &e.DocumentID, &e.Rank, &e.QueryID); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// parseTimestamp parses a time as the driver stores it. The driver converts TIMESTAMP
+// columns itself, but aggregates of them, such as MAX(time), come back as text.
+func parseTimestamp(s string) (time.Time, error) {
+	s = strings.TrimSuffix(s, "Z")
+	for _, layout := range sqlite3.SQLiteTimestampFormats {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// analyticsRange returns the WHERE clause and arguments selecting times in [since, until).
+func analyticsRange(since, until time.Time) (string, []interface{}) {
+	where := ` WHERE 1=1`
+	var args []interface{}
+	if !since.IsZero() {
+		where += ` AND time >= ?`
+		args = append(args, since.UTC())
+	}
+	if !until.IsZero() {
+		where += ` AND time < ?`
+		args = append(args, until.UTC())
+	}
+	return where, args
+}
+
+// ListSearchEvents returns recorded searches in [since, until), oldest first.
+func (s *SQLiteStorage) ListSearchEvents(ctx context.Context, since, until time.Time, limit int) ([]*models.SearchEvent, error) {
+	where, args := analyticsRange(since, until)
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, time, query, keyword_results, semantic_results, latency_ms, clicked_document_id, clicked_rank
+		 FROM search_analytics`+where+` ORDER BY time, id LIMIT ?`,
+		append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.SearchEvent
+	for rows.Next() {
+		var e models.SearchEvent
+		if err := rows.Scan(&e.ID, &e.Time, &e.Query, &e.KeywordResults, &e.SemanticResults, &e.LatencyMs, &e.ClickedDocumentID, &e.ClickedRank); err != nil {
+			return nil, err
+		}
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// AnalyticsReport counts the searches in [since, until) and groups them by query,
+// ignoring case and surrounding space. A limit of 0 or less lists every query.
+func (s *SQLiteStorage) AnalyticsReport(ctx context.Context, since, until time.Time, limit int) (*models.AnalyticsReport, error) {
+	where, args := analyticsRange(since, until)
+	report := &models.AnalyticsReport{}
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*),
+		        COALESCE(SUM(keyword_results + semantic_results = 0), 0),
+		        COALESCE(SUM(clicked_document_id != ''), 0),
+		        COALESCE(AVG(latency_ms), 0)
+		 FROM search_analytics`+where, args...,
+	).Scan(&report.Searches, &report.ZeroResultSearches, &report.ClickedSearches, &report.AvgLatencyMs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count searches: %w", err)
+	}
+	if report.TopQueries, err = s.queryStats(ctx, where, args, limit); err != nil {
+		return nil, fmt.Errorf("failed to list top queries: %w", err)
+	}
+	if report.ZeroResultQueries, err = s.queryStats(ctx, where+` AND keyword_results + semantic_results = 0`, args, limit); err != nil {
+		return nil, fmt.Errorf("failed to list zero-result queries: %w", err)
+	}
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM result_opens`+where, args...).Scan(&report.Opens); err != nil {
+		return nil, fmt.Errorf("failed to count opens: %w", err)
+	}
+	if report.TopOpened, err = s.openStats(ctx, where, args, limit); err != nil {
+		return nil, fmt.Errorf("failed to list opened documents: %w", err)
+	}
+	return report, nil
+}
+
+// openStats aggregates the result opens matching where by document, most opened first.
+func (s *SQLiteStorage) openStats(ctx context.Context, where string, args []interface{}, limit int) ([]*models.DocumentOpenStats, error) {
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT document_id, COUNT(*), COUNT(DISTINCT lower(trim(query))), MAX(time)
+		 FROM result_opens`+where+`
+		 GROUP BY document_id ORDER BY COUNT(*) DESC, MAX(time) DESC, document_id LIMIT ?`,
+		append(append([]interface{}{}, args...), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []*models.DocumentOpenStats{}
+	for rows.Next() {
+		var d models.DocumentOpenStats
+		var last string
+		if err := rows.Scan(&d.DocumentID, &d.Opens, &d.Queries, &last); err != nil {
+			return nil, err
+		}
+		if d.LastOpened, err = parseTimestamp(last); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &d)
+	}
+	return stats, rows.Err()
+}
+
+// queryStats aggregates the searches matching where by query, most frequent first.
+func (s *SQLiteStorage) queryStats(ctx context.Context, where string, args []interface{}, limit int) ([]*models.QueryStats, error) {
+	if limit <= 0 {
+		limit = -1 // no limit
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT lower(trim(query)) AS q, COUNT(*), AVG(keyword_results + semantic_results),
+		        SUM(clicked_document_id != ''), AVG(latency_ms)
+		 FROM search_analytics`+where+`
+		 GROUP BY q ORDER BY COUNT(*) DESC, q LIMIT ?`,
+		append(append([]interface{}{}, args...), limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []*models.QueryStats{}
+	for rows.Next() {
+		var q models.QueryStats
+		if err := rows.Scan(&q.Query, &q.Count, &q.AvgResults, &q.Clicks, &q.AvgLatencyMs); err != nil {
+			return nil, err
+		}
+		stats = append(stats, &q)
+	}
+	return stats, rows.Err()
+}
+
+// CountChunks returns the total number of chunks.
+func (s *SQLiteStorage) CountChunks(ctx context.Context) (int64, error) {
+	var count int64
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM document_chunks`).Scan(&count)
+	return count, err
+}
+
+// Reset deletes all documents and chunks in a single transaction. With version history
+// the documents are kept as versions, so a full reindex does not erase the past.
+func (s *SQLiteStorage) Reset(ctx context.Context) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM document_chunks`); err != nil {
+		return fmt.Errorf("failed to delete chunks: %w", err)
+	}
+	if s.versions {
+		if err := archiveVersions(ctx, tx, time.Now(), ``); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM documents`); err != nil {
+		return fmt.Errorf("failed to delete documents: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ListChanges returns the change log entries after seq, oldest first, up to limit.
+func (s *SQLiteStorage) ListChanges(ctx context.Context, after int64, limit int) ([]*models.ChangeLogEntry, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT seq, document_id, op, time FROM document_changes
+		 WHERE seq > ? ORDER BY seq LIMIT ?`, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []*models.ChangeLogEntry
+	for rows.Next() {
+		var e models.ChangeLogEntry
+		if err := rows.Scan(&e.Seq, &e.DocumentID, &e.Op, &e.Time); err != nil {
+			return nil, err
+		}
+		entries = append(entries, &e)
+	}
+	return entries, rows.Err()
+}
+
+// ChangeLogHead returns the ID of the change log and the sequence number of its latest entry.
+func (s *SQLiteStorage) ChangeLogHead(ctx context.Context) (string, int64, error) {
+	var id string
+	var seq int64
+	err := s.db.QueryRowContext(ctx,
+		`SELECT (SELECT value FROM meta WHERE key = 'change_log_id'),
+		        (SELECT coalesce(max(seq), 0) FROM document_changes)`,
+	).Scan(&id, &seq)
+	return id, seq, err
+}
+
+// Close closes the database connection.
+func (s *SQLiteStorage) Close() error {
+	for _, stmt := range []*sql.Stmt{s.insertDocument, s.insertChunk} {
+		if stmt != nil {
+			_ = stmt.Close()
+		}
+	}
 	return s.db.Close()
 }
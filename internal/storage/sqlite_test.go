@@ -2,9 +2,15 @@ package storage
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hyperjump/sagasu/internal/models"
 )
@@ -30,6 +36,42 @@ func TestNewSQLiteStorage_createsParentDirectories(t *testing.T) {
 	}
 }
 
+func TestNewSQLiteStorage_pragmas(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "pragmas.db"), WithPragmas(Pragmas{
+		Synchronous: "normal",
+		CacheSizeKB: 8192,
+		BusyTimeout: 2 * time.Second,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	var journalMode string
+	var synchronous, cacheSize, busyTimeout int
+	for _, p := range []struct {
+		pragma string
+		dest   any
+	}{
+		{"journal_mode", &journalMode},
+		{"synchronous", &synchronous},
+		{"cache_size", &cacheSize},
+		{"busy_timeout", &busyTimeout},
+	} {
+		if err := store.db.QueryRow("PRAGMA " + p.pragma).Scan(p.dest); err != nil {
+			t.Fatalf("PRAGMA %s: %v", p.pragma, err)
+		}
+	}
+	if journalMode != "wal" || synchronous != 1 || cacheSize != -8192 || busyTimeout != 2000 {
+		t.Errorf("journal_mode %s, synchronous %d, cache_size %d, busy_timeout %d", journalMode, synchronous, cacheSize, busyTimeout)
+	}
+
+	for _, p := range []Pragmas{{JournalMode: "fast"}, {Synchronous: "sometimes"}} {
+		if _, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "bad.db"), WithPragmas(p)); err == nil {
+			t.Errorf("%+v: expected error", p)
+		}
+	}
+}
+
 func TestSQLiteStorage_CRUD(t *testing.T) {
 	dir := t.TempDir()
 	path := filepath.Join(dir, "test.db")
@@ -138,6 +180,56 @@ func TestSQLiteStorage_Chunks(t *testing.T) {
 	}
 }
 
+func TestSQLiteStorage_CompressedContent(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "compressed.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	long := strings.Repeat("the quick brown fox jumps over the lazy dog. ", 50)
+	if err := store.CreateDocument(ctx, &models.Document{ID: "d1", Title: "T", Content: long}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.BatchCreateChunks(ctx, []*models.DocumentChunk{
+		{ID: "d1_c1", DocumentID: "d1", Content: long, ChunkIndex: 0},
+		{ID: "d1_c2", DocumentID: "d1", Content: "short", ChunkIndex: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var stored int
+	if err := store.db.QueryRow(`SELECT length(CAST(content AS BLOB)) FROM documents WHERE id = 'd1'`).Scan(&stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored >= len(long)/2 {
+		t.Errorf("stored content is %d bytes, want it compressed from %d", stored, len(long))
+	}
+
+	doc, err := store.GetDocument(ctx, "d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Content != long {
+		t.Error("document content did not round-trip")
+	}
+	chunks, err := store.GetChunksByDocumentID(ctx, "d1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].Content != long || chunks[1].Content != "short" {
+		t.Errorf("chunks did not round-trip: %d chunks", len(chunks))
+	}
+
+	// Rows written before compression hold plain text and still read as they are.
+	if _, err := store.db.Exec(`UPDATE documents SET content = ? WHERE id = 'd1'`, long); err != nil {
+		t.Fatal(err)
+	}
+	if doc, err = store.GetDocument(ctx, "d1"); err != nil || doc.Content != long {
+		t.Errorf("plain content did not read back: %v", err)
+	}
+}
+
 func TestSQLiteStorage_Counts(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "count.db")
 	store, err := NewSQLiteStorage(path)
@@ -157,3 +249,838 @@ func TestSQLiteStorage_Counts(t *testing.T) {
 		t.Errorf("expected 1 document, got %d", n)
 	}
 }
+
+func TestSQLiteStorage_Reset(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+	if err := store.CreateDocument(ctx, &models.Document{ID: "d1", Content: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateChunk(ctx, &models.DocumentChunk{ID: "c1", DocumentID: "d1", Content: "c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	docs, _ := store.CountDocuments(ctx)
+	chunks, _ := store.CountChunks(ctx)
+	if docs != 0 || chunks != 0 {
+		t.Errorf("after Reset: documents=%d chunks=%d, want 0", docs, chunks)
+	}
+}
+
+func TestSQLiteStorage_ListRecentDocuments(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	now := time.Now()
+	mtime := func(d time.Duration) string { return strconv.FormatInt(now.Add(-d).UnixNano(), 10) }
+	docs := []*models.Document{
+		{ID: "old", Content: "c", Metadata: map[string]interface{}{
+			"source_path": "/docs/old.txt", "source_mtime": mtime(30 * 24 * time.Hour)}},
+		{ID: "new", Content: "c", Metadata: map[string]interface{}{
+			"source_path": "/docs/new.txt", "source_mtime": mtime(time.Hour)}},
+		{ID: "newer", Content: "c", Metadata: map[string]interface{}{
+			"source_path": "/notes/newer.txt", "source_mtime": mtime(time.Minute)}},
+		{ID: "api", Content: "c"}, // no source file: modified when indexed
+	}
+	for _, d := range docs {
+		if err := store.CreateDocument(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	since := now.Add(-7 * 24 * time.Hour)
+	got, total, err := store.ListRecentDocuments(ctx, since, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 {
+		t.Errorf("total = %d, want 3", total)
+	}
+	var ids []string
+	for _, d := range got {
+		ids = append(ids, d.ID)
+	}
+	if strings.Join(ids, ",") != "api,newer,new" {
+		t.Errorf("recent documents = %v, want [api newer new]", ids)
+	}
+	if got[1].Path != "/notes/newer.txt" {
+		t.Errorf("path = %q", got[1].Path)
+	}
+
+	got, total, err = store.ListRecentDocuments(ctx, since, "/docs/", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "new" || total != 1 {
+		t.Errorf("with path prefix: got %d documents of %d, want [new]", len(got), total)
+	}
+
+	got, total, err = store.ListRecentDocuments(ctx, since, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "api" {
+		t.Errorf("with limit 1: got %d documents", len(got))
+	}
+	if total != 3 {
+		t.Errorf("with limit 1: total = %d, want all 3 matching documents", total)
+	}
+
+	// Updating a document moves it by its new mtime.
+	docs[0].Metadata["source_mtime"] = mtime(-time.Minute)
+	if err := store.UpdateDocument(ctx, docs[0]); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err = store.ListRecentDocuments(ctx, since, "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].ID != "old" {
+		t.Errorf("after update: got %v, want [old]", got)
+	}
+}
+
+func TestSQLiteStorage_ListRecentDocuments_fillsModifiedAtOfOlderDatabases(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if _, err := db.Exec(`
+	CREATE TABLE documents (
+		id TEXT PRIMARY KEY,
+		title TEXT,
+		content TEXT NOT NULL,
+		metadata TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+	INSERT INTO documents (id, title, content, metadata) VALUES
+		('file', 't', 'c', '{"source_path":"/docs/a.txt","source_mtime":"` + strconv.FormatInt(mtime.UnixNano(), 10) + `"}'),
+		('api', 't', 'c', '{}');`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	store, err := NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	got, total, err := store.ListRecentDocuments(context.Background(), time.Now().Add(-24*time.Hour), "/docs/", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 1 || len(got) != 1 || !got[0].ModifiedAt.Equal(mtime) || got[0].Path != "/docs/a.txt" {
+		t.Errorf("got %d of %d documents %+v, want file modified at %v", len(got), total, got, mtime)
+	}
+}
+
+func TestSQLiteStorage_ListDocumentSummaries(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	for _, d := range []*models.Document{
+		{ID: "a", Content: "c", Metadata: map[string]interface{}{"source_path": "/docs/a.pdf"}},
+		{ID: "b", Content: "c", Metadata: map[string]interface{}{"source_path": "/docs/b.TXT"}},
+		{ID: "c", Content: "c", Metadata: map[string]interface{}{"source_path": "/notes/c.txt"}},
+		{ID: "d", Content: "c"},
+	} {
+		if err := store.CreateDocument(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := func(docs []*models.DocumentSummary) string {
+		var out []string
+		for _, d := range docs {
+			out = append(out, d.ID)
+		}
+		return strings.Join(out, ",")
+	}
+
+	got, total, err := store.ListDocumentSummaries(ctx, models.DocumentListFilter{}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 || ids(got) != "d,c,b,a" {
+		t.Errorf("all: got %s of %d, want d,c,b,a of 4", ids(got), total)
+	}
+	if got[1].Path != "/notes/c.txt" || got[1].Metadata["source_path"] != "/notes/c.txt" {
+		t.Errorf("summary: got %+v", got[1])
+	}
+
+	got, total, err = store.ListDocumentSummaries(ctx, models.DocumentListFilter{}, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 4 || ids(got) != "c,b" {
+		t.Errorf("page: got %s of %d, want c,b of 4", ids(got), total)
+	}
+
+	got, total, err = store.ListDocumentSummaries(ctx, models.DocumentListFilter{Extensions: []string{"txt"}}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || ids(got) != "c,b" {
+		t.Errorf("txt: got %s of %d, want c,b of 2", ids(got), total)
+	}
+
+	got, total, err = store.ListDocumentSummaries(ctx, models.DocumentListFilter{PathPrefix: "/docs/", Extensions: []string{"pdf", "txt"}}, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 2 || ids(got) != "b,a" {
+		t.Errorf("path prefix: got %s of %d, want b,a of 2", ids(got), total)
+	}
+}
+
+func TestSQLiteStorage_ChangeLog(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewSQLiteStorage(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	logID, head, err := store.ChangeLogHead(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if logID == "" || head != 0 {
+		t.Fatalf("empty log: got %q, %d", logID, head)
+	}
+	doc := &models.Document{ID: "a", Content: "c"}
+	if err := store.CreateDocument(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateDocument(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteDocument(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteDocument(ctx, "missing"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := store.ListChanges(ctx, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ops []string
+	for _, e := range entries {
+		if e.DocumentID != "a" || e.Time.IsZero() {
+			t.Errorf("entry: got %+v", e)
+		}
+		ops = append(ops, e.Op)
+	}
+	if strings.Join(ops, ",") != "insert,update,delete" {
+		t.Errorf("ops = %v, want [insert update delete]", ops)
+	}
+	if entries, _ := store.ListChanges(ctx, 1, 1); len(entries) != 1 || entries[0].Seq != 2 {
+		t.Errorf("after 1, limit 1: got %+v", entries)
+	}
+
+	// The log ID is kept on reopening; another database gets its own.
+	store.Close()
+	store, err = NewSQLiteStorage(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, head, _ := store.ChangeLogHead(ctx); id != logID || head != 3 {
+		t.Errorf("reopened: got %q, %d, want %q, 3", id, head, logID)
+	}
+	other, err := NewSQLiteStorage(filepath.Join(dir, "other.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if id, _, _ := other.ChangeLogHead(ctx); id == logID {
+		t.Error("another database has the same change log ID")
+	}
+}
+
+func TestSQLiteStorage_Pins(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	first := &models.Pin{Query: "expense policy", DocumentID: "policy"}
+	if err := store.CreatePin(ctx, first); err != nil {
+		t.Fatal(err)
+	}
+	if first.ID == "" || first.CreatedAt.IsZero() {
+		t.Errorf("CreatePin should set ID and CreatedAt, got %+v", first)
+	}
+	if err := store.CreatePin(ctx, &models.Pin{Query: "handbook", Path: "/docs/hr"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pins, err := store.ListPins(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pins) != 2 || pins[0].ID != first.ID || pins[1].Path != "/docs/hr" {
+		t.Fatalf("pins should survive Reset in creation order, got %+v", pins)
+	}
+
+	if err := store.DeletePin(ctx, first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeletePin(ctx, first.ID); !errors.Is(err, ErrPinNotFound) {
+		t.Errorf("deleting a missing pin: got %v, want ErrPinNotFound", err)
+	}
+}
+
+func TestSQLiteStorage_Snapshots(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	long := strings.Repeat("compressible content ", 50)
+	for _, doc := range []*models.Document{
+		{ID: "a", Title: "A", Content: "short"},
+		{ID: "b", Title: "B", Content: long},
+	} {
+		if err := store.CreateDocument(ctx, doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	snap, err := store.CreateSnapshot(ctx, "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Documents != 2 || snap.CreatedAt.IsZero() {
+		t.Errorf("CreateSnapshot: got %+v", snap)
+	}
+	if _, err := store.CreateSnapshot(ctx, "baseline"); !errors.Is(err, ErrSnapshotExists) {
+		t.Errorf("taken name: got %v, want ErrSnapshotExists", err)
+	}
+
+	// Documents indexed later are not in the snapshot, and it survives Reset.
+	if err := store.CreateDocument(ctx, &models.Document{ID: "c", Content: "later"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := store.SnapshotDocuments(ctx, "baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a": ContentHash("short"), "b": ContentHash(long)}
+	if !reflect.DeepEqual(docs, want) {
+		t.Errorf("SnapshotDocuments: got %v, want %v", docs, want)
+	}
+
+	copied := &models.Snapshot{Name: "copy", CreatedAt: snap.CreatedAt}
+	if err := store.ImportSnapshot(ctx, copied, map[string]string{"a": "h"}); err != nil {
+		t.Fatal(err)
+	}
+	snaps, err := store.ListSnapshots(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != 2 || snaps[0].Name != "baseline" || snaps[1].Name != "copy" || snaps[1].Documents != 1 {
+		t.Fatalf("ListSnapshots: got %+v", snaps)
+	}
+
+	if err := store.DeleteSnapshot(ctx, "baseline"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteSnapshot(ctx, "baseline"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("deleting a missing snapshot: got %v, want ErrSnapshotNotFound", err)
+	}
+	if _, err := store.SnapshotDocuments(ctx, "baseline"); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("documents of a deleted snapshot: got %v, want ErrSnapshotNotFound", err)
+	}
+	if _, err := store.CreateSnapshot(ctx, "baseline"); err != nil {
+		t.Errorf("name of a deleted snapshot should be free: %v", err)
+	}
+}
+
+func TestSQLiteStorage_Audit(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	// Times in different zones must still be ordered and filtered by instant.
+	east := time.FixedZone("UTC+9", 9*3600)
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entries := []*models.AuditEntry{
+		{Time: base.In(east), Client: "10.0.0.1", Action: models.AuditSearch, Query: "budget", Results: 2, Status: 200},
+		{Time: base.Add(time.Hour), Client: "10.0.0.2", Action: models.AuditDocumentGet, DocumentID: "d1", Status: 200},
+		{Time: base.Add(2 * time.Hour).In(east), Client: "10.0.0.1", APIKey: "ci", Action: models.AuditDocumentFile, DocumentID: "d2", Status: 404},
+	}
+	for _, e := range entries {
+		if err := store.AppendAudit(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+		if e.ID == 0 {
+			t.Errorf("AppendAudit should set ID, got %+v", e)
+		}
+	}
+	if err := store.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := store.ListAudit(ctx, time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("audit log should survive Reset, got %d entries", len(all))
+	}
+	if all[0].Query != "budget" || all[0].Results != 2 || all[2].APIKey != "ci" || all[2].Status != 404 {
+		t.Errorf("entries not read back: %+v %+v", all[0], all[2])
+	}
+
+	got, err := store.ListAudit(ctx, base.Add(30*time.Minute).In(east), base.Add(2*time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].DocumentID != "d1" {
+		t.Errorf("range [12:30, 14:00) UTC: got %+v", got)
+	}
+	if got, _ = store.ListAudit(ctx, time.Time{}, time.Time{}, 2); len(got) != 2 || got[1].DocumentID != "d1" {
+		t.Errorf("limit 2: got %+v", got)
+	}
+}
+
+func TestSQLiteStorage_SearchAnalytics(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := []*models.SearchEvent{
+		{Time: base, Query: "Budget", KeywordResults: 2, SemanticResults: 1, LatencyMs: 10},
+		{Time: base.Add(time.Minute), Query: "budget ", KeywordResults: 1, LatencyMs: 30},
+		{Time: base.Add(2 * time.Minute), Query: "quarterly forecats", LatencyMs: 5},
+		{Time: base.Add(3 * time.Minute), Query: "roadmap", SemanticResults: 4, LatencyMs: 15},
+	}
+	for _, e := range events {
+		if err := store.AppendSearchEvent(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+		if e.ID == 0 {
+			t.Errorf("AppendSearchEvent should set ID, got %+v", e)
+		}
+	}
+	if err := store.RecordClick(ctx, events[1].ID, "d1", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordClick(ctx, 999, "d1", 1); !errors.Is(err, ErrSearchEventNotFound) {
+		t.Errorf("click on a missing search: got %v, want ErrSearchEventNotFound", err)
+	}
+	if err := store.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := store.ListSearchEvents(ctx, time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 {
+		t.Fatalf("analytics should survive Reset, got %d events", len(all))
+	}
+	if all[1].ClickedDocumentID != "d1" || all[1].ClickedRank != 1 || all[0].SemanticResults != 1 {
+		t.Errorf("events not read back: %+v %+v", all[0], all[1])
+	}
+
+	report, err := store.AnalyticsReport(ctx, time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Searches != 4 || report.ZeroResultSearches != 1 || report.ClickedSearches != 1 || report.AvgLatencyMs != 15 {
+		t.Errorf("totals: got %+v", report)
+	}
+	if len(report.TopQueries) != 3 {
+		t.Fatalf("top queries: got %d, want 3", len(report.TopQueries))
+	}
+	top := report.TopQueries[0]
+	if top.Query != "budget" || top.Count != 2 || top.AvgResults != 2 || top.Clicks != 1 || top.AvgLatencyMs != 20 {
+		t.Errorf("top query: got %+v", top)
+	}
+	if len(report.ZeroResultQueries) != 1 || report.ZeroResultQueries[0].Query != "quarterly forecats" {
+		t.Errorf("zero-result queries: got %+v", report.ZeroResultQueries)
+	}
+
+	report, err = store.AnalyticsReport(ctx, base.Add(time.Minute), base.Add(3*time.Minute), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Searches != 2 || len(report.TopQueries) != 1 {
+		t.Errorf("range [12:01, 12:03) limit 1: got %+v", report)
+	}
+}
+
+func TestSQLiteStorage_RecordedSearch(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	events := []*models.SearchEvent{
+		{Time: base, Query: "Budget", KeywordResults: 2, Results: []models.ResultRef{
+			{DocumentID: "d1", List: models.ResultListKeyword, Rank: 1},
+			{DocumentID: "d2", List: models.ResultListKeyword, Rank: 2},
+		}},
+		// Results found but not recorded (before their places were kept): skipped
+		{Time: base.Add(time.Hour), Query: "budget", KeywordResults: 1},
+		{Time: base.Add(2 * time.Hour), Query: "budget ", SemanticResults: 1, Results: []models.ResultRef{
+			{DocumentID: "d3", List: models.ResultListSemantic, Rank: 1},
+		}},
+	}
+	for _, e := range events {
+		if err := store.AppendSearchEvent(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := store.RecordedSearch(ctx, "BUDGET", base.Add(90*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil || got.ID != events[0].ID || len(got.Results) != 2 || got.Results[1] != events[0].Results[1] {
+		t.Errorf("at 13:30: got %+v, want the 12:00 search with its results", got)
+	}
+	// Before any recording, the first one after is the baseline
+	if got, err = store.RecordedSearch(ctx, "budget", base.Add(-time.Hour)); err != nil || got == nil || got.ID != events[0].ID {
+		t.Errorf("at 11:00: got %+v, %v", got, err)
+	}
+	if got, err = store.RecordedSearch(ctx, "budget", base.Add(3*time.Hour)); err != nil || got == nil || got.ID != events[2].ID {
+		t.Errorf("at 15:00: got %+v, %v", got, err)
+	}
+	if got, err = store.RecordedSearch(ctx, "roadmap", base); err != nil || got != nil {
+		t.Errorf("unrecorded query: got %+v, %v", got, err)
+	}
+}
+
+func TestSQLiteStorage_ResultOpens(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	base := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	opens := []*models.OpenEvent{
+		{Time: base, Query: "budget", DocumentID: "d1", Rank: 1, QueryID: 7},
+		{Time: base.Add(time.Minute), Query: "Budget ", DocumentID: "d1", Rank: 2},
+		{Time: base.Add(2 * time.Minute), Query: "forecast", DocumentID: "d1"},
+		{Time: base.Add(3 * time.Minute), Query: "roadmap", DocumentID: "d2", Rank: 1},
+	}
+	for _, e := range opens {
+		if err := store.RecordOpen(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+		if e.ID == 0 {
+			t.Errorf("RecordOpen should set ID, got %+v", e)
+		}
+	}
+	if err := store.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := store.ListOpenEvents(ctx, time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 4 || all[0].QueryID != 7 || all[0].Rank != 1 || !all[0].Time.Equal(base) {
+		t.Fatalf("opens should be read back and survive Reset, got %+v", all)
+	}
+
+	report, err := store.AnalyticsReport(ctx, time.Time{}, time.Time{}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Opens != 4 || len(report.TopOpened) != 2 {
+		t.Fatalf("opens: got %d, top %+v", report.Opens, report.TopOpened)
+	}
+	if top := report.TopOpened[0]; top.DocumentID != "d1" || top.Opens != 3 || top.Queries != 2 || !top.LastOpened.Equal(base.Add(2*time.Minute)) {
+		t.Errorf("most opened: got %+v", top)
+	}
+	report, err = store.AnalyticsReport(ctx, base.Add(3*time.Minute), time.Time{}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Opens != 1 || len(report.TopOpened) != 1 || report.TopOpened[0].DocumentID != "d2" {
+		t.Errorf("range from 12:03: got %d opens, top %+v", report.Opens, report.TopOpened)
+	}
+}
+
+func TestSQLiteStorage_BatchCreateDocuments(t *testing.T) {
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	ctx := context.Background()
+
+	if err := store.CreateDocument(ctx, &models.Document{ID: "taken", Title: "existing"}); err != nil {
+		t.Fatal(err)
+	}
+	docs := []*models.Document{{ID: "a", Title: "A"}, {ID: "taken", Title: "dup"}, {ID: "b", Title: "B"}}
+	chunks := [][]*models.DocumentChunk{
+		{{ID: "a_0", DocumentID: "a", Content: "alpha"}},
+		{{ID: "taken_0", DocumentID: "taken", Content: "dup"}},
+		{{ID: "b_0", DocumentID: "b", Content: "beta"}, {ID: "b_1", DocumentID: "b", Content: "gamma", ChunkIndex: 1}},
+	}
+	errs, err := store.BatchCreateDocuments(ctx, docs, chunks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs[0] != nil || errs[1] == nil || errs[2] != nil {
+		t.Fatalf("errs = %v, want only the duplicate to fail", errs)
+	}
+	if n, _ := store.CountDocuments(ctx); n != 3 {
+		t.Errorf("documents: got %d, want 3", n)
+	}
+	if got, _ := store.GetChunksByDocumentID(ctx, "b"); len(got) != 2 {
+		t.Errorf("chunks of b: got %d, want 2", len(got))
+	}
+	if _, err := store.GetChunk(ctx, "taken_0"); err == nil {
+		t.Error("chunks of the failed document should be rolled back")
+	}
+	if doc, _ := store.GetDocument(ctx, "taken"); doc == nil || doc.Title != "existing" {
+		t.Errorf("existing document changed: %+v", doc)
+	}
+}
+
+func TestSQLiteStorage_SearchLiteral(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "literal.db")
+	ctx := context.Background()
+	store, err := NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := []*models.Document{
+		{ID: "a", Title: "Unit SN-0042-X", Content: "serial sn-0042-x, see also SN-0042-X."},
+		{ID: "b", Title: "code", Content: "for i := range xs[0:n] { }"},
+		{ID: "c", Title: "other", Content: "SN 0042 X is not the same string"},
+	}
+	for _, d := range docs {
+		if err := store.CreateDocument(ctx, d); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(text string) string {
+		matches, err := store.SearchLiteral(ctx, text)
+		if err != nil {
+			t.Fatalf("SearchLiteral(%q): %v", text, err)
+		}
+		var got []string
+		for _, m := range matches {
+			got = append(got, m.DocumentID+":"+strconv.Itoa(m.TitleCount)+"/"+strconv.Itoa(m.ContentCount))
+		}
+		return strings.Join(got, ",")
+	}
+	check := func(label string) {
+		t.Helper()
+		for text, want := range map[string]string{
+			"sn-0042-x":  "a:1/2",
+			"xs[0:n]":    "b:0/1",
+			"0042":       "a:1/2,c:0/1",
+			"x":          "a:1/2,b:0/1,c:0/1",
+			"sn-0042-y":  "",
+			"range xs[0": "b:0/1",
+		} {
+			if got := search(text); got != want {
+				t.Errorf("%s: SearchLiteral(%q) = %q, want %q", label, text, got, want)
+			}
+		}
+	}
+	check("scan")
+	store.Close()
+
+	// Enabling the trigram index builds it for the stored documents and keeps it current.
+	if store, err = NewSQLiteStorage(path, WithTrigramIndex(true)); err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	check("trigram index")
+	docs[2].Content = "now with SN-0042-X"
+	if err := store.UpdateDocument(ctx, docs[2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteDocument(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.BatchCreateDocuments(ctx, []*models.Document{{ID: "d", Content: "xs[0:n] again"}}, [][]*models.DocumentChunk{nil}); err != nil {
+		t.Fatal(err)
+	}
+	if got := search("SN-0042-X"); got != "a:1/2,c:0/1" {
+		t.Errorf("after update: %q", got)
+	}
+	if got := search("xs[0:n]"); got != "d:0/1" {
+		t.Errorf("after delete and batch: %q", got)
+	}
+	var n int
+	if err := store.db.QueryRow(`SELECT count(*) FROM document_trigrams WHERE document_id = 'b'`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("trigrams of deleted document: %d, %v", n, err)
+	}
+}
+
+func TestSQLiteStorage_DocumentsAsOf(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	store, err := NewSQLiteStorage(path, WithVersionHistory(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	tick := func() time.Time {
+		time.Sleep(5 * time.Millisecond)
+		now := time.Now()
+		time.Sleep(5 * time.Millisecond)
+		return now
+	}
+	asOf := func(at time.Time) string {
+		t.Helper()
+		docs, err := store.DocumentsAsOf(ctx, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out []string
+		for _, d := range docs {
+			out = append(out, d.ID+"="+d.Content)
+		}
+		return strings.Join(out, ",")
+	}
+
+	before := tick()
+	doc := &models.Document{ID: "a", Title: "A", Content: "first", Metadata: map[string]interface{}{"k": "v"}}
+	if err := store.CreateDocument(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateDocument(ctx, &models.Document{ID: "b", Content: "kept"}); err != nil {
+		t.Fatal(err)
+	}
+	created := tick()
+	doc.Content = "second"
+	if err := store.UpdateDocument(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	updated := tick()
+	if err := store.DeleteDocument(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	deleted := tick()
+	if err := store.Reset(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateDocument(ctx, &models.Document{ID: "b", Content: "reindexed"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name string
+		at   time.Time
+		want string
+	}{
+		{"before", before, ""},
+		{"created", created, "a=first,b=kept"},
+		{"updated", updated, "a=second,b=kept"},
+		{"deleted", deleted, "b=kept"},
+		{"now", time.Now(), "b=reindexed"},
+	} {
+		if got := asOf(tc.at); got != tc.want {
+			t.Errorf("%s: documents = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	docs, err := store.DocumentsAsOf(ctx, created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs[0].Title != "A" || docs[0].Metadata["k"] != "v" {
+		t.Errorf("version = %+v, want title and metadata kept", docs[0])
+	}
+
+	plain, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "plain.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer plain.Close()
+	if _, err := plain.DocumentsAsOf(ctx, time.Now()); !errors.Is(err, ErrVersionHistoryDisabled) {
+		t.Errorf("without version history: err = %v", err)
+	}
+}
+
+func TestSQLiteStorage_DocumentsAsOf_oneVersionPerInstant(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"), WithVersionHistory(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	// A file edited, then replaced by an older copy: the new content's mtime is before
+	// the time the first version was archived. Versions follow the time of each write.
+	mtime := time.Now().Add(-time.Hour)
+	doc := &models.Document{ID: "a", Content: "v1", Metadata: map[string]interface{}{
+		"source_mtime": strconv.FormatInt(mtime.UnixNano(), 10),
+	}}
+	if err := store.CreateDocument(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	doc.Content = "v2"
+	doc.Metadata["source_mtime"] = strconv.FormatInt(mtime.Add(-24*time.Hour).UnixNano(), 10)
+	if err := store.UpdateDocument(ctx, doc); err != nil {
+		t.Fatal(err)
+	}
+	var replacedAt int64
+	if err := store.db.QueryRow(`SELECT replaced_at FROM document_versions WHERE document_id = 'a'`).Scan(&replacedAt); err != nil {
+		t.Fatal(err)
+	}
+
+	// Right before, at, and right after the update, exactly one version is current.
+	for offset, want := range map[time.Duration]string{-time.Nanosecond: "v1", 0: "v2", time.Nanosecond: "v2"} {
+		docs, err := store.DocumentsAsOf(ctx, time.Unix(0, replacedAt+int64(offset)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(docs) != 1 || docs[0].Content != want {
+			var got []string
+			for _, d := range docs {
+				got = append(got, d.Content)
+			}
+			t.Errorf("%v from the update: versions %v, want [%s]", offset, got, want)
+		}
+	}
+}
@@ -3,10 +3,32 @@ package storage
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
 
 	"github.com/hyperjump/sagasu/internal/models"
 )
 
+// ErrPinNotFound is returned by DeletePin when no pin has the given ID.
+var ErrPinNotFound = errors.New("pin not found")
+
+// ErrSearchEventNotFound is returned by RecordClick when no search has the given ID.
+var ErrSearchEventNotFound = errors.New("search not found")
+
+// ErrSnapshotNotFound is returned for a snapshot name that was never taken or was deleted.
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// ErrSnapshotExists is returned by CreateSnapshot when the name is taken.
+var ErrSnapshotExists = errors.New("snapshot already exists")
+
+// ContentHash returns the hash of document content recorded in snapshots.
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
 // Storage defines document and chunk persistence operations.
 type Storage interface {
 	// Document operations
@@ -15,6 +37,13 @@ type Storage interface {
 	UpdateDocument(ctx context.Context, doc *models.Document) error
 	DeleteDocument(ctx context.Context, id string) error
 	ListDocuments(ctx context.Context, offset, limit int) ([]*models.Document, error)
+	// ListRecentDocuments returns up to limit documents modified at or after since, newest
+	// first, and the number of such documents. When pathPrefix is non-empty, only documents
+	// whose source path starts with it are counted and returned.
+	ListRecentDocuments(ctx context.Context, since time.Time, pathPrefix string, limit int) ([]*models.RecentDocument, int, error)
+	// ListDocumentSummaries returns the page [offset, offset+limit) of the documents
+	// matching filter, newest first, and the number of matching documents.
+	ListDocumentSummaries(ctx context.Context, filter models.DocumentListFilter, offset, limit int) ([]*models.DocumentSummary, int, error)
 
 	// Chunk operations
 	CreateChunk(ctx context.Context, chunk *models.DocumentChunk) error
@@ -24,10 +53,64 @@ type Storage interface {
 
 	// Batch operations
 	BatchCreateChunks(ctx context.Context, chunks []*models.DocumentChunk) error
+	// BatchCreateDocuments stores docs and their chunks (chunks[i] belongs to docs[i]) in
+	// one transaction. A document that cannot be stored does not affect the others: its
+	// error is returned at its index. The second result reports a failed transaction.
+	BatchCreateDocuments(ctx context.Context, docs []*models.Document, chunks [][]*models.DocumentChunk) ([]error, error)
+
+	// Pin operations. Pins are kept by Reset.
+	CreatePin(ctx context.Context, pin *models.Pin) error
+	ListPins(ctx context.Context) ([]*models.Pin, error)
+	DeletePin(ctx context.Context, id string) error
+
+	// Snapshot operations. Snapshots are read-only once taken and kept by Reset.
+	// CreateSnapshot records the current documents and the ContentHash of their content
+	// under name, or returns ErrSnapshotExists.
+	CreateSnapshot(ctx context.Context, name string) (*models.Snapshot, error)
+	// ImportSnapshot stores a snapshot read from other storage, replacing one of its name.
+	ImportSnapshot(ctx context.Context, snap *models.Snapshot, docs map[string]string) error
+	GetSnapshot(ctx context.Context, name string) (*models.Snapshot, error)
+	ListSnapshots(ctx context.Context) ([]*models.Snapshot, error)
+	// SnapshotDocuments returns the content hashes of the snapshot's documents by ID.
+	SnapshotDocuments(ctx context.Context, name string) (map[string]string, error)
+	DeleteSnapshot(ctx context.Context, name string) error
+
+	// Audit log operations. The audit log is kept by Reset.
+	AppendAudit(ctx context.Context, entry *models.AuditEntry) error
+	// ListAudit returns the entries recorded at or after since and before until (zero
+	// times are unbounded), oldest first. A limit of 0 or less returns them all.
+	ListAudit(ctx context.Context, since, until time.Time, limit int) ([]*models.AuditEntry, error)
+
+	// Search analytics operations. Like the audit log, analytics are kept by Reset.
+	AppendSearchEvent(ctx context.Context, event *models.SearchEvent) error
+	// RecordClick notes that documentID, at 1-based rank, was opened from the search
+	// queryID. It returns ErrSearchEventNotFound for an unknown search.
+	RecordClick(ctx context.Context, queryID int64, documentID string, rank int) error
+	// RecordedSearch returns the search for query recorded closest before or at at, with
+	// the places of its results, for comparing a search now with; nil when there is none.
+	RecordedSearch(ctx context.Context, query string, at time.Time) (*models.SearchEvent, error)
+	// ListSearchEvents returns the searches recorded in [since, until), oldest first.
+	ListSearchEvents(ctx context.Context, since, until time.Time, limit int) ([]*models.SearchEvent, error)
+	// RecordOpen records that a result was opened for a query; every open is kept.
+	RecordOpen(ctx context.Context, event *models.OpenEvent) error
+	// ListOpenEvents returns the result opens recorded in [since, until), oldest first.
+	ListOpenEvents(ctx context.Context, since, until time.Time, limit int) ([]*models.OpenEvent, error)
+	// AnalyticsReport summarises the searches and result opens recorded in [since,
+	// until), listing up to limit top and zero-result queries and most opened documents.
+	AnalyticsReport(ctx context.Context, since, until time.Time, limit int) (*models.AnalyticsReport, error)
+
+	// Change log operations. The database records every document insert, update, and
+	// delete in the change log; a rebuilt database starts a new log with another ID.
+	ListChanges(ctx context.Context, after int64, limit int) ([]*models.ChangeLogEntry, error)
+	// ChangeLogHead returns the ID of the change log and the sequence number of its latest entry.
+	ChangeLogHead(ctx context.Context) (id string, seq int64, err error)
 
 	// Stats
 	CountDocuments(ctx context.Context) (int64, error)
 	CountChunks(ctx context.Context) (int64, error)
 
+	// Reset deletes all documents and chunks.
+	Reset(ctx context.Context) error
+
 	Close() error
 }
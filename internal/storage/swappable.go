@@ -0,0 +1,281 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// SwappableStorage wraps a Storage so it can be replaced while in use (e.g. by a rebuilt
+// database). Each call holds a read lock for its duration, so Swap waits for in-flight
+// calls to finish and callers never see a closed store.
+type SwappableStorage struct {
+	mu sync.RWMutex
+	s  Storage
+}
+
+// NewSwappableStorage wraps s.
+func NewSwappableStorage(s Storage) *SwappableStorage {
+	return &SwappableStorage{s: s}
+}
+
+// Swap calls fn with the current store while no other calls are running and installs the
+// store it returns. A non-nil store is installed even when fn also returns an error, so fn
+// can restore a usable store after a failed replacement.
+func (w *SwappableStorage) Swap(fn func(old Storage) (Storage, error)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	next, err := fn(w.s)
+	if next != nil {
+		w.s = next
+	}
+	return err
+}
+
+func (w *SwappableStorage) CreateDocument(ctx context.Context, doc *models.Document) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.CreateDocument(ctx, doc)
+}
+
+func (w *SwappableStorage) GetDocument(ctx context.Context, id string) (*models.Document, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.GetDocument(ctx, id)
+}
+
+func (w *SwappableStorage) UpdateDocument(ctx context.Context, doc *models.Document) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.UpdateDocument(ctx, doc)
+}
+
+func (w *SwappableStorage) DeleteDocument(ctx context.Context, id string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.DeleteDocument(ctx, id)
+}
+
+func (w *SwappableStorage) ListDocuments(ctx context.Context, offset, limit int) ([]*models.Document, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ListDocuments(ctx, offset, limit)
+}
+
+func (w *SwappableStorage) ListRecentDocuments(ctx context.Context, since time.Time, pathPrefix string, limit int) ([]*models.RecentDocument, int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ListRecentDocuments(ctx, since, pathPrefix, limit)
+}
+
+func (w *SwappableStorage) ListDocumentSummaries(ctx context.Context, filter models.DocumentListFilter, offset, limit int) ([]*models.DocumentSummary, int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ListDocumentSummaries(ctx, filter, offset, limit)
+}
+
+func (w *SwappableStorage) CreateChunk(ctx context.Context, chunk *models.DocumentChunk) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.CreateChunk(ctx, chunk)
+}
+
+func (w *SwappableStorage) GetChunksByDocumentID(ctx context.Context, docID string) ([]*models.DocumentChunk, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.GetChunksByDocumentID(ctx, docID)
+}
+
+func (w *SwappableStorage) GetChunk(ctx context.Context, id string) (*models.DocumentChunk, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.GetChunk(ctx, id)
+}
+
+func (w *SwappableStorage) DeleteChunksByDocumentID(ctx context.Context, docID string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.DeleteChunksByDocumentID(ctx, docID)
+}
+
+func (w *SwappableStorage) BatchCreateChunks(ctx context.Context, chunks []*models.DocumentChunk) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.BatchCreateChunks(ctx, chunks)
+}
+
+func (w *SwappableStorage) ListChanges(ctx context.Context, after int64, limit int) ([]*models.ChangeLogEntry, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ListChanges(ctx, after, limit)
+}
+
+func (w *SwappableStorage) ChangeLogHead(ctx context.Context) (string, int64, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ChangeLogHead(ctx)
+}
+
+func (w *SwappableStorage) CountDocuments(ctx context.Context) (int64, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.CountDocuments(ctx)
+}
+
+func (w *SwappableStorage) CountChunks(ctx context.Context) (int64, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.CountChunks(ctx)
+}
+
+func (w *SwappableStorage) CreatePin(ctx context.Context, pin *models.Pin) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.CreatePin(ctx, pin)
+}
+
+func (w *SwappableStorage) ListPins(ctx context.Context) ([]*models.Pin, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ListPins(ctx)
+}
+
+func (w *SwappableStorage) DeletePin(ctx context.Context, id string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.DeletePin(ctx, id)
+}
+
+func (w *SwappableStorage) CreateSnapshot(ctx context.Context, name string) (*models.Snapshot, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.CreateSnapshot(ctx, name)
+}
+
+func (w *SwappableStorage) ImportSnapshot(ctx context.Context, snap *models.Snapshot, docs map[string]string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ImportSnapshot(ctx, snap, docs)
+}
+
+func (w *SwappableStorage) GetSnapshot(ctx context.Context, name string) (*models.Snapshot, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.GetSnapshot(ctx, name)
+}
+
+func (w *SwappableStorage) ListSnapshots(ctx context.Context) ([]*models.Snapshot, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ListSnapshots(ctx)
+}
+
+func (w *SwappableStorage) SnapshotDocuments(ctx context.Context, name string) (map[string]string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.SnapshotDocuments(ctx, name)
+}
+
+func (w *SwappableStorage) DeleteSnapshot(ctx context.Context, name string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.DeleteSnapshot(ctx, name)
+}
+
+func (w *SwappableStorage) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.AppendAudit(ctx, entry)
+}
+
+func (w *SwappableStorage) ListAudit(ctx context.Context, since, until time.Time, limit int) ([]*models.AuditEntry, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ListAudit(ctx, since, until, limit)
+}
+
+func (w *SwappableStorage) BatchCreateDocuments(ctx context.Context, docs []*models.Document, chunks [][]*models.DocumentChunk) ([]error, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.BatchCreateDocuments(ctx, docs, chunks)
+}
+
+func (w *SwappableStorage) AppendSearchEvent(ctx context.Context, event *models.SearchEvent) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.AppendSearchEvent(ctx, event)
+}
+
+func (w *SwappableStorage) RecordClick(ctx context.Context, queryID int64, documentID string, rank int) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.RecordClick(ctx, queryID, documentID, rank)
+}
+
+func (w *SwappableStorage) RecordedSearch(ctx context.Context, query string, at time.Time) (*models.SearchEvent, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.RecordedSearch(ctx, query, at)
+}
+
+func (w *SwappableStorage) ListSearchEvents(ctx context.Context, since, until time.Time, limit int) ([]*models.SearchEvent, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ListSearchEvents(ctx, since, until, limit)
+}
+
+func (w *SwappableStorage) RecordOpen(ctx context.Context, event *models.OpenEvent) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.RecordOpen(ctx, event)
+}
+
+func (w *SwappableStorage) ListOpenEvents(ctx context.Context, since, until time.Time, limit int) ([]*models.OpenEvent, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.ListOpenEvents(ctx, since, until, limit)
+}
+
+func (w *SwappableStorage) AnalyticsReport(ctx context.Context, since, until time.Time, limit int) (*models.AnalyticsReport, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.AnalyticsReport(ctx, since, until, limit)
+}
+
+// SearchLiteral forwards to the current store, returning ErrLiteralSearchUnsupported
+// when it is not a LiteralSearcher.
+func (w *SwappableStorage) SearchLiteral(ctx context.Context, text string) ([]*models.LiteralMatch, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	searcher, ok := w.s.(LiteralSearcher)
+	if !ok {
+		return nil, ErrLiteralSearchUnsupported
+	}
+	return searcher.SearchLiteral(ctx, text)
+}
+
+// DocumentsAsOf forwards to the current store, returning ErrVersionHistoryDisabled when
+// it is not a VersionReader.
+func (w *SwappableStorage) DocumentsAsOf(ctx context.Context, t time.Time) ([]*models.Document, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	reader, ok := w.s.(VersionReader)
+	if !ok {
+		return nil, ErrVersionHistoryDisabled
+	}
+	return reader.DocumentsAsOf(ctx, t)
+}
+
+func (w *SwappableStorage) Reset(ctx context.Context) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.Reset(ctx)
+}
+
+func (w *SwappableStorage) Close() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.s.Close()
+}
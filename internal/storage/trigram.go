@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// ErrLiteralSearchUnsupported is returned by SearchLiteral when the wrapped storage
+// cannot search for literal text.
+var ErrLiteralSearchUnsupported = errors.New("literal search is not supported by this storage")
+
+// LiteralSearcher is implemented by storages that can find the documents containing a
+// string exactly, however the keyword index tokenizes it.
+type LiteralSearcher interface {
+	// SearchLiteral returns the documents whose title or content contains text,
+	// ignoring case, with the number of occurrences in each, most occurrences first.
+	SearchLiteral(ctx context.Context, text string) ([]*models.LiteralMatch, error)
+}
+
+// maxQueryTrigrams caps the trigrams of a literal looked up in the trigram index; the
+// candidates are checked against the whole text anyway.
+const maxQueryTrigrams = 32
+
+// trigramInsertRows is how many trigrams one INSERT statement adds.
+const trigramInsertRows = 400
+
+// WithTrigramIndex keeps an index of the three-character sequences of each document's
+// lowercased title and content, so SearchLiteral reads only the documents containing
+// all of a literal's trigrams instead of every document. The index is built for the
+// existing documents when first enabled, and dropped when opened without it.
+func WithTrigramIndex(enabled bool) SQLiteOption {
+	return func(o *sqliteOptions) { o.trigrams = enabled }
+}
+
+// initTrigrams creates the trigram index and fills it from the stored documents when
+// enabled and missing, or drops it when disabled. Deleted documents leave the index by
+// trigger, so DeleteDocument and Reset need not maintain it.
+func initTrigrams(ctx context.Context, db *sql.DB, enabled bool) error {
+	if !enabled {
+		_, err := db.ExecContext(ctx, `
+		DROP TRIGGER IF EXISTS documents_trigrams_delete;
+		DROP TABLE IF EXISTS document_trigrams;`)
+		return err
+	}
+	var exists int
+	if err := db.QueryRowContext(ctx,
+		`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'document_trigrams'`,
+	).Scan(&exists); err != nil {
+		return err
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `
+	CREATE TABLE document_trigrams (
+		trigram TEXT NOT NULL,
+		document_id TEXT NOT NULL,
+		PRIMARY KEY (trigram, document_id)
+	) WITHOUT ROWID;
+	CREATE INDEX idx_document_trigrams_document_id ON document_trigrams(document_id);
+	CREATE TRIGGER documents_trigrams_delete AFTER DELETE ON documents BEGIN
+		DELETE FROM document_trigrams WHERE document_id = OLD.id;
+	END;`); err != nil {
+		return err
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT id, title, content FROM documents`)
+	if err != nil {
+		return err
+	}
+	docs := make(map[string]string)
+	for rows.Next() {
+		var id, title, content string
+		if err := rows.Scan(&id, &title, &content); err != nil {
+			rows.Close()
+			return err
+		}
+		if content, err = decompressContent(content); err != nil {
+			rows.Close()
+			return err
+		}
+		docs[id] = title + "\n" + content
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, text := range docs {
+		if err := insertTrigrams(ctx, tx, id, text); err != nil {
+			return fmt.Errorf("failed to index trigrams of %s: %w", id, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// trigrams returns the distinct three-character sequences of the lowercased text.
+func trigrams(text string) []string {
+	runes := []rune(strings.ToLower(text))
+	seen := make(map[string]struct{})
+	var out []string
+	for i := 0; i+3 <= len(runes); i++ {
+		t := string(runes[i : i+3])
+		if _, ok := seen[t]; !ok {
+			seen[t] = struct{}{}
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// insertTrigrams adds the trigrams of text to the index under id.
+func insertTrigrams(ctx context.Context, tx *sql.Tx, id, text string) error {
+	tgs := trigrams(text)
+	for len(tgs) > 0 {
+		n := min(len(tgs), trigramInsertRows)
+		args := make([]interface{}, 0, 2*n)
+		for _, t := range tgs[:n] {
+			args = append(args, t, id)
+		}
+		query := `INSERT OR IGNORE INTO document_trigrams (trigram, document_id) VALUES ` +
+			strings.TrimSuffix(strings.Repeat("(?, ?),", n), ",")
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return err
+		}
+		tgs = tgs[n:]
+	}
+	return nil
+}
+
+// reindexTrigrams replaces the trigrams of document id with those of its title and content.
+func reindexTrigrams(ctx context.Context, tx *sql.Tx, id, title, content string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM document_trigrams WHERE document_id = ?`, id); err != nil {
+		return err
+	}
+	return insertTrigrams(ctx, tx, id, title+"\n"+content)
+}
+
+// SearchLiteral returns the documents whose title or content contains text, ignoring
+// case. With the trigram index, only the documents containing the trigrams of text are
+// read; without it, or for text shorter than three characters, every document is.
+func (s *SQLiteStorage) SearchLiteral(ctx context.Context, text string) ([]*models.LiteralMatch, error) {
+	needle := strings.ToLower(text)
+	if needle == "" {
+		return nil, nil
+	}
+	var rows *sql.Rows
+	var err error
+	if tgs := trigrams(needle); s.trigrams && len(tgs) > 0 {
+		if len(tgs) > maxQueryTrigrams {
+			tgs = tgs[:maxQueryTrigrams]
+		}
+		args := make([]interface{}, 0, len(tgs)+1)
+		for _, t := range tgs {
+			args = append(args, t)
+		}
+		args = append(args, len(tgs))
+		rows, err = s.db.QueryContext(ctx,
+			`SELECT id, title, content FROM documents WHERE id IN (
+			   SELECT document_id FROM document_trigrams
+			   WHERE trigram IN (`+strings.TrimSuffix(strings.Repeat("?,", len(tgs)), ",")+`)
+			   GROUP BY document_id HAVING count(*) = ?)`, args...)
+	} else {
+		rows, err = s.db.QueryContext(ctx, `SELECT id, title, content FROM documents`)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []*models.LiteralMatch
+	for rows.Next() {
+		var id, title, content string
+		if err := rows.Scan(&id, &title, &content); err != nil {
+			return nil, err
+		}
+		if content, err = decompressContent(content); err != nil {
+			return nil, err
+		}
+		m := &models.LiteralMatch{
+			DocumentID:   id,
+			TitleCount:   countFold(title, needle),
+			ContentCount: countFold(content, needle),
+		}
+		if m.TitleCount > 0 || m.ContentCount > 0 {
+			matches = append(matches, m)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if n, m := a.TitleCount+a.ContentCount, b.TitleCount+b.ContentCount; n != m {
+			return n > m
+		}
+		return a.DocumentID < b.DocumentID
+	})
+	return matches, nil
+}
+
+// countFold returns the number of non-overlapping occurrences of the lowercased needle
+// in s, ignoring case.
+func countFold(s, needle string) int {
+	return strings.Count(strings.ToLower(s), needle)
+}
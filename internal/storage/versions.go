@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// ErrVersionHistoryDisabled is returned by DocumentsAsOf when the storage does not keep
+// the versions of documents.
+var ErrVersionHistoryDisabled = errors.New("document version history is not enabled (storage.sqlite.version_history)")
+
+// VersionReader is implemented by storages that keep the versions of documents replaced
+// or deleted, so past content can be searched.
+type VersionReader interface {
+	// DocumentsAsOf returns the documents as they were at t: the current documents last
+	// written by then, and the kept versions of those replaced or deleted since.
+	DocumentsAsOf(ctx context.Context, t time.Time) ([]*models.Document, error)
+}
+
+// WithVersionHistory keeps the title, content and metadata a document had each time it
+// is updated or deleted, including when a changed file is re-indexed (which replaces its
+// document) and when the storage is reset for a full reindex. Versions are kept until
+// the database is rebuilt; they grow it by the size of every version replaced.
+func WithVersionHistory(enabled bool) SQLiteOption {
+	return func(o *sqliteOptions) { o.versions = enabled }
+}
+
+// archiveVersions keeps the documents matching where (a WHERE clause, or empty for all)
+// as versions replaced at now, before they are updated or deleted in tx. now is the time
+// of the write, from the clock that sets updated_at, so a version ends exactly where the
+// next one starts.
+func archiveVersions(ctx context.Context, tx *sql.Tx, now time.Time, where string, args ...interface{}) error {
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO document_versions (document_id, title, content, metadata, created_at, updated_at, replaced_at)
+		 SELECT id, title, content, metadata, created_at, updated_at, ? FROM documents `+where,
+		append([]interface{}{now.UnixNano()}, args...)...,
+	)
+	return err
+}
+
+// DocumentsAsOf returns the documents as they were at t, sorted by ID. Each document's
+// UpdatedAt is when that version was written, and each version was valid from then until
+// it was replaced. Every document is read, so this is meant for occasional audits rather
+// than regular queries.
+func (s *SQLiteStorage) DocumentsAsOf(ctx context.Context, t time.Time) ([]*models.Document, error) {
+	if !s.versions {
+		return nil, ErrVersionHistoryDisabled
+	}
+	docs := make(map[string]*models.Document)
+	if err := s.collectAsOf(ctx, docs, t,
+		`SELECT id, title, content, metadata, created_at, updated_at FROM documents`,
+	); err != nil {
+		return nil, err
+	}
+	if err := s.collectAsOf(ctx, docs, t,
+		`SELECT document_id, title, content, metadata, created_at, updated_at
+		 FROM document_versions WHERE replaced_at > ?`, t.UnixNano(),
+	); err != nil {
+		return nil, err
+	}
+	out := make([]*models.Document, 0, len(docs))
+	for _, doc := range docs {
+		out = append(out, doc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out, nil
+}
+
+// collectAsOf adds the rows of query written at or before t to docs, keeping a document
+// already there. A document's versions do not overlap in time, so at most one matches.
+func (s *SQLiteStorage) collectAsOf(ctx context.Context, docs map[string]*models.Document, t time.Time, query string, args ...interface{}) error {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var doc models.Document
+		var metadataJSON sql.NullString
+		if err := rows.Scan(&doc.ID, &doc.Title, &doc.Content, &metadataJSON, &doc.CreatedAt, &doc.UpdatedAt); err != nil {
+			return err
+		}
+		if doc.UpdatedAt.After(t) || docs[doc.ID] != nil {
+			continue
+		}
+		if doc.Content, err = decompressContent(doc.Content); err != nil {
+			return err
+		}
+		if metadataJSON.String != "" {
+			_ = json.Unmarshal([]byte(metadataJSON.String), &doc.Metadata)
+		}
+		docs[doc.ID] = &doc
+	}
+	return rows.Err()
+}
@@ -0,0 +1,121 @@
+// Package tray provides the menu bar / system tray companion: indexing activity, a quick
+// search box, and pause/resume, all backed by the HTTP API of a running server.
+package tray
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+// Client calls the endpoints of the Sagasu HTTP API that the tray uses.
+type Client struct {
+	BaseURL string
+	// APIKey is sent as a bearer token when the server requires one.
+	APIKey string
+	HTTP   *http.Client
+}
+
+// NewClient creates a client for the server at baseURL (e.g. http://localhost:8080).
+func NewClient(baseURL string) *Client {
+	return &Client{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		HTTP:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Status is the part of GET /api/v1/status the tray shows.
+type Status struct {
+	Documents int64          `json:"documents"`
+	Chunks    int64          `json:"chunks"`
+	Paused    bool           `json:"paused"`
+	Jobs      map[string]int `json:"jobs,omitempty"` // job counts by status
+}
+
+// Activity summarizes the indexing state in one line, e.g. "Indexing (3 queued)".
+func (s *Status) Activity() string {
+	pending := s.Jobs["queued"] + s.Jobs["running"]
+	switch {
+	case s.Paused && pending > 0:
+		return fmt.Sprintf("Paused (%d queued)", pending)
+	case s.Paused:
+		return "Paused"
+	case pending > 0:
+		return fmt.Sprintf("Indexing (%d queued)", pending)
+	default:
+		return fmt.Sprintf("Idle: %d documents", s.Documents)
+	}
+}
+
+// Status fetches the server status.
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var s Status
+	if err := c.do(ctx, http.MethodGet, "/api/v1/status", nil, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Search runs a hybrid search for query.
+func (c *Client) Search(ctx context.Context, query string, limit int) (*models.SearchResponse, error) {
+	q := &models.SearchQuery{Query: query, Limit: limit, KeywordEnabled: true, SemanticEnabled: true}
+	var resp models.SearchResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/search", q, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SetPaused pauses or resumes indexing.
+func (c *Client) SetPaused(ctx context.Context, paused bool) error {
+	path := "/api/v1/resume"
+	if paused {
+		path = "/api/v1/pause"
+	}
+	return c.do(ctx, http.MethodPost, path, nil, nil)
+}
+
+// do sends a request with an optional JSON body and decodes the JSON response into out.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, r)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("server returned %d: %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
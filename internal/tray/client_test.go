@@ -0,0 +1,89 @@
+package tray
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperjump/sagasu/internal/models"
+)
+
+func TestClient(t *testing.T) {
+	paused := false
+	var searched models.SearchQuery
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/status", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"documents": 7, "paused": paused, "jobs": map[string]int{"queued": 2, "completed": 5},
+		})
+	})
+	mux.HandleFunc("POST /api/v1/pause", func(w http.ResponseWriter, r *http.Request) { paused = true })
+	mux.HandleFunc("POST /api/v1/resume", func(w http.ResponseWriter, r *http.Request) { paused = false })
+	mux.HandleFunc("POST /api/v1/search", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&searched)
+		_ = json.NewEncoder(w).Encode(models.SearchResponse{Query: searched.Query})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	client := NewClient(srv.URL + "/")
+	status, err := client.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := status.Activity(); got != "Indexing (2 queued)" {
+		t.Errorf("activity: got %q", got)
+	}
+	if err := client.SetPaused(ctx, true); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ = client.Status(ctx); status.Activity() != "Paused (2 queued)" {
+		t.Errorf("activity after pause: got %q", status.Activity())
+	}
+	if err := client.SetPaused(ctx, false); err != nil || paused {
+		t.Errorf("resume: err %v, paused %v", err, paused)
+	}
+
+	resp, err := client.Search(ctx, "budget", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Query != "budget" || searched.Limit != 5 || !searched.KeywordEnabled || !searched.SemanticEnabled {
+		t.Errorf("search: got query %+v", searched)
+	}
+
+	srv.Close()
+	if _, err := client.Status(ctx); err == nil {
+		t.Error("expected error when the server is down")
+	}
+}
+
+func TestStatusActivity_idle(t *testing.T) {
+	s := &Status{Documents: 3, Jobs: map[string]int{"completed": 4}}
+	if got := s.Activity(); got != "Idle: 3 documents" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestClient_apiKey(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tray-key" {
+			http.Error(w, `{"error":"missing or invalid API key"}`, http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"documents": 1}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(srv.URL)
+	if _, err := client.Status(context.Background()); err == nil {
+		t.Error("expected 401 without a key")
+	}
+	client.APIKey = "tray-key"
+	if _, err := client.Status(context.Background()); err != nil {
+		t.Errorf("with key: %v", err)
+	}
+}
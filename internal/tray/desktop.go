@@ -0,0 +1,50 @@
+package tray
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// errNoPrompt is returned when no dialog tool is available to ask for a query.
+var errNoPrompt = errors.New("no dialog tool found (install zenity or kdialog)")
+
+// promptCommands returns the dialog commands that ask for a search query on goos, in
+// order of preference. Each prints the entered text and exits non-zero on cancel.
+func promptCommands(goos string) [][]string {
+	switch goos {
+	case "darwin":
+		return [][]string{{"osascript", "-e",
+			`text returned of (display dialog "Search:" default answer "" with title "Sagasu")`}}
+	case "windows":
+		return [][]string{{"powershell", "-NoProfile", "-Command",
+			`Add-Type -AssemblyName Microsoft.VisualBasic; [Microsoft.VisualBasic.Interaction]::InputBox('Search:', 'Sagasu')`}}
+	default:
+		return [][]string{
+			{"zenity", "--entry", "--title=Sagasu", "--text=Search:"},
+			{"kdialog", "--title", "Sagasu", "--inputbox", "Search:"},
+		}
+	}
+}
+
+// promptQuery asks for a search query with the platform's dialog tool. ok is false when
+// the dialog was cancelled or left empty.
+func promptQuery() (query string, ok bool, err error) {
+	for _, args := range promptCommands(runtime.GOOS) {
+		if _, lookErr := exec.LookPath(args[0]); lookErr != nil {
+			continue
+		}
+		out, runErr := exec.Command(args[0], args[1:]...).Output()
+		if runErr != nil {
+			var exitErr *exec.ExitError
+			if errors.As(runErr, &exitErr) {
+				return "", false, nil // cancelled
+			}
+			return "", false, runErr
+		}
+		query = strings.TrimSpace(string(out))
+		return query, query != "", nil
+	}
+	return "", false, errNoPrompt
+}
@@ -0,0 +1,54 @@
+package tray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"runtime"
+)
+
+// iconSize is the tray icon's width and height in pixels.
+const iconSize = 32
+
+// icon draws the tray icon, a magnifying glass, as PNG (or as an ICO wrapping the PNG on
+// Windows, which requires that format). On macOS it is used as a template image, so only
+// its alpha channel matters.
+func icon() []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, iconSize, iconSize))
+	ink := color.NRGBA{A: 255}
+	const cx, cy, r, stroke = 13.0, 13.0, 9.0, 3.0
+	for y := 0; y < iconSize; y++ {
+		for x := 0; x < iconSize; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			ring := math.Abs(math.Hypot(px-cx, py-cy) - r)
+			// The handle runs diagonally from the lens toward the bottom-right corner.
+			along := (px - cx + py - cy) / math.Sqrt2
+			across := math.Abs(px-cx-(py-cy)) / math.Sqrt2
+			handle := along > r && along < r+11 && across < stroke
+			if ring < stroke/2 || handle {
+				img.SetNRGBA(x, y, ink)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	if runtime.GOOS == "windows" {
+		return wrapICO(buf.Bytes())
+	}
+	return buf.Bytes()
+}
+
+// wrapICO returns a single-image ICO file holding the PNG data.
+func wrapICO(pngData []byte) []byte {
+	var buf bytes.Buffer
+	header := []uint16{0, 1, 1} // reserved, type icon, one image
+	_ = binary.Write(&buf, binary.LittleEndian, header)
+	buf.Write([]byte{iconSize, iconSize, 0, 0}) // width, height, palette size, reserved
+	_ = binary.Write(&buf, binary.LittleEndian, []uint16{1, 32})
+	_ = binary.Write(&buf, binary.LittleEndian, []uint32{uint32(len(pngData)), 6 + 16})
+	buf.Write(pngData)
+	return buf.Bytes()
+}
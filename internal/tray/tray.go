@@ -0,0 +1,205 @@
+package tray
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"fyne.io/systray"
+	"github.com/hyperjump/sagasu/internal/cli"
+	"github.com/hyperjump/sagasu/internal/desktop"
+)
+
+// Options configures the tray.
+type Options struct {
+	// PollInterval is how often the status is refreshed (default 3s).
+	PollInterval time.Duration
+	// ResultLimit is the most search results listed in the menu (default 10).
+	ResultLimit int
+}
+
+// Run shows the tray icon and its menu and blocks until Quit is chosen. The menu shows
+// the server's indexing activity, runs quick searches (asking for the query with the
+// platform's dialog tool and listing the result files, which open on click), and pauses
+// or resumes indexing.
+func Run(client *Client, opts Options) {
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 3 * time.Second
+	}
+	if opts.ResultLimit <= 0 {
+		opts.ResultLimit = 10
+	}
+	t := &tray{client: client, opts: opts}
+	systray.Run(t.onReady, nil)
+}
+
+// tray holds the menu items and the state they show.
+type tray struct {
+	client *Client
+	opts   Options
+
+	status  *systray.MenuItem
+	search  *systray.MenuItem
+	results []*systray.MenuItem
+	pause   *systray.MenuItem
+	quit    *systray.MenuItem
+
+	mu          sync.Mutex
+	paused      bool
+	resultPaths []string
+}
+
+func (t *tray) onReady() {
+	systray.SetTemplateIcon(icon(), icon())
+	systray.SetTooltip("Sagasu")
+
+	t.status = systray.AddMenuItem("Connecting...", "Indexing activity")
+	t.status.Disable()
+	systray.AddSeparator()
+	t.search = systray.AddMenuItem("Search...", "Search indexed files")
+	for i := 0; i < t.opts.ResultLimit; i++ {
+		item := systray.AddMenuItem("", "")
+		item.Hide()
+		t.results = append(t.results, item)
+		go t.openOnClick(i, item)
+	}
+	systray.AddSeparator()
+	t.pause = systray.AddMenuItem("Pause indexing", "Stop indexing changes until resumed")
+	t.quit = systray.AddMenuItem("Quit", "Close the tray (the server keeps running)")
+
+	go t.loop()
+}
+
+// loop refreshes the status on a timer and handles the menu items until Quit.
+func (t *tray) loop() {
+	ticker := time.NewTicker(t.opts.PollInterval)
+	defer ticker.Stop()
+	t.refresh()
+	for {
+		select {
+		case <-ticker.C:
+			t.refresh()
+		case <-t.search.ClickedCh:
+			t.runSearch()
+		case <-t.pause.ClickedCh:
+			t.togglePause()
+		case <-t.quit.ClickedCh:
+			systray.Quit()
+			return
+		}
+	}
+}
+
+// refresh shows the server's current activity, or that it cannot be reached.
+func (t *tray) refresh() {
+	ctx, cancel := context.WithTimeout(context.Background(), t.opts.PollInterval)
+	defer cancel()
+	status, err := t.client.Status(ctx)
+	if err != nil {
+		t.status.SetTitle("Server not reachable")
+		systray.SetTooltip("Sagasu: server not reachable at " + t.client.BaseURL)
+		t.search.Disable()
+		t.pause.Disable()
+		return
+	}
+	activity := status.Activity()
+	t.status.SetTitle(activity)
+	systray.SetTooltip("Sagasu: " + activity)
+	t.search.Enable()
+	t.pause.Enable()
+	t.setPaused(status.Paused)
+}
+
+// setPaused labels the pause item for the current state.
+func (t *tray) setPaused(paused bool) {
+	t.mu.Lock()
+	t.paused = paused
+	t.mu.Unlock()
+	if paused {
+		t.pause.SetTitle("Resume indexing")
+	} else {
+		t.pause.SetTitle("Pause indexing")
+	}
+}
+
+func (t *tray) togglePause() {
+	t.mu.Lock()
+	paused := !t.paused
+	t.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := t.client.SetPaused(ctx, paused); err != nil {
+		t.status.SetTitle("Pause failed: " + err.Error())
+		return
+	}
+	t.setPaused(paused)
+	t.refresh()
+}
+
+// runSearch asks for a query and lists the matching files below the search item.
+func (t *tray) runSearch() {
+	query, ok, err := promptQuery()
+	if err != nil {
+		t.showResults(nil, "Search unavailable: "+err.Error())
+		return
+	}
+	if !ok {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	resp, err := t.client.Search(ctx, query, t.opts.ResultLimit)
+	if err != nil {
+		t.showResults(nil, "Search failed: "+err.Error())
+		return
+	}
+	paths := cli.ResultFilePaths(resp)
+	if len(paths) > t.opts.ResultLimit {
+		paths = paths[:t.opts.ResultLimit]
+	}
+	note := ""
+	if len(paths) == 0 {
+		note = fmt.Sprintf("No files match %q", query)
+	}
+	t.showResults(paths, note)
+}
+
+// showResults fills the result items with paths, or shows note in the first one.
+func (t *tray) showResults(paths []string, note string) {
+	t.mu.Lock()
+	t.resultPaths = paths
+	t.mu.Unlock()
+	for i, item := range t.results {
+		switch {
+		case i < len(paths):
+			item.SetTitle("    " + filepath.Base(paths[i]))
+			item.SetTooltip(paths[i])
+			item.Enable()
+			item.Show()
+		case i == 0 && note != "":
+			item.SetTitle("    " + note)
+			item.SetTooltip("")
+			item.Disable()
+			item.Show()
+		default:
+			item.Hide()
+		}
+	}
+}
+
+// openOnClick opens the file shown in result item i each time it is clicked.
+func (t *tray) openOnClick(i int, item *systray.MenuItem) {
+	for range item.ClickedCh {
+		t.mu.Lock()
+		var path string
+		if i < len(t.resultPaths) {
+			path = t.resultPaths[i]
+		}
+		t.mu.Unlock()
+		if path != "" {
+			_ = desktop.Open(path)
+		}
+	}
+}
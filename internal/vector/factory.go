@@ -1,7 +1,10 @@
 // Package vector provides vector index implementations and a factory for creating them.
 package vector
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+)
 
 // IndexType represents the type of vector index to use.
 type IndexType string
@@ -12,19 +15,71 @@ const (
 	// IndexTypeFAISS uses FAISS for efficient ANN search. Good for large datasets.
 	// Requires FAISS library and build tag -tags=faiss.
 	IndexTypeFAISS IndexType = "faiss"
+	// IndexTypeHNSW uses a pure Go HNSW graph for approximate search. Good for large
+	// datasets when FAISS is not available.
+	IndexTypeHNSW IndexType = "hnsw"
+	// IndexTypeQdrant keeps vectors in a collection of a Qdrant server, so large corpora
+	// and several machines can share one index without a FAISS build.
+	IndexTypeQdrant IndexType = "qdrant"
 )
 
+// indexOptions holds settings for index types that take parameters.
+type indexOptions struct {
+	hnswM, hnswEfConstruction, hnswEfSearch int
+	quantization                            string
+	refineFactor                            int
+	qdrant                                  QdrantConfig
+}
+
+// IndexOption configures NewVectorIndex.
+type IndexOption func(*indexOptions)
+
+// WithHNSWParams sets the HNSW neighbors per node (m) and candidate list sizes for
+// insertion and search. Zero values use the defaults.
+func WithHNSWParams(m, efConstruction, efSearch int) IndexOption {
+	return func(o *indexOptions) {
+		o.hnswM, o.hnswEfConstruction, o.hnswEfSearch = m, efConstruction, efSearch
+	}
+}
+
+// WithQuantization makes the memory index keep quantized codes ("int8" or "pq") in memory
+// instead of full vectors, re-scoring the best refineFactor*k candidates exactly.
+// An empty kind keeps full vectors.
+func WithQuantization(kind string, refineFactor int) IndexOption {
+	return func(o *indexOptions) {
+		o.quantization, o.refineFactor = kind, refineFactor
+	}
+}
+
+// WithQdrant sets the server and collection of a qdrant index.
+func WithQdrant(cfg QdrantConfig) IndexOption {
+	return func(o *indexOptions) {
+		o.qdrant = cfg
+	}
+}
+
 // NewVectorIndex creates a vector index of the specified type.
-// Supported types: "memory" (default), "faiss".
+// Supported types: "memory" (default), "faiss", "hnsw", "qdrant".
 // FAISS requires building with -tags=faiss and having FAISS library installed.
-func NewVectorIndex(indexType string, dimensions int) (VectorIndex, error) {
+func NewVectorIndex(indexType string, dimensions int, opts ...IndexOption) (VectorIndex, error) {
+	var o indexOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
 	switch IndexType(indexType) {
 	case IndexTypeMemory, "":
+		if o.quantization != "" {
+			return NewQuantizedIndex(dimensions, o.quantization, o.refineFactor)
+		}
 		return NewMemoryIndex(dimensions)
 	case IndexTypeFAISS:
 		return NewFAISSIndex(dimensions)
+	case IndexTypeHNSW:
+		return NewHNSWIndex(dimensions, o.hnswM, o.hnswEfConstruction, o.hnswEfSearch)
+	case IndexTypeQdrant:
+		return NewQdrantIndex(context.Background(), o.qdrant, dimensions)
 	default:
-		return nil, fmt.Errorf("unknown index type: %s (supported: memory, faiss)", indexType)
+		return nil, fmt.Errorf("unknown index type: %s (supported: memory, faiss, hnsw, qdrant)", indexType)
 	}
 }
 
@@ -78,3 +78,69 @@ func TestNewVectorIndex_FAISS(t *testing.T) {
 		t.Errorf("Size=%d, want 1", idx.Size())
 	}
 }
+
+func TestNewVectorIndex_HNSW(t *testing.T) {
+	idx, err := NewVectorIndex("hnsw", 3, WithHNSWParams(8, 50, 20))
+	if err != nil {
+		t.Fatalf("NewVectorIndex(hnsw): %v", err)
+	}
+	defer idx.Close()
+
+	h, ok := idx.(*HNSWIndex)
+	if !ok {
+		t.Fatalf("expected *HNSWIndex, got %T", idx)
+	}
+	if h.m != 8 || h.efConstruction != 50 || h.efSearch != 20 {
+		t.Errorf("params = %d/%d/%d, want 8/50/20", h.m, h.efConstruction, h.efSearch)
+	}
+	if idx.Type() != "hnsw" {
+		t.Errorf("Type()=%q, want hnsw", idx.Type())
+	}
+}
+
+func TestNewVectorIndex_Quantized(t *testing.T) {
+	idx, err := NewVectorIndex("memory", 8, WithQuantization("int8", 4))
+	if err != nil {
+		t.Fatalf("NewVectorIndex(memory, int8): %v", err)
+	}
+	defer idx.Close()
+	if _, ok := idx.(*QuantizedIndex); !ok {
+		t.Fatalf("expected *QuantizedIndex, got %T", idx)
+	}
+	if _, err := NewVectorIndex("memory", 8, WithQuantization("int4", 4)); err == nil {
+		t.Error("expected error for unknown quantization")
+	}
+}
+
+func TestVectorGetter(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		name string
+		opts []IndexOption
+		typ  string
+	}{
+		{"memory", nil, "memory"},
+		{"hnsw", nil, "hnsw"},
+		{"int8", []IndexOption{WithQuantization(QuantizationInt8, 4)}, "memory"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			idx, err := NewVectorIndex(tc.typ, 3, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer idx.Close()
+			w := NewSwappableIndex(idx)
+			if err := w.Add(ctx, []string{"a", "b"}, [][]float32{{1, 0, 0}, {0, 0.6, 0.8}}); err != nil {
+				t.Fatal(err)
+			}
+			got, ok := w.Vector("b")
+			if !ok || len(got) != 3 || got[1] != 0.6 || got[2] != 0.8 {
+				t.Errorf("Vector(b) = %v, %v", got, ok)
+			}
+			_ = w.Remove(ctx, []string{"b"})
+			if _, ok := w.Vector("b"); ok {
+				t.Error("Vector(b) found after Remove")
+			}
+		})
+	}
+}
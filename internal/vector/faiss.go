@@ -194,6 +194,20 @@ func (f *FAISSIndex) Remove(ctx context.Context, ids []string) error {
 	return nil
 }
 
+// Reset removes all vectors from the FAISS index and clears the ID mappings.
+func (f *FAISSIndex) Reset() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if ret := C.faiss_Index_reset(f.index); ret != 0 {
+		return fmt.Errorf("failed to reset FAISS index: %s", faissLastError())
+	}
+	f.idToIntID = make(map[string]int64)
+	f.intIDToID = make(map[int64]string)
+	f.nextID = 0
+	return nil
+}
+
 // faissIDMapping stores the ID mapping for persistence.
 type faissIDMapping struct {
 	IDToIntID map[string]int64
@@ -33,6 +33,11 @@ func (f *FAISSIndex) Remove(ctx context.Context, ids []string) error {
 	return fmt.Errorf("FAISS not available")
 }
 
+// Reset is not implemented without FAISS.
+func (f *FAISSIndex) Reset() error {
+	return fmt.Errorf("FAISS not available")
+}
+
 // Save is not implemented without FAISS.
 func (f *FAISSIndex) Save(path string) error {
 	return fmt.Errorf("FAISS not available")
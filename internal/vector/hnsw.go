@@ -0,0 +1,536 @@
+package vector
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Default HNSW parameters.
+const (
+	DefaultHNSWM              = 16
+	DefaultHNSWEfConstruction = 200
+	DefaultHNSWEfSearch       = 64
+)
+
+// hnswMagic starts a saved HNSW index file.
+const hnswMagic = "HNSW1"
+
+// HNSWIndex is a pure Go approximate nearest neighbor index using a Hierarchical Navigable
+// Small World graph, scored by inner product (cosine similarity for normalized vectors).
+// Search is sub-linear in the number of vectors, unlike MemoryIndex.
+//
+// Removed vectors are tombstoned: they stay in the graph for navigation but are never
+// returned. Once more than half the nodes are tombstones, the graph is rebuilt from the
+// live vectors. Adding an ID that is already present replaces its vector.
+type HNSWIndex struct {
+	dimensions     int
+	m              int // neighbors per node on upper layers; 2*m on layer 0
+	efConstruction int
+	efSearch       int
+	levelMult      float64
+
+	mu       sync.RWMutex
+	nodes    []*hnswNode
+	byID     map[string]int32
+	entry    int32 // -1 when empty
+	maxLevel int
+	deleted  int
+	rng      *rand.Rand
+}
+
+type hnswNode struct {
+	id        string
+	vector    []float32
+	neighbors [][]int32 // per layer, 0..level
+	deleted   bool
+}
+
+// NewHNSWIndex creates an HNSW index with the given dimension. m is the number of
+// neighbors per node, efConstruction the candidate list size while inserting, and
+// efSearch the candidate list size while searching (raised to k when smaller).
+// Zero values use the defaults.
+func NewHNSWIndex(dimensions, m, efConstruction, efSearch int) (*HNSWIndex, error) {
+	if dimensions <= 0 {
+		return nil, fmt.Errorf("dimensions must be positive")
+	}
+	if m <= 0 {
+		m = DefaultHNSWM
+	}
+	if m < 2 {
+		return nil, fmt.Errorf("hnsw m must be at least 2")
+	}
+	if efConstruction <= 0 {
+		efConstruction = DefaultHNSWEfConstruction
+	}
+	if efSearch <= 0 {
+		efSearch = DefaultHNSWEfSearch
+	}
+	return &HNSWIndex{
+		dimensions:     dimensions,
+		m:              m,
+		efConstruction: efConstruction,
+		efSearch:       efSearch,
+		levelMult:      1 / math.Log(float64(m)),
+		byID:           make(map[string]int32),
+		entry:          -1,
+		rng:            rand.New(rand.NewSource(1)),
+	}, nil
+}
+
+// Type returns the index type identifier.
+func (h *HNSWIndex) Type() string {
+	return string(IndexTypeHNSW)
+}
+
+// Add inserts vectors with the given IDs, replacing any existing vector with the same ID.
+func (h *HNSWIndex) Add(ctx context.Context, ids []string, vectors [][]float32) error {
+	if len(ids) != len(vectors) {
+		return fmt.Errorf("ids and vectors length mismatch")
+	}
+	for _, v := range vectors {
+		if len(v) != h.dimensions {
+			return fmt.Errorf("vector dimension mismatch: got %d, expected %d", len(v), h.dimensions)
+		}
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for i, id := range ids {
+		h.removeLocked(id)
+		vec := make([]float32, h.dimensions)
+		copy(vec, vectors[i])
+		h.insertLocked(id, vec)
+	}
+	h.compactIfNeededLocked()
+	return nil
+}
+
+// Search returns the top-k vectors by inner product.
+func (h *HNSWIndex) Search(ctx context.Context, query []float32, k int) ([]*VectorResult, error) {
+	if len(query) != h.dimensions {
+		return nil, fmt.Errorf("query dimension mismatch: got %d, expected %d", len(query), h.dimensions)
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if k <= 0 || h.entry < 0 || len(h.byID) == 0 {
+		return nil, nil
+	}
+	ef := h.efSearch
+	if ef < k {
+		ef = k
+	}
+	// Tombstones take up candidate slots, so widen the search in proportion.
+	if h.deleted > 0 {
+		ef = ef * len(h.nodes) / len(h.byID)
+	}
+	ep := h.greedyDescend(query, h.entry, h.maxLevel, 1)
+	found := h.searchLayer(query, []int32{ep}, ef, 0)
+	results := make([]*VectorResult, 0, k)
+	for _, c := range found {
+		n := h.nodes[c.node]
+		if n.deleted {
+			continue
+		}
+		results = append(results, &VectorResult{ID: n.id, Score: c.score})
+		if len(results) == k {
+			break
+		}
+	}
+	return results, nil
+}
+
+// Remove tombstones the vectors with the given IDs.
+func (h *HNSWIndex) Remove(ctx context.Context, ids []string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, id := range ids {
+		h.removeLocked(id)
+	}
+	h.compactIfNeededLocked()
+	return nil
+}
+
+// Vector returns a copy of the live vector stored under id.
+func (h *HNSWIndex) Vector(id string) ([]float32, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	i, ok := h.byID[id]
+	if !ok {
+		return nil, false
+	}
+	return append([]float32(nil), h.nodes[i].vector...), true
+}
+
+// IDs returns the IDs of the live vectors.
+func (h *HNSWIndex) IDs() ([]string, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	ids := make([]string, 0, len(h.byID))
+	for id := range h.byID {
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+// Reset removes all vectors from the index.
+func (h *HNSWIndex) Reset() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.resetLocked()
+	return nil
+}
+
+// Size returns the number of live vectors.
+func (h *HNSWIndex) Size() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.byID)
+}
+
+// Close is a no-op for HNSWIndex.
+func (h *HNSWIndex) Close() error {
+	return nil
+}
+
+func (h *HNSWIndex) resetLocked() {
+	h.nodes = nil
+	h.byID = make(map[string]int32)
+	h.entry = -1
+	h.maxLevel = 0
+	h.deleted = 0
+}
+
+func (h *HNSWIndex) removeLocked(id string) {
+	if i, ok := h.byID[id]; ok {
+		h.nodes[i].deleted = true
+		delete(h.byID, id)
+		h.deleted++
+	}
+}
+
+// compactIfNeededLocked rebuilds the graph from the live vectors once tombstones make up
+// more than half of it.
+func (h *HNSWIndex) compactIfNeededLocked() {
+	if h.deleted*2 > len(h.nodes) {
+		h.rebuildLocked()
+	}
+}
+
+// rebuildLocked rebuilds the graph from the live vectors, dropping tombstones.
+func (h *HNSWIndex) rebuildLocked() {
+	live := make([]*hnswNode, 0, len(h.byID))
+	for _, n := range h.nodes {
+		if !n.deleted {
+			live = append(live, n)
+		}
+	}
+	h.resetLocked()
+	for _, n := range live {
+		h.insertLocked(n.id, n.vector)
+	}
+}
+
+func (h *HNSWIndex) randomLevel() int {
+	return int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelMult))
+}
+
+func (h *HNSWIndex) maxNeighbors(level int) int {
+	if level == 0 {
+		return 2 * h.m
+	}
+	return h.m
+}
+
+func (h *HNSWIndex) insertLocked(id string, vec []float32) {
+	level := h.randomLevel()
+	node := &hnswNode{id: id, vector: vec, neighbors: make([][]int32, level+1)}
+	idx := int32(len(h.nodes))
+	h.nodes = append(h.nodes, node)
+	h.byID[id] = idx
+	if h.entry < 0 {
+		h.entry, h.maxLevel = idx, level
+		return
+	}
+
+	ep := h.entry
+	if level < h.maxLevel {
+		ep = h.greedyDescend(vec, ep, h.maxLevel, level+1)
+	}
+	eps := []int32{ep}
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		candidates := h.searchLayer(vec, eps, h.efConstruction, l)
+		neighbors := h.selectNeighbors(candidates, h.m)
+		node.neighbors[l] = neighbors
+		for _, nb := range neighbors {
+			h.link(nb, idx, l)
+		}
+		eps = eps[:0]
+		for _, c := range candidates {
+			eps = append(eps, c.node)
+		}
+	}
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = idx, level
+	}
+}
+
+// link adds to as a neighbor of from on layer l, pruning from's list to its closest
+// neighbors when it overflows.
+func (h *HNSWIndex) link(from, to int32, l int) {
+	n := h.nodes[from]
+	n.neighbors[l] = append(n.neighbors[l], to)
+	limit := h.maxNeighbors(l)
+	if len(n.neighbors[l]) <= limit {
+		return
+	}
+	scored := make([]hnswCandidate, len(n.neighbors[l]))
+	for i, nb := range n.neighbors[l] {
+		scored[i] = hnswCandidate{node: nb, score: InnerProduct(n.vector, h.nodes[nb].vector)}
+	}
+	sort.Slice(scored, func(i, j int) bool { return scored[i].score > scored[j].score })
+	n.neighbors[l] = h.selectNeighbors(scored, limit)
+}
+
+// selectNeighbors returns up to m of the candidates (sorted best first), preferring ones
+// that are closer to the new node than to any neighbor already selected, which keeps the
+// graph connected across clusters. Remaining slots are filled with the closest leftovers.
+func (h *HNSWIndex) selectNeighbors(candidates []hnswCandidate, m int) []int32 {
+	selected := make([]int32, 0, m)
+	var skipped []int32
+	for _, c := range candidates {
+		if len(selected) == m {
+			break
+		}
+		good := true
+		for _, s := range selected {
+			if InnerProduct(h.nodes[c.node].vector, h.nodes[s].vector) > c.score {
+				good = false
+				break
+			}
+		}
+		if good {
+			selected = append(selected, c.node)
+		} else {
+			skipped = append(skipped, c.node)
+		}
+	}
+	for _, s := range skipped {
+		if len(selected) == m {
+			break
+		}
+		selected = append(selected, s)
+	}
+	return selected
+}
+
+// greedyDescend walks from ep down to layer stop, moving to the best neighbor on each
+// layer from top, and returns the node reached.
+func (h *HNSWIndex) greedyDescend(query []float32, ep int32, top, stop int) int32 {
+	best := InnerProduct(query, h.nodes[ep].vector)
+	for l := top; l >= stop; l-- {
+		for changed := true; changed; {
+			changed = false
+			for _, nb := range h.nodes[ep].neighbors[l] {
+				if s := InnerProduct(query, h.nodes[nb].vector); s > best {
+					best, ep, changed = s, nb, true
+				}
+			}
+		}
+	}
+	return ep
+}
+
+// searchLayer returns up to ef nodes on layer l closest to query, best first.
+func (h *HNSWIndex) searchLayer(query []float32, eps []int32, ef, l int) []hnswCandidate {
+	visited := make(map[int32]bool, ef*4)
+	candidates := &hnswHeap{max: true}
+	results := &hnswHeap{}
+	for _, ep := range eps {
+		if visited[ep] {
+			continue
+		}
+		visited[ep] = true
+		c := hnswCandidate{node: ep, score: InnerProduct(query, h.nodes[ep].vector)}
+		heap.Push(candidates, c)
+		heap.Push(results, c)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if results.Len() >= ef && c.score < results.items[0].score {
+			break
+		}
+		for _, nb := range h.nodes[c.node].neighbors[l] {
+			if visited[nb] {
+				continue
+			}
+			visited[nb] = true
+			s := InnerProduct(query, h.nodes[nb].vector)
+			if results.Len() < ef || s > results.items[0].score {
+				heap.Push(candidates, hnswCandidate{node: nb, score: s})
+				heap.Push(results, hnswCandidate{node: nb, score: s})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+	out := results.items
+	sort.Slice(out, func(i, j int) bool { return out[i].score > out[j].score })
+	return out
+}
+
+type hnswCandidate struct {
+	node  int32
+	score float64
+}
+
+// hnswHeap is a heap of candidates by score: a min-heap, or a max-heap when max is set.
+type hnswHeap struct {
+	items []hnswCandidate
+	max   bool
+}
+
+func (q *hnswHeap) Len() int { return len(q.items) }
+func (q *hnswHeap) Less(i, j int) bool {
+	if q.max {
+		return q.items[i].score > q.items[j].score
+	}
+	return q.items[i].score < q.items[j].score
+}
+func (q *hnswHeap) Swap(i, j int)      { q.items[i], q.items[j] = q.items[j], q.items[i] }
+func (q *hnswHeap) Push(x interface{}) { q.items = append(q.items, x.(hnswCandidate)) }
+func (q *hnswHeap) Pop() interface{} {
+	last := q.items[len(q.items)-1]
+	q.items = q.items[:len(q.items)-1]
+	return last
+}
+
+// Save persists the live vectors and the graph to path. Tombstones are compacted away
+// first. Format: magic, dimensions (4), m (4), node count (4), entry (4, -1 when empty),
+// max level (4), then per node: id length (4), id, vector (dimensions*4), level count (4),
+// and per level a neighbor count (4) and neighbor indexes (4 each).
+func (h *HNSWIndex) Save(path string) error {
+	if path == "" {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.deleted > 0 {
+		h.rebuildLocked()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create index dir: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create index file: %w", err)
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	put := func(v uint32) { _ = binary.Write(w, binary.LittleEndian, v) }
+	_, _ = w.WriteString(hnswMagic)
+	put(uint32(h.dimensions))
+	put(uint32(h.m))
+	put(uint32(len(h.nodes)))
+	put(uint32(h.entry))
+	put(uint32(h.maxLevel))
+	for _, n := range h.nodes {
+		put(uint32(len(n.id)))
+		_, _ = w.WriteString(n.id)
+		_, _ = w.Write(float32SliceToBytes(n.vector))
+		put(uint32(len(n.neighbors)))
+		for _, nbs := range n.neighbors {
+			put(uint32(len(nbs)))
+			for _, nb := range nbs {
+				put(uint32(nb))
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write index file: %w", err)
+	}
+	return nil
+}
+
+// Load reads an index saved by Save and replaces the contents. Dimensions must match.
+// If the file does not exist, no error is returned and the index is unchanged.
+func (h *HNSWIndex) Load(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("open index file: %w", err)
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	magic := make([]byte, len(hnswMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != hnswMagic {
+		return errors.New("not an HNSW index file")
+	}
+	var readErr error
+	get := func() uint32 {
+		var v uint32
+		if readErr == nil {
+			readErr = binary.Read(r, binary.LittleEndian, &v)
+		}
+		return v
+	}
+	dim, m, count, entry, maxLevel := get(), get(), get(), int32(get()), int(get())
+	if readErr != nil {
+		return fmt.Errorf("read header: %w", readErr)
+	}
+	if int(dim) != h.dimensions {
+		return fmt.Errorf("dimension mismatch: file has %d, index expects %d", dim, h.dimensions)
+	}
+	nodes := make([]*hnswNode, count)
+	byID := make(map[string]int32, count)
+	buf := make([]byte, h.dimensions*4)
+	for i := range nodes {
+		id := make([]byte, get())
+		if readErr == nil {
+			_, readErr = io.ReadFull(r, id)
+		}
+		if readErr == nil {
+			_, readErr = io.ReadFull(r, buf)
+		}
+		n := &hnswNode{id: string(id), vector: bytesToFloat32Slice(buf), neighbors: make([][]int32, get())}
+		for l := range n.neighbors {
+			n.neighbors[l] = make([]int32, get())
+			for j := range n.neighbors[l] {
+				if nb := get(); nb < count {
+					n.neighbors[l][j] = int32(nb)
+				} else if readErr == nil {
+					readErr = errors.New("neighbor out of range")
+				}
+			}
+		}
+		if readErr != nil {
+			return fmt.Errorf("read node %d: %w", i, readErr)
+		}
+		nodes[i] = n
+		byID[n.id] = int32(i)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.m = int(m)
+	h.levelMult = 1 / math.Log(float64(m))
+	h.nodes, h.byID, h.entry, h.maxLevel, h.deleted = nodes, byID, entry, maxLevel, 0
+	return nil
+}
@@ -0,0 +1,136 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+func randomVectors(n, dim int, seed int64) [][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	out := make([][]float32, n)
+	for i := range out {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = float32(rng.NormFloat64())
+		}
+		norm := float32(L2Norm(v))
+		for j := range v {
+			v[j] /= norm
+		}
+		out[i] = v
+	}
+	return out
+}
+
+func TestHNSWIndex_recall(t *testing.T) {
+	ctx := context.Background()
+	const n, dim, k = 2000, 16, 10
+	vectors := randomVectors(n, dim, 1)
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("v%d", i)
+	}
+	hnsw, err := NewHNSWIndex(dim, 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exact, _ := NewMemoryIndex(dim)
+	if err := hnsw.Add(ctx, ids, vectors); err != nil {
+		t.Fatal(err)
+	}
+	_ = exact.Add(ctx, ids, vectors)
+
+	hits, total := 0, 0
+	for _, q := range randomVectors(50, dim, 2) {
+		want, _ := exact.Search(ctx, q, k)
+		got, err := hnsw.Search(ctx, q, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := make(map[string]bool, len(got))
+		for _, r := range got {
+			found[r.ID] = true
+		}
+		for _, r := range want {
+			if found[r.ID] {
+				hits++
+			}
+		}
+		total += len(want)
+	}
+	if recall := float64(hits) / float64(total); recall < 0.9 {
+		t.Errorf("recall@%d = %.2f, want >= 0.9", k, recall)
+	}
+}
+
+func TestHNSWIndex_removeAndReplace(t *testing.T) {
+	ctx := context.Background()
+	idx, _ := NewHNSWIndex(2, 4, 0, 0)
+	_ = idx.Add(ctx, []string{"a", "b", "c"}, [][]float32{{1, 0}, {0, 1}, {0.6, 0.8}})
+	_ = idx.Add(ctx, []string{"a"}, [][]float32{{0, -1}})
+	if idx.Size() != 3 {
+		t.Errorf("size = %d, want 3", idx.Size())
+	}
+	results, _ := idx.Search(ctx, []float32{1, 0}, 3)
+	if len(results) != 3 || results[0].ID != "c" || results[2].ID != "a" {
+		t.Errorf("replaced vector should be used, got %v", results)
+	}
+
+	_ = idx.Remove(ctx, []string{"c", "missing"})
+	results, _ = idx.Search(ctx, []float32{1, 0}, 3)
+	for _, r := range results {
+		if r.ID == "c" {
+			t.Errorf("removed vector returned: %v", results)
+		}
+	}
+	if idx.Size() != 2 {
+		t.Errorf("size = %d, want 2", idx.Size())
+	}
+	_ = idx.Remove(ctx, []string{"a"})
+	idx.mu.RLock()
+	nodes, deleted := len(idx.nodes), idx.deleted
+	idx.mu.RUnlock()
+	if nodes != 1 || deleted != 0 {
+		t.Errorf("tombstones should be compacted: %d nodes, %d deleted", nodes, deleted)
+	}
+}
+
+func TestHNSWIndex_saveLoad(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "hnsw.bin")
+	const n, dim = 300, 8
+	vectors := randomVectors(n, dim, 3)
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("v%d", i)
+	}
+	idx, _ := NewHNSWIndex(dim, 0, 0, 0)
+	_ = idx.Add(ctx, ids, vectors)
+	_ = idx.Remove(ctx, []string{"v0"})
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, _ := NewHNSWIndex(dim, 0, 0, 0)
+	if err := loaded.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Size() != n-1 {
+		t.Errorf("size = %d, want %d", loaded.Size(), n-1)
+	}
+	results, _ := loaded.Search(ctx, vectors[5], 1)
+	if len(results) != 1 || results[0].ID != "v5" {
+		t.Errorf("top result = %v, want v5", results)
+	}
+
+	other, _ := NewHNSWIndex(dim+1, 0, 0, 0)
+	if err := other.Load(path); err == nil {
+		t.Error("expected dimension mismatch error")
+	}
+	if err := loaded.Load(filepath.Join(t.TempDir(), "missing.bin")); err != nil {
+		t.Errorf("missing file should not error: %v", err)
+	}
+}
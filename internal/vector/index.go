@@ -21,3 +21,34 @@ type VectorResult struct {
 	ID    string
 	Score float64 // Inner product or cosine similarity (0-1 for normalized)
 }
+
+// Getter is implemented by vector indexes that can return a stored vector by ID, e.g. to
+// compare it with a freshly computed embedding.
+type Getter interface {
+	Vector(id string) ([]float32, bool)
+}
+
+// Lister is implemented by vector indexes that can list the IDs of their vectors, e.g. to
+// find vectors whose chunks are gone. IDs reports false when the index cannot list them
+// after all, such as a wrapper around an index that is no Lister.
+type Lister interface {
+	IDs() ([]string, bool)
+}
+
+// distinctIDs returns ids without repeats, in their first order.
+func distinctIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// Resetter is implemented by vector indexes that can drop all vectors at once.
+type Resetter interface {
+	Reset() error
+}
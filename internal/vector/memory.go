@@ -112,6 +112,34 @@ func (m *MemoryIndex) Remove(ctx context.Context, ids []string) error {
 	return nil
 }
 
+// Vector returns a copy of the vector stored under id.
+func (m *MemoryIndex) Vector(id string) ([]float32, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for i := len(m.ids) - 1; i >= 0; i-- {
+		if m.ids[i] == id {
+			return append([]float32(nil), m.vectors[i]...), true
+		}
+	}
+	return nil, false
+}
+
+// IDs returns the IDs of the stored vectors.
+func (m *MemoryIndex) IDs() ([]string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return distinctIDs(m.ids), true
+}
+
+// Reset removes all vectors from the index.
+func (m *MemoryIndex) Reset() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ids = make([]string, 0)
+	m.vectors = make([][]float32, 0)
+	return nil
+}
+
 // Save persists the index to path. Directory is created if needed. Format: dimension (4), n (4),
 // then per vector: idLen (4), id bytes, vector (dimension*4 bytes).
 func (m *MemoryIndex) Save(path string) error {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"sort"
 	"testing"
 )
 
@@ -126,3 +127,22 @@ func TestMemoryIndex_Type(t *testing.T) {
 		t.Errorf("Type() = %q, want %q", got, "memory")
 	}
 }
+
+func TestLister_IDs(t *testing.T) {
+	mem, _ := NewMemoryIndex(2)
+	hnsw, _ := NewHNSWIndex(2, 8, 50, 50)
+	ctx := context.Background()
+	for _, idx := range []VectorIndex{mem, hnsw} {
+		if err := idx.Add(ctx, []string{"a", "b", "c"}, [][]float32{{1, 0}, {0, 1}, {1, 1}}); err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.Remove(ctx, []string{"b"}); err != nil {
+			t.Fatal(err)
+		}
+		ids, ok := NewSwappableIndex(idx).IDs()
+		sort.Strings(ids)
+		if !ok || len(ids) != 2 || ids[0] != "a" || ids[1] != "c" {
+			t.Errorf("%s: IDs = %v, %v; want [a c]", idx.Type(), ids, ok)
+		}
+	}
+}
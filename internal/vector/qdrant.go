@@ -0,0 +1,323 @@
+package vector
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default QdrantIndex settings.
+const (
+	DefaultQdrantURL        = "http://localhost:6333"
+	DefaultQdrantCollection = "sagasu"
+	DefaultQdrantTimeout    = 30 * time.Second
+	DefaultQdrantMaxRetries = 3
+	qdrantBatchSize         = 256
+)
+
+// QdrantConfig configures a QdrantIndex. Zero values use the defaults.
+type QdrantConfig struct {
+	URL        string // e.g. http://localhost:6333
+	Collection string
+	APIKey     string // sent in the api-key header when set
+	Timeout    time.Duration
+	// MaxRetries is how many times a request failing with a network error, 429, or 502-504
+	// is retried; a negative value disables retry.
+	MaxRetries int
+}
+
+// QdrantIndex implements VectorIndex on a Qdrant collection through its REST API. Points
+// are scored by dot product, which is cosine similarity for the normalized embeddings, as
+// with MemoryIndex. Qdrant point IDs must be integers or UUIDs, so each chunk ID is mapped
+// to a name-based UUID and kept in the point's payload. The vectors live on the server:
+// Save and Load do nothing.
+type QdrantIndex struct {
+	cfg        QdrantConfig
+	dimensions int
+	client     *http.Client
+	backoff    time.Duration
+}
+
+// qdrantError is an error response of the server.
+type qdrantError struct {
+	status int
+	body   string
+}
+
+func (e *qdrantError) Error() string {
+	return fmt.Sprintf("qdrant returned %d: %s", e.status, e.body)
+}
+
+// qdrantPoint is a point as returned by the server.
+type qdrantPoint struct {
+	ID      interface{}       `json:"id"`
+	Score   float64           `json:"score"`
+	Vector  []float32         `json:"vector"`
+	Payload map[string]string `json:"payload"`
+}
+
+// NewQdrantIndex connects to the server at cfg.URL and creates cfg.Collection there for
+// vectors of the given dimension, unless it exists. An existing collection of another
+// dimension is an error.
+func NewQdrantIndex(ctx context.Context, cfg QdrantConfig, dimensions int) (*QdrantIndex, error) {
+	if dimensions <= 0 {
+		return nil, fmt.Errorf("dimensions must be positive")
+	}
+	if cfg.URL == "" {
+		cfg.URL = DefaultQdrantURL
+	}
+	cfg.URL = strings.TrimRight(cfg.URL, "/")
+	if cfg.Collection == "" {
+		cfg.Collection = DefaultQdrantCollection
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultQdrantTimeout
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = DefaultQdrantMaxRetries
+	}
+	q := &QdrantIndex{
+		cfg:        cfg,
+		dimensions: dimensions,
+		client:     &http.Client{Timeout: cfg.Timeout},
+		backoff:    500 * time.Millisecond,
+	}
+	if err := q.create(ctx); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// Type returns the index type identifier.
+func (q *QdrantIndex) Type() string {
+	return string(IndexTypeQdrant)
+}
+
+// create creates the collection unless it exists, and checks the dimension of one that does.
+func (q *QdrantIndex) create(ctx context.Context) error {
+	var info struct {
+		Result struct {
+			Config struct {
+				Params struct {
+					Vectors struct {
+						Size int `json:"size"`
+					} `json:"vectors"`
+				} `json:"params"`
+			} `json:"config"`
+		} `json:"result"`
+	}
+	err := q.do(ctx, http.MethodGet, "", nil, &info)
+	var qe *qdrantError
+	switch {
+	case err == nil:
+		if size := info.Result.Config.Params.Vectors.Size; size != 0 && size != q.dimensions {
+			return fmt.Errorf("qdrant collection %s holds %d-dimension vectors, but the configured model has %d (use another collection or delete it)",
+				q.cfg.Collection, size, q.dimensions)
+		}
+		return nil
+	case !errors.As(err, &qe) || qe.status != http.StatusNotFound:
+		return fmt.Errorf("failed to get qdrant collection %s: %w", q.cfg.Collection, err)
+	}
+	body := map[string]interface{}{"vectors": map[string]interface{}{"size": q.dimensions, "distance": "Dot"}}
+	if err := q.do(ctx, http.MethodPut, "", body, nil); err != nil {
+		return fmt.Errorf("failed to create qdrant collection %s: %w", q.cfg.Collection, err)
+	}
+	return nil
+}
+
+// do sends a request for path under the collection, encoding body as JSON and decoding
+// the response into out when non-nil. Transient failures are retried with exponential
+// backoff.
+func (q *QdrantIndex) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	u := q.cfg.URL + "/collections/" + url.PathEscape(q.cfg.Collection) + path
+	backoff := q.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := q.send(ctx, method, u, payload, out)
+		if err == nil || !retry || attempt >= q.cfg.MaxRetries || ctx.Err() != nil {
+			return err
+		}
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		backoff *= 2
+	}
+}
+
+// send sends one request, reporting whether a failure is worth retrying.
+func (q *QdrantIndex) send(ctx context.Context, method, u string, payload []byte, out interface{}) (retry bool, err error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return false, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if q.cfg.APIKey != "" {
+		req.Header.Set("api-key", q.cfg.APIKey)
+	}
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("qdrant request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			retry = true
+		}
+		return retry, &qdrantError{status: resp.StatusCode, body: strings.TrimSpace(string(b))}
+	}
+	if out == nil {
+		return false, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return false, fmt.Errorf("decode qdrant response: %w", err)
+	}
+	return false, nil
+}
+
+// qdrantPointID returns the UUID that stands for id: the first 16 bytes of its SHA-1,
+// marked as a version 5 (name-based) UUID.
+func qdrantPointID(id string) string {
+	h := sha1.Sum([]byte(id))
+	h[6] = h[6]&0x0f | 0x50
+	h[8] = h[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+// Add upserts vectors with the given IDs in batches, replacing earlier vectors of the same IDs.
+func (q *QdrantIndex) Add(ctx context.Context, ids []string, vectors [][]float32) error {
+	if len(ids) != len(vectors) {
+		return fmt.Errorf("ids and vectors length mismatch")
+	}
+	for start := 0; start < len(ids); start += qdrantBatchSize {
+		end := min(start+qdrantBatchSize, len(ids))
+		points := make([]map[string]interface{}, 0, end-start)
+		for i := start; i < end; i++ {
+			if len(vectors[i]) != q.dimensions {
+				return fmt.Errorf("vector dimension mismatch: got %d, expected %d", len(vectors[i]), q.dimensions)
+			}
+			points = append(points, map[string]interface{}{
+				"id":      qdrantPointID(ids[i]),
+				"vector":  vectors[i],
+				"payload": map[string]string{"chunk_id": ids[i]},
+			})
+		}
+		if err := q.do(ctx, http.MethodPut, "/points?wait=true", map[string]interface{}{"points": points}, nil); err != nil {
+			return fmt.Errorf("failed to add vectors to qdrant: %w", err)
+		}
+	}
+	return nil
+}
+
+// Search returns the top-k vectors by dot product.
+func (q *QdrantIndex) Search(ctx context.Context, query []float32, k int) ([]*VectorResult, error) {
+	if len(query) != q.dimensions {
+		return nil, fmt.Errorf("query dimension mismatch: got %d, expected %d", len(query), q.dimensions)
+	}
+	if k <= 0 {
+		return nil, nil
+	}
+	var out struct {
+		Result []qdrantPoint `json:"result"`
+	}
+	body := map[string]interface{}{"vector": query, "limit": k, "with_payload": []string{"chunk_id"}}
+	if err := q.do(ctx, http.MethodPost, "/points/search", body, &out); err != nil {
+		return nil, fmt.Errorf("qdrant search failed: %w", err)
+	}
+	results := make([]*VectorResult, 0, len(out.Result))
+	for _, p := range out.Result {
+		if id := p.Payload["chunk_id"]; id != "" {
+			results = append(results, &VectorResult{ID: id, Score: p.Score})
+		}
+	}
+	return results, nil
+}
+
+// Remove removes vectors by ID; unknown IDs are ignored.
+func (q *QdrantIndex) Remove(ctx context.Context, ids []string) error {
+	for start := 0; start < len(ids); start += qdrantBatchSize {
+		end := min(start+qdrantBatchSize, len(ids))
+		points := make([]string, 0, end-start)
+		for _, id := range ids[start:end] {
+			points = append(points, qdrantPointID(id))
+		}
+		if err := q.do(ctx, http.MethodPost, "/points/delete?wait=true", map[string]interface{}{"points": points}, nil); err != nil {
+			return fmt.Errorf("failed to remove vectors from qdrant: %w", err)
+		}
+	}
+	return nil
+}
+
+// Vector returns the vector stored under id.
+func (q *QdrantIndex) Vector(id string) ([]float32, bool) {
+	var out struct {
+		Result []qdrantPoint `json:"result"`
+	}
+	body := map[string]interface{}{"ids": []string{qdrantPointID(id)}, "with_vector": true, "with_payload": false}
+	if err := q.do(context.Background(), http.MethodPost, "/points", body, &out); err != nil || len(out.Result) == 0 {
+		return nil, false
+	}
+	return out.Result[0].Vector, true
+}
+
+// Reset drops the collection and creates it again, empty.
+func (q *QdrantIndex) Reset() error {
+	ctx := context.Background()
+	var qe *qdrantError
+	if err := q.do(ctx, http.MethodDelete, "", nil, nil); err != nil && !(errors.As(err, &qe) && qe.status == http.StatusNotFound) {
+		return fmt.Errorf("failed to delete qdrant collection %s: %w", q.cfg.Collection, err)
+	}
+	return q.create(ctx)
+}
+
+// Save does nothing: the server keeps the vectors.
+func (q *QdrantIndex) Save(path string) error {
+	return nil
+}
+
+// Load does nothing: the server keeps the vectors.
+func (q *QdrantIndex) Load(path string) error {
+	return nil
+}
+
+// Size returns the number of vectors in the collection, or 0 when the server cannot be reached.
+func (q *QdrantIndex) Size() int {
+	var out struct {
+		Result struct {
+			Count int `json:"count"`
+		} `json:"result"`
+	}
+	if err := q.do(context.Background(), http.MethodPost, "/points/count", map[string]bool{"exact": true}, &out); err != nil {
+		return 0
+	}
+	return out.Result.Count
+}
+
+// Close releases idle connections.
+func (q *QdrantIndex) Close() error {
+	q.client.CloseIdleConnections()
+	return nil
+}
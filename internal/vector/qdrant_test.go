@@ -0,0 +1,170 @@
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeQdrant is a Qdrant stand-in keeping the points of one collection in memory.
+type fakeQdrant struct {
+	mu       sync.Mutex
+	size     int // vector size of the collection; 0 when it does not exist
+	points   map[string]qdrantPoint
+	failures int // 503s before answering
+}
+
+func (f *fakeQdrant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("api-key") != "secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	if f.failures > 0 {
+		f.failures--
+		http.Error(w, "busy", http.StatusServiceUnavailable)
+		return
+	}
+	var body struct {
+		Vectors struct {
+			Size int `json:"size"`
+		} `json:"vectors"`
+		Points []json.RawMessage `json:"points"`
+		IDs    []string          `json:"ids"`
+		Vector []float32         `json:"vector"`
+		Limit  int               `json:"limit"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	respond := func(result interface{}) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"result": result, "status": "ok"})
+	}
+	path := strings.TrimPrefix(r.URL.Path, "/collections/docs")
+	if f.size == 0 && !(path == "" && r.Method == http.MethodPut) {
+		http.Error(w, `{"status":{"error":"Not found"}}`, http.StatusNotFound)
+		return
+	}
+	switch {
+	case path == "" && r.Method == http.MethodGet:
+		respond(map[string]interface{}{"config": map[string]interface{}{"params": map[string]interface{}{"vectors": map[string]int{"size": f.size}}}})
+	case path == "" && r.Method == http.MethodPut:
+		f.size, f.points = body.Vectors.Size, make(map[string]qdrantPoint)
+		respond(true)
+	case path == "" && r.Method == http.MethodDelete:
+		f.size, f.points = 0, nil
+		respond(true)
+	case path == "/points" && r.Method == http.MethodPut:
+		for _, raw := range body.Points {
+			var p qdrantPoint
+			_ = json.Unmarshal(raw, &p)
+			f.points[p.ID.(string)] = p
+		}
+		respond(map[string]string{"status": "completed"})
+	case path == "/points/delete":
+		for _, raw := range body.Points {
+			var id string
+			_ = json.Unmarshal(raw, &id)
+			delete(f.points, id)
+		}
+		respond(map[string]string{"status": "completed"})
+	case path == "/points/search":
+		var hits []qdrantPoint
+		for _, p := range f.points {
+			hits = append(hits, qdrantPoint{ID: p.ID, Score: InnerProduct(body.Vector, p.Vector), Payload: p.Payload})
+		}
+		sort.Slice(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+		respond(hits[:min(body.Limit, len(hits))])
+	case path == "/points/count":
+		respond(map[string]int{"count": len(f.points)})
+	case path == "/points":
+		var found []qdrantPoint
+		for _, id := range body.IDs {
+			if p, ok := f.points[id]; ok {
+				found = append(found, p)
+			}
+		}
+		respond(found)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func testQdrantIndex(t *testing.T, fake *fakeQdrant) (*QdrantIndex, string) {
+	t.Helper()
+	srv := httptest.NewServer(fake)
+	t.Cleanup(srv.Close)
+	idx, err := NewQdrantIndex(context.Background(), QdrantConfig{URL: srv.URL + "/", Collection: "docs", APIKey: "secret"}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx.backoff = time.Millisecond
+	t.Cleanup(func() { _ = idx.Close() })
+	return idx, srv.URL
+}
+
+func TestQdrantIndex(t *testing.T) {
+	ctx := context.Background()
+	fake := &fakeQdrant{}
+	idx, url := testQdrantIndex(t, fake)
+	if fake.size != 2 {
+		t.Fatalf("collection should be created with size 2, got %d", fake.size)
+	}
+	if idx.Type() != "qdrant" {
+		t.Errorf("Type = %s", idx.Type())
+	}
+
+	if err := idx.Add(ctx, []string{"doc1:0", "doc1:1", "doc2:0"}, [][]float32{{1, 0}, {0.8, 0.6}, {0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Add(ctx, []string{"doc2:0"}, [][]float32{{0.6, 0.8}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := idx.Size(); n != 3 {
+		t.Errorf("Size = %d, want 3 (re-adding an ID replaces it)", n)
+	}
+	fake.failures = 2
+	results, err := idx.Search(ctx, []float32{1, 0}, 2)
+	if err != nil {
+		t.Fatalf("search after retries: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "doc1:0" || results[1].ID != "doc1:1" || math.Abs(results[1].Score-0.8) > 1e-6 {
+		t.Errorf("results = %+v, %+v", results[0], results[1])
+	}
+	if v, ok := idx.Vector("doc2:0"); !ok || v[0] != 0.6 {
+		t.Errorf("Vector = %v, %v", v, ok)
+	}
+
+	if err := idx.Remove(ctx, []string{"doc1:0", "missing"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := idx.Vector("doc1:0"); ok || idx.Size() != 2 {
+		t.Errorf("removed vector still present, size %d", idx.Size())
+	}
+	if err := idx.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if idx.Size() != 0 || fake.size != 2 {
+		t.Errorf("after Reset: size %d, collection size %d", idx.Size(), fake.size)
+	}
+
+	if _, err := NewQdrantIndex(ctx, QdrantConfig{URL: url, Collection: "docs", APIKey: "secret"}, 3); err == nil {
+		t.Error("expected error for a collection of another dimension")
+	}
+}
+
+func TestQdrantPointID(t *testing.T) {
+	id := qdrantPointID("doc1:0")
+	if len(id) != 36 || id[14] != '5' || !strings.ContainsRune("89ab", rune(id[19])) {
+		t.Errorf("point ID %s is not a version 5 UUID", id)
+	}
+	if id != qdrantPointID("doc1:0") || id == qdrantPointID("doc1:1") {
+		t.Error("point IDs should be stable and distinct")
+	}
+}
@@ -0,0 +1,648 @@
+package vector
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Quantization kinds for QuantizedIndex.
+const (
+	// QuantizationInt8 stores each component as a signed byte with a per-vector scale (4x smaller).
+	QuantizationInt8 = "int8"
+	// QuantizationPQ stores one byte per subspace of 8 components (product quantization, about 32x smaller).
+	QuantizationPQ = "pq"
+)
+
+// DefaultRefineFactor is how many candidates per requested result are re-scored with
+// the full-precision vectors.
+const DefaultRefineFactor = 4
+
+const (
+	quantizedMagic   = "QVEC1"
+	pqCentroids      = 256
+	pqTrainSize      = 1024
+	pqMaxTrainSample = 16384
+	pqIterations     = 10
+)
+
+// quantizationCodes are the kind identifiers written to saved files.
+var quantizationCodes = map[string]uint32{QuantizationInt8: 1, QuantizationPQ: 2}
+
+// QuantizedIndex is a brute-force in-memory index like MemoryIndex that keeps only
+// compressed codes in memory, so large corpora fit in RAM. Search scores every code and
+// re-scores the best refineFactor*k candidates with their full-precision vectors, which
+// are kept on disk in the saved index file (vectors added since the last save stay in
+// memory until it is saved, or are appended to the file once it has been loaded or saved).
+//
+// The pq codebook is trained with k-means once the index holds pqTrainSize vectors;
+// until then vectors are scored exactly.
+type QuantizedIndex struct {
+	dimensions   int
+	kind         string
+	refineFactor int
+	subDim       int // pq: components per subspace
+	trainSize    int
+
+	mu       sync.RWMutex
+	entries  []*quantizedEntry
+	codebook [][]float32 // pq: per subspace, centroid components back to back; nil until trained
+	file     *os.File    // saved index holding the full-precision vectors
+	fileSize int64
+}
+
+type quantizedEntry struct {
+	id     string
+	code   []byte
+	scale  float32   // int8: value of one code step
+	offset int64     // position of the full vector in file
+	full   []float32 // full vector while it is not in file
+}
+
+// NewQuantizedIndex creates a quantized index with the given dimension and kind
+// (QuantizationInt8 or QuantizationPQ). refineFactor below 1 disables re-scoring with
+// full-precision vectors.
+func NewQuantizedIndex(dimensions int, kind string, refineFactor int) (*QuantizedIndex, error) {
+	if dimensions <= 0 {
+		return nil, fmt.Errorf("dimensions must be positive")
+	}
+	if _, ok := quantizationCodes[kind]; !ok {
+		return nil, fmt.Errorf("unknown quantization: %s (supported: int8, pq)", kind)
+	}
+	if refineFactor < 0 {
+		refineFactor = 0
+	}
+	subDim := 1
+	for _, s := range []int{8, 4, 2} {
+		if dimensions%s == 0 {
+			subDim = s
+			break
+		}
+	}
+	return &QuantizedIndex{
+		dimensions:   dimensions,
+		kind:         kind,
+		refineFactor: refineFactor,
+		subDim:       subDim,
+		trainSize:    pqTrainSize,
+	}, nil
+}
+
+// Type returns the index type identifier.
+func (q *QuantizedIndex) Type() string {
+	return string(IndexTypeMemory)
+}
+
+// Add appends vectors with the given IDs.
+func (q *QuantizedIndex) Add(ctx context.Context, ids []string, vectors [][]float32) error {
+	if len(ids) != len(vectors) {
+		return fmt.Errorf("ids and vectors length mismatch")
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, id := range ids {
+		if len(vectors[i]) != q.dimensions {
+			return fmt.Errorf("vector dimension mismatch: got %d, expected %d", len(vectors[i]), q.dimensions)
+		}
+		vec := make([]float32, q.dimensions)
+		copy(vec, vectors[i])
+		e := &quantizedEntry{id: id, offset: -1}
+		if err := q.storeLocked(e, vec); err != nil {
+			return err
+		}
+		q.encodeLocked(e, vec)
+		q.entries = append(q.entries, e)
+	}
+	if q.kind == QuantizationPQ && q.codebook == nil && len(q.entries) >= q.trainSize {
+		return q.trainLocked()
+	}
+	return nil
+}
+
+// Search returns the top-k vectors by inner product (assumes normalized vectors = cosine similarity).
+func (q *QuantizedIndex) Search(ctx context.Context, query []float32, k int) ([]*VectorResult, error) {
+	if len(query) != q.dimensions {
+		return nil, fmt.Errorf("query dimension mismatch: got %d, expected %d", len(query), q.dimensions)
+	}
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if k <= 0 || len(q.entries) == 0 {
+		return nil, nil
+	}
+	type scored struct {
+		entry *quantizedEntry
+		score float64
+	}
+	score := q.scorer(query)
+	scores := make([]scored, len(q.entries))
+	exact := true
+	for i, e := range q.entries {
+		if e.code == nil {
+			vec, err := q.vectorLocked(e)
+			if err != nil {
+				return nil, err
+			}
+			scores[i] = scored{entry: e, score: InnerProduct(query, vec)}
+			continue
+		}
+		scores[i] = scored{entry: e, score: score(e)}
+		exact = false
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+
+	if !exact && q.refineFactor > 0 {
+		n := min(k*q.refineFactor, len(scores))
+		for i := range scores[:n] {
+			vec, err := q.vectorLocked(scores[i].entry)
+			if err != nil {
+				return nil, err
+			}
+			scores[i].score = InnerProduct(query, vec)
+		}
+		scores = scores[:n]
+		sort.Slice(scores, func(i, j int) bool { return scores[i].score > scores[j].score })
+	}
+	if k > len(scores) {
+		k = len(scores)
+	}
+	result := make([]*VectorResult, k)
+	for i := 0; i < k; i++ {
+		result[i] = &VectorResult{ID: scores[i].entry.id, Score: scores[i].score}
+	}
+	return result, nil
+}
+
+// scorer returns a function giving the approximate inner product of query with an entry's code.
+func (q *QuantizedIndex) scorer(query []float32) func(*quantizedEntry) float64 {
+	if q.kind == QuantizationInt8 {
+		return func(e *quantizedEntry) float64 {
+			var dot float64
+			for j, c := range e.code {
+				dot += float64(query[j]) * float64(int8(c))
+			}
+			return dot * float64(e.scale)
+		}
+	}
+	if q.codebook == nil {
+		return nil
+	}
+	// Precompute the query's inner product with every centroid of every subspace.
+	centroids := len(q.codebook[0]) / q.subDim
+	table := make([]float64, len(q.codebook)*centroids)
+	for s, book := range q.codebook {
+		sub := query[s*q.subDim : (s+1)*q.subDim]
+		for c := 0; c < centroids; c++ {
+			table[s*centroids+c] = InnerProduct(sub, book[c*q.subDim:(c+1)*q.subDim])
+		}
+	}
+	return func(e *quantizedEntry) float64 {
+		var dot float64
+		for s, c := range e.code {
+			dot += table[s*centroids+int(c)]
+		}
+		return dot
+	}
+}
+
+// Remove removes vectors by ID. Their full vectors stay in the saved file until the next save.
+func (q *QuantizedIndex) Remove(ctx context.Context, ids []string) error {
+	removeSet := make(map[string]bool)
+	for _, id := range ids {
+		removeSet[id] = true
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	kept := make([]*quantizedEntry, 0, len(q.entries))
+	for _, e := range q.entries {
+		if !removeSet[e.id] {
+			kept = append(kept, e)
+		}
+	}
+	q.entries = kept
+	return nil
+}
+
+// Vector returns the full-precision vector stored under id.
+func (q *QuantizedIndex) Vector(id string) ([]float32, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	for i := len(q.entries) - 1; i >= 0; i-- {
+		if q.entries[i].id != id {
+			continue
+		}
+		vec, err := q.vectorLocked(q.entries[i])
+		if err != nil {
+			return nil, false
+		}
+		return append([]float32(nil), vec...), true
+	}
+	return nil, false
+}
+
+// IDs returns the IDs of the stored vectors.
+func (q *QuantizedIndex) IDs() ([]string, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	ids := make([]string, len(q.entries))
+	for i, e := range q.entries {
+		ids[i] = e.id
+	}
+	return distinctIDs(ids), true
+}
+
+// Reset removes all vectors from the index and drops the pq codebook.
+func (q *QuantizedIndex) Reset() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.entries = nil
+	q.codebook = nil
+	return nil
+}
+
+// Size returns the number of vectors in the index.
+func (q *QuantizedIndex) Size() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return len(q.entries)
+}
+
+// Close closes the saved index file.
+func (q *QuantizedIndex) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.file == nil {
+		return nil
+	}
+	err := q.file.Close()
+	q.file = nil
+	return err
+}
+
+// storeLocked keeps the full vector of e: appended to the saved file when there is one,
+// otherwise in memory.
+func (q *QuantizedIndex) storeLocked(e *quantizedEntry, vec []float32) error {
+	if q.file == nil {
+		e.full = vec
+		return nil
+	}
+	b := float32SliceToBytes(vec)
+	if _, err := q.file.WriteAt(b, q.fileSize); err != nil {
+		return fmt.Errorf("write vector: %w", err)
+	}
+	e.offset = q.fileSize
+	q.fileSize += int64(len(b))
+	return nil
+}
+
+// vectorLocked returns the full-precision vector of e.
+func (q *QuantizedIndex) vectorLocked(e *quantizedEntry) ([]float32, error) {
+	if e.full != nil {
+		return e.full, nil
+	}
+	if q.file == nil || e.offset < 0 {
+		return nil, fmt.Errorf("vector %s is not available", e.id)
+	}
+	buf := make([]byte, q.dimensions*4)
+	if _, err := q.file.ReadAt(buf, e.offset); err != nil {
+		return nil, fmt.Errorf("read vector: %w", err)
+	}
+	return bytesToFloat32Slice(buf), nil
+}
+
+// encodeLocked sets the code of e from its full vector. With pq the code stays nil until
+// the codebook is trained.
+func (q *QuantizedIndex) encodeLocked(e *quantizedEntry, vec []float32) {
+	if q.kind == QuantizationInt8 {
+		var maxAbs float64
+		for _, v := range vec {
+			maxAbs = math.Max(maxAbs, math.Abs(float64(v)))
+		}
+		e.code = make([]byte, len(vec))
+		e.scale = float32(maxAbs / 127)
+		if maxAbs == 0 {
+			return
+		}
+		for j, v := range vec {
+			e.code[j] = byte(int8(math.Round(float64(v) / float64(e.scale))))
+		}
+		return
+	}
+	if q.codebook == nil {
+		e.code = nil
+		return
+	}
+	e.code = make([]byte, len(q.codebook))
+	for s, book := range q.codebook {
+		e.code[s] = byte(nearestCentroid(vec[s*q.subDim:(s+1)*q.subDim], book, q.subDim))
+	}
+}
+
+// trainLocked trains the pq codebook with k-means on a sample of the vectors in each
+// subspace, then encodes every vector.
+func (q *QuantizedIndex) trainLocked() error {
+	rng := rand.New(rand.NewSource(1))
+	perm := rng.Perm(len(q.entries))
+	if len(perm) > pqMaxTrainSample {
+		perm = perm[:pqMaxTrainSample]
+	}
+	sample := make([][]float32, len(perm))
+	for i, p := range perm {
+		vec, err := q.vectorLocked(q.entries[p])
+		if err != nil {
+			return err
+		}
+		sample[i] = vec
+	}
+	k := min(pqCentroids, len(sample))
+	subspaces := q.dimensions / q.subDim
+	codebook := make([][]float32, subspaces)
+	for s := range codebook {
+		book := make([]float32, k*q.subDim)
+		for c, p := range rng.Perm(len(sample))[:k] {
+			copy(book[c*q.subDim:], sample[p][s*q.subDim:(s+1)*q.subDim])
+		}
+		sums := make([]float64, len(book))
+		counts := make([]int, k)
+		for iter := 0; iter < pqIterations; iter++ {
+			clear(sums)
+			clear(counts)
+			for _, vec := range sample {
+				sub := vec[s*q.subDim : (s+1)*q.subDim]
+				c := nearestCentroid(sub, book, q.subDim)
+				counts[c]++
+				for j, v := range sub {
+					sums[c*q.subDim+j] += float64(v)
+				}
+			}
+			for c, n := range counts {
+				if n == 0 {
+					continue // keep the previous centroid
+				}
+				for j := 0; j < q.subDim; j++ {
+					book[c*q.subDim+j] = float32(sums[c*q.subDim+j] / float64(n))
+				}
+			}
+		}
+		codebook[s] = book
+	}
+	q.codebook = codebook
+	return q.reencodeLocked()
+}
+
+// reencodeLocked recomputes the code of every entry from its full vector.
+func (q *QuantizedIndex) reencodeLocked() error {
+	for _, e := range q.entries {
+		vec, err := q.vectorLocked(e)
+		if err != nil {
+			return err
+		}
+		q.encodeLocked(e, vec)
+	}
+	return nil
+}
+
+// nearestCentroid returns the index of the centroid in book closest to sub by squared distance.
+func nearestCentroid(sub []float32, book []float32, subDim int) int {
+	best, bestDist := 0, math.Inf(1)
+	for c := 0; c*subDim < len(book); c++ {
+		var dist float64
+		for j, v := range sub {
+			d := float64(v - book[c*subDim+j])
+			dist += d * d
+		}
+		if dist < bestDist {
+			best, bestDist = c, dist
+		}
+	}
+	return best
+}
+
+// Save persists the index to path, written to a temporary file and renamed into place.
+// Format: magic, dimensions (4), kind (4), pq subspace size (4), pq centroids (4, 0 when
+// untrained), codebook (subspaces*centroids*subspace size*4), count (4), then per vector:
+// id length (4), id, scale (4), code length (4), code, offset of the full vector (8);
+// then the full vectors. Vectors added later are appended to the file.
+func (q *QuantizedIndex) Save(path string) error {
+	if path == "" {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create index dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := q.writeLocked(tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replace index file: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("open index file: %w", err)
+	}
+	return q.bindLocked(f, true)
+}
+
+func (q *QuantizedIndex) writeLocked(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create index file: %w", err)
+	}
+	defer f.Close()
+	centroids := 0
+	if q.codebook != nil {
+		centroids = len(q.codebook[0]) / q.subDim
+	}
+	offset := int64(len(quantizedMagic) + 5*4 + len(q.codebook)*centroids*q.subDim*4)
+	for _, e := range q.entries {
+		offset += int64(4 + len(e.id) + 4 + 4 + len(e.code) + 8)
+	}
+
+	w := bufio.NewWriter(f)
+	put := func(v uint32) { _ = binary.Write(w, binary.LittleEndian, v) }
+	_, _ = w.WriteString(quantizedMagic)
+	put(uint32(q.dimensions))
+	put(quantizationCodes[q.kind])
+	put(uint32(q.subDim))
+	put(uint32(centroids))
+	for _, book := range q.codebook {
+		_, _ = w.Write(float32SliceToBytes(book))
+	}
+	put(uint32(len(q.entries)))
+	for _, e := range q.entries {
+		put(uint32(len(e.id)))
+		_, _ = w.WriteString(e.id)
+		put(math.Float32bits(e.scale))
+		put(uint32(len(e.code)))
+		_, _ = w.Write(e.code)
+		_ = binary.Write(w, binary.LittleEndian, uint64(offset))
+		offset += int64(q.dimensions * 4)
+	}
+	for _, e := range q.entries {
+		vec, err := q.vectorLocked(e)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(float32SliceToBytes(vec)); err != nil {
+			return fmt.Errorf("write vector: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("write index file: %w", err)
+	}
+	return nil
+}
+
+// bindLocked makes f the file holding the full vectors. When saved is set, the entries
+// were just written to f in order, so their offsets are recomputed and in-memory vectors dropped.
+func (q *QuantizedIndex) bindLocked(f *os.File, saved bool) error {
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("stat index file: %w", err)
+	}
+	if saved {
+		offset := info.Size() - int64(len(q.entries)*q.dimensions*4)
+		for _, e := range q.entries {
+			e.offset, e.full = offset, nil
+			offset += int64(q.dimensions * 4)
+		}
+	}
+	if q.file != nil {
+		q.file.Close()
+	}
+	q.file, q.fileSize = f, info.Size()
+	return nil
+}
+
+// Load reads the index from path and replaces the in-memory contents. Dimensions must match.
+// If the file does not exist, no error is returned and the index is unchanged. A file saved
+// with another quantization is re-encoded, and a file saved by MemoryIndex is converted
+// (it is written in this format on the next save).
+func (q *QuantizedIndex) Load(path string) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("open index file: %w", err)
+	}
+	r := bufio.NewReader(f)
+	magic := make([]byte, len(quantizedMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != quantizedMagic {
+		f.Close()
+		return q.loadMemoryFile(path)
+	}
+	entries, codebook, sameCodes, err := q.readFile(r)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.bindLocked(f, false); err != nil {
+		return err
+	}
+	q.entries = entries
+	q.codebook = nil
+	if sameCodes {
+		q.codebook = codebook
+		return nil
+	}
+	if q.kind == QuantizationPQ && len(entries) >= q.trainSize {
+		return q.trainLocked()
+	}
+	return q.reencodeLocked()
+}
+
+// readFile reads the header and entries of a saved file after the magic. sameCodes
+// reports whether the codes were made with this index's quantization settings.
+func (q *QuantizedIndex) readFile(r io.Reader) (entries []*quantizedEntry, codebook [][]float32, sameCodes bool, err error) {
+	var readErr error
+	get := func() uint32 {
+		var v uint32
+		if readErr == nil {
+			readErr = binary.Read(r, binary.LittleEndian, &v)
+		}
+		return v
+	}
+	dim, kind, subDim, centroids := get(), get(), get(), int(get())
+	if readErr != nil {
+		return nil, nil, false, fmt.Errorf("read header: %w", readErr)
+	}
+	if int(dim) != q.dimensions {
+		return nil, nil, false, fmt.Errorf("dimension mismatch: file has %d, index expects %d", dim, q.dimensions)
+	}
+	if subDim == 0 || dim%subDim != 0 || centroids > pqCentroids {
+		return nil, nil, false, errors.New("invalid quantized index header")
+	}
+	if centroids > 0 {
+		codebook = make([][]float32, dim/subDim)
+		buf := make([]byte, centroids*int(subDim)*4)
+		for s := range codebook {
+			if _, err := io.ReadFull(r, buf); err != nil {
+				return nil, nil, false, fmt.Errorf("read codebook: %w", err)
+			}
+			codebook[s] = bytesToFloat32Slice(buf)
+		}
+	}
+	count := get()
+	entries = make([]*quantizedEntry, count)
+	for i := range entries {
+		id := make([]byte, get())
+		if readErr == nil {
+			_, readErr = io.ReadFull(r, id)
+		}
+		e := &quantizedEntry{id: string(id), scale: math.Float32frombits(get())}
+		if n := get(); n > 0 && readErr == nil {
+			e.code = make([]byte, n)
+			_, readErr = io.ReadFull(r, e.code)
+		}
+		var offset uint64
+		if readErr == nil {
+			readErr = binary.Read(r, binary.LittleEndian, &offset)
+		}
+		if readErr != nil {
+			return nil, nil, false, fmt.Errorf("read vector %d: %w", i, readErr)
+		}
+		e.offset = int64(offset)
+		entries[i] = e
+	}
+	sameCodes = kind == quantizationCodes[q.kind] && int(subDim) == q.subDim
+	return entries, codebook, sameCodes, nil
+}
+
+// loadMemoryFile loads a file saved by MemoryIndex and quantizes its vectors.
+func (q *QuantizedIndex) loadMemoryFile(path string) error {
+	mem, err := NewMemoryIndex(q.dimensions)
+	if err != nil {
+		return err
+	}
+	if err := mem.Load(path); err != nil {
+		return err
+	}
+	q.mu.Lock()
+	if q.file != nil {
+		q.file.Close()
+		q.file = nil
+	}
+	q.entries, q.codebook = nil, nil
+	q.mu.Unlock()
+	return q.Add(context.Background(), mem.ids, mem.vectors)
+}
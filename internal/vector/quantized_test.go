@@ -0,0 +1,142 @@
+package vector
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func recallAt(t *testing.T, idx VectorIndex, exact *MemoryIndex, queries [][]float32, k int) float64 {
+	t.Helper()
+	ctx := context.Background()
+	hits, total := 0, 0
+	for _, q := range queries {
+		want, _ := exact.Search(ctx, q, k)
+		got, err := idx.Search(ctx, q, k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := make(map[string]bool, len(got))
+		for _, r := range got {
+			found[r.ID] = true
+		}
+		for _, r := range want {
+			if found[r.ID] {
+				hits++
+			}
+		}
+		total += len(want)
+	}
+	return float64(hits) / float64(total)
+}
+
+func TestQuantizedIndex_recall(t *testing.T) {
+	ctx := context.Background()
+	const n, dim, k = 2000, 32, 10
+	vectors := randomVectors(n, dim, 1)
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("v%d", i)
+	}
+	exact, _ := NewMemoryIndex(dim)
+	_ = exact.Add(ctx, ids, vectors)
+	queries := randomVectors(50, dim, 2)
+
+	for _, tt := range []struct {
+		kind       string
+		refine     int
+		wantRecall float64
+	}{
+		{QuantizationInt8, 0, 0.9},
+		{QuantizationInt8, DefaultRefineFactor, 0.99},
+		{QuantizationPQ, 10, 0.8},
+	} {
+		idx, err := NewQuantizedIndex(dim, tt.kind, tt.refine)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := idx.Add(ctx, ids, vectors); err != nil {
+			t.Fatal(err)
+		}
+		if tt.kind == QuantizationPQ && idx.codebook == nil {
+			t.Fatal("pq codebook should be trained")
+		}
+		if recall := recallAt(t, idx, exact, queries, k); recall < tt.wantRecall {
+			t.Errorf("%s refine %d: recall@%d = %.2f, want >= %.2f", tt.kind, tt.refine, k, recall, tt.wantRecall)
+		}
+	}
+}
+
+func TestQuantizedIndex_saveLoad(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "idx.bin")
+	const n, dim = 300, 8
+	vectors := randomVectors(n, dim, 3)
+	ids := make([]string, n)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("v%d", i)
+	}
+	idx, _ := NewQuantizedIndex(dim, QuantizationPQ, DefaultRefineFactor)
+	idx.trainSize = 100
+	_ = idx.Add(ctx, ids, vectors)
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	// Vectors added after saving go to the file, not memory.
+	extra := randomVectors(1, dim, 4)[0]
+	_ = idx.Add(ctx, []string{"extra"}, [][]float32{extra})
+	if e := idx.entries[len(idx.entries)-1]; e.full != nil || e.offset < 0 {
+		t.Error("vector added after save should be appended to the file")
+	}
+	results, _ := idx.Search(ctx, extra, 1)
+	if len(results) != 1 || results[0].ID != "extra" {
+		t.Errorf("top result = %v, want extra", results)
+	}
+	idx.Close()
+
+	for _, kind := range []string{QuantizationPQ, QuantizationInt8} {
+		loaded, _ := NewQuantizedIndex(dim, kind, DefaultRefineFactor)
+		loaded.trainSize = 100
+		if err := loaded.Load(path); err != nil {
+			t.Fatal(err)
+		}
+		if loaded.Size() != n {
+			t.Errorf("%s: size = %d, want %d", kind, loaded.Size(), n)
+		}
+		results, _ := loaded.Search(ctx, vectors[7], 1)
+		if len(results) != 1 || results[0].ID != "v7" {
+			t.Errorf("%s: top result = %v, want v7", kind, results)
+		}
+		loaded.Close()
+	}
+}
+
+func TestQuantizedIndex_loadMemoryFile(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "idx.bin")
+	mem, _ := NewMemoryIndex(2)
+	_ = mem.Add(ctx, []string{"a", "b"}, [][]float32{{1, 0}, {0, 1}})
+	if err := mem.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, _ := NewQuantizedIndex(2, QuantizationInt8, DefaultRefineFactor)
+	if err := idx.Load(path); err != nil {
+		t.Fatal(err)
+	}
+	results, _ := idx.Search(ctx, []float32{0, 1}, 1)
+	if len(results) != 1 || results[0].ID != "b" {
+		t.Errorf("top result = %v, want b", results)
+	}
+	if err := idx.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data[:len(quantizedMagic)]) != quantizedMagic {
+		t.Error("save should write the quantized format")
+	}
+	idx.Close()
+}
@@ -0,0 +1,57 @@
+package vector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrModelMismatch is returned by CheckModelStamp when an index was built by another model.
+var ErrModelMismatch = errors.New("vector index was built by a different embedding model")
+
+// ModelStamp identifies the embedding model whose vectors an index holds. It is saved next
+// to the index file ("<path>.model"), so an index built by one model is never searched
+// with, or extended by, the embeddings of another.
+type ModelStamp struct {
+	Model      string `json:"model"`
+	Dimensions int    `json:"dimensions"`
+}
+
+// modelStampPath returns the stamp file for the index at path.
+func modelStampPath(path string) string {
+	return path + ".model"
+}
+
+// SaveModelStamp records that the index at path holds the vectors of stamp's model.
+func SaveModelStamp(path string, stamp ModelStamp) error {
+	data, err := json.Marshal(stamp)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(modelStampPath(path), append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write model stamp: %w", err)
+	}
+	return nil
+}
+
+// CheckModelStamp returns an error wrapping ErrModelMismatch when the index at path was
+// built by a model other than stamp's. An index without a stamp is accepted.
+func CheckModelStamp(path string, stamp ModelStamp) error {
+	data, err := os.ReadFile(modelStampPath(path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read model stamp: %w", err)
+	}
+	var saved ModelStamp
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("invalid model stamp %s: %w", modelStampPath(path), err)
+	}
+	if saved != stamp {
+		return fmt.Errorf("%w: %s holds %d-dimension vectors from %s, but the configured model is %s with %d dimensions (run sagasu reindex)",
+			ErrModelMismatch, path, saved.Dimensions, saved.Model, stamp.Model, stamp.Dimensions)
+	}
+	return nil
+}
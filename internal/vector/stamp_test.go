@@ -0,0 +1,30 @@
+package vector
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestModelStamp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idx.bin")
+	stamp := ModelStamp{Model: "onnx:model.onnx", Dimensions: 384}
+
+	if err := CheckModelStamp(path, stamp); err != nil {
+		t.Fatalf("an index without a stamp should be accepted: %v", err)
+	}
+	if err := SaveModelStamp(path, stamp); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckModelStamp(path, stamp); err != nil {
+		t.Errorf("same model: %v", err)
+	}
+	for _, other := range []ModelStamp{
+		{Model: "ollama:nomic-embed-text", Dimensions: 384},
+		{Model: "onnx:model.onnx", Dimensions: 768},
+	} {
+		if err := CheckModelStamp(path, other); !errors.Is(err, ErrModelMismatch) {
+			t.Errorf("%+v: got %v, want ErrModelMismatch", other, err)
+		}
+	}
+}
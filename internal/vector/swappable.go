@@ -0,0 +1,114 @@
+package vector
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// SwappableIndex wraps a VectorIndex so it can be replaced while in use (e.g. by an index
+// rebuilt with a new embedding model). Each call holds a read lock for its duration, so
+// Swap waits for in-flight searches to finish and callers never see a closed index.
+type SwappableIndex struct {
+	mu  sync.RWMutex
+	idx VectorIndex
+}
+
+// NewSwappableIndex wraps idx.
+func NewSwappableIndex(idx VectorIndex) *SwappableIndex {
+	return &SwappableIndex{idx: idx}
+}
+
+// Swap calls fn with the current index while no other calls are running and installs the
+// index it returns. A non-nil index is installed even when fn also returns an error, so fn
+// can restore a usable index after a failed replacement.
+func (w *SwappableIndex) Swap(fn func(old VectorIndex) (VectorIndex, error)) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	next, err := fn(w.idx)
+	if next != nil {
+		w.idx = next
+	}
+	return err
+}
+
+func (w *SwappableIndex) Add(ctx context.Context, ids []string, vectors [][]float32) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Add(ctx, ids, vectors)
+}
+
+func (w *SwappableIndex) Search(ctx context.Context, query []float32, k int) ([]*VectorResult, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Search(ctx, query, k)
+}
+
+func (w *SwappableIndex) Remove(ctx context.Context, ids []string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Remove(ctx, ids)
+}
+
+func (w *SwappableIndex) Save(path string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Save(path)
+}
+
+func (w *SwappableIndex) Load(path string) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Load(path)
+}
+
+func (w *SwappableIndex) Size() int {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Size()
+}
+
+func (w *SwappableIndex) Close() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Close()
+}
+
+func (w *SwappableIndex) Type() string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.idx.Type()
+}
+
+// Vector forwards to the wrapped index's Getter.
+func (w *SwappableIndex) Vector(id string) ([]float32, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	g, ok := w.idx.(Getter)
+	if !ok {
+		return nil, false
+	}
+	return g.Vector(id)
+}
+
+// IDs forwards to the wrapped index's Lister.
+func (w *SwappableIndex) IDs() ([]string, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	l, ok := w.idx.(Lister)
+	if !ok {
+		return nil, false
+	}
+	return l.IDs()
+}
+
+// Reset forwards to the wrapped index's Resetter.
+func (w *SwappableIndex) Reset() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	r, ok := w.idx.(Resetter)
+	if !ok {
+		return errors.New("vector index cannot be reset")
+	}
+	return r.Reset()
+}
@@ -0,0 +1,427 @@
+package vector
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// walSuffix is appended to an index's snapshot path, followed by a sequence number, to
+// name its log segments ("<path>.wal.1", "<path>.wal.2", ...).
+const walSuffix = ".wal."
+
+// Log record operations.
+const (
+	walOpAdd byte = iota + 1
+	walOpRemove
+	walOpReset
+)
+
+// DefaultSegmentBytes is the size at which WALIndex starts a new log segment.
+const DefaultSegmentBytes = 4 << 20
+
+// WALIndex wraps a VectorIndex and appends every Add, Remove and Reset to a write-ahead
+// log next to the snapshot, so changes since the last Save survive a crash. Load reads the
+// snapshot and replays the log segments on top of it; Save writes a new snapshot and
+// deletes the segments it covers. Once the log grows past the compaction size, the next
+// write compacts it the same way.
+//
+// The log is bound to a path by the first Load or Save; until then writes are not logged.
+// Replay is idempotent (a logged add replaces any vector with the same ID), so a crash
+// between writing a snapshot and deleting its segments loses nothing.
+type WALIndex struct {
+	idx          VectorIndex
+	compactBytes int64
+	segmentBytes int64
+
+	mu       sync.Mutex // serializes writes so the log order matches the index
+	path     string
+	seg      *os.File
+	segSeq   int
+	segSize  int64
+	logBytes int64 // total size of all segments since the last snapshot
+}
+
+// NewWALIndex wraps idx. The log is compacted into a snapshot once it reaches
+// compactBytes; 0 means it is only compacted by Save.
+func NewWALIndex(idx VectorIndex, compactBytes int64) *WALIndex {
+	return &WALIndex{idx: idx, compactBytes: compactBytes, segmentBytes: DefaultSegmentBytes}
+}
+
+// Add adds the vectors to the wrapped index and logs them.
+func (w *WALIndex) Add(ctx context.Context, ids []string, vectors [][]float32) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.idx.Add(ctx, ids, vectors); err != nil {
+		return err
+	}
+	return w.logLocked(encodeWALRecord(walOpAdd, ids, vectors))
+}
+
+// Remove removes the vectors from the wrapped index and logs the removal.
+func (w *WALIndex) Remove(ctx context.Context, ids []string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.idx.Remove(ctx, ids); err != nil {
+		return err
+	}
+	return w.logLocked(encodeWALRecord(walOpRemove, ids, nil))
+}
+
+// Reset forwards to the wrapped index's Resetter and logs the reset.
+func (w *WALIndex) Reset() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	r, ok := w.idx.(Resetter)
+	if !ok {
+		return errors.New("vector index cannot be reset")
+	}
+	if err := r.Reset(); err != nil {
+		return err
+	}
+	return w.logLocked(encodeWALRecord(walOpReset, nil, nil))
+}
+
+func (w *WALIndex) Search(ctx context.Context, query []float32, k int) ([]*VectorResult, error) {
+	return w.idx.Search(ctx, query, k)
+}
+
+func (w *WALIndex) Size() int {
+	return w.idx.Size()
+}
+
+// Vector forwards to the wrapped index's Getter.
+func (w *WALIndex) Vector(id string) ([]float32, bool) {
+	g, ok := w.idx.(Getter)
+	if !ok {
+		return nil, false
+	}
+	return g.Vector(id)
+}
+
+// IDs forwards to the wrapped index's Lister.
+func (w *WALIndex) IDs() ([]string, bool) {
+	l, ok := w.idx.(Lister)
+	if !ok {
+		return nil, false
+	}
+	return l.IDs()
+}
+
+func (w *WALIndex) Type() string {
+	return w.idx.Type()
+}
+
+// Save writes a snapshot of the index to path and starts a new log there, deleting the
+// segments the snapshot covers.
+func (w *WALIndex) Save(path string) error {
+	if path == "" {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.compactLocked(path)
+}
+
+// Load reads the snapshot at path, replays the log segments written since, and continues
+// the log in a new segment. A torn record at the end of a segment (from a crash mid-write)
+// ends the replay of that segment.
+func (w *WALIndex) Load(path string) error {
+	if path == "" {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.idx.Load(path); err != nil {
+		return err
+	}
+	seqs, err := walSegments(path)
+	if err != nil {
+		return err
+	}
+	var replay walReplay
+	var size int64
+	for _, seq := range seqs {
+		n, err := replay.readSegment(walSegmentPath(path, seq))
+		if err != nil {
+			return err
+		}
+		size += n
+	}
+	if err := replay.apply(w.idx); err != nil {
+		return fmt.Errorf("replay vector log: %w", err)
+	}
+	w.closeSegmentLocked()
+	w.path = path
+	w.logBytes = size
+	next := 1
+	if len(seqs) > 0 {
+		next = seqs[len(seqs)-1] + 1
+	}
+	return w.openSegmentLocked(next)
+}
+
+// Close closes the log and the wrapped index.
+func (w *WALIndex) Close() error {
+	w.mu.Lock()
+	w.closeSegmentLocked()
+	w.mu.Unlock()
+	return w.idx.Close()
+}
+
+// logLocked appends rec to the current segment, rolling over to a new segment or
+// compacting as the log grows. w.mu must be held.
+func (w *WALIndex) logLocked(rec []byte) error {
+	if w.seg == nil {
+		return nil
+	}
+	if w.segSize > 0 && w.segSize+int64(len(rec)) > w.segmentBytes {
+		next := w.segSeq + 1
+		w.closeSegmentLocked()
+		if err := w.openSegmentLocked(next); err != nil {
+			return err
+		}
+	}
+	if _, err := w.seg.Write(rec); err != nil {
+		return fmt.Errorf("write vector log: %w", err)
+	}
+	w.segSize += int64(len(rec))
+	w.logBytes += int64(len(rec))
+	if w.compactBytes > 0 && w.logBytes >= w.compactBytes {
+		return w.compactLocked(w.path)
+	}
+	return nil
+}
+
+// compactLocked snapshots the index to path, then deletes every existing segment and
+// starts a new one. w.mu must be held.
+func (w *WALIndex) compactLocked(path string) error {
+	if err := w.idx.Save(path); err != nil {
+		return err
+	}
+	w.closeSegmentLocked()
+	seqs, err := walSegments(path)
+	if err != nil {
+		return err
+	}
+	next := 1
+	for _, seq := range seqs {
+		if err := os.Remove(walSegmentPath(path, seq)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove vector log segment: %w", err)
+		}
+		next = seq + 1
+	}
+	w.path = path
+	w.logBytes = 0
+	return w.openSegmentLocked(next)
+}
+
+func (w *WALIndex) openSegmentLocked(seq int) error {
+	if err := os.MkdirAll(filepath.Dir(w.path), 0755); err != nil {
+		return fmt.Errorf("create index dir: %w", err)
+	}
+	f, err := os.OpenFile(walSegmentPath(w.path, seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("open vector log segment: %w", err)
+	}
+	w.seg, w.segSeq, w.segSize = f, seq, 0
+	return nil
+}
+
+func (w *WALIndex) closeSegmentLocked() {
+	if w.seg != nil {
+		_ = w.seg.Close()
+		w.seg = nil
+	}
+}
+
+func walSegmentPath(path string, seq int) string {
+	return path + walSuffix + strconv.Itoa(seq)
+}
+
+// walSegments returns the sequence numbers of the log segments of path, in order.
+func walSegments(path string) ([]int, error) {
+	matches, err := filepath.Glob(escapeGlob(path) + walSuffix + "*")
+	if err != nil {
+		return nil, fmt.Errorf("list vector log segments: %w", err)
+	}
+	var seqs []int
+	for _, m := range matches {
+		seq, err := strconv.Atoi(strings.TrimPrefix(m, path+walSuffix))
+		if err == nil {
+			seqs = append(seqs, seq)
+		}
+	}
+	sort.Ints(seqs)
+	return seqs, nil
+}
+
+// escapeGlob escapes the glob metacharacters in path.
+func escapeGlob(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// encodeWALRecord encodes a log record: payload length (4), CRC-32 of the payload (4),
+// then the payload: op (1), count (4), and per ID its length (4) and bytes, followed for
+// adds by the vector length (4) and the vector.
+func encodeWALRecord(op byte, ids []string, vectors [][]float32) []byte {
+	payload := []byte{op}
+	payload = binary.LittleEndian.AppendUint32(payload, uint32(len(ids)))
+	for i, id := range ids {
+		payload = binary.LittleEndian.AppendUint32(payload, uint32(len(id)))
+		payload = append(payload, id...)
+		if op == walOpAdd {
+			payload = binary.LittleEndian.AppendUint32(payload, uint32(len(vectors[i])))
+			payload = append(payload, float32SliceToBytes(vectors[i])...)
+		}
+	}
+	rec := binary.LittleEndian.AppendUint32(nil, uint32(len(payload)))
+	rec = binary.LittleEndian.AppendUint32(rec, crc32.ChecksumIEEE(payload))
+	return append(rec, payload...)
+}
+
+// walReplay accumulates the net effect of log records: whether the index was reset, and
+// the final vector (nil when removed) of every ID touched, in first-touched order.
+type walReplay struct {
+	reset   bool
+	order   []string
+	vectors map[string][]float32
+}
+
+func (r *walReplay) set(id string, vec []float32) {
+	if r.vectors == nil {
+		r.vectors = make(map[string][]float32)
+	}
+	if _, seen := r.vectors[id]; !seen {
+		r.order = append(r.order, id)
+	}
+	r.vectors[id] = vec
+}
+
+// readSegment reads the records of the segment at path into r and returns the number of
+// bytes read up to the last complete record.
+func (r *walReplay) readSegment(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("open vector log segment: %w", err)
+	}
+	defer f.Close()
+	br := bufio.NewReader(f)
+	var read int64
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			return read, nil
+		}
+		payload := make([]byte, binary.LittleEndian.Uint32(header[:4]))
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return read, nil
+		}
+		if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[4:]) || !r.decode(payload) {
+			return read, nil
+		}
+		read += int64(len(header) + len(payload))
+	}
+}
+
+// decode applies one record payload to r. It returns false if the payload is malformed.
+func (r *walReplay) decode(p []byte) bool {
+	if len(p) < 5 {
+		return false
+	}
+	op, n := p[0], binary.LittleEndian.Uint32(p[1:5])
+	p = p[5:]
+	if op == walOpReset {
+		r.reset = true
+		r.order, r.vectors = nil, nil
+		return true
+	}
+	next := func() ([]byte, bool) {
+		if len(p) < 4 {
+			return nil, false
+		}
+		size := int(binary.LittleEndian.Uint32(p))
+		if len(p)-4 < size {
+			return nil, false
+		}
+		b := p[4 : 4+size]
+		p = p[4+size:]
+		return b, true
+	}
+	for i := uint32(0); i < n; i++ {
+		id, ok := next()
+		if !ok {
+			return false
+		}
+		switch op {
+		case walOpAdd:
+			if len(p) < 4 {
+				return false
+			}
+			dim := int(binary.LittleEndian.Uint32(p))
+			if len(p)-4 < dim*4 {
+				return false
+			}
+			r.set(string(id), bytesToFloat32Slice(p[4:4+dim*4]))
+			p = p[4+dim*4:]
+		case walOpRemove:
+			r.set(string(id), nil)
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// apply replays the accumulated changes onto idx: a reset if one was logged, then the
+// removal of every touched ID and the addition of those that still have a vector.
+func (r *walReplay) apply(idx VectorIndex) error {
+	ctx := context.Background()
+	if r.reset {
+		res, ok := idx.(Resetter)
+		if !ok {
+			return errors.New("vector index cannot be reset")
+		}
+		if err := res.Reset(); err != nil {
+			return err
+		}
+	}
+	if len(r.order) == 0 {
+		return nil
+	}
+	if err := idx.Remove(ctx, r.order); err != nil {
+		return err
+	}
+	var ids []string
+	var vectors [][]float32
+	for _, id := range r.order {
+		if vec := r.vectors[id]; vec != nil {
+			ids = append(ids, id)
+			vectors = append(vectors, vec)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return idx.Add(ctx, ids, vectors)
+}
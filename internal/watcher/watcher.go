@@ -3,19 +3,28 @@ package watcher
 
 import (
 	"context"
+	"errors"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/hyperjump/sagasu/internal/events"
+	"github.com/hyperjump/sagasu/internal/fswalk"
+	"github.com/hyperjump/sagasu/internal/pathrules"
+	"github.com/hyperjump/sagasu/internal/schedule"
 	"go.uber.org/zap"
 )
 
 const defaultDebounce = 400 * time.Millisecond
 
+// ErrNotWatched is returned by AddPriority for a file outside the watched roots or extensions.
+var ErrNotWatched = errors.New("file is not under a watched directory or has an unwatched extension")
+
 // Watcher watches directories and invokes callbacks on file changes.
 type Watcher struct {
 	roots       []string
@@ -23,11 +32,26 @@ type Watcher struct {
 	recursive   bool
 	onIndex     func(path string)
 	onRemove    func(path string)
+	onIndexNow  func(path string) // priority files; defaults to onIndex
+	busy        func() bool       // optional; while true, debounced changes are held back
+	windows     schedule.Windows  // optional; outside them, changes and syncs wait for the next window
+	now         func() time.Time
 	debounce    time.Duration
 	watcher     *fsnotify.Watcher
 	mu          sync.Mutex
 	debounceMap map[string]*time.Timer
 	rootPaths   map[string][]string // root -> list of watched paths (dirs we added)
+	priority    map[string]bool     // files indexed on every change without debounce
+	indexingNow map[string]bool     // priority files being indexed -> changed again meanwhile
+	held        map[string]bool     // files changed outside the index windows
+	heldDirs    map[string]bool     // directories to sync when the next index window opens
+	windowTimer *time.Timer         // fires when the next index window opens
+	marker      string              // optional; directories holding this file are not indexed
+	onOptOut    func(dir string)    // called when the marker appears in a directory
+	rules       pathrules.Rules     // optional; per-directory indexing rules
+	ignore      *pathrules.Ignore   // optional; .gitignore-style files honored in the roots
+	walk        fswalk.Options      // hidden directories and symlinks; see WithWalkOptions
+	events      *events.Bus         // optional; directory syncs are published to it
 	done        chan struct{}
 	started     bool
 	stopOnce    sync.Once
@@ -42,6 +66,64 @@ func WithLogger(l *zap.Logger) WatcherOption {
 	return func(w *Watcher) { w.logger = l }
 }
 
+// WithPriorityIndex sets the callback for changes to priority files (see AddPriority), e.g. one
+// that queues the indexing ahead of other jobs. Without it onIndex is used.
+func WithPriorityIndex(fn func(path string)) WatcherOption {
+	return func(w *Watcher) { w.onIndexNow = fn }
+}
+
+// WithBackpressure holds back debounced changes while busy reports true (e.g. the indexer's
+// embed queue is full): they are retried after another debounce interval, and further
+// changes to the same file replace them, so a storm of changes waits as one pending
+// entry per file. Priority files are not held back.
+func WithBackpressure(busy func() bool) WatcherOption {
+	return func(w *Watcher) { w.busy = busy }
+}
+
+// WithIndexWindows limits background indexing to daily windows of local time. Outside them,
+// debounced changes are held as one pending entry per file, and directory syncs (including
+// SyncExistingFiles) are held per directory; both are indexed when the next window opens or
+// when IndexHeld is called. Removals and priority files are not held back.
+func WithIndexWindows(ws schedule.Windows) WatcherOption {
+	return func(w *Watcher) { w.windows = ws }
+}
+
+// WithNoIndexMarker skips every directory holding a file named marker (e.g. ".noindex"),
+// with everything beneath it: its files are neither synced nor indexed on change. When the
+// marker appears, onOptOut is called with its directory, e.g. to remove the documents
+// indexed from it; when it is removed, the directory is synced again.
+func WithNoIndexMarker(marker string, onOptOut func(dir string)) WatcherOption {
+	return func(w *Watcher) { w.marker, w.onOptOut = marker, onOptOut }
+}
+
+// WithRules applies per-directory indexing rules: under a rule's root, its extensions
+// replace the watcher's, and files and directories it excludes are neither watched, synced,
+// nor indexed on change.
+func WithRules(rules pathrules.Rules) WatcherOption {
+	return func(w *Watcher) { w.rules = rules }
+}
+
+// WithIgnore skips the files and directories that the ignore files of their root, and of
+// the directories between, list: they are neither watched, synced, nor indexed on change.
+// When an ignore file changes, its directory is synced again to index what it no longer
+// ignores.
+func WithIgnore(ig *pathrules.Ignore) WatcherOption {
+	return func(w *Watcher) { w.ignore = ig }
+}
+
+// WithWalkOptions sets whether hidden directories are skipped, so they are neither
+// watched, synced, nor indexed on change, and whether symlinked directories are followed
+// (see fswalk.Options).
+func WithWalkOptions(opts fswalk.Options) WatcherOption {
+	return func(w *Watcher) { w.walk = opts }
+}
+
+// WithEvents publishes directory syncs to bus: when the watcher starts looking for files
+// in a directory and when it has handed them all to onIndex, with their number.
+func WithEvents(bus *events.Bus) WatcherOption {
+	return func(w *Watcher) { w.events = bus }
+}
+
 // NewWatcher creates a watcher. onIndex and onRemove are called for file index and remove events.
 // roots are initial directory paths to watch; extensions filter which files (empty = all).
 // Options (e.g. WithLogger) can be passed for debug logging.
@@ -55,6 +137,11 @@ func NewWatcher(roots []string, extensions []string, recursive bool, onIndex, on
 		debounce:    defaultDebounce,
 		debounceMap: make(map[string]*time.Timer),
 		rootPaths:   make(map[string][]string),
+		priority:    make(map[string]bool),
+		indexingNow: make(map[string]bool),
+		held:        make(map[string]bool),
+		heldDirs:    make(map[string]bool),
+		now:         time.Now,
 		done:        make(chan struct{}),
 	}
 	for _, opt := range opts {
@@ -90,11 +177,13 @@ func (w *Watcher) Start(ctx context.Context) error {
 		}
 	}
 	w.mu.Unlock()
-	go w.run(ctx)
+	go w.run(ctx, watcher)
 	return nil
 }
 
-func (w *Watcher) run(ctx context.Context) {
+// run handles the events of fsw until ctx is done or the watcher is stopped. It is given
+// fsw because Stop clears w.watcher.
+func (w *Watcher) run(ctx context.Context, fsw *fsnotify.Watcher) {
 	for {
 		select {
 		case <-ctx.Done():
@@ -102,12 +191,12 @@ func (w *Watcher) run(ctx context.Context) {
 			return
 		case <-w.done:
 			return
-		case ev, ok := <-w.watcher.Events:
+		case ev, ok := <-fsw.Events:
 			if !ok {
 				return
 			}
 			w.handleEvent(ev)
-		case err, ok := <-w.watcher.Errors:
+		case err, ok := <-fsw.Errors:
 			if !ok {
 				return
 			}
@@ -126,15 +215,38 @@ func (w *Watcher) handleEvent(ev fsnotify.Event) {
 	if w.logger != nil {
 		w.logger.Debug("watcher event", zap.String("op", ev.Op.String()), zap.String("path", path))
 	}
+	if w.marker != "" && filepath.Base(path) == w.marker {
+		w.handleMarker(ev)
+		return
+	}
+	if w.ignore.IsIgnoreFile(filepath.Base(path)) {
+		w.handleIgnoreFile(ev)
+		return
+	}
 	switch ev.Op {
 	case fsnotify.Create, fsnotify.Write:
+		if w.optedOut(path) {
+			return
+		}
 		// Check if it's a directory (newly created or moved in)
 		info, err := os.Stat(path)
 		if err == nil && info.IsDir() {
-			w.handleNewDirectory(path)
+			if !w.rules.SkipDir(path) && !w.ignored(path, true) {
+				w.handleNewDirectory(path)
+			}
 			return
 		}
-		if w.matchExtension(path) {
+		size := int64(-1)
+		if err == nil {
+			size = info.Size()
+		}
+		if !w.matchFile(path, size) {
+			return
+		}
+		if w.isPriority(path) {
+			w.cancelDebounce(path)
+			w.indexNow(path)
+		} else {
 			w.debounceIndex(path)
 		}
 	case fsnotify.Remove:
@@ -147,6 +259,77 @@ func (w *Watcher) handleEvent(ev fsnotify.Event) {
 	}
 }
 
+// handleMarker opts the marker's directory out of indexing when the marker appears, and
+// back in when it is removed or renamed.
+func (w *Watcher) handleMarker(ev fsnotify.Event) {
+	dir := filepath.Dir(ev.Name)
+	switch {
+	case ev.Has(fsnotify.Create):
+		if w.logger != nil {
+			w.logger.Debug("watcher directory opted out of indexing", zap.String("path", dir))
+		}
+		if w.onOptOut != nil {
+			w.onOptOut(dir)
+		}
+	case ev.Has(fsnotify.Remove) || ev.Has(fsnotify.Rename):
+		if w.markedAt(dir) {
+			return
+		}
+		if w.logger != nil {
+			w.logger.Debug("watcher directory opted back into indexing", zap.String("path", dir))
+		}
+		w.handleNewDirectory(dir)
+	}
+}
+
+// handleIgnoreFile syncs the directory of an ignore file that was created, changed, or
+// removed, so the files it no longer ignores are indexed. Documents of files it now
+// ignores are kept.
+func (w *Watcher) handleIgnoreFile(ev fsnotify.Event) {
+	if !ev.Has(fsnotify.Create) && !ev.Has(fsnotify.Write) && !ev.Has(fsnotify.Remove) && !ev.Has(fsnotify.Rename) {
+		return
+	}
+	dir := filepath.Dir(ev.Name)
+	if w.markedAt(dir) || w.ignored(dir, true) {
+		return
+	}
+	if w.logger != nil {
+		w.logger.Debug("watcher ignore file changed", zap.String("path", ev.Name))
+	}
+	w.handleNewDirectory(dir)
+}
+
+// hasMarker reports whether dir holds a file named marker.
+func hasMarker(dir, marker string) bool {
+	if marker == "" {
+		return false
+	}
+	_, err := os.Lstat(filepath.Join(dir, marker))
+	return err == nil
+}
+
+// optedOut reports whether a directory above path holds the no-index marker.
+func (w *Watcher) optedOut(path string) bool {
+	return w.markedAt(filepath.Dir(path))
+}
+
+// markedAt reports whether dir or a directory above it holds the no-index marker.
+func (w *Watcher) markedAt(dir string) bool {
+	if w.marker == "" {
+		return false
+	}
+	for {
+		if hasMarker(dir, w.marker) {
+			return true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
+
 // handleNewDirectory handles a newly created directory by adding it to the watch list
 // and indexing all files inside it.
 func (w *Watcher) handleNewDirectory(dirPath string) {
@@ -155,7 +338,7 @@ func (w *Watcher) handleNewDirectory(dirPath string) {
 	}
 
 	w.mu.Lock()
-	recursive := w.recursive
+	recursive := w.rules.Recursive(dirPath, w.recursive)
 	watcher := w.watcher
 	w.mu.Unlock()
 
@@ -164,12 +347,16 @@ func (w *Watcher) handleNewDirectory(dirPath string) {
 	}
 
 	// Add directory (and subdirectories if recursive) to watcher
+	root := w.rootOf(dirPath)
 	if recursive {
-		filepath.WalkDir(dirPath, func(path string, d fs.DirEntry, err error) error {
+		fswalk.Walk(dirPath, w.walk, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
 			if d.IsDir() {
+				if path != dirPath && (w.rules.SkipDir(path) || w.ignore.Match(root, path, true)) {
+					return filepath.SkipDir
+				}
 				if err := watcher.Add(path); err != nil {
 					if w.logger != nil {
 						w.logger.Debug("watcher failed to add directory", zap.String("path", path), zap.Error(err))
@@ -177,6 +364,10 @@ func (w *Watcher) handleNewDirectory(dirPath string) {
 				} else if w.logger != nil {
 					w.logger.Debug("watcher added new directory", zap.String("path", path))
 				}
+				// An opted-out directory is watched only for its marker going away
+				if hasMarker(path, w.marker) {
+					return filepath.SkipDir
+				}
 			}
 			return nil
 		})
@@ -192,6 +383,37 @@ func (w *Watcher) handleNewDirectory(dirPath string) {
 	w.syncDirectory(dirPath)
 }
 
+// rootOf returns the deepest watched root containing path, "" when none does.
+func (w *Watcher) rootOf(path string) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	clean := filepath.Clean(path)
+	best := ""
+	for _, root := range w.roots {
+		root = filepath.Clean(root)
+		if (root == clean || inDir(root, clean)) && len(root) > len(best) {
+			best = root
+		}
+	}
+	return best
+}
+
+// ignored reports whether the ignore files of its root ignore path or a directory above it,
+// or path is in a hidden directory skipped by WithWalkOptions.
+func (w *Watcher) ignored(path string, isDir bool) bool {
+	if w.ignore == nil && !w.walk.SkipHidden {
+		return false
+	}
+	root := w.rootOf(path)
+	if root == "" {
+		return false
+	}
+	if w.walk.SkipHidden && fswalk.Hidden(root, path, isDir) {
+		return true
+	}
+	return w.ignore != nil && w.ignore.Ignored(root, path, isDir)
+}
+
 func (w *Watcher) underRoot(path string) bool {
 	w.mu.Lock()
 	roots := append([]string(nil), w.roots...)
@@ -215,7 +437,16 @@ func inDir(dir, path string) bool {
 }
 
 func (w *Watcher) matchExtension(path string) bool {
-	return matchExtension(path, w.extensions)
+	w.mu.Lock()
+	exts := w.extensions
+	w.mu.Unlock()
+	return matchExtension(path, w.rules.Extensions(path, exts))
+}
+
+// matchFile reports whether the file at path, of size bytes (negative when unknown), is
+// indexed: it matches the extensions and the rules of its directory, and is not ignored.
+func (w *Watcher) matchFile(path string, size int64) bool {
+	return w.matchExtension(path) && w.rules.AllowFile(path, size) && !w.ignored(path, false)
 }
 
 func matchExtension(path string, extensions []string) bool {
@@ -236,11 +467,40 @@ func matchExtension(path string, extensions []string) bool {
 func (w *Watcher) debounceIndex(path string) {
 	w.mu.Lock()
 	defer w.mu.Unlock()
+	w.scheduleIndexLocked(path)
+}
+
+// scheduleIndexLocked (re)starts the timer that indexes path after the debounce interval,
+// or schedules it again while the indexer is busy. Callers hold w.mu.
+func (w *Watcher) scheduleIndexLocked(path string) {
 	if t, ok := w.debounceMap[path]; ok {
 		t.Stop()
 	}
-	t := time.AfterFunc(w.debounce, func() {
+	var t *time.Timer
+	t = time.AfterFunc(w.debounce, func() {
 		w.mu.Lock()
+		if w.debounceMap[path] != t {
+			w.mu.Unlock()
+			return // replaced by a later change
+		}
+		if !w.windows.Allows(w.now()) {
+			if w.logger != nil {
+				w.logger.Debug("watcher holding back file until the index window opens", zap.String("path", path))
+			}
+			delete(w.debounceMap, path)
+			w.held[path] = true
+			w.armWindowLocked()
+			w.mu.Unlock()
+			return
+		}
+		if w.busy != nil && w.busy() {
+			if w.logger != nil {
+				w.logger.Debug("watcher holding back file, indexer busy", zap.String("path", path))
+			}
+			w.scheduleIndexLocked(path)
+			w.mu.Unlock()
+			return
+		}
 		delete(w.debounceMap, path)
 		logger := w.logger
 		w.mu.Unlock()
@@ -261,6 +521,196 @@ func (w *Watcher) cancelDebounce(path string) {
 		t.Stop()
 		delete(w.debounceMap, path)
 	}
+	delete(w.held, path)
+}
+
+// armWindowLocked starts the timer that indexes held changes when the next index window
+// opens, unless it is already running. Callers hold w.mu.
+func (w *Watcher) armWindowLocked() {
+	if w.windowTimer != nil {
+		return
+	}
+	now := w.now()
+	w.windowTimer = time.AfterFunc(w.windows.NextOpen(now).Sub(now), w.releaseHeld)
+}
+
+// releaseHeld indexes the held changes once an index window is open.
+func (w *Watcher) releaseHeld() {
+	w.mu.Lock()
+	w.windowTimer = nil
+	if !w.windows.Allows(w.now()) {
+		w.armWindowLocked() // woke early, e.g. the clock changed
+		w.mu.Unlock()
+		return
+	}
+	files, dirs := w.takeHeldLocked()
+	w.mu.Unlock()
+	w.indexHeld(files, dirs)
+}
+
+// takeHeldLocked empties the held changes and returns them. Files under a held directory
+// are left out since syncing the directory indexes them. Callers hold w.mu.
+func (w *Watcher) takeHeldLocked() (files, dirs []string) {
+	for d := range w.heldDirs {
+		dirs = append(dirs, d)
+	}
+	for p := range w.held {
+		covered := false
+		for _, d := range dirs {
+			if inDir(d, p) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			files = append(files, p)
+		}
+	}
+	w.held = make(map[string]bool)
+	w.heldDirs = make(map[string]bool)
+	sort.Strings(files)
+	sort.Strings(dirs)
+	return files, dirs
+}
+
+func (w *Watcher) indexHeld(files, dirs []string) {
+	w.mu.Lock()
+	onIndex := w.onIndex
+	logger := w.logger
+	w.mu.Unlock()
+	if logger != nil && len(files)+len(dirs) > 0 {
+		logger.Debug("watcher indexing held changes", zap.Int("files", len(files)), zap.Strings("directories", dirs))
+	}
+	for _, p := range files {
+		if onIndex != nil {
+			onIndex(p)
+		}
+	}
+	for _, d := range dirs {
+		w.walkDirectory(d)
+	}
+}
+
+// IndexHeld indexes the changes held back outside the index windows now, in the
+// background, and returns how many files and directories it queued.
+func (w *Watcher) IndexHeld() (files, directories int) {
+	w.mu.Lock()
+	f, d := w.takeHeldLocked()
+	w.mu.Unlock()
+	if len(f)+len(d) > 0 {
+		go w.indexHeld(f, d)
+	}
+	return len(f), len(d)
+}
+
+// IndexWindowStatus describes the index windows and the changes waiting for them.
+type IndexWindowStatus struct {
+	Windows         []string   `json:"windows"`
+	Open            bool       `json:"open"`
+	NextOpen        *time.Time `json:"next_open,omitempty"`
+	HeldFiles       int        `json:"held_files"`
+	HeldDirectories int        `json:"held_directories"`
+}
+
+// IndexWindowStatus returns the state of the index windows, or nil when none are set.
+func (w *Watcher) IndexWindowStatus() *IndexWindowStatus {
+	if len(w.windows) == 0 {
+		return nil
+	}
+	now := w.now()
+	st := &IndexWindowStatus{Windows: w.windows.Strings(), Open: w.windows.Allows(now)}
+	if !st.Open {
+		next := w.windows.NextOpen(now)
+		st.NextOpen = &next
+	}
+	w.mu.Lock()
+	st.HeldFiles = len(w.held)
+	st.HeldDirectories = len(w.heldDirs)
+	w.mu.Unlock()
+	return st
+}
+
+// AddPriority marks a file (e.g. one open in an editor) as high priority: each change to it is
+// indexed as soon as it is seen instead of after the debounce. The file must be under a watched
+// root and match the extensions, otherwise ErrNotWatched is returned.
+func (w *Watcher) AddPriority(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if !w.underRoot(abs) || !w.matchFile(abs, -1) {
+		return ErrNotWatched
+	}
+	w.mu.Lock()
+	w.priority[abs] = true
+	w.mu.Unlock()
+	return nil
+}
+
+// RemovePriority returns a file to normal, debounced indexing.
+func (w *Watcher) RemovePriority(path string) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return
+	}
+	w.mu.Lock()
+	delete(w.priority, abs)
+	w.mu.Unlock()
+}
+
+// PriorityPaths returns the high-priority files, sorted.
+func (w *Watcher) PriorityPaths() []string {
+	w.mu.Lock()
+	paths := make([]string, 0, len(w.priority))
+	for p := range w.priority {
+		paths = append(paths, p)
+	}
+	w.mu.Unlock()
+	sort.Strings(paths)
+	return paths
+}
+
+func (w *Watcher) isPriority(path string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.priority[filepath.Clean(path)]
+}
+
+// indexNow indexes a priority file in the background. Changes that arrive while it is being
+// indexed are folded into one more run, so a burst of writes never indexes the file concurrently.
+func (w *Watcher) indexNow(path string) {
+	w.mu.Lock()
+	if _, running := w.indexingNow[path]; running {
+		w.indexingNow[path] = true
+		w.mu.Unlock()
+		return
+	}
+	w.indexingNow[path] = false
+	fn := w.onIndexNow
+	if fn == nil {
+		fn = w.onIndex
+	}
+	logger := w.logger
+	w.mu.Unlock()
+	go func() {
+		for {
+			if logger != nil {
+				logger.Debug("watcher indexing file (priority)", zap.String("path", path))
+			}
+			if fn != nil {
+				fn(path)
+			}
+			w.mu.Lock()
+			again := w.indexingNow[path]
+			if !again {
+				delete(w.indexingNow, path)
+				w.mu.Unlock()
+				return
+			}
+			w.indexingNow[path] = false
+			w.mu.Unlock()
+		}
+	}()
 }
 
 // AddDirectory adds a root directory to watch and optionally syncs existing files.
@@ -308,14 +758,21 @@ func (w *Watcher) addRootLocked(root string) error {
 		if !d.IsDir() {
 			return nil
 		}
+		if path != root && (w.rules.SkipDir(path) || w.ignore.Match(root, path, true)) {
+			return filepath.SkipDir
+		}
 		if err := w.watcher.Add(path); err != nil {
 			return err
 		}
 		paths = append(paths, path)
+		// An opted-out directory is watched only for its marker going away
+		if hasMarker(path, w.marker) {
+			return filepath.SkipDir
+		}
 		return nil
 	}
-	if w.recursive {
-		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+	if w.rules.Recursive(root, w.recursive) {
+		err := fswalk.Walk(root, w.walk, func(path string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
 			}
@@ -334,7 +791,24 @@ func (w *Watcher) addRootLocked(root string) error {
 	return nil
 }
 
+// syncDirectory indexes the files in root, or holds the sync until the next index window.
 func (w *Watcher) syncDirectory(root string) {
+	w.mu.Lock()
+	if !w.windows.Allows(w.now()) {
+		w.heldDirs[filepath.Clean(root)] = true
+		w.armWindowLocked()
+		logger := w.logger
+		w.mu.Unlock()
+		if logger != nil {
+			logger.Debug("watcher holding back directory sync until the index window opens", zap.String("root", root))
+		}
+		return
+	}
+	w.mu.Unlock()
+	w.walkDirectory(root)
+}
+
+func (w *Watcher) walkDirectory(root string) {
 	w.mu.Lock()
 	exts := append([]string(nil), w.extensions...)
 	onIndex := w.onIndex
@@ -343,17 +817,35 @@ func (w *Watcher) syncDirectory(root string) {
 	if logger != nil {
 		logger.Debug("watcher syncing directory", zap.String("root", root))
 	}
-	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil || d.IsDir() {
+	if w.optedOut(root) {
+		return
+	}
+	top := w.rootOf(root)
+	w.events.Publish(events.Event{Type: events.SyncStarted, Path: root})
+	files := 0
+	defer func() { w.events.Publish(events.Event{Type: events.SyncFinished, Path: root, Files: files}) }()
+	fswalk.Walk(root, w.walk, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
 			return err
 		}
-		if matchExtension(path, exts) {
+		if d.IsDir() {
+			if hasMarker(path, w.marker) || (path != root && (w.rules.SkipDir(path) || w.ignore.Match(top, path, true))) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		size := int64(-1)
+		if info, err := d.Info(); err == nil {
+			size = info.Size()
+		}
+		if matchExtension(path, w.rules.Extensions(path, exts)) && w.rules.AllowFile(path, size) && !w.ignore.Match(top, path, false) {
 			if logger != nil {
 				logger.Debug("watcher sync indexing file", zap.String("path", path))
 			}
 			if onIndex != nil {
 				onIndex(path)
 			}
+			files++
 		}
 		return nil
 	})
@@ -386,6 +878,11 @@ func (w *Watcher) RemoveDirectory(root string) error {
 		_ = w.watcher.Remove(p)
 	}
 	delete(w.rootPaths, abs)
+	for d := range w.heldDirs {
+		if d == abs || inDir(abs, d) {
+			delete(w.heldDirs, d)
+		}
+	}
 	w.roots = append(w.roots[:idx], w.roots[idx+1:]...)
 	if w.logger != nil {
 		w.logger.Debug("watcher directory removed", zap.String("path", abs))
@@ -400,6 +897,43 @@ func (w *Watcher) Directories() []string {
 	return append([]string(nil), w.roots...)
 }
 
+// Extensions returns a copy of the file extensions watched; empty means all files.
+func (w *Watcher) Extensions() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string(nil), w.extensions...)
+}
+
+// SetExtensions replaces the file extensions watched (empty = all files). When files
+// skipped before now match, the watched directories are synced in the background to index
+// them. Documents indexed from files that no longer match are kept.
+func (w *Watcher) SetExtensions(extensions []string) {
+	w.mu.Lock()
+	old := w.extensions
+	w.extensions = append([]string(nil), extensions...)
+	started := w.started
+	w.mu.Unlock()
+	if started && widensExtensions(old, extensions) {
+		go w.SyncExistingFiles()
+	}
+}
+
+// widensExtensions reports whether next matches files that old does not.
+func widensExtensions(old, next []string) bool {
+	if len(old) == 0 {
+		return false
+	}
+	if len(next) == 0 {
+		return true
+	}
+	for _, ext := range next {
+		if !matchExtension("f."+strings.TrimPrefix(ext, "."), old) {
+			return true
+		}
+	}
+	return false
+}
+
 // SyncExistingFiles indexes all existing files in each watched root that match the configured extensions.
 // Call this after Start() to index files that were already present when the watcher started.
 func (w *Watcher) SyncExistingFiles() {
@@ -425,6 +959,10 @@ func (w *Watcher) Stop() {
 		t.Stop()
 		delete(w.debounceMap, path)
 	}
+	if w.windowTimer != nil {
+		w.windowTimer.Stop()
+		w.windowTimer = nil
+	}
 	_ = w.watcher.Close()
 	w.watcher = nil
 	w.started = false
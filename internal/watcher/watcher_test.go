@@ -2,12 +2,20 @@ package watcher
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/hyperjump/sagasu/internal/events"
+	"github.com/hyperjump/sagasu/internal/fswalk"
+	"github.com/hyperjump/sagasu/internal/pathrules"
+	"github.com/hyperjump/sagasu/internal/schedule"
 )
 
 func TestWatcher_AddRemoveDirectories(t *testing.T) {
@@ -157,6 +165,40 @@ func TestWatcher_SyncExistingFiles_indexesMatchingFiles(t *testing.T) {
 	}
 }
 
+func TestWatcher_SetExtensions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.md"} {
+		if err := writeFile(filepath.Join(dir, name), "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	indexed := make(chan string, 4)
+	w := NewWatcher([]string{dir}, []string{".txt"}, true, func(path string) { indexed <- path }, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := w.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	// Narrowing does not sync.
+	w.SetExtensions([]string{"txt"})
+	// Allowing .md syncs the directories in the background, indexing b.md.
+	w.SetExtensions([]string{"txt", ".MD"})
+	if got := w.Extensions(); len(got) != 2 || got[1] != ".MD" {
+		t.Errorf("Extensions() = %v", got)
+	}
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		select {
+		case path := <-indexed:
+			seen[filepath.Base(path)] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("sync after widening indexed %v, want a.txt and b.md", seen)
+		}
+	}
+}
+
 func TestWatcher_Start_createsMissingRootDirectory(t *testing.T) {
 	base := t.TempDir()
 	root := filepath.Join(base, "watch", "me")
@@ -175,6 +217,33 @@ func TestWatcher_Start_createsMissingRootDirectory(t *testing.T) {
 	}
 }
 
+func TestWatcher_SyncEvents(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.txt", "b.txt", "ignore.xyz"} {
+		if err := writeFile(filepath.Join(dir, name), "x"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	bus := events.NewBus(0)
+	ch, cancel, _ := bus.Subscribe(0)
+	defer cancel()
+	w := NewWatcher([]string{dir}, []string{".txt"}, true, func(string) {}, nil, WithEvents(bus))
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	if err := w.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	w.SyncExistingFiles()
+
+	if e := <-ch; e.Type != events.SyncStarted || e.Path != dir {
+		t.Errorf("first event = %+v, want sync_started for %s", e, dir)
+	}
+	if e := <-ch; e.Type != events.SyncFinished || e.Path != dir || e.Files != 2 {
+		t.Errorf("second event = %+v, want sync_finished with 2 files", e)
+	}
+}
+
 func TestWatcher_HandleNewDirectory_indexesFilesInNewFolder(t *testing.T) {
 	dir := t.TempDir()
 
@@ -287,6 +356,248 @@ func TestWatcher_HandleNewDirectory_recursiveSubfolders(t *testing.T) {
 	}
 }
 
+func TestWatcher_NoIndexMarker(t *testing.T) {
+	dir := t.TempDir()
+	private := filepath.Join(dir, "private")
+	if err := mkdirAll(filepath.Join(private, "sub")); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(filepath.Join(private, ".noindex"), ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(filepath.Join(private, "sub", "old.txt"), "old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(filepath.Join(dir, "public.txt"), "public"); err != nil {
+		t.Fatal(err)
+	}
+
+	var indexed, optedOut []string
+	var mu sync.Mutex
+	onIndex := func(path string) {
+		mu.Lock()
+		indexed = append(indexed, path)
+		mu.Unlock()
+	}
+	onOptOut := func(dir string) {
+		mu.Lock()
+		optedOut = append(optedOut, dir)
+		mu.Unlock()
+	}
+	w := NewWatcher([]string{dir}, []string{".txt"}, true, onIndex, nil, WithNoIndexMarker(".noindex", onOptOut))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := w.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	w.SyncExistingFiles()
+
+	// Files written under the marker are ignored; a new marker opts its directory out
+	if err := writeFile(filepath.Join(private, "sub", "new.txt"), "new"); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(dir, "other")
+	if err := mkdirAll(other); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if err := writeFile(filepath.Join(other, ".noindex"), ""); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(800 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(indexed) != 1 || !strings.HasSuffix(indexed[0], "public.txt") {
+		t.Errorf("expected only public.txt to be indexed, got %v", indexed)
+	}
+	if len(optedOut) != 1 || filepath.Clean(optedOut[0]) != filepath.Clean(other) {
+		t.Errorf("expected %s to be opted out, got %v", other, optedOut)
+	}
+}
+
+func TestWatcher_Rules(t *testing.T) {
+	dir := t.TempDir()
+	code := filepath.Join(dir, "code")
+	for _, name := range []string{
+		filepath.Join(dir, "notes.txt"),
+		filepath.Join(dir, "big.txt"),
+		filepath.Join(dir, "node_modules", "lib", "readme.txt"),
+		filepath.Join(code, "main.go"),
+		filepath.Join(code, "readme.txt"),
+	} {
+		if err := mkdirAll(filepath.Dir(name)); err != nil {
+			t.Fatal(err)
+		}
+		content := "hello"
+		if filepath.Base(name) == "big.txt" {
+			content = strings.Repeat("x", 2<<20)
+		}
+		if err := writeFile(name, content); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rules, err := pathrules.New([]pathrules.Rule{
+		{Root: dir, Exclude: []string{"**/node_modules/**"}, MaxFileSize: 1 << 20},
+		{Root: code, Extensions: []string{".go"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var indexed []string
+	var mu sync.Mutex
+	onIndex := func(path string) {
+		mu.Lock()
+		indexed = append(indexed, filepath.Base(path))
+		mu.Unlock()
+	}
+	w := NewWatcher([]string{dir}, []string{".txt"}, true, onIndex, nil, WithRules(rules))
+	w.debounce = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := w.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	w.SyncExistingFiles()
+
+	// Changes follow the rules too.
+	if err := writeFile(filepath.Join(dir, "node_modules", "new.txt"), "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(filepath.Join(code, "util.go"), "package code"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(indexed)
+	if got := strings.Join(indexed, ","); got != "main.go,notes.txt,util.go" {
+		t.Errorf("indexed %s, want main.go, notes.txt, and util.go", got)
+	}
+	if err := w.AddPriority(filepath.Join(code, "readme.txt")); !errors.Is(err, ErrNotWatched) {
+		t.Errorf("AddPriority of a file the rules exclude: err = %v, want ErrNotWatched", err)
+	}
+}
+
+func TestWatcher_Ignore(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"notes.txt", "build/out.txt", "logs/today.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := mkdirAll(filepath.Dir(path)); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeFile(path, "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writeFile(filepath.Join(dir, ".gitignore"), "build/\n"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(filepath.Join(dir, "logs", ".sagasuignore"), "*\n"); err != nil {
+		t.Fatal(err)
+	}
+	ig, err := pathrules.NewIgnore([]string{".gitignore", ".sagasuignore"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var indexed []string
+	var mu sync.Mutex
+	onIndex := func(path string) {
+		mu.Lock()
+		indexed = append(indexed, filepath.Base(path))
+		mu.Unlock()
+	}
+	w := NewWatcher([]string{dir}, []string{".txt"}, true, onIndex, nil, WithIgnore(ig))
+	w.debounce = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := w.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	w.SyncExistingFiles()
+	if err := writeFile(filepath.Join(dir, "logs", "new.txt"), "new"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	mu.Lock()
+	if got := strings.Join(indexed, ","); got != "notes.txt" {
+		t.Errorf("indexed %s, want only notes.txt", got)
+	}
+	indexed = nil
+	mu.Unlock()
+
+	// Removing an ignore file syncs its directory.
+	if err := os.Remove(filepath.Join(dir, "logs", ".sagasuignore")); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(indexed)
+	if got := strings.Join(indexed, ","); got != "new.txt,today.txt" {
+		t.Errorf("after removing the ignore file, indexed %s, want new.txt and today.txt", got)
+	}
+}
+
+func TestWatcher_WalkOptions(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	shared := filepath.Join(dir, "shared")
+	for _, name := range []string{"root/notes.txt", "root/.cache/c.txt", "shared/s.txt"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := mkdirAll(filepath.Dir(path)); err != nil {
+			t.Fatal(err)
+		}
+		if err := writeFile(path, "hello"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(shared, filepath.Join(root, "shared")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(root, filepath.Join(shared, "back")); err != nil {
+		t.Fatal(err)
+	}
+
+	var indexed []string
+	var mu sync.Mutex
+	onIndex := func(path string) {
+		mu.Lock()
+		rel, _ := filepath.Rel(root, path)
+		indexed = append(indexed, filepath.ToSlash(rel))
+		mu.Unlock()
+	}
+	w := NewWatcher([]string{root}, []string{".txt"}, true, onIndex, nil,
+		WithWalkOptions(fswalk.Options{SkipHidden: true, FollowSymlinks: true}))
+	w.debounce = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := w.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+	w.SyncExistingFiles()
+	if err := writeFile(filepath.Join(root, ".cache", "new.txt"), "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(filepath.Join(shared, "added.txt"), "new"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(indexed)
+	if got := strings.Join(indexed, ","); got != "notes.txt,shared/added.txt,shared/s.txt" {
+		t.Errorf("indexed %s, want notes.txt and the files of the linked directory", got)
+	}
+}
+
 func mkdirAll(path string) error {
 	return os.MkdirAll(path, 0755)
 }
@@ -294,3 +605,200 @@ func mkdirAll(path string) error {
 func writeFile(path, content string) error {
 	return os.WriteFile(path, []byte(content), 0600)
 }
+
+func TestWatcher_PrioritySkipsDebounce(t *testing.T) {
+	dir := t.TempDir()
+	open := filepath.Join(dir, "open.txt")
+	other := filepath.Join(dir, "other.txt")
+	for _, p := range []string{open, other} {
+		if err := writeFile(p, "v1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var debounced, urgent []string
+	var mu sync.Mutex
+	onIndex := func(path string) {
+		mu.Lock()
+		debounced = append(debounced, path)
+		mu.Unlock()
+	}
+	onIndexNow := func(path string) {
+		mu.Lock()
+		urgent = append(urgent, path)
+		mu.Unlock()
+	}
+	w := NewWatcher([]string{dir}, []string{".txt"}, true, onIndex, nil, WithPriorityIndex(onIndexNow))
+	w.debounce = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := w.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	if err := w.AddPriority(open); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.AddPriority(filepath.Join(dir, "notes.xyz")); !errors.Is(err, ErrNotWatched) {
+		t.Errorf("AddPriority(unwatched extension) = %v, want ErrNotWatched", err)
+	}
+	if err := w.AddPriority(filepath.Join(t.TempDir(), "a.txt")); !errors.Is(err, ErrNotWatched) {
+		t.Errorf("AddPriority(outside roots) = %v, want ErrNotWatched", err)
+	}
+	if got := w.PriorityPaths(); len(got) != 1 || got[0] != open {
+		t.Errorf("PriorityPaths() = %v", got)
+	}
+
+	if err := writeFile(open, "v2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFile(other, "v2"); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		n := len(urgent)
+		mu.Unlock()
+		if n > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(urgent) == 0 || urgent[0] != open {
+		t.Errorf("priority callbacks = %v, want %s", urgent, open)
+	}
+	for _, p := range urgent {
+		if p == other {
+			t.Errorf("non-priority file %s skipped the debounce", other)
+		}
+	}
+	if len(debounced) != 0 {
+		t.Errorf("debounced callbacks before the debounce elapsed: %v", debounced)
+	}
+
+	w.RemovePriority(open)
+	if got := w.PriorityPaths(); len(got) != 0 {
+		t.Errorf("PriorityPaths() after remove = %v", got)
+	}
+}
+
+func TestWatcher_BackpressureHoldsBackChanges(t *testing.T) {
+	var busy atomic.Bool
+	busy.Store(true)
+	var mu sync.Mutex
+	var indexed []string
+	onIndex := func(path string) {
+		mu.Lock()
+		indexed = append(indexed, path)
+		mu.Unlock()
+	}
+	w := NewWatcher(nil, nil, true, onIndex, nil, WithBackpressure(busy.Load))
+	w.debounce = 10 * time.Millisecond
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(indexed)
+	}
+
+	w.debounceIndex("/docs/a.txt")
+	w.debounceIndex("/docs/a.txt")
+	time.Sleep(100 * time.Millisecond)
+	if n := count(); n != 0 {
+		t.Fatalf("indexed %d files while busy, want 0", n)
+	}
+	w.mu.Lock()
+	pending := len(w.debounceMap)
+	w.mu.Unlock()
+	if pending != 1 {
+		t.Errorf("pending = %d, want the held-back file once", pending)
+	}
+
+	busy.Store(false)
+	deadline := time.Now().Add(2 * time.Second)
+	for count() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(indexed) != 1 || indexed[0] != "/docs/a.txt" {
+		t.Errorf("indexed %v, want /docs/a.txt once", indexed)
+	}
+}
+
+func TestWatcher_IndexWindowsHoldChanges(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeFile(filepath.Join(dir, "a.txt"), "hello"); err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	var indexed []string
+	onIndex := func(path string) {
+		mu.Lock()
+		indexed = append(indexed, path)
+		mu.Unlock()
+	}
+	indexedNow := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), indexed...)
+	}
+	windows, err := schedule.Parse([]string{"02:00-06:00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewWatcher([]string{dir}, []string{".txt"}, true, onIndex, nil, WithIndexWindows(windows))
+	w.debounce = 10 * time.Millisecond
+	var clock atomic.Value
+	clock.Store(time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local))
+	w.now = func() time.Time { return clock.Load().(time.Time) }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := w.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	defer w.Stop()
+
+	w.SyncExistingFiles()
+	w.debounceIndex("/elsewhere/b.txt")
+	w.debounceIndex("/elsewhere/b.txt")
+	w.debounceIndex("/elsewhere/gone.txt")
+	time.Sleep(100 * time.Millisecond)
+	w.cancelDebounce("/elsewhere/gone.txt") // removed while held
+	if got := indexedNow(); len(got) != 0 {
+		t.Fatalf("indexed %v outside the index window, want nothing", got)
+	}
+	st := w.IndexWindowStatus()
+	if st == nil || st.Open || st.NextOpen == nil || st.HeldFiles != 1 || st.HeldDirectories != 1 {
+		t.Fatalf("status = %+v, want closed with one held file and directory", st)
+	}
+	if want := time.Date(2026, 3, 11, 2, 0, 0, 0, time.Local); !st.NextOpen.Equal(want) {
+		t.Errorf("next open = %v, want %v", st.NextOpen, want)
+	}
+
+	if files, dirs := w.IndexHeld(); files != 1 || dirs != 1 {
+		t.Errorf("IndexHeld = %d files, %d directories; want 1, 1", files, dirs)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for len(indexedNow()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := indexedNow(); len(got) != 2 || got[0] != "/elsewhere/b.txt" || !strings.HasSuffix(got[1], "a.txt") {
+		t.Errorf("indexed %v, want b.txt and a.txt", got)
+	}
+
+	// Changes held later are indexed when the window opens.
+	w.debounceIndex("/elsewhere/c.txt")
+	time.Sleep(100 * time.Millisecond)
+	clock.Store(time.Date(2026, 3, 11, 2, 0, 0, 0, time.Local))
+	w.releaseHeld()
+	if got := indexedNow(); len(got) != 3 || got[2] != "/elsewhere/c.txt" {
+		t.Errorf("indexed %v, want c.txt once the window opened", got)
+	}
+	if st := w.IndexWindowStatus(); !st.Open || st.HeldFiles != 0 {
+		t.Errorf("status = %+v, want open with nothing held", st)
+	}
+}